@@ -4,15 +4,19 @@ go 1.25
 
 require (
 	github.com/Xuanwo/go-locale v1.1.3
+	github.com/cespare/xxhash/v2 v2.3.0
 	github.com/charmbracelet/bubbles v0.21.0
 	github.com/charmbracelet/bubbletea v1.3.10
 	github.com/charmbracelet/lipgloss v1.1.0
 	github.com/charmbracelet/x/ansi v0.11.4
 	github.com/expr-lang/expr v1.17.7
+	github.com/fsnotify/fsnotify v1.9.0
 	github.com/klauspost/compress v1.18.3
+	github.com/nwaples/rardecode/v2 v2.2.0
 	github.com/oapi-codegen/runtime v1.1.2
 	github.com/spf13/cobra v1.10.2
 	github.com/ulikunitz/xz v0.5.15
+	go.etcd.io/bbolt v1.4.3
 	golang.org/x/text v0.33.0
 )
 
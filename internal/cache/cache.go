@@ -5,7 +5,9 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 	"time"
@@ -69,6 +71,19 @@ func MediaKey(systemID, gameID, mediaType, region string) string {
 	return fmt.Sprintf("media:%s:%s:%s:%s", systemID, gameID, mediaType, region)
 }
 
+// credentialParams are the query parameters left out of response keys
+var credentialParams = []string{"devid", "devpassword", "softname", "ssid", "sspassword"}
+
+// ResponseKey creates a cache key for an API response, from its endpoint and
+// its parameters other than credentials
+func ResponseKey(u *url.URL) string {
+	query := u.Query()
+	for _, param := range credentialParams {
+		query.Del(param)
+	}
+	return fmt.Sprintf("response:%s?%s", path.Base(u.Path), query.Encode())
+}
+
 // cacheEntry represents metadata about a cached item
 type cacheEntry struct {
 	Key       string    `json:"key"`
@@ -116,6 +131,18 @@ func (c *DiskCache) GetMedia(systemID, gameID, mediaType, region string) ([]byte
 		return nil, "", false
 	}
 
+	return c.findMedia(systemID, gameID, mediaType, region, false)
+}
+
+// GetStaleMedia retrieves cached media even if it has expired, so it can be
+// revalidated by its hashes instead of downloaded again
+// Returns the data and file extension if found
+func (c *DiskCache) GetStaleMedia(systemID, gameID, mediaType, region string) ([]byte, string, bool) {
+	return c.findMedia(systemID, gameID, mediaType, region, true)
+}
+
+// findMedia reads cached media with any of the common extensions
+func (c *DiskCache) findMedia(systemID, gameID, mediaType, region string, allowExpired bool) ([]byte, string, bool) {
 	key := MediaKey(systemID, gameID, mediaType, region)
 	dir := filepath.Join(c.baseDir, "media", systemID, gameID)
 	baseName := hashKey(key)
@@ -123,8 +150,11 @@ func (c *DiskCache) GetMedia(systemID, gameID, mediaType, region string) ([]byte
 	// Try common extensions (including .nomedia for cached "not available")
 	for _, ext := range []string{".nomedia", ".png", ".jpg", ".mp4"} {
 		path := filepath.Join(dir, baseName+ext)
-		data, err := c.readIfValid(path)
-		if err == nil {
+		read := c.readIfValid
+		if allowExpired {
+			read = os.ReadFile
+		}
+		if data, err := read(path); err == nil {
 			return data, ext[1:], true // Strip the leading dot
 		}
 	}
@@ -149,6 +179,40 @@ func (c *DiskCache) SetMedia(systemID, gameID, mediaType, region string, data []
 	return c.writeWithMeta(path, key, data)
 }
 
+// GetResponse retrieves a cached API response
+func (c *DiskCache) GetResponse(key string) ([]byte, bool) {
+	if c.mode == ModeNoRead {
+		return nil, false
+	}
+
+	data, err := c.readIfValid(c.responsePath(key))
+	if err != nil {
+		return nil, false
+	}
+
+	return data, true
+}
+
+// SetResponse stores an API response in the cache
+func (c *DiskCache) SetResponse(key string, data []byte) error {
+	if c.mode == ModeReadOnly {
+		return nil
+	}
+
+	path := c.responsePath(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	return c.writeWithMeta(path, key, data)
+}
+
+// responsePath returns the path of a response, in a directory per endpoint
+func (c *DiskCache) responsePath(key string) string {
+	endpoint, _, _ := strings.Cut(strings.TrimPrefix(key, "response:"), "?")
+	return filepath.Join(c.baseDir, "responses", strings.TrimSuffix(endpoint, ".php"), hashKey(key)+".json")
+}
+
 // readIfValid reads a file if it exists and hasn't expired
 func (c *DiskCache) readIfValid(path string) ([]byte, error) {
 	metaPath := path + ".meta"
@@ -164,11 +228,9 @@ func (c *DiskCache) readIfValid(path string) ([]byte, error) {
 		return nil, err
 	}
 
-	// Check if expired
+	// Check if expired (expired files are kept, as stale media can be
+	// revalidated, and they're replaced when refreshed)
 	if time.Since(entry.CreatedAt) > c.maxAge {
-		// Remove expired files
-		os.Remove(path)
-		os.Remove(metaPath)
 		return nil, fmt.Errorf("cache entry expired")
 	}
 
@@ -0,0 +1,77 @@
+package cache
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// Transport is an http.RoundTripper that caches the responses of
+// Screenscraper's metadata endpoints: game info, game search, and the
+// reference lists. Media downloads, account and server status, and
+// proposals always go to the API.
+type Transport struct {
+	cache *DiskCache
+	next  http.RoundTripper
+}
+
+// NewTransport creates a transport that caches responses in c, making
+// requests with next, or http.DefaultTransport if nil
+func NewTransport(c *DiskCache, next http.RoundTripper) *Transport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &Transport{cache: c, next: next}
+}
+
+// RoundTrip serves a cached response if there's one, and caches successful
+// responses otherwise
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet || !isCacheable(req.URL.Path) {
+		return t.next.RoundTrip(req)
+	}
+
+	key := ResponseKey(req.URL)
+	if data, ok := t.cache.GetResponse(key); ok {
+		return &http.Response{
+			Status:        "200 OK",
+			StatusCode:    http.StatusOK,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        http.Header{"Content-Type": []string{"application/json"}},
+			Body:          io.NopCloser(bytes.NewReader(data)),
+			ContentLength: int64(len(data)),
+			Request:       req,
+		}, nil
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+
+	// A failed cache write only costs a request next time
+	t.cache.SetResponse(key, data)
+
+	return resp, nil
+}
+
+// isCacheable returns true if the responses of an endpoint may be cached
+func isCacheable(urlPath string) bool {
+	switch endpoint := path.Base(urlPath); endpoint {
+	case "jeuInfos.php", "jeuRecherche.php":
+		return true
+	default:
+		return strings.HasSuffix(endpoint, "Liste.php")
+	}
+}
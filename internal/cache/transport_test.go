@@ -0,0 +1,76 @@
+package cache
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestResponseKey(t *testing.T) {
+	a, _ := url.Parse("https://api.screenscraper.fr/api2/jeuInfos.php?devid=x&devpassword=y&softname=z&ssid=u&sspassword=p&output=json&crc=ABCD&systemeid=1")
+	b, _ := url.Parse("https://api.screenscraper.fr/api2/jeuInfos.php?systemeid=1&crc=ABCD&output=json&ssid=other")
+
+	if ResponseKey(a) != ResponseKey(b) {
+		t.Errorf("ResponseKey() = %q and %q, want the same key without credentials", ResponseKey(a), ResponseKey(b))
+	}
+	if want := "response:jeuInfos.php?crc=ABCD&output=json&systemeid=1"; ResponseKey(a) != want {
+		t.Errorf("ResponseKey() = %q, want %q", ResponseKey(a), want)
+	}
+}
+
+func TestTransport(t *testing.T) {
+	requests := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests[r.URL.Path]++
+		if r.URL.Query().Get("crc") == "missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"path":"` + r.URL.Path + `"}`))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	get := func(mode Mode, path string) (int, string) {
+		t.Helper()
+		c, err := New(dir, time.Hour, mode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		client := &http.Client{Transport: NewTransport(c, nil)}
+		resp, err := client.Get(server.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode, string(body)
+	}
+
+	// Users share cached responses
+	for _, user := range []string{"alice", "bob"} {
+		if status, body := get(ModeNormal, "/api2/jeuInfos.php?crc=ABCD&ssid="+user); status != 200 || body != `{"path":"/api2/jeuInfos.php"}` {
+			t.Errorf("GET as %s = %d %s", user, status, body)
+		}
+		get(ModeNormal, "/api2/jeuInfos.php?crc=missing")
+		get(ModeNormal, "/api2/ssuserInfos.php")
+	}
+	if requests["/api2/jeuInfos.php"] != 3 {
+		t.Errorf("jeuInfos requests = %d, want 3 (one cached, not-found ones not)", requests["/api2/jeuInfos.php"])
+	}
+	if requests["/api2/ssuserInfos.php"] != 2 {
+		t.Errorf("ssuserInfos requests = %d, want 2 (never cached)", requests["/api2/ssuserInfos.php"])
+	}
+
+	// Refreshing skips the cache
+	get(ModeNoRead, "/api2/jeuInfos.php?crc=ABCD")
+	if requests["/api2/jeuInfos.php"] != 4 {
+		t.Errorf("jeuInfos requests = %d, want 4 after a refresh", requests["/api2/jeuInfos.php"])
+	}
+}
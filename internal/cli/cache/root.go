@@ -2,10 +2,12 @@ package cache
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/spf13/cobra"
 
 	"github.com/sargunv/rom-tools/internal/cache"
+	"github.com/sargunv/rom-tools/lib/hashcache"
 )
 
 var Cmd = &cobra.Command{
@@ -45,6 +47,15 @@ var cleanCmd = &cobra.Command{
 			return fmt.Errorf("failed to clear cache: %w", err)
 		}
 
+		// Also remove the hash cache from identify --hash-cache
+		hashCachePath, err := hashcache.DefaultPath()
+		if err != nil {
+			return err
+		}
+		if err := os.Remove(hashCachePath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to clear hash cache: %w", err)
+		}
+
 		fmt.Println("Cache cleared.")
 		return nil
 	},
@@ -0,0 +1,219 @@
+package convert
+
+import (
+	"crypto/sha1"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sargunv/rom-tools/lib/chd"
+	"github.com/sargunv/rom-tools/lib/cue"
+	"github.com/sargunv/rom-tools/lib/gdi"
+)
+
+// userDataSize is the size of a sector's user data in an ISO image.
+const userDataSize = 2048
+
+// image is a disc image opened for conversion. CD and GD-ROM images have
+// tracks; ISO images and DVD CHDs only have data.
+type image struct {
+	tracks []chd.CDTrack
+	gdrom  bool
+	data   io.ReaderAt
+	size   int64
+	files  []*os.File
+}
+
+// Close closes the image's files.
+func (img *image) Close() error {
+	var errs []error
+	for _, f := range img.files {
+		errs = append(errs, f.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// openFile opens a file belonging to the image, to be closed with it.
+func (img *image) openFile(path string) (io.ReaderAt, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, 0, err
+	}
+	img.files = append(img.files, f)
+	return f, stat.Size(), nil
+}
+
+// openImage opens a disc image by its extension.
+func openImage(path string) (*image, error) {
+	img := &image{}
+	var err error
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".cue":
+		err = img.openCue(path)
+	case ".gdi":
+		err = img.openGDI(path)
+	case ".iso":
+		img.data, img.size, err = img.openFile(path)
+	case ".chd":
+		err = img.openCHD(path)
+	default:
+		err = fmt.Errorf("unsupported input format: %s", filepath.Ext(path))
+	}
+	if err != nil {
+		img.Close()
+		return nil, err
+	}
+	return img, nil
+}
+
+func (img *image) openCue(path string) error {
+	r, size, err := img.openFile(path)
+	if err != nil {
+		return err
+	}
+	sheet, err := cue.Parse(r, size)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(path)
+	img.tracks, err = chd.CueTracks(sheet, func(name string) (io.ReaderAt, int64, error) {
+		return img.openFile(filepath.Join(dir, name))
+	})
+	return err
+}
+
+func (img *image) openGDI(path string) error {
+	r, size, err := img.openFile(path)
+	if err != nil {
+		return err
+	}
+	sheet, err := gdi.Parse(r, size)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(path)
+	img.tracks, err = chd.GDITracks(sheet, func(name string) (io.ReaderAt, int64, error) {
+		return img.openFile(filepath.Join(dir, name))
+	})
+	img.gdrom = true
+	return err
+}
+
+func (img *image) openCHD(path string) error {
+	r, size, err := img.openFile(path)
+	if err != nil {
+		return err
+	}
+	reader, err := chd.NewReader(r, size)
+	if err != nil {
+		return err
+	}
+	if len(reader.Tracks) == 0 {
+		img.data, img.size = reader, reader.Size()
+		return nil
+	}
+	img.tracks, err = reader.CDTracks()
+	return err
+}
+
+// trackSize returns the size of a track's data in bytes.
+func trackSize(track chd.CDTrack) int64 {
+	return int64(track.Frames) * int64(chd.SectorSize(track.Type))
+}
+
+// totalSize returns the size of the image's data in bytes, or of its ISO
+// user data if iso is set.
+func (img *image) totalSize(iso bool) int64 {
+	if iso {
+		_, size, _ := img.userData()
+		return size
+	}
+	if img.tracks == nil {
+		return img.size
+	}
+	var total int64
+	for _, track := range img.tracks {
+		total += trackSize(track)
+	}
+	return total
+}
+
+// userData returns the image as a plain ISO: the data itself, or the user
+// data of a CD image's first track, which must be MODE1.
+func (img *image) userData() (io.ReaderAt, int64, error) {
+	if img.tracks == nil {
+		return img.data, img.size, nil
+	}
+
+	track := img.tracks[0]
+	switch track.Type {
+	case "MODE1":
+		return track.Data, trackSize(track), nil
+	case "MODE1_RAW":
+		// Skip the 16-byte sync and header of each raw sector
+		return &userDataReader{raw: track.Data}, int64(track.Frames) * userDataSize, nil
+	default:
+		return nil, 0, fmt.Errorf("can't extract ISO from %s track", track.Type)
+	}
+}
+
+// userDataReader reads the user data of MODE1 raw sectors.
+type userDataReader struct {
+	raw io.ReaderAt
+}
+
+// ReadAt implements io.ReaderAt.
+func (u *userDataReader) ReadAt(p []byte, off int64) (int, error) {
+	const headerSize = 16
+
+	n := 0
+	sector := make([]byte, userDataSize)
+	for n < len(p) {
+		pos := off + int64(n)
+		if _, err := u.raw.ReadAt(sector, pos/userDataSize*2352+headerSize); err != nil {
+			return n, err
+		}
+		n += copy(p[n:], sector[pos%userDataSize:])
+	}
+	return n, nil
+}
+
+// digest returns a SHA1 of each track of the image, or of its data, for
+// verifying a conversion. If iso is set, it's the SHA1 of the image's ISO
+// user data.
+func (img *image) digest(iso bool, p *progress) ([][sha1.Size]byte, error) {
+	hashOf := func(r io.ReaderAt, size int64) ([sha1.Size]byte, error) {
+		h := sha1.New()
+		if _, err := io.Copy(h, io.NewSectionReader(p.wrap(r), 0, size)); err != nil {
+			return [sha1.Size]byte{}, err
+		}
+		return [sha1.Size]byte(h.Sum(nil)), nil
+	}
+
+	if iso || img.tracks == nil {
+		r, size, err := img.userData()
+		if err != nil {
+			return nil, err
+		}
+		sum, err := hashOf(r, size)
+		return [][sha1.Size]byte{sum}, err
+	}
+
+	sums := make([][sha1.Size]byte, len(img.tracks))
+	for i, track := range img.tracks {
+		sum, err := hashOf(track.Data, trackSize(track))
+		if err != nil {
+			return nil, fmt.Errorf("track %d: %w", i+1, err)
+		}
+		sums[i] = sum
+	}
+	return sums, nil
+}
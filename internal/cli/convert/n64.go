@@ -0,0 +1,76 @@
+package convert
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+
+	"github.com/sargunv/rom-tools/lib/roms/nintendo/n64"
+
+	"github.com/spf13/cobra"
+)
+
+var n64Target string
+
+var n64Cmd = &cobra.Command{
+	Use:   "n64 <input> <output>",
+	Short: "Convert an N64 ROM between byte orders",
+	Long: `Convert an N64 ROM between the z64 (big-endian), v64 (byte-swapped), and
+n64 (little-endian) byte orders. The input's byte order is detected from its
+header.`,
+	Args: cobra.ExactArgs(2),
+	RunE: runN64,
+}
+
+func init() {
+	n64Cmd.Flags().StringVar(&n64Target, "to", string(n64.ByteOrderBigEndian), "Target byte order: z64, v64, or n64")
+	Cmd.AddCommand(n64Cmd)
+}
+
+func runN64(cmd *cobra.Command, args []string) error {
+	input, output := args[0], args[1]
+
+	target := n64.ByteOrder(n64Target)
+	switch target {
+	case n64.ByteOrderBigEndian, n64.ByteOrderByteSwapped, n64.ByteOrderLittleEndian:
+	default:
+		return fmt.Errorf("unknown byte order %q (want z64, v64, or n64)", n64Target)
+	}
+	created, err := checkOutput(output)
+	if err != nil {
+		return err
+	}
+
+	in, err := os.Open(input)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", input, err)
+	}
+	defer in.Close()
+
+	if err := writeN64(in, output, target); err != nil {
+		// Don't leave a partial ROM behind
+		removeAll(created)
+		return fmt.Errorf("failed to convert %s: %w", input, err)
+	}
+
+	fmt.Printf("Converted %s\n", output)
+	return nil
+}
+
+// writeN64 writes the ROM read from in to path in the target byte order.
+func writeN64(in *os.File, path string, target n64.ByteOrder) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	if err := n64.Convert(bufio.NewReader(in), w, target); err != nil {
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,58 @@
+package convert
+
+import (
+	"fmt"
+	"io"
+)
+
+// progress reports the percentage of bytes read from an image's sources.
+type progress struct {
+	out     io.Writer
+	label   string
+	total   int64
+	done    int64
+	percent int
+}
+
+// newProgress starts reporting progress for reading total bytes.
+func newProgress(out io.Writer, label string, total int64) *progress {
+	p := &progress{out: out, label: label, total: total, percent: -1}
+	p.add(0)
+	return p
+}
+
+// wrap returns r, counting the bytes read from it.
+func (p *progress) wrap(r io.ReaderAt) io.ReaderAt {
+	return &progressReader{r: r, p: p}
+}
+
+// add records n more bytes read, printing the percentage if it changed.
+func (p *progress) add(n int) {
+	p.done += int64(n)
+	percent := 100
+	if p.total > 0 {
+		percent = int(min(p.done, p.total) * 100 / p.total)
+	}
+	if percent != p.percent {
+		p.percent = percent
+		fmt.Fprintf(p.out, "\r%s: %d%%", p.label, percent)
+	}
+}
+
+// finish ends the progress line.
+func (p *progress) finish() {
+	fmt.Fprintln(p.out)
+}
+
+// progressReader counts the bytes read from an io.ReaderAt.
+type progressReader struct {
+	r io.ReaderAt
+	p *progress
+}
+
+// ReadAt implements io.ReaderAt.
+func (pr *progressReader) ReadAt(b []byte, off int64) (int, error) {
+	n, err := pr.r.ReadAt(b, off)
+	pr.p.add(n)
+	return n, err
+}
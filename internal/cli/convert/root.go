@@ -0,0 +1,199 @@
+package convert
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/sargunv/rom-tools/lib/chd"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	compression string
+	force       bool
+	noVerify    bool
+)
+
+// codecNames maps --compression names to CHD codecs.
+var codecNames = map[string]chd.Codec{
+	"zlib": chd.CodecZlib,
+	"lzma": chd.CodecLZMA,
+	"zstd": chd.CodecZstd,
+	"cdzl": chd.CodecCDZlib,
+	"cdlz": chd.CodecCDLZMA,
+	"cdzs": chd.CodecCDZstd,
+}
+
+var Cmd = &cobra.Command{
+	Use:   "convert <input> <output>",
+	Short: "Convert disc images and ROMs between formats",
+	Long: `Convert a disc image to another format, chosen by the output file extension.
+
+Supports:
+- BIN/CUE to CHD: .cue to .chd
+- CHD to BIN/CUE: .chd to .cue, with all tracks in one .bin alongside it
+- ISO to CHD: .iso to .chd
+- CHD to ISO: .chd to .iso, from DVD CHDs or CD CHDs with a MODE1 first track
+- GDI to CHD: .gdi to .chd
+
+CHD compression defaults to cdlz,cdzl for CDs and lzma,zlib for DVDs. Each
+hunk is stored with whichever codec compresses it best.
+
+After converting, the output is read back and its tracks hashed to verify
+they match the input.`,
+	Args: cobra.ExactArgs(2),
+	RunE: runConvert,
+}
+
+func init() {
+	Cmd.Flags().StringVarP(&compression, "compression", "c", "",
+		"CHD codecs to use, comma separated: zlib, lzma, zstd, cdzl, cdlz, cdzs, or none")
+	Cmd.PersistentFlags().BoolVarP(&force, "force", "f", false, "Overwrite the output file if it exists")
+	Cmd.Flags().BoolVar(&noVerify, "no-verify", false, "Skip verifying the output after converting")
+}
+
+func runConvert(cmd *cobra.Command, args []string) error {
+	input, output := args[0], args[1]
+
+	outExt := strings.ToLower(filepath.Ext(output))
+	if !slices.Contains([]string{".chd", ".cue", ".iso"}, outExt) {
+		return fmt.Errorf("unsupported output format: %s", filepath.Ext(output))
+	}
+	created, err := checkOutput(outputFiles(output)...)
+	if err != nil {
+		return err
+	}
+
+	img, err := openImage(input)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", input, err)
+	}
+	defer img.Close()
+
+	p := newProgress(os.Stderr, "Converting", img.totalSize(outExt == ".iso"))
+	switch outExt {
+	case ".chd":
+		var compressors []chd.Codec
+		compressors, err = parseCompression(compression, img.tracks != nil)
+		if err == nil {
+			err = writeCHD(img, output, compressors, p)
+		}
+	case ".cue":
+		err = writeCue(img, output, p)
+	case ".iso":
+		err = writeISO(img, output, p)
+	}
+	p.finish()
+	if err != nil {
+		// Don't leave a partial image behind
+		removeAll(created)
+		return fmt.Errorf("failed to convert %s: %w", input, err)
+	}
+
+	if !noVerify {
+		if err := verify(img, output, outExt == ".iso"); err != nil {
+			return fmt.Errorf("failed to verify %s: %w", output, err)
+		}
+		fmt.Printf("Converted and verified %s\n", output)
+	} else {
+		fmt.Printf("Converted %s\n", output)
+	}
+	return nil
+}
+
+// outputFiles returns the files a conversion to output writes: the output
+// itself, plus the BIN file beside a CUE sheet.
+func outputFiles(output string) []string {
+	if strings.EqualFold(filepath.Ext(output), ".cue") {
+		return []string{output, cueBinPath(output)}
+	}
+	return []string{output}
+}
+
+// checkOutput returns an error if any of paths exist, unless --force is set.
+// It returns the paths that don't exist yet, which are the only ones to
+// remove if the conversion fails.
+func checkOutput(paths ...string) ([]string, error) {
+	var created []string
+	for _, path := range paths {
+		if _, err := os.Stat(path); err != nil {
+			created = append(created, path)
+			continue
+		}
+		if !force {
+			return nil, fmt.Errorf("output %s already exists (use --force to overwrite)", path)
+		}
+	}
+	return created, nil
+}
+
+// removeAll removes paths, ignoring errors, to clean up after a failed
+// conversion.
+func removeAll(paths []string) {
+	for _, path := range paths {
+		os.Remove(path)
+	}
+}
+
+// verify reads back the converted image and checks its contents match the
+// input.
+func verify(img *image, output string, iso bool) error {
+	converted, err := openImage(output)
+	if err != nil {
+		return err
+	}
+	defer converted.Close()
+
+	p := newProgress(os.Stderr, "Verifying", img.totalSize(iso)+converted.totalSize(iso))
+	defer p.finish()
+
+	want, err := img.digest(iso, p)
+	if err != nil {
+		return fmt.Errorf("hash input: %w", err)
+	}
+	got, err := converted.digest(iso, p)
+	if err != nil {
+		return fmt.Errorf("hash output: %w", err)
+	}
+
+	if len(got) != len(want) {
+		return fmt.Errorf("output has %d tracks, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			return fmt.Errorf("track %d SHA1 mismatch: got %x, want %x", i+1, got[i], want[i])
+		}
+	}
+	return nil
+}
+
+// parseCompression parses the --compression flag, returning the defaults if
+// it's empty.
+func parseCompression(value string, cd bool) ([]chd.Codec, error) {
+	switch value {
+	case "":
+		if cd {
+			return []chd.Codec{chd.CodecCDLZMA, chd.CodecCDZlib}, nil
+		}
+		return []chd.Codec{chd.CodecLZMA, chd.CodecZlib}, nil
+	case "none":
+		return nil, nil
+	}
+
+	var codecs []chd.Codec
+	for _, name := range strings.Split(value, ",") {
+		codec, ok := codecNames[strings.TrimSpace(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown codec %q", name)
+		}
+		if slices.Contains(codecs, codec) {
+			return nil, fmt.Errorf("duplicate codec %q", name)
+		}
+		codecs = append(codecs, codec)
+	}
+	return codecs, nil
+}
@@ -0,0 +1,138 @@
+package convert
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sargunv/rom-tools/lib/chd"
+	"github.com/sargunv/rom-tools/lib/cue"
+)
+
+// cueTrackTypes maps CHD track types to CUE sheet track types.
+var cueTrackTypes = map[string]string{
+	"MODE1":     "MODE1/2048",
+	"MODE1_RAW": "MODE1/2352",
+	"MODE2":     "MODE2/2336",
+	"MODE2_RAW": "MODE2/2352",
+	"AUDIO":     "AUDIO",
+}
+
+// writeCHD writes img to path as a CHD.
+func writeCHD(img *image, path string, compressors []chd.Codec, p *progress) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if img.tracks != nil {
+		tracks := make([]chd.CDTrack, len(img.tracks))
+		for i, track := range img.tracks {
+			track.Data = p.wrap(track.Data)
+			tracks[i] = track
+		}
+		if img.gdrom {
+			_, err = chd.WriteGD(f, tracks, compressors)
+		} else {
+			_, err = chd.WriteCD(f, tracks, compressors)
+		}
+		if err != nil {
+			return err
+		}
+		return f.Close()
+	}
+
+	w, err := chd.NewWriter(f, chd.WriterConfig{UnitBytes: userDataSize, Compressors: compressors})
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(w, io.NewSectionReader(p.wrap(img.data), 0, img.size)); err != nil {
+		return err
+	}
+	// chdman marks DVD images with an empty DVD metadata entry
+	if err := w.AddMetadata(chd.TagDVD, []byte{0}); err != nil {
+		return err
+	}
+	if _, err := w.Close(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// writeCue writes img to path as a CUE sheet, with its tracks in a single BIN
+// file of the same base name.
+func writeCue(img *image, path string, p *progress) error {
+	if img.tracks == nil {
+		return fmt.Errorf("can't write a CUE sheet for an image without tracks")
+	}
+
+	binPath := cueBinPath(path)
+	bin, err := os.Create(binPath)
+	if err != nil {
+		return err
+	}
+	defer bin.Close()
+
+	var sheet strings.Builder
+	fmt.Fprintf(&sheet, "FILE \"%s\" BINARY\n", filepath.Base(binPath))
+
+	var frame int64
+	for i, track := range img.tracks {
+		cueType, ok := cueTrackTypes[track.Type]
+		if !ok {
+			return fmt.Errorf("track %d: unsupported track type %s", i+1, track.Type)
+		}
+
+		fmt.Fprintf(&sheet, "  TRACK %02d %s\n", i+1, cueType)
+		if track.PregapStored {
+			fmt.Fprintf(&sheet, "    INDEX 00 %s\n", cue.FormatMSF(frame))
+			fmt.Fprintf(&sheet, "    INDEX 01 %s\n", cue.FormatMSF(frame+int64(track.Pregap)))
+		} else {
+			if track.Pregap > 0 {
+				fmt.Fprintf(&sheet, "    PREGAP %s\n", cue.FormatMSF(int64(track.Pregap)))
+			}
+			fmt.Fprintf(&sheet, "    INDEX 01 %s\n", cue.FormatMSF(frame))
+		}
+		if track.Postgap > 0 {
+			fmt.Fprintf(&sheet, "    POSTGAP %s\n", cue.FormatMSF(int64(track.Postgap)))
+		}
+
+		if _, err := io.Copy(bin, io.NewSectionReader(p.wrap(track.Data), 0, trackSize(track))); err != nil {
+			return fmt.Errorf("track %d: %w", i+1, err)
+		}
+		frame += int64(track.Frames)
+	}
+	if err := bin.Close(); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, []byte(sheet.String()), 0o644)
+}
+
+// cueBinPath returns the path of the BIN file written beside the CUE sheet
+// at path.
+func cueBinPath(path string) string {
+	return strings.TrimSuffix(path, filepath.Ext(path)) + ".bin"
+}
+
+// writeISO writes img to path as an ISO.
+func writeISO(img *image, path string, p *progress) error {
+	data, size, err := img.userData()
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(f, io.NewSectionReader(p.wrap(data), 0, size)); err != nil {
+		return err
+	}
+	return f.Close()
+}
@@ -2,15 +2,21 @@ package identify
 
 import (
 	"cmp"
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"slices"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/sargunv/rom-tools/internal/format"
 	"github.com/sargunv/rom-tools/lib/core"
+	"github.com/sargunv/rom-tools/lib/datfile"
+	"github.com/sargunv/rom-tools/lib/hashcache"
 	romident "github.com/sargunv/rom-tools/lib/identify"
 
 	"github.com/spf13/cobra"
@@ -19,8 +25,24 @@ import (
 var (
 	jsonOutput  bool
 	maxHashSize int64
+	datPaths    []string
+	concurrency int
+	extraHashes []string
+	progress    bool
+	hashCache   bool
+	refresh     bool
+	watch       bool
+	fileTimeout time.Duration
+	sniff       bool
+	fast        bool
+	depth       int
+	password    string
 )
 
+// progressMinSize is the size from which --progress reports an item's
+// hashing, so small files don't flood the terminal.
+const progressMinSize = 64 * 1024 * 1024
+
 var Cmd = &cobra.Command{
 	Use:   "identify <file>...",
 	Short: "Identify ROM files and extract metadata",
@@ -29,28 +51,58 @@ var Cmd = &cobra.Command{
 Supports:
 - Platform specific ROMs: identifies game information from the ROM header. Supported formats:
   - Famicom (NES): .nes
+  - Famicom Disk System: .fds
   - Super Famicom (SNES): .sfc, .smc
   - Nintendo 64: .z64, .v64, .n64
-  - Nintendo GameCube / Wii: .gcm, .iso, .rvz, .wia
+  - Nintendo GameCube / Wii: .gcm, .iso, .rvz, .wia, .wbfs, .ciso, .gcz, .nkit.iso
   - Nintendo Game Boy / Color: .gb, .gbc
   - Nintendo Game Boy Advance: .gba
   - Nintendo DS: .nds, .dsi, .ids
-  - Nintendo 3DS: .3ds, .cci
+  - Nintendo 3DS: .3ds, .cci, .cia
   - Sega Master System / Game Gear: .sms, .gg
-  - Sega Mega Drive (Genesis): .md, .gen, .smd, .32x
-  - Sega CD: .bin, .chd
-  - Sega Saturn: .bin, .chd
-  - Sega Dreamcast: .bin, .chd
-  - Sony PlayStation 1: .bin, .chd
-  - Sony PlayStation 2: .iso, .bin, .chd
-  - Sony PlayStation 3: .pkg
-  - Sony PlayStation Portable: .iso, .chd
+  - Sega Mega Drive (Genesis) / 32X / Pico: .md, .gen, .smd, .32x
+  - Sega CD: .bin, .cue/.bin, .ccd/.img, .nrg, .chd
+  - Sega Saturn: .bin, .cue/.bin, .ccd/.img, .nrg, .chd
+  - Sega Dreamcast: .bin, .cue/.bin, .gdi, .ccd/.img, .nrg, .chd
+  - NEC PC Engine (TurboGrafx-16): .pce
+  - NEC PC Engine CD (TurboGrafx-CD): .bin, .cue/.bin, .ccd/.img, .nrg, .chd
+  - SNK Neo Geo CD: .iso, .bin, .cue/.bin, .ccd/.img, .nrg, .chd
+  - 3DO Interactive Multiplayer: .iso, .bin, .cue/.bin, .ccd/.img, .nrg, .chd
+  - Atari 2600: .a26
+  - Atari 7800: .a78
+  - Atari Lynx: .lnx
+  - Atari Jaguar: .j64, .rom
+  - Atari Jaguar CD: .cue/.bin, .ccd/.img, .nrg, .chd
+  - MSX / MSX2: .rom, .mx1, .mx2
+  - Commodore 64: .crt, .d64, .t64
+  - Commodore Amiga: .adf, .dms
+  - ZX Spectrum: .tap, .tzx, .z80
+  - Sony PlayStation 1: .bin, .cue/.bin, .ccd/.img, .nrg, .chd, .pbp
+  - Sony PlayStation 2: .iso, .bin, .cue/.bin, .ccd/.img, .nrg, .chd
+  - Sony PlayStation 3: .pkg, disc folders (PS3_GAME/PARAM.SFO)
+  - Sony PlayStation Portable: .iso, .cso, .zso, .chd, .pbp
   - Sony PlayStation Vita: .pkg
   - Microsoft Xbox: .iso, .chd, .xbe
-- .chd discs: extracts SHA1 hashes from header (no decompression needed)
+- .chd discs: extracts SHA1 hashes, and MD5 hashes from v1-v3 CHDs, from the header (no decompression needed)
+- NKit discs: extracts the original disc image CRC32 from the NKit header
 - .zip archives: extracts CRC32 hashes from metadata (no decompression needed)
-- All files: calculates SHA1, MD5, CRC32 for uncompressed files under --max-hash-size
-- All folders: identifies files within`,
+- .rar archives, including multi-volume sets (.part1.rar, .part2.rar, ... or .rar, .r00, ...): identifies and hashes their contents
+- .tar archives, and .gz, .xz, and .zst compressed files and tar archives (e.g., .nes.gz, .tar.gz, .tgz): identifies and hashes their contents
+- Archives within archives and folders (e.g., a .zip of per-game .zip files): identifies their contents, up to --archive-depth levels deep
+- Encrypted .zip and .rar archives: decrypts their contents with --password
+- Split .zip archives (.z01, .z02, ..., .zip): reads their contents from every part
+- All files: calculates SHA1, MD5, CRC32 for uncompressed files under --max-hash-size, plus SHA256 and xxHash64 with --hash
+- --hash-cache: caches calculated hashes, so unchanged files aren't hashed again
+- --sniff: identifies files with generic or missing extensions (e.g., renamed .bin files) by their magic bytes, for formats that have them
+- Game Boy ROMs: checks the header checksum and Nintendo logo, and the global checksum over the whole ROM unless --fast is set, to flag bad dumps and overdumps
+- --file-timeout: gives up on files that take too long to identify and hash; Ctrl-C stops partway through a file
+- --watch: watches directories and identifies each new or modified file once it stops changing, printing a JSON Line for each (e.g., for a download folder)
+- Headered ROMs (.nes, .fds, .smc, .pce, .lnx, .a78, .smd): also calculates headerless hashes, as used by No-Intro DATs (for .smd, of the de-interleaved ROM)
+- All folders: identifies files within
+- Archives and folders: marks the items that are games as primary, as opposed to auxiliary files (e.g., .sub files, the tracks of .cue sheets, or readmes)
+- Archives and folders: groups the discs of multi-disc games, by "(Disc N)" names, the disc numbers of GameCube, Xbox, PS3, PSP, Sega CD, Saturn, and Dreamcast headers, or the consecutive serials of PlayStation discs in one folder
+- --dat: matches hashes against No-Intro / Redump / MAME DAT files (including MAME -listxml output)
+- --dat with archives and folders: matches their contents as a whole against DAT sets (e.g. MAME machines), reporting complete or partial sets and parent/clone relationships`,
 	Args: cobra.MinimumNArgs(1),
 	RunE: runIdentify,
 }
@@ -61,22 +113,99 @@ func init() {
 	Cmd.Flags().BoolVarP(&jsonOutput, "json", "j", false, "Output results as JSON Lines (one JSON object per line)")
 	Cmd.Flags().Int64Var(&maxHashSize, "max-hash-size", defaults.MaxHashSize,
 		"Max file size in bytes for hash calculation (-1 = no limit)")
+	Cmd.Flags().IntVar(&concurrency, "concurrency", defaults.Concurrency,
+		"Number of files identified in parallel within a folder or archive (0 = number of CPUs for folders, serial for archives)")
+	Cmd.Flags().StringArrayVar(&datPaths, "dat", nil,
+		"DAT file to match against (repeatable)")
+	Cmd.Flags().StringSliceVar(&extraHashes, "hash", nil,
+		"Extra hashes to calculate, comma separated: sha256, xxh64")
+	Cmd.Flags().BoolVar(&progress, "progress", false,
+		"Show hashing progress on stderr for files of 64 MiB or more")
+	Cmd.Flags().BoolVar(&hashCache, "hash-cache", false,
+		"Cache calculated hashes, and reuse them for files whose size and modification time are unchanged")
+	Cmd.Flags().BoolVar(&refresh, "refresh-hash-cache", false,
+		"With --hash-cache, recalculate every hash and replace the cached ones")
+	Cmd.Flags().DurationVar(&fileTimeout, "file-timeout", defaults.FileTimeout,
+		"Give up on a file (or a whole folder or archive) if one file in it takes longer than this (0 = no limit)")
+	Cmd.Flags().IntVar(&depth, "archive-depth", defaults.MaxArchiveDepth,
+		"Levels of archives nested in folders or archives to identify the contents of (0 = identify nested archives as files)")
+	Cmd.Flags().StringVar(&password, "password", "",
+		"Password of encrypted .zip and .rar archives")
+	Cmd.Flags().BoolVar(&sniff, "sniff", false,
+		"Identify files their extension doesn't by checking for the magic bytes of every supported format")
+	Cmd.Flags().BoolVar(&fast, "fast", false,
+		"Skip checks that read whole ROMs, like the Game Boy global checksum")
+	Cmd.Flags().BoolVar(&watch, "watch", false,
+		"Watch directories and identify files as they're added or changed, as JSON Lines, until interrupted")
 }
 
 func runIdentify(cmd *cobra.Command, args []string) error {
 	opts := romident.Options{
-		MaxHashSize: maxHashSize,
+		MaxHashSize:     maxHashSize,
+		Concurrency:     concurrency,
+		FileTimeout:     fileTimeout,
+		Sniff:           sniff,
+		MaxArchiveDepth: depth,
+		Password:        password,
+		Fast:            fast,
+	}
+	for _, name := range extraHashes {
+		opts.ExtraHashes = append(opts.ExtraHashes, core.HashType(strings.TrimSpace(name)))
+	}
+	if progress {
+		opts.Progress = newProgress()
+	}
+	if hashCache {
+		path, err := hashcache.DefaultPath()
+		if err != nil {
+			return err
+		}
+		cache, err := hashcache.Open(path)
+		if err != nil {
+			return err
+		}
+		defer cache.Close()
+		opts.HashCache = cache
+		if refresh {
+			opts.HashCacheMode = romident.HashCacheRefresh
+		}
 	}
 
+	var matcher *romident.Matcher
+	if len(datPaths) > 0 {
+		index := romident.NewDATIndex()
+		for _, datPath := range datPaths {
+			dat, err := datfile.Parse(datPath)
+			if err != nil {
+				return fmt.Errorf("failed to load DAT %s: %w", datPath, err)
+			}
+			index.Add("", dat)
+		}
+		matcher = romident.NewMatcher(index)
+	}
+
+	if watch {
+		return watchDirs(args, opts, matcher)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
 	first := true
 
 	for _, path := range args {
-		result, err := romident.Identify(path, opts)
+		result, err := romident.IdentifyContext(ctx, path, opts)
 		if err != nil {
+			if ctx.Err() != nil {
+				return err
+			}
 			fmt.Fprintf(os.Stderr, "Error: failed to identify %s: %v\n", path, err)
 			continue
 		}
 
+		if matcher != nil {
+			matcher.Annotate(result)
+		}
+
 		if jsonOutput {
 			outputJSONLine(result)
 		} else {
@@ -91,6 +220,31 @@ func runIdentify(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// newProgress returns a progress callback that shows the percentage of each
+// large item hashed on one line of stderr, cleared once the item is done.
+func newProgress() func(name string, done, total int64) {
+	var mu sync.Mutex
+	percents := make(map[string]int)
+	return func(name string, done, total int64) {
+		if total < progressMinSize {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if done == total {
+			delete(percents, name)
+			fmt.Fprint(os.Stderr, "\r\033[K")
+			return
+		}
+		percent := int(done * 100 / total)
+		if last, ok := percents[name]; ok && last == percent {
+			return
+		}
+		percents[name] = percent
+		fmt.Fprintf(os.Stderr, "\r\033[KHashing %s: %d%%", name, percent)
+	}
+}
+
 func outputJSONLine(result *romident.Result) {
 	output, err := json.Marshal(result)
 	if err != nil {
@@ -123,7 +277,11 @@ func outputText(result *romident.Result) {
 		})
 
 		for _, item := range items {
-			fmt.Printf("  %s\n", item.Name)
+			if item.Primary && len(items) > 1 {
+				fmt.Printf("  %s (primary)\n", item.Name)
+			} else {
+				fmt.Printf("  %s\n", item.Name)
+			}
 			fmt.Printf("    Size: %s\n", formatSize(item.Size))
 
 			if len(item.Hashes) > 0 {
@@ -158,6 +316,55 @@ func outputText(result *romident.Result) {
 					fmt.Printf("      Region: %s\n", formatRegions(regions))
 				}
 			}
+
+			if item.Match != nil {
+				fmt.Println("    Match:")
+				fmt.Printf("      Status: %s\n", item.Match.Status)
+				if item.Match.Game != "" {
+					fmt.Printf("      Game: %s\n", item.Match.Game)
+				}
+				if item.Match.Source != "" {
+					fmt.Printf("      DAT: %s\n", item.Match.Source)
+				}
+			}
+		}
+	}
+
+	if len(result.Groups) > 0 {
+		fmt.Println(format.HeaderStyle.Render("Multi-disc games:"))
+		for _, group := range result.Groups {
+			fmt.Printf("  %s\n", group.Title)
+			if group.Platform != "" {
+				fmt.Printf("    Platform: %s\n", group.Platform)
+			}
+			for _, disc := range group.Discs {
+				if group.Total > 0 {
+					fmt.Printf("    Disc %d of %d: %s\n", disc.Number, group.Total, disc.Item)
+				} else {
+					fmt.Printf("    Disc %d: %s\n", disc.Number, disc.Item)
+				}
+			}
+		}
+	}
+
+	if set := result.Set; set != nil {
+		fmt.Println(format.HeaderStyle.Render("Set:"))
+		fmt.Printf("  %s\n", set.Set)
+		if set.Description != "" {
+			fmt.Printf("    Description: %s\n", set.Description)
+		}
+		fmt.Printf("    Status: %s (%d/%d ROMs)\n", set.Status, set.Have, set.Total)
+		if set.CloneOf != "" {
+			fmt.Printf("    Clone of: %s\n", set.CloneOf)
+		}
+		if set.RomOf != "" && set.RomOf != set.CloneOf {
+			fmt.Printf("    ROMs from: %s\n", set.RomOf)
+		}
+		if len(set.Missing) > 0 {
+			fmt.Printf("    Missing: %s\n", strings.Join(set.Missing, ", "))
+		}
+		if set.Source != "" {
+			fmt.Printf("    DAT: %s\n", set.Source)
 		}
 	}
 }
@@ -0,0 +1,146 @@
+package identify
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	romident "github.com/sargunv/rom-tools/lib/identify"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// settleDelay is how long a file must go without changes before it's
+// identified, so files still being written or downloaded are skipped.
+const settleDelay = 2 * time.Second
+
+// partialExtensions are the extensions browsers and download tools give
+// files that are still being downloaded.
+var partialExtensions = []string{".part", ".partial", ".crdownload", ".download", ".tmp"}
+
+// watchDirs identifies files as they're created or modified under dirs,
+// once they've settled, printing a JSON Line for each, until interrupted.
+func watchDirs(dirs []string, opts romident.Options, matcher *romident.Matcher) error {
+	for _, dir := range dirs {
+		info, err := os.Stat(dir)
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("--watch needs directories: %s is a file", dir)
+		}
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to start watching: %w", err)
+	}
+	defer watcher.Close()
+
+	// fsnotify doesn't watch recursively, so each directory is added
+	pending := make(map[string]time.Time)
+	addDir := func(dir string, queueFiles bool) {
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if path != dir && skipName(d.Name()) {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if d.IsDir() {
+				if err := watcher.Add(path); err != nil {
+					fmt.Fprintf(os.Stderr, "Error: failed to watch %s: %v\n", path, err)
+				}
+			} else if queueFiles && d.Type().IsRegular() {
+				pending[path] = time.Now()
+			}
+			return nil
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to watch %s: %v\n", dir, err)
+		}
+	}
+	for _, dir := range dirs {
+		addDir(dir, false)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ticker := time.NewTicker(settleDelay / 4)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if skipName(filepath.Base(event.Name)) {
+				continue
+			}
+			switch {
+			case event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename):
+				// A renamed file is created again under its new name
+				delete(pending, event.Name)
+			case event.Has(fsnotify.Create) || event.Has(fsnotify.Write):
+				info, err := os.Stat(event.Name)
+				switch {
+				case err != nil:
+				case info.IsDir():
+					// A directory moved in already holds files
+					addDir(event.Name, true)
+				case info.Mode().IsRegular():
+					pending[event.Name] = time.Now()
+				}
+			}
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+
+		case now := <-ticker.C:
+			var ready []string
+			for path, changed := range pending {
+				if now.Sub(changed) >= settleDelay {
+					ready = append(ready, path)
+				}
+			}
+			slices.Sort(ready)
+			for _, path := range ready {
+				delete(pending, path)
+				result, err := romident.IdentifyContext(ctx, path, opts)
+				if ctx.Err() != nil {
+					return nil
+				}
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error: failed to identify %s: %v\n", path, err)
+					continue
+				}
+				if matcher != nil {
+					matcher.Annotate(result)
+				}
+				outputJSONLine(result)
+			}
+		}
+	}
+}
+
+// skipName reports whether a file or directory is skipped by watch mode:
+// hidden ones, and downloads in progress.
+func skipName(name string) bool {
+	return strings.HasPrefix(name, ".") || slices.Contains(partialExtensions, strings.ToLower(filepath.Ext(name)))
+}
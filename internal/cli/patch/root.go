@@ -0,0 +1,85 @@
+package patch
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/sargunv/rom-tools/lib/patch"
+
+	"github.com/spf13/cobra"
+)
+
+var force bool
+
+var Cmd = &cobra.Command{
+	Use:   "patch",
+	Short: "Apply ROM patches",
+}
+
+var applyCmd = &cobra.Command{
+	Use:   "apply <rom> <patch> <output>",
+	Short: "Apply a patch to a ROM",
+	Long: `Apply a patch to a ROM, writing the patched ROM to output.
+
+Supported patch formats, detected from the patch contents:
+- IPS, including the truncation extension
+- BPS
+- UPS
+
+BPS and UPS patches are checked against the CRC32s they record, so applying
+one to the wrong ROM or revision fails before anything is written.`,
+	Args: cobra.ExactArgs(3),
+	RunE: runApply,
+}
+
+func init() {
+	applyCmd.Flags().BoolVarP(&force, "force", "f", false, "Overwrite the output file if it exists")
+	Cmd.AddCommand(applyCmd)
+}
+
+func runApply(cmd *cobra.Command, args []string) error {
+	romPath, patchPath, output := args[0], args[1], args[2]
+
+	if !force {
+		if _, err := os.Stat(output); err == nil {
+			return fmt.Errorf("output %s already exists (use --force to overwrite)", output)
+		}
+	}
+
+	p, err := openPatch(patchPath)
+	if err != nil {
+		return fmt.Errorf("failed to open patch %s: %w", patchPath, err)
+	}
+
+	rom, err := os.ReadFile(romPath)
+	if err != nil {
+		return fmt.Errorf("failed to read ROM: %w", err)
+	}
+
+	patched, err := p.Apply(rom)
+	if err != nil {
+		return fmt.Errorf("failed to apply %s: %w", patchPath, err)
+	}
+
+	if err := os.WriteFile(output, patched, 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", output, err)
+	}
+
+	fmt.Printf("Patched %s\n", output)
+	return nil
+}
+
+// openPatch reads and parses the patch at path.
+func openPatch(path string) (patch.Patch, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return patch.Parse(f, stat.Size())
+}
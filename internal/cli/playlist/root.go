@@ -0,0 +1,137 @@
+package playlist
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/sargunv/rom-tools/lib/core"
+	"github.com/sargunv/rom-tools/lib/datfile"
+	romident "github.com/sargunv/rom-tools/lib/identify"
+	"github.com/sargunv/rom-tools/lib/retroarch"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	datPaths []string
+	output   string
+	coresDir string
+	platform string
+	dryRun   bool
+)
+
+var Cmd = &cobra.Command{
+	Use:   "playlist <path>...",
+	Short: "Write RetroArch playlists of ROMs",
+	Long: `Identify ROMs and write RetroArch playlists (.lpl) of them, one per platform.
+
+Each path may be a ROM file, a .zip archive, or a folder of them, which is
+searched recursively.
+
+- Playlists are named after the platform's libretro database (e.g.,
+  "Nintendo - Game Boy.lpl"), and existing ones are replaced
+- Entries record the CRC32 of each game, so RetroArch finds it in its
+  database and shows its thumbnails
+- With --dat, entries are labeled with the DAT names of the games they
+  match, which RetroArch's thumbnails are named by; otherwise, with their
+  file names
+- Only the files that are games are added, not the tracks of .cue sheets or
+  other auxiliary files
+- Games inside .zip archives are added as "archive.zip#game.rom"; other
+  archives, and archives within archives, can't be read by RetroArch, so
+  their games are skipped
+- With --cores-dir, each playlist's default core is set to one that emulates
+  its platform (e.g., Gambatte for Game Boy)
+- Games whose platform isn't identified (e.g., headerless ROMs) are skipped,
+  unless --platform gives it`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runPlaylist,
+}
+
+func init() {
+	Cmd.Flags().StringArrayVar(&datPaths, "dat", nil, "DAT file to label games from (repeatable)")
+	Cmd.Flags().StringVarP(&output, "output", "o", "", "Directory to write playlists to (required)")
+	Cmd.Flags().StringVar(&coresDir, "cores-dir", "", "RetroArch cores directory, to set each playlist's default core")
+	Cmd.Flags().StringVar(&platform, "platform", "", "Platform of games whose platform isn't identified (e.g., gameboy, megadrive)")
+	Cmd.Flags().BoolVarP(&dryRun, "dry-run", "n", false, "Show the playlists that would be written without writing them")
+	Cmd.MarkFlagRequired("output")
+}
+
+func runPlaylist(cmd *cobra.Command, args []string) error {
+	if platform != "" && retroarch.PlaylistName(core.Platform(platform)) == "" {
+		return fmt.Errorf("invalid --platform %q (no RetroArch database for it)", platform)
+	}
+
+	var matcher *romident.Matcher
+	if len(datPaths) > 0 {
+		index := romident.NewDATIndex()
+		for _, datPath := range datPaths {
+			dat, err := datfile.Parse(datPath)
+			if err != nil {
+				return fmt.Errorf("failed to load DAT %s: %w", datPath, err)
+			}
+			index.Add("", dat)
+		}
+		matcher = romident.NewMatcher(index)
+	}
+
+	cmd.SilenceUsage = true
+
+	// RetroArch reads ZIPs in folders, but not archives within them
+	opts := romident.DefaultOptions()
+	opts.MaxArchiveDepth = 1
+
+	var results []*romident.Result
+	for _, path := range args {
+		result, err := romident.Identify(path, opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to identify %s: %v\n", path, err)
+			continue
+		}
+		if matcher != nil {
+			matcher.Annotate(result)
+		}
+		results = append(results, result)
+	}
+
+	export, err := retroarch.NewExport(results, retroarch.Options{
+		CoresDir: coresDir,
+		Platform: core.Platform(platform),
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, skipped := range export.Skipped {
+		fmt.Printf("Skipped %s: %s\n", skipped.Path, skipped.Reason)
+	}
+	if len(export.Playlists) == 0 {
+		fmt.Println("No playlists to write")
+		return nil
+	}
+	if !dryRun {
+		if err := os.MkdirAll(output, 0o755); err != nil {
+			return fmt.Errorf("failed to create %s: %w", output, err)
+		}
+	}
+	for _, name := range export.Names() {
+		playlist := export.Playlists[name]
+		path := filepath.Join(output, name)
+		fmt.Printf("%s: %d games\n", path, len(playlist.Items))
+		if dryRun {
+			continue
+		}
+		data, err := retroarch.Write(playlist)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+	}
+	if dryRun {
+		fmt.Println("Dry run: nothing was written")
+	}
+	return nil
+}
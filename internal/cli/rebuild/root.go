@@ -0,0 +1,162 @@
+package rebuild
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sargunv/rom-tools/lib/datfile"
+	romident "github.com/sargunv/rom-tools/lib/identify"
+	"github.com/sargunv/rom-tools/lib/rebuild"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	datPaths  []string
+	dest      string
+	dryRun    bool
+	collision string
+)
+
+var Cmd = &cobra.Command{
+	Use:   "rebuild <path>...",
+	Short: "Rename ROMs to their DAT names",
+	Long: `Rename ROM files to the names given by the DAT entries they match.
+
+Each path may be a ROM file, a .zip archive, or a folder holding one game. A
+directory of those is rebuilt entry by entry.
+
+- ROM files are renamed to their ROM name from the DAT
+- Files inside .zip archives and folders are renamed to their ROM names, and
+  the archive or folder is then renamed after its game if everything in it
+  belongs to the same one
+- Files that don't match any DAT entry are left as they are
+- With --dest, files, archives, and folders are moved there instead of being
+  renamed in place
+
+When a target name is already taken, --collision decides what happens:
+- skip: leave the file as it is (identical files are always skipped)
+- overwrite: replace the existing file
+- rename: add a numbered suffix, e.g. "Game (USA) (1).nes"`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runRebuild,
+}
+
+func init() {
+	Cmd.Flags().StringArrayVar(&datPaths, "dat", nil, "DAT file to match against (repeatable, required)")
+	Cmd.Flags().StringVarP(&dest, "dest", "d", "", "Directory to move files to (default: rename in place)")
+	Cmd.Flags().BoolVarP(&dryRun, "dry-run", "n", false, "Show what would be renamed without changing anything")
+	Cmd.Flags().StringVar(&collision, "collision", string(rebuild.CollisionSkip),
+		"What to do when a target exists: skip, overwrite, or rename")
+	Cmd.MarkFlagRequired("dat")
+}
+
+func runRebuild(cmd *cobra.Command, args []string) error {
+	policy := rebuild.CollisionPolicy(collision)
+	switch policy {
+	case rebuild.CollisionSkip, rebuild.CollisionOverwrite, rebuild.CollisionRename:
+	default:
+		return fmt.Errorf("invalid --collision %q (must be skip, overwrite, or rename)", collision)
+	}
+
+	index := romident.NewDATIndex()
+	for _, datPath := range datPaths {
+		dat, err := datfile.Parse(datPath)
+		if err != nil {
+			return fmt.Errorf("failed to load DAT %s: %w", datPath, err)
+		}
+		index.Add("", dat)
+	}
+	matcher := romident.NewMatcher(index)
+
+	paths, err := expandPaths(args)
+	if err != nil {
+		return err
+	}
+
+	// Entries of nested archives can't be renamed in place, so they're left
+	// as files of the archive they're in
+	opts := romident.DefaultOptions()
+	opts.MaxArchiveDepth = 0
+
+	var results []*romident.Result
+	for _, path := range paths {
+		result, err := romident.Identify(path, opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to identify %s: %v\n", path, err)
+			continue
+		}
+		matcher.Annotate(result)
+		results = append(results, result)
+	}
+
+	if dest != "" {
+		if dest, err = filepath.Abs(dest); err != nil {
+			return fmt.Errorf("failed to resolve --dest: %w", err)
+		}
+	}
+	plan, err := rebuild.NewPlan(results, rebuild.Options{Dest: dest, Collision: policy})
+	if err != nil {
+		return err
+	}
+
+	outputPlan(plan)
+	if dryRun {
+		fmt.Println("Dry run: nothing was changed")
+		return nil
+	}
+	return plan.Apply()
+}
+
+// expandPaths replaces each directory argument with its entries, since the
+// directory itself is a collection rather than one game.
+func expandPaths(args []string) ([]string, error) {
+	var paths []string
+	for _, arg := range args {
+		info, err := os.Stat(arg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat %s: %w", arg, err)
+		}
+		if !info.IsDir() {
+			paths = append(paths, arg)
+			continue
+		}
+		entries, err := os.ReadDir(arg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", arg, err)
+		}
+		for _, e := range entries {
+			if !strings.HasPrefix(e.Name(), ".") {
+				paths = append(paths, filepath.Join(arg, e.Name()))
+			}
+		}
+	}
+	return paths, nil
+}
+
+func outputPlan(plan *rebuild.Plan) {
+	for _, action := range plan.Actions {
+		var line string
+		switch action.Kind {
+		case rebuild.ActionRenameEntry:
+			line = fmt.Sprintf("Rename %s: %s -> %s", action.Path, action.Entry, action.Target)
+		default:
+			line = fmt.Sprintf("Move %s -> %s", action.Path, action.Target)
+		}
+		switch {
+		case action.Skip != "":
+			line += fmt.Sprintf(" (skipped: %s)", action.Skip)
+		case action.Overwrite:
+			line += " (overwrite)"
+		}
+		fmt.Println(line)
+	}
+	for _, path := range plan.Unmatched {
+		fmt.Printf("Unmatched: %s\n", path)
+	}
+	if len(plan.Actions) == 0 {
+		fmt.Println("Nothing to rename")
+	}
+}
@@ -0,0 +1,305 @@
+package rename
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/sargunv/rom-tools/lib/datfile"
+	"github.com/sargunv/rom-tools/lib/esde"
+	romident "github.com/sargunv/rom-tools/lib/identify"
+	"github.com/sargunv/rom-tools/lib/rename"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	template  string
+	datPaths  []string
+	gamelists []string
+	dryRun    bool
+	logPath   string
+	undo      bool
+)
+
+var Cmd = &cobra.Command{
+	Use:   "rename <path>...",
+	Short: "Rename ROMs after a naming template",
+	Long: `Rename ROM files, archives, and game folders after a naming template, filled
+in from the DAT entries they match or from scraped metadata.
+
+Each path may be a ROM file, an archive, or a folder holding one game. A
+directory of those is renamed entry by entry. Files are renamed within their
+folder; those that match nothing are left as they are.
+
+Fields are written in braces and matched ignoring case:
+- {Name}: the full DAT or scraped name
+- {Title}: the name without tags, or the scraped title
+- {Regions}, {Languages}, {Revision}, {Disc}: from the tags of the DAT name,
+  or of the current file name for scraped metadata
+- {Year}, {Developer}, {Publisher}, {Genre}, {Players}: scraped metadata
+- {ext}: the current extension, without the dot
+
+A part in parentheses or brackets is left out when all its fields are empty,
+so "(Rev {Revision})" only appears for revisions. Characters that aren't
+allowed in file names are replaced or removed.
+
+With --dat, files are named after the DAT entries they match. With
+--gamelist, they're named after their scraped metadata in an ES-DE
+gamelist.xml (as written by "rom-tools scrape") in the folder of the files
+or a parent folder, and the gamelist is updated with the new names. With
+both, scraped metadata fills in the fields that the DAT match doesn't have.
+
+Each rename is recorded in the undo log as it happens; "rename --undo"
+reverses the renames in it.`,
+	Example: `  # Show how ROMs would be renamed after their DAT entries
+  rom-tools rename --dat "Nintendo - Game Boy.dat" --dry-run roms/gb
+
+  # Rename ROMs after their scraped metadata
+  rom-tools rename --gamelist roms/gb/gamelist.xml \
+      --template "{Title} ({Year}) ({Regions}).{ext}" roms/gb
+
+  # Undo the renames
+  rom-tools rename --undo`,
+	RunE: runRename,
+}
+
+func init() {
+	Cmd.Flags().StringVarP(&template, "template", "t", rename.DefaultTemplate, "Naming template")
+	Cmd.Flags().StringArrayVar(&datPaths, "dat", nil, "DAT file to match against (repeatable)")
+	Cmd.Flags().StringArrayVar(&gamelists, "gamelist", nil, "ES-DE gamelist.xml with scraped metadata (repeatable)")
+	Cmd.Flags().BoolVarP(&dryRun, "dry-run", "n", false, "Show what would be renamed without changing anything")
+	Cmd.Flags().StringVar(&logPath, "log", "rom-tools-rename.jsonl", "Undo log to record renames in, or to undo with --undo")
+	Cmd.Flags().BoolVar(&undo, "undo", false, "Undo the renames recorded in the undo log")
+}
+
+func runRename(cmd *cobra.Command, args []string) error {
+	if undo {
+		if len(args) > 0 {
+			return fmt.Errorf("--undo takes no paths")
+		}
+		cmd.SilenceUsage = true
+		return runUndo()
+	}
+
+	if len(args) == 0 {
+		return fmt.Errorf("requires at least 1 path")
+	}
+	if len(datPaths) == 0 && len(gamelists) == 0 {
+		return fmt.Errorf("--dat or --gamelist required")
+	}
+	tmpl, err := rename.ParseTemplate(template)
+	if err != nil {
+		return err
+	}
+	cmd.SilenceUsage = true
+
+	var matcher *romident.Matcher
+	if len(datPaths) > 0 {
+		index := romident.NewDATIndex()
+		for _, datPath := range datPaths {
+			dat, err := datfile.Parse(datPath)
+			if err != nil {
+				return fmt.Errorf("failed to load DAT %s: %w", datPath, err)
+			}
+			index.Add("", dat)
+		}
+		matcher = romident.NewMatcher(index)
+	}
+
+	scraped, err := loadGamelists(gamelists)
+	if err != nil {
+		return err
+	}
+
+	paths, err := expandPaths(args)
+	if err != nil {
+		return err
+	}
+
+	// Identification is only needed to match DATs, whose entries are named
+	// after the files of archives, not their nested archives
+	opts := romident.DefaultOptions()
+	opts.MaxArchiveDepth = 0
+
+	var files []rename.File
+	var unmatched []string
+	for _, path := range paths {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		file := rename.File{Path: abs}
+
+		if matcher != nil {
+			result, err := romident.Identify(abs, opts)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: failed to identify %s: %v\n", path, err)
+				continue
+			}
+			matcher.Annotate(result)
+			if game := matchedGame(result); game != "" {
+				file.Fields = rename.NameFields(game)
+			}
+		}
+
+		if entry, ok := scraped[abs]; ok {
+			file.Gamelist, file.GamelistPath = entry.gamelist, entry.game.Path
+			file.Fields = scrapedFields(file.Fields, abs, entry.game)
+		}
+
+		if file.Fields == nil {
+			unmatched = append(unmatched, path)
+			continue
+		}
+		files = append(files, file)
+	}
+
+	renames := rename.Plan(files, tmpl)
+	outputRenames(renames, unmatched)
+	if dryRun {
+		fmt.Println("Dry run: nothing was changed")
+		return nil
+	}
+	if err := rename.Apply(renames, logPath); err != nil {
+		return err
+	}
+	for _, r := range renames {
+		if r.Skip == "" {
+			fmt.Printf("Undo with: rom-tools rename --undo --log %s\n", logPath)
+			break
+		}
+	}
+	return nil
+}
+
+func runUndo() error {
+	renames, err := rename.Undo(logPath)
+	outputRenames(renames, nil)
+	return err
+}
+
+// scrapedEntry is a game of a gamelist, and the gamelist it's in.
+type scrapedEntry struct {
+	game     esde.Game
+	gamelist string
+}
+
+// loadGamelists indexes the games of gamelists by their ROM's absolute
+// path, taking paths relative to the gamelist's folder.
+func loadGamelists(paths []string) (map[string]scrapedEntry, error) {
+	entries := make(map[string]scrapedEntry)
+	for _, path := range paths {
+		path, err := filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read gamelist: %w", err)
+		}
+		list, err := esde.Parse(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse gamelist %s: %w", path, err)
+		}
+		for _, game := range list.Games {
+			romPath := filepath.Join(filepath.Dir(path), filepath.FromSlash(game.Path))
+			entries[romPath] = scrapedEntry{game: game, gamelist: path}
+		}
+	}
+	return entries, nil
+}
+
+// scrapedFields adds the fields of a scraped game to those of its DAT
+// match, if any, or else to those of the tags of its file name.
+func scrapedFields(fields rename.Fields, path string, game esde.Game) rename.Fields {
+	if fields == nil {
+		base := filepath.Base(path)
+		fields = rename.NameFields(strings.TrimSuffix(base, filepath.Ext(base)))
+		fields["name"], fields["title"] = game.Name, game.Name
+	}
+	set := func(key, value string) {
+		if fields[key] == "" {
+			fields[key] = value
+		}
+	}
+	set("name", game.Name)
+	set("title", game.Name)
+	if !game.ReleaseDate.IsZero() {
+		set("year", strconv.Itoa(game.ReleaseDate.Year()))
+	}
+	set("developer", game.Developer)
+	set("publisher", game.Publisher)
+	set("genre", game.Genre)
+	if game.Players > 0 {
+		set("players", strconv.Itoa(game.Players))
+	}
+	return fields
+}
+
+// matchedGame returns the DAT game a file, archive, or folder matched: the
+// DAT set it matched, or the game all its matched files belong to. Returns
+// "" if its files match several games or none.
+func matchedGame(result *romident.Result) string {
+	if result.Set != nil {
+		return result.Set.Set
+	}
+	game := ""
+	for _, item := range result.Items {
+		if item.Match == nil || item.Match.Status == romident.MatchStatusUnknown {
+			continue
+		}
+		if game != "" && item.Match.Game != game {
+			return ""
+		}
+		game = item.Match.Game
+	}
+	return game
+}
+
+// expandPaths replaces each directory argument with its entries, since the
+// directory itself is a collection rather than one game.
+func expandPaths(args []string) ([]string, error) {
+	var paths []string
+	for _, arg := range args {
+		info, err := os.Stat(arg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat %s: %w", arg, err)
+		}
+		if !info.IsDir() {
+			paths = append(paths, arg)
+			continue
+		}
+		entries, err := os.ReadDir(arg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", arg, err)
+		}
+		for _, e := range entries {
+			if !strings.HasPrefix(e.Name(), ".") {
+				paths = append(paths, filepath.Join(arg, e.Name()))
+			}
+		}
+	}
+	return paths, nil
+}
+
+func outputRenames(renames []rename.Rename, unmatched []string) {
+	for _, r := range renames {
+		line := fmt.Sprintf("Rename %s", r.From)
+		if r.To != "" {
+			line += " -> " + filepath.Base(r.To)
+		}
+		if r.Skip != "" {
+			line += fmt.Sprintf(" (skipped: %s)", r.Skip)
+		}
+		fmt.Println(line)
+	}
+	for _, path := range unmatched {
+		fmt.Printf("Unmatched: %s\n", path)
+	}
+	if len(renames) == 0 {
+		fmt.Println("Nothing to rename")
+	}
+}
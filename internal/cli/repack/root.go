@@ -0,0 +1,87 @@
+package repack
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/sargunv/rom-tools/lib/repack"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	dest          string
+	dryRun        bool
+	deleteSources bool
+)
+
+var Cmd = &cobra.Command{
+	Use:   "repack <path>...",
+	Short: "Repack ROMs into one format per kind of ROM",
+	Long: `Rewrite ROMs and disc images into a consistent, deterministic format.
+
+Each path may be a file or a directory, which is searched recursively.
+
+- Cartridge ROMs are stored in TorrentZip .zip archives, one per ROM
+- .zip archives are rewritten as TorrentZip archives, unless they already are
+- BIN/CUE and GDI images become CD and GD-ROM CHDs (cdlz,cdzl)
+- ISO images become DVD CHDs (lzma,zlib); GameCube, Wii, and Xbox ISOs are
+  skipped, since CHD can't hold them
+- .chd, .rvz, .wia, and other formats that are already the preferred ones for
+  their platform are left as they are
+
+7z archives aren't written. Each repacked file is read back and its contents
+checked against its sources before it's moved into place, and with --delete
+the sources are removed only after that check passes.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runRepack,
+}
+
+func init() {
+	Cmd.Flags().StringVarP(&dest, "dest", "d", "", "Directory to write repacked files to (default: beside their sources)")
+	Cmd.Flags().BoolVarP(&dryRun, "dry-run", "n", false, "Show what would be repacked without changing anything")
+	Cmd.Flags().BoolVar(&deleteSources, "delete", false, "Delete source files once their repacked files are verified")
+}
+
+func runRepack(cmd *cobra.Command, args []string) error {
+	var err error
+	if dest != "" {
+		if dest, err = filepath.Abs(dest); err != nil {
+			return fmt.Errorf("failed to resolve --dest: %w", err)
+		}
+	}
+	plan, err := repack.NewPlan(args, repack.Options{Dest: dest, DeleteSources: deleteSources})
+	if err != nil {
+		return err
+	}
+
+	outputPlan(plan)
+	if dryRun {
+		fmt.Println("Dry run: nothing was changed")
+		return nil
+	}
+	return plan.Apply()
+}
+
+func outputPlan(plan *repack.Plan) {
+	n := 0
+	for _, item := range plan.Items {
+		if item.Kind == repack.KindPassthrough && item.Skip == "" {
+			fmt.Printf("Keep %s\n", item.Path)
+			continue
+		}
+		line := fmt.Sprintf("Repack %s -> %s (%s)", item.Path, item.Target, item.Kind)
+		if item.Kind == repack.KindPassthrough {
+			line = "Ignore " + item.Path
+		}
+		if item.Skip != "" {
+			line += fmt.Sprintf(" (skipped: %s)", item.Skip)
+		} else {
+			n++
+		}
+		fmt.Println(line)
+	}
+	if n == 0 {
+		fmt.Println("Nothing to repack")
+	}
+}
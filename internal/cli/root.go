@@ -2,9 +2,17 @@ package cli
 
 import (
 	"github.com/sargunv/rom-tools/internal/cli/cache"
+	"github.com/sargunv/rom-tools/internal/cli/convert"
 	"github.com/sargunv/rom-tools/internal/cli/identify"
+	"github.com/sargunv/rom-tools/internal/cli/patch"
+	"github.com/sargunv/rom-tools/internal/cli/playlist"
+	"github.com/sargunv/rom-tools/internal/cli/rebuild"
+	"github.com/sargunv/rom-tools/internal/cli/rename"
+	"github.com/sargunv/rom-tools/internal/cli/repack"
 	"github.com/sargunv/rom-tools/internal/cli/scrape"
 	"github.com/sargunv/rom-tools/internal/cli/screenscraper"
+	"github.com/sargunv/rom-tools/internal/cli/serve"
+	"github.com/sargunv/rom-tools/internal/cli/verify"
 
 	"github.com/spf13/cobra"
 )
@@ -17,9 +25,17 @@ var rootCmd = &cobra.Command{
 
 func init() {
 	rootCmd.AddCommand(cache.Cmd)
+	rootCmd.AddCommand(convert.Cmd)
 	rootCmd.AddCommand(identify.Cmd)
+	rootCmd.AddCommand(patch.Cmd)
+	rootCmd.AddCommand(playlist.Cmd)
+	rootCmd.AddCommand(rebuild.Cmd)
+	rootCmd.AddCommand(rename.Cmd)
+	rootCmd.AddCommand(repack.Cmd)
 	rootCmd.AddCommand(scrape.Cmd)
 	rootCmd.AddCommand(screenscraper.Cmd)
+	rootCmd.AddCommand(serve.Cmd)
+	rootCmd.AddCommand(verify.Cmd)
 }
 
 func Execute() error {
@@ -0,0 +1,193 @@
+package scrape
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sargunv/rom-tools/internal/scraper"
+	"github.com/sargunv/rom-tools/lib/hashcache"
+	romident "github.com/sargunv/rom-tools/lib/identify"
+)
+
+var (
+	journalPath string
+	restart     bool
+)
+
+var batchCmd = &cobra.Command{
+	Use:   "batch <dir>",
+	Short: "Scrape a ROM library folder, resuming interrupted runs",
+	Long: `Identify the games of a ROM library folder and scrape their metadata and media.
+
+The folder is searched recursively. Each game file (a ROM, an archive, or a
+control file like a .cue sheet, but not the tracks it lists) is looked up by
+its hashes, and then by its name, in the Screenscraper system of its
+identified platform, or in --system if it's given.
+
+- Games are named after their paths in the folder, so the gamelist and
+  media of games in subfolders keep those subfolders
+- Hashes are kept in the hash cache of 'rom-tools identify --hash-cache', so
+  only new and changed files are hashed again
+- Each finished game is recorded in a journal (by default,
+  .rom-tools-scrape.jsonl in the folder); run the same command again to
+  resume an interrupted scrape, skipping games found or not found before,
+  or use --restart to scrape them all again
+- Games are the files that 'rom-tools identify' marks as primary, so
+  unidentified files (e.g., headerless ROMs) are only scraped if nothing
+  else in the folder is identified
+- Games whose platform has no Screenscraper system are skipped
+- Media is chosen as in 'rom-tools scrape', by --regions, --media-source,
+  --min-width, and --min-height, and composed into miximages with
+  --miximage`,
+	Example: `  # Scrape a folder of Game Boy Advance ROMs to ES-DE format
+  rom-tools scrape batch ./roms/gba \
+      --esde-gamelist ./roms/gba/gamelist.xml \
+      --esde-media ./roms/gba/media`,
+	Args: cobra.ExactArgs(1),
+	RunE: runBatch,
+}
+
+func init() {
+	batchCmd.Flags().StringVarP(&systemName, "system", "s", "", "System name or ID to look up every game in (default: each game's platform)")
+	batchCmd.Flags().StringVar(&esdeGamelist, "esde-gamelist", "", "Path for ES-DE gamelist.xml")
+	batchCmd.Flags().StringVar(&esdeMedia, "esde-media", "", "Path for ES-DE media folder")
+	batchCmd.Flags().StringSliceVarP(&mediaTypes, "media", "m", scraper.DefaultMediaTypes(),
+		"Media types to download: screenshots,titlescreens,covers,3dboxes,marquees,fanart,videos,physicalmedia,backcovers")
+	addMediaPolicyFlags(batchCmd)
+	addMiximageFlag(batchCmd)
+	batchCmd.Flags().StringSliceVarP(&regions, "regions", "r", []string{"us", "eu", "jp"},
+		"Preferred regions in order")
+	batchCmd.Flags().DurationVar(&cacheAge, "cache-age", 720*time.Hour, "Maximum cache age (default 30 days)")
+	batchCmd.Flags().BoolVar(&noCache, "no-cache", false, "Don't read from cache (still writes to cache)")
+	batchCmd.Flags().BoolVar(&overwrite, "overwrite", false, "Overwrite existing media files and gamelist entries")
+	batchCmd.Flags().IntVar(&threadsLimit, "threads", 0, "Max concurrent API requests (0 = use account limit)")
+	batchCmd.Flags().StringVar(&journalPath, "journal", "", "Path of the progress journal (default: .rom-tools-scrape.jsonl in the folder)")
+	batchCmd.Flags().BoolVar(&restart, "restart", false, "Discard the journal and scrape every game again")
+	batchCmd.Flags().BoolVarP(&jsonOutput, "json", "j", false, "Output final results as JSON")
+	Cmd.AddCommand(batchCmd)
+}
+
+func runBatch(cmd *cobra.Command, args []string) error {
+	dir := args[0]
+	if info, err := os.Stat(dir); err != nil {
+		return err
+	} else if !info.IsDir() {
+		return fmt.Errorf("%s is not a folder", dir)
+	}
+
+	var systemID string
+	if systemName != "" {
+		id, err := scraper.LookupSystemID(systemName)
+		if err != nil {
+			return err
+		}
+		systemID = id
+	}
+
+	if esdeGamelist == "" && esdeMedia == "" {
+		return fmt.Errorf("at least one output target is required (--esde-gamelist, --esde-media)")
+	}
+	esdeGamelist = normalizeGamelistPath(esdeGamelist)
+	policy, err := mediaPolicy()
+	if err != nil {
+		return err
+	}
+	layout, err := miximageLayout()
+	if err != nil {
+		return err
+	}
+	if journalPath == "" {
+		journalPath = filepath.Join(dir, ".rom-tools-scrape.jsonl")
+	}
+
+	cmd.SilenceUsage = true
+
+	ctx, cancel := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	journal, err := scraper.OpenJournal(journalPath, restart)
+	if err != nil {
+		return err
+	}
+	defer journal.Close()
+
+	entries, skipped, err := identifyLibrary(ctx, dir, systemID)
+	if err != nil {
+		return err
+	}
+	for _, result := range skipped {
+		if !journal.Done(result.Entry.Name) {
+			fmt.Fprintf(os.Stderr, "Skipped %s: %s\n", result.Entry.Name, result.Reason)
+		}
+	}
+
+	// Leave out the games finished by earlier runs
+	var toScrape []*scraper.LookupEntry
+	for _, entry := range entries {
+		if !journal.Done(entry.Name) {
+			toScrape = append(toScrape, entry)
+		}
+	}
+	fmt.Printf("Found %d games in %s\n", len(entries), dir)
+	if done := len(entries) - len(toScrape); done > 0 {
+		fmt.Printf("Resuming: %d already scraped, %d to go\n", done, len(toScrape))
+	}
+
+	client, limits, err := connect(ctx)
+	if err != nil {
+		return err
+	}
+	diskCache, err := openCache()
+	if err != nil {
+		return err
+	}
+
+	config := newConfig(limits, policy)
+	config.SystemID = systemID
+	config.Miximage = layout
+	config.Journal = journal
+	s := scraper.New(client, diskCache, config)
+
+	fmt.Printf("Using %d threads, %d req/min\n\n", config.MaxThreads, config.MaxRequestsPerMin)
+
+	results, err := run(ctx, cancel, s, len(toScrape), func(ctx context.Context) (*scraper.ScrapeResults, error) {
+		return s.ScrapeEntries(ctx, toScrape)
+	})
+	if err != nil {
+		return err
+	}
+
+	return finish(ctx, s, results)
+}
+
+// identifyLibrary identifies the games of dir, using the hash cache
+func identifyLibrary(ctx context.Context, dir, systemID string) ([]*scraper.LookupEntry, []*scraper.ScrapeResult, error) {
+	opts := romident.DefaultOptions()
+	path, err := hashcache.DefaultPath()
+	if err != nil {
+		return nil, nil, err
+	}
+	hashCache, err := hashcache.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer hashCache.Close()
+	opts.HashCache = hashCache
+
+	fmt.Printf("Identifying %s...", dir)
+	result, err := romident.IdentifyContext(ctx, dir, opts)
+	fmt.Print("\r\033[K") // Clear the line
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to identify %s: %w", dir, err)
+	}
+
+	entries, skipped := scraper.LibraryEntries(result, systemID)
+	return entries, skipped, nil
+}
@@ -0,0 +1,189 @@
+package scrape
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sargunv/rom-tools/internal/scraper"
+	"github.com/sargunv/rom-tools/lib/igdb"
+	"github.com/sargunv/rom-tools/lib/thegamesdb"
+)
+
+var (
+	backendNames []string
+	libretroDB   string
+)
+
+var romCmd = &cobra.Command{
+	Use:   "rom <path>",
+	Short: "Look up the metadata of a single ROM",
+	Long: `Identify a ROM and look up its game's metadata.
+
+The ROM is looked up in each of --backend in turn, until one finds its game.
+A backend without credentials, or that fails (e.g., because it doesn't
+support the ROM's platform or your daily quota is used up), is passed over.
+
+- screenscraper: looked up by hashes, then by file name, in --system or the
+  identified platform's system; needs SCREENSCRAPER_DEV_USER and
+  SCREENSCRAPER_DEV_PASSWORD, and optionally SCREENSCRAPER_ID and
+  SCREENSCRAPER_PASSWORD
+- igdb: looked up by the title in the file name, within the identified
+  platform; needs IGDB_CLIENT_ID and IGDB_CLIENT_SECRET, the client
+  credentials of a Twitch application
+- thegamesdb: looked up like igdb; needs THEGAMESDB_API_KEY
+- libretro: looked up offline by hashes, serial, then file name, in the
+  libretro database (.rdb file) of the identified platform in --libretro-db,
+  e.g. RetroArch's database/rdb folder`,
+	Example: `  # Look up a ROM on Screenscraper
+  rom-tools scrape rom "Tetris (World) (Rev 1).gb"
+
+  # Fall back to IGDB, then TheGamesDB
+  rom-tools scrape rom "Tetris (World) (Rev 1).gb" --backend screenscraper,igdb,thegamesdb
+
+  # Look up a ROM offline, in RetroArch's databases
+  rom-tools scrape rom "Tetris (World) (Rev 1).gb" --backend libretro \
+      --libretro-db ~/.config/retroarch/database/rdb`,
+	Args: cobra.ExactArgs(1),
+	RunE: runROM,
+}
+
+func init() {
+	romCmd.Flags().StringSliceVarP(&backendNames, "backend", "b", []string{"screenscraper"},
+		"Backends to look the ROM up in, in order: screenscraper,igdb,thegamesdb,libretro")
+	romCmd.Flags().StringVar(&libretroDB, "libretro-db", "", "Folder of libretro databases (.rdb files) for the libretro backend")
+	romCmd.Flags().StringVarP(&systemName, "system", "s", "", "Screenscraper system name or ID (default: the ROM's platform)")
+	romCmd.Flags().StringSliceVarP(&regions, "regions", "r", []string{"us", "eu", "jp"},
+		"Preferred regions in order")
+	romCmd.Flags().DurationVar(&cacheAge, "cache-age", 720*time.Hour, "Maximum cache age (default 30 days)")
+	romCmd.Flags().BoolVar(&noCache, "no-cache", false, "Don't read from cache (still writes to cache)")
+	romCmd.Flags().BoolVarP(&jsonOutput, "json", "j", false, "Output results as JSON")
+	Cmd.AddCommand(romCmd)
+}
+
+func runROM(cmd *cobra.Command, args []string) error {
+	var systemID string
+	if systemName != "" {
+		id, err := scraper.LookupSystemID(systemName)
+		if err != nil {
+			return err
+		}
+		systemID = id
+	}
+	for _, name := range backendNames {
+		switch name {
+		case "screenscraper", "igdb", "thegamesdb", "libretro":
+		default:
+			return fmt.Errorf("unknown backend %q (want screenscraper, igdb, thegamesdb, or libretro)", name)
+		}
+	}
+
+	cmd.SilenceUsage = true
+
+	ctx, cancel := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	var backends scraper.Fallback
+	for _, name := range backendNames {
+		backend, err := newBackend(ctx, name, systemID)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Skipping %s: %v\n", name, err)
+			continue
+		}
+		backends = append(backends, backend)
+	}
+	if len(backends) == 0 {
+		return fmt.Errorf("no backend available")
+	}
+
+	md, err := backends.ScrapeROM(ctx, args[0])
+	if errors.Is(err, scraper.ErrNotFound) {
+		return fmt.Errorf("no game found for %s", args[0])
+	}
+	if err != nil {
+		return err
+	}
+
+	if jsonOutput {
+		data, err := json.MarshalIndent(md, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	printMetadata(md)
+	return nil
+}
+
+// newBackend creates the named backend, with credentials from environment
+// variables and databases from flags
+func newBackend(ctx context.Context, name, systemID string) (scraper.Backend, error) {
+	switch name {
+	case "igdb":
+		clientID, clientSecret := os.Getenv("IGDB_CLIENT_ID"), os.Getenv("IGDB_CLIENT_SECRET")
+		if clientID == "" || clientSecret == "" {
+			return nil, fmt.Errorf("igdb credentials required: set IGDB_CLIENT_ID and IGDB_CLIENT_SECRET")
+		}
+		return scraper.NewIGDB(igdb.NewClient(clientID, clientSecret)), nil
+
+	case "thegamesdb":
+		apiKey := os.Getenv("THEGAMESDB_API_KEY")
+		if apiKey == "" {
+			return nil, fmt.Errorf("thegamesdb credentials required: set THEGAMESDB_API_KEY")
+		}
+		return scraper.NewTheGamesDB(thegamesdb.NewClient(apiKey)), nil
+
+	case "libretro":
+		if libretroDB == "" {
+			return nil, fmt.Errorf("libretro databases required: set --libretro-db")
+		}
+		return scraper.NewLibretro(libretroDB), nil
+
+	default:
+		client, limits, err := connect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		diskCache, err := openCache()
+		if err != nil {
+			return nil, err
+		}
+		config := newConfig(limits, scraper.MediaPolicy{})
+		config.SystemID = systemID
+		return scraper.New(client, diskCache, config), nil
+	}
+}
+
+// printMetadata prints the fields of metadata that are set
+func printMetadata(md *scraper.Metadata) {
+	field := func(label, value string) {
+		if value != "" {
+			fmt.Printf("%-12s %s\n", label+":", value)
+		}
+	}
+	field("Name", md.Name)
+	field("Source", fmt.Sprintf("%s (game %s)", md.Source, md.ID))
+	field("Developer", md.Developer)
+	field("Publisher", md.Publisher)
+	if !md.ReleaseDate.IsZero() {
+		field("Released", md.ReleaseDate.Format(time.DateOnly))
+	}
+	field("Genres", strings.Join(md.Genres, ", "))
+	field("Players", md.Players)
+	if md.Rating > 0 {
+		field("Rating", fmt.Sprintf("%.0f%%", md.Rating*100))
+	}
+	if md.Description != "" {
+		fmt.Printf("\n%s\n", md.Description)
+	}
+}
@@ -1,12 +1,12 @@
 package scrape
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
 	"os/signal"
 	"path/filepath"
-	"strconv"
 	"strings"
 	"syscall"
 	"time"
@@ -19,6 +19,8 @@ import (
 	"github.com/sargunv/rom-tools/internal/scraper"
 	"github.com/sargunv/rom-tools/internal/scraper/output/esde"
 	"github.com/sargunv/rom-tools/lib/datfile"
+	miximagelib "github.com/sargunv/rom-tools/lib/miximage"
+	"github.com/sargunv/rom-tools/lib/screenscraper"
 )
 
 var (
@@ -32,7 +34,11 @@ var (
 	esdeMedia    string
 
 	// Media
-	mediaTypes []string
+	mediaTypes   []string
+	mediaSources []string
+	minWidth     int
+	minHeight    int
+	miximage     string
 
 	// Regions
 	regions []string
@@ -70,6 +76,22 @@ Scans the input (DAT file or ROM directory), identifies games using hashes,
 fetches metadata from Screenscraper, downloads media files, and generates
 output in the specified format(s).
 
+Requests are limited to your account's threads and requests per minute.
+When Screenscraper rate limits them or closes the API to your account's
+level, they back off and are retried; when your daily quota runs out, the
+scrape stops and the remaining games are left for another day.
+
+Media is taken from the game's regions, then --regions, falling back from
+each region to the ones containing it (e.g., Germany to Europe to World).
+Each media type is downloaded from Screenscraper media types in priority
+order (e.g., marquees from wheel-hd, then wheel), which --media-source
+overrides. Images smaller than --min-width or --min-height are passed over
+for the next region or Screenscraper media type.
+
+With --miximage, a miximage is also composed of each game's screenshot (or
+title screen), box (3D, or else 2D), and marquee, for those of them in
+--media, and saved to the miximages media folder.
+
 Example:
   # Scrape from DAT file to ES-DE format
   rom-tools scrape --system megadrive --dat megadrive.dat \
@@ -83,6 +105,17 @@ Example:
       --media screenshots,covers,3dboxes,marquees,videos \
       --regions jp,us,eu
 
+  # Prefer carbon wheels for marquees, and skip small screenshots
+  rom-tools scrape --system snes --dat snes.dat \
+      --esde-media ./snes/media \
+      --media-source marquees=wheel-carbon,wheel-hd,wheel \
+      --min-width 512
+
+  # Also compose miximages of the screenshots, covers, and marquees
+  rom-tools scrape --system snes --dat snes.dat \
+      --esde-media ./snes/media \
+      --miximage standard
+
   # Dry run to see what would be scraped
   rom-tools scrape --system snes --dat snes.dat --dry-run
 
@@ -105,6 +138,8 @@ func init() {
 	// Media flags
 	Cmd.Flags().StringSliceVarP(&mediaTypes, "media", "m", scraper.DefaultMediaTypes(),
 		"Media types to download: screenshots,titlescreens,covers,3dboxes,marquees,fanart,videos,physicalmedia,backcovers")
+	addMediaPolicyFlags(Cmd)
+	addMiximageFlag(Cmd)
 
 	// Region flags
 	Cmd.Flags().StringSliceVarP(&regions, "regions", "r", []string{"us", "eu", "jp"},
@@ -177,8 +212,11 @@ func runScrape(cmd *cobra.Command, args []string) error {
 	// Normalize gamelist path
 	esdeGamelist = normalizeGamelistPath(esdeGamelist)
 
-	// Initialize client from environment variables
-	client, err := shared.NewClientFromEnv("rom-tools")
+	policy, err := mediaPolicy()
+	if err != nil {
+		return err
+	}
+	layout, err := miximageLayout()
 	if err != nil {
 		return err
 	}
@@ -186,74 +224,25 @@ func runScrape(cmd *cobra.Command, args []string) error {
 	// Validation complete - don't show help for errors from here on
 	cmd.SilenceUsage = true
 
-	// Note: HTTP timeout is configured at client creation time in the library
-
 	// Set up signal handling early so we can cancel during setup if needed
 	ctx, cancel := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
 	defer cancel()
 
-	// Get user info for rate limits
-	fmt.Print("Connecting to Screenscraper...")
-	userInfoResp, err := client.GetUserInfoWithResponse(ctx)
-	fmt.Print("\r\033[K") // Clear the line
-	if err != nil {
-		return fmt.Errorf("failed to get user info: %w", err)
-	}
-
-	if userInfoResp.JSON200 == nil || userInfoResp.JSON200.Response.User.Id == "" {
-		return fmt.Errorf("failed to get user info: invalid response")
-	}
-
-	userInfo := userInfoResp.JSON200.Response.User
-	maxThreadsStr := userInfo.MaxThreads
-	maxReqPerMinStr := userInfo.MaxRequestsPerMin
-	maxThreads, _ := strconv.Atoi(maxThreadsStr)
-	maxReqPerMin, _ := strconv.Atoi(maxReqPerMinStr)
-
-	if maxThreads == 0 {
-		maxThreads = 1
-	}
-	if maxReqPerMin == 0 {
-		maxReqPerMin = 60
-	}
-
-	// Apply user-specified thread limit
-	if threadsLimit > 0 && threadsLimit < maxThreads {
-		maxThreads = threadsLimit
-	}
-
-	// Initialize cache
-	cacheDir, err := cache.DefaultCacheDir()
+	client, limits, err := connect(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to get cache directory: %w", err)
-	}
-
-	cacheMode := cache.ModeNormal
-	if noCache {
-		cacheMode = cache.ModeNoRead
-	} else if cacheOnly {
-		cacheMode = cache.ModeReadOnly
+		return err
 	}
-
-	diskCache, err := cache.New(cacheDir, cacheAge, cacheMode)
+	diskCache, err := openCache()
 	if err != nil {
-		return fmt.Errorf("failed to initialize cache: %w", err)
+		return err
 	}
 
 	// Build config
-	config := &scraper.Config{
-		SystemID:          systemID,
-		MediaTypes:        mediaTypes,
-		PreferredRegions:  regions,
-		MediaOutputDir:    esdeMedia,
-		SkipCacheRead:     noCache,
-		SkipCacheWrite:    cacheOnly,
-		Overwrite:         overwrite,
-		MaxThreads:        maxThreads,
-		MaxRequestsPerMin: maxReqPerMin,
-		Filter:            filter,
-		FilterConfig:      filterConfig,
-	}
+	config := newConfig(limits, policy)
+	config.SystemID = systemID
+	config.Miximage = layout
+	config.Filter = filter
+	config.FilterConfig = filterConfig
 
 	// Create scraper
 	s := scraper.New(client, diskCache, config)
@@ -287,44 +276,165 @@ func runScrape(cmd *cobra.Command, args []string) error {
 		fmt.Printf("Filter: %s\n", filterExpr)
 		fmt.Printf("To scrape: %d (filtered out: %d)\n", toScrape, totalInDat-toScrape)
 	}
-	fmt.Printf("Using %d threads, %d req/min\n\n", maxThreads, maxReqPerMin)
+	fmt.Printf("Using %d threads, %d req/min\n\n", config.MaxThreads, config.MaxRequestsPerMin)
 
 	// Use filtered count for progress tracking
-	total := toScrape
-
-	// Run with TUI if terminal, otherwise simple output
-	var results *scraper.ScrapeResults
-
-	if !jsonOutput && isTerminal() {
-		// Create and run TUI
-		model := scraper.NewModel(total, maxThreads, len(mediaTypes), s.Updates(), s.RateLimiterStats)
-
-		// Run scraper in background
-		resultsChan := make(chan *scraper.ScrapeResults, 1)
-		go func() {
-			res, _ := s.ScrapeFromDAT(ctx, datPath)
-			resultsChan <- res
-		}()
-
-		// Run TUI with context so it exits on Ctrl+C
-		p := tea.NewProgram(model, tea.WithContext(ctx))
-		if _, err := p.Run(); err != nil && ctx.Err() == nil {
-			return fmt.Errorf("TUI error: %w", err)
-		}
+	results, err := run(ctx, cancel, s, toScrape, func(ctx context.Context) (*scraper.ScrapeResults, error) {
+		return s.ScrapeFromDAT(ctx, datPath)
+	})
+	if err != nil {
+		return err
+	}
 
-		// Cancel context to stop scraper if TUI exited early (user pressed 'q')
-		cancel()
+	return finish(ctx, s, results)
+}
 
-		// Wait for scraper to finish (should be quick after cancellation)
-		results = <-resultsChan
-	} else {
+// connect creates a client from environment variables, and gets the limits
+// of the account
+func connect(ctx context.Context) (*screenscraper.ScreenscraperClient, scraper.Limits, error) {
+	client, err := shared.NewClientFromEnv("rom-tools")
+	if err != nil {
+		return nil, scraper.Limits{}, err
+	}
+
+	// Get user info for rate limits
+	fmt.Print("Connecting to Screenscraper...")
+	userInfoResp, err := client.GetUserInfoWithResponse(ctx)
+	fmt.Print("\r\033[K") // Clear the line
+	if err != nil {
+		return nil, scraper.Limits{}, fmt.Errorf("failed to get user info: %w", err)
+	}
+
+	if userInfoResp.JSON200 == nil || userInfoResp.JSON200.Response.User.Id == "" {
+		return nil, scraper.Limits{}, fmt.Errorf("failed to get user info: invalid response")
+	}
+
+	limits := scraper.LimitsFromUser(userInfoResp.JSON200.Response.User)
+	if limits.RequestsLeftToday == 0 {
+		return nil, scraper.Limits{}, scraper.ErrQuotaExceeded
+	}
+
+	// Apply user-specified thread limit
+	if threadsLimit > 0 && threadsLimit < limits.MaxThreads {
+		limits.MaxThreads = threadsLimit
+	}
+	return client, limits, nil
+}
+
+// openCache opens the cache in the default directory, per the cache flags
+func openCache() (*cache.DiskCache, error) {
+	cacheDir, err := cache.DefaultCacheDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cache directory: %w", err)
+	}
+
+	cacheMode := cache.ModeNormal
+	if noCache {
+		cacheMode = cache.ModeNoRead
+	} else if cacheOnly {
+		cacheMode = cache.ModeReadOnly
+	}
+
+	diskCache, err := cache.New(cacheDir, cacheAge, cacheMode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize cache: %w", err)
+	}
+	return diskCache, nil
+}
+
+// addMediaPolicyFlags adds the flags of the media policy to cmd
+func addMediaPolicyFlags(cmd *cobra.Command) {
+	cmd.Flags().StringArrayVar(&mediaSources, "media-source", nil,
+		"Screenscraper media types to download a media type from, in priority order (e.g., marquees=wheel-carbon,wheel); repeatable")
+	cmd.Flags().IntVar(&minWidth, "min-width", 0, "Minimum image width in pixels (0 = no minimum)")
+	cmd.Flags().IntVar(&minHeight, "min-height", 0, "Minimum image height in pixels (0 = no minimum)")
+}
+
+// mediaPolicy returns the media policy of the flags
+func mediaPolicy() (scraper.MediaPolicy, error) {
+	sources, err := scraper.ParseMediaSources(mediaSources)
+	if err != nil {
+		return scraper.MediaPolicy{}, err
+	}
+	if minWidth < 0 || minHeight < 0 {
+		return scraper.MediaPolicy{}, fmt.Errorf("--min-width and --min-height can't be negative")
+	}
+	return scraper.MediaPolicy{Sources: sources, MinWidth: minWidth, MinHeight: minHeight}, nil
+}
+
+// addMiximageFlag adds the miximage flag to cmd
+func addMiximageFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&miximage, "miximage", "",
+		"Compose miximages of the downloaded media with a layout: "+strings.Join(miximagelib.TemplateNames(), ", ")+", or a JSON layout file")
+}
+
+// miximageLayout returns the miximage layout of the flags, or nil if
+// miximages aren't composed
+func miximageLayout() (*miximagelib.Layout, error) {
+	if miximage == "" {
+		return nil, nil
+	}
+	layout, err := miximagelib.LoadLayout(miximage)
+	if err != nil {
+		return nil, err
+	}
+	return &layout, nil
+}
+
+// newConfig returns the scraper config of the flags, media policy, and
+// account limits
+func newConfig(limits scraper.Limits, policy scraper.MediaPolicy) *scraper.Config {
+	return &scraper.Config{
+		MediaTypes:        mediaTypes,
+		Media:             policy,
+		PreferredRegions:  regions,
+		MediaOutputDir:    esdeMedia,
+		SkipCacheRead:     noCache,
+		SkipCacheWrite:    cacheOnly,
+		Overwrite:         overwrite,
+		MaxThreads:        limits.MaxThreads,
+		MaxRequestsPerMin: limits.MaxRequestsPerMin,
+		RequestsLeftToday: max(limits.RequestsLeftToday, 0),
+	}
+}
+
+// run scrapes total entries with scrape, showing its progress in a TUI if
+// the output is a terminal
+func run(ctx context.Context, cancel context.CancelFunc, s *scraper.Scraper, total int, scrape func(context.Context) (*scraper.ScrapeResults, error)) (*scraper.ScrapeResults, error) {
+	if jsonOutput || !isTerminal() {
 		// Simple output mode
-		results, err = s.ScrapeFromDAT(ctx, datPath)
+		results, err := scrape(ctx)
 		if err != nil {
-			return fmt.Errorf("scrape failed: %w", err)
+			return nil, fmt.Errorf("scrape failed: %w", err)
 		}
+		return results, nil
+	}
+
+	// Create and run TUI
+	model := scraper.NewModel(total, s.GetConfig().MaxThreads, len(mediaTypes), s.Updates(), s.RateLimiterStats)
+
+	// Run scraper in background
+	resultsChan := make(chan *scraper.ScrapeResults, 1)
+	go func() {
+		res, _ := scrape(ctx)
+		resultsChan <- res
+	}()
+
+	// Run TUI with context so it exits on Ctrl+C
+	p := tea.NewProgram(model, tea.WithContext(ctx))
+	if _, err := p.Run(); err != nil && ctx.Err() == nil {
+		return nil, fmt.Errorf("TUI error: %w", err)
 	}
 
+	// Cancel context to stop scraper if TUI exited early (user pressed 'q')
+	cancel()
+
+	// Wait for scraper to finish (should be quick after cancellation)
+	return <-resultsChan, nil
+}
+
+// finish writes the ES-DE output of results, and prints a summary of them
+func finish(ctx context.Context, s *scraper.Scraper, results *scraper.ScrapeResults) error {
 	cancelled := ctx.Err() != nil
 
 	// Generate output (even if cancelled, save partial results)
@@ -357,6 +467,7 @@ func runScrape(cmd *cobra.Command, args []string) error {
 			"media_downloaded": results.MediaDownloaded,
 			"cache_hits":       results.CacheHits,
 			"api_calls":        stats.TotalRequests,
+			"quota_exceeded":   results.QuotaExceeded,
 		}, "", "  ")
 		fmt.Println(string(data))
 	} else {
@@ -384,6 +495,9 @@ func runScrape(cmd *cobra.Command, args []string) error {
 
 		// API stats
 		fmt.Printf(" API: %d calls completed\n", stats.TotalRequests)
+		if results.QuotaExceeded {
+			fmt.Printf(" Daily request quota exceeded; run again tomorrow to scrape the rest\n")
+		}
 	}
 
 	return nil
@@ -26,7 +26,10 @@ var (
 var downloadCmd = &cobra.Command{
 	Use:   "download",
 	Short: "Download media files",
-	Long:  "Download game or system media files (images, videos, etc.)",
+	Long: `Download game or system media files (images, videos, etc.)
+
+If the --output file exists, its hashes are sent with the request, and it's
+left as is without being downloaded again if it's up to date.`,
 }
 
 var downloadGameMediaCmd = &cobra.Command{
@@ -51,6 +54,7 @@ var downloadGameMediaCmd = &cobra.Command{
 			MaxHeight:    dlMaxHeight,
 			OutputFormat: screenscraper.DownloadGameMediaParamsOutputformat(dlFormat),
 		}
+		params.Crc, params.Md5, params.Sha1 = outputHashes()
 
 		resp, err := shared.Client.DownloadGameMediaWithResponse(context.Background(), params)
 		if err != nil {
@@ -63,7 +67,12 @@ var downloadGameMediaCmd = &cobra.Command{
 
 		data := resp.Body
 
-		// Check if it's a text response (NOMEDIA, CRCOK, etc.)
+		if screenscraper.IsMediaUnchanged(data) {
+			fmt.Printf("%s is up to date\n", dlOutput)
+			return nil
+		}
+
+		// Check if it's a text response (NOMEDIA, etc.)
 		if len(data) > 0 && len(data) < 100 && data[0] != 0xFF && data[0] != 0x89 { // not binary
 			fmt.Printf("Response: %s\n", string(data))
 			return nil
@@ -102,6 +111,7 @@ var downloadSystemMediaCmd = &cobra.Command{
 			MaxHeight:    dlMaxHeight,
 			OutputFormat: screenscraper.DownloadSystemMediaParamsOutputformat(dlFormat),
 		}
+		params.Crc, params.Md5, params.Sha1 = outputHashes()
 
 		resp, err := shared.Client.DownloadSystemMediaWithResponse(context.Background(), params)
 		if err != nil {
@@ -114,7 +124,12 @@ var downloadSystemMediaCmd = &cobra.Command{
 
 		data := resp.Body
 
-		// Check if it's a text response (NOMEDIA, CRCOK, etc.)
+		if screenscraper.IsMediaUnchanged(data) {
+			fmt.Printf("%s is up to date\n", dlOutput)
+			return nil
+		}
+
+		// Check if it's a text response (NOMEDIA, etc.)
 		if len(data) > 0 && len(data) < 100 && data[0] != 0xFF && data[0] != 0x89 { // not binary
 			fmt.Printf("Response: %s\n", string(data))
 			return nil
@@ -153,6 +168,7 @@ var downloadGroupMediaCmd = &cobra.Command{
 			MaxHeight:    dlMaxHeight,
 			OutputFormat: screenscraper.DownloadGroupMediaParamsOutputformat(dlFormat),
 		}
+		params.Crc, params.Md5, params.Sha1 = outputHashes()
 
 		resp, err := shared.Client.DownloadGroupMediaWithResponse(context.Background(), params)
 		if err != nil {
@@ -165,7 +181,12 @@ var downloadGroupMediaCmd = &cobra.Command{
 
 		data := resp.Body
 
-		// Check if it's a text response (NOMEDIA, CRCOK, etc.)
+		if screenscraper.IsMediaUnchanged(data) {
+			fmt.Printf("%s is up to date\n", dlOutput)
+			return nil
+		}
+
+		// Check if it's a text response (NOMEDIA, etc.)
 		if len(data) > 0 && len(data) < 100 && data[0] != 0xFF && data[0] != 0x89 { // not binary
 			fmt.Printf("Response: %s\n", string(data))
 			return nil
@@ -204,6 +225,7 @@ var downloadCompanyMediaCmd = &cobra.Command{
 			MaxHeight:    dlMaxHeight,
 			OutputFormat: screenscraper.DownloadCompanyMediaParamsOutputformat(dlFormat),
 		}
+		params.Crc, params.Md5, params.Sha1 = outputHashes()
 
 		resp, err := shared.Client.DownloadCompanyMediaWithResponse(context.Background(), params)
 		if err != nil {
@@ -216,7 +238,12 @@ var downloadCompanyMediaCmd = &cobra.Command{
 
 		data := resp.Body
 
-		// Check if it's a text response (NOMEDIA, CRCOK, etc.)
+		if screenscraper.IsMediaUnchanged(data) {
+			fmt.Printf("%s is up to date\n", dlOutput)
+			return nil
+		}
+
+		// Check if it's a text response (NOMEDIA, etc.)
 		if len(data) > 0 && len(data) < 100 && data[0] != 0xFF && data[0] != 0x89 { // not binary
 			fmt.Printf("Response: %s\n", string(data))
 			return nil
@@ -277,3 +304,17 @@ func init() {
 	downloadCmd.AddCommand(downloadCompanyMediaCmd)
 	Cmd.AddCommand(downloadCmd)
 }
+
+// outputHashes returns the CRC32, MD5, and SHA1 of the existing output file,
+// if any, so Screenscraper answers that it's current instead of sending it
+// again
+func outputHashes() (string, string, string) {
+	if dlOutput == "" || dlOutput == "-" {
+		return "", "", ""
+	}
+	data, err := os.ReadFile(dlOutput)
+	if err != nil {
+		return "", "", ""
+	}
+	return screenscraper.MediaHashes(data)
+}
@@ -3,6 +3,7 @@ package screenscraper
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/sargunv/rom-tools/internal/cli/screenscraper/detail"
 	"github.com/sargunv/rom-tools/internal/cli/screenscraper/list"
@@ -22,9 +23,18 @@ Credentials are loaded from environment variables:
 - SCREENSCRAPER_DEV_USER     - Developer username
 - SCREENSCRAPER_DEV_PASSWORD - Developer password
 - SCREENSCRAPER_ID           - User ID (optional)
-- SCREENSCRAPER_PASSWORD     - User password (optional)`,
+- SCREENSCRAPER_PASSWORD     - User password (optional)
+
+Game info, search results, and reference lists are cached on disk for
+--cache-age, so repeated lookups don't count against your daily quota. Use
+--refresh to fetch them again. Media downloads and status aren't cached.`,
 	PersistentPreRun: func(cmd *cobra.Command, args []string) {
-		client, err := shared.NewClientFromEnv("screenscraper-go")
+		cacheOption, err := shared.CacheOption(shared.CacheAge, shared.Refresh)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(1)
+		}
+		client, err := shared.NewClientFromEnv("screenscraper-go", cacheOption)
 		if err != nil {
 			fmt.Fprintln(os.Stderr, "Error:", err)
 			os.Exit(1)
@@ -36,6 +46,8 @@ Credentials are loaded from environment variables:
 func init() {
 	Cmd.PersistentFlags().BoolVar(&shared.JsonOutput, "json", false, "Output results as JSON")
 	Cmd.PersistentFlags().StringVar(&shared.Locale, "locale", "", "Override locale for output (e.g., en, fr, de)")
+	Cmd.PersistentFlags().BoolVar(&shared.Refresh, "refresh", false, "Fetch responses from the API instead of the cache")
+	Cmd.PersistentFlags().DurationVar(&shared.CacheAge, "cache-age", 720*time.Hour, "Maximum age of cached responses")
 
 	// Add sub-package commands
 	Cmd.AddCommand(detail.Cmd)
@@ -2,8 +2,11 @@ package shared
 
 import (
 	"fmt"
+	"net/http"
 	"os"
+	"time"
 
+	"github.com/sargunv/rom-tools/internal/cache"
 	"github.com/sargunv/rom-tools/lib/screenscraper"
 )
 
@@ -11,12 +14,14 @@ import (
 var (
 	JsonOutput bool
 	Locale     string
+	Refresh    bool
+	CacheAge   time.Duration
 	Client     *screenscraper.ScreenscraperClient
 )
 
 // NewClientFromEnv creates a client from environment variables
 // Returns error if dev credentials are missing
-func NewClientFromEnv(appName string) (*screenscraper.ScreenscraperClient, error) {
+func NewClientFromEnv(appName string, opts ...screenscraper.ClientOption) (*screenscraper.ScreenscraperClient, error) {
 	devID := os.Getenv("SCREENSCRAPER_DEV_USER")
 	devPassword := os.Getenv("SCREENSCRAPER_DEV_PASSWORD")
 	ssID := os.Getenv("SCREENSCRAPER_ID")
@@ -26,5 +31,26 @@ func NewClientFromEnv(appName string) (*screenscraper.ScreenscraperClient, error
 		return nil, fmt.Errorf("screenscraper credentials required: set SCREENSCRAPER_DEV_USER and SCREENSCRAPER_DEV_PASSWORD")
 	}
 
-	return screenscraper.NewScreenscraperClient(devID, devPassword, appName, ssID, ssPassword)
+	return screenscraper.NewScreenscraperClient(devID, devPassword, appName, ssID, ssPassword, opts...)
+}
+
+// CacheOption returns a client option that caches metadata responses in the
+// default cache directory for up to maxAge
+// If refresh is true, cached responses are ignored, but still replaced
+func CacheOption(maxAge time.Duration, refresh bool) (screenscraper.ClientOption, error) {
+	dir, err := cache.DefaultCacheDir()
+	if err != nil {
+		return nil, err
+	}
+
+	mode := cache.ModeNormal
+	if refresh {
+		mode = cache.ModeNoRead
+	}
+	diskCache, err := cache.New(dir, maxAge, mode)
+	if err != nil {
+		return nil, err
+	}
+
+	return screenscraper.WithHTTPClient(&http.Client{Transport: cache.NewTransport(diskCache, nil)}), nil
 }
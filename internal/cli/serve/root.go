@@ -0,0 +1,191 @@
+package serve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"time"
+
+	"github.com/sargunv/rom-tools/internal/server"
+	"github.com/sargunv/rom-tools/lib/datfile"
+	romident "github.com/sargunv/rom-tools/lib/identify"
+	"github.com/sargunv/rom-tools/lib/library"
+	"github.com/sargunv/rom-tools/lib/scan"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	addr          string
+	datPaths      []string
+	libraryPath   string
+	scanDBPath    string
+	maxUploadSize int64
+	hosts         []string
+)
+
+var Cmd = &cobra.Command{
+	Use:   "serve [dir]...",
+	Short: "Serve ROM identification and library queries over HTTP",
+	Long: `Serve ROM identification and library queries as a JSON API over HTTP, so
+frontends and scripts in other languages can use them without running
+rom-tools for each file.
+
+Endpoints:
+- POST /identify: identifies a file, and responds with the same JSON as
+  "identify --json". The file is either a path on the server, given as the
+  JSON body {"path": "..."}, or uploaded as the "file" field of a multipart
+  form. Files are matched against the --dat files.
+- GET /library: responds with the files of the --library index, filtered by
+  the query parameters platform (e.g. gameboy), region (e.g. Europe, which
+  includes Germany), unmatched=true (files that match no DAT entry), and
+  missing-media (e.g. covers, for files whose scrape has none).
+- GET /scan: scans the directories of the dir query parameters, which must
+  be within those given as arguments, or else those given as arguments,
+  into the --library index, streaming its progress as server-sent events:
+  a "progress" event for each file done, with its path, status (added,
+  modified, unchanged, or removed), new identification, and the count of
+  files done and to do; then a "done" event with the paths added, modified,
+  and removed, or an "error" event. Closing the connection stops the scan.
+  One scan runs at a time.
+
+Errors are responded with as {"error": "..."}.
+
+Directories given as arguments are also scanned into the library index at
+startup. Scans identify new and modified files (recorded in the --scan-db
+database, so unchanged files are skipped by later scans) and match the
+library against the --dat files.
+
+The server can read any file the user running it can, so it listens on
+localhost only unless --addr says otherwise. Requests for hosts other than
+the --host names are refused, so web pages can't reach the server by DNS
+rebinding. They default to the host of --addr (and 127.0.0.1 and ::1 for
+localhost); if --addr listens on every interface, any host is allowed.`,
+	Example: `  # Serve identification with DAT matching
+  rom-tools serve --dat "Nintendo - Game Boy.dat"
+
+  # Identify a file on the server, and upload one
+  curl -d '{"path": "roms/gb/Tetris.gb"}' -H 'Content-Type: application/json' \
+      localhost:8080/identify
+  curl -F file=@Tetris.gb localhost:8080/identify
+
+  # Scan a library at startup, and list its unmatched Game Boy ROMs
+  rom-tools serve --library library.db --dat "Nintendo - Game Boy.dat" roms
+  curl 'localhost:8080/library?platform=gameboy&unmatched=true'
+
+  # Rescan the library, following its progress
+  curl -N localhost:8080/scan`,
+	RunE: runServe,
+}
+
+func init() {
+	Cmd.Flags().StringVar(&addr, "addr", "localhost:8080", "Address to listen on")
+	Cmd.Flags().StringArrayVar(&datPaths, "dat", nil, "DAT file to match against (repeatable)")
+	Cmd.Flags().StringVar(&libraryPath, "library", "", "Library index to serve at /library and scan into at /scan")
+	Cmd.Flags().StringVar(&scanDBPath, "scan-db", "rom-tools-scan.db", "Scan database of the library, for rescans to skip unchanged files")
+	Cmd.Flags().Int64Var(&maxUploadSize, "max-upload-size", 4<<30, "Max size in bytes of uploaded files (0 = no limit)")
+	Cmd.Flags().StringArrayVar(&hosts, "host", nil, "Host name clients reach the server by (repeatable; default the host of --addr)")
+}
+
+func runServe(cmd *cobra.Command, args []string) error {
+	if len(args) > 0 && libraryPath == "" {
+		return fmt.Errorf("--library required to scan directories")
+	}
+	allowed, err := allowedHosts(addr, hosts)
+	if err != nil {
+		return err
+	}
+	cmd.SilenceUsage = true
+
+	s := &server.Server{Options: romident.DefaultOptions(), MaxUploadSize: maxUploadSize, Hosts: allowed}
+	if len(datPaths) > 0 {
+		index := romident.NewDATIndex()
+		for _, datPath := range datPaths {
+			dat, err := datfile.Parse(datPath)
+			if err != nil {
+				return fmt.Errorf("failed to load DAT %s: %w", datPath, err)
+			}
+			index.Add("", dat)
+		}
+		s.Matcher = romident.NewMatcher(index)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if libraryPath != "" {
+		db, err := library.Open(libraryPath)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+		scanDB, err := scan.Open(scanDBPath)
+		if err != nil {
+			return err
+		}
+		defer scanDB.Close()
+		s.Library, s.ScanDB, s.Dirs = db, scanDB, args
+
+		if len(args) > 0 {
+			changes, err := s.ScanLibrary(ctx, args, printProgress)
+			fmt.Fprint(os.Stderr, "\r\033[K")
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "Scanned: %d added, %d modified, %d removed, %d unchanged\n",
+				len(changes.Added), len(changes.Modified), len(changes.Removed), changes.Unchanged)
+		}
+	}
+
+	// Requests, and so scans, are cancelled on shutdown
+	srv := &http.Server{Addr: addr, Handler: s.Handler(), BaseContext: func(net.Listener) context.Context { return ctx }}
+	errc := make(chan error, 1)
+	go func() {
+		errc <- srv.ListenAndServe()
+	}()
+	fmt.Fprintf(os.Stderr, "Listening on http://%s\n", addr)
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil && !errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	return nil
+}
+
+// allowedHosts returns the host names requests may be for: hosts if set, or
+// else the host of addr, with its loopback addresses if it's localhost. It
+// returns nil, allowing any, if addr listens on every interface.
+func allowedHosts(addr string, hosts []string) ([]string, error) {
+	if len(hosts) > 0 {
+		return hosts, nil
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --addr: %w", err)
+	}
+	ip := net.ParseIP(host)
+	switch {
+	case host == "" || ip != nil && ip.IsUnspecified():
+		return nil, nil
+	case host == "localhost":
+		return []string{"localhost", "127.0.0.1", "::1"}, nil
+	default:
+		return []string{host}, nil
+	}
+}
+
+// printProgress shows the progress of a scan on one line of stderr.
+func printProgress(e scan.Event) {
+	fmt.Fprintf(os.Stderr, "\r\033[KScanning: %d/%d %s", e.Done, e.Total, filepath.Base(e.Path))
+}
@@ -0,0 +1,134 @@
+// Package compressed provides handling of single compressed files (.gz, .xz,
+// and .zst) for ROM identification, as containers of the one file each holds.
+package compressed
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+
+	"github.com/sargunv/rom-tools/internal/util"
+)
+
+// IsCompressed reports whether ext (e.g., ".gz") is a supported compression
+// format, in any case.
+func IsCompressed(ext string) bool {
+	switch strings.ToLower(ext) {
+	case ".gz", ".xz", ".zst":
+		return true
+	}
+	return false
+}
+
+// NewReader returns a reader of r decompressed with the compression format
+// of ext, one of .gz, .xz, or .zst in any case.
+func NewReader(ext string, r io.Reader) (io.ReadCloser, error) {
+	switch strings.ToLower(ext) {
+	case ".gz":
+		return gzip.NewReader(r)
+	case ".xz":
+		xr, err := xz.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return io.NopCloser(xr), nil
+	case ".zst":
+		zr, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+		return zr.IOReadCloser(), nil
+	}
+	return nil, fmt.Errorf("unsupported compression format: %s", ext)
+}
+
+// File is a compressed file, as a container of the one file it holds, and
+// implements Container. The file is named by the compressed file's name
+// without its extension (e.g., game.nes for game.nes.gz).
+type File struct {
+	r     io.ReaderAt
+	size  int64
+	ext   string
+	entry util.FileEntry
+	ctx   context.Context
+}
+
+// NewFile reads the compressed file name of the given size from r, such as
+// a file on disk or an entry of an archive. Its size isn't stored by every
+// format, so the file is decompressed once to find it, which also checks
+// that it can be. Entries opened with OpenFileAt stop decompressing once ctx
+// is done.
+func NewFile(ctx context.Context, name string, r io.ReaderAt, size int64) (*File, error) {
+	ext := filepath.Ext(name)
+	f := &File{r: r, size: size, ext: ext, ctx: ctx}
+
+	dr, err := f.open()
+	if err != nil {
+		return nil, err
+	}
+	defer dr.Close()
+	var total int64
+	buf := make([]byte, 64*1024)
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		n, err := dr.Read(buf)
+		total += int64(n)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress %s: %w", name, err)
+		}
+	}
+
+	f.entry = util.FileEntry{
+		Name: strings.TrimSuffix(filepath.Base(name), ext),
+		Size: total,
+	}
+	return f, nil
+}
+
+// open returns a reader of the decompressed file.
+func (f *File) open() (io.ReadCloser, error) {
+	dr, err := NewReader(f.ext, io.NewSectionReader(f.r, 0, f.size))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s file: %w", f.ext, err)
+	}
+	return dr, nil
+}
+
+// Entries returns the one decompressed file.
+func (f *File) Entries() []util.FileEntry {
+	return []util.FileEntry{f.entry}
+}
+
+// OpenFile opens the decompressed file for reading.
+func (f *File) OpenFile(name string) (io.ReadCloser, error) {
+	if name != f.entry.Name {
+		return nil, fmt.Errorf("file not found in %s file: %s", f.ext, name)
+	}
+	return f.open()
+}
+
+// OpenFileAt opens the decompressed file with random access support,
+// decompressing it into a buffer as far as reads need.
+func (f *File) OpenFileAt(name string) (util.RandomAccessReader, int64, error) {
+	if name != f.entry.Name {
+		return nil, 0, fmt.Errorf("file not found in %s file: %s", f.ext, name)
+	}
+	return util.NewStreamReader(f.ctx, f.entry.Size, f.open), f.entry.Size, nil
+}
+
+// Close releases nothing, as the compressed file's reader belongs to the
+// caller.
+func (f *File) Close() error {
+	return nil
+}
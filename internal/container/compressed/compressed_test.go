@@ -0,0 +1,79 @@
+package compressed
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+const content = "Hello from a compressed file.\n"
+
+// compress compresses data with the format of ext.
+func compress(t *testing.T, ext string, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	var err error
+	switch ext {
+	case ".gz":
+		w = gzip.NewWriter(&buf)
+	case ".xz":
+		w, err = xz.NewWriter(&buf)
+	case ".zst":
+		w, err = zstd.NewWriter(&buf)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestNewFile(t *testing.T) {
+	for _, ext := range []string{".gz", ".xz", ".zst"} {
+		t.Run(ext, func(t *testing.T) {
+			data := compress(t, ext, []byte(content))
+			f, err := NewFile(context.Background(), "dir/readme.txt"+ext, bytes.NewReader(data), int64(len(data)))
+			if err != nil {
+				t.Fatalf("NewFile() error = %v", err)
+			}
+			defer f.Close()
+
+			entries := f.Entries()
+			if len(entries) != 1 || entries[0].Name != "readme.txt" || entries[0].Size != int64(len(content)) {
+				t.Fatalf("Entries() = %+v, want readme.txt of %d bytes", entries, len(content))
+			}
+
+			reader, size, err := f.OpenFileAt("readme.txt")
+			if err != nil {
+				t.Fatalf("OpenFileAt() error = %v", err)
+			}
+			defer reader.Close()
+			got, err := io.ReadAll(io.NewSectionReader(reader, 0, size))
+			if err != nil {
+				t.Fatalf("Reading error = %v", err)
+			}
+			if string(got) != content {
+				t.Errorf("readme.txt = %q, want %q", got, content)
+			}
+		})
+	}
+}
+
+func TestNewFile_Corrupt(t *testing.T) {
+	data := compress(t, ".gz", []byte(content))
+	data = data[:len(data)-4]
+	if _, err := NewFile(context.Background(), "readme.txt.gz", bytes.NewReader(data), int64(len(data))); err == nil {
+		t.Error("NewFile() of a truncated file expected error, got nil")
+	}
+}
@@ -3,6 +3,7 @@ package folder
 import (
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 
@@ -52,6 +53,11 @@ func (f *FolderContainer) Entries() []util.FileEntry {
 	return f.entries
 }
 
+// FS returns the folder's contents as an fs.FS.
+func (f *FolderContainer) FS() fs.FS {
+	return os.DirFS(f.path)
+}
+
 // OpenFile opens a file within the folder for sequential reading.
 func (f *FolderContainer) OpenFile(name string) (io.ReadCloser, error) {
 	fullPath := filepath.Join(f.path, name)
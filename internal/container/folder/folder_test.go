@@ -2,6 +2,7 @@ package folder
 
 import (
 	"testing"
+	"testing/fstest"
 )
 
 func TestFolderContainer(t *testing.T) {
@@ -66,3 +67,15 @@ func TestFolderContainerOpenFileAt(t *testing.T) {
 		t.Errorf("Expected magic 'XBEH', got '%s'", string(magic))
 	}
 }
+
+func TestFolderContainerFS(t *testing.T) {
+	container, err := NewFolderContainer("testdata/xromwell")
+	if err != nil {
+		t.Fatalf("NewFolderContainer() error = %v", err)
+	}
+	defer container.Close()
+
+	if err := fstest.TestFS(container.FS(), "default.xbe"); err != nil {
+		t.Fatal(err)
+	}
+}
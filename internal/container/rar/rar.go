@@ -0,0 +1,226 @@
+// Package rar provides RAR archive handling for ROM identification. It wraps
+// rardecode, which reads RAR 1.5 through 5 archives, including multi-volume
+// and encrypted ones.
+package rar
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"time"
+
+	"github.com/nwaples/rardecode/v2"
+
+	"github.com/sargunv/rom-tools/internal/util"
+)
+
+// RARArchive represents an open RAR archive and implements Container.
+//
+// Its entries have no pre-computed hashes: RAR archives store CRC32s, but
+// rardecode doesn't expose them, so their contents are hashed instead.
+type RARArchive struct {
+	name    string // path of the first volume, opened with opts
+	opts    []rardecode.Option
+	files   []*rardecode.File
+	entries []util.FileEntry
+	ctx     context.Context
+}
+
+// Entries returns all files in the RAR archive.
+func (a *RARArchive) Entries() []util.FileEntry {
+	return a.entries
+}
+
+// Close closes the RAR archive. Volumes are opened as entries are read, so
+// there's nothing to release.
+func (a *RARArchive) Close() error {
+	return nil
+}
+
+// OpenFile opens a file within the RAR archive for reading.
+func (a *RARArchive) OpenFile(name string) (io.ReadCloser, error) {
+	for _, f := range a.files {
+		if f.Name == name {
+			return a.open(f)
+		}
+	}
+	return nil, fmt.Errorf("file not found in RAR: %s", name)
+}
+
+// OpenFileAt opens a file within the RAR archive with random access support,
+// decompressing it into a buffer as far as reads need.
+func (a *RARArchive) OpenFileAt(name string) (util.RandomAccessReader, int64, error) {
+	for _, f := range a.files {
+		if f.Name == name {
+			reader := util.NewStreamReader(a.ctx, f.UnPackedSize, func() (io.ReadCloser, error) {
+				return a.open(f)
+			})
+			return reader, f.UnPackedSize, nil
+		}
+	}
+	return nil, 0, fmt.Errorf("file not found in RAR: %s", name)
+}
+
+// open opens f for reading. The files of solid archives are compressed as
+// one stream, so they're read by decompressing the files before them too.
+func (a *RARArchive) open(f *rardecode.File) (io.ReadCloser, error) {
+	if !f.Solid {
+		rc, err := f.Open()
+		if err != nil {
+			return nil, entryError(f.Name, err)
+		}
+		return &entryFile{r: rc, c: rc, name: f.Name}, nil
+	}
+
+	rc, err := rardecode.OpenReader(a.name, a.opts...)
+	if err != nil {
+		return nil, entryError(f.Name, err)
+	}
+	for {
+		h, err := rc.Next()
+		if err != nil {
+			rc.Close()
+			return nil, entryError(f.Name, err)
+		}
+		if h.Name == f.Name {
+			return &entryFile{r: rc, c: rc, name: f.Name}, nil
+		}
+	}
+}
+
+// Open opens a RAR archive and returns metadata for all files.
+func Open(path, password string) (*RARArchive, error) {
+	return OpenContext(context.Background(), path, password)
+}
+
+// OpenContext is like Open, but entries opened with OpenFileAt stop
+// decompressing once ctx is done. Multi-volume archives are read from all
+// their volumes, which must be next to path, the first volume (e.g.,
+// game.part1.rar or game.rar with game.r00).
+//
+// The password decrypts encrypted entries, and archives whose file list is
+// encrypted too. Without it, opening or reading them fails with
+// util.ErrPasswordRequired.
+func OpenContext(ctx context.Context, path, password string) (*RARArchive, error) {
+	return list(ctx, path, options(password))
+}
+
+// NewArchive reads a single-volume RAR archive of the given size from r,
+// such as an entry of another archive. Entries opened with OpenFileAt stop
+// decompressing once ctx is done.
+func NewArchive(ctx context.Context, r io.ReaderAt, size int64, password string) (*RARArchive, error) {
+	opts := append(options(password), rardecode.FileSystem(readerFS{r: r, size: size}))
+	return list(ctx, readerFSName, opts)
+}
+
+// options returns the rardecode options for password. An empty password is
+// left unset, so encrypted entries fail as needing one.
+func options(password string) []rardecode.Option {
+	if password == "" {
+		return nil
+	}
+	return []rardecode.Option{rardecode.Password(password)}
+}
+
+// list lists the files of the archive whose first volume is name.
+func list(ctx context.Context, name string, opts []rardecode.Option) (*RARArchive, error) {
+	files, err := rardecode.List(name, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open RAR: %w", passwordError(err))
+	}
+
+	archive := &RARArchive{name: name, opts: opts, ctx: ctx}
+	for _, f := range files {
+		// Skip directories
+		if f.IsDir {
+			continue
+		}
+		archive.files = append(archive.files, f)
+		archive.entries = append(archive.entries, util.FileEntry{
+			Name: f.Name,
+			Size: f.UnPackedSize,
+		})
+	}
+	return archive, nil
+}
+
+// passwordError returns util.ErrPasswordRequired or util.ErrWrongPassword
+// for rardecode's errors about them, and other errors as is.
+func passwordError(err error) error {
+	switch {
+	case errors.Is(err, rardecode.ErrArchiveEncrypted), errors.Is(err, rardecode.ErrArchivedFileEncrypted):
+		return util.ErrPasswordRequired
+	case errors.Is(err, rardecode.ErrBadPassword):
+		return util.ErrWrongPassword
+	}
+	return err
+}
+
+// entryError annotates an error reading the entry name.
+func entryError(name string, err error) error {
+	return fmt.Errorf("%s: %w", name, passwordError(err))
+}
+
+// entryFile reads an entry, returning password errors as such. rardecode
+// only reports missing passwords once an encrypted entry is read.
+type entryFile struct {
+	r    io.Reader
+	c    io.Closer
+	name string
+}
+
+func (f *entryFile) Read(p []byte) (int, error) {
+	n, err := f.r.Read(p)
+	if err != nil && err != io.EOF {
+		err = entryError(f.name, err)
+	}
+	return n, err
+}
+
+func (f *entryFile) Close() error {
+	return f.c.Close()
+}
+
+// readerFSName is the name of the one file of a readerFS.
+const readerFSName = "archive.rar"
+
+// readerFS is an fs.FS of one file read from an io.ReaderAt, for rardecode
+// to read an archive that isn't a file on disk.
+type readerFS struct {
+	r    io.ReaderAt
+	size int64
+}
+
+func (f readerFS) Open(name string) (fs.File, error) {
+	if name != readerFSName {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return &readerFile{SectionReader: io.NewSectionReader(f.r, 0, f.size)}, nil
+}
+
+// readerFile is the file of a readerFS.
+type readerFile struct {
+	*io.SectionReader
+}
+
+func (f *readerFile) Stat() (fs.FileInfo, error) {
+	return readerFileInfo{size: f.Size()}, nil
+}
+
+func (f *readerFile) Close() error {
+	return nil
+}
+
+// readerFileInfo describes the file of a readerFS.
+type readerFileInfo struct {
+	size int64
+}
+
+func (i readerFileInfo) Name() string       { return readerFSName }
+func (i readerFileInfo) Size() int64        { return i.size }
+func (i readerFileInfo) Mode() fs.FileMode  { return 0o444 }
+func (i readerFileInfo) ModTime() time.Time { return time.Time{} }
+func (i readerFileInfo) IsDir() bool        { return false }
+func (i readerFileInfo) Sys() any           { return nil }
@@ -0,0 +1,107 @@
+package rar
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"testing"
+)
+
+const readme = "Hello from a RAR archive.\n"
+
+// readEntry reads the whole of an entry with OpenFileAt.
+func readEntry(t *testing.T, archive *RARArchive, name string) []byte {
+	t.Helper()
+	reader, size, err := archive.OpenFileAt(name)
+	if err != nil {
+		t.Fatalf("OpenFileAt(%s) error = %v", name, err)
+	}
+	defer reader.Close()
+	data, err := io.ReadAll(io.NewSectionReader(reader, 0, size))
+	if err != nil {
+		t.Fatalf("Reading %s error = %v", name, err)
+	}
+	return data
+}
+
+func TestOpen(t *testing.T) {
+	reference, err := Open("testdata/rar5.rar", "")
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	game := readEntry(t, reference, "game.bin")
+
+	tests := []struct {
+		path    string
+		entries int
+	}{
+		{"testdata/rar4.rar", 2},
+		{"testdata/rar5.rar", 2},
+		{"testdata/solid.rar", 2},
+		{"testdata/volumes.part1.rar", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			archive, err := Open(tt.path, "")
+			if err != nil {
+				t.Fatalf("Open() error = %v", err)
+			}
+			defer archive.Close()
+
+			entries := archive.Entries()
+			if len(entries) != tt.entries {
+				t.Fatalf("Expected %d entries, got %d", tt.entries, len(entries))
+			}
+			if entries[0].Name != "game.bin" || entries[0].Size != int64(len(game)) {
+				t.Errorf("Entries()[0] = %s (%d bytes), want game.bin (%d bytes)", entries[0].Name, entries[0].Size, len(game))
+			}
+			if got := readEntry(t, archive, "game.bin"); !bytes.Equal(got, game) {
+				t.Error("game.bin content doesn't match")
+			}
+			if tt.entries > 1 {
+				if got := readEntry(t, archive, "docs/readme.txt"); string(got) != readme {
+					t.Errorf("docs/readme.txt = %q, want %q", got, readme)
+				}
+			}
+		})
+	}
+}
+
+func TestOpenFile_Solid(t *testing.T) {
+	archive, err := Open("testdata/solid.rar", "")
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer archive.Close()
+
+	// The second file of a solid archive is read after the first
+	rc, err := archive.OpenFile("docs/readme.txt")
+	if err != nil {
+		t.Fatalf("OpenFile() error = %v", err)
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("Reading error = %v", err)
+	}
+	if string(data) != readme {
+		t.Errorf("docs/readme.txt = %q, want %q", data, readme)
+	}
+}
+
+func TestNewArchive(t *testing.T) {
+	data, err := os.ReadFile("testdata/rar5.rar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	archive, err := NewArchive(context.Background(), bytes.NewReader(data), int64(len(data)), "")
+	if err != nil {
+		t.Fatalf("NewArchive() error = %v", err)
+	}
+	defer archive.Close()
+
+	if got := readEntry(t, archive, "docs/readme.txt"); string(got) != readme {
+		t.Errorf("docs/readme.txt = %q, want %q", got, readme)
+	}
+}
@@ -0,0 +1,179 @@
+// Package tar provides tar archive handling for ROM identification,
+// including tar archives compressed as a whole (.tar.gz, .tar.xz, .tar.zst).
+package tar
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/sargunv/rom-tools/internal/container/compressed"
+	"github.com/sargunv/rom-tools/internal/util"
+)
+
+// TarArchive represents an open tar archive and implements Container.
+//
+// The files of uncompressed archives are read directly from it. Compressed
+// archives can only be read from the start, so their files are read by
+// decompressing the archive up to them.
+type TarArchive struct {
+	r           io.ReaderAt
+	size        int64
+	compression string // compression extension, or "" if uncompressed
+	files       []file
+	entries     []util.FileEntry
+	ctx         context.Context
+}
+
+// file locates a regular file in the archive.
+type file struct {
+	index  int   // index among all headers, for reading it sequentially
+	offset int64 // offset of its data, or -1 if it must be read sequentially
+}
+
+// NewArchive reads a tar archive of the given size from r, such as a file on
+// disk or an entry of another archive. compression is the extension of the
+// format the whole archive is compressed with (e.g., ".gz"), or "" if it
+// isn't. Entries opened with OpenFileAt stop decompressing once ctx is done.
+func NewArchive(ctx context.Context, r io.ReaderAt, size int64, compression string) (*TarArchive, error) {
+	archive := &TarArchive{r: r, size: size, compression: compression, ctx: ctx}
+
+	tr, sr, closer, err := archive.open()
+	if err != nil {
+		return nil, err
+	}
+	defer closer.Close()
+
+	for index := 0; ; index++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tar: %w", err)
+		}
+		// Skip directories, links, and other special files
+		if h.Typeflag != tar.TypeReg && h.Typeflag != tar.TypeGNUSparse {
+			continue
+		}
+
+		f := file{index: index, offset: -1}
+		if sr != nil && !isSparse(h) {
+			if f.offset, err = sr.Seek(0, io.SeekCurrent); err != nil {
+				return nil, err
+			}
+		}
+		archive.files = append(archive.files, f)
+		archive.entries = append(archive.entries, util.FileEntry{
+			Name: h.Name,
+			Size: h.Size,
+		})
+	}
+	return archive, nil
+}
+
+// open returns a reader of the archive from its start. For uncompressed
+// archives, it also returns the section reader it reads, whose position is
+// the start of each file's data once its header is read.
+func (a *TarArchive) open() (*tar.Reader, *io.SectionReader, io.Closer, error) {
+	sr := io.NewSectionReader(a.r, 0, a.size)
+	if a.compression == "" {
+		return tar.NewReader(sr), sr, io.NopCloser(nil), nil
+	}
+	dr, err := compressed.NewReader(a.compression, sr)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to open %s file: %w", a.compression, err)
+	}
+	return tar.NewReader(dr), nil, dr, nil
+}
+
+// isSparse reports whether h is a sparse file, whose data isn't stored
+// contiguously.
+func isSparse(h *tar.Header) bool {
+	if h.Typeflag == tar.TypeGNUSparse {
+		return true
+	}
+	for key := range h.PAXRecords {
+		if strings.HasPrefix(key, "GNU.sparse.") {
+			return true
+		}
+	}
+	return false
+}
+
+// Entries returns all files in the tar archive.
+func (a *TarArchive) Entries() []util.FileEntry {
+	return a.entries
+}
+
+// Close releases nothing, as the archive's reader belongs to the caller.
+func (a *TarArchive) Close() error {
+	return nil
+}
+
+// OpenFile opens a file within the tar archive for reading.
+func (a *TarArchive) OpenFile(name string) (io.ReadCloser, error) {
+	for i, entry := range a.entries {
+		if entry.Name == name {
+			return a.openFile(a.files[i], entry.Size)
+		}
+	}
+	return nil, fmt.Errorf("file not found in tar: %s", name)
+}
+
+// OpenFileAt opens a file within the tar archive with random access support.
+// Files of compressed archives are decompressed into a buffer as far as
+// reads need.
+func (a *TarArchive) OpenFileAt(name string) (util.RandomAccessReader, int64, error) {
+	for i, entry := range a.entries {
+		if entry.Name != name {
+			continue
+		}
+		f := a.files[i]
+		if f.offset >= 0 {
+			return readerAt{io.NewSectionReader(a.r, f.offset, entry.Size)}, entry.Size, nil
+		}
+		reader := util.NewStreamReader(a.ctx, entry.Size, func() (io.ReadCloser, error) {
+			return a.openFile(f, entry.Size)
+		})
+		return reader, entry.Size, nil
+	}
+	return nil, 0, fmt.Errorf("file not found in tar: %s", name)
+}
+
+// openFile opens f for reading, directly if its data is stored contiguously,
+// or by reading the archive up to it.
+func (a *TarArchive) openFile(f file, size int64) (io.ReadCloser, error) {
+	if f.offset >= 0 {
+		return io.NopCloser(io.NewSectionReader(a.r, f.offset, size)), nil
+	}
+
+	tr, _, closer, err := a.open()
+	if err != nil {
+		return nil, err
+	}
+	for index := 0; index <= f.index; index++ {
+		if _, err := tr.Next(); err != nil {
+			closer.Close()
+			return nil, fmt.Errorf("failed to read tar: %w", err)
+		}
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{tr, closer}, nil
+}
+
+// readerAt is a section of the archive as a util.RandomAccessReader.
+type readerAt struct {
+	*io.SectionReader
+}
+
+func (readerAt) Close() error {
+	return nil
+}
@@ -0,0 +1,90 @@
+package tar
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"testing"
+)
+
+var files = []struct {
+	name string
+	data string
+}{
+	{"game.bin", "not really a game\n"},
+	{"docs/readme.txt", "Hello from a tar archive.\n"},
+}
+
+// tarBytes returns a tar archive of files, with a directory entry first.
+func tarBytes(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := tar.NewWriter(&buf)
+	if err := w.WriteHeader(&tar.Header{Name: "docs/", Typeflag: tar.TypeDir, Mode: 0o755}); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range files {
+		if err := w.WriteHeader(&tar.Header{Name: f.name, Mode: 0o644, Size: int64(len(f.data))}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(f.data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestNewArchive(t *testing.T) {
+	plain := tarBytes(t)
+	var gz bytes.Buffer
+	w := gzip.NewWriter(&gz)
+	w.Write(plain)
+	w.Close()
+
+	tests := []struct {
+		name        string
+		data        []byte
+		compression string
+	}{
+		{"tar", plain, ""},
+		{"tar.gz", gz.Bytes(), ".gz"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			archive, err := NewArchive(context.Background(), bytes.NewReader(tt.data), int64(len(tt.data)), tt.compression)
+			if err != nil {
+				t.Fatalf("NewArchive() error = %v", err)
+			}
+			defer archive.Close()
+
+			entries := archive.Entries()
+			if len(entries) != len(files) {
+				t.Fatalf("Expected %d entries, got %d", len(files), len(entries))
+			}
+			// Read in reverse, so compressed files are read from the start
+			for i := len(files) - 1; i >= 0; i-- {
+				f := files[i]
+				if entries[i].Name != f.name || entries[i].Size != int64(len(f.data)) {
+					t.Errorf("Entries()[%d] = %+v, want %s of %d bytes", i, entries[i], f.name, len(f.data))
+				}
+				reader, size, err := archive.OpenFileAt(f.name)
+				if err != nil {
+					t.Fatalf("OpenFileAt(%s) error = %v", f.name, err)
+				}
+				got, err := io.ReadAll(io.NewSectionReader(reader, 0, size))
+				reader.Close()
+				if err != nil {
+					t.Fatalf("Reading %s error = %v", f.name, err)
+				}
+				if string(got) != f.data {
+					t.Errorf("%s = %q, want %q", f.name, got, f.data)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,303 @@
+package zip
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/sha1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+
+	"github.com/sargunv/rom-tools/internal/util"
+)
+
+const (
+	// flagEncrypted is the general purpose flag of encrypted entries.
+	flagEncrypted = 0x1
+	// flagDataDescriptor is the general purpose flag of entries whose CRC32
+	// follows their data, so the traditional encryption header is checked
+	// against the modification time instead.
+	flagDataDescriptor = 0x8
+
+	// methodAES is the compression method of WinZip AES encrypted entries,
+	// whose real method is in their AES extra field.
+	methodAES = 99
+	// aesExtraID is the extra field ID of WinZip AES encrypted entries.
+	aesExtraID = 0x9901
+	// aesKeyIterations is the PBKDF2 iteration count of WinZip AES keys.
+	aesKeyIterations = 1000
+	// aesVerifierSize and aesAuthCodeSize are the sizes of the password
+	// verifier before, and the HMAC after, AES encrypted data.
+	aesVerifierSize = 2
+	aesAuthCodeSize = 10
+
+	// cryptHeaderSize is the size of the traditional encryption header.
+	cryptHeaderSize = 12
+)
+
+// isEncrypted reports whether f is encrypted, with traditional PKWARE or
+// WinZip AES encryption.
+func isEncrypted(f *zip.File) bool {
+	return f.Flags&flagEncrypted != 0 || f.Method == methodAES
+}
+
+// openEntry opens f for reading its decompressed content, decrypting it with
+// password if it's encrypted.
+func openEntry(f *zip.File, password string) (io.ReadCloser, error) {
+	if !isEncrypted(f) {
+		return f.Open()
+	}
+	if password == "" {
+		return nil, fmt.Errorf("%s: %w", f.Name, util.ErrPasswordRequired)
+	}
+
+	raw, err := f.OpenRaw()
+	if err != nil {
+		return nil, err
+	}
+	var data io.Reader
+	method := f.Method
+	checkCRC := true
+	if f.Method == methodAES {
+		aesMethod, version, strength, err := parseAESExtra(f.Extra)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Name, err)
+		}
+		data, err = newAESReader(raw, int64(f.CompressedSize64), strength, password)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Name, err)
+		}
+		// AE-2 entries have no CRC32, so the HMAC, checked by the AES
+		// reader, is their only check
+		method, checkCRC = aesMethod, version == 1
+	} else {
+		check := byte(f.CRC32 >> 24)
+		if f.Flags&flagDataDescriptor != 0 {
+			check = byte(f.ModifiedTime >> 8)
+		}
+		data, err = newCryptReader(raw, password, check)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Name, err)
+		}
+	}
+
+	var rc io.ReadCloser
+	switch method {
+	case zip.Store:
+		rc = io.NopCloser(data)
+	case zip.Deflate:
+		rc = flate.NewReader(data)
+	default:
+		return nil, fmt.Errorf("%s: %w", f.Name, zip.ErrAlgorithm)
+	}
+	if !checkCRC {
+		return rc, nil
+	}
+	return &checksumReader{rc: rc, hash: crc32.NewIEEE(), want: f.CRC32}, nil
+}
+
+// parseAESExtra returns the real compression method, the AE-1 or AE-2
+// version, and the key strength (1-3 for 128-256 bits) from the WinZip AES
+// extra field.
+func parseAESExtra(extra []byte) (method uint16, version uint16, strength byte, err error) {
+	for len(extra) >= 4 {
+		id := binary.LittleEndian.Uint16(extra)
+		size := int(binary.LittleEndian.Uint16(extra[2:]))
+		extra = extra[4:]
+		if size > len(extra) {
+			break
+		}
+		if id == aesExtraID && size >= 7 {
+			field := extra[:size]
+			return binary.LittleEndian.Uint16(field[5:]), binary.LittleEndian.Uint16(field), field[4], nil
+		}
+		extra = extra[size:]
+	}
+	return 0, 0, 0, errors.New("missing WinZip AES extra field")
+}
+
+// newAESReader returns a reader of the decrypted data of a WinZip AES
+// encrypted entry, whose stored data (salt, password verifier, encrypted
+// data, and HMAC) is size bytes of raw.
+func newAESReader(raw io.Reader, size int64, strength byte, password string) (io.Reader, error) {
+	if strength < 1 || strength > 3 {
+		return nil, fmt.Errorf("invalid AES key strength %d", strength)
+	}
+	keySize := 8 + 8*int(strength)
+	saltSize := keySize / 2
+	dataSize := size - int64(saltSize+aesVerifierSize+aesAuthCodeSize)
+	if dataSize < 0 {
+		return nil, errors.New("AES encrypted entry is truncated")
+	}
+
+	header := make([]byte, saltSize+aesVerifierSize)
+	if _, err := io.ReadFull(raw, header); err != nil {
+		return nil, err
+	}
+	keys, err := pbkdf2.Key(sha1.New, password, header[:saltSize], aesKeyIterations, 2*keySize+aesVerifierSize)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(keys[2*keySize:], header[saltSize:]) {
+		return nil, util.ErrWrongPassword
+	}
+
+	block, err := aes.NewCipher(keys[:keySize])
+	if err != nil {
+		return nil, err
+	}
+	return &aesReader{
+		raw:    raw,
+		data:   io.LimitReader(raw, dataSize),
+		stream: &winZipCTR{block: block, used: aes.BlockSize},
+		mac:    hmac.New(sha1.New, keys[keySize:2*keySize]),
+	}, nil
+}
+
+// aesReader decrypts the data of a WinZip AES encrypted entry, checking the
+// HMAC-SHA1 of the encrypted data against the auth code after it once it's
+// all been read.
+type aesReader struct {
+	raw    io.Reader // stored data after the password verifier
+	data   io.Reader // encrypted data of raw
+	stream cipher.Stream
+	mac    hash.Hash
+	err    error // result of the auth code check, once done
+}
+
+func (a *aesReader) Read(p []byte) (int, error) {
+	if a.err != nil {
+		return 0, a.err
+	}
+	n, err := a.data.Read(p)
+	a.mac.Write(p[:n])
+	a.stream.XORKeyStream(p[:n], p[:n])
+	if err != io.EOF {
+		return n, err
+	}
+
+	authCode := make([]byte, aesAuthCodeSize)
+	if _, err := io.ReadFull(a.raw, authCode); err != nil {
+		a.err = fmt.Errorf("failed to read AES auth code: %w", err)
+	} else if !hmac.Equal(a.mac.Sum(nil)[:aesAuthCodeSize], authCode) {
+		a.err = zip.ErrChecksum
+	} else {
+		a.err = io.EOF
+	}
+	return n, a.err
+}
+
+// winZipCTR is AES in counter mode as WinZip uses it: a little-endian
+// counter starting from 1, rather than crypto/cipher's big-endian one.
+type winZipCTR struct {
+	block   cipher.Block
+	counter uint64
+	stream  [aes.BlockSize]byte
+	used    int // bytes of stream used
+}
+
+// XORKeyStream implements cipher.Stream.
+func (c *winZipCTR) XORKeyStream(dst, src []byte) {
+	for i := range src {
+		if c.used == aes.BlockSize {
+			c.counter++
+			var counter [aes.BlockSize]byte
+			binary.LittleEndian.PutUint64(counter[:], c.counter)
+			c.block.Encrypt(c.stream[:], counter[:])
+			c.used = 0
+		}
+		dst[i] = src[i] ^ c.stream[c.used]
+		c.used++
+	}
+}
+
+// cryptKeys is the state of traditional PKWARE encryption.
+type cryptKeys [3]uint32
+
+// newCryptKeys returns the keys initialized with password.
+func newCryptKeys(password string) *cryptKeys {
+	k := &cryptKeys{0x12345678, 0x23456789, 0x34567890}
+	for i := 0; i < len(password); i++ {
+		k.update(password[i])
+	}
+	return k
+}
+
+// update mixes a plaintext byte into the keys.
+func (k *cryptKeys) update(b byte) {
+	k[0] = crc32Update(k[0], b)
+	k[1] = (k[1]+k[0]&0xff)*134775813 + 1
+	k[2] = crc32Update(k[2], byte(k[1]>>24))
+}
+
+// decrypt decrypts buf in place.
+func (k *cryptKeys) decrypt(buf []byte) {
+	for i, c := range buf {
+		t := k[2] | 2
+		b := c ^ byte((t*(t^1))>>8)
+		k.update(b)
+		buf[i] = b
+	}
+}
+
+// crc32Update updates a raw (not inverted) CRC32 with one byte, as
+// traditional encryption does.
+func crc32Update(crc uint32, b byte) uint32 {
+	return crc32.IEEETable[byte(crc)^b] ^ crc>>8
+}
+
+// cryptReader decrypts an entry's data with traditional PKWARE encryption.
+type cryptReader struct {
+	r    io.Reader
+	keys *cryptKeys
+}
+
+// newCryptReader returns a reader of the decrypted data of raw, checking the
+// last byte of its encryption header against check to catch wrong passwords.
+func newCryptReader(raw io.Reader, password string, check byte) (io.Reader, error) {
+	keys := newCryptKeys(password)
+	header := make([]byte, cryptHeaderSize)
+	if _, err := io.ReadFull(raw, header); err != nil {
+		return nil, err
+	}
+	keys.decrypt(header)
+	if header[cryptHeaderSize-1] != check {
+		return nil, util.ErrWrongPassword
+	}
+	return &cryptReader{r: raw, keys: keys}, nil
+}
+
+func (c *cryptReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.keys.decrypt(p[:n])
+	return n, err
+}
+
+// checksumReader checks the CRC32 of the data read once it's all been read,
+// as zip.File.Open does.
+type checksumReader struct {
+	rc   io.ReadCloser
+	hash hash.Hash32
+	want uint32
+}
+
+func (c *checksumReader) Read(p []byte) (int, error) {
+	n, err := c.rc.Read(p)
+	c.hash.Write(p[:n])
+	if err == io.EOF && c.hash.Sum32() != c.want {
+		return n, zip.ErrChecksum
+	}
+	return n, err
+}
+
+func (c *checksumReader) Close() error {
+	return c.rc.Close()
+}
@@ -0,0 +1,167 @@
+package zip
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/aes"
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/sha1"
+	"encoding/binary"
+	"errors"
+	"io"
+	"slices"
+	"testing"
+
+	"github.com/sargunv/rom-tools/internal/util"
+)
+
+const encryptedContent = "hello from an encrypted archive\n"
+
+func TestEncryptedEntries(t *testing.T) {
+	tests := []struct {
+		path string
+		name string
+	}{
+		{"testdata/encrypted.zip", "readme.txt"},
+		{"testdata/encrypted-streamed.zip", "-"}, // checked against the time, with a data descriptor
+	}
+	for _, tt := range tests {
+		archive, err := Open(tt.path)
+		if err != nil {
+			t.Fatalf("Open(%s) error = %v", tt.path, err)
+		}
+		defer archive.Close()
+
+		if _, err := readEntry(archive, tt.name); !errors.Is(err, util.ErrPasswordRequired) {
+			t.Errorf("%s without a password error = %v, want ErrPasswordRequired", tt.path, err)
+		}
+
+		archive.SetPassword("wrong")
+		if _, err := readEntry(archive, tt.name); !errors.Is(err, util.ErrWrongPassword) {
+			t.Errorf("%s with the wrong password error = %v, want ErrWrongPassword", tt.path, err)
+		}
+
+		archive.SetPassword("secret")
+		data, err := readEntry(archive, tt.name)
+		if err != nil {
+			t.Fatalf("%s with the password error = %v", tt.path, err)
+		}
+		if want := bytes.Repeat([]byte(encryptedContent), 4); !bytes.Equal(data, want) {
+			t.Errorf("%s content = %q, want %q", tt.path, data, want)
+		}
+	}
+}
+
+func TestAESEncryptedEntry(t *testing.T) {
+	content := bytes.Repeat([]byte(encryptedContent), 4)
+	data := aesZIP(t, "readme.txt", content, "secret")
+	archive, err := NewArchive(t.Context(), bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("NewArchive() error = %v", err)
+	}
+
+	archive.SetPassword("wrong")
+	if _, err := readEntry(archive, "readme.txt"); !errors.Is(err, util.ErrWrongPassword) {
+		t.Errorf("With the wrong password error = %v, want ErrWrongPassword", err)
+	}
+
+	archive.SetPassword("secret")
+	got, err := readEntry(archive, "readme.txt")
+	if err != nil {
+		t.Fatalf("With the password error = %v", err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Errorf("Content = %q, want %q", got, content)
+	}
+
+	// A corrupted auth code fails the entry, as a wrong password that gets
+	// past the 2-byte verifier would
+	i := bytes.Index(data, aesAuthCode(t, content, "secret"))
+	if i < 0 {
+		t.Fatal("auth code not found in the archive")
+	}
+	data[i] ^= 0xFF
+	archive, err = NewArchive(t.Context(), bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("NewArchive() error = %v", err)
+	}
+	archive.SetPassword("secret")
+	if _, err := readEntry(archive, "readme.txt"); !errors.Is(err, zip.ErrChecksum) {
+		t.Errorf("With a corrupted auth code error = %v, want zip.ErrChecksum", err)
+	}
+}
+
+// readEntry reads a whole entry with OpenFileAt.
+func readEntry(archive *ZIPArchive, name string) ([]byte, error) {
+	reader, size, err := archive.OpenFileAt(name)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(io.NewSectionReader(reader, 0, size))
+}
+
+// aesTestSalt is the salt of the entries of aesZIP.
+var aesTestSalt = bytes.Repeat([]byte{0x5a}, 16)
+
+// aesEncrypt returns the password verifier, encrypted content, and auth code
+// of content encrypted with 256-bit WinZip AES.
+func aesEncrypt(t *testing.T, content []byte, password string) (verifier, encrypted, authCode []byte) {
+	t.Helper()
+	keys, err := pbkdf2.Key(sha1.New, password, aesTestSalt, aesKeyIterations, 2*32+aesVerifierSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := aes.NewCipher(keys[:32])
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted = make([]byte, len(content))
+	(&winZipCTR{block: block, used: aes.BlockSize}).XORKeyStream(encrypted, content)
+
+	mac := hmac.New(sha1.New, keys[32:64])
+	mac.Write(encrypted)
+	return keys[64:], encrypted, mac.Sum(nil)[:aesAuthCodeSize]
+}
+
+// aesAuthCode returns the auth code of the entries of aesZIP.
+func aesAuthCode(t *testing.T, content []byte, password string) []byte {
+	t.Helper()
+	_, _, authCode := aesEncrypt(t, content, password)
+	return authCode
+}
+
+// aesZIP returns a ZIP archive of one stored AE-2 entry, encrypted with
+// 256-bit WinZip AES.
+func aesZIP(t *testing.T, name string, content []byte, password string) []byte {
+	t.Helper()
+	verifier, encrypted, authCode := aesEncrypt(t, content, password)
+	raw := append(append(slices.Clone(aesTestSalt), verifier...), encrypted...)
+	raw = append(raw, authCode...)
+
+	extra := binary.LittleEndian.AppendUint16(nil, aesExtraID)
+	extra = binary.LittleEndian.AppendUint16(extra, 7)
+	extra = binary.LittleEndian.AppendUint16(extra, 2) // AE-2
+	extra = append(extra, 'A', 'E', 3)                 // 256-bit key
+	extra = binary.LittleEndian.AppendUint16(extra, zip.Store)
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.CreateRaw(&zip.FileHeader{
+		Name:               name,
+		Method:             methodAES,
+		Flags:              flagEncrypted,
+		Extra:              extra,
+		CompressedSize64:   uint64(len(raw)),
+		UncompressedSize64: uint64(len(content)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write(raw)
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
@@ -6,6 +6,7 @@ package zip
 
 import (
 	"archive/zip"
+	"context"
 	"fmt"
 	"io"
 	"sync"
@@ -15,12 +16,15 @@ import (
 // It decompresses data lazily, only reading as much as needed to satisfy ReadAt requests.
 // Data is buffered so subsequent reads don't re-decompress.
 type EntryReader struct {
-	file   *zip.File
-	mu     sync.Mutex
-	buffer []byte
-	reader io.ReadCloser
-	err    error // sticky error from decompression
-	pos    int64 // current position for Seek/Read
+	file     *zip.File
+	mu       sync.Mutex
+	buffer   []byte
+	reader   io.ReadCloser
+	err      error           // sticky error from decompression
+	pos      int64           // current position for Seek/Read
+	ctx      context.Context // stops decompression once done, if set
+	password string          // decrypts encrypted entries, if set
+	eof      bool            // whether reader has returned io.EOF
 }
 
 // NewEntryReader creates a new EntryReader for random access to a ZIP entry.
@@ -105,7 +109,7 @@ func (r *EntryReader) ReadAt(p []byte, off int64) (n int, err error) {
 func (r *EntryReader) decompressTo(needed int64) error {
 	// Open reader if not already open
 	if r.reader == nil {
-		rd, err := r.file.Open()
+		rd, err := openEntry(r.file, r.password)
 		if err != nil {
 			return fmt.Errorf("failed to open ZIP entry: %w", err)
 		}
@@ -126,11 +130,17 @@ func (r *EntryReader) decompressTo(needed int64) error {
 
 	buf := make([]byte, chunkSize)
 	for int64(len(r.buffer)) < needed {
+		if r.ctx != nil {
+			if err := r.ctx.Err(); err != nil {
+				return err
+			}
+		}
 		n, err := r.reader.Read(buf)
 		if n > 0 {
 			r.buffer = append(r.buffer, buf[:n]...)
 		}
 		if err == io.EOF {
+			r.eof = true
 			break
 		}
 		if err != nil {
@@ -138,6 +148,21 @@ func (r *EntryReader) decompressTo(needed int64) error {
 		}
 	}
 
+	// Read to the end once the whole entry is buffered, so the checks done
+	// there (the CRC32, or the HMAC of AES encrypted entries) catch corrupt
+	// data and wrong passwords that get past the password check
+	for !r.eof && int64(len(r.buffer)) >= int64(r.file.UncompressedSize64) {
+		n, err := r.reader.Read(buf[:1])
+		if n > 0 {
+			return fmt.Errorf("ZIP entry is longer than its size of %d bytes", r.file.UncompressedSize64)
+		}
+		if err == io.EOF {
+			r.eof = true
+		} else if err != nil {
+			return fmt.Errorf("failed to decompress ZIP entry: %w", err)
+		}
+	}
+
 	return nil
 }
 
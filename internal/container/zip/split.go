@@ -0,0 +1,409 @@
+package zip
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// Record signatures
+	eocdSignature        = 0x06054b50
+	eocd64Signature      = 0x06064b50
+	eocd64LocSignature   = 0x07064b50
+	centralDirSignature  = 0x02014b50
+	zip64ExtraID         = 0x0001
+	eocdSize             = 22
+	eocd64Size           = 56
+	eocd64LocSize        = 20
+	centralDirHeaderSize = 46
+
+	// maxCommentSize bounds how far from the end the end of central directory
+	// record is searched for
+	maxCommentSize = 0xffff
+
+	// maxSplitParts bounds how many .z01, .z02, ... parts are looked for
+	maxSplitParts = 9999
+)
+
+// SplitParts returns the parts of the split archive whose last part is the
+// .zip at path, in order (e.g., game.z01, game.z02, game.zip), or nil if
+// there's no game.z01 next to it.
+func SplitParts(path string) []string {
+	ext := filepath.Ext(path)
+	if !strings.EqualFold(ext, ".zip") {
+		return nil
+	}
+	base := strings.TrimSuffix(path, ext)
+	prefix := ext[:2] // ".z" or ".Z", matching the .zip's case
+
+	var parts []string
+	for i := 1; i <= maxSplitParts; i++ {
+		part := fmt.Sprintf("%s%s%02d", base, prefix, i)
+		if _, err := os.Stat(part); err != nil {
+			break
+		}
+		parts = append(parts, part)
+	}
+	if len(parts) == 0 {
+		return nil
+	}
+	return append(parts, path)
+}
+
+// OpenSplitContext opens a split archive from its parts, in order, as
+// listed by SplitParts. Each part is a disk of the archive, which is read
+// as though it were one file. Entries opened with OpenFileAt stop
+// decompressing once ctx is done.
+func OpenSplitContext(ctx context.Context, parts []string) (*ZIPArchive, error) {
+	files := make(multiCloser, 0, len(parts))
+	disks := make([]io.ReaderAt, len(parts))
+	sizes := make([]int64, len(parts))
+	for i, part := range parts {
+		f, err := os.Open(part)
+		if err != nil {
+			files.Close()
+			return nil, fmt.Errorf("failed to open ZIP part: %w", err)
+		}
+		files = append(files, f)
+		info, err := f.Stat()
+		if err != nil {
+			files.Close()
+			return nil, fmt.Errorf("failed to open ZIP part: %w", err)
+		}
+		disks[i], sizes[i] = f, info.Size()
+	}
+
+	r, size, err := newSplitReader(disks, sizes)
+	if err != nil {
+		files.Close()
+		return nil, fmt.Errorf("failed to open split ZIP: %w", err)
+	}
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		files.Close()
+		return nil, fmt.Errorf("failed to open split ZIP: %w", err)
+	}
+	archive := newArchive(ctx, zr)
+	archive.closer = files
+	return archive, nil
+}
+
+// multiCloser closes each of its closers.
+type multiCloser []io.Closer
+
+func (m multiCloser) Close() error {
+	var errs []error
+	for _, c := range m {
+		errs = append(errs, c.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// directoryEnd is the location of an archive's central directory, from its
+// end of central directory record.
+type directoryEnd struct {
+	disk    uint32 // number of the disk holding the record, i.e. the last
+	dirDisk uint32 // number of the disk where the central directory starts
+	records uint64
+	size    uint64
+	offset  uint64 // relative to the start of dirDisk
+}
+
+// readDirectoryEnd reads the end of central directory record from the end
+// of r, following it to the ZIP64 record if there is one.
+func readDirectoryEnd(r io.ReaderAt, size int64) (*directoryEnd, error) {
+	tail := make([]byte, min(size, eocdSize+maxCommentSize))
+	tailOffset := size - int64(len(tail))
+	if _, err := r.ReadAt(tail, tailOffset); err != nil {
+		return nil, err
+	}
+	pos := -1
+	for i := len(tail) - eocdSize; i >= 0; i-- {
+		if binary.LittleEndian.Uint32(tail[i:]) == eocdSignature {
+			pos = i
+			break
+		}
+	}
+	if pos < 0 {
+		return nil, errors.New("no end of central directory record")
+	}
+
+	b := tail[pos:]
+	end := &directoryEnd{
+		disk:    uint32(binary.LittleEndian.Uint16(b[4:])),
+		dirDisk: uint32(binary.LittleEndian.Uint16(b[6:])),
+		records: uint64(binary.LittleEndian.Uint16(b[10:])),
+		size:    uint64(binary.LittleEndian.Uint32(b[12:])),
+		offset:  uint64(binary.LittleEndian.Uint32(b[16:])),
+	}
+
+	// A ZIP64 locator precedes the record when any field overflowed
+	locOffset := tailOffset + int64(pos) - eocd64LocSize
+	if locOffset < 0 {
+		return end, nil
+	}
+	loc := make([]byte, eocd64LocSize)
+	if _, err := r.ReadAt(loc, locOffset); err != nil {
+		return nil, err
+	}
+	if binary.LittleEndian.Uint32(loc) != eocd64LocSignature {
+		return end, nil
+	}
+
+	// The ZIP64 record is on the last disk too, unless it was split from it
+	// mid-record, which isn't supported
+	offset := int64(binary.LittleEndian.Uint64(loc[8:]))
+	b = make([]byte, eocd64Size)
+	if offset < 0 || offset+eocd64Size > size {
+		return nil, errors.New("ZIP64 end of central directory record isn't on the last disk")
+	}
+	if _, err := r.ReadAt(b, offset); err != nil {
+		return nil, err
+	}
+	if binary.LittleEndian.Uint32(b) != eocd64Signature {
+		return nil, errors.New("invalid ZIP64 end of central directory record")
+	}
+	end.disk = binary.LittleEndian.Uint32(b[16:])
+	end.dirDisk = binary.LittleEndian.Uint32(b[20:])
+	end.records = binary.LittleEndian.Uint64(b[32:])
+	end.size = binary.LittleEndian.Uint64(b[40:])
+	end.offset = binary.LittleEndian.Uint64(b[48:])
+	return end, nil
+}
+
+// isSplit reports whether the archive in r is the last part of a split
+// archive, which can't be read without the others.
+func isSplit(r io.ReaderAt, size int64) bool {
+	end, err := readDirectoryEnd(r, size)
+	return err == nil && end.disk > 0
+}
+
+// splitReader reads a split archive's disks as one single-disk archive: the
+// disks in order, followed by a rewritten central directory whose offsets
+// are from the start of the first disk.
+type splitReader struct {
+	disks   []io.ReaderAt
+	offsets []int64 // start of each disk
+	size    int64   // total size of the disks
+	tail    []byte  // rewritten central directory and end records
+}
+
+// newSplitReader returns a reader of the archive split into disks, and its
+// size.
+func newSplitReader(disks []io.ReaderAt, sizes []int64) (*splitReader, int64, error) {
+	s := &splitReader{disks: disks, offsets: make([]int64, len(disks))}
+	for i, size := range sizes {
+		s.offsets[i] = s.size
+		s.size += size
+	}
+
+	last := len(disks) - 1
+	end, err := readDirectoryEnd(disks[last], sizes[last])
+	if err != nil {
+		return nil, 0, err
+	}
+	if int(end.disk) != last {
+		return nil, 0, fmt.Errorf("archive has %d disks, found %d", end.disk+1, len(disks))
+	}
+	if int(end.dirDisk) > last || end.size > math.MaxInt32 {
+		return nil, 0, errors.New("invalid central directory location")
+	}
+
+	dir := make([]byte, end.size)
+	dirOffset := s.offsets[end.dirDisk] + int64(end.offset)
+	if _, err := s.ReadAt(dir, dirOffset); err != nil {
+		return nil, 0, fmt.Errorf("failed to read central directory: %w", err)
+	}
+	newDir, records, err := s.rewriteDirectory(dir)
+	if err != nil {
+		return nil, 0, err
+	}
+	if records != end.records {
+		return nil, 0, fmt.Errorf("central directory has %d records, expected %d", records, end.records)
+	}
+	s.tail = appendDirectoryEnd(newDir, records, uint64(s.size))
+	return s, s.size + int64(len(s.tail)), nil
+}
+
+// rewriteDirectory returns the central directory with each record's local
+// header offset made relative to the start of the first disk, and its disk
+// number made 0.
+func (s *splitReader) rewriteDirectory(dir []byte) ([]byte, uint64, error) {
+	var out []byte
+	var records uint64
+	for len(dir) > 0 {
+		if len(dir) < centralDirHeaderSize || binary.LittleEndian.Uint32(dir) != centralDirSignature {
+			return nil, 0, errors.New("invalid central directory record")
+		}
+		nameLen := int(binary.LittleEndian.Uint16(dir[28:]))
+		extraLen := int(binary.LittleEndian.Uint16(dir[30:]))
+		commentLen := int(binary.LittleEndian.Uint16(dir[32:]))
+		recordLen := centralDirHeaderSize + nameLen + extraLen + commentLen
+		if recordLen > len(dir) {
+			return nil, 0, errors.New("truncated central directory record")
+		}
+		header := bytes.Clone(dir[:centralDirHeaderSize])
+		name := dir[centralDirHeaderSize : centralDirHeaderSize+nameLen]
+		extra := dir[centralDirHeaderSize+nameLen : centralDirHeaderSize+nameLen+extraLen]
+		comment := dir[centralDirHeaderSize+nameLen+extraLen : recordLen]
+		dir = dir[recordLen:]
+
+		// Values too large for the header are in the ZIP64 extra field, in
+		// this order
+		uncompressed := uint64(binary.LittleEndian.Uint32(header[24:]))
+		compressed := uint64(binary.LittleEndian.Uint32(header[20:]))
+		offset := uint64(binary.LittleEndian.Uint32(header[42:]))
+		disk := uint32(binary.LittleEndian.Uint16(header[34:]))
+		zip64, otherExtra := splitZip64Extra(extra)
+		for _, value := range []*uint64{&uncompressed, &compressed, &offset} {
+			if *value == math.MaxUint32 {
+				if len(zip64) < 8 {
+					return nil, 0, errors.New("invalid ZIP64 extra field")
+				}
+				*value = binary.LittleEndian.Uint64(zip64)
+				zip64 = zip64[8:]
+			}
+		}
+		if disk == math.MaxUint16 {
+			if len(zip64) < 4 {
+				return nil, 0, errors.New("invalid ZIP64 extra field")
+			}
+			disk = binary.LittleEndian.Uint32(zip64)
+		}
+		if int(disk) >= len(s.disks) {
+			return nil, 0, fmt.Errorf("central directory record on missing disk %d", disk)
+		}
+		offset += uint64(s.offsets[disk])
+
+		var newZip64 []byte
+		if binary.LittleEndian.Uint32(header[24:]) == math.MaxUint32 {
+			newZip64 = binary.LittleEndian.AppendUint64(newZip64, uncompressed)
+		}
+		if binary.LittleEndian.Uint32(header[20:]) == math.MaxUint32 {
+			newZip64 = binary.LittleEndian.AppendUint64(newZip64, compressed)
+		}
+		if offset >= math.MaxUint32 {
+			newZip64 = binary.LittleEndian.AppendUint64(newZip64, offset)
+			offset = math.MaxUint32
+		}
+		binary.LittleEndian.PutUint32(header[42:], uint32(offset))
+		binary.LittleEndian.PutUint16(header[34:], 0)
+
+		newExtra := otherExtra
+		if len(newZip64) > 0 {
+			newExtra = binary.LittleEndian.AppendUint16(nil, zip64ExtraID)
+			newExtra = binary.LittleEndian.AppendUint16(newExtra, uint16(len(newZip64)))
+			newExtra = append(append(newExtra, newZip64...), otherExtra...)
+		}
+		if len(newExtra) > math.MaxUint16 {
+			return nil, 0, errors.New("central directory record extra field too large")
+		}
+		binary.LittleEndian.PutUint16(header[30:], uint16(len(newExtra)))
+
+		out = append(out, header...)
+		out = append(out, name...)
+		out = append(out, newExtra...)
+		out = append(out, comment...)
+		records++
+	}
+	return out, records, nil
+}
+
+// splitZip64Extra returns the data of the ZIP64 field of an extra field, and
+// the other fields.
+func splitZip64Extra(extra []byte) (zip64, other []byte) {
+	for len(extra) >= 4 {
+		id := binary.LittleEndian.Uint16(extra)
+		size := int(binary.LittleEndian.Uint16(extra[2:]))
+		if 4+size > len(extra) {
+			break
+		}
+		if id == zip64ExtraID {
+			zip64 = extra[4 : 4+size]
+		} else {
+			other = append(other, extra[:4+size]...)
+		}
+		extra = extra[4+size:]
+	}
+	return zip64, other
+}
+
+// appendDirectoryEnd appends the end of central directory records of a
+// single-disk archive to its central directory, which starts at offset.
+func appendDirectoryEnd(dir []byte, records, offset uint64) []byte {
+	size := uint64(len(dir))
+	if records >= math.MaxUint16 || size >= math.MaxUint32 || offset >= math.MaxUint32 {
+		eocd64Offset := offset + size
+		dir = binary.LittleEndian.AppendUint32(dir, eocd64Signature)
+		dir = binary.LittleEndian.AppendUint64(dir, eocd64Size-12) // size of the rest of the record
+		dir = binary.LittleEndian.AppendUint16(dir, 45)            // version made by
+		dir = binary.LittleEndian.AppendUint16(dir, 45)            // version needed to extract
+		dir = binary.LittleEndian.AppendUint32(dir, 0)             // number of this disk
+		dir = binary.LittleEndian.AppendUint32(dir, 0)             // disk where the central directory starts
+		dir = binary.LittleEndian.AppendUint64(dir, records)
+		dir = binary.LittleEndian.AppendUint64(dir, records)
+		dir = binary.LittleEndian.AppendUint64(dir, size)
+		dir = binary.LittleEndian.AppendUint64(dir, offset)
+
+		dir = binary.LittleEndian.AppendUint32(dir, eocd64LocSignature)
+		dir = binary.LittleEndian.AppendUint32(dir, 0) // disk with the ZIP64 record
+		dir = binary.LittleEndian.AppendUint64(dir, eocd64Offset)
+		dir = binary.LittleEndian.AppendUint32(dir, 1) // total number of disks
+
+		records, size, offset = math.MaxUint16, math.MaxUint32, math.MaxUint32
+	}
+	dir = binary.LittleEndian.AppendUint32(dir, eocdSignature)
+	dir = binary.LittleEndian.AppendUint16(dir, 0) // number of this disk
+	dir = binary.LittleEndian.AppendUint16(dir, 0) // disk where the central directory starts
+	dir = binary.LittleEndian.AppendUint16(dir, uint16(records))
+	dir = binary.LittleEndian.AppendUint16(dir, uint16(records))
+	dir = binary.LittleEndian.AppendUint32(dir, uint32(size))
+	dir = binary.LittleEndian.AppendUint32(dir, uint32(offset))
+	dir = binary.LittleEndian.AppendUint16(dir, 0) // comment length
+	return dir
+}
+
+// ReadAt implements io.ReaderAt.
+func (s *splitReader) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("negative offset")
+	}
+	n := 0
+	for n < len(p) {
+		pos := off + int64(n)
+		if pos >= s.size {
+			tailPos := pos - s.size
+			if tailPos >= int64(len(s.tail)) {
+				return n, io.EOF
+			}
+			n += copy(p[n:], s.tail[tailPos:])
+			continue
+		}
+
+		// Find the disk holding pos
+		disk := len(s.offsets) - 1
+		for disk > 0 && s.offsets[disk] > pos {
+			disk--
+		}
+		end := s.size
+		if disk+1 < len(s.offsets) {
+			end = s.offsets[disk+1]
+		}
+		chunk := p[n:min(len(p), n+int(end-pos))]
+		m, err := s.disks[disk].ReadAt(chunk, pos-s.offsets[disk])
+		n += m
+		if err != nil && !(err == io.EOF && m == len(chunk)) {
+			return n, err
+		}
+	}
+	return n, nil
+}
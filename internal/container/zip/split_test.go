@@ -0,0 +1,62 @@
+package zip
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/sargunv/rom-tools/lib/core"
+)
+
+func TestSplitParts(t *testing.T) {
+	want := []string{"testdata/split.z01", "testdata/split.zip"}
+	if got := SplitParts("testdata/split.zip"); !slices.Equal(got, want) {
+		t.Errorf("SplitParts() = %q, want %q", got, want)
+	}
+	if got := SplitParts("testdata/gbtictac.gb.zip"); got != nil {
+		t.Errorf("SplitParts() of an unsplit archive = %q, want nil", got)
+	}
+}
+
+func TestOpenSplitContext(t *testing.T) {
+	archive, err := OpenSplitContext(context.Background(), SplitParts("testdata/split.zip"))
+	if err != nil {
+		t.Fatalf("OpenSplitContext() error = %v", err)
+	}
+	defer archive.Close()
+
+	// The first entry spans both parts, and the second is in the last part
+	entries := archive.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(entries))
+	}
+	for _, entry := range entries {
+		reader, size, err := archive.OpenFileAt(entry.Name)
+		if err != nil {
+			t.Fatalf("OpenFileAt(%s) error = %v", entry.Name, err)
+		}
+		data, err := io.ReadAll(io.NewSectionReader(reader, 0, size))
+		reader.Close()
+		if err != nil {
+			t.Fatalf("Reading %s error = %v", entry.Name, err)
+		}
+		if got, want := fmt.Sprintf("%08x", crc32.ChecksumIEEE(data)), entry.Hashes[core.HashZipCRC32]; got != want {
+			t.Errorf("%s CRC32 = %s, want %s", entry.Name, got, want)
+		}
+	}
+}
+
+func TestNewArchive_SplitLastPart(t *testing.T) {
+	data, err := os.ReadFile("testdata/split.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewArchive(context.Background(), bytes.NewReader(data), int64(len(data))); err == nil {
+		t.Error("NewArchive() of the last part of a split archive expected error, got nil")
+	}
+}
@@ -4,8 +4,11 @@ package zip
 
 import (
 	"archive/zip"
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 
 	"github.com/sargunv/rom-tools/internal/util"
 	"github.com/sargunv/rom-tools/lib/core"
@@ -13,8 +16,11 @@ import (
 
 // ZIPArchive represents an open ZIP archive and implements Container.
 type ZIPArchive struct {
-	reader  *zip.ReadCloser
-	entries []util.FileEntry
+	reader   *zip.Reader
+	closer   io.Closer // nil for archives read with NewArchive
+	entries  []util.FileEntry
+	ctx      context.Context
+	password string
 }
 
 // Entries returns all files in the ZIP archive.
@@ -24,14 +30,30 @@ func (z *ZIPArchive) Entries() []util.FileEntry {
 
 // Close closes the ZIP archive.
 func (z *ZIPArchive) Close() error {
-	return z.reader.Close()
+	if z.closer == nil {
+		return nil
+	}
+	return z.closer.Close()
+}
+
+// SetPassword sets the password that encrypted entries are decrypted with.
+// Without one, opening them fails with util.ErrPasswordRequired.
+func (z *ZIPArchive) SetPassword(password string) {
+	z.password = password
+}
+
+// FS returns the archive's contents as an fs.FS. Unlike OpenFileAt, its
+// files are read sequentially, ignore the context given to OpenContext, and
+// can't be decrypted.
+func (z *ZIPArchive) FS() fs.FS {
+	return z.reader
 }
 
 // OpenFile opens a file within the ZIP archive for reading.
 func (z *ZIPArchive) OpenFile(name string) (io.ReadCloser, error) {
 	for _, f := range z.reader.File {
 		if f.Name == name {
-			return f.Open()
+			return openEntry(f, z.password)
 		}
 	}
 	return nil, fmt.Errorf("file not found in ZIP: %s", name)
@@ -43,7 +65,10 @@ func (z *ZIPArchive) OpenFile(name string) (io.ReadCloser, error) {
 func (z *ZIPArchive) OpenFileAt(name string) (util.RandomAccessReader, int64, error) {
 	for _, f := range z.reader.File {
 		if f.Name == name {
-			return NewEntryReader(f), int64(f.UncompressedSize64), nil
+			reader := NewEntryReader(f)
+			reader.ctx = z.ctx
+			reader.password = z.password
+			return reader, int64(f.UncompressedSize64), nil
 		}
 	}
 	return nil, 0, fmt.Errorf("file not found in ZIP: %s", name)
@@ -51,11 +76,37 @@ func (z *ZIPArchive) OpenFileAt(name string) (util.RandomAccessReader, int64, er
 
 // Open opens a ZIP archive and returns metadata for all files.
 func Open(path string) (*ZIPArchive, error) {
+	return OpenContext(context.Background(), path)
+}
+
+// OpenContext is like Open, but entries opened with OpenFileAt stop
+// decompressing once ctx is done.
+func OpenContext(ctx context.Context, path string) (*ZIPArchive, error) {
 	r, err := zip.OpenReader(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open ZIP: %w", err)
 	}
+	archive := newArchive(ctx, &r.Reader)
+	archive.closer = r
+	return archive, nil
+}
 
+// NewArchive reads a ZIP archive of the given size from r, such as an entry
+// of another archive. Entries opened with OpenFileAt stop decompressing once
+// ctx is done. Closing the archive doesn't close r.
+func NewArchive(ctx context.Context, r io.ReaderAt, size int64) (*ZIPArchive, error) {
+	if isSplit(r, size) {
+		return nil, errors.New("failed to open ZIP: it's the last part of a split archive, and needs the .z01 and later parts")
+	}
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ZIP: %w", err)
+	}
+	return newArchive(ctx, zr), nil
+}
+
+// newArchive lists the files of r.
+func newArchive(ctx context.Context, r *zip.Reader) *ZIPArchive {
 	var entries []util.FileEntry
 	for _, f := range r.File {
 		// Skip directories
@@ -75,5 +126,6 @@ func Open(path string) (*ZIPArchive, error) {
 	return &ZIPArchive{
 		reader:  r,
 		entries: entries,
-	}, nil
+		ctx:     ctx,
+	}
 }
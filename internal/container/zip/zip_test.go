@@ -1,8 +1,13 @@
 package zip
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"io"
+	"os"
 	"testing"
+	"testing/fstest"
 
 	"github.com/sargunv/rom-tools/lib/core"
 )
@@ -74,3 +79,56 @@ func TestZIPArchiveOpenFileAt(t *testing.T) {
 		t.Errorf("Expected XISO magic '%s', got '%s'", expectedMagic, string(xisoMagic[:20]))
 	}
 }
+
+func TestOpenContext_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	archive, err := OpenContext(ctx, "testdata/xromwell.xiso.iso.zip")
+	if err != nil {
+		t.Fatalf("OpenContext() error = %v", err)
+	}
+	defer archive.Close()
+
+	reader, _, err := archive.OpenFileAt("xbox.xiso.iso")
+	if err != nil {
+		t.Fatalf("OpenFileAt() error = %v", err)
+	}
+	defer reader.Close()
+
+	cancel()
+	if _, err := reader.ReadAt(make([]byte, 20), 0x10000); !errors.Is(err, context.Canceled) {
+		t.Errorf("ReadAt() after cancel error = %v, want context.Canceled", err)
+	}
+}
+
+func TestZIPArchiveFS(t *testing.T) {
+	archive, err := Open("testdata/gbtictac.gb.zip")
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer archive.Close()
+
+	if err := fstest.TestFS(archive.FS(), "gbtictac.gb"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNewArchive(t *testing.T) {
+	data, err := os.ReadFile("testdata/gbtictac.gb.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	archive, err := NewArchive(context.Background(), bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("NewArchive() error = %v", err)
+	}
+	defer archive.Close()
+
+	entries := archive.Entries()
+	if len(entries) != 1 || entries[0].Name != "gbtictac.gb" {
+		t.Fatalf("Entries() = %+v, want gbtictac.gb", entries)
+	}
+
+	if _, err := NewArchive(context.Background(), bytes.NewReader([]byte("not a zip")), 9); err == nil {
+		t.Error("NewArchive() of non-ZIP data expected error, got nil")
+	}
+}
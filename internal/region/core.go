@@ -0,0 +1,88 @@
+package region
+
+import "github.com/sargunv/rom-tools/lib/core"
+
+// codes maps regions to their Screenscraper region codes
+var codes = map[core.Region]string{
+	core.RegionWorld:      "wor",
+	core.RegionEurope:     "eu",
+	core.RegionAsia:       "asi",
+	core.RegionAmericas:   "ame",
+	core.RegionOceania:    "oce",
+	core.RegionMiddleEast: "mor",
+	core.RegionAfrica:     "afr",
+
+	core.RegionGermany:     "de",
+	core.RegionFrance:      "fr",
+	core.RegionUK:          "uk",
+	core.RegionSpain:       "sp",
+	core.RegionItaly:       "it",
+	core.RegionNetherlands: "nl",
+	core.RegionSweden:      "se",
+	core.RegionDenmark:     "dk",
+	core.RegionFinland:     "fi",
+	core.RegionNorway:      "no",
+	core.RegionPortugal:    "pt",
+	core.RegionPoland:      "pl",
+	core.RegionCzechia:     "cz",
+	core.RegionHungary:     "hu",
+	core.RegionSlovakia:    "sk",
+	core.RegionBulgaria:    "bg",
+	core.RegionGreece:      "gr",
+	core.RegionRussia:      "ru",
+
+	core.RegionJapan:  "jp",
+	core.RegionChina:  "cn",
+	core.RegionKorea:  "kr",
+	core.RegionTaiwan: "tw",
+
+	core.RegionUSA:    "us",
+	core.RegionCanada: "ca",
+	core.RegionBrazil: "br",
+	core.RegionMexico: "mex",
+	core.RegionChile:  "cl",
+	core.RegionPeru:   "pe",
+
+	core.RegionAustralia:  "au",
+	core.RegionNewZealand: "nz",
+
+	core.RegionIsrael: "il",
+	core.RegionTurkey: "tr",
+	core.RegionKuwait: "kw",
+	core.RegionUAE:    "ae",
+
+	core.RegionSouthAfrica: "za",
+}
+
+// byCode maps Screenscraper region codes to regions
+var byCode = func() map[string]core.Region {
+	m := make(map[string]core.Region, len(codes))
+	for r, code := range codes {
+		m[code] = r
+	}
+	return m
+}()
+
+// Code returns the Screenscraper region code of a region (e.g., "us" for
+// core.RegionUSA), or "" if it has none
+func Code(r core.Region) string {
+	return codes[r]
+}
+
+// FromCode returns the region of a Screenscraper region code, or
+// core.RegionUnknown if it's not one
+func FromCode(code string) core.Region {
+	return byCode[code]
+}
+
+// Codes returns the Screenscraper region codes of regions, skipping those
+// without one
+func Codes(regions []core.Region) []string {
+	var result []string
+	for _, r := range regions {
+		if code := Code(r); code != "" {
+			result = append(result, code)
+		}
+	}
+	return result
+}
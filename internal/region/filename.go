@@ -34,7 +34,7 @@ var filenamePatterns = []struct {
 	{"(W)", []string{"wor"}},
 	{"(Germany)", []string{"de"}},
 	{"(France)", []string{"fr"}},
-	{"(Spain)", []string{"es"}},
+	{"(Spain)", []string{"sp"}},
 	{"(Italy)", []string{"it"}},
 	{"(Netherlands)", []string{"nl"}},
 	{"(Sweden)", []string{"se"}},
@@ -67,7 +67,7 @@ var languagePatterns = map[string]string{
 	"Ja": "jp",
 	"Fr": "fr",
 	"De": "de",
-	"Es": "es",
+	"Es": "sp",
 	"It": "it",
 	"Nl": "nl",
 	"Pt": "pt",
@@ -129,7 +129,7 @@ func ParseFilename(filename string) []string {
 		euLangs := 0
 		for _, r := range regions {
 			switch r {
-			case "fr", "de", "es", "it", "nl", "se", "dk", "fi", "pt":
+			case "fr", "de", "sp", "it", "nl", "se", "dk", "fi", "pt":
 				euLangs++
 			}
 		}
@@ -160,8 +160,8 @@ func Normalize(region string) string {
 		return "de"
 	case "france", "fra":
 		return "fr"
-	case "spain", "spa":
-		return "es"
+	case "spain", "spa", "es", "sp":
+		return "sp"
 	case "italy", "ita":
 		return "it"
 	case "korea", "kor":
@@ -1,36 +1,9 @@
 package region
 
-import "strings"
-
-// Hierarchy maps child regions to their parent regions
-// Used for fallback when exact region match isn't available
-var Hierarchy = map[string]string{
-	"jp":  "asi",
-	"kr":  "asi",
-	"tw":  "asi",
-	"cn":  "asi",
-	"hk":  "asi",
-	"de":  "eu",
-	"fr":  "eu",
-	"it":  "eu",
-	"es":  "eu",
-	"uk":  "eu",
-	"nl":  "eu",
-	"se":  "eu",
-	"dk":  "eu",
-	"fi":  "eu",
-	"pt":  "eu",
-	"us":  "ame",
-	"ca":  "ame",
-	"br":  "ame",
-	"mex": "ame",
-	"au":  "oce",
-	"nz":  "oce",
-	"asi": "wor",
-	"eu":  "wor",
-	"ame": "wor",
-	"oce": "wor",
-}
+import (
+	"slices"
+	"strings"
+)
 
 // ToLanguage maps region codes to language codes
 // Used for selecting localized text
@@ -43,7 +16,7 @@ var ToLanguage = map[string]string{
 	"de":  "de",
 	"fr":  "fr",
 	"it":  "it",
-	"es":  "es",
+	"sp":  "es",
 	"uk":  "en",
 	"nl":  "nl",
 	"se":  "sv",
@@ -63,18 +36,22 @@ var ToLanguage = map[string]string{
 }
 
 // BuildSearchOrder creates an ordered list of regions to search
-// based on ROM regions and user preferences, with fallback through hierarchy
+// based on ROM regions and user preferences, with fallback through the
+// ancestors of each (e.g., "de", then "eu" and "wor")
 func BuildSearchOrder(romRegions, userRegions []string) []string {
 	seen := make(map[string]bool)
 	var order []string
 
-	addWithParents := func(region string) {
-		for r := region; r != ""; {
-			if !seen[r] {
-				order = append(order, r)
-				seen[r] = true
-			}
-			r = Hierarchy[r]
+	add := func(code string) {
+		if code != "" && !seen[code] {
+			order = append(order, code)
+			seen[code] = true
+		}
+	}
+	addWithParents := func(code string) {
+		add(code)
+		for _, r := range FromCode(code).Ancestors() {
+			add(Code(r))
 		}
 	}
 
@@ -160,6 +137,16 @@ type Media struct {
 
 // SelectMedia finds the best media match for a given type and region preferences
 func SelectMedia(available []Media, mediaType string, romRegions, userRegions []string) *Media {
+	ranked := RankMedia(available, mediaType, romRegions, userRegions)
+	if len(ranked) == 0 {
+		return nil
+	}
+	return &ranked[0]
+}
+
+// RankMedia returns the media of a given type, best match first: those in
+// the search order of BuildSearchOrder in that order, then any others
+func RankMedia(available []Media, mediaType string, romRegions, userRegions []string) []Media {
 	// Filter to matching media type
 	var candidates []Media
 	for _, m := range available {
@@ -172,18 +159,19 @@ func SelectMedia(available []Media, mediaType string, romRegions, userRegions []
 		return nil
 	}
 
-	// Build search order
-	searchOrder := BuildSearchOrder(romRegions, userRegions)
-
-	// Find first match in search order
-	for _, region := range searchOrder {
-		for i := range candidates {
-			if candidates[i].Region == region {
-				return &candidates[i]
-			}
+	rank := make(map[string]int)
+	for i, region := range BuildSearchOrder(romRegions, userRegions) {
+		rank[region] = i
+	}
+	position := func(m Media) int {
+		if i, ok := rank[m.Region]; ok {
+			return i
 		}
+		return len(rank)
 	}
 
-	// Fall back to any available
-	return &candidates[0]
+	slices.SortStableFunc(candidates, func(a, b Media) int {
+		return position(a) - position(b)
+	})
+	return candidates
 }
@@ -0,0 +1,84 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/sargunv/rom-tools/lib/title"
+)
+
+// Backend is a source of game metadata that ROMs are looked up in:
+// Screenscraper (a Scraper), IGDB, TheGamesDB, or libretro databases.
+type Backend interface {
+	// Name returns the name of the backend, e.g. "screenscraper"
+	Name() string
+
+	// ScrapeROM identifies the ROM at path and looks up its game. It
+	// returns ErrNotFound if the backend has no game for the ROM.
+	ScrapeROM(ctx context.Context, path string) (*Metadata, error)
+}
+
+// Name returns "screenscraper"
+func (s *Scraper) Name() string {
+	return "screenscraper"
+}
+
+// Fallback is a backend that looks ROMs up in each of its backends in
+// turn, until one finds the game. A backend that fails, e.g. because it
+// doesn't support the ROM's platform or its daily quota is used up, is
+// passed over too.
+type Fallback []Backend
+
+// Name returns the names of the backends, separated by commas
+func (f Fallback) Name() string {
+	names := make([]string, len(f))
+	for i, b := range f {
+		names[i] = b.Name()
+	}
+	return strings.Join(names, ",")
+}
+
+// ScrapeROM returns the metadata of the first backend that finds the game.
+// If none do, it returns ErrNotFound if none failed, or else the first
+// failure.
+func (f Fallback) ScrapeROM(ctx context.Context, path string) (*Metadata, error) {
+	var firstErr error
+	for _, b := range f {
+		md, err := b.ScrapeROM(ctx, path)
+		if err == nil {
+			return md, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if firstErr == nil && !errors.Is(err, ErrNotFound) {
+			firstErr = err
+		}
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return nil, ErrNotFound
+}
+
+// searchTitle returns the title to search name-based backends for: the
+// entry's name cleaned of No-Intro style tags and moved articles (e.g.,
+// "The Legend of Zelda" for "Legend of Zelda, The (USA) (Rev 1)")
+func searchTitle(entry *LookupEntry) string {
+	if t := title.Clean(entry.Name); t != "" {
+		return t
+	}
+	return entry.Name
+}
+
+// bestMatch returns the result whose name is most similar to name, or the
+// first of equally similar results, which backends rank best
+func bestMatch[T any](results []T, name string, resultName func(T) string) T {
+	names := make([]string, len(results))
+	for i, r := range results {
+		names[i] = resultName(r)
+	}
+	i, _ := title.Best(name, names)
+	return results[i]
+}
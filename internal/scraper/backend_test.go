@@ -0,0 +1,242 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/sargunv/rom-tools/lib/igdb"
+	"github.com/sargunv/rom-tools/lib/thegamesdb"
+)
+
+// fakeBackend returns md, or err if md is nil
+type fakeBackend struct {
+	name  string
+	md    *Metadata
+	err   error
+	calls int
+}
+
+func (b *fakeBackend) Name() string { return b.name }
+
+func (b *fakeBackend) ScrapeROM(ctx context.Context, path string) (*Metadata, error) {
+	b.calls++
+	if b.md == nil {
+		return nil, b.err
+	}
+	return b.md, nil
+}
+
+func TestFallback(t *testing.T) {
+	failed := errors.New("failed")
+	found := &Metadata{Name: "Found"}
+
+	tests := []struct {
+		name      string
+		backends  []*fakeBackend
+		want      *Metadata
+		wantErr   error
+		wantCalls []int
+	}{
+		{
+			name:      "first finds",
+			backends:  []*fakeBackend{{md: found}, {md: &Metadata{}}},
+			want:      found,
+			wantCalls: []int{1, 0},
+		},
+		{
+			name:      "passes over not found and failures",
+			backends:  []*fakeBackend{{err: ErrNotFound}, {err: ErrQuotaExceeded}, {md: found}},
+			want:      found,
+			wantCalls: []int{1, 1, 1},
+		},
+		{
+			name:      "none find",
+			backends:  []*fakeBackend{{err: ErrNotFound}, {err: ErrNotFound}},
+			wantErr:   ErrNotFound,
+			wantCalls: []int{1, 1},
+		},
+		{
+			name:      "first failure",
+			backends:  []*fakeBackend{{err: ErrNotFound}, {err: failed}, {err: ErrQuotaExceeded}},
+			wantErr:   failed,
+			wantCalls: []int{1, 1, 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var f Fallback
+			for _, b := range tt.backends {
+				f = append(f, b)
+			}
+			md, err := f.ScrapeROM(context.Background(), "rom.gb")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ScrapeROM() error = %v, want %v", err, tt.wantErr)
+			}
+			if md != tt.want {
+				t.Errorf("ScrapeROM() = %v, want %v", md, tt.want)
+			}
+			var calls []int
+			for _, b := range tt.backends {
+				calls = append(calls, b.calls)
+			}
+			if !slices.Equal(calls, tt.wantCalls) {
+				t.Errorf("Calls = %v, want %v", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestFallback_Name(t *testing.T) {
+	f := Fallback{&fakeBackend{name: "screenscraper"}, &fakeBackend{name: "igdb"}}
+	if got := f.Name(); got != "screenscraper,igdb" {
+		t.Errorf("Name() = %q", got)
+	}
+}
+
+// testROM writes the Tic Tac Game Boy ROM to a temporary file
+func testROM(t *testing.T) string {
+	t.Helper()
+	rom, err := os.ReadFile("../../lib/identify/testdata/gbtictac.gb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "Tic Tac (USA).gb")
+	if err := os.WriteFile(path, rom, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestIGDB_ScrapeROM(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			w.Write([]byte(`{"access_token": "abc", "expires_in": 3600}`))
+		case "/games":
+			// The exact title wins over the first result
+			w.Write([]byte(`[
+				{"id": 1, "name": "Tic Tac Toe", "platforms": [33]},
+				{"id": 2, "name": "Tic-Tac", "summary": "Noughts and crosses.", "first_release_date": 631152000,
+				 "genres": [{"id": 9, "name": "Puzzle"}], "total_rating": 50,
+				 "involved_companies": [{"company": {"id": 3, "name": "Homebrew"}, "developer": true}]}
+			]`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := igdb.NewClient("id", "secret")
+	client.BaseURL = server.URL
+	client.TokenURL = server.URL + "/token"
+
+	md, err := NewIGDB(client).ScrapeROM(context.Background(), testROM(t))
+	if err != nil {
+		t.Fatalf("ScrapeROM() error = %v", err)
+	}
+	want := Metadata{
+		Source:      "igdb",
+		ID:          "2",
+		SystemID:    "33",
+		Name:        "Tic-Tac",
+		Description: "Noughts and crosses.",
+		Developer:   "Homebrew",
+		ReleaseDate: time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC),
+		Genres:      []string{"Puzzle"},
+		Rating:      0.5,
+	}
+	if md.Source != want.Source || md.ID != want.ID || md.SystemID != want.SystemID || md.Name != want.Name ||
+		md.Description != want.Description || md.Developer != want.Developer || md.Publisher != "" ||
+		!md.ReleaseDate.Equal(want.ReleaseDate) || !slices.Equal(md.Genres, want.Genres) || md.Rating != want.Rating {
+		t.Errorf("ScrapeROM() = %+v, want %+v", md, want)
+	}
+}
+
+func TestTheGamesDB_ScrapeROM(t *testing.T) {
+	tests := []struct {
+		name    string
+		games   string
+		wantErr error
+	}{
+		{name: "found", games: `[{"id": 7, "game_title": "Tic Tac", "platform": 4, "players": 2, "release_date": "1990-01-01", "developers": [1], "publishers": [1], "genres": [2]}]`},
+		{name: "not found", games: `[]`, wantErr: ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/v1.1/Games/ByGameName":
+					if q := r.URL.Query(); q.Get("name") != "Tic Tac" || q.Get("filter[platform]") != "4" {
+						t.Errorf("query = %v, want the title on Game Boy", q)
+					}
+					w.Write([]byte(`{"data": {"games": ` + tt.games + `}}`))
+				case "/v1/Genres":
+					w.Write([]byte(`{"data": {"genres": {"2": {"id": 2, "name": "Puzzle"}}}}`))
+				case "/v1/Developers":
+					w.Write([]byte(`{"data": {"developers": {"1": {"id": 1, "name": "Homebrew"}}}}`))
+				case "/v1/Publishers":
+					w.Write([]byte(`{"data": {"publishers": {"1": {"id": 1, "name": "Self"}}}}`))
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			defer server.Close()
+
+			client := thegamesdb.NewClient("key")
+			client.BaseURL = server.URL
+
+			md, err := NewTheGamesDB(client).ScrapeROM(context.Background(), testROM(t))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ScrapeROM() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if md.Source != "thegamesdb" || md.ID != "7" || md.Name != "Tic Tac" || md.Players != "2" ||
+				md.Developer != "Homebrew" || md.Publisher != "Self" || !slices.Equal(md.Genres, []string{"Puzzle"}) {
+				t.Errorf("ScrapeROM() = %+v", md)
+			}
+		})
+	}
+}
+
+func TestLibretro_ScrapeROM(t *testing.T) {
+	b := NewLibretro("../../lib/retroarch/testdata")
+
+	md, err := b.ScrapeROM(context.Background(), testROM(t))
+	if err != nil {
+		t.Fatalf("ScrapeROM() error = %v", err)
+	}
+	if md.Source != "libretro" || md.ID != "Tic-Tac (USA)" || md.SystemID != "Nintendo - Game Boy" || md.Name != "Tic-Tac" ||
+		md.Developer != "Homebrew" || md.Players != "2" || !slices.Equal(md.Genres, []string{"Board Game"}) {
+		t.Errorf("ScrapeROM() = %+v", md)
+	}
+	if want := time.Date(1997, 3, 1, 0, 0, 0, 0, time.UTC); !md.ReleaseDate.Equal(want) {
+		t.Errorf("ReleaseDate = %v, want %v", md.ReleaseDate, want)
+	}
+
+	// Without the platform's database
+	if _, err := NewLibretro(t.TempDir()).ScrapeROM(context.Background(), testROM(t)); err == nil {
+		t.Error("ScrapeROM() without a database error = nil")
+	}
+}
+
+func TestBestMatch(t *testing.T) {
+	results := []string{"Tetris 2", "Tetris DX", "Tetris"}
+	if got := bestMatch(results, "Tetris", func(s string) string { return s }); got != "Tetris" {
+		t.Errorf("bestMatch() = %q, want %q", got, "Tetris")
+	}
+
+	// Without an exact match, the most similar result wins
+	results = []string{"Super Mario World", "The Legend of Zelda: A Link to the Past"}
+	if got := bestMatch(results, "Zelda - A Link to the Past", func(s string) string { return s }); got != results[1] {
+		t.Errorf("bestMatch() = %q, want %q", got, results[1])
+	}
+}
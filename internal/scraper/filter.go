@@ -27,6 +27,7 @@ type MissingContext struct {
 	Videos        bool `expr:"videos"`
 	Physicalmedia bool `expr:"physicalmedia"`
 	Backcovers    bool `expr:"backcovers"`
+	Miximages     bool `expr:"miximages"`
 }
 
 // Filter evaluates filter expressions against entries
@@ -112,6 +113,7 @@ func BuildFilterContext(baseName string, config *FilterConfig) FilterContext {
 			Videos:        true,
 			Physicalmedia: true,
 			Backcovers:    true,
+			Miximages:     true,
 		},
 	}
 
@@ -148,6 +150,7 @@ func BuildFilterContext(baseName string, config *FilterConfig) FilterContext {
 		ctx.Missing.Videos = !mediaExists(config.MediaDir, baseName, "videos")
 		ctx.Missing.Physicalmedia = !mediaExists(config.MediaDir, baseName, "physicalmedia")
 		ctx.Missing.Backcovers = !mediaExists(config.MediaDir, baseName, "backcovers")
+		ctx.Missing.Miximages = !mediaExists(config.MediaDir, baseName, MiximageType)
 	}
 
 	return ctx
@@ -164,6 +167,7 @@ var mediaExtensions = map[string][]string{
 	"videos":        {"mp4", "mkv", "avi", "webm"},
 	"physicalmedia": {"png", "jpg", "jpeg"},
 	"backcovers":    {"png", "jpg", "jpeg"},
+	"miximages":     {"png"},
 }
 
 // mediaExists checks if a media file exists for the given entry
@@ -0,0 +1,65 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/sargunv/rom-tools/lib/igdb"
+)
+
+// IGDB is a backend that looks ROMs up on IGDB by the title in their file
+// names, within their identified platform. Lookups aren't cached or rate
+// limited.
+type IGDB struct {
+	client *igdb.Client
+}
+
+// NewIGDB creates an IGDB backend
+func NewIGDB(client *igdb.Client) *IGDB {
+	return &IGDB{client: client}
+}
+
+// Name returns "igdb"
+func (b *IGDB) Name() string {
+	return "igdb"
+}
+
+// ScrapeROM identifies the ROM at path and looks up its game on IGDB
+func (b *IGDB) ScrapeROM(ctx context.Context, path string) (*Metadata, error) {
+	entry, platform, err := romLookupEntry(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	platformID, ok := igdb.PlatformID(platform)
+	if !ok {
+		return nil, fmt.Errorf("no IGDB platform for %s", path)
+	}
+
+	title := searchTitle(entry)
+	games, err := b.client.SearchGames(ctx, title, platformID)
+	if err != nil {
+		return nil, err
+	}
+	if len(games) == 0 {
+		return nil, ErrNotFound
+	}
+	game := bestMatch(games, title, func(g igdb.Game) string { return g.Name })
+
+	md := &Metadata{
+		Source:      b.Name(),
+		ID:          strconv.Itoa(game.ID),
+		SystemID:    strconv.Itoa(platformID),
+		Name:        game.Name,
+		Description: game.Summary,
+		Developer:   strings.Join(game.Developers(), ", "),
+		Publisher:   strings.Join(game.Publishers(), ", "),
+		ReleaseDate: game.ReleaseDate(),
+		Rating:      game.TotalRating / 100, // IGDB uses a 0-100 scale
+	}
+	for _, genre := range game.Genres {
+		md.Genres = append(md.Genres, genre.Name)
+	}
+	return md, nil
+}
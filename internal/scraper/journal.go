@@ -0,0 +1,112 @@
+package scraper
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Journal records the entries a scrape has finished, one JSON line each, so
+// an interrupted scrape resumes where it left off. Entries are keyed by
+// name, and the last record of an entry is the one that counts.
+type Journal struct {
+	file    *os.File
+	records map[string]JournalRecord
+}
+
+// JournalRecord is the outcome of scraping an entry
+type JournalRecord struct {
+	Name   string            `json:"name"`
+	Status string            `json:"status"` // found, not_found, skipped, or error
+	GameID string            `json:"game_id,omitempty"`
+	Media  map[string]string `json:"media,omitempty"`
+	Error  string            `json:"error,omitempty"`
+}
+
+// OpenJournal opens the journal at path, creating it if needed. If restart
+// is true, its earlier records are discarded.
+func OpenJournal(path string, restart bool) (*Journal, error) {
+	j := &Journal{records: make(map[string]JournalRecord)}
+
+	if !restart {
+		if f, err := os.Open(path); err == nil {
+			scanner := bufio.NewScanner(f)
+			scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+			for scanner.Scan() {
+				var record JournalRecord
+				// A run killed mid-write leaves a partial last line
+				if err := json.Unmarshal(scanner.Bytes(), &record); err != nil || record.Name == "" {
+					continue
+				}
+				j.records[record.Name] = record
+			}
+			err := scanner.Err()
+			f.Close()
+			if err != nil {
+				return nil, fmt.Errorf("failed to read journal: %w", err)
+			}
+		} else if !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to open journal: %w", err)
+		}
+	}
+
+	flags := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	if restart {
+		flags |= os.O_TRUNC
+	}
+	f, err := os.OpenFile(path, flags, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open journal: %w", err)
+	}
+	j.file = f
+	return j, nil
+}
+
+// Done returns true if the entry was found or not found by an earlier
+// scrape; entries that failed are scraped again
+func (j *Journal) Done(name string) bool {
+	record, ok := j.records[name]
+	return ok && (record.Status == StatusFound.String() || record.Status == StatusNotFound.String())
+}
+
+// Len returns the number of entries with records
+func (j *Journal) Len() int {
+	return len(j.records)
+}
+
+// Record appends the outcome of a scrape
+func (j *Journal) Record(result *ScrapeResult) error {
+	record := JournalRecord{Name: result.Entry.Name, Media: result.Media}
+	switch {
+	case result.Skipped:
+		record.Status = StatusSkipped.String()
+		record.Error = result.Reason
+	case result.Error != nil:
+		record.Status = StatusError.String()
+		record.Error = result.Error.Error()
+	case result.Game != nil:
+		record.Status = StatusFound.String()
+		record.GameID = result.Game.Id
+	default:
+		record.Status = StatusNotFound.String()
+	}
+	if len(record.Media) == 0 {
+		record.Media = nil
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if _, err := j.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	j.records[record.Name] = record
+	return nil
+}
+
+// Close closes the journal file
+func (j *Journal) Close() error {
+	return j.file.Close()
+}
@@ -0,0 +1,72 @@
+package scraper
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sargunv/rom-tools/lib/screenscraper"
+)
+
+func TestJournal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+
+	j, err := OpenJournal(path, false)
+	if err != nil {
+		t.Fatalf("OpenJournal() error = %v", err)
+	}
+	results := []*ScrapeResult{
+		{Entry: &LookupEntry{Name: "found.gb"}, Game: &screenscraper.Game{Id: "1"}, Media: map[string]string{"covers": "covers/found.png"}},
+		{Entry: &LookupEntry{Name: "missing.gb"}},
+		{Entry: &LookupEntry{Name: "failed.gb"}, Error: errors.New("timeout")},
+		{Entry: &LookupEntry{Name: "retried.gb"}, Error: errors.New("timeout")},
+		{Entry: &LookupEntry{Name: "retried.gb"}, Game: &screenscraper.Game{Id: "2"}},
+	}
+	for _, result := range results {
+		if err := j.Record(result); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+	}
+	if err := j.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A run killed mid-write leaves a partial line
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"name":"partial.gb","sta`)
+	f.Close()
+
+	j, err = OpenJournal(path, false)
+	if err != nil {
+		t.Fatalf("OpenJournal() again error = %v", err)
+	}
+	defer j.Close()
+	for name, want := range map[string]bool{
+		"found.gb":   true,
+		"missing.gb": true,
+		"failed.gb":  false,
+		"retried.gb": true,
+		"partial.gb": false,
+		"new.gb":     false,
+	} {
+		if got := j.Done(name); got != want {
+			t.Errorf("Done(%q) = %v, want %v", name, got, want)
+		}
+	}
+	if j.Len() != 4 {
+		t.Errorf("Len() = %d, want 4", j.Len())
+	}
+
+	restarted, err := OpenJournal(path, true)
+	if err != nil {
+		t.Fatalf("OpenJournal(restart) error = %v", err)
+	}
+	defer restarted.Close()
+	if restarted.Done("found.gb") {
+		t.Error("Done() after a restart = true, want false")
+	}
+}
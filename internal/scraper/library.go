@@ -0,0 +1,79 @@
+package scraper
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sargunv/rom-tools/internal/region"
+	"github.com/sargunv/rom-tools/lib/identify"
+)
+
+// LibraryEntries returns lookup entries for the games of an identified
+// library folder: one per file (ROM, archive, or control file like a CUE
+// sheet) in the folder or its subfolders that holds a primary item. Each is
+// named after its path relative to the folder, and looked up by its first
+// primary item.
+//
+// Entries are looked up in systemID if it's set, or else in the system of
+// their identified platform. Those with neither are returned as skipped
+// results instead.
+func LibraryEntries(result *identify.Result, systemID string) ([]*LookupEntry, []*ScrapeResult) {
+	var entries []*LookupEntry
+	var skipped []*ScrapeResult
+	seen := make(map[string]bool)
+
+	for i := range result.Items {
+		item := &result.Items[i]
+		if !item.Primary {
+			continue
+		}
+		rel, ok := libraryFile(result.Path, item.Name)
+		if !ok || seen[rel] {
+			continue
+		}
+		seen[rel] = true
+
+		entry, platform := itemLookupEntry(item)
+		entry.Name = rel
+		entry.BaseName = BaseName(rel)
+		entry.Regions = region.ParseFilename(filepath.Base(rel))
+		entry.ROMPath = filepath.Join(result.Path, filepath.FromSlash(rel))
+		entry.SystemID = systemID
+		if entry.SystemID == "" {
+			entry.SystemID = PlatformSystemID(platform)
+		}
+
+		if entry.SystemID == "" {
+			reason := "platform not identified"
+			if platform != "" {
+				reason = fmt.Sprintf("no Screenscraper system for %s", platform)
+			}
+			skipped = append(skipped, &ScrapeResult{Entry: entry, Skipped: true, Reason: reason})
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, skipped
+}
+
+// libraryFile returns the path, relative to the library folder at root and
+// with forward slashes, of the file holding the item name: the item itself,
+// or the archive it's in.
+func libraryFile(root, name string) (string, bool) {
+	parts := strings.Split(filepath.ToSlash(name), "/")
+	path := root
+	for i, part := range parts {
+		path = filepath.Join(path, part)
+		info, err := os.Stat(path)
+		if err != nil {
+			return "", false
+		}
+		if !info.IsDir() {
+			return strings.Join(parts[:i+1], "/"), true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,114 @@
+package scraper
+
+import (
+	"archive/zip"
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sargunv/rom-tools/lib/identify"
+)
+
+func TestLibraryEntries(t *testing.T) {
+	rom, err := os.ReadFile("../../lib/identify/testdata/gbtictac.gb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "sub", "Tic Tac (USA).gb"), rom, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "readme.txt"), []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Create(filepath.Join(dir, "Tic Tac (Europe).zip"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	w, err := zw.Create("tictac.gb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write(rom)
+	zw.Close()
+	f.Close()
+
+	result, err := identify.Identify(dir, identify.DefaultOptions())
+	if err != nil {
+		t.Fatalf("Identify() error = %v", err)
+	}
+
+	entries, skipped := LibraryEntries(result, "")
+	if len(skipped) != 0 {
+		t.Errorf("Skipped = %d, want none", len(skipped))
+	}
+	want := map[string]struct{ baseName, fileName string }{
+		"Tic Tac (Europe).zip": {"Tic Tac (Europe)", "tictac.gb"},
+		"sub/Tic Tac (USA).gb": {"sub/Tic Tac (USA)", "Tic Tac (USA).gb"},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("LibraryEntries() = %d entries, want %d", len(entries), len(want))
+	}
+	for _, entry := range entries {
+		w, ok := want[entry.Name]
+		if !ok {
+			t.Errorf("Unexpected entry %q", entry.Name)
+			continue
+		}
+		if entry.BaseName != w.baseName || entry.FileName != w.fileName {
+			t.Errorf("%s: BaseName, FileName = %q, %q, want %q, %q", entry.Name, entry.BaseName, entry.FileName, w.baseName, w.fileName)
+		}
+		if entry.SystemID != "9" {
+			t.Errorf("%s: SystemID = %q, want 9 (Game Boy)", entry.Name, entry.SystemID)
+		}
+		if entry.Hashes.CRC32 == "" {
+			t.Errorf("%s: no CRC32", entry.Name)
+		}
+	}
+
+	// The system overrides the platforms
+	entries, _ = LibraryEntries(result, "10")
+	for _, entry := range entries {
+		if entry.SystemID != "10" {
+			t.Errorf("%s: SystemID = %q, want 10", entry.Name, entry.SystemID)
+		}
+	}
+}
+
+func TestScrapeEntries_Journal(t *testing.T) {
+	journal, err := OpenJournal(filepath.Join(t.TempDir(), "journal.jsonl"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer journal.Close()
+
+	s := newTestScraper(t, &Config{Journal: journal}, func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})
+	entries := []*LookupEntry{
+		{Name: "a.gb", FileName: "a.gb", SystemID: "9", Hashes: Hashes{CRC32: "00000001"}},
+		{Name: "b.gb", FileName: "b.gb", SystemID: "9", Hashes: Hashes{CRC32: "00000002"}},
+	}
+	go func() {
+		for range s.Updates() {
+		}
+	}()
+	results, err := s.ScrapeEntries(context.Background(), entries)
+	if err != nil {
+		t.Fatalf("ScrapeEntries() error = %v", err)
+	}
+	if results.NotFound != 2 {
+		t.Errorf("NotFound = %d, want 2", results.NotFound)
+	}
+	for _, entry := range entries {
+		if !journal.Done(entry.Name) {
+			t.Errorf("Done(%q) = false, want true", entry.Name)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sargunv/rom-tools/lib/onegame"
+	"github.com/sargunv/rom-tools/lib/retroarch"
+)
+
+// Libretro is a backend that looks ROMs up offline in the libretro
+// databases (.rdb files) of a folder, like RetroArch's database/rdb folder,
+// by their hashes, serial, or file name. Each database is read when it's
+// first needed.
+type Libretro struct {
+	dir string
+
+	mu  sync.Mutex
+	dbs map[string]*retroarch.Database
+}
+
+// NewLibretro creates a backend of the libretro databases in dir
+func NewLibretro(dir string) *Libretro {
+	return &Libretro{dir: dir, dbs: make(map[string]*retroarch.Database)}
+}
+
+// Name returns "libretro"
+func (b *Libretro) Name() string {
+	return "libretro"
+}
+
+// ScrapeROM identifies the ROM at path and looks up its game in the
+// database of its platform
+func (b *Libretro) ScrapeROM(ctx context.Context, path string) (*Metadata, error) {
+	entry, platform, err := romLookupEntry(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	name := retroarch.DatabaseName(platform)
+	if name == "" {
+		return nil, fmt.Errorf("no libretro database for %s", path)
+	}
+	db, err := b.database(name)
+	if err != nil {
+		return nil, err
+	}
+
+	game, ok := lookupRDB(db, entry)
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	info := onegame.ParseName(game.Name)
+	md := &Metadata{
+		Source:    b.Name(),
+		ID:        game.Name,
+		SystemID:  name,
+		Name:      info.Title,
+		Developer: game.Developer,
+		Publisher: game.Publisher,
+	}
+	if game.Genre != "" {
+		md.Genres = []string{game.Genre}
+	}
+	if game.Users > 0 {
+		md.Players = strconv.Itoa(game.Users)
+	}
+	if game.ReleaseYear > 0 {
+		month := time.Month(max(game.ReleaseMonth, 1))
+		md.ReleaseDate = time.Date(game.ReleaseYear, month, 1, 0, 0, 0, 0, time.UTC)
+	}
+	return md, nil
+}
+
+// database returns the named database, reading it if needed
+func (b *Libretro) database(name string) (*retroarch.Database, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if db, ok := b.dbs[name]; ok {
+		return db, nil
+	}
+	db, err := retroarch.OpenDatabase(filepath.Join(b.dir, name+".rdb"))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("no %s.rdb in %s", name, b.dir)
+	}
+	if err != nil {
+		return nil, err
+	}
+	b.dbs[name] = db
+	return db, nil
+}
+
+// lookupRDB finds an entry's game by its strongest hash, then its serial,
+// then its file name
+func lookupRDB(db *retroarch.Database, entry *LookupEntry) (*retroarch.RDBEntry, bool) {
+	lookups := []func() (*retroarch.RDBEntry, bool){
+		func() (*retroarch.RDBEntry, bool) { return db.BySHA1(entry.Hashes.SHA1) },
+		func() (*retroarch.RDBEntry, bool) { return db.ByMD5(entry.Hashes.MD5) },
+		func() (*retroarch.RDBEntry, bool) { return db.ByCRC32(entry.Hashes.CRC32) },
+		func() (*retroarch.RDBEntry, bool) { return db.BySerial(entry.Serial) },
+		func() (*retroarch.RDBEntry, bool) { return db.ByROMName(entry.FileName) },
+	}
+	for _, lookup := range lookups {
+		if game, ok := lookup(); ok {
+			return game, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,150 @@
+package scraper
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sargunv/rom-tools/internal/region"
+	"github.com/sargunv/rom-tools/lib/screenscraper"
+)
+
+// Metadata is the game metadata from a backend. That of Screenscraper has
+// its localized texts chosen for the ROM's regions and the preferred ones.
+type Metadata struct {
+	Source      string    `json:"source"`                // name of the backend, e.g. "screenscraper"
+	ID          string    `json:"id"`                    // game ID in the backend
+	SystemID    string    `json:"system_id,omitempty"`   // system or platform ID in the backend
+	Name        string    `json:"name"`                  // localized name
+	Description string    `json:"description,omitempty"` // localized synopsis
+	Developer   string    `json:"developer,omitempty"`   // developer
+	Publisher   string    `json:"publisher,omitempty"`   // publisher
+	ReleaseDate time.Time `json:"release_date,omitzero"` // localized release date, zero if unknown
+	Genres      []string  `json:"genres,omitempty"`      // localized genre names
+	Players     string    `json:"players,omitempty"`     // number of players (e.g., "1" or "1-2")
+	Rating      float64   `json:"rating,omitempty"`      // rating from 0 to 1, or 0 if unrated
+}
+
+// NewMetadata normalizes a Screenscraper game. Texts are chosen for
+// romRegions, the regions of the ROM (e.g., from its filename), then
+// userRegions, the preferred ones.
+func NewMetadata(game *screenscraper.Game, romRegions, userRegions []string) *Metadata {
+	md := &Metadata{
+		Source:      "screenscraper",
+		ID:          game.Id,
+		SystemID:    game.System.Id,
+		Name:        selectName(game.Names, romRegions, userRegions),
+		Description: selectLocalizedText(game.Synopsis, romRegions, userRegions),
+		Developer:   game.Developer.Text,
+		Publisher:   game.Publisher.Text,
+		ReleaseDate: selectReleaseDate(game.Dates, romRegions, userRegions),
+		Players:     game.Players.Text,
+	}
+	if md.Name == "" {
+		md.Name = game.Name
+	}
+
+	for _, genre := range game.Genres {
+		if name := selectLocalizedText(genre.Names, romRegions, userRegions); name != "" {
+			md.Genres = append(md.Genres, name)
+		}
+	}
+
+	// Screenscraper uses a 0-20 scale
+	if game.Note.Text != "" {
+		if note, err := strconv.ParseFloat(game.Note.Text, 64); err == nil {
+			md.Rating = note / 20.0
+		}
+	}
+
+	return md
+}
+
+// Helper functions for localized content selection
+
+func selectName(names []screenscraper.NameEntry, romRegions, userRegions []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+
+	searchOrder := region.BuildSearchOrder(romRegions, userRegions)
+
+	for _, r := range searchOrder {
+		for _, n := range names {
+			if n.Region == r && n.Text != "" {
+				return n.Text
+			}
+		}
+	}
+
+	// Fallback to any
+	for _, n := range names {
+		if n.Text != "" {
+			return n.Text
+		}
+	}
+
+	return ""
+}
+
+func selectLocalizedText(entries []screenscraper.LocalizedName, romRegions, userRegions []string) string {
+	if len(entries) == 0 {
+		return ""
+	}
+
+	// Convert to region.LocalizedEntry
+	regionEntries := make([]region.LocalizedEntry, len(entries))
+	for i, e := range entries {
+		regionEntries[i] = region.LocalizedEntry{
+			Language: e.Language,
+			Text:     e.Text,
+		}
+	}
+
+	return region.SelectLocalizedText(regionEntries, romRegions, userRegions)
+}
+
+func selectReleaseDate(dates []screenscraper.DateEntry, romRegions, userRegions []string) time.Time {
+	if len(dates) == 0 {
+		return time.Time{}
+	}
+
+	searchOrder := region.BuildSearchOrder(romRegions, userRegions)
+
+	for _, r := range searchOrder {
+		for _, d := range dates {
+			if d.Region == r && d.Text != "" {
+				return parseDate(d.Text)
+			}
+		}
+	}
+
+	// Fallback to any
+	for _, d := range dates {
+		if d.Text != "" {
+			return parseDate(d.Text)
+		}
+	}
+
+	return time.Time{}
+}
+
+// parseDate parses a Screenscraper date: "1991-06-23", "1991-06", or "1991".
+func parseDate(date string) time.Time {
+	// Remove any dashes
+	clean := strings.ReplaceAll(date, "-", "")
+
+	// Pad with the first month and day if needed
+	switch len(clean) {
+	case 4: // Just year
+		clean += "0101"
+	case 6: // Year and month
+		clean += "01"
+	}
+
+	t, err := time.Parse("20060102", clean)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
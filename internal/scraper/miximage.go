@@ -0,0 +1,82 @@
+package scraper
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+
+	"github.com/sargunv/rom-tools/lib/miximage"
+)
+
+// MiximageType is the ES-DE media type of composed miximages
+const MiximageType = "miximages"
+
+// miximageSources maps the images of a miximage to the ES-DE media types
+// they're taken from, in priority order
+var miximageSources = map[miximage.Asset][]string{
+	miximage.AssetScreenshot: {"screenshots", "titlescreens"},
+	miximage.AssetBox:        {"3dboxes", "covers"},
+	miximage.AssetMarquee:    {"marquees"},
+}
+
+// composeMiximage composes a miximage of the media downloaded for an entry
+// Returns its path relative to the media folder, or "" if none of the media
+// it's composed of was downloaded
+func (w *Worker) composeMiximage(entry *LookupEntry, media map[string]string) (string, error) {
+	if w.config.MediaOutputDir == "" {
+		return "", nil
+	}
+
+	relativePath := filepath.Join(MiximageType, entry.BaseName+".png")
+	outputPath := filepath.Join(w.config.MediaOutputDir, relativePath)
+
+	// Check if output file already exists (skip unless overwrite)
+	if !w.config.Overwrite {
+		if _, err := os.Stat(outputPath); err == nil {
+			return relativePath, nil
+		}
+	}
+
+	assets := make(map[miximage.Asset]image.Image)
+	for asset, esdeTypes := range miximageSources {
+		for _, esdeType := range esdeTypes {
+			path, ok := media[esdeType]
+			if !ok {
+				continue
+			}
+			img, err := miximage.DecodeFile(filepath.Join(w.config.MediaOutputDir, path))
+			if err != nil {
+				continue // Try the next type
+			}
+			assets[asset] = img
+			break
+		}
+	}
+
+	img, err := miximage.Compose(*w.config.Miximage, assets)
+	if errors.Is(err, miximage.ErrNoAssets) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return "", fmt.Errorf("failed to create media directory: %w", err)
+	}
+	f, err := os.Create(outputPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to write miximage: %w", err)
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return "", fmt.Errorf("failed to write miximage: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return "", fmt.Errorf("failed to write miximage: %w", err)
+	}
+	return relativePath, nil
+}
@@ -0,0 +1,58 @@
+package scraper
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sargunv/rom-tools/lib/miximage"
+)
+
+func TestComposeMiximage(t *testing.T) {
+	layout := miximage.Templates["wide"]
+	config := &Config{MediaOutputDir: t.TempDir(), Miximage: &layout}
+	w := NewWorker(0, nil, nil, config, NewRateLimiter(1, 60), NewDeduplicator(), nil)
+	entry := &LookupEntry{BaseName: "Game"}
+
+	// Nothing to compose without downloaded media
+	path, err := w.composeMiximage(entry, map[string]string{})
+	if err != nil || path != "" {
+		t.Fatalf("composeMiximage() with no media = %q, %v, want none", path, err)
+	}
+
+	// Covers stand in for missing 3D boxes, and unreadable media is passed over
+	media := map[string]string{
+		"screenshots": filepath.Join("screenshots", "Game.png"),
+		"covers":      filepath.Join("covers", "Game.png"),
+		"marquees":    filepath.Join("marquees", "Game.png"),
+	}
+	images := map[string][]byte{
+		"screenshots": testPNG(t, 320, 224),
+		"covers":      testPNG(t, 300, 420),
+		"marquees":    []byte("not an image"),
+	}
+	for esdeType, rel := range media {
+		full := filepath.Join(config.MediaOutputDir, rel)
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, images[esdeType], 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	path, err = w.composeMiximage(entry, media)
+	if err != nil {
+		t.Fatalf("composeMiximage() error = %v", err)
+	}
+	if path != filepath.Join(MiximageType, "Game.png") {
+		t.Fatalf("composeMiximage() = %q", path)
+	}
+	img, err := miximage.DecodeFile(filepath.Join(config.MediaOutputDir, path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := img.Bounds().Dx(); got != layout.Width {
+		t.Errorf("Width = %d, want %d", got, layout.Width)
+	}
+}
@@ -7,12 +7,9 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
-	"time"
 
-	"github.com/sargunv/rom-tools/internal/region"
 	"github.com/sargunv/rom-tools/internal/scraper"
 	"github.com/sargunv/rom-tools/lib/esde"
-	"github.com/sargunv/rom-tools/lib/screenscraper"
 )
 
 // Generator generates ES-DE compatible output
@@ -71,53 +68,17 @@ func (g *Generator) Generate(results *scraper.ScrapeResults) error {
 // resultToGame converts a scrape result to an ES-DE game entry
 func (g *Generator) resultToGame(result *scraper.ScrapeResult) esde.Game {
 	entry := result.Entry
-	ssGame := result.Game
+	md := scraper.NewMetadata(result.Game, entry.Regions, g.regions)
 
-	// Get localized text
-	romRegions := entry.Regions
-	userRegions := g.regions
-
-	// Build name
-	name := selectName(ssGame.Names, romRegions, userRegions)
-	if name == "" {
-		name = ssGame.Name
-	}
+	name := md.Name
 	if name == "" {
 		name = entry.Name
 	}
 
-	// Build description
-	desc := selectLocalizedText(ssGame.Synopsis, romRegions, userRegions)
-
-	// Build genre
-	var genres []string
-	for _, genre := range ssGame.Genres {
-		genreName := selectLocalizedText(genre.Names, romRegions, userRegions)
-		if genreName != "" {
-			genres = append(genres, genreName)
-		}
-	}
-
-	// Format rating (0-1 scale)
-	var rating float64
-	if ssGame.Note.Text != "" {
-		// Screenscraper uses 0-20 scale, convert to 0-1
-		if noteVal, err := strconv.ParseFloat(ssGame.Note.Text, 64); err == nil {
-			rating = noteVal / 20.0
-		}
-	}
-
-	// Format release date (YYYYMMDDTHHMMSS)
-	releaseDate := selectReleaseDate(ssGame.Dates, romRegions, userRegions)
-
-	// Get developer, publisher
-	developer := ssGame.Developer.Text
-	publisher := ssGame.Publisher.Text
-
 	// Parse players
 	var players int
-	if ssGame.Players.Text != "" {
-		if p, err := strconv.Atoi(ssGame.Players.Text); err == nil {
+	if md.Players != "" {
+		if p, err := strconv.Atoi(md.Players); err == nil {
 			players = p
 		}
 	}
@@ -125,12 +86,12 @@ func (g *Generator) resultToGame(result *scraper.ScrapeResult) esde.Game {
 	return esde.Game{
 		Path:        "./" + entry.BaseName + filepath.Ext(entry.Name),
 		Name:        name,
-		Desc:        desc,
-		Rating:      rating,
-		ReleaseDate: releaseDate,
-		Developer:   developer,
-		Publisher:   publisher,
-		Genre:       strings.Join(genres, ", "),
+		Desc:        md.Description,
+		Rating:      md.Rating,
+		ReleaseDate: esde.DateTime{Time: md.ReleaseDate},
+		Developer:   md.Developer,
+		Publisher:   md.Publisher,
+		Genre:       strings.Join(md.Genres, ", "),
 		Players:     players,
 	}
 }
@@ -220,98 +181,3 @@ func (g *Generator) writeGameList(list *esde.GameList) error {
 
 	return nil
 }
-
-// Helper functions for localized content selection
-
-func selectName(names []screenscraper.NameEntry, romRegions, userRegions []string) string {
-	if len(names) == 0 {
-		return ""
-	}
-
-	searchOrder := region.BuildSearchOrder(romRegions, userRegions)
-
-	for _, r := range searchOrder {
-		for _, n := range names {
-			if n.Region == r && n.Text != "" {
-				return n.Text
-			}
-		}
-	}
-
-	// Fallback to any
-	for _, n := range names {
-		if n.Text != "" {
-			return n.Text
-		}
-	}
-
-	return ""
-}
-
-func selectLocalizedText(entries []screenscraper.LocalizedName, romRegions, userRegions []string) string {
-	if len(entries) == 0 {
-		return ""
-	}
-
-	// Convert to region.LocalizedEntry
-	regionEntries := make([]region.LocalizedEntry, len(entries))
-	for i, e := range entries {
-		regionEntries[i] = region.LocalizedEntry{
-			Language: e.Language,
-			Text:     e.Text,
-		}
-	}
-
-	return region.SelectLocalizedText(regionEntries, romRegions, userRegions)
-}
-
-func selectReleaseDate(dates []screenscraper.DateEntry, romRegions, userRegions []string) esde.DateTime {
-	if len(dates) == 0 {
-		return esde.DateTime{}
-	}
-
-	searchOrder := region.BuildSearchOrder(romRegions, userRegions)
-
-	for _, r := range searchOrder {
-		for _, d := range dates {
-			if d.Region == r && d.Text != "" {
-				return parseDate(d.Text)
-			}
-		}
-	}
-
-	// Fallback to any
-	for _, d := range dates {
-		if d.Text != "" {
-			return parseDate(d.Text)
-		}
-	}
-
-	return esde.DateTime{}
-}
-
-// parseDate converts screenscraper date format to esde.DateTime
-// Screenscraper: "1991-06-23" or "1991"
-func parseDate(date string) esde.DateTime {
-	// Remove any dashes
-	clean := strings.ReplaceAll(date, "-", "")
-
-	// Pad with zeros if needed
-	var dateStr string
-	switch len(clean) {
-	case 4: // Just year
-		dateStr = clean + "0101T000000"
-	case 6: // Year and month
-		dateStr = clean + "01T000000"
-	case 8: // Full date
-		dateStr = clean + "T000000"
-	default:
-		dateStr = clean
-	}
-
-	t, err := time.Parse(esde.DateTimeFormat, dateStr)
-	if err != nil {
-		return esde.DateTime{}
-	}
-	return esde.DateTime{Time: t}
-}
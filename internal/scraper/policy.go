@@ -0,0 +1,77 @@
+package scraper
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"strings"
+)
+
+// MediaPolicy configures which of the media Screenscraper has for a game
+// is downloaded. The regions it's chosen from are Config.PreferredRegions.
+type MediaPolicy struct {
+	// Sources maps ES-DE media types to the Screenscraper media types
+	// downloaded for them, in priority order. Types not in it use
+	// MediaTypeMapping.
+	Sources map[string][]string
+
+	// MinWidth and MinHeight are the smallest images accepted, in pixels;
+	// smaller ones are passed over for the next region or media type. 0 for
+	// no minimum. Videos are always accepted.
+	MinWidth  int
+	MinHeight int
+}
+
+// sources returns the Screenscraper media types for an ES-DE media type
+func (p *MediaPolicy) sources(esdeType string) ([]string, bool) {
+	if ssTypes, ok := p.Sources[esdeType]; ok {
+		return ssTypes, true
+	}
+	ssTypes, ok := MediaTypeMapping[esdeType]
+	return ssTypes, ok
+}
+
+// accepts returns true if media meets the minimum resolution. Media that
+// isn't a decodable image is accepted.
+func (p *MediaPolicy) accepts(data []byte) bool {
+	if p.MinWidth <= 0 && p.MinHeight <= 0 {
+		return true
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return true
+	}
+	return config.Width >= p.MinWidth && config.Height >= p.MinHeight
+}
+
+// ParseMediaSources parses media source overrides of the form
+// "marquees=wheel-carbon,wheel": an ES-DE media type, and the Screenscraper
+// media types to download for it in priority order
+func ParseMediaSources(specs []string) (map[string][]string, error) {
+	sources := make(map[string][]string)
+	for _, spec := range specs {
+		esdeType, list, ok := strings.Cut(spec, "=")
+		esdeType = strings.TrimSpace(esdeType)
+		if !ok {
+			return nil, fmt.Errorf("invalid media source %q: want <type>=<screenscraper types>", spec)
+		}
+		if _, known := MediaTypeMapping[esdeType]; !known {
+			return nil, fmt.Errorf("unknown media type %q", esdeType)
+		}
+
+		var ssTypes []string
+		for _, ssType := range strings.Split(list, ",") {
+			if ssType = strings.TrimSpace(ssType); ssType != "" {
+				ssTypes = append(ssTypes, ssType)
+			}
+		}
+		if len(ssTypes) == 0 {
+			return nil, fmt.Errorf("invalid media source %q: no Screenscraper media types", spec)
+		}
+		sources[esdeType] = ssTypes
+	}
+	return sources, nil
+}
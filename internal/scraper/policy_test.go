@@ -0,0 +1,87 @@
+package scraper
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"reflect"
+	"testing"
+)
+
+// testPNG returns a blank PNG image of the given size
+func testPNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestParseMediaSources(t *testing.T) {
+	tests := []struct {
+		name    string
+		specs   []string
+		want    map[string][]string
+		wantErr bool
+	}{
+		{name: "none", want: map[string][]string{}},
+		{
+			name:  "sources",
+			specs: []string{"marquees=wheel-carbon, wheel", "covers=box-2D"},
+			want:  map[string][]string{"marquees": {"wheel-carbon", "wheel"}, "covers": {"box-2D"}},
+		},
+		{name: "no separator", specs: []string{"marquees"}, wantErr: true},
+		{name: "unknown type", specs: []string{"wheels=wheel"}, wantErr: true},
+		{name: "no sources", specs: []string{"marquees=,"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseMediaSources(tt.specs)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseMediaSources() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseMediaSources() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMediaPolicy_Accepts(t *testing.T) {
+	small := testPNG(t, 160, 144)
+	large := testPNG(t, 640, 480)
+
+	tests := []struct {
+		name   string
+		policy MediaPolicy
+		data   []byte
+		want   bool
+	}{
+		{name: "no minimum", data: small, want: true},
+		{name: "large enough", policy: MediaPolicy{MinWidth: 640, MinHeight: 480}, data: large, want: true},
+		{name: "too narrow", policy: MediaPolicy{MinWidth: 320}, data: small, want: false},
+		{name: "too short", policy: MediaPolicy{MinHeight: 480}, data: small, want: false},
+		{name: "not an image", policy: MediaPolicy{MinWidth: 320}, data: []byte("video"), want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.accepts(tt.data); got != tt.want {
+				t.Errorf("accepts() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMediaPolicy_Sources(t *testing.T) {
+	policy := MediaPolicy{Sources: map[string][]string{"marquees": {"wheel-carbon"}}}
+	if got, _ := policy.sources("marquees"); !reflect.DeepEqual(got, []string{"wheel-carbon"}) {
+		t.Errorf("sources(marquees) = %v, want the override", got)
+	}
+	if got, _ := policy.sources("covers"); !reflect.DeepEqual(got, MediaTypeMapping["covers"]) {
+		t.Errorf("sources(covers) = %v, want the default", got)
+	}
+	if _, ok := policy.sources("unknown"); ok {
+		t.Error("sources(unknown) ok = true, want false")
+	}
+}
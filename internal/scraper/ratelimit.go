@@ -2,10 +2,19 @@ package scraper
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strconv"
 	"sync"
 	"time"
+
+	"github.com/sargunv/rom-tools/lib/screenscraper"
 )
 
+// ErrQuotaExceeded is returned once the account's daily request quota is
+// used up. Screenscraper bans accounts that keep making requests past it.
+var ErrQuotaExceeded = errors.New("Screenscraper daily request quota exceeded")
+
 // RateLimiter controls request rate to respect API limits
 type RateLimiter struct {
 	mu sync.Mutex
@@ -28,14 +37,44 @@ type RateLimiter struct {
 	// Backoff state
 	backoffUntil time.Time
 	backoffLevel int
+
+	// Daily quota (-1 if unlimited)
+	quotaLeft     int
+	quotaExceeded bool
 }
 
 const (
 	maxBackoffLevel = 6 // Max backoff: 2^6 = 64 seconds
 	baseBackoff     = 1 * time.Second
 	maxBackoff      = 60 * time.Second
+	maxRetries      = 3 // retries of rate limited requests
 )
 
+// Limits are the request limits of a Screenscraper account
+type Limits struct {
+	MaxThreads        int
+	MaxRequestsPerMin int
+	RequestsLeftToday int // -1 if unknown
+}
+
+// LimitsFromUser returns the limits of a user, defaulting to 1 thread and
+// 60 requests per minute if they're missing
+func LimitsFromUser(user screenscraper.UserInfo) Limits {
+	limits := Limits{MaxThreads: 1, MaxRequestsPerMin: 60, RequestsLeftToday: -1}
+	if n, err := strconv.Atoi(user.MaxThreads); err == nil && n > 0 {
+		limits.MaxThreads = n
+	}
+	if n, err := strconv.Atoi(user.MaxRequestsPerMin); err == nil && n > 0 {
+		limits.MaxRequestsPerMin = n
+	}
+	maxPerDay, err1 := strconv.Atoi(user.MaxRequestsPerDay)
+	today, err2 := strconv.Atoi(user.RequestsToday)
+	if err1 == nil && err2 == nil && maxPerDay > 0 {
+		limits.RequestsLeftToday = max(maxPerDay-today, 0)
+	}
+	return limits
+}
+
 // NewRateLimiter creates a new rate limiter
 func NewRateLimiter(maxThreads, maxPerMinute int) *RateLimiter {
 	rl := &RateLimiter{
@@ -44,6 +83,7 @@ func NewRateLimiter(maxThreads, maxPerMinute int) *RateLimiter {
 		threadSem:    make(chan struct{}, maxThreads),
 		requestTimes: make([]time.Time, 0, maxPerMinute),
 		startTime:    time.Now(),
+		quotaLeft:    -1,
 	}
 
 	// Pre-fill thread semaphore
@@ -54,9 +94,38 @@ func NewRateLimiter(maxThreads, maxPerMinute int) *RateLimiter {
 	return rl
 }
 
+// SetDailyQuota limits the requests made from now on to left, the requests
+// left in the account's daily quota, or removes the limit if negative
+func (rl *RateLimiter) SetDailyQuota(left int) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.quotaLeft = left
+	rl.quotaExceeded = left == 0
+}
+
+// ExhaustQuota marks the daily quota as used up (call when receiving 430
+// or 431), so no more requests are made
+func (rl *RateLimiter) ExhaustQuota() {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.quotaExceeded = true
+}
+
+// QuotaExceeded returns true if the daily quota is used up
+func (rl *RateLimiter) QuotaExceeded() bool {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	return rl.quotaExceeded
+}
+
 // Acquire waits for permission to make a request
-// Returns an error if the context is cancelled
+// Returns an error if the context is cancelled, or ErrQuotaExceeded if the
+// daily quota is used up
 func (rl *RateLimiter) Acquire(ctx context.Context) error {
+	if rl.QuotaExceeded() {
+		return ErrQuotaExceeded
+	}
+
 	// Wait for a thread slot
 	select {
 	case <-ctx.Done():
@@ -80,7 +149,10 @@ func (rl *RateLimiter) Acquire(ctx context.Context) error {
 	}
 
 	// Record this request
-	rl.recordRequest()
+	if !rl.recordRequest() {
+		rl.threadSem <- struct{}{}
+		return ErrQuotaExceeded
+	}
 
 	return nil
 }
@@ -109,7 +181,8 @@ func (rl *RateLimiter) recordCompleted() {
 	rl.completedTimes = newTimes
 }
 
-// TriggerBackoff triggers exponential backoff (call when receiving 429)
+// TriggerBackoff triggers exponential backoff (call when receiving 429, or
+// 401 when the API is closed to the account's level)
 func (rl *RateLimiter) TriggerBackoff() {
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
@@ -188,11 +261,50 @@ func (rl *RateLimiter) waitForBackoff(ctx context.Context) error {
 }
 
 // recordRequest records a request time for rate limiting
-func (rl *RateLimiter) recordRequest() {
+// Returns false if the daily quota was used up
+func (rl *RateLimiter) recordRequest() bool {
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
+	if rl.quotaExceeded {
+		return false
+	}
+	if rl.quotaLeft > 0 {
+		rl.quotaLeft--
+		rl.quotaExceeded = rl.quotaLeft == 0
+	}
 	rl.requestTimes = append(rl.requestTimes, time.Now())
 	rl.totalRequests++
+	return true
+}
+
+// doRequest makes a request within the limits, backing off and retrying
+// it while Screenscraper is rate limiting or closed to the account's level.
+// Returns ErrQuotaExceeded if the daily quota is used up.
+func doRequest[R screenscraper.Response](ctx context.Context, rl *RateLimiter, request func() (R, error)) (R, error) {
+	var zero R
+	for attempt := 0; ; attempt++ {
+		if err := rl.Acquire(ctx); err != nil {
+			return zero, err
+		}
+		resp, err := request()
+		rl.Release()
+		if err != nil {
+			return zero, err
+		}
+
+		switch {
+		case screenscraper.IsQuotaExceeded(resp):
+			rl.ExhaustQuota()
+			return zero, ErrQuotaExceeded
+		case screenscraper.IsRateLimited(resp), screenscraper.IsServerBusy(resp):
+			rl.TriggerBackoff()
+			if attempt == maxRetries {
+				return zero, fmt.Errorf("rate limited: HTTP %d", resp.StatusCode())
+			}
+			continue
+		}
+		return resp, nil
+	}
 }
 
 // RateLimiterStats contains current rate limiter statistics
@@ -0,0 +1,112 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/sargunv/rom-tools/lib/screenscraper"
+)
+
+func TestLimitsFromUser(t *testing.T) {
+	tests := []struct {
+		name string
+		user screenscraper.UserInfo
+		want Limits
+	}{
+		{
+			name: "member",
+			user: screenscraper.UserInfo{MaxThreads: "4", MaxRequestsPerMin: "512", MaxRequestsPerDay: "20000", RequestsToday: "150"},
+			want: Limits{MaxThreads: 4, MaxRequestsPerMin: 512, RequestsLeftToday: 19850},
+		},
+		{
+			name: "quota used up",
+			user: screenscraper.UserInfo{MaxThreads: "1", MaxRequestsPerMin: "60", MaxRequestsPerDay: "100", RequestsToday: "120"},
+			want: Limits{MaxThreads: 1, MaxRequestsPerMin: 60, RequestsLeftToday: 0},
+		},
+		{
+			name: "missing",
+			want: Limits{MaxThreads: 1, MaxRequestsPerMin: 60, RequestsLeftToday: -1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := LimitsFromUser(tt.user); got != tt.want {
+				t.Errorf("LimitsFromUser() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateLimiter_DailyQuota(t *testing.T) {
+	ctx := context.Background()
+	rl := NewRateLimiter(1, 60)
+	rl.SetDailyQuota(2)
+
+	for i := 0; i < 2; i++ {
+		if err := rl.Acquire(ctx); err != nil {
+			t.Fatalf("Acquire() #%d error = %v", i+1, err)
+		}
+		rl.Release()
+	}
+	if err := rl.Acquire(ctx); !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("Acquire() past the quota error = %v, want ErrQuotaExceeded", err)
+	}
+	if stats := rl.Stats(); stats.ActiveThreads != 0 {
+		t.Errorf("ActiveThreads = %d, want 0 after a refused request", stats.ActiveThreads)
+	}
+}
+
+func TestScrapeROM_RateLimited(t *testing.T) {
+	gameInfo, err := os.ReadFile("../../lib/screenscraper/testdata/game_info.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rom, err := os.ReadFile("../../lib/identify/testdata/gbtictac.gb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "Tic Tac (USA).gb")
+	if err := os.WriteFile(path, rom, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		status    int // status of the first response
+		wantCalls int32
+		wantErr   error
+	}{
+		{name: "rate limited", status: http.StatusTooManyRequests, wantCalls: 2},
+		{name: "closed for leechers", status: http.StatusUnauthorized, wantCalls: 2},
+		{name: "quota exceeded", status: 430, wantCalls: 1, wantErr: ErrQuotaExceeded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			s := newTestScraper(t, &Config{}, func(w http.ResponseWriter, r *http.Request) {
+				if calls.Add(1) == 1 {
+					w.WriteHeader(tt.status)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.Write(gameInfo)
+			})
+
+			_, err := s.ScrapeROM(context.Background(), path)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ScrapeROM() error = %v, want %v", err, tt.wantErr)
+			}
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("Requests = %d, want %d", got, tt.wantCalls)
+			}
+			if tt.wantErr != nil && !s.rateLimiter.QuotaExceeded() {
+				t.Error("QuotaExceeded() = false, want true")
+			}
+		})
+	}
+}
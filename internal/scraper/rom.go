@@ -0,0 +1,100 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/sargunv/rom-tools/internal/region"
+	"github.com/sargunv/rom-tools/lib/core"
+	"github.com/sargunv/rom-tools/lib/identify"
+)
+
+// ErrNotFound is returned by ScrapeROM when the backend has no game for a
+// ROM.
+var ErrNotFound = errors.New("game not found")
+
+// ScrapeROM identifies the ROM at path and looks up its game on
+// Screenscraper by its hashes, or by its file name within its system if no
+// ROM has them. Archives are looked up by their primary item.
+//
+// The system is the configured one, or else the identified platform's.
+// Lookups go through the cache, rate limiter, and deduplicator like those
+// of other scrapes. It returns ErrNotFound if there's no game.
+func (s *Scraper) ScrapeROM(ctx context.Context, path string) (*Metadata, error) {
+	entry, platform, err := romLookupEntry(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.config.SystemID == "" {
+		systemID := PlatformSystemID(platform)
+		if systemID == "" {
+			return nil, fmt.Errorf("no Screenscraper system for %s, so one must be set", path)
+		}
+		entry.SystemID = systemID
+	}
+
+	worker := NewWorker(0, s.client, s.cache, s.config, s.rateLimiter, s.dedup, nil)
+	game, _, notFound, err := worker.lookupGame(ctx, entry)
+	if err != nil {
+		return nil, err
+	}
+	if notFound {
+		return nil, ErrNotFound
+	}
+	return NewMetadata(game, entry.Regions, s.config.PreferredRegions), nil
+}
+
+// romLookupEntry identifies the ROM at path as a lookup entry, and returns
+// its identified platform, if any.
+func romLookupEntry(ctx context.Context, path string) (*LookupEntry, core.Platform, error) {
+	result, err := identify.IdentifyContext(ctx, path, identify.DefaultOptions())
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to identify %s: %w", path, err)
+	}
+
+	var item *identify.Item
+	for i := range result.Items {
+		if result.Items[i].Primary {
+			item = &result.Items[i]
+			break
+		}
+	}
+	if item == nil {
+		return nil, "", fmt.Errorf("no ROM found in %s", path)
+	}
+
+	entry, platform := itemLookupEntry(item)
+	entry.Name = BaseName(filepath.Base(path))
+	entry.Regions = region.ParseFilename(filepath.Base(path))
+	entry.BaseName = BaseName(filepath.Base(path))
+	entry.ROMPath = result.Path
+	return entry, platform, nil
+}
+
+// itemLookupEntry returns a lookup entry of an identified item, with its
+// file name, hashes, and serial, and its identified platform, if any
+func itemLookupEntry(item *identify.Item) (*LookupEntry, core.Platform) {
+	entry := &LookupEntry{
+		FileName: filepath.Base(item.Name),
+		Hashes: Hashes{
+			SHA1:  item.Hashes[core.HashSHA1],
+			MD5:   item.Hashes[core.HashMD5],
+			CRC32: item.Hashes[core.HashCRC32],
+		},
+		Size:   item.Size,
+		Source: SourceROM,
+	}
+	if entry.Hashes.CRC32 == "" {
+		entry.Hashes.CRC32 = item.Hashes[core.HashZipCRC32]
+	}
+
+	var platform core.Platform
+	if item.Game != nil {
+		entry.Serial = item.Game.GameSerial()
+		platform = item.Game.GamePlatform()
+	}
+	return entry, platform
+}
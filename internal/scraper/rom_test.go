@@ -0,0 +1,158 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sargunv/rom-tools/internal/cache"
+	"github.com/sargunv/rom-tools/lib/core"
+	"github.com/sargunv/rom-tools/lib/screenscraper"
+)
+
+// newTestScraper returns a scraper of a server that answers jeuInfos
+// requests with handler.
+func newTestScraper(t *testing.T, config *Config, handler http.HandlerFunc) *Scraper {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	inner, err := screenscraper.NewClientWithResponses(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	diskCache, err := cache.New(t.TempDir(), time.Hour, cache.ModeNormal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.MaxThreads = 1
+	config.MaxRequestsPerMin = 60
+	return New(&screenscraper.ScreenscraperClient{ClientWithResponses: inner}, diskCache, config)
+}
+
+func TestScrapeROM(t *testing.T) {
+	gameInfo, err := os.ReadFile("../../lib/screenscraper/testdata/game_info.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rom, err := os.ReadFile("../../lib/identify/testdata/gbtictac.gb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "Tic Tac (USA).gb")
+	if err := os.WriteFile(path, rom, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		byHash    bool // whether the server knows the hashes
+		byName    bool // whether the server knows the name
+		wantQuery []string
+		wantErr   error
+	}{
+		{name: "hash", byHash: true, wantQuery: []string{"hash"}},
+		{name: "name fallback", byName: true, wantQuery: []string{"hash", "name"}},
+		{name: "not found", wantQuery: []string{"hash", "name"}, wantErr: ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var queries []string
+			s := newTestScraper(t, &Config{PreferredRegions: []string{"us"}}, func(w http.ResponseWriter, r *http.Request) {
+				q := r.URL.Query()
+				if q.Get("systemeid") != "9" {
+					t.Errorf("systemeid = %q, want 9 (Game Boy)", q.Get("systemeid"))
+				}
+				if q.Get("romnom") != "Tic Tac (USA).gb" {
+					t.Errorf("romnom = %q, want the file name", q.Get("romnom"))
+				}
+				found := tt.byName
+				query := "name"
+				if q.Get("crc") != "" {
+					found = tt.byHash
+					query = "hash"
+					if q.Get("sha1") == "" || q.Get("md5") == "" {
+						t.Errorf("hash query without sha1 and md5: %s", r.URL.RawQuery)
+					}
+				}
+				mu.Lock()
+				queries = append(queries, query)
+				mu.Unlock()
+
+				if !found {
+					http.NotFound(w, r)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.Write(gameInfo)
+			})
+
+			md, err := s.ScrapeROM(context.Background(), path)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ScrapeROM() error = %v, want %v", err, tt.wantErr)
+			}
+			if !slices.Equal(queries, tt.wantQuery) {
+				t.Errorf("Queries = %v, want %v", queries, tt.wantQuery)
+			}
+			if err != nil {
+				// Games that aren't found are cached too
+				if _, err := s.ScrapeROM(context.Background(), path); !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ScrapeROM() again error = %v, want %v", err, tt.wantErr)
+				}
+				if !slices.Equal(queries, tt.wantQuery) {
+					t.Errorf("Queries again = %v, want %v from the cache", queries, tt.wantQuery)
+				}
+				return
+			}
+
+			if md.ID != "2138" || md.Name != "The Legend of Zelda : A Link to the Past" {
+				t.Errorf("Metadata = %s %q, want 2138 in US English", md.ID, md.Name)
+			}
+			if want := time.Date(1992, 4, 13, 0, 0, 0, 0, time.UTC); !md.ReleaseDate.Equal(want) {
+				t.Errorf("ReleaseDate = %v, want %v", md.ReleaseDate, want)
+			}
+			if md.Rating != 0.95 {
+				t.Errorf("Rating = %v, want 0.95", md.Rating)
+			}
+			if len(md.Genres) != 1 || md.Genres[0] != "Role Playing Game" {
+				t.Errorf("Genres = %v, want [Role Playing Game]", md.Genres)
+			}
+		})
+	}
+}
+
+func TestLookupSystemID(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"gb", "9"},
+		{"GameBoy", "9"},
+		{"msx2", "116"}, // only a platform name
+		{" c64 ", "66"},
+	}
+	for _, tt := range tests {
+		got, err := LookupSystemID(tt.name)
+		if err != nil || got != tt.want {
+			t.Errorf("LookupSystemID(%q) = %q, %v; want %q", tt.name, got, err, tt.want)
+		}
+	}
+	if _, err := LookupSystemID("nope"); err == nil {
+		t.Error("LookupSystemID(nope) succeeded, want error")
+	}
+
+	// Aliases named after platforms agree with the platforms' systems
+	for name, id := range SystemMapping {
+		if want := PlatformSystemID(core.Platform(name)); want != "" && id != want {
+			t.Errorf("SystemMapping[%q] = %s, but its platform's system is %s", name, id, want)
+		}
+	}
+}
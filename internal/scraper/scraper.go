@@ -26,11 +26,15 @@ type Scraper struct {
 
 // New creates a new scraper
 func New(client *screenscraper.ScreenscraperClient, diskCache *cache.DiskCache, config *Config) *Scraper {
+	rateLimiter := NewRateLimiter(config.MaxThreads, config.MaxRequestsPerMin)
+	if config.RequestsLeftToday > 0 {
+		rateLimiter.SetDailyQuota(config.RequestsLeftToday)
+	}
 	return &Scraper{
 		client:      client,
 		cache:       diskCache,
 		config:      config,
-		rateLimiter: NewRateLimiter(config.MaxThreads, config.MaxRequestsPerMin),
+		rateLimiter: rateLimiter,
 		dedup:       NewDeduplicator(),
 		updates:     make(chan ProgressUpdate, 100),
 	}
@@ -57,7 +61,8 @@ type ScrapeResults struct {
 	MediaTotal      int
 	MediaDownloaded int
 	CacheHits       int
-	FilteredOut     int // entries excluded by --filter expression
+	FilteredOut     int  // entries excluded by --filter expression
+	QuotaExceeded   bool // the daily quota ran out before all entries were scraped
 }
 
 // ScrapeFromDAT scrapes games from a DAT file
@@ -79,6 +84,11 @@ func (s *Scraper) ScrapeFromDAT(ctx context.Context, datPath string) (*ScrapeRes
 	return results, err
 }
 
+// ScrapeEntries scrapes lookup entries, like those of LibraryEntries
+func (s *Scraper) ScrapeEntries(ctx context.Context, entries []*LookupEntry) (*ScrapeResults, error) {
+	return s.scrape(ctx, entries)
+}
+
 // datToLookupEntries converts DAT games to lookup entries
 // Returns entries to scrape and count of entries filtered out
 func (s *Scraper) datToLookupEntries(dat *datfile.Datafile) ([]*LookupEntry, int) {
@@ -174,6 +184,10 @@ func (s *Scraper) scrape(ctx context.Context, entries []*LookupEntry) (*ScrapeRe
 			worker := NewWorker(workerID, s.client, s.cache, s.config, s.rateLimiter, s.dedup, s.updates)
 
 			for entry := range entryChan {
+				// Leave the rest unscraped rather than failing each one
+				if s.rateLimiter.QuotaExceeded() {
+					return
+				}
 				select {
 				case <-ctx.Done():
 					return
@@ -193,8 +207,12 @@ func (s *Scraper) scrape(ctx context.Context, entries []*LookupEntry) (*ScrapeRe
 	}()
 
 	// Collect results
+	var journalErr error
 	for result := range resultChan {
 		results.Results = append(results.Results, result)
+		if s.config.Journal != nil && journalErr == nil {
+			journalErr = s.config.Journal.Record(result)
+		}
 
 		if result.Skipped {
 			results.Skipped++
@@ -211,7 +229,8 @@ func (s *Scraper) scrape(ctx context.Context, entries []*LookupEntry) (*ScrapeRe
 		results.CacheHits += result.CacheHits
 	}
 
-	return results, nil
+	results.QuotaExceeded = s.rateLimiter.QuotaExceeded()
+	return results, journalErr
 }
 
 // GetConfig returns the scraper configuration
@@ -3,23 +3,25 @@ package scraper
 import (
 	"fmt"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/sargunv/rom-tools/lib/core"
+	"github.com/sargunv/rom-tools/lib/screenscraper"
 )
 
 // SystemMapping maps platform names to Screenscraper system IDs.
-// Platform names can be romident Platform values, recalbox names, or common aliases.
+// Platform names can be core.Platform values, recalbox names, or common aliases.
 var SystemMapping = map[string]string{
 	// Nintendo consoles (from screenscraper list systems)
 	"nes":          "3",
-	"famicom":      "3", // romident Platform
+	"famicom":      "3", // core.Platform
 	"snes":         "4",
-	"superfamicom": "4", // romident Platform
+	"superfamicom": "4", // core.Platform
 	"n64":          "14",
-	"nintendo64":   "14", // romident Platform
+	"nintendo64":   "14", // core.Platform
 	"gc":           "13",
-	"gamecube":     "13", // romident Platform
+	"gamecube":     "13", // core.Platform
 	"ngc":          "13", // alias
 	"wii":          "16",
 	"wiiu":         "18",
@@ -27,14 +29,14 @@ var SystemMapping = map[string]string{
 
 	// Nintendo handhelds
 	"gb":             "9",
-	"gameboy":        "9", // romident Platform
+	"gameboy":        "9", // core.Platform
 	"gbc":            "10",
-	"gameboycolor":   "10", // romident Platform
+	"gameboycolor":   "10", // core.Platform
 	"gba":            "12",
-	"gameboyadvance": "12", // romident Platform
+	"gameboyadvance": "12", // core.Platform
 	"nds":            "15",
-	"ds":             "15", // romident Platform
-	"dsi":            "15", // romident Platform (same system ID)
+	"ds":             "15", // core.Platform
+	"dsi":            "15", // core.Platform (same system ID)
 	"3ds":            "17",
 	"virtualboy":     "11",
 	"vb":             "11", // alias
@@ -49,6 +51,7 @@ var SystemMapping = map[string]string{
 	"32x":          "19", // alias
 	"segacd":       "20",
 	"megacd":       "20", // alias
+	"pico":         "250",
 	"gamegear":     "21",
 	"gg":           "21", // alias
 	"saturn":       "22",
@@ -58,11 +61,11 @@ var SystemMapping = map[string]string{
 	// Sony consoles
 	"psx":          "57",
 	"ps1":          "57", // alias
-	"playstation":  "57", // romident Platform
+	"playstation":  "57", // core.Platform
 	"ps2":          "58",
-	"playstation2": "58", // romident Platform
+	"playstation2": "58", // core.Platform
 	"ps3":          "59",
-	"playstation3": "59", // romident Platform
+	"playstation3": "59", // core.Platform
 
 	// Sony handhelds
 	"psp":    "61",
@@ -79,6 +82,9 @@ var SystemMapping = map[string]string{
 	"pce":          "31", // alias
 	"turbografx16": "31", // alias
 	"tg16":         "31", // alias
+	"pcenginecd":   "114",
+	"pcecd":        "114", // alias
+	"tgcd":         "114", // alias
 	"supergrafx":   "105",
 	"sgx":          "105", // alias
 	"pcfx":         "72",
@@ -103,6 +109,7 @@ var SystemMapping = map[string]string{
 	"atarilynx":   "28", // alias
 	"jaguar":      "27",
 	"atarijaguar": "27", // alias
+	"jaguarcd":    "171",
 
 	// Bandai
 	"wonderswan":      "45",
@@ -118,7 +125,7 @@ var SystemMapping = map[string]string{
 }
 
 // LookupSystemID converts a platform name to a Screenscraper system ID.
-// Accepts romident Platform values, recalbox names, or common aliases.
+// Accepts core.Platform values, recalbox names, or common aliases.
 // Returns error if the platform is not recognized.
 func LookupSystemID(platform string) (string, error) {
 	// Normalize input
@@ -128,10 +135,23 @@ func LookupSystemID(platform string) (string, error) {
 	if id, ok := SystemMapping[normalized]; ok {
 		return id, nil
 	}
+	if id := PlatformSystemID(core.Platform(normalized)); id != "" {
+		return id, nil
+	}
 
 	return "", fmt.Errorf("unknown system: %q (use 'rom-tools screenscraper list systems' to see all systems)", platform)
 }
 
+// PlatformSystemID returns the Screenscraper system ID of a platform, or ""
+// if it has none.
+func PlatformSystemID(platform core.Platform) string {
+	id, ok := screenscraper.SystemID(platform)
+	if !ok {
+		return ""
+	}
+	return strconv.Itoa(id)
+}
+
 // AvailableSystems returns a sorted list of commonly used system names.
 func AvailableSystems() []string {
 	// Collect unique primary names (prefer short names)
@@ -144,17 +164,17 @@ func AvailableSystems() []string {
 		"nes", "snes", "n64", "gc", "wii", "wiiu", "fds",
 		"gb", "gbc", "gba", "nds", "3ds", "virtualboy",
 		// Sega
-		"megadrive", "mastersystem", "sega32x", "segacd", "gamegear", "saturn", "dreamcast",
+		"megadrive", "mastersystem", "sega32x", "segacd", "pico", "gamegear", "saturn", "dreamcast",
 		// Sony
 		"psx", "ps2", "ps3", "psp", "psvita",
 		// Microsoft
 		"xbox", "xbox360",
 		// NEC
-		"pcengine", "supergrafx", "pcfx",
+		"pcengine", "pcenginecd", "supergrafx", "pcfx",
 		// SNK
 		"neogeo", "neogeocd", "ngp", "ngpc",
 		// Atari
-		"atari2600", "atari5200", "atari7800", "lynx", "jaguar",
+		"atari2600", "atari5200", "atari7800", "lynx", "jaguar", "jaguarcd",
 		// Bandai
 		"wonderswan", "wonderswancolor",
 		// Other
@@ -168,8 +188,8 @@ func AvailableSystems() []string {
 		}
 	}
 
-	// Also include romident Platform values that aren't already covered
-	romidentPlatforms := []core.Platform{
+	// Also include core.Platform values that aren't already covered
+	platforms := []core.Platform{
 		core.PlatformNES, core.PlatformSNES, core.PlatformN64, core.PlatformGC,
 		core.PlatformWii, core.PlatformWiiU, core.PlatformGB, core.PlatformGBC,
 		core.PlatformGBA, core.PlatformNDS, core.PlatformDSi, core.Platform3DS,
@@ -178,7 +198,7 @@ func AvailableSystems() []string {
 		core.PlatformDreamcast, core.PlatformGameGear, core.PlatformXbox, core.PlatformXbox360,
 	}
 
-	for _, p := range romidentPlatforms {
+	for _, p := range platforms {
 		name := string(p)
 		if _, ok := SystemMapping[name]; ok && !seen[name] {
 			result = append(result, name)
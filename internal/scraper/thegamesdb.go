@@ -0,0 +1,79 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/sargunv/rom-tools/lib/thegamesdb"
+)
+
+// TheGamesDB is a backend that looks ROMs up on TheGamesDB by the title in
+// their file names, within their identified platform. Lookups aren't
+// cached or rate limited.
+type TheGamesDB struct {
+	client *thegamesdb.Client
+}
+
+// NewTheGamesDB creates a TheGamesDB backend
+func NewTheGamesDB(client *thegamesdb.Client) *TheGamesDB {
+	return &TheGamesDB{client: client}
+}
+
+// Name returns "thegamesdb"
+func (b *TheGamesDB) Name() string {
+	return "thegamesdb"
+}
+
+// ScrapeROM identifies the ROM at path and looks up its game on TheGamesDB
+func (b *TheGamesDB) ScrapeROM(ctx context.Context, path string) (*Metadata, error) {
+	entry, platform, err := romLookupEntry(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	platformID, ok := thegamesdb.PlatformID(platform)
+	if !ok {
+		return nil, fmt.Errorf("no TheGamesDB platform for %s", path)
+	}
+
+	title := searchTitle(entry)
+	games, err := b.client.GamesByName(ctx, title, platformID)
+	if err != nil {
+		return nil, err
+	}
+	if len(games) == 0 {
+		return nil, ErrNotFound
+	}
+	game := bestMatch(games, title, func(g thegamesdb.Game) string { return g.Title })
+
+	// Games refer to genres and companies by ID
+	genres, err := b.client.GenreNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+	developers, err := b.client.DeveloperNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+	publishers, err := b.client.PublisherNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	md := &Metadata{
+		Source:      b.Name(),
+		ID:          strconv.Itoa(game.ID),
+		SystemID:    strconv.Itoa(platformID),
+		Name:        game.Title,
+		Description: game.Overview,
+		Developer:   strings.Join(thegamesdb.Names(developers, game.Developers), ", "),
+		Publisher:   strings.Join(thegamesdb.Names(publishers, game.Publishers), ", "),
+		ReleaseDate: game.Released(),
+		Genres:      thegamesdb.Names(genres, game.Genres),
+	}
+	if game.Players > 0 {
+		md.Players = strconv.Itoa(game.Players)
+	}
+	return md, nil
+}
@@ -4,6 +4,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/sargunv/rom-tools/lib/miximage"
 	"github.com/sargunv/rom-tools/lib/screenscraper"
 )
 
@@ -53,6 +54,7 @@ type LookupEntry struct {
 	FileName string // ROM filename with extension (for API)
 	Hashes   Hashes // SHA1, MD5, CRC32
 	Serial   string // Game code (from DAT serial or ROM header)
+	SystemID string // Screenscraper system ID, if not the configured one
 	Size     int64  // File size in bytes
 
 	// Region info (parsed from name or ROM header)
@@ -116,7 +118,11 @@ type Config struct {
 	SystemID string
 
 	// Media selection
-	MediaTypes []string // ES-DE media type names
+	MediaTypes []string    // ES-DE media type names
+	Media      MediaPolicy // which media of each type to download
+
+	// Layout of the miximages composed of the downloaded media, if any
+	Miximage *miximage.Layout
 
 	// Region preferences
 	PreferredRegions []string
@@ -134,10 +140,14 @@ type Config struct {
 	// Rate limiting (from user info)
 	MaxThreads        int
 	MaxRequestsPerMin int
+	RequestsLeftToday int // requests left in the daily quota, or 0 for no limit
 
 	// Filter for which entries to scrape
 	Filter       *Filter
 	FilterConfig *FilterConfig
+
+	// Journal to record finished entries in, if any
+	Journal *Journal
 }
 
 // DefaultMediaTypes returns the default media types to download
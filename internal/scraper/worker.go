@@ -1,6 +1,7 @@
 package scraper
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -14,6 +15,10 @@ import (
 	"github.com/sargunv/rom-tools/lib/screenscraper"
 )
 
+// notFoundMarker is cached in place of the game info of games that
+// Screenscraper doesn't have
+const notFoundMarker = "NOTFOUND"
+
 // Worker handles scraping tasks
 type Worker struct {
 	id          int
@@ -46,6 +51,9 @@ func (w *Worker) Process(ctx context.Context, entry *LookupEntry) *ScrapeResult
 	}
 
 	mediaTotal := len(w.config.MediaTypes)
+	if w.config.Miximage != nil {
+		mediaTotal++
+	}
 
 	// Notify progress
 	w.sendUpdate(ProgressUpdate{
@@ -71,6 +79,9 @@ func (w *Worker) Process(ctx context.Context, entry *LookupEntry) *ScrapeResult
 
 	if notFound {
 		result.Error = nil
+		if cached {
+			result.CacheHits = 1
+		}
 		w.sendUpdate(ProgressUpdate{
 			Type:       UpdateTypeNotFound,
 			EntryName:  entry.Name,
@@ -108,7 +119,7 @@ func (w *Worker) Process(ctx context.Context, entry *LookupEntry) *ScrapeResult
 			CurrentMedia: esdeType,
 		})
 
-		ssTypes, ok := MediaTypeMapping[esdeType]
+		ssTypes, ok := w.config.Media.sources(esdeType)
 		if !ok {
 			mediaMissing++
 			continue
@@ -145,6 +156,32 @@ func (w *Worker) Process(ctx context.Context, entry *LookupEntry) *ScrapeResult
 		}
 	}
 
+	// Compose a miximage of the media downloaded above
+	if w.config.Miximage != nil {
+		w.sendUpdate(ProgressUpdate{
+			Type:         UpdateTypeProgress,
+			EntryName:    entry.Name,
+			WorkerID:     w.id,
+			MediaTotal:   mediaTotal,
+			MediaDone:    mediaDone,
+			CacheHits:    cacheHits,
+			MediaFailed:  mediaFailed,
+			MediaMissing: mediaMissing,
+			CurrentMedia: MiximageType,
+		})
+
+		path, err := w.composeMiximage(entry, result.Media)
+		switch {
+		case err != nil:
+			mediaFailed++
+		case path == "":
+			mediaMissing++
+		default:
+			result.Media[MiximageType] = path
+			mediaDone++
+		}
+	}
+
 	w.sendUpdate(ProgressUpdate{
 		Type:         UpdateTypeFound,
 		EntryName:    entry.Name,
@@ -164,10 +201,15 @@ func (w *Worker) Process(ctx context.Context, entry *LookupEntry) *ScrapeResult
 // Returns (game, cached, notFound, error)
 func (w *Worker) lookupGame(ctx context.Context, entry *LookupEntry) (*screenscraper.Game, bool, bool, error) {
 	cacheKey := entry.Hashes.CacheKey()
+	systemID := w.systemID(entry)
 
 	// Check cache first
 	if !w.config.SkipCacheRead {
-		if data, ok := w.cache.GetGameInfo(w.config.SystemID, cacheKey); ok {
+		if data, ok := w.cache.GetGameInfo(systemID, cacheKey); ok {
+			// A cached "not found", so unknown games aren't looked up again
+			if string(data) == notFoundMarker {
+				return nil, true, true, nil
+			}
 			var game screenscraper.Game
 			if err := json.Unmarshal(data, &game); err == nil {
 				return &game, true, false, nil
@@ -176,7 +218,7 @@ func (w *Worker) lookupGame(ctx context.Context, entry *LookupEntry) (*screenscr
 	}
 
 	// Use deduplicator to coalesce identical requests
-	dedupKey := fmt.Sprintf("game:%s:%s", w.config.SystemID, cacheKey)
+	dedupKey := fmt.Sprintf("game:%s:%s", systemID, cacheKey)
 
 	type lookupResult struct {
 		game     *screenscraper.Game
@@ -196,32 +238,31 @@ func (w *Worker) lookupGame(ctx context.Context, entry *LookupEntry) (*screenscr
 	}
 
 	if result.notFound {
+		if !w.config.SkipCacheWrite {
+			w.cache.SetGameInfo(systemID, cacheKey, []byte(notFoundMarker))
+		}
 		return nil, false, true, nil
 	}
 
 	// Cache the result
 	if !w.config.SkipCacheWrite {
 		if data, err := json.Marshal(result.game); err == nil {
-			w.cache.SetGameInfo(w.config.SystemID, cacheKey, data)
+			w.cache.SetGameInfo(systemID, cacheKey, data)
 		}
 	}
 
 	return result.game, false, false, nil
 }
 
-// fetchGameFromAPI fetches game info from Screenscraper API
+// fetchGameFromAPI fetches game info from Screenscraper API, by the entry's
+// hashes, or by its file name within the system if no ROM has them (e.g.,
+// for hacks and unverified dumps)
 // Returns (game, notFound, error)
 func (w *Worker) fetchGameFromAPI(ctx context.Context, entry *LookupEntry) (*screenscraper.Game, bool, error) {
-	// Acquire rate limiter
-	if err := w.rateLimiter.Acquire(ctx); err != nil {
-		return nil, false, err
-	}
-	defer w.rateLimiter.Release()
-
 	// Prepare parameters
 	romSize := strconv.FormatInt(entry.Size, 10)
 	params := &screenscraper.GetGameInfoParams{
-		SystemID: w.config.SystemID,
+		SystemID: w.systemID(entry),
 		Crc:      entry.Hashes.CRC32,
 		Md5:      entry.Hashes.MD5,
 		Sha1:     entry.Hashes.SHA1,
@@ -234,15 +275,27 @@ func (w *Worker) fetchGameFromAPI(ctx context.Context, entry *LookupEntry) (*scr
 		params.SerialNumber = entry.Serial
 	}
 
-	resp, err := w.client.GetGameInfoWithResponse(ctx, params)
-	if err != nil {
-		return nil, false, err
+	game, notFound, err := w.requestGameInfo(ctx, params)
+	if err != nil || !notFound || entry.Hashes.IsEmpty() || entry.FileName == "" {
+		return game, notFound, err
 	}
 
-	// Check for rate limiting
-	if screenscraper.IsRateLimited(resp) {
-		w.rateLimiter.TriggerBackoff()
-		return nil, false, fmt.Errorf("rate limited")
+	// Fall back to the name alone
+	return w.requestGameInfo(ctx, &screenscraper.GetGameInfoParams{
+		SystemID: w.systemID(entry),
+		ROMName:  entry.FileName,
+		ROMType:  "rom",
+	})
+}
+
+// requestGameInfo makes one jeuInfos request
+// Returns (game, notFound, error)
+func (w *Worker) requestGameInfo(ctx context.Context, params *screenscraper.GetGameInfoParams) (*screenscraper.Game, bool, error) {
+	resp, err := doRequest(ctx, w.rateLimiter, func() (*screenscraper.GetGameInfoResponse, error) {
+		return w.client.GetGameInfoWithResponse(ctx, params)
+	})
+	if err != nil {
+		return nil, false, err
 	}
 
 	// Check for not found
@@ -266,13 +319,16 @@ func (w *Worker) fetchGameFromAPI(ctx context.Context, entry *LookupEntry) (*scr
 	return &resp.JSON200.Response.Game, false, nil
 }
 
-// downloadMedia downloads a specific media type for a game
+// downloadMedia downloads a specific media type for a game, trying the
+// regions it's available in from best to worst until one meets the media
+// policy
 // Returns (path, cached, error) where cached indicates if the media was served from cache
 func (w *Worker) downloadMedia(ctx context.Context, entry *LookupEntry, game *screenscraper.Game, esdeType, ssType string) (string, bool, error) {
-	// Find the best media match based on region
 	candidates := make([]region.Media, 0)
+	byURL := make(map[string]screenscraper.Media)
 	for _, m := range game.Media {
 		if m.Type == ssType {
+			byURL[m.Url] = m
 			candidates = append(candidates, region.Media{
 				Type:   m.Type,
 				Region: m.Region,
@@ -282,14 +338,19 @@ func (w *Worker) downloadMedia(ctx context.Context, entry *LookupEntry, game *sc
 		}
 	}
 
-	if len(candidates) == 0 {
-		return "", false, nil // No media available
+	for _, media := range region.RankMedia(candidates, ssType, entry.Regions, w.config.PreferredRegions) {
+		path, cached, err := w.downloadMediaRegion(ctx, entry, game, esdeType, &media, byURL[media.URL])
+		if err != nil || path != "" {
+			return path, cached, err
+		}
 	}
+	return "", false, nil // No media available
+}
 
-	media := region.SelectMedia(candidates, ssType, entry.Regions, w.config.PreferredRegions)
-	if media == nil {
-		return "", false, nil
-	}
+// downloadMediaRegion downloads one region's media of a game, returning no
+// path if it's not available or the media policy rejects it
+func (w *Worker) downloadMediaRegion(ctx context.Context, entry *LookupEntry, game *screenscraper.Game, esdeType string, media *region.Media, info screenscraper.Media) (string, bool, error) {
+	ssType := media.Type
 
 	// Determine extension
 	ext := MediaExtensions[ssType]
@@ -308,22 +369,29 @@ func (w *Worker) downloadMedia(ctx context.Context, entry *LookupEntry, game *sc
 		}
 	}
 
+	// A file being overwritten may already be current
+	existing, _ := os.ReadFile(outputPath)
+
 	// Get game ID
 	if game.Id == "" {
 		return "", false, nil // No game ID
 	}
 
 	// Check cache for media data
+	systemID := w.systemID(entry)
 	mediaRegion := media.Region
 	var data []byte
 	cached := false
 	if !w.config.SkipCacheRead {
-		if cachedData, cachedExt, ok := w.cache.GetMedia(w.config.SystemID, game.Id, ssType, mediaRegion); ok {
+		if cachedData, cachedExt, ok := w.cache.GetMedia(systemID, game.Id, ssType, mediaRegion); ok {
 			// Check if this is a cached "no media available" marker
 			if cachedExt == "nomedia" {
 				return "", false, nil
 			}
 			data = cachedData
+			if cachedExt != ext {
+				existing = nil // the output path changes
+			}
 			ext = cachedExt
 			relativePath = filepath.Join(esdeType, entry.BaseName+"."+ext)
 			outputPath = filepath.Join(w.config.MediaOutputDir, relativePath)
@@ -333,24 +401,43 @@ func (w *Worker) downloadMedia(ctx context.Context, entry *LookupEntry, game *sc
 
 	// Download if not in cache
 	if data == nil {
-		// Use deduplicator for downloads
-		dedupKey := fmt.Sprintf("media:%s:%s:%s:%s", w.config.SystemID, game.Id, ssType, mediaRegion)
-		downloaded, err := DoTyped(w.dedup, dedupKey, func() ([]byte, error) {
-			return w.downloadMediaFromAPI(ctx, game.Id, ssType, mediaRegion, ext)
-		})
+		// A local copy (expired in the cache, or being overwritten) is
+		// revalidated by its hashes rather than downloaded again
+		local := existing
+		if staleData, staleExt, ok := w.cache.GetStaleMedia(systemID, game.Id, ssType, mediaRegion); ok && staleExt == ext {
+			local = staleData
+		}
 
-		if err != nil {
-			return "", false, err
+		if len(local) > 0 && mediaMatches(local, info) {
+			// The game info lists the same hashes, so no request is needed
+			data = local
+			cached = true
+			if !w.config.SkipCacheWrite {
+				w.cache.SetMedia(systemID, game.Id, ssType, mediaRegion, data, ext)
+			}
+		} else {
+			// Use deduplicator for downloads
+			dedupKey := fmt.Sprintf("media:%s:%s:%s:%s", systemID, game.Id, ssType, mediaRegion)
+			downloaded, err := DoTyped(w.dedup, dedupKey, func() ([]byte, error) {
+				return w.downloadMediaFromAPI(ctx, systemID, game.Id, ssType, mediaRegion, ext, local)
+			})
+
+			if err != nil {
+				return "", false, err
+			}
+			data = downloaded
 		}
-		data = downloaded
 	}
 
 	if data == nil || len(data) == 0 {
 		return "", false, nil // No data
 	}
+	if !w.config.Media.accepts(data) {
+		return "", false, nil // Below the minimum resolution
+	}
 
-	// Write to output directory
-	if w.config.MediaOutputDir != "" {
+	// Write to output directory, unless it's already there
+	if w.config.MediaOutputDir != "" && !bytes.Equal(data, existing) {
 		if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
 			return "", false, fmt.Errorf("failed to create media directory: %w", err)
 		}
@@ -363,13 +450,9 @@ func (w *Worker) downloadMedia(ctx context.Context, entry *LookupEntry, game *sc
 }
 
 // downloadMediaFromAPI downloads media from Screenscraper API
-func (w *Worker) downloadMediaFromAPI(ctx context.Context, gameID, mediaType, mediaRegion, ext string) ([]byte, error) {
-	// Acquire rate limiter
-	if err := w.rateLimiter.Acquire(ctx); err != nil {
-		return nil, err
-	}
-	defer w.rateLimiter.Release()
-
+// If local is a copy of the media, its hashes are sent, and it's returned
+// without being downloaded again if Screenscraper says it's current
+func (w *Worker) downloadMediaFromAPI(ctx context.Context, systemID, gameID, mediaType, mediaRegion, ext string, local []byte) ([]byte, error) {
 	// Build media identifier (e.g., "box-2D(us)")
 	mediaID := mediaType
 	if mediaRegion != "" {
@@ -377,27 +460,26 @@ func (w *Worker) downloadMediaFromAPI(ctx context.Context, gameID, mediaType, me
 	}
 
 	params := &screenscraper.DownloadGameMediaParams{
-		SystemID: w.config.SystemID,
+		SystemID: systemID,
 		GameID:   gameID,
 		Media:    mediaID,
 	}
+	if len(local) > 0 {
+		params.Crc, params.Md5, params.Sha1 = screenscraper.MediaHashes(local)
+	}
 
-	resp, err := w.client.DownloadGameMediaWithResponse(ctx, params)
+	resp, err := doRequest(ctx, w.rateLimiter, func() (*screenscraper.DownloadGameMediaResponse, error) {
+		return w.client.DownloadGameMediaWithResponse(ctx, params)
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	// Check for rate limiting
-	if screenscraper.IsRateLimited(resp) {
-		w.rateLimiter.TriggerBackoff()
-		return nil, fmt.Errorf("rate limited")
-	}
-
 	// Check for errors (not found is not an error for media - just means no media)
 	if !screenscraper.IsSuccess(resp) {
 		// Media doesn't exist - cache this so we don't retry
 		if !w.config.SkipCacheWrite {
-			w.cache.SetMedia(w.config.SystemID, gameID, mediaType, mediaRegion, []byte("NOMEDIA"), "nomedia")
+			w.cache.SetMedia(systemID, gameID, mediaType, mediaRegion, []byte("NOMEDIA"), "nomedia")
 		}
 		return nil, nil
 	}
@@ -405,25 +487,56 @@ func (w *Worker) downloadMediaFromAPI(ctx context.Context, gameID, mediaType, me
 	data := resp.Body
 
 	// Check for special responses
-	dataStr := string(data)
-	if dataStr == "NOMEDIA" || dataStr == "CRCOK" || dataStr == "MD5OK" || dataStr == "SHA1OK" {
-		// Media doesn't exist or already up to date - cache this so we don't retry
+	if screenscraper.IsNoMedia(data) {
+		// Media doesn't exist - cache this so we don't retry
 		if !w.config.SkipCacheWrite {
-			w.cache.SetMedia(w.config.SystemID, gameID, mediaType, mediaRegion, []byte("NOMEDIA"), "nomedia")
+			w.cache.SetMedia(systemID, gameID, mediaType, mediaRegion, []byte("NOMEDIA"), "nomedia")
 		}
 		return nil, nil
 	}
 
 	w.rateLimiter.ResetBackoff()
 
+	// The local copy is current, so keep it
+	if screenscraper.IsMediaUnchanged(data) {
+		if len(local) == 0 {
+			return nil, nil
+		}
+		data = local
+	}
+
 	// Cache the media
 	if !w.config.SkipCacheWrite {
-		w.cache.SetMedia(w.config.SystemID, gameID, mediaType, mediaRegion, data, ext)
+		w.cache.SetMedia(systemID, gameID, mediaType, mediaRegion, data, ext)
 	}
 
 	return data, nil
 }
 
+// systemID returns the system an entry is looked up in: its own, or else
+// the configured one
+func (w *Worker) systemID(entry *LookupEntry) string {
+	if entry.SystemID != "" {
+		return entry.SystemID
+	}
+	return w.config.SystemID
+}
+
+// mediaMatches returns true if data has the strongest hash listed for
+// media, or false if none is listed
+func mediaMatches(data []byte, media screenscraper.Media) bool {
+	crc, md5sum, sha1sum := screenscraper.MediaHashes(data)
+	switch {
+	case media.Sha1 != "":
+		return strings.EqualFold(media.Sha1, sha1sum)
+	case media.Md5 != "":
+		return strings.EqualFold(media.Md5, md5sum)
+	case media.Crc != "":
+		return strings.EqualFold(media.Crc, crc)
+	}
+	return false
+}
+
 func (w *Worker) sendUpdate(update ProgressUpdate) {
 	w.updates <- update
 }
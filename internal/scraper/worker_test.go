@@ -0,0 +1,139 @@
+package scraper
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/sargunv/rom-tools/internal/cache"
+	"github.com/sargunv/rom-tools/lib/screenscraper"
+)
+
+func TestDownloadMedia_Revalidate(t *testing.T) {
+	image := []byte("\x89PNG screenshot")
+	sum := sha1.Sum(image)
+	imageSHA1 := hex.EncodeToString(sum[:])
+
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		queries = append(queries, q.Get("sha1"))
+		if q.Get("sha1") == imageSHA1 {
+			w.Write([]byte("SHA1OK"))
+			return
+		}
+		w.Write(image)
+	}))
+	defer server.Close()
+
+	inner, err := screenscraper.NewClientWithResponses(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Every cached entry is expired, so media is always revalidated
+	diskCache, err := cache.New(t.TempDir(), 0, cache.ModeNormal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &Config{SystemID: "1", MediaOutputDir: t.TempDir(), Overwrite: true}
+	w := NewWorker(0, &screenscraper.ScreenscraperClient{ClientWithResponses: inner}, diskCache, config, NewRateLimiter(1, 60), NewDeduplicator(), nil)
+
+	entry := &LookupEntry{BaseName: "Game"}
+	game := &screenscraper.Game{Id: "42", Media: []screenscraper.Media{{Type: "ss", Region: "wor", Url: "https://example.com/ss.png", Format: "png"}}}
+	output := filepath.Join(config.MediaOutputDir, "screenshots", "Game.png")
+
+	download := func() {
+		t.Helper()
+		path, _, err := w.downloadMedia(context.Background(), entry, game, "screenshots", "ss")
+		if err != nil {
+			t.Fatalf("downloadMedia() error = %v", err)
+		}
+		if path != filepath.Join("screenshots", "Game.png") {
+			t.Fatalf("downloadMedia() = %q", path)
+		}
+		if data, err := os.ReadFile(output); err != nil || string(data) != string(image) {
+			t.Fatalf("Output = %q, %v, want the image", data, err)
+		}
+	}
+
+	// Downloaded, then revalidated by its hashes
+	download()
+	download()
+	if len(queries) != 2 || queries[0] != "" || queries[1] != imageSHA1 {
+		t.Errorf("SHA1 sent = %q, want none then the cached image's", queries)
+	}
+
+	// Not requested when the game info lists the same hash
+	game.Media[0].Sha1 = imageSHA1
+	download()
+	if len(queries) != 2 {
+		t.Errorf("Requests = %d, want none for media matching the game info", len(queries)-2)
+	}
+}
+
+func TestDownloadMedia_RegionFallback(t *testing.T) {
+	small := testPNG(t, 160, 144)
+	large := testPNG(t, 640, 480)
+
+	var requested []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		media := r.URL.Query().Get("media")
+		requested = append(requested, media)
+		switch media {
+		case "ss(de)":
+			w.Write(small)
+		case "ss(eu)":
+			w.Write([]byte("NOMEDIA"))
+		default:
+			w.Write(large)
+		}
+	}))
+	defer server.Close()
+
+	inner, err := screenscraper.NewClientWithResponses(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	diskCache, err := cache.New(t.TempDir(), time.Hour, cache.ModeNormal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &Config{
+		SystemID:         "1",
+		MediaOutputDir:   t.TempDir(),
+		PreferredRegions: []string{"us"},
+		Media:            MediaPolicy{MinWidth: 320},
+	}
+	w := NewWorker(0, &screenscraper.ScreenscraperClient{ClientWithResponses: inner}, diskCache, config, NewRateLimiter(1, 60), NewDeduplicator(), nil)
+
+	// A German ROM falls back from Germany to Europe to World before the
+	// preferred US region
+	entry := &LookupEntry{BaseName: "Game", Regions: []string{"de"}}
+	var media []screenscraper.Media
+	for _, r := range []string{"us", "wor", "eu", "de"} {
+		media = append(media, screenscraper.Media{Type: "ss", Region: r, Url: "https://example.com/ss-" + r + ".png", Format: "png"})
+	}
+	game := &screenscraper.Game{Id: "42", Media: media}
+
+	path, _, err := w.downloadMedia(context.Background(), entry, game, "screenshots", "ss")
+	if err != nil {
+		t.Fatalf("downloadMedia() error = %v", err)
+	}
+	if path != filepath.Join("screenshots", "Game.png") {
+		t.Fatalf("downloadMedia() = %q", path)
+	}
+	if want := []string{"ss(de)", "ss(eu)", "ss(wor)"}; !slices.Equal(requested, want) {
+		t.Errorf("Requested = %v, want %v", requested, want)
+	}
+	if data, err := os.ReadFile(filepath.Join(config.MediaOutputDir, path)); err != nil || !bytes.Equal(data, large) {
+		t.Errorf("Output = %d bytes, %v, want the large image", len(data), err)
+	}
+}
@@ -0,0 +1,128 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+
+	"github.com/sargunv/rom-tools/lib/scan"
+)
+
+// ScanLibrary scans dirs into the scan database, calling progress, if it's
+// set, for each file, and then brings the library index up to date with the
+// database and matches it against the DATs. It returns the changes of every
+// dir together. Only one scan runs at a time; ScanLibrary fails with
+// ErrScanning while another is running.
+func (s *Server) ScanLibrary(ctx context.Context, dirs []string, progress func(scan.Event)) (*scan.Changes, error) {
+	if s.Library == nil || s.ScanDB == nil {
+		return nil, errors.New("no library index: start the server with --library")
+	}
+	if !s.scanning.TryLock() {
+		return nil, ErrScanning
+	}
+	defer s.scanning.Unlock()
+
+	all := &scan.Changes{Added: []string{}, Modified: []string{}, Removed: []string{}}
+	for _, dir := range dirs {
+		changes, err := s.ScanDB.ScanContext(ctx, dir, s.Options, progress)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan %s: %w", dir, err)
+		}
+		all.Added = append(all.Added, changes.Added...)
+		all.Modified = append(all.Modified, changes.Modified...)
+		all.Removed = append(all.Removed, changes.Removed...)
+		all.Unchanged += changes.Unchanged
+	}
+
+	entries, err := s.ScanDB.Entries()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Library.ImportScan(entries); err != nil {
+		return nil, fmt.Errorf("failed to update library: %w", err)
+	}
+	if s.Matcher != nil {
+		if err := s.Library.MatchDATs(s.Matcher); err != nil {
+			return nil, fmt.Errorf("failed to match library: %w", err)
+		}
+	}
+	return all, nil
+}
+
+// allowedDir returns the absolute path of dir if it's one of s.Dirs or
+// within one, since scans replace the library's records.
+func (s *Server) allowedDir(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for _, root := range s.Dirs {
+		rootAbs, err := filepath.Abs(root)
+		if err != nil {
+			continue
+		}
+		if rel, err := filepath.Rel(rootAbs, abs); err == nil && filepath.IsLocal(rel) {
+			return abs, nil
+		}
+	}
+	return "", fmt.Errorf("dir %s is not within the library directories", dir)
+}
+
+// handleScan scans the dirs of the dir parameters, which must be within
+// s.Dirs, or else s.Dirs, into the library, streaming its progress as
+// server-sent events: a "progress" event with a scan.Event for each file,
+// and then a "done" event with the scan.Changes, or an "error" event.
+// Closing the connection stops the scan. It's a GET so browsers' EventSource
+// can follow it.
+func (s *Server) handleScan(w http.ResponseWriter, r *http.Request) {
+	dirs := r.URL.Query()["dir"]
+	for i, dir := range dirs {
+		allowed, err := s.allowedDir(dir)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		dirs[i] = allowed
+	}
+	if len(dirs) == 0 {
+		dirs = s.Dirs
+	}
+	if len(dirs) == 0 {
+		writeError(w, http.StatusBadRequest, errors.New("dir required"))
+		return
+	}
+	if s.Library == nil || s.ScanDB == nil {
+		writeError(w, http.StatusNotFound, errors.New("no library index: start the server with --library"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	rc := http.NewResponseController(w)
+	send := func(event string, v any) {
+		data, err := json.Marshal(v)
+		if err != nil {
+			event = "error"
+			data, _ = json.Marshal(errorResponse{Error: fmt.Sprintf("failed to marshal JSON: %v", err)})
+		}
+		fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+		rc.Flush()
+	}
+
+	changes, err := s.ScanLibrary(r.Context(), dirs, func(e scan.Event) {
+		send("progress", e)
+	})
+	if errors.Is(err, ErrScanning) {
+		// Nothing has been written yet, so the status can still be set
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+	if err != nil {
+		send("error", errorResponse{Error: err.Error()})
+		return
+	}
+	send("done", changes)
+}
@@ -0,0 +1,269 @@
+// Package server serves ROM identification and library queries over HTTP as
+// JSON, for frontends and scripts that can't link the Go libraries.
+//
+// Endpoints:
+//   - POST /identify identifies a file on the server, given as a JSON body
+//     {"path": "..."}, or a file uploaded as the "file" field of a
+//     multipart form. It responds with an identify.Result.
+//   - GET /library responds with the records of the library index that a
+//     query selects, given as the parameters platform, region, unmatched,
+//     and missing-media (see library.Query).
+//   - GET /scan scans directories into the library index, given as dir
+//     parameters within Server.Dirs or else Server.Dirs themselves, and
+//     streams its progress as server-sent events.
+//
+// Errors are responded with as {"error": "..."}. Requests for a host not in
+// Server.Hosts are refused, so web pages can't reach a local server by DNS
+// rebinding.
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/sargunv/rom-tools/lib/core"
+	"github.com/sargunv/rom-tools/lib/identify"
+	"github.com/sargunv/rom-tools/lib/library"
+	"github.com/sargunv/rom-tools/lib/scan"
+)
+
+// Server handles the endpoints.
+type Server struct {
+	// Options are the options files are identified with.
+	Options identify.Options
+
+	// Matcher matches identified files against DATs, if set.
+	Matcher *identify.Matcher
+
+	// Library is the index GET /library queries, if set.
+	Library *library.DB
+
+	// ScanDB is the scan database GET /scan scans into, with Library.
+	ScanDB *scan.DB
+
+	// Dirs are the directories GET /scan scans when it's given none. It
+	// only scans directories within them.
+	Dirs []string
+
+	// Hosts are the host names (without ports) requests may be for, if set.
+	// Others are refused with 403.
+	Hosts []string
+
+	// MaxUploadSize is the largest file POST /identify accepts, in bytes
+	// (0 = no limit).
+	MaxUploadSize int64
+
+	scanning sync.Mutex // held while a scan runs
+}
+
+// ErrScanning is returned when a scan is requested while another is running.
+var ErrScanning = errors.New("a scan is already running")
+
+// identifyRequest is the JSON body of POST /identify.
+type identifyRequest struct {
+	Path string `json:"path"`
+}
+
+// errorResponse is the body of error responses.
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// Handler returns the handler of the endpoints.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /identify", s.handleIdentify)
+	mux.HandleFunc("GET /library", s.handleLibrary)
+	mux.HandleFunc("GET /scan", s.handleScan)
+	if len(s.Hosts) == 0 {
+		return mux
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.allowedHost(r.Host) {
+			writeError(w, http.StatusForbidden, fmt.Errorf("host %q not allowed", r.Host))
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// allowedHost returns whether host, a request's Host, is one of s.Hosts.
+func (s *Server) allowedHost(host string) bool {
+	if name, _, err := net.SplitHostPort(host); err == nil {
+		host = name
+	}
+	host = strings.Trim(host, "[]")
+	return slices.ContainsFunc(s.Hosts, func(allowed string) bool {
+		return strings.EqualFold(host, allowed)
+	})
+}
+
+func (s *Server) handleIdentify(w http.ResponseWriter, r *http.Request) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "application/json":
+		var req identifyRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request: %w", err))
+			return
+		}
+		if req.Path == "" {
+			writeError(w, http.StatusBadRequest, errors.New("path required"))
+			return
+		}
+		s.identify(w, r, req.Path, "")
+
+	case "multipart/form-data":
+		s.identifyUpload(w, r)
+
+	default:
+		writeError(w, http.StatusUnsupportedMediaType, errors.New("want a JSON body or a multipart form"))
+	}
+}
+
+// identifyUpload identifies the file uploaded as the "file" field of a
+// multipart form. It's written to a temporary folder under its own name,
+// since identification goes by the extension.
+func (s *Server) identifyUpload(w http.ResponseWriter, r *http.Request) {
+	if s.MaxUploadSize > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, s.MaxUploadSize)
+	}
+	reader, err := r.MultipartReader()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid form: %w", err))
+		return
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			writeError(w, http.StatusBadRequest, errors.New("file required"))
+			return
+		}
+		if err != nil {
+			writeError(w, uploadErrorStatus(err), fmt.Errorf("invalid form: %w", err))
+			return
+		}
+		if part.FormName() != "file" {
+			continue
+		}
+
+		name := filepath.Base(part.FileName())
+		if name == "." || name == string(filepath.Separator) {
+			writeError(w, http.StatusBadRequest, errors.New("file name required"))
+			return
+		}
+		dir, err := os.MkdirTemp("", "rom-tools-upload-")
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		defer os.RemoveAll(dir)
+
+		path := filepath.Join(dir, name)
+		f, err := os.Create(path)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		_, err = io.Copy(f, part)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			writeError(w, uploadErrorStatus(err), fmt.Errorf("failed to receive file: %w", err))
+			return
+		}
+		s.identify(w, r, path, name)
+		return
+	}
+}
+
+// uploadErrorStatus returns the status for an error reading an upload.
+func uploadErrorStatus(err error) int {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
+
+// identify identifies the file at path and responds with the result. The
+// result's path is replaced by name, if set, for uploads.
+func (s *Server) identify(w http.ResponseWriter, r *http.Request, path, name string) {
+	label := path
+	if name != "" {
+		label = name
+	}
+	result, err := identify.IdentifyContext(r.Context(), path, s.Options)
+	if err != nil {
+		status := http.StatusUnprocessableEntity
+		if errors.Is(err, os.ErrNotExist) {
+			status = http.StatusNotFound
+		}
+		writeError(w, status, fmt.Errorf("failed to identify %s: %w", label, err))
+		return
+	}
+	if s.Matcher != nil {
+		s.Matcher.Annotate(result)
+	}
+	if name != "" {
+		result.Path = name
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) handleLibrary(w http.ResponseWriter, r *http.Request) {
+	if s.Library == nil {
+		writeError(w, http.StatusNotFound, errors.New("no library index: start the server with --library"))
+		return
+	}
+
+	params := r.URL.Query()
+	q := library.Query{
+		Platform:     core.Platform(params.Get("platform")),
+		Region:       core.Region(params.Get("region")),
+		MissingMedia: params.Get("missing-media"),
+	}
+	if v := params.Get("unmatched"); v != "" {
+		unmatched, err := strconv.ParseBool(v)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid unmatched: %q", v))
+			return
+		}
+		q.Unmatched = unmatched
+	}
+
+	records, err := s.Library.Query(q)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, records)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		status = http.StatusInternalServerError
+		data, _ = json.Marshal(errorResponse{Error: fmt.Sprintf("failed to marshal JSON: %v", err)})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(append(data, '\n'))
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
@@ -0,0 +1,217 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sargunv/rom-tools/lib/core"
+	"github.com/sargunv/rom-tools/lib/identify"
+	"github.com/sargunv/rom-tools/lib/library"
+	"github.com/sargunv/rom-tools/lib/scan"
+)
+
+const testROM = "../../lib/identify/testdata/gbtictac.gb"
+
+// testResult is the part of an identify.Result the tests check. Games are
+// platform-specific, so identify.Result can't be unmarshaled itself.
+type testResult struct {
+	Path  string `json:"path"`
+	Items []struct {
+		Hashes core.Hashes `json:"hashes"`
+		Game   struct {
+			Title string `json:"title"`
+		} `json:"game"`
+	} `json:"items"`
+}
+
+func do(t *testing.T, s *Server, req *http.Request, wantStatus int, v any) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, req)
+	if rec.Code != wantStatus {
+		t.Fatalf("%s %s status = %d, want %d: %s", req.Method, req.URL, rec.Code, wantStatus, rec.Body)
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+		t.Fatalf("invalid JSON response %q: %v", rec.Body, err)
+	}
+}
+
+func TestIdentify_Path(t *testing.T) {
+	s := &Server{Options: identify.DefaultOptions()}
+	req := httptest.NewRequest("POST", "/identify", strings.NewReader(`{"path": "`+testROM+`"}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	var result testResult
+	do(t, s, req, http.StatusOK, &result)
+	if len(result.Items) != 1 || result.Items[0].Hashes[core.HashSHA1] != "48a59d5b31e374731ece4d9eb33679d38143495e" {
+		t.Errorf("result = %+v, want the test ROM's SHA1", result)
+	}
+	if !filepath.IsAbs(result.Path) {
+		t.Errorf("result path = %q, want an absolute path", result.Path)
+	}
+
+	req = httptest.NewRequest("POST", "/identify", strings.NewReader(`{"path": "missing.gb"}`))
+	req.Header.Set("Content-Type", "application/json")
+	var errResp errorResponse
+	do(t, s, req, http.StatusNotFound, &errResp)
+	if errResp.Error == "" {
+		t.Errorf("error response has no error")
+	}
+}
+
+func TestIdentify_Upload(t *testing.T) {
+	data, err := os.ReadFile(testROM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", "Tic-Tac-Toe.gb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(data)
+	form.Close()
+
+	newRequest := func() *http.Request {
+		req := httptest.NewRequest("POST", "/identify", bytes.NewReader(body.Bytes()))
+		req.Header.Set("Content-Type", form.FormDataContentType())
+		return req
+	}
+
+	s := &Server{Options: identify.DefaultOptions()}
+	var result testResult
+	do(t, s, newRequest(), http.StatusOK, &result)
+	if result.Path != "Tic-Tac-Toe.gb" {
+		t.Errorf("result path = %q, want the uploaded name", result.Path)
+	}
+	if len(result.Items) != 1 || result.Items[0].Game.Title != "TIC-TAC-TOE" {
+		t.Errorf("result = %+v, want the test ROM's title", result)
+	}
+
+	s.MaxUploadSize = 1024
+	var errResp errorResponse
+	do(t, s, newRequest(), http.StatusRequestEntityTooLarge, &errResp)
+}
+
+func TestLibrary(t *testing.T) {
+	s := &Server{}
+	var errResp errorResponse
+	do(t, s, httptest.NewRequest("GET", "/library", nil), http.StatusNotFound, &errResp)
+
+	db, err := library.Open(filepath.Join(t.TempDir(), "library.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	entries := []scan.Entry{
+		{Path: "/roms/a.gb", Size: 1, ModTime: time.Unix(0, 0), Items: []scan.Item{{Name: "a.gb", Size: 1, Platform: core.PlatformGB, Primary: true}}},
+		{Path: "/roms/b.sfc", Size: 1, ModTime: time.Unix(0, 0), Items: []scan.Item{{Name: "b.sfc", Size: 1, Platform: core.PlatformSNES, Primary: true}}},
+	}
+	if err := db.ImportScan(entries); err != nil {
+		t.Fatal(err)
+	}
+	s.Library = db
+
+	var records []library.Record
+	do(t, s, httptest.NewRequest("GET", "/library?platform=gameboy", nil), http.StatusOK, &records)
+	if len(records) != 1 || records[0].Path != "/roms/a.gb" {
+		t.Errorf("records = %+v, want /roms/a.gb", records)
+	}
+	do(t, s, httptest.NewRequest("GET", "/library?unmatched=maybe", nil), http.StatusBadRequest, &errResp)
+}
+
+func TestScan(t *testing.T) {
+	root := t.TempDir()
+	data, err := os.ReadFile(testROM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "Tic-Tac-Toe.gb"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	db, err := library.Open(filepath.Join(dir, "library.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	scanDB, err := scan.Open(filepath.Join(dir, "scan.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer scanDB.Close()
+	s := &Server{Options: identify.DefaultOptions(), Library: db, ScanDB: scanDB, Dirs: []string{root}}
+
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/scan", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("GET /scan status = %d, content type %q: %s", rec.Code, rec.Header().Get("Content-Type"), rec.Body)
+	}
+
+	// Each event is "event: <name>\ndata: <json>\n\n"
+	var names []string
+	var progress scan.Event
+	for _, block := range strings.Split(strings.TrimSpace(rec.Body.String()), "\n\n") {
+		name, data, _ := strings.Cut(block, "\n")
+		names = append(names, strings.TrimPrefix(name, "event: "))
+		if name == "event: progress" {
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(data, "data: ")), &progress); err != nil {
+				t.Fatalf("invalid progress event %q: %v", data, err)
+			}
+		}
+	}
+	if strings.Join(names, ",") != "progress,done" {
+		t.Fatalf("events = %v, want progress and done", names)
+	}
+	if progress.Status != scan.StatusAdded || progress.Done != 1 || progress.Total != 1 || progress.Entry == nil {
+		t.Errorf("progress event = %+v, want 1 of 1 added", progress)
+	}
+
+	records, err := db.Query(library.Query{Platform: core.PlatformGB})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 {
+		t.Errorf("library records = %+v, want the scanned ROM", records)
+	}
+
+	// Only dirs within s.Dirs can be scanned
+	if err := os.Mkdir(filepath.Join(root, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	rec = httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/scan?dir="+filepath.Join(root, "sub"), nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("GET /scan of a subdirectory status = %d: %s", rec.Code, rec.Body)
+	}
+	var errResp errorResponse
+	for _, dir := range []string{t.TempDir(), filepath.Join(root, ".."), root + "-other"} {
+		do(t, s, httptest.NewRequest("GET", "/scan?dir="+dir, nil), http.StatusBadRequest, &errResp)
+	}
+}
+
+func TestHosts(t *testing.T) {
+	s := &Server{Hosts: []string{"localhost", "::1"}}
+	for _, host := range []string{"localhost", "LOCALHOST:8080", "[::1]:8080"} {
+		req := httptest.NewRequest("GET", "/library", nil)
+		req.Host = host
+		var errResp errorResponse
+		do(t, s, req, http.StatusNotFound, &errResp) // allowed, but no library
+	}
+	for _, host := range []string{"example.com", "example.com:8080", "127.0.0.1:8080"} {
+		req := httptest.NewRequest("GET", "/library", nil)
+		req.Host = host
+		var errResp errorResponse
+		do(t, s, req, http.StatusForbidden, &errResp)
+	}
+}
@@ -1,11 +1,22 @@
 package util
 
 import (
+	"errors"
 	"io"
 
 	"github.com/sargunv/rom-tools/lib/core"
 )
 
+// Encrypted archive errors, returned by containers that support encryption.
+var (
+	// ErrPasswordRequired is returned when reading an encrypted entry of an
+	// archive opened without a password.
+	ErrPasswordRequired = errors.New("entry is encrypted, and no password was given")
+	// ErrWrongPassword is returned when an encrypted entry can't be
+	// decrypted with the archive's password.
+	ErrWrongPassword = errors.New("wrong password for encrypted entry")
+)
+
 // FileEntry represents a file within a container.
 type FileEntry struct {
 	Name   string      // Relative path within container
@@ -0,0 +1,106 @@
+package util
+
+import (
+	"context"
+	"io"
+	"sync"
+)
+
+// StreamReader provides random access to a stream that can only be read
+// sequentially, like a compressed archive entry. It reads the stream lazily,
+// only as far as needed to satisfy ReadAt requests, into a buffer so
+// subsequent reads don't re-read it.
+type StreamReader struct {
+	open   func() (io.ReadCloser, error)
+	size   int64
+	mu     sync.Mutex
+	buffer []byte
+	reader io.ReadCloser
+	err    error           // sticky error from reading
+	ctx    context.Context // stops reading once done, if set
+}
+
+// NewStreamReader returns a StreamReader of the size bytes read from the
+// stream returned by open, which is opened on the first read. Reading stops
+// once ctx is done, if it's set.
+func NewStreamReader(ctx context.Context, size int64, open func() (io.ReadCloser, error)) *StreamReader {
+	return &StreamReader{open: open, size: size, ctx: ctx}
+}
+
+// Size returns the size of the stream.
+func (r *StreamReader) Size() int64 {
+	return r.size
+}
+
+// ReadAt implements io.ReaderAt by reading the stream on-demand.
+func (r *StreamReader) ReadAt(p []byte, off int64) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.err != nil {
+		return 0, r.err
+	}
+	if off >= r.size {
+		return 0, io.EOF
+	}
+
+	needed := min(off+int64(len(p)), r.size)
+	if int64(len(r.buffer)) < needed {
+		if err := r.readTo(needed); err != nil {
+			r.err = err
+			return 0, err
+		}
+	}
+
+	available := int64(len(r.buffer)) - off
+	if available <= 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, r.buffer[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// readTo ensures at least 'needed' bytes are read into the buffer.
+func (r *StreamReader) readTo(needed int64) error {
+	if r.reader == nil {
+		rd, err := r.open()
+		if err != nil {
+			return err
+		}
+		r.reader = rd
+	}
+
+	buf := make([]byte, 64*1024)
+	for int64(len(r.buffer)) < needed {
+		if r.ctx != nil {
+			if err := r.ctx.Err(); err != nil {
+				return err
+			}
+		}
+		n, err := r.reader.Read(buf)
+		r.buffer = append(r.buffer, buf[:n]...)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close releases resources associated with the reader.
+func (r *StreamReader) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.reader != nil {
+		err := r.reader.Close()
+		r.reader = nil
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,249 @@
+// Package ccd provides support for CloneCD disc images.
+//
+// A CloneCD image is a set of three files sharing a base name:
+//   - .ccd: the control file, an INI-style description of the disc's TOC
+//   - .img: the main channel data, 2352-byte raw sectors starting at LBA 0
+//   - .sub: the subchannel data, 96 bytes (P-W) per sector, in the same order
+//
+// Use Parse to read the control file, then Sheet.OpenTrack to access a track
+// within the companion .img. Identification only needs the main channel; the
+// subchannel offset of a sector can be found with SubchannelOffset.
+//
+// Control file example (abbreviated):
+//
+//	[CloneCD]
+//	Version=3
+//	[Disc]
+//	TocEntries=4
+//	Sessions=1
+//	DataTracksScrambled=0
+//	[Entry 3]
+//	Session=1
+//	Point=0x01
+//	Control=0x04
+//	PLBA=0
+//	[TRACK 1]
+//	MODE=2
+//	INDEX 1=0
+//
+// [Entry N] sections are raw TOC entries. Points 0x01-0x63 describe tracks
+// (bit 2 of Control marks a data track); point 0xA2 is the lead-out.
+// [TRACK N] sections give each track's mode and index positions.
+package ccd
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	// SectorSize is the size of a raw sector in the .img file.
+	SectorSize = 2352
+
+	// SubchannelSize is the size of a sector's subchannel data in the .sub file.
+	SubchannelSize = 96
+
+	controlDataTrack = 0x04
+	pointFirstTrack  = 0x01
+	pointLastTrack   = 0x63
+	pointLeadOut     = 0xA2
+)
+
+// Track describes one track of a CloneCD image.
+type Track struct {
+	// Number is the track number (1-99).
+	Number int `json:"number"`
+	// Session is the session containing the track.
+	Session int `json:"session"`
+	// Mode is the track mode (0 = audio, 1 = MODE1, 2 = MODE2).
+	Mode int `json:"mode"`
+	// Data is true for data tracks, from the TOC control field.
+	Data bool `json:"data"`
+	// Index0 is the LBA of the track's pregap, or -1 if it has none.
+	Index0 int64 `json:"index0"`
+	// Index1 is the LBA where the track's data starts.
+	Index1 int64 `json:"index1"`
+}
+
+// Sheet contains the parsed contents of a .ccd control file.
+type Sheet struct {
+	// Version is the CloneCD control file version.
+	Version int `json:"version"`
+	// Sessions is the number of sessions on the disc.
+	Sessions int `json:"sessions"`
+	// DataTracksScrambled is true if data sectors are stored scrambled.
+	DataTracksScrambled bool `json:"data_tracks_scrambled"`
+	// LeadOut is the LBA of the lead-out, or -1 if the TOC doesn't have one.
+	LeadOut int64 `json:"lead_out"`
+	// Tracks lists the tracks in order.
+	Tracks []Track `json:"tracks"`
+}
+
+// Parse parses a CloneCD .ccd control file.
+func Parse(r io.ReaderAt, size int64) (*Sheet, error) {
+	data := make([]byte, size)
+	if _, err := r.ReadAt(data, 0); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to read CCD file: %w", err)
+	}
+
+	sections := parseINI(data)
+	if _, ok := sections["CloneCD"]; !ok {
+		return nil, fmt.Errorf("not a valid CCD file: missing [CloneCD] section")
+	}
+
+	sheet := &Sheet{
+		Version:             atoi(sections["CloneCD"]["Version"]),
+		Sessions:            atoi(sections["Disc"]["Sessions"]),
+		DataTracksScrambled: atoi(sections["Disc"]["DataTracksScrambled"]) != 0,
+		LeadOut:             -1,
+	}
+
+	tracks := make(map[int]*Track)
+	track := func(number int) *Track {
+		if t, ok := tracks[number]; ok {
+			return t
+		}
+		t := &Track{Number: number, Index0: -1, Index1: -1}
+		tracks[number] = t
+		return t
+	}
+
+	for name, keys := range sections {
+		switch {
+		case strings.HasPrefix(name, "Entry "):
+			point := atoi(keys["Point"])
+			plba, err := strconv.ParseInt(keys["PLBA"], 10, 64)
+			if err != nil {
+				continue
+			}
+			switch {
+			case point == pointLeadOut:
+				// Multi-session discs have a lead-out per session; keep the last
+				sheet.LeadOut = max(sheet.LeadOut, plba)
+			case point >= pointFirstTrack && point <= pointLastTrack:
+				t := track(point)
+				t.Session = atoi(keys["Session"])
+				t.Data = atoi(keys["Control"])&controlDataTrack != 0
+				if t.Index1 < 0 {
+					t.Index1 = plba
+				}
+			}
+		case strings.HasPrefix(name, "TRACK "):
+			number, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(name, "TRACK ")))
+			if err != nil {
+				continue
+			}
+			t := track(number)
+			t.Mode = atoi(keys["MODE"])
+			if v, err := strconv.ParseInt(keys["INDEX 0"], 10, 64); err == nil {
+				t.Index0 = v
+			}
+			if v, err := strconv.ParseInt(keys["INDEX 1"], 10, 64); err == nil {
+				t.Index1 = v
+			}
+		}
+	}
+
+	for _, t := range tracks {
+		if t.Index1 < 0 {
+			return nil, fmt.Errorf("not a valid CCD file: track %d has no start position", t.Number)
+		}
+		sheet.Tracks = append(sheet.Tracks, *t)
+	}
+	if len(sheet.Tracks) == 0 {
+		return nil, fmt.Errorf("not a valid CCD file: no tracks")
+	}
+	slices.SortFunc(sheet.Tracks, func(a, b Track) int { return a.Number - b.Number })
+
+	return sheet, nil
+}
+
+// FirstDataTrack returns the first data track, or nil if the disc only has
+// audio tracks.
+func (s *Sheet) FirstDataTrack() *Track {
+	for i := range s.Tracks {
+		if s.Tracks[i].Data {
+			return &s.Tracks[i]
+		}
+	}
+	return nil
+}
+
+// OpenTrack returns a reader for a track's raw sectors within the .img file,
+// from INDEX 1 up to the start of the next track (or the end of the image).
+func (s *Sheet) OpenTrack(img io.ReaderAt, imgSize int64, number int) (*io.SectionReader, error) {
+	i := slices.IndexFunc(s.Tracks, func(t Track) bool { return t.Number == number })
+	if i < 0 {
+		return nil, fmt.Errorf("track %d not found", number)
+	}
+	t := s.Tracks[i]
+	if t.Data && s.DataTracksScrambled {
+		return nil, fmt.Errorf("track %d: scrambled data tracks not supported", number)
+	}
+
+	start := t.Index1 * SectorSize
+	end := imgSize
+	if i+1 < len(s.Tracks) {
+		next := s.Tracks[i+1]
+		if next.Index0 >= 0 {
+			end = next.Index0 * SectorSize
+		} else {
+			end = next.Index1 * SectorSize
+		}
+	}
+	end = min(end, imgSize)
+	if start >= end {
+		return nil, fmt.Errorf("track %d extends beyond image: starts at %d, image size %d", number, start, imgSize)
+	}
+
+	return io.NewSectionReader(img, start, end-start), nil
+}
+
+// SubchannelOffset returns the offset of a sector's subchannel data within
+// the .sub file.
+func SubchannelOffset(lba int64) int64 {
+	return lba * SubchannelSize
+}
+
+// parseINI parses INI-style data into sections of key-value pairs.
+// Keys before the first section header are ignored.
+func parseINI(data []byte) map[string]map[string]string {
+	sections := make(map[string]map[string]string)
+	var current map[string]string
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			current = make(map[string]string)
+			sections[strings.TrimSpace(line[1:len(line)-1])] = current
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok || current == nil {
+			continue
+		}
+		current[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+
+	return sections
+}
+
+// atoi parses a decimal or 0x-prefixed hex integer, returning 0 if invalid.
+func atoi(s string) int {
+	v, err := strconv.ParseInt(s, 0, 64)
+	if err != nil {
+		return 0
+	}
+	return int(v)
+}
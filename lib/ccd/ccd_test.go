@@ -0,0 +1,187 @@
+package ccd
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+// testCCD describes a disc with a MODE1 data track followed by an audio
+// track with a 2-second pregap.
+const testCCD = `[CloneCD]
+Version=3
+[Disc]
+TocEntries=5
+Sessions=1
+DataTracksScrambled=0
+CDTextLength=0
+[Session 1]
+PreGapMode=1
+PreGapSubC=0
+[Entry 0]
+Session=1
+Point=0xa0
+ADR=0x01
+Control=0x04
+PLBA=4350
+[Entry 1]
+Session=1
+Point=0xa1
+ADR=0x01
+Control=0x00
+PLBA=6750
+[Entry 2]
+Session=1
+Point=0xa2
+ADR=0x01
+Control=0x00
+PLBA=400
+[Entry 3]
+Session=1
+Point=0x01
+ADR=0x01
+Control=0x04
+PLBA=0
+[Entry 4]
+Session=1
+Point=0x02
+ADR=0x01
+Control=0x00
+PLBA=300
+[TRACK 1]
+MODE=1
+INDEX 1=0
+[TRACK 2]
+MODE=0
+INDEX 0=150
+INDEX 1=300
+`
+
+func parseTestCCD(t *testing.T, data string) *Sheet {
+	t.Helper()
+	sheet, err := Parse(strings.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	return sheet
+}
+
+func TestParse(t *testing.T) {
+	sheet := parseTestCCD(t, testCCD)
+
+	if sheet.Version != 3 {
+		t.Errorf("Version = %d, want 3", sheet.Version)
+	}
+	if sheet.Sessions != 1 {
+		t.Errorf("Sessions = %d, want 1", sheet.Sessions)
+	}
+	if sheet.DataTracksScrambled {
+		t.Error("DataTracksScrambled = true, want false")
+	}
+	if sheet.LeadOut != 400 {
+		t.Errorf("LeadOut = %d, want 400", sheet.LeadOut)
+	}
+
+	want := []Track{
+		{Number: 1, Session: 1, Mode: 1, Data: true, Index0: -1, Index1: 0},
+		{Number: 2, Session: 1, Mode: 0, Data: false, Index0: 150, Index1: 300},
+	}
+	if len(sheet.Tracks) != len(want) {
+		t.Fatalf("len(Tracks) = %d, want %d", len(sheet.Tracks), len(want))
+	}
+	for i, tr := range sheet.Tracks {
+		if tr != want[i] {
+			t.Errorf("Tracks[%d] = %+v, want %+v", i, tr, want[i])
+		}
+	}
+
+	if dt := sheet.FirstDataTrack(); dt == nil || dt.Number != 1 {
+		t.Errorf("FirstDataTrack() = %+v, want track 1", dt)
+	}
+}
+
+func TestParse_EntryOnly(t *testing.T) {
+	// Track positions fall back to the TOC entries if [TRACK] sections omit them
+	data := "[CloneCD]\nVersion=3\n[Entry 0]\nSession=1\nPoint=0x01\nControl=0x04\nPLBA=0\n"
+	sheet := parseTestCCD(t, data)
+
+	if len(sheet.Tracks) != 1 || sheet.Tracks[0].Index1 != 0 || !sheet.Tracks[0].Data {
+		t.Errorf("Tracks = %+v, want one data track at LBA 0", sheet.Tracks)
+	}
+	if sheet.LeadOut != -1 {
+		t.Errorf("LeadOut = %d, want -1", sheet.LeadOut)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"empty", ""},
+		{"missing CloneCD section", "[Disc]\nSessions=1\n[TRACK 1]\nMODE=1\nINDEX 1=0\n"},
+		{"no tracks", "[CloneCD]\nVersion=3\n"},
+		{"track without start", "[CloneCD]\nVersion=3\n[TRACK 1]\nMODE=1\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(strings.NewReader(tt.data), int64(len(tt.data))); err == nil {
+				t.Error("Parse() expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestOpenTrack(t *testing.T) {
+	sheet := parseTestCCD(t, testCCD)
+
+	img := make([]byte, 400*SectorSize)
+	img[0] = 1
+	img[300*SectorSize] = 2
+
+	// The data track ends at the audio track's pregap
+	track1, err := sheet.OpenTrack(bytes.NewReader(img), int64(len(img)), 1)
+	if err != nil {
+		t.Fatalf("OpenTrack(1) error = %v", err)
+	}
+	if track1.Size() != 150*SectorSize {
+		t.Errorf("track 1 size = %d, want %d", track1.Size(), 150*SectorSize)
+	}
+
+	// The audio track starts at INDEX 1 and runs to the end of the image
+	track2, err := sheet.OpenTrack(bytes.NewReader(img), int64(len(img)), 2)
+	if err != nil {
+		t.Fatalf("OpenTrack(2) error = %v", err)
+	}
+	if track2.Size() != 100*SectorSize {
+		t.Errorf("track 2 size = %d, want %d", track2.Size(), 100*SectorSize)
+	}
+	first := make([]byte, 1)
+	if _, err := track2.ReadAt(first, 0); err != nil && err != io.EOF {
+		t.Fatalf("ReadAt() error = %v", err)
+	}
+	if first[0] != 2 {
+		t.Errorf("track 2 first byte = %d, want 2", first[0])
+	}
+
+	if _, err := sheet.OpenTrack(bytes.NewReader(img), int64(len(img)), 3); err == nil {
+		t.Error("OpenTrack(3) expected error, got nil")
+	}
+}
+
+func TestOpenTrack_Scrambled(t *testing.T) {
+	sheet := parseTestCCD(t, strings.Replace(testCCD, "DataTracksScrambled=0", "DataTracksScrambled=1", 1))
+
+	img := make([]byte, 400*SectorSize)
+	if _, err := sheet.OpenTrack(bytes.NewReader(img), int64(len(img)), 1); err == nil {
+		t.Error("OpenTrack() expected error for scrambled data track, got nil")
+	}
+}
+
+func TestSubchannelOffset(t *testing.T) {
+	if got := SubchannelOffset(150); got != 150*SubchannelSize {
+		t.Errorf("SubchannelOffset(150) = %d, want %d", got, 150*SubchannelSize)
+	}
+}
@@ -0,0 +1,75 @@
+package ccd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/sargunv/rom-tools/lib/core"
+)
+
+// Disc is a CloneCD control file together with its .img file, as a
+// core.DiscImage.
+type Disc struct {
+	sheet   *Sheet
+	img     io.ReaderAt
+	imgSize int64
+}
+
+// NewDisc returns the disc described by sheet, whose main channel data is
+// img.
+func NewDisc(sheet *Sheet, img io.ReaderAt, imgSize int64) *Disc {
+	return &Disc{sheet: sheet, img: img, imgSize: imgSize}
+}
+
+// Size returns the size of the .img file.
+func (d *Disc) Size() int64 {
+	return d.imgSize
+}
+
+// Tracks lists the tracks in order.
+func (d *Disc) Tracks() []core.DiscTrack {
+	tracks := make([]core.DiscTrack, len(d.sheet.Tracks))
+	for i, t := range d.sheet.Tracks {
+		tracks[i] = core.DiscTrack{Number: t.Number, Data: t.Data, SectorSize: SectorSize}
+	}
+	return tracks
+}
+
+// OpenTrack returns a reader for a track's raw sectors, as Sheet.OpenTrack
+// does.
+func (d *Disc) OpenTrack(number int) (io.ReaderAt, int64, error) {
+	r, err := d.sheet.OpenTrack(d.img, d.imgSize, number)
+	if err != nil {
+		return nil, 0, err
+	}
+	return r, r.Size(), nil
+}
+
+// OpenUserData returns a reader for the user data of the first data track.
+func (d *Disc) OpenUserData() (io.ReaderAt, int64, error) {
+	track := d.sheet.FirstDataTrack()
+	if track == nil {
+		return nil, 0, core.ErrNoDataTrack
+	}
+
+	// Skip the sync pattern and header, and the subheader of MODE2 sectors
+	var offset int
+	switch track.Mode {
+	case 1:
+		offset = 16
+	case 2:
+		offset = 24
+	default:
+		return nil, 0, fmt.Errorf("track %d: unsupported mode %d", track.Number, track.Mode)
+	}
+
+	r, size, err := d.OpenTrack(track.Number)
+	if err != nil {
+		return nil, 0, err
+	}
+	userData, err := core.NewUserDataReader(r, size, SectorSize, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	return userData, userData.Size(), nil
+}
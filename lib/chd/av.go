@@ -0,0 +1,114 @@
+package chd
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/sargunv/rom-tools/lib/core"
+)
+
+// A/V CHDs, made by chdman createld or createav, store one video frame (or
+// field, for interlaced video) and its audio per hunk, compressed with the
+// AVHuff codec. The AVAV metadata entry describes the video and audio:
+//
+//	FPS:29.970030 WIDTH:720 HEIGHT:240 INTERLACED:1 CHANNELS:2 SAMPLERATE:48000
+//
+// LaserDisc captures, used by MAME for games like Dragon's Lair, also have an
+// AVLD entry holding the VBI data of each hunk, packed into vbiPackedBytes
+// bytes, which MAME reads for frame numbers and chapter stops.
+//
+// Format specification: https://github.com/mamedev/mame/blob/master/src/lib/util/avhuff.h
+
+// vbiPackedBytes is the size of a hunk's packed VBI data in AVLD metadata.
+const vbiPackedBytes = 16
+
+// AVInfo describes an A/V CHD, from its AVAV metadata.
+type AVInfo struct {
+	// FPS is the rate of hunks: frames, or fields if Interlaced.
+	FPS float64 `json:"fps"`
+	// Width and Height are the size of a hunk's picture, in pixels.
+	Width  int `json:"width"`
+	Height int `json:"height"`
+	// Interlaced is true if each hunk holds one field of interlaced video.
+	Interlaced bool `json:"interlaced"`
+	// Channels and SampleRate describe the audio.
+	Channels   int `json:"channels"`
+	SampleRate int `json:"sample_rate"`
+	// Frames is the number of hunks.
+	Frames int `json:"frames"`
+	// LaserDisc is true if the CHD has VBI data for every hunk, as MAME
+	// needs for LaserDisc games.
+	LaserDisc bool `json:"laserdisc"`
+}
+
+// GamePlatform implements core.GameInfo.
+func (i *AVInfo) GamePlatform() core.Platform { return core.PlatformLaserDisc }
+
+// GameTitle implements core.GameInfo. A/V CHDs don't record a title.
+func (i *AVInfo) GameTitle() string { return "" }
+
+// GameSerial implements core.GameInfo. A/V CHDs don't record a serial.
+func (i *AVInfo) GameSerial() string { return "" }
+
+// GameRegions implements core.GameInfo. A/V CHDs don't record a region.
+func (i *AVInfo) GameRegions() []core.Region { return []core.Region{} }
+
+// Duration returns the length of the video in seconds.
+func (i *AVInfo) Duration() float64 {
+	if i.FPS == 0 {
+		return 0
+	}
+	return float64(i.Frames) / i.FPS
+}
+
+// parseAVMetadata extracts A/V information from metadata entries, returning
+// nil if there's no valid AVAV entry.
+func parseAVMetadata(entries []metadataEntry, header *Header) *AVInfo {
+	var info *AVInfo
+	var vbiBytes int
+	for _, entry := range entries {
+		switch entry.tag {
+		case TagAV:
+			if info == nil {
+				info = parseAVMetadataEntry(entry.data)
+			}
+		case TagAVLaserdisc:
+			vbiBytes = len(entry.data)
+		}
+	}
+	if info == nil {
+		return nil
+	}
+
+	info.Frames = int(header.TotalHunks)
+	info.LaserDisc = vbiBytes > 0 && vbiBytes == info.Frames*vbiPackedBytes
+	return info
+}
+
+// parseAVMetadataEntry parses the AVAV text format, returning nil if a field
+// is missing or invalid.
+func parseAVMetadataEntry(data []byte) *AVInfo {
+	fields := parseMetadataFields(strings.TrimRight(string(data), "\x00"))
+
+	ints := make(map[string]int)
+	for _, key := range []string{"WIDTH", "HEIGHT", "INTERLACED", "CHANNELS", "SAMPLERATE"} {
+		v, err := strconv.Atoi(fields[key])
+		if err != nil || v < 0 {
+			return nil
+		}
+		ints[key] = v
+	}
+	fps, err := strconv.ParseFloat(fields["FPS"], 64)
+	if err != nil || fps <= 0 {
+		return nil
+	}
+
+	return &AVInfo{
+		FPS:        fps,
+		Width:      ints["WIDTH"],
+		Height:     ints["HEIGHT"],
+		Interlaced: ints["INTERLACED"] != 0,
+		Channels:   ints["CHANNELS"],
+		SampleRate: ints["SAMPLERATE"],
+	}
+}
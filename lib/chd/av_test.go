@@ -0,0 +1,71 @@
+package chd
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestNewReader_AVMetadata(t *testing.T) {
+	const hunks = 3
+	tests := []struct {
+		name     string
+		avav     string
+		vbiBytes int
+		want     *AVInfo
+	}{
+		{
+			name:     "laserdisc",
+			avav:     "FPS:59.940060 WIDTH:720 HEIGHT:240 INTERLACED:1 CHANNELS:2 SAMPLERATE:48000",
+			vbiBytes: hunks * vbiPackedBytes,
+			want: &AVInfo{
+				FPS: 59.940060, Width: 720, Height: 240, Interlaced: true,
+				Channels: 2, SampleRate: 48000, Frames: hunks, LaserDisc: true,
+			},
+		},
+		{
+			name:     "incomplete VBI data",
+			avav:     "FPS:30.000000 WIDTH:640 HEIGHT:480 INTERLACED:0 CHANNELS:1 SAMPLERATE:44100",
+			vbiBytes: vbiPackedBytes,
+			want: &AVInfo{
+				FPS: 30, Width: 640, Height: 480,
+				Channels: 1, SampleRate: 44100, Frames: hunks,
+			},
+		},
+		{
+			name: "missing field",
+			avav: "FPS:30.000000 WIDTH:640 HEIGHT:480",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := &memFile{}
+			w, err := NewWriter(file, WriterConfig{HunkBytes: 1024, UnitBytes: 1024})
+			if err != nil {
+				t.Fatalf("NewWriter() error = %v", err)
+			}
+			w.Write(make([]byte, hunks*1024))
+			w.AddMetadata(TagAV, append([]byte(tt.avav), 0))
+			if tt.vbiBytes > 0 {
+				w.AddMetadata(TagAVLaserdisc, make([]byte, tt.vbiBytes))
+			}
+			if _, err := w.Close(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+
+			r, err := NewReader(bytes.NewReader(file.data), int64(len(file.data)))
+			if err != nil {
+				t.Fatalf("NewReader() error = %v", err)
+			}
+			if tt.want == nil {
+				if r.AV != nil {
+					t.Errorf("AV = %+v, want nil", r.AV)
+				}
+				return
+			}
+			if r.AV == nil || *r.AV != *tt.want {
+				t.Errorf("AV = %+v, want %+v", r.AV, tt.want)
+			}
+		})
+	}
+}
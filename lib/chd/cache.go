@@ -0,0 +1,66 @@
+package chd
+
+import (
+	"container/list"
+	"sync"
+)
+
+// hunkCache is an LRU cache of decompressed hunks, bounded by their total
+// size. It's safe for concurrent use.
+type hunkCache struct {
+	mu       sync.Mutex
+	maxBytes int64
+	bytes    int64
+	hunks    map[uint32]*list.Element
+	order    *list.List // of *cachedHunk, most recently used first
+}
+
+// cachedHunk is a hunk in a hunkCache.
+type cachedHunk struct {
+	num  uint32
+	data []byte
+}
+
+func newHunkCache(maxBytes int64) *hunkCache {
+	return &hunkCache{
+		maxBytes: maxBytes,
+		hunks:    make(map[uint32]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// get returns a cached hunk, marking it as recently used. The data must not
+// be modified.
+func (c *hunkCache) get(num uint32) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.hunks[num]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*cachedHunk).data, true
+}
+
+// put caches a hunk, evicting the least recently used hunks to stay within
+// the budget. Hunks larger than the whole budget aren't cached.
+func (c *hunkCache) put(num uint32, data []byte) {
+	size := int64(len(data))
+	if size > c.maxBytes {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.hunks[num]; ok {
+		return
+	}
+	for c.bytes+size > c.maxBytes {
+		oldest := c.order.Back()
+		hunk := c.order.Remove(oldest).(*cachedHunk)
+		delete(c.hunks, hunk.num)
+		c.bytes -= int64(len(hunk.data))
+	}
+	c.hunks[num] = c.order.PushFront(&cachedHunk{num: num, data: data})
+	c.bytes += size
+}
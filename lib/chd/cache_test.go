@@ -0,0 +1,69 @@
+package chd
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestHunkCache(t *testing.T) {
+	c := newHunkCache(30)
+	c.put(0, make([]byte, 10))
+	c.put(1, make([]byte, 10))
+	c.put(2, make([]byte, 10))
+
+	// Using hunk 0 makes hunk 1 the least recently used
+	if _, ok := c.get(0); !ok {
+		t.Fatal("get(0) missed")
+	}
+	c.put(3, make([]byte, 10))
+	for num, want := range []bool{true, false, true, true} {
+		if _, ok := c.get(uint32(num)); ok != want {
+			t.Errorf("get(%d) ok = %v, want %v", num, ok, want)
+		}
+	}
+
+	// A hunk over the whole budget isn't cached, and evicts nothing
+	c.put(4, make([]byte, 31))
+	if _, ok := c.get(4); ok {
+		t.Error("get(4) hit for a hunk over the budget")
+	}
+	if c.bytes != 30 || len(c.hunks) != 3 {
+		t.Errorf("cache holds %d bytes in %d hunks, want 30 in 3", c.bytes, len(c.hunks))
+	}
+}
+
+func TestNewReader_WithCacheBytes(t *testing.T) {
+	const hunkBytes = 4096
+	data := testImage(hunkBytes)
+	file := &memFile{}
+	w, err := NewWriter(file, WriterConfig{HunkBytes: hunkBytes, UnitBytes: 2048, Compressors: []Codec{CodecZlib}})
+	if err != nil {
+		t.Fatalf("NewWriter() error = %v", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if _, err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	for _, cacheBytes := range []int64{0, hunkBytes, DefaultCacheBytes} {
+		r, err := NewReader(bytes.NewReader(file.data), int64(len(file.data)), WithCacheBytes(cacheBytes))
+		if err != nil {
+			t.Fatalf("NewReader() error = %v", err)
+		}
+		// Read a sector at a time, so each hunk is read twice
+		got := make([]byte, len(data))
+		for off := 0; off < len(data); off += 2048 {
+			if _, err := r.ReadAt(got[off:min(off+2048, len(data))], int64(off)); err != nil {
+				t.Fatalf("ReadAt(%d) error = %v", off, err)
+			}
+		}
+		if !bytes.Equal(got, data) {
+			t.Errorf("WithCacheBytes(%d): ReadAt() data doesn't match the written data", cacheBytes)
+		}
+		if r.hunkCache.bytes > cacheBytes {
+			t.Errorf("WithCacheBytes(%d): cache holds %d bytes", cacheBytes, r.hunkCache.bytes)
+		}
+	}
+}
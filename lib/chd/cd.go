@@ -0,0 +1,265 @@
+package chd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/sargunv/rom-tools/lib/cue"
+	"github.com/sargunv/rom-tools/lib/gdi"
+)
+
+// Track metadata formats, from MAME cdrom.h.
+const (
+	cdMetadataFormat = "TRACK:%d TYPE:%s SUBTYPE:NONE FRAMES:%d PREGAP:%d PGTYPE:%s PGSUB:NONE POSTGAP:%d"
+	gdMetadataFormat = "TRACK:%d TYPE:%s SUBTYPE:NONE FRAMES:%d PAD:%d PREGAP:%d PGTYPE:%s PGSUB:NONE POSTGAP:%d"
+)
+
+// CDTrack is a CD track to write with WriteCD, or read with Reader.CDTracks.
+type CDTrack struct {
+	// Type is the CHD track type: "MODE1", "MODE1_RAW", "MODE2", "MODE2_RAW",
+	// or "AUDIO".
+	Type string
+	// Data holds the track's sectors, in the type's sector size, including
+	// any stored pregap. Audio is little-endian, as in BIN files.
+	Data io.ReaderAt
+	// Frames is the number of sectors in Data.
+	Frames int
+	// Pregap is the pregap length. If PregapStored, it's the first Pregap
+	// frames of Data; otherwise it isn't stored.
+	Pregap       int
+	PregapStored bool
+	// Postgap is the postgap length, which isn't stored.
+	Postgap int
+}
+
+// SectorSize returns the size of a sector of a CHD track type, as CDTrack
+// data holds it, or 0 if the type isn't supported.
+func SectorSize(trackType string) int {
+	switch trackType {
+	case "MODE1":
+		return 2048
+	case "MODE2":
+		return 2336
+	case "MODE1_RAW", "MODE2_RAW", "AUDIO":
+		return rawSectorSize
+	default:
+		return 0
+	}
+}
+
+// WriteCD writes CD tracks to w as a CHD, with track metadata, in the layout
+// chdman uses: each frame is a sector padded to rawSectorSize plus empty
+// subcode, audio is byte-swapped to big-endian, and each track is padded to a
+// multiple of 4 frames.
+func WriteCD(w io.WriterAt, tracks []CDTrack, compressors []Codec) (*Header, error) {
+	return writeCD(w, tracks, compressors, TagCDROM2, func(number int, track CDTrack, pregapType string) string {
+		return fmt.Sprintf(cdMetadataFormat, number, track.Type, track.Frames, track.Pregap, pregapType, track.Postgap)
+	})
+}
+
+// WriteGD writes GD-ROM tracks to w as a CHD. It's the same as WriteCD, but
+// with GD-ROM track metadata.
+func WriteGD(w io.WriterAt, tracks []CDTrack, compressors []Codec) (*Header, error) {
+	return writeCD(w, tracks, compressors, TagGDROM, func(number int, track CDTrack, pregapType string) string {
+		pad := (cdTrackPadding - track.Frames%cdTrackPadding) % cdTrackPadding
+		return fmt.Sprintf(gdMetadataFormat, number, track.Type, track.Frames, pad, track.Pregap, pregapType, track.Postgap)
+	})
+}
+
+// writeCD writes tracks with a metadata entry for each, formatted by meta.
+func writeCD(w io.WriterAt, tracks []CDTrack, compressors []Codec, tag MetadataTag, meta func(number int, track CDTrack, pregapType string) string) (*Header, error) {
+	cw, err := NewWriter(w, WriterConfig{
+		HunkBytes:   DefaultCDHunkBytes,
+		UnitBytes:   CDFrameSize,
+		Compressors: compressors,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i, track := range tracks {
+		sectorSize := SectorSize(track.Type)
+		if sectorSize == 0 {
+			return nil, fmt.Errorf("track %d: unsupported track type %s", i+1, track.Type)
+		}
+		if track.PregapStored && track.Pregap > track.Frames {
+			return nil, fmt.Errorf("track %d: pregap %d longer than track (%d frames)", i+1, track.Pregap, track.Frames)
+		}
+
+		frame := make([]byte, CDFrameSize)
+		for f := range track.Frames {
+			clear(frame)
+			if _, err := track.Data.ReadAt(frame[:sectorSize], int64(f)*int64(sectorSize)); err != nil {
+				return nil, fmt.Errorf("track %d: read frame %d: %w", i+1, f, err)
+			}
+			if track.Type == "AUDIO" {
+				for j := 0; j+1 < rawSectorSize; j += 2 {
+					frame[j], frame[j+1] = frame[j+1], frame[j]
+				}
+			}
+			if _, err := cw.Write(frame); err != nil {
+				return nil, err
+			}
+		}
+
+		clear(frame)
+		for range (cdTrackPadding - track.Frames%cdTrackPadding) % cdTrackPadding {
+			if _, err := cw.Write(frame); err != nil {
+				return nil, err
+			}
+		}
+
+		pregapType := track.Type
+		if track.PregapStored && track.Pregap > 0 {
+			pregapType = "V" + track.Type
+		}
+		if err := cw.AddMetadata(tag, append([]byte(meta(i+1, track, pregapType)), 0)); err != nil {
+			return nil, err
+		}
+	}
+
+	return cw.Close()
+}
+
+// CDTracks returns the CHD's tracks in the form WriteCD takes, for
+// converting to other formats: sectors are in the type's sector size, audio
+// is little-endian, and stored pregaps are included.
+func (r *Reader) CDTracks() ([]CDTrack, error) {
+	tracks := make([]CDTrack, 0, len(r.Tracks))
+	for _, t := range r.Tracks {
+		sectorSize := SectorSize(t.Type)
+		if sectorSize == 0 {
+			return nil, fmt.Errorf("track %d: unsupported track type %s", t.Number, t.Type)
+		}
+		tracks = append(tracks, CDTrack{
+			Type:         t.Type,
+			Data:         &cdTrackReader{track: t, sectorSize: sectorSize},
+			Frames:       t.Frames,
+			Pregap:       t.Pregap,
+			PregapStored: t.storedPregap > 0,
+			Postgap:      t.Postgap,
+		})
+	}
+	return tracks, nil
+}
+
+// cdTrackReader reads a track's sectors, including any stored pregap, in
+// the track type's sector size.
+type cdTrackReader struct {
+	track      *Track
+	sectorSize int
+}
+
+// ReadAt implements io.ReaderAt.
+func (tr *cdTrackReader) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, fmt.Errorf("negative offset")
+	}
+
+	n := 0
+	for n < len(p) {
+		sector := (off + int64(n)) / int64(tr.sectorSize)
+		offsetInSector := int((off + int64(n)) % int64(tr.sectorSize))
+		if sector >= int64(tr.track.Frames) {
+			return n, io.EOF
+		}
+
+		frame, err := tr.track.reader.readSector(uint64(tr.track.startFrame + sector))
+		if err != nil {
+			return n, fmt.Errorf("read sector %d: %w", sector, err)
+		}
+		if len(frame) < tr.sectorSize {
+			return n, fmt.Errorf("read sector %d: short frame", sector)
+		}
+		data := frame[:tr.sectorSize]
+		if tr.track.Type == "AUDIO" {
+			for j := 0; j+1 < len(data); j += 2 {
+				data[j], data[j+1] = data[j+1], data[j]
+			}
+		}
+		n += copy(p[n:], data[offsetInSector:])
+	}
+	return n, nil
+}
+
+// cueTrackTypes maps CUE sheet track types to CHD track types.
+var cueTrackTypes = map[string]string{
+	"MODE1/2048": "MODE1",
+	"MODE1/2352": "MODE1_RAW",
+	"MODE2/2336": "MODE2",
+	"MODE2/2352": "MODE2_RAW",
+	"AUDIO":      "AUDIO",
+}
+
+// CueTracks returns the tracks of a BIN/CUE image for WriteCD. The open
+// function opens a file named in the sheet, returning its contents and
+// size; closing it is the caller's responsibility.
+func CueTracks(sheet *cue.Sheet, open func(name string) (io.ReaderAt, int64, error)) ([]CDTrack, error) {
+	var tracks []CDTrack
+	for _, file := range sheet.Files {
+		if file.Type != "BINARY" {
+			return nil, fmt.Errorf("%s: unsupported file type %s", file.Name, file.Type)
+		}
+
+		bin, binSize, err := open(file.Name)
+		if err != nil {
+			return nil, fmt.Errorf("open %s: %w", file.Name, err)
+		}
+
+		for _, t := range file.Tracks {
+			trackType, ok := cueTrackTypes[t.Type]
+			if !ok {
+				return nil, fmt.Errorf("track %d: unsupported track type %s", t.Number, t.Type)
+			}
+			data, err := file.OpenTrack(bin, binSize, t.Number)
+			if err != nil {
+				return nil, err
+			}
+
+			track := CDTrack{
+				Type:    trackType,
+				Data:    data,
+				Frames:  int(data.Size() / int64(t.SectorSize())),
+				Pregap:  int(t.Pregap),
+				Postgap: int(t.Postgap),
+			}
+			if t.Index0 >= 0 {
+				track.Pregap = int(t.Index1 - t.Index0)
+				track.PregapStored = true
+			}
+			tracks = append(tracks, track)
+		}
+	}
+	return tracks, nil
+}
+
+// GDITracks returns the tracks of a GDI image for WriteGD. The open function
+// opens a track file named in the sheet, returning its contents and size;
+// closing it is the caller's responsibility.
+func GDITracks(sheet *gdi.Sheet, open func(name string) (io.ReaderAt, int64, error)) ([]CDTrack, error) {
+	tracks := make([]CDTrack, 0, len(sheet.Tracks))
+	for _, t := range sheet.Tracks {
+		data, size, err := open(t.File)
+		if err != nil {
+			return nil, fmt.Errorf("open %s: %w", t.File, err)
+		}
+
+		trackType := "AUDIO"
+		if t.Data {
+			trackType = "MODE1_RAW"
+			if t.SectorSize == 2048 {
+				trackType = "MODE1"
+			}
+		}
+		if SectorSize(trackType) != t.SectorSize {
+			return nil, fmt.Errorf("track %d: invalid sector size %d for %s", t.Number, t.SectorSize, trackType)
+		}
+
+		tracks = append(tracks, CDTrack{
+			Type:   trackType,
+			Data:   data,
+			Frames: int(size / int64(t.SectorSize)),
+		})
+	}
+	return tracks, nil
+}
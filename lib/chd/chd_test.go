@@ -1,7 +1,12 @@
 package chd
 
 import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
 	"os"
+	"strings"
 	"testing"
 )
 
@@ -19,6 +24,8 @@ func TestParseTrackMetadataEntry(t *testing.T) {
 				Number: 1,
 				Type:   "MODE1_RAW",
 				Frames: 337350,
+				// Padded to a multiple of 4 frames
+				padFrames: 2,
 			},
 		},
 		{
@@ -44,10 +51,11 @@ func TestParseTrackMetadataEntry(t *testing.T) {
 			name: "CHGD format",
 			data: "TRACK:3 TYPE:MODE1_RAW SUBTYPE:NONE FRAMES:450000 PAD:100 PREGAP:150 PGTYPE:MODE1 PGSUB:NONE POSTGAP:0",
 			want: Track{
-				Number: 3,
-				Type:   "MODE1_RAW",
-				Frames: 450000,
-				Pregap: 150,
+				Number:    3,
+				Type:      "MODE1_RAW",
+				Frames:    450000,
+				Pregap:    150,
+				padFrames: 100,
 			},
 		},
 		{
@@ -79,6 +87,9 @@ func TestParseTrackMetadataEntry(t *testing.T) {
 			if got.Pregap != tt.want.Pregap {
 				t.Errorf("Pregap = %v, want %v", got.Pregap, tt.want.Pregap)
 			}
+			if got.padFrames != tt.want.padFrames {
+				t.Errorf("padFrames = %v, want %v", got.padFrames, tt.want.padFrames)
+			}
 		})
 	}
 }
@@ -141,3 +152,256 @@ func TestTrackSize(t *testing.T) {
 		t.Errorf("Track.Size() = %v, want %v", got, want)
 	}
 }
+
+func TestNewReaderWithParent_NoParentSHA1(t *testing.T) {
+	file, err := os.Open("testdata/empty.chd")
+	if err != nil {
+		t.Fatalf("Failed to open CHD file: %v", err)
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		t.Fatalf("Failed to stat CHD file: %v", err)
+	}
+
+	parent, err := NewReader(file, stat.Size())
+	if err != nil {
+		t.Fatalf("NewReader() error = %v", err)
+	}
+
+	// empty.chd is standalone, so supplying a parent is an error
+	if _, err := NewReaderWithParent(file, stat.Size(), parent); err == nil {
+		t.Error("Expected error when CHD has no parent, got nil")
+	}
+}
+
+func TestReadHunk_ParentReference(t *testing.T) {
+	// An uncompressed two-hunk parent with distinct data in each hunk
+	parentData := make([]byte, 2*4096)
+	for i := range parentData {
+		parentData[i] = byte(i / 2048)
+	}
+	parent := &Reader{
+		file: bytes.NewReader(parentData),
+		header: &Header{
+			LogicalBytes: uint64(len(parentData)),
+			HunkBytes:    4096,
+			UnitBytes:    2048,
+			TotalHunks:   2,
+		},
+		hunkMap: &chdMap{entries: []mapEntry{
+			{compression: compressionNone, offset: 0, length: 4096},
+			{compression: compressionNone, offset: 4096, length: 4096},
+		}},
+		hunkCache: newHunkCache(DefaultCacheBytes),
+	}
+
+	// A child whose only hunk references the parent starting at unit 1,
+	// which straddles both parent hunks
+	child := &Reader{
+		header: &Header{
+			LogicalBytes: 4096,
+			HunkBytes:    4096,
+			UnitBytes:    2048,
+			TotalHunks:   1,
+		},
+		hunkMap: &chdMap{entries: []mapEntry{
+			{compression: compressionParent, offset: 1},
+		}},
+		hunkCache: newHunkCache(DefaultCacheBytes),
+	}
+
+	buf := make([]byte, 16)
+	if _, err := child.ReadAt(buf, 0); !errors.Is(err, ErrParentRequired) {
+		t.Errorf("ReadAt() without parent error = %v, want ErrParentRequired", err)
+	}
+
+	child.parent = parent
+
+	got := make([]byte, 4096)
+	if _, err := child.ReadAt(got, 0); err != nil {
+		t.Fatalf("ReadAt() with parent error = %v", err)
+	}
+	if !bytes.Equal(got, parentData[2048:6144]) {
+		t.Error("ReadAt() with parent returned data that doesn't match the parent")
+	}
+}
+
+// buildV4CHD builds an in-memory v4 CHD with 16-byte hunks:
+// an uncompressed hunk, a zlib-compressed hunk, a mini hunk, and a self reference.
+func buildV4CHD(t *testing.T) ([]byte, []byte) {
+	t.Helper()
+
+	const hunkBytes = 16
+	hunk0 := []byte("uncompressed!!!!")
+	hunk1 := []byte("compressed data.")
+	mini := uint64(0x0102030405060708)
+
+	var compressed bytes.Buffer
+	fw, err := flate.NewWriter(&compressed, flate.BestCompression)
+	if err != nil {
+		t.Fatalf("flate.NewWriter() error = %v", err)
+	}
+	fw.Write(hunk1)
+	fw.Close()
+
+	header := make([]byte, v4HeaderSize)
+	copy(header, "MComprHD")
+	binary.BigEndian.PutUint32(header[8:], v4HeaderSize)
+	binary.BigEndian.PutUint32(header[12:], 4)
+	binary.BigEndian.PutUint32(header[20:], legacyCompressionZlib)
+	binary.BigEndian.PutUint32(header[24:], 4)              // total hunks
+	binary.BigEndian.PutUint64(header[28:], 4*hunkBytes)    // logical bytes
+	binary.BigEndian.PutUint32(header[44:], hunkBytes)      // hunk bytes
+	copy(header[48:], bytes.Repeat([]byte{0xAA}, sha1Size)) // SHA1
+	copy(header[88:], bytes.Repeat([]byte{0xBB}, sha1Size)) // raw SHA1
+
+	dataOffset := uint64(v4HeaderSize + 4*v34MapEntrySize)
+	mapData := make([]byte, 4*v34MapEntrySize)
+	putEntry := func(i int, offset uint64, length uint32, entryType byte) {
+		e := mapData[i*v34MapEntrySize:]
+		binary.BigEndian.PutUint64(e[0:], offset)
+		binary.BigEndian.PutUint16(e[12:], uint16(length))
+		e[14] = byte(length >> 16)
+		e[15] = entryType
+	}
+	putEntry(0, dataOffset, hunkBytes, v34EntryUncompressed)
+	putEntry(1, dataOffset+hunkBytes, uint32(compressed.Len()), v34EntryCompressed)
+	putEntry(2, mini, 0, v34EntryMini)
+	putEntry(3, 0, 0, v34EntrySelfHunk)
+
+	file := append(header, mapData...)
+	file = append(file, hunk0...)
+	file = append(file, compressed.Bytes()...)
+
+	want := append([]byte{}, hunk0...)
+	want = append(want, hunk1...)
+	want = append(want, bytes.Repeat([]byte{1, 2, 3, 4, 5, 6, 7, 8}, 2)...)
+	want = append(want, hunk0...)
+	return file, want
+}
+
+func TestNewReader_V4(t *testing.T) {
+	file, want := buildV4CHD(t)
+
+	reader, err := NewReader(bytes.NewReader(file), int64(len(file)))
+	if err != nil {
+		t.Fatalf("NewReader() error = %v", err)
+	}
+
+	header := reader.Header()
+	if header.Version != 4 {
+		t.Errorf("Version = %d, want 4", header.Version)
+	}
+	if header.Compressors[0] != CodecZlib {
+		t.Errorf("Compressors[0] = %#x, want %#x", header.Compressors[0], CodecZlib)
+	}
+	if header.HunkBytes != 16 || header.UnitBytes != 16 {
+		t.Errorf("HunkBytes/UnitBytes = %d/%d, want 16/16", header.HunkBytes, header.UnitBytes)
+	}
+	if header.SHA1 != strings.Repeat("aa", sha1Size) {
+		t.Errorf("SHA1 = %s", header.SHA1)
+	}
+	if header.RawSHA1 != strings.Repeat("bb", sha1Size) {
+		t.Errorf("RawSHA1 = %s", header.RawSHA1)
+	}
+	if header.ParentSHA1 != "" {
+		t.Errorf("ParentSHA1 = %s, want empty", header.ParentSHA1)
+	}
+
+	got := make([]byte, len(want))
+	if _, err := reader.ReadAt(got, 0); err != nil {
+		t.Fatalf("ReadAt() error = %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("ReadAt() = %q, want %q", got, want)
+	}
+}
+
+func TestNewReader_V2(t *testing.T) {
+	const sectorBytes = 256
+
+	header := make([]byte, v2HeaderSize)
+	copy(header, "MComprHD")
+	binary.BigEndian.PutUint32(header[8:], v2HeaderSize)
+	binary.BigEndian.PutUint32(header[12:], 2)
+	binary.BigEndian.PutUint32(header[24:], 1) // sectors per hunk
+	binary.BigEndian.PutUint32(header[28:], 2) // total hunks
+	binary.BigEndian.PutUint32(header[32:], 1) // cylinders
+	binary.BigEndian.PutUint32(header[36:], 1) // heads
+	binary.BigEndian.PutUint32(header[40:], 2) // sectors
+	copy(header[44:], bytes.Repeat([]byte{0xCC}, md5Size))
+	binary.BigEndian.PutUint32(header[76:], sectorBytes)
+
+	dataOffset := uint64(v2HeaderSize + 2*v12MapEntrySize)
+	mapData := make([]byte, 2*v12MapEntrySize)
+	binary.BigEndian.PutUint64(mapData[0:], uint64(sectorBytes)<<44|dataOffset)
+	binary.BigEndian.PutUint64(mapData[8:], uint64(sectorBytes)<<44|(dataOffset+sectorBytes))
+
+	data := make([]byte, 2*sectorBytes)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	file := append(append(header, mapData...), data...)
+
+	reader, err := NewReader(bytes.NewReader(file), int64(len(file)))
+	if err != nil {
+		t.Fatalf("NewReader() error = %v", err)
+	}
+
+	if reader.Size() != 2*sectorBytes {
+		t.Errorf("Size() = %d, want %d", reader.Size(), 2*sectorBytes)
+	}
+	if reader.Header().MD5 != strings.Repeat("cc", md5Size) {
+		t.Errorf("MD5 = %s", reader.Header().MD5)
+	}
+	if reader.Header().SHA1 != "" {
+		t.Errorf("SHA1 = %s, want empty for v2", reader.Header().SHA1)
+	}
+	wantGeometry := HardDiskInfo{Cylinders: 1, Heads: 1, Sectors: 2, BytesPerSector: sectorBytes}
+	if reader.HardDisk == nil || *reader.HardDisk != wantGeometry {
+		t.Errorf("HardDisk = %+v, want %+v", reader.HardDisk, wantGeometry)
+	}
+
+	got := make([]byte, len(data))
+	if _, err := reader.ReadAt(got, 0); err != nil {
+		t.Fatalf("ReadAt() error = %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Error("ReadAt() returned unexpected data")
+	}
+}
+
+func TestNewReader_LegacyMapBeyondFile(t *testing.T) {
+	file, _ := buildV4CHD(t)
+	binary.BigEndian.PutUint32(file[24:], 0xFFFFFFFF) // total hunks
+
+	_, err := NewReader(bytes.NewReader(file), int64(len(file)))
+	if err == nil || !strings.Contains(err.Error(), "beyond file") {
+		t.Errorf("NewReader() error = %v, want map extends beyond file", err)
+	}
+}
+
+func TestParseOldTrackMetadata(t *testing.T) {
+	data := make([]byte, 4+2*24)
+	binary.BigEndian.PutUint32(data[0:], 2)
+	binary.BigEndian.PutUint32(data[4:], 1)       // MODE1_RAW
+	binary.BigEndian.PutUint32(data[4+16:], 1000) // frames
+	binary.BigEndian.PutUint32(data[28:], 7)      // AUDIO
+	binary.BigEndian.PutUint32(data[28+16:], 500) // frames
+
+	tracks, err := parseOldTrackMetadata(data)
+	if err != nil {
+		t.Fatalf("parseOldTrackMetadata() error = %v", err)
+	}
+	if len(tracks) != 2 {
+		t.Fatalf("got %d tracks, want 2", len(tracks))
+	}
+	if tracks[0].Number != 1 || tracks[0].Type != "MODE1_RAW" || tracks[0].Frames != 1000 {
+		t.Errorf("track 1 = %+v", tracks[0])
+	}
+	if tracks[1].Number != 2 || tracks[1].Type != "AUDIO" || tracks[1].Frames != 500 {
+		t.Errorf("track 2 = %+v", tracks[1])
+	}
+}
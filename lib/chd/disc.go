@@ -0,0 +1,94 @@
+package chd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/sargunv/rom-tools/lib/core"
+)
+
+// Disc is a CD, GD-ROM, or DVD CHD's tracks, as a core.DiscImage. DVD CHDs
+// have no track metadata, so they're one track of ISO sectors.
+type Disc struct {
+	reader *Reader
+}
+
+// NewDisc returns the disc held by r.
+func NewDisc(r *Reader) *Disc {
+	return &Disc{reader: r}
+}
+
+// Size returns the total size of the tracks.
+func (d *Disc) Size() int64 {
+	if len(d.reader.Tracks) == 0 {
+		return d.reader.Size()
+	}
+	var total int64
+	for _, t := range d.reader.Tracks {
+		total += t.Size()
+	}
+	return total
+}
+
+// Tracks lists the tracks in order.
+func (d *Disc) Tracks() []core.DiscTrack {
+	if len(d.reader.Tracks) == 0 {
+		return []core.DiscTrack{{Number: 1, Data: true, SectorSize: core.UserDataSize}}
+	}
+	tracks := make([]core.DiscTrack, len(d.reader.Tracks))
+	for i, t := range d.reader.Tracks {
+		tracks[i] = core.DiscTrack{Number: t.Number, Data: t.Type != "AUDIO", SectorSize: rawSectorSize}
+	}
+	return tracks
+}
+
+// OpenTrack returns a reader for a track's raw sectors, as Track.Open does.
+func (d *Disc) OpenTrack(number int) (io.ReaderAt, int64, error) {
+	if len(d.reader.Tracks) == 0 && number == 1 {
+		return d.reader, d.reader.Size(), nil
+	}
+	for _, t := range d.reader.Tracks {
+		if t.Number == number {
+			return t.Open(), t.Size(), nil
+		}
+	}
+	return nil, 0, fmt.Errorf("track %d not found", number)
+}
+
+// OpenUserData returns a reader for the user data of the first data track,
+// or of a GD-ROM's first high-density data track.
+func (d *Disc) OpenUserData() (io.ReaderAt, int64, error) {
+	if len(d.reader.Tracks) == 0 {
+		return d.reader, d.reader.Size(), nil
+	}
+
+	track := d.reader.HighDensityDataTrack()
+	for _, t := range d.reader.Tracks {
+		if track == nil && t.Type != "AUDIO" {
+			track = t
+		}
+	}
+	if track == nil {
+		return nil, 0, core.ErrNoDataTrack
+	}
+
+	// Every sector is read as 2352 bytes, with the user data after the sync
+	// pattern and header of raw sectors, and the subheader of MODE2 sectors
+	var offset int
+	switch track.Type {
+	case "MODE1", "MODE2_FORM1":
+	case "MODE1_RAW":
+		offset = 16
+	case "MODE2_RAW":
+		offset = 24
+	case "MODE2", "MODE2_FORM2", "MODE2_FORM_MIX":
+		offset = 8
+	default:
+		return nil, 0, fmt.Errorf("track %d: unsupported track type %s", track.Number, track.Type)
+	}
+	userData, err := core.NewUserDataReader(track.Open(), track.Size(), rawSectorSize, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	return userData, userData.Size(), nil
+}
@@ -0,0 +1,39 @@
+package chd
+
+import (
+	"strconv"
+	"strings"
+)
+
+// HardDiskInfo is the geometry of a hard disk CHD, from its GDDD metadata
+// (or the header, for v1-v2 CHDs), formatted as:
+//
+//	CYLS:%d,HEADS:%d,SECS:%d,BPS:%d
+type HardDiskInfo struct {
+	Cylinders      int `json:"cylinders"`
+	Heads          int `json:"heads"`
+	Sectors        int `json:"sectors"` // per track
+	BytesPerSector int `json:"bytes_per_sector"`
+}
+
+// parseHardDiskMetadata extracts the hard disk geometry from metadata
+// entries, returning nil if there's no valid GDDD entry.
+func parseHardDiskMetadata(entries []metadataEntry, header *Header) *HardDiskInfo {
+	for _, entry := range entries {
+		if entry.tag != TagHardDisk {
+			continue
+		}
+		fields := parseMetadataFields(strings.ReplaceAll(strings.TrimRight(string(entry.data), "\x00"), ",", " "))
+		var values [4]int
+		valid := true
+		for i, key := range []string{"CYLS", "HEADS", "SECS", "BPS"} {
+			v, err := strconv.Atoi(fields[key])
+			valid = valid && err == nil && v > 0
+			values[i] = v
+		}
+		if valid {
+			return &HardDiskInfo{Cylinders: values[0], Heads: values[1], Sectors: values[2], BytesPerSector: values[3]}
+		}
+	}
+	return header.legacyGeometry
+}
@@ -0,0 +1,31 @@
+package chd
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestNewReader_HardDiskMetadata(t *testing.T) {
+	file := &memFile{}
+	w, err := NewWriter(file, WriterConfig{HunkBytes: 4096, UnitBytes: 512})
+	if err != nil {
+		t.Fatalf("NewWriter() error = %v", err)
+	}
+	w.Write(make([]byte, 8192))
+	w.AddMetadata(TagHardDisk, []byte("CYLS:1,HEADS:2,SECS:8,BPS:512\x00"))
+	if _, err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	r, err := NewReader(bytes.NewReader(file.data), int64(len(file.data)))
+	if err != nil {
+		t.Fatalf("NewReader() error = %v", err)
+	}
+	want := HardDiskInfo{Cylinders: 1, Heads: 2, Sectors: 8, BytesPerSector: 512}
+	if r.HardDisk == nil || *r.HardDisk != want {
+		t.Errorf("HardDisk = %+v, want %+v", r.HardDisk, want)
+	}
+	if r.AV != nil {
+		t.Errorf("AV = %+v, want nil", r.AV)
+	}
+}
@@ -13,22 +13,38 @@ const (
 
 // CDZLIB decompresses CD-ROM data using zlib for the base codec.
 func CDZLIB(data []byte, hunkBytes uint32) ([]byte, error) {
-	return decompressCDCodec(data, hunkBytes, Zlib, "zlib")
+	return decompressCDCodec(data, hunkBytes, Zlib, Zlib, "zlib")
 }
 
 // CDLZMA decompresses CD-ROM data using LZMA for the base codec.
 func CDLZMA(data []byte, hunkBytes uint32) ([]byte, error) {
-	return decompressCDCodec(data, hunkBytes, LZMA, "lzma")
+	return decompressCDCodec(data, hunkBytes, LZMA, Zlib, "lzma")
 }
 
-// CDZstd decompresses CD-ROM data using Zstd for the base codec.
+// CDZstd decompresses CD-ROM data using Zstd for the base and subcode codecs.
 func CDZstd(data []byte, hunkBytes uint32) ([]byte, error) {
-	return decompressCDCodec(data, hunkBytes, Zstd, "zstd")
+	return decompressCDCodec(data, hunkBytes, Zstd, Zstd, "zstd")
+}
+
+// CompressCDZLIB compresses CD-ROM data using zlib for the base codec.
+func CompressCDZLIB(data []byte) ([]byte, error) {
+	return compressCDCodec(data, CompressZlib, CompressZlib)
+}
+
+// CompressCDLZMA compresses CD-ROM data using LZMA for the base codec.
+func CompressCDLZMA(data []byte) ([]byte, error) {
+	return compressCDCodec(data, CompressLZMA, CompressZlib)
+}
+
+// CompressCDZstd compresses CD-ROM data using Zstd for the base and subcode
+// codecs.
+func CompressCDZstd(data []byte) ([]byte, error) {
+	return compressCDCodec(data, CompressZstd, CompressZstd)
 }
 
 // decompressCDCodec is the common implementation for CD codecs.
-// Format: [ECC bitmap] [compressed base length] [base data (sector)] [subcode data (zlib)]
-func decompressCDCodec(data []byte, hunkBytes uint32, baseDecompress func([]byte, int) ([]byte, error), codecName string) ([]byte, error) {
+// Format: [ECC bitmap] [compressed base length] [base data (sector)] [subcode data]
+func decompressCDCodec(data []byte, hunkBytes uint32, baseDecompress, subcodeDecompress func([]byte, int) ([]byte, error), codecName string) ([]byte, error) {
 	// Calculate frame count
 	frames := int(hunkBytes / cdFrameSize)
 	if frames == 0 {
@@ -69,12 +85,12 @@ func decompressCDCodec(data []byte, hunkBytes uint32, baseDecompress func([]byte
 		return nil, fmt.Errorf("CD codec base decompress (%s): %w", codecName, err)
 	}
 
-	// Decompress subcode data (always zlib) - outputs cdMaxSubcodeData (96) bytes per frame
+	// Decompress subcode data - outputs cdMaxSubcodeData (96) bytes per frame
 	subcodeCompressed := data[headerBytes+complenBase:]
 	expectedSubcodeSize := frames * cdMaxSubcodeData
 	var subcodeData []byte
 	if len(subcodeCompressed) > 0 {
-		subcodeData, err = Zlib(subcodeCompressed, expectedSubcodeSize)
+		subcodeData, err = subcodeDecompress(subcodeCompressed, expectedSubcodeSize)
 		if err != nil {
 			return nil, fmt.Errorf("CD codec subcode decompress: %w", err)
 		}
@@ -103,3 +119,46 @@ func decompressCDCodec(data []byte, hunkBytes uint32, baseDecompress func([]byte
 
 	return result, nil
 }
+
+// compressCDCodec is the common implementation for CD compressors. Sector and
+// subcode data are compressed separately. The ECC bitmap is left empty, so
+// sectors are stored with their ECC intact rather than regenerated.
+func compressCDCodec(data []byte, baseCompress, subcodeCompress func([]byte) ([]byte, error)) ([]byte, error) {
+	frames := len(data) / cdFrameSize
+	if frames == 0 || len(data)%cdFrameSize != 0 {
+		return nil, fmt.Errorf("CD codec: invalid hunk size %d", len(data))
+	}
+
+	base := make([]byte, 0, frames*cdMaxSectorData)
+	subcode := make([]byte, 0, frames*cdMaxSubcodeData)
+	for i := range frames {
+		frame := data[i*cdFrameSize:]
+		base = append(base, frame[:cdMaxSectorData]...)
+		subcode = append(subcode, frame[cdMaxSectorData:cdFrameSize]...)
+	}
+
+	baseCompressed, err := baseCompress(base)
+	if err != nil {
+		return nil, fmt.Errorf("CD codec base compress: %w", err)
+	}
+	subcodeCompressed, err := subcodeCompress(subcode)
+	if err != nil {
+		return nil, fmt.Errorf("CD codec subcode compress: %w", err)
+	}
+
+	eccBytes := (frames + 7) / 8
+	complenBytes := 2
+	if len(data) >= 65536 {
+		complenBytes = 3
+	}
+	if len(baseCompressed) >= 1<<(8*complenBytes) {
+		return nil, fmt.Errorf("CD codec: compressed base too large (%d bytes)", len(baseCompressed))
+	}
+
+	out := make([]byte, eccBytes+complenBytes, eccBytes+complenBytes+len(baseCompressed)+len(subcodeCompressed))
+	for i := range complenBytes {
+		out[eccBytes+i] = byte(len(baseCompressed) >> (8 * (complenBytes - 1 - i)))
+	}
+	out = append(out, baseCompressed...)
+	return append(out, subcodeCompressed...), nil
+}
@@ -1,7 +1,9 @@
 package codec
 
 import (
+	"cmp"
 	"fmt"
+	"slices"
 )
 
 // Huffman decoder for CHD's 8-bit Huffman encoding.
@@ -167,28 +169,7 @@ func (hd *HuffmanDecoder) buildFromBitLengths(bitLengths []uint8) error {
 		}
 	}
 
-	// Compute starting codes for each length using libchdr's algorithm
-	// (iterates from longest to shortest)
-	curStart := uint32(0)
-	startCodes := make([]uint32, actualMaxBits+1)
-	for codeLen := int(actualMaxBits); codeLen > 0; codeLen-- {
-		nextStart := (curStart + bitHisto[codeLen]) >> 1
-		startCodes[codeLen] = curStart
-		curStart = nextStart
-	}
-
-	// Assign codes to symbols (in symbol order, using next available code for each length)
-	nextCode := make([]uint32, actualMaxBits+1)
-	copy(nextCode, startCodes)
-
-	symbolCodes := make([]uint32, hd.numCodes)
-	for i := range hd.numCodes {
-		bl := bitLengths[i]
-		if bl > 0 && bl <= actualMaxBits {
-			symbolCodes[i] = nextCode[bl]
-			nextCode[bl]++
-		}
-	}
+	symbolCodes := assignCanonicalCodes(bitLengths, bitHisto, actualMaxBits)
 
 	// Build lookup table
 	tableSize := uint32(1) << actualMaxBits
@@ -218,6 +199,34 @@ func (hd *HuffmanDecoder) buildFromBitLengths(bitLengths []uint8) error {
 	return nil
 }
 
+// assignCanonicalCodes assigns a code to each symbol from its bit length,
+// given the number of symbols of each length.
+// Uses the same algorithm as libchdr/MAME for canonical code assignment.
+func assignCanonicalCodes(bitLengths []uint8, bitHisto []uint32, maxBits uint8) []uint32 {
+	// Compute starting codes for each length using libchdr's algorithm
+	// (iterates from longest to shortest)
+	curStart := uint32(0)
+	startCodes := make([]uint32, maxBits+1)
+	for codeLen := int(maxBits); codeLen > 0; codeLen-- {
+		nextStart := (curStart + bitHisto[codeLen]) >> 1
+		startCodes[codeLen] = curStart
+		curStart = nextStart
+	}
+
+	// Assign codes to symbols (in symbol order, using next available code for each length)
+	nextCode := make([]uint32, maxBits+1)
+	copy(nextCode, startCodes)
+
+	symbolCodes := make([]uint32, len(bitLengths))
+	for i, bl := range bitLengths {
+		if bl > 0 && bl <= maxBits {
+			symbolCodes[i] = nextCode[bl]
+			nextCode[bl]++
+		}
+	}
+	return symbolCodes
+}
+
 // Decode reads one symbol from the bit reader using the Huffman table.
 func (hd *HuffmanDecoder) Decode(br *BitReader) (uint32, error) {
 	if len(hd.lookup) == 0 {
@@ -278,3 +287,167 @@ func Huffman(data []byte, outputSize int) ([]byte, error) {
 
 	return result, nil
 }
+
+// BitWriter writes bits to a byte slice (MSB first).
+type BitWriter struct {
+	data   []byte
+	bitPos uint32
+}
+
+// WriteBits writes the low n bits of value, most significant first.
+func (bw *BitWriter) WriteBits(value uint32, n uint32) {
+	for i := n; i > 0; i-- {
+		if bw.bitPos%8 == 0 {
+			bw.data = append(bw.data, 0)
+		}
+		if (value>>(i-1))&1 != 0 {
+			bw.data[len(bw.data)-1] |= 1 << (7 - bw.bitPos%8)
+		}
+		bw.bitPos++
+	}
+}
+
+// Bytes returns the written data, padded with zero bits to a whole byte.
+func (bw *BitWriter) Bytes() []byte {
+	return bw.data
+}
+
+// HuffmanEncoder encodes symbols with a Huffman code built from their
+// frequencies, in the format read by HuffmanDecoder.
+type HuffmanEncoder struct {
+	maxBits    uint8
+	histogram  []uint32
+	bitLengths []uint8
+	codes      []uint32
+}
+
+// NewHuffmanEncoder creates a Huffman encoder for the given number of codes.
+func NewHuffmanEncoder(numCodes uint32, maxBits uint8) *HuffmanEncoder {
+	return &HuffmanEncoder{
+		maxBits:   maxBits,
+		histogram: make([]uint32, numCodes),
+	}
+}
+
+// Count records an occurrence of symbol. All symbols must be counted before
+// calling BuildTree.
+func (he *HuffmanEncoder) Count(symbol uint32) {
+	he.histogram[symbol]++
+}
+
+// BuildTree computes the code for each counted symbol, limiting code lengths
+// to maxBits.
+func (he *HuffmanEncoder) BuildTree() {
+	weights := make([]uint64, len(he.histogram))
+	for i, count := range he.histogram {
+		weights[i] = uint64(count)
+	}
+
+	// Flatten the frequencies until the tree fits, as MAME does
+	for {
+		he.bitLengths = huffmanBitLengths(weights)
+		if slices.Max(he.bitLengths) <= he.maxBits {
+			break
+		}
+		for i, w := range weights {
+			if w > 1 {
+				weights[i] = w / 2
+			}
+		}
+	}
+
+	actualMaxBits := slices.Max(he.bitLengths)
+	bitHisto := make([]uint32, actualMaxBits+1)
+	for _, bl := range he.bitLengths {
+		if bl > 0 {
+			bitHisto[bl]++
+		}
+	}
+	he.codes = assignCanonicalCodes(he.bitLengths, bitHisto, actualMaxBits)
+}
+
+// huffmanBitLengths computes Huffman code lengths for the given weights.
+// Symbols with zero weight get no code; a lone symbol gets a 1-bit code.
+func huffmanBitLengths(weights []uint64) []uint8 {
+	type node struct {
+		weight  uint64
+		symbols []int
+	}
+
+	var nodes []node
+	for symbol, w := range weights {
+		if w > 0 {
+			nodes = append(nodes, node{weight: w, symbols: []int{symbol}})
+		}
+	}
+
+	lengths := make([]uint8, len(weights))
+	if len(nodes) == 1 {
+		lengths[nodes[0].symbols[0]] = 1
+		return lengths
+	}
+
+	for len(nodes) > 1 {
+		slices.SortStableFunc(nodes, func(a, b node) int { return cmp.Compare(a.weight, b.weight) })
+		merged := node{weight: nodes[0].weight + nodes[1].weight}
+		for _, n := range nodes[:2] {
+			for _, symbol := range n.symbols {
+				lengths[symbol]++
+			}
+			merged.symbols = append(merged.symbols, n.symbols...)
+		}
+		nodes = append([]node{merged}, nodes[2:]...)
+	}
+	return lengths
+}
+
+// ExportTreeRLE writes the code lengths in the RLE format read by
+// HuffmanDecoder.ImportTreeRLE.
+// This follows MAME's huffman_encoder::export_tree_rle.
+func (he *HuffmanEncoder) ExportTreeRLE(bw *BitWriter) {
+	var numBits uint32
+	if he.maxBits >= 16 {
+		numBits = 5
+	} else if he.maxBits >= 8 {
+		numBits = 4
+	} else {
+		numBits = 3
+	}
+
+	writeRun := func(value uint8, repCount int) {
+		for repCount > 0 {
+			switch {
+			case value == 1:
+				// One is the escape code, so a literal one is doubled
+				bw.WriteBits(1, numBits)
+				bw.WriteBits(1, numBits)
+				repCount--
+			case repCount <= 2:
+				bw.WriteBits(uint32(value), numBits)
+				repCount--
+			default:
+				reps := min(repCount-3, 1<<numBits-1)
+				bw.WriteBits(1, numBits)
+				bw.WriteBits(uint32(value), numBits)
+				bw.WriteBits(uint32(reps), numBits)
+				repCount -= reps + 3
+			}
+		}
+	}
+
+	lastValue, repCount := he.bitLengths[0], 1
+	for _, value := range he.bitLengths[1:] {
+		if value == lastValue {
+			repCount++
+			continue
+		}
+		writeRun(lastValue, repCount)
+		lastValue, repCount = value, 1
+	}
+	writeRun(lastValue, repCount)
+}
+
+// Encode writes the code for symbol.
+func (he *HuffmanEncoder) Encode(bw *BitWriter, symbol uint32) {
+	bw.WriteBits(he.codes[symbol], uint32(he.bitLengths[symbol]))
+}
@@ -9,6 +9,8 @@ import (
 	"github.com/ulikunitz/xz/lzma"
 )
 
+const lzmaHeaderSize = 13
+
 // LZMA decompresses raw LZMA data (no header) as used by CHD.
 // CHD stores raw LZMA compressed data without the standard 13-byte header.
 func LZMA(data []byte, outputSize int) ([]byte, error) {
@@ -31,7 +33,7 @@ func LZMA(data []byte, outputSize int) ([]byte, error) {
 	// [0]   Properties byte
 	// [1-4] Dictionary size (little-endian)
 	// [5-12] Uncompressed size (little-endian)
-	header := make([]byte, 13)
+	header := make([]byte, lzmaHeaderSize)
 	header[0] = propsByte
 	binary.LittleEndian.PutUint32(header[1:5], dictSize)
 	binary.LittleEndian.PutUint64(header[5:13], uint64(outputSize))
@@ -48,3 +50,28 @@ func LZMA(data []byte, outputSize int) ([]byte, error) {
 	}
 	return result[:n], nil
 }
+
+// CompressLZMA compresses data as raw LZMA (no header), as used by CHD.
+func CompressLZMA(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := lzma.WriterConfig{
+		Properties: &lzma.Properties{LC: 3, LP: 0, PB: 2},
+		DictCap:    max(65536, len(data)),
+		Size:       int64(len(data)),
+	}.NewWriter(&buf)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	// Strip the standard 13-byte header, which CHD doesn't store
+	if buf.Len() < lzmaHeaderSize {
+		return nil, fmt.Errorf("LZMA output too short")
+	}
+	return buf.Bytes()[lzmaHeaderSize:], nil
+}
@@ -18,3 +18,19 @@ func Zlib(data []byte, outputSize int) ([]byte, error) {
 	}
 	return result[:n], nil
 }
+
+// CompressZlib compresses data as raw deflate, as used by CHD.
+func CompressZlib(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := flate.NewWriter(&buf, flate.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -6,7 +6,10 @@ import (
 	"github.com/klauspost/compress/zstd"
 )
 
-var zstdDecoder *zstd.Decoder
+var (
+	zstdDecoder *zstd.Decoder
+	zstdEncoder *zstd.Encoder
+)
 
 func init() {
 	var err error
@@ -14,6 +17,10 @@ func init() {
 	if err != nil {
 		panic(fmt.Sprintf("failed to create zstd decoder: %v", err))
 	}
+	zstdEncoder, err = zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedBestCompression))
+	if err != nil {
+		panic(fmt.Sprintf("failed to create zstd encoder: %v", err))
+	}
 }
 
 // Zstd decompresses Zstandard compressed data.
@@ -24,3 +31,8 @@ func Zstd(data []byte, outputSize int) ([]byte, error) {
 	}
 	return result, nil
 }
+
+// CompressZstd compresses data as a Zstandard frame.
+func CompressZstd(data []byte) ([]byte, error) {
+	return zstdEncoder.EncodeAll(data, nil), nil
+}
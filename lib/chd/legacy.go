@@ -0,0 +1,318 @@
+package chd
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Legacy (v1-v4) header layouts. All fields are big-endian.
+//
+// V1 header (76 bytes) / V2 header (80 bytes):
+//
+//	Offset  Size  Description
+//	0       8     Magic ("MComprHD")
+//	8       4     Header length
+//	12      4     Version
+//	16      4     Flags
+//	20      4     Compression type
+//	24      4     Hunk size (in sectors)
+//	28      4     Total hunks
+//	32      4     Cylinders
+//	36      4     Heads
+//	40      4     Sectors
+//	44      16    MD5 (of the raw data)
+//	60      16    Parent MD5
+//	76      4     Bytes per sector (v2 only, v1 is always 512)
+//
+// V3 header (120 bytes):
+//
+//	Offset  Size  Description
+//	16      4     Flags
+//	20      4     Compression type
+//	24      4     Total hunks
+//	28      8     Logical bytes
+//	36      8     Metadata offset
+//	44      16    MD5 (of the raw data)
+//	60      16    Parent MD5
+//	76      4     Hunk bytes
+//	80      20    SHA1 (of the raw data)
+//	100     20    Parent SHA1
+//
+// V4 header (108 bytes):
+//
+//	Offset  Size  Description
+//	16      4     Flags
+//	20      4     Compression type
+//	24      4     Total hunks
+//	28      8     Logical bytes
+//	36      8     Metadata offset
+//	44      4     Hunk bytes
+//	48      20    SHA1 (of the raw data and metadata)
+//	68      20    Parent SHA1
+//	88      20    Raw SHA1 (of the raw data)
+//
+// In all legacy versions, the hunk map immediately follows the header.
+const (
+	v1HeaderSize = 76
+	v2HeaderSize = 80
+	v3HeaderSize = 120
+	v4HeaderSize = 108
+
+	md5Size = 16
+
+	legacyFlagHasParent = 0x01
+
+	v1SectorSize = 512
+)
+
+// Legacy compression types (header field, not map entry type).
+const (
+	legacyCompressionNone     = 0
+	legacyCompressionZlib     = 1
+	legacyCompressionZlibPlus = 2
+	legacyCompressionAV       = 3
+)
+
+// V3/V4 map entry types (low nibble of the entry flags).
+const (
+	v34EntryCompressed   = 1
+	v34EntryUncompressed = 2
+	v34EntryMini         = 3
+	v34EntrySelfHunk     = 4
+	v34EntryParentHunk   = 5
+
+	v34EntryTypeMask = 0x0F
+	v34EntryNoCRC    = 0x10 // the entry's CRC32 isn't valid
+)
+
+const (
+	v12MapEntrySize = 8
+	v34MapEntrySize = 16
+)
+
+// cdFrameSize is the size of a CD frame (2352 sector bytes + 96 subcode bytes),
+// used as the unit size for legacy CD-ROM CHDs.
+const cdFrameSize = 2448
+
+// parseLegacyHeader parses a v1-v4 CHD header into the common Header form.
+func parseLegacyHeader(r io.ReaderAt, size int64, version, headerLen uint32) (*Header, error) {
+	var want uint32
+	switch version {
+	case 1:
+		want = v1HeaderSize
+	case 2:
+		want = v2HeaderSize
+	case 3:
+		want = v3HeaderSize
+	case 4:
+		want = v4HeaderSize
+	default:
+		return nil, fmt.Errorf("CHD version %d not supported", version)
+	}
+	if headerLen < want {
+		return nil, fmt.Errorf("CHD v%d header too small: %d bytes", version, headerLen)
+	}
+	if size < int64(want) {
+		return nil, fmt.Errorf("file too small for CHD v%d header: need %d bytes, got %d", version, want, size)
+	}
+
+	buf := make([]byte, want)
+	if _, err := r.ReadAt(buf, 0); err != nil {
+		return nil, fmt.Errorf("failed to read CHD header: %w", err)
+	}
+
+	flags := binary.BigEndian.Uint32(buf[16:20])
+	compression := binary.BigEndian.Uint32(buf[20:24])
+
+	codecID, err := legacyCodec(compression)
+	if err != nil {
+		return nil, err
+	}
+
+	header := &Header{
+		Version:     version,
+		Compressors: [4]Codec{codecID},
+		MapOffset:   uint64(headerLen),
+	}
+
+	switch version {
+	case 1, 2:
+		hunkSectors := binary.BigEndian.Uint32(buf[24:28])
+		header.TotalHunks = binary.BigEndian.Uint32(buf[28:32])
+		cylinders := uint64(binary.BigEndian.Uint32(buf[32:36]))
+		heads := uint64(binary.BigEndian.Uint32(buf[36:40]))
+		sectors := uint64(binary.BigEndian.Uint32(buf[40:44]))
+		header.MD5 = hex.EncodeToString(buf[44 : 44+md5Size])
+		if flags&legacyFlagHasParent != 0 {
+			header.ParentMD5 = hexIfNonZero(buf[60 : 60+md5Size])
+		}
+
+		sectorBytes := uint32(v1SectorSize)
+		if version == 2 {
+			sectorBytes = binary.BigEndian.Uint32(buf[76:80])
+		}
+		header.HunkBytes = sectorBytes * hunkSectors
+		header.UnitBytes = sectorBytes
+		header.LogicalBytes = cylinders * heads * sectors * uint64(sectorBytes)
+		// V1/V2 CHDs are always hard disks
+		header.legacyGeometry = &HardDiskInfo{
+			Cylinders:      int(cylinders),
+			Heads:          int(heads),
+			Sectors:        int(sectors),
+			BytesPerSector: int(sectorBytes),
+		}
+
+	case 3:
+		header.TotalHunks = binary.BigEndian.Uint32(buf[24:28])
+		header.LogicalBytes = binary.BigEndian.Uint64(buf[28:36])
+		header.MetaOffset = binary.BigEndian.Uint64(buf[36:44])
+		header.MD5 = hex.EncodeToString(buf[44 : 44+md5Size])
+		header.HunkBytes = binary.BigEndian.Uint32(buf[76:80])
+		// V3 has a single SHA1 covering the raw data
+		header.SHA1 = hex.EncodeToString(buf[80 : 80+sha1Size])
+		header.RawSHA1 = header.SHA1
+		if flags&legacyFlagHasParent != 0 {
+			header.ParentMD5 = hexIfNonZero(buf[60 : 60+md5Size])
+			header.ParentSHA1 = hexIfNonZero(buf[100 : 100+sha1Size])
+		}
+
+	case 4:
+		header.TotalHunks = binary.BigEndian.Uint32(buf[24:28])
+		header.LogicalBytes = binary.BigEndian.Uint64(buf[28:36])
+		header.MetaOffset = binary.BigEndian.Uint64(buf[36:44])
+		header.HunkBytes = binary.BigEndian.Uint32(buf[44:48])
+		header.SHA1 = hex.EncodeToString(buf[48 : 48+sha1Size])
+		header.RawSHA1 = hex.EncodeToString(buf[88 : 88+sha1Size])
+		if flags&legacyFlagHasParent != 0 {
+			header.ParentSHA1 = hexIfNonZero(buf[68 : 68+sha1Size])
+		}
+	}
+
+	if header.HunkBytes == 0 {
+		return nil, fmt.Errorf("invalid CHD v%d header: zero hunk size", version)
+	}
+
+	// V3/V4 headers don't store a unit size, so infer it from the metadata
+	if version >= 3 {
+		header.UnitBytes, err = guessUnitBytes(r, header)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return header, nil
+}
+
+// legacyCodec maps a v1-v4 header compression type to a codec ID.
+func legacyCodec(compression uint32) (Codec, error) {
+	switch compression {
+	case legacyCompressionNone:
+		return CodecNone, nil
+	case legacyCompressionZlib, legacyCompressionZlibPlus:
+		return CodecZlib, nil
+	case legacyCompressionAV:
+		return CodecAVHuff, nil
+	default:
+		return CodecNone, fmt.Errorf("unknown CHD compression type: %d", compression)
+	}
+}
+
+// guessUnitBytes infers the unit size of a v3/v4 CHD from its metadata,
+// mirroring MAME: CD-ROM/GD-ROM images use the CD frame size, hard disks use
+// the sector size from their geometry, and anything else uses the hunk size.
+func guessUnitBytes(r io.ReaderAt, header *Header) (uint32, error) {
+	entries, err := readMetadata(r, header.MetaOffset)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, entry := range entries {
+		switch entry.tag {
+		case TagCDROMOld, TagCDROM, TagCDROM2, TagGDROMOld, TagGDROM:
+			return cdFrameSize, nil
+		case TagHardDisk:
+			// Format: CYLS:%d,HEADS:%d,SECS:%d,BPS:%d
+			for _, field := range strings.Split(strings.TrimRight(string(entry.data), "\x00"), ",") {
+				if v, ok := strings.CutPrefix(field, "BPS:"); ok {
+					if bps, err := strconv.Atoi(v); err == nil && bps > 0 {
+						return uint32(bps), nil
+					}
+				}
+			}
+		}
+	}
+
+	return header.HunkBytes, nil
+}
+
+// decodeLegacyMap reads the uncompressed v1-v4 hunk map.
+func decodeLegacyMap(r io.ReaderAt, size int64, header *Header) (*chdMap, error) {
+	entrySize := v34MapEntrySize
+	if header.Version < 3 {
+		entrySize = v12MapEntrySize
+	}
+
+	// The map must fit in the file, which bounds the allocation for a
+	// corrupt hunk count
+	mapBytes := int64(header.TotalHunks) * int64(entrySize)
+	if header.MapOffset > uint64(size) || mapBytes > size-int64(header.MapOffset) {
+		return nil, fmt.Errorf("map extends beyond file: %d hunks, file size %d", header.TotalHunks, size)
+	}
+
+	data := make([]byte, mapBytes)
+	if _, err := r.ReadAt(data, int64(header.MapOffset)); err != nil {
+		return nil, fmt.Errorf("failed to read map: %w", err)
+	}
+
+	entries := make([]mapEntry, header.TotalHunks)
+	for hunkNum := range entries {
+		raw := data[hunkNum*entrySize : (hunkNum+1)*entrySize]
+		entry := &entries[hunkNum]
+
+		if header.Version < 3 {
+			// 44-bit offset, 20-bit length; a full-size hunk is stored uncompressed
+			value := binary.BigEndian.Uint64(raw)
+			entry.offset = value & 0xFFFFFFFFFFF
+			entry.length = uint32(value >> 44)
+			if entry.length == header.HunkBytes {
+				entry.compression = compressionNone
+			} else {
+				entry.compression = compressionType0
+			}
+			continue
+		}
+
+		offset := binary.BigEndian.Uint64(raw[0:8])
+		entry.crc32 = binary.BigEndian.Uint32(raw[8:12])
+		entry.length = uint32(binary.BigEndian.Uint16(raw[12:14])) | uint32(raw[14])<<16
+
+		switch raw[15] & v34EntryTypeMask {
+		case v34EntryCompressed:
+			entry.compression = compressionType0
+			entry.offset = offset
+			entry.hasCRC32 = raw[15]&v34EntryNoCRC == 0
+		case v34EntryUncompressed:
+			entry.compression = compressionNone
+			entry.offset = offset
+			entry.hasCRC32 = raw[15]&v34EntryNoCRC == 0
+		case v34EntryMini:
+			entry.compression = compressionMini
+			entry.offset = offset
+		case v34EntrySelfHunk:
+			entry.compression = compressionSelf
+			entry.offset = offset
+		case v34EntryParentHunk:
+			// Legacy parent references are hunk numbers; normalize to units like v5
+			entry.compression = compressionParent
+			entry.offset = offset * uint64(header.HunkBytes) / uint64(header.UnitBytes)
+		default:
+			return nil, fmt.Errorf("unknown map entry type %d for hunk %d", raw[15]&v34EntryTypeMask, hunkNum)
+		}
+	}
+
+	return &chdMap{entries: entries}, nil
+}
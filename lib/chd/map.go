@@ -24,6 +24,10 @@ const (
 	compressionParentSelf = 11 // Parent reference, same offset as self
 	compressionParent0    = 12 // Parent reference, offset +0
 	compressionParent1    = 13 // Parent reference, offset +1
+
+	// compressionMini is not a v5 map type. It represents legacy (v3/v4) "mini"
+	// hunks, where the 8-byte offset value is repeated to fill the hunk.
+	compressionMini = 0xFF
 )
 
 // mapEntry represents a single hunk's location and compression info.
@@ -31,7 +35,9 @@ type mapEntry struct {
 	compression uint8  // Compression type (0-6)
 	length      uint32 // Compressed length in bytes
 	offset      uint64 // Offset in file (or hunk number for self-reference)
-	crc16       uint16 // CRC of uncompressed data
+	crc16       uint16 // CRC of uncompressed data (v5)
+	crc32       uint32 // CRC of uncompressed data (v3/v4)
+	hasCRC32    bool   // whether crc32 is valid
 }
 
 // chdMap contains the decoded hunk map for a CHD file.
@@ -288,3 +294,125 @@ func crc16(data []byte) uint16 {
 	}
 	return crc
 }
+
+// encodeMap compresses the V5 hunk map, returning the map header followed by
+// the compressed map data. Entries must be compressed, uncompressed, or self
+// references, with their data stored contiguously, in order.
+// This follows MAME's chd_file::compress_v5_map, without the SELF_0/SELF_1
+// shorthands.
+func encodeMap(entries []mapEntry, hunkBytes uint32) ([]byte, error) {
+	// Find the field sizes, and check the data is contiguous, since the map
+	// only stores the first offset
+	var maxLength uint32
+	var maxSelf uint64
+	var firstOffset, nextOffset uint64
+	for i, e := range entries {
+		length := hunkBytes
+		switch e.compression {
+		case compressionType0, compressionType1, compressionType2, compressionType3:
+			length = e.length
+			maxLength = max(maxLength, e.length)
+		case compressionNone:
+		case compressionSelf:
+			maxSelf = max(maxSelf, e.offset)
+			continue
+		default:
+			return nil, fmt.Errorf("unsupported compression type %d for map encoding", e.compression)
+		}
+
+		if nextOffset == 0 {
+			firstOffset = e.offset
+		} else if e.offset != nextOffset {
+			return nil, fmt.Errorf("hunk %d data at offset %d, want %d", i, e.offset, nextOffset)
+		}
+		nextOffset = e.offset + uint64(length)
+	}
+	lengthBits := bitsForValue(uint64(maxLength))
+	selfBits := bitsForValue(maxSelf)
+
+	// RLE-encode the compression types. The decoder starts with a previous
+	// type of 0, so runs of type 0 can use RLE from the start.
+	var symbols []uint32
+	var lastComp uint8
+	for i := 0; i < len(entries); {
+		comp := entries[i].compression
+		count := 1
+		for i+count < len(entries) && entries[i+count].compression == comp {
+			count++
+		}
+		i += count
+
+		if comp != lastComp {
+			symbols = append(symbols, uint32(comp))
+			lastComp = comp
+			count--
+		}
+		for count > 0 {
+			switch {
+			case count < 3:
+				symbols = append(symbols, uint32(comp))
+				count--
+			case count <= 3+15:
+				symbols = append(symbols, compressionRLESmall, uint32(count-3))
+				count = 0
+			default:
+				run := min(count, 3+16+255)
+				symbols = append(symbols, compressionRLELarge, uint32(run-3-16)>>4, uint32(run-3-16)&15)
+				count -= run
+			}
+		}
+	}
+
+	huffman := codec.NewHuffmanEncoder(16, 8)
+	for _, symbol := range symbols {
+		huffman.Count(symbol)
+	}
+	huffman.BuildTree()
+
+	var bw codec.BitWriter
+	huffman.ExportTreeRLE(&bw)
+	for _, symbol := range symbols {
+		huffman.Encode(&bw, symbol)
+	}
+
+	for _, e := range entries {
+		switch e.compression {
+		case compressionType0, compressionType1, compressionType2, compressionType3:
+			bw.WriteBits(e.length, uint32(lengthBits))
+			bw.WriteBits(uint32(e.crc16), 16)
+		case compressionNone:
+			bw.WriteBits(uint32(e.crc16), 16)
+		case compressionSelf:
+			bw.WriteBits(uint32(e.offset), uint32(selfBits))
+		}
+	}
+	compressed := bw.Bytes()
+
+	out := make([]byte, mapHeaderSize, mapHeaderSize+len(compressed))
+	binary.BigEndian.PutUint32(out[0:4], uint32(len(compressed)))
+	writeUint48BE(out[4:10], firstOffset)
+	binary.BigEndian.PutUint16(out[10:12], calculateMapCRC(entries))
+	out[12] = lengthBits
+	out[13] = selfBits
+	out[14] = 0 // parent bits
+	return append(out, compressed...), nil
+}
+
+// bitsForValue returns the number of bits needed to store value.
+func bitsForValue(value uint64) uint8 {
+	var bits uint8
+	for ; value != 0; value >>= 1 {
+		bits++
+	}
+	return bits
+}
+
+// writeUint48BE writes a 48-bit big-endian unsigned integer.
+func writeUint48BE(b []byte, v uint64) {
+	b[0] = byte(v >> 40)
+	b[1] = byte(v >> 32)
+	b[2] = byte(v >> 24)
+	b[3] = byte(v >> 16)
+	b[4] = byte(v >> 8)
+	b[5] = byte(v)
+}
@@ -0,0 +1,35 @@
+package chd
+
+// DefaultCacheBytes is the default memory budget for a Reader's cache of
+// decompressed hunks.
+const DefaultCacheBytes = 16 << 20
+
+// Option configures a Reader.
+type Option func(*readerOptions)
+
+// readerOptions holds the settings Options change.
+type readerOptions struct {
+	cacheBytes  int64
+	concurrency int
+}
+
+// WithCacheBytes sets the memory budget for the Reader's cache of
+// decompressed hunks. Hunks are evicted least recently used first. Reads
+// smaller than a hunk, like reading a sector at a time, decompress each hunk
+// once as long as it stays cached. A budget of 0 disables the cache.
+func WithCacheBytes(n int64) Option {
+	return func(o *readerOptions) {
+		o.cacheBytes = max(n, 0)
+	}
+}
+
+// WithConcurrency sets the number of hunks the Reader decompresses in
+// parallel, for reads that span several hunks, like verifying or extracting
+// a whole image with a large buffer. Reads within a hunk, and the default of
+// 1, decompress serially. Each extra hunk in flight holds a decompressed
+// hunk in memory, besides the cache.
+func WithConcurrency(n int) Option {
+	return func(o *readerOptions) {
+		o.concurrency = max(n, 1)
+	}
+}
@@ -0,0 +1,79 @@
+package chd
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// benchCHD returns a CHD of hunks of text with random runs, which compress,
+// but not trivially, and its data.
+func benchCHD(tb testing.TB, hunks int) ([]byte, []byte) {
+	tb.Helper()
+	const hunkBytes = 16384
+	rng := rand.New(rand.NewSource(1))
+	var data []byte
+	for len(data) < hunks*hunkBytes {
+		run := make([]byte, rng.Intn(64))
+		rng.Read(run)
+		data = append(data, run...)
+		data = append(data, "rom-tools reads CHD hunks in parallel. "...)
+	}
+	data = data[:hunks*hunkBytes]
+
+	file := &memFile{}
+	w, err := NewWriter(file, WriterConfig{HunkBytes: hunkBytes, UnitBytes: 2048, Compressors: []Codec{CodecLZMA, CodecZlib}})
+	if err != nil {
+		tb.Fatalf("NewWriter() error = %v", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		tb.Fatalf("Write() error = %v", err)
+	}
+	if _, err := w.Close(); err != nil {
+		tb.Fatalf("Close() error = %v", err)
+	}
+	return file.data, data
+}
+
+func TestNewReader_WithConcurrency(t *testing.T) {
+	file, data := benchCHD(t, 37)
+	for _, concurrency := range []int{1, 4, 64} {
+		r, err := NewReader(bytes.NewReader(file), int64(len(file)), WithConcurrency(concurrency), WithCacheBytes(0))
+		if err != nil {
+			t.Fatalf("NewReader() error = %v", err)
+		}
+
+		// Start and end mid-hunk, so the first and last hunks are partial
+		got := make([]byte, len(data)-1000)
+		if _, err := r.ReadAt(got, 500); err != nil {
+			t.Fatalf("WithConcurrency(%d): ReadAt() error = %v", concurrency, err)
+		}
+		if !bytes.Equal(got, data[500:len(data)-500]) {
+			t.Errorf("WithConcurrency(%d): ReadAt() data doesn't match the written data", concurrency)
+		}
+		if err := r.Verify(); err != nil {
+			t.Errorf("WithConcurrency(%d): Verify() error = %v", concurrency, err)
+		}
+	}
+}
+
+func BenchmarkReadAt_Concurrency(b *testing.B) {
+	file, data := benchCHD(b, 256)
+	for _, concurrency := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("concurrency=%d", concurrency), func(b *testing.B) {
+			// Without a cache, every read decompresses every hunk
+			r, err := NewReader(bytes.NewReader(file), int64(len(file)), WithConcurrency(concurrency), WithCacheBytes(0))
+			if err != nil {
+				b.Fatalf("NewReader() error = %v", err)
+			}
+			buf := make([]byte, len(data))
+			b.SetBytes(int64(len(data)))
+			for b.Loop() {
+				if _, err := r.ReadAt(buf, 0); err != nil {
+					b.Fatalf("ReadAt() error = %v", err)
+				}
+			}
+		})
+	}
+}
@@ -1,8 +1,13 @@
-// Package chd provides support for reading CHD (Compressed Hunks of Data) files.
+// Package chd provides support for reading and writing CHD (Compressed Hunks
+// of Data) files.
 // CHD is MAME's compressed disc image format.
 //
+// Versions 1 through 5 are supported. Legacy (v1-v4) headers and hunk maps are
+// normalized into the same Header and map representation used for v5.
+//
 // The API mirrors archive/zip: use NewReader to open a CHD, then access
-// individual tracks via the Tracks slice.
+// individual tracks via the Tracks slice. Use NewWriter to create a v5 CHD
+// from raw data, or WriteCD to create one from CD tracks.
 //
 // Format specification: https://github.com/mamedev/mame/blob/master/src/lib/util/chd.h
 package chd
@@ -10,6 +15,7 @@ package chd
 import (
 	"encoding/binary"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"sync"
@@ -37,6 +43,7 @@ import (
 //	104     20    Parent SHA1 (all zeros if no parent)
 const (
 	headerSize       = 124
+	headerPrefixSize = 16 // magic + length + version, common to all versions
 	rawSHA1Offset    = 64
 	sha1Offset       = 84
 	parentSHA1Offset = 104
@@ -58,20 +65,37 @@ const (
 	CodecCDLZMA Codec = 0x63646c7a // 'cdlz'
 	CodecCDFLAC Codec = 0x6364666c // 'cdfl'
 	CodecCDZstd Codec = 0x63647a73 // 'cdzs'
+	CodecAVHuff Codec = 0x61766875 // 'avhu'
 )
 
+// ErrParentRequired is returned when reading a hunk that is stored in a parent
+// CHD, but the Reader was opened without one. Use NewReaderWithParent to read
+// delta CHDs (e.g., MAME clone sets).
+var ErrParentRequired = errors.New("parent CHD required")
+
 // Header contains metadata extracted from a CHD file header.
 type Header struct {
 	Version      uint32
 	Compressors  [4]Codec
 	LogicalBytes uint64
 	MapOffset    uint64
+	MetaOffset   uint64
 	HunkBytes    uint32
 	UnitBytes    uint32
 	TotalHunks   uint32
-	RawSHA1      string
-	SHA1         string
+	RawSHA1      string // empty for v1-v2
+	SHA1         string // empty for v1-v2
 	ParentSHA1   string
+	MD5          string // v1-v3 only
+	ParentMD5    string // v1-v3 only
+
+	// legacyGeometry is the hard disk geometry from a v1-v2 header.
+	legacyGeometry *HardDiskInfo
+}
+
+// hasParent reports whether the CHD references a parent CHD.
+func (h *Header) hasParent() bool {
+	return h.ParentSHA1 != "" || h.ParentMD5 != ""
 }
 
 // Reader provides access to a CHD file's contents.
@@ -82,42 +106,94 @@ type Reader struct {
 	// For multi-track CDs (e.g., with audio tracks), iterate to find data tracks.
 	Tracks []*Track
 
+	// AV describes the video and audio of A/V CHDs, like LaserDisc captures.
+	// It's nil for other CHDs.
+	AV *AVInfo
+
+	// HardDisk is the geometry of hard disk CHDs, and nil for other CHDs.
+	HardDisk *HardDiskInfo
+
 	file      io.ReaderAt
+	parent    *Reader
 	header    *Header
 	hunkMap   *chdMap
-	hunkCache map[uint32][]byte
-	cacheMu   sync.RWMutex
+	hunkCache *hunkCache
+	// concurrency is the number of hunks decompressed in parallel.
+	concurrency int
 }
 
 // NewReader creates a Reader reading from r, which must be an io.ReaderAt.
 // This mirrors the archive/zip.NewReader pattern.
-func NewReader(r io.ReaderAt, size int64) (*Reader, error) {
+//
+// Delta CHDs (those with a ParentSHA1) can be opened, and their header and
+// track metadata inspected, but reading hunks stored in the parent fails with
+// ErrParentRequired. Use NewReaderWithParent to read their full contents.
+func NewReader(r io.ReaderAt, size int64, opts ...Option) (*Reader, error) {
+	return newReader(r, size, nil, opts)
+}
+
+// NewReaderWithParent creates a Reader for a delta CHD, resolving hunks that
+// reference the parent CHD against parent. The parent's SHA1 must match the
+// child's ParentSHA1.
+func NewReaderWithParent(r io.ReaderAt, size int64, parent *Reader, opts ...Option) (*Reader, error) {
+	if parent == nil {
+		return nil, fmt.Errorf("parent reader is nil")
+	}
+	return newReader(r, size, parent, opts)
+}
+
+func newReader(r io.ReaderAt, size int64, parent *Reader, opts []Option) (*Reader, error) {
+	options := readerOptions{cacheBytes: DefaultCacheBytes, concurrency: 1}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
 	header, err := parseHeader(r, size)
 	if err != nil {
 		return nil, fmt.Errorf("parse header: %w", err)
 	}
 
-	if header.ParentSHA1 != "" {
-		return nil, fmt.Errorf("parent CHD references not supported")
+	if parent != nil {
+		if !header.hasParent() {
+			return nil, fmt.Errorf("CHD has no parent, but a parent was given")
+		}
+		if header.ParentSHA1 != "" && parent.header.SHA1 != header.ParentSHA1 {
+			return nil, fmt.Errorf("parent SHA1 mismatch: got %s, want %s", parent.header.SHA1, header.ParentSHA1)
+		}
+		if header.ParentSHA1 == "" && parent.header.MD5 != header.ParentMD5 {
+			return nil, fmt.Errorf("parent MD5 mismatch: got %s, want %s", parent.header.MD5, header.ParentMD5)
+		}
 	}
 
-	hunkMap, err := decodeMap(r, header)
+	var hunkMap *chdMap
+	if header.Version < 5 {
+		hunkMap, err = decodeLegacyMap(r, size, header)
+	} else {
+		hunkMap, err = decodeMap(r, header)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("decode hunk map: %w", err)
 	}
 
 	reader := &Reader{
-		file:      r,
-		header:    header,
-		hunkMap:   hunkMap,
-		hunkCache: make(map[uint32][]byte),
+		file:        r,
+		parent:      parent,
+		header:      header,
+		hunkMap:     hunkMap,
+		hunkCache:   newHunkCache(options.cacheBytes),
+		concurrency: options.concurrency,
 	}
 
-	// Parse track metadata
-	reader.Tracks, err = parseTrackMetadata(r, header, reader)
-	if err != nil {
-		return nil, fmt.Errorf("parse tracks: %w", err)
+	var metadata []metadataEntry
+	if header.MetaOffset != 0 {
+		metadata, err = readMetadata(r, header.MetaOffset)
+		if err != nil {
+			return nil, fmt.Errorf("read metadata: %w", err)
+		}
 	}
+	reader.Tracks = parseTrackMetadata(metadata, reader)
+	reader.AV = parseAVMetadata(metadata, header)
+	reader.HardDisk = parseHardDiskMetadata(metadata, header)
 
 	return reader, nil
 }
@@ -142,47 +218,87 @@ func (r *Reader) ReadAt(p []byte, off int64) (n int, err error) {
 	}
 
 	hunkBytes := int64(r.header.HunkBytes)
-	remaining := len(p)
+	end := min(off+int64(len(p)), int64(r.header.LogicalBytes))
 	pos := off
 
-	for remaining > 0 && pos < int64(r.header.LogicalBytes) {
-		hunkNum := uint32(pos / hunkBytes)
-		hunkOffset := int(pos % hunkBytes)
+	for pos < end {
+		// Read up to concurrency hunks at a time
+		first := uint32(pos / hunkBytes)
+		last := uint32((end - 1) / hunkBytes)
+		hunks, err := r.readHunks(first, min(last-first+1, uint32(max(r.concurrency, 1))))
+
+		for _, hunkData := range hunks {
+			hunkOffset := int(pos % hunkBytes)
+			available := len(hunkData) - hunkOffset
+			if available <= 0 {
+				err = io.EOF
+				break
+			}
+			toCopy := min(int(end-pos), available)
+
+			copy(p[n:n+toCopy], hunkData[hunkOffset:hunkOffset+toCopy])
+			n += toCopy
+			pos += int64(toCopy)
+		}
 
-		hunkData, err := r.readHunk(hunkNum)
 		if err != nil {
 			if n > 0 {
 				return n, nil
 			}
-			return 0, fmt.Errorf("read hunk %d: %w", hunkNum, err)
-		}
-
-		available := len(hunkData) - hunkOffset
-		if available <= 0 {
-			break
+			return 0, err
 		}
-		toCopy := min(remaining, available)
-
-		copy(p[n:n+toCopy], hunkData[hunkOffset:hunkOffset+toCopy])
-		n += toCopy
-		remaining -= toCopy
-		pos += int64(toCopy)
 	}
 
-	if n == 0 && remaining > 0 {
+	if n == 0 && len(p) > 0 {
 		return 0, io.EOF
 	}
 	return n, nil
 }
 
+// readHunks reads and decompresses count hunks from first, in parallel if
+// the Reader was opened WithConcurrency. On error, it returns the hunks
+// before the one that failed.
+func (r *Reader) readHunks(first, count uint32) ([][]byte, error) {
+	hunks := make([][]byte, count)
+	errs := make([]error, count)
+
+	if workers := min(r.concurrency, int(count)); workers > 1 {
+		next := make(chan uint32, count)
+		for i := range count {
+			next <- i
+		}
+		close(next)
+
+		var wg sync.WaitGroup
+		for range workers {
+			wg.Go(func() {
+				for i := range next {
+					hunks[i], errs[i] = r.readHunk(first + i)
+				}
+			})
+		}
+		wg.Wait()
+	} else {
+		for i := range count {
+			if hunks[i], errs[i] = r.readHunk(first + i); errs[i] != nil {
+				break
+			}
+		}
+	}
+
+	for i, err := range errs {
+		if err != nil {
+			return hunks[:i], fmt.Errorf("read hunk %d: %w", first+uint32(i), err)
+		}
+	}
+	return hunks, nil
+}
+
 // readHunk reads and decompresses a single hunk.
 func (r *Reader) readHunk(hunkNum uint32) ([]byte, error) {
-	r.cacheMu.RLock()
-	if cached, ok := r.hunkCache[hunkNum]; ok {
-		r.cacheMu.RUnlock()
+	if cached, ok := r.hunkCache.get(hunkNum); ok {
 		return cached, nil
 	}
-	r.cacheMu.RUnlock()
 
 	if int(hunkNum) >= len(r.hunkMap.entries) {
 		return nil, fmt.Errorf("hunk %d out of range (total: %d)", hunkNum, len(r.hunkMap.entries))
@@ -216,6 +332,13 @@ func (r *Reader) readHunk(hunkNum uint32) ([]byte, error) {
 			return nil, fmt.Errorf("decompress hunk (codec 0x%08x): %w", codecID, err)
 		}
 
+	case compressionMini:
+		// The 8-byte offset value is repeated to fill the hunk
+		data = make([]byte, hunkBytes)
+		for i := 0; i+8 <= len(data); i += 8 {
+			binary.BigEndian.PutUint64(data[i:], entry.offset)
+		}
+
 	case compressionSelf:
 		refHunk := uint32(entry.offset)
 		if refHunk >= hunkNum {
@@ -228,18 +351,22 @@ func (r *Reader) readHunk(hunkNum uint32) ([]byte, error) {
 		data = append([]byte(nil), data...)
 
 	case compressionParent:
-		return nil, fmt.Errorf("parent CHD references not supported")
+		// Parent references are stored in units of the parent's unit size
+		if r.parent == nil {
+			return nil, ErrParentRequired
+		}
+		data = make([]byte, hunkBytes)
+		off := int64(entry.offset) * int64(r.parent.header.UnitBytes)
+		n, err := r.parent.ReadAt(data, off)
+		if err != nil && !(err == io.EOF && n > 0) {
+			return nil, fmt.Errorf("read parent data at offset %d: %w", off, err)
+		}
 
 	default:
 		return nil, fmt.Errorf("unknown compression type: %d", entry.compression)
 	}
 
-	r.cacheMu.Lock()
-	if len(r.hunkCache) < 32 {
-		r.hunkCache[hunkNum] = data
-	}
-	r.cacheMu.Unlock()
-
+	r.hunkCache.put(hunkNum, data)
 	return data, nil
 }
 
@@ -258,29 +385,38 @@ func (r *Reader) readSector(sectorNum uint64) ([]byte, error) {
 
 // parseHeader reads and parses a CHD file header.
 func parseHeader(r io.ReaderAt, size int64) (*Header, error) {
-	if size < headerSize {
-		return nil, fmt.Errorf("file too small for CHD header: need %d bytes, got %d", headerSize, size)
+	if size < headerPrefixSize {
+		return nil, fmt.Errorf("file too small for CHD header: need %d bytes, got %d", headerPrefixSize, size)
 	}
 
-	buf := make([]byte, headerSize)
-	if _, err := r.ReadAt(buf, 0); err != nil {
+	prefix := make([]byte, headerPrefixSize)
+	if _, err := r.ReadAt(prefix, 0); err != nil {
 		return nil, fmt.Errorf("failed to read CHD header: %w", err)
 	}
 
-	if string(buf[0:8]) != "MComprHD" {
+	if string(prefix[0:8]) != "MComprHD" {
 		return nil, fmt.Errorf("not a valid CHD file: invalid magic")
 	}
 
-	headerLen := binary.BigEndian.Uint32(buf[8:12])
-	version := binary.BigEndian.Uint32(buf[12:16])
+	headerLen := binary.BigEndian.Uint32(prefix[8:12])
+	version := binary.BigEndian.Uint32(prefix[12:16])
 
 	if version < 5 {
-		return nil, fmt.Errorf("CHD version %d not supported (only v5+ supported)", version)
+		return parseLegacyHeader(r, size, version, headerLen)
+	}
+
+	if size < headerSize {
+		return nil, fmt.Errorf("file too small for CHD header: need %d bytes, got %d", headerSize, size)
 	}
 	if headerLen < headerSize {
 		return nil, fmt.Errorf("CHD header too small: %d bytes", headerLen)
 	}
 
+	buf := make([]byte, headerSize)
+	if _, err := r.ReadAt(buf, 0); err != nil {
+		return nil, fmt.Errorf("failed to read CHD header: %w", err)
+	}
+
 	var compressors [4]Codec
 	for i := range 4 {
 		compressors[i] = Codec(binary.BigEndian.Uint32(buf[16+i*4:]))
@@ -288,6 +424,7 @@ func parseHeader(r io.ReaderAt, size int64) (*Header, error) {
 
 	logicalBytes := binary.BigEndian.Uint64(buf[32:40])
 	mapOffset := binary.BigEndian.Uint64(buf[40:48])
+	metaOffset := binary.BigEndian.Uint64(buf[48:56])
 	hunkBytes := binary.BigEndian.Uint32(buf[56:60])
 	unitBytes := binary.BigEndian.Uint32(buf[60:64])
 
@@ -296,32 +433,31 @@ func parseHeader(r io.ReaderAt, size int64) (*Header, error) {
 		totalHunks = uint32((logicalBytes + uint64(hunkBytes) - 1) / uint64(hunkBytes))
 	}
 
-	rawSHA1 := hex.EncodeToString(buf[rawSHA1Offset : rawSHA1Offset+sha1Size])
-	sha1 := hex.EncodeToString(buf[sha1Offset : sha1Offset+sha1Size])
-
-	parentSHA1Bytes := buf[parentSHA1Offset : parentSHA1Offset+sha1Size]
-	parentSHA1 := ""
-	for _, b := range parentSHA1Bytes {
-		if b != 0 {
-			parentSHA1 = hex.EncodeToString(parentSHA1Bytes)
-			break
-		}
-	}
-
 	return &Header{
 		Version:      version,
 		Compressors:  compressors,
 		LogicalBytes: logicalBytes,
 		MapOffset:    mapOffset,
+		MetaOffset:   metaOffset,
 		HunkBytes:    hunkBytes,
 		UnitBytes:    unitBytes,
 		TotalHunks:   totalHunks,
-		RawSHA1:      rawSHA1,
-		SHA1:         sha1,
-		ParentSHA1:   parentSHA1,
+		RawSHA1:      hex.EncodeToString(buf[rawSHA1Offset : rawSHA1Offset+sha1Size]),
+		SHA1:         hex.EncodeToString(buf[sha1Offset : sha1Offset+sha1Size]),
+		ParentSHA1:   hexIfNonZero(buf[parentSHA1Offset : parentSHA1Offset+sha1Size]),
 	}, nil
 }
 
+// hexIfNonZero hex-encodes b, or returns "" if b is all zeros.
+func hexIfNonZero(b []byte) string {
+	for _, v := range b {
+		if v != 0 {
+			return hex.EncodeToString(b)
+		}
+	}
+	return ""
+}
+
 // decompressHunk decompresses a single hunk using the appropriate codec.
 func decompressHunk(compressedData []byte, codecID Codec, hunkBytes uint32) ([]byte, error) {
 	size := int(hunkBytes)
@@ -358,6 +494,9 @@ func decompressHunk(compressedData []byte, codecID Codec, hunkBytes uint32) ([]b
 		// FLAC is for audio tracks - we don't need to decompress audio for identification
 		return nil, fmt.Errorf("FLAC codec not supported (audio only)")
 
+	case CodecAVHuff:
+		return nil, fmt.Errorf("AVHuff codec not supported (A/V only)")
+
 	default:
 		return nil, fmt.Errorf("unknown codec: 0x%08x", codecID)
 	}
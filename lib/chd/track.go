@@ -6,8 +6,6 @@ import (
 	"io"
 	"strconv"
 	"strings"
-
-	"github.com/sargunv/rom-tools/internal/util"
 )
 
 // rawSectorSize is the size of a raw CD sector (2352 bytes).
@@ -15,14 +13,26 @@ const rawSectorSize = 2352
 
 // Track represents a single track in the CHD (like zip.File).
 type Track struct {
-	Number int    // Track number (1-based)
-	Frames int    // Number of frames in the track
-	Pregap int    // Pregap frames
-	Type   string // Raw type string: "AUDIO", "MODE1_RAW", "MODE2_RAW", etc.
+	Number  int    // Track number (1-based)
+	Frames  int    // Number of frames in the track
+	Pregap  int    // Pregap frames
+	Postgap int    // Postgap frames (not stored)
+	Type    string // Raw type string: "AUDIO", "MODE1_RAW", "MODE2_RAW", etc.
+	LBA     int64  // Disc position of the track's data, after its pregap
+
+	// HighDensity is true for tracks in a GD-ROM's high-density area (track 3
+	// onwards), which starts at LBA 45000. Tracks 1 and 2 are in the
+	// single-density area, readable by CD drives.
+	HighDensity bool
 
 	// unexported
 	reader     *Reader
 	startFrame int64
+	// storedPregap is the number of pregap frames stored in the CHD before
+	// the track data, which are included in Frames (PGTYPE starting with V).
+	storedPregap int
+	// padFrames is the number of padding frames stored after the track.
+	padFrames int
 }
 
 // Open returns a reader for this track's raw sector data (2352 bytes/sector).
@@ -30,13 +40,13 @@ func (t *Track) Open() io.ReaderAt {
 	return &trackReader{
 		reader:     t.reader,
 		track:      t,
-		numSectors: int64(t.Frames),
+		numSectors: int64(t.Frames - t.storedPregap),
 	}
 }
 
-// Size returns the track size in bytes (Frames * 2352).
+// Size returns the track size in bytes, excluding any pregap stored with it.
 func (t *Track) Size() int64 {
-	return int64(t.Frames) * rawSectorSize
+	return int64(t.Frames-t.storedPregap) * rawSectorSize
 }
 
 // trackReader provides access to a track's raw sector data within a CHD file.
@@ -63,8 +73,8 @@ func (tr *trackReader) ReadAt(p []byte, off int64) (int, error) {
 			return 0, io.EOF
 		}
 
-		// Calculate actual sector number in the CHD (skip pregap)
-		actualSector := uint64(tr.track.startFrame + int64(tr.track.Pregap) + sector)
+		// Calculate actual sector number in the CHD (skip stored pregap)
+		actualSector := uint64(tr.track.startFrame + int64(tr.track.storedPregap) + sector)
 
 		// Read the physical sector from CHD
 		sectorData, err := tr.reader.readSector(actualSector)
@@ -87,6 +97,12 @@ func (tr *trackReader) ReadAt(p []byte, off int64) (int, error) {
 	return n, nil
 }
 
+// gdHighDensityLBA is where a GD-ROM's high-density area starts.
+const gdHighDensityLBA = 45000
+
+// gdHighDensityTrack is the number of a GD-ROM's first high-density track.
+const gdHighDensityTrack = 3
+
 // MetadataTag represents a 4-character CHD metadata tag.
 type MetadataTag string
 
@@ -106,23 +122,15 @@ const (
 	TagAVLaserdisc   MetadataTag = "AVLD" // A/V laserdisc frame metadata
 )
 
-// parseTrackMetadata reads metadata and extracts track information.
-func parseTrackMetadata(r io.ReaderAt, header *Header, reader *Reader) ([]*Track, error) {
-	metaOffset := binary.BigEndian.Uint64(make([]byte, 8))
-
-	// Read metadata offset from header (bytes 48-55)
-	buf := make([]byte, 8)
-	if _, err := r.ReadAt(buf, 48); err != nil {
-		return nil, fmt.Errorf("read metadata offset: %w", err)
-	}
-	metaOffset = binary.BigEndian.Uint64(buf)
-
-	if metaOffset == 0 {
-		return nil, nil // No metadata
-	}
+// metadataEntry is a single raw entry from the CHD metadata list.
+type metadataEntry struct {
+	tag  MetadataTag
+	data []byte
+}
 
-	var tracks []*Track
-	offset := metaOffset
+// readMetadata reads the linked list of metadata entries starting at offset.
+func readMetadata(r io.ReaderAt, offset uint64) ([]metadataEntry, error) {
+	var entries []metadataEntry
 
 	for offset != 0 {
 		// Read metadata entry header (16 bytes):
@@ -134,7 +142,7 @@ func parseTrackMetadata(r io.ReaderAt, header *Header, reader *Reader) ([]*Track
 			return nil, fmt.Errorf("read metadata header at offset %d: %w", offset, err)
 		}
 
-		tag := MetadataTag(util.ExtractASCII(entryHeader[0:4]))
+		tag := MetadataTag(entryHeader[0:4])
 		lengthFlags := binary.BigEndian.Uint32(entryHeader[4:8])
 		length := lengthFlags & 0x00FFFFFF // Lower 24 bits
 		nextOffset := binary.BigEndian.Uint64(entryHeader[8:16])
@@ -147,22 +155,105 @@ func parseTrackMetadata(r io.ReaderAt, header *Header, reader *Reader) ([]*Track
 			}
 		}
 
-		// Parse track metadata (CHTR, CHT2, CHGD all use same format)
-		if tag == TagCDROM || tag == TagCDROM2 || tag == TagGDROM {
-			if track, err := parseTrackMetadataEntry(data); err == nil {
-				track.reader = reader
+		entries = append(entries, metadataEntry{tag: tag, data: data})
+		offset = nextOffset
+	}
+
+	return entries, nil
+}
+
+// parseTrackMetadata extracts track information from metadata entries.
+func parseTrackMetadata(entries []metadataEntry, reader *Reader) []*Track {
+	var tracks []*Track
+	for _, entry := range entries {
+		switch entry.tag {
+		case TagCDROM, TagCDROM2, TagGDROM:
+			// CHTR, CHT2, CHGD all use the same text format
+			if track, err := parseTrackMetadataEntry(entry.data); err == nil {
+				track.HighDensity = entry.tag == TagGDROM && track.Number >= gdHighDensityTrack
 				tracks = append(tracks, track)
 			}
+		case TagCDROMOld:
+			// Legacy binary table of contents (v3 CHDs)
+			if oldTracks, err := parseOldTrackMetadata(entry.data); err == nil {
+				tracks = append(tracks, oldTracks...)
+			}
 		}
-
-		offset = nextOffset
 	}
 
-	// Calculate start frames for each track
-	var currentFrame int64
-	for _, track := range tracks {
+	// Calculate start frames for each track, and their positions on the
+	// disc. Unstored pregaps and postgaps take up disc positions, but not
+	// frames, except the first track's pregap, which comes before LBA 0. A
+	// GD-ROM's high-density area starts at a fixed position, after the
+	// lead-out of the single-density area.
+	var currentFrame, lba int64
+	for i, track := range tracks {
+		track.reader = reader
 		track.startFrame = currentFrame
-		currentFrame += int64(track.Pregap + track.Frames)
+		currentFrame += int64(track.Frames + track.padFrames)
+
+		if track.HighDensity && (i == 0 || !tracks[i-1].HighDensity) {
+			lba = gdHighDensityLBA
+		} else if i > 0 {
+			lba += int64(track.Pregap - track.storedPregap)
+		}
+		track.LBA = lba
+		lba += int64(track.Frames-track.storedPregap) + int64(track.Postgap)
+	}
+
+	return tracks
+}
+
+// HighDensityDataTrack returns the first data track in a GD-ROM's
+// high-density area, or nil if the CHD isn't a GD-ROM. It holds the disc's
+// IP.BIN boot header and filesystem; the single-density data track only
+// holds a warning for CD players.
+func (r *Reader) HighDensityDataTrack() *Track {
+	for _, track := range r.Tracks {
+		if track.HighDensity && track.Type != "AUDIO" {
+			return track
+		}
+	}
+	return nil
+}
+
+// oldTrackTypes maps legacy CHCD track type codes to their text names.
+var oldTrackTypes = []string{
+	"MODE1", "MODE1_RAW", "MODE2", "MODE2_FORM1", "MODE2_FORM2", "MODE2_FORM_MIX", "MODE2_RAW", "AUDIO",
+}
+
+// parseOldTrackMetadata parses the legacy binary CHCD table of contents:
+// a big-endian uint32 track count followed by up to 99 track records of six
+// uint32 fields (type, subtype, data size, subcode size, frames, extra frames).
+func parseOldTrackMetadata(data []byte) ([]*Track, error) {
+	const trackRecordSize = 24
+
+	if len(data) < 4 {
+		return nil, fmt.Errorf("CHCD metadata too short")
+	}
+	numTracks := int(binary.BigEndian.Uint32(data[0:4]))
+	if numTracks > 99 || len(data) < 4+numTracks*trackRecordSize {
+		return nil, fmt.Errorf("invalid CHCD track count: %d", numTracks)
+	}
+
+	tracks := make([]*Track, 0, numTracks)
+	for i := range numTracks {
+		rec := data[4+i*trackRecordSize:]
+		trackType := int(binary.BigEndian.Uint32(rec[0:4]))
+		frames := int(binary.BigEndian.Uint32(rec[16:20]))
+		extraFrames := int(binary.BigEndian.Uint32(rec[20:24]))
+
+		typeName := "UNKNOWN"
+		if trackType < len(oldTrackTypes) {
+			typeName = oldTrackTypes[trackType]
+		}
+
+		tracks = append(tracks, &Track{
+			Number:    i + 1,
+			Type:      typeName,
+			Frames:    frames,
+			padFrames: extraFrames,
+		})
 	}
 
 	return tracks, nil
@@ -188,6 +279,20 @@ func parseTrackMetadataEntry(data []byte) (*Track, error) {
 	if v, ok := fields["PREGAP"]; ok {
 		track.Pregap, _ = strconv.Atoi(v)
 	}
+	if v, ok := fields["POSTGAP"]; ok {
+		track.Postgap, _ = strconv.Atoi(v)
+	}
+	// A PGTYPE starting with V means the pregap is stored with the track
+	if strings.HasPrefix(fields["PGTYPE"], "V") {
+		track.storedPregap = min(track.Pregap, track.Frames)
+	}
+	// Track frames are stored padded to a multiple of cdTrackPadding. CHGD
+	// metadata gives the padding explicitly.
+	if v, ok := fields["PAD"]; ok {
+		track.padFrames, _ = strconv.Atoi(v)
+	} else {
+		track.padFrames = (cdTrackPadding - track.Frames%cdTrackPadding) % cdTrackPadding
+	}
 
 	if track.Number == 0 {
 		return nil, fmt.Errorf("invalid track metadata")
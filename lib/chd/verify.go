@@ -0,0 +1,109 @@
+package chd
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+)
+
+// ErrCorrupt is returned by Verify when a CHD's data doesn't match its
+// checksums.
+var ErrCorrupt = errors.New("CHD data is corrupt")
+
+// Verify checks the CHD's data against its checksums, like chdman verify.
+// Every hunk is read and decompressed, each hunk's map CRC (CRC16 for v5,
+// CRC32 for v3/v4) is checked, and the raw SHA1 (or MD5, for v1-v2) of the
+// logical data is recomputed and compared to the header.
+//
+// Mismatches are reported as errors wrapping ErrCorrupt. Delta CHDs need to be
+// opened with NewReaderWithParent to be verified. Open the Reader
+// WithConcurrency to decompress hunks in parallel.
+func (r *Reader) Verify() error {
+	return r.VerifyContext(context.Background())
+}
+
+// VerifyContext is like Verify, but stops and returns the context's error
+// once ctx is done.
+func (r *Reader) VerifyContext(ctx context.Context) error {
+	var sha1Hash, md5Hash hash.Hash
+	if r.header.RawSHA1 != "" {
+		sha1Hash = sha1.New()
+	}
+	if r.header.MD5 != "" {
+		md5Hash = md5.New()
+	}
+
+	// Hunks are decompressed in batches, in parallel WithConcurrency
+	remaining := r.header.LogicalBytes
+	total := uint32(len(r.hunkMap.entries))
+	batch := uint32(max(r.concurrency, 1))
+	for first := uint32(0); first < total; first += batch {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		hunks, err := r.readHunks(first, min(batch, total-first))
+		if err != nil {
+			return err
+		}
+
+		for i, data := range hunks {
+			hunkNum := first + uint32(i)
+			if err := checkHunkCRC(r.hunkMap.entries[hunkNum], data, r.header.Version); err != nil {
+				return fmt.Errorf("%w: hunk %d: %w", ErrCorrupt, hunkNum, err)
+			}
+
+			// The last hunk is padded past the logical size
+			data = data[:min(uint64(len(data)), remaining)]
+			remaining -= uint64(len(data))
+			if sha1Hash != nil {
+				sha1Hash.Write(data)
+			}
+			if md5Hash != nil {
+				md5Hash.Write(data)
+			}
+		}
+	}
+	if remaining > 0 {
+		return fmt.Errorf("%w: hunks hold %d bytes less than the logical size", ErrCorrupt, remaining)
+	}
+
+	if sha1Hash != nil {
+		if got := hex.EncodeToString(sha1Hash.Sum(nil)); got != r.header.RawSHA1 {
+			return fmt.Errorf("%w: raw SHA1 mismatch: got %s, want %s", ErrCorrupt, got, r.header.RawSHA1)
+		}
+	}
+	if md5Hash != nil {
+		if got := hex.EncodeToString(md5Hash.Sum(nil)); got != r.header.MD5 {
+			return fmt.Errorf("%w: MD5 mismatch: got %s, want %s", ErrCorrupt, got, r.header.MD5)
+		}
+	}
+	return nil
+}
+
+// checkHunkCRC checks a hunk's decompressed data against its map entry's CRC.
+// References to other hunks and the parent have no CRC of their own, and
+// neither do v1-v2 hunks.
+func checkHunkCRC(entry mapEntry, data []byte, version uint32) error {
+	switch entry.compression {
+	case compressionType0, compressionType1, compressionType2, compressionType3, compressionNone:
+	default:
+		return nil
+	}
+
+	switch {
+	case version >= 5:
+		if got := crc16(data); got != entry.crc16 {
+			return fmt.Errorf("CRC16 mismatch: got %04x, want %04x", got, entry.crc16)
+		}
+	case entry.hasCRC32:
+		if got := crc32.ChecksumIEEE(data); got != entry.crc32 {
+			return fmt.Errorf("CRC32 mismatch: got %08x, want %08x", got, entry.crc32)
+		}
+	}
+	return nil
+}
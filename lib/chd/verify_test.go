@@ -0,0 +1,80 @@
+package chd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestVerify(t *testing.T) {
+	const hunkBytes = 4096
+	file := &memFile{}
+	w, err := NewWriter(file, WriterConfig{HunkBytes: hunkBytes, UnitBytes: 2048, Compressors: []Codec{CodecZlib}})
+	if err != nil {
+		t.Fatalf("NewWriter() error = %v", err)
+	}
+	if _, err := w.Write(testImage(hunkBytes)); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if _, err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	open := func(t *testing.T, data []byte) *Reader {
+		t.Helper()
+		r, err := NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			t.Fatalf("NewReader() error = %v", err)
+		}
+		return r
+	}
+
+	if err := open(t, file.data).Verify(); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+
+	// The random hunk doesn't compress, so it's stored as is, and a flipped
+	// bit decompresses fine but fails the hunk CRC
+	r := open(t, file.data)
+	entry := r.hunkMap.entries[1]
+	if entry.compression != compressionNone {
+		t.Fatalf("hunk 1 compression = %d, want uncompressed", entry.compression)
+	}
+	corrupt := bytes.Clone(file.data)
+	corrupt[entry.offset+100] ^= 0x01
+	if err := open(t, corrupt).Verify(); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("Verify() with a corrupt hunk error = %v, want ErrCorrupt", err)
+	}
+
+	// A wrong header SHA1 fails even though every hunk is intact
+	corrupt = bytes.Clone(file.data)
+	corrupt[rawSHA1Offset] ^= 0x01
+	if err := open(t, corrupt).Verify(); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("Verify() with a wrong raw SHA1 error = %v, want ErrCorrupt", err)
+	}
+}
+
+func TestVerifyContext_Cancelled(t *testing.T) {
+	file := &memFile{}
+	w, err := NewWriter(file, WriterConfig{HunkBytes: 4096, UnitBytes: 2048, Compressors: []Codec{CodecZlib}})
+	if err != nil {
+		t.Fatalf("NewWriter() error = %v", err)
+	}
+	if _, err := w.Write(testImage(4096)); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if _, err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	r, err := NewReader(bytes.NewReader(file.data), int64(len(file.data)))
+	if err != nil {
+		t.Fatalf("NewReader() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := r.VerifyContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("VerifyContext() error = %v, want context.Canceled", err)
+	}
+}
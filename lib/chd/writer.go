@@ -0,0 +1,320 @@
+package chd
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"slices"
+
+	"github.com/sargunv/rom-tools/lib/chd/internal/codec"
+)
+
+// Default hunk sizes, matching chdman.
+const (
+	DefaultHunkBytes   = 4096
+	DefaultCDHunkBytes = CDFramesPerHunk * CDFrameSize
+)
+
+// CD frame layout in CHD files: each frame is a raw sector followed by its
+// subcode, and tracks are padded to a multiple of cdTrackPadding frames.
+const (
+	CDFrameSize     = rawSectorSize + cdSubcodeSize
+	CDFramesPerHunk = 8
+	cdSubcodeSize   = 96
+	cdTrackPadding  = 4
+)
+
+// metadataFlagChecksum marks metadata included in the overall SHA1.
+const metadataFlagChecksum = 0x01
+
+// WriterConfig configures a Writer.
+type WriterConfig struct {
+	// HunkBytes is the size of each independently compressed block.
+	// Defaults to DefaultHunkBytes.
+	HunkBytes uint32
+	// UnitBytes is the size of the smallest addressable unit (e.g., a CD
+	// frame). Defaults to HunkBytes.
+	UnitBytes uint32
+	// Compressors are the codecs to try on each hunk, keeping the smallest
+	// result. At most four; CodecZlib, CodecLZMA, CodecZstd, CodecCDZlib,
+	// CodecCDLZMA, and CodecCDZstd are supported. CD codecs require HunkBytes
+	// to be a multiple of CDFrameSize. With none, hunks are stored
+	// uncompressed.
+	Compressors []Codec
+}
+
+// Writer compresses raw data into a v5 CHD file. Data written is split into
+// hunks; identical hunks are stored once. Close writes the hunk map,
+// metadata, and header, so it must be called to produce a valid file.
+type Writer struct {
+	w         io.WriterAt
+	config    WriterConfig
+	offset    int64
+	hunk      []byte
+	entries   []mapEntry
+	hunkIndex map[[sha1.Size]byte]uint32
+	rawSHA1   hash.Hash
+	logical   uint64
+	metadata  []metadataEntry
+	header    *Header
+}
+
+// NewWriter creates a Writer writing a CHD to w.
+func NewWriter(w io.WriterAt, config WriterConfig) (*Writer, error) {
+	if config.HunkBytes == 0 {
+		config.HunkBytes = DefaultHunkBytes
+	}
+	if config.UnitBytes == 0 {
+		config.UnitBytes = config.HunkBytes
+	}
+	if config.HunkBytes%config.UnitBytes != 0 {
+		return nil, fmt.Errorf("hunk size %d is not a multiple of unit size %d", config.HunkBytes, config.UnitBytes)
+	}
+	if len(config.Compressors) > 4 {
+		return nil, fmt.Errorf("too many compressors: %d (max 4)", len(config.Compressors))
+	}
+	for _, c := range config.Compressors {
+		if _, err := compressorFor(c); err != nil {
+			return nil, err
+		}
+		if isCDCodec(c) && config.HunkBytes%CDFrameSize != 0 {
+			return nil, fmt.Errorf("codec 0x%08x requires hunk size to be a multiple of %d", c, CDFrameSize)
+		}
+	}
+
+	return &Writer{
+		w:         w,
+		config:    config,
+		offset:    headerSize,
+		hunk:      make([]byte, 0, config.HunkBytes),
+		hunkIndex: make(map[[sha1.Size]byte]uint32),
+		rawSHA1:   sha1.New(),
+	}, nil
+}
+
+// Write compresses p into the CHD. It implements io.Writer.
+func (cw *Writer) Write(p []byte) (int, error) {
+	if cw.header != nil {
+		return 0, fmt.Errorf("write to closed CHD writer")
+	}
+
+	n := 0
+	for n < len(p) {
+		copied := min(len(p)-n, cap(cw.hunk)-len(cw.hunk))
+		cw.hunk = append(cw.hunk, p[n:n+copied]...)
+		n += copied
+		if len(cw.hunk) == cap(cw.hunk) {
+			if err := cw.flushHunk(len(cw.hunk)); err != nil {
+				return n, err
+			}
+		}
+	}
+	return n, nil
+}
+
+// AddMetadata adds a metadata entry, such as track information. Entries are
+// included in the overall SHA1.
+func (cw *Writer) AddMetadata(tag MetadataTag, data []byte) error {
+	if len(tag) != 4 {
+		return fmt.Errorf("invalid metadata tag %q", tag)
+	}
+	if len(data) > 0x00FFFFFF {
+		return fmt.Errorf("metadata too large: %d bytes", len(data))
+	}
+	cw.metadata = append(cw.metadata, metadataEntry{tag: tag, data: bytes.Clone(data)})
+	return nil
+}
+
+// Close pads and writes the final hunk, then the hunk map, metadata, and
+// header. The returned header has the computed SHA1s.
+func (cw *Writer) Close() (*Header, error) {
+	if cw.header != nil {
+		return cw.header, nil
+	}
+
+	if logical := len(cw.hunk); logical > 0 {
+		cw.hunk = append(cw.hunk, make([]byte, cap(cw.hunk)-logical)...)
+		if err := cw.flushHunk(logical); err != nil {
+			return nil, err
+		}
+	}
+
+	var mapOffset uint64
+	if len(cw.entries) > 0 {
+		mapData, err := encodeMap(cw.entries, cw.config.HunkBytes)
+		if err != nil {
+			return nil, fmt.Errorf("encode hunk map: %w", err)
+		}
+		mapOffset = uint64(cw.offset)
+		if err := cw.writeData(mapData); err != nil {
+			return nil, fmt.Errorf("write hunk map: %w", err)
+		}
+	}
+
+	metaOffset, err := cw.writeMetadata()
+	if err != nil {
+		return nil, fmt.Errorf("write metadata: %w", err)
+	}
+
+	rawSHA1 := cw.rawSHA1.Sum(nil)
+	var compressors [4]Codec
+	copy(compressors[:], cw.config.Compressors)
+	header := &Header{
+		Version:      5,
+		Compressors:  compressors,
+		LogicalBytes: cw.logical,
+		MapOffset:    mapOffset,
+		MetaOffset:   metaOffset,
+		HunkBytes:    cw.config.HunkBytes,
+		UnitBytes:    cw.config.UnitBytes,
+		TotalHunks:   uint32(len(cw.entries)),
+		RawSHA1:      hex.EncodeToString(rawSHA1),
+		SHA1:         hex.EncodeToString(cw.overallSHA1(rawSHA1)),
+	}
+
+	buf := make([]byte, headerSize)
+	copy(buf, "MComprHD")
+	binary.BigEndian.PutUint32(buf[8:], headerSize)
+	binary.BigEndian.PutUint32(buf[12:], header.Version)
+	for i, c := range header.Compressors {
+		binary.BigEndian.PutUint32(buf[16+i*4:], uint32(c))
+	}
+	binary.BigEndian.PutUint64(buf[32:], header.LogicalBytes)
+	binary.BigEndian.PutUint64(buf[40:], header.MapOffset)
+	binary.BigEndian.PutUint64(buf[48:], header.MetaOffset)
+	binary.BigEndian.PutUint32(buf[56:], header.HunkBytes)
+	binary.BigEndian.PutUint32(buf[60:], header.UnitBytes)
+	copy(buf[rawSHA1Offset:], rawSHA1)
+	copy(buf[sha1Offset:], cw.overallSHA1(rawSHA1))
+	if _, err := cw.w.WriteAt(buf, 0); err != nil {
+		return nil, fmt.Errorf("write header: %w", err)
+	}
+
+	cw.header = header
+	return header, nil
+}
+
+// flushHunk compresses and writes the buffered hunk, of which the first
+// logical bytes are data. Only the last hunk is padded, with zeros.
+func (cw *Writer) flushHunk(logical int) error {
+	data := cw.hunk
+	cw.rawSHA1.Write(data[:logical])
+	cw.logical += uint64(logical)
+	cw.hunk = cw.hunk[:0]
+
+	hunkNum := uint32(len(cw.entries))
+	sum := sha1.Sum(data)
+	if ref, ok := cw.hunkIndex[sum]; ok {
+		cw.entries = append(cw.entries, mapEntry{compression: compressionSelf, offset: uint64(ref)})
+		return nil
+	}
+	cw.hunkIndex[sum] = hunkNum
+
+	entry := mapEntry{
+		compression: compressionNone,
+		length:      cw.config.HunkBytes,
+		offset:      uint64(cw.offset),
+		crc16:       crc16(data),
+	}
+	stored := data
+	for i, c := range cw.config.Compressors {
+		compress, _ := compressorFor(c)
+		compressed, err := compress(data)
+		if err != nil {
+			return fmt.Errorf("compress hunk %d (codec 0x%08x): %w", hunkNum, c, err)
+		}
+		if len(compressed) < len(stored) {
+			stored = compressed
+			entry.compression = uint8(compressionType0 + i)
+			entry.length = uint32(len(compressed))
+		}
+	}
+
+	if err := cw.writeData(stored); err != nil {
+		return fmt.Errorf("write hunk %d: %w", hunkNum, err)
+	}
+	cw.entries = append(cw.entries, entry)
+	return nil
+}
+
+// writeData appends data to the file.
+func (cw *Writer) writeData(data []byte) error {
+	if _, err := cw.w.WriteAt(data, cw.offset); err != nil {
+		return err
+	}
+	cw.offset += int64(len(data))
+	return nil
+}
+
+// writeMetadata writes the metadata entries as a linked list, returning the
+// offset of the first.
+func (cw *Writer) writeMetadata() (uint64, error) {
+	if len(cw.metadata) == 0 {
+		return 0, nil
+	}
+
+	first := uint64(cw.offset)
+	for i, entry := range cw.metadata {
+		var next uint64
+		if i+1 < len(cw.metadata) {
+			next = uint64(cw.offset) + 16 + uint64(len(entry.data))
+		}
+		buf := make([]byte, 16, 16+len(entry.data))
+		copy(buf[0:4], entry.tag)
+		binary.BigEndian.PutUint32(buf[4:8], metadataFlagChecksum<<24|uint32(len(entry.data)))
+		binary.BigEndian.PutUint64(buf[8:16], next)
+		if err := cw.writeData(append(buf, entry.data...)); err != nil {
+			return 0, err
+		}
+	}
+	return first, nil
+}
+
+// overallSHA1 computes the header SHA1, which covers the raw data SHA1 and
+// the checksummed metadata: the SHA1 of the raw SHA1 followed by each entry's
+// tag and data SHA1, sorted.
+// This follows MAME's chd_file::compute_overall_sha1.
+func (cw *Writer) overallSHA1(rawSHA1 []byte) []byte {
+	hashes := make([][]byte, 0, len(cw.metadata))
+	for _, entry := range cw.metadata {
+		sum := sha1.Sum(entry.data)
+		hashes = append(hashes, append([]byte(entry.tag), sum[:]...))
+	}
+	slices.SortFunc(hashes, bytes.Compare)
+
+	h := sha1.New()
+	h.Write(rawSHA1)
+	for _, entryHash := range hashes {
+		h.Write(entryHash)
+	}
+	return h.Sum(nil)
+}
+
+// compressorFor returns the compression function for a codec.
+func compressorFor(c Codec) (func([]byte) ([]byte, error), error) {
+	switch c {
+	case CodecZlib:
+		return codec.CompressZlib, nil
+	case CodecLZMA:
+		return codec.CompressLZMA, nil
+	case CodecZstd:
+		return codec.CompressZstd, nil
+	case CodecCDZlib:
+		return codec.CompressCDZLIB, nil
+	case CodecCDLZMA:
+		return codec.CompressCDLZMA, nil
+	case CodecCDZstd:
+		return codec.CompressCDZstd, nil
+	default:
+		return nil, fmt.Errorf("codec 0x%08x not supported for writing", c)
+	}
+}
+
+// isCDCodec reports whether c compresses CD frames.
+func isCDCodec(c Codec) bool {
+	return c == CodecCDZlib || c == CodecCDLZMA || c == CodecCDZstd
+}
@@ -0,0 +1,414 @@
+package chd
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"io"
+	"math/rand"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/sargunv/rom-tools/lib/cue"
+	"github.com/sargunv/rom-tools/lib/gdi"
+)
+
+// memFile is an in-memory io.WriterAt.
+type memFile struct {
+	data []byte
+}
+
+func (m *memFile) WriteAt(p []byte, off int64) (int, error) {
+	if end := int(off) + len(p); end > len(m.data) {
+		m.data = append(m.data, make([]byte, end-len(m.data))...)
+	}
+	return copy(m.data[off:], p), nil
+}
+
+// testImage returns data with compressible, incompressible, and repeated
+// hunks, ending with a partial hunk.
+func testImage(hunkBytes int) []byte {
+	rng := rand.New(rand.NewSource(1))
+	random := make([]byte, hunkBytes)
+	rng.Read(random)
+	text := []byte(strings.Repeat("rom-tools writes CHD files. ", hunkBytes/28+1))[:hunkBytes]
+
+	var data []byte
+	data = append(data, text...)
+	data = append(data, random...)
+	data = append(data, make([]byte, hunkBytes)...)
+	data = append(data, text...) // self reference
+	data = append(data, random...)
+	data = append(data, text[:hunkBytes/3]...)
+	return data
+}
+
+func TestWriter_RoundTrip(t *testing.T) {
+	tests := []struct {
+		name        string
+		compressors []Codec
+	}{
+		{"none", nil},
+		{"zlib", []Codec{CodecZlib}},
+		{"lzma", []Codec{CodecLZMA}},
+		{"zstd", []Codec{CodecZstd}},
+		{"all", []Codec{CodecLZMA, CodecZlib, CodecZstd}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			const hunkBytes = 4096
+			data := testImage(hunkBytes)
+
+			file := &memFile{}
+			w, err := NewWriter(file, WriterConfig{HunkBytes: hunkBytes, UnitBytes: 2048, Compressors: tt.compressors})
+			if err != nil {
+				t.Fatalf("NewWriter() error = %v", err)
+			}
+			// Write in uneven pieces to cross hunk boundaries
+			for chunk := range slices.Chunk(data, 1000) {
+				if _, err := w.Write(chunk); err != nil {
+					t.Fatalf("Write() error = %v", err)
+				}
+			}
+			written, err := w.Close()
+			if err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+
+			wantSHA1 := sha1.Sum(data)
+			if written.RawSHA1 != hex.EncodeToString(wantSHA1[:]) {
+				t.Errorf("RawSHA1 = %s, want %x", written.RawSHA1, wantSHA1)
+			}
+			// Without metadata, the overall SHA1 is the SHA1 of the raw SHA1
+			wantOverall := sha1.Sum(wantSHA1[:])
+			if written.SHA1 != hex.EncodeToString(wantOverall[:]) {
+				t.Errorf("SHA1 = %s, want %x", written.SHA1, wantOverall)
+			}
+
+			r, err := NewReader(bytes.NewReader(file.data), int64(len(file.data)))
+			if err != nil {
+				t.Fatalf("NewReader() error = %v", err)
+			}
+			if *r.Header() != *written {
+				t.Errorf("Header() = %+v, want %+v", *r.Header(), *written)
+			}
+			if r.Size() != int64(len(data)) {
+				t.Errorf("Size() = %d, want %d", r.Size(), len(data))
+			}
+			if r.hunkMap.entries[3].compression != compressionSelf || r.hunkMap.entries[3].offset != 0 {
+				t.Errorf("hunk 3 = %+v, want self reference to hunk 0", r.hunkMap.entries[3])
+			}
+
+			got := make([]byte, len(data))
+			if _, err := r.ReadAt(got, 0); err != nil {
+				t.Fatalf("ReadAt() error = %v", err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Error("ReadAt() data doesn't match the written data")
+			}
+		})
+	}
+}
+
+func TestWriter_Metadata(t *testing.T) {
+	file := &memFile{}
+	w, err := NewWriter(file, WriterConfig{})
+	if err != nil {
+		t.Fatalf("NewWriter() error = %v", err)
+	}
+	w.Write([]byte("data"))
+	if err := w.AddMetadata(TagDVD, []byte{0}); err != nil {
+		t.Fatalf("AddMetadata() error = %v", err)
+	}
+	if err := w.AddMetadata("BAD", nil); err == nil {
+		t.Error("AddMetadata() with 3-byte tag error = nil, want error")
+	}
+	written, err := w.Close()
+	if err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	r, err := NewReader(bytes.NewReader(file.data), int64(len(file.data)))
+	if err != nil {
+		t.Fatalf("NewReader() error = %v", err)
+	}
+	entries, err := readMetadata(bytes.NewReader(file.data), r.Header().MetaOffset)
+	if err != nil {
+		t.Fatalf("readMetadata() error = %v", err)
+	}
+	if len(entries) != 1 || entries[0].tag != TagDVD || !bytes.Equal(entries[0].data, []byte{0}) {
+		t.Errorf("metadata = %+v, want one DVD entry", entries)
+	}
+	if written.SHA1 == written.RawSHA1 {
+		t.Error("SHA1 doesn't include metadata")
+	}
+}
+
+// TestWriter_OverallSHA1 checks the overall SHA1 against a chdman-created file.
+func TestWriter_OverallSHA1(t *testing.T) {
+	file, err := os.Open("testdata/empty.chd")
+	if err != nil {
+		t.Fatalf("Failed to open CHD file: %v", err)
+	}
+	defer file.Close()
+	stat, err := file.Stat()
+	if err != nil {
+		t.Fatalf("Failed to stat CHD file: %v", err)
+	}
+	r, err := NewReader(file, stat.Size())
+	if err != nil {
+		t.Fatalf("NewReader() error = %v", err)
+	}
+
+	metadata, err := readMetadata(file, r.Header().MetaOffset)
+	if err != nil {
+		t.Fatalf("readMetadata() error = %v", err)
+	}
+	rawSHA1, _ := hex.DecodeString(r.Header().RawSHA1)
+	w := &Writer{metadata: metadata}
+	if got := hex.EncodeToString(w.overallSHA1(rawSHA1)); got != r.Header().SHA1 {
+		t.Errorf("overallSHA1() = %s, want %s", got, r.Header().SHA1)
+	}
+}
+
+// TestEncodeMap_RoundTrip checks a chdman-created map survives re-encoding.
+func TestEncodeMap_RoundTrip(t *testing.T) {
+	data, err := os.ReadFile("testdata/empty.chd")
+	if err != nil {
+		t.Fatalf("Failed to read CHD file: %v", err)
+	}
+	r, err := NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("NewReader() error = %v", err)
+	}
+
+	encoded, err := encodeMap(r.hunkMap.entries, r.Header().HunkBytes)
+	if err != nil {
+		t.Fatalf("encodeMap() error = %v", err)
+	}
+	header := *r.Header()
+	header.MapOffset = 0
+	decoded, err := decodeMap(bytes.NewReader(encoded), &header)
+	if err != nil {
+		t.Fatalf("decodeMap() error = %v", err)
+	}
+	for i, want := range r.hunkMap.entries {
+		if decoded.entries[i] != want {
+			t.Errorf("entry %d = %+v, want %+v", i, decoded.entries[i], want)
+		}
+	}
+}
+
+func TestNewWriter_InvalidConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		config WriterConfig
+	}{
+		{"unit size", WriterConfig{HunkBytes: 4096, UnitBytes: 3000}},
+		{"too many compressors", WriterConfig{Compressors: []Codec{CodecZlib, CodecLZMA, CodecZstd, CodecZlib, CodecLZMA}}},
+		{"unsupported codec", WriterConfig{Compressors: []Codec{CodecFLAC}}},
+		{"CD codec hunk size", WriterConfig{HunkBytes: 4096, Compressors: []Codec{CodecCDZlib}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewWriter(&memFile{}, tt.config); err == nil {
+				t.Error("NewWriter() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestWriteCD(t *testing.T) {
+	// A 5-frame data track and a 6-frame audio track with a 2-frame pregap
+	// stored in its BIN, as described by a CUE sheet
+	const sheetText = `FILE "track1.bin" BINARY
+  TRACK 01 MODE1/2352
+    INDEX 01 00:00:00
+FILE "track2.bin" BINARY
+  TRACK 02 AUDIO
+    INDEX 00 00:00:00
+    INDEX 01 00:00:02
+`
+	track1 := make([]byte, 5*rawSectorSize)
+	for i := range track1 {
+		track1[i] = byte(i / rawSectorSize)
+	}
+	track2 := make([]byte, 6*rawSectorSize)
+	for i := range track2 {
+		track2[i] = byte(i)
+	}
+	bins := map[string][]byte{"track1.bin": track1, "track2.bin": track2}
+
+	sheet, err := cue.Parse(strings.NewReader(sheetText), int64(len(sheetText)))
+	if err != nil {
+		t.Fatalf("cue.Parse() error = %v", err)
+	}
+	tracks, err := CueTracks(sheet, func(name string) (io.ReaderAt, int64, error) {
+		return bytes.NewReader(bins[name]), int64(len(bins[name])), nil
+	})
+	if err != nil {
+		t.Fatalf("CueTracks() error = %v", err)
+	}
+
+	for _, c := range []Codec{CodecCDZlib, CodecCDLZMA, CodecCDZstd} {
+		file := &memFile{}
+		if _, err := WriteCD(file, tracks, []Codec{c}); err != nil {
+			t.Fatalf("WriteCD(0x%08x) error = %v", c, err)
+		}
+
+		r, err := NewReader(bytes.NewReader(file.data), int64(len(file.data)))
+		if err != nil {
+			t.Fatalf("NewReader() error = %v", err)
+		}
+		// Each track is padded to 8 frames
+		if want := int64(16 * CDFrameSize); r.Size() != want {
+			t.Errorf("Size() = %d, want %d", r.Size(), want)
+		}
+		if len(r.Tracks) != 2 {
+			t.Fatalf("len(Tracks) = %d, want 2", len(r.Tracks))
+		}
+		if tr := r.Tracks[1]; tr.Type != "AUDIO" || tr.Frames != 6 || tr.Pregap != 2 {
+			t.Errorf("Tracks[1] = %+v, want AUDIO with 6 frames and pregap 2", tr)
+		}
+
+		got := make([]byte, r.Tracks[0].Size())
+		if _, err := r.Tracks[0].Open().ReadAt(got, 0); err != nil {
+			t.Fatalf("track 1 ReadAt() error = %v", err)
+		}
+		if !bytes.Equal(got, track1) {
+			t.Error("track 1 data doesn't match the BIN")
+		}
+
+		// Audio is stored big-endian, after the pregap
+		wantAudio := append([]byte{}, track2[2*rawSectorSize:]...)
+		for i := 0; i < len(wantAudio); i += 2 {
+			wantAudio[i], wantAudio[i+1] = wantAudio[i+1], wantAudio[i]
+		}
+		got = make([]byte, r.Tracks[1].Size())
+		if _, err := r.Tracks[1].Open().ReadAt(got, 0); err != nil {
+			t.Fatalf("track 2 ReadAt() error = %v", err)
+		}
+		if !bytes.Equal(got, wantAudio) {
+			t.Error("track 2 data doesn't match the BIN")
+		}
+
+		// CDTracks gives back the BINs, including the pregap
+		cdTracks, err := r.CDTracks()
+		if err != nil {
+			t.Fatalf("CDTracks() error = %v", err)
+		}
+		for i, want := range [][]byte{track1, track2} {
+			tr := cdTracks[i]
+			if tr.Type != tracks[i].Type || tr.Frames != tracks[i].Frames || tr.Pregap != tracks[i].Pregap || tr.PregapStored != tracks[i].PregapStored {
+				t.Errorf("CDTracks()[%d] = %+v, want %+v", i, tr, tracks[i])
+			}
+			got := make([]byte, len(want))
+			if _, err := tr.Data.ReadAt(got, 0); err != nil {
+				t.Fatalf("CDTracks()[%d] ReadAt() error = %v", i, err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("CDTracks()[%d] data doesn't match the BIN", i)
+			}
+		}
+	}
+}
+
+func TestWriteGD(t *testing.T) {
+	const sheetText = `2
+1 0 4 2048 track01.iso 0
+2 600 0 2352 "track 02.raw" 0
+`
+	track1 := bytes.Repeat([]byte{1}, 3*2048)
+	track2 := make([]byte, 2*rawSectorSize)
+	for i := range track2 {
+		track2[i] = byte(i)
+	}
+	files := map[string][]byte{"track01.iso": track1, "track 02.raw": track2}
+
+	sheet, err := gdi.Parse(strings.NewReader(sheetText), int64(len(sheetText)))
+	if err != nil {
+		t.Fatalf("gdi.Parse() error = %v", err)
+	}
+	tracks, err := GDITracks(sheet, func(name string) (io.ReaderAt, int64, error) {
+		return bytes.NewReader(files[name]), int64(len(files[name])), nil
+	})
+	if err != nil {
+		t.Fatalf("GDITracks() error = %v", err)
+	}
+
+	file := &memFile{}
+	if _, err := WriteGD(file, tracks, []Codec{CodecCDZlib}); err != nil {
+		t.Fatalf("WriteGD() error = %v", err)
+	}
+	r, err := NewReader(bytes.NewReader(file.data), int64(len(file.data)))
+	if err != nil {
+		t.Fatalf("NewReader() error = %v", err)
+	}
+	if len(r.Tracks) != 2 {
+		t.Fatalf("len(Tracks) = %d, want 2", len(r.Tracks))
+	}
+	if tr := r.Tracks[0]; tr.Type != "MODE1" || tr.Frames != 3 {
+		t.Errorf("Tracks[0] = %+v, want MODE1 with 3 frames", tr)
+	}
+	if tr := r.Tracks[1]; tr.Type != "AUDIO" || tr.Frames != 2 {
+		t.Errorf("Tracks[1] = %+v, want AUDIO with 2 frames", tr)
+	}
+
+	cdTracks, err := r.CDTracks()
+	if err != nil {
+		t.Fatalf("CDTracks() error = %v", err)
+	}
+	for i, want := range [][]byte{track1, track2} {
+		got := make([]byte, len(want))
+		if _, err := cdTracks[i].Data.ReadAt(got, 0); err != nil {
+			t.Fatalf("CDTracks()[%d] ReadAt() error = %v", i, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("CDTracks()[%d] data doesn't match the track file", i)
+		}
+	}
+}
+
+func TestWriteGD_DensityAreas(t *testing.T) {
+	tracks := []CDTrack{
+		{Type: "MODE1", Data: bytes.NewReader(make([]byte, 3*2048)), Frames: 3},
+		{Type: "AUDIO", Data: bytes.NewReader(make([]byte, 2*rawSectorSize)), Frames: 2, Pregap: 150},
+		{Type: "MODE1_RAW", Data: bytes.NewReader(make([]byte, 5*rawSectorSize)), Frames: 5},
+		{Type: "AUDIO", Data: bytes.NewReader(make([]byte, 2*rawSectorSize)), Frames: 2, Pregap: 75},
+	}
+	file := &memFile{}
+	if _, err := WriteGD(file, tracks, nil); err != nil {
+		t.Fatalf("WriteGD() error = %v", err)
+	}
+	r, err := NewReader(bytes.NewReader(file.data), int64(len(file.data)))
+	if err != nil {
+		t.Fatalf("NewReader() error = %v", err)
+	}
+
+	want := []struct {
+		lba         int64
+		highDensity bool
+		startFrame  int64
+	}{
+		{0, false, 0},
+		{3 + 150, false, 4},
+		{45000, true, 8},
+		{45000 + 5 + 75, true, 16},
+	}
+	if len(r.Tracks) != len(want) {
+		t.Fatalf("len(Tracks) = %d, want %d", len(r.Tracks), len(want))
+	}
+	for i, w := range want {
+		tr := r.Tracks[i]
+		if tr.LBA != w.lba || tr.HighDensity != w.highDensity || tr.startFrame != w.startFrame {
+			t.Errorf("Tracks[%d] LBA/HighDensity/start = %d/%v/%d, want %d/%v/%d",
+				i, tr.LBA, tr.HighDensity, tr.startFrame, w.lba, w.highDensity, w.startFrame)
+		}
+	}
+	if got := r.HighDensityDataTrack(); got != r.Tracks[2] {
+		t.Errorf("HighDensityDataTrack() = %+v, want track 3", got)
+	}
+}
@@ -0,0 +1,96 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// UserDataSize is the size of a data sector's user data, and of a sector in
+// an ISO image.
+const UserDataSize = 2048
+
+// ErrNoDataTrack is returned by DiscImage.OpenUserData for discs with only
+// audio tracks.
+var ErrNoDataTrack = errors.New("disc has no data track")
+
+// DiscTrack describes one track of a DiscImage.
+type DiscTrack struct {
+	Number     int  `json:"number"`
+	Data       bool `json:"data"`        // false for audio tracks
+	SectorSize int  `json:"sector_size"` // size of a sector as read by OpenTrack
+}
+
+// DiscImage is implemented by disc image formats (CHD, BIN/CUE, GDI,
+// CloneCD, NRG, ISO), so disc content can be identified the same way
+// whatever the image is stored in.
+type DiscImage interface {
+	// Size returns the size of the image's tracks in bytes, as read by
+	// OpenTrack.
+	Size() int64
+
+	// Tracks lists the tracks in order.
+	Tracks() []DiscTrack
+
+	// OpenTrack returns a reader for a track's sectors as stored, e.g. 2352
+	// byte raw sectors, and its size.
+	OpenTrack(number int) (io.ReaderAt, int64, error)
+
+	// OpenUserData returns a reader for the user data of the disc's boot
+	// track as UserDataSize-byte sectors, and its size. That's the first
+	// data track, or a GD-ROM's first high-density data track. Returns
+	// ErrNoDataTrack if there's none.
+	OpenUserData() (io.ReaderAt, int64, error)
+}
+
+// UserDataReader reads the user data of a data track's sectors as
+// contiguous UserDataSize-byte sectors, like an ISO image.
+type UserDataReader struct {
+	r          io.ReaderAt
+	sectorSize int64
+	dataOffset int64
+	size       int64
+}
+
+// NewUserDataReader returns a reader for the user data in r, which holds size
+// bytes of sectorSize-byte sectors with their user data at dataOffset.
+func NewUserDataReader(r io.ReaderAt, size int64, sectorSize, dataOffset int) (*UserDataReader, error) {
+	if sectorSize < dataOffset+UserDataSize {
+		return nil, fmt.Errorf("%d-byte sectors can't hold user data at offset %d", sectorSize, dataOffset)
+	}
+	return &UserDataReader{
+		r:          r,
+		sectorSize: int64(sectorSize),
+		dataOffset: int64(dataOffset),
+		size:       size / int64(sectorSize) * UserDataSize,
+	}, nil
+}
+
+// Size returns the size of the user data in bytes.
+func (u *UserDataReader) Size() int64 {
+	return u.size
+}
+
+// ReadAt implements io.ReaderAt, translating user data offsets to sector
+// offsets.
+func (u *UserDataReader) ReadAt(p []byte, off int64) (n int, err error) {
+	if off < 0 {
+		return 0, fmt.Errorf("negative offset")
+	}
+
+	for n < len(p) && off < u.size {
+		sector, inSector := off/UserDataSize, off%UserDataSize
+		chunk := min(int64(len(p)-n), UserDataSize-inSector, u.size-off)
+		read, err := u.r.ReadAt(p[n:n+int(chunk)], sector*u.sectorSize+u.dataOffset+inSector)
+		n += read
+		off += int64(read)
+		if err != nil {
+			return n, err
+		}
+	}
+
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
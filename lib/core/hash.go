@@ -9,12 +9,27 @@ const (
 	HashMD5   HashType = "md5"
 	HashCRC32 HashType = "crc32"
 
+	// Optional calculated hash types, computed only when requested
+	HashSHA256 HashType = "sha256"
+	HashXXH64  HashType = "xxh64"
+
+	// Headerless hash types (computed from file content after a header that
+	// DATs strip, such as an iNES header or SNES copier header)
+	HashHeaderlessSHA1   HashType = "headerless-sha1"
+	HashHeaderlessMD5    HashType = "headerless-md5"
+	HashHeaderlessCRC32  HashType = "headerless-crc32"
+	HashHeaderlessSHA256 HashType = "headerless-sha256"
+
 	// Container metadata hash types (extracted from archive headers)
 	HashZipCRC32 HashType = "zip-crc32"
 
 	// CHD hash types (extracted from CHD file headers)
 	HashCHDUncompressedSHA1 HashType = "chd-uncompressed-sha1"
 	HashCHDCompressedSHA1   HashType = "chd-compressed-sha1"
+	HashCHDMD5              HashType = "chd-md5" // v1-v3 only, of the raw data
+
+	// NKit hash types (the original disc image's, extracted from NKit headers)
+	HashNKitCRC32 HashType = "nkit-crc32"
 )
 
 // Hashes maps hash type to hex-encoded value.
@@ -6,6 +6,7 @@ type Platform string
 
 const (
 	PlatformNES     Platform = "famicom"
+	PlatformFDS     Platform = "fds"
 	PlatformSNES    Platform = "superfamicom"
 	PlatformN64     Platform = "nintendo64"
 	PlatformGC      Platform = "gamecube"
@@ -36,13 +37,38 @@ const (
 	PlatformMD        Platform = "megadrive"
 	PlatformSegaCD    Platform = "segacd"
 	Platform32X       Platform = "sega32x"
+	PlatformPico      Platform = "pico"
 	PlatformSaturn    Platform = "saturn"
 	PlatformDreamcast Platform = "dreamcast"
 
 	PlatformGameGear Platform = "gamegear"
 
+	PlatformPCE   Platform = "pcengine"
+	PlatformPCECD Platform = "pcenginecd"
+
+	PlatformNeoGeoCD Platform = "neogeocd"
+
+	PlatformAtari2600 Platform = "atari2600"
+	PlatformAtari7800 Platform = "atari7800"
+	PlatformLynx      Platform = "atarilynx"
+	PlatformJaguar    Platform = "jaguar"
+	PlatformJaguarCD  Platform = "jaguarcd"
+
+	Platform3DO Platform = "3do"
+
+	PlatformMSX  Platform = "msx"
+	PlatformMSX2 Platform = "msx2"
+
+	PlatformC64   Platform = "c64"
+	PlatformAmiga Platform = "amiga"
+
+	PlatformZXSpectrum Platform = "zxspectrum"
+
 	PlatformXbox       Platform = "xbox"
 	PlatformXbox360    Platform = "xbox360"
 	PlatformXboxOne    Platform = "xboxone"
 	PlatformXboxSeries Platform = "xboxseries"
+
+	PlatformArcade    Platform = "arcade"
+	PlatformLaserDisc Platform = "laserdisc"
 )
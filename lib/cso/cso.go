@@ -0,0 +1,241 @@
+// Package cso provides support for reading CSO (compressed ISO) disc images,
+// and the LZ4-based ZSO variant, as used by PSP homebrew loaders and emulators
+// such as PPSSPP.
+//
+// The decompressed image is exposed through io.ReaderAt, so it can be passed
+// directly to iso9660.NewReader.
+//
+// Format specifications:
+//   - https://github.com/unknownbrackets/maxcso/blob/master/README_CSO.md
+//   - https://github.com/unknownbrackets/maxcso/blob/master/README_ZSO.md
+package cso
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Header layout (24 bytes, little-endian):
+//
+//	Offset  Size  Description
+//	0x00    4     Magic ("CISO" or "ZISO")
+//	0x04    4     Header size (0x18; some tools write 0)
+//	0x08    8     Uncompressed size
+//	0x10    4     Block size (usually 2048)
+//	0x14    1     Version (1 or 2)
+//	0x15    1     Index alignment (offsets are shifted left by this amount)
+//	0x16    2     Reserved
+//
+// The header is followed by an index of (blocks + 1) uint32 entries. The low
+// 31 bits of each entry, shifted left by the index alignment, give the file
+// offset of a block; the next entry gives its end. The high bit marks an
+// uncompressed block in version 1. Compressed blocks are raw deflate.
+//
+// In version 2, a block whose stored size is at least the block size is
+// uncompressed, and the high bit marks LZ4 compression instead.
+//
+// ZSO files use the same layout as version 1, with LZ4 in place of deflate.
+const (
+	headerSize = 0x18
+
+	magicOffset      = 0x00
+	totalSizeOffset  = 0x08
+	blockSizeOffset  = 0x10
+	versionOffset    = 0x14
+	alignmentOffset  = 0x15
+	indexFlagMask    = 0x80000000
+	indexOffsetMask  = 0x7FFFFFFF
+	maxBlockSize     = 1 << 24 // sanity limit on the header's block size
+	indexEntrySize   = 4
+	supportedVersion = 2
+)
+
+var (
+	magic    = []byte("CISO")
+	zsoMagic = []byte("ZISO")
+)
+
+// Format identifies the compressed image variant.
+type Format string
+
+const (
+	FormatCSO Format = "CSO" // Deflate (and LZ4 in version 2) compressed
+	FormatZSO Format = "ZSO" // LZ4 compressed
+)
+
+// Header contains the parsed CSO header.
+type Header struct {
+	Format    Format // Image variant, from the magic
+	Version   uint8  // Format version (1 or 2)
+	TotalSize int64  // Uncompressed image size in bytes
+	BlockSize uint32 // Uncompressed block size in bytes
+	Alignment uint8  // Index offset alignment shift
+}
+
+// Reader provides random access to the decompressed contents of a CSO image.
+type Reader struct {
+	file   io.ReaderAt
+	header *Header
+	index  []uint32
+
+	// The most recently decompressed block, since reads are usually sequential
+	cacheMu    sync.Mutex
+	cacheBlock int64
+	cacheData  []byte
+}
+
+// NewReader creates a Reader reading from r, which must be an io.ReaderAt.
+func NewReader(r io.ReaderAt, size int64) (*Reader, error) {
+	if size < headerSize {
+		return nil, fmt.Errorf("file too small for CSO header: %d bytes", size)
+	}
+
+	buf := make([]byte, headerSize)
+	if _, err := r.ReadAt(buf, 0); err != nil {
+		return nil, fmt.Errorf("failed to read CSO header: %w", err)
+	}
+
+	var format Format
+	switch {
+	case bytes.Equal(buf[magicOffset:magicOffset+len(magic)], magic):
+		format = FormatCSO
+	case bytes.Equal(buf[magicOffset:magicOffset+len(zsoMagic)], zsoMagic):
+		format = FormatZSO
+	default:
+		return nil, fmt.Errorf("not a valid CSO file: invalid magic")
+	}
+
+	header := &Header{
+		Format:    format,
+		Version:   buf[versionOffset],
+		TotalSize: int64(binary.LittleEndian.Uint64(buf[totalSizeOffset:])),
+		BlockSize: binary.LittleEndian.Uint32(buf[blockSizeOffset:]),
+		Alignment: buf[alignmentOffset],
+	}
+
+	if header.Version > supportedVersion || (format == FormatZSO && header.Version > 1) {
+		return nil, fmt.Errorf("CSO version %d not supported", header.Version)
+	}
+	if header.BlockSize == 0 || header.BlockSize > maxBlockSize {
+		return nil, fmt.Errorf("invalid CSO block size: %d", header.BlockSize)
+	}
+	if header.TotalSize < 0 {
+		return nil, fmt.Errorf("invalid CSO uncompressed size: %d", header.TotalSize)
+	}
+
+	numBlocks := (header.TotalSize + int64(header.BlockSize) - 1) / int64(header.BlockSize)
+	indexBytes := (numBlocks + 1) * indexEntrySize
+	if headerSize+indexBytes > size {
+		return nil, fmt.Errorf("CSO index extends beyond file: %d blocks, file size %d", numBlocks, size)
+	}
+
+	indexData := make([]byte, indexBytes)
+	if _, err := r.ReadAt(indexData, headerSize); err != nil {
+		return nil, fmt.Errorf("failed to read CSO index: %w", err)
+	}
+	index := make([]uint32, numBlocks+1)
+	for i := range index {
+		index[i] = binary.LittleEndian.Uint32(indexData[i*indexEntrySize:])
+	}
+
+	return &Reader{
+		file:       r,
+		header:     header,
+		index:      index,
+		cacheBlock: -1,
+	}, nil
+}
+
+// Header returns the CSO header information.
+func (r *Reader) Header() *Header {
+	return r.header
+}
+
+// Size returns the uncompressed size in bytes.
+func (r *Reader) Size() int64 {
+	return r.header.TotalSize
+}
+
+// ReadAt implements io.ReaderAt, reading from the uncompressed image.
+func (r *Reader) ReadAt(p []byte, off int64) (n int, err error) {
+	if off < 0 {
+		return 0, fmt.Errorf("negative offset")
+	}
+
+	blockSize := int64(r.header.BlockSize)
+	for n < len(p) && off < r.header.TotalSize {
+		blockNum := off / blockSize
+		blockOffset := off % blockSize
+
+		data, err := r.readBlock(blockNum)
+		if err != nil {
+			return n, fmt.Errorf("read block %d: %w", blockNum, err)
+		}
+
+		copied := copy(p[n:], data[blockOffset:])
+		n += copied
+		off += int64(copied)
+	}
+
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// readBlock reads and decompresses a single block.
+func (r *Reader) readBlock(blockNum int64) ([]byte, error) {
+	r.cacheMu.Lock()
+	defer r.cacheMu.Unlock()
+
+	if r.cacheBlock == blockNum {
+		return r.cacheData, nil
+	}
+
+	blockSize := int64(r.header.BlockSize)
+	want := min(blockSize, r.header.TotalSize-blockNum*blockSize)
+
+	entry := r.index[blockNum]
+	start := int64(entry&indexOffsetMask) << r.header.Alignment
+	end := int64(r.index[blockNum+1]&indexOffsetMask) << r.header.Alignment
+	if end < start {
+		return nil, fmt.Errorf("invalid index entry")
+	}
+
+	// With alignment, the stored size may include padding past the block
+	raw := make([]byte, end-start)
+	if _, err := r.file.ReadAt(raw, start); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to read compressed data: %w", err)
+	}
+
+	var data []byte
+	flagged := entry&indexFlagMask != 0
+	switch {
+	case r.header.Version < 2 && flagged, r.header.Version >= 2 && int64(len(raw)) >= blockSize:
+		if int64(len(raw)) < want {
+			return nil, fmt.Errorf("uncompressed block truncated: %d bytes", len(raw))
+		}
+		data = raw[:want]
+	case flagged, r.header.Format == FormatZSO:
+		data = make([]byte, want)
+		if err := decodeLZ4(raw, data); err != nil {
+			return nil, fmt.Errorf("failed to decompress: %w", err)
+		}
+	default:
+		data = make([]byte, want)
+		fr := flate.NewReader(bytes.NewReader(raw))
+		_, err := io.ReadFull(fr, data)
+		fr.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress: %w", err)
+		}
+	}
+
+	r.cacheBlock = blockNum
+	r.cacheData = data
+	return data, nil
+}
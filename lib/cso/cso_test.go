@@ -0,0 +1,276 @@
+package cso
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"io"
+	"testing"
+)
+
+// makeTestCSO compresses data into a CSO image. Blocks in stored are written
+// uncompressed; the rest are LZ4 compressed if in lz4 or the format is ZSO,
+// and deflated otherwise.
+func makeTestCSO(t *testing.T, data []byte, h Header, stored, lz4 map[int]bool) []byte {
+	t.Helper()
+
+	blockSize, version, align := h.BlockSize, h.Version, h.Alignment
+	numBlocks := (len(data) + int(blockSize) - 1) / int(blockSize)
+	header := make([]byte, headerSize)
+	if h.Format == FormatZSO {
+		copy(header, zsoMagic)
+	} else {
+		copy(header, magic)
+	}
+	binary.LittleEndian.PutUint32(header[4:], headerSize)
+	binary.LittleEndian.PutUint64(header[totalSizeOffset:], uint64(len(data)))
+	binary.LittleEndian.PutUint32(header[blockSizeOffset:], blockSize)
+	header[versionOffset] = version
+	header[alignmentOffset] = align
+
+	index := make([]uint32, numBlocks+1)
+	var body bytes.Buffer
+	pos := int64(headerSize + len(index)*indexEntrySize)
+	alignTo := func() {
+		for pos%(1<<align) != 0 {
+			body.WriteByte(0)
+			pos++
+		}
+	}
+
+	for i := range numBlocks {
+		alignTo()
+		block := data[i*int(blockSize) : min((i+1)*int(blockSize), len(data))]
+		index[i] = uint32(pos >> align)
+
+		if stored[i] {
+			if version < 2 {
+				index[i] |= indexFlagMask
+			}
+			body.Write(block)
+			pos += int64(len(block))
+			continue
+		}
+
+		if h.Format == FormatZSO || lz4[i] {
+			compressed := encodeTestLZ4(block)
+			if h.Format == FormatCSO {
+				index[i] |= indexFlagMask
+			}
+			body.Write(compressed)
+			pos += int64(len(compressed))
+			continue
+		}
+
+		var buf bytes.Buffer
+		fw, err := flate.NewWriter(&buf, flate.BestCompression)
+		if err != nil {
+			t.Fatalf("flate.NewWriter() error = %v", err)
+		}
+		fw.Write(block)
+		fw.Close()
+		body.Write(buf.Bytes())
+		pos += int64(buf.Len())
+	}
+	alignTo()
+	index[numBlocks] = uint32(pos >> align)
+
+	out := bytes.NewBuffer(header)
+	for _, entry := range index {
+		binary.Write(out, binary.LittleEndian, entry)
+	}
+	out.Write(body.Bytes())
+	return out.Bytes()
+}
+
+// encodeTestLZ4 compresses data as an LZ4 block, greedily matching against
+// the previous occurrence of each 4-byte sequence.
+func encodeTestLZ4(data []byte) []byte {
+	var out []byte
+	writeLength := func(n int) {
+		for ; n >= 255; n -= 255 {
+			out = append(out, 255)
+		}
+		out = append(out, byte(n))
+	}
+	writeSequence := func(literals []byte, offset, matchLen int) {
+		token := byte(min(len(literals), 15)) << 4
+		if offset > 0 {
+			token |= byte(min(matchLen-lz4MinMatch, 15))
+		}
+		out = append(out, token)
+		if len(literals) >= 15 {
+			writeLength(len(literals) - 15)
+		}
+		out = append(out, literals...)
+		if offset > 0 {
+			out = append(out, byte(offset), byte(offset>>8))
+			if matchLen-lz4MinMatch >= 15 {
+				writeLength(matchLen - lz4MinMatch - 15)
+			}
+		}
+	}
+
+	// The last 5 bytes must be literals, and the last match must start at
+	// least 12 bytes before the end
+	last := map[uint32]int{}
+	anchor := 0
+	for i := 0; i+12 <= len(data); {
+		key := binary.LittleEndian.Uint32(data[i:])
+		prev, ok := last[key]
+		last[key] = i
+		if !ok || i-prev > 0xFFFF {
+			i++
+			continue
+		}
+		length := lz4MinMatch
+		for i+length < len(data)-5 && data[prev+length] == data[i+length] {
+			length++
+		}
+		writeSequence(data[anchor:i], i-prev, length)
+		i += length
+		anchor = i
+	}
+	writeSequence(data[anchor:], 0, 0)
+	return out
+}
+
+func makeTestData(size int) []byte {
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = byte(i / 7)
+	}
+	return data
+}
+
+func TestReader(t *testing.T) {
+	data := makeTestData(2048*5 + 100)
+
+	tests := []struct {
+		name    string
+		format  Format
+		version uint8
+		align   uint8
+		stored  map[int]bool
+		lz4     map[int]bool
+	}{
+		{"v1", FormatCSO, 1, 0, map[int]bool{1: true, 5: true}, nil},
+		{"v1 aligned", FormatCSO, 1, 2, map[int]bool{3: true}, nil},
+		{"v2", FormatCSO, 2, 0, map[int]bool{0: true}, nil},
+		{"v2 LZ4", FormatCSO, 2, 0, map[int]bool{0: true}, map[int]bool{2: true, 3: true}},
+		{"ZSO", FormatZSO, 1, 0, map[int]bool{4: true}, nil},
+		{"ZSO aligned", FormatZSO, 1, 3, nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := Header{Format: tt.format, Version: tt.version, BlockSize: 2048, Alignment: tt.align}
+			cso := makeTestCSO(t, data, h, tt.stored, tt.lz4)
+
+			r, err := NewReader(bytes.NewReader(cso), int64(len(cso)))
+			if err != nil {
+				t.Fatalf("NewReader() error = %v", err)
+			}
+			if r.Size() != int64(len(data)) {
+				t.Errorf("Size() = %d, want %d", r.Size(), len(data))
+			}
+			if r.Header().Version != tt.version {
+				t.Errorf("Version = %d, want %d", r.Header().Version, tt.version)
+			}
+			if r.Header().Format != tt.format {
+				t.Errorf("Format = %q, want %q", r.Header().Format, tt.format)
+			}
+
+			got, err := io.ReadAll(io.NewSectionReader(r, 0, r.Size()))
+			if err != nil {
+				t.Fatalf("ReadAll() error = %v", err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Error("decompressed data does not match original")
+			}
+
+			// Read spanning a block boundary
+			buf := make([]byte, 300)
+			if _, err := r.ReadAt(buf, 2048*3-150); err != nil {
+				t.Fatalf("ReadAt() error = %v", err)
+			}
+			if !bytes.Equal(buf, data[2048*3-150:2048*3+150]) {
+				t.Error("ReadAt() across block boundary returned wrong data")
+			}
+		})
+	}
+}
+
+func TestReader_ReadPastEnd(t *testing.T) {
+	data := makeTestData(4096)
+	cso := makeTestCSO(t, data, Header{Format: FormatCSO, Version: 1, BlockSize: 2048}, nil, nil)
+
+	r, err := NewReader(bytes.NewReader(cso), int64(len(cso)))
+	if err != nil {
+		t.Fatalf("NewReader() error = %v", err)
+	}
+
+	buf := make([]byte, 100)
+	n, err := r.ReadAt(buf, 4046)
+	if n != 50 || err != io.EOF {
+		t.Errorf("ReadAt() = %d, %v, want 50, io.EOF", n, err)
+	}
+}
+
+func TestNewReader_Invalid(t *testing.T) {
+	if _, err := NewReader(bytes.NewReader([]byte("CISO")), 4); err == nil {
+		t.Error("NewReader() expected error for small file, got nil")
+	}
+
+	data := make([]byte, headerSize)
+	copy(data, "NOPE")
+	if _, err := NewReader(bytes.NewReader(data), int64(len(data))); err == nil {
+		t.Error("NewReader() expected error for invalid magic, got nil")
+	}
+
+	// Valid header, but the index for 1000 blocks is missing
+	copy(data, magic)
+	binary.LittleEndian.PutUint64(data[totalSizeOffset:], 2048*1000)
+	binary.LittleEndian.PutUint32(data[blockSizeOffset:], 2048)
+	if _, err := NewReader(bytes.NewReader(data), int64(len(data))); err == nil {
+		t.Error("NewReader() expected error for truncated index, got nil")
+	}
+}
+
+func TestDecodeLZ4(t *testing.T) {
+	tests := []struct {
+		name string
+		src  []byte
+		want []byte
+	}{
+		{"literals only", []byte{0x30, 'a', 'b', 'c'}, []byte("abc")},
+		// "ab" then an overlapping match of 6 at offset 2, then "c"
+		{"overlapping match", []byte{0x22, 'a', 'b', 0x02, 0x00, 0x10, 'c'}, []byte("ababababc")},
+		// 16 literals, using an extended length
+		{"extended literals", append([]byte{0xF0, 0x01}, bytes.Repeat([]byte{'x'}, 16)...), bytes.Repeat([]byte{'x'}, 16)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := make([]byte, len(tt.want))
+			if err := decodeLZ4(tt.src, got); err != nil {
+				t.Fatalf("decodeLZ4() error = %v", err)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("decodeLZ4() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	invalid := map[string][]byte{
+		"empty":          {},
+		"offset zero":    {0x10, 'a', 0x00, 0x00, 0x00},
+		"offset too far": {0x10, 'a', 0x05, 0x00, 0x00},
+		"truncated":      {0x50, 'a'},
+	}
+	for name, src := range invalid {
+		if err := decodeLZ4(src, make([]byte, 16)); err == nil {
+			t.Errorf("decodeLZ4(%s) expected error, got nil", name)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package cso
+
+import "fmt"
+
+// LZ4 block format decoding, for ZSO images and LZ4 blocks in CSO v2.
+//
+// A block is a sequence of sequences. Each starts with a token byte: the high
+// nibble is the literal length and the low nibble the match length minus 4.
+// A nibble of 15 is extended by following bytes, each added to it, until a
+// byte other than 255. The literals follow, then a 2-byte little-endian
+// match offset. The last sequence has literals only.
+//
+// Format specification: https://github.com/lz4/lz4/blob/dev/doc/lz4_Block_format.md
+
+const lz4MinMatch = 4
+
+// decodeLZ4 decompresses an LZ4 block into dst, which must be exactly the
+// uncompressed size.
+func decodeLZ4(src, dst []byte) error {
+	var si, di int
+
+	readLength := func(n int) (int, error) {
+		if n != 15 {
+			return n, nil
+		}
+		for {
+			if si >= len(src) {
+				return 0, fmt.Errorf("LZ4 length extends beyond input")
+			}
+			b := src[si]
+			si++
+			n += int(b)
+			if b != 255 {
+				return n, nil
+			}
+		}
+	}
+
+	for {
+		if si >= len(src) {
+			return fmt.Errorf("LZ4 block truncated")
+		}
+		token := src[si]
+		si++
+
+		literals, err := readLength(int(token >> 4))
+		if err != nil {
+			return err
+		}
+		if literals > len(src)-si || literals > len(dst)-di {
+			return fmt.Errorf("LZ4 literals out of range")
+		}
+		di += copy(dst[di:], src[si:si+literals])
+		si += literals
+
+		// The last sequence ends with its literals. Aligned images may pad
+		// the block past it.
+		if si == len(src) || di == len(dst) {
+			break
+		}
+
+		if si+2 > len(src) {
+			return fmt.Errorf("LZ4 match offset truncated")
+		}
+		offset := int(src[si]) | int(src[si+1])<<8
+		si += 2
+		if offset == 0 || offset > di {
+			return fmt.Errorf("invalid LZ4 match offset: %d", offset)
+		}
+
+		length, err := readLength(int(token & 0x0F))
+		if err != nil {
+			return err
+		}
+		length += lz4MinMatch
+		if length > len(dst)-di {
+			return fmt.Errorf("LZ4 match out of range")
+		}
+
+		// Matches may overlap their own output, so copy byte by byte
+		for i := range length {
+			dst[di+i] = dst[di-offset+i]
+		}
+		di += length
+	}
+
+	if di != len(dst) {
+		return fmt.Errorf("LZ4 block decompressed to %d bytes, want %d", di, len(dst))
+	}
+	return nil
+}
@@ -0,0 +1,299 @@
+// Package cue provides support for CUE sheets, which describe the tracks of
+// a disc image stored in one or more BIN files.
+//
+// Use Parse to read the sheet, then File.OpenTrack to access a track within
+// its BIN file. Positions are in frames (sectors), 75 per second.
+//
+// Sheet example:
+//
+//	FILE "game (Track 1).bin" BINARY
+//	  TRACK 01 MODE1/2352
+//	    INDEX 01 00:00:00
+//	FILE "game (Track 2).bin" BINARY
+//	  TRACK 02 AUDIO
+//	    INDEX 00 00:00:00
+//	    INDEX 01 00:02:00
+//
+// INDEX positions are relative to the start of their FILE. A PREGAP command
+// gives a pregap that isn't stored in the file, unlike INDEX 00.
+package cue
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// FramesPerSecond is the number of frames in one second of MSF time.
+const FramesPerSecond = 75
+
+// Track describes one track of a CUE sheet.
+type Track struct {
+	// Number is the track number (1-99).
+	Number int `json:"number"`
+	// Type is the track type, e.g. "MODE1/2352" or "AUDIO".
+	Type string `json:"type"`
+	// Pregap is the length of a pregap not stored in the file (PREGAP).
+	Pregap int64 `json:"pregap,omitempty"`
+	// Postgap is the length of a postgap not stored in the file (POSTGAP).
+	Postgap int64 `json:"postgap,omitempty"`
+	// Index0 is the position of the track's pregap in the file, or -1 if it
+	// has none.
+	Index0 int64 `json:"index0"`
+	// Index1 is the position where the track's data starts in the file.
+	Index1 int64 `json:"index1"`
+}
+
+// SectorSize returns the size of one of the track's sectors in the file, or
+// 0 if the type is unknown.
+func (t *Track) SectorSize() int {
+	switch t.Type {
+	case "AUDIO", "MODE1/2352", "MODE2/2352", "CDI/2352":
+		return 2352
+	case "MODE1/2048":
+		return 2048
+	case "MODE2/2336", "CDI/2336":
+		return 2336
+	case "CDG":
+		return 2448
+	default:
+		return 0
+	}
+}
+
+// IsData reports whether the track holds data rather than audio.
+func (t *Track) IsData() bool {
+	return t.Type != "AUDIO" && t.Type != "CDG"
+}
+
+// Start returns the position of the track's first sector stored in the file:
+// INDEX 00 if present, otherwise INDEX 01.
+func (t *Track) Start() int64 {
+	if t.Index0 >= 0 {
+		return t.Index0
+	}
+	return t.Index1
+}
+
+// File is a FILE entry of a CUE sheet and the tracks it contains.
+type File struct {
+	// Name is the file name, relative to the CUE sheet.
+	Name string `json:"name"`
+	// Type is the file type, e.g. "BINARY" or "WAVE".
+	Type string `json:"type"`
+	// Tracks lists the tracks in the file in order.
+	Tracks []Track `json:"tracks"`
+}
+
+// Sheet contains the parsed contents of a CUE sheet.
+type Sheet struct {
+	// Files lists the FILE entries in order.
+	Files []File `json:"files"`
+}
+
+// Parse parses a CUE sheet.
+func Parse(r io.ReaderAt, size int64) (*Sheet, error) {
+	data := make([]byte, size)
+	if _, err := r.ReadAt(data, 0); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to read CUE sheet: %w", err)
+	}
+
+	sheet := &Sheet{}
+	var file *File
+	var track *Track
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		fields := splitFields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		args := fields[1:]
+		switch strings.ToUpper(fields[0]) {
+		case "FILE":
+			if len(args) < 1 {
+				return nil, fmt.Errorf("not a valid CUE sheet: line %d: FILE without name", lineNum)
+			}
+			fileType := ""
+			if len(args) > 1 {
+				fileType = strings.ToUpper(args[1])
+			}
+			sheet.Files = append(sheet.Files, File{Name: args[0], Type: fileType})
+			file = &sheet.Files[len(sheet.Files)-1]
+			track = nil
+
+		case "TRACK":
+			if file == nil {
+				return nil, fmt.Errorf("not a valid CUE sheet: line %d: TRACK before FILE", lineNum)
+			}
+			if len(args) < 2 {
+				return nil, fmt.Errorf("not a valid CUE sheet: line %d: TRACK without type", lineNum)
+			}
+			number, err := strconv.Atoi(args[0])
+			if err != nil || number < 1 || number > 99 {
+				return nil, fmt.Errorf("not a valid CUE sheet: line %d: invalid track number %q", lineNum, args[0])
+			}
+			file.Tracks = append(file.Tracks, Track{
+				Number: number,
+				Type:   strings.ToUpper(args[1]),
+				Index0: -1,
+				Index1: -1,
+			})
+			track = &file.Tracks[len(file.Tracks)-1]
+
+		case "INDEX", "PREGAP", "POSTGAP":
+			if track == nil {
+				return nil, fmt.Errorf("not a valid CUE sheet: line %d: %s before TRACK", lineNum, fields[0])
+			}
+			if len(args) < 1 {
+				return nil, fmt.Errorf("not a valid CUE sheet: line %d: %s without time", lineNum, fields[0])
+			}
+			frames, err := ParseMSF(args[len(args)-1])
+			if err != nil {
+				return nil, fmt.Errorf("not a valid CUE sheet: line %d: %w", lineNum, err)
+			}
+			switch strings.ToUpper(fields[0]) {
+			case "PREGAP":
+				track.Pregap = frames
+			case "POSTGAP":
+				track.Postgap = frames
+			default:
+				if len(args) < 2 {
+					return nil, fmt.Errorf("not a valid CUE sheet: line %d: INDEX without number", lineNum)
+				}
+				// Indexes above 1 mark positions within the track, so only
+				// the pregap and track start are kept
+				switch args[0] {
+				case "00", "0":
+					track.Index0 = frames
+				case "01", "1":
+					track.Index1 = frames
+				}
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read CUE sheet: %w", err)
+	}
+
+	numTracks := 0
+	for _, f := range sheet.Files {
+		for _, t := range f.Tracks {
+			if t.Index1 < 0 {
+				return nil, fmt.Errorf("not a valid CUE sheet: track %d has no INDEX 01", t.Number)
+			}
+			numTracks++
+		}
+	}
+	if numTracks == 0 {
+		return nil, fmt.Errorf("not a valid CUE sheet: no tracks")
+	}
+
+	return sheet, nil
+}
+
+// FirstDataTrack returns the first data track and the file containing it,
+// or nil if the disc only has audio tracks.
+func (s *Sheet) FirstDataTrack() (*File, *Track) {
+	for i := range s.Files {
+		for j := range s.Files[i].Tracks {
+			if s.Files[i].Tracks[j].IsData() {
+				return &s.Files[i], &s.Files[i].Tracks[j]
+			}
+		}
+	}
+	return nil, nil
+}
+
+// OpenTrack returns a reader for a track's sectors within the file, from its
+// Start up to the start of the next track in the file (or the end of the
+// file). The sectors are in the track's SectorSize.
+func (f *File) OpenTrack(bin io.ReaderAt, binSize int64, number int) (*io.SectionReader, error) {
+	i := slices.IndexFunc(f.Tracks, func(t Track) bool { return t.Number == number })
+	if i < 0 {
+		return nil, fmt.Errorf("track %d not found in %s", number, f.Name)
+	}
+
+	// Tracks in one file may have different sector sizes, so find the byte
+	// offset by walking the tracks before this one
+	start := f.Tracks[0].Start() * int64(f.Tracks[0].SectorSize())
+	for j := range i {
+		t := &f.Tracks[j]
+		if t.SectorSize() == 0 {
+			return nil, fmt.Errorf("track %d: unsupported track type %s", t.Number, t.Type)
+		}
+		start += (f.Tracks[j+1].Start() - t.Start()) * int64(t.SectorSize())
+	}
+
+	t := &f.Tracks[i]
+	sectorSize := int64(t.SectorSize())
+	if sectorSize == 0 {
+		return nil, fmt.Errorf("track %d: unsupported track type %s", t.Number, t.Type)
+	}
+	end := binSize
+	if i+1 < len(f.Tracks) {
+		end = min(end, start+(f.Tracks[i+1].Start()-t.Start())*sectorSize)
+	}
+	if start >= end {
+		return nil, fmt.Errorf("track %d extends beyond file: starts at %d, file size %d", number, start, binSize)
+	}
+
+	return io.NewSectionReader(bin, start, end-start), nil
+}
+
+// ParseMSF parses an mm:ss:ff time into frames.
+func ParseMSF(s string) (int64, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 {
+		return 0, fmt.Errorf("invalid MSF time %q", s)
+	}
+	var msf [3]int64
+	for i, part := range parts {
+		v, err := strconv.ParseInt(part, 10, 64)
+		if err != nil || v < 0 {
+			return 0, fmt.Errorf("invalid MSF time %q", s)
+		}
+		msf[i] = v
+	}
+	if msf[1] >= 60 || msf[2] >= FramesPerSecond {
+		return 0, fmt.Errorf("invalid MSF time %q", s)
+	}
+	return (msf[0]*60+msf[1])*FramesPerSecond + msf[2], nil
+}
+
+// FormatMSF formats frames as an mm:ss:ff time.
+func FormatMSF(frames int64) string {
+	return fmt.Sprintf("%02d:%02d:%02d", frames/FramesPerSecond/60, frames/FramesPerSecond%60, frames%FramesPerSecond)
+}
+
+// splitFields splits a line into whitespace-separated fields, keeping
+// double-quoted strings together.
+func splitFields(line string) []string {
+	var fields []string
+	line = strings.TrimSpace(line)
+	for line != "" {
+		if line[0] == '"' {
+			end := strings.IndexByte(line[1:], '"')
+			if end < 0 {
+				fields = append(fields, line[1:])
+				break
+			}
+			fields = append(fields, line[1:end+1])
+			line = strings.TrimSpace(line[end+2:])
+			continue
+		}
+		end := strings.IndexAny(line, " \t")
+		if end < 0 {
+			fields = append(fields, line)
+			break
+		}
+		fields = append(fields, line[:end])
+		line = strings.TrimSpace(line[end:])
+	}
+	return fields
+}
@@ -0,0 +1,151 @@
+package cue
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// testCue describes a disc with a MODE1 data track and two audio tracks
+// sharing one BIN, the second with a 2-second pregap stored in the file.
+const testCue = `REM COMMENT "test disc"
+FILE "Game (Track 1).bin" BINARY
+  TRACK 01 MODE1/2352
+    INDEX 01 00:00:00
+FILE "Game (Track 2).bin" BINARY
+  TRACK 02 AUDIO
+    PREGAP 00:02:00
+    INDEX 01 00:00:00
+  TRACK 03 audio
+    INDEX 00 00:04:00
+    INDEX 01 00:06:00
+    INDEX 02 00:07:00
+`
+
+func parseTestCue(t *testing.T, data string) *Sheet {
+	t.Helper()
+	sheet, err := Parse(strings.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	return sheet
+}
+
+func TestParse(t *testing.T) {
+	sheet := parseTestCue(t, testCue)
+
+	if len(sheet.Files) != 2 {
+		t.Fatalf("len(Files) = %d, want 2", len(sheet.Files))
+	}
+	if f := sheet.Files[0]; f.Name != "Game (Track 1).bin" || f.Type != "BINARY" {
+		t.Errorf("Files[0] = %q %q, want \"Game (Track 1).bin\" BINARY", f.Name, f.Type)
+	}
+
+	want := [][]Track{
+		{{Number: 1, Type: "MODE1/2352", Index0: -1, Index1: 0}},
+		{
+			{Number: 2, Type: "AUDIO", Pregap: 150, Index0: -1, Index1: 0},
+			{Number: 3, Type: "AUDIO", Index0: 300, Index1: 450},
+		},
+	}
+	for i, f := range sheet.Files {
+		if len(f.Tracks) != len(want[i]) {
+			t.Fatalf("len(Files[%d].Tracks) = %d, want %d", i, len(f.Tracks), len(want[i]))
+		}
+		for j, tr := range f.Tracks {
+			if tr != want[i][j] {
+				t.Errorf("Files[%d].Tracks[%d] = %+v, want %+v", i, j, tr, want[i][j])
+			}
+		}
+	}
+
+	if f, tr := sheet.FirstDataTrack(); f != &sheet.Files[0] || tr == nil || tr.Number != 1 {
+		t.Errorf("FirstDataTrack() = %+v, want track 1", tr)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"empty", ""},
+		{"track before file", "TRACK 01 MODE1/2352\n  INDEX 01 00:00:00\n"},
+		{"index before track", "FILE \"a.bin\" BINARY\n  INDEX 01 00:00:00\n"},
+		{"invalid track number", "FILE \"a.bin\" BINARY\n  TRACK 100 AUDIO\n    INDEX 01 00:00:00\n"},
+		{"invalid time", "FILE \"a.bin\" BINARY\n  TRACK 01 AUDIO\n    INDEX 01 00:60:00\n"},
+		{"track without start", "FILE \"a.bin\" BINARY\n  TRACK 01 AUDIO\n    INDEX 00 00:00:00\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(strings.NewReader(tt.data), int64(len(tt.data))); err == nil {
+				t.Error("Parse() expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestOpenTrack(t *testing.T) {
+	sheet := parseTestCue(t, testCue)
+	file := &sheet.Files[1]
+
+	bin := make([]byte, 500*2352)
+	bin[300*2352] = 3
+
+	// Track 2 ends where track 3's pregap starts
+	track2, err := file.OpenTrack(bytes.NewReader(bin), int64(len(bin)), 2)
+	if err != nil {
+		t.Fatalf("OpenTrack(2) error = %v", err)
+	}
+	if track2.Size() != 300*2352 {
+		t.Errorf("track 2 size = %d, want %d", track2.Size(), 300*2352)
+	}
+
+	// Track 3 starts at its pregap and runs to the end of the file
+	track3, err := file.OpenTrack(bytes.NewReader(bin), int64(len(bin)), 3)
+	if err != nil {
+		t.Fatalf("OpenTrack(3) error = %v", err)
+	}
+	if track3.Size() != 200*2352 {
+		t.Errorf("track 3 size = %d, want %d", track3.Size(), 200*2352)
+	}
+	first := make([]byte, 1)
+	if _, err := track3.ReadAt(first, 0); err != nil {
+		t.Fatalf("ReadAt() error = %v", err)
+	}
+	if first[0] != 3 {
+		t.Errorf("track 3 first byte = %d, want 3", first[0])
+	}
+
+	if _, err := file.OpenTrack(bytes.NewReader(bin), int64(len(bin)), 1); err == nil {
+		t.Error("OpenTrack(1) in second file expected error, got nil")
+	}
+}
+
+func TestParseMSF(t *testing.T) {
+	tests := []struct {
+		msf  string
+		want int64
+	}{
+		{"00:00:00", 0},
+		{"00:02:00", 150},
+		{"01:00:74", 4574},
+		{"80:00:00", 360000},
+	}
+	for _, tt := range tests {
+		got, err := ParseMSF(tt.msf)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseMSF(%q) = %d, %v, want %d", tt.msf, got, err, tt.want)
+		}
+		if got := FormatMSF(tt.want); got != tt.msf {
+			t.Errorf("FormatMSF(%d) = %q, want %q", tt.want, got, tt.msf)
+		}
+	}
+
+	for _, msf := range []string{"", "00:00", "00:00:75", "aa:00:00", "-1:00:00"} {
+		if _, err := ParseMSF(msf); err == nil {
+			t.Errorf("ParseMSF(%q) expected error, got nil", msf)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package cue
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/sargunv/rom-tools/lib/core"
+)
+
+// Disc is a CUE sheet together with its BIN files, as a core.DiscImage.
+type Disc struct {
+	sheet *Sheet
+	bins  map[string]bin
+}
+
+// bin is an opened BIN file, or why it couldn't be opened.
+type bin struct {
+	r    io.ReaderAt
+	size int64
+	err  error
+}
+
+// NewDisc returns the disc described by sheet. The open function opens a
+// file named in the sheet, returning its contents and size; closing it is
+// the caller's responsibility. Files that can't be opened only fail their
+// tracks' OpenTrack.
+func NewDisc(sheet *Sheet, open func(name string) (io.ReaderAt, int64, error)) *Disc {
+	d := &Disc{sheet: sheet, bins: make(map[string]bin)}
+	for _, file := range sheet.Files {
+		if _, ok := d.bins[file.Name]; ok {
+			continue
+		}
+		r, size, err := open(file.Name)
+		d.bins[file.Name] = bin{r, size, err}
+	}
+	return d
+}
+
+// Size returns the total size of the BIN files that could be opened.
+func (d *Disc) Size() int64 {
+	var total int64
+	for _, b := range d.bins {
+		total += b.size
+	}
+	return total
+}
+
+// Tracks lists the tracks of every file in order.
+func (d *Disc) Tracks() []core.DiscTrack {
+	var tracks []core.DiscTrack
+	for _, file := range d.sheet.Files {
+		for _, t := range file.Tracks {
+			tracks = append(tracks, core.DiscTrack{Number: t.Number, Data: t.IsData(), SectorSize: t.SectorSize()})
+		}
+	}
+	return tracks
+}
+
+// OpenTrack returns a reader for a track's sectors within its BIN file, as
+// File.OpenTrack does.
+func (d *Disc) OpenTrack(number int) (io.ReaderAt, int64, error) {
+	for i := range d.sheet.Files {
+		file := &d.sheet.Files[i]
+		for _, t := range file.Tracks {
+			if t.Number != number {
+				continue
+			}
+			b := d.bins[file.Name]
+			if b.err != nil {
+				return nil, 0, fmt.Errorf("open %s: %w", file.Name, b.err)
+			}
+			r, err := file.OpenTrack(b.r, b.size, number)
+			if err != nil {
+				return nil, 0, err
+			}
+			return r, r.Size(), nil
+		}
+	}
+	return nil, 0, fmt.Errorf("track %d not found", number)
+}
+
+// OpenUserData returns a reader for the user data of the first data track.
+func (d *Disc) OpenUserData() (io.ReaderAt, int64, error) {
+	_, track := d.sheet.FirstDataTrack()
+	if track == nil {
+		return nil, 0, core.ErrNoDataTrack
+	}
+	offset, ok := track.userDataOffset()
+	if !ok {
+		return nil, 0, fmt.Errorf("track %d: unsupported track type %s", track.Number, track.Type)
+	}
+	r, size, err := d.OpenTrack(track.Number)
+	if err != nil {
+		return nil, 0, err
+	}
+	userData, err := core.NewUserDataReader(r, size, track.SectorSize(), offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	return userData, userData.Size(), nil
+}
+
+// userDataOffset returns the offset of the user data within the data
+// track's sectors, past the sync pattern, header, and subheader that raw
+// and MODE2 sectors have.
+func (t *Track) userDataOffset() (int, bool) {
+	switch t.Type {
+	case "MODE1/2048":
+		return 0, true
+	case "MODE1/2352":
+		return 16, true
+	case "MODE2/2352", "CDI/2352":
+		return 24, true
+	case "MODE2/2336", "CDI/2336":
+		return 8, true
+	default:
+		return 0, false
+	}
+}
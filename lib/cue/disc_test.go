@@ -0,0 +1,52 @@
+package cue
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/sargunv/rom-tools/lib/core"
+)
+
+func TestDisc(t *testing.T) {
+	sheet := parseTestCue(t, testCue)
+
+	// Only the data track's BIN exists, with user data after each 16-byte
+	// sync pattern and header
+	const sectors = 4
+	data := make([]byte, sectors*2352)
+	for i := range sectors {
+		data[i*2352+16] = byte(i + 1)
+	}
+	disc := NewDisc(sheet, func(name string) (io.ReaderAt, int64, error) {
+		if name != "Game (Track 1).bin" {
+			return nil, 0, os.ErrNotExist
+		}
+		return bytes.NewReader(data), int64(len(data)), nil
+	})
+
+	if got := len(disc.Tracks()); got != 3 {
+		t.Errorf("len(Tracks()) = %d, want 3", got)
+	}
+	if disc.Size() != int64(len(data)) {
+		t.Errorf("Size() = %d, want %d", disc.Size(), len(data))
+	}
+
+	userData, size, err := disc.OpenUserData()
+	if err != nil {
+		t.Fatalf("OpenUserData() error = %v", err)
+	}
+	if size != sectors*core.UserDataSize {
+		t.Errorf("user data size = %d, want %d", size, sectors*core.UserDataSize)
+	}
+	buf := make([]byte, 1)
+	if _, err := userData.ReadAt(buf, 2*core.UserDataSize); err != nil || buf[0] != 3 {
+		t.Errorf("user data sector 2 starts with %d (error %v), want 3", buf[0], err)
+	}
+
+	if _, _, err := disc.OpenTrack(2); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("OpenTrack(2) with a missing BIN error = %v, want ErrNotExist", err)
+	}
+}
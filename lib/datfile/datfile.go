@@ -164,6 +164,7 @@ type Game struct {
 	Name       string
 	SourceFile string
 	IsBIOS     bool
+	IsDevice   bool // MAME only
 	CloneOf    string
 	RomOf      string
 	SampleOf   string
@@ -191,6 +192,7 @@ func (g *Game) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 		Name       string `xml:"name,attr"`
 		SourceFile string `xml:"sourcefile,attr"`
 		IsBIOS     string `xml:"isbios,attr"`
+		IsDevice   string `xml:"isdevice,attr"`
 		CloneOf    string `xml:"cloneof,attr"`
 		RomOf      string `xml:"romof,attr"`
 		SampleOf   string `xml:"sampleof,attr"`
@@ -219,6 +221,7 @@ func (g *Game) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 	g.Name = raw.Name
 	g.SourceFile = raw.SourceFile
 	g.IsBIOS = parseBool(raw.IsBIOS)
+	g.IsDevice = parseBool(raw.IsDevice)
 	g.CloneOf = raw.CloneOf
 	g.RomOf = raw.RomOf
 	g.SampleOf = raw.SampleOf
@@ -243,32 +246,34 @@ func (g *Game) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 
 // ROM represents a ROM file entry
 type ROM struct {
-	Name   string
-	Size   int64
-	CRC    string
-	SHA1   string
-	MD5    string
-	SHA256 string // No-Intro only
-	Merge  string
-	Status DumpStatus
-	Date   string
-	Serial string // No-Intro only
-	Header string // No-Intro only
+	Name     string
+	Size     int64
+	CRC      string
+	SHA1     string
+	MD5      string
+	SHA256   string // No-Intro only
+	Merge    string
+	Status   DumpStatus
+	Optional bool // MAME only
+	Date     string
+	Serial   string // No-Intro only
+	Header   string // No-Intro only
 }
 
 func (r *ROM) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 	type rawROM struct {
-		Name   string `xml:"name,attr"`
-		Size   string `xml:"size,attr"`
-		CRC    string `xml:"crc,attr"`
-		SHA1   string `xml:"sha1,attr"`
-		MD5    string `xml:"md5,attr"`
-		SHA256 string `xml:"sha256,attr"`
-		Merge  string `xml:"merge,attr"`
-		Status string `xml:"status,attr"`
-		Date   string `xml:"date,attr"`
-		Serial string `xml:"serial,attr"`
-		Header string `xml:"header,attr"`
+		Name     string `xml:"name,attr"`
+		Size     string `xml:"size,attr"`
+		CRC      string `xml:"crc,attr"`
+		SHA1     string `xml:"sha1,attr"`
+		MD5      string `xml:"md5,attr"`
+		SHA256   string `xml:"sha256,attr"`
+		Merge    string `xml:"merge,attr"`
+		Status   string `xml:"status,attr"`
+		Optional string `xml:"optional,attr"`
+		Date     string `xml:"date,attr"`
+		Serial   string `xml:"serial,attr"`
+		Header   string `xml:"header,attr"`
 	}
 	var raw rawROM
 	if err := d.DecodeElement(&raw, &start); err != nil {
@@ -283,6 +288,7 @@ func (r *ROM) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 	r.SHA256 = raw.SHA256
 	r.Merge = raw.Merge
 	r.Status = DumpStatus(raw.Status)
+	r.Optional = parseBool(raw.Optional)
 	r.Date = raw.Date
 	r.Serial = raw.Serial
 	r.Header = raw.Header
@@ -365,7 +371,8 @@ type Archive struct {
 	Name string `xml:"name,attr"`
 }
 
-// Parse reads and parses a DAT file (Logiqx XML format)
+// Parse reads and parses a DAT file (Logiqx XML format, or MAME -listxml
+// output)
 func Parse(path string) (*Datafile, error) {
 	f, err := os.Open(path)
 	if err != nil {
@@ -376,30 +383,27 @@ func Parse(path string) (*Datafile, error) {
 	return ParseReader(f)
 }
 
-// ParseReader parses a DAT file from a reader
+// ParseReader parses a DAT file from a reader.
+// For very large DATs, use NewDecoder to stream entries instead.
 func ParseReader(r io.Reader) (*Datafile, error) {
-	// xmlDatafile is used only for top-level parsing to handle both <game> and <machine> elements
-	type xmlDatafile struct {
-		XMLName  xml.Name `xml:"datafile"`
-		Header   Header   `xml:"header"`
-		Games    []Game   `xml:"game"`
-		Machines []Game   `xml:"machine"`
-	}
+	decoder := NewDecoder(r)
 
-	var xmlFile xmlDatafile
-	decoder := xml.NewDecoder(r)
-	if err := decoder.Decode(&xmlFile); err != nil {
-		return nil, fmt.Errorf("failed to parse DAT file: %w", err)
-	}
-
-	file := &Datafile{
-		Header: xmlFile.Header,
-		Games:  make([]Game, 0, len(xmlFile.Games)+len(xmlFile.Machines)),
+	var games []Game
+	for {
+		game, err := decoder.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		games = append(games, *game)
 	}
-	file.Games = append(file.Games, xmlFile.Games...)
-	file.Games = append(file.Games, xmlFile.Machines...)
 
-	return file, nil
+	return &Datafile{
+		Header: decoder.Header(),
+		Games:  games,
+	}, nil
 }
 
 func parseBool(s string) bool {
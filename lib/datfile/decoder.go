@@ -0,0 +1,105 @@
+package datfile
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+// Decoder reads games from a DAT file one at a time, without holding the
+// whole file in memory. This is useful for multi-hundred-MB DATs (e.g., MAME
+// or full Redump sets) where only a subset of fields is needed.
+type Decoder struct {
+	d          *xml.Decoder
+	header     Header
+	seenRoot   bool
+	seenHeader bool
+	done       bool
+}
+
+// NewDecoder creates a Decoder reading a DAT file from r. Both Logiqx XML
+// DATs and MAME -listxml output are accepted; the latter has no header, so
+// one is synthesized from the root element's build attribute.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{d: xml.NewDecoder(r)}
+}
+
+// Header returns the DAT header. The header precedes all games in the file,
+// so it is populated once Next has returned the first game (or io.EOF).
+func (d *Decoder) Header() Header {
+	return d.header
+}
+
+// Next returns the next game (or machine) entry in the DAT.
+// Returns io.EOF when there are no more entries.
+func (d *Decoder) Next() (*Game, error) {
+	if d.done {
+		return nil, io.EOF
+	}
+
+	for {
+		tok, err := d.d.Token()
+		if err == io.EOF {
+			d.done = true
+			if !d.seenRoot {
+				return nil, fmt.Errorf("failed to parse DAT file: no datafile element")
+			}
+			return nil, io.EOF
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse DAT file: %w", err)
+		}
+
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch start.Name.Local {
+		case "datafile":
+			d.seenRoot = true
+
+		case "mame":
+			d.seenRoot = true
+			d.header = Header{Name: "MAME", Version: attr(start, "build")}
+			d.seenHeader = true
+
+		case "header":
+			if d.seenHeader {
+				if err := d.d.Skip(); err != nil {
+					return nil, fmt.Errorf("failed to parse DAT file: %w", err)
+				}
+				continue
+			}
+			if err := d.d.DecodeElement(&d.header, &start); err != nil {
+				return nil, fmt.Errorf("failed to parse DAT header: %w", err)
+			}
+			d.seenHeader = true
+
+		case "game", "machine":
+			var game Game
+			if err := d.d.DecodeElement(&game, &start); err != nil {
+				return nil, fmt.Errorf("failed to parse DAT entry: %w", err)
+			}
+			return &game, nil
+
+		default:
+			if !d.seenRoot {
+				return nil, fmt.Errorf("failed to parse DAT file: unexpected root element %q", start.Name.Local)
+			}
+			if err := d.d.Skip(); err != nil {
+				return nil, fmt.Errorf("failed to parse DAT file: %w", err)
+			}
+		}
+	}
+}
+
+// attr returns the value of the named attribute of start, or "" if absent.
+func attr(start xml.StartElement, name string) string {
+	for _, a := range start.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,133 @@
+package datfile
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDecoder_NoIntro(t *testing.T) {
+	path := filepath.Join("testdata", "Nintendo - Pokemon Mini (20250407-153358).dat")
+
+	want, err := Parse(path)
+	if err != nil {
+		t.Fatalf("failed to parse No-Intro DAT: %v", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open DAT: %v", err)
+	}
+	defer f.Close()
+
+	decoder := NewDecoder(f)
+	count := 0
+	for {
+		game, err := decoder.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Next() error = %v", err)
+		}
+		if game.Name != want.Games[count].Name {
+			t.Errorf("game %d: expected %q, got %q", count, want.Games[count].Name, game.Name)
+		}
+		count++
+	}
+
+	if count != len(want.Games) {
+		t.Errorf("expected %d games, got %d", len(want.Games), count)
+	}
+	if decoder.Header().Name != "Nintendo - Pokemon Mini" {
+		t.Errorf("expected header Name 'Nintendo - Pokemon Mini', got %q", decoder.Header().Name)
+	}
+
+	// Subsequent calls keep returning EOF
+	if _, err := decoder.Next(); err != io.EOF {
+		t.Errorf("expected io.EOF after end, got %v", err)
+	}
+}
+
+func TestDecoder_HeaderBeforeFirstGame(t *testing.T) {
+	decoder := NewDecoder(strings.NewReader(`<?xml version="1.0"?>
+<datafile>
+	<header><name>Streamed</name></header>
+	<game name="A"><rom name="a.bin" size="1" crc="00000001"/></game>
+	<machine name="B"><rom name="b.bin" size="2" crc="00000002"/></machine>
+</datafile>`))
+
+	game, err := decoder.Next()
+	if err != nil {
+		t.Fatalf("Next() error = %v", err)
+	}
+	if decoder.Header().Name != "Streamed" {
+		t.Errorf("expected header Name 'Streamed', got %q", decoder.Header().Name)
+	}
+	if game.Name != "A" || len(game.ROMs) != 1 || game.ROMs[0].CRC != "00000001" {
+		t.Errorf("unexpected first game: %+v", game)
+	}
+
+	game, err = decoder.Next()
+	if err != nil {
+		t.Fatalf("Next() error = %v", err)
+	}
+	if game.Name != "B" || game.ROMs[0].Size != 2 {
+		t.Errorf("unexpected second game: %+v", game)
+	}
+
+	if _, err := decoder.Next(); err != io.EOF {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+}
+
+func TestDecoder_MAME(t *testing.T) {
+	dat, err := ParseReader(strings.NewReader(`<?xml version="1.0"?>
+<mame build="0.276 (mame0276)" debug="no" mameconfig="10">
+	<machine name="pacman" sourcefile="pacman/pacman.cpp" cloneof="puckman" romof="puckman">
+		<description>Pac-Man (Midway)</description>
+		<year>1980</year>
+		<manufacturer>Namco (Midway license)</manufacturer>
+		<rom name="pacman.6e" size="4096" crc="c1e6ab10" sha1="e87e059c5be45753f7e9f33dff851f16d6751181" region="maincpu" offset="0"/>
+		<rom name="82s123.7f" merge="82s123.7f" size="32" crc="2fc650bd" sha1="8d0268dee78e47c712202b0ec4f1f51109b1f2a5" region="proms" offset="0"/>
+		<rom name="opt.bin" size="16" crc="12345678" region="user1" offset="0" optional="yes"/>
+		<device_ref name="z80"/>
+		<driver status="good" emulation="good" savestate="supported"/>
+	</machine>
+	<machine name="z80" sourcefile="devices/cpu/z80/z80.cpp" isdevice="yes" runnable="no">
+		<description>Zilog Z80</description>
+	</machine>
+</mame>`))
+	if err != nil {
+		t.Fatalf("ParseReader() error = %v", err)
+	}
+
+	if dat.Header.Name != "MAME" || dat.Header.Version != "0.276 (mame0276)" {
+		t.Errorf("Header = %q %q, want %q %q", dat.Header.Name, dat.Header.Version, "MAME", "0.276 (mame0276)")
+	}
+	if len(dat.Games) != 2 {
+		t.Fatalf("expected 2 machines, got %d", len(dat.Games))
+	}
+
+	pacman := dat.Games[0]
+	if pacman.CloneOf != "puckman" || pacman.RomOf != "puckman" {
+		t.Errorf("CloneOf = %q, RomOf = %q, want puckman", pacman.CloneOf, pacman.RomOf)
+	}
+	if pacman.Description != "Pac-Man (Midway)" {
+		t.Errorf("Description = %q, want %q", pacman.Description, "Pac-Man (Midway)")
+	}
+	if len(pacman.ROMs) != 3 {
+		t.Fatalf("expected 3 ROMs, got %d", len(pacman.ROMs))
+	}
+	if pacman.ROMs[1].Merge != "82s123.7f" {
+		t.Errorf("ROMs[1].Merge = %q, want %q", pacman.ROMs[1].Merge, "82s123.7f")
+	}
+	if pacman.ROMs[0].Optional || !pacman.ROMs[2].Optional {
+		t.Errorf("Optional = %v, %v, want false, true", pacman.ROMs[0].Optional, pacman.ROMs[2].Optional)
+	}
+	if pacman.IsDevice || !dat.Games[1].IsDevice {
+		t.Errorf("IsDevice = %v, %v, want false, true", pacman.IsDevice, dat.Games[1].IsDevice)
+	}
+}
@@ -0,0 +1,107 @@
+package gdi
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/sargunv/rom-tools/lib/core"
+)
+
+// highDensityLBA is where a GD-ROM's high-density area starts.
+const highDensityLBA = 45000
+
+// rawDataOffset is the offset of the user data in a raw MODE1 sector, past
+// the sync pattern and header.
+const rawDataOffset = 16
+
+// Disc is a GDI file together with its track files, as a core.DiscImage.
+type Disc struct {
+	sheet *Sheet
+	files []trackFile
+}
+
+// trackFile is an opened track file, or why it couldn't be opened.
+type trackFile struct {
+	r    io.ReaderAt
+	size int64
+	err  error
+}
+
+// NewDisc returns the disc described by sheet. The open function opens a
+// track file named in the sheet, returning its contents and size; closing it
+// is the caller's responsibility. Files that can't be opened only fail their
+// track's OpenTrack.
+func NewDisc(sheet *Sheet, open func(name string) (io.ReaderAt, int64, error)) *Disc {
+	d := &Disc{sheet: sheet, files: make([]trackFile, len(sheet.Tracks))}
+	for i, t := range sheet.Tracks {
+		r, size, err := open(t.File)
+		d.files[i] = trackFile{r, size, err}
+	}
+	return d
+}
+
+// Size returns the total size of the track files that could be opened.
+func (d *Disc) Size() int64 {
+	var total int64
+	for _, f := range d.files {
+		total += f.size
+	}
+	return total
+}
+
+// Tracks lists the tracks in order.
+func (d *Disc) Tracks() []core.DiscTrack {
+	tracks := make([]core.DiscTrack, len(d.sheet.Tracks))
+	for i, t := range d.sheet.Tracks {
+		tracks[i] = core.DiscTrack{Number: t.Number, Data: t.Data, SectorSize: t.SectorSize}
+	}
+	return tracks
+}
+
+// OpenTrack returns a reader for a track's file.
+func (d *Disc) OpenTrack(number int) (io.ReaderAt, int64, error) {
+	for i, t := range d.sheet.Tracks {
+		if t.Number != number {
+			continue
+		}
+		f := d.files[i]
+		if f.err != nil {
+			return nil, 0, fmt.Errorf("open %s: %w", t.File, f.err)
+		}
+		return f.r, f.size, nil
+	}
+	return nil, 0, fmt.Errorf("track %d not found", number)
+}
+
+// OpenUserData returns a reader for the user data of the first data track in
+// the high-density area, which holds the IP.BIN boot header and filesystem.
+// Sheets without one fall back to the first data track.
+func (d *Disc) OpenUserData() (io.ReaderAt, int64, error) {
+	var track *Track
+	for i := range d.sheet.Tracks {
+		t := &d.sheet.Tracks[i]
+		if !t.Data {
+			continue
+		}
+		if track == nil || (t.LBA >= highDensityLBA && track.LBA < highDensityLBA) {
+			track = t
+		}
+	}
+	if track == nil {
+		return nil, 0, core.ErrNoDataTrack
+	}
+
+	r, size, err := d.OpenTrack(track.Number)
+	if err != nil {
+		return nil, 0, err
+	}
+	offset := 0
+	if track.SectorSize != core.UserDataSize {
+		offset = rawDataOffset
+	}
+	userData, err := core.NewUserDataReader(r, size, track.SectorSize, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	return userData, userData.Size(), nil
+}
@@ -0,0 +1,153 @@
+// Package gdi provides support for GDI files, which describe the tracks of a
+// Dreamcast GD-ROM image stored as one file per track.
+//
+// Use Parse to read the GDI file; each track's file holds its sectors from
+// its start LBA, with no pregap.
+//
+// GDI file example:
+//
+//	3
+//	1 0 4 2352 track01.bin 0
+//	2 450 0 2352 track02.raw 0
+//	3 45000 4 2352 track03.bin 0
+//
+// The first line is the track count. Each track line gives the track number,
+// start LBA, type (0 = audio, 4 = data), sector size, file name (quoted if it
+// contains spaces), and an unused offset.
+package gdi
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	typeAudio = 0
+	typeData  = 4
+)
+
+// Track describes one track of a GDI file.
+type Track struct {
+	// Number is the track number (1-99).
+	Number int `json:"number"`
+	// LBA is the track's start position.
+	LBA int64 `json:"lba"`
+	// Data is true for data tracks, false for audio.
+	Data bool `json:"data"`
+	// SectorSize is the size of a sector in the track's file (2352 or 2048).
+	SectorSize int `json:"sector_size"`
+	// File is the name of the track's file, relative to the GDI file.
+	File string `json:"file"`
+}
+
+// Sheet contains the parsed contents of a GDI file.
+type Sheet struct {
+	// Tracks lists the tracks in order.
+	Tracks []Track `json:"tracks"`
+}
+
+// Parse parses a GDI file.
+func Parse(r io.ReaderAt, size int64) (*Sheet, error) {
+	data := make([]byte, size)
+	if _, err := r.ReadAt(data, 0); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to read GDI file: %w", err)
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	numTracks := -1
+	sheet := &Sheet{}
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		if numTracks < 0 {
+			n, err := strconv.Atoi(line)
+			if err != nil || n < 1 || n > 99 {
+				return nil, fmt.Errorf("not a valid GDI file: invalid track count %q", line)
+			}
+			numTracks = n
+			continue
+		}
+
+		track, err := parseTrackLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("not a valid GDI file: line %d: %w", lineNum, err)
+		}
+		sheet.Tracks = append(sheet.Tracks, track)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read GDI file: %w", err)
+	}
+
+	if numTracks < 0 {
+		return nil, fmt.Errorf("not a valid GDI file: missing track count")
+	}
+	if len(sheet.Tracks) != numTracks {
+		return nil, fmt.Errorf("not a valid GDI file: %d tracks, want %d", len(sheet.Tracks), numTracks)
+	}
+	for i, t := range sheet.Tracks {
+		if t.Number != i+1 {
+			return nil, fmt.Errorf("not a valid GDI file: track %d out of order", t.Number)
+		}
+	}
+
+	return sheet, nil
+}
+
+// parseTrackLine parses a track line.
+func parseTrackLine(line string) (Track, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 5 {
+		return Track{}, fmt.Errorf("too few fields")
+	}
+
+	number, err := strconv.Atoi(fields[0])
+	if err != nil || number < 1 || number > 99 {
+		return Track{}, fmt.Errorf("invalid track number %q", fields[0])
+	}
+	lba, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil || lba < 0 {
+		return Track{}, fmt.Errorf("invalid LBA %q", fields[1])
+	}
+	trackType, err := strconv.Atoi(fields[2])
+	if err != nil || (trackType != typeAudio && trackType != typeData) {
+		return Track{}, fmt.Errorf("invalid track type %q", fields[2])
+	}
+	sectorSize, err := strconv.Atoi(fields[3])
+	if err != nil || (sectorSize != 2352 && sectorSize != 2048) {
+		return Track{}, fmt.Errorf("invalid sector size %q", fields[3])
+	}
+
+	// The file name may be quoted and contain spaces, so take it from the
+	// rest of the line, dropping the trailing offset
+	rest := line
+	for range 4 {
+		rest = strings.TrimSpace(rest)
+		rest = rest[strings.IndexAny(rest, " \t"):]
+	}
+	rest = strings.TrimSpace(rest)
+	var file string
+	if strings.HasPrefix(rest, `"`) {
+		end := strings.IndexByte(rest[1:], '"')
+		if end < 0 {
+			return Track{}, fmt.Errorf("unterminated file name")
+		}
+		file = rest[1 : end+1]
+	} else {
+		file = strings.Fields(rest)[0]
+	}
+
+	return Track{
+		Number:     number,
+		LBA:        lba,
+		Data:       trackType == typeData,
+		SectorSize: sectorSize,
+		File:       file,
+	}, nil
+}
@@ -0,0 +1,65 @@
+package gdi
+
+import (
+	"strings"
+	"testing"
+)
+
+// testGDI describes a disc with a low-density data track, an audio track,
+// and a high-density data track whose file name contains spaces.
+const testGDI = `3
+1 0 4 2352 track01.bin 0
+2 450 0 2352 track02.raw 0
+3 45000 4 2048 "Game (Track 3).iso" 0
+`
+
+func parseTestGDI(t *testing.T, data string) *Sheet {
+	t.Helper()
+	sheet, err := Parse(strings.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	return sheet
+}
+
+func TestParse(t *testing.T) {
+	sheet := parseTestGDI(t, testGDI)
+
+	want := []Track{
+		{Number: 1, LBA: 0, Data: true, SectorSize: 2352, File: "track01.bin"},
+		{Number: 2, LBA: 450, Data: false, SectorSize: 2352, File: "track02.raw"},
+		{Number: 3, LBA: 45000, Data: true, SectorSize: 2048, File: "Game (Track 3).iso"},
+	}
+	if len(sheet.Tracks) != len(want) {
+		t.Fatalf("len(Tracks) = %d, want %d", len(sheet.Tracks), len(want))
+	}
+	for i, tr := range sheet.Tracks {
+		if tr != want[i] {
+			t.Errorf("Tracks[%d] = %+v, want %+v", i, tr, want[i])
+		}
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"empty", ""},
+		{"invalid track count", "x\n1 0 4 2352 track01.bin 0\n"},
+		{"wrong track count", "2\n1 0 4 2352 track01.bin 0\n"},
+		{"too few fields", "1\n1 0 4 2352\n"},
+		{"invalid type", "1\n1 0 1 2352 track01.bin 0\n"},
+		{"invalid sector size", "1\n1 0 4 2336 track01.bin 0\n"},
+		{"out of order", "2\n2 0 4 2352 track02.bin 0\n1 450 0 2352 track01.raw 0\n"},
+		{"unterminated file name", "1\n1 0 4 2352 \"track 01.bin 0\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(strings.NewReader(tt.data), int64(len(tt.data))); err == nil {
+				t.Error("Parse() expected error, got nil")
+			}
+		})
+	}
+}
@@ -0,0 +1,236 @@
+// Package harddisk probes hard disk images, like MAME's hard disk CHDs, for
+// a partition table and the filesystems on it.
+//
+// Only the basics are recognized: an MBR partition table, and FAT12, FAT16,
+// and FAT32 volumes, either in a partition or filling the whole disk. NTFS
+// and exFAT volumes are named, but not read.
+//
+// MBR layout (sector 0, little-endian):
+//   - 0x1BE: Four 16-byte partition entries: status (0x80 = bootable), CHS
+//     start, type, CHS end, start LBA (4 bytes), and sector count (4 bytes)
+//   - 0x1FE: Signature (0x55 0xAA)
+//
+// FAT boot sector layout (little-endian):
+//   - 0x00: Jump instruction (0xEB xx 0x90 or 0xE9 xx xx)
+//   - 0x03: OEM name (8 bytes)
+//   - 0x0B: BIOS parameter block: bytes per sector, sectors per cluster,
+//     reserved sectors, FAT count, root entries, and sector counts
+//   - 0x2B (FAT12/16) or 0x47 (FAT32): Volume label (11 bytes), if the
+//     extended boot signature (0x29) precedes it
+//   - 0x1FE: Signature (0x55 0xAA)
+//
+// The FAT type is determined by the cluster count, as Microsoft's FAT
+// specification requires.
+//
+// Specification: https://en.wikipedia.org/wiki/Design_of_the_FAT_file_system
+package harddisk
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/sargunv/rom-tools/lib/chd"
+	"github.com/sargunv/rom-tools/lib/core"
+)
+
+const (
+	mbrPartitionsOffset = 0x1BE
+	mbrEntrySize        = 16
+	mbrEntries          = 4
+	signatureOffset     = 0x1FE
+	bootSectorSize      = 512
+)
+
+// Filesystem names.
+const (
+	FAT12 = "FAT12"
+	FAT16 = "FAT16"
+	FAT32 = "FAT32"
+	NTFS  = "NTFS"
+	ExFAT = "exFAT"
+)
+
+// Info describes a hard disk image.
+type Info struct {
+	// Geometry is the disk's geometry, if known, as from a CHD.
+	Geometry *chd.HardDiskInfo `json:"geometry,omitempty"`
+	// Partitions are the MBR partitions, empty if there's no partition table.
+	Partitions []Partition `json:"partitions,omitempty"`
+	// Volume is the filesystem filling the whole disk, for disks without a
+	// partition table.
+	Volume *Volume `json:"volume,omitempty"`
+}
+
+// Partition is an MBR partition.
+type Partition struct {
+	// Number is the partition's entry number (1-4).
+	Number int `json:"number"`
+	// Type is the partition type ID, like 0x0C for FAT32 (LBA).
+	Type byte `json:"type"`
+	// Bootable is true if the partition is marked active.
+	Bootable bool `json:"bootable"`
+	// StartLBA and Sectors give the partition's position and size.
+	StartLBA uint32 `json:"start_lba"`
+	Sectors  uint32 `json:"sectors"`
+	// Volume is the filesystem in the partition, if it was recognized.
+	Volume *Volume `json:"volume,omitempty"`
+}
+
+// Volume is a recognized filesystem.
+type Volume struct {
+	// Filesystem is the filesystem type: FAT12, FAT16, FAT32, NTFS, or exFAT.
+	Filesystem string `json:"filesystem"`
+	// OEMName is the name of the system that formatted the volume.
+	OEMName string `json:"oem_name,omitempty"`
+	// Label is the volume label from the boot sector, for FAT volumes.
+	Label string `json:"label,omitempty"`
+}
+
+// GamePlatform implements core.GameInfo. Hard disk images are nearly all
+// drives from arcade systems.
+func (i *Info) GamePlatform() core.Platform { return core.PlatformArcade }
+
+// GameTitle implements core.GameInfo, returning the first volume label.
+func (i *Info) GameTitle() string {
+	for _, v := range i.volumes() {
+		if v.Label != "" && v.Label != "NO NAME" {
+			return v.Label
+		}
+	}
+	return ""
+}
+
+// GameSerial implements core.GameInfo. Hard disks don't have serials.
+func (i *Info) GameSerial() string { return "" }
+
+// GameRegions implements core.GameInfo. Hard disks don't record a region.
+func (i *Info) GameRegions() []core.Region { return []core.Region{} }
+
+// volumes returns every recognized volume on the disk.
+func (i *Info) volumes() []*Volume {
+	var volumes []*Volume
+	if i.Volume != nil {
+		volumes = append(volumes, i.Volume)
+	}
+	for _, p := range i.Partitions {
+		if p.Volume != nil {
+			volumes = append(volumes, p.Volume)
+		}
+	}
+	return volumes
+}
+
+// Parse probes a hard disk image with the given sector size for a partition
+// table and filesystems. An image with neither returns an empty Info; only
+// failing to read it returns an error.
+func Parse(r io.ReaderAt, size int64, sectorSize int) (*Info, error) {
+	if sectorSize <= 0 {
+		sectorSize = bootSectorSize
+	}
+	if size < bootSectorSize {
+		return nil, fmt.Errorf("image too small: %d bytes", size)
+	}
+	sector := make([]byte, bootSectorSize)
+	if _, err := r.ReadAt(sector, 0); err != nil {
+		return nil, fmt.Errorf("failed to read sector 0: %w", err)
+	}
+
+	// A boot sector at the start of the disk means there's no partition
+	// table, which otherwise shares its signature
+	info := &Info{}
+	if volume := parseBootSector(sector); volume != nil {
+		info.Volume = volume
+		return info, nil
+	}
+	if sector[signatureOffset] != 0x55 || sector[signatureOffset+1] != 0xAA {
+		return info, nil
+	}
+
+	for i := range mbrEntries {
+		entry := sector[mbrPartitionsOffset+i*mbrEntrySize:][:mbrEntrySize]
+		p := Partition{
+			Number:   i + 1,
+			Type:     entry[4],
+			Bootable: entry[0] == 0x80,
+			StartLBA: binary.LittleEndian.Uint32(entry[8:12]),
+			Sectors:  binary.LittleEndian.Uint32(entry[12:16]),
+		}
+		if p.Type == 0 || p.Sectors == 0 || (entry[0] != 0 && entry[0] != 0x80) {
+			continue
+		}
+		offset := int64(p.StartLBA) * int64(sectorSize)
+		if offset+bootSectorSize <= size {
+			boot := make([]byte, bootSectorSize)
+			if _, err := r.ReadAt(boot, offset); err == nil {
+				p.Volume = parseBootSector(boot)
+			}
+		}
+		info.Partitions = append(info.Partitions, p)
+	}
+	return info, nil
+}
+
+// parseBootSector recognizes a volume boot sector, returning nil if it
+// isn't one.
+func parseBootSector(sector []byte) *Volume {
+	if sector[0] != 0xEB && sector[0] != 0xE9 {
+		return nil
+	}
+	oemName := strings.TrimRight(string(sector[3:11]), " \x00")
+	switch oemName {
+	case "NTFS":
+		return &Volume{Filesystem: NTFS, OEMName: oemName}
+	case "EXFAT":
+		return &Volume{Filesystem: ExFAT, OEMName: oemName}
+	}
+	if sector[signatureOffset] != 0x55 || sector[signatureOffset+1] != 0xAA {
+		return nil
+	}
+
+	bytesPerSector := int(binary.LittleEndian.Uint16(sector[0x0B:]))
+	sectorsPerCluster := int(sector[0x0D])
+	reservedSectors := int(binary.LittleEndian.Uint16(sector[0x0E:]))
+	fatCount := int(sector[0x10])
+	rootEntries := int(binary.LittleEndian.Uint16(sector[0x11:]))
+	totalSectors := int(binary.LittleEndian.Uint16(sector[0x13:]))
+	if totalSectors == 0 {
+		totalSectors = int(binary.LittleEndian.Uint32(sector[0x20:]))
+	}
+	fatSectors := int(binary.LittleEndian.Uint16(sector[0x16:]))
+	if fatSectors == 0 {
+		fatSectors = int(binary.LittleEndian.Uint32(sector[0x24:]))
+	}
+
+	switch bytesPerSector {
+	case 512, 1024, 2048, 4096:
+	default:
+		return nil
+	}
+	if sectorsPerCluster == 0 || sectorsPerCluster&(sectorsPerCluster-1) != 0 ||
+		reservedSectors == 0 || fatCount == 0 || fatSectors == 0 {
+		return nil
+	}
+
+	rootSectors := (rootEntries*32 + bytesPerSector - 1) / bytesPerSector
+	dataSectors := totalSectors - reservedSectors - fatCount*fatSectors - rootSectors
+	if dataSectors <= 0 {
+		return nil
+	}
+	volume := &Volume{OEMName: oemName}
+	labelOffset := 0x2B
+	switch clusters := dataSectors / sectorsPerCluster; {
+	case clusters < 4085:
+		volume.Filesystem = FAT12
+	case clusters < 65525:
+		volume.Filesystem = FAT16
+	default:
+		volume.Filesystem = FAT32
+		labelOffset = 0x47
+	}
+	if sector[labelOffset-5] == 0x29 { // extended boot signature, before the serial number
+		volume.Label = strings.TrimRight(string(sector[labelOffset:labelOffset+11]), " \x00")
+	}
+	return volume
+}
@@ -0,0 +1,86 @@
+package harddisk
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// bootSector returns a FAT boot sector with the given geometry and label.
+func bootSector(totalSectors uint32, sectorsPerCluster byte, fatSectors uint16, label string) []byte {
+	b := make([]byte, bootSectorSize)
+	copy(b, []byte{0xEB, 0x3C, 0x90})
+	copy(b[3:], "MSDOS5.0")
+	binary.LittleEndian.PutUint16(b[0x0B:], 512)
+	b[0x0D] = sectorsPerCluster
+	binary.LittleEndian.PutUint16(b[0x0E:], 1)
+	b[0x10] = 2
+	binary.LittleEndian.PutUint16(b[0x11:], 512)
+	if totalSectors < 0x10000 {
+		binary.LittleEndian.PutUint16(b[0x13:], uint16(totalSectors))
+	} else {
+		binary.LittleEndian.PutUint32(b[0x20:], totalSectors)
+	}
+	binary.LittleEndian.PutUint16(b[0x16:], fatSectors)
+	b[0x26] = 0x29
+	copy(b[0x2B:], label+"           "[len(label):])
+	b[0x1FE], b[0x1FF] = 0x55, 0xAA
+	return b
+}
+
+func TestParse(t *testing.T) {
+	t.Run("MBR with a FAT16 partition", func(t *testing.T) {
+		const start = 63
+		disk := make([]byte, (start+1)*512)
+		entry := disk[mbrPartitionsOffset:]
+		entry[0] = 0x80
+		entry[4] = 0x06
+		binary.LittleEndian.PutUint32(entry[8:], start)
+		binary.LittleEndian.PutUint32(entry[12:], 100000)
+		disk[0x1FE], disk[0x1FF] = 0x55, 0xAA
+		copy(disk[start*512:], bootSector(100000, 4, 100, "GAME DATA"))
+
+		info, err := Parse(bytes.NewReader(disk), int64(len(disk)), 512)
+		if err != nil {
+			t.Fatalf("Parse() error = %v", err)
+		}
+		if len(info.Partitions) != 1 {
+			t.Fatalf("Partitions = %+v, want 1", info.Partitions)
+		}
+		p := info.Partitions[0]
+		if p.Number != 1 || p.Type != 0x06 || !p.Bootable || p.StartLBA != start || p.Sectors != 100000 {
+			t.Errorf("Partitions[0] = %+v", p)
+		}
+		if p.Volume == nil || p.Volume.Filesystem != FAT16 || p.Volume.Label != "GAME DATA" {
+			t.Errorf("Partitions[0].Volume = %+v, want FAT16 labeled GAME DATA", p.Volume)
+		}
+		if info.GameTitle() != "GAME DATA" {
+			t.Errorf("GameTitle() = %q, want GAME DATA", info.GameTitle())
+		}
+	})
+
+	t.Run("unpartitioned FAT12", func(t *testing.T) {
+		disk := bootSector(2880, 1, 9, "NO NAME")
+		info, err := Parse(bytes.NewReader(disk), int64(len(disk)), 512)
+		if err != nil {
+			t.Fatalf("Parse() error = %v", err)
+		}
+		if info.Volume == nil || info.Volume.Filesystem != FAT12 || len(info.Partitions) != 0 {
+			t.Errorf("Parse() = %+v, want an unpartitioned FAT12 volume", info)
+		}
+		if info.GameTitle() != "" {
+			t.Errorf("GameTitle() = %q, want empty for NO NAME", info.GameTitle())
+		}
+	})
+
+	t.Run("unrecognized", func(t *testing.T) {
+		disk := make([]byte, 4096)
+		info, err := Parse(bytes.NewReader(disk), int64(len(disk)), 512)
+		if err != nil {
+			t.Fatalf("Parse() error = %v", err)
+		}
+		if info.Volume != nil || len(info.Partitions) != 0 {
+			t.Errorf("Parse() = %+v, want nothing recognized", info)
+		}
+	})
+}
@@ -0,0 +1,111 @@
+// Package hashcache stores calculated ROM hashes between runs, so that
+// rescanning a large library only hashes the files that changed.
+//
+// Entries are keyed by a file's absolute path, and are only used while the
+// file's size and modification time are unchanged. They're kept in a single
+// bbolt database, which is safe to use from several goroutines, but is locked
+// by the process that opens it.
+package hashcache
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sargunv/rom-tools/lib/core"
+	bolt "go.etcd.io/bbolt"
+)
+
+// bucket is the bbolt bucket holding the entries.
+var bucket = []byte("hashes")
+
+// Cache is a persistent cache of file hashes.
+type Cache struct {
+	db *bolt.DB
+}
+
+// entry is a cached file's hashes, and the size and modification time they
+// were calculated at.
+type entry struct {
+	Size    int64       `json:"size"`
+	ModTime int64       `json:"mod_time"` // Unix nanoseconds
+	Hashes  core.Hashes `json:"hashes"`
+}
+
+// DefaultPath returns the default cache database path, in the user's cache
+// directory.
+func DefaultPath() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user cache directory: %w", err)
+	}
+	return filepath.Join(cacheDir, "rom-tools", "hashes", "v1", "hashes.db"), nil
+}
+
+// Open opens the cache database at path, creating it if needed. It fails if
+// another process has the database open.
+func Open(path string) (*Cache, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	db, err := bolt.Open(path, 0644, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open hash cache: %w", err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(bucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize hash cache: %w", err)
+	}
+	return &Cache{db: db}, nil
+}
+
+// Close closes the cache database.
+func (c *Cache) Close() error {
+	return c.db.Close()
+}
+
+// Get returns the cached hashes of the file at path, if they were calculated
+// when it had the given size and modification time.
+func (c *Cache) Get(path string, size int64, modTime time.Time) (core.Hashes, bool) {
+	var e entry
+	err := c.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(bucket).Get([]byte(path))
+		if data == nil {
+			return fmt.Errorf("not cached")
+		}
+		return json.Unmarshal(data, &e)
+	})
+	if err != nil || e.Size != size || e.ModTime != modTime.UnixNano() {
+		return nil, false
+	}
+	return e.Hashes, true
+}
+
+// Put caches the hashes of the file at path, calculated when it had the
+// given size and modification time.
+func (c *Cache) Put(path string, size int64, modTime time.Time, hashes core.Hashes) error {
+	data, err := json.Marshal(entry{Size: size, ModTime: modTime.UnixNano(), Hashes: hashes})
+	if err != nil {
+		return fmt.Errorf("failed to marshal cache entry: %w", err)
+	}
+	return c.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).Put([]byte(path), data)
+	})
+}
+
+// Clear removes every entry.
+func (c *Cache) Clear() error {
+	return c.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.DeleteBucket(bucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucket(bucket)
+		return err
+	})
+}
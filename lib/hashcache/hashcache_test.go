@@ -0,0 +1,65 @@
+package hashcache
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sargunv/rom-tools/lib/core"
+)
+
+func TestCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache", "hashes.db")
+	c, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	modTime := time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC)
+	hashes := core.Hashes{core.HashSHA1: "aaaa", core.HashCRC32: "12345678"}
+	if err := c.Put("/roms/game.nes", 100, modTime, hashes); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		size    int64
+		modTime time.Time
+		want    bool
+	}{
+		{"unchanged", "/roms/game.nes", 100, modTime, true},
+		{"resized", "/roms/game.nes", 101, modTime, false},
+		{"modified", "/roms/game.nes", 100, modTime.Add(time.Nanosecond), false},
+		{"other file", "/roms/other.nes", 100, modTime, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := c.Get(tt.path, tt.size, tt.modTime)
+			if ok != tt.want {
+				t.Fatalf("Get() ok = %v, want %v", ok, tt.want)
+			}
+			if ok && got[core.HashSHA1] != "aaaa" {
+				t.Errorf("Get() = %v, want %v", got, hashes)
+			}
+		})
+	}
+
+	// Entries survive reopening, until cleared
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if c, err = Open(path); err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer c.Close()
+	if _, ok := c.Get("/roms/game.nes", 100, modTime); !ok {
+		t.Error("Get() after reopening found nothing")
+	}
+	if err := c.Clear(); err != nil {
+		t.Fatalf("Clear() error = %v", err)
+	}
+	if _, ok := c.Get("/roms/game.nes", 100, modTime); ok {
+		t.Error("Get() after Clear() found an entry")
+	}
+}
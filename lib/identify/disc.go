@@ -2,16 +2,33 @@ package identify
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
 	"io"
+	"path/filepath"
+	"strings"
 
+	"github.com/sargunv/rom-tools/lib/ccd"
 	"github.com/sargunv/rom-tools/lib/chd"
 	"github.com/sargunv/rom-tools/lib/core"
+	"github.com/sargunv/rom-tools/lib/cso"
+	"github.com/sargunv/rom-tools/lib/cue"
+	"github.com/sargunv/rom-tools/lib/gdi"
+	"github.com/sargunv/rom-tools/lib/harddisk"
 	"github.com/sargunv/rom-tools/lib/iso9660"
+	"github.com/sargunv/rom-tools/lib/nrg"
+	"github.com/sargunv/rom-tools/lib/roms/atari/jaguar"
+	"github.com/sargunv/rom-tools/lib/roms/nec/pce"
+	"github.com/sargunv/rom-tools/lib/roms/nintendo/gcz"
+	"github.com/sargunv/rom-tools/lib/roms/nintendo/nkit"
 	"github.com/sargunv/rom-tools/lib/roms/playstation/cnf"
 	"github.com/sargunv/rom-tools/lib/roms/playstation/sfo"
 	"github.com/sargunv/rom-tools/lib/roms/sega/dreamcast"
 	"github.com/sargunv/rom-tools/lib/roms/sega/md"
 	"github.com/sargunv/rom-tools/lib/roms/sega/saturn"
+	"github.com/sargunv/rom-tools/lib/roms/snk/neogeocd"
+	"github.com/sargunv/rom-tools/lib/roms/threedo"
+	"github.com/sargunv/rom-tools/lib/udf"
 )
 
 func identifyCHD(r io.ReaderAt, size int64) (core.GameInfo, core.Hashes, error) {
@@ -20,30 +37,200 @@ func identifyCHD(r io.ReaderAt, size int64) (core.GameInfo, core.Hashes, error)
 		return nil, nil, err
 	}
 
+	// Legacy v1-v3 CHDs carry an MD5 of the raw data, and v1/v2 CHDs carry
+	// nothing else, for matching old disk entries that only list an MD5
 	header := reader.Header()
-	hashes := core.Hashes{
-		core.HashCHDUncompressedSHA1: header.RawSHA1,
-		core.HashCHDCompressedSHA1:   header.SHA1,
-	}
-
-	// Find first non-audio track and try to identify its content.
-	// Errors are intentionally ignored: many disc formats (Sega CD, Saturn,
-	// Dreamcast) use custom headers rather than ISO9660. Failure to parse
-	// just means we return CHD hashes without game metadata, which is fine
-	// since CHD hashes are the primary identifier for DAT matching.
-	for _, track := range reader.Tracks {
-		if track.Type != "AUDIO" {
-			content, _, _ := identifyISO9660(track.Open(), track.Size())
-			if content != nil {
-				return content, hashes, nil
-			}
-			break
+	hashes := make(core.Hashes)
+	if header.SHA1 != "" {
+		hashes[core.HashCHDUncompressedSHA1] = header.RawSHA1
+		hashes[core.HashCHDCompressedSHA1] = header.SHA1
+	}
+	if header.MD5 != "" {
+		hashes[core.HashCHDMD5] = header.MD5
+	}
+
+	// A/V CHDs hold video, which the AVAV metadata describes
+	if reader.AV != nil {
+		return reader.AV, hashes, nil
+	}
+
+	// Hard disks have no tracks, but may have partitions
+	if reader.HardDisk != nil {
+		info, err := harddisk.Parse(reader, reader.Size(), reader.HardDisk.BytesPerSector)
+		if err != nil {
+			return nil, hashes, nil
+		}
+		info.Geometry = reader.HardDisk
+		return info, hashes, nil
+	}
+
+	// Failure to identify the content just means we return CHD hashes without
+	// game metadata, which is fine since CHD hashes are the primary
+	// identifier for DAT matching.
+	return identifyDisc(chd.NewDisc(reader)), hashes, nil
+}
+
+func identifyCSO(r io.ReaderAt, size int64) (core.GameInfo, core.Hashes, error) {
+	reader, err := cso.NewReader(r, size)
+	if err != nil {
+		return nil, nil, err
+	}
+	return identifyDisc(iso9660.NewDisc(reader, reader.Size())), nil, nil
+}
+
+// identifyNKit identifies NKit disc images, reporting the original image's
+// CRC32 since the NKit image itself won't match any DAT.
+func identifyNKit(r io.ReaderAt, size int64) (core.GameInfo, core.Hashes, error) {
+	info, err := nkit.Parse(r, size)
+	if err != nil {
+		return nil, nil, err
+	}
+	return info, core.Hashes{core.HashNKitCRC32: fmt.Sprintf("%08x", info.ISOCRC32)}, nil
+}
+
+func identifyGCZ(r io.ReaderAt, size int64) (core.GameInfo, core.Hashes, error) {
+	reader, err := gcz.NewReader(r, size)
+	if err != nil {
+		return nil, nil, err
+	}
+	if info, hashes, err := identifyNKit(reader, reader.Size()); err == nil {
+		return info, hashes, nil
+	}
+	return WrapParser(gcz.Parse)(r, size)
+}
+
+func identifyCCD(r io.ReaderAt, size int64, name string, open openFunc) (core.GameInfo, core.Hashes, error) {
+	sheet, err := ccd.Parse(r, size)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// A missing .img leaves the control file identified by hash only
+	imgName := strings.TrimSuffix(filepath.Base(name), filepath.Ext(name)) + ".img"
+	img, imgSize, err := open(imgName)
+	if err != nil {
+		return nil, nil, nil
+	}
+	defer img.Close()
+	return identifyDisc(ccd.NewDisc(sheet, img, imgSize)), nil, nil
+}
+
+// identifyCUE identifies a CUE sheet by its BIN files. A missing BIN leaves
+// the sheet identified by hash only.
+func identifyCUE(r io.ReaderAt, size int64, name string, open openFunc) (core.GameInfo, core.Hashes, error) {
+	sheet, err := cue.Parse(r, size)
+	if err != nil {
+		return nil, nil, err
+	}
+	files := &companionFiles{open: open}
+	defer files.Close()
+	return identifyDisc(cue.NewDisc(sheet, files.Open)), nil, nil
+}
+
+// identifyGDI identifies a GDI file by its track files.
+func identifyGDI(r io.ReaderAt, size int64, name string, open openFunc) (core.GameInfo, core.Hashes, error) {
+	sheet, err := gdi.Parse(r, size)
+	if err != nil {
+		return nil, nil, err
+	}
+	files := &companionFiles{open: open}
+	defer files.Close()
+	return identifyDisc(gdi.NewDisc(sheet, files.Open)), nil, nil
+}
+
+func identifyNRG(r io.ReaderAt, size int64) (core.GameInfo, core.Hashes, error) {
+	image, err := nrg.Parse(r, size)
+	if err != nil {
+		return nil, nil, err
+	}
+	return identifyDisc(nrg.NewDisc(image, r)), nil, nil
+}
+
+// companionFiles opens the companion files of a disc image, keeping them to
+// be closed once it's identified.
+type companionFiles struct {
+	open  openFunc
+	files []io.Closer
+}
+
+// Open opens a companion file.
+func (c *companionFiles) Open(name string) (io.ReaderAt, int64, error) {
+	f, size, err := c.open(name)
+	if err != nil {
+		return nil, 0, err
+	}
+	c.files = append(c.files, f)
+	return f, size, nil
+}
+
+// Close closes the opened files.
+func (c *companionFiles) Close() {
+	for _, f := range c.files {
+		f.Close()
+	}
+}
+
+// identifyDisc identifies a disc image from the user data of its boot track,
+// or from its audio tracks if it has none. Returns nil if the content isn't
+// recognized: some discs (e.g., PC Engine CD) have no filesystem at all, and
+// others may be unsupported.
+func identifyDisc(disc core.DiscImage) core.GameInfo {
+	r, size, err := disc.OpenUserData()
+	if errors.Is(err, core.ErrNoDataTrack) {
+		return identifyAudioTracks(disc)
+	}
+	if err != nil {
+		return nil
+	}
+
+	// GD-ROM boot tracks address their filesystem from LBA 45000, so they
+	// can only be identified by their system area
+	if info := identifySystemArea(r); info != nil {
+		return info
+	}
+	info, _, _ := identifyDataTrack(r, size)
+	return info
+}
+
+// identifyAudioTracks identifies discs with only audio tracks. Jaguar CD
+// discs keep their boot program in an audio track, so each track is searched
+// for the boot header. Tracks that can't be opened are skipped.
+func identifyAudioTracks(disc core.DiscImage) core.GameInfo {
+	for _, track := range disc.Tracks() {
+		r, size, err := disc.OpenTrack(track.Number)
+		if err != nil {
+			continue
+		}
+		if info, err := jaguar.ParseCD(r, size); err == nil {
+			return info
 		}
 	}
+	return nil
+}
 
-	// Try raw CHD access (for hard disk images, etc.)
-	content, _, _ := identifyISO9660(reader, reader.Size())
-	return content, hashes, nil
+// identifyDataTrack identifies a disc's first data track, which is usually an
+// ISO 9660 filesystem. DVD images (e.g., PS2) may only be identifiable
+// through UDF. PC Engine CD data tracks have no filesystem, and are
+// identified by their IPL sector instead; 3DO data tracks use the Opera
+// filesystem.
+func identifyDataTrack(r io.ReaderAt, size int64) (core.GameInfo, core.Hashes, error) {
+	info, hashes, err := identifyISO9660(r, size)
+	if err == nil && info != nil {
+		return info, hashes, nil
+	}
+	if info, _, err := identifyUDF(r, size); err == nil && info != nil {
+		return info, nil, nil
+	}
+	if err == nil {
+		return nil, nil, nil
+	}
+	if info, err := pce.ParseCD(r, size); err == nil {
+		return info, nil, nil
+	}
+	if info, err := threedo.Parse(r, size); err == nil {
+		return info, nil, nil
+	}
+	return nil, nil, err
 }
 
 func identifyISO9660(r io.ReaderAt, size int64) (core.GameInfo, core.Hashes, error) {
@@ -52,43 +239,122 @@ func identifyISO9660(r io.ReaderAt, size int64) (core.GameInfo, core.Hashes, err
 		return nil, nil, err
 	}
 
-	// Try to read system area (sector 0) for Sega CD/Saturn/Dreamcast identification
-	systemArea := make([]byte, 2048)
-	if _, err := reader.ReadAt(systemArea, 0); err == nil {
-		if info, err := md.ParseCD(bytes.NewReader(systemArea), int64(len(systemArea))); err == nil {
-			return info, nil, nil
+	if info := identifySystemArea(reader); info != nil {
+		return info, nil, nil
+	}
+
+	// Neo Geo CD discs have no system area header, only boot files
+	if info, err := neogeocd.Parse(reader.FS()); err == nil {
+		return info, nil, nil
+	}
+
+	// Valid ISO9660 filesystem but no recognized game content is expected
+	// for data discs, unsupported platforms, etc. Returning nil allows the
+	// caller to try other parsers or fall back to hash-only identification,
+	// which is sufficient for DAT matching.
+	if info := identifyDiscFiles(reader.OpenFile); info != nil {
+		return info, nil, nil
+	}
+	return identifyPS1Executable(reader), nil, nil
+}
+
+// identifySystemArea identifies Sega CD, Saturn, and Dreamcast discs by the
+// boot header in their system area, the first sector of user data in r.
+func identifySystemArea(r io.ReaderAt) core.GameInfo {
+	systemArea := make([]byte, core.UserDataSize)
+	if _, err := r.ReadAt(systemArea, 0); err != nil {
+		return nil
+	}
+	if info, err := md.ParseCD(bytes.NewReader(systemArea), int64(len(systemArea))); err == nil {
+		return info
+	}
+	if info, err := saturn.Parse(bytes.NewReader(systemArea), int64(len(systemArea))); err == nil {
+		return info
+	}
+	if info, err := dreamcast.Parse(bytes.NewReader(systemArea), int64(len(systemArea))); err == nil {
+		return info
+	}
+	return nil
+}
+
+// identifyPS1Executable identifies PS1 discs without SYSTEM.CNF (some demo
+// and prototype discs) by their boot executable: a serial-named file in the
+// root directory, or else PSX.EXE. Returns nil if neither is a PS-X EXE.
+func identifyPS1Executable(reader *iso9660.Reader) core.GameInfo {
+	entries, err := reader.ReadDir("")
+	if err != nil {
+		return nil
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir && cnf.IsDiscID(entry.Name) {
+			names = append(names, entry.Name)
 		}
-		if info, err := saturn.Parse(bytes.NewReader(systemArea), int64(len(systemArea))); err == nil {
-			return info, nil, nil
+	}
+	names = append(names, cnf.DefaultExecutable)
+
+	for _, name := range names {
+		fileReader, fileSize, err := reader.OpenFile(name)
+		if err != nil {
+			continue
 		}
-		if info, err := dreamcast.Parse(bytes.NewReader(systemArea), int64(len(systemArea))); err == nil {
-			return info, nil, nil
+		if info, err := cnf.ParseExecutable(fileReader, fileSize, name); err == nil {
+			return info
 		}
 	}
+	return nil
+}
 
-	// Try to read SYSTEM.CNF (PS1/PS2 discs)
-	if fileReader, fileSize, err := reader.OpenFile("SYSTEM.CNF"); err == nil {
-		data := make([]byte, fileSize)
-		if _, err := fileReader.ReadAt(data, 0); err == nil {
-			if info, err := cnf.Parse(bytes.NewReader(data), fileSize); err == nil {
-				return info, nil, nil
-			}
-		}
+// identifyUDF identifies UDF-only DVD images (those without an ISO 9660
+// bridge filesystem) by their well-known files.
+func identifyUDF(r io.ReaderAt, size int64) (core.GameInfo, core.Hashes, error) {
+	reader, err := udf.NewReader(r, size)
+	if err != nil {
+		return nil, nil, err
 	}
+	return identifyDiscFiles(reader.OpenFile), nil, nil
+}
 
-	// Try to read PSP_GAME/PARAM.SFO (PSP/PS3/Vita/PS4 discs)
-	if fileReader, fileSize, err := reader.OpenFile("PSP_GAME/PARAM.SFO"); err == nil {
+// identifyDiscFiles identifies a disc filesystem by its well-known files,
+// opened with open. Returns nil if none are recognized.
+func identifyDiscFiles(open func(path string) (io.ReaderAt, int64, error)) core.GameInfo {
+	parsers := []struct {
+		path  string
+		parse IdentifyFunc
+	}{
+		{"SYSTEM.CNF", WrapParser(cnf.Parse)},         // PS1/PS2 discs
+		{"PSP_GAME/PARAM.SFO", WrapParser(sfo.Parse)}, // PSP/PS3/Vita/PS4 discs
+	}
+	for _, p := range parsers {
+		fileReader, fileSize, err := open(p.path)
+		if err != nil {
+			continue
+		}
 		data := make([]byte, fileSize)
-		if _, err := fileReader.ReadAt(data, 0); err == nil {
-			if info, err := sfo.Parse(bytes.NewReader(data), fileSize); err == nil {
-				return info, nil, nil
+		if _, err := fileReader.ReadAt(data, 0); err != nil {
+			continue
+		}
+		if info, _, err := p.parse(bytes.NewReader(data), fileSize); err == nil {
+			if disc, ok := info.(*cnf.Info); ok && disc.GamePlatform() == core.PlatformPS2 {
+				disc.BootELF = readBootELF(disc, open)
 			}
+			return info
 		}
 	}
+	return nil
+}
 
-	// Valid ISO9660 filesystem but no recognized game content.
-	// This is expected for data discs, unsupported platforms, etc.
-	// Returning nil allows the caller to try other parsers or fall back
-	// to hash-only identification, which is sufficient for DAT matching.
-	return nil, nil, nil
+// readBootELF reads the ELF header of a PS2 disc's boot executable, opened
+// with open. Returns nil if it's missing or not a PS2 ELF.
+func readBootELF(disc *cnf.Info, open func(path string) (io.ReaderAt, int64, error)) *cnf.ELFInfo {
+	fileReader, fileSize, err := open(disc.BootFile())
+	if err != nil {
+		return nil
+	}
+	elf, err := cnf.ParseELF(fileReader, fileSize)
+	if err != nil {
+		return nil
+	}
+	return elf
 }
@@ -0,0 +1,233 @@
+package identify
+
+import (
+	"cmp"
+	"maps"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/sargunv/rom-tools/lib/core"
+	"github.com/sargunv/rom-tools/lib/roms/nintendo/gcm"
+	"github.com/sargunv/rom-tools/lib/roms/playstation/cnf"
+	"github.com/sargunv/rom-tools/lib/roms/playstation/sfo"
+	"github.com/sargunv/rom-tools/lib/roms/sega/dreamcast"
+	"github.com/sargunv/rom-tools/lib/roms/sega/md"
+	"github.com/sargunv/rom-tools/lib/roms/sega/saturn"
+	"github.com/sargunv/rom-tools/lib/roms/xbox/xbe"
+)
+
+// Group is a game of several discs, found among a container's items.
+type Group struct {
+	Title    string        `json:"title"`              // the name of the discs without their disc numbers, or the game's title
+	Platform core.Platform `json:"platform,omitempty"` // platform of the discs, if identified
+	Total    int           `json:"total,omitempty"`    // number of discs the game has, if known
+	Discs    []Disc        `json:"discs"`              // discs found, in order
+}
+
+// Disc is an item that is one disc of a Group.
+type Disc struct {
+	Number int    `json:"number"` // 1-based disc number
+	Item   string `json:"item"`   // name of the item
+}
+
+var (
+	// discTagPattern matches disc numbers in names, like "(Disc 2)" or
+	// "(Disc 2 of 3)" of No-Intro and Redump, or "[CD2]".
+	discTagPattern = regexp.MustCompile(`(?i)\s*[(\[](?:disc|disk|cd)\s*(\d+)(?:\s*of\s*(\d+))?[)\]]`)
+
+	// discTitlePattern matches disc numbers in header titles, like "DISC 2".
+	discTitlePattern = regexp.MustCompile(`(?i)[\s-]*\b(?:disc|disk|cd)\s*-?\s*\d+\b`)
+
+	// discDevicePattern matches the disc number and count of Sega's device
+	// info, like "GD-ROM1/2" or "CD-1/2".
+	discDevicePattern = regexp.MustCompile(`(\d+)/(\d+)\s*$`)
+
+	// serialPattern splits PlayStation serials, like "SLUS_123.45", into
+	// their prefix and number.
+	serialPattern = regexp.MustCompile(`^([A-Z]{4})[_-](\d{3})\.?(\d{2})$`)
+)
+
+// groupDiscs returns the multi-disc games among items, which are marked
+// primary. Discs are found by, in order of preference:
+//
+//   - a disc number in the item's name, like "(Disc 2)", grouping items whose
+//     names are the same without it
+//   - a disc number in the game's header (GameCube, Xbox, PS3 and PSP, Sega
+//     CD, Saturn, and Dreamcast discs), grouping games of the same serial, or
+//     title if they have different serials
+//   - consecutive serials of PlayStation and PlayStation 2 discs, whose
+//     SYSTEM.CNF has no disc number, if they're in the same folder
+//
+// Only sets of at least two discs are returned, in order of their first disc
+// among items.
+func groupDiscs(items []Item) []Group {
+	var groups []*Group
+	byKey := make(map[string]*Group)
+	add := func(key, title string, platform core.Platform, number, total int, item *Item) {
+		g, ok := byKey[key]
+		if !ok {
+			g = &Group{Title: title, Platform: platform}
+			byKey[key] = g
+			groups = append(groups, g)
+		}
+		g.Total = max(g.Total, total)
+		g.Discs = append(g.Discs, Disc{Number: number, Item: item.Name})
+	}
+
+	families := make(map[string][]*Item)
+	for i := range items {
+		item := &items[i]
+		if !item.Primary {
+			continue
+		}
+		var platform core.Platform
+		if item.Game != nil {
+			platform = item.Game.GamePlatform()
+		}
+
+		base := filepath.Base(item.Name)
+		base = strings.TrimSuffix(base, filepath.Ext(base))
+		if m := discTagPattern.FindStringSubmatchIndex(base); m != nil {
+			number, _ := strconv.Atoi(base[m[2]:m[3]])
+			total := 0
+			if m[4] >= 0 {
+				total, _ = strconv.Atoi(base[m[4]:m[5]])
+			}
+			title := strings.TrimSpace(base[:m[0]] + base[m[1]:])
+			add("name:"+strings.ToLower(title), title, platform, number, total, item)
+			continue
+		}
+
+		if item.Game == nil {
+			continue
+		}
+		if key, number, total, ok := headerDisc(item.Game); ok {
+			title := strings.TrimSpace(discTitlePattern.ReplaceAllString(item.Game.GameTitle(), ""))
+			add("header:"+string(platform)+":"+key, title, platform, number, total, item)
+			continue
+		}
+		if game, ok := item.Game.(*cnf.Info); ok {
+			if m := serialPattern.FindStringSubmatch(strings.ToUpper(game.DiscID)); m != nil {
+				dir := filepath.Dir(filepath.ToSlash(item.Name))
+				if dir != "." {
+					key := string(platform) + ":" + dir + ":" + m[1]
+					families[key] = append(families[key], item)
+				}
+			}
+		}
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(families)) {
+		addSerialFamilies(families[key], add)
+	}
+
+	var result []Group
+	for _, g := range groups {
+		if len(g.Discs) < 2 {
+			continue
+		}
+		slices.SortStableFunc(g.Discs, func(a, b Disc) int {
+			return cmp.Compare(a.Number, b.Number)
+		})
+		result = append(result, *g)
+	}
+	slices.SortStableFunc(result, func(a, b Group) int {
+		return cmp.Compare(itemIndex(items, a), itemIndex(items, b))
+	})
+	return result
+}
+
+// headerDisc returns the disc number (1-based) and count (0 if unknown) that
+// game's header records, and the key that the game's other discs share: its
+// serial, or its title without the disc number where each disc has its own
+// serial.
+func headerDisc(game core.GameInfo) (key string, number, total int, ok bool) {
+	switch game := game.(type) {
+	case *gcm.Info:
+		// Both discs share the game ID, and disc numbers start at 0
+		return game.GameSerial(), game.DiscNumber + 1, 0, true
+	case *xbe.Info:
+		if game.DiscNumber == 0 {
+			return "", 0, 0, false
+		}
+		return game.TitleIDHex, int(game.DiscNumber), 0, true
+	case *sfo.Info:
+		if game.DiscNumber == 0 {
+			return "", 0, 0, false
+		}
+		return strings.ToLower(game.Title), game.DiscNumber, game.DiscTotal, true
+	case *md.CDInfo:
+		if game.DiscNumber == 0 {
+			return "", 0, 0, false
+		}
+		title := discTitlePattern.ReplaceAllString(game.GameTitle(), "")
+		return strings.ToLower(strings.TrimSpace(title)), game.DiscNumber, 0, true
+	case *dreamcast.Info:
+		return deviceDisc(game.ProductNumber, game.DeviceInfo)
+	case *saturn.Info:
+		return deviceDisc(game.ProductNumber, game.DeviceInfo)
+	}
+	return "", 0, 0, false
+}
+
+// deviceDisc returns the disc number and count of Sega's device info, like
+// "GD-ROM1/2", keyed by the product number all the discs share.
+func deviceDisc(serial, device string) (key string, number, total int, ok bool) {
+	m := discDevicePattern.FindStringSubmatch(device)
+	if m == nil {
+		return "", 0, 0, false
+	}
+	number, _ = strconv.Atoi(m[1])
+	total, _ = strconv.Atoi(m[2])
+	if number == 0 {
+		return "", 0, 0, false
+	}
+	return serial, number, total, true
+}
+
+// addSerialFamilies adds the discs among items, PlayStation discs with the
+// same serial prefix in the same folder, whose serial numbers are
+// consecutive, numbered in serial order.
+func addSerialFamilies(items []*Item, add func(key, title string, platform core.Platform, number, total int, item *Item)) {
+	serial := func(item *Item) int {
+		m := serialPattern.FindStringSubmatch(strings.ToUpper(item.Game.(*cnf.Info).DiscID))
+		n, _ := strconv.Atoi(m[2] + m[3])
+		return n
+	}
+	slices.SortStableFunc(items, func(a, b *Item) int {
+		return cmp.Compare(serial(a), serial(b))
+	})
+
+	for start := 0; start < len(items); {
+		end := start + 1
+		for end < len(items) && serial(items[end]) == serial(items[end-1])+1 {
+			end++
+		}
+		if end-start > 1 {
+			first := items[start]
+			key := "serial:" + string(first.Game.GamePlatform()) + ":" + first.Game.GameSerial()
+			title := filepath.Base(filepath.Dir(filepath.ToSlash(first.Name)))
+			for i, item := range items[start:end] {
+				add(key, title, first.Game.GamePlatform(), i+1, 0, item)
+			}
+		}
+		start = end
+	}
+}
+
+// itemIndex returns the index among items of the first disc of g.
+func itemIndex(items []Item, g Group) int {
+	first := len(items)
+	for _, disc := range g.Discs {
+		for i := range items {
+			if items[i].Name == disc.Item {
+				first = min(first, i)
+				break
+			}
+		}
+	}
+	return first
+}
@@ -0,0 +1,116 @@
+package identify
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/sargunv/rom-tools/lib/core"
+	"github.com/sargunv/rom-tools/lib/roms/nintendo/gcm"
+	"github.com/sargunv/rom-tools/lib/roms/playstation/cnf"
+	"github.com/sargunv/rom-tools/lib/roms/sega/dreamcast"
+)
+
+func TestIdentifyGroups(t *testing.T) {
+	dir := t.TempDir()
+	for _, disc := range []string{"2", "1", "3"} {
+		name := "Game (USA) (Disc " + disc + ")"
+		cue := "FILE \"" + name + ".bin\" BINARY\n  TRACK 01 MODE1/2352\n    INDEX 01 00:00:00\n"
+		if err := os.WriteFile(filepath.Join(dir, name+".cue"), []byte(cue), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name+".bin"), make([]byte, 4096), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "Other (USA).bin"), make([]byte, 8192), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Identify(dir, DefaultOptions())
+	if err != nil {
+		t.Fatalf("Identify() error = %v", err)
+	}
+	want := []Group{{
+		Title: "Game (USA)",
+		Discs: []Disc{
+			{Number: 1, Item: "Game (USA) (Disc 1).cue"},
+			{Number: 2, Item: "Game (USA) (Disc 2).cue"},
+			{Number: 3, Item: "Game (USA) (Disc 3).cue"},
+		},
+	}}
+	if !reflect.DeepEqual(result.Groups, want) {
+		t.Errorf("Groups = %+v, want %+v", result.Groups, want)
+	}
+}
+
+func TestGroupDiscs(t *testing.T) {
+	tests := []struct {
+		name  string
+		items []Item
+		want  []Group
+	}{
+		{
+			name: "name tags",
+			items: []Item{
+				{Name: "Game (Disc 2 of 2).chd", Primary: true},
+				{Name: "Game (Disc 1 of 2).chd", Primary: true},
+				{Name: "Game (Disc 1 of 2).sub"},
+				{Name: "Single (Disc 1).chd", Primary: true},
+			},
+			want: []Group{{
+				Title: "Game",
+				Total: 2,
+				Discs: []Disc{{1, "Game (Disc 1 of 2).chd"}, {2, "Game (Disc 2 of 2).chd"}},
+			}},
+		},
+		{
+			name: "GameCube disc numbers",
+			items: []Item{
+				{Name: "a.iso", Primary: true, Game: &gcm.Info{SystemCode: 'G', GameCode: "RE", Region: 'E', MakerCode: "08", DiscNumber: 1, Title: "Resident Evil 4"}},
+				{Name: "b.iso", Primary: true, Game: &gcm.Info{SystemCode: 'G', GameCode: "RE", Region: 'E', MakerCode: "08", DiscNumber: 0, Title: "Resident Evil 4"}},
+			},
+			want: []Group{{
+				Title: "Resident Evil 4",
+				Discs: []Disc{{1, "b.iso"}, {2, "a.iso"}},
+			}},
+		},
+		{
+			name: "GD-ROM markers",
+			items: []Item{
+				{Name: "disc1.gdi", Primary: true, Game: &dreamcast.Info{ProductNumber: "MK-51059", Title: "SHENMUE", DeviceInfo: "D018 GD-ROM1/3"}},
+				{Name: "disc3.gdi", Primary: true, Game: &dreamcast.Info{ProductNumber: "MK-51059", Title: "SHENMUE", DeviceInfo: "D018 GD-ROM3/3"}},
+				{Name: "other.gdi", Primary: true, Game: &dreamcast.Info{ProductNumber: "MK-51000", Title: "OTHER", DeviceInfo: "D018 GD-ROM1/1"}},
+			},
+			want: []Group{{
+				Title:    "SHENMUE",
+				Platform: core.PlatformDreamcast,
+				Total:    3,
+				Discs:    []Disc{{1, "disc1.gdi"}, {3, "disc3.gdi"}},
+			}},
+		},
+		{
+			name: "serial families",
+			items: []Item{
+				{Name: "ff7/b.bin", Primary: true, Game: &cnf.Info{DiscID: "SCUS_941.64"}},
+				{Name: "ff7/a.bin", Primary: true, Game: &cnf.Info{DiscID: "SCUS_941.63"}},
+				{Name: "ff7/c.bin", Primary: true, Game: &cnf.Info{DiscID: "SCUS_941.65"}},
+				{Name: "ff7/d.bin", Primary: true, Game: &cnf.Info{DiscID: "SCUS_942.00"}},
+				{Name: "loose.bin", Primary: true, Game: &cnf.Info{DiscID: "SCUS_941.66"}},
+			},
+			want: []Group{{
+				Title: "ff7",
+				Discs: []Disc{{1, "ff7/a.bin"}, {2, "ff7/b.bin"}, {3, "ff7/c.bin"}},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := groupDiscs(tt.items)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("groupDiscs() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
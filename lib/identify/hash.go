@@ -3,22 +3,102 @@ package identify
 import (
 	"crypto/md5"
 	"crypto/sha1"
+	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"hash"
 	"hash/crc32"
 	"io"
+	"os"
+	"path/filepath"
+	"slices"
 
+	"github.com/cespare/xxhash/v2"
 	"github.com/sargunv/rom-tools/lib/core"
 )
 
-// calculateHashes computes SHA1, MD5, and CRC32 hashes from a ReaderAt in a single pass.
-func calculateHashes(r io.ReaderAt, size int64) (core.Hashes, error) {
-	sha1Hash := sha1.New()
-	md5Hash := md5.New()
-	crc32Hash := crc32.NewIEEE()
+// extraHashTypes are the hash types that Options.ExtraHashes may request.
+var extraHashTypes = []core.HashType{core.HashSHA256, core.HashXXH64}
+
+// hashSet is a set of hashes fed the same data, with their hash types.
+type hashSet struct {
+	types  []core.HashType
+	hashes []hash.Hash
+}
+
+// newHashSet creates a hashSet of SHA1, MD5, CRC32, and the extra hash types.
+// The types are given as the full-file types; headerless maps them to their
+// headerless counterparts, for those that have one.
+func newHashSet(extra []core.HashType) *hashSet {
+	s := &hashSet{
+		types:  []core.HashType{core.HashSHA1, core.HashMD5, core.HashCRC32},
+		hashes: []hash.Hash{sha1.New(), md5.New(), crc32.NewIEEE()},
+	}
+	if slices.Contains(extra, core.HashSHA256) {
+		s.types = append(s.types, core.HashSHA256)
+		s.hashes = append(s.hashes, sha256.New())
+	}
+	if slices.Contains(extra, core.HashXXH64) {
+		s.types = append(s.types, core.HashXXH64)
+		s.hashes = append(s.hashes, xxhash.New())
+	}
+	return s
+}
+
+// writer returns a writer feeding every hash in the set.
+func (s *hashSet) writer() io.Writer {
+	writers := make([]io.Writer, len(s.hashes))
+	for i, h := range s.hashes {
+		writers[i] = h
+	}
+	return io.MultiWriter(writers...)
+}
+
+// sums adds the set's hashes to hashes. If headerless is set, they're added
+// under their headerless types, and those without one are left out.
+func (s *hashSet) sums(hashes core.Hashes, headerless bool) {
+	for i, ht := range s.types {
+		if headerless {
+			var ok bool
+			if ht, ok = headerlessTypes[ht]; !ok {
+				continue
+			}
+		}
+		if h, ok := s.hashes[i].(hash.Hash32); ok {
+			hashes[ht] = fmt.Sprintf("%08x", h.Sum32())
+		} else {
+			hashes[ht] = hex.EncodeToString(s.hashes[i].Sum(nil))
+		}
+	}
+}
+
+// headerlessTypes maps full-file hash types to their headerless counterparts.
+var headerlessTypes = map[core.HashType]core.HashType{
+	core.HashSHA1:   core.HashHeaderlessSHA1,
+	core.HashMD5:    core.HashHeaderlessMD5,
+	core.HashCRC32:  core.HashHeaderlessCRC32,
+	core.HashSHA256: core.HashHeaderlessSHA256,
+}
+
+// calculateHashes computes SHA1, MD5, and CRC32 hashes, plus any extra hash
+// types, from a ReaderAt in a single pass.
+// If headerSize is positive, headerless hashes of the data after the header are
+// computed in the same pass.
+func calculateHashes(r io.ReaderAt, size int64, headerSize int64, extra []core.HashType) (core.Hashes, error) {
+	full := newHashSet(extra)
 
 	// MultiWriter writes to all hashes simultaneously
-	multiWriter := io.MultiWriter(sha1Hash, md5Hash, crc32Hash)
+	writers := []io.Writer{full.writer()}
+
+	headerless := headerSize > 0 && headerSize < size
+	payload := newHashSet(extra)
+	if headerless {
+		writers = append(writers, &skipWriter{
+			w:    payload.writer(),
+			skip: headerSize,
+		})
+	}
+	multiWriter := io.MultiWriter(writers...)
 
 	// Use SectionReader to read from offset 0 to size
 	sectionReader := io.NewSectionReader(r, 0, size)
@@ -26,9 +106,162 @@ func calculateHashes(r io.ReaderAt, size int64) (core.Hashes, error) {
 		return nil, fmt.Errorf("failed to read data for hashing: %w", err)
 	}
 
-	return core.Hashes{
-		core.HashSHA1:  hex.EncodeToString(sha1Hash.Sum(nil)),
-		core.HashMD5:   hex.EncodeToString(md5Hash.Sum(nil)),
-		core.HashCRC32: fmt.Sprintf("%08x", crc32Hash.Sum32()),
-	}, nil
+	hashes := make(core.Hashes)
+	full.sums(hashes, false)
+	if headerless {
+		payload.sums(hashes, true)
+	}
+	return hashes, nil
+}
+
+// gameHashes returns the hashes of a file identified as game, as
+// calculateGameHashes does, using opts.HashCache for files on disk. Failing
+// to cache the hashes isn't an error, since the cache is only an
+// optimization.
+func gameHashes(r io.ReaderAt, size int64, game core.GameInfo, name string, opts Options) (core.Hashes, error) {
+	progress := opts.progressFor(name)
+	file := r
+	if cr, ok := r.(*contextReader); ok {
+		file = cr.RandomAccessReader
+	}
+	f, ok := file.(*os.File)
+	if opts.HashCache == nil || !ok {
+		return calculateGameHashes(r, size, game, opts.ExtraHashes, progress)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return calculateGameHashes(r, size, game, opts.ExtraHashes, progress)
+	}
+	path, err := filepath.Abs(f.Name())
+	if err != nil {
+		return calculateGameHashes(r, size, game, opts.ExtraHashes, progress)
+	}
+
+	if opts.HashCacheMode != HashCacheRefresh {
+		if cached, ok := opts.HashCache.Get(path, info.Size(), info.ModTime()); ok {
+			if hashes, ok := cachedHashes(cached, game, opts.ExtraHashes); ok {
+				if progress != nil {
+					progress(size, size)
+				}
+				return hashes, nil
+			}
+		}
+	}
+
+	hashes, err := calculateGameHashes(r, size, game, opts.ExtraHashes, progress)
+	if err != nil {
+		return nil, err
+	}
+	_ = opts.HashCache.Put(path, info.Size(), info.ModTime(), hashes)
+	return hashes, nil
+}
+
+// cachedHashes returns the cached hashes of a file identified as game, with
+// only the extra hash types requested, or false if any hash needed is
+// missing.
+func cachedHashes(cached core.Hashes, game core.GameInfo, extra []core.HashType) (core.Hashes, bool) {
+	needed := append([]core.HashType{core.HashSHA1, core.HashMD5, core.HashCRC32}, extra...)
+	if headerSize(game) > 0 {
+		for _, ht := range needed {
+			if headerlessType, ok := headerlessTypes[ht]; ok {
+				needed = append(needed, headerlessType)
+			}
+		}
+	}
+
+	hashes := make(core.Hashes, len(needed))
+	for _, ht := range needed {
+		sum, ok := cached[ht]
+		if !ok {
+			return nil, false
+		}
+		hashes[ht] = sum
+	}
+	return hashes, true
+}
+
+// calculateGameHashes computes the hashes of a file identified as game. Its
+// headerless hashes cover the canonical payload that DATs hash: the data
+// after the header, or a converted view for formats that rearrange it.
+// If progress isn't nil, it's called as the file is read.
+func calculateGameHashes(r io.ReaderAt, size int64, game core.GameInfo, extra []core.HashType, progress func(done, total int64)) (core.Hashes, error) {
+	var pr *progressReader
+	if progress != nil {
+		pr = &progressReader{r: r, progress: progress, total: size}
+		r = pr
+	}
+
+	payload, payloadSize := payloadReader(game, r, size)
+	if payload == nil {
+		hashes, err := calculateHashes(r, size, headerSize(game), extra)
+		if err == nil && pr != nil {
+			pr.finish()
+		}
+		return hashes, err
+	}
+
+	// The payload is read in a second pass
+	if pr != nil {
+		pr.total += payloadSize
+	}
+	hashes, err := calculateHashes(r, size, 0, extra)
+	if err != nil {
+		return nil, err
+	}
+	payloadHashes, err := calculateHashes(payload, payloadSize, 0, extra)
+	if err != nil {
+		return nil, err
+	}
+	for ht, headerlessType := range headerlessTypes {
+		if sum, ok := payloadHashes[ht]; ok {
+			hashes[headerlessType] = sum
+		}
+	}
+	if pr != nil {
+		pr.finish()
+	}
+	return hashes, nil
+}
+
+// progressReader reports the bytes read from an io.ReaderAt, out of the total
+// expected.
+type progressReader struct {
+	r        io.ReaderAt
+	progress func(done, total int64)
+	done     int64
+	total    int64
+}
+
+// ReadAt implements io.ReaderAt.
+func (pr *progressReader) ReadAt(p []byte, off int64) (int, error) {
+	n, err := pr.r.ReadAt(p, off)
+	pr.done += int64(n)
+	pr.progress(min(pr.done, pr.total), pr.total)
+	return n, err
+}
+
+// finish reports that reading is complete, since headers read twice or
+// skipped make the count inexact.
+func (pr *progressReader) finish() {
+	pr.progress(pr.total, pr.total)
+}
+
+// skipWriter discards the first skip bytes written to it, passing the rest to w.
+type skipWriter struct {
+	w    io.Writer
+	skip int64
+}
+
+// Write implements io.Writer.
+func (s *skipWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	if s.skip >= int64(n) {
+		s.skip -= int64(n)
+		return n, nil
+	}
+	if _, err := s.w.Write(p[s.skip:]); err != nil {
+		return 0, err
+	}
+	s.skip = 0
+	return n, nil
 }
@@ -1,22 +1,53 @@
 package identify
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"maps"
 	"os"
 	"path/filepath"
+	"runtime"
+	"slices"
 	"strings"
+	"sync"
 
 	"github.com/sargunv/rom-tools/internal/container/folder"
+	"github.com/sargunv/rom-tools/internal/container/rar"
 	"github.com/sargunv/rom-tools/internal/container/zip"
 	"github.com/sargunv/rom-tools/internal/util"
 	"github.com/sargunv/rom-tools/lib/core"
 )
 
-// Identify identifies a ROM file, ZIP archive, or folder.
+// Errors identifying encrypted archive entries, wrapped in the errors
+// returned by Identify.
+var (
+	// ErrPasswordRequired means an entry is encrypted, and Options.Password
+	// isn't set.
+	ErrPasswordRequired = util.ErrPasswordRequired
+	// ErrWrongPassword means an entry can't be decrypted with
+	// Options.Password.
+	ErrWrongPassword = util.ErrWrongPassword
+)
+
+// Identify identifies a ROM file, archive (ZIP, RAR, tar, or compressed
+// file), or folder.
 // Returns a Result with identified items and their hashes.
 func Identify(path string, opts Options) (*Result, error) {
+	return IdentifyContext(context.Background(), path, opts)
+}
+
+// IdentifyContext is like Identify, but stops reading files and returns the
+// context's error once ctx is done, so hashing a large file can be cancelled
+// partway through. Options.FileTimeout limits the time spent on each file.
+func IdentifyContext(ctx context.Context, path string, opts Options) (*Result, error) {
+	for _, ht := range opts.ExtraHashes {
+		if !slices.Contains(extraHashTypes, ht) {
+			return nil, fmt.Errorf("unsupported extra hash type: %s", ht)
+		}
+	}
+
 	absPath, err := filepath.Abs(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to resolve path: %w", err)
@@ -33,37 +64,73 @@ func Identify(path string, opts Options) (*Result, error) {
 			return nil, err
 		}
 		defer container.Close()
-		return identifyContainer(absPath, container, opts)
+		return identifyContainer(ctx, absPath, container, opts)
+	}
+
+	return identifyFile(ctx, absPath, info.Size(), opts)
+}
+
+// openFile opens a file with random access support, returning it and its size.
+func openFile(path string) (util.RandomAccessReader, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
 	}
 
-	return identifyFile(absPath, info.Size(), opts)
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, 0, err
+	}
+
+	return f, info.Size(), nil
 }
 
 // identifyFile handles a single file (may be a container like ZIP).
-func identifyFile(path string, size int64, opts Options) (*Result, error) {
-	ext := strings.ToLower(filepath.Ext(path))
+func identifyFile(ctx context.Context, path string, size int64, opts Options) (*Result, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
 
-	// ZIP files are containers - identify their contents
-	if ext == ".zip" {
-		container, err := zip.Open(path)
+	// Archives are containers - identify their contents, reading split ZIPs
+	// and multi-volume RARs from all their parts
+	if parts := zip.SplitParts(path); parts != nil {
+		container, err := zip.OpenSplitContext(ctx, parts)
+		if err != nil {
+			return nil, err
+		}
+		defer container.Close()
+		container.SetPassword(opts.Password)
+		return identifyContainer(ctx, path, container, archiveOptions(opts))
+	}
+	if strings.EqualFold(filepath.Ext(path), ".rar") {
+		container, err := rar.OpenContext(ctx, path, opts.Password)
 		if err != nil {
 			return nil, err
 		}
 		defer container.Close()
-		return identifyContainer(path, container, opts)
+		return identifyContainer(ctx, path, container, archiveOptions(opts))
+	}
+	if open, ok := archiveByExtension(path); ok {
+		container, err := open(ctx, filepath.Base(path), f, size, opts.Password)
+		if err != nil {
+			return nil, err
+		}
+		defer container.Close()
+		return identifyContainer(ctx, path, container, archiveOptions(opts))
 	}
 
-	// Single file - open and identify it
-	f, err := os.Open(path)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open file: %w", err)
+	open := func(name string) (util.RandomAccessReader, int64, error) {
+		return openFile(filepath.Join(filepath.Dir(path), name))
 	}
-	defer f.Close()
 
-	item, err := identifyReader(f, size, filepath.Base(path), opts)
+	item, err := identifyReader(ctx, f, size, filepath.Base(path), open, opts)
 	if err != nil {
 		return nil, err
 	}
+	markPrimary([]*Item{item}, nil, nil)
 
 	return &Result{
 		Path:  path,
@@ -71,46 +138,187 @@ func identifyFile(path string, size int64, opts Options) (*Result, error) {
 	}, nil
 }
 
-// identifyContainer handles any container (ZIP, folder, etc.) using the FileContainer interface.
-func identifyContainer(path string, c util.FileContainer, opts Options) (*Result, error) {
+// identifyContainer handles any container (archive, folder, etc.) using the FileContainer interface.
+func identifyContainer(ctx context.Context, path string, c util.FileContainer, opts Options) (*Result, error) {
 	entries := c.Entries()
 	if len(entries) == 0 {
 		return nil, fmt.Errorf("container is empty")
 	}
 
-	items := make([]Item, 0, len(entries))
+	// Nested archives expand into several items
+	items := make([][]Item, len(entries))
+	nested := make([]bool, len(entries))
+	errs := make([]error, len(entries))
 
-	for _, entry := range entries {
-		item, err := identifyContainerEntry(c, entry, opts)
+	identifyEntry := func(i int) {
+		if err := ctx.Err(); err != nil {
+			errs[i] = err
+			return
+		}
+		if open, ok := archiveByExtension(entries[i].Name); ok && opts.MaxArchiveDepth > 0 {
+			archiveItems, err := identifyNestedArchive(ctx, c, entries[i], open, opts)
+			if err != nil {
+				errs[i] = fmt.Errorf("failed to identify %s: %w", entries[i].Name, err)
+				return
+			}
+			if archiveItems != nil {
+				items[i] = archiveItems
+				nested[i] = true
+				return
+			}
+		}
+		item, err := identifyContainerEntry(ctx, c, entries[i], opts)
 		if err != nil {
-			return nil, fmt.Errorf("failed to identify %s: %w", entry.Name, err)
+			errs[i] = fmt.Errorf("failed to identify %s: %w", entries[i].Name, err)
+			return
+		}
+		items[i] = []Item{*item}
+	}
+
+	numWorkers := opts.Concurrency
+	if numWorkers <= 0 {
+		numWorkers = runtime.NumCPU()
+	}
+	numWorkers = min(numWorkers, len(entries))
+	if numWorkers <= 1 {
+		for i := range entries {
+			identifyEntry(i)
+			if errs[i] != nil {
+				return nil, errs[i]
+			}
+		}
+	} else {
+		// Results are written by index, so ordering matches Entries() regardless
+		// of which worker finishes first
+		indexChan := make(chan int, len(entries))
+		for i := range entries {
+			indexChan <- i
+		}
+		close(indexChan)
+
+		var wg sync.WaitGroup
+		for range numWorkers {
+			wg.Go(func() {
+				for i := range indexChan {
+					identifyEntry(i)
+				}
+			})
+		}
+		wg.Wait()
+
+		for _, err := range errs {
+			if err != nil {
+				return nil, err
+			}
 		}
-		items = append(items, *item)
 	}
 
+	// Nested archives' items were marked as they were identified
+	var direct []*Item
+	var archiveItems []Item
+	refs := make(map[string]bool)
+	for i, entry := range entries {
+		if nested[i] {
+			archiveItems = append(archiveItems, items[i]...)
+			continue
+		}
+		direct = append(direct, &items[i][0])
+		if _, ok := identifyCompanionByExtension(entry.Name); ok {
+			controlFileRefs(c, entry, refs)
+		}
+	}
+	markPrimary(direct, refs, archiveItems)
+
+	all := slices.Concat(items...)
 	return &Result{
-		Path:  path,
-		Items: items,
+		Path:   path,
+		Items:  all,
+		Groups: groupDiscs(all),
 	}, nil
 }
 
+// archiveOptions returns the options for identifying an archive's entries.
+func archiveOptions(opts Options) Options {
+	// Each worker buffers a whole decompressed entry, so archives are only
+	// identified in parallel when asked for explicitly
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 1
+	}
+	return opts
+}
+
+// identifyNestedArchive identifies the entries of an archive within a
+// container, naming them by their path through it. It returns nil if the
+// archive can't be opened or is empty, so it's identified as a plain file,
+// unless it can't be opened for lack of the right password.
+func identifyNestedArchive(ctx context.Context, c util.FileContainer, entry util.FileEntry, open archiveFunc, opts Options) ([]Item, error) {
+	file, size, err := c.OpenFileAt(entry.Name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	archive, err := open(ctx, entry.Name, withContext(ctx, file), size, opts.Password)
+	if errors.Is(err, ErrPasswordRequired) || errors.Is(err, ErrWrongPassword) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, nil
+	}
+	defer archive.Close()
+	if len(archive.Entries()) == 0 {
+		return nil, nil
+	}
+
+	opts = archiveOptions(opts)
+	opts.MaxArchiveDepth--
+	result, err := identifyContainer(ctx, entry.Name, archive, opts)
+	if err != nil {
+		return nil, err
+	}
+	for i := range result.Items {
+		result.Items[i].Name = filepath.ToSlash(entry.Name) + "/" + result.Items[i].Name
+	}
+	return result.Items, nil
+}
+
 // identifyContainerEntry identifies a single entry within a container.
-func identifyContainerEntry(c util.FileContainer, entry util.FileEntry, opts Options) (*Item, error) {
+func identifyContainerEntry(ctx context.Context, c util.FileContainer, entry util.FileEntry, opts Options) (*Item, error) {
 	item := &Item{
 		Name: entry.Name,
 		Size: entry.Size,
 	}
+	ctx, cancel := opts.fileContext(ctx)
+	defer cancel()
 
 	// Open and identify the file
-	reader, size, err := c.OpenFileAt(entry.Name)
+	file, size, err := c.OpenFileAt(entry.Name)
 	if err != nil {
 		return nil, err
 	}
-	defer reader.Close()
+	defer file.Close()
+	reader := withContext(ctx, file)
 
 	// Identify the content (may also return embedded hashes for formats like CHD)
-	game, embeddedHashes := identifyContent(reader, size, entry.Name)
+	open := func(name string) (util.RandomAccessReader, int64, error) {
+		dir := strings.TrimSuffix(entry.Name, filepath.Base(entry.Name))
+		r, size, err := c.OpenFileAt(dir + name)
+		return withContext(ctx, r), size, err
+	}
+	game, embeddedHashes := identifyContent(reader, size, entry.Name, open, opts)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// Parser errors are ignored too, so entries that can't be read at all,
+	// like encrypted ones without the password, are checked for after
+	if size > 0 {
+		if _, err := reader.ReadAt(make([]byte, 1), 0); err != nil && err != io.EOF {
+			return nil, err
+		}
+	}
 	item.Game = game
+	item.HeaderSize = headerSize(game)
 
 	// Build hashes: merge container metadata with embedded hashes
 	// For example, a CHD in a ZIP gets both zip-crc32 and chd-*-sha1
@@ -124,13 +332,20 @@ func identifyContainerEntry(c util.FileContainer, entry util.FileEntry, opts Opt
 		maps.Copy(item.Hashes, embeddedHashes)
 	}
 
-	// Calculate hashes if none available and within size limit
-	if item.Hashes == nil && (opts.MaxHashSize < 0 || size <= opts.MaxHashSize) {
-		hashes, err := calculateHashes(reader, size)
+	// Calculate hashes if none available, or headerless or extra hashes are
+	// needed, and within size limit. As outside containers, extra hashes
+	// aren't calculated for formats with embedded hashes.
+	needHashes := item.Hashes == nil || item.HeaderSize > 0 || (len(opts.ExtraHashes) > 0 && embeddedHashes == nil)
+	if needHashes && (opts.MaxHashSize < 0 || size <= opts.MaxHashSize) {
+		hashes, err := gameHashes(reader, size, game, item.Name, opts)
 		if err != nil {
 			return nil, fmt.Errorf("failed to calculate hashes: %w", err)
 		}
-		item.Hashes = hashes
+		if item.Hashes == nil {
+			item.Hashes = hashes
+		} else {
+			maps.Copy(item.Hashes, hashes)
+		}
 	}
 
 	return item, nil
@@ -138,14 +353,27 @@ func identifyContainerEntry(c util.FileContainer, entry util.FileEntry, opts Opt
 
 // identifyReader identifies a single file from a reader.
 // Returns an Item with hashes and game info.
-func identifyReader(r util.RandomAccessReader, size int64, name string, opts Options) (*Item, error) {
-	// Try to identify content (may also return embedded hashes for formats like CHD)
-	game, embeddedHashes := identifyContent(r, size, name)
+func identifyReader(ctx context.Context, r util.RandomAccessReader, size int64, name string, open openFunc, opts Options) (*Item, error) {
+	ctx, cancel := opts.fileContext(ctx)
+	defer cancel()
+	r = withContext(ctx, r)
+	contextOpen := func(name string) (util.RandomAccessReader, int64, error) {
+		r, size, err := open(name)
+		return withContext(ctx, r), size, err
+	}
+
+	// Try to identify content (may also return embedded hashes for formats like CHD).
+	// Parser errors are ignored, so a cancelled read is checked for after.
+	game, embeddedHashes := identifyContent(r, size, name, contextOpen, opts)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
 	item := &Item{
-		Name: name,
-		Size: size,
-		Game: game,
+		Name:       name,
+		Size:       size,
+		HeaderSize: headerSize(game),
+		Game:       game,
 	}
 
 	// Use embedded hashes if provided (CHD, etc.)
@@ -160,7 +388,7 @@ func identifyReader(r util.RandomAccessReader, size int64, name string, opts Opt
 	}
 
 	// Calculate hashes
-	hashes, err := calculateHashes(r, size)
+	hashes, err := gameHashes(r, size, game, name, opts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to calculate hashes: %w", err)
 	}
@@ -170,18 +398,45 @@ func identifyReader(r util.RandomAccessReader, size int64, name string, opts Opt
 }
 
 // identifyContent tries to identify the content from a reader.
+// Companion files (e.g., the .img of a .ccd) are opened with open. With
+// Options.Sniff, content its extension doesn't identify is probed for every
+// registered format's magic.
 // Returns the game info and any embedded hashes (both may be nil).
-func identifyContent(r io.ReaderAt, size int64, name string) (core.GameInfo, core.Hashes) {
-	// Get candidate parsers by extension
-	parsers := identifyByExtension(name)
-	if len(parsers) == 0 {
-		return nil, nil
+func identifyContent(r io.ReaderAt, size int64, name string, open openFunc, opts Options) (core.GameInfo, core.Hashes) {
+	// Control files are identified by the companion files they describe
+	if identify, ok := identifyCompanionByExtension(name); ok {
+		game, hashes, err := identify(r, size, name, open)
+		if err != nil {
+			return nil, nil
+		}
+		return game, hashes
+	}
+
+	// Try the formats registered for the extension, then, when sniffing,
+	// the rest by their magic
+	if game, hashes := identifyFormats(r, size, identifyByExtension(name), opts.Fast); game != nil || hashes != nil {
+		return game, hashes
+	}
+	if opts.Sniff {
+		return identifyFormats(r, size, sniffFormats(name), opts.Fast)
 	}
+	return nil, nil
+}
 
-	// Try each parser
+// identifyFormats tries each format in order, returning the game info and
+// embedded hashes from the first that identifies the content. With fast,
+// formats' FastIdentify is used where they have one.
+func identifyFormats(r io.ReaderAt, size int64, formats []Format, fast bool) (core.GameInfo, core.Hashes) {
 	// TODO: log parser errors at debug level when logging is available
-	for _, parser := range parsers {
-		game, hashes, err := parser(r, size)
+	for _, format := range formats {
+		if !format.matches(r, size) {
+			continue
+		}
+		identify := format.Identify
+		if fast && format.FastIdentify != nil {
+			identify = format.FastIdentify
+		}
+		game, hashes, err := identify(r, size)
 		if err == nil && game != nil {
 			return game, hashes
 		}
@@ -190,6 +445,29 @@ func identifyContent(r io.ReaderAt, size int64, name string) (core.GameInfo, cor
 			return nil, hashes
 		}
 	}
-
 	return nil, nil
 }
+
+// contextReader is a RandomAccessReader whose reads fail once its context
+// is done, so parsing and hashing stop partway through a file.
+type contextReader struct {
+	util.RandomAccessReader
+	ctx context.Context
+}
+
+// withContext returns r with reads that fail once ctx is done, or nil if r
+// is nil.
+func withContext(ctx context.Context, r util.RandomAccessReader) util.RandomAccessReader {
+	if r == nil {
+		return nil
+	}
+	return &contextReader{RandomAccessReader: r, ctx: ctx}
+}
+
+// ReadAt implements io.ReaderAt.
+func (r *contextReader) ReadAt(p []byte, off int64) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.RandomAccessReader.ReadAt(p, off)
+}
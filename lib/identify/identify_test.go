@@ -1,9 +1,34 @@
 package identify
 
 import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/cespare/xxhash/v2"
+	"github.com/sargunv/rom-tools/lib/chd"
 	"github.com/sargunv/rom-tools/lib/core"
+	"github.com/sargunv/rom-tools/lib/roms/atari/jaguar"
+	"github.com/sargunv/rom-tools/lib/roms/nec/pce"
+	"github.com/sargunv/rom-tools/lib/roms/nintendo/nes"
+	"github.com/sargunv/rom-tools/lib/roms/playstation/cnf"
+	"github.com/sargunv/rom-tools/lib/roms/sega/dreamcast"
+	"github.com/sargunv/rom-tools/lib/roms/sega/md"
 )
 
 func TestIdentifyZIP(t *testing.T) {
@@ -48,6 +73,194 @@ func TestIdentifyZIP(t *testing.T) {
 	}
 }
 
+// zipFile is a file to write to a test ZIP archive.
+type zipFile struct {
+	name string
+	data []byte
+}
+
+// zipBytes returns a ZIP archive of the given files, in order.
+func zipBytes(t *testing.T, files ...zipFile) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range files {
+		w, err := zw.Create(f.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(f.data)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestIdentifyNestedArchive(t *testing.T) {
+	rom, err := os.ReadFile("testdata/gbtictac.gb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	inner := zipBytes(t, zipFile{"gbtictac.gb", rom})
+	outer := zipBytes(t,
+		zipFile{"readme.txt", []byte("hello")},
+		zipFile{"games/gbtictac.zip", inner},
+		zipFile{"broken.zip", []byte("not a zip")},
+	)
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "romset.zip"), outer, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path  string
+		depth int
+		want  []string
+		game  string // name of the item identified as a game, if any
+	}{
+		{"romset.zip", 1, []string{"readme.txt", "games/gbtictac.zip/gbtictac.gb", "broken.zip"}, "games/gbtictac.zip/gbtictac.gb"},
+		{"romset.zip", 0, []string{"readme.txt", "games/gbtictac.zip", "broken.zip"}, ""},
+		{".", 1, []string{"romset.zip/readme.txt", "romset.zip/games/gbtictac.zip", "romset.zip/broken.zip"}, ""},
+		{".", 2, []string{"romset.zip/readme.txt", "romset.zip/games/gbtictac.zip/gbtictac.gb", "romset.zip/broken.zip"}, "romset.zip/games/gbtictac.zip/gbtictac.gb"},
+	}
+	for _, tt := range tests {
+		opts := DefaultOptions()
+		opts.MaxArchiveDepth = tt.depth
+		result, err := Identify(filepath.Join(dir, tt.path), opts)
+		if err != nil {
+			t.Fatalf("Identify(%s, depth %d) error = %v", tt.path, tt.depth, err)
+		}
+
+		var names []string
+		for _, item := range result.Items {
+			names = append(names, item.Name)
+			if identified := item.Game != nil; identified != (item.Name == tt.game) {
+				t.Errorf("Identify(%s, depth %d) item %s game = %v", tt.path, tt.depth, item.Name, item.Game)
+			}
+		}
+		if !reflect.DeepEqual(names, tt.want) {
+			t.Errorf("Identify(%s, depth %d) items = %q, want %q", tt.path, tt.depth, names, tt.want)
+		}
+	}
+}
+
+func TestIdentifyRAR(t *testing.T) {
+	data, err := os.ReadFile("testdata/gbtictac.gb.rar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "gbtictac.gb.rar"), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Directly, and nested in a folder
+	tests := []struct {
+		path string
+		name string
+	}{
+		{"testdata/gbtictac.gb.rar", "gbtictac.gb"},
+		{dir, "gbtictac.gb.rar/gbtictac.gb"},
+	}
+	for _, tt := range tests {
+		result, err := Identify(tt.path, DefaultOptions())
+		if err != nil {
+			t.Fatalf("Identify(%s) error = %v", tt.path, err)
+		}
+		if len(result.Items) != 1 || result.Items[0].Name != tt.name {
+			t.Fatalf("Identify(%s) items = %v, want one named %s", tt.path, result.Items, tt.name)
+		}
+		item := result.Items[0]
+		if item.Game == nil || item.Game.GamePlatform() != core.PlatformGB {
+			t.Errorf("Identify(%s) game = %v, want a Game Boy ROM", tt.path, item.Game)
+		}
+		// RAR entries have no stored hashes to use, so they're calculated
+		if _, ok := item.Hashes[core.HashSHA1]; !ok {
+			t.Errorf("Identify(%s) expected SHA1 hash", tt.path)
+		}
+	}
+}
+
+func TestIdentifyCompressed(t *testing.T) {
+	rom, err := os.ReadFile("testdata/gbtictac.gb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := fmt.Sprintf("%x", sha1.Sum(rom))
+
+	var tarBuf bytes.Buffer
+	tw := tar.NewWriter(&tarBuf)
+	if err := tw.WriteHeader(&tar.Header{Name: "gbtictac.gb", Mode: 0o644, Size: int64(len(rom))}); err != nil {
+		t.Fatal(err)
+	}
+	tw.Write(rom)
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	gzipBytes := func(data []byte) []byte {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		zw.Write(data)
+		if err := zw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+
+	dir := t.TempDir()
+	files := map[string][]byte{
+		"gbtictac.gb.gz":  gzipBytes(rom),
+		"gbtictac.tar":    tarBuf.Bytes(),
+		"gbtictac.tar.gz": gzipBytes(tarBuf.Bytes()),
+	}
+	for name, data := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := Identify(path, DefaultOptions())
+		if err != nil {
+			t.Fatalf("Identify(%s) error = %v", name, err)
+		}
+		if len(result.Items) != 1 || result.Items[0].Name != "gbtictac.gb" {
+			t.Fatalf("Identify(%s) items = %v, want one named gbtictac.gb", name, result.Items)
+		}
+		item := result.Items[0]
+		if item.Game == nil || item.Game.GamePlatform() != core.PlatformGB {
+			t.Errorf("Identify(%s) game = %v, want a Game Boy ROM", name, item.Game)
+		}
+		if got := item.Hashes[core.HashSHA1]; got != want {
+			t.Errorf("Identify(%s) SHA1 = %s, want %s", name, got, want)
+		}
+	}
+}
+
+func TestIdentifyEncryptedArchive(t *testing.T) {
+	for _, path := range []string{"testdata/gbtictac.gb.encrypted.zip", "testdata/gbtictac.gb.encrypted.rar"} {
+		if _, err := Identify(path, DefaultOptions()); !errors.Is(err, ErrPasswordRequired) {
+			t.Errorf("Identify(%s) without a password error = %v, want ErrPasswordRequired", path, err)
+		}
+
+		opts := DefaultOptions()
+		opts.Password = "wrong"
+		if _, err := Identify(path, opts); !errors.Is(err, ErrWrongPassword) {
+			t.Errorf("Identify(%s) with the wrong password error = %v, want ErrWrongPassword", path, err)
+		}
+
+		opts.Password = "secret"
+		result, err := Identify(path, opts)
+		if err != nil {
+			t.Fatalf("Identify(%s) error = %v", path, err)
+		}
+		if game := result.Items[0].Game; game == nil || game.GamePlatform() != core.PlatformGB {
+			t.Errorf("Identify(%s) game = %v, want a Game Boy ROM", path, game)
+		}
+	}
+}
+
 func TestIdentifyFolder(t *testing.T) {
 	romPath := "testdata/xromwell"
 
@@ -191,3 +404,913 @@ func TestIdentifyLooseFileNoLimitHashes(t *testing.T) {
 		t.Errorf("Expected 3 hashes with MaxHashSize=-1, got %d", len(item.Hashes))
 	}
 }
+
+func TestIdentifyExtraHashes(t *testing.T) {
+	romPath := "testdata/gbtictac.gb"
+
+	opts := DefaultOptions()
+	opts.ExtraHashes = []core.HashType{core.HashSHA256, core.HashXXH64}
+	result, err := Identify(romPath, opts)
+	if err != nil {
+		t.Fatalf("Identify() error = %v", err)
+	}
+
+	item := result.Items[0]
+	if len(item.Hashes) != 5 {
+		t.Errorf("Expected 5 hashes, got %d", len(item.Hashes))
+	}
+	if got, want := item.Hashes[core.HashSHA256], "343d601b4c1eb8dd72eaca5abe25cd9eec630fa4ee7a0f06821f88e4feb77683"; got != want {
+		t.Errorf("Expected SHA256 '%s', got '%s'", want, got)
+	}
+	data, err := os.ReadFile(romPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := item.Hashes[core.HashXXH64], fmt.Sprintf("%016x", xxhash.Sum64(data)); got != want {
+		t.Errorf("Expected xxHash64 '%s', got '%s'", want, got)
+	}
+
+	// Headered ROMs get a headerless SHA256 too, but no headerless xxHash64
+	header := []byte{'N', 'E', 'S', 0x1A, 1, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}
+	rom := make([]byte, 24*1024)
+	path := filepath.Join(t.TempDir(), "test.nes")
+	if err := os.WriteFile(path, append(header, rom...), 0o644); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+	result, err = Identify(path, opts)
+	if err != nil {
+		t.Fatalf("Identify() error = %v", err)
+	}
+	sum := sha256.Sum256(rom)
+	if got := result.Items[0].Hashes[core.HashHeaderlessSHA256]; got != hex.EncodeToString(sum[:]) {
+		t.Errorf("Expected headerless SHA256 %x, got %s", sum, got)
+	}
+	if len(result.Items[0].Hashes) != 9 {
+		t.Errorf("Expected 9 hashes, got %d", len(result.Items[0].Hashes))
+	}
+
+	opts.ExtraHashes = []core.HashType{core.HashCHDMD5}
+	if _, err := Identify(romPath, opts); err == nil {
+		t.Error("Expected error for unsupported extra hash type")
+	}
+}
+
+func TestIdentifyProgress(t *testing.T) {
+	var calls int
+	var last, total int64
+	opts := DefaultOptions()
+	opts.Progress = func(name string, done, size int64) {
+		if name != "gbtictac.gb" {
+			t.Errorf("Expected progress for gbtictac.gb, got %s", name)
+		}
+		if done < last || done > size {
+			t.Errorf("Progress went from %d to %d of %d", last, done, size)
+		}
+		calls++
+		last, total = done, size
+	}
+
+	if _, err := Identify("testdata/gbtictac.gb", opts); err != nil {
+		t.Fatalf("Identify() error = %v", err)
+	}
+	if calls < 2 {
+		t.Errorf("Expected several progress calls, got %d", calls)
+	}
+	if last != 32768 || total != 32768 {
+		t.Errorf("Expected final progress 32768 of 32768, got %d of %d", last, total)
+	}
+}
+
+// memoryHashCache is a HashCache for tests.
+type memoryHashCache struct {
+	mu      sync.Mutex
+	entries map[string]core.Hashes
+	puts    int
+}
+
+func (c *memoryHashCache) key(path string, size int64, modTime time.Time) string {
+	return fmt.Sprintf("%s:%d:%d", path, size, modTime.UnixNano())
+}
+
+func (c *memoryHashCache) Get(path string, size int64, modTime time.Time) (core.Hashes, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	hashes, ok := c.entries[c.key(path, size, modTime)]
+	return hashes, ok
+}
+
+func (c *memoryHashCache) Put(path string, size int64, modTime time.Time, hashes core.Hashes) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[c.key(path, size, modTime)] = hashes
+	c.puts++
+	return nil
+}
+
+func TestIdentifyHashCache(t *testing.T) {
+	cache := &memoryHashCache{entries: make(map[string]core.Hashes)}
+	opts := DefaultOptions()
+	opts.HashCache = cache
+
+	path := filepath.Join(t.TempDir(), "test.gb")
+	data, err := os.ReadFile("testdata/gbtictac.gb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	identifySHA1 := func() string {
+		t.Helper()
+		result, err := Identify(path, opts)
+		if err != nil {
+			t.Fatalf("Identify() error = %v", err)
+		}
+		return result.Items[0].Hashes[core.HashSHA1]
+	}
+
+	if got := identifySHA1(); got != "48a59d5b31e374731ece4d9eb33679d38143495e" {
+		t.Fatalf("Expected SHA1 of the file, got %s", got)
+	}
+	if cache.puts != 1 {
+		t.Fatalf("Expected 1 cache write, got %d", cache.puts)
+	}
+
+	// Cached hashes are used as they are, even if they're wrong
+	for _, hashes := range cache.entries {
+		hashes[core.HashSHA1] = "cached"
+	}
+	if got := identifySHA1(); got != "cached" {
+		t.Errorf("Expected cached SHA1, got %s", got)
+	}
+
+	// Hashes the cache doesn't have are calculated
+	opts.ExtraHashes = []core.HashType{core.HashSHA256}
+	if got := identifySHA1(); got != "48a59d5b31e374731ece4d9eb33679d38143495e" {
+		t.Errorf("Expected recalculated SHA1 with SHA256 requested, got %s", got)
+	}
+	opts.ExtraHashes = nil
+	for _, hashes := range cache.entries {
+		hashes[core.HashSHA1] = "cached"
+	}
+
+	// Refreshing ignores the cache
+	opts.HashCacheMode = HashCacheRefresh
+	if got := identifySHA1(); got != "48a59d5b31e374731ece4d9eb33679d38143495e" {
+		t.Errorf("Expected recalculated SHA1 when refreshing, got %s", got)
+	}
+	if cache.puts != 3 {
+		t.Errorf("Expected 3 cache writes, got %d", cache.puts)
+	}
+
+	// Modified files miss the cache
+	opts.HashCacheMode = HashCacheNormal
+	for _, hashes := range cache.entries {
+		hashes[core.HashSHA1] = "cached"
+	}
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if got := identifySHA1(); got != "48a59d5b31e374731ece4d9eb33679d38143495e" {
+		t.Errorf("Expected recalculated SHA1 after modification, got %s", got)
+	}
+}
+
+func TestIdentifyFolderConcurrency(t *testing.T) {
+	dir := t.TempDir()
+	for i := range 32 {
+		name := filepath.Join(dir, fmt.Sprintf("file%02d.bin", i))
+		if err := os.WriteFile(name, []byte(name), 0o644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	serial, err := Identify(dir, Options{MaxHashSize: -1, Concurrency: 1})
+	if err != nil {
+		t.Fatalf("Identify() serial error = %v", err)
+	}
+	parallel, err := Identify(dir, Options{MaxHashSize: -1, Concurrency: 8})
+	if err != nil {
+		t.Fatalf("Identify() parallel error = %v", err)
+	}
+
+	if len(parallel.Items) != 32 {
+		t.Fatalf("Expected 32 items, got %d", len(parallel.Items))
+	}
+
+	// Parallel identification must return the same items in the same order
+	if !reflect.DeepEqual(serial.Items, parallel.Items) {
+		t.Error("Expected parallel results to match serial results")
+	}
+}
+
+func TestIdentifyContext(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "large.bin")
+	if err := os.WriteFile(path, make([]byte, 4<<20), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Cancelling partway through hashing stops it
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	opts := DefaultOptions()
+	opts.Progress = func(name string, done, total int64) { cancel() }
+	if _, err := IdentifyContext(ctx, path, opts); !errors.Is(err, context.Canceled) {
+		t.Errorf("IdentifyContext() cancelled while hashing error = %v, want context.Canceled", err)
+	}
+
+	// Nothing in a cancelled folder or archive is identified
+	for _, path := range []string{dir, "testdata/AGB_Rogue.gba.zip"} {
+		if _, err := IdentifyContext(ctx, path, DefaultOptions()); !errors.Is(err, context.Canceled) {
+			t.Errorf("IdentifyContext(%s) cancelled error = %v, want context.Canceled", path, err)
+		}
+	}
+
+	opts = DefaultOptions()
+	opts.FileTimeout = time.Nanosecond
+	if _, err := Identify(path, opts); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Identify() past FileTimeout error = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestIdentifyHeaderedPCE(t *testing.T) {
+	// 8 KiB HuCard with a 512-byte copier header
+	rom := make([]byte, 8*1024)
+	rom[0x1FFF] = 0xE0
+	data := append(make([]byte, 512), rom...)
+
+	path := filepath.Join(t.TempDir(), "test.pce")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+
+	result, err := Identify(path, DefaultOptions())
+	if err != nil {
+		t.Fatalf("Identify() error = %v", err)
+	}
+
+	item := result.Items[0]
+	if item.Game == nil || item.Game.GamePlatform() != core.PlatformPCE {
+		t.Fatalf("Expected platform %s, got %v", core.PlatformPCE, item.Game)
+	}
+
+	// Headerless hashes should cover the ROM data only, matching DATs
+	sum := sha1.Sum(rom)
+	if got := item.Hashes[core.HashHeaderlessSHA1]; got != hex.EncodeToString(sum[:]) {
+		t.Errorf("Expected headerless SHA1 %x, got %s", sum, got)
+	}
+	fullSum := sha1.Sum(data)
+	if got := item.Hashes[core.HashSHA1]; got != hex.EncodeToString(fullSum[:]) {
+		t.Errorf("Expected SHA1 of full file %x, got %s", fullSum, got)
+	}
+	if item.Size != int64(len(data)) {
+		t.Errorf("Expected size %d, got %d", len(data), item.Size)
+	}
+	if item.HeaderSize != 512 {
+		t.Errorf("Expected header size 512, got %d", item.HeaderSize)
+	}
+}
+
+// TestIdentifyHeaderedFullFileHashes checks that the plain hashes of headered
+// formats always cover the whole file, with the header stripped only from
+// the headerless hashes.
+func TestIdentifyHeaderedFullFileHashes(t *testing.T) {
+	lnx := make([]byte, 64+1024)
+	copy(lnx, "LYNX")
+	lnx[64] = 0xAA
+
+	fds := make([]byte, 16+65500)
+	copy(fds, "FDS\x1A")
+	fds[4] = 1
+	fds[16] = 0x01
+	copy(fds[17:], "*NINTENDO-HVC*")
+
+	tests := []struct {
+		name       string
+		data       []byte
+		headerSize int
+	}{
+		{"test.lnx", lnx, 64},
+		{"test.fds", fds, 16},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.name)
+			if err := os.WriteFile(path, tt.data, 0o644); err != nil {
+				t.Fatalf("failed to write %s: %v", path, err)
+			}
+
+			result, err := Identify(path, DefaultOptions())
+			if err != nil {
+				t.Fatalf("Identify() error = %v", err)
+			}
+			item := result.Items[0]
+			if item.Game == nil {
+				t.Fatal("Expected the file to be identified")
+			}
+
+			fullSum := sha1.Sum(tt.data)
+			if got := item.Hashes[core.HashSHA1]; got != hex.EncodeToString(fullSum[:]) {
+				t.Errorf("Expected SHA1 of full file %x, got %s", fullSum, got)
+			}
+			if got, want := item.Hashes[core.HashCRC32], fmt.Sprintf("%08x", crc32.ChecksumIEEE(tt.data)); got != want {
+				t.Errorf("Expected CRC32 of full file %s, got %s", want, got)
+			}
+			sum := sha1.Sum(tt.data[tt.headerSize:])
+			if got := item.Hashes[core.HashHeaderlessSHA1]; got != hex.EncodeToString(sum[:]) {
+				t.Errorf("Expected headerless SHA1 %x, got %s", sum, got)
+			}
+		})
+	}
+}
+
+func TestIdentifyHeaderlessNES(t *testing.T) {
+	// NROM cartridge with an iNES header: 16 KiB PRG-ROM, 8 KiB CHR-ROM
+	header := []byte{'N', 'E', 'S', 0x1A, 1, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}
+	rom := make([]byte, 24*1024)
+	for i := range rom {
+		rom[i] = byte(i)
+	}
+	data := append(header, rom...)
+
+	path := filepath.Join(t.TempDir(), "test.nes")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+
+	result, err := Identify(path, DefaultOptions())
+	if err != nil {
+		t.Fatalf("Identify() error = %v", err)
+	}
+
+	item := result.Items[0]
+	if item.HeaderSize != 16 {
+		t.Errorf("Expected header size 16, got %d", item.HeaderSize)
+	}
+	fullSum := sha1.Sum(data)
+	if got := item.Hashes[core.HashSHA1]; got != hex.EncodeToString(fullSum[:]) {
+		t.Errorf("Expected SHA1 of full file %x, got %s", fullSum, got)
+	}
+	sum := sha1.Sum(rom)
+	if got := item.Hashes[core.HashHeaderlessSHA1]; got != hex.EncodeToString(sum[:]) {
+		t.Errorf("Expected headerless SHA1 %x, got %s", sum, got)
+	}
+	if got, want := item.Hashes[core.HashHeaderlessCRC32], fmt.Sprintf("%08x", crc32.ChecksumIEEE(rom)); got != want {
+		t.Errorf("Expected headerless CRC32 %s, got %s", want, got)
+	}
+}
+
+func TestIdentifySniff(t *testing.T) {
+	header := []byte{'N', 'E', 'S', 0x1A, 1, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}
+	data := append(header, make([]byte, 24*1024)...)
+
+	dir := t.TempDir()
+	for _, name := range []string{"game", "game.bin", "game.dat"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatalf("failed to write %s: %v", path, err)
+		}
+
+		result, err := Identify(path, DefaultOptions())
+		if err != nil {
+			t.Fatalf("Identify(%s) error = %v", name, err)
+		}
+		if game := result.Items[0].Game; game != nil {
+			t.Errorf("Identify(%s) without sniffing = %T, want nil", name, game)
+		}
+
+		opts := DefaultOptions()
+		opts.Sniff = true
+		result, err = Identify(path, opts)
+		if err != nil {
+			t.Fatalf("Identify(%s) error = %v", name, err)
+		}
+		item := result.Items[0]
+		if _, ok := item.Game.(*nes.Info); !ok {
+			t.Errorf("Identify(%s) with sniffing = %T, want *nes.Info", name, item.Game)
+		}
+		if item.HeaderSize != 16 {
+			t.Errorf("Identify(%s) header size = %d, want 16", name, item.HeaderSize)
+		}
+	}
+}
+
+func TestIdentifyHeaderlessSMD(t *testing.T) {
+	// One 16 KiB block of a native Mega Drive ROM, interleaved into SMD form:
+	// odd-position bytes in the first half, even-position bytes in the second
+	rom := make([]byte, 16*1024)
+	for i := range rom {
+		rom[i] = byte(i * 3)
+	}
+	copy(rom[0x100:], "SEGA MEGA DRIVE ")
+	data := make([]byte, 512+len(rom))
+	data[0], data[1], data[8], data[9] = 1, 0x03, 0xAA, 0xBB
+	for i := range len(rom) / 2 {
+		data[512+i] = rom[i*2+1]
+		data[512+len(rom)/2+i] = rom[i*2]
+	}
+
+	path := filepath.Join(t.TempDir(), "test.smd")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+
+	result, err := Identify(path, DefaultOptions())
+	if err != nil {
+		t.Fatalf("Identify() error = %v", err)
+	}
+
+	item := result.Items[0]
+	if item.Game == nil || item.Game.GamePlatform() != core.PlatformMD {
+		t.Fatalf("Expected platform %s, got %v", core.PlatformMD, item.Game)
+	}
+	if item.HeaderSize != 512 {
+		t.Errorf("Expected header size 512, got %d", item.HeaderSize)
+	}
+
+	// Headerless hashes should cover the native ROM, matching DATs
+	sum := sha1.Sum(rom)
+	if got := item.Hashes[core.HashHeaderlessSHA1]; got != hex.EncodeToString(sum[:]) {
+		t.Errorf("Expected headerless SHA1 %x, got %s", sum, got)
+	}
+	if got, want := item.Hashes[core.HashHeaderlessCRC32], fmt.Sprintf("%08x", crc32.ChecksumIEEE(rom)); got != want {
+		t.Errorf("Expected headerless CRC32 %s, got %s", want, got)
+	}
+	fullSum := sha1.Sum(data)
+	if got := item.Hashes[core.HashSHA1]; got != hex.EncodeToString(fullSum[:]) {
+		t.Errorf("Expected SHA1 of full file %x, got %s", fullSum, got)
+	}
+}
+
+func TestIdentifyHeaderlessGB(t *testing.T) {
+	// Formats without a header only get full-file hashes
+	result, err := Identify("testdata/gbtictac.gb", DefaultOptions())
+	if err != nil {
+		t.Fatalf("Identify() error = %v", err)
+	}
+	item := result.Items[0]
+	if item.HeaderSize != 0 {
+		t.Errorf("Expected header size 0, got %d", item.HeaderSize)
+	}
+	if _, ok := item.Hashes[core.HashHeaderlessSHA1]; ok {
+		t.Error("Expected no headerless SHA1")
+	}
+}
+
+// makeTestSFO creates a minimal PARAM.SFO with DISC_ID and TITLE entries.
+func makeTestSFO(discID, title string) []byte {
+	keys := "DISC_ID\x00TITLE\x00"
+	values := []string{discID + "\x00", title + "\x00"}
+	keyOffsets := []uint16{0, 8}
+
+	keyTableOffset := 20 + 2*16
+	dataTableOffset := keyTableOffset + len(keys)
+	sfo := make([]byte, dataTableOffset)
+	copy(sfo, "\x00PSF")
+	binary.LittleEndian.PutUint32(sfo[4:], 0x0101)
+	binary.LittleEndian.PutUint32(sfo[8:], uint32(keyTableOffset))
+	binary.LittleEndian.PutUint32(sfo[12:], uint32(dataTableOffset))
+	binary.LittleEndian.PutUint32(sfo[16:], 2)
+	copy(sfo[keyTableOffset:], keys)
+
+	dataOffset := 0
+	for i, v := range values {
+		entry := sfo[20+i*16:]
+		binary.LittleEndian.PutUint16(entry[0:], keyOffsets[i])
+		binary.LittleEndian.PutUint16(entry[2:], 0x0204)
+		binary.LittleEndian.PutUint32(entry[4:], uint32(len(v)))
+		binary.LittleEndian.PutUint32(entry[8:], uint32(len(v)))
+		binary.LittleEndian.PutUint32(entry[12:], uint32(dataOffset))
+		sfo = append(sfo, v...)
+		dataOffset += len(v)
+	}
+	return sfo
+}
+
+func TestIdentifyPS3Folder(t *testing.T) {
+	dir := t.TempDir()
+	gameDir := filepath.Join(dir, "PS3_GAME")
+	if err := os.MkdirAll(filepath.Join(gameDir, "USRDIR"), 0o755); err != nil {
+		t.Fatalf("failed to create %s: %v", gameDir, err)
+	}
+	if err := os.WriteFile(filepath.Join(gameDir, "PARAM.SFO"), makeTestSFO("BLUS30001", "Test Game"), 0o644); err != nil {
+		t.Fatalf("failed to write PARAM.SFO: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(gameDir, "USRDIR", "EBOOT.BIN"), []byte("SCE\x00"), 0o644); err != nil {
+		t.Fatalf("failed to write EBOOT.BIN: %v", err)
+	}
+
+	result, err := Identify(dir, DefaultOptions())
+	if err != nil {
+		t.Fatalf("Identify() error = %v", err)
+	}
+
+	var found bool
+	for _, item := range result.Items {
+		if item.Game == nil {
+			continue
+		}
+		found = true
+		if item.Name != filepath.Join("PS3_GAME", "PARAM.SFO") {
+			t.Errorf("Expected PARAM.SFO to be identified, got %s", item.Name)
+		}
+		if item.Game.GamePlatform() != core.PlatformPS3 {
+			t.Errorf("Expected platform %s, got %s", core.PlatformPS3, item.Game.GamePlatform())
+		}
+		if item.Game.GameSerial() != "BLUS-30001" {
+			t.Errorf("Expected serial BLUS-30001, got %s", item.Game.GameSerial())
+		}
+	}
+	if !found {
+		t.Error("Expected PS3_GAME/PARAM.SFO to be identified")
+	}
+}
+
+func TestIdentifyCCD(t *testing.T) {
+	// Raw MODE1/2352 data track with a Saturn system area and an ISO9660 PVD
+	const sectorSize, userDataOffset = 2352, 16
+	img := make([]byte, 18*sectorSize)
+	copy(img[userDataOffset:], "SEGA SEGASATURN SEGA TP T-999   T-12345   V1.000")
+	copy(img[userDataOffset+0x60:], "TEST GAME")
+	pvd := img[16*sectorSize+userDataOffset:]
+	pvd[0] = 0x01
+	copy(pvd[1:], "CD001")
+	pvd[6] = 0x01
+	pvd[156] = 34
+	binary.LittleEndian.PutUint32(pvd[156+2:], 17)
+	binary.LittleEndian.PutUint32(pvd[156+10:], 2048)
+
+	ccdData := "[CloneCD]\nVersion=3\n[Disc]\nSessions=1\nDataTracksScrambled=0\n" +
+		"[Entry 0]\nSession=1\nPoint=0x01\nControl=0x04\nPLBA=0\n[TRACK 1]\nMODE=1\nINDEX 1=0\n"
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "game.ccd"), []byte(ccdData), 0o644); err != nil {
+		t.Fatalf("failed to write game.ccd: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "game.img"), img, 0o644); err != nil {
+		t.Fatalf("failed to write game.img: %v", err)
+	}
+
+	// Loose control file: the .img is read from the same directory
+	result, err := Identify(filepath.Join(dir, "game.ccd"), DefaultOptions())
+	if err != nil {
+		t.Fatalf("Identify() error = %v", err)
+	}
+	if game := result.Items[0].Game; game == nil || game.GamePlatform() != core.PlatformSaturn {
+		t.Fatalf("Expected platform %s, got %v", core.PlatformSaturn, game)
+	}
+	if serial := result.Items[0].Game.GameSerial(); serial != "T-12345" {
+		t.Errorf("Expected serial T-12345, got %s", serial)
+	}
+
+	// Folder: both the control file and the image are identified
+	result, err = Identify(dir, DefaultOptions())
+	if err != nil {
+		t.Fatalf("Identify() error = %v", err)
+	}
+	for _, item := range result.Items {
+		if item.Game == nil || item.Game.GamePlatform() != core.PlatformSaturn {
+			t.Errorf("Expected %s to be identified as %s, got %v", item.Name, core.PlatformSaturn, item.Game)
+		}
+	}
+}
+
+func TestIdentifyCUE(t *testing.T) {
+	// Raw MODE1/2352 data track with a Sega CD system area and an ISO9660 PVD
+	const sectorSize, userDataOffset = 2352, 16
+	bin := make([]byte, 18*sectorSize)
+	copy(bin[userDataOffset:], "SEGADISCSYSTEM  ")
+	copy(bin[userDataOffset+0x100:], "SEGA MEGA DRIVE ")
+	copy(bin[userDataOffset+0x150:], "SLAM CITY DISC 2")
+	copy(bin[userDataOffset+0x180:], "GM T-11111-00")
+	copy(bin[userDataOffset+0x1F0:], "U")
+	pvd := bin[16*sectorSize+userDataOffset:]
+	pvd[0] = 0x01
+	copy(pvd[1:], "CD001")
+	pvd[6] = 0x01
+	pvd[156] = 34
+	binary.LittleEndian.PutUint32(pvd[156+2:], 17)
+	binary.LittleEndian.PutUint32(pvd[156+10:], 2048)
+
+	cueData := "FILE \"game (Track 1).bin\" BINARY\n  TRACK 01 MODE1/2352\n    INDEX 01 00:00:00\n" +
+		"FILE \"game (Track 2).bin\" BINARY\n  TRACK 02 AUDIO\n    INDEX 01 00:00:00\n"
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "game.cue"), []byte(cueData), 0o644); err != nil {
+		t.Fatalf("failed to write game.cue: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "game (Track 1).bin"), bin, 0o644); err != nil {
+		t.Fatalf("failed to write game (Track 1).bin: %v", err)
+	}
+
+	result, err := Identify(filepath.Join(dir, "game.cue"), DefaultOptions())
+	if err != nil {
+		t.Fatalf("Identify() error = %v", err)
+	}
+	game, ok := result.Items[0].Game.(*md.CDInfo)
+	if !ok {
+		t.Fatalf("Expected *md.CDInfo, got %T", result.Items[0].Game)
+	}
+	if game.GameSerial() != "GM T-11111-00" {
+		t.Errorf("Expected serial GM T-11111-00, got %s", game.GameSerial())
+	}
+	if game.GameTitle() != "SLAM CITY DISC 2" || game.DiscNumber != 2 {
+		t.Errorf("Expected SLAM CITY DISC 2 (disc 2), got %s (disc %d)", game.GameTitle(), game.DiscNumber)
+	}
+	if regions := game.GameRegions(); len(regions) != 1 || regions[0] != core.RegionAmericas {
+		t.Errorf("Expected regions [%s], got %v", core.RegionAmericas, regions)
+	}
+
+	// A missing BIN leaves the sheet identified by hash only
+	if err := os.Remove(filepath.Join(dir, "game (Track 1).bin")); err != nil {
+		t.Fatal(err)
+	}
+	result, err = Identify(filepath.Join(dir, "game.cue"), DefaultOptions())
+	if err != nil {
+		t.Fatalf("Identify() error = %v", err)
+	}
+	if result.Items[0].Game != nil {
+		t.Errorf("Expected no game without the BIN, got %v", result.Items[0].Game)
+	}
+}
+
+func TestIdentifyCHD_GDROM(t *testing.T) {
+	ipBin, err := os.ReadFile("../roms/sega/dreamcast/testdata/jet_set_radio_jp.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The single-density data track holds no IP.BIN, like the warning track
+	// on real discs; the high-density track starts with it
+	const sectorSize, userDataOffset = 2352, 16
+	lowDensity := make([]byte, 4*2048)
+	highDensity := make([]byte, 4*sectorSize)
+	copy(highDensity[userDataOffset:], ipBin)
+	tracks := []chd.CDTrack{
+		{Type: "MODE1", Data: bytes.NewReader(lowDensity), Frames: 4},
+		{Type: "AUDIO", Data: bytes.NewReader(make([]byte, 4*sectorSize)), Frames: 4},
+		{Type: "MODE1_RAW", Data: bytes.NewReader(highDensity), Frames: 4},
+	}
+
+	path := filepath.Join(t.TempDir(), "game.chd")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := chd.WriteGD(f, tracks, nil); err != nil {
+		t.Fatalf("WriteGD() error = %v", err)
+	}
+	f.Close()
+
+	result, err := Identify(path, DefaultOptions())
+	if err != nil {
+		t.Fatalf("Identify() error = %v", err)
+	}
+	game, ok := result.Items[0].Game.(*dreamcast.Info)
+	if !ok {
+		t.Fatalf("Expected *dreamcast.Info, got %T", result.Items[0].Game)
+	}
+	if game.GameTitle() != "JET SET RADIO" {
+		t.Errorf("Expected title JET SET RADIO, got %s", game.GameTitle())
+	}
+}
+
+func TestIdentifyGDI(t *testing.T) {
+	ipBin, err := os.ReadFile("../roms/sega/dreamcast/testdata/jet_set_radio_jp.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The high-density track starts with the IP.BIN, in raw sectors
+	const sectorSize, userDataOffset = 2352, 16
+	highDensity := make([]byte, 4*sectorSize)
+	copy(highDensity[userDataOffset:], ipBin)
+
+	gdiData := "3\n1 0 4 2352 track01.bin 0\n2 450 0 2352 track02.raw 0\n3 45000 4 2352 track03.bin 0\n"
+	dir := t.TempDir()
+	files := map[string][]byte{
+		"game.gdi":    []byte(gdiData),
+		"track01.bin": make([]byte, 4*sectorSize),
+		"track02.raw": make([]byte, 4*sectorSize),
+		"track03.bin": highDensity,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), content, 0o644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	result, err := Identify(filepath.Join(dir, "game.gdi"), DefaultOptions())
+	if err != nil {
+		t.Fatalf("Identify() error = %v", err)
+	}
+	game, ok := result.Items[0].Game.(*dreamcast.Info)
+	if !ok {
+		t.Fatalf("Expected *dreamcast.Info, got %T", result.Items[0].Game)
+	}
+	if game.GameTitle() != "JET SET RADIO" {
+		t.Errorf("Expected title JET SET RADIO, got %s", game.GameTitle())
+	}
+}
+
+func TestIdentifyCUE_PCECD(t *testing.T) {
+	// Audio track followed by a raw MODE1/2352 data track with an IPL sector
+	const sectorSize, userDataOffset = 2352, 16
+	data := make([]byte, 2*sectorSize)
+	ipl := data[sectorSize+userDataOffset:]
+	copy(ipl[0x20:], "PC Engine CD-ROM SYSTEM\x00")
+	copy(ipl[0x6A:], "GATE OF THUNDER")
+
+	cueData := "FILE \"game (Track 1).bin\" BINARY\n  TRACK 01 AUDIO\n    INDEX 01 00:00:00\n" +
+		"FILE \"game (Track 2).bin\" BINARY\n  TRACK 02 MODE1/2352\n    INDEX 01 00:00:00\n"
+
+	dir := t.TempDir()
+	files := map[string][]byte{
+		"game.cue":           []byte(cueData),
+		"game (Track 1).bin": make([]byte, sectorSize),
+		"game (Track 2).bin": data,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), content, 0o644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	result, err := Identify(filepath.Join(dir, "game.cue"), DefaultOptions())
+	if err != nil {
+		t.Fatalf("Identify() error = %v", err)
+	}
+	game, ok := result.Items[0].Game.(*pce.CDInfo)
+	if !ok {
+		t.Fatalf("Expected *pce.CDInfo, got %T", result.Items[0].Game)
+	}
+	if game.GameTitle() != "GATE OF THUNDER" {
+		t.Errorf("Expected title GATE OF THUNDER, got %s", game.GameTitle())
+	}
+}
+
+func TestIdentifyCUE_JaguarCD(t *testing.T) {
+	// Two audio tracks, the second holding the boot header with its words
+	// swapped, as in most BIN dumps
+	const sectorSize = 2352
+	boot := make([]byte, 4*sectorSize)
+	copy(boot[sectorSize+64:], "TARA IPARPVODED TA AEHDAREA RT I")
+	copy(boot[sectorSize+64+0x20:], []byte{0x00, 0x00, 0x00, 0x40, 0x00, 0x00, 0x00, 0x10})
+
+	cueData := "FILE \"game (Track 1).bin\" BINARY\n  TRACK 01 AUDIO\n    INDEX 01 00:00:00\n" +
+		"FILE \"game (Track 2).bin\" BINARY\n  TRACK 02 AUDIO\n    INDEX 01 00:00:00\n"
+
+	dir := t.TempDir()
+	files := map[string][]byte{
+		"game.cue":           []byte(cueData),
+		"game (Track 1).bin": make([]byte, sectorSize),
+		"game (Track 2).bin": boot,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), content, 0o644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	result, err := Identify(filepath.Join(dir, "game.cue"), DefaultOptions())
+	if err != nil {
+		t.Fatalf("Identify() error = %v", err)
+	}
+	game, ok := result.Items[0].Game.(*jaguar.CDInfo)
+	if !ok {
+		t.Fatalf("Expected *jaguar.CDInfo, got %T", result.Items[0].Game)
+	}
+	if !game.WordSwapped || game.LoadAddress != 0x4000 || game.Length != 0x1000 {
+		t.Errorf("Expected swapped boot header loading 0x1000 bytes at 0x4000, got %+v", game)
+	}
+}
+
+// putISODirRecord writes an ISO 9660 directory record and returns its length.
+func putISODirRecord(buf []byte, name string, sector, size uint32, flags byte) int {
+	length := (33 + len(name) + 1) &^ 1
+	buf[0] = byte(length)
+	binary.LittleEndian.PutUint32(buf[2:], sector)
+	binary.LittleEndian.PutUint32(buf[10:], size)
+	buf[25] = flags
+	buf[32] = byte(len(name))
+	copy(buf[33:], name)
+	return length
+}
+
+func TestIdentifyISO_PSXEXE(t *testing.T) {
+	// PS1 disc with no SYSTEM.CNF, booting a serial-named executable
+	const sectorSize = 2048
+	iso := make([]byte, 21*sectorSize)
+	pvd := iso[16*sectorSize:]
+	pvd[0] = 0x01
+	copy(pvd[1:], "CD001")
+	pvd[6] = 0x01
+	putISODirRecord(pvd[156:], "\x00", 18, sectorSize, 0x02)
+
+	root := iso[18*sectorSize:]
+	n := putISODirRecord(root, "\x00", 18, sectorSize, 0x02)
+	n += putISODirRecord(root[n:], "\x01", 18, sectorSize, 0x02)
+	putISODirRecord(root[n:], "SLES_012.34;1", 19, 2*sectorSize, 0)
+
+	exe := iso[19*sectorSize:]
+	copy(exe, "PS-X EXE")
+	copy(exe[0x4C:], "Sony Computer Entertainment Inc. for Europe area")
+
+	path := filepath.Join(t.TempDir(), "demo.iso")
+	if err := os.WriteFile(path, iso, 0o644); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+
+	result, err := Identify(path, DefaultOptions())
+	if err != nil {
+		t.Fatalf("Identify() error = %v", err)
+	}
+	game := result.Items[0].Game
+	if game == nil {
+		t.Fatal("Expected game info, got nil")
+	}
+	if game.GamePlatform() != core.PlatformPS1 {
+		t.Errorf("Expected platform %s, got %s", core.PlatformPS1, game.GamePlatform())
+	}
+	if game.GameSerial() != "SLES_012.34" {
+		t.Errorf("Expected serial SLES_012.34, got %s", game.GameSerial())
+	}
+	if regions := game.GameRegions(); len(regions) != 1 || regions[0] != core.RegionEurope {
+		t.Errorf("Expected regions [%s], got %v", core.RegionEurope, regions)
+	}
+}
+
+func TestIdentifyISO_PS2BootELF(t *testing.T) {
+	const sectorSize = 2048
+	cnfData := "BOOT2 = cdrom0:\\SLUS_123.45;1\nVER = 1.01\nVMODE = NTSC\n"
+	iso := make([]byte, 21*sectorSize)
+	pvd := iso[16*sectorSize:]
+	pvd[0] = 0x01
+	copy(pvd[1:], "CD001")
+	pvd[6] = 0x01
+	putISODirRecord(pvd[156:], "\x00", 18, sectorSize, 0x02)
+
+	root := iso[18*sectorSize:]
+	n := putISODirRecord(root, "\x00", 18, sectorSize, 0x02)
+	n += putISODirRecord(root[n:], "\x01", 18, sectorSize, 0x02)
+	n += putISODirRecord(root[n:], "SLUS_123.45;1", 19, 0x34, 0)
+	putISODirRecord(root[n:], "SYSTEM.CNF;1", 20, uint32(len(cnfData)), 0)
+
+	elf := iso[19*sectorSize:]
+	copy(elf, "\x7FELF\x01\x01")
+	binary.LittleEndian.PutUint16(elf[0x10:], 2) // Executable
+	binary.LittleEndian.PutUint16(elf[0x12:], 8) // MIPS
+	binary.LittleEndian.PutUint32(elf[0x18:], 0x00100008)
+	binary.LittleEndian.PutUint32(elf[0x24:], 0x20924001)
+	copy(iso[20*sectorSize:], cnfData)
+
+	path := filepath.Join(t.TempDir(), "game.iso")
+	if err := os.WriteFile(path, iso, 0o644); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+
+	result, err := Identify(path, DefaultOptions())
+	if err != nil {
+		t.Fatalf("Identify() error = %v", err)
+	}
+	game, ok := result.Items[0].Game.(*cnf.Info)
+	if !ok {
+		t.Fatalf("Expected *cnf.Info, got %T", result.Items[0].Game)
+	}
+	if game.GameSerial() != "SLUS_123.45" || game.Version != "1.01" {
+		t.Errorf("Expected SLUS_123.45 version 1.01, got %s version %s", game.GameSerial(), game.Version)
+	}
+	if game.BootELF == nil || game.BootELF.Entry != 0x00100008 || !game.BootELF.R5900 {
+		t.Errorf("Expected R5900 boot ELF with entry 0x100008, got %+v", game.BootELF)
+	}
+}
+
+func TestIdentifyNKit(t *testing.T) {
+	disc := make([]byte, 0x440)
+	copy(disc, "GALE01")
+	binary.BigEndian.PutUint32(disc[0x1C:], 0xC2339F3D) // GameCube magic
+	copy(disc[0x20:], "SUPER SMASH BROS MELEE")
+	copy(disc[0x200:], "NKIT v01")
+	binary.BigEndian.PutUint32(disc[0x208:], 0x0E7A7A3F)
+	binary.BigEndian.PutUint32(disc[0x210:], 1459978240)
+
+	path := filepath.Join(t.TempDir(), "game.nkit.iso")
+	if err := os.WriteFile(path, disc, 0o644); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+
+	result, err := Identify(path, DefaultOptions())
+	if err != nil {
+		t.Fatalf("Identify() error = %v", err)
+	}
+	item := result.Items[0]
+	if item.Game == nil || item.Game.GamePlatform() != core.PlatformGC {
+		t.Fatalf("Expected platform %s, got %v", core.PlatformGC, item.Game)
+	}
+	if got := item.Hashes[core.HashNKitCRC32]; got != "0e7a7a3f" {
+		t.Errorf("Expected NKit CRC32 0e7a7a3f, got %q", got)
+	}
+}
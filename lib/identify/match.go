@@ -0,0 +1,205 @@
+package identify
+
+import (
+	"strings"
+
+	"github.com/sargunv/rom-tools/lib/core"
+	"github.com/sargunv/rom-tools/lib/datfile"
+)
+
+// MatchStatus describes how an item relates to the loaded DATs.
+type MatchStatus string
+
+const (
+	MatchStatusVerified MatchStatus = "verified" // hashes match a good dump
+	MatchStatusBadDump  MatchStatus = "bad-dump" // hashes match a dump the DAT marks as bad
+	MatchStatusUnknown  MatchStatus = "unknown"  // no DAT entry matches
+)
+
+// Match is the DAT entry an item was matched against.
+type Match struct {
+	Status MatchStatus `json:"status"`
+	Game   string      `json:"game,omitempty"`   // game (or machine) name from the DAT
+	ROM    string      `json:"rom,omitempty"`    // ROM (or disk) name within the game
+	Source string      `json:"source,omitempty"` // name of the DAT the entry came from
+}
+
+// datEntry is a single ROM or disk from a loaded DAT.
+type datEntry struct {
+	game   string
+	rom    string
+	size   int64 // -1 for disks, which don't record a size
+	status datfile.DumpStatus
+	source string
+}
+
+// DATIndex is a hash index over the ROMs and disks of one or more DAT files.
+type DATIndex struct {
+	sha256 map[string]*datEntry
+	sha1   map[string]*datEntry
+	md5    map[string]*datEntry
+	crc32  map[string][]*datEntry // CRC32 alone is too weak; candidates are filtered by size
+
+	sets      map[string]*datSet   // by game name
+	setsByCRC map[string][]*datSet // by the CRC32 of each ROM
+}
+
+// NewDATIndex creates an empty DAT index.
+func NewDATIndex() *DATIndex {
+	return &DATIndex{
+		sha256: make(map[string]*datEntry),
+		sha1:   make(map[string]*datEntry),
+		md5:    make(map[string]*datEntry),
+		crc32:  make(map[string][]*datEntry),
+
+		sets:      make(map[string]*datSet),
+		setsByCRC: make(map[string][]*datSet),
+	}
+}
+
+// Add indexes every ROM and disk in dat, and every game as a set of ROMs.
+// The source identifies the DAT in match results; if empty, the DAT header
+// name is used.
+// When several DATs contain the same hash, the first one added wins.
+func (x *DATIndex) Add(source string, dat *datfile.Datafile) {
+	if source == "" {
+		source = dat.Header.Name
+	}
+
+	for i := range dat.Games {
+		game := &dat.Games[i]
+		x.addSet(source, game)
+		for _, rom := range game.ROMs {
+			if rom.Status == datfile.DumpStatusNoDump {
+				continue
+			}
+			x.add(&datEntry{
+				game:   game.Name,
+				rom:    rom.Name,
+				size:   rom.Size,
+				status: rom.Status,
+				source: source,
+			}, rom.SHA256, rom.SHA1, rom.MD5, rom.CRC)
+		}
+		for _, disk := range game.Disks {
+			if disk.Status == datfile.DumpStatusNoDump {
+				continue
+			}
+			x.add(&datEntry{
+				game:   game.Name,
+				rom:    disk.Name,
+				size:   -1,
+				status: disk.Status,
+				source: source,
+			}, "", disk.SHA1, disk.MD5, "")
+		}
+	}
+}
+
+func (x *DATIndex) add(entry *datEntry, sha256, sha1, md5, crc string) {
+	if sha256 = normalizeHash(sha256); sha256 != "" {
+		if _, ok := x.sha256[sha256]; !ok {
+			x.sha256[sha256] = entry
+		}
+	}
+	if sha1 = normalizeHash(sha1); sha1 != "" {
+		if _, ok := x.sha1[sha1]; !ok {
+			x.sha1[sha1] = entry
+		}
+	}
+	if md5 = normalizeHash(md5); md5 != "" {
+		if _, ok := x.md5[md5]; !ok {
+			x.md5[md5] = entry
+		}
+	}
+	if crc = normalizeHash(crc); crc != "" {
+		x.crc32[crc] = append(x.crc32[crc], entry)
+	}
+}
+
+// Len returns the number of distinct SHA1 hashes in the index.
+func (x *DATIndex) Len() int {
+	return len(x.sha1)
+}
+
+// Matcher annotates identified items with their DAT entries.
+type Matcher struct {
+	index *DATIndex
+}
+
+// NewMatcher creates a Matcher backed by index.
+func NewMatcher(index *DATIndex) *Matcher {
+	return &Matcher{index: index}
+}
+
+// Match looks up an item by its hashes, strongest first: SHA256 (when
+// calculated), SHA1, MD5, then CRC32 together with the item size. CHD header SHA1s and MD5s are matched
+// against disk entries, and ZIP metadata CRC32s are treated like calculated
+// ones. Headerless hashes are tried after the full-file hashes of each kind,
+// with the header excluded from the size.
+func (m *Matcher) Match(item Item) *Match {
+	if entry := m.lookup(item); entry != nil {
+		status := MatchStatusVerified
+		if entry.status == datfile.DumpStatusBadDump {
+			status = MatchStatusBadDump
+		}
+		return &Match{
+			Status: status,
+			Game:   entry.game,
+			ROM:    entry.rom,
+			Source: entry.source,
+		}
+	}
+	return &Match{Status: MatchStatusUnknown}
+}
+
+func (m *Matcher) lookup(item Item) *datEntry {
+	for _, ht := range []core.HashType{core.HashSHA256, core.HashHeaderlessSHA256} {
+		if entry, ok := m.index.sha256[normalizeHash(item.Hashes[ht])]; ok {
+			return entry
+		}
+	}
+
+	for _, ht := range []core.HashType{core.HashSHA1, core.HashHeaderlessSHA1, core.HashCHDCompressedSHA1} {
+		if entry, ok := m.index.sha1[normalizeHash(item.Hashes[ht])]; ok {
+			return entry
+		}
+	}
+
+	for _, ht := range []core.HashType{core.HashMD5, core.HashHeaderlessMD5, core.HashCHDMD5} {
+		if entry, ok := m.index.md5[normalizeHash(item.Hashes[ht])]; ok {
+			return entry
+		}
+	}
+
+	crcSizes := []struct {
+		ht   core.HashType
+		size int64
+	}{
+		{core.HashCRC32, item.Size},
+		{core.HashZipCRC32, item.Size},
+		{core.HashHeaderlessCRC32, item.Size - item.HeaderSize},
+	}
+	for _, cs := range crcSizes {
+		for _, entry := range m.index.crc32[normalizeHash(item.Hashes[cs.ht])] {
+			if entry.size == cs.size {
+				return entry
+			}
+		}
+	}
+
+	return nil
+}
+
+// Annotate sets the Match field on every item in result, and the Set field
+// on result.
+func (m *Matcher) Annotate(result *Result) {
+	for i := range result.Items {
+		result.Items[i].Match = m.Match(result.Items[i])
+	}
+	result.Set = m.MatchSet(result)
+}
+
+func normalizeHash(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
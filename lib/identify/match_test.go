@@ -0,0 +1,103 @@
+package identify
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/sargunv/rom-tools/lib/core"
+	"github.com/sargunv/rom-tools/lib/datfile"
+)
+
+func loadTestDAT(t *testing.T, games string) *datfile.Datafile {
+	t.Helper()
+	dat, err := datfile.ParseReader(strings.NewReader(`<?xml version="1.0"?>
+<datafile>
+	<header><name>Test DAT</name></header>
+	` + games + `
+</datafile>`))
+	if err != nil {
+		t.Fatalf("failed to parse test DAT: %v", err)
+	}
+	return dat
+}
+
+func TestMatcher_Annotate(t *testing.T) {
+	result, err := Identify("testdata/gbtictac.gb", DefaultOptions())
+	if err != nil {
+		t.Fatalf("Identify() error = %v", err)
+	}
+	item := result.Items[0]
+
+	index := NewDATIndex()
+	index.Add("", loadTestDAT(t, fmt.Sprintf(
+		`<game name="Tic-Tac-Toe (World)"><rom name="Tic-Tac-Toe (World).gb" size="%d" sha1="%s"/></game>`,
+		item.Size, strings.ToUpper(item.Hashes[core.HashSHA1]))))
+
+	NewMatcher(index).Annotate(result)
+
+	match := result.Items[0].Match
+	if match == nil {
+		t.Fatal("Expected match, got nil")
+	}
+	if match.Status != MatchStatusVerified {
+		t.Errorf("Expected status %s, got %s", MatchStatusVerified, match.Status)
+	}
+	if match.Game != "Tic-Tac-Toe (World)" {
+		t.Errorf("Expected game 'Tic-Tac-Toe (World)', got '%s'", match.Game)
+	}
+	if match.ROM != "Tic-Tac-Toe (World).gb" {
+		t.Errorf("Expected ROM 'Tic-Tac-Toe (World).gb', got '%s'", match.ROM)
+	}
+	if match.Source != "Test DAT" {
+		t.Errorf("Expected source 'Test DAT', got '%s'", match.Source)
+	}
+}
+
+func TestMatcher_Match(t *testing.T) {
+	index := NewDATIndex()
+	index.Add("redump", loadTestDAT(t, `
+	<game name="Good"><rom name="good.bin" size="4" crc="11111111" md5="aaaa"/></game>
+	<game name="Bad"><rom name="bad.bin" size="4" crc="22222222" sha1="bbbb" status="baddump"/></game>
+	<game name="Missing"><rom name="missing.bin" size="4" status="nodump"/></game>
+	<game name="Disc"><disk name="disc" sha1="cccc"/></game>
+	<game name="Old Disc"><disk name="old" md5="dddd"/></game>
+	<game name="New"><rom name="new.bin" size="4" crc="33333333" sha1="eeee" sha256="9999"/></game>`))
+	matcher := NewMatcher(index)
+
+	tests := []struct {
+		name       string
+		item       Item
+		wantStatus MatchStatus
+		wantGame   string
+	}{
+		{"md5", Item{Size: 4, Hashes: core.Hashes{core.HashMD5: "AAAA"}}, MatchStatusVerified, "Good"},
+		{"crc32 with size", Item{Size: 4, Hashes: core.Hashes{core.HashCRC32: "11111111"}}, MatchStatusVerified, "Good"},
+		{"zip crc32", Item{Size: 4, Hashes: core.Hashes{core.HashZipCRC32: "11111111"}}, MatchStatusVerified, "Good"},
+		{"crc32 size mismatch", Item{Size: 8, Hashes: core.Hashes{core.HashCRC32: "11111111"}}, MatchStatusUnknown, ""},
+		{"bad dump", Item{Size: 4, Hashes: core.Hashes{core.HashSHA1: "bbbb"}}, MatchStatusBadDump, "Bad"},
+		{"chd sha1", Item{Size: 100, Hashes: core.Hashes{core.HashCHDCompressedSHA1: "cccc"}}, MatchStatusVerified, "Disc"},
+		{"chd md5", Item{Size: 100, Hashes: core.Hashes{core.HashCHDMD5: "dddd"}}, MatchStatusVerified, "Old Disc"},
+		{"headerless md5", Item{Size: 20, HeaderSize: 16, Hashes: core.Hashes{core.HashMD5: "ffff", core.HashHeaderlessMD5: "aaaa"}}, MatchStatusVerified, "Good"},
+		{"headerless crc32 with size", Item{Size: 20, HeaderSize: 16, Hashes: core.Hashes{core.HashHeaderlessCRC32: "11111111"}}, MatchStatusVerified, "Good"},
+		{"headerless crc32 size mismatch", Item{Size: 20, Hashes: core.Hashes{core.HashHeaderlessCRC32: "11111111"}}, MatchStatusUnknown, ""},
+		{"sha256", Item{Size: 4, Hashes: core.Hashes{core.HashSHA256: "9999"}}, MatchStatusVerified, "New"},
+		{"headerless sha256", Item{Size: 20, HeaderSize: 16, Hashes: core.Hashes{core.HashSHA1: "ffff", core.HashHeaderlessSHA256: "9999"}}, MatchStatusVerified, "New"},
+		{"no hashes", Item{Size: 4}, MatchStatusUnknown, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			match := matcher.Match(tt.item)
+			if match.Status != tt.wantStatus {
+				t.Errorf("Expected status %s, got %s", tt.wantStatus, match.Status)
+			}
+			if match.Game != tt.wantGame {
+				t.Errorf("Expected game '%s', got '%s'", tt.wantGame, match.Game)
+			}
+			if tt.wantGame != "" && match.Source != "redump" {
+				t.Errorf("Expected source 'redump', got '%s'", match.Source)
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package identify
+
+import (
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/sargunv/rom-tools/internal/util"
+	"github.com/sargunv/rom-tools/lib/ccd"
+	"github.com/sargunv/rom-tools/lib/cue"
+	"github.com/sargunv/rom-tools/lib/gdi"
+)
+
+// auxiliaryExtensions are the extensions of files that accompany games, but
+// are never games themselves: subchannel data, patches for it, checksums,
+// and documentation.
+var auxiliaryExtensions = map[string]bool{
+	".sub":  true,
+	".sbi":  true,
+	".sfv":  true,
+	".md5":  true,
+	".sha1": true,
+	".txt":  true,
+	".nfo":  true,
+	".diz":  true,
+	".pdf":  true,
+	".jpg":  true,
+	".jpeg": true,
+	".png":  true,
+	".log":  true,
+}
+
+// bootFiles are paths, upper case with forward slashes and matching the end
+// of an item's name, of the files that boot the games of disc and homebrew
+// folders. Other items within a folder holding one are part of its game.
+var bootFiles = []string{
+	"PS3_GAME/PARAM.SFO",
+	"PSP_GAME/PARAM.SFO",
+	"DEFAULT.XBE",
+	"EBOOT.PBP",
+}
+
+// markPrimary sets Item.Primary for items of the same container, which have
+// been identified. The primary items are the games: control files (e.g., CUE
+// sheets) rather than the tracks they describe, the boot files of disc
+// folders rather than their other files, and other identified items. If
+// none are, the largest item that could be a game is, like the data track
+// of a disc whose format isn't recognized.
+//
+// refs holds the names of the items that control files describe, in lower
+// case. Items in nested are already marked, but count toward there being a
+// primary item.
+func markPrimary(items []*Item, refs map[string]bool, nested []Item) {
+	var roots []string
+	for _, item := range items {
+		if root, ok := bootFileRoot(item.Name); ok {
+			roots = append(roots, root)
+		}
+	}
+
+	found := false
+	for _, item := range nested {
+		found = found || item.Primary
+	}
+	var largest *Item
+	for _, item := range items {
+		item.Primary = false
+		if !couldBePrimary(item.Name, refs, roots) {
+			continue
+		}
+		_, control := companionRegistry[strings.ToLower(filepath.Ext(item.Name))]
+		_, boot := bootFileRoot(item.Name)
+		item.Primary = control || boot || item.Game != nil
+		found = found || item.Primary
+		if largest == nil || item.Size > largest.Size {
+			largest = item
+		}
+	}
+	if !found && largest != nil {
+		largest.Primary = true
+	}
+}
+
+// couldBePrimary reports whether the item name could be a game: one that
+// isn't described by a control file, part of a folder with a boot file
+// (other than the boot file), or an auxiliary file.
+func couldBePrimary(name string, refs map[string]bool, roots []string) bool {
+	if refs[strings.ToLower(filepath.ToSlash(name))] {
+		return false
+	}
+	if _, boot := bootFileRoot(name); !boot {
+		slashed := strings.ToUpper(filepath.ToSlash(name))
+		for _, root := range roots {
+			if strings.HasPrefix(slashed, root) {
+				return false
+			}
+		}
+	}
+	return !auxiliaryExtensions[strings.ToLower(filepath.Ext(name))]
+}
+
+// bootFileRoot returns the upper case path, with forward slashes, of the
+// folder that name boots, if it's a boot file.
+func bootFileRoot(name string) (string, bool) {
+	slashed := strings.ToUpper(filepath.ToSlash(name))
+	for _, boot := range bootFiles {
+		if slashed == boot || strings.HasSuffix(slashed, "/"+boot) {
+			return strings.TrimSuffix(slashed, boot), true
+		}
+	}
+	return "", false
+}
+
+// controlFileRefs adds the names of the entries of c that the control file
+// entry describes to refs, in lower case: a CUE sheet's files, a GDI's track
+// files, or a CloneCD sheet's image and subchannel data. Unreadable control
+// files describe nothing.
+func controlFileRefs(c util.FileContainer, entry util.FileEntry, refs map[string]bool) {
+	r, size, err := c.OpenFileAt(entry.Name)
+	if err != nil {
+		return
+	}
+	defer r.Close()
+
+	dir := strings.TrimSuffix(filepath.ToSlash(entry.Name), filepath.Base(entry.Name))
+	for _, name := range controlFileNames(r, size, entry.Name) {
+		refs[strings.ToLower(dir+name)] = true
+	}
+}
+
+// controlFileNames returns the names of the files the control file name
+// describes, relative to its directory.
+func controlFileNames(r io.ReaderAt, size int64, name string) []string {
+	var names []string
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".cue":
+		sheet, err := cue.Parse(r, size)
+		if err != nil {
+			return nil
+		}
+		for _, f := range sheet.Files {
+			names = append(names, f.Name)
+		}
+	case ".gdi":
+		sheet, err := gdi.Parse(r, size)
+		if err != nil {
+			return nil
+		}
+		for _, t := range sheet.Tracks {
+			names = append(names, t.File)
+		}
+	case ".ccd":
+		if _, err := ccd.Parse(r, size); err != nil {
+			return nil
+		}
+		base := strings.TrimSuffix(filepath.Base(name), filepath.Ext(name))
+		names = append(names, base+".img", base+".sub")
+	}
+	return names
+}
@@ -0,0 +1,96 @@
+package identify
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestIdentifyPrimary(t *testing.T) {
+	rom, err := os.ReadFile("testdata/gbtictac.gb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cueData := "FILE \"game (Track 1).bin\" BINARY\n  TRACK 01 MODE1/2352\n    INDEX 01 00:00:00\n" +
+		"FILE \"GAME (Track 2).bin\" BINARY\n  TRACK 02 AUDIO\n    INDEX 01 00:00:00\n"
+
+	tests := []struct {
+		name  string
+		files map[string][]byte
+		want  []string
+	}{
+		{
+			name: "games",
+			files: map[string][]byte{
+				"disc/game.cue":            []byte(cueData),
+				"disc/game (Track 1).bin":  make([]byte, 4096),
+				"disc/game (Track 2).bin":  make([]byte, 8192),
+				"disc/game.sub":            make([]byte, 1024),
+				"gbtictac.gb":              rom,
+				"readme.txt":               []byte("hello"),
+				"xbox/default.xbe":         make([]byte, 16),
+				"xbox/media/intro.bin":     make([]byte, 65536),
+				"xbox/media/dashboard.xbe": make([]byte, 16),
+			},
+			want: []string{"disc/game.cue", "gbtictac.gb", "xbox/default.xbe"},
+		},
+		{
+			// Without identified games, the largest file is the game
+			name: "unidentified",
+			files: map[string][]byte{
+				"track01.bin": make([]byte, 8192),
+				"track02.bin": make([]byte, 4096),
+				"notes.txt":   make([]byte, 16384),
+			},
+			want: []string{"track01.bin"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, data := range tt.files {
+				path := filepath.Join(dir, name)
+				if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, data, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			result, err := Identify(dir, DefaultOptions())
+			if err != nil {
+				t.Fatalf("Identify() error = %v", err)
+			}
+			var got []string
+			for _, item := range result.Items {
+				if item.Primary {
+					got = append(got, filepath.ToSlash(item.Name))
+				}
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Primary items = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIdentifyPrimaryLooseFile(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"game.bin", "game.sub"} {
+		if err := os.WriteFile(filepath.Join(dir, name), make([]byte, 16), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, want := range map[string]bool{"game.bin": true, "game.sub": false} {
+		result, err := Identify(filepath.Join(dir, name), DefaultOptions())
+		if err != nil {
+			t.Fatalf("Identify(%s) error = %v", name, err)
+		}
+		if got := result.Items[0].Primary; got != want {
+			t.Errorf("Identify(%s) primary = %v, want %v", name, got, want)
+		}
+	}
+}
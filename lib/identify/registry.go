@@ -1,11 +1,31 @@
 package identify
 
 import (
+	"bytes"
+	"cmp"
+	"context"
 	"io"
 	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
 
+	"github.com/sargunv/rom-tools/internal/container/compressed"
+	"github.com/sargunv/rom-tools/internal/container/rar"
+	"github.com/sargunv/rom-tools/internal/container/tar"
+	"github.com/sargunv/rom-tools/internal/container/zip"
+	"github.com/sargunv/rom-tools/internal/util"
 	"github.com/sargunv/rom-tools/lib/core"
+	"github.com/sargunv/rom-tools/lib/roms/atari/a2600"
+	"github.com/sargunv/rom-tools/lib/roms/atari/a7800"
+	"github.com/sargunv/rom-tools/lib/roms/atari/jaguar"
+	"github.com/sargunv/rom-tools/lib/roms/atari/lynx"
+	"github.com/sargunv/rom-tools/lib/roms/commodore/amiga"
+	"github.com/sargunv/rom-tools/lib/roms/commodore/c64"
+	"github.com/sargunv/rom-tools/lib/roms/msx"
+	"github.com/sargunv/rom-tools/lib/roms/nec/pce"
+	"github.com/sargunv/rom-tools/lib/roms/nintendo/ciso"
+	"github.com/sargunv/rom-tools/lib/roms/nintendo/fds"
 	"github.com/sargunv/rom-tools/lib/roms/nintendo/gb"
 	"github.com/sargunv/rom-tools/lib/roms/nintendo/gba"
 	"github.com/sargunv/rom-tools/lib/roms/nintendo/gcm"
@@ -15,64 +35,343 @@ import (
 	"github.com/sargunv/rom-tools/lib/roms/nintendo/nes"
 	"github.com/sargunv/rom-tools/lib/roms/nintendo/rvz"
 	"github.com/sargunv/rom-tools/lib/roms/nintendo/sfc"
+	"github.com/sargunv/rom-tools/lib/roms/nintendo/wbfs"
+	"github.com/sargunv/rom-tools/lib/roms/playstation/pbp"
 	"github.com/sargunv/rom-tools/lib/roms/playstation/pkg"
+	"github.com/sargunv/rom-tools/lib/roms/playstation/sfo"
 	"github.com/sargunv/rom-tools/lib/roms/sega/md"
 	"github.com/sargunv/rom-tools/lib/roms/sega/sms"
+	"github.com/sargunv/rom-tools/lib/roms/sinclair/zxspectrum"
+	"github.com/sargunv/rom-tools/lib/roms/threedo"
 	"github.com/sargunv/rom-tools/lib/roms/xbox/xbe"
 	"github.com/sargunv/rom-tools/lib/roms/xbox/xiso"
 )
 
-// identifyFunc attempts to identify content from a reader.
-// Returns game info, optional embedded hashes (for formats like CHD), and error.
-type identifyFunc func(r io.ReaderAt, size int64) (core.GameInfo, core.Hashes, error)
+// IdentifyFunc identifies content from a reader. It returns the game info,
+// any hashes embedded in the format (e.g., a CHD's), and an error if the
+// content isn't in the format.
+type IdentifyFunc func(r io.ReaderAt, size int64) (core.GameInfo, core.Hashes, error)
 
-// wrapParser converts a typed parser function to the generic signature.
+// WrapParser converts a typed parser function to an IdentifyFunc.
 // This is needed because Go function types are invariant - a function returning
 // *GBAInfo is not assignable to a function returning GameInfo even though
 // *GBAInfo implements GameInfo.
-func wrapParser[T core.GameInfo](fn func(io.ReaderAt, int64) (T, error)) identifyFunc {
+func WrapParser[T core.GameInfo](fn func(io.ReaderAt, int64) (T, error)) IdentifyFunc {
 	return func(r io.ReaderAt, size int64) (core.GameInfo, core.Hashes, error) {
 		info, err := fn(r, size)
 		return info, nil, err
 	}
 }
 
-// registry maps file extensions to ordered list of parsers to try.
-// Parsers are tried in order until one succeeds.
-var registry = map[string][]identifyFunc{
-	".gba":  {wrapParser(gba.Parse)},
-	".gb":   {wrapParser(gb.Parse)},
-	".gbc":  {wrapParser(gb.Parse)},
-	".nds":  {wrapParser(nds.Parse)},
-	".dsi":  {wrapParser(nds.Parse)},
-	".ids":  {wrapParser(nds.Parse)},
-	".3ds":  {wrapParser(n3ds.Parse)},
-	".cci":  {wrapParser(n3ds.Parse)},
-	".nes":  {wrapParser(nes.Parse)},
-	".sfc":  {wrapParser(sfc.Parse)},
-	".smc":  {wrapParser(sfc.Parse)},
-	".z64":  {wrapParser(n64.Parse)},
-	".v64":  {wrapParser(n64.Parse)},
-	".n64":  {wrapParser(n64.Parse)},
-	".md":   {wrapParser(md.Parse)},
-	".gen":  {wrapParser(md.Parse)},
-	".32x":  {wrapParser(md.Parse)},
-	".smd":  {wrapParser(md.Parse)},
-	".sms":  {wrapParser(sms.Parse)},
-	".gg":   {wrapParser(sms.Parse)},
-	".xbe":  {wrapParser(xbe.Parse)},
-	".pkg":  {wrapParser(pkg.Parse)},
-	".chd":  {identifyCHD},
-	".rvz":  {wrapParser(rvz.Parse)},
-	".wia":  {wrapParser(rvz.Parse)},
-	".gcm":  {wrapParser(gcm.Parse)},
-	".xiso": {wrapParser(xiso.Parse)},
-	".iso":  {wrapParser(xiso.Parse), wrapParser(gcm.Parse), identifyISO9660},
-	".bin":  {identifyISO9660, wrapParser(md.Parse)},
-}
-
-// identifyByExtension returns the list of parsers to try for a given filename.
-func identifyByExtension(filename string) []identifyFunc {
+// headerSize returns the size of the header that DATs strip before hashing
+// ROMs of game's format, or 0 if there's none. The rest of the file is the
+// canonical payload, which gets its own headerless hashes.
+func headerSize(game core.GameInfo) int64 {
+	switch info := game.(type) {
+	case *nes.Info:
+		return nes.HeaderSize
+	case *fds.Info:
+		return info.HeaderSize
+	case *sfc.Info:
+		if info.HasCopierHeader {
+			return sfc.CopierHeaderSize
+		}
+	case *pce.Info:
+		return info.HeaderSize
+	case *lynx.Info:
+		return lynx.HeaderSize
+	case *a7800.Info:
+		return a7800.HeaderSize
+	case *md.Info:
+		if info.SourceFormat == md.FormatSMD {
+			return md.SMDHeaderSize
+		}
+	}
+	return 0
+}
+
+// payloadReader returns a reader of the canonical payload for formats that
+// rearrange it rather than just prefixing a header, or nil for the rest. SMD
+// files interleave the native Mega Drive ROM, which is what DATs hash.
+func payloadReader(game core.GameInfo, r io.ReaderAt, size int64) (io.ReaderAt, int64) {
+	if info, ok := game.(*md.Info); ok && info.SourceFormat == md.FormatSMD {
+		if reader, err := md.NewSMDReader(r, size); err == nil {
+			return reader, reader.Size()
+		}
+	}
+	return nil, 0
+}
+
+// openFunc opens a companion file in the same directory as the file being
+// identified. Returns the reader and the file size.
+type openFunc func(name string) (util.RandomAccessReader, int64, error)
+
+// companionFunc is like IdentifyFunc, for control files (e.g., CloneCD .ccd)
+// whose content lives in companion files opened with open.
+type companionFunc func(r io.ReaderAt, size int64, name string, open openFunc) (core.GameInfo, core.Hashes, error)
+
+// Magic is a byte signature at a fixed offset in a file.
+type Magic struct {
+	Offset int64
+	Bytes  []byte
+}
+
+// Format describes a file format recognized by Identify.
+type Format struct {
+	// Name describes the format, e.g. "Game Boy ROM".
+	Name string
+
+	// Extensions are the format's file extensions, in lower case with the
+	// leading dot.
+	Extensions []string
+
+	// Magic lists signatures of the format. If it's set, Identify is only
+	// tried on files matching one of them, and Options.Sniff can identify
+	// files in the format whatever their extension.
+	Magic []Magic
+
+	// Identify identifies content in the format.
+	Identify IdentifyFunc
+
+	// FastIdentify, if set, identifies content in the format without the
+	// checks of Identify that read all of it, like whole-ROM checksums. It's
+	// used instead of Identify with Options.Fast.
+	FastIdentify IdentifyFunc
+}
+
+// matches reports whether r, of the given size, has one of the format's
+// magic signatures, or true if it has none.
+func (f *Format) matches(r io.ReaderAt, size int64) bool {
+	if len(f.Magic) == 0 {
+		return true
+	}
+	for _, m := range f.Magic {
+		if m.Offset+int64(len(m.Bytes)) > size {
+			continue
+		}
+		buf := make([]byte, len(m.Bytes))
+		if _, err := r.ReadAt(buf, m.Offset); err == nil && bytes.Equal(buf, m.Bytes) {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	formatsMu sync.RWMutex
+	formats   []Format
+)
+
+// RegisterFormat adds a format to the ones Identify recognizes, so formats
+// outside this module can be identified too. Files are identified by the
+// formats with their extension, in the order they were registered (the
+// built-in formats first), until one succeeds. RegisterFormat is usually
+// called from an init function.
+func RegisterFormat(f Format) {
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+	formats = append(formats, f)
+}
+
+// Formats returns the registered formats, in the order they're tried.
+func Formats() []Format {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+	return slices.Clone(formats)
+}
+
+// builtinFormats are the formats registered by this package. Where formats
+// share an extension, the ones with stricter checks come first.
+var builtinFormats = []Format{
+	{Name: "Game Boy Advance ROM", Extensions: []string{".gba"}, Identify: WrapParser(gba.Parse)},
+	{Name: "Game Boy ROM", Extensions: []string{".gb", ".gbc"}, Identify: WrapParser(gb.Parse), FastIdentify: WrapParser(gb.ParseHeader)},
+	{Name: "Nintendo DS ROM", Extensions: []string{".nds", ".dsi", ".ids"}, Identify: WrapParser(nds.Parse)},
+	{Name: "Nintendo 3DS ROM", Extensions: []string{".3ds", ".cci"}, Magic: []Magic{{0x100, []byte("NCSD")}}, Identify: WrapParser(n3ds.Parse)},
+	{Name: "Nintendo 3DS CIA", Extensions: []string{".cia"}, Magic: []Magic{{0, []byte{0x20, 0x20, 0x00, 0x00}}}, Identify: WrapParser(n3ds.ParseCIA)},
+	{Name: "NES ROM", Extensions: []string{".nes"}, Magic: []Magic{{0, []byte("NES\x1a")}}, Identify: WrapParser(nes.Parse)},
+	{Name: "Famicom Disk System image", Extensions: []string{".fds"}, Magic: []Magic{{0, []byte("FDS\x1a")}, {0, []byte("\x01*NINTENDO-HVC*")}}, Identify: WrapParser(fds.Parse)},
+	{Name: "SNES ROM", Extensions: []string{".sfc", ".smc"}, Identify: WrapParser(sfc.Parse)},
+	{Name: "Nintendo 64 ROM", Extensions: []string{".z64", ".v64", ".n64"}, Identify: WrapParser(n64.Parse)},
+	{Name: "Master System/Game Gear ROM", Extensions: []string{".sms", ".gg"}, Magic: []Magic{{0x1ff0, []byte("TMR SEGA")}, {0x3ff0, []byte("TMR SEGA")}, {0x7ff0, []byte("TMR SEGA")}}, Identify: WrapParser(sms.Parse)},
+	{Name: "PC Engine HuCard", Extensions: []string{".pce"}, Identify: WrapParser(pce.Parse)},
+	{Name: "Atari Lynx ROM", Extensions: []string{".lnx"}, Magic: []Magic{{0, []byte("LYNX")}}, Identify: WrapParser(lynx.Parse)},
+	{Name: "Atari 7800 ROM", Extensions: []string{".a78"}, Magic: []Magic{{1, []byte("ATARI7800")}}, Identify: WrapParser(a7800.Parse)},
+	{Name: "Atari 2600 ROM", Extensions: []string{".a26"}, Identify: WrapParser(a2600.Parse)},
+	{Name: "Atari Jaguar ROM", Extensions: []string{".j64", ".rom"}, Identify: WrapParser(jaguar.Parse)},
+	{Name: "MSX ROM", Extensions: []string{".rom", ".mx1"}, Identify: WrapParser(msx.Parse)},
+	{Name: "MSX2 ROM", Extensions: []string{".mx2"}, Identify: WrapParser(msx.ParseMSX2)},
+	{Name: "Commodore 64 cartridge", Extensions: []string{".crt"}, Magic: []Magic{{0, []byte("C64 CARTRIDGE   ")}}, Identify: WrapParser(c64.ParseCRT)},
+	{Name: "Commodore 64 disk image", Extensions: []string{".d64"}, Identify: WrapParser(c64.ParseD64)},
+	{Name: "Commodore 64 tape image", Extensions: []string{".t64"}, Magic: []Magic{{0, []byte("C64")}}, Identify: WrapParser(c64.ParseT64)},
+	{Name: "Amiga disk image", Extensions: []string{".adf"}, Magic: []Magic{{0, []byte("DOS")}}, Identify: WrapParser(amiga.ParseADF)},
+	{Name: "Amiga DMS disk image", Extensions: []string{".dms"}, Magic: []Magic{{0, []byte("DMS!")}}, Identify: WrapParser(amiga.ParseDMS)},
+	{Name: "ZX Spectrum TAP", Extensions: []string{".tap"}, Identify: WrapParser(zxspectrum.ParseTAP)},
+	{Name: "ZX Spectrum TZX", Extensions: []string{".tzx"}, Magic: []Magic{{0, []byte("ZXTape!\x1a")}}, Identify: WrapParser(zxspectrum.ParseTZX)},
+	{Name: "ZX Spectrum snapshot", Extensions: []string{".z80"}, Identify: WrapParser(zxspectrum.ParseZ80)},
+	{Name: "Xbox executable", Extensions: []string{".xbe"}, Magic: []Magic{{0, []byte("XBEH")}}, Identify: WrapParser(xbe.Parse)},
+	{Name: "PlayStation package", Extensions: []string{".pkg"}, Magic: []Magic{{0, []byte("\x7fPKG")}}, Identify: WrapParser(pkg.Parse)},
+	{Name: "PSP PBP", Extensions: []string{".pbp"}, Magic: []Magic{{0, []byte("\x00PBP")}}, Identify: WrapParser(pbp.Parse)},
+	{Name: "CHD", Extensions: []string{".chd"}, Magic: []Magic{{0, []byte("MComprHD")}}, Identify: identifyCHD},
+	{Name: "GameCube/Wii CISO", Extensions: []string{".ciso"}, Magic: []Magic{{0, []byte("CISO")}}, Identify: WrapParser(ciso.Parse)},
+	{Name: "CSO/ZSO", Extensions: []string{".cso", ".zso", ".ciso"}, Magic: []Magic{{0, []byte("CISO")}, {0, []byte("ZISO")}}, Identify: identifyCSO},
+	{Name: "RVZ/WIA", Extensions: []string{".rvz", ".wia"}, Magic: []Magic{{0, []byte("RVZ\x01")}, {0, []byte("WIA\x01")}}, Identify: WrapParser(rvz.Parse)},
+	{Name: "WBFS", Extensions: []string{".wbfs"}, Magic: []Magic{{0, []byte("WBFS")}}, Identify: WrapParser(wbfs.Parse)},
+	{Name: "GCZ", Extensions: []string{".gcz"}, Magic: []Magic{{0, []byte{0x01, 0xc0, 0x0b, 0xb1}}}, Identify: identifyGCZ},
+	{Name: "Xbox ISO", Extensions: []string{".xiso", ".iso"}, Magic: []Magic{{0x10000, []byte("MICROSOFT*XBOX*MEDIA")}}, Identify: WrapParser(xiso.Parse)},
+	{Name: "NKit", Extensions: []string{".iso"}, Magic: []Magic{{0x200, []byte("NKIT")}}, Identify: identifyNKit},
+	{Name: "GameCube/Wii disc image", Extensions: []string{".gcm", ".iso"}, Magic: []Magic{{0x18, []byte{0x5d, 0x1c, 0x9e, 0xa3}}, {0x1c, []byte{0xc2, 0x33, 0x9f, 0x3d}}}, Identify: WrapParser(gcm.Parse)},
+	{Name: "ISO 9660 image", Extensions: []string{".iso", ".bin", ".img"}, Magic: []Magic{{0x8001, []byte("CD001")}, {0x9311, []byte("CD001")}, {0x9319, []byte("CD001")}}, Identify: identifyISO9660},
+	{Name: "UDF image", Extensions: []string{".iso"}, Identify: identifyUDF},
+	{Name: "PC Engine CD data track", Extensions: []string{".bin"}, Identify: WrapParser(pce.ParseCD)},
+	{Name: "3DO disc image", Extensions: []string{".iso", ".bin"}, Magic: []Magic{{0, []byte("\x01ZZZZZ\x01")}, {0x10, []byte("\x01ZZZZZ\x01")}}, Identify: WrapParser(threedo.Parse)},
+	{Name: "Mega Drive ROM", Extensions: []string{".md", ".gen", ".32x", ".smd", ".bin"}, Identify: WrapParser(md.Parse)},
+	{Name: "Nero image", Extensions: []string{".nrg"}, Identify: identifyNRG},
+}
+
+func init() {
+	for _, f := range builtinFormats {
+		RegisterFormat(f)
+	}
+}
+
+// companionRegistry maps control file extensions to identifiers that read
+// the companion files they describe.
+var companionRegistry = map[string]companionFunc{
+	".ccd": identifyCCD,
+	".cue": identifyCUE,
+	".gdi": identifyGDI,
+}
+
+// archiveFunc opens the archive name of the given size in r as a container,
+// whose entries stop reading once ctx is done, and are decrypted with
+// password.
+type archiveFunc func(ctx context.Context, name string, r io.ReaderAt, size int64, password string) (util.FileContainer, error)
+
+// archiveRegistry maps archive extensions to the containers that open them.
+// Compressed files are containers of the file they hold, or of the files of
+// the tar archive they hold.
+var archiveRegistry = map[string]archiveFunc{
+	".zip": func(ctx context.Context, name string, r io.ReaderAt, size int64, password string) (util.FileContainer, error) {
+		archive, err := zip.NewArchive(ctx, r, size)
+		if err != nil {
+			return nil, err
+		}
+		archive.SetPassword(password)
+		return archive, nil
+	},
+	".rar": func(ctx context.Context, name string, r io.ReaderAt, size int64, password string) (util.FileContainer, error) {
+		archive, err := rar.NewArchive(ctx, r, size, password)
+		if err != nil {
+			return nil, err
+		}
+		return archive, nil
+	},
+	".tar":  openTar(""),
+	".tgz":  openTar(".gz"),
+	".txz":  openTar(".xz"),
+	".tzst": openTar(".zst"),
+	".gz":   openCompressed,
+	".xz":   openCompressed,
+	".zst":  openCompressed,
+}
+
+// openTar returns the archiveFunc of tar archives compressed with the format
+// of the extension compression, or uncompressed if it's "".
+func openTar(compression string) archiveFunc {
+	return func(ctx context.Context, name string, r io.ReaderAt, size int64, password string) (util.FileContainer, error) {
+		archive, err := tar.NewArchive(ctx, r, size, compression)
+		if err != nil {
+			return nil, err
+		}
+		return archive, nil
+	}
+}
+
+// openCompressed opens a compressed file as a tar archive if it's named like
+// one (e.g., game.tar.gz), or else as the one file it holds.
+func openCompressed(ctx context.Context, name string, r io.ReaderAt, size int64, password string) (util.FileContainer, error) {
+	ext := filepath.Ext(name)
+	if strings.EqualFold(filepath.Ext(strings.TrimSuffix(name, ext)), ".tar") {
+		return openTar(ext)(ctx, name, r, size, password)
+	}
+	f, err := compressed.NewFile(ctx, name, r, size)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// pathRegistry maps well-known paths within disc folders to formats, for
+// platforms whose folder-form backups have no single identifiable file
+// extension. Paths are upper case with forward slashes, and match the end of
+// an entry's path.
+var pathRegistry = map[string][]Format{
+	"PS3_GAME/PARAM.SFO": {{Name: "PS3 disc folder", Identify: WrapParser(sfo.Parse)}},
+}
+
+// identifyByExtension returns the formats to try for a given filename, in
+// order. Well-known disc folder paths take precedence over the extension.
+func identifyByExtension(filename string) []Format {
+	path := strings.ToUpper(filepath.ToSlash(filename))
+	for suffix, parsers := range pathRegistry {
+		if path == suffix || strings.HasSuffix(path, "/"+suffix) {
+			return parsers
+		}
+	}
+
+	ext := strings.ToLower(filepath.Ext(filename))
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+	var matched []Format
+	for _, f := range formats {
+		if slices.Contains(f.Extensions, ext) {
+			matched = append(matched, f)
+		}
+	}
+	return matched
+}
+
+// sniffFormats returns the formats with magic signatures that aren't
+// registered for filename's extension, for identifying files their extension
+// doesn't. Formats whose signatures are nearest the start of a file come
+// first, since they're cheapest to check.
+func sniffFormats(filename string) []Format {
+	ext := strings.ToLower(filepath.Ext(filename))
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+	var matched []Format
+	for _, f := range formats {
+		if len(f.Magic) > 0 && !slices.Contains(f.Extensions, ext) {
+			matched = append(matched, f)
+		}
+	}
+	slices.SortStableFunc(matched, func(a, b Format) int {
+		return cmp.Compare(a.minMagicOffset(), b.minMagicOffset())
+	})
+	return matched
+}
+
+// minMagicOffset returns the lowest offset of the format's signatures.
+func (f *Format) minMagicOffset() int64 {
+	offset := f.Magic[0].Offset
+	for _, m := range f.Magic[1:] {
+		offset = min(offset, m.Offset)
+	}
+	return offset
+}
+
+// archiveByExtension returns the archive opener for a given filename, if
+// it's an archive.
+func archiveByExtension(filename string) (archiveFunc, bool) {
+	ext := strings.ToLower(filepath.Ext(filename))
+	fn, ok := archiveRegistry[ext]
+	return fn, ok
+}
+
+// identifyCompanionByExtension returns the companion file identifier for a
+// given filename, if it's a control file.
+func identifyCompanionByExtension(filename string) (companionFunc, bool) {
 	ext := strings.ToLower(filepath.Ext(filename))
-	return registry[ext]
+	fn, ok := companionRegistry[ext]
+	return fn, ok
 }
@@ -0,0 +1,91 @@
+package identify
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sargunv/rom-tools/lib/core"
+	"github.com/sargunv/rom-tools/lib/roms/nintendo/gb"
+)
+
+// testInfo is the game info of the formats registered by tests.
+type testInfo struct {
+	Title string `json:"title"`
+}
+
+func (i *testInfo) GamePlatform() core.Platform { return "test" }
+func (i *testInfo) GameTitle() string           { return i.Title }
+func (i *testInfo) GameSerial() string          { return "" }
+func (i *testInfo) GameRegions() []core.Region  { return nil }
+
+func TestRegisterFormat(t *testing.T) {
+	parse := func(title string) func(io.ReaderAt, int64) (*testInfo, error) {
+		return func(io.ReaderAt, int64) (*testInfo, error) {
+			return &testInfo{Title: title}, nil
+		}
+	}
+	RegisterFormat(Format{
+		Name:       "Magic test format",
+		Extensions: []string{".rtfmt"},
+		Magic:      []Magic{{Offset: 2, Bytes: []byte("RT")}},
+		Identify:   WrapParser(parse("magic")),
+	})
+	RegisterFormat(Format{
+		Name:       "Fallback test format",
+		Extensions: []string{".rtfmt"},
+		Identify:   WrapParser(parse("fallback")),
+	})
+
+	dir := t.TempDir()
+	tests := []struct {
+		data string
+		want string
+	}{
+		{"..RT..", "magic"},
+		{"......", "fallback"},
+		{"RT", "fallback"}, // too short for the magic
+	}
+	for _, tt := range tests {
+		path := filepath.Join(dir, "game.rtfmt")
+		if err := os.WriteFile(path, []byte(tt.data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		result, err := Identify(path, DefaultOptions())
+		if err != nil {
+			t.Fatalf("Identify() error = %v", err)
+		}
+		if game := result.Items[0].Game; game == nil || game.GameTitle() != tt.want {
+			t.Errorf("Identify(%q) = %v, want the %s format", tt.data, game, tt.want)
+		}
+	}
+
+	found := false
+	for _, f := range Formats() {
+		found = found || f.Name == "Fallback test format"
+	}
+	if !found {
+		t.Error("Formats() doesn't list the registered format")
+	}
+}
+
+func TestIdentifyFast(t *testing.T) {
+	opts := DefaultOptions()
+	result, err := Identify("testdata/gbtictac.gb", opts)
+	if err != nil {
+		t.Fatalf("Identify() error = %v", err)
+	}
+	if info, ok := result.Items[0].Game.(*gb.Info); !ok || info.GlobalChecksumValid == nil || !*info.GlobalChecksumValid {
+		t.Errorf("Identify() game = %+v, want a checked global checksum", result.Items[0].Game)
+	}
+
+	opts.Fast = true
+	result, err = Identify("testdata/gbtictac.gb", opts)
+	if err != nil {
+		t.Fatalf("Identify() error = %v", err)
+	}
+	if info, ok := result.Items[0].Game.(*gb.Info); !ok || info.GlobalChecksumValid != nil {
+		t.Errorf("Identify() with Fast game = %+v, want an unchecked global checksum", result.Items[0].Game)
+	}
+}
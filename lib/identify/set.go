@@ -0,0 +1,175 @@
+package identify
+
+import (
+	"cmp"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/sargunv/rom-tools/lib/core"
+	"github.com/sargunv/rom-tools/lib/datfile"
+)
+
+// SetStatus describes how complete an archive is as a ROM set.
+type SetStatus string
+
+const (
+	SetStatusComplete SetStatus = "complete" // every ROM the set needs is present
+	SetStatusPartial  SetStatus = "partial"  // some ROMs the set needs are missing
+)
+
+// SetMatch is the DAT game (usually a MAME machine) an archive or folder was
+// matched against as a whole.
+//
+// Sets are checked in split form: ROMs the DAT marks as merged from the
+// parent or BIOS set don't need to be present, but count if they are.
+type SetMatch struct {
+	Status      SetStatus `json:"status"`
+	Set         string    `json:"set"`                   // game (or machine) name from the DAT
+	Description string    `json:"description,omitempty"` // full title from the DAT
+	CloneOf     string    `json:"clone_of,omitempty"`    // parent set, for clones
+	RomOf       string    `json:"rom_of,omitempty"`      // set merged ROMs come from (the parent or a BIOS)
+	Have        int       `json:"have"`                  // ROMs the set needs that are present
+	Total       int       `json:"total"`                 // ROMs the set needs
+	Missing     []string  `json:"missing,omitempty"`     // names of the ROMs that aren't present
+	Source      string    `json:"source,omitempty"`      // name of the DAT the set came from
+}
+
+// datSet is a game from a loaded DAT, as a set of ROMs.
+type datSet struct {
+	name        string
+	description string
+	cloneOf     string
+	romOf       string
+	roms        []setROM
+	source      string
+}
+
+// setROM is a ROM of a datSet.
+type setROM struct {
+	name   string
+	size   int64
+	crc    string
+	merged bool // stored in the romOf set when sets are split
+}
+
+func (r setROM) key() string {
+	return romKey(r.crc, r.size)
+}
+
+// addSet indexes game as a set. Only ROMs with CRCs are included, since that's
+// what archives record; nodump and optional ROMs are left out.
+func (x *DATIndex) addSet(source string, game *datfile.Game) {
+	set := &datSet{
+		name:        game.Name,
+		description: game.Description,
+		cloneOf:     game.CloneOf,
+		romOf:       game.RomOf,
+		source:      source,
+	}
+	for _, rom := range game.ROMs {
+		crc := normalizeHash(rom.CRC)
+		if crc == "" || rom.Status == datfile.DumpStatusNoDump || rom.Optional {
+			continue
+		}
+		set.roms = append(set.roms, setROM{name: rom.Name, size: rom.Size, crc: crc, merged: rom.Merge != ""})
+		x.setsByCRC[crc] = append(x.setsByCRC[crc], set)
+	}
+	if len(set.roms) == 0 {
+		return
+	}
+	if _, ok := x.sets[set.name]; !ok {
+		x.sets[set.name] = set
+	}
+}
+
+// MatchSet matches the items of a container result against the indexed
+// sets. The set named after the archive or folder is used if there is one;
+// otherwise, the set sharing the most ROMs with the items. Returns nil for
+// single files and containers that share no ROMs with any set.
+func (m *Matcher) MatchSet(result *Result) *SetMatch {
+	if len(result.Items) == 1 && result.Items[0].Name == filepath.Base(result.Path) {
+		return nil
+	}
+
+	have := make(map[string]bool)
+	for _, item := range result.Items {
+		for _, ht := range []core.HashType{core.HashZipCRC32, core.HashCRC32} {
+			if crc := normalizeHash(item.Hashes[ht]); crc != "" {
+				have[romKey(crc, item.Size)] = true
+			}
+		}
+	}
+
+	name := strings.TrimSuffix(filepath.Base(result.Path), filepath.Ext(result.Path))
+	if set, ok := m.index.sets[name]; ok {
+		return set.match(have)
+	}
+
+	var best *SetMatch
+	seen := make(map[*datSet]bool)
+	for _, item := range result.Items {
+		for _, ht := range []core.HashType{core.HashZipCRC32, core.HashCRC32} {
+			for _, set := range m.index.setsByCRC[normalizeHash(item.Hashes[ht])] {
+				if seen[set] {
+					continue
+				}
+				seen[set] = true
+				if match := set.match(have); match.Have > 0 && betterSetMatch(match, best) {
+					best = match
+				}
+			}
+		}
+	}
+	return best
+}
+
+// match checks which of the set's ROMs are present in have.
+func (s *datSet) match(have map[string]bool) *SetMatch {
+	match := &SetMatch{
+		Set:         s.name,
+		Description: s.description,
+		CloneOf:     s.cloneOf,
+		RomOf:       s.romOf,
+		Source:      s.source,
+	}
+	for _, rom := range s.roms {
+		if rom.merged {
+			continue
+		}
+		match.Total++
+		if have[rom.key()] {
+			match.Have++
+		} else {
+			match.Missing = append(match.Missing, rom.name)
+		}
+	}
+	match.Status = SetStatusPartial
+	if match.Have == match.Total {
+		match.Status = SetStatusComplete
+	}
+	return match
+}
+
+// betterSetMatch reports whether a is a better match than b: more ROMs
+// present, then fewer missing, then the parent over its clones.
+func betterSetMatch(a, b *SetMatch) bool {
+	if b == nil {
+		return true
+	}
+	if c := cmp.Compare(a.Have, b.Have); c != 0 {
+		return c > 0
+	}
+	if c := cmp.Compare(len(a.Missing), len(b.Missing)); c != 0 {
+		return c < 0
+	}
+	if (a.CloneOf == "") != (b.CloneOf == "") {
+		return a.CloneOf == ""
+	}
+	return a.Set < b.Set
+}
+
+// romKey identifies a ROM by CRC32 and size.
+func romKey(crc string, size int64) string {
+	return crc + ":" + strconv.FormatInt(size, 10)
+}
@@ -0,0 +1,145 @@
+package identify
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/sargunv/rom-tools/lib/core"
+)
+
+const testMAMESets = `
+	<game name="puckman">
+		<description>Puck Man (Japan set 1)</description>
+		<rom name="pm1_prg1.6e" size="2048" crc="f36e88ab"/>
+		<rom name="pm1_prg2.6k" size="2048" crc="618bd9b3"/>
+		<rom name="82s123.7f" size="32" crc="2fc650bd"/>
+		<rom name="82s126.1m" size="256" crc="a9cc86bf" status="nodump"/>
+	</game>
+	<game name="pacman" cloneof="puckman" romof="puckman">
+		<description>Pac-Man (Midway)</description>
+		<rom name="pacman.6e" size="4096" crc="c1e6ab10"/>
+		<rom name="pacman.6f" size="4096" crc="1a6fb2d4"/>
+		<rom name="82s123.7f" merge="82s123.7f" size="32" crc="2fc650bd"/>
+	</game>`
+
+// zipItem is an item from a ZIP, with only the metadata CRC32.
+func zipItem(name string, size int64, crc string) Item {
+	return Item{Name: name, Size: size, Hashes: core.Hashes{core.HashZipCRC32: crc}}
+}
+
+func TestMatcher_MatchSet(t *testing.T) {
+	index := NewDATIndex()
+	index.Add("", loadTestDAT(t, testMAMESets))
+	matcher := NewMatcher(index)
+
+	tests := []struct {
+		name        string
+		result      Result
+		wantSet     string
+		wantStatus  SetStatus
+		wantHave    int
+		wantTotal   int
+		wantMissing []string
+	}{
+		{
+			name: "complete parent",
+			result: Result{Path: "/roms/puckman.zip", Items: []Item{
+				zipItem("pm1_prg1.6e", 2048, "f36e88ab"),
+				zipItem("pm1_prg2.6k", 2048, "618bd9b3"),
+				zipItem("82s123.7f", 32, "2fc650bd"),
+			}},
+			wantSet: "puckman", wantStatus: SetStatusComplete, wantHave: 3, wantTotal: 3,
+		},
+		{
+			name: "split clone without merged ROMs",
+			result: Result{Path: "/roms/pacman.zip", Items: []Item{
+				zipItem("pacman.6e", 4096, "c1e6ab10"),
+				zipItem("pacman.6f", 4096, "1a6fb2d4"),
+			}},
+			wantSet: "pacman", wantStatus: SetStatusComplete, wantHave: 2, wantTotal: 2,
+		},
+		{
+			name: "partial by name",
+			result: Result{Path: "/roms/pacman.zip", Items: []Item{
+				zipItem("pacman.6e", 4096, "c1e6ab10"),
+				zipItem("pacman.6f", 4096, "00000000"),
+			}},
+			wantSet: "pacman", wantStatus: SetStatusPartial, wantHave: 1, wantTotal: 2,
+			wantMissing: []string{"pacman.6f"},
+		},
+		{
+			name: "renamed archive by contents",
+			result: Result{Path: "/roms/Pac-Man.zip", Items: []Item{
+				zipItem("a.bin", 4096, "c1e6ab10"),
+				zipItem("b.bin", 4096, "1a6fb2d4"),
+				zipItem("c.bin", 32, "2fc650bd"),
+			}},
+			wantSet: "pacman", wantStatus: SetStatusComplete, wantHave: 2, wantTotal: 2,
+		},
+		{
+			name: "shared ROM prefers parent",
+			result: Result{Path: "/roms/proms", Items: []Item{
+				zipItem("82s123.7f", 32, "2fc650bd"),
+			}},
+			wantSet: "puckman", wantStatus: SetStatusPartial, wantHave: 1, wantTotal: 3,
+			wantMissing: []string{"pm1_prg1.6e", "pm1_prg2.6k"},
+		},
+		{
+			name: "crc size mismatch",
+			result: Result{Path: "/roms/other.zip", Items: []Item{
+				zipItem("pacman.6e", 2048, "c1e6ab10"),
+			}},
+		},
+		{
+			name: "single file",
+			result: Result{Path: "/roms/pacman.6e", Items: []Item{
+				{Name: "pacman.6e", Size: 4096, Hashes: core.Hashes{core.HashCRC32: "c1e6ab10"}},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set := matcher.MatchSet(&tt.result)
+			if tt.wantSet == "" {
+				if set != nil {
+					t.Errorf("Expected no set, got %+v", set)
+				}
+				return
+			}
+			if set == nil {
+				t.Fatal("Expected set, got nil")
+			}
+			if set.Set != tt.wantSet || set.Status != tt.wantStatus {
+				t.Errorf("Expected %s (%s), got %s (%s)", tt.wantSet, tt.wantStatus, set.Set, set.Status)
+			}
+			if set.Have != tt.wantHave || set.Total != tt.wantTotal {
+				t.Errorf("Expected %d/%d ROMs, got %d/%d", tt.wantHave, tt.wantTotal, set.Have, set.Total)
+			}
+			if !slices.Equal(set.Missing, tt.wantMissing) {
+				t.Errorf("Expected missing %v, got %v", tt.wantMissing, set.Missing)
+			}
+			if set.Source != "Test DAT" {
+				t.Errorf("Expected source 'Test DAT', got '%s'", set.Source)
+			}
+		})
+	}
+}
+
+func TestMatcher_MatchSet_ParentClone(t *testing.T) {
+	index := NewDATIndex()
+	index.Add("", loadTestDAT(t, testMAMESets))
+
+	set := NewMatcher(index).MatchSet(&Result{Path: "/roms/pacman.zip", Items: []Item{
+		zipItem("pacman.6e", 4096, "c1e6ab10"),
+	}})
+	if set == nil {
+		t.Fatal("Expected set, got nil")
+	}
+	if set.CloneOf != "puckman" || set.RomOf != "puckman" {
+		t.Errorf("Expected clone of puckman, got CloneOf %q, RomOf %q", set.CloneOf, set.RomOf)
+	}
+	if set.Description != "Pac-Man (Midway)" {
+		t.Errorf("Expected description 'Pac-Man (Midway)', got '%s'", set.Description)
+	}
+}
@@ -0,0 +1,282 @@
+package identify
+
+import (
+	"cmp"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/sargunv/rom-tools/internal/container/folder"
+	"github.com/sargunv/rom-tools/internal/container/zip"
+	"github.com/sargunv/rom-tools/internal/util"
+	"github.com/sargunv/rom-tools/lib/core"
+	"github.com/sargunv/rom-tools/lib/datfile"
+)
+
+// SetMode is how a collection of ROM sets stores the ROMs clones share with
+// their parents.
+type SetMode string
+
+const (
+	SetModeSplit     SetMode = "split"      // each set holds only its own ROMs; clones need their parent
+	SetModeMerged    SetMode = "merged"     // clones are stored inside their parent's set
+	SetModeNonMerged SetMode = "non-merged" // each set holds every ROM it needs, apart from BIOS ROMs
+)
+
+// setModes lists the modes in order of preference when a collection fits
+// several equally well (e.g. when it has no clones).
+var setModes = []SetMode{SetModeSplit, SetModeMerged, SetModeNonMerged}
+
+// SetAnalysis is the result of checking a collection of ROM sets against a
+// DAT in each set mode.
+type SetAnalysis struct {
+	Mode    SetMode          `json:"mode"`    // mode the collection fits best
+	Reports []*SetModeReport `json:"reports"` // one per mode, in the order split, merged, non-merged
+}
+
+// Report returns the report for mode.
+func (a *SetAnalysis) Report(mode SetMode) *SetModeReport {
+	for _, r := range a.Reports {
+		if r.Mode == mode {
+			return r
+		}
+	}
+	return nil
+}
+
+// SetModeReport is what a collection has and lacks if it's meant to be in
+// a given set mode.
+type SetModeReport struct {
+	Mode        SetMode     `json:"mode"`
+	Complete    []string    `json:"complete"`               // sets with every ROM they need
+	Incomplete  []SetReport `json:"incomplete,omitempty"`   // sets with missing or extra ROMs
+	MissingSets []string    `json:"missing_sets,omitempty"` // sets the mode needs that aren't present
+	Unneeded    []string    `json:"unneeded,omitempty"`     // sets present that the mode doesn't use
+}
+
+// SetReport lists the differences between a set in a collection and what
+// the DAT expects of it.
+type SetReport struct {
+	Set     string   `json:"set"`
+	Missing []string `json:"missing,omitempty"` // names of ROMs not present
+	Extra   []string `json:"extra,omitempty"`   // names of files the set shouldn't hold
+}
+
+// score counts the ROMs out of place in the collection: missing from sets
+// that are present, extra in them, or in sets the mode doesn't use. Sets
+// missing entirely don't count, since collections are often partial.
+func (r *SetModeReport) score(sets map[string][]setFile) int {
+	n := 0
+	for _, s := range r.Incomplete {
+		n += len(s.Missing) + len(s.Extra)
+	}
+	for _, name := range r.Unneeded {
+		n += len(sets[name])
+	}
+	return n
+}
+
+// setFile is a file in a set of a collection.
+type setFile struct {
+	name string
+	key  string // see romKey
+}
+
+// AnalyzeSets checks a directory of ROM sets (ZIP archives or folders named
+// after the DAT's games) against dat in each set mode, and reports the mode
+// it fits best along with what it lacks in every mode.
+//
+// ROMs are matched by CRC32 and size, and ROMs the DAT marks as nodump or
+// optional are never required. BIOS ROMs belong in the BIOS set in every
+// mode.
+func AnalyzeSets(dir string, dat *datfile.Datafile) (*SetAnalysis, error) {
+	sets, err := readSets(dir)
+	if err != nil {
+		return nil, err
+	}
+	return analyzeSets(sets, dat), nil
+}
+
+// readSets reads the file keys of every set in dir.
+func readSets(dir string) (map[string][]setFile, error) {
+	dirEntries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read set directory: %w", err)
+	}
+
+	sets := make(map[string][]setFile)
+	for _, e := range dirEntries {
+		path := filepath.Join(dir, e.Name())
+		var c util.FileContainer
+		var name string
+		switch {
+		case e.IsDir():
+			name = e.Name()
+			c, err = folder.NewFolderContainer(path)
+		case strings.EqualFold(filepath.Ext(e.Name()), ".zip"):
+			name = strings.TrimSuffix(e.Name(), filepath.Ext(e.Name()))
+			c, err = zip.Open(path)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to open set %s: %w", e.Name(), err)
+		}
+		files, err := readSetFiles(c)
+		c.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read set %s: %w", e.Name(), err)
+		}
+		sets[name] = files
+	}
+	return sets, nil
+}
+
+// readSetFiles keys the files of a set, using container CRC32s when
+// available and calculating them otherwise.
+func readSetFiles(c util.FileContainer) ([]setFile, error) {
+	var files []setFile
+	for _, entry := range c.Entries() {
+		crc := entry.Hashes[core.HashZipCRC32]
+		if crc == "" {
+			r, err := c.OpenFile(entry.Name)
+			if err != nil {
+				return nil, err
+			}
+			h := crc32.NewIEEE()
+			_, err = io.Copy(h, r)
+			r.Close()
+			if err != nil {
+				return nil, fmt.Errorf("failed to hash %s: %w", entry.Name, err)
+			}
+			crc = fmt.Sprintf("%08x", h.Sum32())
+		}
+		files = append(files, setFile{name: entry.Name, key: romKey(normalizeHash(crc), entry.Size)})
+	}
+	return files, nil
+}
+
+// analyzeSets checks the sets against dat in each set mode.
+func analyzeSets(sets map[string][]setFile, dat *datfile.Datafile) *SetAnalysis {
+	games := make(map[string]*datfile.Game, len(dat.Games))
+	for i := range dat.Games {
+		if _, ok := games[dat.Games[i].Name]; !ok {
+			games[dat.Games[i].Name] = &dat.Games[i]
+		}
+	}
+
+	analysis := &SetAnalysis{}
+	bestScore := -1
+	for _, mode := range setModes {
+		report := compareSets(mode, expectedSets(mode, dat, games), sets)
+		analysis.Reports = append(analysis.Reports, report)
+		if score := report.score(sets); bestScore < 0 || score < bestScore {
+			analysis.Mode, bestScore = mode, score
+		}
+	}
+	return analysis
+}
+
+// expectedSets lists the ROMs each set holds in mode.
+func expectedSets(mode SetMode, dat *datfile.Datafile, games map[string]*datfile.Game) map[string][]setROM {
+	expected := make(map[string][]setROM)
+	seen := make(map[string]bool) // set name and ROM key, as merged sets share ROMs
+	for i := range dat.Games {
+		game := &dat.Games[i]
+		if games[game.Name] != game {
+			continue // duplicate name
+		}
+
+		target := game.Name
+		if parent, ok := games[game.CloneOf]; ok && mode == SetModeMerged {
+			target = parent.Name
+		}
+		for _, rom := range game.ROMs {
+			if rom.CRC == "" || rom.Status == datfile.DumpStatusNoDump || rom.Optional {
+				continue
+			}
+			if rom.Merge != "" {
+				// Merged ROMs only appear in non-merged sets, and not even
+				// there if they come from a BIOS
+				if mode != SetModeNonMerged || mergeOrigin(games, game, rom.Merge).IsBIOS {
+					continue
+				}
+			}
+			r := setROM{name: rom.Name, size: rom.Size, crc: normalizeHash(rom.CRC)}
+			if k := target + "/" + r.key(); !seen[k] {
+				seen[k] = true
+				expected[target] = append(expected[target], r)
+			}
+		}
+	}
+	return expected
+}
+
+// mergeOrigin follows the romof chain from game to the set that holds the
+// ROM named merge itself. If the chain breaks, the last set found is
+// returned.
+func mergeOrigin(games map[string]*datfile.Game, game *datfile.Game, merge string) *datfile.Game {
+	for range len(games) {
+		parent, ok := games[game.RomOf]
+		if !ok || parent == game {
+			return game
+		}
+		i := slices.IndexFunc(parent.ROMs, func(r datfile.ROM) bool { return r.Name == merge })
+		if i < 0 || parent.ROMs[i].Merge == "" {
+			return parent
+		}
+		game, merge = parent, parent.ROMs[i].Merge
+	}
+	return game
+}
+
+// compareSets compares the sets present with those expected in mode.
+func compareSets(mode SetMode, expected map[string][]setROM, sets map[string][]setFile) *SetModeReport {
+	report := &SetModeReport{Mode: mode, Complete: []string{}}
+	for name, roms := range expected {
+		files, ok := sets[name]
+		if !ok {
+			report.MissingSets = append(report.MissingSets, name)
+			continue
+		}
+
+		present := make(map[string]bool, len(files))
+		for _, f := range files {
+			present[f.key] = true
+		}
+		needed := make(map[string]bool, len(roms))
+		s := SetReport{Set: name}
+		for _, rom := range roms {
+			needed[rom.key()] = true
+			if !present[rom.key()] {
+				s.Missing = append(s.Missing, rom.name)
+			}
+		}
+		for _, f := range files {
+			if !needed[f.key] {
+				s.Extra = append(s.Extra, f.name)
+			}
+		}
+
+		if len(s.Missing) == 0 && len(s.Extra) == 0 {
+			report.Complete = append(report.Complete, name)
+		} else {
+			report.Incomplete = append(report.Incomplete, s)
+		}
+	}
+	for name := range sets {
+		if _, ok := expected[name]; !ok {
+			report.Unneeded = append(report.Unneeded, name)
+		}
+	}
+
+	slices.Sort(report.Complete)
+	slices.SortFunc(report.Incomplete, func(a, b SetReport) int { return cmp.Compare(a.Set, b.Set) })
+	slices.Sort(report.MissingSets)
+	slices.Sort(report.Unneeded)
+	return report
+}
@@ -0,0 +1,165 @@
+package identify
+
+import (
+	"archive/zip"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// testSetModeDAT has a BIOS, a parent using it, and a clone of the parent.
+const testSetModeDAT = `
+	<game name="neogeo" isbios="yes">
+		<rom name="sp-s2.sp1" size="4" crc="11111111"/>
+	</game>
+	<game name="parent" romof="neogeo">
+		<rom name="sp-s2.sp1" merge="sp-s2.sp1" size="4" crc="11111111"/>
+		<rom name="p1.bin" size="4" crc="22222222"/>
+		<rom name="p2.bin" size="4" crc="33333333"/>
+		<rom name="opt.bin" size="4" crc="99999999" optional="yes"/>
+	</game>
+	<game name="clone" cloneof="parent" romof="parent">
+		<rom name="sp-s2.sp1" merge="sp-s2.sp1" size="4" crc="11111111"/>
+		<rom name="c1.bin" size="4" crc="44444444"/>
+		<rom name="p2.bin" merge="p2.bin" size="4" crc="33333333"/>
+	</game>`
+
+func testSetFile(name, crc string) setFile {
+	return setFile{name: name, key: romKey(crc, 4)}
+}
+
+func TestAnalyzeSets_Modes(t *testing.T) {
+	dat := loadTestDAT(t, testSetModeDAT)
+	bios := []setFile{testSetFile("sp-s2.sp1", "11111111")}
+	p1, p2, c1 := testSetFile("p1.bin", "22222222"), testSetFile("p2.bin", "33333333"), testSetFile("c1.bin", "44444444")
+
+	tests := []struct {
+		name string
+		sets map[string][]setFile
+		want SetMode
+	}{
+		{"split", map[string][]setFile{"neogeo": bios, "parent": {p1, p2}, "clone": {c1}}, SetModeSplit},
+		{"merged", map[string][]setFile{"neogeo": bios, "parent": {p1, p2, c1}}, SetModeMerged},
+		{"non-merged", map[string][]setFile{"neogeo": bios, "parent": {p1, p2}, "clone": {c1, p2}}, SetModeNonMerged},
+		{"no clones", map[string][]setFile{"neogeo": bios, "parent": {p1, p2}}, SetModeSplit},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analysis := analyzeSets(tt.sets, dat)
+			if analysis.Mode != tt.want {
+				t.Errorf("Expected mode %s, got %s", tt.want, analysis.Mode)
+			}
+			report := analysis.Report(tt.want)
+			if len(report.Incomplete) != 0 || len(report.Unneeded) != 0 {
+				t.Errorf("Expected no problems in %s mode, got %+v", tt.want, report)
+			}
+		})
+	}
+}
+
+func TestAnalyzeSets_Missing(t *testing.T) {
+	dat := loadTestDAT(t, testSetModeDAT)
+	sets := map[string][]setFile{
+		"parent": {testSetFile("p1.bin", "22222222"), testSetFile("junk.txt", "deadbeef")},
+		"clone":  {testSetFile("c1.bin", "44444444")},
+		"other":  {testSetFile("x.bin", "55555555")},
+	}
+
+	analysis := analyzeSets(sets, dat)
+	if analysis.Mode != SetModeSplit {
+		t.Errorf("Expected mode %s, got %s", SetModeSplit, analysis.Mode)
+	}
+
+	split := analysis.Report(SetModeSplit)
+	if !slices.Equal(split.Complete, []string{"clone"}) {
+		t.Errorf("Expected complete [clone], got %v", split.Complete)
+	}
+	wantIncomplete := []SetReport{{Set: "parent", Missing: []string{"p2.bin"}, Extra: []string{"junk.txt"}}}
+	if len(split.Incomplete) != 1 || split.Incomplete[0].Set != "parent" ||
+		!slices.Equal(split.Incomplete[0].Missing, wantIncomplete[0].Missing) ||
+		!slices.Equal(split.Incomplete[0].Extra, wantIncomplete[0].Extra) {
+		t.Errorf("Expected incomplete %+v, got %+v", wantIncomplete, split.Incomplete)
+	}
+	if !slices.Equal(split.MissingSets, []string{"neogeo"}) {
+		t.Errorf("Expected missing sets [neogeo], got %v", split.MissingSets)
+	}
+	if !slices.Equal(split.Unneeded, []string{"other"}) {
+		t.Errorf("Expected unneeded [other], got %v", split.Unneeded)
+	}
+
+	nonMerged := analysis.Report(SetModeNonMerged)
+	for _, s := range nonMerged.Incomplete {
+		if s.Set == "clone" && !slices.Equal(s.Missing, []string{"p2.bin"}) {
+			t.Errorf("Expected clone missing [p2.bin] in non-merged mode, got %v", s.Missing)
+		}
+	}
+
+	merged := analysis.Report(SetModeMerged)
+	if !slices.Contains(merged.Unneeded, "clone") {
+		t.Errorf("Expected clone unneeded in merged mode, got %v", merged.Unneeded)
+	}
+}
+
+func TestAnalyzeSets_Directory(t *testing.T) {
+	dir := t.TempDir()
+	data := map[string][]byte{
+		"p1.bin": []byte("AAAA"),
+		"p2.bin": []byte("BBBB"),
+		"c1.bin": []byte("CCCC"),
+	}
+	crc := func(name string) string {
+		return fmt.Sprintf("%08x", crc32.ChecksumIEEE(data[name]))
+	}
+	dat := loadTestDAT(t, `
+	<game name="parent">
+		<rom name="p1.bin" size="4" crc="`+crc("p1.bin")+`"/>
+		<rom name="p2.bin" size="4" crc="`+crc("p2.bin")+`"/>
+	</game>
+	<game name="clone" cloneof="parent" romof="parent">
+		<rom name="c1.bin" size="4" crc="`+crc("c1.bin")+`"/>
+		<rom name="p2.bin" merge="p2.bin" size="4" crc="`+crc("p2.bin")+`"/>
+	</game>`)
+
+	// A zipped parent and an unzipped, non-merged clone
+	f, err := os.Create(filepath.Join(dir, "parent.zip"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	for _, name := range []string{"p1.bin", "p2.bin"} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(data[name])
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	if err := os.Mkdir(filepath.Join(dir, "clone"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"c1.bin", "p2.bin"} {
+		if err := os.WriteFile(filepath.Join(dir, "clone", name), data[name], 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "readme.txt"), []byte("ignored"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	analysis, err := AnalyzeSets(dir, dat)
+	if err != nil {
+		t.Fatalf("AnalyzeSets() error = %v", err)
+	}
+	if analysis.Mode != SetModeNonMerged {
+		t.Errorf("Expected mode %s, got %s", SetModeNonMerged, analysis.Mode)
+	}
+	if complete := analysis.Report(SetModeNonMerged).Complete; !slices.Equal(complete, []string{"clone", "parent"}) {
+		t.Errorf("Expected complete [clone parent], got %v", complete)
+	}
+}
@@ -1,20 +1,34 @@
 // Package identify provides ROM identification and hashing utilities.
+//
+// Files are identified by the formats registered for their extension, or with
+// Options.Sniff, by magic signatures. RegisterFormat adds formats beyond the
+// built-in ones.
 package identify
 
-import "github.com/sargunv/rom-tools/lib/core"
+import (
+	"context"
+	"time"
+
+	"github.com/sargunv/rom-tools/lib/core"
+)
 
 // Item represents one identifiable unit (a file or entry within a container).
 type Item struct {
-	Name   string        `json:"name"`             // filename (basename for single files, relative path in containers)
-	Size   int64         `json:"size"`             // file size in bytes
-	Hashes core.Hashes   `json:"hashes,omitempty"` // hash values by type
-	Game   core.GameInfo `json:"game,omitempty"`   // identified game info (platform-specific struct)
+	Name       string        `json:"name"`                  // filename (basename for single files, relative path in containers)
+	Size       int64         `json:"size"`                  // file size in bytes
+	HeaderSize int64         `json:"header_size,omitempty"` // size of the header excluded from headerless hashes
+	Hashes     core.Hashes   `json:"hashes,omitempty"`      // hash values by type
+	Game       core.GameInfo `json:"game,omitempty"`        // identified game info (platform-specific struct)
+	Primary    bool          `json:"primary,omitempty"`     // whether the item is a game, rather than an auxiliary file (e.g., a .sub, or a track of a .cue)
+	Match      *Match        `json:"match,omitempty"`       // DAT match, set by Matcher.Annotate
 }
 
 // Result is the result of identifying a path.
 type Result struct {
-	Path  string `json:"path"`  // absolute path that was identified
-	Items []Item `json:"items"` // identified items (1 for single file, N for containers)
+	Path   string    `json:"path"`             // absolute path that was identified
+	Items  []Item    `json:"items"`            // identified items (1 for single file, N for containers)
+	Groups []Group   `json:"groups,omitempty"` // multi-disc games among the primary items of containers
+	Set    *SetMatch `json:"set,omitempty"`    // DAT set match for containers, set by Matcher.Annotate
 }
 
 // Options controls ROM identification behavior.
@@ -27,11 +41,128 @@ type Options struct {
 	// Use -1 for no limit (always calculate when needed).
 	// Default is -1 (no limit).
 	MaxHashSize int64
+
+	// Concurrency is the number of container entries (e.g., files in a folder)
+	// identified in parallel. Items are always returned in container order.
+	// Use 1 to identify entries serially, or 0 for the default: the number of
+	// CPUs for folders, and serial for archives.
+	//
+	// Archive entries are decompressed into memory as they're read (except
+	// those of uncompressed tar archives), so each worker can hold a whole
+	// entry: identifying an archive of 700 MB disc images with 16 workers can
+	// take over 11 GB. Set this above 1 for archives only when their entries
+	// are small.
+	// Default is 0.
+	Concurrency int
+
+	// ExtraHashes are hash types calculated in addition to SHA1, MD5, and
+	// CRC32: core.HashSHA256, as listed by newer No-Intro DATs, and
+	// core.HashXXH64, a fast hash for local integrity checks. Each one adds
+	// to the cost of hashing, so none are calculated by default.
+	// Requesting any also calculates hashes of ZIP entries, which otherwise
+	// only have the CRC32 from the archive's metadata. Formats with embedded
+	// hashes, like CHD, are still not hashed.
+	ExtraHashes []core.HashType
+
+	// Progress, if set, is called as each item is hashed, with the item's
+	// name and the number of bytes read so far out of the total. All hashes
+	// of an item are calculated in a single pass over its data (two for
+	// formats whose headerless hashes cover a rearranged payload), and the
+	// last call for an item always has done equal to total. With Concurrency
+	// above 1, it's called from several goroutines at once.
+	// Default is nil.
+	Progress func(name string, done, total int64)
+
+	// HashCache, if set, stores calculated hashes of files on disk between
+	// runs, so unchanged files aren't hashed again. ZIP entries aren't
+	// cached. See package hashcache for an implementation.
+	// Default is nil.
+	HashCache HashCache
+
+	// HashCacheMode controls how HashCache is used.
+	// Default is HashCacheNormal.
+	HashCacheMode HashCacheMode
+
+	// FileTimeout, if positive, is the most time spent identifying and
+	// hashing each file or container entry. A file that takes longer fails
+	// with context.DeadlineExceeded, failing the whole container it's in.
+	// Default is 0 (no limit).
+	FileTimeout time.Duration
+
+	// MaxArchiveDepth is how many levels of archives nested in folders or
+	// other archives are opened, so a ZIP of per-game ZIPs is identified
+	// down to the games. Their entries are named by their path through the
+	// archives (e.g., "game.zip/game.gb"). Nested archives are read into
+	// memory as they're decompressed, like other ZIP entries. Use 0 to
+	// identify nested archives as plain files.
+	// Default is 2.
+	MaxArchiveDepth int
+
+	// Password decrypts the encrypted entries of ZIP archives, with
+	// traditional PKWARE or WinZip AES encryption, and of RAR archives,
+	// including those whose file list is encrypted too. Identifying an
+	// archive with encrypted entries fails with ErrPasswordRequired without
+	// it, or ErrWrongPassword if it's wrong (except for RAR 4 archives, which
+	// can't tell a wrong password from corrupt data).
+	// Default is "".
+	Password string
+
+	// Sniff identifies files that their extension doesn't, like generic .bin
+	// files or ones with no extension at all, by checking them for the magic
+	// signatures of every registered format. Formats without one, like Game
+	// Boy or Mega Drive ROMs, are still only identified by extension.
+	// Default is false.
+	Sniff bool
+
+	// Fast skips the checks of formats that read a whole file, like the
+	// Game Boy global checksum, leaving their results unset. Hashing still
+	// reads whole files unless MaxHashSize limits it.
+	// Default is false.
+	Fast bool
 }
 
+// HashCache stores the hashes of files, keyed by path, that stay valid while
+// their size and modification time are unchanged. It must be safe for
+// concurrent use.
+type HashCache interface {
+	Get(path string, size int64, modTime time.Time) (core.Hashes, bool)
+	Put(path string, size int64, modTime time.Time, hashes core.Hashes) error
+}
+
+// HashCacheMode determines how Options.HashCache is used.
+type HashCacheMode int
+
+const (
+	// HashCacheNormal uses cached hashes, and caches newly calculated ones.
+	HashCacheNormal HashCacheMode = iota
+	// HashCacheRefresh ignores cached hashes, but caches newly calculated
+	// ones, replacing what was cached.
+	HashCacheRefresh
+)
+
 // DefaultOptions returns Options with sensible defaults.
 func DefaultOptions() Options {
 	return Options{
-		MaxHashSize: -1, // no limit
+		MaxHashSize:     -1, // no limit
+		Concurrency:     0,  // number of CPUs for folders, serial for archives
+		MaxArchiveDepth: 2,  // ZIPs of ZIPs, in folders
+	}
+}
+
+// fileContext returns the context for identifying one file, limited by
+// FileTimeout.
+func (o Options) fileContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if o.FileTimeout > 0 {
+		return context.WithTimeout(ctx, o.FileTimeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// progressFor returns the progress callback for hashing the item called name,
+// or nil if progress isn't reported.
+func (o Options) progressFor(name string) func(done, total int64) {
+	if o.Progress == nil {
+		return nil
 	}
+	return func(done, total int64) { o.Progress(name, done, total) }
 }
@@ -0,0 +1,222 @@
+// Package igdb is a client for the metadata of games on IGDB (the Internet
+// Game Database), whose API is authenticated with the client credentials of
+// a Twitch application.
+//
+// See https://api-docs.igdb.com for the API and how to register an
+// application.
+package igdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultBaseURL  = "https://api.igdb.com/v4"
+	defaultTokenURL = "https://id.twitch.tv/oauth2/token"
+)
+
+// Client is an IGDB API client. Its access token is requested when it's
+// first needed, and again when it expires.
+type Client struct {
+	BaseURL    string       // API URL, without a trailing slash
+	TokenURL   string       // Twitch OAuth token URL
+	HTTPClient *http.Client // client to send requests with
+
+	clientID     string
+	clientSecret string
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// NewClient creates a client with the client ID and secret of a Twitch
+// application.
+func NewClient(clientID, clientSecret string) *Client {
+	return &Client{
+		BaseURL:      defaultBaseURL,
+		TokenURL:     defaultTokenURL,
+		HTTPClient:   http.DefaultClient,
+		clientID:     clientID,
+		clientSecret: clientSecret,
+	}
+}
+
+// Game is a game on IGDB, with the fields requested by SearchGames.
+type Game struct {
+	ID                int               `json:"id"`
+	Name              string            `json:"name"`
+	Summary           string            `json:"summary,omitempty"`
+	FirstReleaseDate  int64             `json:"first_release_date,omitempty"` // Unix time
+	Genres            []Named           `json:"genres,omitempty"`
+	InvolvedCompanies []InvolvedCompany `json:"involved_companies,omitempty"`
+	Platforms         []int             `json:"platforms,omitempty"`
+	TotalRating       float64           `json:"total_rating,omitempty"` // 0 to 100
+	GameModes         []Named           `json:"game_modes,omitempty"`
+}
+
+// Named is an IGDB object referenced by a game, expanded to its name.
+type Named struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// InvolvedCompany is a company's role in a game.
+type InvolvedCompany struct {
+	Company   Named `json:"company"`
+	Developer bool  `json:"developer"`
+	Publisher bool  `json:"publisher"`
+}
+
+// ReleaseDate returns the first release date, or the zero time if unknown.
+func (g *Game) ReleaseDate() time.Time {
+	if g.FirstReleaseDate == 0 {
+		return time.Time{}
+	}
+	return time.Unix(g.FirstReleaseDate, 0).UTC()
+}
+
+// Developers returns the names of the companies that developed the game.
+func (g *Game) Developers() []string {
+	var names []string
+	for _, c := range g.InvolvedCompanies {
+		if c.Developer {
+			names = append(names, c.Company.Name)
+		}
+	}
+	return names
+}
+
+// Publishers returns the names of the companies that published the game.
+func (g *Game) Publishers() []string {
+	var names []string
+	for _, c := range g.InvolvedCompanies {
+		if c.Publisher {
+			names = append(names, c.Company.Name)
+		}
+	}
+	return names
+}
+
+// gameFields are the fields of the games requested by SearchGames.
+const gameFields = "name,summary,first_release_date,genres.name," +
+	"involved_companies.company.name,involved_companies.developer,involved_companies.publisher," +
+	"platforms,total_rating,game_modes.name"
+
+// SearchGames searches for games by name, best matches first. If platformID
+// isn't 0, only games released on that platform are returned.
+func (c *Client) SearchGames(ctx context.Context, name string, platformID int) ([]Game, error) {
+	query := fmt.Sprintf("search %s; fields %s;", quote(name), gameFields)
+	if platformID != 0 {
+		query += fmt.Sprintf(" where platforms = (%d);", platformID)
+	}
+	query += " limit 10;"
+
+	var games []Game
+	if err := c.Query(ctx, "games", query, &games); err != nil {
+		return nil, err
+	}
+	return games, nil
+}
+
+// Query sends an Apicalypse query to an endpoint (e.g., "games") and decodes
+// its JSON response into result.
+func (c *Client) Query(ctx context.Context, endpoint, query string, result any) error {
+	token, err := c.accessToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+"/"+endpoint, strings.NewReader(query))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Client-ID", c.clientID)
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("igdb: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		// The token was revoked early, so request a new one next time
+		c.mu.Lock()
+		c.token = ""
+		c.mu.Unlock()
+	}
+	if resp.StatusCode != http.StatusOK {
+		return statusError(resp)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("igdb: failed to decode %s response: %w", endpoint, err)
+	}
+	return nil
+}
+
+// accessToken returns the current access token, requesting one if needed.
+func (c *Client) accessToken(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token != "" && time.Now().Before(c.expires) {
+		return c.token, nil
+	}
+
+	form := url.Values{
+		"client_id":     {c.clientID},
+		"client_secret": {c.clientSecret},
+		"grant_type":    {"client_credentials"},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("igdb: failed to get an access token: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("igdb: failed to get an access token: %w", statusError(resp))
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"` // seconds
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("igdb: failed to decode access token: %w", err)
+	}
+
+	// Renew a minute early, so a token doesn't expire mid-request
+	c.token = token.AccessToken
+	c.expires = time.Now().Add(time.Duration(token.ExpiresIn)*time.Second - time.Minute)
+	return c.token, nil
+}
+
+// statusError returns an error for an unsuccessful response.
+func statusError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	if msg := strings.TrimSpace(string(body)); msg != "" {
+		return fmt.Errorf("igdb: %s: %s", resp.Status, msg)
+	}
+	return fmt.Errorf("igdb: %s", resp.Status)
+}
+
+// quote quotes a string for an Apicalypse query.
+func quote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
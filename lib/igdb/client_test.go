@@ -0,0 +1,116 @@
+package igdb
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sargunv/rom-tools/lib/core"
+)
+
+const gamesResponse = `[{
+	"id": 1026,
+	"name": "The Legend of Zelda: A Link to the Past",
+	"summary": "Link must rescue Zelda.",
+	"first_release_date": 690076800,
+	"genres": [{"id": 12, "name": "Role-playing (RPG)"}, {"id": 31, "name": "Adventure"}],
+	"involved_companies": [
+		{"company": {"id": 70, "name": "Nintendo"}, "developer": true, "publisher": true},
+		{"company": {"id": 71, "name": "Other Publisher"}, "developer": false, "publisher": true}
+	],
+	"platforms": [19],
+	"total_rating": 92.5
+}]`
+
+func TestSearchGames(t *testing.T) {
+	var tokens int
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			tokens++
+			if r.FormValue("client_id") != "id" || r.FormValue("client_secret") != "secret" || r.FormValue("grant_type") != "client_credentials" {
+				t.Errorf("token request form = %v", r.Form)
+			}
+			w.Write([]byte(`{"access_token": "abc", "expires_in": 3600, "token_type": "bearer"}`))
+		case "/games":
+			if r.Header.Get("Client-ID") != "id" || r.Header.Get("Authorization") != "Bearer abc" {
+				t.Errorf("headers = %v", r.Header)
+			}
+			body, _ := io.ReadAll(r.Body)
+			queries = append(queries, string(body))
+			w.Write([]byte(gamesResponse))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	c := NewClient("id", "secret")
+	c.BaseURL = server.URL
+	c.TokenURL = server.URL + "/token"
+
+	platform, _ := PlatformID(core.PlatformSNES)
+	games, err := c.SearchGames(context.Background(), `Zelda "ALttP"`, platform)
+	if err != nil {
+		t.Fatalf("SearchGames() error = %v", err)
+	}
+	if _, err := c.SearchGames(context.Background(), "Zelda", 0); err != nil {
+		t.Fatalf("SearchGames() again error = %v", err)
+	}
+
+	if tokens != 1 {
+		t.Errorf("Token requests = %d, want 1", tokens)
+	}
+	if !strings.HasPrefix(queries[0], `search "Zelda \"ALttP\"";`) || !strings.Contains(queries[0], "where platforms = (19);") {
+		t.Errorf("Query = %q", queries[0])
+	}
+	if strings.Contains(queries[1], "where") {
+		t.Errorf("Query without a platform = %q", queries[1])
+	}
+
+	if len(games) != 1 {
+		t.Fatalf("Games = %d, want 1", len(games))
+	}
+	g := games[0]
+	if g.ID != 1026 || g.Name != "The Legend of Zelda: A Link to the Past" || g.TotalRating != 92.5 {
+		t.Errorf("Game = %+v", g)
+	}
+	if want := time.Date(1991, 11, 14, 0, 0, 0, 0, time.UTC); !g.ReleaseDate().Equal(want) {
+		t.Errorf("ReleaseDate() = %v, want %v", g.ReleaseDate(), want)
+	}
+	if got := g.Developers(); !slices.Equal(got, []string{"Nintendo"}) {
+		t.Errorf("Developers() = %v", got)
+	}
+	if got := g.Publishers(); !slices.Equal(got, []string{"Nintendo", "Other Publisher"}) {
+		t.Errorf("Publishers() = %v", got)
+	}
+}
+
+func TestSearchGames_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			w.Write([]byte(`{"access_token": "abc", "expires_in": 3600}`))
+			return
+		}
+		http.Error(w, "Authorization Failure", http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	c := NewClient("id", "secret")
+	c.BaseURL = server.URL
+	c.TokenURL = server.URL + "/token"
+
+	_, err := c.SearchGames(context.Background(), "Zelda", 0)
+	if err == nil || !strings.Contains(err.Error(), "Authorization Failure") {
+		t.Errorf("SearchGames() error = %v, want the response's", err)
+	}
+	if c.token != "" {
+		t.Error("token kept after an unauthorized response")
+	}
+}
@@ -0,0 +1,80 @@
+package igdb
+
+import "github.com/sargunv/rom-tools/lib/core"
+
+// platformIDs maps platforms to IGDB platform IDs.
+var platformIDs = map[core.Platform]int{
+	// Nintendo consoles
+	core.PlatformNES:     18,
+	core.PlatformFDS:     51,
+	core.PlatformSNES:    19,
+	core.PlatformN64:     4,
+	core.PlatformGC:      21,
+	core.PlatformWii:     5,
+	core.PlatformWiiU:    41,
+	core.PlatformSwitch:  130,
+	core.PlatformSwitch2: 508,
+
+	// Nintendo handhelds
+	core.PlatformGB:     33,
+	core.PlatformGBC:    22,
+	core.PlatformGBA:    24,
+	core.PlatformNDS:    20,
+	core.PlatformDSi:    159,
+	core.Platform3DS:    37,
+	core.PlatformNew3DS: 137,
+
+	// Sony
+	core.PlatformPS1:    7,
+	core.PlatformPS2:    8,
+	core.PlatformPS3:    9,
+	core.PlatformPS4:    48,
+	core.PlatformPS5:    167,
+	core.PlatformPSP:    38,
+	core.PlatformPSVita: 46,
+
+	// Sega
+	core.PlatformMS:        64,
+	core.PlatformMD:        29,
+	core.PlatformSegaCD:    78,
+	core.Platform32X:       30,
+	core.PlatformPico:      339,
+	core.PlatformSaturn:    32,
+	core.PlatformDreamcast: 23,
+	core.PlatformGameGear:  35,
+
+	// NEC
+	core.PlatformPCE:   86,
+	core.PlatformPCECD: 150,
+
+	// SNK
+	core.PlatformNeoGeoCD: 136,
+
+	// Atari
+	core.PlatformAtari2600: 59,
+	core.PlatformAtari7800: 60,
+	core.PlatformLynx:      61,
+	core.PlatformJaguar:    62,
+	core.PlatformJaguarCD:  410,
+
+	// Others
+	core.Platform3DO:        50,
+	core.PlatformMSX:        27,
+	core.PlatformMSX2:       53,
+	core.PlatformC64:        15,
+	core.PlatformAmiga:      16,
+	core.PlatformZXSpectrum: 26,
+	core.PlatformArcade:     52,
+
+	// Microsoft
+	core.PlatformXbox:       11,
+	core.PlatformXbox360:    12,
+	core.PlatformXboxOne:    49,
+	core.PlatformXboxSeries: 169,
+}
+
+// PlatformID returns the IGDB platform ID of a platform.
+func PlatformID(p core.Platform) (int, bool) {
+	id, ok := platformIDs[p]
+	return id, ok
+}
@@ -0,0 +1,43 @@
+package iso9660
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/sargunv/rom-tools/lib/core"
+)
+
+// Disc is an ISO image, as a core.DiscImage of one data track of 2048-byte
+// sectors. The image may hold any filesystem, not just ISO 9660.
+type Disc struct {
+	r    io.ReaderAt
+	size int64
+}
+
+// NewDisc returns the disc held by the ISO image r.
+func NewDisc(r io.ReaderAt, size int64) *Disc {
+	return &Disc{r: r, size: size}
+}
+
+// Size returns the size of the image.
+func (d *Disc) Size() int64 {
+	return d.size
+}
+
+// Tracks returns the image's one data track.
+func (d *Disc) Tracks() []core.DiscTrack {
+	return []core.DiscTrack{{Number: 1, Data: true, SectorSize: core.UserDataSize}}
+}
+
+// OpenTrack returns the image, which is track 1.
+func (d *Disc) OpenTrack(number int) (io.ReaderAt, int64, error) {
+	if number != 1 {
+		return nil, 0, fmt.Errorf("track %d not found", number)
+	}
+	return d.r, d.size, nil
+}
+
+// OpenUserData returns the image, whose sectors are all user data.
+func (d *Disc) OpenUserData() (io.ReaderAt, int64, error) {
+	return d.r, d.size, nil
+}
@@ -0,0 +1,86 @@
+package iso9660
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// fileExtent is a run of a file's data in the logical (2048-byte sector) view.
+type fileExtent struct {
+	offset int64
+	length int64
+}
+
+// recordExtents returns the runs of a directory record's data. Interleaved
+// records alternate file units of data with gaps belonging to other files.
+func recordExtents(entry []byte) []fileExtent {
+	loc := int64(binary.LittleEndian.Uint32(entry[dirEntryExtentLoc:]))
+	length := int64(binary.LittleEndian.Uint32(entry[dirEntryDataLen:]))
+	unit := int64(entry[dirEntryUnitSize])
+	gap := int64(entry[dirEntryGapSize])
+
+	if unit == 0 {
+		return []fileExtent{{offset: loc * sectorSize2048, length: length}}
+	}
+
+	var extents []fileExtent
+	for length > 0 {
+		n := min(length, unit*sectorSize2048)
+		extents = appendExtents(extents, fileExtent{offset: loc * sectorSize2048, length: n})
+		loc += unit + gap
+		length -= n
+	}
+	return extents
+}
+
+// appendExtents appends extents to a file's extents, merging contiguous runs.
+func appendExtents(extents []fileExtent, more ...fileExtent) []fileExtent {
+	for _, e := range more {
+		if n := len(extents); n > 0 && extents[n-1].offset+extents[n-1].length == e.offset {
+			extents[n-1].length += e.length
+			continue
+		}
+		extents = append(extents, e)
+	}
+	return extents
+}
+
+// extentReader reads file data spread across several extents.
+type extentReader struct {
+	r       io.ReaderAt
+	extents []fileExtent
+	size    int64
+}
+
+// ReadAt implements io.ReaderAt.
+func (e *extentReader) ReadAt(p []byte, off int64) (int, error) {
+	if off >= e.size {
+		return 0, io.EOF
+	}
+
+	n := 0
+	start := int64(0) // file offset of the current extent
+	for _, ext := range e.extents {
+		if n == len(p) || off+int64(n) >= e.size {
+			break
+		}
+		pos := off + int64(n)
+		if pos >= start+ext.length {
+			start += ext.length
+			continue
+		}
+
+		toRead := min(int64(len(p)-n), start+ext.length-pos, e.size-pos)
+		read, err := e.r.ReadAt(p[n:n+int(toRead)], ext.offset+pos-start)
+		n += read
+		if int64(read) < toRead {
+			return n, err
+		}
+		start += ext.length
+	}
+
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
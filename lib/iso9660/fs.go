@@ -0,0 +1,158 @@
+package iso9660
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+	"time"
+)
+
+// FS returns the image's filesystem as an fs.FS, which also implements
+// fs.ReadDirFS. Names are resolved as by OpenFile: case-insensitively, in the
+// preferred directory tree, following symlinks.
+func (r *Reader) FS() fs.FS {
+	return readerFS{r}
+}
+
+// Walk walks the image's filesystem, calling fn for each file and directory
+// as fs.WalkDir does. Symlinks are reported but not followed.
+func (r *Reader) Walk(fn fs.WalkDirFunc) error {
+	return fs.WalkDir(r.FS(), ".", fn)
+}
+
+// readerFS adapts a Reader to fs.FS.
+type readerFS struct {
+	r *Reader
+}
+
+// Open implements fs.FS.
+func (f readerFS) Open(name string) (fs.File, error) {
+	record, tree, err := f.resolve("open", name)
+	if err != nil {
+		return nil, err
+	}
+	info := fileInfo{name: path.Base(name), record: record}
+	if record.isDir {
+		return &dirFile{r: f.r, tree: tree, info: info}, nil
+	}
+	reader := io.NewSectionReader(f.r.fileData(record), 0, record.size())
+	return &file{SectionReader: reader, info: info}, nil
+}
+
+// ReadDir implements fs.ReadDirFS.
+func (f readerFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	record, tree, err := f.resolve("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := f.r.dirEntries(record, tree)
+	if err != nil {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: err}
+	}
+	slices.SortFunc(entries, func(a, b fs.DirEntry) int {
+		return strings.Compare(a.Name(), b.Name())
+	})
+	return entries, nil
+}
+
+// resolve finds the record for an fs.FS name.
+func (f readerFS) resolve(op, name string) (dirRecord, dirTree, error) {
+	if !fs.ValidPath(name) {
+		return dirRecord{}, dirTree{}, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	if name == "." {
+		name = ""
+	}
+	record, tree, err := f.r.resolve(name)
+	if err != nil {
+		return dirRecord{}, dirTree{}, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	return record, tree, nil
+}
+
+// dirEntries lists a directory's records as fs.DirEntry values.
+func (r *Reader) dirEntries(record dirRecord, tree dirTree) ([]fs.DirEntry, error) {
+	records, err := r.readDirRecord(record, tree)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]fs.DirEntry, len(records))
+	for i, record := range records {
+		entries[i] = fs.FileInfoToDirEntry(fileInfo{name: record.name, record: record})
+	}
+	return entries, nil
+}
+
+// fileInfo implements fs.FileInfo for a directory record.
+type fileInfo struct {
+	name   string
+	record dirRecord
+}
+
+func (fi fileInfo) Name() string       { return fi.name }
+func (fi fileInfo) Size() int64        { return fi.record.size() }
+func (fi fileInfo) ModTime() time.Time { return fi.record.modTime }
+func (fi fileInfo) IsDir() bool        { return fi.Mode().IsDir() }
+func (fi fileInfo) Sys() any           { return nil }
+
+func (fi fileInfo) Mode() fs.FileMode {
+	switch {
+	case fi.record.target != "":
+		return fs.ModeSymlink | 0o777
+	case fi.record.isDir:
+		return fs.ModeDir | 0o555
+	default:
+		return 0o444
+	}
+}
+
+// file is an open regular file.
+type file struct {
+	*io.SectionReader
+	info fileInfo
+}
+
+func (f *file) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *file) Close() error               { return nil }
+
+// dirFile is an open directory, implementing fs.ReadDirFile.
+type dirFile struct {
+	r       *Reader
+	tree    dirTree
+	info    fileInfo
+	entries []fs.DirEntry // nil until first read
+	offset  int
+}
+
+func (d *dirFile) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *dirFile) Close() error               { return nil }
+
+func (d *dirFile) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.name, Err: errors.New("is a directory")}
+}
+
+// ReadDir implements fs.ReadDirFile.
+func (d *dirFile) ReadDir(n int) ([]fs.DirEntry, error) {
+	if d.entries == nil {
+		entries, err := d.r.dirEntries(d.info.record, d.tree)
+		if err != nil {
+			return nil, &fs.PathError{Op: "readdir", Path: d.info.name, Err: err}
+		}
+		d.entries = entries
+	}
+
+	remaining := d.entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return remaining, nil
+	}
+	if len(remaining) == 0 {
+		return nil, io.EOF
+	}
+	n = min(n, len(remaining))
+	d.offset += n
+	return remaining[:n], nil
+}
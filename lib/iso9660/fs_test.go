@@ -0,0 +1,71 @@
+package iso9660
+
+import (
+	"io/fs"
+	"slices"
+	"testing"
+	"testing/fstest"
+)
+
+func TestReader_FS(t *testing.T) {
+	data := createJolietISO([]byte("Joliet content"))
+	reader, err := NewReader(&mockReaderAt{data}, int64(len(data)))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+
+	if err := fstest.TestFS(reader.FS(), "Read Me First.txt", "Save Data/Slot 1.bin"); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := fs.ReadFile(reader.FS(), "save data/slot 1.bin")
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if string(content) != "Joliet content" {
+		t.Errorf("ReadFile = %q, want %q", content, "Joliet content")
+	}
+
+	if _, err := reader.FS().Open("/Save Data"); err == nil {
+		t.Error("Open of an invalid path expected error, got nil")
+	}
+}
+
+func TestReader_Walk(t *testing.T) {
+	data := createRockRidgeISO([]byte("Rock Ridge content"))
+	reader, err := NewReader(&mockReaderAt{data}, int64(len(data)))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+
+	var paths []string
+	err = reader.Walk(func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type()&fs.ModeSymlink != 0 {
+			path += "@"
+		}
+		paths = append(paths, path)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Walk failed: %v", err)
+	}
+
+	want := []string{
+		".",
+		"ABS@",
+		"DEEP",
+		"DEEP/file.bin",
+		"DOCS",
+		"DOCS/ReadMe",
+		"LINK@",
+		"LOOP@",
+		"Long Mixed-Case Name.txt",
+		"RR_MOVED",
+	}
+	if !slices.Equal(paths, want) {
+		t.Errorf("Walk paths = %q, want %q", paths, want)
+	}
+}
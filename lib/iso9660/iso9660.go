@@ -5,12 +5,20 @@
 // (MODE1/2048, MODE1/2352, MODE2/2352).
 //
 // The API mirrors archive/zip: use NewReader to open an ISO, then access
-// files via OpenFile or read raw sectors via ReadAt.
+// files via OpenFile, list directories via ReadDir, or read raw sectors via
+// ReadAt. FS exposes the filesystem as an io/fs.FS, and Walk enumerates it.
+//
+// When the image has a Joliet Supplementary Volume Descriptor, its directory
+// tree (with long, mixed-case UCS-2 names) is preferred over the primary one,
+// unless the primary tree has Rock Ridge extensions (POSIX names, symlinks,
+// and relocated deep directories; see rockridge.go).
 //
 // ISO 9660 layout (relevant parts):
 //   - Sectors 0-15: System area (platform-specific, e.g., Saturn/Dreamcast headers)
 //   - Sector 16 (offset 0x8000): Primary Volume Descriptor
-//   - PVD offset 156: Root directory record (34 bytes)
+//   - Sectors 17+: Other volume descriptors (e.g., Joliet SVD), ending with a terminator
+//   - PVD/SVD offset 156: Root directory record (34 bytes)
+//   - SVD offset 88: Escape sequences ("%/@", "%/C", or "%/E" for Joliet)
 package iso9660
 
 import (
@@ -18,20 +26,65 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"time"
+	"unicode/utf16"
 )
 
 const (
 	pvdMagicOffset    = 1
 	pvdRootDirOffset  = 156
+	svdEscapeOffset   = 88
 	dirEntryExtentLoc = 2  // Offset within directory entry
 	dirEntryDataLen   = 10 // Offset within directory entry
+	dirEntryDate      = 18 // Offset within directory entry (7-byte recording date)
 	dirEntryFlags     = 25 // Offset within directory entry (bit 1 = directory)
+	dirEntryUnitSize  = 26 // Offset within directory entry (interleaved file unit size, in sectors)
+	dirEntryGapSize   = 27 // Offset within directory entry (interleave gap size, in sectors)
 	dirEntryNameLen   = 32 // Offset within directory entry
 	dirEntryName      = 33 // Offset within directory entry
 
-	flagDirectory = 0x02 // Directory flag in file flags byte
+	flagDirectory   = 0x02 // Directory flag in file flags byte
+	flagMultiExtent = 0x80 // Multi-extent flag: the file continues in the next record
+
+	// Volume descriptor types
+	vdTypeSupplementary = 0x02
+	vdTypeTerminator    = 0xFF
+
+	// maxVolumeDescriptors bounds the scan for a Joliet SVD
+	maxVolumeDescriptors = 32
 )
 
+// jolietEscapes are the SVD escape sequences for Joliet UCS-2 levels 1-3.
+var jolietEscapes = []string{"%/@", "%/C", "%/E"}
+
+// DirEntry is an entry in a directory listing.
+type DirEntry struct {
+	Name  string `json:"name"`
+	Size  int64  `json:"size"`
+	IsDir bool   `json:"is_dir"`
+	// Target is the target path of a Rock Ridge symlink, empty otherwise.
+	Target string `json:"target,omitempty"`
+}
+
+// dirRecord is a directory record's location and name.
+type dirRecord struct {
+	name      string
+	extentLoc uint32
+	extentLen uint32
+	isDir     bool
+	extents   []fileExtent // a file's data, which may span several records
+	modTime   time.Time
+	target    string // Rock Ridge symlink target
+	relocated bool   // Rock Ridge relocated directory (listed via its CL placeholder)
+}
+
+// dirTree is the root directory of a directory tree.
+type dirTree struct {
+	extentLoc uint32
+	extentLen uint32
+	joliet    bool
+}
+
 // Reader provides access to an ISO 9660 filesystem image.
 // It implements io.ReaderAt for raw sector access.
 type Reader struct {
@@ -39,6 +92,15 @@ type Reader struct {
 	size          int64
 	rootExtentLoc uint32
 	rootExtentLen uint32
+
+	// Joliet root directory, if the image has a Joliet SVD
+	joliet          bool
+	jolietExtentLoc uint32
+	jolietExtentLen uint32
+
+	// Rock Ridge extensions in the primary tree
+	rockRidge bool
+	suspSkip  int // bytes to skip at the start of each System Use area
 }
 
 // NewReader opens an ISO 9660 image and validates the primary volume descriptor.
@@ -84,17 +146,53 @@ func NewReader(r io.ReaderAt, size int64) (*Reader, error) {
 		rootExtentLoc := binary.LittleEndian.Uint32(rootRecord[dirEntryExtentLoc:])
 		rootExtentLen := binary.LittleEndian.Uint32(rootRecord[dirEntryDataLen:])
 
-		return &Reader{
+		iso := &Reader{
 			r:             reader,
 			size:          logicalSize,
 			rootExtentLoc: rootExtentLoc,
 			rootExtentLen: rootExtentLen,
-		}, nil
+		}
+		iso.findJoliet()
+		iso.detectRockRidge()
+		return iso, nil
 	}
 
 	return nil, fmt.Errorf("not a valid ISO 9660: no CD001 magic found")
 }
 
+// findJoliet scans the volume descriptors after the PVD for a Joliet SVD.
+// A missing or unreadable SVD just leaves the primary directory tree in use.
+func (r *Reader) findJoliet() {
+	vd := make([]byte, sectorSize2048)
+	for sector := int64(17); sector < 17+maxVolumeDescriptors; sector++ {
+		if _, err := r.r.ReadAt(vd, sector*sectorSize2048); err != nil {
+			return
+		}
+		if string(vd[pvdMagicOffset:pvdMagicOffset+5]) != "CD001" || vd[0] == vdTypeTerminator {
+			return
+		}
+		if vd[0] != vdTypeSupplementary || !isJolietEscape(vd[svdEscapeOffset:svdEscapeOffset+3]) {
+			continue
+		}
+
+		rootRecord := vd[pvdRootDirOffset:]
+		r.joliet = true
+		r.jolietExtentLoc = binary.LittleEndian.Uint32(rootRecord[dirEntryExtentLoc:])
+		r.jolietExtentLen = binary.LittleEndian.Uint32(rootRecord[dirEntryDataLen:])
+		return
+	}
+}
+
+// isJolietEscape reports whether escape is one of the Joliet escape sequences.
+func isJolietEscape(escape []byte) bool {
+	for _, e := range jolietEscapes {
+		if string(escape) == e {
+			return true
+		}
+	}
+	return false
+}
+
 // ReadAt implements io.ReaderAt, reading from the logical (2048-byte sector) view.
 // This allows direct access to any part of the ISO, including the system area
 // at offset 0 (used for Saturn/Dreamcast identification).
@@ -107,57 +205,210 @@ func (r *Reader) Size() int64 {
 	return r.size
 }
 
+// Joliet reports whether the image has a Joliet SVD.
+func (r *Reader) Joliet() bool {
+	return r.joliet
+}
+
+// RockRidge reports whether the primary tree has Rock Ridge extensions.
+func (r *Reader) RockRidge() bool {
+	return r.rockRidge
+}
+
+// trees returns the directory trees in order of preference: Rock Ridge names
+// (unlimited length) beat Joliet names (up to 64 characters), which beat
+// plain ISO 9660 names.
+func (r *Reader) trees() []dirTree {
+	primary := dirTree{extentLoc: r.rootExtentLoc, extentLen: r.rootExtentLen}
+	if !r.joliet {
+		return []dirTree{primary}
+	}
+	joliet := dirTree{extentLoc: r.jolietExtentLoc, extentLen: r.jolietExtentLen, joliet: true}
+	if r.rockRidge {
+		return []dirTree{primary, joliet}
+	}
+	return []dirTree{joliet, primary}
+}
+
 // OpenFile opens a file by path (case-insensitive) and returns a reader for its contents.
 // Supports subdirectory paths like "PSP_GAME/PARAM.SFO".
-// Handles ISO 9660 version suffixes (e.g., ";1").
+// Handles ISO 9660 version suffixes (e.g., ";1") and follows Rock Ridge symlinks.
+// Looks in each directory tree in order of preference.
 func (r *Reader) OpenFile(path string) (io.ReaderAt, int64, error) {
+	record, _, err := r.resolve(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	return r.openRecord(record)
+}
+
+// ReadDir lists a directory by path (case-insensitive), excluding the "." and
+// ".." entries. An empty path or "/" lists the root directory.
+// Uses the preferred directory tree that has the directory.
+func (r *Reader) ReadDir(path string) ([]DirEntry, error) {
+	record, tree, err := r.resolve(path)
+	if err != nil {
+		return nil, err
+	}
+	records, err := r.readDirRecord(record, tree)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]DirEntry, len(records))
+	for i, record := range records {
+		entries[i] = DirEntry{
+			Name:   record.name,
+			Size:   record.size(),
+			IsDir:  record.isDir,
+			Target: record.target,
+		}
+	}
+	return entries, nil
+}
+
+// resolve finds the record for path in the preferred directory tree that has
+// it, along with that tree. An empty path or "/" is the root directory.
+func (r *Reader) resolve(path string) (dirRecord, dirTree, error) {
+	path = strings.Trim(path, "/")
+	var err error
+	for _, tree := range r.trees() {
+		if path == "" {
+			return dirRecord{extentLoc: tree.extentLoc, extentLen: tree.extentLen, isDir: true}, tree, nil
+		}
+		var record dirRecord
+		if record, err = r.lookup(tree, path); err == nil {
+			return record, tree, nil
+		}
+	}
+	return dirRecord{}, dirTree{}, err
+}
+
+// readDirRecord reads the records of a directory in tree.
+func (r *Reader) readDirRecord(record dirRecord, tree dirTree) ([]dirRecord, error) {
+	if !record.isDir {
+		return nil, fmt.Errorf("%q is not a directory", record.name)
+	}
+	return r.readDir(record.extentLoc, record.extentLen, tree.joliet)
+}
+
+// openRecord returns a reader for a file's contents.
+func (r *Reader) openRecord(record dirRecord) (io.ReaderAt, int64, error) {
+	if record.isDir {
+		return nil, 0, fmt.Errorf("%q is a directory, not a file", record.name)
+	}
+	return r.fileData(record), record.size(), nil
+}
+
+// fileData returns a reader for a file's data.
+func (r *Reader) fileData(record dirRecord) io.ReaderAt {
+	if len(record.extents) == 1 {
+		return io.NewSectionReader(r.r, record.extents[0].offset, record.extents[0].length)
+	}
+	return &extentReader{r: r.r, extents: record.extents, size: record.size()}
+}
+
+// size returns the size of a file's data, or of a directory's records.
+func (d dirRecord) size() int64 {
+	if d.extents == nil {
+		return int64(d.extentLen)
+	}
+	var size int64
+	for _, e := range d.extents {
+		size += e.length
+	}
+	return size
+}
+
+// lookup resolves a path to its directory record in tree, following symlinks.
+func (r *Reader) lookup(tree dirTree, path string) (dirRecord, error) {
 	// Split path into components
 	parts := strings.Split(path, "/")
 
-	// Start from root directory
-	dirExtentLoc := r.rootExtentLoc
-	dirExtentLen := r.rootExtentLen
+	// The current directory and its ancestors, for resolving ".." in symlinks
+	dirs := []dirRecord{{extentLoc: tree.extentLoc, extentLen: tree.extentLen, isDir: true}}
 
-	// Traverse directories
-	for i, part := range parts {
-		isLast := i == len(parts)-1
+	links := 0
+	for len(parts) > 0 {
+		part := parts[0]
+		parts = parts[1:]
 
-		extentLoc, extentLen, isDir, err := r.findEntry(dirExtentLoc, dirExtentLen, part)
+		switch part {
+		case ".":
+			continue
+		case "..":
+			if len(dirs) > 1 {
+				dirs = dirs[:len(dirs)-1]
+			}
+			continue
+		}
+
+		dir := dirs[len(dirs)-1]
+		record, err := r.findEntry(dir.extentLoc, dir.extentLen, tree.joliet, part)
 		if err != nil {
-			return nil, 0, fmt.Errorf("path component %q not found: %w", part, err)
+			return dirRecord{}, fmt.Errorf("path component %q not found: %w", part, err)
 		}
 
-		if isLast {
-			// Final component - return a reader for the file
-			if isDir {
-				return nil, 0, fmt.Errorf("%q is a directory, not a file", part)
+		if record.target != "" {
+			// Symlink: continue with its target, then the rest of the path
+			links++
+			if links > maxSymlinks {
+				return dirRecord{}, fmt.Errorf("too many levels of symlinks at %q", part)
+			}
+			if strings.HasPrefix(record.target, "/") {
+				dirs = dirs[:1]
 			}
-			fileOffset := int64(extentLoc) * sectorSize2048
-			fileSize := int64(extentLen)
-			return io.NewSectionReader(r.r, fileOffset, fileSize), fileSize, nil
+			var target []string
+			for _, p := range strings.Split(record.target, "/") {
+				if p != "" {
+					target = append(target, p)
+				}
+			}
+			parts = append(target, parts...)
+			continue
 		}
 
-		// Intermediate component - must be a directory
-		if !isDir {
-			return nil, 0, fmt.Errorf("%q is not a directory", part)
+		// Intermediate components must be directories
+		if len(parts) > 0 && !record.isDir {
+			return dirRecord{}, fmt.Errorf("%q is not a directory", part)
 		}
-		dirExtentLoc = extentLoc
-		dirExtentLen = extentLen
+		dirs = append(dirs, record)
 	}
+	return dirs[len(dirs)-1], nil
+}
 
-	return nil, 0, fmt.Errorf("empty path")
+// findEntry searches a directory for an entry by name, preferring an exact
+// match over a case-insensitive one.
+func (r *Reader) findEntry(dirExtentLoc, dirExtentLen uint32, joliet bool, name string) (dirRecord, error) {
+	records, err := r.readDir(dirExtentLoc, dirExtentLen, joliet)
+	if err != nil {
+		return dirRecord{}, err
+	}
+	for _, record := range records {
+		if record.name == name {
+			return record, nil
+		}
+	}
+	for _, record := range records {
+		if strings.EqualFold(record.name, name) {
+			return record, nil
+		}
+	}
+	return dirRecord{}, fmt.Errorf("entry not found: %s", name)
 }
 
-// findEntry searches a directory for an entry by name.
-// Returns the entry's extent location, size, whether it's a directory, and any error.
-func (r *Reader) findEntry(dirExtentLoc, dirExtentLen uint32, name string) (uint32, uint32, bool, error) {
+// readDir reads a directory's records, excluding the "." and ".." entries
+// and Rock Ridge relocated directories. Joliet names are decoded from UCS-2
+// (big-endian). Version suffixes (";1") are stripped.
+func (r *Reader) readDir(dirExtentLoc, dirExtentLen uint32, joliet bool) ([]dirRecord, error) {
 	// Read directory
 	dirData := make([]byte, dirExtentLen)
 	if _, err := r.r.ReadAt(dirData, int64(dirExtentLoc)*sectorSize2048); err != nil {
-		return 0, 0, false, fmt.Errorf("failed to read directory: %w", err)
+		return nil, fmt.Errorf("failed to read directory: %w", err)
 	}
 
-	name = strings.ToUpper(name)
+	var records []dirRecord
+	continued := false // whether the last record continues in this one
 	offset := 0
 	for offset < len(dirData) {
 		entryLen := int(dirData[offset])
@@ -171,32 +422,81 @@ func (r *Reader) findEntry(dirExtentLoc, dirExtentLen uint32, name string) (uint
 			continue
 		}
 
-		if offset+dirEntryName >= len(dirData) {
+		if offset+dirEntryName >= len(dirData) || offset+entryLen > len(dirData) {
 			break
 		}
 
 		nameLen := int(dirData[offset+dirEntryNameLen])
-		if offset+dirEntryName+nameLen > len(dirData) {
+		if dirEntryName+nameLen > entryLen {
 			break
 		}
+		entry := dirData[offset : offset+entryLen]
+		rawName := entry[dirEntryName : dirEntryName+nameLen]
+
+		// Skip "." (0x00) and ".." (0x01), which are single bytes even in Joliet
+		if nameLen == 1 && rawName[0] <= 0x01 {
+			offset += entryLen
+			continue
+		}
 
-		entryName := strings.ToUpper(string(dirData[offset+dirEntryName : offset+dirEntryName+nameLen]))
+		var entryName string
+		if joliet {
+			entryName = decodeUCS2(rawName)
+		} else {
+			entryName = string(rawName)
+		}
 
 		// Strip version suffix (";1")
 		if idx := strings.Index(entryName, ";"); idx != -1 {
 			entryName = entryName[:idx]
 		}
 
-		if entryName == name {
-			extentLoc := binary.LittleEndian.Uint32(dirData[offset+dirEntryExtentLoc:])
-			extentLen := binary.LittleEndian.Uint32(dirData[offset+dirEntryDataLen:])
-			flags := dirData[offset+dirEntryFlags]
-			isDir := (flags & flagDirectory) != 0
-			return extentLoc, extentLen, isDir, nil
+		record := dirRecord{
+			name:      entryName,
+			extentLoc: binary.LittleEndian.Uint32(entry[dirEntryExtentLoc:]),
+			extentLen: binary.LittleEndian.Uint32(entry[dirEntryDataLen:]),
+			isDir:     entry[dirEntryFlags]&flagDirectory != 0,
+			modTime:   recordingDate(entry[dirEntryDate : dirEntryDate+7]),
+		}
+		if r.rockRidge && !joliet {
+			r.applyRockRidge(systemUseArea(entry, r.suspSkip), &record)
+		}
+		if !record.isDir {
+			record.extents = recordExtents(entry)
+		}
+
+		switch {
+		case continued && len(records) > 0 && records[len(records)-1].name == record.name:
+			// Multi-extent file: add this record's data to the last one
+			last := &records[len(records)-1]
+			last.extents = appendExtents(last.extents, record.extents...)
+		case !record.relocated:
+			records = append(records, record)
 		}
+		continued = entry[dirEntryFlags]&flagMultiExtent != 0
 
 		offset += entryLen
 	}
 
-	return 0, 0, false, fmt.Errorf("entry not found: %s", name)
+	return records, nil
+}
+
+// recordingDate decodes a directory record's recording date: years since
+// 1900, month, day, hour, minute, second, and the offset from GMT in 15-minute
+// intervals. An all-zero date is unspecified.
+func recordingDate(b []byte) time.Time {
+	if b[0] == 0 && b[1] == 0 && b[2] == 0 {
+		return time.Time{}
+	}
+	zone := time.FixedZone("", int(int8(b[6]))*15*60)
+	return time.Date(1900+int(b[0]), time.Month(b[1]), int(b[2]), int(b[3]), int(b[4]), int(b[5]), 0, zone)
+}
+
+// decodeUCS2 decodes a big-endian UCS-2 (UTF-16) name.
+func decodeUCS2(b []byte) string {
+	units := make([]uint16, len(b)/2)
+	for i := range units {
+		units[i] = binary.BigEndian.Uint16(b[2*i:])
+	}
+	return string(utf16.Decode(units))
 }
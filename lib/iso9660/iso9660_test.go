@@ -5,6 +5,8 @@ import (
 	"encoding/binary"
 	"io"
 	"testing"
+	"time"
+	"unicode/utf16"
 )
 
 // mockReaderAt wraps a byte slice to implement io.ReaderAt
@@ -127,6 +129,87 @@ func createISOWithFile(filename string, content []byte) []byte {
 	return data
 }
 
+// writeDirRecord writes a directory record at offset, with an optional System
+// Use area, and returns its length.
+func writeDirRecord(data []byte, offset int, extentLoc, extentLen uint32, flags byte, name []byte, su ...byte) int {
+	entryLen := 33 + len(name)
+	if entryLen%2 == 1 {
+		entryLen++ // Padding to even
+	}
+	copy(data[offset+entryLen:], su)
+	entryLen += len(su)
+	if entryLen%2 == 1 {
+		entryLen++
+	}
+	data[offset+0] = byte(entryLen)
+	binary.LittleEndian.PutUint32(data[offset+dirEntryExtentLoc:], extentLoc)
+	binary.LittleEndian.PutUint32(data[offset+dirEntryDataLen:], extentLen)
+	data[offset+dirEntryFlags] = flags
+	data[offset+dirEntryNameLen] = byte(len(name))
+	copy(data[offset+dirEntryName:], name)
+	return entryLen
+}
+
+// ucs2 encodes a name as big-endian UCS-2, as in Joliet directory records.
+func ucs2(name string) []byte {
+	units := utf16.Encode([]rune(name))
+	b := make([]byte, 2*len(units))
+	for i, u := range units {
+		binary.BigEndian.PutUint16(b[2*i:], u)
+	}
+	return b
+}
+
+// createJolietISO creates an ISO with a Joliet SVD. The primary tree has
+// README.TXT; the Joliet tree names the same file "Read Me First.txt" and
+// adds a "Save Data" directory containing "Slot 1.bin".
+func createJolietISO(content []byte) []byte {
+	// 16 system + PVD + SVD + terminator + primary root + Joliet root + Joliet subdir + file
+	data := make([]byte, 23*sectorSize2048)
+	const (
+		primaryRoot = 19
+		jolietRoot  = 20
+		jolietSub   = 21
+		fileSector  = 22
+	)
+
+	writeVD := func(sector int, vdType byte, rootLoc uint32) {
+		offset := sector * sectorSize2048
+		data[offset+0] = vdType
+		copy(data[offset+1:], "CD001")
+		data[offset+6] = 0x01
+		if vdType != vdTypeTerminator {
+			writeDirRecord(data, offset+pvdRootDirOffset, rootLoc, sectorSize2048, flagDirectory, []byte{0x00})
+		}
+	}
+	writeVD(16, 0x01, primaryRoot)
+	writeVD(17, vdTypeSupplementary, jolietRoot)
+	copy(data[17*sectorSize2048+svdEscapeOffset:], "%/E")
+	writeVD(18, vdTypeTerminator, 0)
+
+	writeDir := func(sector int, parent uint32, names [][]byte, locs []uint32, flags []byte) {
+		offset := sector * sectorSize2048
+		offset += writeDirRecord(data, offset, uint32(sector), sectorSize2048, flagDirectory, []byte{0x00})
+		offset += writeDirRecord(data, offset, parent, sectorSize2048, flagDirectory, []byte{0x01})
+		for i, name := range names {
+			extentLen := uint32(len(content))
+			if flags[i] == flagDirectory {
+				extentLen = sectorSize2048
+			}
+			offset += writeDirRecord(data, offset, locs[i], extentLen, flags[i], name)
+		}
+	}
+	writeDir(primaryRoot, primaryRoot, [][]byte{[]byte("README.TXT;1")}, []uint32{fileSector}, []byte{0})
+	writeDir(jolietRoot, jolietRoot,
+		[][]byte{ucs2("Read Me First.txt;1"), ucs2("Save Data")},
+		[]uint32{fileSector, jolietSub},
+		[]byte{0, flagDirectory})
+	writeDir(jolietSub, jolietRoot, [][]byte{ucs2("Slot 1.bin;1")}, []uint32{fileSector}, []byte{0})
+
+	copy(data[fileSector*sectorSize2048:], content)
+	return data
+}
+
 func TestNewReader_ValidISO(t *testing.T) {
 	data := createMinimalISO()
 	reader, err := NewReader(&mockReaderAt{data}, int64(len(data)))
@@ -330,3 +413,196 @@ func TestNewReader_RawMODE2(t *testing.T) {
 		t.Errorf("Size() = %d, want %d", reader.Size(), expectedSize)
 	}
 }
+
+func TestReader_Joliet(t *testing.T) {
+	content := []byte("Joliet content")
+	data := createJolietISO(content)
+
+	reader, err := NewReader(&mockReaderAt{data}, int64(len(data)))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	if !reader.Joliet() {
+		t.Fatal("Joliet() = false, want true")
+	}
+
+	for _, path := range []string{"Read Me First.txt", "read me first.TXT", "Save Data/Slot 1.bin", "README.TXT"} {
+		fileReader, size, err := reader.OpenFile(path)
+		if err != nil {
+			t.Errorf("OpenFile(%q) failed: %v", path, err)
+			continue
+		}
+		buf := make([]byte, size)
+		if _, err := fileReader.ReadAt(buf, 0); err != nil {
+			t.Fatalf("file ReadAt failed: %v", err)
+		}
+		if !bytes.Equal(buf, content) {
+			t.Errorf("OpenFile(%q) content = %q, want %q", path, buf, content)
+		}
+	}
+
+	if _, _, err := reader.OpenFile("Save Data"); err == nil {
+		t.Error("OpenFile of a directory expected error, got nil")
+	}
+}
+
+func TestReader_NoJoliet(t *testing.T) {
+	data := createISOWithFile("TEST.TXT", []byte("x"))
+	reader, err := NewReader(&mockReaderAt{data}, int64(len(data)))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	if reader.Joliet() {
+		t.Error("Joliet() = true, want false")
+	}
+}
+
+func TestReader_ReadDir(t *testing.T) {
+	content := []byte("Joliet content")
+	data := createJolietISO(content)
+
+	reader, err := NewReader(&mockReaderAt{data}, int64(len(data)))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+
+	tests := []struct {
+		path string
+		want []DirEntry
+	}{
+		{"", []DirEntry{
+			{Name: "Read Me First.txt", Size: int64(len(content))},
+			{Name: "Save Data", Size: sectorSize2048, IsDir: true},
+		}},
+		{"/save data/", []DirEntry{
+			{Name: "Slot 1.bin", Size: int64(len(content))},
+		}},
+	}
+	for _, tt := range tests {
+		entries, err := reader.ReadDir(tt.path)
+		if err != nil {
+			t.Errorf("ReadDir(%q) failed: %v", tt.path, err)
+			continue
+		}
+		if len(entries) != len(tt.want) {
+			t.Errorf("ReadDir(%q) = %+v, want %+v", tt.path, entries, tt.want)
+			continue
+		}
+		for i := range entries {
+			if entries[i] != tt.want[i] {
+				t.Errorf("ReadDir(%q)[%d] = %+v, want %+v", tt.path, i, entries[i], tt.want[i])
+			}
+		}
+	}
+
+	if _, err := reader.ReadDir("Read Me First.txt"); err == nil {
+		t.Error("ReadDir of a file expected error, got nil")
+	}
+	if _, err := reader.ReadDir("MISSING"); err == nil {
+		t.Error("ReadDir of a missing directory expected error, got nil")
+	}
+}
+
+func TestReader_ReadDir_Primary(t *testing.T) {
+	data := createISOWithFile("TEST.TXT", []byte("x"))
+	reader, err := NewReader(&mockReaderAt{data}, int64(len(data)))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+
+	entries, err := reader.ReadDir("/")
+	if err != nil {
+		t.Fatalf("ReadDir failed: %v", err)
+	}
+	want := DirEntry{Name: "TEST.TXT", Size: 1}
+	if len(entries) != 1 || entries[0] != want {
+		t.Errorf("ReadDir = %+v, want [%+v]", entries, want)
+	}
+}
+
+func TestRecordingDate(t *testing.T) {
+	// 2001-02-03 04:05:06 at GMT+9 (36 quarter hours)
+	got := recordingDate([]byte{101, 2, 3, 4, 5, 6, 36})
+	want := time.Date(2001, 2, 3, 4, 5, 6, 0, time.FixedZone("", 9*60*60))
+	if !got.Equal(want) {
+		t.Errorf("recordingDate = %v, want %v", got, want)
+	}
+
+	if got := recordingDate(make([]byte, 7)); !got.IsZero() {
+		t.Errorf("recordingDate of unspecified date = %v, want zero", got)
+	}
+}
+
+// createISOWithExtents creates an ISO with BIG.DAT, a multi-extent file of
+// 2048 'A's (sector 18) then 100 'B's (sector 20), and MIX.DAT, interleaved
+// in 1-sector units with 1-sector gaps (sectors 22, 24, 26).
+func createISOWithExtents() []byte {
+	data := createMinimalISO()
+	data = append(data, make([]byte, 10*sectorSize2048)...)
+
+	offset := 17*sectorSize2048 + 68
+	offset += writeDirRecord(data, offset, 18, sectorSize2048, flagMultiExtent, []byte("BIG.DAT;1"))
+	offset += writeDirRecord(data, offset, 20, 100, 0, []byte("BIG.DAT;1"))
+	data[offset+dirEntryUnitSize] = 1
+	data[offset+dirEntryGapSize] = 1
+	writeDirRecord(data, offset, 22, 2*sectorSize2048+10, 0, []byte("MIX.DAT;1"))
+
+	for i, fill := range []byte("A_B_C_D_E") {
+		copy(data[(18+i)*sectorSize2048:(19+i)*sectorSize2048], bytes.Repeat([]byte{fill}, sectorSize2048))
+	}
+	return data
+}
+
+func TestReader_OpenFile_Extents(t *testing.T) {
+	data := createISOWithExtents()
+	reader, err := NewReader(&mockReaderAt{data}, int64(len(data)))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+
+	big := append(bytes.Repeat([]byte("A"), sectorSize2048), bytes.Repeat([]byte("B"), 100)...)
+	mix := append(bytes.Repeat([]byte("C"), sectorSize2048), bytes.Repeat([]byte("D"), sectorSize2048)...)
+	mix = append(mix, bytes.Repeat([]byte("E"), 10)...)
+
+	for _, tt := range []struct {
+		path string
+		want []byte
+	}{
+		{"BIG.DAT", big},
+		{"MIX.DAT", mix},
+	} {
+		fileReader, size, err := reader.OpenFile(tt.path)
+		if err != nil {
+			t.Fatalf("OpenFile(%q) failed: %v", tt.path, err)
+		}
+		if size != int64(len(tt.want)) {
+			t.Errorf("OpenFile(%q) size = %d, want %d", tt.path, size, len(tt.want))
+		}
+		buf := make([]byte, len(tt.want))
+		if _, err := fileReader.ReadAt(buf, 0); err != nil {
+			t.Fatalf("ReadAt failed: %v", err)
+		}
+		if !bytes.Equal(buf, tt.want) {
+			t.Errorf("OpenFile(%q) content mismatch", tt.path)
+		}
+
+		// A read spanning extents, past the end
+		buf = make([]byte, 20)
+		n, err := fileReader.ReadAt(buf, sectorSize2048-5)
+		if want := min(20, len(tt.want)-(sectorSize2048-5)); n != want || (n < 20 && err != io.EOF) {
+			t.Errorf("OpenFile(%q) ReadAt across extents = %d, %v, want %d", tt.path, n, err, want)
+		}
+		if !bytes.Equal(buf[:n], tt.want[sectorSize2048-5:sectorSize2048-5+n]) {
+			t.Errorf("OpenFile(%q) ReadAt across extents content mismatch", tt.path)
+		}
+	}
+
+	entries, err := reader.ReadDir("")
+	if err != nil {
+		t.Fatalf("ReadDir failed: %v", err)
+	}
+	want := []DirEntry{{Name: "BIG.DAT", Size: int64(len(big))}, {Name: "MIX.DAT", Size: int64(len(mix))}}
+	if len(entries) != len(want) || entries[0] != want[0] || entries[1] != want[1] {
+		t.Errorf("ReadDir = %+v, want %+v", entries, want)
+	}
+}
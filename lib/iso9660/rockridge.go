@@ -0,0 +1,194 @@
+package iso9660
+
+import (
+	"encoding/binary"
+	"strings"
+)
+
+// Rock Ridge (RRIP) extensions, carried in SUSP entries in the System Use
+// area at the end of each directory record (after the name and its padding).
+//
+// Each SUSP entry:
+//
+//	Offset  Size  Description
+//	0       2     Signature (e.g. "NM")
+//	2       1     Length, including this header
+//	3       1     Version
+//	4       ...   Data
+//
+// Entries used here:
+//
+//	SP  SUSP indicator, in the root directory's "." record; 0xBE 0xEF, then the bytes to skip
+//	CE  Continuation area: block, offset, and length (both-endian 32-bit each)
+//	ST  Terminator
+//	NM  Alternate (POSIX) name: flags, then name bytes; may be split across entries
+//	SL  Symlink: flags, then components of (flags, length, content)
+//	CL  Child link: placeholder for a relocated deep directory at the given block
+//	RE  Relocated directory, listed via its CL placeholder instead
+
+const (
+	// rrContinue marks an NM name or SL component continued in the next one
+	rrContinue = 0x01
+
+	// SL component flags
+	slCurrent = 0x02
+	slParent  = 0x04
+	slRoot    = 0x08
+
+	// maxContinuations bounds how many CE areas are followed per record
+	maxContinuations = 16
+
+	// maxSymlinks bounds symlink resolution, as ELOOP does
+	maxSymlinks = 40
+)
+
+// detectRockRidge looks for the SP entry in the root directory's "." record.
+func (r *Reader) detectRockRidge() {
+	record := make([]byte, 255)
+	if _, err := r.r.ReadAt(record, int64(r.rootExtentLoc)*sectorSize2048); err != nil {
+		return
+	}
+	entryLen := int(record[0])
+	if entryLen <= dirEntryName {
+		return
+	}
+	sp := systemUseArea(record[:entryLen], 0)
+	if len(sp) >= 7 && string(sp[0:2]) == "SP" && sp[4] == 0xBE && sp[5] == 0xEF {
+		r.rockRidge = true
+		r.suspSkip = int(sp[6])
+	}
+}
+
+// systemUseArea returns the System Use area of a directory record, after
+// skipping skip bytes.
+func systemUseArea(entry []byte, skip int) []byte {
+	nameLen := int(entry[dirEntryNameLen])
+	start := dirEntryName + nameLen
+	if nameLen%2 == 0 {
+		start++ // Padding to even
+	}
+	start += skip
+	if start >= len(entry) {
+		return nil
+	}
+	return entry[start:]
+}
+
+// applyRockRidge updates record from the SUSP entries in area, following
+// continuation areas.
+func (r *Reader) applyRockRidge(area []byte, record *dirRecord) {
+	var name strings.Builder
+	var hasName bool
+	var link []string
+	var linkContinued, hasLink bool
+
+	for range maxContinuations {
+		var next []byte
+		for len(area) >= 4 {
+			length := int(area[2])
+			if length < 4 || length > len(area) {
+				break
+			}
+			entry := area[:length]
+			area = area[length:]
+
+			switch string(entry[0:2]) {
+			case "ST":
+				area = nil
+
+			case "CE":
+				if length < 28 {
+					continue
+				}
+				block := binary.LittleEndian.Uint32(entry[4:])
+				offset := binary.LittleEndian.Uint32(entry[12:])
+				size := binary.LittleEndian.Uint32(entry[20:])
+				// A continuation area fits within one sector
+				if size <= sectorSize2048 {
+					next = make([]byte, size)
+					if _, err := r.r.ReadAt(next, int64(block)*sectorSize2048+int64(offset)); err != nil {
+						next = nil
+					}
+				}
+
+			case "NM":
+				if length >= 5 && entry[4]&^rrContinue == 0 {
+					name.Write(entry[5:])
+					hasName = true
+				}
+
+			case "SL":
+				if length >= 5 {
+					hasLink = true
+					link, linkContinued = appendSymlink(link, linkContinued, entry[5:])
+				}
+
+			case "CL":
+				if length >= 12 {
+					r.applyChildLink(binary.LittleEndian.Uint32(entry[4:]), record)
+				}
+
+			case "RE":
+				record.relocated = true
+			}
+		}
+		if next == nil {
+			break
+		}
+		area = next
+	}
+
+	if hasName {
+		record.name = name.String()
+	}
+	if hasLink {
+		record.target = strings.Join(link, "/")
+		if record.target == "" {
+			record.target = "/"
+		}
+	}
+}
+
+// appendSymlink appends the components of an SL entry to link. continued
+// reports whether the last component of link is continued by the next one.
+func appendSymlink(link []string, continued bool, data []byte) ([]string, bool) {
+	for len(data) >= 2 {
+		flags := data[0]
+		length := int(data[1])
+		if 2+length > len(data) {
+			break
+		}
+		var component string
+		switch {
+		case flags&slRoot != 0:
+			component = ""
+		case flags&slParent != 0:
+			component = ".."
+		case flags&slCurrent != 0:
+			component = "."
+		default:
+			component = string(data[2 : 2+length])
+		}
+		data = data[2+length:]
+
+		if continued && len(link) > 0 {
+			link[len(link)-1] += component
+		} else {
+			link = append(link, component)
+		}
+		continued = flags&rrContinue != 0
+	}
+	return link, continued
+}
+
+// applyChildLink points a CL placeholder record at its relocated directory,
+// taking the directory's size from its "." record.
+func (r *Reader) applyChildLink(block uint32, record *dirRecord) {
+	dot := make([]byte, dirEntryName)
+	if _, err := r.r.ReadAt(dot, int64(block)*sectorSize2048); err != nil {
+		return
+	}
+	record.extentLoc = block
+	record.extentLen = binary.LittleEndian.Uint32(dot[dirEntryDataLen:])
+	record.isDir = true
+}
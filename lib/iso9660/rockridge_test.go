@@ -0,0 +1,224 @@
+package iso9660
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// suspEntry encodes a SUSP entry.
+func suspEntry(sig string, data ...byte) []byte {
+	return append([]byte{sig[0], sig[1], byte(4 + len(data)), 1}, data...)
+}
+
+// bothEndian encodes v as a both-endian 32-bit number.
+func bothEndian(v uint32) []byte {
+	b := make([]byte, 8)
+	binary.LittleEndian.PutUint32(b, v)
+	binary.BigEndian.PutUint32(b[4:], v)
+	return b
+}
+
+// nm encodes an NM entry.
+func nm(name string, flags byte) []byte {
+	return suspEntry("NM", append([]byte{flags}, name...)...)
+}
+
+// sl encodes an SL entry from its components' flags and contents.
+func sl(components ...any) []byte {
+	data := []byte{0}
+	for i := 0; i < len(components); i += 2 {
+		content := components[i+1].(string)
+		data = append(data, components[i].(byte), byte(len(content)))
+		data = append(data, content...)
+	}
+	return suspEntry("SL", data...)
+}
+
+// createRockRidgeISO creates an ISO whose primary tree has Rock Ridge names,
+// a name split into a continuation area, symlinks, and a relocated directory:
+//
+//	Long Mixed-Case Name.txt
+//	DOCS/ReadMe
+//	LINK -> ./DOCS/ReadMe
+//	ABS -> /DOCS
+//	LOOP -> LOOP
+//	DEEP/file.bin (relocated to RR_MOVED/DEEP)
+//	RR_MOVED/
+func createRockRidgeISO(content []byte) []byte {
+	data := make([]byte, 24*sectorSize2048)
+	const (
+		root       = 18
+		docs       = 19
+		deep       = 20
+		rrMoved    = 21
+		fileSector = 22
+		ceSector   = 23
+	)
+	fileLen := uint32(len(content))
+
+	// PVD and terminator
+	pvdOffset := 16 * sectorSize2048
+	data[pvdOffset+0] = 0x01
+	copy(data[pvdOffset+1:], "CD001")
+	data[pvdOffset+6] = 0x01
+	writeDirRecord(data, pvdOffset+pvdRootDirOffset, root, sectorSize2048, flagDirectory, []byte{0x00})
+	data[17*sectorSize2048+0] = vdTypeTerminator
+	copy(data[17*sectorSize2048+1:], "CD001")
+
+	sp := suspEntry("SP", 0xBE, 0xEF, 0)
+	dots := func(offset int, self, parent uint32, su ...byte) int {
+		n := writeDirRecord(data, offset, self, sectorSize2048, flagDirectory, []byte{0x00}, su...)
+		return n + writeDirRecord(data, offset+n, parent, sectorSize2048, flagDirectory, []byte{0x01})
+	}
+
+	// Root directory
+	offset := root * sectorSize2048
+	offset += dots(offset, root, root, sp...)
+	ce := suspEntry("CE", append(append(bothEndian(ceSector), bothEndian(0)...), bothEndian(32)...)...)
+	offset += writeDirRecord(data, offset, fileSector, fileLen, 0, []byte("LONGNAME.TXT;1"),
+		append(nm("Long Mixed-", rrContinue), ce...)...)
+	offset += writeDirRecord(data, offset, docs, sectorSize2048, flagDirectory, []byte("DOCS"))
+	offset += writeDirRecord(data, offset, 0, 0, 0, []byte("LINK.;1"),
+		append(nm("LINK", 0), sl(byte(slCurrent), "", byte(0), "DOCS", byte(0), "ReadMe")...)...)
+	offset += writeDirRecord(data, offset, 0, 0, 0, []byte("ABS.;1"),
+		append(nm("ABS", 0), sl(byte(slRoot), "", byte(0), "DOCS")...)...)
+	offset += writeDirRecord(data, offset, 0, 0, 0, []byte("LOOP.;1"),
+		append(nm("LOOP", 0), sl(byte(0), "LOOP")...)...)
+	offset += writeDirRecord(data, offset, 0, 0, 0, []byte("DEEP.;1"),
+		append(nm("DEEP", 0), suspEntry("CL", bothEndian(deep)...)...)...)
+	writeDirRecord(data, offset, rrMoved, sectorSize2048, flagDirectory, []byte("RR_MOVED"))
+
+	// Continuation of the long name
+	copy(data[ceSector*sectorSize2048:], append(nm("Case Name.txt", 0), suspEntry("ST")...))
+
+	// DOCS
+	offset = docs * sectorSize2048
+	offset += dots(offset, docs, root)
+	writeDirRecord(data, offset, fileSector, fileLen, 0, []byte("README.;1"), nm("ReadMe", 0)...)
+
+	// Relocated DEEP
+	offset = deep * sectorSize2048
+	offset += dots(offset, deep, rrMoved)
+	writeDirRecord(data, offset, fileSector, fileLen, 0, []byte("FILE.BIN;1"), nm("file.bin", 0)...)
+
+	// RR_MOVED, whose DEEP is hidden by RE
+	offset = rrMoved * sectorSize2048
+	offset += dots(offset, rrMoved, root)
+	writeDirRecord(data, offset, deep, sectorSize2048, flagDirectory, []byte("DEEP"), suspEntry("RE")...)
+
+	copy(data[fileSector*sectorSize2048:], content)
+	return data
+}
+
+func TestReader_RockRidge(t *testing.T) {
+	content := []byte("Rock Ridge content")
+	data := createRockRidgeISO(content)
+
+	reader, err := NewReader(&mockReaderAt{data}, int64(len(data)))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	if !reader.RockRidge() {
+		t.Fatal("RockRidge() = false, want true")
+	}
+
+	for _, path := range []string{
+		"Long Mixed-Case Name.txt",
+		"DOCS/ReadMe",
+		"LINK",
+		"ABS/ReadMe",
+		"DEEP/file.bin",
+		"DOCS/../LINK",
+	} {
+		fileReader, size, err := reader.OpenFile(path)
+		if err != nil {
+			t.Errorf("OpenFile(%q) failed: %v", path, err)
+			continue
+		}
+		buf := make([]byte, size)
+		if _, err := fileReader.ReadAt(buf, 0); err != nil {
+			t.Fatalf("file ReadAt failed: %v", err)
+		}
+		if !bytes.Equal(buf, content) {
+			t.Errorf("OpenFile(%q) content = %q, want %q", path, buf, content)
+		}
+	}
+
+	if _, _, err := reader.OpenFile("LOOP"); err == nil {
+		t.Error("OpenFile of a symlink loop expected error, got nil")
+	}
+	if _, _, err := reader.OpenFile("LONGNAME.TXT"); err == nil {
+		t.Error("OpenFile of the ISO 9660 name expected error, got nil")
+	}
+}
+
+func TestReader_RockRidge_ReadDir(t *testing.T) {
+	content := []byte("Rock Ridge content")
+	data := createRockRidgeISO(content)
+
+	reader, err := NewReader(&mockReaderAt{data}, int64(len(data)))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+
+	entries, err := reader.ReadDir("")
+	if err != nil {
+		t.Fatalf("ReadDir failed: %v", err)
+	}
+	want := []DirEntry{
+		{Name: "Long Mixed-Case Name.txt", Size: int64(len(content))},
+		{Name: "DOCS", Size: sectorSize2048, IsDir: true},
+		{Name: "LINK", Target: "./DOCS/ReadMe"},
+		{Name: "ABS", Target: "/DOCS"},
+		{Name: "LOOP", Target: "LOOP"},
+		{Name: "DEEP", Size: sectorSize2048, IsDir: true},
+		{Name: "RR_MOVED", Size: sectorSize2048, IsDir: true},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("ReadDir = %+v, want %+v", entries, want)
+	}
+	for i := range entries {
+		if entries[i] != want[i] {
+			t.Errorf("ReadDir[%d] = %+v, want %+v", i, entries[i], want[i])
+		}
+	}
+
+	// The relocated directory is only listed via its placeholder
+	moved, err := reader.ReadDir("RR_MOVED")
+	if err != nil {
+		t.Fatalf("ReadDir(RR_MOVED) failed: %v", err)
+	}
+	if len(moved) != 0 {
+		t.Errorf("ReadDir(RR_MOVED) = %+v, want empty", moved)
+	}
+
+	// Directory symlinks are followed
+	linked, err := reader.ReadDir("ABS")
+	if err != nil {
+		t.Fatalf("ReadDir(ABS) failed: %v", err)
+	}
+	if len(linked) != 1 || linked[0].Name != "ReadMe" {
+		t.Errorf("ReadDir(ABS) = %+v, want [ReadMe]", linked)
+	}
+}
+
+func TestReader_RockRidge_PreferredOverJoliet(t *testing.T) {
+	// createJolietISO has no SP entry, so its primary tree has no Rock Ridge
+	data := createJolietISO([]byte("x"))
+	reader, err := NewReader(&mockReaderAt{data}, int64(len(data)))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	if reader.RockRidge() {
+		t.Error("RockRidge() = true, want false")
+	}
+	if trees := reader.trees(); !trees[0].joliet {
+		t.Error("Joliet tree not preferred without Rock Ridge")
+	}
+
+	reader.rockRidge = true
+	if trees := reader.trees(); trees[0].joliet {
+		t.Error("Joliet tree preferred over Rock Ridge")
+	}
+}
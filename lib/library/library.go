@@ -0,0 +1,306 @@
+// Package library keeps an index of a ROM library: the identification of
+// every file from a scan, the DAT entries they match, and the results of
+// scraping them, so a collection can be queried by platform, region,
+// missing media, or unmatched files without rescanning it.
+//
+// Like lib/scan's database, the index is a bbolt file keyed by absolute
+// path. It's filled from scan entries with ImportScan, matched against DATs
+// with MatchDATs, and given scrape results with SetScrape.
+package library
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/sargunv/rom-tools/lib/core"
+	"github.com/sargunv/rom-tools/lib/identify"
+	"github.com/sargunv/rom-tools/lib/scan"
+	bolt "go.etcd.io/bbolt"
+)
+
+// bucket is the bbolt bucket holding the records.
+var bucket = []byte("records")
+
+// ErrNotFound is returned when the index has no record for a path.
+var ErrNotFound = errors.New("not in library")
+
+// DB is a library index.
+type DB struct {
+	db *bolt.DB
+}
+
+// Record is a file of the library.
+type Record struct {
+	Path     string        `json:"path"` // absolute path
+	Size     int64         `json:"size"`
+	ModTime  time.Time     `json:"mod_time"`
+	Platform core.Platform `json:"platform,omitempty"` // of the first identified primary item
+	Title    string        `json:"title,omitempty"`    // of the first identified primary item
+	Serial   string        `json:"serial,omitempty"`   // of the first identified primary item
+	Regions  []core.Region `json:"regions,omitempty"`  // of the first identified primary item
+	Items    []Item        `json:"items,omitempty"`    // identified items (1 for single files, N for archives)
+	Error    string        `json:"error,omitempty"`    // why the file couldn't be identified, if it couldn't
+	Scrape   *Scrape       `json:"scrape,omitempty"`   // result of the last scrape, if scraped
+}
+
+// Item is an identified file or archive entry, and its DAT match.
+type Item struct {
+	Name       string          `json:"name"`
+	Size       int64           `json:"size"`
+	Hashes     core.Hashes     `json:"hashes,omitempty"`
+	HeaderSize int64           `json:"header_size,omitempty"` // size of the header excluded from headerless hashes
+	Primary    bool            `json:"primary,omitempty"`
+	Match      *identify.Match `json:"match,omitempty"` // set by MatchDATs
+}
+
+// Scrape is the result of scraping a file's game.
+type Scrape struct {
+	Source string            `json:"source"`            // backend, e.g. "screenscraper"
+	GameID string            `json:"game_id,omitempty"` // game ID in the backend
+	Name   string            `json:"name,omitempty"`    // game name
+	Media  map[string]string `json:"media,omitempty"`   // paths of downloaded media by type, e.g. "covers"
+	Time   time.Time         `json:"time"`              // when it was scraped
+}
+
+// Matched returns whether any item of the record matched a DAT entry.
+func (r *Record) Matched() bool {
+	return slices.ContainsFunc(r.Items, func(item Item) bool {
+		return item.Match != nil && item.Match.Status != identify.MatchStatusUnknown
+	})
+}
+
+// HasMedia returns whether the record's last scrape downloaded media of a
+// type.
+func (r *Record) HasMedia(mediaType string) bool {
+	return r.Scrape != nil && r.Scrape.Media[mediaType] != ""
+}
+
+// Open opens the library index at path, creating it if needed. It fails if
+// another process has the index open.
+func Open(path string) (*DB, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create library directory: %w", err)
+	}
+	db, err := bolt.Open(path, 0644, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open library: %w", err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(bucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize library: %w", err)
+	}
+	return &DB{db: db}, nil
+}
+
+// Close closes the index.
+func (d *DB) Close() error {
+	return d.db.Close()
+}
+
+// ImportScan brings the index up to date with the entries of a scan, as
+// returned by scan.DB.Entries. Records of files whose size and modification
+// time are unchanged keep their DAT matches and scrape result; records of
+// files that are no longer in the scan are removed.
+func (d *DB) ImportScan(entries []scan.Entry) error {
+	return d.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket)
+		seen := make(map[string]bool, len(entries))
+		for _, entry := range entries {
+			seen[entry.Path] = true
+			record := newRecord(entry)
+
+			if old, err := get(b, entry.Path); err == nil && old.Size == entry.Size && old.ModTime.Equal(entry.ModTime) {
+				record.Scrape = old.Scrape
+				for i := range record.Items {
+					if i < len(old.Items) && old.Items[i].Name == record.Items[i].Name {
+						record.Items[i].Match = old.Items[i].Match
+					}
+				}
+			}
+			if err := put(b, record); err != nil {
+				return err
+			}
+		}
+
+		var removed [][]byte
+		err := b.ForEach(func(k, _ []byte) error {
+			if !seen[string(k)] {
+				removed = append(removed, k)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, k := range removed {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// newRecord converts a scan entry to a record.
+func newRecord(entry scan.Entry) *Record {
+	record := &Record{Path: entry.Path, Size: entry.Size, ModTime: entry.ModTime, Error: entry.Error}
+	for _, item := range entry.Items {
+		record.Items = append(record.Items, Item{Name: item.Name, Size: item.Size, Hashes: item.Hashes, HeaderSize: item.HeaderSize, Primary: item.Primary})
+		if record.Platform == "" && item.Primary && item.Platform != "" {
+			record.Platform = item.Platform
+			record.Title = item.Title
+			record.Serial = item.Serial
+			record.Regions = item.Regions
+		}
+	}
+	return record
+}
+
+// MatchDATs matches the items of every record against the DATs of matcher,
+// replacing their earlier matches.
+func (d *DB) MatchDATs(matcher *identify.Matcher) error {
+	return d.update(func(r *Record) bool {
+		for i, item := range r.Items {
+			r.Items[i].Match = matcher.Match(identify.Item{Name: item.Name, Size: item.Size, Hashes: item.Hashes, HeaderSize: item.HeaderSize})
+		}
+		return len(r.Items) > 0
+	})
+}
+
+// SetScrape records the result of scraping the file at path. It returns
+// ErrNotFound if the file isn't in the index.
+func (d *DB) SetScrape(path string, result *Scrape) error {
+	return d.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket)
+		record, err := get(b, path)
+		if err != nil {
+			return err
+		}
+		record.Scrape = result
+		return put(b, record)
+	})
+}
+
+// Get returns the record of the file at path. It returns ErrNotFound if the
+// file isn't in the index.
+func (d *DB) Get(path string) (*Record, error) {
+	var record *Record
+	err := d.db.View(func(tx *bolt.Tx) error {
+		var err error
+		record, err = get(tx.Bucket(bucket), path)
+		return err
+	})
+	return record, err
+}
+
+// Query selects records. Its zero value selects every record; each set
+// field narrows the selection.
+type Query struct {
+	// Platform selects records identified as a platform.
+	Platform core.Platform
+
+	// Region selects records with a region that is, or is within, a region
+	// (e.g., Europe selects Germany releases too).
+	Region core.Region
+
+	// Unmatched selects records with items that didn't match any DAT entry.
+	Unmatched bool
+
+	// MissingMedia selects records whose last scrape didn't download media
+	// of a type (e.g., "covers"), or that weren't scraped.
+	MissingMedia string
+}
+
+// matches returns whether the query selects a record.
+func (q Query) matches(r *Record) bool {
+	if q.Platform != "" && r.Platform != q.Platform {
+		return false
+	}
+	if q.Region != "" && !slices.ContainsFunc(r.Regions, func(region core.Region) bool {
+		within, _ := region.IsDescendantOf(q.Region)
+		return region == q.Region || within
+	}) {
+		return false
+	}
+	if q.Unmatched && (len(r.Items) == 0 || r.Matched()) {
+		return false
+	}
+	if q.MissingMedia != "" && r.HasMedia(q.MissingMedia) {
+		return false
+	}
+	return true
+}
+
+// Query returns the records a query selects, sorted by path.
+func (d *DB) Query(q Query) ([]Record, error) {
+	records := []Record{}
+	err := d.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).ForEach(func(k, v []byte) error {
+			var record Record
+			if err := json.Unmarshal(v, &record); err != nil {
+				return fmt.Errorf("failed to read record %s: %w", k, err)
+			}
+			if q.matches(&record) {
+				records = append(records, record)
+			}
+			return nil
+		})
+	})
+	return records, err
+}
+
+// update calls fn on every record, storing those it changed.
+func (d *DB) update(fn func(r *Record) bool) error {
+	return d.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket)
+		var changed []*Record
+		err := b.ForEach(func(k, v []byte) error {
+			var record Record
+			if err := json.Unmarshal(v, &record); err != nil {
+				return fmt.Errorf("failed to read record %s: %w", k, err)
+			}
+			if fn(&record) {
+				changed = append(changed, &record)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, record := range changed {
+			if err := put(b, record); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func get(b *bolt.Bucket, path string) (*Record, error) {
+	data := b.Get([]byte(path))
+	if data == nil {
+		return nil, fmt.Errorf("%s: %w", path, ErrNotFound)
+	}
+	var record Record
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("failed to read record %s: %w", path, err)
+	}
+	return &record, nil
+}
+
+func put(b *bolt.Bucket, record *Record) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal record: %w", err)
+	}
+	return b.Put([]byte(record.Path), data)
+}
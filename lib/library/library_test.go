@@ -0,0 +1,188 @@
+package library
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sargunv/rom-tools/lib/core"
+	"github.com/sargunv/rom-tools/lib/datfile"
+	"github.com/sargunv/rom-tools/lib/identify"
+	"github.com/sargunv/rom-tools/lib/scan"
+)
+
+var modTime = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func openTestDB(t *testing.T) *DB {
+	t.Helper()
+	db, err := Open(filepath.Join(t.TempDir(), "library.db"))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func entry(path string, platform core.Platform, region core.Region, sha1 string) scan.Entry {
+	return scan.Entry{
+		Path:    path,
+		Size:    4,
+		ModTime: modTime,
+		Items: []scan.Item{{
+			Name:     filepath.Base(path),
+			Size:     4,
+			Hashes:   core.Hashes{core.HashSHA1: sha1},
+			Platform: platform,
+			Regions:  []core.Region{region},
+			Primary:  true,
+		}},
+	}
+}
+
+func testMatcher(t *testing.T) *identify.Matcher {
+	t.Helper()
+	dat, err := datfile.ParseReader(strings.NewReader(`<?xml version="1.0"?>
+<datafile>
+	<header><name>Test DAT</name></header>
+	<game name="Tetris (World)"><rom name="Tetris (World).gb" size="4" sha1="aaaa"/></game>
+	<game name="Zelda (USA)"><rom name="Zelda (USA).nes" size="4" crc="1234abcd"/></game>
+</datafile>`))
+	if err != nil {
+		t.Fatalf("failed to parse test DAT: %v", err)
+	}
+	index := identify.NewDATIndex()
+	index.Add("", dat)
+	return identify.NewMatcher(index)
+}
+
+func paths(records []Record) string {
+	var names []string
+	for _, r := range records {
+		names = append(names, r.Path)
+	}
+	return strings.Join(names, ",")
+}
+
+func TestQuery(t *testing.T) {
+	db := openTestDB(t)
+	err := db.ImportScan([]scan.Entry{
+		entry("/roms/tetris.gb", core.PlatformGB, core.RegionUSA, "aaaa"),
+		entry("/roms/hack.gb", core.PlatformGB, core.RegionGermany, "bbbb"),
+		entry("/roms/mario.sfc", core.PlatformSNES, core.RegionJapan, "cccc"),
+		{Path: "/roms/broken.gb", Size: 1, ModTime: modTime, Error: "unreadable"},
+	})
+	if err != nil {
+		t.Fatalf("ImportScan() error = %v", err)
+	}
+	if err := db.MatchDATs(testMatcher(t)); err != nil {
+		t.Fatalf("MatchDATs() error = %v", err)
+	}
+	err = db.SetScrape("/roms/tetris.gb", &Scrape{Source: "test", Media: map[string]string{"covers": "/media/tetris.png"}, Time: modTime})
+	if err != nil {
+		t.Fatalf("SetScrape() error = %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		query Query
+		want  string
+	}{
+		{"all", Query{}, "/roms/broken.gb,/roms/hack.gb,/roms/mario.sfc,/roms/tetris.gb"},
+		{"platform", Query{Platform: core.PlatformGB}, "/roms/hack.gb,/roms/tetris.gb"},
+		{"region", Query{Region: core.RegionJapan}, "/roms/mario.sfc"},
+		{"parent region", Query{Region: core.RegionEurope}, "/roms/hack.gb"},
+		{"unmatched", Query{Unmatched: true}, "/roms/hack.gb,/roms/mario.sfc"},
+		{"missing media", Query{Platform: core.PlatformGB, MissingMedia: "covers"}, "/roms/hack.gb"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records, err := db.Query(tt.query)
+			if err != nil {
+				t.Fatalf("Query() error = %v", err)
+			}
+			if got := paths(records); got != tt.want {
+				t.Errorf("Query() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchDATs_Headered(t *testing.T) {
+	db := openTestDB(t)
+	// A 16-byte header before the 4 bytes the DAT entry has
+	zelda := scan.Entry{
+		Path:    "/roms/zelda.nes",
+		Size:    20,
+		ModTime: modTime,
+		Items: []scan.Item{{
+			Name:       "zelda.nes",
+			Size:       20,
+			Hashes:     core.Hashes{core.HashCRC32: "ffffffff", core.HashHeaderlessCRC32: "1234abcd"},
+			HeaderSize: 16,
+			Platform:   core.PlatformNES,
+			Primary:    true,
+		}},
+	}
+	if err := db.ImportScan([]scan.Entry{zelda}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.MatchDATs(testMatcher(t)); err != nil {
+		t.Fatalf("MatchDATs() error = %v", err)
+	}
+	record, err := db.Get("/roms/zelda.nes")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if !record.Matched() || record.Items[0].Match.Game != "Zelda (USA)" {
+		t.Errorf("headered record didn't match by its headerless CRC32: %+v", record.Items[0].Match)
+	}
+}
+
+func TestImportScan_KeepsUnchanged(t *testing.T) {
+	db := openTestDB(t)
+	tetris := entry("/roms/tetris.gb", core.PlatformGB, core.RegionUSA, "aaaa")
+	mario := entry("/roms/mario.gb", core.PlatformGB, core.RegionUSA, "cccc")
+	if err := db.ImportScan([]scan.Entry{tetris, mario}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.MatchDATs(testMatcher(t)); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SetScrape("/roms/tetris.gb", &Scrape{Source: "test", Time: modTime}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Rescan with tetris unchanged and mario gone
+	if err := db.ImportScan([]scan.Entry{tetris}); err != nil {
+		t.Fatal(err)
+	}
+	record, err := db.Get("/roms/tetris.gb")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if !record.Matched() || record.Scrape == nil {
+		t.Errorf("unchanged record lost its match or scrape: %+v", record)
+	}
+	if _, err := db.Get("/roms/mario.gb"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() of removed file error = %v, want ErrNotFound", err)
+	}
+
+	// Rescan with tetris modified
+	tetris.ModTime = modTime.Add(time.Hour)
+	if err := db.ImportScan([]scan.Entry{tetris}); err != nil {
+		t.Fatal(err)
+	}
+	record, err = db.Get("/roms/tetris.gb")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if record.Matched() || record.Scrape != nil {
+		t.Errorf("modified record kept its match or scrape: %+v", record)
+	}
+
+	if err := db.SetScrape("/roms/missing.gb", &Scrape{}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("SetScrape() of missing file error = %v, want ErrNotFound", err)
+	}
+}
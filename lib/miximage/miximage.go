@@ -0,0 +1,243 @@
+// Package miximage composes "miximages": single images that combine a
+// game's screenshot, box art, and marquee, like those generated by Skraper
+// and ES-DE for frontends to show in place of a plain screenshot.
+//
+// Where each image goes is set by a Layout, either one of the built-in
+// Templates or one loaded from a JSON file, e.g.:
+//
+//	{
+//	  "width": 1280,
+//	  "height": 960,
+//	  "slots": [
+//	    {"asset": "screenshot", "x": 80, "y": 60, "width": 1120, "height": 760},
+//	    {"asset": "box", "x": 40, "y": 500, "width": 400, "height": 440, "align": "bottom-left"}
+//	  ]
+//	}
+package miximage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ErrNoAssets is returned when none of the images a layout places are given.
+var ErrNoAssets = errors.New("no images to compose")
+
+// Asset is a kind of image placed in a miximage.
+type Asset string
+
+const (
+	AssetScreenshot Asset = "screenshot" // in-game screenshot
+	AssetBox        Asset = "box"        // box art, 2D or 3D
+	AssetMarquee    Asset = "marquee"    // logo or wheel art, usually transparent
+)
+
+// Layout is where the images of a miximage go.
+type Layout struct {
+	Width      int    `json:"width"`                // width of the image, in pixels
+	Height     int    `json:"height"`               // height of the image, in pixels
+	Background string `json:"background,omitempty"` // "#RRGGBB" or "#RRGGBBAA"; transparent if empty
+	Slots      []Slot `json:"slots"`                // drawn in order, so later slots are on top
+}
+
+// Slot is the area of a layout that an image is fit into, keeping its
+// aspect ratio.
+type Slot struct {
+	Asset  Asset  `json:"asset"`
+	X      int    `json:"x"`
+	Y      int    `json:"y"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	Align  string `json:"align,omitempty"` // e.g. "top", "bottom-left", or "center" (the default)
+}
+
+// Templates are the built-in layouts, by name.
+var Templates = map[string]Layout{
+	// 4:3, like ES-DE: a large screenshot with the marquee over its top
+	// left corner and the box over its bottom left corner
+	"standard": {
+		Width:  1280,
+		Height: 960,
+		Slots: []Slot{
+			{Asset: AssetScreenshot, X: 80, Y: 60, Width: 1120, Height: 840},
+			{Asset: AssetMarquee, X: 40, Y: 20, Width: 480, Height: 200, Align: "top-left"},
+			{Asset: AssetBox, X: 40, Y: 500, Width: 400, Height: 440, Align: "bottom-left"},
+		},
+	},
+	// 16:9: the marquee and box side by side on the left, and the
+	// screenshot on the right
+	"wide": {
+		Width:  1280,
+		Height: 720,
+		Slots: []Slot{
+			{Asset: AssetScreenshot, X: 400, Y: 40, Width: 840, Height: 640},
+			{Asset: AssetMarquee, X: 40, Y: 40, Width: 340, Height: 180},
+			{Asset: AssetBox, X: 40, Y: 240, Width: 340, Height: 440, Align: "bottom"},
+		},
+	},
+}
+
+// TemplateNames returns the names of the built-in layouts, sorted.
+func TemplateNames() []string {
+	names := make([]string, 0, len(Templates))
+	for name := range Templates {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// LoadLayout returns the built-in layout with the given name, or else the
+// layout in the JSON file at that path.
+func LoadLayout(nameOrPath string) (Layout, error) {
+	if layout, ok := Templates[nameOrPath]; ok {
+		return layout, nil
+	}
+
+	data, err := os.ReadFile(nameOrPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Layout{}, fmt.Errorf("unknown miximage layout %q (templates: %s)", nameOrPath, strings.Join(TemplateNames(), ", "))
+		}
+		return Layout{}, err
+	}
+	var layout Layout
+	if err := json.Unmarshal(data, &layout); err != nil {
+		return Layout{}, fmt.Errorf("failed to parse miximage layout %s: %w", nameOrPath, err)
+	}
+	if err := layout.Validate(); err != nil {
+		return Layout{}, fmt.Errorf("invalid miximage layout %s: %w", nameOrPath, err)
+	}
+	return layout, nil
+}
+
+// Validate returns an error if the layout can't be composed.
+func (l Layout) Validate() error {
+	if l.Width <= 0 || l.Height <= 0 {
+		return fmt.Errorf("size %dx%d is not positive", l.Width, l.Height)
+	}
+	if _, err := parseColor(l.Background); err != nil {
+		return err
+	}
+	for i, slot := range l.Slots {
+		switch slot.Asset {
+		case AssetScreenshot, AssetBox, AssetMarquee:
+		default:
+			return fmt.Errorf("slot %d: unknown asset %q", i+1, slot.Asset)
+		}
+		if slot.Width <= 0 || slot.Height <= 0 {
+			return fmt.Errorf("slot %d: size %dx%d is not positive", i+1, slot.Width, slot.Height)
+		}
+		if _, _, err := parseAlign(slot.Align); err != nil {
+			return fmt.Errorf("slot %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// Compose draws the given images into the slots of a layout. Slots without
+// an image are left empty, but at least one must have one.
+func Compose(layout Layout, assets map[Asset]image.Image) (*image.RGBA, error) {
+	if err := layout.Validate(); err != nil {
+		return nil, err
+	}
+	background, _ := parseColor(layout.Background)
+
+	canvas := image.NewRGBA(image.Rect(0, 0, layout.Width, layout.Height))
+	draw.Draw(canvas, canvas.Bounds(), image.NewUniform(background), image.Point{}, draw.Src)
+
+	drawn := false
+	for _, slot := range layout.Slots {
+		src, ok := assets[slot.Asset]
+		if !ok || src == nil || src.Bounds().Empty() {
+			continue
+		}
+		rect := fit(src.Bounds().Size(), slot)
+		draw.Draw(canvas, rect, resize(src, rect.Dx(), rect.Dy()), image.Point{}, draw.Over)
+		drawn = true
+	}
+	if !drawn {
+		return nil, ErrNoAssets
+	}
+	return canvas, nil
+}
+
+// DecodeFile decodes the PNG, JPEG, or GIF image at path.
+func DecodeFile(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	return img, nil
+}
+
+// fit returns the largest rectangle of the aspect ratio of size that fits in
+// a slot, aligned within it.
+func fit(size image.Point, slot Slot) image.Rectangle {
+	w, h := slot.Width, size.Y*slot.Width/size.X
+	if h > slot.Height {
+		w, h = size.X*slot.Height/size.Y, slot.Height
+	}
+	w, h = max(w, 1), max(h, 1)
+
+	horizontal, vertical, _ := parseAlign(slot.Align)
+	x := slot.X + (slot.Width-w)*horizontal/2
+	y := slot.Y + (slot.Height-h)*vertical/2
+	return image.Rect(x, y, x+w, y+h)
+}
+
+// parseAlign returns the horizontal and vertical position of an alignment,
+// 0 for left or top, 1 for center, and 2 for right or bottom.
+func parseAlign(align string) (int, int, error) {
+	horizontal, vertical := 1, 1
+	if align == "" || align == "center" {
+		return horizontal, vertical, nil
+	}
+	for _, part := range strings.Split(align, "-") {
+		switch part {
+		case "left":
+			horizontal = 0
+		case "right":
+			horizontal = 2
+		case "top":
+			vertical = 0
+		case "bottom":
+			vertical = 2
+		default:
+			return 0, 0, fmt.Errorf("unknown alignment %q", align)
+		}
+	}
+	return horizontal, vertical, nil
+}
+
+// parseColor parses a "#RRGGBB" or "#RRGGBBAA" color; empty is transparent.
+func parseColor(s string) (color.NRGBA, error) {
+	if s == "" {
+		return color.NRGBA{}, nil
+	}
+	hex, ok := strings.CutPrefix(s, "#")
+	if ok && len(hex) == 6 {
+		hex += "ff"
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if !ok || len(hex) != 8 || err != nil {
+		return color.NRGBA{}, fmt.Errorf("invalid color %q: want #RRGGBB or #RRGGBBAA", s)
+	}
+	return color.NRGBA{R: uint8(v >> 24), G: uint8(v >> 16), B: uint8(v >> 8), A: uint8(v)}, nil
+}
@@ -0,0 +1,177 @@
+package miximage
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// solid returns an image of the given size and color
+func solid(width, height int, c color.Color) image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+var (
+	red   = color.NRGBA{R: 255, A: 255}
+	green = color.NRGBA{G: 255, A: 255}
+	blue  = color.NRGBA{B: 255, A: 255}
+)
+
+func TestCompose(t *testing.T) {
+	layout := Layout{
+		Width:      200,
+		Height:     100,
+		Background: "#000000",
+		Slots: []Slot{
+			{Asset: AssetScreenshot, X: 0, Y: 0, Width: 200, Height: 100},
+			{Asset: AssetBox, X: 0, Y: 50, Width: 50, Height: 50, Align: "bottom-left"},
+			{Asset: AssetMarquee, X: 150, Y: 0, Width: 50, Height: 50, Align: "top-right"},
+		},
+	}
+	// A 4:3 screenshot is pillarboxed, a tall box is put at the left, and a
+	// half-transparent marquee is blended over the screenshot
+	assets := map[Asset]image.Image{
+		AssetScreenshot: solid(160, 120, red),
+		AssetBox:        solid(20, 40, green),
+		AssetMarquee:    solid(40, 20, color.NRGBA{B: 255, A: 128}),
+	}
+
+	img, err := Compose(layout, assets)
+	if err != nil {
+		t.Fatalf("Compose() error = %v", err)
+	}
+	if got := img.Bounds().Size(); got != image.Pt(200, 100) {
+		t.Fatalf("Size = %v, want 200x100", got)
+	}
+
+	tests := []struct {
+		name string
+		x, y int
+		want color.RGBA
+	}{
+		{name: "pillarbox", x: 10, y: 10, want: color.RGBA{A: 255}},
+		{name: "screenshot", x: 100, y: 10, want: color.RGBA{R: 255, A: 255}},
+		{name: "box", x: 10, y: 90, want: color.RGBA{G: 255, A: 255}},
+		{name: "beside box", x: 30, y: 90, want: color.RGBA{A: 255}},
+		{name: "marquee", x: 190, y: 10, want: color.RGBA{B: 128, A: 255}},
+		{name: "below marquee", x: 190, y: 40, want: color.RGBA{A: 255}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := img.RGBAAt(tt.x, tt.y)
+			if diff(got.R, tt.want.R) > 1 || diff(got.G, tt.want.G) > 1 || diff(got.B, tt.want.B) > 1 || got.A != tt.want.A {
+				t.Errorf("At(%d, %d) = %v, want %v", tt.x, tt.y, got, tt.want)
+			}
+		})
+	}
+}
+
+func diff(a, b uint8) int {
+	return max(int(a)-int(b), int(b)-int(a))
+}
+
+func TestCompose_NoAssets(t *testing.T) {
+	_, err := Compose(Templates["standard"], map[Asset]image.Image{})
+	if !errors.Is(err, ErrNoAssets) {
+		t.Errorf("Compose() error = %v, want ErrNoAssets", err)
+	}
+}
+
+func TestCompose_Templates(t *testing.T) {
+	assets := map[Asset]image.Image{
+		AssetScreenshot: solid(320, 224, red),
+		AssetBox:        solid(300, 420, green),
+		AssetMarquee:    solid(400, 150, blue),
+	}
+	for _, name := range TemplateNames() {
+		t.Run(name, func(t *testing.T) {
+			layout := Templates[name]
+			img, err := Compose(layout, assets)
+			if err != nil {
+				t.Fatalf("Compose() error = %v", err)
+			}
+			if got := img.Bounds().Size(); got != image.Pt(layout.Width, layout.Height) {
+				t.Errorf("Size = %v, want %dx%d", got, layout.Width, layout.Height)
+			}
+		})
+	}
+}
+
+func TestLoadLayout(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, data string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	tests := []struct {
+		name      string
+		path      string
+		wantWidth int
+		wantErr   bool
+	}{
+		{name: "template", path: "wide", wantWidth: 1280},
+		{
+			name:      "file",
+			path:      write("good.json", `{"width": 640, "height": 480, "background": "#112233", "slots": [{"asset": "box", "width": 100, "height": 100, "align": "top-left"}]}`),
+			wantWidth: 640,
+		},
+		{name: "unknown", path: filepath.Join(dir, "missing.json"), wantErr: true},
+		{name: "bad asset", path: write("asset.json", `{"width": 640, "height": 480, "slots": [{"asset": "fanart", "width": 1, "height": 1}]}`), wantErr: true},
+		{name: "bad align", path: write("align.json", `{"width": 640, "height": 480, "slots": [{"asset": "box", "width": 1, "height": 1, "align": "middle"}]}`), wantErr: true},
+		{name: "bad color", path: write("color.json", `{"width": 640, "height": 480, "background": "black"}`), wantErr: true},
+		{name: "no size", path: write("size.json", `{"slots": []}`), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			layout, err := LoadLayout(tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadLayout() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if layout.Width != tt.wantWidth {
+				t.Errorf("Width = %d, want %d", layout.Width, tt.wantWidth)
+			}
+		})
+	}
+}
+
+func TestResize(t *testing.T) {
+	tests := []struct {
+		name          string
+		width, height int
+	}{
+		{name: "up", width: 37, height: 23},
+		{name: "down", width: 3, height: 2},
+		{name: "same", width: 10, height: 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Uniform images stay uniform, including their transparency
+			src := solid(10, 10, color.NRGBA{R: 200, G: 100, B: 50, A: 128})
+			want := resize(src, 10, 10).RGBAAt(0, 0)
+			out := resize(src, tt.width, tt.height)
+			if got := out.Bounds().Size(); got != image.Pt(tt.width, tt.height) {
+				t.Fatalf("Size = %v", got)
+			}
+			for y := 0; y < tt.height; y++ {
+				for x := 0; x < tt.width; x++ {
+					if got := out.RGBAAt(x, y); got != want {
+						t.Fatalf("At(%d, %d) = %v, want %v", x, y, got, want)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package miximage
+
+import (
+	"image"
+	"image/draw"
+	"math"
+)
+
+// contribution is the weight of a source pixel in a destination pixel.
+type contribution struct {
+	index  int
+	weight float64
+}
+
+// resize scales src to width by height with a triangle (bilinear) filter,
+// widened when shrinking so every source pixel is averaged in.
+func resize(src image.Image, width, height int) *image.RGBA {
+	// Work on premultiplied RGBA, so transparent pixels don't bleed color
+	b := src.Bounds()
+	in := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(in, in.Bounds(), src, b.Min, draw.Src)
+	if b.Dx() == width && b.Dy() == height {
+		return in
+	}
+
+	// Scale rows, then columns
+	columns := weights(b.Dx(), width)
+	rows := weights(b.Dy(), height)
+
+	tmp := make([]float64, width*b.Dy()*4)
+	for y := 0; y < b.Dy(); y++ {
+		for x, cs := range columns {
+			var px [4]float64
+			for _, c := range cs {
+				i := in.PixOffset(c.index, y)
+				for k := range px {
+					px[k] += float64(in.Pix[i+k]) * c.weight
+				}
+			}
+			copy(tmp[(y*width+x)*4:], px[:])
+		}
+	}
+
+	out := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y, cs := range rows {
+		for x := 0; x < width; x++ {
+			var px [4]float64
+			for _, c := range cs {
+				i := (c.index*width + x) * 4
+				for k := range px {
+					px[k] += tmp[i+k] * c.weight
+				}
+			}
+			i := out.PixOffset(x, y)
+			for k, v := range px {
+				out.Pix[i+k] = uint8(math.Round(min(max(v, 0), 255)))
+			}
+			// Premultiplied color can't exceed alpha
+			for k := 0; k < 3; k++ {
+				out.Pix[i+k] = min(out.Pix[i+k], out.Pix[i+3])
+			}
+		}
+	}
+	return out
+}
+
+// weights returns the source pixels, and their weights, of each of the dst
+// pixels a line of src pixels is scaled to.
+func weights(src, dst int) [][]contribution {
+	scale := float64(src) / float64(dst)
+	radius := max(scale, 1)
+
+	result := make([][]contribution, dst)
+	for i := range result {
+		center := (float64(i)+0.5)*scale - 0.5
+		lo := int(math.Ceil(center - radius))
+		hi := int(math.Floor(center + radius))
+
+		var cs []contribution
+		var total float64
+		for j := lo; j <= hi; j++ {
+			w := 1 - math.Abs(float64(j)-center)/radius
+			if w <= 0 {
+				continue
+			}
+			cs = append(cs, contribution{index: min(max(j, 0), src-1), weight: w})
+			total += w
+		}
+		for k := range cs {
+			cs[k].weight /= total
+		}
+		result[i] = cs
+	}
+	return result
+}
@@ -0,0 +1,62 @@
+package nrg
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/sargunv/rom-tools/lib/core"
+)
+
+// Disc is an NRG image's tracks, as a core.DiscImage.
+type Disc struct {
+	image *Image
+	r     io.ReaderAt
+}
+
+// NewDisc returns the disc laid out by image. r must be the NRG image it was
+// parsed from.
+func NewDisc(image *Image, r io.ReaderAt) *Disc {
+	return &Disc{image: image, r: r}
+}
+
+// Size returns the total size of the tracks.
+func (d *Disc) Size() int64 {
+	var total int64
+	for _, t := range d.image.Tracks {
+		total += t.Size
+	}
+	return total
+}
+
+// Tracks lists the tracks in order.
+func (d *Disc) Tracks() []core.DiscTrack {
+	tracks := make([]core.DiscTrack, len(d.image.Tracks))
+	for i, t := range d.image.Tracks {
+		tracks[i] = core.DiscTrack{Number: t.Number, Data: t.Mode.IsData(), SectorSize: int(t.SectorSize)}
+	}
+	return tracks
+}
+
+// OpenTrack returns a reader for a track's sectors as stored in the image.
+func (d *Disc) OpenTrack(number int) (io.ReaderAt, int64, error) {
+	for _, t := range d.image.Tracks {
+		if t.Number == number {
+			return io.NewSectionReader(d.r, t.Offset, t.Size), t.Size, nil
+		}
+	}
+	return nil, 0, fmt.Errorf("track %d not found", number)
+}
+
+// OpenUserData returns a reader for the user data of the first data track,
+// as Track.Open does.
+func (d *Disc) OpenUserData() (io.ReaderAt, int64, error) {
+	track := d.image.FirstDataTrack()
+	if track == nil {
+		return nil, 0, core.ErrNoDataTrack
+	}
+	r, err := track.Open(d.r)
+	if err != nil {
+		return nil, 0, err
+	}
+	return r, r.Size(), nil
+}
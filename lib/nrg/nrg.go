@@ -0,0 +1,403 @@
+// Package nrg provides support for reading Nero Burning ROM (.nrg) disc images.
+//
+// An NRG image is the raw track data followed by a chain of chunks describing
+// the disc layout. The chain's offset is stored in a footer at the end of the
+// file:
+//
+//	Version 1: "NERO" + 4-byte offset (last 8 bytes)
+//	Version 2: "NER5" + 8-byte offset (last 12 bytes)
+//
+// Each chunk is a 4-byte ID, a 4-byte size, and the data (big-endian). The
+// chain ends with an "END!" chunk. The chunks used here are:
+//
+//	CUES/CUEX  Cue sheet: 8-byte entries (control, BCD track, BCD index, pad, LBA)
+//	DAOI/DAOX  Disc-at-once track layout (one chunk per session)
+//	ETNF/ETN2  Track-at-once track layout
+//
+// DAOI/DAOX chunk layout:
+//
+//	Offset  Size  Description
+//	0x00    4     Chunk size (repeated)
+//	0x04    14    UPC/EAN (null-terminated)
+//	0x12    2     TOC type
+//	0x14    1     First track
+//	0x15    1     Last track
+//
+// followed by one entry per track:
+//
+//	Offset  Size  Description
+//	0x00    12    ISRC
+//	0x0C    2     Sector size
+//	0x0E    1     Mode (see Mode)
+//	0x0F    3     Unknown
+//	0x12    4/8   Pregap (index 0) offset in file (4 bytes in DAOI, 8 in DAOX)
+//	        4/8   Index 1 offset in file
+//	        4/8   End offset in file
+//
+// ETNF/ETN2 entries are offset (4/8), length (4/8), mode (4), start LBA (4),
+// and an unknown field (4/8).
+//
+// Use Parse to read the track layout, then Track.Open to read a data track as
+// 2048-byte logical sectors, suitable for iso9660.NewReader.
+//
+// Format reference: https://en.wikipedia.org/wiki/NRG_(file_format) and the
+// libmirage NRG parser.
+package nrg
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+const (
+	footerV1Size = 8
+	footerV2Size = 12
+
+	chunkHeaderSize = 8
+	maxChunks       = 1024 // sanity limit on the chunk chain
+
+	daoHeaderSize       = 0x16
+	daoFirstTrackOffset = 0x14
+	daoLastTrackOffset  = 0x15
+	daoSectorSizeOffset = 0x0C
+	daoModeOffset       = 0x0E
+	daoOffsetsOffset    = 0x12
+	daoEntryV1Size      = daoOffsetsOffset + 3*4
+	daoEntryV2Size      = daoOffsetsOffset + 3*8
+
+	etnEntryV1Size = 20
+	etnEntryV2Size = 32
+
+	cueEntrySize     = 8
+	cueLeadOutTrack  = 0xAA
+	cueIndexStart    = 1
+	logicalSectorLen = 2048
+)
+
+// Mode is the NRG track mode code.
+type Mode byte
+
+// Mode values (from libmirage).
+const (
+	ModeMode1       Mode = 0x00 // MODE1, 2048-byte sectors
+	ModeMode2Form1  Mode = 0x02 // MODE2 Form 1, 2048-byte sectors
+	ModeMode2       Mode = 0x03 // MODE2, 2336-byte sectors (no sync/header)
+	ModeMode1Raw    Mode = 0x05 // MODE1, 2352-byte raw sectors
+	ModeMode2Raw    Mode = 0x06 // MODE2, 2352-byte raw sectors
+	ModeAudio       Mode = 0x07 // Audio, 2352-byte sectors
+	ModeMode2RawSub Mode = 0x0F // MODE2, 2352-byte raw sectors + 96 bytes subchannel
+	ModeAudioSub    Mode = 0x10 // Audio, 2352-byte sectors + 96 bytes subchannel
+	ModeMode1RawSub Mode = 0x11 // MODE1, 2352-byte raw sectors + 96 bytes subchannel
+)
+
+// sectorLayout returns the sector size and the offset of the 2048 bytes of
+// user data within each sector. ok is false for audio and unknown modes.
+func (m Mode) sectorLayout() (sectorSize, dataOffset int64, ok bool) {
+	switch m {
+	case ModeMode1, ModeMode2Form1:
+		return 2048, 0, true
+	case ModeMode2:
+		return 2336, 8, true
+	case ModeMode1Raw:
+		return 2352, 16, true
+	case ModeMode2Raw:
+		return 2352, 24, true
+	case ModeMode1RawSub:
+		return 2448, 16, true
+	case ModeMode2RawSub:
+		return 2448, 24, true
+	}
+	return 0, 0, false
+}
+
+// IsData reports whether the mode is a data mode with readable user data.
+func (m Mode) IsData() bool {
+	_, _, ok := m.sectorLayout()
+	return ok
+}
+
+// Track describes one track of an NRG image.
+type Track struct {
+	// Number is the track number.
+	Number int `json:"number"`
+	// Session is the session containing the track (1-based).
+	Session int `json:"session"`
+	// Mode is the track mode.
+	Mode Mode `json:"mode"`
+	// SectorSize is the size of each stored sector in bytes.
+	SectorSize int64 `json:"sector_size"`
+	// LBA is the logical block address of index 1, from the cue sheet
+	// (-1 if unknown).
+	LBA int64 `json:"lba"`
+	// Offset is the file offset of index 1.
+	Offset int64 `json:"offset"`
+	// Size is the size in bytes of the track data from index 1.
+	Size int64 `json:"size"`
+}
+
+// Image contains the parsed layout of an NRG image.
+type Image struct {
+	// Version is the footer version (1 for "NERO", 2 for "NER5").
+	Version int `json:"version"`
+	// Tracks lists the tracks in order.
+	Tracks []Track `json:"tracks"`
+}
+
+// Parse reads the chunk chain of an NRG image.
+func Parse(r io.ReaderAt, size int64) (*Image, error) {
+	if size < footerV2Size {
+		return nil, fmt.Errorf("file too small for NRG footer: %d bytes", size)
+	}
+
+	footer := make([]byte, footerV2Size)
+	if _, err := r.ReadAt(footer, size-footerV2Size); err != nil {
+		return nil, fmt.Errorf("failed to read NRG footer: %w", err)
+	}
+
+	img := &Image{}
+	var chainOffset int64
+	switch {
+	case string(footer[0:4]) == "NER5":
+		img.Version = 2
+		chainOffset = int64(binary.BigEndian.Uint64(footer[4:]))
+	case string(footer[4:8]) == "NERO":
+		img.Version = 1
+		chainOffset = int64(binary.BigEndian.Uint32(footer[8:]))
+	default:
+		return nil, fmt.Errorf("not a valid NRG file: missing footer")
+	}
+
+	footerSize := int64(footerV1Size)
+	if img.Version == 2 {
+		footerSize = footerV2Size
+	}
+	chainEnd := size - footerSize
+	if chainOffset < 0 || chainOffset >= chainEnd {
+		return nil, fmt.Errorf("not a valid NRG file: chunk offset %d out of range", chainOffset)
+	}
+
+	lbas := make(map[int]int64)
+	session := 0
+	offset := chainOffset
+	header := make([]byte, chunkHeaderSize)
+	for range maxChunks {
+		if offset+chunkHeaderSize > chainEnd {
+			return nil, fmt.Errorf("NRG chunk chain truncated at offset %d", offset)
+		}
+		if _, err := r.ReadAt(header, offset); err != nil {
+			return nil, fmt.Errorf("failed to read NRG chunk header: %w", err)
+		}
+		id := string(header[0:4])
+		chunkSize := int64(binary.BigEndian.Uint32(header[4:]))
+		if id == "END!" {
+			break
+		}
+		if offset+chunkHeaderSize+chunkSize > chainEnd {
+			return nil, fmt.Errorf("NRG chunk %q extends beyond chunk chain", id)
+		}
+
+		data := make([]byte, chunkSize)
+		if _, err := r.ReadAt(data, offset+chunkHeaderSize); err != nil {
+			return nil, fmt.Errorf("failed to read NRG chunk %q: %w", id, err)
+		}
+
+		switch id {
+		case "CUES", "CUEX":
+			parseCue(data, lbas)
+		case "DAOI", "DAOX":
+			session++
+			tracks, err := parseDAO(data, id == "DAOX", session)
+			if err != nil {
+				return nil, err
+			}
+			img.Tracks = append(img.Tracks, tracks...)
+		case "ETNF", "ETN2":
+			session++
+			tracks, err := parseETN(data, id == "ETN2", session, len(img.Tracks)+1)
+			if err != nil {
+				return nil, err
+			}
+			img.Tracks = append(img.Tracks, tracks...)
+		}
+
+		offset += chunkHeaderSize + chunkSize
+	}
+
+	if len(img.Tracks) == 0 {
+		return nil, fmt.Errorf("not a valid NRG file: no tracks")
+	}
+	for i := range img.Tracks {
+		t := &img.Tracks[i]
+		if lba, ok := lbas[t.Number]; ok && t.LBA < 0 {
+			t.LBA = lba
+		}
+		if t.Offset < 0 || t.Size < 0 || t.Offset+t.Size > chainOffset {
+			return nil, fmt.Errorf("NRG track %d extends beyond track data", t.Number)
+		}
+	}
+
+	return img, nil
+}
+
+// parseCue records the index 1 LBA of each track in a CUES/CUEX chunk.
+func parseCue(data []byte, lbas map[int]int64) {
+	for i := 0; i+cueEntrySize <= len(data); i += cueEntrySize {
+		entry := data[i : i+cueEntrySize]
+		if entry[1] == cueLeadOutTrack || fromBCD(entry[2]) != cueIndexStart {
+			continue
+		}
+		lbas[fromBCD(entry[1])] = int64(int32(binary.BigEndian.Uint32(entry[4:])))
+	}
+}
+
+// parseDAO parses the tracks of a DAOI/DAOX chunk.
+func parseDAO(data []byte, v2 bool, session int) ([]Track, error) {
+	if len(data) < daoHeaderSize {
+		return nil, fmt.Errorf("NRG DAO chunk too small: %d bytes", len(data))
+	}
+
+	entrySize, offsetSize := daoEntryV1Size, 4
+	if v2 {
+		entrySize, offsetSize = daoEntryV2Size, 8
+	}
+
+	first := int(data[daoFirstTrackOffset])
+	last := int(data[daoLastTrackOffset])
+	count := last - first + 1
+	if count <= 0 || daoHeaderSize+count*entrySize > len(data) {
+		return nil, fmt.Errorf("NRG DAO chunk too small for tracks %d-%d: %d bytes", first, last, len(data))
+	}
+
+	tracks := make([]Track, count)
+	for i := range tracks {
+		entry := data[daoHeaderSize+i*entrySize:]
+		index1 := readOffset(entry[daoOffsetsOffset+offsetSize:], offsetSize)
+		end := readOffset(entry[daoOffsetsOffset+2*offsetSize:], offsetSize)
+		tracks[i] = Track{
+			Number:     first + i,
+			Session:    session,
+			Mode:       Mode(entry[daoModeOffset]),
+			SectorSize: int64(binary.BigEndian.Uint16(entry[daoSectorSizeOffset:])),
+			LBA:        -1,
+			Offset:     index1,
+			Size:       end - index1,
+		}
+	}
+	return tracks, nil
+}
+
+// parseETN parses the tracks of an ETNF/ETN2 chunk.
+func parseETN(data []byte, v2 bool, session, firstTrack int) ([]Track, error) {
+	entrySize, offsetSize := etnEntryV1Size, 4
+	if v2 {
+		entrySize, offsetSize = etnEntryV2Size, 8
+	}
+	if len(data)%entrySize != 0 {
+		return nil, fmt.Errorf("invalid NRG ETN chunk size: %d bytes", len(data))
+	}
+
+	tracks := make([]Track, len(data)/entrySize)
+	for i := range tracks {
+		entry := data[i*entrySize:]
+		mode := Mode(binary.BigEndian.Uint32(entry[2*offsetSize:]))
+		sectorSize, _, _ := mode.sectorLayout()
+		if !mode.IsData() {
+			sectorSize = 2352
+		}
+		tracks[i] = Track{
+			Number:     firstTrack + i,
+			Session:    session,
+			Mode:       mode,
+			SectorSize: sectorSize,
+			LBA:        int64(binary.BigEndian.Uint32(entry[2*offsetSize+4:])),
+			Offset:     readOffset(entry, offsetSize),
+			Size:       readOffset(entry[offsetSize:], offsetSize),
+		}
+	}
+	return tracks, nil
+}
+
+// FirstDataTrack returns the first data track, or nil if the image only has
+// audio tracks.
+func (img *Image) FirstDataTrack() *Track {
+	for i := range img.Tracks {
+		if img.Tracks[i].Mode.IsData() {
+			return &img.Tracks[i]
+		}
+	}
+	return nil
+}
+
+// Open returns a reader for the track's user data as 2048-byte logical
+// sectors. r must be the NRG image the track was parsed from.
+func (t *Track) Open(r io.ReaderAt) (*SectorReader, error) {
+	sectorSize, dataOffset, ok := t.Mode.sectorLayout()
+	if !ok {
+		return nil, fmt.Errorf("track %d: mode 0x%02X has no user data", t.Number, byte(t.Mode))
+	}
+	if t.SectorSize != sectorSize {
+		return nil, fmt.Errorf("track %d: sector size %d doesn't match mode 0x%02X", t.Number, t.SectorSize, byte(t.Mode))
+	}
+
+	return &SectorReader{
+		r:          r,
+		offset:     t.Offset,
+		sectorSize: sectorSize,
+		dataOffset: dataOffset,
+		size:       t.Size / sectorSize * logicalSectorLen,
+	}, nil
+}
+
+// SectorReader reads a data track's user data as 2048-byte logical sectors.
+type SectorReader struct {
+	r          io.ReaderAt
+	offset     int64
+	sectorSize int64
+	dataOffset int64
+	size       int64
+}
+
+// Size returns the logical size in bytes.
+func (s *SectorReader) Size() int64 {
+	return s.size
+}
+
+// ReadAt implements io.ReaderAt, translating logical offsets to physical.
+func (s *SectorReader) ReadAt(p []byte, off int64) (n int, err error) {
+	if off < 0 {
+		return 0, fmt.Errorf("negative offset")
+	}
+
+	for n < len(p) && off < s.size {
+		sector := off / logicalSectorLen
+		inSector := off % logicalSectorLen
+		physical := s.offset + sector*s.sectorSize + s.dataOffset + inSector
+		chunk := min(int64(len(p)-n), logicalSectorLen-inSector, s.size-off)
+
+		read, err := s.r.ReadAt(p[n:n+int(chunk)], physical)
+		n += read
+		off += int64(read)
+		if err != nil {
+			return n, err
+		}
+	}
+
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// readOffset reads a big-endian 4- or 8-byte offset.
+func readOffset(b []byte, size int) int64 {
+	if size == 8 {
+		return int64(binary.BigEndian.Uint64(b))
+	}
+	return int64(binary.BigEndian.Uint32(b))
+}
+
+// fromBCD decodes a 2-digit BCD byte.
+func fromBCD(b byte) int {
+	return int(b>>4)*10 + int(b&0x0F)
+}
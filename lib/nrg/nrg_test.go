@@ -0,0 +1,210 @@
+package nrg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// daoTrack describes a track for makeTestNRG.
+type daoTrack struct {
+	mode       Mode
+	sectorSize int
+	sectors    int
+	fill       byte
+}
+
+// makeTestNRG builds an NRG image with a single-session DAO layout and a cue
+// sheet. Each sector's user data is filled with the track's fill byte.
+func makeTestNRG(t *testing.T, v2 bool, tracks []daoTrack) []byte {
+	t.Helper()
+
+	var body bytes.Buffer
+	type span struct{ start, end int64 }
+	spans := make([]span, len(tracks))
+	for i, tr := range tracks {
+		spans[i].start = int64(body.Len())
+		_, dataOffset, ok := tr.mode.sectorLayout()
+		for range tr.sectors {
+			sector := make([]byte, tr.sectorSize)
+			if ok {
+				for j := range logicalSectorLen {
+					sector[dataOffset+int64(j)] = tr.fill
+				}
+			}
+			body.Write(sector)
+		}
+		spans[i].end = int64(body.Len())
+	}
+	chainOffset := int64(body.Len())
+
+	chunk := func(id string, data []byte) {
+		header := make([]byte, chunkHeaderSize)
+		copy(header, id)
+		binary.BigEndian.PutUint32(header[4:], uint32(len(data)))
+		body.Write(header)
+		body.Write(data)
+	}
+
+	// Cue sheet: index 1 of each track
+	var cue []byte
+	lba := int32(0)
+	for i, tr := range tracks {
+		entry := make([]byte, cueEntrySize)
+		entry[0] = 0x41
+		entry[1] = byte(i + 1)
+		entry[2] = 0x01
+		binary.BigEndian.PutUint32(entry[4:], uint32(lba))
+		cue = append(cue, entry...)
+		lba += int32(tr.sectors)
+	}
+	cueID, daoID, offsetSize, entrySize := "CUES", "DAOI", 4, daoEntryV1Size
+	if v2 {
+		cueID, daoID, offsetSize, entrySize = "CUEX", "DAOX", 8, daoEntryV2Size
+	}
+	chunk(cueID, cue)
+
+	dao := make([]byte, daoHeaderSize+len(tracks)*entrySize)
+	dao[daoFirstTrackOffset] = 1
+	dao[daoLastTrackOffset] = byte(len(tracks))
+	for i, tr := range tracks {
+		entry := dao[daoHeaderSize+i*entrySize:]
+		binary.BigEndian.PutUint16(entry[daoSectorSizeOffset:], uint16(tr.sectorSize))
+		entry[daoModeOffset] = byte(tr.mode)
+		for j, v := range []int64{spans[i].start, spans[i].start, spans[i].end} {
+			if v2 {
+				binary.BigEndian.PutUint64(entry[daoOffsetsOffset+j*offsetSize:], uint64(v))
+			} else {
+				binary.BigEndian.PutUint32(entry[daoOffsetsOffset+j*offsetSize:], uint32(v))
+			}
+		}
+	}
+	chunk(daoID, dao)
+	chunk("END!", nil)
+
+	if v2 {
+		footer := make([]byte, footerV2Size)
+		copy(footer, "NER5")
+		binary.BigEndian.PutUint64(footer[4:], uint64(chainOffset))
+		body.Write(footer)
+	} else {
+		footer := make([]byte, footerV1Size)
+		copy(footer, "NERO")
+		binary.BigEndian.PutUint32(footer[4:], uint32(chainOffset))
+		body.Write(footer)
+	}
+	return body.Bytes()
+}
+
+func TestParse(t *testing.T) {
+	for _, v2 := range []bool{false, true} {
+		data := makeTestNRG(t, v2, []daoTrack{
+			{ModeMode2Raw, 2352, 20, 0xAB},
+			{ModeAudio, 2352, 10, 0},
+		})
+
+		img, err := Parse(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			t.Fatalf("Parse(v2=%v) error = %v", v2, err)
+		}
+
+		wantVersion := 1
+		if v2 {
+			wantVersion = 2
+		}
+		if img.Version != wantVersion {
+			t.Errorf("Version = %d, want %d", img.Version, wantVersion)
+		}
+		if len(img.Tracks) != 2 {
+			t.Fatalf("len(Tracks) = %d, want 2", len(img.Tracks))
+		}
+
+		want := []Track{
+			{Number: 1, Session: 1, Mode: ModeMode2Raw, SectorSize: 2352, LBA: 0, Offset: 0, Size: 20 * 2352},
+			{Number: 2, Session: 1, Mode: ModeAudio, SectorSize: 2352, LBA: 20, Offset: 20 * 2352, Size: 10 * 2352},
+		}
+		for i, tr := range img.Tracks {
+			if tr != want[i] {
+				t.Errorf("Tracks[%d] = %+v, want %+v", i, tr, want[i])
+			}
+		}
+
+		if dt := img.FirstDataTrack(); dt == nil || dt.Number != 1 {
+			t.Errorf("FirstDataTrack() = %+v, want track 1", dt)
+		}
+	}
+}
+
+func TestTrackOpen(t *testing.T) {
+	modes := []struct {
+		mode       Mode
+		sectorSize int
+	}{
+		{ModeMode1, 2048},
+		{ModeMode2, 2336},
+		{ModeMode1Raw, 2352},
+		{ModeMode2Raw, 2352},
+		{ModeMode1RawSub, 2448},
+		{ModeMode2RawSub, 2448},
+	}
+
+	for _, m := range modes {
+		data := makeTestNRG(t, true, []daoTrack{
+			{ModeAudio, 2352, 2, 0},
+			{m.mode, m.sectorSize, 3, 0x5A},
+		})
+
+		img, err := Parse(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			t.Fatalf("Parse(mode 0x%02X) error = %v", byte(m.mode), err)
+		}
+
+		reader, err := img.FirstDataTrack().Open(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("Open(mode 0x%02X) error = %v", byte(m.mode), err)
+		}
+		if reader.Size() != 3*logicalSectorLen {
+			t.Errorf("mode 0x%02X: Size() = %d, want %d", byte(m.mode), reader.Size(), 3*logicalSectorLen)
+		}
+
+		// Read across a sector boundary
+		buf := make([]byte, logicalSectorLen)
+		if _, err := reader.ReadAt(buf, logicalSectorLen/2); err != nil {
+			t.Fatalf("mode 0x%02X: ReadAt() error = %v", byte(m.mode), err)
+		}
+		if !bytes.Equal(buf, bytes.Repeat([]byte{0x5A}, logicalSectorLen)) {
+			t.Errorf("mode 0x%02X: ReadAt() returned data outside user data area", byte(m.mode))
+		}
+	}
+}
+
+func TestTrackOpen_Audio(t *testing.T) {
+	track := Track{Number: 1, Mode: ModeAudio, SectorSize: 2352}
+	if _, err := track.Open(bytes.NewReader(nil)); err == nil {
+		t.Error("Open() expected error for audio track, got nil")
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	valid := makeTestNRG(t, true, []daoTrack{{ModeMode1, 2048, 1, 0}})
+
+	badOffset := bytes.Clone(valid)
+	binary.BigEndian.PutUint64(badOffset[len(badOffset)-8:], uint64(len(badOffset)))
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"too small", make([]byte, 4)},
+		{"missing footer", make([]byte, 64)},
+		{"chunk offset out of range", badOffset},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(bytes.NewReader(tt.data), int64(len(tt.data))); err == nil {
+				t.Error("Parse() expected error, got nil")
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+// Package onegame implements 1G1R (one game, one ROM) selection: grouping
+// the entries of a DAT into games and picking a single preferred entry for
+// each, by region and language.
+//
+// Regions, languages, and other details are read from the tags in No-Intro
+// and Redump style names by lib/romname, e.g.:
+//
+//	Title (Region1, Region2) (En,Fr,De) (Rev 1) (Beta)
+package onegame
+
+import (
+	"cmp"
+	"strconv"
+	"strings"
+
+	"github.com/sargunv/rom-tools/lib/romname"
+)
+
+// NameInfo is the information in the tags of a DAT entry name.
+type NameInfo = romname.Name
+
+// ParseName reads the tags of a DAT entry name with romname.Parse.
+func ParseName(name string) NameInfo {
+	return romname.Parse(name)
+}
+
+// compareRevisions compares two revisions part by part, numerically where
+// both parts are numbers. No revision sorts before any revision.
+func compareRevisions(a, b string) int {
+	ap, bp := strings.Split(a, "."), strings.Split(b, ".")
+	if a == "" {
+		ap = nil
+	}
+	if b == "" {
+		bp = nil
+	}
+	for i := range max(len(ap), len(bp)) {
+		if i >= len(ap) {
+			return -1
+		}
+		if i >= len(bp) {
+			return 1
+		}
+		an, aErr := strconv.Atoi(ap[i])
+		bn, bErr := strconv.Atoi(bp[i])
+		switch {
+		case aErr == nil && bErr == nil:
+			if an != bn {
+				return cmp.Compare(an, bn)
+			}
+		case ap[i] != bp[i]:
+			return strings.Compare(ap[i], bp[i])
+		}
+	}
+	return 0
+}
@@ -0,0 +1,104 @@
+package onegame
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/sargunv/rom-tools/lib/core"
+)
+
+func TestParseName(t *testing.T) {
+	tests := []struct {
+		name string
+		want NameInfo
+	}{
+		{
+			name: "Super Mario World (USA)",
+			want: NameInfo{Title: "Super Mario World", Regions: []core.Region{core.RegionUSA}},
+		},
+		{
+			name: "Legend of Zelda, The - A Link to the Past (Europe) (En,Fr,De) (Rev 1)",
+			want: NameInfo{
+				Title:     "Legend of Zelda, The - A Link to the Past",
+				Regions:   []core.Region{core.RegionEurope},
+				Languages: []string{"en", "fr", "de"},
+				Revision:  "1",
+			},
+		},
+		{
+			name: "Tetris (Japan, USA) (En) (v1.1)",
+			want: NameInfo{
+				Title:     "Tetris",
+				Regions:   []core.Region{core.RegionJapan, core.RegionUSA},
+				Languages: []string{"en"},
+				Revision:  "1.1",
+			},
+		},
+		{
+			name: "Star Fox 2 (Japan) (Beta) (1995-06-20)",
+			want: NameInfo{
+				Title:      "Star Fox 2",
+				Regions:    []core.Region{core.RegionJapan},
+				Prerelease: true,
+				Tags:       []string{"Beta", "1995-06-20"},
+			},
+		},
+		{
+			name: "Game (Brazil) (Pt-BR,Es) (Alt 1) [b]",
+			want: NameInfo{
+				Title:     "Game",
+				Regions:   []core.Region{core.RegionBrazil},
+				Languages: []string{"pt-br", "es"},
+				Tags:      []string{"Alt 1", "b"},
+			},
+		},
+		{
+			name: "Homebrew Game",
+			want: NameInfo{Title: "Homebrew Game", Regions: []core.Region{}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseName(tt.name)
+			if got.Title != tt.want.Title {
+				t.Errorf("Title = %q, want %q", got.Title, tt.want.Title)
+			}
+			if !slices.Equal(got.Regions, tt.want.Regions) {
+				t.Errorf("Regions = %v, want %v", got.Regions, tt.want.Regions)
+			}
+			if !slices.Equal(got.Languages, tt.want.Languages) {
+				t.Errorf("Languages = %v, want %v", got.Languages, tt.want.Languages)
+			}
+			if got.Revision != tt.want.Revision {
+				t.Errorf("Revision = %q, want %q", got.Revision, tt.want.Revision)
+			}
+			if got.Prerelease != tt.want.Prerelease {
+				t.Errorf("Prerelease = %v, want %v", got.Prerelease, tt.want.Prerelease)
+			}
+			if !slices.Equal(got.Tags, tt.want.Tags) {
+				t.Errorf("Tags = %v, want %v", got.Tags, tt.want.Tags)
+			}
+		})
+	}
+}
+
+func TestCompareRevisions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "1", -1},
+		{"1", "2", -1},
+		{"10", "9", 1},
+		{"1.1", "1.02", -1},
+		{"1.1", "1.1.1", -1},
+		{"A", "B", -1},
+		{"B", "B", 0},
+	}
+	for _, tt := range tests {
+		if got := compareRevisions(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareRevisions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package onegame
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/sargunv/rom-tools/lib/core"
+	"github.com/sargunv/rom-tools/lib/datfile"
+)
+
+// Options controls which entry is preferred for each game.
+type Options struct {
+	// Regions in order of preference. A region also matches entries for the
+	// regions above and below it in the hierarchy, after exact matches: with
+	// USA preferred, a World release beats a Japan one, and with Europe
+	// preferred, a Germany release beats a USA one.
+	Regions []core.Region
+
+	// Languages in order of preference, as lowercase codes like "en". Entries
+	// without a language tag are taken to be in the main language of their
+	// regions.
+	Languages []string
+}
+
+// Group is a game and every DAT entry for it.
+type Group struct {
+	Name     string          `json:"name"`     // parent entry name, or title when grouping by title
+	Selected *datfile.Game   `json:"selected"` // the preferred entry
+	Games    []*datfile.Game `json:"games"`    // every entry, most preferred first
+}
+
+// regionLanguages are the main languages of regions, for entries without a
+// language tag.
+var regionLanguages = map[core.Region]string{
+	core.RegionUSA:         "en",
+	core.RegionCanada:      "en",
+	core.RegionUK:          "en",
+	core.RegionAustralia:   "en",
+	core.RegionNewZealand:  "en",
+	core.RegionWorld:       "en",
+	core.RegionEurope:      "en",
+	core.RegionJapan:       "ja",
+	core.RegionGermany:     "de",
+	core.RegionFrance:      "fr",
+	core.RegionSpain:       "es",
+	core.RegionMexico:      "es",
+	core.RegionItaly:       "it",
+	core.RegionNetherlands: "nl",
+	core.RegionSweden:      "sv",
+	core.RegionDenmark:     "da",
+	core.RegionFinland:     "fi",
+	core.RegionNorway:      "no",
+	core.RegionPortugal:    "pt",
+	core.RegionBrazil:      "pt",
+	core.RegionPoland:      "pl",
+	core.RegionRussia:      "ru",
+	core.RegionKorea:       "ko",
+	core.RegionChina:       "zh",
+	core.RegionTaiwan:      "zh",
+}
+
+// candidate is a DAT entry with its parsed name and ranks.
+type candidate struct {
+	game     *datfile.Game
+	info     NameInfo
+	region   [2]int // preference index, then distance in the region hierarchy
+	language int    // preference index
+}
+
+// Select groups the entries of dat into games and picks the preferred entry
+// for each. Entries are grouped by their parent (following cloneof, or
+// No-Intro's cloneofid); if the DAT has no parent/clone information, entries
+// are grouped by title instead.
+//
+// Within a group, entries are preferred in this order: good dumps over bad
+// dumps, then final releases over prereleases, then by region, then by
+// language, then newer revisions, then entries with fewer other tags (e.g.
+// no "Alt"), then parents over clones.
+// Groups are returned in the order of their first entry in the DAT.
+func Select(dat *datfile.Datafile, opts Options) []Group {
+	groupOf := parentGroups(dat)
+	if groupOf == nil {
+		groupOf = titleGroups(dat)
+	}
+
+	var names []string
+	members := make(map[string][]*candidate)
+	for i := range dat.Games {
+		game := &dat.Games[i]
+		name := groupOf[game]
+		if _, ok := members[name]; !ok {
+			names = append(names, name)
+		}
+		members[name] = append(members[name], newCandidate(game, opts))
+	}
+
+	groups := make([]Group, 0, len(names))
+	for _, name := range names {
+		candidates := members[name]
+		slices.SortStableFunc(candidates, compareCandidates)
+		group := Group{Name: name}
+		for _, c := range candidates {
+			group.Games = append(group.Games, c.game)
+		}
+		group.Selected = group.Games[0]
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+// parentGroups maps each game to the name of its root parent. Returns nil
+// if no game in dat is a clone.
+func parentGroups(dat *datfile.Datafile) map[*datfile.Game]string {
+	byName := make(map[string]*datfile.Game, len(dat.Games))
+	byID := make(map[string]*datfile.Game)
+	hasClones := false
+	for i := range dat.Games {
+		game := &dat.Games[i]
+		if _, ok := byName[game.Name]; !ok {
+			byName[game.Name] = game
+		}
+		if game.ID != "" {
+			byID[game.ID] = game
+		}
+		if game.CloneOf != "" || game.CloneOfID != "" {
+			hasClones = true
+		}
+	}
+	if !hasClones {
+		return nil
+	}
+
+	parent := func(game *datfile.Game) *datfile.Game {
+		if p, ok := byName[game.CloneOf]; ok {
+			return p
+		}
+		return byID[game.CloneOfID]
+	}
+
+	groupOf := make(map[*datfile.Game]string, len(dat.Games))
+	for i := range dat.Games {
+		root := &dat.Games[i]
+		// Bounded, in case of a cloneof cycle
+		for range len(dat.Games) {
+			p := parent(root)
+			if p == nil || p == root {
+				break
+			}
+			root = p
+		}
+		groupOf[&dat.Games[i]] = root.Name
+	}
+	return groupOf
+}
+
+// titleGroups maps each game to its title.
+func titleGroups(dat *datfile.Datafile) map[*datfile.Game]string {
+	groupOf := make(map[*datfile.Game]string, len(dat.Games))
+	for i := range dat.Games {
+		groupOf[&dat.Games[i]] = ParseName(dat.Games[i].Name).Title
+	}
+	return groupOf
+}
+
+func newCandidate(game *datfile.Game, opts Options) *candidate {
+	c := &candidate{game: game, info: ParseName(game.Name)}
+	c.region = regionRank(c.info.Regions, opts.Regions)
+
+	languages := c.info.Languages
+	if languages == nil {
+		for _, r := range c.info.Regions {
+			if lang, ok := regionLanguages[r]; ok {
+				languages = append(languages, lang)
+			}
+		}
+	}
+	c.language = languageRank(languages, opts.Languages)
+	return c
+}
+
+// regionRank finds the most preferred region that regions match, and how
+// far apart the two are in the region hierarchy. Returns len(prefs) if no
+// region matches.
+func regionRank(regions, prefs []core.Region) [2]int {
+	for i, pref := range prefs {
+		best := -1
+		for _, r := range regions {
+			dist := -1
+			if r == pref {
+				dist = 0
+			} else if ok, d := r.IsAncestorOf(pref); ok {
+				dist = d
+			} else if ok, d := r.IsDescendantOf(pref); ok {
+				dist = d
+			}
+			if dist >= 0 && (best < 0 || dist < best) {
+				best = dist
+			}
+		}
+		if best >= 0 {
+			return [2]int{i, best}
+		}
+	}
+	return [2]int{len(prefs), 0}
+}
+
+// languageRank finds the most preferred language in languages. Returns
+// len(prefs) if none is preferred. Variants like "pt-br" match their base
+// language too.
+func languageRank(languages, prefs []string) int {
+	for i, pref := range prefs {
+		for _, lang := range languages {
+			if lang == pref || (len(lang) > 2 && lang[:2] == pref) {
+				return i
+			}
+		}
+	}
+	return len(prefs)
+}
+
+// compareCandidates orders candidates most preferred first.
+func compareCandidates(a, b *candidate) int {
+	if a.info.BadDump != b.info.BadDump {
+		if a.info.BadDump {
+			return 1
+		}
+		return -1
+	}
+	if a.info.Prerelease != b.info.Prerelease {
+		if a.info.Prerelease {
+			return 1
+		}
+		return -1
+	}
+	if c := cmp.Compare(a.region[0], b.region[0]); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(a.region[1], b.region[1]); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(a.language, b.language); c != 0 {
+		return c
+	}
+	if c := compareRevisions(a.info.Revision, b.info.Revision); c != 0 {
+		return -c
+	}
+	if c := cmp.Compare(len(a.info.Tags), len(b.info.Tags)); c != 0 {
+		return c
+	}
+	aClone := a.game.CloneOf != "" || a.game.CloneOfID != ""
+	bClone := b.game.CloneOf != "" || b.game.CloneOfID != ""
+	if aClone != bClone {
+		if aClone {
+			return 1
+		}
+		return -1
+	}
+	return 0
+}
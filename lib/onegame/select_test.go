@@ -0,0 +1,165 @@
+package onegame
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sargunv/rom-tools/lib/core"
+	"github.com/sargunv/rom-tools/lib/datfile"
+)
+
+func loadTestDAT(t *testing.T, games string) *datfile.Datafile {
+	t.Helper()
+	dat, err := datfile.ParseReader(strings.NewReader(`<?xml version="1.0"?><datafile><header><name>Test</name></header>` + games + `</datafile>`))
+	if err != nil {
+		t.Fatalf("ParseReader() error = %v", err)
+	}
+	return dat
+}
+
+// selected returns the names of the selected entries by group name.
+func selected(groups []Group) map[string]string {
+	out := make(map[string]string, len(groups))
+	for _, g := range groups {
+		out[g.Name] = g.Selected.Name
+	}
+	return out
+}
+
+const testParentCloneDAT = `
+	<game name="Tetris (World) (Rev 1)"><description>Tetris</description></game>
+	<game name="Tetris (Japan) (En)" cloneof="Tetris (World) (Rev 1)"><description>Tetris</description></game>
+	<game name="Tetris (World)" cloneof="Tetris (World) (Rev 1)"><description>Tetris</description></game>
+	<game name="Mario (Japan)"><description>Mario</description></game>
+	<game name="Mario (USA)" cloneof="Mario (Japan)"><description>Mario</description></game>
+	<game name="Mario (Germany) (De)" cloneof="Mario (Japan)"><description>Mario</description></game>
+	<game name="Mario (USA) (Beta)" cloneof="Mario (Japan)"><description>Mario</description></game>
+	<game name="Zelda (France)"><description>Zelda</description></game>
+	<game name="Zelda (Europe) (En,Fr,De)" cloneof="Zelda (France)"><description>Zelda</description></game>`
+
+func TestSelect_Regions(t *testing.T) {
+	dat := loadTestDAT(t, testParentCloneDAT)
+
+	tests := []struct {
+		name string
+		opts Options
+		want map[string]string
+	}{
+		{
+			name: "usa first",
+			opts: Options{Regions: []core.Region{core.RegionUSA, core.RegionEurope, core.RegionJapan}},
+			want: map[string]string{
+				"Tetris (World) (Rev 1)": "Tetris (World) (Rev 1)",
+				"Mario (Japan)":          "Mario (USA)",
+				"Zelda (France)":         "Zelda (Europe) (En,Fr,De)",
+			},
+		},
+		{
+			name: "japan first",
+			opts: Options{Regions: []core.Region{core.RegionJapan, core.RegionUSA}},
+			want: map[string]string{
+				"Tetris (World) (Rev 1)": "Tetris (Japan) (En)",
+				"Mario (Japan)":          "Mario (Japan)",
+				"Zelda (France)":         "Zelda (France)",
+			},
+		},
+		{
+			name: "germany first",
+			opts: Options{Regions: []core.Region{core.RegionGermany}},
+			want: map[string]string{
+				"Tetris (World) (Rev 1)": "Tetris (World) (Rev 1)",
+				"Mario (Japan)":          "Mario (Germany) (De)",
+				"Zelda (France)":         "Zelda (Europe) (En,Fr,De)",
+			},
+		},
+		{
+			name: "europe first",
+			opts: Options{Regions: []core.Region{core.RegionEurope}},
+			want: map[string]string{
+				"Tetris (World) (Rev 1)": "Tetris (World) (Rev 1)",
+				"Mario (Japan)":          "Mario (Germany) (De)",
+				"Zelda (France)":         "Zelda (Europe) (En,Fr,De)",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			groups := Select(dat, tt.opts)
+			if len(groups) != len(tt.want) {
+				t.Fatalf("got %d groups, want %d", len(groups), len(tt.want))
+			}
+			got := selected(groups)
+			for group, want := range tt.want {
+				if got[group] != want {
+					t.Errorf("group %q selected %q, want %q", group, got[group], want)
+				}
+			}
+		})
+	}
+}
+
+func TestSelect_Languages(t *testing.T) {
+	dat := loadTestDAT(t, testParentCloneDAT)
+
+	groups := Select(dat, Options{Languages: []string{"de"}})
+	got := selected(groups)
+	if got["Mario (Japan)"] != "Mario (Germany) (De)" {
+		t.Errorf("Mario selected %q, want the German release", got["Mario (Japan)"])
+	}
+
+	// Entries without a language tag are in the language of their region
+	groups = Select(dat, Options{Languages: []string{"ja"}})
+	got = selected(groups)
+	if got["Tetris (World) (Rev 1)"] != "Tetris (World) (Rev 1)" {
+		t.Errorf("Tetris selected %q, want the parent, as the Japan release is in English", got["Tetris (World) (Rev 1)"])
+	}
+}
+
+func TestSelect_Order(t *testing.T) {
+	dat := loadTestDAT(t, testParentCloneDAT)
+
+	groups := Select(dat, Options{Regions: []core.Region{core.RegionUSA}})
+	if groups[1].Name != "Mario (Japan)" {
+		t.Fatalf("groups[1] = %q, want Mario", groups[1].Name)
+	}
+	var names []string
+	for _, g := range groups[1].Games {
+		names = append(names, g.Name)
+	}
+	want := []string{"Mario (USA)", "Mario (Japan)", "Mario (Germany) (De)", "Mario (USA) (Beta)"}
+	if strings.Join(names, "|") != strings.Join(want, "|") {
+		t.Errorf("Games = %v, want %v", names, want)
+	}
+}
+
+func TestSelect_BadDumps(t *testing.T) {
+	dat := loadTestDAT(t, `
+		<game name="Pong (USA) [b]"><description>Pong</description></game>
+		<game name="Pong (Japan) (Beta)"><description>Pong</description></game>`)
+
+	groups := Select(dat, Options{Regions: []core.Region{core.RegionUSA}})
+	if got := selected(groups)["Pong"]; got != "Pong (Japan) (Beta)" {
+		t.Errorf("Pong selected %q, want the good dump", got)
+	}
+}
+
+func TestSelect_ByTitle(t *testing.T) {
+	dat := loadTestDAT(t, `
+		<game name="Pong (USA)"><description>Pong</description></game>
+		<game name="Pong (Japan)"><description>Pong</description></game>
+		<game name="Pong (USA) (Rev 2)"><description>Pong</description></game>
+		<game name="Breakout (Europe)"><description>Breakout</description></game>`)
+
+	groups := Select(dat, Options{Regions: []core.Region{core.RegionUSA}})
+	got := selected(groups)
+	if len(groups) != 2 {
+		t.Fatalf("got %d groups, want 2", len(groups))
+	}
+	if got["Pong"] != "Pong (USA) (Rev 2)" {
+		t.Errorf("Pong selected %q, want the latest USA revision", got["Pong"])
+	}
+	if got["Breakout"] != "Breakout (Europe)" {
+		t.Errorf("Breakout selected %q, want its only entry", got["Breakout"])
+	}
+}
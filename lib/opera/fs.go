@@ -0,0 +1,159 @@
+package opera
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+	"time"
+)
+
+// FS returns the image's filesystem as an fs.FS, which also implements
+// fs.ReadDirFS. Names are resolved as by OpenFile: exactly, then
+// case-insensitively. Opera records no modification times, so they're zero.
+func (r *Reader) FS() fs.FS {
+	return readerFS{r}
+}
+
+// Walk walks the image's filesystem, calling fn for each file and directory
+// as fs.WalkDir does.
+func (r *Reader) Walk(fn fs.WalkDirFunc) error {
+	return fs.WalkDir(r.FS(), ".", fn)
+}
+
+// readerFS adapts a Reader to fs.FS.
+type readerFS struct {
+	r *Reader
+}
+
+// Open implements fs.FS.
+func (f readerFS) Open(name string) (fs.File, error) {
+	record, err := f.resolve("open", name)
+	if err != nil {
+		return nil, err
+	}
+	info := fileInfo{name: path.Base(name), record: record}
+	if record.isDir {
+		return &dirFile{r: f.r, info: info}, nil
+	}
+	reader, err := f.r.open(record)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	return &file{SectionReader: reader, info: info}, nil
+}
+
+// ReadDir implements fs.ReadDirFS.
+func (f readerFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	record, err := f.resolve("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	if !record.isDir {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: errors.New("not a directory")}
+	}
+	entries, err := f.r.dirEntries(record)
+	if err != nil {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: err}
+	}
+	slices.SortFunc(entries, func(a, b fs.DirEntry) int {
+		return strings.Compare(a.Name(), b.Name())
+	})
+	return entries, nil
+}
+
+// resolve finds the record for an fs.FS name.
+func (f readerFS) resolve(op, name string) (dirRecord, error) {
+	if !fs.ValidPath(name) {
+		return dirRecord{}, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	if name == "." {
+		name = ""
+	}
+	record, err := f.r.lookup(name)
+	if err != nil {
+		return dirRecord{}, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	return record, nil
+}
+
+// dirEntries lists a directory's records as fs.DirEntry values.
+func (r *Reader) dirEntries(dir dirRecord) ([]fs.DirEntry, error) {
+	records, err := r.readDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]fs.DirEntry, len(records))
+	for i, record := range records {
+		entries[i] = fs.FileInfoToDirEntry(fileInfo{name: record.name, record: record})
+	}
+	return entries, nil
+}
+
+// fileInfo implements fs.FileInfo for a directory record.
+type fileInfo struct {
+	name   string
+	record dirRecord
+}
+
+func (fi fileInfo) Name() string       { return fi.name }
+func (fi fileInfo) Size() int64        { return fi.record.size }
+func (fi fileInfo) ModTime() time.Time { return time.Time{} }
+func (fi fileInfo) IsDir() bool        { return fi.record.isDir }
+func (fi fileInfo) Sys() any           { return nil }
+
+func (fi fileInfo) Mode() fs.FileMode {
+	if fi.record.isDir {
+		return fs.ModeDir | 0o555
+	}
+	return 0o444
+}
+
+// file is an open regular file.
+type file struct {
+	*io.SectionReader
+	info fileInfo
+}
+
+func (f *file) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *file) Close() error               { return nil }
+
+// dirFile is an open directory, implementing fs.ReadDirFile.
+type dirFile struct {
+	r       *Reader
+	info    fileInfo
+	entries []fs.DirEntry // nil until first read
+	offset  int
+}
+
+func (d *dirFile) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *dirFile) Close() error               { return nil }
+
+func (d *dirFile) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.name, Err: errors.New("is a directory")}
+}
+
+// ReadDir implements fs.ReadDirFile.
+func (d *dirFile) ReadDir(n int) ([]fs.DirEntry, error) {
+	if d.entries == nil {
+		entries, err := d.r.dirEntries(d.info.record)
+		if err != nil {
+			return nil, &fs.PathError{Op: "readdir", Path: d.info.name, Err: err}
+		}
+		d.entries = entries
+	}
+
+	remaining := d.entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return remaining, nil
+	}
+	if len(remaining) == 0 {
+		return nil, io.EOF
+	}
+	n = min(n, len(remaining))
+	d.offset += n
+	return remaining[:n], nil
+}
@@ -0,0 +1,58 @@
+package opera
+
+import (
+	"bytes"
+	"io/fs"
+	"slices"
+	"testing"
+	"testing/fstest"
+)
+
+func TestReader_FS(t *testing.T) {
+	data := toRaw(createOpera())
+	reader, err := NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+
+	if err := fstest.TestFS(reader.FS(), "ReadMe", "LaunchMe", "System/Kernel"); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := fs.ReadFile(reader.FS(), "system/kernel")
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if string(content) != "kernel" {
+		t.Errorf("ReadFile = %q, want %q", content, "kernel")
+	}
+
+	if _, err := reader.FS().Open("/System"); err == nil {
+		t.Error("Open of an invalid path expected error, got nil")
+	}
+}
+
+func TestReader_Walk(t *testing.T) {
+	data := createOpera()
+	reader, err := NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+
+	var paths []string
+	err = reader.Walk(func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		paths = append(paths, path)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Walk failed: %v", err)
+	}
+
+	want := []string{".", "LaunchMe", "ReadMe", "System", "System/Kernel"}
+	if !slices.Equal(paths, want) {
+		t.Errorf("Walk paths = %q, want %q", paths, want)
+	}
+}
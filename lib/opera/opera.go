@@ -0,0 +1,325 @@
+// Package opera provides support for reading Opera filesystem images, the
+// filesystem of 3DO discs.
+//
+// Handles both cooked (2048 bytes/sector) and raw MODE1/2352 images of the
+// data track. Only the first avatar (copy) of each file and directory is
+// read; the others are redundant copies for faster seeking.
+//
+// The API mirrors iso9660: use NewReader to open an image, then access files
+// via OpenFile or list directories via ReadDir. FS exposes the filesystem as
+// an io/fs.FS, and Walk enumerates it.
+//
+// Opera layout (all integers big-endian):
+//   - Block 0: Volume label (record type 1, five 0x5A sync bytes, version 1),
+//     with the volume name, block size, and root directory avatars
+//   - Directories: chains of blocks, each with a 20-byte header (next and
+//     previous block indexes, first free byte, first entry offset) followed
+//     by directory records
+//   - Directory records: flags, type, size, a 32-byte name, and the block
+//     numbers of each avatar
+//
+// Block numbers are relative to the start of the volume, in units of the
+// volume's block size.
+package opera
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/sargunv/rom-tools/internal/util"
+)
+
+const (
+	// Volume label
+	labelSize           = 0x84
+	labelRecordType     = 0x00
+	labelSync           = 0x01
+	labelVersion        = 0x06
+	labelCommentary     = 0x08
+	labelIdentifier     = 0x28
+	labelUniqueID       = 0x48
+	labelBlockSize      = 0x4C
+	labelBlockCount     = 0x50
+	labelRootBlockCount = 0x58
+	labelRootBlockSize  = 0x5C
+	labelRootAvatars    = 0x64
+	labelStringLen      = 32
+
+	recordTypeLabel = 1
+	volumeVersion   = 1
+
+	// Directory block header
+	dirNextBlock   = 0x00
+	dirFirstFree   = 0x0C
+	dirFirstEntry  = 0x10
+	dirHeaderSize  = 0x14
+	noBlock        = -1
+	maxDirBlocks   = 4096
+	maxBlockSize   = 64 * 1024
+	minBlockSize   = 512
+	dirEntryHeader = 0x48
+
+	// Directory record
+	entryFlags      = 0x00
+	entryBlockSize  = 0x0C
+	entryByteCount  = 0x10
+	entryBlockCount = 0x14
+	entryName       = 0x20
+	entryLastAvatar = 0x40
+	entryAvatars    = 0x44
+	maxAvatars      = 16
+
+	flagDirectory   = 0x00000001
+	flagLastInBlock = 0x40000000
+	flagLastInDir   = 0x80000000
+)
+
+// labelSyncBytes is the volume label's sync pattern.
+var labelSyncBytes = bytes.Repeat([]byte{0x5A}, 5)
+
+// Label is the volume label at the start of an Opera filesystem.
+type Label struct {
+	// Commentary is free-form volume commentary.
+	Commentary string `json:"commentary,omitempty"`
+	// Identifier is the volume name (e.g., "CD-ROM").
+	Identifier string `json:"identifier,omitempty"`
+	// UniqueID is the volume's unique identifier.
+	UniqueID uint32 `json:"unique_id"`
+	// BlockSize is the size of a volume block in bytes (usually 2048).
+	BlockSize uint32 `json:"block_size"`
+	// BlockCount is the number of blocks in the volume.
+	BlockCount uint32 `json:"block_count"`
+}
+
+// DirEntry is an entry in a directory listing.
+type DirEntry struct {
+	Name  string `json:"name"`
+	Size  int64  `json:"size"`
+	IsDir bool   `json:"is_dir"`
+}
+
+// dirRecord is a directory record's location and name.
+type dirRecord struct {
+	name       string
+	isDir      bool
+	size       int64
+	block      uint32 // first avatar
+	blockSize  uint32
+	blockCount uint32
+}
+
+// Reader provides access to an Opera filesystem image.
+type Reader struct {
+	r     io.ReaderAt
+	size  int64
+	label Label
+	root  dirRecord
+}
+
+// NewReader opens an Opera image and validates its volume label.
+// Automatically detects the sector format (cooked or raw MODE1).
+func NewReader(r io.ReaderAt, size int64) (*Reader, error) {
+	if size < labelSize {
+		return nil, fmt.Errorf("file too small for Opera volume label: %d bytes", size)
+	}
+
+	var data []byte
+	for _, raw := range []bool{false, true} {
+		reader, readerSize := r, size
+		if raw {
+			sr := newRawReader(r, size)
+			reader, readerSize = sr, sr.Size()
+		}
+		if readerSize < labelSize {
+			continue
+		}
+		data = make([]byte, labelSize)
+		if _, err := reader.ReadAt(data, 0); err != nil {
+			return nil, fmt.Errorf("failed to read Opera volume label: %w", err)
+		}
+		if isLabel(data) {
+			r, size = reader, readerSize
+			break
+		}
+		data = nil
+	}
+	if data == nil {
+		return nil, fmt.Errorf("not a valid Opera image: no volume label found")
+	}
+
+	label := Label{
+		Commentary: util.ExtractASCII(data[labelCommentary : labelCommentary+labelStringLen]),
+		Identifier: util.ExtractASCII(data[labelIdentifier : labelIdentifier+labelStringLen]),
+		UniqueID:   binary.BigEndian.Uint32(data[labelUniqueID:]),
+		BlockSize:  binary.BigEndian.Uint32(data[labelBlockSize:]),
+		BlockCount: binary.BigEndian.Uint32(data[labelBlockCount:]),
+	}
+	if label.BlockSize < minBlockSize || label.BlockSize > maxBlockSize {
+		return nil, fmt.Errorf("not a valid Opera image: block size %d", label.BlockSize)
+	}
+
+	root := dirRecord{
+		isDir:      true,
+		block:      binary.BigEndian.Uint32(data[labelRootAvatars:]),
+		blockSize:  binary.BigEndian.Uint32(data[labelRootBlockSize:]),
+		blockCount: binary.BigEndian.Uint32(data[labelRootBlockCount:]),
+	}
+	return &Reader{r: r, size: size, label: label, root: root}, nil
+}
+
+// isLabel reports whether data starts with an Opera volume label.
+func isLabel(data []byte) bool {
+	return data[labelRecordType] == recordTypeLabel &&
+		bytes.Equal(data[labelSync:labelSync+len(labelSyncBytes)], labelSyncBytes) &&
+		data[labelVersion] == volumeVersion
+}
+
+// Label returns the volume label.
+func (r *Reader) Label() Label {
+	return r.label
+}
+
+// OpenFile opens a file by path (case-insensitive) and returns a reader for its contents.
+// Supports subdirectory paths like "System/Kernel/kernel".
+func (r *Reader) OpenFile(path string) (io.ReaderAt, int64, error) {
+	record, err := r.lookup(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	if record.isDir {
+		return nil, 0, fmt.Errorf("%q is a directory, not a file", record.name)
+	}
+	reader, err := r.open(record)
+	if err != nil {
+		return nil, 0, err
+	}
+	return reader, record.size, nil
+}
+
+// open returns a reader for a file record's contents.
+func (r *Reader) open(record dirRecord) (*io.SectionReader, error) {
+	offset := int64(record.block) * int64(r.label.BlockSize)
+	if offset+record.size > r.size {
+		return nil, fmt.Errorf("%q extends beyond image: ends at %d, image size %d", record.name, offset+record.size, r.size)
+	}
+	return io.NewSectionReader(r.r, offset, record.size), nil
+}
+
+// ReadDir lists a directory by path (case-insensitive). An empty path or "/"
+// lists the root directory.
+func (r *Reader) ReadDir(path string) ([]DirEntry, error) {
+	record, err := r.lookup(path)
+	if err != nil {
+		return nil, err
+	}
+	if !record.isDir {
+		return nil, fmt.Errorf("%q is not a directory", record.name)
+	}
+	records, err := r.readDir(record)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]DirEntry, len(records))
+	for i, record := range records {
+		entries[i] = DirEntry{Name: record.name, Size: record.size, IsDir: record.isDir}
+	}
+	return entries, nil
+}
+
+// lookup resolves a path to its directory record. An empty path or "/" is
+// the root directory.
+func (r *Reader) lookup(path string) (dirRecord, error) {
+	dir := r.root
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return dir, nil
+	}
+
+	for _, part := range strings.Split(path, "/") {
+		// Intermediate components must be directories
+		if !dir.isDir {
+			return dirRecord{}, fmt.Errorf("%q is not a directory", dir.name)
+		}
+
+		record, err := r.findEntry(dir, part)
+		if err != nil {
+			return dirRecord{}, fmt.Errorf("path component %q not found: %w", part, err)
+		}
+		dir = record
+	}
+	return dir, nil
+}
+
+// findEntry searches a directory for an entry by name, preferring an exact
+// match over a case-insensitive one.
+func (r *Reader) findEntry(dir dirRecord, name string) (dirRecord, error) {
+	records, err := r.readDir(dir)
+	if err != nil {
+		return dirRecord{}, err
+	}
+	for _, record := range records {
+		if record.name == name {
+			return record, nil
+		}
+	}
+	for _, record := range records {
+		if strings.EqualFold(record.name, name) {
+			return record, nil
+		}
+	}
+	return dirRecord{}, fmt.Errorf("entry not found: %s", name)
+}
+
+// readDir reads a directory's records by following its chain of blocks.
+func (r *Reader) readDir(dir dirRecord) ([]dirRecord, error) {
+	if dir.blockSize < dirHeaderSize || dir.blockSize > maxBlockSize {
+		return nil, fmt.Errorf("invalid directory block size: %d", dir.blockSize)
+	}
+
+	var records []dirRecord
+	block := make([]byte, dir.blockSize)
+	index := int32(0)
+	for visited := 0; index != noBlock; visited++ {
+		if index < 0 || uint32(index) >= dir.blockCount || visited >= maxDirBlocks {
+			return nil, fmt.Errorf("invalid directory block index: %d", index)
+		}
+		offset := int64(dir.block)*int64(r.label.BlockSize) + int64(index)*int64(dir.blockSize)
+		if _, err := r.r.ReadAt(block, offset); err != nil {
+			return nil, fmt.Errorf("failed to read directory block: %w", err)
+		}
+
+		index = int32(binary.BigEndian.Uint32(block[dirNextBlock:]))
+		end := min(binary.BigEndian.Uint32(block[dirFirstFree:]), dir.blockSize)
+		pos := binary.BigEndian.Uint32(block[dirFirstEntry:])
+		for pos >= dirHeaderSize && pos+dirEntryHeader <= end {
+			entry := block[pos:]
+			flags := binary.BigEndian.Uint32(entry[entryFlags:])
+			lastAvatar := binary.BigEndian.Uint32(entry[entryLastAvatar:])
+			if lastAvatar >= maxAvatars {
+				return nil, fmt.Errorf("invalid directory record: %d avatars", lastAvatar+1)
+			}
+
+			records = append(records, dirRecord{
+				name:       util.ExtractASCII(entry[entryName : entryName+labelStringLen]),
+				isDir:      flags&flagDirectory != 0,
+				size:       int64(binary.BigEndian.Uint32(entry[entryByteCount:])),
+				block:      binary.BigEndian.Uint32(entry[entryAvatars:]),
+				blockSize:  binary.BigEndian.Uint32(entry[entryBlockSize:]),
+				blockCount: binary.BigEndian.Uint32(entry[entryBlockCount:]),
+			})
+
+			if flags&flagLastInDir != 0 {
+				return records, nil
+			}
+			if flags&flagLastInBlock != 0 {
+				break
+			}
+			pos += dirEntryHeader + 4*lastAvatar
+		}
+	}
+	return records, nil
+}
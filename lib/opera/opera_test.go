@@ -0,0 +1,192 @@
+package opera
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+const testBlocks = 7
+
+// putEntry writes a directory record at pos with the given avatars and
+// returns the position of the next record.
+func putEntry(block []byte, pos int, flags uint32, name string, size int, avatars ...uint32) int {
+	e := block[pos:]
+	binary.BigEndian.PutUint32(e[entryFlags:], flags)
+	binary.BigEndian.PutUint32(e[entryBlockSize:], sectorSize2048)
+	binary.BigEndian.PutUint32(e[entryByteCount:], uint32(size))
+	binary.BigEndian.PutUint32(e[entryBlockCount:], uint32((size+sectorSize2048-1)/sectorSize2048))
+	copy(e[entryName:], name)
+	binary.BigEndian.PutUint32(e[entryLastAvatar:], uint32(len(avatars)-1))
+	for i, avatar := range avatars {
+		binary.BigEndian.PutUint32(e[entryAvatars+4*i:], avatar)
+	}
+	return pos + dirEntryHeader + 4*(len(avatars)-1)
+}
+
+// putDirHeader writes a directory block header.
+func putDirHeader(block []byte, next int32, firstFree int) {
+	binary.BigEndian.PutUint32(block[dirNextBlock:], uint32(next))
+	binary.BigEndian.PutUint32(block[dirFirstFree:], uint32(firstFree))
+	binary.BigEndian.PutUint32(block[dirFirstEntry:], dirHeaderSize)
+}
+
+// createOpera creates a cooked Opera image:
+//
+//	ReadMe (two avatars)
+//	LaunchMe
+//	System/Kernel (in the root directory's second block)
+func createOpera() []byte {
+	data := make([]byte, testBlocks*sectorSize2048)
+	block := func(n int) []byte { return data[n*sectorSize2048 : (n+1)*sectorSize2048] }
+
+	label := block(0)
+	label[labelRecordType] = recordTypeLabel
+	copy(label[labelSync:], labelSyncBytes)
+	label[labelVersion] = volumeVersion
+	copy(label[labelCommentary:], "opera test volume")
+	copy(label[labelIdentifier:], "CD-ROM")
+	binary.BigEndian.PutUint32(label[labelUniqueID:], 0x1234)
+	binary.BigEndian.PutUint32(label[labelBlockSize:], sectorSize2048)
+	binary.BigEndian.PutUint32(label[labelBlockCount:], testBlocks)
+	binary.BigEndian.PutUint32(label[labelRootBlockCount:], 2)
+	binary.BigEndian.PutUint32(label[labelRootBlockSize:], sectorSize2048)
+	binary.BigEndian.PutUint32(label[labelRootAvatars:], 1)
+
+	// Root directory, blocks 1-2
+	root := block(1)
+	pos := putEntry(root, dirHeaderSize, 0x02, "ReadMe", 5, 5, 5)
+	pos = putEntry(root, pos, 0x02|flagLastInBlock, "LaunchMe", 8, 4)
+	putDirHeader(root, 1, pos)
+	root = block(2)
+	pos = putEntry(root, dirHeaderSize, 0x07|flagLastInDir, "System", sectorSize2048, 3)
+	putDirHeader(root, noBlock, pos)
+
+	system := block(3)
+	pos = putEntry(system, dirHeaderSize, 0x02|flagLastInDir, "Kernel", 6, 6)
+	putDirHeader(system, noBlock, pos)
+
+	copy(block(4), "LaunchMe")
+	copy(block(5), "hello")
+	copy(block(6), "kernel")
+	return data
+}
+
+// toRaw converts a cooked image to raw MODE1/2352 sectors.
+func toRaw(cooked []byte) []byte {
+	var raw []byte
+	for i := 0; i < len(cooked); i += sectorSize2048 {
+		sector := make([]byte, sectorSize2352)
+		copy(sector, []byte{0x00, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0x00})
+		copy(sector[mode1SectorHeader:], cooked[i:i+sectorSize2048])
+		raw = append(raw, sector...)
+	}
+	return raw
+}
+
+func TestNewReader(t *testing.T) {
+	for _, raw := range []bool{false, true} {
+		data := createOpera()
+		if raw {
+			data = toRaw(data)
+		}
+		reader, err := NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			t.Fatalf("NewReader(raw=%v) failed: %v", raw, err)
+		}
+		want := Label{Commentary: "opera test volume", Identifier: "CD-ROM", UniqueID: 0x1234, BlockSize: sectorSize2048, BlockCount: testBlocks}
+		if got := reader.Label(); got != want {
+			t.Errorf("Label() = %+v, want %+v", got, want)
+		}
+	}
+}
+
+func TestNewReader_Invalid(t *testing.T) {
+	if _, err := NewReader(bytes.NewReader(make([]byte, 100)), 100); err == nil {
+		t.Error("NewReader of a small file expected error, got nil")
+	}
+
+	data := make([]byte, 2*sectorSize2352)
+	if _, err := NewReader(bytes.NewReader(data), int64(len(data))); err == nil {
+		t.Error("NewReader without a volume label expected error, got nil")
+	}
+}
+
+func TestReader_OpenFile(t *testing.T) {
+	data := toRaw(createOpera())
+	reader, err := NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"LaunchMe", "LaunchMe"},
+		{"readme", "hello"},
+		{"/System/Kernel", "kernel"},
+	}
+	for _, tt := range tests {
+		fileReader, size, err := reader.OpenFile(tt.path)
+		if err != nil {
+			t.Errorf("OpenFile(%q) failed: %v", tt.path, err)
+			continue
+		}
+		buf := make([]byte, size)
+		if _, err := fileReader.ReadAt(buf, 0); err != nil {
+			t.Fatalf("file ReadAt failed: %v", err)
+		}
+		if string(buf) != tt.want {
+			t.Errorf("OpenFile(%q) = %q, want %q", tt.path, buf, tt.want)
+		}
+	}
+
+	for _, path := range []string{"MISSING", "System", "LaunchMe/X", ""} {
+		if _, _, err := reader.OpenFile(path); err == nil {
+			t.Errorf("OpenFile(%q) expected error, got nil", path)
+		}
+	}
+}
+
+func TestReader_ReadDir(t *testing.T) {
+	data := createOpera()
+	reader, err := NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+
+	tests := []struct {
+		path string
+		want []DirEntry
+	}{
+		{"", []DirEntry{
+			{Name: "ReadMe", Size: 5},
+			{Name: "LaunchMe", Size: 8},
+			{Name: "System", Size: sectorSize2048, IsDir: true},
+		}},
+		{"system", []DirEntry{
+			{Name: "Kernel", Size: 6},
+		}},
+	}
+	for _, tt := range tests {
+		entries, err := reader.ReadDir(tt.path)
+		if err != nil {
+			t.Errorf("ReadDir(%q) failed: %v", tt.path, err)
+			continue
+		}
+		if len(entries) != len(tt.want) {
+			t.Errorf("ReadDir(%q) = %+v, want %+v", tt.path, entries, tt.want)
+			continue
+		}
+		for i := range entries {
+			if entries[i] != tt.want[i] {
+				t.Errorf("ReadDir(%q)[%d] = %+v, want %+v", tt.path, i, entries[i], tt.want[i])
+			}
+		}
+	}
+
+	if _, err := reader.ReadDir("LaunchMe"); err == nil {
+		t.Error("ReadDir of a file expected error, got nil")
+	}
+}
@@ -0,0 +1,56 @@
+package opera
+
+import "io"
+
+// CD sector formats
+const (
+	sectorSize2048 = 2048 // Cooked sector
+	sectorSize2352 = 2352 // Raw CD sector
+
+	// For MODE1/2352, user data starts at offset 16 within each sector:
+	// 12 bytes sync + 4 bytes header = 16 bytes before data
+	mode1SectorHeader = 16
+)
+
+// rawReader wraps a raw MODE1/2352 image to read it as 2048-byte sectors.
+type rawReader struct {
+	r    io.ReaderAt
+	size int64 // logical size (in 2048-byte terms)
+}
+
+// newRawReader creates a sector-translating reader.
+func newRawReader(r io.ReaderAt, physicalSize int64) *rawReader {
+	return &rawReader{r: r, size: physicalSize / sectorSize2352 * sectorSize2048}
+}
+
+// ReadAt implements io.ReaderAt, translating logical offsets to physical.
+func (s *rawReader) ReadAt(p []byte, off int64) (int, error) {
+	if off >= s.size {
+		return 0, io.EOF
+	}
+
+	n := 0
+	for n < len(p) && off+int64(n) < s.size {
+		logicalOffset := off + int64(n)
+		sector := logicalOffset / sectorSize2048
+		offsetInSector := logicalOffset % sectorSize2048
+		physicalOffset := sector*sectorSize2352 + mode1SectorHeader + offsetInSector
+
+		bytesToRead := min(int64(len(p)-n), sectorSize2048-offsetInSector, s.size-logicalOffset)
+		bytesRead, err := s.r.ReadAt(p[n:n+int(bytesToRead)], physicalOffset)
+		n += bytesRead
+		if err != nil {
+			return n, err
+		}
+	}
+
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// Size returns the logical size (as if it were a cooked image).
+func (s *rawReader) Size() int64 {
+	return s.size
+}
@@ -0,0 +1,185 @@
+package patch
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// BPS patch format (by byuu).
+//
+// Layout:
+//
+//	Offset  Size  Description
+//	0x00    4     Magic ("BPS1")
+//	0x04    var   Source size
+//	...     var   Target size
+//	...     var   Metadata size
+//	...     n     Metadata (usually XML)
+//	...     ...   Actions
+//	-12     4     Source CRC32 (little-endian)
+//	-8      4     Target CRC32 (little-endian)
+//	-4      4     Patch CRC32 (little-endian, of everything before it)
+//
+// Each action starts with a number whose low 2 bits are the command and the
+// rest the length minus 1:
+//
+//	0  SourceRead  copy from the source at the output position
+//	1  TargetRead  copy the following bytes of the patch
+//	2  SourceCopy  copy from the source at a relative offset (a signed number)
+//	3  TargetCopy  copy from the output at a relative offset (a signed number)
+
+var bpsMagic = []byte("BPS1")
+
+const (
+	bpsSourceRead = iota
+	bpsTargetRead
+	bpsSourceCopy
+	bpsTargetCopy
+)
+
+// BPS is a parsed BPS patch.
+type BPS struct {
+	// SourceSize is the size of the ROM the patch applies to.
+	SourceSize int64 `json:"source_size"`
+	// TargetSize is the size of the patched ROM.
+	TargetSize int64 `json:"target_size"`
+	// SourceCRC32 is the CRC32 of the ROM the patch applies to.
+	SourceCRC32 uint32 `json:"source_crc32"`
+	// TargetCRC32 is the CRC32 of the patched ROM.
+	TargetCRC32 uint32 `json:"target_crc32"`
+	// Metadata is the patch's metadata, usually XML. May be empty.
+	Metadata string `json:"metadata,omitempty"`
+
+	actions []byte
+}
+
+// ParseBPS parses a BPS patch, verifying its patch CRC32.
+func ParseBPS(r io.ReaderAt, size int64) (*BPS, error) {
+	data := make([]byte, size)
+	if _, err := r.ReadAt(data, 0); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to read BPS patch: %w", err)
+	}
+	if !bytes.HasPrefix(data, bpsMagic) {
+		return nil, fmt.Errorf("not a valid BPS patch: magic mismatch")
+	}
+	sums, err := readFooter(data)
+	if err != nil {
+		return nil, fmt.Errorf("not a valid BPS patch: %w", err)
+	}
+
+	body := data[:len(data)-footerSize]
+	pos := len(bpsMagic)
+	var sizes [3]int64
+	for i := range sizes {
+		if sizes[i], err = readVarint(body, &pos); err != nil {
+			return nil, fmt.Errorf("not a valid BPS patch: %w", err)
+		}
+	}
+	if sizes[0] > maxROMSize || sizes[1] > maxROMSize {
+		return nil, fmt.Errorf("not a valid BPS patch: ROM sizes %d, %d too large", sizes[0], sizes[1])
+	}
+	metadataSize := sizes[2]
+	if metadataSize > int64(len(body)-pos) {
+		return nil, fmt.Errorf("not a valid BPS patch: metadata size %d exceeds patch", metadataSize)
+	}
+	metadata := string(body[pos : pos+int(metadataSize)])
+	pos += int(metadataSize)
+
+	return &BPS{
+		SourceSize:  sizes[0],
+		TargetSize:  sizes[1],
+		SourceCRC32: sums.source,
+		TargetCRC32: sums.target,
+		Metadata:    metadata,
+		actions:     body[pos:],
+	}, nil
+}
+
+// Apply implements Patch, checking rom and the patched ROM against the
+// patch's CRC32s.
+func (p *BPS) Apply(rom []byte) ([]byte, error) {
+	if err := checkSource(rom, p.SourceSize, p.SourceCRC32); err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, 0, p.TargetSize)
+	var sourceOffset, targetOffset int64
+	pos := 0
+	for pos < len(p.actions) {
+		start := pos
+		data, err := readVarint(p.actions, &pos)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BPS action: %w", err)
+		}
+		command := data & 3
+		length := data>>2 + 1
+		if int64(len(out))+length > p.TargetSize {
+			return nil, fmt.Errorf("invalid BPS action at 0x%X: writes past target size %d", start, p.TargetSize)
+		}
+
+		switch command {
+		case bpsSourceRead:
+			outPos := int64(len(out))
+			if outPos+length > int64(len(rom)) {
+				return nil, fmt.Errorf("invalid BPS action at 0x%X: reads past end of source", start)
+			}
+			out = append(out, rom[outPos:outPos+length]...)
+
+		case bpsTargetRead:
+			if int64(pos)+length > int64(len(p.actions)) {
+				return nil, fmt.Errorf("invalid BPS action at 0x%X: reads past end of patch", start)
+			}
+			out = append(out, p.actions[pos:pos+int(length)]...)
+			pos += int(length)
+
+		case bpsSourceCopy:
+			delta, err := readSignedVarint(p.actions, &pos)
+			if err != nil {
+				return nil, fmt.Errorf("invalid BPS action: %w", err)
+			}
+			sourceOffset += delta
+			if sourceOffset < 0 || sourceOffset+length > int64(len(rom)) {
+				return nil, fmt.Errorf("invalid BPS action at 0x%X: reads outside source", start)
+			}
+			out = append(out, rom[sourceOffset:sourceOffset+length]...)
+			sourceOffset += length
+
+		case bpsTargetCopy:
+			delta, err := readSignedVarint(p.actions, &pos)
+			if err != nil {
+				return nil, fmt.Errorf("invalid BPS action: %w", err)
+			}
+			targetOffset += delta
+			if targetOffset < 0 || targetOffset >= int64(len(out)) {
+				return nil, fmt.Errorf("invalid BPS action at 0x%X: reads outside target", start)
+			}
+			// Byte by byte: the copy may overlap the bytes it writes, repeating them
+			for range length {
+				out = append(out, out[targetOffset])
+				targetOffset++
+			}
+		}
+	}
+
+	if int64(len(out)) != p.TargetSize {
+		return nil, fmt.Errorf("%w: size %d, want %d", ErrTargetMismatch, len(out), p.TargetSize)
+	}
+	if err := checkTarget(out, p.TargetCRC32); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// readSignedVarint reads a BPS relative offset: a number whose low bit is
+// the sign and the rest the magnitude.
+func readSignedVarint(data []byte, pos *int) (int64, error) {
+	v, err := readVarint(data, pos)
+	if err != nil {
+		return 0, err
+	}
+	if v&1 != 0 {
+		return -(v >> 1), nil
+	}
+	return v >> 1, nil
+}
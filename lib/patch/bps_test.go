@@ -0,0 +1,145 @@
+package patch
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"testing"
+)
+
+// appendVarint appends a BPS/UPS variable-length integer.
+func appendVarint(b []byte, v int64) []byte {
+	for {
+		x := byte(v & 0x7F)
+		v >>= 7
+		if v == 0 {
+			return append(b, x|0x80)
+		}
+		b = append(b, x)
+		v--
+	}
+}
+
+// appendFooter appends the source, target, and patch CRC32s.
+func appendFooter(b []byte, source, target []byte) []byte {
+	b = binary.LittleEndian.AppendUint32(b, crc32.ChecksumIEEE(source))
+	b = binary.LittleEndian.AppendUint32(b, crc32.ChecksumIEEE(target))
+	return binary.LittleEndian.AppendUint32(b, crc32.ChecksumIEEE(b))
+}
+
+// testBPS builds a BPS patch from "hello world" to "hello there world!!!"
+// using every action.
+func testBPS(source, target []byte) []byte {
+	b := append([]byte{}, bpsMagic...)
+	b = appendVarint(b, int64(len(source)))
+	b = appendVarint(b, int64(len(target)))
+	b = appendVarint(b, 4)
+	b = append(b, "meta"...)
+
+	action := func(command, length int64) {
+		b = appendVarint(b, (length-1)<<2|command)
+	}
+	action(bpsSourceRead, 6) // "hello "
+	action(bpsTargetRead, 6) // "there "
+	b = append(b, "there "...)
+	action(bpsSourceCopy, 5) // "world", from source offset 6
+	b = appendVarint(b, 6<<1)
+	action(bpsTargetRead, 1) // "!"
+	b = append(b, '!')
+	action(bpsTargetCopy, 2) // "!!", overlapping from target offset 17
+	b = appendVarint(b, 17<<1)
+
+	return appendFooter(b, source, target)
+}
+
+func TestParseBPS(t *testing.T) {
+	source := []byte("hello world")
+	target := []byte("hello there world!!!")
+	data := testBPS(source, target)
+
+	p, err := Parse(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	bps, ok := p.(*BPS)
+	if !ok {
+		t.Fatalf("Parse() = %T, want *BPS", p)
+	}
+	if bps.SourceSize != 11 || bps.TargetSize != 20 || bps.Metadata != "meta" {
+		t.Errorf("Parse() = %+v, want sizes 11, 20 and metadata \"meta\"", bps)
+	}
+	if bps.SourceCRC32 != crc32.ChecksumIEEE(source) || bps.TargetCRC32 != crc32.ChecksumIEEE(target) {
+		t.Errorf("CRC32s = %08x, %08x, want source and target CRC32s", bps.SourceCRC32, bps.TargetCRC32)
+	}
+
+	got, err := p.Apply(source)
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if !bytes.Equal(got, target) {
+		t.Errorf("Apply() = %q, want %q", got, target)
+	}
+}
+
+func TestBPS_Apply_WrongSource(t *testing.T) {
+	data := testBPS([]byte("hello world"), []byte("hello there world!!!"))
+	p, err := ParseBPS(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("ParseBPS() error = %v", err)
+	}
+
+	for _, rom := range []string{"hello World", "hello worlds"} {
+		if _, err := p.Apply([]byte(rom)); !errors.Is(err, ErrSourceMismatch) {
+			t.Errorf("Apply(%q) error = %v, want ErrSourceMismatch", rom, err)
+		}
+	}
+}
+
+func TestBPS_Apply_WrongTarget(t *testing.T) {
+	source := []byte("hello world")
+	data := testBPS(source, []byte("hello there world???"))
+	p, err := ParseBPS(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("ParseBPS() error = %v", err)
+	}
+
+	if _, err := p.Apply(source); !errors.Is(err, ErrTargetMismatch) {
+		t.Errorf("Apply() error = %v, want ErrTargetMismatch", err)
+	}
+}
+
+func TestParseBPS_Errors(t *testing.T) {
+	valid := testBPS([]byte("hello world"), []byte("hello there world!!!"))
+	corrupt := bytes.Clone(valid)
+	corrupt[len(bpsMagic)+5] ^= 0xFF
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"bad magic", append([]byte("BPS2"), valid[4:]...)},
+		{"too small", []byte("BPS1")},
+		{"corrupt", corrupt},
+		{"truncated", appendFooter(append([]byte{}, bpsMagic...), nil, nil)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseBPS(bytes.NewReader(tt.data), int64(len(tt.data))); err == nil {
+				t.Error("ParseBPS() expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestReadVarint(t *testing.T) {
+	for _, v := range []int64{0, 1, 127, 128, 129, 16383, 16384, 1 << 30} {
+		b := appendVarint(nil, v)
+		pos := 0
+		got, err := readVarint(b, &pos)
+		if err != nil || got != v || pos != len(b) {
+			t.Errorf("readVarint(appendVarint(%d)) = %d, %v, read %d of %d bytes", v, got, err, pos, len(b))
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package patch
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// IPS patch format:
+// https://zerosoft.zophar.net/ips.php
+//
+// Layout:
+//
+//	Offset  Size  Description
+//	0x00    5     Magic ("PATCH")
+//	0x05    ...   Records
+//	...     3     End marker ("EOF")
+//	...     3     Truncated size (optional extension, big-endian)
+//
+// Record layout:
+//
+//	Offset  Size  Description
+//	0x00    3     Offset in the ROM (big-endian)
+//	0x03    2     Data size (big-endian); 0 for an RLE record
+//	0x05    n     Data
+//
+// RLE record layout (after a 0 data size):
+//
+//	Offset  Size  Description
+//	0x05    2     Run length (big-endian)
+//	0x07    1     Value repeated for the run
+
+var (
+	ipsMagic     = []byte("PATCH")
+	ipsEndMarker = []byte("EOF")
+)
+
+const (
+	ipsOffsetSize   = 3
+	ipsRecordHeader = 5 // offset + data size
+)
+
+// IPSRecord is a run of bytes written by an IPS patch.
+type IPSRecord struct {
+	// Offset is where the data is written in the ROM.
+	Offset int64 `json:"offset"`
+	// Data is the bytes written. RLE records are expanded.
+	Data []byte `json:"data"`
+}
+
+// IPS is a parsed IPS patch.
+type IPS struct {
+	// Records lists the patch's records in order. Later records overwrite
+	// earlier ones where they overlap.
+	Records []IPSRecord `json:"records"`
+	// TruncateSize is the size the patched ROM is truncated to, or -1 if the
+	// patch doesn't truncate.
+	TruncateSize int64 `json:"truncate_size"`
+}
+
+// ParseIPS parses an IPS patch.
+func ParseIPS(r io.ReaderAt, size int64) (*IPS, error) {
+	data := make([]byte, size)
+	if _, err := r.ReadAt(data, 0); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to read IPS patch: %w", err)
+	}
+	if !bytes.HasPrefix(data, ipsMagic) {
+		return nil, fmt.Errorf("not a valid IPS patch: magic mismatch")
+	}
+
+	patch := &IPS{TruncateSize: -1}
+	pos := len(ipsMagic)
+	for {
+		if pos+len(ipsEndMarker) > len(data) {
+			return nil, fmt.Errorf("not a valid IPS patch: missing end marker")
+		}
+		if bytes.Equal(data[pos:pos+len(ipsEndMarker)], ipsEndMarker) {
+			pos += len(ipsEndMarker)
+			break
+		}
+
+		if pos+ipsRecordHeader > len(data) {
+			return nil, fmt.Errorf("not a valid IPS patch: truncated record at 0x%X", pos)
+		}
+		offset := readUint24(data[pos:])
+		n := int(binary.BigEndian.Uint16(data[pos+ipsOffsetSize:]))
+		pos += ipsRecordHeader
+
+		var recordData []byte
+		if n > 0 {
+			if pos+n > len(data) {
+				return nil, fmt.Errorf("not a valid IPS patch: truncated record at 0x%X", pos-ipsRecordHeader)
+			}
+			recordData = bytes.Clone(data[pos : pos+n])
+			pos += n
+		} else {
+			if pos+3 > len(data) {
+				return nil, fmt.Errorf("not a valid IPS patch: truncated RLE record at 0x%X", pos-ipsRecordHeader)
+			}
+			run := int(binary.BigEndian.Uint16(data[pos:]))
+			recordData = bytes.Repeat([]byte{data[pos+2]}, run)
+			pos += 3
+		}
+		patch.Records = append(patch.Records, IPSRecord{Offset: offset, Data: recordData})
+	}
+
+	// Truncation extension: a 3-byte size after the end marker
+	switch len(data) - pos {
+	case 0:
+	case ipsOffsetSize:
+		patch.TruncateSize = readUint24(data[pos:])
+	default:
+		return nil, fmt.Errorf("not a valid IPS patch: %d unexpected bytes after end marker", len(data)-pos)
+	}
+
+	return patch, nil
+}
+
+// Apply implements Patch. Records past the end of rom extend it, with any gap
+// zero-filled.
+func (p *IPS) Apply(rom []byte) ([]byte, error) {
+	out := bytes.Clone(rom)
+	for _, rec := range p.Records {
+		end := rec.Offset + int64(len(rec.Data))
+		if end > int64(len(out)) {
+			out = append(out, make([]byte, end-int64(len(out)))...)
+		}
+		copy(out[rec.Offset:], rec.Data)
+	}
+	if p.TruncateSize >= 0 && p.TruncateSize < int64(len(out)) {
+		out = out[:p.TruncateSize]
+	}
+	return out, nil
+}
+
+// readUint24 reads a big-endian 24-bit integer.
+func readUint24(b []byte) int64 {
+	return int64(b[0])<<16 | int64(b[1])<<8 | int64(b[2])
+}
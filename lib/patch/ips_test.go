@@ -0,0 +1,102 @@
+package patch
+
+import (
+	"bytes"
+	"testing"
+)
+
+// buildIPS assembles an IPS patch from raw record bytes and an optional
+// trailer after the end marker.
+func buildIPS(records []byte, trailer []byte) []byte {
+	var b bytes.Buffer
+	b.Write(ipsMagic)
+	b.Write(records)
+	b.Write(ipsEndMarker)
+	b.Write(trailer)
+	return b.Bytes()
+}
+
+func TestParseIPS(t *testing.T) {
+	data := buildIPS([]byte{
+		0x00, 0x00, 0x02, 0x00, 0x03, 'a', 'b', 'c', // 3 bytes at 0x2
+		0x00, 0x00, 0x08, 0x00, 0x00, 0x00, 0x04, 0xFF, // RLE: 4 x 0xFF at 0x8
+	}, nil)
+
+	p, err := ParseIPS(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("ParseIPS() error = %v", err)
+	}
+	if len(p.Records) != 2 {
+		t.Fatalf("len(Records) = %d, want 2", len(p.Records))
+	}
+	if r := p.Records[0]; r.Offset != 2 || string(r.Data) != "abc" {
+		t.Errorf("Records[0] = %+v, want \"abc\" at 2", r)
+	}
+	if r := p.Records[1]; r.Offset != 8 || !bytes.Equal(r.Data, []byte{0xFF, 0xFF, 0xFF, 0xFF}) {
+		t.Errorf("Records[1] = %+v, want 4 x 0xFF at 8", r)
+	}
+	if p.TruncateSize != -1 {
+		t.Errorf("TruncateSize = %d, want -1", p.TruncateSize)
+	}
+
+	rom := make([]byte, 10)
+	got, err := p.Apply(rom)
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	want := []byte{0, 0, 'a', 'b', 'c', 0, 0, 0, 0xFF, 0xFF, 0xFF, 0xFF}
+	if !bytes.Equal(got, want) {
+		t.Errorf("Apply() = % X, want % X", got, want)
+	}
+	if !bytes.Equal(rom, make([]byte, 10)) {
+		t.Error("Apply() modified its input")
+	}
+}
+
+func TestParseIPS_Truncate(t *testing.T) {
+	data := buildIPS([]byte{0x00, 0x00, 0x00, 0x00, 0x01, 'x'}, []byte{0x00, 0x00, 0x04})
+
+	p, err := Parse(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if ips, ok := p.(*IPS); !ok || ips.TruncateSize != 4 {
+		t.Fatalf("Parse() = %+v, want IPS truncating to 4", p)
+	}
+
+	got, err := p.Apply([]byte("abcdefgh"))
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if string(got) != "xbcd" {
+		t.Errorf("Apply() = %q, want %q", got, "xbcd")
+	}
+}
+
+func TestParseIPS_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"bad magic", []byte("PATCHX")[1:]},
+		{"missing end marker", []byte("PATCH")},
+		{"truncated record", append([]byte("PATCH"), 0x00, 0x00, 0x01, 0x00, 0x05, 'a')},
+		{"truncated RLE record", append([]byte("PATCH"), 0x00, 0x00, 0x01, 0x00, 0x00, 0x00)},
+		{"trailing garbage", buildIPS(nil, []byte{0x01})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseIPS(bytes.NewReader(tt.data), int64(len(tt.data))); err == nil {
+				t.Error("ParseIPS() expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestParse_Unrecognized(t *testing.T) {
+	data := []byte("NOTAPATCH")
+	if _, err := Parse(bytes.NewReader(data), int64(len(data))); err == nil {
+		t.Error("Parse() expected error, got nil")
+	}
+}
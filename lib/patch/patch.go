@@ -0,0 +1,133 @@
+// Package patch provides support for applying ROM patches, as distributed
+// for translations and ROM hacks.
+//
+// Use Parse to read a patch of any supported format, detected from its magic,
+// then Patch.Apply to patch a ROM. Supported formats:
+//   - IPS: ParseIPS
+//   - BPS: ParseBPS
+//   - UPS: ParseUPS
+//
+// BPS and UPS patches carry CRC32s of the source and target ROMs. Apply checks
+// them, returning ErrSourceMismatch if the patch is for a different ROM (or
+// revision) and ErrTargetMismatch if the patched ROM isn't what it should be.
+package patch
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+var (
+	// ErrSourceMismatch is returned when applying a patch to a ROM other than
+	// the one it was made for.
+	ErrSourceMismatch = errors.New("ROM doesn't match the patch's source")
+	// ErrTargetMismatch is returned when a patched ROM doesn't match the
+	// patch's target.
+	ErrTargetMismatch = errors.New("patched ROM doesn't match the patch's target")
+)
+
+// Patch is a parsed ROM patch.
+type Patch interface {
+	// Apply returns a patched copy of rom.
+	Apply(rom []byte) ([]byte, error)
+}
+
+// magicSize is the length of the longest patch magic.
+const magicSize = 5
+
+// Parse parses a patch, detecting its format from its magic.
+func Parse(r io.ReaderAt, size int64) (Patch, error) {
+	magic := make([]byte, min(size, magicSize))
+	if _, err := r.ReadAt(magic, 0); err != nil {
+		return nil, fmt.Errorf("failed to read patch magic: %w", err)
+	}
+
+	switch {
+	case bytes.HasPrefix(magic, ipsMagic):
+		return ParseIPS(r, size)
+	case bytes.HasPrefix(magic, bpsMagic):
+		return ParseBPS(r, size)
+	case bytes.HasPrefix(magic, upsMagic):
+		return ParseUPS(r, size)
+	default:
+		return nil, fmt.Errorf("unrecognized patch format")
+	}
+}
+
+// maxROMSize is the largest source or target size accepted from a patch, to
+// avoid huge allocations from corrupt patches.
+const maxROMSize = 1 << 30
+
+// footerSize is the size of the BPS and UPS footer: source, target, and patch
+// CRC32s (little-endian).
+const footerSize = 12
+
+// checksums holds the CRC32s from a BPS or UPS footer.
+type checksums struct {
+	source, target uint32
+}
+
+// readFooter validates the patch CRC32 at the end of data, returning the
+// source and target CRC32s before it.
+func readFooter(data []byte) (checksums, error) {
+	if len(data) < footerSize {
+		return checksums{}, fmt.Errorf("too small for footer: %d bytes", len(data))
+	}
+	footer := data[len(data)-footerSize:]
+	if got, want := crc32.ChecksumIEEE(data[:len(data)-4]), binary.LittleEndian.Uint32(footer[8:]); got != want {
+		return checksums{}, fmt.Errorf("patch CRC32 %08x, want %08x", got, want)
+	}
+	return checksums{
+		source: binary.LittleEndian.Uint32(footer[0:]),
+		target: binary.LittleEndian.Uint32(footer[4:]),
+	}, nil
+}
+
+// checkSource returns ErrSourceMismatch if rom doesn't have the given size
+// and CRC32.
+func checkSource(rom []byte, size int64, crc uint32) error {
+	if int64(len(rom)) != size {
+		return fmt.Errorf("%w: size %d, want %d", ErrSourceMismatch, len(rom), size)
+	}
+	if got := crc32.ChecksumIEEE(rom); got != crc {
+		return fmt.Errorf("%w: CRC32 %08x, want %08x", ErrSourceMismatch, got, crc)
+	}
+	return nil
+}
+
+// checkTarget returns ErrTargetMismatch if out doesn't have the given CRC32.
+func checkTarget(out []byte, crc uint32) error {
+	if got := crc32.ChecksumIEEE(out); got != crc {
+		return fmt.Errorf("%w: CRC32 %08x, want %08x", ErrTargetMismatch, got, crc)
+	}
+	return nil
+}
+
+// readVarint reads a BPS/UPS variable-length integer from data at *pos,
+// advancing *pos past it. Each byte holds 7 bits, least significant first,
+// with the high bit set on the last byte. Each continuation also adds the
+// next place value, so every number has a single encoding.
+func readVarint(data []byte, pos *int) (int64, error) {
+	var value int64
+	shift := int64(1)
+	for {
+		if *pos >= len(data) {
+			return 0, fmt.Errorf("truncated number at 0x%X", *pos)
+		}
+		x := data[*pos]
+		*pos++
+		value += int64(x&0x7F) * shift
+		if x&0x80 != 0 {
+			return value, nil
+		}
+		if shift > 1<<48 {
+			return 0, fmt.Errorf("number too large at 0x%X", *pos)
+		}
+		shift <<= 7
+		value += shift
+	}
+}
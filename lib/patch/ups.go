@@ -0,0 +1,120 @@
+package patch
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// UPS patch format (by byuu).
+//
+// Layout:
+//
+//	Offset  Size  Description
+//	0x00    4     Magic ("UPS1")
+//	0x04    var   Source size
+//	...     var   Target size
+//	...     ...   Hunks
+//	-12     4     Source CRC32 (little-endian)
+//	-8      4     Target CRC32 (little-endian)
+//	-4      4     Patch CRC32 (little-endian, of everything before it)
+//
+// Each hunk is a number of bytes to skip, then bytes to XOR with the source,
+// ending with a 0 byte (which also skips a byte). Source bytes past the end
+// of the source are 0.
+
+var upsMagic = []byte("UPS1")
+
+// UPS is a parsed UPS patch.
+type UPS struct {
+	// SourceSize is the size of the ROM the patch applies to.
+	SourceSize int64 `json:"source_size"`
+	// TargetSize is the size of the patched ROM.
+	TargetSize int64 `json:"target_size"`
+	// SourceCRC32 is the CRC32 of the ROM the patch applies to.
+	SourceCRC32 uint32 `json:"source_crc32"`
+	// TargetCRC32 is the CRC32 of the patched ROM.
+	TargetCRC32 uint32 `json:"target_crc32"`
+
+	hunks []byte
+}
+
+// ParseUPS parses a UPS patch, verifying its patch CRC32.
+func ParseUPS(r io.ReaderAt, size int64) (*UPS, error) {
+	data := make([]byte, size)
+	if _, err := r.ReadAt(data, 0); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to read UPS patch: %w", err)
+	}
+	if !bytes.HasPrefix(data, upsMagic) {
+		return nil, fmt.Errorf("not a valid UPS patch: magic mismatch")
+	}
+	sums, err := readFooter(data)
+	if err != nil {
+		return nil, fmt.Errorf("not a valid UPS patch: %w", err)
+	}
+
+	body := data[:len(data)-footerSize]
+	pos := len(upsMagic)
+	sourceSize, err := readVarint(body, &pos)
+	if err != nil {
+		return nil, fmt.Errorf("not a valid UPS patch: %w", err)
+	}
+	targetSize, err := readVarint(body, &pos)
+	if err != nil {
+		return nil, fmt.Errorf("not a valid UPS patch: %w", err)
+	}
+	if sourceSize > maxROMSize || targetSize > maxROMSize {
+		return nil, fmt.Errorf("not a valid UPS patch: ROM sizes %d, %d too large", sourceSize, targetSize)
+	}
+
+	return &UPS{
+		SourceSize:  sourceSize,
+		TargetSize:  targetSize,
+		SourceCRC32: sums.source,
+		TargetCRC32: sums.target,
+		hunks:       body[pos:],
+	}, nil
+}
+
+// Apply implements Patch, checking rom and the patched ROM against the
+// patch's CRC32s.
+func (p *UPS) Apply(rom []byte) ([]byte, error) {
+	if err := checkSource(rom, p.SourceSize, p.SourceCRC32); err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, p.TargetSize)
+	copy(out, rom)
+
+	var offset int64
+	pos := 0
+	for pos < len(p.hunks) {
+		skip, err := readVarint(p.hunks, &pos)
+		if err != nil {
+			return nil, fmt.Errorf("invalid UPS hunk: %w", err)
+		}
+		offset += skip
+
+		for {
+			if pos >= len(p.hunks) {
+				return nil, fmt.Errorf("invalid UPS hunk: missing terminator")
+			}
+			x := p.hunks[pos]
+			pos++
+			if x == 0 {
+				offset++
+				break
+			}
+			if offset >= p.TargetSize {
+				return nil, fmt.Errorf("invalid UPS hunk: writes past target size %d", p.TargetSize)
+			}
+			out[offset] ^= x
+			offset++
+		}
+	}
+
+	if err := checkTarget(out, p.TargetCRC32); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,82 @@
+package patch
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// testUPS builds a UPS patch from "hello world" to "hello World!!".
+func testUPS(source, target []byte) []byte {
+	b := append([]byte{}, upsMagic...)
+	b = appendVarint(b, int64(len(source)))
+	b = appendVarint(b, int64(len(target)))
+
+	// Skip "hello ", change "w" to "W"
+	b = appendVarint(b, 6)
+	b = append(b, 'w'^'W', 0)
+	// Skip "orld" (less the byte the terminator skipped), append "!!"
+	b = appendVarint(b, 3)
+	b = append(b, '!', '!', 0)
+
+	return appendFooter(b, source, target)
+}
+
+func TestParseUPS(t *testing.T) {
+	source := []byte("hello world")
+	target := []byte("hello World!!")
+	data := testUPS(source, target)
+
+	p, err := Parse(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	ups, ok := p.(*UPS)
+	if !ok {
+		t.Fatalf("Parse() = %T, want *UPS", p)
+	}
+	if ups.SourceSize != 11 || ups.TargetSize != 13 {
+		t.Errorf("Parse() = %+v, want sizes 11, 13", ups)
+	}
+
+	got, err := p.Apply(source)
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if !bytes.Equal(got, target) {
+		t.Errorf("Apply() = %q, want %q", got, target)
+	}
+}
+
+func TestUPS_Apply_Mismatch(t *testing.T) {
+	source := []byte("hello world")
+	data := testUPS(source, []byte("hello World!!"))
+	p, err := ParseUPS(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("ParseUPS() error = %v", err)
+	}
+	if _, err := p.Apply([]byte("HELLO WORLD")); !errors.Is(err, ErrSourceMismatch) {
+		t.Errorf("Apply() error = %v, want ErrSourceMismatch", err)
+	}
+
+	data = testUPS(source, []byte("hello world!!"))
+	p, err = ParseUPS(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("ParseUPS() error = %v", err)
+	}
+	if _, err := p.Apply(source); !errors.Is(err, ErrTargetMismatch) {
+		t.Errorf("Apply() error = %v, want ErrTargetMismatch", err)
+	}
+}
+
+func TestParseUPS_Errors(t *testing.T) {
+	valid := testUPS([]byte("hello world"), []byte("hello World!!"))
+	corrupt := bytes.Clone(valid)
+	corrupt[len(upsMagic)+2] ^= 0xFF
+
+	for _, data := range [][]byte{[]byte("UPS1"), corrupt, append([]byte("UPS0"), valid[4:]...)} {
+		if _, err := ParseUPS(bytes.NewReader(data), int64(len(data))); err == nil {
+			t.Errorf("ParseUPS(%q) expected error, got nil", data)
+		}
+	}
+}
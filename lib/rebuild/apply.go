@@ -0,0 +1,174 @@
+package rebuild
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// Apply carries out the plan's actions in order, skipping those with a Skip
+// reason. The entry renames for a ZIP archive are applied together, by
+// rewriting the archive once. Apply stops at the first error; actions before
+// it stay applied.
+func (p *Plan) Apply() error {
+	actions := p.Actions
+	for len(actions) > 0 {
+		action := actions[0]
+		if action.Kind != ActionRenameEntry {
+			actions = actions[1:]
+			if action.Skip != "" {
+				continue
+			}
+			if err := moveFile(action.Path, action.Target, action.Overwrite); err != nil {
+				return fmt.Errorf("failed to move %s to %s: %w", action.Path, action.Target, err)
+			}
+			continue
+		}
+
+		n := 1
+		for n < len(actions) && actions[n].Kind == ActionRenameEntry && actions[n].Path == action.Path {
+			n++
+		}
+		if err := renameEntries(action.Path, actions[:n]); err != nil {
+			return fmt.Errorf("failed to rename entries in %s: %w", action.Path, err)
+		}
+		actions = actions[n:]
+	}
+	return nil
+}
+
+// moveFile moves the file or folder at path to target, creating the
+// directories above target. Files are copied when target is on another
+// filesystem.
+func moveFile(path, target string, overwrite bool) error {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+	if targetInfo, err := os.Lstat(target); err == nil && !os.SameFile(info, targetInfo) {
+		if !overwrite {
+			return fmt.Errorf("%s already exists", target)
+		}
+		if err := os.RemoveAll(target); err != nil {
+			return err
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+	err = os.Rename(path, target)
+	if errors.Is(err, syscall.EXDEV) && info.Mode().IsRegular() {
+		if err := copyFile(path, target, info.Mode().Perm()); err != nil {
+			os.Remove(target)
+			return err
+		}
+		return os.Remove(path)
+	}
+	return err
+}
+
+// copyFile copies the file at path to target.
+func copyFile(path, target string, perm os.FileMode) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
+
+// renameEntries rewrites the ZIP archive at path with the entries renamed by
+// actions. Entries are copied without recompressing them. The new archive is
+// written next to the old one and replaces it only once complete.
+func renameEntries(path string, actions []Action) error {
+	renames := make(map[string]string)
+	replaced := make(map[string]bool) // entries overwritten by a rename
+	for _, action := range actions {
+		if action.Skip != "" {
+			continue
+		}
+		renames[action.Entry] = action.Target
+		if action.Overwrite {
+			replaced[action.Target] = true
+		}
+	}
+	if len(renames) == 0 {
+		return nil
+	}
+
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".rebuild-*.zip")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := copyEntries(tmp, &r.Reader, renames, replaced); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), info.Mode().Perm()); err != nil {
+		return err
+	}
+	r.Close()
+	return os.Rename(tmp.Name(), path)
+}
+
+// copyEntries writes the entries of r to w, renamed according to renames and
+// leaving out those replaced by a renamed entry.
+func copyEntries(w io.Writer, r *zip.Reader, renames map[string]string, replaced map[string]bool) error {
+	zw := zip.NewWriter(w)
+	if err := zw.SetComment(r.Comment); err != nil {
+		return err
+	}
+
+	for _, f := range r.File {
+		header := f.FileHeader
+		if target, ok := renames[f.Name]; ok {
+			header.Name = target
+			header.NonUTF8 = false
+		} else if replaced[f.Name] {
+			continue
+		}
+
+		raw, err := f.OpenRaw()
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", f.Name, err)
+		}
+		fw, err := zw.CreateRaw(&header)
+		if err != nil {
+			return fmt.Errorf("failed to write %s: %w", header.Name, err)
+		}
+		if _, err := io.Copy(fw, raw); err != nil {
+			return fmt.Errorf("failed to copy %s: %w", f.Name, err)
+		}
+	}
+	return zw.Close()
+}
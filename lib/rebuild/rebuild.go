@@ -0,0 +1,404 @@
+// Package rebuild renames ROM files, and the files inside ZIP archives and
+// folders, to the names given by the DAT entries they match.
+//
+// Rebuilding happens in two steps: NewPlan works out what to rename without
+// touching anything, so the plan can be shown as a dry run, and Plan.Apply
+// carries it out.
+package rebuild
+
+import (
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sargunv/rom-tools/lib/core"
+	"github.com/sargunv/rom-tools/lib/identify"
+)
+
+// ActionKind is the kind of change an action makes.
+type ActionKind string
+
+const (
+	ActionMove        ActionKind = "move"         // move or rename a file, ZIP archive, or folder
+	ActionRenameEntry ActionKind = "rename-entry" // rename a file within a ZIP archive
+)
+
+// Skip reasons, for actions that won't be applied.
+const (
+	SkipExists    = "target exists"        // a different file already has the target name
+	SkipDuplicate = "duplicate of target"  // an identical file already has the target name
+	SkipClaimed   = "target already taken" // an earlier action moves another file to the target
+	SkipUnsafe    = "unsafe target name"   // the DAT name is absolute or climbs out of its directory with ".."
+)
+
+// CollisionPolicy decides what happens when the target of an action already
+// exists and holds a different file.
+type CollisionPolicy string
+
+const (
+	CollisionSkip      CollisionPolicy = "skip"      // leave the file where it is
+	CollisionOverwrite CollisionPolicy = "overwrite" // replace the existing file
+	CollisionRename    CollisionPolicy = "rename"    // add a numbered suffix, e.g. "Game (USA) (1).nes"
+)
+
+// Options controls how a rebuild is planned.
+type Options struct {
+	// Dest is the directory files and containers are moved to. If empty,
+	// they're renamed in place.
+	Dest string
+
+	// Collision is what to do when a target already exists.
+	// Default is CollisionSkip.
+	Collision CollisionPolicy
+}
+
+// Action is a single rename or move.
+type Action struct {
+	Kind      ActionKind `json:"kind"`
+	Path      string     `json:"path"`                // file, ZIP archive, or folder to change
+	Entry     string     `json:"entry,omitempty"`     // name of the entry within the ZIP, for rename-entry
+	Target    string     `json:"target"`              // new path, or new entry name for rename-entry
+	Overwrite bool       `json:"overwrite,omitempty"` // replace the existing target
+	Skip      string     `json:"skip,omitempty"`      // why the action won't be applied, if it won't
+}
+
+// Plan is the set of actions that rebuild a collection.
+type Plan struct {
+	Actions   []Action `json:"actions"`
+	Unmatched []string `json:"unmatched,omitempty"` // files no DAT entry matched, left as they are
+}
+
+// planner tracks the targets claimed while a plan is built, so that two files
+// matching the same DAT entry aren't both moved to it.
+type planner struct {
+	opts    Options
+	plan    *Plan
+	claimed map[string]bool
+}
+
+// NewPlan works out the actions that give every matched file in results its
+// DAT name. The results must have been annotated by identify.Matcher.
+//
+// Single files are renamed to their ROM name. Files inside ZIP archives and
+// folders are renamed to their ROM names too, and then the archive or folder
+// is renamed after its game, if its files all match the same one (or it
+// matched a DAT set as a whole).
+func NewPlan(results []*identify.Result, opts Options) (*Plan, error) {
+	if opts.Collision == "" {
+		opts.Collision = CollisionSkip
+	}
+	p := &planner{
+		opts:    opts,
+		plan:    &Plan{Actions: []Action{}},
+		claimed: make(map[string]bool),
+	}
+
+	for _, result := range results {
+		info, err := os.Stat(result.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat %s: %w", result.Path, err)
+		}
+
+		switch {
+		case info.IsDir():
+			err = p.addFolder(result)
+		case strings.EqualFold(filepath.Ext(result.Path), ".zip"):
+			err = p.addZIP(result)
+		default:
+			err = p.addFile(result)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return p.plan, nil
+}
+
+// destDir is the directory path ends up in.
+func (p *planner) destDir(path string) string {
+	if p.opts.Dest != "" {
+		return p.opts.Dest
+	}
+	return filepath.Dir(path)
+}
+
+// addFile plans the move of a single file to its ROM name.
+func (p *planner) addFile(result *identify.Result) error {
+	if len(result.Items) != 1 {
+		return fmt.Errorf("expected one item for %s, got %d", result.Path, len(result.Items))
+	}
+	item := result.Items[0]
+	if !matched(item) {
+		p.plan.Unmatched = append(p.plan.Unmatched, result.Path)
+		return nil
+	}
+
+	if !localName(item.Match.ROM) {
+		p.skipUnsafe(ActionMove, result.Path, "", item.Match.ROM)
+		return nil
+	}
+	target := filepath.Join(p.destDir(result.Path), filepath.FromSlash(item.Match.ROM))
+	return p.move(result.Path, target, func(path string) (bool, error) {
+		return sameFile(path, item)
+	})
+}
+
+// addFolder plans the renames of the files in a folder, then of the folder.
+func (p *planner) addFolder(result *identify.Result) error {
+	for _, item := range result.Items {
+		path := filepath.Join(result.Path, item.Name)
+		if !matched(item) {
+			p.plan.Unmatched = append(p.plan.Unmatched, path)
+			continue
+		}
+		if !localName(item.Match.ROM) {
+			p.skipUnsafe(ActionMove, path, "", item.Match.ROM)
+			continue
+		}
+		target := filepath.Join(result.Path, filepath.FromSlash(item.Match.ROM))
+		if err := p.move(path, target, func(path string) (bool, error) {
+			return sameFile(path, item)
+		}); err != nil {
+			return err
+		}
+	}
+
+	if game := containerGame(result); game != "" {
+		if !localName(game) {
+			p.skipUnsafe(ActionMove, result.Path, "", game)
+			return nil
+		}
+		target := filepath.Join(p.destDir(result.Path), game)
+		return p.move(result.Path, target, nil)
+	}
+	return nil
+}
+
+// addZIP plans the renames of the entries in a ZIP archive, then of the
+// archive.
+func (p *planner) addZIP(result *identify.Result) error {
+	// Names stay taken even when their entry is renamed, so a rename never
+	// depends on another one happening first
+	taken := make(map[string]*identify.Item, len(result.Items))
+	for i := range result.Items {
+		taken[result.Items[i].Name] = &result.Items[i]
+	}
+	renamedTo := make(map[string]bool)
+
+	for _, item := range result.Items {
+		if !matched(item) {
+			p.plan.Unmatched = append(p.plan.Unmatched, filepath.Join(result.Path, item.Name))
+			continue
+		}
+		if item.Match.ROM == item.Name {
+			continue
+		}
+		if !localName(item.Match.ROM) {
+			p.skipUnsafe(ActionRenameEntry, result.Path, item.Name, item.Match.ROM)
+			continue
+		}
+
+		action := Action{Kind: ActionRenameEntry, Path: result.Path, Entry: item.Name, Target: item.Match.ROM}
+		if existing, ok := taken[action.Target]; ok {
+			switch {
+			case renamedTo[action.Target] && p.opts.Collision != CollisionRename:
+				action.Skip = SkipClaimed
+			case sameItem(*existing, item):
+				action.Skip = SkipDuplicate
+			case p.opts.Collision == CollisionOverwrite:
+				action.Overwrite = true
+			case p.opts.Collision == CollisionRename:
+				action.Target = numbered(action.Target, func(name string) bool { return taken[name] != nil })
+			default:
+				action.Skip = SkipExists
+			}
+		}
+		if action.Skip == "" {
+			taken[action.Target] = &item
+			renamedTo[action.Target] = true
+		}
+		p.plan.Actions = append(p.plan.Actions, action)
+	}
+
+	if game := containerGame(result); game != "" {
+		if !localName(game) {
+			p.skipUnsafe(ActionMove, result.Path, "", game+filepath.Ext(result.Path))
+			return nil
+		}
+		target := filepath.Join(p.destDir(result.Path), game+filepath.Ext(result.Path))
+		return p.move(result.Path, target, nil)
+	}
+	return nil
+}
+
+// move plans the move of path to target, resolving collisions with files
+// already at the target. same reports whether an existing file is identical
+// to the one at path; if nil, existing files are never considered identical.
+func (p *planner) move(path, target string, same func(string) (bool, error)) error {
+	if target == path {
+		return nil
+	}
+
+	action := Action{Kind: ActionMove, Path: path, Target: target}
+	exists, err := p.exists(path, target)
+	if err != nil {
+		return err
+	}
+	if exists {
+		identical := false
+		if same != nil && !p.claimed[target] {
+			if identical, err = same(target); err != nil {
+				return err
+			}
+		}
+		switch {
+		case identical:
+			action.Skip = SkipDuplicate
+		case p.claimed[target] && p.opts.Collision != CollisionRename:
+			action.Skip = SkipClaimed
+		case p.opts.Collision == CollisionOverwrite:
+			action.Overwrite = true
+		case p.opts.Collision == CollisionRename:
+			if action.Target, err = p.numberedPath(path, target); err != nil {
+				return err
+			}
+		default:
+			action.Skip = SkipExists
+		}
+	}
+
+	if action.Skip == "" {
+		p.claimed[action.Target] = true
+	}
+	p.plan.Actions = append(p.plan.Actions, action)
+	return nil
+}
+
+// skipUnsafe plans a skipped action for a DAT name that would escape the
+// directory it's joined to. The target is left as the raw name, for display.
+func (p *planner) skipUnsafe(kind ActionKind, path, entry, name string) {
+	p.plan.Actions = append(p.plan.Actions, Action{Kind: kind, Path: path, Entry: entry, Target: name, Skip: SkipUnsafe})
+}
+
+// localName reports whether a name from a DAT stays within the directory
+// it's joined to: it must be relative and not climb out with "..".
+func localName(name string) bool {
+	return filepath.IsLocal(filepath.FromSlash(name))
+}
+
+// exists reports whether target is taken, either on disk or by an earlier
+// action. A target that differs from path only in case is the same file on
+// case-insensitive filesystems, and doesn't count.
+func (p *planner) exists(path, target string) (bool, error) {
+	if p.claimed[target] {
+		return true, nil
+	}
+	info, err := os.Stat(target)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to stat %s: %w", target, err)
+	}
+	if pathInfo, err := os.Stat(path); err == nil && os.SameFile(info, pathInfo) {
+		return false, nil
+	}
+	return true, nil
+}
+
+// numberedPath finds a free numbered variant of target.
+func (p *planner) numberedPath(path, target string) (string, error) {
+	var statErr error
+	numberedTarget := numbered(target, func(name string) bool {
+		exists, err := p.exists(path, name)
+		if err != nil {
+			statErr = err
+			return false
+		}
+		return exists
+	})
+	return numberedTarget, statErr
+}
+
+// numbered returns name with the lowest numbered suffix, e.g. "Game (1).nes",
+// that taken doesn't report as taken.
+func numbered(name string, taken func(string) bool) string {
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	for i := 1; ; i++ {
+		candidate := fmt.Sprintf("%s (%d)%s", base, i, ext)
+		if !taken(candidate) {
+			return candidate
+		}
+	}
+}
+
+// matched reports whether item matched a DAT entry.
+func matched(item identify.Item) bool {
+	return item.Match != nil && item.Match.Status != identify.MatchStatusUnknown && item.Match.ROM != ""
+}
+
+// containerGame returns the game an archive or folder should be named after:
+// the DAT set it matched, or the game all its matched files belong to.
+// Returns "" if its files match several games or none.
+func containerGame(result *identify.Result) string {
+	if result.Set != nil {
+		return result.Set.Set
+	}
+	game := ""
+	for _, item := range result.Items {
+		if !matched(item) {
+			continue
+		}
+		if game != "" && item.Match.Game != game {
+			return ""
+		}
+		game = item.Match.Game
+	}
+	return game
+}
+
+// itemCRC returns the CRC32 of item, or "" if it wasn't hashed.
+func itemCRC(item identify.Item) string {
+	if crc := item.Hashes[core.HashCRC32]; crc != "" {
+		return strings.ToLower(crc)
+	}
+	return strings.ToLower(item.Hashes[core.HashZipCRC32])
+}
+
+// sameItem reports whether two items have the same size and CRC32.
+func sameItem(a, b identify.Item) bool {
+	crc := itemCRC(a)
+	return crc != "" && a.Size == b.Size && crc == itemCRC(b)
+}
+
+// sameFile reports whether the file at path has the size and CRC32 of item.
+func sameFile(path string, item identify.Item) (bool, error) {
+	crc := itemCRC(item)
+	if crc == "" {
+		return false, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return false, fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+	if info.IsDir() || info.Size() != item.Size {
+		return false, nil
+	}
+
+	h := crc32.NewIEEE()
+	if _, err := io.Copy(h, f); err != nil {
+		return false, fmt.Errorf("failed to hash %s: %w", path, err)
+	}
+	return fmt.Sprintf("%08x", h.Sum32()) == crc, nil
+}
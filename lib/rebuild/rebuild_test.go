@@ -0,0 +1,408 @@
+package rebuild
+
+import (
+	"archive/zip"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/sargunv/rom-tools/lib/datfile"
+	"github.com/sargunv/rom-tools/lib/identify"
+)
+
+// testROMs are the contents of the ROMs in the test DAT.
+var testROMs = map[string]string{
+	"Alpha (USA).bin":  "alpha rom data",
+	"Beta (Japan).bin": "beta rom data",
+	"gamma.a":          "gamma rom a",
+	"gamma.b":          "gamma rom b",
+}
+
+// testMatcher returns a matcher over a DAT with the test ROMs: one game each
+// for Alpha and Beta, and a two-ROM game for gamma.
+func testMatcher(t *testing.T) *identify.Matcher {
+	t.Helper()
+
+	var games strings.Builder
+	rom := func(name string) string {
+		data := testROMs[name]
+		return fmt.Sprintf(`<rom name="%s" size="%d" crc="%08x"/>`, name, len(data), crc32.ChecksumIEEE([]byte(data)))
+	}
+	fmt.Fprintf(&games, `<game name="Alpha (USA)"><description>Alpha</description>%s</game>`, rom("Alpha (USA).bin"))
+	fmt.Fprintf(&games, `<game name="Beta (Japan)"><description>Beta</description>%s</game>`, rom("Beta (Japan).bin"))
+	fmt.Fprintf(&games, `<game name="gamma"><description>Gamma</description>%s%s</game>`, rom("gamma.a"), rom("gamma.b"))
+
+	dat, err := datfile.ParseReader(strings.NewReader(`<?xml version="1.0"?><datafile><header><name>Test</name></header>` + games.String() + `</datafile>`))
+	if err != nil {
+		t.Fatalf("ParseReader() error = %v", err)
+	}
+	index := identify.NewDATIndex()
+	index.Add("", dat)
+	return identify.NewMatcher(index)
+}
+
+// identifyAll identifies and annotates paths.
+func identifyAll(t *testing.T, matcher *identify.Matcher, paths ...string) []*identify.Result {
+	t.Helper()
+	var results []*identify.Result
+	for _, path := range paths {
+		result, err := identify.Identify(path, identify.DefaultOptions())
+		if err != nil {
+			t.Fatalf("Identify(%s) error = %v", path, err)
+		}
+		matcher.Annotate(result)
+		results = append(results, result)
+	}
+	return results
+}
+
+func writeFile(t *testing.T, path, data string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeZIP(t *testing.T, path string, files [][2]string) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	for _, file := range files {
+		w, err := zw.Create(file[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(file[1])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readZIP returns the contents of a ZIP archive by entry name.
+func readZIP(t *testing.T, path string) map[string]string {
+	t.Helper()
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatalf("failed to open %s: %v", path, err)
+	}
+	defer r.Close()
+	files := make(map[string]string)
+	for _, f := range r.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", f.Name, err)
+		}
+		files[f.Name] = string(data)
+	}
+	return files
+}
+
+func assertContents(t *testing.T, path, want string) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Errorf("ReadFile(%s) error = %v", path, err)
+		return
+	}
+	if string(data) != want {
+		t.Errorf("%s = %q, want %q", path, data, want)
+	}
+}
+
+func assertMissing(t *testing.T, path string) {
+	t.Helper()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("%s exists, want it moved", path)
+	}
+}
+
+func TestRebuild_Files(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "alpha.bin"), testROMs["Alpha (USA).bin"])
+	writeFile(t, filepath.Join(dir, "unknown.bin"), "not in the dat")
+
+	results := identifyAll(t, testMatcher(t), filepath.Join(dir, "alpha.bin"), filepath.Join(dir, "unknown.bin"))
+	plan, err := NewPlan(results, Options{})
+	if err != nil {
+		t.Fatalf("NewPlan() error = %v", err)
+	}
+
+	want := []Action{{Kind: ActionMove, Path: filepath.Join(dir, "alpha.bin"), Target: filepath.Join(dir, "Alpha (USA).bin")}}
+	if !slices.Equal(plan.Actions, want) {
+		t.Fatalf("Actions = %+v, want %+v", plan.Actions, want)
+	}
+	if !slices.Equal(plan.Unmatched, []string{filepath.Join(dir, "unknown.bin")}) {
+		t.Errorf("Unmatched = %v, want unknown.bin", plan.Unmatched)
+	}
+
+	// Planning is a dry run
+	assertContents(t, filepath.Join(dir, "alpha.bin"), testROMs["Alpha (USA).bin"])
+
+	if err := plan.Apply(); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	assertMissing(t, filepath.Join(dir, "alpha.bin"))
+	assertContents(t, filepath.Join(dir, "Alpha (USA).bin"), testROMs["Alpha (USA).bin"])
+	assertContents(t, filepath.Join(dir, "unknown.bin"), "not in the dat")
+}
+
+func TestRebuild_Dest(t *testing.T) {
+	dir := t.TempDir()
+	dest := filepath.Join(t.TempDir(), "out")
+	writeFile(t, filepath.Join(dir, "beta.bin"), testROMs["Beta (Japan).bin"])
+
+	results := identifyAll(t, testMatcher(t), filepath.Join(dir, "beta.bin"))
+	plan, err := NewPlan(results, Options{Dest: dest})
+	if err != nil {
+		t.Fatalf("NewPlan() error = %v", err)
+	}
+	if err := plan.Apply(); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	assertMissing(t, filepath.Join(dir, "beta.bin"))
+	assertContents(t, filepath.Join(dest, "Beta (Japan).bin"), testROMs["Beta (Japan).bin"])
+}
+
+func TestRebuild_Collisions(t *testing.T) {
+	tests := []struct {
+		name       string
+		existing   string // contents of the file already at the target
+		collision  CollisionPolicy
+		wantSkip   string
+		wantTarget string
+		wantAt     map[string]string // contents of files after applying
+	}{
+		{
+			name:       "skip",
+			existing:   "something else",
+			collision:  CollisionSkip,
+			wantSkip:   SkipExists,
+			wantTarget: "Alpha (USA).bin",
+			wantAt:     map[string]string{"alpha.bin": testROMs["Alpha (USA).bin"], "Alpha (USA).bin": "something else"},
+		},
+		{
+			name:       "overwrite",
+			existing:   "something else",
+			collision:  CollisionOverwrite,
+			wantTarget: "Alpha (USA).bin",
+			wantAt:     map[string]string{"Alpha (USA).bin": testROMs["Alpha (USA).bin"]},
+		},
+		{
+			name:       "rename",
+			existing:   "something else",
+			collision:  CollisionRename,
+			wantTarget: "Alpha (USA) (1).bin",
+			wantAt:     map[string]string{"Alpha (USA).bin": "something else", "Alpha (USA) (1).bin": testROMs["Alpha (USA).bin"]},
+		},
+		{
+			name:       "duplicate",
+			existing:   testROMs["Alpha (USA).bin"],
+			collision:  CollisionOverwrite,
+			wantSkip:   SkipDuplicate,
+			wantTarget: "Alpha (USA).bin",
+			wantAt:     map[string]string{"alpha.bin": testROMs["Alpha (USA).bin"], "Alpha (USA).bin": testROMs["Alpha (USA).bin"]},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFile(t, filepath.Join(dir, "alpha.bin"), testROMs["Alpha (USA).bin"])
+			writeFile(t, filepath.Join(dir, "Alpha (USA).bin"), tt.existing)
+
+			results := identifyAll(t, testMatcher(t), filepath.Join(dir, "alpha.bin"))
+			plan, err := NewPlan(results, Options{Collision: tt.collision})
+			if err != nil {
+				t.Fatalf("NewPlan() error = %v", err)
+			}
+			if len(plan.Actions) != 1 {
+				t.Fatalf("Actions = %+v, want one", plan.Actions)
+			}
+			action := plan.Actions[0]
+			if action.Skip != tt.wantSkip {
+				t.Errorf("Skip = %q, want %q", action.Skip, tt.wantSkip)
+			}
+			if action.Target != filepath.Join(dir, tt.wantTarget) {
+				t.Errorf("Target = %q, want %q", action.Target, filepath.Join(dir, tt.wantTarget))
+			}
+
+			if err := plan.Apply(); err != nil {
+				t.Fatalf("Apply() error = %v", err)
+			}
+			for name, want := range tt.wantAt {
+				assertContents(t, filepath.Join(dir, name), want)
+			}
+		})
+	}
+}
+
+func TestRebuild_ClaimedTarget(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "a1.bin"), testROMs["Alpha (USA).bin"])
+	writeFile(t, filepath.Join(dir, "a2.bin"), testROMs["Alpha (USA).bin"])
+
+	results := identifyAll(t, testMatcher(t), filepath.Join(dir, "a1.bin"), filepath.Join(dir, "a2.bin"))
+	plan, err := NewPlan(results, Options{})
+	if err != nil {
+		t.Fatalf("NewPlan() error = %v", err)
+	}
+	if len(plan.Actions) != 2 || plan.Actions[0].Skip != "" || plan.Actions[1].Skip != SkipClaimed {
+		t.Fatalf("Actions = %+v, want the second skipped as claimed", plan.Actions)
+	}
+	if err := plan.Apply(); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	assertContents(t, filepath.Join(dir, "Alpha (USA).bin"), testROMs["Alpha (USA).bin"])
+	assertContents(t, filepath.Join(dir, "a2.bin"), testROMs["Alpha (USA).bin"])
+}
+
+func TestRebuild_ZIP(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "g.zip")
+	writeZIP(t, path, [][2]string{
+		{"first", testROMs["gamma.a"]},
+		{"gamma.b", testROMs["gamma.b"]},
+		{"readme.txt", "extra"},
+	})
+
+	results := identifyAll(t, testMatcher(t), path)
+	plan, err := NewPlan(results, Options{})
+	if err != nil {
+		t.Fatalf("NewPlan() error = %v", err)
+	}
+
+	want := []Action{
+		{Kind: ActionRenameEntry, Path: path, Entry: "first", Target: "gamma.a"},
+		{Kind: ActionMove, Path: path, Target: filepath.Join(dir, "gamma.zip")},
+	}
+	if !slices.Equal(plan.Actions, want) {
+		t.Fatalf("Actions = %+v, want %+v", plan.Actions, want)
+	}
+	if !slices.Equal(plan.Unmatched, []string{filepath.Join(path, "readme.txt")}) {
+		t.Errorf("Unmatched = %v, want readme.txt", plan.Unmatched)
+	}
+
+	if err := plan.Apply(); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	assertMissing(t, path)
+	files := readZIP(t, filepath.Join(dir, "gamma.zip"))
+	wantFiles := map[string]string{"gamma.a": testROMs["gamma.a"], "gamma.b": testROMs["gamma.b"], "readme.txt": "extra"}
+	if len(files) != len(wantFiles) {
+		t.Errorf("entries = %v, want %v", files, wantFiles)
+	}
+	for name, data := range wantFiles {
+		if files[name] != data {
+			t.Errorf("entry %s = %q, want %q", name, files[name], data)
+		}
+	}
+}
+
+func TestRebuild_ZIPCollision(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "alpha.zip")
+	writeZIP(t, path, [][2]string{
+		{"alpha", testROMs["Alpha (USA).bin"]},
+		{"Alpha (USA).bin", "something else"},
+	})
+
+	results := identifyAll(t, testMatcher(t), path)
+	plan, err := NewPlan(results, Options{Collision: CollisionOverwrite})
+	if err != nil {
+		t.Fatalf("NewPlan() error = %v", err)
+	}
+	if len(plan.Actions) == 0 || !plan.Actions[0].Overwrite {
+		t.Fatalf("Actions = %+v, want an overwriting entry rename first", plan.Actions)
+	}
+	if err := plan.Apply(); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+
+	files := readZIP(t, filepath.Join(dir, "Alpha (USA).zip"))
+	if len(files) != 1 || files["Alpha (USA).bin"] != testROMs["Alpha (USA).bin"] {
+		t.Errorf("entries = %v, want only the matched ROM", files)
+	}
+}
+
+func TestRebuild_Folder(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "g")
+	writeFile(t, filepath.Join(path, "one"), testROMs["gamma.a"])
+	writeFile(t, filepath.Join(path, "two"), testROMs["gamma.b"])
+
+	results := identifyAll(t, testMatcher(t), path)
+	plan, err := NewPlan(results, Options{})
+	if err != nil {
+		t.Fatalf("NewPlan() error = %v", err)
+	}
+	if err := plan.Apply(); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+
+	assertMissing(t, path)
+	assertContents(t, filepath.Join(dir, "gamma", "gamma.a"), testROMs["gamma.a"])
+	assertContents(t, filepath.Join(dir, "gamma", "gamma.b"), testROMs["gamma.b"])
+}
+
+func TestRebuild_UnsafeNames(t *testing.T) {
+	data := "escaping rom data"
+	dat, err := datfile.ParseReader(strings.NewReader(fmt.Sprintf(`<?xml version="1.0"?><datafile><header><name>Test</name></header>`+
+		`<game name="../evil"><description>Evil</description><rom name="../escape.bin" size="%d" crc="%08x"/></game></datafile>`,
+		len(data), crc32.ChecksumIEEE([]byte(data)))))
+	if err != nil {
+		t.Fatalf("ParseReader() error = %v", err)
+	}
+	index := identify.NewDATIndex()
+	index.Add("", dat)
+	matcher := identify.NewMatcher(index)
+
+	root := t.TempDir()
+	dir := filepath.Join(root, "roms")
+	writeFile(t, filepath.Join(dir, "rom.bin"), data)
+	writeFile(t, filepath.Join(root, "escape.bin"), "outside the scanned directory")
+	zipPath := filepath.Join(dir, "set.zip")
+	writeZIP(t, zipPath, [][2]string{{"rom.bin", data}})
+
+	results := identifyAll(t, matcher, filepath.Join(dir, "rom.bin"), zipPath)
+	plan, err := NewPlan(results, Options{Collision: CollisionOverwrite})
+	if err != nil {
+		t.Fatalf("NewPlan() error = %v", err)
+	}
+	want := []Action{
+		{Kind: ActionMove, Path: filepath.Join(dir, "rom.bin"), Target: "../escape.bin", Skip: SkipUnsafe},
+		{Kind: ActionRenameEntry, Path: zipPath, Entry: "rom.bin", Target: "../escape.bin", Skip: SkipUnsafe},
+		{Kind: ActionMove, Path: zipPath, Target: "../evil.zip", Skip: SkipUnsafe},
+	}
+	if !slices.Equal(plan.Actions, want) {
+		t.Fatalf("Actions = %+v, want %+v", plan.Actions, want)
+	}
+
+	if err := plan.Apply(); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	assertContents(t, filepath.Join(dir, "rom.bin"), data)
+	assertContents(t, filepath.Join(root, "escape.bin"), "outside the scanned directory")
+	if files := readZIP(t, zipPath); files["rom.bin"] != data {
+		t.Errorf("entries = %v, want rom.bin unchanged", files)
+	}
+}
@@ -0,0 +1,272 @@
+package rename
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/sargunv/rom-tools/lib/esde"
+)
+
+// Skip reasons, for renames that won't be applied.
+const (
+	SkipNoName  = "no name"              // the template gave an empty name
+	SkipExists  = "target exists"        // another file already has the new name
+	SkipClaimed = "target already taken" // an earlier rename gives another file the new name
+	SkipMissing = "file not found"       // the file to undo the rename of is gone
+)
+
+// File is a file to rename, with the fields to name it by.
+type File struct {
+	Path   string
+	Fields Fields
+
+	// Gamelist is the ES-DE gamelist.xml whose entry for the file is
+	// updated along with it, if any.
+	Gamelist string
+
+	// GamelistPath is the path of the file's entry in Gamelist, relative to
+	// the gamelist's folder, e.g. "./gb/Tetris.gb". If it's empty, the entry
+	// is taken to be the file's name alone, e.g. "./Tetris.gb".
+	GamelistPath string
+}
+
+// Rename is a single rename of a file within its folder.
+type Rename struct {
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Gamelist string `json:"gamelist,omitempty"` // gamelist.xml with an entry for the file
+	Skip     string `json:"skip,omitempty"`     // why the rename won't be applied, if it won't
+
+	// GamelistPath is the path of the file's entry in Gamelist, as in File.
+	GamelistPath string `json:"gamelist_path,omitempty"`
+}
+
+// Plan works out the renames that give files the names of a template.
+// Files that already have their name are left out. Files whose name is
+// taken, by an existing file or an earlier rename, are skipped.
+func Plan(files []File, tmpl *Template) []Rename {
+	renames := []Rename{}
+	claimed := make(map[string]bool)
+	for _, f := range files {
+		fields := Fields{}
+		for k, v := range f.Fields {
+			fields[k] = v
+		}
+		if _, ok := fields["ext"]; !ok {
+			fields["ext"] = strings.TrimPrefix(filepath.Ext(f.Path), ".")
+		}
+
+		r := Rename{From: f.Path, Gamelist: f.Gamelist, GamelistPath: f.GamelistPath}
+		name := tmpl.Execute(fields)
+		if name == "" {
+			r.Skip = SkipNoName
+			renames = append(renames, r)
+			continue
+		}
+		r.To = filepath.Join(filepath.Dir(f.Path), name)
+		if r.To == r.From {
+			continue
+		}
+
+		key := strings.ToLower(r.To)
+		switch {
+		case claimed[key]:
+			r.Skip = SkipClaimed
+		case exists(r.From, r.To):
+			r.Skip = SkipExists
+		default:
+			claimed[key] = true
+		}
+		renames = append(renames, r)
+	}
+	return renames
+}
+
+// exists returns whether a file other than path is at target. A target that
+// is path itself, as when only the case of its name changes on a
+// case-insensitive filesystem, doesn't count.
+func exists(path, target string) bool {
+	targetInfo, err := os.Lstat(target)
+	if err != nil {
+		return false
+	}
+	info, err := os.Lstat(path)
+	return err != nil || !os.SameFile(info, targetInfo)
+}
+
+// Apply carries out renames in order, skipping those with a Skip reason,
+// and appends each to the log at logPath, if it's set, as a JSON line.
+// Gamelist entries are updated once all renames are done. Apply stops at
+// the first error; renames before it stay applied and logged.
+func Apply(renames []Rename, logPath string) error {
+	var log *os.File
+	if logPath != "" {
+		f, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to open undo log: %w", err)
+		}
+		defer f.Close()
+		log = f
+	}
+
+	var applied []Rename
+	err := func() error {
+		for _, r := range renames {
+			if r.Skip != "" {
+				continue
+			}
+			if exists(r.From, r.To) {
+				return fmt.Errorf("failed to rename %s: %s already exists", r.From, r.To)
+			}
+			if err := os.Rename(r.From, r.To); err != nil {
+				return fmt.Errorf("failed to rename %s: %w", r.From, err)
+			}
+			applied = append(applied, r)
+			if log != nil {
+				data, err := json.Marshal(r)
+				if err != nil {
+					return err
+				}
+				if _, err := log.Write(append(data, '\n')); err != nil {
+					return fmt.Errorf("failed to write undo log: %w", err)
+				}
+			}
+		}
+		return nil
+	}()
+
+	if gamelistErr := updateGamelists(applied); err == nil {
+		err = gamelistErr
+	}
+	return err
+}
+
+// Undo reverses the renames in the log at logPath, last first, and then
+// removes the log. Renames whose file has moved on, or whose old name has
+// been taken since, are left as they are and returned with a Skip reason.
+func Undo(logPath string) ([]Rename, error) {
+	f, err := os.Open(logPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open undo log: %w", err)
+	}
+	var logged []Rename
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var r Rename
+		// A run killed mid-write leaves a partial last line
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil || r.From == "" || r.To == "" {
+			continue
+		}
+		logged = append(logged, r)
+	}
+	err = scanner.Err()
+	f.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read undo log: %w", err)
+	}
+
+	var undone, results []Rename
+	for _, r := range slices.Backward(logged) {
+		_, entry := gamelistPaths(r)
+		back := Rename{From: r.To, To: r.From, Gamelist: r.Gamelist, GamelistPath: entry}
+		switch {
+		case !fileExists(back.From):
+			back.Skip = SkipMissing
+		case exists(back.From, back.To):
+			back.Skip = SkipExists
+		default:
+			if err := os.Rename(back.From, back.To); err != nil {
+				// Keep the gamelists in step with the renames undone so far
+				updateGamelists(undone)
+				return results, fmt.Errorf("failed to rename %s: %w", back.From, err)
+			}
+			undone = append(undone, back)
+		}
+		results = append(results, back)
+	}
+
+	if err := updateGamelists(undone); err != nil {
+		return results, err
+	}
+	if err := os.Remove(logPath); err != nil {
+		return results, fmt.Errorf("failed to remove undo log: %w", err)
+	}
+	return results, nil
+}
+
+func fileExists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
+
+// gamelistPaths returns the cleaned paths of the gamelist entry of a
+// rename's file before and after it, relative to the gamelist's folder.
+func gamelistPaths(r Rename) (from, to string) {
+	from = r.GamelistPath
+	if from == "" {
+		from = filepath.Base(r.From)
+	}
+	from = path.Clean(filepath.ToSlash(from))
+	to = path.Join(path.Dir(from), filepath.Base(r.To))
+	if !path.IsAbs(to) {
+		to = "./" + to
+	}
+	return from, to
+}
+
+// updateGamelists changes the paths of the gamelist entries of applied
+// renames to their new names. It fails if a gamelist has no entry for a
+// rename, after updating the others.
+func updateGamelists(applied []Rename) error {
+	byGamelist := make(map[string][]Rename)
+	var order []string
+	for _, r := range applied {
+		if r.Gamelist == "" {
+			continue
+		}
+		if _, ok := byGamelist[r.Gamelist]; !ok {
+			order = append(order, r.Gamelist)
+		}
+		byGamelist[r.Gamelist] = append(byGamelist[r.Gamelist], r)
+	}
+
+	var missing []error
+	for _, listPath := range order {
+		data, err := os.ReadFile(listPath)
+		if err != nil {
+			return fmt.Errorf("failed to read gamelist %s: %w", listPath, err)
+		}
+		list, err := esde.Parse(data)
+		if err != nil {
+			return fmt.Errorf("failed to parse gamelist %s: %w", listPath, err)
+		}
+		for _, r := range byGamelist[listPath] {
+			from, to := gamelistPaths(r)
+			found := false
+			for i := range list.Games {
+				if path.Clean(filepath.ToSlash(list.Games[i].Path)) == from {
+					list.Games[i].Path = to
+					found = true
+				}
+			}
+			if !found {
+				missing = append(missing, fmt.Errorf("gamelist %s has no entry for %s", listPath, from))
+			}
+		}
+		data, err = esde.Write(list)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(listPath, data, 0644); err != nil {
+			return fmt.Errorf("failed to write gamelist %s: %w", listPath, err)
+		}
+	}
+	return errors.Join(missing...)
+}
@@ -0,0 +1,224 @@
+package rename
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sargunv/rom-tools/lib/esde"
+)
+
+func mustParse(t *testing.T, text string) *Template {
+	t.Helper()
+	tmpl, err := ParseTemplate(text)
+	if err != nil {
+		t.Fatalf("ParseTemplate(%q) error = %v", text, err)
+	}
+	return tmpl
+}
+
+func writeFile(t *testing.T, path, data string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func assertContents(t *testing.T, path, want string) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Errorf("failed to read %s: %v", path, err)
+		return
+	}
+	if string(data) != want {
+		t.Errorf("%s = %q, want %q", path, data, want)
+	}
+}
+
+func TestTemplate_Execute(t *testing.T) {
+	fields := NameFields("Legend of Zelda, The - A Link to the Past (Europe) (En,Fr,De) (Rev 1)")
+	fields["ext"] = "sfc"
+
+	tests := []struct {
+		template string
+		fields   Fields
+		want     string
+	}{
+		{"{Title} ({Regions}) ({Languages}).{ext}", fields, "Legend of Zelda, The - A Link to the Past (Europe) (En,Fr,De).sfc"},
+		{DefaultTemplate, fields, "Legend of Zelda, The - A Link to the Past (Europe) (En,Fr,De) (Rev 1).sfc"},
+		{DefaultTemplate, Fields{"title": "Tetris", "regions": "World", "ext": "gb"}, "Tetris (World).gb"},
+		{"{title} [{year}] {{{developer}}}.{ext}", Fields{"title": "Tetris", "developer": "Bullet-Proof", "ext": "gb"}, "Tetris {Bullet-Proof}.gb"},
+		{"{Title}.{ext}", Fields{"title": "Zelda: Oracle of Ages / Seasons?", "ext": "gbc"}, "Zelda - Oracle of Ages - Seasons.gbc"},
+		{"{Title}", Fields{"title": "Final Fantasy (Japan)"}, "Final Fantasy (Japan)"},
+		{"{Title}.{ext}", Fields{}, ""},
+	}
+	for _, tt := range tests {
+		if got := mustParse(t, tt.template).Execute(tt.fields); got != tt.want {
+			t.Errorf("Execute(%q) = %q, want %q", tt.template, got, tt.want)
+		}
+	}
+}
+
+func TestParseTemplate_Invalid(t *testing.T) {
+	for _, text := range []string{"{Title", "{Nope}", "{Title})", "({Title}", "Title}"} {
+		if _, err := ParseTemplate(text); err == nil {
+			t.Errorf("ParseTemplate(%q) succeeded, want error", text)
+		}
+	}
+}
+
+func TestNameFields(t *testing.T) {
+	f := NameFields("Game (Brazil) (Pt-BR,Es) (Disc 2)")
+	want := Fields{
+		"name":      "Game (Brazil) (Pt-BR,Es) (Disc 2)",
+		"title":     "Game",
+		"regions":   "Brazil",
+		"languages": "Pt-BR,Es",
+		"revision":  "",
+		"disc":      "2",
+	}
+	for k, v := range want {
+		if f[k] != v {
+			t.Errorf("NameFields()[%q] = %q, want %q", k, f[k], v)
+		}
+	}
+	if got := NameFields("Game (1995-03-01)(Publisher)")["year"]; got != "1995" {
+		t.Errorf("NameFields() year = %q, want 1995", got)
+	}
+}
+
+func TestPlanApplyUndo(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "tetris.gb"), "tetris")
+	writeFile(t, filepath.Join(dir, "tetris2.gb"), "tetris 2")
+	writeFile(t, filepath.Join(dir, "mario.gb"), "mario")
+	writeFile(t, filepath.Join(dir, "Taken (USA).gb"), "taken")
+	writeFile(t, filepath.Join(dir, "Done (USA).gb"), "done")
+
+	gamelist := filepath.Join(dir, "gamelist.xml")
+	data, err := esde.Write(&esde.GameList{Games: []esde.Game{{Path: "./tetris.gb", Name: "Tetris"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, gamelist, string(data))
+
+	files := []File{
+		{Path: filepath.Join(dir, "tetris.gb"), Fields: NameFields("Tetris (World) (Rev 1)"), Gamelist: gamelist},
+		{Path: filepath.Join(dir, "tetris2.gb"), Fields: NameFields("Tetris (World) (Rev 1)")},
+		{Path: filepath.Join(dir, "mario.gb"), Fields: NameFields("Taken (USA)")},
+		{Path: filepath.Join(dir, "Done (USA).gb"), Fields: NameFields("Done (USA)")},
+	}
+	renames := Plan(files, mustParse(t, DefaultTemplate))
+	if len(renames) != 3 {
+		t.Fatalf("Plan() = %+v, want 3 renames", renames)
+	}
+	if renames[0].To != filepath.Join(dir, "Tetris (World) (Rev 1).gb") || renames[0].Skip != "" {
+		t.Errorf("renames[0] = %+v, want a rename to Tetris (World) (Rev 1).gb", renames[0])
+	}
+	if renames[1].Skip != SkipClaimed {
+		t.Errorf("renames[1].Skip = %q, want %q", renames[1].Skip, SkipClaimed)
+	}
+	if renames[2].Skip != SkipExists {
+		t.Errorf("renames[2].Skip = %q, want %q", renames[2].Skip, SkipExists)
+	}
+
+	logPath := filepath.Join(dir, "rename.log")
+	if err := Apply(renames, logPath); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	assertContents(t, filepath.Join(dir, "Tetris (World) (Rev 1).gb"), "tetris")
+	assertContents(t, filepath.Join(dir, "tetris2.gb"), "tetris 2")
+	assertContents(t, filepath.Join(dir, "mario.gb"), "mario")
+	data, _ = os.ReadFile(gamelist)
+	if !strings.Contains(string(data), "<path>./Tetris (World) (Rev 1).gb</path>") {
+		t.Errorf("gamelist path not updated:\n%s", data)
+	}
+
+	undone, err := Undo(logPath)
+	if err != nil {
+		t.Fatalf("Undo() error = %v", err)
+	}
+	if len(undone) != 1 || undone[0].Skip != "" {
+		t.Errorf("Undo() = %+v, want 1 rename undone", undone)
+	}
+	assertContents(t, filepath.Join(dir, "tetris.gb"), "tetris")
+	data, _ = os.ReadFile(gamelist)
+	if !strings.Contains(string(data), "<path>./tetris.gb</path>") {
+		t.Errorf("gamelist path not restored:\n%s", data)
+	}
+	if _, err := os.Stat(logPath); !os.IsNotExist(err) {
+		t.Errorf("undo log still exists after Undo()")
+	}
+}
+
+func TestApply_GamelistSubfolder(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "gb"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(dir, "gb", "tetris.gb"), "tetris")
+	writeFile(t, filepath.Join(dir, "gb", "mario.gb"), "mario")
+
+	gamelist := filepath.Join(dir, "gamelist.xml")
+	data, err := esde.Write(&esde.GameList{Games: []esde.Game{
+		{Path: "./gb/tetris.gb", Name: "Tetris"},
+		{Path: "./tetris.gb", Name: "Another Tetris"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, gamelist, string(data))
+
+	logPath := filepath.Join(dir, "rename.log")
+	files := []File{{Path: filepath.Join(dir, "gb", "tetris.gb"), Fields: NameFields("Tetris (World)"), Gamelist: gamelist, GamelistPath: "./gb/tetris.gb"}}
+	if err := Apply(Plan(files, mustParse(t, DefaultTemplate)), logPath); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	data, _ = os.ReadFile(gamelist)
+	if !strings.Contains(string(data), "<path>./gb/Tetris (World).gb</path>") || !strings.Contains(string(data), "<path>./tetris.gb</path>") {
+		t.Errorf("gamelist path in subfolder not updated, or another entry changed:\n%s", data)
+	}
+	if _, err := Undo(logPath); err != nil {
+		t.Fatalf("Undo() error = %v", err)
+	}
+	data, _ = os.ReadFile(gamelist)
+	if !strings.Contains(string(data), "<path>./gb/tetris.gb</path>") {
+		t.Errorf("gamelist path in subfolder not restored:\n%s", data)
+	}
+
+	files = []File{{Path: filepath.Join(dir, "gb", "mario.gb"), Fields: NameFields("Mario (World)"), Gamelist: gamelist, GamelistPath: "./gb/mario.gb"}}
+	err = Apply(Plan(files, mustParse(t, DefaultTemplate)), "")
+	if err == nil || !strings.Contains(err.Error(), "no entry for gb/mario.gb") {
+		t.Errorf("Apply() error = %v, want no entry for gb/mario.gb", err)
+	}
+}
+
+func TestUndo_Skips(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "a.gb"), "a")
+	writeFile(t, filepath.Join(dir, "b.gb"), "b")
+
+	logPath := filepath.Join(dir, "rename.log")
+	renames := []Rename{
+		{From: filepath.Join(dir, "a.gb"), To: filepath.Join(dir, "A (USA).gb")},
+		{From: filepath.Join(dir, "b.gb"), To: filepath.Join(dir, "B (USA).gb")},
+	}
+	if err := Apply(renames, logPath); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+
+	// The first file's old name is taken, and the second file is gone
+	writeFile(t, filepath.Join(dir, "a.gb"), "new a")
+	os.Remove(filepath.Join(dir, "B (USA).gb"))
+
+	undone, err := Undo(logPath)
+	if err != nil {
+		t.Fatalf("Undo() error = %v", err)
+	}
+	if len(undone) != 2 || undone[0].Skip != SkipMissing || undone[1].Skip != SkipExists {
+		t.Errorf("Undo() = %+v, want the second missing and the first existing", undone)
+	}
+	assertContents(t, filepath.Join(dir, "A (USA).gb"), "a")
+}
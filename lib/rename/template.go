@@ -0,0 +1,240 @@
+// Package rename renames ROM files after naming templates like
+// "{Title} ({Regions}) ({Languages}).{ext}", filled in from the DAT entries
+// the files match or from scraped metadata.
+//
+// Renaming happens in two steps: Plan works out the new names without
+// touching anything, so they can be shown as a dry run, and Apply carries
+// them out, recording each rename in a log that Undo reverses.
+package rename
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/sargunv/rom-tools/lib/romname"
+)
+
+// Fields are the values of the fields of a template, keyed by lowercase
+// field name. Missing fields are empty.
+type Fields map[string]string
+
+// FieldNames are the fields templates may use, which are matched ignoring
+// case.
+var FieldNames = []string{
+	"name",      // full name, e.g. "Tetris (World) (Rev 1)"
+	"title",     // name without tags, e.g. "Tetris"
+	"regions",   // e.g. "USA, Europe"
+	"languages", // e.g. "En,Fr,De"
+	"revision",  // e.g. "1" or "1.1"
+	"disc",      // disc number, e.g. "2"
+	"year",      // release year
+	"developer", // developer
+	"publisher", // publisher
+	"genre",     // genres, e.g. "Puzzle, Action"
+	"players",   // number of players
+	"ext",       // the file's extension without the dot, e.g. "gb"
+}
+
+// DefaultTemplate names files like No-Intro does.
+const DefaultTemplate = "{Title} ({Regions}) ({Languages}) (Rev {Revision}) (Disc {Disc}).{ext}"
+
+// NameFields returns the fields of a No-Intro, Redump, or TOSEC style name.
+func NameFields(name string) Fields {
+	n := romname.Parse(name)
+	f := Fields{
+		"name":     name,
+		"title":    n.Title,
+		"revision": n.Revision,
+	}
+
+	regions := make([]string, len(n.Regions))
+	for i, r := range n.Regions {
+		regions[i] = string(r)
+	}
+	f["regions"] = strings.Join(regions, ", ")
+
+	languages := make([]string, len(n.Languages))
+	for i, lang := range n.Languages {
+		code, variant, _ := strings.Cut(lang, "-")
+		languages[i] = strings.ToUpper(code[:1]) + code[1:]
+		if variant != "" {
+			languages[i] += "-" + strings.ToUpper(variant)
+		}
+	}
+	f["languages"] = strings.Join(languages, ",")
+
+	if n.Disc > 0 {
+		f["disc"] = strconv.Itoa(n.Disc)
+	}
+	if year, _, _ := strings.Cut(n.Date, "-"); year != "" && !strings.Contains(year, "x") {
+		f["year"] = year
+	}
+	return f
+}
+
+// Template is a parsed naming template. Fields are written in braces, e.g.
+// "{Title}", and literal braces doubled, e.g. "{{". A parenthesized or
+// bracketed part with fields, e.g. " (Rev {Revision})", is left out with
+// the space before it when all its fields are empty.
+type Template struct {
+	text  string
+	parts []part
+}
+
+// part is literal text, a field, or an optional group of parts.
+type part struct {
+	text  string
+	field string // lowercase field name, if a field
+	group []part // parts of a group, if a group
+}
+
+// ParseTemplate parses a naming template.
+func ParseTemplate(text string) (*Template, error) {
+	parts, rest, err := parseParts(text, 0)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template %q: %w", text, err)
+	}
+	if rest != "" {
+		return nil, fmt.Errorf("invalid template %q: unbalanced %q", text, rest[:1])
+	}
+	return &Template{text: text, parts: parts}, nil
+}
+
+// String returns the template's text.
+func (t *Template) String() string {
+	return t.text
+}
+
+// parseParts parses parts until the closing bracket of a group, if close
+// isn't 0, returning the text after it.
+func parseParts(s string, close byte) ([]part, string, error) {
+	var parts []part
+	var lit strings.Builder
+	flush := func() {
+		if lit.Len() > 0 {
+			parts = append(parts, part{text: lit.String()})
+			lit.Reset()
+		}
+	}
+
+	for len(s) > 0 {
+		c := s[0]
+		switch {
+		case strings.HasPrefix(s, "{{"), strings.HasPrefix(s, "}}"):
+			lit.WriteByte(c)
+			s = s[2:]
+
+		case c == '{':
+			end := strings.IndexByte(s, '}')
+			if end < 0 {
+				return nil, "", fmt.Errorf("unclosed field")
+			}
+			name := strings.ToLower(strings.TrimSpace(s[1:end]))
+			if !slices.Contains(FieldNames, name) {
+				return nil, "", fmt.Errorf("unknown field %q (want one of %s)", s[1:end], strings.Join(FieldNames, ", "))
+			}
+			flush()
+			parts = append(parts, part{field: name})
+			s = s[end+1:]
+
+		case c == '}':
+			return nil, "", fmt.Errorf("unopened field")
+
+		case c == '(' || c == '[':
+			closing := byte(')')
+			if c == '[' {
+				closing = ']'
+			}
+			group, rest, err := parseParts(s[1:], closing)
+			if err != nil {
+				return nil, "", err
+			}
+			flush()
+			group = append(append([]part{{text: string(c)}}, group...), part{text: string(closing)})
+			parts = append(parts, part{group: group})
+			s = rest
+
+		case c == close:
+			flush()
+			return parts, s[1:], nil
+
+		case c == ')' || c == ']':
+			// Unbalanced at the top level
+			flush()
+			return parts, s, nil
+
+		default:
+			lit.WriteByte(c)
+			s = s[1:]
+		}
+	}
+	if close != 0 {
+		return nil, "", fmt.Errorf("unclosed %q", map[byte]string{')': "(", ']': "["}[close])
+	}
+	flush()
+	return parts, "", nil
+}
+
+// Execute fills in the template with fields, returning a file name. Field
+// values are made safe for file names: path separators and other
+// characters that aren't allowed on common filesystems are replaced or
+// removed. It returns "" if the name would be empty.
+func (t *Template) Execute(fields Fields) string {
+	var b strings.Builder
+	render(&b, t.parts, fields)
+	name := strings.Join(strings.Fields(b.String()), " ")
+	return strings.TrimRight(name, ". ")
+}
+
+// render writes parts to b, and returns whether they have a field and
+// whether all their fields are empty.
+func render(b *strings.Builder, parts []part, fields Fields) (hasField, empty bool) {
+	empty = true
+	for _, p := range parts {
+		switch {
+		case p.field != "":
+			hasField = true
+			value := sanitize(fields[p.field])
+			if value != "" {
+				empty = false
+			}
+			b.WriteString(value)
+
+		case p.group != nil:
+			var g strings.Builder
+			groupHasField, groupEmpty := render(&g, p.group, fields)
+			if groupHasField && groupEmpty {
+				// Drop the group and the space before it
+				s := strings.TrimSuffix(b.String(), " ")
+				b.Reset()
+				b.WriteString(s)
+				continue
+			}
+			hasField = hasField || groupHasField
+			empty = empty && groupEmpty
+			b.WriteString(g.String())
+
+		default:
+			b.WriteString(p.text)
+		}
+	}
+	return hasField, empty
+}
+
+// sanitize makes a field value safe for a file name: separators become
+// dashes (as in "Title - Subtitle" for "Title: Subtitle"), and other
+// characters Windows doesn't allow are removed.
+func sanitize(value string) string {
+	value = strings.ReplaceAll(value, ": ", " - ")
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '/' || r == '\\' || r == ':':
+			return '-'
+		case r < ' ' || strings.ContainsRune(`*?"<>|`, r):
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(value))
+}
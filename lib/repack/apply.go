@@ -0,0 +1,376 @@
+package repack
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha1"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sargunv/rom-tools/lib/chd"
+	"github.com/sargunv/rom-tools/lib/cue"
+	"github.com/sargunv/rom-tools/lib/gdi"
+	"github.com/sargunv/rom-tools/lib/torrentzip"
+)
+
+// dvdSectorSize is the size of a sector of an ISO image, the unit of a DVD
+// CHD.
+const dvdSectorSize = 2048
+
+// Apply repacks every item in the plan that isn't skipped or passed through.
+// Each repacked file is written to a temporary file beside its target, read
+// back and checked against its sources, and only then renamed into place.
+// Sources are deleted afterwards if Options.DeleteSources is set.
+func (p *Plan) Apply() error {
+	for _, item := range p.Items {
+		if item.Skip != "" || item.Kind == KindPassthrough {
+			continue
+		}
+		if err := p.apply(item); err != nil {
+			return fmt.Errorf("failed to repack %s: %w", item.Path, err)
+		}
+	}
+	return nil
+}
+
+// apply repacks a single item.
+func (p *Plan) apply(item Item) error {
+	if err := os.MkdirAll(filepath.Dir(item.Target), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(item.Target), ".repack-*"+filepath.Ext(item.Target))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	switch item.Kind {
+	case KindZIP:
+		err = writeZIP(tmp, item)
+	case KindCHD:
+		err = writeCHD(tmp, item, p.opts.Compressors)
+	default:
+		err = fmt.Errorf("unknown kind %q", item.Kind)
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp.Name(), item.Target); err != nil {
+		return err
+	}
+	if !p.opts.DeleteSources {
+		return nil
+	}
+	var errs []error
+	for _, source := range item.Sources {
+		if source != item.Target {
+			errs = append(errs, os.Remove(source))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// zipEntry is the name, size, and CRC32 of a file in a ZIP archive, for
+// checking a repacked archive against its source.
+type zipEntry struct {
+	name string
+	size uint64
+	crc  uint32
+}
+
+// writeZIP writes the item as a TorrentZip archive to f, then checks the
+// archive's entries against the source.
+func writeZIP(f *os.File, item Item) error {
+	var files []torrentzip.File
+	var want []zipEntry
+
+	if strings.EqualFold(filepath.Ext(item.Path), ".zip") {
+		r, err := zip.OpenReader(item.Path)
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+		files = torrentzip.ZIPFiles(&r.Reader)
+		for _, zf := range r.File {
+			if i := indexFile(files, zf.Name); i >= 0 {
+				want = append(want, zipEntry{files[i].Name, zf.UncompressedSize64, zf.CRC32})
+			}
+		}
+	} else {
+		entry, err := fileEntry(item.Path)
+		if err != nil {
+			return err
+		}
+		want = append(want, entry)
+		files = []torrentzip.File{{
+			Name: entry.name,
+			Open: func() (io.ReadCloser, error) { return os.Open(item.Path) },
+		}}
+	}
+
+	if err := torrentzip.Write(f, files); err != nil {
+		return err
+	}
+	return verifyZIP(f, want)
+}
+
+// indexFile returns the index of the file written for the ZIP entry name, or
+// -1 if it's left out.
+func indexFile(files []torrentzip.File, name string) int {
+	name = strings.ReplaceAll(name, "\\", "/")
+	for i, file := range files {
+		if file.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// fileEntry returns the ZIP entry a loose file is stored as.
+func fileEntry(path string) (zipEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return zipEntry{}, err
+	}
+	defer f.Close()
+	h := crc32.NewIEEE()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return zipEntry{}, err
+	}
+	return zipEntry{filepath.Base(path), uint64(n), h.Sum32()}, nil
+}
+
+// verifyZIP reads back every entry of the archive in f, checking that it's a
+// TorrentZip archive holding exactly the wanted entries.
+func verifyZIP(f *os.File, want []zipEntry) error {
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if err := torrentzip.Verify(f, info.Size()); err != nil {
+		return err
+	}
+	r, err := zip.NewReader(f, info.Size())
+	if err != nil {
+		return err
+	}
+	if len(r.File) != len(want) {
+		return fmt.Errorf("verification failed: wrote %d entries, want %d", len(r.File), len(want))
+	}
+
+	for _, zf := range r.File {
+		i := -1
+		for j, entry := range want {
+			if entry.name == zf.Name {
+				i = j
+			}
+		}
+		if i < 0 {
+			return fmt.Errorf("verification failed: unexpected entry %s", zf.Name)
+		}
+		if zf.UncompressedSize64 != want[i].size || zf.CRC32 != want[i].crc {
+			return fmt.Errorf("verification failed: %s doesn't match its source", zf.Name)
+		}
+		// archive/zip checks the CRC32 of the decompressed data at EOF
+		rc, err := zf.Open()
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(io.Discard, rc)
+		rc.Close()
+		if err != nil {
+			return fmt.Errorf("verification failed: %s: %w", zf.Name, err)
+		}
+	}
+	return nil
+}
+
+// disc is a disc image opened for repacking. CD and GD-ROM images have
+// tracks; ISO images only have data.
+type disc struct {
+	tracks []chd.CDTrack
+	gdrom  bool
+	data   io.ReaderAt
+	size   int64
+	files  []*os.File
+}
+
+// Close closes the disc's files.
+func (d *disc) Close() error {
+	var errs []error
+	for _, f := range d.files {
+		errs = append(errs, f.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// openFile opens a file belonging to the disc, to be closed with it.
+func (d *disc) openFile(path string) (io.ReaderAt, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, 0, err
+	}
+	d.files = append(d.files, f)
+	return f, info.Size(), nil
+}
+
+// openDisc opens the CUE sheet, GDI sheet, or ISO image at path.
+func openDisc(path string) (*disc, error) {
+	d := &disc{}
+	r, size, err := d.openFile(path)
+	if err != nil {
+		return nil, err
+	}
+	open := func(name string) (io.ReaderAt, int64, error) {
+		return d.openFile(filepath.Join(filepath.Dir(path), filepath.FromSlash(name)))
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".cue":
+		var sheet *cue.Sheet
+		if sheet, err = cue.Parse(r, size); err == nil {
+			d.tracks, err = chd.CueTracks(sheet, open)
+		}
+	case ".gdi":
+		var sheet *gdi.Sheet
+		if sheet, err = gdi.Parse(r, size); err == nil {
+			d.tracks, err = chd.GDITracks(sheet, open)
+		}
+		d.gdrom = true
+	default:
+		d.data, d.size = r, size
+	}
+	if err != nil {
+		d.Close()
+		return nil, err
+	}
+	return d, nil
+}
+
+// writeCHD writes the item's disc image as a CHD to f, then checks the CHD's
+// tracks or data against the image.
+func writeCHD(f *os.File, item Item, compressors []chd.Codec) error {
+	d, err := openDisc(item.Path)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	switch {
+	case d.tracks != nil:
+		if len(compressors) == 0 {
+			compressors = []chd.Codec{chd.CodecCDLZMA, chd.CodecCDZlib}
+		}
+		if d.gdrom {
+			_, err = chd.WriteGD(f, d.tracks, compressors)
+		} else {
+			_, err = chd.WriteCD(f, d.tracks, compressors)
+		}
+	case d.size%dvdSectorSize != 0:
+		err = fmt.Errorf("ISO size %d is not a multiple of %d", d.size, dvdSectorSize)
+	default:
+		if len(compressors) == 0 {
+			compressors = []chd.Codec{chd.CodecLZMA, chd.CodecZlib}
+		}
+		err = writeDVD(f, d, compressors)
+	}
+	if err != nil {
+		return err
+	}
+	return verifyCHD(f, d)
+}
+
+// writeDVD writes the disc's data to f as a DVD CHD.
+func writeDVD(f *os.File, d *disc, compressors []chd.Codec) error {
+	w, err := chd.NewWriter(f, chd.WriterConfig{UnitBytes: dvdSectorSize, Compressors: compressors})
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(w, io.NewSectionReader(d.data, 0, d.size)); err != nil {
+		return err
+	}
+	// chdman marks DVD images with an empty DVD metadata entry
+	if err := w.AddMetadata(chd.TagDVD, []byte{0}); err != nil {
+		return err
+	}
+	_, err = w.Close()
+	return err
+}
+
+// verifyCHD reads back the CHD in f and checks that each of its tracks, or
+// its data, has the same SHA1 as the disc's.
+func verifyCHD(f *os.File, d *disc) error {
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	reader, err := chd.NewReader(f, info.Size())
+	if err != nil {
+		return err
+	}
+
+	if d.tracks == nil {
+		if reader.Size() != d.size {
+			return fmt.Errorf("verification failed: CHD holds %d bytes, want %d", reader.Size(), d.size)
+		}
+		return compareSHA1(reader, d.data, d.size, "data")
+	}
+
+	tracks, err := reader.CDTracks()
+	if err != nil {
+		return err
+	}
+	if len(tracks) != len(d.tracks) {
+		return fmt.Errorf("verification failed: CHD holds %d tracks, want %d", len(tracks), len(d.tracks))
+	}
+	for i, track := range d.tracks {
+		got := tracks[i]
+		if got.Type != track.Type || got.Frames != track.Frames {
+			return fmt.Errorf("verification failed: track %d is %s with %d frames, want %s with %d", i+1, got.Type, got.Frames, track.Type, track.Frames)
+		}
+		size := int64(track.Frames) * int64(chd.SectorSize(track.Type))
+		if err := compareSHA1(got.Data, track.Data, size, fmt.Sprintf("track %d", i+1)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compareSHA1 checks that the first size bytes of got and want have the same
+// SHA1.
+func compareSHA1(got, want io.ReaderAt, size int64, what string) error {
+	hashOf := func(r io.ReaderAt) ([]byte, error) {
+		h := sha1.New()
+		if _, err := io.Copy(h, io.NewSectionReader(r, 0, size)); err != nil {
+			return nil, err
+		}
+		return h.Sum(nil), nil
+	}
+	gotSum, err := hashOf(got)
+	if err != nil {
+		return fmt.Errorf("verification failed: %s: %w", what, err)
+	}
+	wantSum, err := hashOf(want)
+	if err != nil {
+		return fmt.Errorf("failed to hash %s: %w", what, err)
+	}
+	if !bytes.Equal(gotSum, wantSum) {
+		return fmt.Errorf("verification failed: %s doesn't match its source", what)
+	}
+	return nil
+}
@@ -0,0 +1,311 @@
+// Package repack rewrites ROMs into one storage format per kind of ROM:
+// cartridge ROMs and ZIP archives into TorrentZip archives, and CD, GD-ROM,
+// and DVD images into CHDs. Formats that are already the preferred ones for
+// their platform, like CHD and RVZ, are passed through unchanged.
+//
+// Repacking happens in two steps: NewPlan works out what to write without
+// touching anything, so the plan can be shown as a dry run, and Plan.Apply
+// carries it out. Every written file is read back and checked against its
+// sources before it replaces them.
+package repack
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/sargunv/rom-tools/lib/chd"
+	"github.com/sargunv/rom-tools/lib/cue"
+	"github.com/sargunv/rom-tools/lib/gdi"
+	"github.com/sargunv/rom-tools/lib/roms/nintendo/gcm"
+	"github.com/sargunv/rom-tools/lib/roms/xbox/xiso"
+	"github.com/sargunv/rom-tools/lib/torrentzip"
+)
+
+// Kind is the format an item is repacked into.
+type Kind string
+
+const (
+	KindZIP         Kind = "zip"         // a TorrentZip archive
+	KindCHD         Kind = "chd"         // a CHD, as chdman createcd, creategd, or createdvd would write
+	KindPassthrough Kind = "passthrough" // already in its preferred format, left as it is
+)
+
+// Skip reasons, for items that won't be repacked.
+const (
+	SkipExists      = "target exists"                      // a file other than the source already has the target name
+	SkipClaimed     = "target already taken"               // an earlier item is written to the target
+	SkipRepacked    = "already a TorrentZip archive"       // the ZIP is already in its canonical form
+	SkipGameCube    = "GameCube/Wii image: convert to RVZ" // CHD can't hold GameCube or Wii images
+	SkipXbox        = "Xbox image: not supported by CHD"   // CHD can't hold Xbox images
+	SkipUnreadable  = "unreadable disc image"              // the CUE or GDI sheet couldn't be parsed
+	SkipUnsupported = "unsupported format"                 // the file isn't a format this package repacks
+)
+
+// Options controls how a repack is planned and applied.
+type Options struct {
+	// Dest is the directory repacked files are written to. If empty, they're
+	// written next to their sources.
+	Dest string
+
+	// DeleteSources removes the source files of each item once its repacked
+	// file has been written and verified.
+	DeleteSources bool
+
+	// Compressors are the CHD codecs to use, in order of preference. If
+	// empty, chdman's defaults are used: cdlz and cdzl for CD and GD-ROM
+	// images, lzma and zlib for DVD images.
+	Compressors []chd.Codec
+}
+
+// Item is a single file, or CUE or GDI sheet and its track files, to repack.
+type Item struct {
+	Kind    Kind     `json:"kind"`
+	Path    string   `json:"path"`           // file, archive, or sheet to repack
+	Sources []string `json:"sources"`        // every file the item reads, Path first
+	Target  string   `json:"target"`         // path of the repacked file
+	Skip    string   `json:"skip,omitempty"` // why the item won't be repacked, if it won't
+}
+
+// Plan is the set of items that repack a collection.
+type Plan struct {
+	Items []Item `json:"items"`
+	opts  Options
+}
+
+// cartExtensions are the extensions of cartridge and other single-file ROMs,
+// which are stored in ZIP archives.
+var cartExtensions = []string{
+	".gba", ".gb", ".gbc", ".nds", ".dsi", ".ids", ".3ds", ".cci", ".nes", ".fds",
+	".sfc", ".smc", ".z64", ".v64", ".n64", ".md", ".gen", ".32x", ".smd", ".sms",
+	".gg", ".pce", ".lnx", ".a78", ".a26", ".j64", ".rom", ".mx1", ".mx2", ".crt",
+	".d64", ".t64", ".adf", ".dms", ".tap", ".tzx", ".z80", ".bin",
+}
+
+// passthroughExtensions are the extensions of formats that are already the
+// preferred ones for their platform.
+var passthroughExtensions = []string{
+	".chd", ".rvz", ".wia", ".wbfs", ".gcz", ".cso", ".zso", ".ciso", ".xiso",
+	".pbp", ".pkg", ".cia", ".7z",
+}
+
+// planner tracks the targets claimed while a plan is built, so that two items
+// aren't written to the same file.
+type planner struct {
+	opts    Options
+	plan    *Plan
+	claimed map[string]bool
+}
+
+// NewPlan works out how to repack the files at paths. Directories are walked
+// recursively. Track files referenced by a CUE or GDI sheet are repacked with
+// their sheet, not on their own.
+func NewPlan(paths []string, opts Options) (*Plan, error) {
+	files, err := listFiles(paths)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &planner{
+		opts:    opts,
+		plan:    &Plan{Items: []Item{}, opts: opts},
+		claimed: make(map[string]bool),
+	}
+
+	// Sheets go first, so their track files are known before loose files are
+	// planned
+	tracks := make(map[string]bool)
+	for _, path := range files {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".cue", ".gdi":
+			item := p.addSheet(path)
+			for _, source := range item.Sources[1:] {
+				tracks[source] = true
+			}
+		}
+	}
+
+	for _, path := range files {
+		if tracks[path] {
+			continue
+		}
+		ext := strings.ToLower(filepath.Ext(path))
+		switch {
+		case ext == ".cue" || ext == ".gdi":
+			// already planned
+		case ext == ".iso":
+			p.addISO(path)
+		case ext == ".zip":
+			p.addZIP(path)
+		case slices.Contains(cartExtensions, ext):
+			p.add(Item{Kind: KindZIP, Path: path, Sources: []string{path}, Target: p.target(path, ".zip")})
+		case slices.Contains(passthroughExtensions, ext):
+			p.plan.Items = append(p.plan.Items, Item{Kind: KindPassthrough, Path: path, Sources: []string{path}, Target: path})
+		default:
+			p.plan.Items = append(p.plan.Items, Item{Kind: KindPassthrough, Path: path, Sources: []string{path}, Target: path, Skip: SkipUnsupported})
+		}
+	}
+	return p.plan, nil
+}
+
+// listFiles returns the files at paths, walking directories, with duplicates
+// removed.
+func listFiles(paths []string) ([]string, error) {
+	var files []string
+	seen := make(map[string]bool)
+	addFile := func(path string) {
+		path = filepath.Clean(path)
+		if !seen[path] {
+			seen[path] = true
+			files = append(files, path)
+		}
+	}
+
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			addFile(path)
+			continue
+		}
+		err = filepath.WalkDir(path, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.Type().IsRegular() {
+				addFile(path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// target returns where the repacked form of path is written, with its
+// extension replaced by ext.
+func (p *planner) target(path, ext string) string {
+	dir := filepath.Dir(path)
+	if p.opts.Dest != "" {
+		dir = p.opts.Dest
+	}
+	base := filepath.Base(path)
+	return filepath.Join(dir, strings.TrimSuffix(base, filepath.Ext(base))+ext)
+}
+
+// add appends item to the plan, skipping it if its target is taken.
+func (p *planner) add(item Item) Item {
+	switch {
+	case item.Skip != "":
+	case p.claimed[item.Target]:
+		item.Skip = SkipClaimed
+	case !slices.Contains(item.Sources, item.Target) && exists(item.Target):
+		item.Skip = SkipExists
+	default:
+		p.claimed[item.Target] = true
+	}
+	p.plan.Items = append(p.plan.Items, item)
+	return item
+}
+
+// addSheet plans a CUE or GDI sheet and its track files as a CHD.
+func (p *planner) addSheet(path string) Item {
+	item := Item{Kind: KindCHD, Path: path, Sources: []string{path}, Target: p.target(path, ".chd")}
+	names, err := sheetFiles(path)
+	if err != nil {
+		item.Skip = SkipUnreadable
+		return p.add(item)
+	}
+	for _, name := range names {
+		source := filepath.Join(filepath.Dir(path), filepath.FromSlash(name))
+		if !slices.Contains(item.Sources, source) {
+			item.Sources = append(item.Sources, source)
+		}
+	}
+	return p.add(item)
+}
+
+// sheetFiles returns the names of the track files referenced by the CUE or
+// GDI sheet at path.
+func sheetFiles(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	r := strings.NewReader(string(data))
+
+	var names []string
+	if strings.EqualFold(filepath.Ext(path), ".gdi") {
+		sheet, err := gdi.Parse(r, int64(len(data)))
+		if err != nil {
+			return nil, err
+		}
+		for _, track := range sheet.Tracks {
+			names = append(names, track.File)
+		}
+		return names, nil
+	}
+
+	sheet, err := cue.Parse(r, int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range sheet.Files {
+		names = append(names, file.Name)
+	}
+	return names, nil
+}
+
+// addISO plans an ISO image as a DVD CHD, unless it's a GameCube, Wii, or
+// Xbox image, which CHD can't hold.
+func (p *planner) addISO(path string) {
+	item := Item{Kind: KindCHD, Path: path, Sources: []string{path}, Target: p.target(path, ".chd")}
+	if f, err := os.Open(path); err == nil {
+		if info, err := f.Stat(); err == nil {
+			if _, err := gcm.Parse(f, info.Size()); err == nil {
+				item.Skip = SkipGameCube
+			} else if _, err := xiso.Parse(f, info.Size()); err == nil {
+				item.Skip = SkipXbox
+			}
+		}
+		f.Close()
+	}
+	p.add(item)
+}
+
+// addZIP plans the rewrite of a ZIP archive as a TorrentZip archive. Archives
+// that already are TorrentZip archives are only copied, and only if Dest is
+// set.
+func (p *planner) addZIP(path string) {
+	item := Item{Kind: KindZIP, Path: path, Sources: []string{path}, Target: p.target(path, ".zip")}
+	if item.Target == path && isTorrentZip(path) {
+		item.Skip = SkipRepacked
+	}
+	p.add(item)
+}
+
+// isTorrentZip reports whether the file at path is a TorrentZip archive.
+func isTorrentZip(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return torrentzip.Verify(f, info.Size()) == nil
+}
+
+// exists reports whether a file exists at path.
+func exists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
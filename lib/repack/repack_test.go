@@ -0,0 +1,239 @@
+package repack
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sargunv/rom-tools/lib/chd"
+	"github.com/sargunv/rom-tools/lib/torrentzip"
+)
+
+// writeFile writes data to name in dir and returns its path.
+func writeFile(t *testing.T, dir, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// writeStoredZIP writes a ZIP that isn't a TorrentZip archive.
+func writeStoredZIP(t *testing.T, path string, files map[string][]byte) {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, data := range files {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Store})
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(data)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readZIP returns the contents of the entries of the ZIP at path, checking
+// that it's a TorrentZip archive.
+func readZIP(t *testing.T, path string) map[string][]byte {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := torrentzip.Verify(bytes.NewReader(data), int64(len(data))); err != nil {
+		t.Fatalf("%s is not a TorrentZip archive: %v", path, err)
+	}
+	r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := make(map[string][]byte)
+	for _, zf := range r.File {
+		rc, err := zf.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		buf.ReadFrom(rc)
+		rc.Close()
+		files[zf.Name] = buf.Bytes()
+	}
+	return files
+}
+
+func findItem(t *testing.T, plan *Plan, path string) Item {
+	t.Helper()
+	for _, item := range plan.Items {
+		if item.Path == path {
+			return item
+		}
+	}
+	t.Fatalf("no item for %s in %+v", path, plan.Items)
+	return Item{}
+}
+
+func TestRepack(t *testing.T) {
+	dir := t.TempDir()
+	rom := []byte("NES\x1a cartridge data")
+	nes := writeFile(t, dir, "Game.nes", rom)
+	archive := filepath.Join(dir, "Other.zip")
+	writeStoredZIP(t, archive, map[string][]byte{"b.gb": []byte("second"), "a.gb": []byte("first")})
+
+	// A two-frame MODE1/2048 track, described by a CUE sheet
+	track := bytes.Repeat([]byte{0x5a}, 2*2048)
+	bin := writeFile(t, dir, "Disc.bin", track)
+	cueSheet := writeFile(t, dir, "Disc.cue", []byte("FILE \"Disc.bin\" BINARY\n  TRACK 01 MODE1/2048\n    INDEX 01 00:00:00\n"))
+
+	iso := writeFile(t, dir, "DVD.iso", bytes.Repeat([]byte{0xa5}, 4*2048))
+	rvz := writeFile(t, dir, "Kept.rvz", []byte("RVZ\x01"))
+	txt := writeFile(t, dir, "notes.txt", []byte("hello"))
+
+	plan, err := NewPlan([]string{dir}, Options{DeleteSources: true})
+	if err != nil {
+		t.Fatalf("NewPlan() error = %v", err)
+	}
+
+	for _, tt := range []struct {
+		path   string
+		kind   Kind
+		target string
+		skip   string
+	}{
+		{nes, KindZIP, filepath.Join(dir, "Game.zip"), ""},
+		{archive, KindZIP, archive, ""},
+		{cueSheet, KindCHD, filepath.Join(dir, "Disc.chd"), ""},
+		{iso, KindCHD, filepath.Join(dir, "DVD.chd"), ""},
+		{rvz, KindPassthrough, rvz, ""},
+		{txt, KindPassthrough, txt, SkipUnsupported},
+	} {
+		item := findItem(t, plan, tt.path)
+		if item.Kind != tt.kind || item.Target != tt.target || item.Skip != tt.skip {
+			t.Errorf("item for %s = %+v, want kind %s, target %s, skip %q", tt.path, item, tt.kind, tt.target, tt.skip)
+		}
+	}
+	if got := findItem(t, plan, cueSheet).Sources; len(got) != 2 || got[1] != bin {
+		t.Errorf("CUE sources = %v, want the sheet and %s", got, bin)
+	}
+	for _, item := range plan.Items {
+		if item.Path == bin {
+			t.Errorf("track file planned on its own: %+v", item)
+		}
+	}
+
+	if err := plan.Apply(); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+
+	if got := readZIP(t, filepath.Join(dir, "Game.zip")); len(got) != 1 || !bytes.Equal(got["Game.nes"], rom) {
+		t.Errorf("Game.zip = %v", got)
+	}
+	if got := readZIP(t, archive); len(got) != 2 || string(got["a.gb"]) != "first" || string(got["b.gb"]) != "second" {
+		t.Errorf("Other.zip = %v", got)
+	}
+
+	for name, want := range map[string][]byte{"Disc.chd": track, "DVD.chd": bytes.Repeat([]byte{0xa5}, 4*2048)} {
+		f, err := os.Open(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		info, _ := f.Stat()
+		reader, err := chd.NewReader(f, info.Size())
+		if err != nil {
+			t.Fatalf("chd.NewReader(%s) error = %v", name, err)
+		}
+		var got []byte
+		if tracks, _ := reader.CDTracks(); len(tracks) > 0 {
+			got = make([]byte, tracks[0].Frames*2048)
+			tracks[0].Data.ReadAt(got, 0)
+		} else {
+			got = make([]byte, reader.Size())
+			reader.ReadAt(got, 0)
+		}
+		f.Close()
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s holds the wrong data", name)
+		}
+	}
+
+	for _, path := range []string{nes, bin, cueSheet, iso} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("source %s not deleted", path)
+		}
+	}
+	for _, path := range []string{archive, rvz, txt} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("%s removed: %v", path, err)
+		}
+	}
+
+	// Everything is now repacked
+	plan, err = NewPlan([]string{dir}, Options{})
+	if err != nil {
+		t.Fatalf("NewPlan() error = %v", err)
+	}
+	for _, item := range plan.Items {
+		if item.Skip == "" && item.Kind != KindPassthrough {
+			t.Errorf("item still to repack: %+v", item)
+		}
+	}
+}
+
+func TestNewPlan_Collisions(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "Game.nes", []byte("nes"))
+	writeFile(t, dir, "Game.gb", []byte("gb"))
+	writeFile(t, dir, "Taken.sms", []byte("sms"))
+	writeFile(t, dir, "Taken.zip", []byte("not a zip"))
+
+	plan, err := NewPlan([]string{
+		filepath.Join(dir, "Game.nes"),
+		filepath.Join(dir, "Game.gb"),
+		filepath.Join(dir, "Taken.sms"),
+	}, Options{})
+	if err != nil {
+		t.Fatalf("NewPlan() error = %v", err)
+	}
+
+	want := []string{"", SkipClaimed, SkipExists}
+	if len(plan.Items) != len(want) {
+		t.Fatalf("got %d items, want %d", len(plan.Items), len(want))
+	}
+	for i, item := range plan.Items {
+		if item.Skip != want[i] {
+			t.Errorf("item %s skip = %q, want %q", item.Path, item.Skip, want[i])
+		}
+	}
+}
+
+func TestApply_KeepsSourcesOnFailure(t *testing.T) {
+	dir := t.TempDir()
+	bin := writeFile(t, dir, "Disc.bin", make([]byte, 2*2048))
+	// The second track's file is missing
+	cueSheet := writeFile(t, dir, "Disc.cue", []byte("FILE \"Disc.bin\" BINARY\n  TRACK 01 MODE1/2048\n    INDEX 01 00:00:00\n"+
+		"FILE \"Missing.bin\" BINARY\n  TRACK 02 AUDIO\n    INDEX 01 00:00:00\n"))
+
+	plan, err := NewPlan([]string{dir}, Options{DeleteSources: true})
+	if err != nil {
+		t.Fatalf("NewPlan() error = %v", err)
+	}
+	if err := plan.Apply(); err == nil {
+		t.Fatal("Apply() succeeded, want error")
+	}
+	for _, path := range []string{bin, cueSheet} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("source %s removed: %v", path, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "Disc.chd")); !os.IsNotExist(err) {
+		t.Errorf("partial CHD left behind")
+	}
+}
@@ -0,0 +1,174 @@
+package retroarch
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/sargunv/rom-tools/lib/core"
+	"github.com/sargunv/rom-tools/lib/identify"
+)
+
+// Options controls how playlists are built from identify results.
+type Options struct {
+	// CoresDir, if set, is the directory of RetroArch's cores. Each
+	// playlist's default core is then set to the core suggested for its
+	// platform, if there is one, so its games launch without choosing one.
+	CoresDir string
+
+	// Platform, if set, is the platform of games whose own platform wasn't
+	// identified, like ROMs without a header.
+	Platform core.Platform
+}
+
+// Export is the set of playlists built from identify results.
+type Export struct {
+	Playlists map[string]*Playlist // playlists by file name (e.g., "Nintendo - Game Boy.lpl")
+	Skipped   []Skipped            // games that couldn't be added to a playlist
+}
+
+// Skipped is a game left out of the playlists, and why.
+type Skipped struct {
+	Path   string
+	Reason string
+}
+
+// NewExport builds playlists of the primary items of results, one per
+// platform, in the order of results. Entries record the CRC32 of their game
+// for RetroArch's database and thumbnails, and are labeled with the DAT
+// name of games matched by identify.Matcher, or else their file name.
+//
+// Single files are added as is, the files of folders by their paths, and
+// entries of ZIP archives, including those in folders, as
+// "archive.zip#entry". RetroArch can't read other archives, or archives
+// within archives, so their entries are skipped.
+func NewExport(results []*identify.Result, opts Options) (*Export, error) {
+	export := &Export{Playlists: make(map[string]*Playlist)}
+	for _, result := range results {
+		info, err := os.Stat(result.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat %s: %w", result.Path, err)
+		}
+
+		for _, item := range result.Items {
+			if !item.Primary {
+				continue
+			}
+			path, ok := itemPath(result.Path, info.IsDir(), item.Name)
+			if !ok {
+				export.skip(filepath.Join(result.Path, item.Name), "RetroArch can't read this archive")
+				continue
+			}
+
+			platform := opts.Platform
+			if item.Game != nil && item.Game.GamePlatform() != "" {
+				platform = item.Game.GamePlatform()
+			}
+			if platform == "" {
+				export.skip(path, "platform not identified")
+				continue
+			}
+			name := PlaylistName(platform)
+			if name == "" {
+				export.skip(path, fmt.Sprintf("no RetroArch database for %s", platform))
+				continue
+			}
+
+			playlist := export.playlist(name, platform, opts)
+			playlist.Items = append(playlist.Items, Entry{
+				Path:     path,
+				Label:    label(item),
+				CorePath: Detect,
+				CoreName: Detect,
+				CRC32:    CRC32(itemCRC32(item)),
+				DBName:   name,
+			})
+		}
+	}
+	return export, nil
+}
+
+// playlist returns the playlist called name, creating it for platform.
+func (e *Export) playlist(name string, platform core.Platform, opts Options) *Playlist {
+	if playlist, ok := e.Playlists[name]; ok {
+		return playlist
+	}
+	playlist := &Playlist{Version: Version, Items: []Entry{}}
+	if c, ok := SuggestedCore(platform); ok && opts.CoresDir != "" {
+		playlist.DefaultCorePath = c.Path(opts.CoresDir)
+		playlist.DefaultCoreName = c.Name
+	}
+	e.Playlists[name] = playlist
+	return playlist
+}
+
+func (e *Export) skip(path, reason string) {
+	e.Skipped = append(e.Skipped, Skipped{Path: path, Reason: reason})
+}
+
+// Names returns the file names of the playlists, sorted.
+func (e *Export) Names() []string {
+	return slices.Sorted(maps.Keys(e.Playlists))
+}
+
+// itemPath returns the path RetroArch loads an item by: the file itself, a
+// file of a folder, or "archive.zip#entry" for entries of ZIP archives, in
+// folders or not. Entries of other archives, and of archives nested in
+// ZIPs, can't be loaded.
+func itemPath(root string, isDir bool, name string) (string, bool) {
+	if !isDir {
+		if name == filepath.Base(root) {
+			return root, true
+		}
+		return zipEntryPath(root, name)
+	}
+
+	parts := strings.Split(filepath.ToSlash(name), "/")
+	path := root
+	for i, part := range parts {
+		path = filepath.Join(path, part)
+		info, err := os.Stat(path)
+		if err != nil {
+			return "", false
+		}
+		if info.IsDir() {
+			continue
+		}
+		if i == len(parts)-1 {
+			return path, true
+		}
+		return zipEntryPath(path, strings.Join(parts[i+1:], "/"))
+	}
+	return "", false
+}
+
+// zipEntryPath returns the path of the entry name of the archive at path, if
+// it's a ZIP archive and the entry isn't in an archive nested in it.
+func zipEntryPath(path, name string) (string, bool) {
+	if !strings.EqualFold(filepath.Ext(path), ".zip") || strings.Contains(strings.ToLower(name), ".zip/") {
+		return "", false
+	}
+	return path + "#" + filepath.ToSlash(name), true
+}
+
+// label returns the name an item is listed by: its game's DAT name, if it
+// was matched, or else its file name without the extension.
+func label(item identify.Item) string {
+	if item.Match != nil && item.Match.Status != identify.MatchStatusUnknown && item.Match.Game != "" {
+		return item.Match.Game
+	}
+	base := filepath.Base(filepath.FromSlash(item.Name))
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// itemCRC32 returns the CRC32 of an item's whole file, calculated or from
+// its ZIP archive, or "" if it has none.
+func itemCRC32(item identify.Item) string {
+	if crc := item.Hashes[core.HashCRC32]; crc != "" {
+		return crc
+	}
+	return item.Hashes[core.HashZipCRC32]
+}
@@ -0,0 +1,183 @@
+package retroarch
+
+import (
+	"archive/zip"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sargunv/rom-tools/lib/core"
+	"github.com/sargunv/rom-tools/lib/datfile"
+	"github.com/sargunv/rom-tools/lib/identify"
+)
+
+const (
+	alphaData = "alpha rom data"
+	betaData  = "beta rom data"
+)
+
+// identifyAll identifies paths, annotating them with a DAT of the Alpha ROM.
+func identifyAll(t *testing.T, paths ...string) []*identify.Result {
+	t.Helper()
+	dat, err := datfile.ParseReader(strings.NewReader(fmt.Sprintf(
+		`<?xml version="1.0"?><datafile><header><name>Test</name></header>`+
+			`<game name="Alpha (USA)"><description>Alpha</description><rom name="Alpha (USA).bin" size="%d" crc="%08x"/></game>`+
+			`</datafile>`, len(alphaData), crc32.ChecksumIEEE([]byte(alphaData)))))
+	if err != nil {
+		t.Fatalf("ParseReader() error = %v", err)
+	}
+	index := identify.NewDATIndex()
+	index.Add("", dat)
+	matcher := identify.NewMatcher(index)
+
+	var results []*identify.Result
+	for _, path := range paths {
+		result, err := identify.Identify(path, identify.DefaultOptions())
+		if err != nil {
+			t.Fatalf("Identify(%s) error = %v", path, err)
+		}
+		matcher.Annotate(result)
+		results = append(results, result)
+	}
+	return results
+}
+
+func writeZIP(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	for name, data := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNewExport(t *testing.T) {
+	dir := t.TempDir()
+	alpha := filepath.Join(dir, "alpha.bin")
+	if err := os.WriteFile(alpha, []byte(alphaData), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	beta := filepath.Join(dir, "beta.zip")
+	writeZIP(t, beta, map[string]string{"Beta (Japan).bin": betaData, "readme.txt": "hello"})
+
+	results := identifyAll(t, alpha, beta)
+	export, err := NewExport(results, Options{CoresDir: "/cores", Platform: core.PlatformGB})
+	if err != nil {
+		t.Fatalf("NewExport() error = %v", err)
+	}
+
+	if names := export.Names(); len(names) != 1 || names[0] != "Nintendo - Game Boy.lpl" {
+		t.Fatalf("Names() = %v, want [Nintendo - Game Boy.lpl]", names)
+	}
+	playlist := export.Playlists["Nintendo - Game Boy.lpl"]
+	if playlist.DefaultCoreName != "Nintendo - Game Boy / Color (Gambatte)" {
+		t.Errorf("DefaultCoreName = %q", playlist.DefaultCoreName)
+	}
+	if !strings.HasPrefix(playlist.DefaultCorePath, filepath.Join("/cores", "gambatte_libretro.")) {
+		t.Errorf("DefaultCorePath = %q", playlist.DefaultCorePath)
+	}
+
+	want := []Entry{
+		{
+			Path:     alpha,
+			Label:    "Alpha (USA)",
+			CorePath: Detect,
+			CoreName: Detect,
+			CRC32:    fmt.Sprintf("%08X|crc", crc32.ChecksumIEEE([]byte(alphaData))),
+			DBName:   "Nintendo - Game Boy.lpl",
+		},
+		{
+			Path:     beta + "#Beta (Japan).bin",
+			Label:    "Beta (Japan)",
+			CorePath: Detect,
+			CoreName: Detect,
+			CRC32:    fmt.Sprintf("%08X|crc", crc32.ChecksumIEEE([]byte(betaData))),
+			DBName:   "Nintendo - Game Boy.lpl",
+		},
+	}
+	if len(playlist.Items) != len(want) {
+		t.Fatalf("Items = %+v, want %+v", playlist.Items, want)
+	}
+	for i := range want {
+		if playlist.Items[i] != want[i] {
+			t.Errorf("Items[%d] = %+v, want %+v", i, playlist.Items[i], want[i])
+		}
+	}
+}
+
+func TestNewExport_Skipped(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "unknown.bin")
+	if err := os.WriteFile(path, []byte(alphaData), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without a platform, unidentified games have no playlist
+	export, err := NewExport(identifyAll(t, path), Options{})
+	if err != nil {
+		t.Fatalf("NewExport() error = %v", err)
+	}
+	if len(export.Playlists) != 0 {
+		t.Errorf("Playlists = %v, want none", export.Names())
+	}
+	if len(export.Skipped) != 1 || export.Skipped[0].Path != path {
+		t.Errorf("Skipped = %+v, want %s", export.Skipped, path)
+	}
+}
+
+func TestNewExport_Folder(t *testing.T) {
+	gb, err := os.ReadFile("../identify/testdata/gbtictac.gb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "sub", "tictac.gb"), gb, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "sub", "readme.txt"), []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	writeZIP(t, filepath.Join(dir, "tictac.zip"), map[string]string{"tictac.gb": string(gb)})
+
+	export, err := NewExport(identifyAll(t, dir), Options{})
+	if err != nil {
+		t.Fatalf("NewExport() error = %v", err)
+	}
+	playlist := export.Playlists["Nintendo - Game Boy.lpl"]
+	if playlist == nil {
+		t.Fatalf("Names() = %v, want Nintendo - Game Boy.lpl", export.Names())
+	}
+	if playlist.DefaultCorePath != "" {
+		t.Errorf("DefaultCorePath = %q, want none without CoresDir", playlist.DefaultCorePath)
+	}
+	var paths []string
+	for _, entry := range playlist.Items {
+		paths = append(paths, entry.Path)
+	}
+	want := []string{
+		filepath.Join(dir, "sub", "tictac.gb"),
+		filepath.Join(dir, "tictac.zip") + "#tictac.gb",
+	}
+	if strings.Join(paths, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Paths = %q, want %q", paths, want)
+	}
+}
@@ -0,0 +1,138 @@
+package retroarch
+
+import (
+	"path/filepath"
+	"runtime"
+
+	"github.com/sargunv/rom-tools/lib/core"
+)
+
+// system is the libretro database of a platform, and the core suggested for
+// its games.
+type system struct {
+	database string // libretro database name, which names its playlist
+	core     string // core library name without its extension, or "" if there's none
+	coreName string // display name of the core, as in its info file
+}
+
+// Core is a libretro core, as suggested for a platform's games.
+type Core struct {
+	Library string // library name without its extension (e.g., "gambatte_libretro")
+	Name    string // display name (e.g., "Nintendo - Game Boy / Color (Gambatte)")
+}
+
+// Path returns the path of the core's library in coresDir, with the
+// extension of libraries on the current OS.
+func (c Core) Path(coresDir string) string {
+	ext := ".so"
+	switch runtime.GOOS {
+	case "windows":
+		ext = ".dll"
+	case "darwin", "ios":
+		ext = ".dylib"
+	}
+	return filepath.Join(coresDir, c.Library+ext)
+}
+
+// DatabaseName returns the libretro database name of a platform (e.g.,
+// "Nintendo - Game Boy"), or "" if it has none.
+func DatabaseName(platform core.Platform) string {
+	return systems[platform].database
+}
+
+// PlaylistName returns the file name of the playlist of a platform's games
+// (e.g., "Nintendo - Game Boy.lpl"), or "" if it has no database.
+func PlaylistName(platform core.Platform) string {
+	if db := DatabaseName(platform); db != "" {
+		return db + ".lpl"
+	}
+	return ""
+}
+
+// SuggestedCore returns the core suggested for a platform's games, if one
+// emulates it.
+func SuggestedCore(platform core.Platform) (Core, bool) {
+	s, ok := systems[platform]
+	if !ok || s.core == "" {
+		return Core{}, false
+	}
+	return Core{Library: s.core, Name: s.coreName}, true
+}
+
+// Cores shared by several platforms.
+const (
+	genesisPlusGX     = "genesis_plus_gx_libretro"
+	genesisPlusGXName = "Sega - MS/GG/MD/CD (Genesis Plus GX)"
+	picoDrive         = "picodrive_libretro"
+	picoDriveName     = "Sega - MS/GG/MD/CD/32X (PicoDrive)"
+	mesen             = "mesen_libretro"
+	mesenName         = "Nintendo - NES / Famicom (Mesen)"
+	gambatte          = "gambatte_libretro"
+	gambatteName      = "Nintendo - Game Boy / Color (Gambatte)"
+	melonDS           = "melonds_libretro"
+	melonDSName       = "Nintendo - DS (melonDS)"
+	dolphin           = "dolphin_libretro"
+	dolphinName       = "Nintendo - GameCube / Wii (Dolphin)"
+	pceFast           = "mednafen_pce_fast_libretro"
+	pceFastName       = "NEC - PC Engine / CD (Beetle PCE FAST)"
+	blueMSX           = "bluemsx_libretro"
+	blueMSXName       = "Microsoft - MSX / SVI / ColecoVision / SG-1000 (blueMSX)"
+)
+
+// systems maps Platform values to their libretro databases and cores.
+var systems = map[core.Platform]system{
+	// Nintendo
+	core.PlatformNES:    {"Nintendo - Nintendo Entertainment System", mesen, mesenName},
+	core.PlatformFDS:    {"Nintendo - Family Computer Disk System", mesen, mesenName},
+	core.PlatformSNES:   {"Nintendo - Super Nintendo Entertainment System", "snes9x_libretro", "Nintendo - SNES / SFC (Snes9x - Current)"},
+	core.PlatformN64:    {"Nintendo - Nintendo 64", "mupen64plus_next_libretro", "Nintendo - Nintendo 64 (Mupen64Plus-Next)"},
+	core.PlatformGC:     {"Nintendo - GameCube", dolphin, dolphinName},
+	core.PlatformWii:    {"Nintendo - Wii", dolphin, dolphinName},
+	core.PlatformGB:     {"Nintendo - Game Boy", gambatte, gambatteName},
+	core.PlatformGBC:    {"Nintendo - Game Boy Color", gambatte, gambatteName},
+	core.PlatformGBA:    {"Nintendo - Game Boy Advance", "mgba_libretro", "Nintendo - Game Boy Advance (mGBA)"},
+	core.PlatformNDS:    {"Nintendo - Nintendo DS", melonDS, melonDSName},
+	core.PlatformDSi:    {"Nintendo - Nintendo DSi", melonDS, melonDSName},
+	core.Platform3DS:    {"Nintendo - Nintendo 3DS", "citra_libretro", "Nintendo - 3DS (Citra)"},
+	core.PlatformNew3DS: {"Nintendo - New Nintendo 3DS", "citra_libretro", "Nintendo - 3DS (Citra)"},
+	core.PlatformWiiU:   {"Nintendo - Wii U", "", ""},
+
+	// Sony
+	core.PlatformPS1: {"Sony - PlayStation", "mednafen_psx_hw_libretro", "Sony - PlayStation (Beetle PSX HW)"},
+	core.PlatformPS2: {"Sony - PlayStation 2", "pcsx2_libretro", "Sony - PlayStation 2 (PCSX2)"},
+	core.PlatformPS3: {"Sony - PlayStation 3", "", ""},
+	core.PlatformPSP: {"Sony - PlayStation Portable", "ppsspp_libretro", "Sony - PlayStation Portable (PPSSPP)"},
+
+	// Sega
+	core.PlatformMS:        {"Sega - Master System - Mark III", genesisPlusGX, genesisPlusGXName},
+	core.PlatformMD:        {"Sega - Mega Drive - Genesis", genesisPlusGX, genesisPlusGXName},
+	core.PlatformSegaCD:    {"Sega - Mega-CD - Sega CD", genesisPlusGX, genesisPlusGXName},
+	core.Platform32X:       {"Sega - 32X", picoDrive, picoDriveName},
+	core.PlatformPico:      {"Sega - PICO", picoDrive, picoDriveName},
+	core.PlatformSaturn:    {"Sega - Saturn", "mednafen_saturn_libretro", "Sega - Saturn (Beetle Saturn)"},
+	core.PlatformDreamcast: {"Sega - Dreamcast", "flycast_libretro", "Sega - Dreamcast/NAOMI (Flycast)"},
+	core.PlatformGameGear:  {"Sega - Game Gear", genesisPlusGX, genesisPlusGXName},
+
+	// NEC
+	core.PlatformPCE:   {"NEC - PC Engine - TurboGrafx 16", pceFast, pceFastName},
+	core.PlatformPCECD: {"NEC - PC Engine CD - TurboGrafx-CD", pceFast, pceFastName},
+
+	// SNK
+	core.PlatformNeoGeoCD: {"SNK - Neo Geo CD", "neocd_libretro", "SNK - Neo Geo CD (NeoCD)"},
+
+	// Atari
+	core.PlatformAtari2600: {"Atari - 2600", "stella_libretro", "Atari - 2600 (Stella)"},
+	core.PlatformAtari7800: {"Atari - 7800", "prosystem_libretro", "Atari - 7800 (ProSystem)"},
+	core.PlatformLynx:      {"Atari - Lynx", "handy_libretro", "Atari - Lynx (Handy)"},
+	core.PlatformJaguar:    {"Atari - Jaguar", "virtualjaguar_libretro", "Atari - Jaguar (Virtual Jaguar)"},
+
+	// Other
+	core.Platform3DO:        {"The 3DO Company - 3DO", "opera_libretro", "The 3DO Company - 3DO (Opera)"},
+	core.PlatformMSX:        {"Microsoft - MSX", blueMSX, blueMSXName},
+	core.PlatformMSX2:       {"Microsoft - MSX2", blueMSX, blueMSXName},
+	core.PlatformC64:        {"Commodore - 64", "vice_x64_libretro", "Commodore - C64 (VICE x64, fast)"},
+	core.PlatformAmiga:      {"Commodore - Amiga", "puae_libretro", "Commodore - Amiga (PUAE)"},
+	core.PlatformZXSpectrum: {"Sinclair - ZX Spectrum", "fuse_libretro", "Sinclair - ZX Spectrum (Fuse)"},
+	core.PlatformXbox:       {"Microsoft - Xbox", "", ""},
+	core.PlatformArcade:     {"MAME", "mame_libretro", "Arcade (MAME)"},
+}
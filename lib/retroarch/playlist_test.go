@@ -0,0 +1,61 @@
+package retroarch
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRoundtrip(t *testing.T) {
+	original := &Playlist{
+		DefaultCorePath: "/cores/gambatte_libretro.so",
+		DefaultCoreName: "Nintendo - Game Boy / Color (Gambatte)",
+		Items: []Entry{{
+			Path:     "/roms/gb/Tetris (World).zip#Tetris (World).gb",
+			Label:    "Tetris (World)",
+			CorePath: Detect,
+			CoreName: Detect,
+			CRC32:    CRC32("46df91ad"),
+			DBName:   "Nintendo - Game Boy.lpl",
+		}},
+	}
+
+	data, err := Write(original)
+	if err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	for _, want := range []string{`"version": "1.5"`, `"crc32": "46DF91AD|crc"`, `"core_path": "DETECT"`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("Write output missing %s:\n%s", want, data)
+		}
+	}
+
+	parsed, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	want := *original
+	want.Version = Version
+	if !reflect.DeepEqual(parsed, &want) {
+		t.Errorf("Roundtrip = %+v, want %+v", parsed, &want)
+	}
+}
+
+func TestWriteEmpty(t *testing.T) {
+	data, err := Write(&Playlist{})
+	if err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if !strings.Contains(string(data), `"items": []`) {
+		t.Errorf("Write output should have empty items:\n%s", data)
+	}
+}
+
+func TestCRC32(t *testing.T) {
+	if got := CRC32(""); got != Detect {
+		t.Errorf("CRC32(\"\") = %q, want %q", got, Detect)
+	}
+	if got := CRC32("0a1b2c3d"); got != "0A1B2C3D|crc" {
+		t.Errorf("CRC32(\"0a1b2c3d\") = %q, want %q", got, "0A1B2C3D|crc")
+	}
+}
@@ -0,0 +1,362 @@
+package retroarch
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+)
+
+// rdbMagic starts a libretro database file.
+var rdbMagic = []byte("RARCHDB\x00")
+
+// ErrNotRDB is returned when a file is not a libretro database.
+var ErrNotRDB = errors.New("not a libretro database")
+
+// RDBEntry is a game in a libretro database (.rdb file), as built from the
+// DATs of the libretro-database project.
+type RDBEntry struct {
+	Name         string `json:"name"`                    // e.g. "Tetris (World) (Rev 1)"
+	Description  string `json:"description,omitempty"`   // usually the same as the name
+	ROMName      string `json:"rom_name,omitempty"`      // e.g. "Tetris (World) (Rev 1).gb"
+	Size         uint64 `json:"size,omitempty"`          // ROM size in bytes
+	CRC32        string `json:"crc32,omitempty"`         // lowercase hex
+	MD5          string `json:"md5,omitempty"`           // lowercase hex
+	SHA1         string `json:"sha1,omitempty"`          // lowercase hex
+	Serial       string `json:"serial,omitempty"`        // e.g. "SLUS-00594"
+	Region       string `json:"region,omitempty"`        // e.g. "USA"
+	Developer    string `json:"developer,omitempty"`     // developer
+	Publisher    string `json:"publisher,omitempty"`     // publisher
+	Genre        string `json:"genre,omitempty"`         // genre
+	Franchise    string `json:"franchise,omitempty"`     // franchise
+	Users        int    `json:"users,omitempty"`         // number of players
+	ReleaseYear  int    `json:"release_year,omitempty"`  // 0 if unknown
+	ReleaseMonth int    `json:"release_month,omitempty"` // 1 to 12, or 0 if unknown
+}
+
+// Database is the games of a libretro database, indexed by their hashes,
+// serials, and ROM names.
+type Database struct {
+	Entries []RDBEntry
+
+	byCRC32   map[string]int
+	byMD5     map[string]int
+	bySHA1    map[string]int
+	bySerial  map[string]int
+	byROMName map[string]int
+}
+
+// OpenDatabase reads the libretro database at path.
+func OpenDatabase(path string) (*Database, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	db, err := ParseDatabase(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return db, nil
+}
+
+// ParseDatabase parses a libretro database: a header with the offset of its
+// metadata, followed by a MessagePack map for each game.
+func ParseDatabase(data []byte) (*Database, error) {
+	if len(data) < 16 || !bytes.Equal(data[:8], rdbMagic) {
+		return nil, ErrNotRDB
+	}
+	metadataOffset := binary.BigEndian.Uint64(data[8:16])
+	end := uint64(len(data))
+	if metadataOffset >= 16 && metadataOffset < end {
+		end = metadataOffset
+	}
+
+	db := &Database{
+		byCRC32:   make(map[string]int),
+		byMD5:     make(map[string]int),
+		bySHA1:    make(map[string]int),
+		bySerial:  make(map[string]int),
+		byROMName: make(map[string]int),
+	}
+	d := &msgpackDecoder{data: data[:end], pos: 16}
+	for d.pos < len(d.data) {
+		value, err := d.decode()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read entry %d: %w", len(db.Entries)+1, err)
+		}
+		if value == nil {
+			break // Terminates the entries
+		}
+		fields, ok := value.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("entry %d is not a map", len(db.Entries)+1)
+		}
+		db.add(rdbEntry(fields))
+	}
+	return db, nil
+}
+
+// add appends an entry, indexing it if its keys aren't taken by an earlier
+// one.
+func (db *Database) add(e RDBEntry) {
+	i := len(db.Entries)
+	db.Entries = append(db.Entries, e)
+	index := func(m map[string]int, key string) {
+		if _, ok := m[key]; key != "" && !ok {
+			m[key] = i
+		}
+	}
+	index(db.byCRC32, e.CRC32)
+	index(db.byMD5, e.MD5)
+	index(db.bySHA1, e.SHA1)
+	index(db.bySerial, strings.ToUpper(e.Serial))
+	index(db.byROMName, e.ROMName)
+}
+
+// ByCRC32 returns the entry with a CRC32, in hex.
+func (db *Database) ByCRC32(crc string) (*RDBEntry, bool) {
+	return db.lookup(db.byCRC32, strings.ToLower(crc))
+}
+
+// ByMD5 returns the entry with an MD5, in hex.
+func (db *Database) ByMD5(md5 string) (*RDBEntry, bool) {
+	return db.lookup(db.byMD5, strings.ToLower(md5))
+}
+
+// BySHA1 returns the entry with a SHA1, in hex.
+func (db *Database) BySHA1(sha1 string) (*RDBEntry, bool) {
+	return db.lookup(db.bySHA1, strings.ToLower(sha1))
+}
+
+// BySerial returns the entry with a serial, ignoring case.
+func (db *Database) BySerial(serial string) (*RDBEntry, bool) {
+	return db.lookup(db.bySerial, strings.ToUpper(serial))
+}
+
+// ByROMName returns the entry with a ROM file name.
+func (db *Database) ByROMName(name string) (*RDBEntry, bool) {
+	return db.lookup(db.byROMName, name)
+}
+
+func (db *Database) lookup(m map[string]int, key string) (*RDBEntry, bool) {
+	if key == "" {
+		return nil, false
+	}
+	i, ok := m[key]
+	if !ok {
+		return nil, false
+	}
+	return &db.Entries[i], true
+}
+
+// rdbEntry returns the entry of the fields of a database map. Hashes are
+// stored as binary, and serials as either binary or strings.
+func rdbEntry(fields map[string]any) RDBEntry {
+	str := func(key string) string {
+		switch v := fields[key].(type) {
+		case string:
+			return v
+		case []byte:
+			return string(v)
+		}
+		return ""
+	}
+	hash := func(key string) string {
+		switch v := fields[key].(type) {
+		case []byte:
+			return hex.EncodeToString(v)
+		case string:
+			return strings.ToLower(v)
+		}
+		return ""
+	}
+	num := func(key string) uint64 {
+		switch v := fields[key].(type) {
+		case uint64:
+			return v
+		case int64:
+			if v > 0 {
+				return uint64(v)
+			}
+		}
+		return 0
+	}
+
+	return RDBEntry{
+		Name:         str("name"),
+		Description:  str("description"),
+		ROMName:      str("rom_name"),
+		Size:         num("size"),
+		CRC32:        hash("crc"),
+		MD5:          hash("md5"),
+		SHA1:         hash("sha1"),
+		Serial:       str("serial"),
+		Region:       str("region"),
+		Developer:    str("developer"),
+		Publisher:    str("publisher"),
+		Genre:        str("genre"),
+		Franchise:    str("franchise"),
+		Users:        int(min(num("users"), math.MaxInt32)),
+		ReleaseYear:  int(min(num("releaseyear"), 9999)),
+		ReleaseMonth: int(min(num("releasemonth"), 12)),
+	}
+}
+
+// msgpackDecoder decodes the subset of MessagePack used by libretro
+// databases: maps, arrays, strings, binary, integers, floats, booleans, and
+// nil.
+type msgpackDecoder struct {
+	data []byte
+	pos  int
+}
+
+// decode returns the next value: nil, bool, int64, uint64, float64, string,
+// []byte, []any, or map[string]any.
+func (d *msgpackDecoder) decode() (any, error) {
+	b, err := d.readByte()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case b <= 0x7f:
+		return uint64(b), nil
+	case b >= 0xe0:
+		return int64(int8(b)), nil
+	case b&0xf0 == 0x80:
+		return d.decodeMap(int(b & 0x0f))
+	case b&0xf0 == 0x90:
+		return d.decodeArray(int(b & 0x0f))
+	case b&0xe0 == 0xa0:
+		return d.readString(int(b & 0x1f))
+	}
+
+	switch b {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6:
+		n, err := d.readUint(1 << (b - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+		data, err := d.readBytes(int(n))
+		if err != nil {
+			return nil, err
+		}
+		return bytes.Clone(data), nil
+	case 0xca:
+		n, err := d.readUint(4)
+		return float64(math.Float32frombits(uint32(n))), err
+	case 0xcb:
+		n, err := d.readUint(8)
+		return math.Float64frombits(n), err
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		return d.readUint(1 << (b - 0xcc))
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (b - 0xd0)
+		n, err := d.readUint(size)
+		if err != nil {
+			return nil, err
+		}
+		// Sign-extend from the size of the value
+		shift := 64 - 8*size
+		return int64(n<<shift) >> shift, nil
+	case 0xd9, 0xda, 0xdb:
+		n, err := d.readUint(1 << (b - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return d.readString(int(n))
+	case 0xdc, 0xdd:
+		n, err := d.readUint(2 << (b - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeArray(int(n))
+	case 0xde, 0xdf:
+		n, err := d.readUint(2 << (b - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeMap(int(n))
+	}
+	return nil, fmt.Errorf("unsupported MessagePack type 0x%02x at offset %d", b, d.pos-1)
+}
+
+func (d *msgpackDecoder) decodeMap(n int) (map[string]any, error) {
+	m := make(map[string]any, min(n, 64))
+	for range n {
+		key, err := d.decode()
+		if err != nil {
+			return nil, err
+		}
+		value, err := d.decode()
+		if err != nil {
+			return nil, err
+		}
+		switch k := key.(type) {
+		case string:
+			m[k] = value
+		case []byte:
+			m[string(k)] = value
+		}
+	}
+	return m, nil
+}
+
+func (d *msgpackDecoder) decodeArray(n int) ([]any, error) {
+	a := make([]any, 0, min(n, 64))
+	for range n {
+		value, err := d.decode()
+		if err != nil {
+			return nil, err
+		}
+		a = append(a, value)
+	}
+	return a, nil
+}
+
+func (d *msgpackDecoder) readByte() (byte, error) {
+	if d.pos >= len(d.data) {
+		return 0, io.ErrUnexpectedEOF
+	}
+	b := d.data[d.pos]
+	d.pos++
+	return b, nil
+}
+
+func (d *msgpackDecoder) readBytes(n int) ([]byte, error) {
+	if n < 0 || n > len(d.data)-d.pos {
+		return nil, io.ErrUnexpectedEOF
+	}
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
+
+// readUint reads a big-endian unsigned integer of size bytes.
+func (d *msgpackDecoder) readUint(size int) (uint64, error) {
+	b, err := d.readBytes(size)
+	if err != nil {
+		return 0, err
+	}
+	var n uint64
+	for _, c := range b {
+		n = n<<8 | uint64(c)
+	}
+	return n, nil
+}
+
+func (d *msgpackDecoder) readString(n int) (string, error) {
+	b, err := d.readBytes(n)
+	return string(b), err
+}
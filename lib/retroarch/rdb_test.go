@@ -0,0 +1,182 @@
+package retroarch
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// msgpackEncoder writes the MessagePack of libretro databases, for tests
+type msgpackEncoder []byte
+
+func (e *msgpackEncoder) str(s string) {
+	switch {
+	case len(s) < 32:
+		*e = append(*e, 0xa0|byte(len(s)))
+	default:
+		*e = append(*e, 0xd9, byte(len(s)))
+	}
+	*e = append(*e, s...)
+}
+
+func (e *msgpackEncoder) bin(b []byte) {
+	*e = append(*e, 0xc4, byte(len(b)))
+	*e = append(*e, b...)
+}
+
+func (e *msgpackEncoder) uint(n uint64) {
+	switch {
+	case n < 0x80:
+		*e = append(*e, byte(n))
+	case n < 0x10000:
+		*e = append(*e, 0xcd, byte(n>>8), byte(n))
+	default:
+		*e = binary.BigEndian.AppendUint64(append(*e, 0xcf), n)
+	}
+}
+
+// entry writes a map of fields, whose values are strings, []byte, or uint64
+func (e *msgpackEncoder) entry(fields ...any) {
+	*e = append(*e, 0xde, 0, byte(len(fields)/2))
+	for i := 0; i < len(fields); i += 2 {
+		e.str(fields[i].(string))
+		switch v := fields[i+1].(type) {
+		case string:
+			e.str(v)
+		case []byte:
+			e.bin(v)
+		case uint64:
+			e.uint(v)
+		}
+	}
+}
+
+func mustHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// testRDB returns a database of two Game Boy games
+func testRDB() []byte {
+	var e msgpackEncoder
+	e = append(e, rdbMagic...)
+	e = append(e, make([]byte, 8)...) // metadata offset, set below
+
+	e.entry(
+		"name", "Tetris (World) (Rev 1)",
+		"description", "Tetris (World) (Rev 1)",
+		"rom_name", "Tetris (World) (Rev 1).gb",
+		"size", uint64(32768),
+		"crc", mustHex("46df91ad"),
+		"md5", mustHex("084f1e457749cdec86183189bd88ce69"),
+		"sha1", mustHex("74591cc9501af93873f9a5d3eb12da12c0723bbc"),
+		"users", uint64(2),
+		"releaseyear", uint64(1989),
+		"releasemonth", uint64(6),
+		"developer", "Bullet-Proof Software",
+		"publisher", "Nintendo",
+		"genre", "Puzzle",
+		"region", "World",
+	)
+	e.entry(
+		"name", "Medarot (Japan)",
+		"rom_name", "Medarot (Japan).gb",
+		"serial", []byte("DMG-AMEJ-JPN"),
+		"crc", mustHex("12345678"),
+	)
+	e = append(e, 0xc0) // end of entries
+
+	binary.BigEndian.PutUint64(e[8:16], uint64(len(e)))
+	e = append(e, 0x81)
+	e.str("count")
+	e.uint(2)
+	return e
+}
+
+func TestParseDatabase(t *testing.T) {
+	db, err := ParseDatabase(testRDB())
+	if err != nil {
+		t.Fatalf("ParseDatabase() error = %v", err)
+	}
+	if len(db.Entries) != 2 {
+		t.Fatalf("Entries = %d, want 2", len(db.Entries))
+	}
+
+	want := RDBEntry{
+		Name:         "Tetris (World) (Rev 1)",
+		Description:  "Tetris (World) (Rev 1)",
+		ROMName:      "Tetris (World) (Rev 1).gb",
+		Size:         32768,
+		CRC32:        "46df91ad",
+		MD5:          "084f1e457749cdec86183189bd88ce69",
+		SHA1:         "74591cc9501af93873f9a5d3eb12da12c0723bbc",
+		Region:       "World",
+		Developer:    "Bullet-Proof Software",
+		Publisher:    "Nintendo",
+		Genre:        "Puzzle",
+		Users:        2,
+		ReleaseYear:  1989,
+		ReleaseMonth: 6,
+	}
+	if db.Entries[0] != want {
+		t.Errorf("Entries[0] = %+v, want %+v", db.Entries[0], want)
+	}
+
+	tests := []struct {
+		name   string
+		lookup func() (*RDBEntry, bool)
+		want   string
+	}{
+		{name: "crc32", lookup: func() (*RDBEntry, bool) { return db.ByCRC32("46DF91AD") }, want: "Tetris (World) (Rev 1)"},
+		{name: "md5", lookup: func() (*RDBEntry, bool) { return db.ByMD5("084f1e457749cdec86183189bd88ce69") }, want: "Tetris (World) (Rev 1)"},
+		{name: "sha1", lookup: func() (*RDBEntry, bool) { return db.BySHA1("74591CC9501AF93873F9A5D3EB12DA12C0723BBC") }, want: "Tetris (World) (Rev 1)"},
+		{name: "binary serial", lookup: func() (*RDBEntry, bool) { return db.BySerial("dmg-amej-jpn") }, want: "Medarot (Japan)"},
+		{name: "rom name", lookup: func() (*RDBEntry, bool) { return db.ByROMName("Medarot (Japan).gb") }, want: "Medarot (Japan)"},
+		{name: "unknown", lookup: func() (*RDBEntry, bool) { return db.ByCRC32("00000000") }},
+		{name: "empty", lookup: func() (*RDBEntry, bool) { return db.BySerial("") }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, ok := tt.lookup()
+			if ok != (tt.want != "") {
+				t.Fatalf("found = %v, want %v", ok, tt.want != "")
+			}
+			if ok && e.Name != tt.want {
+				t.Errorf("Name = %q, want %q", e.Name, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDatabase_Invalid(t *testing.T) {
+	if _, err := ParseDatabase([]byte("not a database at all")); !errors.Is(err, ErrNotRDB) {
+		t.Errorf("ParseDatabase() error = %v, want ErrNotRDB", err)
+	}
+
+	// Truncated mid-entry
+	data := testRDB()
+	binary.BigEndian.PutUint64(data[8:16], 0)
+	if _, err := ParseDatabase(data[:40]); err == nil {
+		t.Error("ParseDatabase() of a truncated database error = nil")
+	}
+}
+
+func TestOpenDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "Nintendo - Game Boy.rdb")
+	if err := os.WriteFile(path, testRDB(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	db, err := OpenDatabase(path)
+	if err != nil {
+		t.Fatalf("OpenDatabase() error = %v", err)
+	}
+	if len(db.Entries) != 2 {
+		t.Errorf("Entries = %d, want 2", len(db.Entries))
+	}
+}
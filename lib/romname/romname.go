@@ -0,0 +1,441 @@
+// Package romname parses the tags of No-Intro, Redump, and TOSEC style ROM
+// names into their regions, languages, revision, disc, and dump flags, e.g.:
+//
+//	Title (Region1, Region2) (En,Fr,De) (Rev 1) (Disc 2) (Beta) [b]
+//	Title v1.1 (1995)(Publisher)(US-EU)(en-de)(Disk 1 of 2)[cr][!]
+package romname
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/sargunv/rom-tools/lib/core"
+)
+
+// Name is the information in the tags of a ROM name.
+type Name struct {
+	Title      string        `json:"title"`                // name without any tags
+	Regions    []core.Region `json:"regions"`              // regions from the region tag, in order
+	Languages  []string      `json:"languages,omitempty"`  // lowercase language codes, e.g. "en" or "pt-br"
+	Revision   string        `json:"revision,omitempty"`   // revision or version, e.g. "1", "A", or "1.1"
+	Prerelease bool          `json:"prerelease,omitempty"` // alpha, beta, prototype, demo, sample, kiosk, or preview
+	Disc       int           `json:"disc,omitempty"`       // disc or disk number, from 1, or 0 if not tagged
+	Discs      int           `json:"discs,omitempty"`      // number of discs, or 0 if not tagged
+	Date       string        `json:"date,omitempty"`       // TOSEC date, e.g. "1995", "199x", or "1995-03-12"
+	Publisher  string        `json:"publisher,omitempty"`  // TOSEC publisher
+
+	Verified    bool   `json:"verified,omitempty"`    // [!] known good dump
+	BadDump     bool   `json:"bad_dump,omitempty"`    // [b] bad dump
+	Overdump    bool   `json:"overdump,omitempty"`    // [o] overdump
+	Alternate   bool   `json:"alternate,omitempty"`   // [a] or (Alt) alternate dump
+	Fixed       bool   `json:"fixed,omitempty"`       // [f] fixed
+	Hacked      bool   `json:"hacked,omitempty"`      // [h] or (Hack)
+	Trained     bool   `json:"trained,omitempty"`     // [t] trainer
+	Cracked     bool   `json:"cracked,omitempty"`     // [cr] cracked
+	Pirate      bool   `json:"pirate,omitempty"`      // [p] or (Pirate)
+	Unlicensed  bool   `json:"unlicensed,omitempty"`  // (Unl)
+	Translation string `json:"translation,omitempty"` // [T+Eng] or [tr en] translation language, e.g. "Eng" or "en"
+
+	// Tags are the tags other than the region, language, and revision tags,
+	// without parentheses or brackets, including those read into the fields
+	// above (e.g. "Beta" or "b").
+	Tags []string `json:"tags,omitempty"`
+}
+
+// regionNames maps the region names used in No-Intro and Redump tags to
+// regions. Names for areas without a region of their own map to the
+// closest broader one.
+var regionNames = map[string]core.Region{
+	"World":                core.RegionWorld,
+	"Europe":               core.RegionEurope,
+	"Asia":                 core.RegionAsia,
+	"Americas":             core.RegionAmericas,
+	"Latin America":        core.RegionAmericas,
+	"Oceania":              core.RegionOceania,
+	"Middle East":          core.RegionMiddleEast,
+	"Africa":               core.RegionAfrica,
+	"Germany":              core.RegionGermany,
+	"France":               core.RegionFrance,
+	"UK":                   core.RegionUK,
+	"United Kingdom":       core.RegionUK,
+	"Spain":                core.RegionSpain,
+	"Italy":                core.RegionItaly,
+	"Netherlands":          core.RegionNetherlands,
+	"Sweden":               core.RegionSweden,
+	"Denmark":              core.RegionDenmark,
+	"Finland":              core.RegionFinland,
+	"Norway":               core.RegionNorway,
+	"Scandinavia":          core.RegionEurope,
+	"Portugal":             core.RegionPortugal,
+	"Poland":               core.RegionPoland,
+	"Czech":                core.RegionCzechia,
+	"Czechia":              core.RegionCzechia,
+	"Hungary":              core.RegionHungary,
+	"Slovakia":             core.RegionSlovakia,
+	"Bulgaria":             core.RegionBulgaria,
+	"Greece":               core.RegionGreece,
+	"Russia":               core.RegionRussia,
+	"Japan":                core.RegionJapan,
+	"China":                core.RegionChina,
+	"Hong Kong":            core.RegionChina,
+	"Korea":                core.RegionKorea,
+	"Taiwan":               core.RegionTaiwan,
+	"USA":                  core.RegionUSA,
+	"Canada":               core.RegionCanada,
+	"Brazil":               core.RegionBrazil,
+	"Mexico":               core.RegionMexico,
+	"Chile":                core.RegionChile,
+	"Peru":                 core.RegionPeru,
+	"Australia":            core.RegionAustralia,
+	"New Zealand":          core.RegionNewZealand,
+	"Israel":               core.RegionIsrael,
+	"Turkey":               core.RegionTurkey,
+	"Kuwait":               core.RegionKuwait,
+	"United Arab Emirates": core.RegionUAE,
+	"South Africa":         core.RegionSouthAfrica,
+}
+
+// countryCodes maps the country codes used in TOSEC tags to regions.
+var countryCodes = map[string]core.Region{
+	"AE": core.RegionUAE,
+	"AU": core.RegionAustralia,
+	"BG": core.RegionBulgaria,
+	"BR": core.RegionBrazil,
+	"CA": core.RegionCanada,
+	"CL": core.RegionChile,
+	"CN": core.RegionChina,
+	"CZ": core.RegionCzechia,
+	"DE": core.RegionGermany,
+	"DK": core.RegionDenmark,
+	"ES": core.RegionSpain,
+	"EU": core.RegionEurope,
+	"FI": core.RegionFinland,
+	"FR": core.RegionFrance,
+	"GB": core.RegionUK,
+	"GR": core.RegionGreece,
+	"HK": core.RegionChina,
+	"HU": core.RegionHungary,
+	"IL": core.RegionIsrael,
+	"IT": core.RegionItaly,
+	"JP": core.RegionJapan,
+	"KR": core.RegionKorea,
+	"KW": core.RegionKuwait,
+	"MX": core.RegionMexico,
+	"NL": core.RegionNetherlands,
+	"NO": core.RegionNorway,
+	"NZ": core.RegionNewZealand,
+	"PE": core.RegionPeru,
+	"PL": core.RegionPoland,
+	"PT": core.RegionPortugal,
+	"RU": core.RegionRussia,
+	"SE": core.RegionSweden,
+	"SK": core.RegionSlovakia,
+	"TR": core.RegionTurkey,
+	"TW": core.RegionTaiwan,
+	"US": core.RegionUSA,
+	"ZA": core.RegionSouthAfrica,
+}
+
+// prereleaseWords are the first words (before a space or hyphen) of tags,
+// in lower case, that mark a release that isn't final.
+var prereleaseWords = map[string]bool{
+	"alpha":     true,
+	"beta":      true,
+	"proto":     true,
+	"prototype": true,
+	"demo":      true,
+	"sample":    true,
+	"kiosk":     true,
+	"preview":   true,
+	"pre":       true, // TOSEC "pre-release"
+}
+
+// tag is a tag of a name, and whether it's in brackets.
+type tag struct {
+	text    string
+	bracket bool
+}
+
+// Parse reads the tags of a ROM name. The first tag of region names (or
+// TOSEC country codes) is the region tag; a tag of language codes is the
+// language tag. A TOSEC date as the first tag is followed by the publisher.
+func Parse(name string) Name {
+	n := Name{Regions: []core.Region{}}
+
+	end := len(name)
+	for _, sep := range []string{" (", " ["} {
+		if i := strings.Index(name, sep); i >= 0 && i < end {
+			end = i
+		}
+	}
+	n.Title = strings.TrimSpace(name[:end])
+
+	tags := splitTags(name[end:])
+	for i, t := range tags {
+		if t.bracket {
+			n.parseFlag(t.text)
+			n.Tags = append(n.Tags, t.text)
+			continue
+		}
+
+		switch {
+		case len(n.Regions) == 0 && n.parseRegions(t.text):
+			continue
+		case n.Languages == nil && n.parseLanguages(t.text):
+			continue
+		case n.Revision == "" && n.parseRevision(t.text):
+			continue
+		case i == 0 && isDate(t.text):
+			n.Date = t.text
+			n.parseTitleVersion()
+		case i == 1 && n.Date != "":
+			if t.text != "-" {
+				n.Publisher = t.text
+			}
+		case n.Disc == 0 && n.parseDisc(t.text):
+		default:
+			n.parseTag(t.text)
+		}
+		n.Tags = append(n.Tags, t.text)
+	}
+	return n
+}
+
+// ParseFile reads the tags of a ROM file name, without its directory or
+// extension.
+func ParseFile(path string) Name {
+	base := filepath.Base(path)
+	return Parse(strings.TrimSuffix(base, filepath.Ext(base)))
+}
+
+// splitTags returns the parenthesized and bracketed tags in s.
+func splitTags(s string) []tag {
+	var tags []tag
+	depth, start := 0, 0
+	for i, c := range s {
+		switch c {
+		case '(', '[':
+			if depth == 0 {
+				start = i + 1
+			}
+			depth++
+		case ')', ']':
+			if depth == 0 {
+				continue
+			}
+			depth--
+			if depth == 0 {
+				tags = append(tags, tag{text: strings.TrimSpace(s[start:i]), bracket: c == ']'})
+			}
+		}
+	}
+	return tags
+}
+
+// parseRegions parses a region tag, e.g. "USA, Europe", or TOSEC's
+// "US-EU".
+func (n *Name) parseRegions(tag string) bool {
+	var regions []core.Region
+	for _, part := range strings.Split(tag, ",") {
+		region, ok := regionNames[strings.TrimSpace(part)]
+		if !ok {
+			regions = nil
+			break
+		}
+		regions = append(regions, region)
+	}
+	if regions == nil {
+		for _, code := range strings.Split(tag, "-") {
+			region, ok := countryCodes[code]
+			if !ok {
+				return false
+			}
+			regions = append(regions, region)
+		}
+	}
+	n.Regions = regions
+	return true
+}
+
+// parseLanguages parses a language tag, e.g. "En,Fr,De" or "En+Ja" (for
+// games with languages in separate modes), or "Pt-BR" for a variant, or
+// TOSEC's "en-de".
+func (n *Name) parseLanguages(tag string) bool {
+	var languages []string
+	if tag == strings.ToLower(tag) {
+		for _, code := range strings.Split(tag, "-") {
+			if len(code) != 2 || !isLower(code[0]) || !isLower(code[1]) {
+				return false
+			}
+			languages = append(languages, code)
+		}
+		n.Languages = languages
+		return true
+	}
+
+	for _, part := range strings.FieldsFunc(tag, func(r rune) bool { return r == ',' || r == '+' }) {
+		code, variant, _ := strings.Cut(part, "-")
+		if len(code) != 2 || !isUpper(code[0]) || !isLower(code[1]) {
+			return false
+		}
+		if variant != "" && (len(variant) < 2 || len(variant) > 4) {
+			return false
+		}
+		languages = append(languages, strings.ToLower(part))
+	}
+	if len(languages) == 0 {
+		return false
+	}
+	n.Languages = languages
+	return true
+}
+
+// parseRevision parses a revision tag, e.g. "Rev 1", "Rev A", or "v1.1".
+func (n *Name) parseRevision(tag string) bool {
+	if rev, ok := strings.CutPrefix(tag, "Rev "); ok && rev != "" && !strings.Contains(rev, " ") {
+		n.Revision = rev
+		return true
+	}
+	if v, ok := strings.CutPrefix(tag, "v"); ok && v != "" && isDigit(v[0]) && !strings.Contains(v, " ") {
+		n.Revision = v
+		return true
+	}
+	return false
+}
+
+// parseTitleVersion moves a TOSEC version at the end of the title, e.g.
+// "Title v1.1", to the revision.
+func (n *Name) parseTitleVersion() {
+	i := strings.LastIndex(n.Title, " v")
+	if i < 0 || n.Revision != "" {
+		return
+	}
+	if v := n.Title[i+2:]; v != "" && isDigit(v[0]) && !strings.Contains(v, " ") {
+		n.Revision = v
+		n.Title = n.Title[:i]
+	}
+}
+
+// parseDisc parses a disc tag, e.g. "Disc 2" or TOSEC's "Disk 1 of 2".
+func (n *Name) parseDisc(tag string) bool {
+	rest, ok := strings.CutPrefix(tag, "Disc ")
+	if !ok {
+		rest, ok = strings.CutPrefix(tag, "Disk ")
+	}
+	if !ok {
+		return false
+	}
+	num, total, hasTotal := strings.Cut(rest, " of ")
+	disc, err := strconv.Atoi(num)
+	if err != nil || disc < 1 {
+		return false
+	}
+	if hasTotal {
+		discs, err := strconv.Atoi(total)
+		if err != nil || discs < disc {
+			return false
+		}
+		n.Discs = discs
+	}
+	n.Disc = disc
+	return true
+}
+
+// parseTag reads the flags of a parenthesized tag, e.g. "Beta 2", "Unl",
+// or "Alt 1".
+func (n *Name) parseTag(tag string) {
+	word, _, _ := strings.Cut(strings.ToLower(tag), " ")
+	if prereleaseWords[word] {
+		n.Prerelease = true
+		return
+	}
+	if word, _, _ = strings.Cut(word, "-"); prereleaseWords[word] {
+		n.Prerelease = true
+		return
+	}
+	switch word {
+	case "unl":
+		n.Unlicensed = true
+	case "hack":
+		n.Hacked = true
+	case "pirate":
+		n.Pirate = true
+	case "alt":
+		n.Alternate = true
+	}
+}
+
+// parseFlag reads a TOSEC or GoodTools dump flag in brackets, e.g. "!",
+// "b2", "h Group", "T+Eng", or "tr fr".
+func (n *Name) parseFlag(flag string) {
+	if flag == "!" {
+		n.Verified = true
+		return
+	}
+	if lang, ok := strings.CutPrefix(flag, "T+"); ok {
+		n.Translation = lang
+		return
+	}
+	if lang, ok := strings.CutPrefix(flag, "T-"); ok {
+		n.Translation = lang
+		return
+	}
+
+	// A code of lowercase letters, then nothing, a number, or details after
+	// a space
+	i := 0
+	for i < len(flag) && isLower(flag[i]) {
+		i++
+	}
+	code, rest := flag[:i], flag[i:]
+	if rest != "" && rest[0] != ' ' && !isDigit(rest[0]) {
+		return
+	}
+	switch code {
+	case "b":
+		n.BadDump = true
+	case "o":
+		n.Overdump = true
+	case "a":
+		n.Alternate = true
+	case "f":
+		n.Fixed = true
+	case "h":
+		n.Hacked = true
+	case "t":
+		n.Trained = true
+	case "cr":
+		n.Cracked = true
+	case "p":
+		n.Pirate = true
+	case "tr":
+		n.Translation = strings.TrimSpace(rest)
+	}
+}
+
+// isDate returns whether tag is a TOSEC date: a year, with a month and day
+// optionally, where unknown digits are "x" (e.g. "199x" or "1995-03-xx").
+func isDate(tag string) bool {
+	if len(tag) != 4 && len(tag) != 7 && len(tag) != 10 {
+		return false
+	}
+	if tag[0] != '1' && tag[0] != '2' {
+		return false
+	}
+	for i := range len(tag) {
+		switch {
+		case i == 4 || i == 7:
+			if tag[i] != '-' {
+				return false
+			}
+		case !isDigit(tag[i]) && tag[i] != 'x':
+			return false
+		}
+	}
+	return true
+}
+
+func isUpper(c byte) bool { return c >= 'A' && c <= 'Z' }
+func isLower(c byte) bool { return c >= 'a' && c <= 'z' }
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
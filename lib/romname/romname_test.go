@@ -0,0 +1,131 @@
+package romname
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sargunv/rom-tools/lib/core"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		want Name
+	}{
+		{
+			name: "Super Mario World (USA)",
+			want: Name{Title: "Super Mario World", Regions: []core.Region{core.RegionUSA}},
+		},
+		{
+			name: "Legend of Zelda, The - A Link to the Past (Europe) (En,Fr,De) (Rev 1)",
+			want: Name{
+				Title:     "Legend of Zelda, The - A Link to the Past",
+				Regions:   []core.Region{core.RegionEurope},
+				Languages: []string{"en", "fr", "de"},
+				Revision:  "1",
+			},
+		},
+		{
+			name: "Final Fantasy VII (USA) (Disc 2)",
+			want: Name{
+				Title:   "Final Fantasy VII",
+				Regions: []core.Region{core.RegionUSA},
+				Disc:    2,
+				Tags:    []string{"Disc 2"},
+			},
+		},
+		{
+			name: "Star Fox 2 (Japan) (Proto) (1995-06-20)",
+			want: Name{
+				Title:      "Star Fox 2",
+				Regions:    []core.Region{core.RegionJapan},
+				Prerelease: true,
+				Tags:       []string{"Proto", "1995-06-20"},
+			},
+		},
+		{
+			name: "Game (Brazil) (Pt-BR,Es) (Alt 1) [b]",
+			want: Name{
+				Title:     "Game",
+				Regions:   []core.Region{core.RegionBrazil},
+				Languages: []string{"pt-br", "es"},
+				Alternate: true,
+				BadDump:   true,
+				Tags:      []string{"Alt 1", "b"},
+			},
+		},
+		{
+			name: "Pirate Game (Asia) (Unl) (Pirate) (Hack)",
+			want: Name{
+				Title:      "Pirate Game",
+				Regions:    []core.Region{core.RegionAsia},
+				Unlicensed: true,
+				Pirate:     true,
+				Hacked:     true,
+				Tags:       []string{"Unl", "Pirate", "Hack"},
+			},
+		},
+		{
+			name: "Legend of TOSEC, The v1.2 (1995)(Publisher)(US-EU)(en-de)(Disk 1 of 2)[cr Group][t2][!]",
+			want: Name{
+				Title:     "Legend of TOSEC, The",
+				Regions:   []core.Region{core.RegionUSA, core.RegionEurope},
+				Languages: []string{"en", "de"},
+				Revision:  "1.2",
+				Disc:      1,
+				Discs:     2,
+				Date:      "1995",
+				Publisher: "Publisher",
+				Cracked:   true,
+				Trained:   true,
+				Verified:  true,
+				Tags:      []string{"1995", "Publisher", "Disk 1 of 2", "cr Group", "t2", "!"},
+			},
+		},
+		{
+			name: "Demo Disk (199x)(-)(demo-playable)[a2][o][f][h Group]",
+			want: Name{
+				Title:      "Demo Disk",
+				Regions:    []core.Region{},
+				Prerelease: true,
+				Date:       "199x",
+				Alternate:  true,
+				Overdump:   true,
+				Fixed:      true,
+				Hacked:     true,
+				Tags:       []string{"199x", "-", "demo-playable", "a2", "o", "f", "h Group"},
+			},
+		},
+		{
+			name: "Some Game (Japan) [T+Eng1.0 Translator]",
+			want: Name{
+				Title:       "Some Game",
+				Regions:     []core.Region{core.RegionJapan},
+				Translation: "Eng1.0 Translator",
+				Tags:        []string{"T+Eng1.0 Translator"},
+			},
+		},
+		{
+			name: "Homebrew Game",
+			want: Name{Title: "Homebrew Game", Regions: []core.Region{}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Parse(tt.name); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFile(t *testing.T) {
+	got := ParseFile("/roms/gb/Tetris (World) (Rev 1).gb")
+	if got.Title != "Tetris" || got.Revision != "1" || len(got.Regions) != 1 {
+		t.Errorf("ParseFile() = %+v, want Tetris (World) revision 1", got)
+	}
+	if got := ParseFile("Dr. Mario.nes").Title; got != "Dr. Mario" {
+		t.Errorf("ParseFile() title = %q, want %q", got, "Dr. Mario")
+	}
+}
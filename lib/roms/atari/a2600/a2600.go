@@ -0,0 +1,268 @@
+package a2600
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/sargunv/rom-tools/lib/core"
+)
+
+// Atari 2600 ROM bank switching detection.
+//
+// 2600 ROMs (.a26) are raw cartridge dumps with no header. The console only
+// addresses 4K of cartridge space, so larger games switch banks by touching
+// hotspot addresses, in one of many incompatible schemes. Emulators detect
+// the scheme from the ROM size and from the instructions that access the
+// hotspots, and so does this package, following the heuristics of Stella:
+// https://github.com/stella-emu/stella/blob/master/src/emucore/CartDetector.cxx
+//
+//	Size   Schemes
+//	2K     2K
+//	4K     4K
+//	8K     F8, F8SC, E0, 3E, 3F, UA, FE (4K if both halves match)
+//	10K    DPC (8K program + 2K graphics, with or without 255 bytes of sound)
+//	12K    FA
+//	16K    F6, F6SC, E7, 3E
+//	32K    F4, F4SC, 3E, 3F
+//	64K    EF, EFSC, 3E, 3F
+//	other  3E, 3F (multiples of 2K)
+//
+// "SC" schemes add a Superchip, 128 bytes of RAM that read as the first 256
+// bytes of each bank, which dumps fill with the same value twice over.
+//
+// The ROM has no title, serial, or region.
+
+const (
+	bankSize = 4 * 1024
+
+	// maxROMSize bounds the ROM size, well above the largest 3E/3F games.
+	maxROMSize = 512 * 1024
+
+	dpcSize      = 8*1024 + 2*1024
+	dpcSoundSize = dpcSize + 255
+)
+
+// BankSwitching is a cartridge bank switching scheme, named as in Stella.
+type BankSwitching string
+
+// BankSwitching values
+const (
+	BankSwitchingUnknown BankSwitching = ""
+	BankSwitching2K      BankSwitching = "2K"   // Unbanked 2K
+	BankSwitching4K      BankSwitching = "4K"   // Unbanked 4K
+	BankSwitchingF8      BankSwitching = "F8"   // Atari 8K
+	BankSwitchingF8SC    BankSwitching = "F8SC" // Atari 8K + Superchip
+	BankSwitchingF6      BankSwitching = "F6"   // Atari 16K
+	BankSwitchingF6SC    BankSwitching = "F6SC" // Atari 16K + Superchip
+	BankSwitchingF4      BankSwitching = "F4"   // Atari 32K
+	BankSwitchingF4SC    BankSwitching = "F4SC" // Atari 32K + Superchip
+	BankSwitchingEF      BankSwitching = "EF"   // Homebrew 64K
+	BankSwitchingEFSC    BankSwitching = "EFSC" // Homebrew 64K + Superchip
+	BankSwitchingE0      BankSwitching = "E0"   // Parker Brothers 8K
+	BankSwitchingE7      BankSwitching = "E7"   // M Network 16K
+	BankSwitchingFA      BankSwitching = "FA"   // CBS RAM Plus 12K
+	BankSwitchingFE      BankSwitching = "FE"   // Activision 8K
+	BankSwitchingUA      BankSwitching = "UA"   // UA Limited 8K
+	BankSwitching3E      BankSwitching = "3E"   // Tigervision with RAM
+	BankSwitching3F      BankSwitching = "3F"   // Tigervision
+	BankSwitchingDPC     BankSwitching = "DPC"  // Pitfall II
+)
+
+// Signatures of instructions that access bank switching hotspots.
+var (
+	// STA $1FF9 / STA $FFF9: switching to the second F8 bank.
+	sigsF8 = [][]byte{{0x8D, 0xF9, 0x1F}, {0x8D, 0xF9, 0xFF}}
+
+	sigsE0 = [][]byte{
+		{0x8D, 0xE0, 0x1F}, // STA $1FE0
+		{0x8D, 0xE0, 0x5F}, // STA $5FE0
+		{0x8D, 0xE9, 0xFF}, // STA $FFE9
+		{0x0C, 0xE0, 0x1F}, // NOP $1FE0
+		{0xAD, 0xE0, 0x1F}, // LDA $1FE0
+		{0xAD, 0xE9, 0xFF}, // LDA $FFE9
+		{0xAD, 0xED, 0xFF}, // LDA $FFED
+		{0xAD, 0xF3, 0xBF}, // LDA $BFF3
+	}
+
+	sigsE7 = [][]byte{
+		{0xAD, 0xE2, 0xFF}, // LDA $FFE2
+		{0xAD, 0xE5, 0xFF}, // LDA $FFE5
+		{0xAD, 0xE5, 0x1F}, // LDA $1FE5
+		{0xAD, 0xE7, 0x1F}, // LDA $1FE7
+		{0x0C, 0xE7, 0x1F}, // NOP $1FE7
+		{0x8D, 0xE7, 0xFF}, // STA $FFE7
+		{0x8D, 0xE7, 0x1F}, // STA $1FE7
+	}
+
+	sigsFE = [][]byte{
+		{0x20, 0x00, 0xD0, 0xC6, 0xC5}, // JSR $D000; DEC $C5
+		{0x20, 0xC3, 0xF8, 0xA5, 0x82}, // JSR $F8C3; LDA $82
+		{0xD0, 0xFB, 0x20, 0x73, 0xFE}, // BNE $FB; JSR $FE73
+		{0x20, 0x00, 0xF0, 0x84, 0xD6}, // JSR $F000; STY $D6
+	}
+
+	sigsUA = [][]byte{
+		{0x8D, 0x40, 0x02}, // STA $240
+		{0xAD, 0x40, 0x02}, // LDA $240
+		{0xBD, 0x1F, 0x02}, // LDA $21F,X
+		{0x2C, 0xC0, 0x02}, // BIT $2C0
+		{0x8D, 0xC0, 0x02}, // STA $2C0
+		{0xAD, 0xC0, 0x02}, // LDA $2C0
+	}
+
+	sig3E = []byte{0x85, 0x3E} // STA $3E
+	sig3F = []byte{0x85, 0x3F} // STA $3F
+
+	// Newer EF ROMs store "EFEF" or "EFSC" at $FFF8.
+	sigEFEF = []byte("EFEF")
+	sigEFSC = []byte("EFSC")
+)
+
+// Info contains the bank switching scheme detected for a 2600 ROM.
+type Info struct {
+	// BankSwitching is the detected bank switching scheme, or
+	// BankSwitchingUnknown if the size matches no known scheme.
+	BankSwitching BankSwitching `json:"bank_switching"`
+	// Size is the ROM size in bytes.
+	Size int64 `json:"size"`
+}
+
+// GamePlatform implements core.GameInfo.
+func (i *Info) GamePlatform() core.Platform { return core.PlatformAtari2600 }
+
+// GameTitle implements core.GameInfo. 2600 ROMs don't include titles.
+func (i *Info) GameTitle() string { return "" }
+
+// GameSerial implements core.GameInfo. 2600 ROMs don't include serials.
+func (i *Info) GameSerial() string { return "" }
+
+// GameRegions implements core.GameInfo. 2600 ROMs don't include regions.
+func (i *Info) GameRegions() []core.Region { return []core.Region{} }
+
+// Parse detects the bank switching scheme of a 2600 ROM.
+func Parse(r io.ReaderAt, size int64) (*Info, error) {
+	if size == 0 {
+		return nil, fmt.Errorf("not a valid Atari 2600 ROM: file is empty")
+	}
+	if size > maxROMSize {
+		return nil, fmt.Errorf("not a valid Atari 2600 ROM: %d bytes is too large", size)
+	}
+
+	rom := make([]byte, size)
+	if _, err := r.ReadAt(rom, 0); err != nil {
+		return nil, fmt.Errorf("failed to read Atari 2600 ROM: %w", err)
+	}
+
+	return &Info{BankSwitching: detectBankSwitching(rom), Size: size}, nil
+}
+
+// detectBankSwitching guesses the bank switching scheme of a ROM.
+func detectBankSwitching(rom []byte) BankSwitching {
+	size := len(rom)
+	switch {
+	case size <= 2*1024:
+		return BankSwitching2K
+	case size == bankSize:
+		return BankSwitching4K
+	case size == 8*1024:
+		// Check for F8 first, as its hotspot accesses can look like FE's
+		f8 := hasAny(rom, sigsF8, 2)
+		switch {
+		case hasSuperchip(rom):
+			return BankSwitchingF8SC
+		case bytes.Equal(rom[:bankSize], rom[bankSize:]):
+			return BankSwitching4K
+		case hasAny(rom, sigsE0, 1):
+			return BankSwitchingE0
+		case is3E(rom):
+			return BankSwitching3E
+		case is3F(rom):
+			return BankSwitching3F
+		case hasAny(rom, sigsUA, 1):
+			return BankSwitchingUA
+		case hasAny(rom, sigsFE, 1) && !f8:
+			return BankSwitchingFE
+		}
+		return BankSwitchingF8
+	case size == dpcSize || size == dpcSoundSize:
+		return BankSwitchingDPC
+	case size == 12*1024:
+		return BankSwitchingFA
+	case size == 16*1024:
+		switch {
+		case hasSuperchip(rom):
+			return BankSwitchingF6SC
+		case hasAny(rom, sigsE7, 1):
+			return BankSwitchingE7
+		case is3E(rom):
+			return BankSwitching3E
+		}
+		return BankSwitchingF6
+	case size == 32*1024:
+		switch {
+		case hasSuperchip(rom):
+			return BankSwitchingF4SC
+		case is3E(rom):
+			return BankSwitching3E
+		case is3F(rom):
+			return BankSwitching3F
+		}
+		return BankSwitchingF4
+	case size == 64*1024:
+		switch {
+		case bytes.Contains(rom[size-8:], sigEFSC):
+			return BankSwitchingEFSC
+		case bytes.Contains(rom[size-8:], sigEFEF):
+			return BankSwitchingEF
+		case is3E(rom):
+			return BankSwitching3E
+		case is3F(rom):
+			return BankSwitching3F
+		case hasSuperchip(rom):
+			return BankSwitchingEFSC
+		}
+		return BankSwitchingEF
+	case size%(2*1024) == 0:
+		switch {
+		case is3E(rom):
+			return BankSwitching3E
+		case is3F(rom):
+			return BankSwitching3F
+		}
+	}
+	return BankSwitchingUnknown
+}
+
+// hasSuperchip reports whether each 4K bank starts with a 128-byte block
+// repeated twice, as dumps of Superchip RAM do.
+func hasSuperchip(rom []byte) bool {
+	for bank := 0; bank+bankSize <= len(rom); bank += bankSize {
+		if !bytes.Equal(rom[bank:bank+128], rom[bank+128:bank+256]) {
+			return false
+		}
+	}
+	return true
+}
+
+// is3E reports whether the ROM switches RAM banks with STA $3E and ROM banks
+// with STA $3F.
+func is3E(rom []byte) bool {
+	return bytes.Count(rom, sig3E) >= 1 && bytes.Count(rom, sig3F) >= 2
+}
+
+// is3F reports whether the ROM switches banks with STA $3F, which a
+// multi-bank game must do at least twice.
+func is3F(rom []byte) bool {
+	return bytes.Count(rom, sig3F) >= 2
+}
+
+// hasAny reports whether any of the signatures occurs at least count times.
+func hasAny(rom []byte, sigs [][]byte, count int) bool {
+	for _, sig := range sigs {
+		if bytes.Count(rom, sig) >= count {
+			return true
+		}
+	}
+	return false
+}
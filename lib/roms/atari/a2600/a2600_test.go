@@ -0,0 +1,115 @@
+package a2600
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/sargunv/rom-tools/lib/core"
+)
+
+// makeROM creates a ROM of the given size with distinct, signature-free
+// contents, then places each code snippet at the start of its own 1K block.
+func makeROM(size int, code ...[]byte) []byte {
+	rom := make([]byte, size)
+	for i := range rom {
+		rom[i] = byte(i*7 + i/256)
+	}
+	for i, c := range code {
+		copy(rom[i*1024+512:], c)
+	}
+	return rom
+}
+
+// withSuperchip fills the first 256 bytes of each 4K bank as a Superchip
+// RAM dump would.
+func withSuperchip(rom []byte) []byte {
+	for bank := 0; bank < len(rom); bank += bankSize {
+		for i := range 256 {
+			rom[bank+i] = 0xFF
+		}
+	}
+	return rom
+}
+
+func TestDetectBankSwitching(t *testing.T) {
+	sta1FF9 := []byte{0x8D, 0xF9, 0x1F}
+	sta3F := []byte{0x85, 0x3F}
+	sta3E := []byte{0x85, 0x3E}
+
+	halves := makeROM(4 * 1024)
+
+	tests := []struct {
+		name string
+		rom  []byte
+		want BankSwitching
+	}{
+		{"2K", makeROM(2 * 1024), BankSwitching2K},
+		{"4K", makeROM(4 * 1024), BankSwitching4K},
+		{"8K default", makeROM(8 * 1024), BankSwitchingF8},
+		{"8K mirrored 4K", append(halves, halves...), BankSwitching4K},
+		{"F8SC", withSuperchip(makeROM(8 * 1024)), BankSwitchingF8SC},
+		{"E0", makeROM(8*1024, []byte{0xAD, 0xE0, 0x1F}), BankSwitchingE0},
+		{"3F", makeROM(8*1024, sta3F, sta3F), BankSwitching3F},
+		{"3E", makeROM(8*1024, sta3E, sta3F, sta3F), BankSwitching3E},
+		{"UA", makeROM(8*1024, []byte{0x8D, 0x40, 0x02}), BankSwitchingUA},
+		{"FE", makeROM(8*1024, []byte{0x20, 0x00, 0xD0, 0xC6, 0xC5}), BankSwitchingFE},
+		{"FE with F8 hotspots", makeROM(8*1024, []byte{0x20, 0x00, 0xD0, 0xC6, 0xC5}, sta1FF9, sta1FF9), BankSwitchingF8},
+		{"DPC", makeROM(10 * 1024), BankSwitchingDPC},
+		{"DPC with sound", makeROM(10*1024 + 255), BankSwitchingDPC},
+		{"FA", makeROM(12 * 1024), BankSwitchingFA},
+		{"F6", makeROM(16 * 1024), BankSwitchingF6},
+		{"F6SC", withSuperchip(makeROM(16 * 1024)), BankSwitchingF6SC},
+		{"E7", makeROM(16*1024, []byte{0xAD, 0xE5, 0x1F}), BankSwitchingE7},
+		{"F4", makeROM(32 * 1024), BankSwitchingF4},
+		{"F4SC", withSuperchip(makeROM(32 * 1024)), BankSwitchingF4SC},
+		{"32K 3F", makeROM(32*1024, sta3F, sta3F), BankSwitching3F},
+		{"EF", makeROM(64 * 1024), BankSwitchingEF},
+		{"EFSC", withSuperchip(makeROM(64 * 1024)), BankSwitchingEFSC},
+		{"128K 3E", makeROM(128*1024, sta3E, sta3F, sta3F), BankSwitching3E},
+		{"128K unknown", makeROM(128 * 1024), BankSwitchingUnknown},
+		{"odd size", makeROM(5000), BankSwitchingUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := detectBankSwitching(tt.rom); got != tt.want {
+				t.Errorf("detectBankSwitching() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectBankSwitching_EFSignature(t *testing.T) {
+	rom := makeROM(64 * 1024)
+	copy(rom[len(rom)-8:], "EFEFEFSC")
+	if got := detectBankSwitching(rom); got != BankSwitchingEFSC {
+		t.Errorf("detectBankSwitching() = %q, want %q", got, BankSwitchingEFSC)
+	}
+}
+
+func TestParse(t *testing.T) {
+	rom := makeROM(16 * 1024)
+
+	info, err := Parse(bytes.NewReader(rom), int64(len(rom)))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if info.GamePlatform() != core.PlatformAtari2600 {
+		t.Errorf("Platform = %v, want %v", info.GamePlatform(), core.PlatformAtari2600)
+	}
+	if info.BankSwitching != BankSwitchingF6 {
+		t.Errorf("BankSwitching = %q, want %q", info.BankSwitching, BankSwitchingF6)
+	}
+	if info.Size != int64(len(rom)) {
+		t.Errorf("Size = %d, want %d", info.Size, len(rom))
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	if _, err := Parse(bytes.NewReader(nil), 0); err == nil {
+		t.Error("Parse() expected error for empty file, got nil")
+	}
+	if _, err := Parse(bytes.NewReader(nil), maxROMSize+1); err == nil {
+		t.Error("Parse() expected error for oversized file, got nil")
+	}
+}
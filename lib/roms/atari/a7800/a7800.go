@@ -0,0 +1,174 @@
+package a7800
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/sargunv/rom-tools/internal/util"
+	"github.com/sargunv/rom-tools/lib/core"
+)
+
+// Atari 7800 A78 ROM format parsing.
+//
+// A78 files are cartridge dumps with a 128-byte header describing the
+// cartridge hardware, which emulators need since 7800 cartridges don't
+// describe themselves. No-Intro hashes the data after the A78 header.
+//
+// Header specification:
+// https://7800.8bitdev.org/index.php/A78_Header_Specification
+//
+// Header layout (128 bytes, big-endian):
+//
+//	Offset  Size  Description
+//	0x00    1     Header version
+//	0x01    16    Magic "ATARI7800" (null-padded)
+//	0x11    32    Cartridge title (null-padded)
+//	0x31    4     ROM size, excluding the header
+//	0x35    2     Cartridge type (bit flags, see CartType)
+//	0x37    1     Port 1 controller type
+//	0x38    1     Port 2 controller type
+//	0x39    1     TV type (bit 0: 0 = NTSC, 1 = PAL)
+//	0x3A    1     Save device (version 2+)
+//	0x64    28    "ACTUAL CART DATA STARTS HERE"
+
+const (
+	// HeaderSize is the size of the A78 header preceding the cartridge data.
+	HeaderSize = 128
+
+	a78VersionOffset     = 0x00
+	a78MagicOffset       = 0x01
+	a78TitleOffset       = 0x11
+	a78TitleLen          = 32
+	a78ROMSizeOffset     = 0x31
+	a78CartTypeOffset    = 0x35
+	a78Controller1Offset = 0x37
+	a78Controller2Offset = 0x38
+	a78TVTypeOffset      = 0x39
+)
+
+var a78Magic = []byte("ATARI7800")
+
+// CartType is the cartridge type bit field, describing the bank switching
+// scheme and any extra hardware on the cartridge.
+type CartType uint16
+
+// CartType flags
+const (
+	CartPokey4000      CartType = 1 << 0  // POKEY at $4000
+	CartSuperGame      CartType = 1 << 1  // SuperGame bank switching
+	CartSuperGameRAM   CartType = 1 << 2  // SuperGame RAM at $4000
+	CartROM4000        CartType = 1 << 3  // ROM at $4000
+	CartBank6At4000    CartType = 1 << 4  // Bank 6 at $4000
+	CartBankedRAM      CartType = 1 << 5  // Banked RAM
+	CartPokey450       CartType = 1 << 6  // POKEY at $450
+	CartMirrorRAM      CartType = 1 << 7  // Mirrored RAM at $4000
+	CartActivision     CartType = 1 << 8  // Activision bank switching
+	CartAbsolute       CartType = 1 << 9  // Absolute bank switching
+	CartPokey440       CartType = 1 << 10 // POKEY at $440
+	CartYM2151         CartType = 1 << 11 // YM2151 at $460
+	CartSouper         CartType = 1 << 12 // SOUPER bank switching
+	CartBanksets       CartType = 1 << 13 // Banksets
+	CartHaltBankedRAM  CartType = 1 << 14 // Halt-banked RAM
+	CartPokey800       CartType = 1 << 15 // POKEY at $800
+	cartPokeyLocations          = CartPokey4000 | CartPokey450 | CartPokey440 | CartPokey800
+)
+
+// HasPokey reports whether the cartridge has a POKEY sound chip.
+func (t CartType) HasPokey() bool {
+	return t&cartPokeyLocations != 0
+}
+
+// Controller is a controller type expected in one of the ports.
+type Controller byte
+
+// Controller values
+const (
+	ControllerNone          Controller = 0
+	ControllerJoystick      Controller = 1 // 7800 ProLine joystick
+	ControllerLightgun      Controller = 2
+	ControllerPaddle        Controller = 3
+	ControllerTrakball      Controller = 4
+	ControllerJoystick2600  Controller = 5
+	ControllerDriving2600   Controller = 6
+	ControllerKeypad2600    Controller = 7
+	ControllerSTMouse       Controller = 8
+	ControllerAmigaMouse    Controller = 9
+	ControllerAtariVox      Controller = 10 // AtariVox or SaveKey
+	ControllerSNES2Atari    Controller = 11
+	ControllerMegaDrive7800 Controller = 12
+)
+
+// TVType is the video standard the game was made for.
+type TVType byte
+
+// TVType values
+const (
+	TVTypeNTSC TVType = 0
+	TVTypePAL  TVType = 1
+)
+
+// Info contains metadata extracted from an A78 header.
+type Info struct {
+	// Title is the cartridge title.
+	Title string `json:"title,omitempty"`
+	// Version is the A78 header version.
+	Version byte `json:"version"`
+	// ROMSize is the cartridge size declared in the header, in bytes.
+	ROMSize uint32 `json:"rom_size"`
+	// CartType describes the bank switching scheme and extra hardware.
+	CartType CartType `json:"cart_type"`
+	// Controller1 is the controller expected in port 1.
+	Controller1 Controller `json:"controller1"`
+	// Controller2 is the controller expected in port 2.
+	Controller2 Controller `json:"controller2"`
+	// TVType is the video standard.
+	TVType TVType `json:"tv_type"`
+	// PayloadSize is the size of the cartridge data following the header.
+	PayloadSize int64 `json:"payload_size"`
+}
+
+// GamePlatform implements core.GameInfo.
+func (i *Info) GamePlatform() core.Platform { return core.PlatformAtari7800 }
+
+// GameTitle implements core.GameInfo.
+func (i *Info) GameTitle() string { return i.Title }
+
+// GameSerial implements core.GameInfo. A78 headers don't include serials.
+func (i *Info) GameSerial() string { return "" }
+
+// GameRegions implements core.GameInfo, inferred from the TV type.
+func (i *Info) GameRegions() []core.Region {
+	if i.TVType == TVTypePAL {
+		return []core.Region{core.RegionEurope}
+	}
+	return []core.Region{core.RegionAmericas}
+}
+
+// Parse extracts game information from an A78 file.
+func Parse(r io.ReaderAt, size int64) (*Info, error) {
+	if size < HeaderSize {
+		return nil, fmt.Errorf("file too small for A78 header: %d bytes", size)
+	}
+
+	header := make([]byte, HeaderSize)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, fmt.Errorf("failed to read A78 header: %w", err)
+	}
+
+	if !bytes.Equal(header[a78MagicOffset:a78MagicOffset+len(a78Magic)], a78Magic) {
+		return nil, fmt.Errorf("not a valid A78 file: invalid magic")
+	}
+
+	return &Info{
+		Title:       util.ExtractASCII(header[a78TitleOffset : a78TitleOffset+a78TitleLen]),
+		Version:     header[a78VersionOffset],
+		ROMSize:     binary.BigEndian.Uint32(header[a78ROMSizeOffset:]),
+		CartType:    CartType(binary.BigEndian.Uint16(header[a78CartTypeOffset:])),
+		Controller1: Controller(header[a78Controller1Offset]),
+		Controller2: Controller(header[a78Controller2Offset]),
+		TVType:      TVType(header[a78TVTypeOffset] & 0x01),
+		PayloadSize: size - HeaderSize,
+	}, nil
+}
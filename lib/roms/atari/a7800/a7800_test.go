@@ -0,0 +1,97 @@
+package a7800
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+
+	"github.com/sargunv/rom-tools/lib/core"
+)
+
+// makeTestA78 creates an A78 file with the given TV type and payload.
+func makeTestA78(tvType TVType, payload []byte) []byte {
+	header := make([]byte, HeaderSize)
+	header[a78VersionOffset] = 3
+	copy(header[a78MagicOffset:], a78Magic)
+	copy(header[a78TitleOffset:], "Ballblazer")
+	header[a78ROMSizeOffset+1] = 0x02 // 0x00020000 = 128 KiB
+	header[a78CartTypeOffset+1] = byte(CartPokey4000 | CartSuperGame)
+	header[a78Controller1Offset] = byte(ControllerJoystick)
+	header[a78Controller2Offset] = byte(ControllerJoystick)
+	header[a78TVTypeOffset] = byte(tvType)
+	copy(header[0x64:], "ACTUAL CART DATA STARTS HERE")
+	return append(header, payload...)
+}
+
+func TestParse(t *testing.T) {
+	data := makeTestA78(TVTypeNTSC, make([]byte, 1024))
+
+	info, err := Parse(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if info.GamePlatform() != core.PlatformAtari7800 {
+		t.Errorf("Platform = %v, want %v", info.GamePlatform(), core.PlatformAtari7800)
+	}
+	if info.GameTitle() != "Ballblazer" {
+		t.Errorf("Title = %q, want %q", info.GameTitle(), "Ballblazer")
+	}
+	if info.Version != 3 {
+		t.Errorf("Version = %d, want 3", info.Version)
+	}
+	if info.ROMSize != 128*1024 {
+		t.Errorf("ROMSize = %d, want %d", info.ROMSize, 128*1024)
+	}
+	if info.CartType != CartPokey4000|CartSuperGame {
+		t.Errorf("CartType = %#x, want %#x", info.CartType, CartPokey4000|CartSuperGame)
+	}
+	if !info.CartType.HasPokey() {
+		t.Error("CartType.HasPokey() = false, want true")
+	}
+	if info.Controller1 != ControllerJoystick || info.Controller2 != ControllerJoystick {
+		t.Errorf("Controllers = %v, %v, want %v", info.Controller1, info.Controller2, ControllerJoystick)
+	}
+	if info.TVType != TVTypeNTSC {
+		t.Errorf("TVType = %v, want %v", info.TVType, TVTypeNTSC)
+	}
+	if info.PayloadSize != 1024 {
+		t.Errorf("PayloadSize = %d, want 1024", info.PayloadSize)
+	}
+	if regions := info.GameRegions(); !slices.Equal(regions, []core.Region{core.RegionAmericas}) {
+		t.Errorf("GameRegions() = %v, want [%v]", regions, core.RegionAmericas)
+	}
+}
+
+func TestParse_PAL(t *testing.T) {
+	// Bit 1 (composite/component) doesn't affect the TV type
+	data := makeTestA78(TVTypePAL|0x02, nil)
+
+	info, err := Parse(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if info.TVType != TVTypePAL {
+		t.Errorf("TVType = %v, want %v", info.TVType, TVTypePAL)
+	}
+	if regions := info.GameRegions(); !slices.Equal(regions, []core.Region{core.RegionEurope}) {
+		t.Errorf("GameRegions() = %v, want [%v]", regions, core.RegionEurope)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"too small", make([]byte, HeaderSize-1)},
+		{"bad magic", make([]byte, HeaderSize)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(bytes.NewReader(tt.data), int64(len(tt.data))); err == nil {
+				t.Error("Parse() expected error, got nil")
+			}
+		})
+	}
+}
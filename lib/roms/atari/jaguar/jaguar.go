@@ -0,0 +1,87 @@
+package jaguar
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/sargunv/rom-tools/lib/core"
+)
+
+// Atari Jaguar cartridge ROM format parsing.
+//
+// Jaguar cartridge images (.j64, .rom) are raw dumps of the cartridge, which
+// the console maps at $800000. The cartridge starts with the "universal
+// header": an encrypted block the boot ROM verifies, then the boot fields
+// telling it how to access the cartridge and where to start the game.
+//
+// The header has no title, serial, or region, so there is no internal name to
+// extract; the boot fields are used to recognize the format.
+//
+// Layout (big-endian):
+//
+//	Offset  Size    Description
+//	0x0000  0x400   Encrypted boot check data
+//	0x0400  4       Memory configuration (ROM width and speed, usually 0x04040404)
+//	0x0404  4       Entry point (usually $802000)
+//	0x2000  ...     Game code and data
+
+const (
+	jaguarBootFieldsOffset = 0x400
+	jaguarMemConfigOffset  = 0x400
+	jaguarEntryPointOffset = 0x404
+	jaguarBootFieldsSize   = 8
+	jaguarCodeOffset       = 0x2000
+	jaguarCartBase         = 0x800000
+	jaguarMaxCartSize      = 6 * 1024 * 1024
+)
+
+// Info contains metadata extracted from a Jaguar cartridge ROM.
+type Info struct {
+	// MemoryConfig is the ROM width and speed the boot ROM configures.
+	MemoryConfig uint32 `json:"memory_config"`
+	// EntryPoint is the address the boot ROM jumps to.
+	EntryPoint uint32 `json:"entry_point"`
+	// ROMSize is the size of the cartridge data.
+	ROMSize int64 `json:"rom_size"`
+}
+
+// GamePlatform implements core.GameInfo.
+func (i *Info) GamePlatform() core.Platform { return core.PlatformJaguar }
+
+// GameTitle implements core.GameInfo. Jaguar cartridges don't have embedded
+// titles.
+func (i *Info) GameTitle() string { return "" }
+
+// GameSerial implements core.GameInfo. Jaguar cartridges don't have embedded
+// serials.
+func (i *Info) GameSerial() string { return "" }
+
+// GameRegions implements core.GameInfo. Jaguar cartridges are region free.
+func (i *Info) GameRegions() []core.Region { return []core.Region{} }
+
+// Parse extracts information from a Jaguar cartridge ROM.
+func Parse(r io.ReaderAt, size int64) (*Info, error) {
+	if size <= jaguarCodeOffset {
+		return nil, fmt.Errorf("file too small for Jaguar ROM: %d bytes", size)
+	}
+	if size > jaguarMaxCartSize {
+		return nil, fmt.Errorf("not a valid Jaguar ROM: size %d exceeds cartridge space", size)
+	}
+
+	fields := make([]byte, jaguarBootFieldsSize)
+	if _, err := r.ReadAt(fields, jaguarBootFieldsOffset); err != nil {
+		return nil, fmt.Errorf("failed to read Jaguar boot fields: %w", err)
+	}
+
+	entryPoint := binary.BigEndian.Uint32(fields[jaguarEntryPointOffset-jaguarBootFieldsOffset:])
+	if entryPoint < jaguarCartBase || entryPoint >= jaguarCartBase+uint32(size) {
+		return nil, fmt.Errorf("not a valid Jaguar ROM: entry point $%06X outside cartridge", entryPoint)
+	}
+
+	return &Info{
+		MemoryConfig: binary.BigEndian.Uint32(fields[jaguarMemConfigOffset-jaguarBootFieldsOffset:]),
+		EntryPoint:   entryPoint,
+		ROMSize:      size,
+	}, nil
+}
@@ -0,0 +1,60 @@
+package jaguar
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/sargunv/rom-tools/lib/core"
+)
+
+// makeTestROM creates a cartridge ROM of the given size with the usual boot
+// fields.
+func makeTestROM(size int, entryPoint uint32) []byte {
+	rom := make([]byte, size)
+	binary.BigEndian.PutUint32(rom[jaguarMemConfigOffset:], 0x04040404)
+	binary.BigEndian.PutUint32(rom[jaguarEntryPointOffset:], entryPoint)
+	return rom
+}
+
+func TestParse(t *testing.T) {
+	rom := makeTestROM(2*1024*1024, 0x802000)
+
+	info, err := Parse(bytes.NewReader(rom), int64(len(rom)))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if info.GamePlatform() != core.PlatformJaguar {
+		t.Errorf("Platform = %v, want %v", info.GamePlatform(), core.PlatformJaguar)
+	}
+	if info.MemoryConfig != 0x04040404 {
+		t.Errorf("MemoryConfig = %#x, want 0x04040404", info.MemoryConfig)
+	}
+	if info.EntryPoint != 0x802000 {
+		t.Errorf("EntryPoint = %#x, want 0x802000", info.EntryPoint)
+	}
+	if info.ROMSize != int64(len(rom)) {
+		t.Errorf("ROMSize = %d, want %d", info.ROMSize, len(rom))
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		rom  []byte
+	}{
+		{"too small", makeTestROM(jaguarCodeOffset, 0x802000)},
+		{"too large", makeTestROM(jaguarMaxCartSize+1, 0x802000)},
+		{"entry point in RAM", makeTestROM(1024*1024, 0x004000)},
+		{"entry point past end", makeTestROM(1024*1024, 0x900000)},
+		{"no boot fields", make([]byte, 1024*1024)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(bytes.NewReader(tt.rom), int64(len(tt.rom))); err == nil {
+				t.Error("Parse() expected error, got nil")
+			}
+		})
+	}
+}
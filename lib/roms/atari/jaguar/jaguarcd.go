@@ -0,0 +1,113 @@
+package jaguar
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/sargunv/rom-tools/lib/core"
+)
+
+// Atari Jaguar CD disc identification from the boot track header.
+//
+// Jaguar CD discs have no filesystem, and every track is an audio track, even
+// those holding code and data. The CD BIOS finds the boot program by
+// searching the first track of the second session for the data header
+// string, which is followed by the program's load address and length. Since
+// audio sectors have no sync pattern, the header may be at any offset, after
+// a run of padding.
+//
+// The tracks may be stored in either byte order: BIN files from most rippers
+// have 16-bit words swapped relative to the CHD's big-endian audio, so both
+// orders are searched.
+//
+// Boot header layout (big-endian):
+//
+//	Offset  Size  Description
+//	0x00    32    "ATARI APPROVED DATA HEADER ATRI "
+//	0x20    4     Load address
+//	0x24    4     Length of the boot program
+
+const (
+	jaguarCDHeader         = "ATARI APPROVED DATA HEADER ATRI "
+	jaguarCDLoadAddrOffset = 0x20
+	jaguarCDLengthOffset   = 0x24
+	jaguarCDHeaderSize     = 0x28
+	jaguarCDSearchSize     = 1024 * 1024
+)
+
+// CDInfo contains metadata extracted from a Jaguar CD boot track.
+type CDInfo struct {
+	// LoadAddress is the address the boot program is loaded at.
+	LoadAddress uint32 `json:"load_address"`
+	// Length is the size of the boot program.
+	Length uint32 `json:"length"`
+	// HeaderOffset is the offset of the boot header within the track.
+	HeaderOffset int64 `json:"header_offset"`
+	// WordSwapped reports whether the track's 16-bit words are swapped
+	// relative to the Jaguar's big-endian order.
+	WordSwapped bool `json:"word_swapped"`
+}
+
+// GamePlatform implements core.GameInfo.
+func (i *CDInfo) GamePlatform() core.Platform { return core.PlatformJaguarCD }
+
+// GameTitle implements core.GameInfo. Jaguar CD discs don't have embedded
+// titles.
+func (i *CDInfo) GameTitle() string { return "" }
+
+// GameSerial implements core.GameInfo. Jaguar CD discs don't have embedded
+// serials.
+func (i *CDInfo) GameSerial() string { return "" }
+
+// GameRegions implements core.GameInfo. Jaguar CD discs are region free.
+func (i *CDInfo) GameRegions() []core.Region { return []core.Region{} }
+
+// ParseCD extracts information from a Jaguar CD boot track, searching the
+// start of the track for the boot header in either byte order.
+func ParseCD(r io.ReaderAt, size int64) (*CDInfo, error) {
+	if size < jaguarCDHeaderSize {
+		return nil, fmt.Errorf("file too small for Jaguar CD boot header: %d bytes", size)
+	}
+
+	data := make([]byte, min(size, jaguarCDSearchSize))
+	if _, err := r.ReadAt(data, 0); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to read Jaguar CD track: %w", err)
+	}
+
+	// The header starts on an even offset, so swapping the whole buffer
+	// swaps the header too
+	for _, swapped := range []bool{false, true} {
+		buf := data
+		if swapped {
+			buf = swapWords(data)
+		}
+		for start := 0; ; {
+			i := bytes.Index(buf[start:], []byte(jaguarCDHeader))
+			if i < 0 {
+				break
+			}
+			offset := start + i
+			if offset%2 == 0 && offset+jaguarCDHeaderSize <= len(buf) {
+				return &CDInfo{
+					LoadAddress:  binary.BigEndian.Uint32(buf[offset+jaguarCDLoadAddrOffset:]),
+					Length:       binary.BigEndian.Uint32(buf[offset+jaguarCDLengthOffset:]),
+					HeaderOffset: int64(offset),
+					WordSwapped:  swapped,
+				}, nil
+			}
+			start = offset + 1
+		}
+	}
+	return nil, fmt.Errorf("not a valid Jaguar CD boot track: missing %q header", jaguarCDHeader)
+}
+
+// swapWords returns a copy of data with the bytes of each 16-bit word swapped.
+func swapWords(data []byte) []byte {
+	out := make([]byte, len(data))
+	for i := 0; i+1 < len(data); i += 2 {
+		out[i], out[i+1] = data[i+1], data[i]
+	}
+	return out
+}
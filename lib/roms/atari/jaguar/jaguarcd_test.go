@@ -0,0 +1,62 @@
+package jaguar
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/sargunv/rom-tools/lib/core"
+)
+
+// makeTestCDTrack creates a boot track with the boot header after some
+// padding, with its words swapped if swapped is set.
+func makeTestCDTrack(offset int, swapped bool) []byte {
+	track := make([]byte, 4*2352)
+	copy(track[offset:], jaguarCDHeader)
+	binary.BigEndian.PutUint32(track[offset+jaguarCDLoadAddrOffset:], 0x4000)
+	binary.BigEndian.PutUint32(track[offset+jaguarCDLengthOffset:], 0x1234)
+	if swapped {
+		return swapWords(track)
+	}
+	return track
+}
+
+func TestParseCD(t *testing.T) {
+	for _, swapped := range []bool{false, true} {
+		track := makeTestCDTrack(2352+100, swapped)
+		info, err := ParseCD(bytes.NewReader(track), int64(len(track)))
+		if err != nil {
+			t.Fatalf("ParseCD(swapped=%v) error = %v", swapped, err)
+		}
+		if info.GamePlatform() != core.PlatformJaguarCD {
+			t.Errorf("Platform = %v, want %v", info.GamePlatform(), core.PlatformJaguarCD)
+		}
+		if info.LoadAddress != 0x4000 || info.Length != 0x1234 {
+			t.Errorf("LoadAddress, Length = %#x, %#x, want 0x4000, 0x1234", info.LoadAddress, info.Length)
+		}
+		if info.HeaderOffset != 2352+100 {
+			t.Errorf("HeaderOffset = %d, want %d", info.HeaderOffset, 2352+100)
+		}
+		if info.WordSwapped != swapped {
+			t.Errorf("WordSwapped = %v, want %v", info.WordSwapped, swapped)
+		}
+	}
+}
+
+func TestParseCD_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"too small", make([]byte, 16)},
+		{"silence", make([]byte, 4*2352)},
+		{"header at odd offset", makeTestCDTrack(101, false)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseCD(bytes.NewReader(tt.data), int64(len(tt.data))); err == nil {
+				t.Error("ParseCD() expected error")
+			}
+		})
+	}
+}
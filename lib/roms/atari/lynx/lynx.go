@@ -0,0 +1,145 @@
+package lynx
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/sargunv/rom-tools/internal/util"
+	"github.com/sargunv/rom-tools/lib/core"
+)
+
+// Atari Lynx LNX ROM format parsing.
+//
+// LNX files are cartridge dumps with a 64-byte header added by Handy (the
+// emulator) describing the cartridge layout. Lynx cartridges have no internal
+// header of their own; No-Intro hashes the data after the LNX header.
+//
+// Header specification:
+// https://atarigamer.com/lynx/lnxhdrgen
+//
+// Header layout (64 bytes, little-endian):
+//
+//	Offset  Size  Description
+//	0x00    4     Magic "LYNX"
+//	0x04    2     Bank 0 page size (bytes per page / 256 bytes)
+//	0x06    2     Bank 1 page size
+//	0x08    2     Header version
+//	0x0A    32    Cartridge name (null-terminated)
+//	0x2A    16    Manufacturer name (null-terminated)
+//	0x3A    1     Rotation (0 = none, 1 = left, 2 = right)
+//	0x3B    1     AUDIN used (version 2+)
+//	0x3C    1     EEPROM type (version 2+)
+//	0x3D    3     Reserved
+//
+// Each bank holds 256 pages, so the bank size is page size * 256.
+
+const (
+	// HeaderSize is the size of the LNX header preceding the cartridge data.
+	HeaderSize = 64
+
+	lynxMagicOffset        = 0x00
+	lynxBank0PageOffset    = 0x04
+	lynxBank1PageOffset    = 0x06
+	lynxVersionOffset      = 0x08
+	lynxNameOffset         = 0x0A
+	lynxNameLen            = 32
+	lynxManufacturerOffset = 0x2A
+	lynxManufacturerLen    = 16
+	lynxRotationOffset     = 0x3A
+	lynxAudinOffset        = 0x3B
+	lynxEEPROMOffset       = 0x3C
+
+	lynxPagesPerBank = 256
+)
+
+var lynxMagic = []byte("LYNX")
+
+// Rotation is the screen rotation the game expects.
+type Rotation byte
+
+// Rotation values
+const (
+	RotationNone  Rotation = 0
+	RotationLeft  Rotation = 1
+	RotationRight Rotation = 2
+)
+
+// Info contains metadata extracted from an LNX header.
+type Info struct {
+	// Title is the cartridge name.
+	Title string `json:"title,omitempty"`
+	// Manufacturer is the manufacturer name.
+	Manufacturer string `json:"manufacturer,omitempty"`
+	// Version is the LNX header version.
+	Version uint16 `json:"version"`
+	// Bank0Size is the size of cartridge bank 0 in bytes.
+	Bank0Size int64 `json:"bank0_size"`
+	// Bank1Size is the size of cartridge bank 1 in bytes (0 if unused).
+	Bank1Size int64 `json:"bank1_size"`
+	// Rotation is the screen rotation.
+	Rotation Rotation `json:"rotation"`
+	// Audin is true if the cartridge uses the AUDIN pin for banking (version 2+).
+	Audin bool `json:"audin,omitempty"`
+	// EEPROM is the EEPROM type byte (version 2+).
+	EEPROM byte `json:"eeprom,omitempty"`
+	// PayloadSize is the size of the cartridge data following the header.
+	PayloadSize int64 `json:"payload_size"`
+}
+
+// GamePlatform implements core.GameInfo.
+func (i *Info) GamePlatform() core.Platform { return core.PlatformLynx }
+
+// GameTitle implements core.GameInfo.
+func (i *Info) GameTitle() string { return i.Title }
+
+// GameSerial implements core.GameInfo. LNX headers don't include serials.
+func (i *Info) GameSerial() string { return "" }
+
+// GameRegions implements core.GameInfo. LNX headers don't include regions.
+func (i *Info) GameRegions() []core.Region { return []core.Region{} }
+
+// Parse extracts game information from an LNX file.
+func Parse(r io.ReaderAt, size int64) (*Info, error) {
+	if size < HeaderSize {
+		return nil, fmt.Errorf("file too small for LNX header: %d bytes", size)
+	}
+
+	header := make([]byte, HeaderSize)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, fmt.Errorf("failed to read LNX header: %w", err)
+	}
+
+	if !bytes.Equal(header[lynxMagicOffset:lynxMagicOffset+len(lynxMagic)], lynxMagic) {
+		return nil, fmt.Errorf("not a valid LNX file: invalid magic")
+	}
+
+	version := binary.LittleEndian.Uint16(header[lynxVersionOffset:])
+
+	info := &Info{
+		Title:        util.ExtractASCII(header[lynxNameOffset : lynxNameOffset+lynxNameLen]),
+		Manufacturer: util.ExtractASCII(header[lynxManufacturerOffset : lynxManufacturerOffset+lynxManufacturerLen]),
+		Version:      version,
+		Bank0Size:    int64(binary.LittleEndian.Uint16(header[lynxBank0PageOffset:])) * lynxPagesPerBank,
+		Bank1Size:    int64(binary.LittleEndian.Uint16(header[lynxBank1PageOffset:])) * lynxPagesPerBank,
+		Rotation:     Rotation(header[lynxRotationOffset]),
+		PayloadSize:  size - HeaderSize,
+	}
+
+	if version >= 2 {
+		info.Audin = header[lynxAudinOffset] != 0
+		info.EEPROM = header[lynxEEPROMOffset]
+	}
+
+	return info, nil
+}
+
+// Payload returns the cartridge data following the LNX header. This is the
+// data No-Intro DATs hash, and the format of headerless .lyx dumps.
+func Payload(r io.ReaderAt, size int64) (*io.SectionReader, error) {
+	if _, err := Parse(r, size); err != nil {
+		return nil, err
+	}
+	return io.NewSectionReader(r, HeaderSize, size-HeaderSize), nil
+}
@@ -0,0 +1,102 @@
+package lynx
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/sargunv/rom-tools/lib/core"
+)
+
+// makeTestLNX creates an LNX file with a 256 KiB bank 0 and the given payload.
+func makeTestLNX(version uint16, payload []byte) []byte {
+	header := make([]byte, HeaderSize)
+	copy(header, lynxMagic)
+	header[lynxBank0PageOffset+1] = 0x04 // 0x0400 pages * 256 = 256 KiB
+	header[lynxVersionOffset] = byte(version)
+	copy(header[lynxNameOffset:], "Chip's Challenge")
+	copy(header[lynxManufacturerOffset:], "Epyx")
+	header[lynxRotationOffset] = byte(RotationLeft)
+	header[lynxAudinOffset] = 1
+	header[lynxEEPROMOffset] = 0x01
+	return append(header, payload...)
+}
+
+func TestParse(t *testing.T) {
+	data := makeTestLNX(1, make([]byte, 1024))
+
+	info, err := Parse(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if info.GamePlatform() != core.PlatformLynx {
+		t.Errorf("Platform = %v, want %v", info.GamePlatform(), core.PlatformLynx)
+	}
+	if info.GameTitle() != "Chip's Challenge" {
+		t.Errorf("Title = %q, want %q", info.GameTitle(), "Chip's Challenge")
+	}
+	if info.Manufacturer != "Epyx" {
+		t.Errorf("Manufacturer = %q, want %q", info.Manufacturer, "Epyx")
+	}
+	if info.Bank0Size != 256*1024 {
+		t.Errorf("Bank0Size = %d, want %d", info.Bank0Size, 256*1024)
+	}
+	if info.Bank1Size != 0 {
+		t.Errorf("Bank1Size = %d, want 0", info.Bank1Size)
+	}
+	if info.Rotation != RotationLeft {
+		t.Errorf("Rotation = %v, want %v", info.Rotation, RotationLeft)
+	}
+	if info.PayloadSize != 1024 {
+		t.Errorf("PayloadSize = %d, want 1024", info.PayloadSize)
+	}
+	// Version 1 headers don't define the AUDIN/EEPROM bytes
+	if info.Audin || info.EEPROM != 0 {
+		t.Errorf("Audin = %v, EEPROM = %d, want unset for version 1", info.Audin, info.EEPROM)
+	}
+}
+
+func TestParse_Version2(t *testing.T) {
+	data := makeTestLNX(2, nil)
+
+	info, err := Parse(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if !info.Audin {
+		t.Error("Audin = false, want true")
+	}
+	if info.EEPROM != 0x01 {
+		t.Errorf("EEPROM = %d, want 1", info.EEPROM)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	data := make([]byte, HeaderSize)
+	copy(data, "LYMX")
+	if _, err := Parse(bytes.NewReader(data), int64(len(data))); err == nil {
+		t.Error("Parse() expected error for invalid magic, got nil")
+	}
+
+	if _, err := Parse(bytes.NewReader(data[:10]), 10); err == nil {
+		t.Error("Parse() expected error for small file, got nil")
+	}
+}
+
+func TestPayload(t *testing.T) {
+	payload := []byte("cartridge data")
+	data := makeTestLNX(1, payload)
+
+	sr, err := Payload(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Payload() error = %v", err)
+	}
+	got, err := io.ReadAll(sr)
+	if err != nil {
+		t.Fatalf("failed to read payload: %v", err)
+	}
+	if !bytes.Equal(got, payload) {
+		t.Errorf("Payload() = %q, want %q", got, payload)
+	}
+}
@@ -0,0 +1,214 @@
+package amiga
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/sargunv/rom-tools/lib/core"
+)
+
+// Amiga ADF disk image parsing.
+//
+// ADF files are sector dumps of Amiga floppy disks: 80 cylinders of two
+// tracks, each 11 sectors of 512 bytes on double density disks (880 KiB) or
+// 22 on high density ones (1760 KiB). Some dumps include up to three extra
+// cylinders.
+//
+// AmigaDOS disks start with a boot block (the first two sectors):
+//
+//	Offset  Size  Description
+//	0x00    3     "DOS"
+//	0x03    1     Flags (bit 0: FFS, bit 1: international mode, bit 2: directory cache)
+//	0x04    4     Checksum
+//	0x08    4     Root block (usually 880, but the actual root is always mid-disk)
+//	0x0C    1012  Boot code
+//
+// The disk is bootable if the boot block checksum is valid. Many games use
+// non-DOS ("NDOS") disks with their own track loader in the boot block and
+// no filesystem.
+//
+// The root block sits in the middle of the disk (block 880 on DD disks, 1760
+// on HD disks) and names the volume:
+//
+//	Offset  Size  Description
+//	0x000   4     Type (2 = header block)
+//	0x014   4     Checksum (all longwords sum to 0)
+//	0x1B0   1     Volume name length
+//	0x1B1   30    Volume name
+//	0x1E4   12    Filesystem creation date (days, minutes, ticks)
+//	0x1FC   4     Secondary type (1 = root)
+//
+// Dates count days since 1978-01-01, minutes since midnight, and 1/50 s
+// ticks. All values are big-endian.
+//
+// Documentation: http://lclevy.free.fr/adflib/adf_info.html
+
+const (
+	adfBlockSize        = 512
+	adfBootBlockSize    = 2 * adfBlockSize
+	adfDDTrackSize      = 11 * adfBlockSize
+	adfHDTrackSize      = 22 * adfBlockSize
+	adfCylinders        = 80
+	adfMaxCylinders     = 83
+	adfDDSize           = adfCylinders * 2 * adfDDTrackSize
+	adfHDSize           = adfCylinders * 2 * adfHDTrackSize
+	adfFlagsOffset      = 0x03
+	adfBootSumOffset    = 0x04
+	adfRootTypeOffset   = 0x000
+	adfRootNameLenOff   = 0x1B0
+	adfRootNameOffset   = 0x1B1
+	adfRootNameMaxLen   = 30
+	adfRootCreatedOff   = 0x1E4
+	adfRootSecTypeOff   = 0x1FC
+	adfRootType         = 2
+	adfRootSecType      = 1
+	adfTicksPerSecond   = 50
+	adfFlagFFS          = 1 << 0
+	adfFlagIntl         = 1 << 1
+	adfFlagDirCache     = 1 << 2
+	adfBootChecksumGood = 0xFFFFFFFF
+)
+
+var (
+	adfDOSMagic = []byte("DOS")
+	amigaEpoch  = time.Date(1978, 1, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// FileSystem is the AmigaDOS filesystem of a disk.
+type FileSystem string
+
+// FileSystem values
+const (
+	FileSystemNDOS FileSystem = "NDOS" // Not an AmigaDOS disk
+	FileSystemOFS  FileSystem = "OFS"  // Original File System
+	FileSystemFFS  FileSystem = "FFS"  // Fast File System
+)
+
+// ADFInfo contains metadata extracted from an ADF disk image.
+type ADFInfo struct {
+	// VolumeName is the volume name from the root block.
+	VolumeName string `json:"volume_name,omitempty"`
+	// FileSystem is the disk's filesystem.
+	FileSystem FileSystem `json:"file_system"`
+	// International is true if the filesystem uses international mode.
+	International bool `json:"international,omitempty"`
+	// DirCache is true if the filesystem uses directory caching.
+	DirCache bool `json:"dir_cache,omitempty"`
+	// Bootable is true if the boot block checksum is valid.
+	Bootable bool `json:"bootable"`
+	// HighDensity is true for HD disks.
+	HighDensity bool `json:"high_density,omitempty"`
+	// Created is when the filesystem was created (zero if not a DOS disk).
+	Created time.Time `json:"created,omitzero"`
+}
+
+// GamePlatform implements core.GameInfo.
+func (i *ADFInfo) GamePlatform() core.Platform { return core.PlatformAmiga }
+
+// GameTitle implements core.GameInfo.
+func (i *ADFInfo) GameTitle() string { return i.VolumeName }
+
+// GameSerial implements core.GameInfo. Disks don't include serials.
+func (i *ADFInfo) GameSerial() string { return "" }
+
+// GameRegions implements core.GameInfo. Disks don't include regions.
+func (i *ADFInfo) GameRegions() []core.Region { return []core.Region{} }
+
+// ParseADF extracts the volume information from an ADF disk image.
+func ParseADF(r io.ReaderAt, size int64) (*ADFInfo, error) {
+	info := &ADFInfo{}
+	switch {
+	case size == adfHDSize:
+		info.HighDensity = true
+	case size >= adfDDSize && size <= adfMaxCylinders*2*adfDDTrackSize && size%(2*adfDDTrackSize) == 0:
+	default:
+		return nil, fmt.Errorf("not a valid ADF image: unexpected size %d", size)
+	}
+
+	boot := make([]byte, adfBootBlockSize)
+	if _, err := r.ReadAt(boot, 0); err != nil {
+		return nil, fmt.Errorf("failed to read ADF boot block: %w", err)
+	}
+	info.Bootable = bootBlockChecksum(boot) == adfBootChecksumGood
+
+	if !bytes.HasPrefix(boot, adfDOSMagic) {
+		info.FileSystem = FileSystemNDOS
+		return info, nil
+	}
+	flags := boot[adfFlagsOffset]
+	info.FileSystem = FileSystemOFS
+	if flags&adfFlagFFS != 0 {
+		info.FileSystem = FileSystemFFS
+	}
+	info.International = flags&(adfFlagIntl|adfFlagDirCache) != 0 // DC implies international mode
+	info.DirCache = flags&adfFlagDirCache != 0
+
+	blocks := adfDDSize / adfBlockSize
+	if info.HighDensity {
+		blocks = adfHDSize / adfBlockSize
+	}
+	root := make([]byte, adfBlockSize)
+	if _, err := r.ReadAt(root, int64(blocks/2)*adfBlockSize); err != nil {
+		return nil, fmt.Errorf("failed to read ADF root block: %w", err)
+	}
+	if binary.BigEndian.Uint32(root[adfRootTypeOffset:]) != adfRootType ||
+		binary.BigEndian.Uint32(root[adfRootSecTypeOff:]) != adfRootSecType {
+		return nil, fmt.Errorf("not a valid ADF image: block %d is not a root block", blocks/2)
+	}
+	if blockChecksum(root) != 0 {
+		return nil, fmt.Errorf("not a valid ADF image: root block checksum mismatch")
+	}
+
+	nameLen := min(int(root[adfRootNameLenOff]), adfRootNameMaxLen)
+	info.VolumeName = decodeLatin1(root[adfRootNameOffset : adfRootNameOffset+nameLen])
+	info.Created = amigaDate(root[adfRootCreatedOff:])
+	return info, nil
+}
+
+// bootBlockChecksum sums the boot block's longwords with end-around carry.
+// Valid boot blocks sum to 0xFFFFFFFF.
+func bootBlockChecksum(boot []byte) uint32 {
+	var sum uint32
+	for i := 0; i < len(boot); i += 4 {
+		prev := sum
+		sum += binary.BigEndian.Uint32(boot[i:])
+		if sum < prev {
+			sum++
+		}
+	}
+	return sum
+}
+
+// blockChecksum sums a block's longwords. Valid blocks sum to 0.
+func blockChecksum(block []byte) uint32 {
+	var sum uint32
+	for i := 0; i < len(block); i += 4 {
+		sum += binary.BigEndian.Uint32(block[i:])
+	}
+	return sum
+}
+
+// amigaDate decodes an AmigaDOS date stamp of days, minutes, and ticks.
+func amigaDate(data []byte) time.Time {
+	days := binary.BigEndian.Uint32(data)
+	mins := binary.BigEndian.Uint32(data[4:])
+	ticks := binary.BigEndian.Uint32(data[8:])
+	if days == 0 && mins == 0 && ticks == 0 {
+		return time.Time{}
+	}
+	return amigaEpoch.AddDate(0, 0, int(days)).
+		Add(time.Duration(mins) * time.Minute).
+		Add(time.Duration(ticks) * time.Second / adfTicksPerSecond)
+}
+
+// decodeLatin1 decodes ISO 8859-1 text, the Amiga's character set.
+func decodeLatin1(data []byte) string {
+	runes := make([]rune, len(data))
+	for i, b := range data {
+		runes[i] = rune(b)
+	}
+	return string(runes)
+}
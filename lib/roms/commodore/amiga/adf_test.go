@@ -0,0 +1,110 @@
+package amiga
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/sargunv/rom-tools/lib/core"
+)
+
+// makeTestADF creates a DD FFS disk image with the given volume name.
+func makeTestADF(name string) []byte {
+	img := make([]byte, adfDDSize)
+
+	boot := img[:adfBootBlockSize]
+	copy(boot, adfDOSMagic)
+	boot[adfFlagsOffset] = adfFlagFFS | adfFlagIntl
+	binary.BigEndian.PutUint32(boot[0x08:], 880)
+	binary.BigEndian.PutUint32(boot[adfBootSumOffset:], ^bootBlockChecksum(boot))
+
+	root := img[880*adfBlockSize : 881*adfBlockSize]
+	binary.BigEndian.PutUint32(root[adfRootTypeOffset:], adfRootType)
+	binary.BigEndian.PutUint32(root[adfRootSecTypeOff:], adfRootSecType)
+	root[adfRootNameLenOff] = byte(len(name))
+	copy(root[adfRootNameOffset:], name)
+	binary.BigEndian.PutUint32(root[adfRootCreatedOff:], 5000)  // days
+	binary.BigEndian.PutUint32(root[adfRootCreatedOff+4:], 90)  // minutes
+	binary.BigEndian.PutUint32(root[adfRootCreatedOff+8:], 100) // ticks
+	binary.BigEndian.PutUint32(root[0x14:], -blockChecksum(root))
+	return img
+}
+
+func TestParseADF(t *testing.T) {
+	img := makeTestADF("Lemmings")
+
+	info, err := ParseADF(bytes.NewReader(img), int64(len(img)))
+	if err != nil {
+		t.Fatalf("ParseADF() error = %v", err)
+	}
+
+	if info.GamePlatform() != core.PlatformAmiga {
+		t.Errorf("Platform = %v, want %v", info.GamePlatform(), core.PlatformAmiga)
+	}
+	if info.GameTitle() != "Lemmings" {
+		t.Errorf("Title = %q, want %q", info.GameTitle(), "Lemmings")
+	}
+	if info.FileSystem != FileSystemFFS || !info.International || info.DirCache {
+		t.Errorf("FileSystem = %v, International = %v, DirCache = %v, want FFS, true, false",
+			info.FileSystem, info.International, info.DirCache)
+	}
+	if !info.Bootable {
+		t.Error("Bootable = false, want true")
+	}
+	if info.HighDensity {
+		t.Error("HighDensity = true, want false")
+	}
+	wantCreated := time.Date(1991, 9, 10, 1, 30, 2, 0, time.UTC)
+	if !info.Created.Equal(wantCreated) {
+		t.Errorf("Created = %v, want %v", info.Created, wantCreated)
+	}
+}
+
+func TestParseADF_ExtraCylinders(t *testing.T) {
+	img := append(makeTestADF("EXTRA"), make([]byte, 2*adfDDTrackSize)...)
+
+	info, err := ParseADF(bytes.NewReader(img), int64(len(img)))
+	if err != nil {
+		t.Fatalf("ParseADF() error = %v", err)
+	}
+	if info.VolumeName != "EXTRA" {
+		t.Errorf("VolumeName = %q, want %q", info.VolumeName, "EXTRA")
+	}
+}
+
+func TestParseADF_NDOS(t *testing.T) {
+	img := make([]byte, adfDDSize)
+	copy(img, []byte{0x60, 0x00}) // track loader code
+
+	info, err := ParseADF(bytes.NewReader(img), int64(len(img)))
+	if err != nil {
+		t.Fatalf("ParseADF() error = %v", err)
+	}
+	if info.FileSystem != FileSystemNDOS {
+		t.Errorf("FileSystem = %v, want %v", info.FileSystem, FileSystemNDOS)
+	}
+	if info.Bootable {
+		t.Error("Bootable = true, want false")
+	}
+	if info.GameTitle() != "" {
+		t.Errorf("Title = %q, want empty", info.GameTitle())
+	}
+}
+
+func TestParseADF_InvalidSize(t *testing.T) {
+	img := make([]byte, adfDDSize-adfBlockSize)
+
+	if _, err := ParseADF(bytes.NewReader(img), int64(len(img))); err == nil {
+		t.Error("ParseADF() expected error for truncated image")
+	}
+}
+
+func TestParseADF_BadRootChecksum(t *testing.T) {
+	img := makeTestADF("Lemmings")
+	img[880*adfBlockSize+adfRootNameOffset] ^= 0xFF
+
+	if _, err := ParseADF(bytes.NewReader(img), int64(len(img))); err == nil {
+		t.Error("ParseADF() expected error for bad root block checksum")
+	}
+}
@@ -0,0 +1,619 @@
+package amiga
+
+import (
+	"errors"
+	"fmt"
+)
+
+// DMS track decrunchers, ported from xDMS.
+//
+// All LZ77 modes copy matches out of a shared ring buffer, each mode using
+// its own window size and write position. QUICK and MEDIUM code literals and
+// matches with fixed-width fields, DEEP with an adaptive Huffman tree (as in
+// LZHUF), and HEAVY with static Huffman tables sent with the track data.
+
+const (
+	dmsTextSize = 0x4000
+	dmsTextInit = 0x3FC8 // bytes of the ring buffer cleared on reset
+
+	quickMask  = 0xFF
+	mediumMask = 0x3FFF
+	deepMask   = 0x3FFF
+	heavy1Mask = 0x0FFF
+	heavy2Mask = 0x1FFF
+
+	// DEEP adaptive Huffman tree
+	deepLookahead = 60
+	deepThreshold = 2
+	deepChars     = 256 - deepThreshold + deepLookahead // literal and length codes
+	deepTable     = deepChars*2 - 1                     // nodes in the tree
+	deepRoot      = deepTable - 1
+	deepMaxFreq   = 0x8000
+
+	// HEAVY static Huffman tables
+	heavyChars      = 510 // literal and length codes
+	heavyCharBits   = 12  // bits looked up directly in the code table
+	heavyPosBits    = 8   // bits looked up directly in the position table
+	heavyMatchBase  = 253 // code of a match of length 0
+	heavy1PosCodes  = 14
+	heavy2PosCodes  = 15
+	heavyMaxPosCode = 20
+)
+
+// posCode and posLen decode the upper six bits of a match position and the
+// number of extra bits used by the first byte, as in LZHUF.
+var posCode, posLen = func() (code, length [256]byte) {
+	// Each group is (count of first bytes, bits per position code)
+	groups := []struct{ count, bits int }{{32, 3}, {48, 4}, {64, 5}, {48, 6}, {48, 7}, {16, 8}}
+	i, c := 0, 0
+	for _, g := range groups {
+		perCode := 1 << (8 - g.bits)
+		for n := 0; n < g.count; n++ {
+			code[i] = byte(c + n/perCode)
+			length[i] = byte(g.bits)
+			i++
+		}
+		c += g.count / perCode
+	}
+	return code, length
+}()
+
+// bitReader reads bits most significant first, as xDMS's GETBITS and
+// DROPBITS macros do. Reads past the end of the input return zero bits.
+type bitReader struct {
+	in    []byte
+	buf   uint32
+	count uint
+}
+
+func newBitReader(in []byte) *bitReader {
+	br := &bitReader{in: in}
+	br.drop(0)
+	return br
+}
+
+// peek returns the next n bits (n <= 16) without consuming them.
+func (br *bitReader) peek(n uint) uint16 {
+	return uint16(br.buf >> (br.count - n))
+}
+
+// drop consumes n bits, refilling the buffer to at least 16 bits.
+func (br *bitReader) drop(n uint) {
+	br.count -= n
+	br.buf &= 1<<br.count - 1
+	for br.count < 16 {
+		var b byte
+		if len(br.in) > 0 {
+			b, br.in = br.in[0], br.in[1:]
+		}
+		br.buf = br.buf<<8 | uint32(b)
+		br.count += 8
+	}
+}
+
+// read consumes and returns the next n bits.
+func (br *bitReader) read(n uint) uint16 {
+	v := br.peek(n)
+	br.drop(n)
+	return v
+}
+
+// decruncher holds the state the LZ77 decrunchers carry between tracks.
+type decruncher struct {
+	text []byte
+
+	quickPos, mediumPos, deepPos, heavyPos uint16
+
+	// DEEP tree
+	deepInit bool
+	freq     [deepTable + 1]uint16
+	parent   [deepTable + deepChars]uint16
+	son      [deepTable]uint16
+
+	// HEAVY tables
+	left, right          [2*heavyChars - 1 + 9]uint16
+	charLen              [heavyChars]byte
+	posTableLen          [heavyMaxPosCode]byte
+	charTable            [1 << heavyCharBits]uint16
+	posTable             [1 << heavyPosBits]uint16
+	posCodes, lastPosLen uint16
+}
+
+func newDecruncher() *decruncher {
+	d := &decruncher{text: make([]byte, dmsTextSize)}
+	d.reset()
+	return d
+}
+
+// reset restores the initial dictionary state.
+func (d *decruncher) reset() {
+	d.quickPos = 251
+	d.mediumPos = 0x3FBE
+	d.heavyPos = 0
+	d.deepPos = 0x3FC4
+	d.deepInit = true
+	clear(d.text[:dmsTextInit])
+}
+
+// unpackTrack unpacks one track's data.
+func (d *decruncher) unpackTrack(packed []byte, midLen, size int, mode, flags byte) ([]byte, error) {
+	var out []byte
+	var err error
+	switch mode {
+	case dmsModeNone:
+		if len(packed) < size {
+			return nil, errors.New("uncompressed track is truncated")
+		}
+		out = packed[:size]
+	case dmsModeSimple:
+		out, err = unpackRLE(packed, size)
+	case dmsModeQuick, dmsModeMedium, dmsModeDeep:
+		var mid []byte
+		switch mode {
+		case dmsModeQuick:
+			mid = d.unpackQuick(packed, midLen)
+		case dmsModeMedium:
+			mid = d.unpackMedium(packed, midLen)
+		default:
+			mid = d.unpackDeep(packed, midLen)
+		}
+		out, err = unpackRLE(mid, size)
+	case dmsModeHeavy1, dmsModeHeavy2:
+		out, err = d.unpackHeavy(packed, midLen, mode == dmsModeHeavy2, flags&dmsFlagNewTables != 0)
+		if err == nil && flags&dmsFlagRLE != 0 {
+			out, err = unpackRLE(out, size)
+		}
+	default:
+		return nil, fmt.Errorf("unknown compression mode %d", mode)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(out) < size {
+		return nil, fmt.Errorf("track unpacked to %d bytes, want %d", len(out), size)
+	}
+
+	if flags&dmsFlagKeepState == 0 {
+		d.reset()
+	}
+	return out[:size], nil
+}
+
+// copyMatch appends a match of length bytes starting at src in the ring
+// buffer, advancing pos.
+func (d *decruncher) copyMatch(out []byte, pos *uint16, src uint16, length int, mask uint16) []byte {
+	for range length {
+		b := d.text[src&mask]
+		d.text[*pos&mask] = b
+		out = append(out, b)
+		*pos++
+		src++
+	}
+	return out
+}
+
+// literal appends a literal byte, advancing pos.
+func (d *decruncher) literal(out []byte, pos *uint16, b byte, mask uint16) []byte {
+	d.text[*pos&mask] = b
+	*pos++
+	return append(out, b)
+}
+
+// unpackQuick decodes QUICK data: a 1 bit and an 8-bit literal, or a 0 bit,
+// a 2-bit length (+2), and an 8-bit distance (+1).
+func (d *decruncher) unpackQuick(in []byte, size int) []byte {
+	br := newBitReader(in)
+	out := make([]byte, 0, size+8)
+	for len(out) < size {
+		if br.read(1) != 0 {
+			out = d.literal(out, &d.quickPos, byte(br.read(8)), quickMask)
+		} else {
+			length := int(br.read(2)) + 2
+			src := d.quickPos - br.read(8) - 1
+			out = d.copyMatch(out, &d.quickPos, src, length, quickMask)
+		}
+	}
+	d.quickPos = (d.quickPos + 5) & quickMask
+	return out[:size]
+}
+
+// unpackMedium decodes MEDIUM data: a 1 bit and an 8-bit literal, or a 0 bit
+// and LZHUF-style coded length and distance.
+func (d *decruncher) unpackMedium(in []byte, size int) []byte {
+	br := newBitReader(in)
+	out := make([]byte, 0, size+64)
+	for len(out) < size {
+		if br.read(1) != 0 {
+			out = d.literal(out, &d.mediumPos, byte(br.read(8)), mediumMask)
+			continue
+		}
+		c := br.read(8)
+		length := int(posCode[c]) + 3
+		u := uint(posLen[c])
+		c = (c<<u | br.read(u)) & 0xFF
+		u = uint(posLen[c])
+		c = uint16(posCode[c])<<8 | (c<<u|br.read(u))&0xFF
+		src := d.mediumPos - c - 1
+		out = d.copyMatch(out, &d.mediumPos, src, length, mediumMask)
+	}
+	d.mediumPos = (d.mediumPos + 66) & mediumMask
+	return out[:size]
+}
+
+// unpackDeep decodes DEEP data: adaptive Huffman coded literals and match
+// lengths, each match followed by an LZHUF-style coded distance.
+func (d *decruncher) unpackDeep(in []byte, size int) []byte {
+	br := newBitReader(in)
+	if d.deepInit {
+		d.initDeepTree()
+	}
+	out := make([]byte, 0, size+deepLookahead)
+	for len(out) < size {
+		c := d.decodeDeepChar(br)
+		if c < 256 {
+			out = d.literal(out, &d.deepPos, byte(c), deepMask)
+			continue
+		}
+		length := int(c) - 255 + deepThreshold
+		src := d.deepPos - decodeDeepPosition(br) - 1
+		out = d.copyMatch(out, &d.deepPos, src, length, deepMask)
+	}
+	d.deepPos = (d.deepPos + 60) & deepMask
+	return out[:size]
+}
+
+// initDeepTree builds the initial DEEP tree, with every code equally likely.
+func (d *decruncher) initDeepTree() {
+	for i := range uint16(deepChars) {
+		d.freq[i] = 1
+		d.son[i] = i + deepTable
+		d.parent[i+deepTable] = i
+	}
+	for i, j := uint16(0), uint16(deepChars); j <= deepRoot; i, j = i+2, j+1 {
+		d.freq[j] = d.freq[i] + d.freq[i+1]
+		d.son[j] = i
+		d.parent[i], d.parent[i+1] = j, j
+	}
+	d.freq[deepTable] = 0xFFFF
+	d.parent[deepRoot] = 0
+	d.deepInit = false
+}
+
+// decodeDeepChar walks the tree from the root to a leaf, one bit per level,
+// then updates the tree.
+func (d *decruncher) decodeDeepChar(br *bitReader) uint16 {
+	c := d.son[deepRoot]
+	for c < deepTable {
+		c = d.son[c+br.read(1)]
+	}
+	c -= deepTable
+	d.updateDeepTree(c)
+	return c
+}
+
+// decodeDeepPosition decodes a match distance.
+func decodeDeepPosition(br *bitReader) uint16 {
+	i := br.read(8)
+	c := uint16(posCode[i]) << 8
+	j := uint(posLen[i])
+	i = (i<<j | br.read(j)) & 0xFF
+	return c | i
+}
+
+// updateDeepTree increments the frequency of code c, swapping nodes to keep
+// the tree ordered by frequency.
+func (d *decruncher) updateDeepTree(c uint16) {
+	if d.freq[deepRoot] == deepMaxFreq {
+		d.rebuildDeepTree()
+	}
+	c = d.parent[c+deepTable]
+	for {
+		d.freq[c]++
+		k := d.freq[c]
+
+		// If the order is disturbed, exchange nodes
+		if l := c + 1; k > d.freq[l] {
+			for l++; k > d.freq[l]; l++ {
+			}
+			l--
+			d.freq[c] = d.freq[l]
+			d.freq[l] = k
+
+			i := d.son[c]
+			d.parent[i] = l
+			if i < deepTable {
+				d.parent[i+1] = l
+			}
+
+			j := d.son[l]
+			d.son[l] = i
+
+			d.parent[j] = c
+			if j < deepTable {
+				d.parent[j+1] = c
+			}
+			d.son[c] = j
+
+			c = l
+		}
+
+		c = d.parent[c]
+		if c == 0 {
+			return
+		}
+	}
+}
+
+// rebuildDeepTree halves all frequencies and rebuilds the tree.
+func (d *decruncher) rebuildDeepTree() {
+	// Collect the leaves in the first half of the table, halving their
+	// frequencies
+	j := 0
+	for i := range deepTable {
+		if d.son[i] >= deepTable {
+			d.freq[j] = (d.freq[i] + 1) / 2
+			d.son[j] = d.son[i]
+			j++
+		}
+	}
+
+	// Connect sons, keeping nodes sorted by frequency
+	for i, j := 0, deepChars; j < deepTable; i, j = i+2, j+1 {
+		f := d.freq[i] + d.freq[i+1]
+		k := j - 1
+		for f < d.freq[k] {
+			k--
+		}
+		k++
+		copy(d.freq[k+1:j+1], d.freq[k:j])
+		d.freq[k] = f
+		copy(d.son[k+1:j+1], d.son[k:j])
+		d.son[k] = uint16(i)
+	}
+
+	// Connect parents
+	for i := range uint16(deepTable) {
+		if k := d.son[i]; k >= deepTable {
+			d.parent[k] = i
+		} else {
+			d.parent[k], d.parent[k+1] = i, i
+		}
+	}
+}
+
+// unpackHeavy decodes HEAVY data: static Huffman coded literals and match
+// lengths, each match followed by a Huffman coded distance. New tables are
+// read from the start of the data if newTables is set; otherwise the
+// previous track's are reused.
+func (d *decruncher) unpackHeavy(in []byte, size int, heavy2, newTables bool) ([]byte, error) {
+	mask := uint16(heavy1Mask)
+	d.posCodes = heavy1PosCodes
+	if heavy2 {
+		mask = heavy2Mask
+		d.posCodes = heavy2PosCodes
+	}
+
+	br := newBitReader(in)
+	if newTables {
+		if err := d.readCharTable(br); err != nil {
+			return nil, err
+		}
+		if err := d.readPosTable(br); err != nil {
+			return nil, err
+		}
+	}
+
+	out := make([]byte, 0, size+256)
+	for len(out) < size {
+		c := d.decodeHeavyChar(br)
+		if c < 256 {
+			out = d.literal(out, &d.heavyPos, byte(c), mask)
+			continue
+		}
+		length := int(c) - heavyMatchBase
+		src := d.heavyPos - d.decodeHeavyPosition(br) - 1
+		out = d.copyMatch(out, &d.heavyPos, src, length, mask)
+	}
+	return out[:size], nil
+}
+
+// decodeHeavyChar decodes a literal or match length code.
+func (d *decruncher) decodeHeavyChar(br *bitReader) uint16 {
+	j := d.charTable[br.peek(heavyCharBits)]
+	if j < heavyChars {
+		br.drop(uint(d.charLen[j]))
+		return j
+	}
+	br.drop(heavyCharBits)
+	j = d.walkTree(j, br.peek(16), heavyChars)
+	br.drop(uint(d.charLen[j]) - heavyCharBits)
+	return j
+}
+
+// decodeHeavyPosition decodes a match distance. The last position code
+// repeats the previous distance.
+func (d *decruncher) decodeHeavyPosition(br *bitReader) uint16 {
+	j := d.posTable[br.peek(heavyPosBits)]
+	if j < d.posCodes {
+		br.drop(uint(d.posTableLen[j]))
+	} else {
+		br.drop(heavyPosBits)
+		j = d.walkTree(j, br.peek(16), d.posCodes)
+		br.drop(uint(d.posTableLen[j]) - heavyPosBits)
+	}
+
+	if j != d.posCodes-1 {
+		if j > 0 {
+			n := uint(j - 1)
+			j = br.read(n) | 1<<n
+		}
+		d.lastPosLen = j
+	}
+	return d.lastPosLen
+}
+
+// walkTree follows a code longer than the lookup table through the
+// overflow tree, one bit of bits per level. Corrupt trees decode as code 0;
+// the track checksum catches the bad output.
+func (d *decruncher) walkTree(j, bits, leaves uint16) uint16 {
+	for m := uint16(0x8000); j >= leaves; m >>= 1 {
+		if m == 0 || int(j) >= len(d.left) {
+			return 0
+		}
+		if bits&m != 0 {
+			j = d.right[j]
+		} else {
+			j = d.left[j]
+		}
+	}
+	return j
+}
+
+// readCharTable reads the literal and length code lengths.
+func (d *decruncher) readCharTable(br *bitReader) error {
+	n := br.read(9)
+	if n == 0 {
+		// A single code, used for every symbol
+		c := br.read(9)
+		if c >= heavyChars {
+			return fmt.Errorf("invalid HEAVY code %d", c)
+		}
+		clear(d.charLen[:])
+		for i := range d.charTable {
+			d.charTable[i] = c
+		}
+		return nil
+	}
+	if n > heavyChars {
+		return fmt.Errorf("invalid HEAVY code count %d", n)
+	}
+	for i := range n {
+		d.charLen[i] = byte(br.read(5))
+	}
+	clear(d.charLen[n:])
+	return d.makeTable(heavyChars, d.charLen[:], heavyCharBits, d.charTable[:])
+}
+
+// readPosTable reads the position code lengths.
+func (d *decruncher) readPosTable(br *bitReader) error {
+	n := br.read(5)
+	if n == 0 {
+		c := br.read(5)
+		if c >= d.posCodes {
+			return fmt.Errorf("invalid HEAVY position code %d", c)
+		}
+		clear(d.posTableLen[:d.posCodes])
+		for i := range d.posTable {
+			d.posTable[i] = c
+		}
+		return nil
+	}
+	if n > d.posCodes {
+		return fmt.Errorf("invalid HEAVY position code count %d", n)
+	}
+	for i := range n {
+		d.posTableLen[i] = byte(br.read(4))
+	}
+	clear(d.posTableLen[n:d.posCodes])
+	return d.makeTable(d.posCodes, d.posTableLen[:], heavyPosBits, d.posTable[:])
+}
+
+// tableMaker builds a Huffman lookup table from code lengths. Codes up to
+// tableBits long fill table entries directly; longer ones go through the
+// left/right overflow tree, whose nodes are numbered from nchar up.
+type tableMaker struct {
+	d             *decruncher
+	nchar         uint16
+	lengths       []byte
+	table         []uint16
+	tableSize     uint16
+	c             int
+	length, depth uint16
+	maxDepth      uint16
+	avail         uint16
+	codeword, bit uint16
+	err           error
+}
+
+// makeTable builds a lookup table, as xDMS's make_table.
+func (d *decruncher) makeTable(nchar uint16, lengths []byte, tableBits uint, table []uint16) error {
+	m := &tableMaker{
+		d:         d,
+		nchar:     nchar,
+		lengths:   lengths,
+		table:     table,
+		tableSize: 1 << tableBits,
+		bit:       1 << tableBits / 2,
+		maxDepth:  uint16(tableBits) + 1,
+		depth:     1,
+		length:    1,
+		c:         -1,
+		avail:     nchar,
+	}
+	m.make() // left subtree
+	m.make() // right subtree
+	if m.err != nil {
+		return m.err
+	}
+	if m.codeword != m.tableSize {
+		return errors.New("incomplete HEAVY Huffman table")
+	}
+	return nil
+}
+
+func (m *tableMaker) make() uint16 {
+	if m.err != nil {
+		return 0
+	}
+
+	if m.length == m.depth {
+		for m.c++; m.c < int(m.nchar); m.c++ {
+			if uint16(m.lengths[m.c]) != m.length {
+				continue
+			}
+			i := m.codeword
+			m.codeword += m.bit
+			if m.codeword > m.tableSize {
+				m.err = errors.New("oversubscribed HEAVY Huffman table")
+				return 0
+			}
+			for ; i < m.codeword; i++ {
+				m.table[i] = uint16(m.c)
+			}
+			return uint16(m.c)
+		}
+		m.c = -1
+		m.length++
+		m.bit >>= 1
+	}
+
+	var i uint16
+	m.depth++
+	switch {
+	case m.depth < m.maxDepth:
+		m.make()
+		m.make()
+	case m.depth > 32:
+		m.err = errors.New("HEAVY Huffman code too long")
+		return 0
+	default:
+		i = m.avail
+		m.avail++
+		if i >= 2*m.nchar-1 {
+			m.err = errors.New("too many HEAVY Huffman tree nodes")
+			return 0
+		}
+		m.d.left[i] = m.make()
+		m.d.right[i] = m.make()
+		if m.codeword >= m.tableSize {
+			m.err = errors.New("oversubscribed HEAVY Huffman table")
+			return 0
+		}
+		if m.depth == m.maxDepth {
+			m.table[m.codeword] = i
+			m.codeword++
+		}
+	}
+	m.depth--
+	return i
+}
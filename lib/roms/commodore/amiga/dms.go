@@ -0,0 +1,248 @@
+package amiga
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Amiga DMS (Disk Masher System) archive decompression.
+//
+// DMS archives hold a floppy disk compressed track by track. A 56-byte
+// archive header is followed by one block per track, each a 20-byte header
+// and the packed data:
+//
+//	Archive header (big-endian):
+//	Offset  Size  Description
+//	0x00    4     "DMS!"
+//	0x0A    2     General info flags (bit 1: encrypted)
+//	0x10    2     Lowest track
+//	0x12    2     Highest track
+//	0x36    2     CRC-16 of 0x04-0x35
+//
+//	Track header (big-endian):
+//	Offset  Size  Description
+//	0x00    2     "TR"
+//	0x02    2     Cylinder number (0xFFFF = banner, 80 = FILE_ID.DIZ text)
+//	0x06    2     Packed length
+//	0x08    2     Length after the first unpacking stage
+//	0x0A    2     Unpacked length
+//	0x0C    1     Flags (bit 0: keep decruncher state, bit 1: new Huffman tables, bit 2: RLE stage)
+//	0x0D    1     Compression mode
+//	0x0E    2     Sum of the unpacked bytes
+//	0x10    2     CRC-16 of the packed data
+//	0x12    2     CRC-16 of 0x00-0x11
+//
+// Compression modes are NOCOMP (0), SIMPLE (1, run-length encoding), and
+// QUICK (2), MEDIUM (3), DEEP (4), HEAVY1 (5), and HEAVY2 (6), LZ77 variants
+// followed by run-length decoding. The LZ77 decrunchers share a dictionary
+// that carries over from one track to the next unless a track's flags say
+// otherwise. This follows the xDMS reference implementation.
+
+const (
+	dmsHeaderSize      = 56
+	dmsInfoOffset      = 0x0A
+	dmsHeaderCRCOffset = 0x36
+	dmsTrackHeaderSize = 20
+	dmsInfoEncrypted   = 1 << 1
+	dmsMaxTrackSize    = 0x8000
+	dmsBannerTrack     = 0xFFFF
+
+	dmsModeNone   = 0
+	dmsModeSimple = 1
+	dmsModeQuick  = 2
+	dmsModeMedium = 3
+	dmsModeDeep   = 4
+	dmsModeHeavy1 = 5
+	dmsModeHeavy2 = 6
+
+	dmsFlagKeepState = 1 << 0
+	dmsFlagNewTables = 1 << 1
+	dmsFlagRLE       = 1 << 2
+)
+
+var (
+	dmsMagic      = []byte("DMS!")
+	dmsTrackMagic = []byte("TR")
+)
+
+// NewDMSReader decompresses the disk in a DMS archive, returning a reader of
+// its ADF image.
+func NewDMSReader(r io.ReaderAt, size int64) (*bytes.Reader, error) {
+	if size < dmsHeaderSize {
+		return nil, fmt.Errorf("file too small for DMS header: %d bytes", size)
+	}
+
+	header := make([]byte, dmsHeaderSize)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, fmt.Errorf("failed to read DMS header: %w", err)
+	}
+	if !bytes.HasPrefix(header, dmsMagic) {
+		return nil, fmt.Errorf("not a valid DMS archive: invalid magic")
+	}
+	if dmsCRC(header[4:dmsHeaderCRCOffset]) != binary.BigEndian.Uint16(header[dmsHeaderCRCOffset:]) {
+		return nil, fmt.Errorf("not a valid DMS archive: header CRC mismatch")
+	}
+	if binary.BigEndian.Uint16(header[dmsInfoOffset:])&dmsInfoEncrypted != 0 {
+		return nil, errors.New("encrypted DMS archives aren't supported")
+	}
+
+	d := newDecruncher()
+	var image []byte
+	trackLen := 0
+	trackHeader := make([]byte, dmsTrackHeaderSize)
+	for offset := int64(dmsHeaderSize); offset+dmsTrackHeaderSize <= size; {
+		if _, err := r.ReadAt(trackHeader, offset); err != nil {
+			return nil, fmt.Errorf("failed to read DMS track header: %w", err)
+		}
+		if !bytes.HasPrefix(trackHeader, dmsTrackMagic) {
+			// Some archives end with padding
+			break
+		}
+		if dmsCRC(trackHeader[:18]) != binary.BigEndian.Uint16(trackHeader[18:]) {
+			return nil, fmt.Errorf("not a valid DMS archive: track header CRC mismatch at %#x", offset)
+		}
+
+		number := binary.BigEndian.Uint16(trackHeader[2:])
+		packedLen := int(binary.BigEndian.Uint16(trackHeader[6:]))
+		midLen := int(binary.BigEndian.Uint16(trackHeader[8:]))
+		unpackedLen := int(binary.BigEndian.Uint16(trackHeader[10:]))
+		flags, mode := trackHeader[12], trackHeader[13]
+		sum := binary.BigEndian.Uint16(trackHeader[14:])
+		dataCRC := binary.BigEndian.Uint16(trackHeader[16:])
+		offset += dmsTrackHeaderSize
+
+		if offset+int64(packedLen) > size {
+			return nil, fmt.Errorf("not a valid DMS archive: track %d extends beyond end of file", number)
+		}
+		packed := make([]byte, packedLen)
+		if _, err := r.ReadAt(packed, offset); err != nil {
+			return nil, fmt.Errorf("failed to read DMS track %d: %w", number, err)
+		}
+		offset += int64(packedLen)
+		if dmsCRC(packed) != dataCRC {
+			return nil, fmt.Errorf("not a valid DMS archive: track %d data CRC mismatch", number)
+		}
+
+		// Only disk tracks are unpacked; banners and text don't affect the
+		// decrunchers' state
+		if number == dmsBannerTrack || number >= adfCylinders || unpackedLen <= 2048 {
+			continue
+		}
+		if midLen > dmsMaxTrackSize || unpackedLen > dmsMaxTrackSize {
+			return nil, fmt.Errorf("not a valid DMS archive: track %d is too large", number)
+		}
+
+		track, err := d.unpackTrack(packed, midLen, unpackedLen, mode, flags)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unpack DMS track %d: %w", number, err)
+		}
+		if dmsSum(track) != sum {
+			return nil, fmt.Errorf("failed to unpack DMS track %d: checksum mismatch", number)
+		}
+
+		trackLen = unpackedLen
+		end := (int(number) + 1) * unpackedLen
+		if end > len(image) {
+			image = append(image, make([]byte, end-len(image))...)
+		}
+		copy(image[int(number)*unpackedLen:], track)
+	}
+
+	if len(image) == 0 {
+		return nil, errors.New("not a valid DMS archive: no disk tracks")
+	}
+	// Pad archives of partial disks to a whole one
+	if diskSize := adfCylinders * trackLen; len(image) < diskSize {
+		image = append(image, make([]byte, diskSize-len(image))...)
+	}
+	return bytes.NewReader(image), nil
+}
+
+// ParseDMS extracts the volume information from the disk in a DMS archive.
+func ParseDMS(r io.ReaderAt, size int64) (*ADFInfo, error) {
+	adf, err := NewDMSReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+	return ParseADF(adf, adf.Size())
+}
+
+// dmsCRCTable is the CRC-16/ARC table (reflected polynomial 0xA001).
+var dmsCRCTable = func() [256]uint16 {
+	var table [256]uint16
+	for i := range table {
+		crc := uint16(i)
+		for range 8 {
+			if crc&1 != 0 {
+				crc = crc>>1 ^ 0xA001
+			} else {
+				crc >>= 1
+			}
+		}
+		table[i] = crc
+	}
+	return table
+}()
+
+// dmsCRC computes the CRC-16 DMS uses for headers and packed data.
+func dmsCRC(data []byte) uint16 {
+	var crc uint16
+	for _, b := range data {
+		crc = dmsCRCTable[byte(crc)^b] ^ crc>>8
+	}
+	return crc
+}
+
+// dmsSum computes the 16-bit sum DMS uses for unpacked tracks.
+func dmsSum(data []byte) uint16 {
+	var sum uint16
+	for _, b := range data {
+		sum += uint16(b)
+	}
+	return sum
+}
+
+// unpackRLE expands DMS run-length encoding: 0x90 0x00 is a literal 0x90,
+// 0x90 n b repeats b n times, and 0x90 0xFF b hi lo repeats b hi<<8|lo times.
+func unpackRLE(in []byte, size int) ([]byte, error) {
+	out := make([]byte, 0, size)
+	for len(out) < size {
+		if len(in) == 0 {
+			return nil, errors.New("RLE data ended early")
+		}
+		a := in[0]
+		if a != 0x90 {
+			out = append(out, a)
+			in = in[1:]
+			continue
+		}
+		if len(in) < 2 {
+			return nil, errors.New("RLE data ended early")
+		}
+		if in[1] == 0 {
+			out = append(out, a)
+			in = in[2:]
+			continue
+		}
+		if len(in) < 3 {
+			return nil, errors.New("RLE data ended early")
+		}
+		n, value := int(in[1]), in[2]
+		in = in[3:]
+		if n == 0xFF {
+			if len(in) < 2 {
+				return nil, errors.New("RLE data ended early")
+			}
+			n = int(in[0])<<8 | int(in[1])
+			in = in[2:]
+		}
+		if len(out)+n > size {
+			return nil, errors.New("RLE run overflows track")
+		}
+		out = append(out, bytes.Repeat([]byte{value}, n)...)
+	}
+	return out, nil
+}
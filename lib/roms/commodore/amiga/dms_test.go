@@ -0,0 +1,230 @@
+package amiga
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// bitWriter packs bits most significant first, as the DMS decrunchers read
+// them.
+type bitWriter struct {
+	out   []byte
+	nbits int
+}
+
+func (w *bitWriter) write(v uint16, n int) {
+	for i := n - 1; i >= 0; i-- {
+		if w.nbits%8 == 0 {
+			w.out = append(w.out, 0)
+		}
+		if v>>i&1 != 0 {
+			w.out[len(w.out)-1] |= 0x80 >> (w.nbits % 8)
+		}
+		w.nbits++
+	}
+}
+
+// packRLE encodes data with DMS run-length encoding.
+func packRLE(data []byte) []byte {
+	var out []byte
+	for i := 0; i < len(data); {
+		run := 1
+		for i+run < len(data) && data[i+run] == data[i] && run < 0xFFFF {
+			run++
+		}
+		switch {
+		case run >= 4:
+			out = append(out, 0x90, 0xFF, data[i], byte(run>>8), byte(run))
+			i += run
+		case data[i] == 0x90:
+			out = append(out, 0x90, 0x00)
+			i++
+		default:
+			out = append(out, data[i])
+			i++
+		}
+	}
+	return out
+}
+
+// packQuickLiterals encodes data as QUICK (or MEDIUM) literals only.
+func packQuickLiterals(data []byte) []byte {
+	w := &bitWriter{}
+	for _, b := range data {
+		w.write(1, 1)
+		w.write(uint16(b), 8)
+	}
+	return w.out
+}
+
+// dmsTrack is a track to add to a test archive.
+type dmsTrack struct {
+	number       uint16
+	mode, flags  byte
+	packed       []byte
+	midLen       int
+	unpacked     []byte
+	corruptCRC   bool
+	corruptTrack bool
+}
+
+// makeTestDMS creates a DMS archive of the given tracks.
+func makeTestDMS(info uint16, tracks []dmsTrack) []byte {
+	header := make([]byte, dmsHeaderSize)
+	copy(header, dmsMagic)
+	binary.BigEndian.PutUint16(header[dmsInfoOffset:], info)
+	binary.BigEndian.PutUint16(header[0x12:], adfCylinders-1)
+	binary.BigEndian.PutUint16(header[dmsHeaderCRCOffset:], dmsCRC(header[4:dmsHeaderCRCOffset]))
+
+	out := header
+	for _, tr := range tracks {
+		th := make([]byte, dmsTrackHeaderSize)
+		copy(th, dmsTrackMagic)
+		binary.BigEndian.PutUint16(th[2:], tr.number)
+		binary.BigEndian.PutUint16(th[6:], uint16(len(tr.packed)))
+		binary.BigEndian.PutUint16(th[8:], uint16(tr.midLen))
+		binary.BigEndian.PutUint16(th[10:], uint16(len(tr.unpacked)))
+		th[12], th[13] = tr.flags, tr.mode
+		binary.BigEndian.PutUint16(th[14:], dmsSum(tr.unpacked))
+		binary.BigEndian.PutUint16(th[16:], dmsCRC(tr.packed))
+		if tr.corruptTrack {
+			th[14] ^= 0xFF
+		}
+		binary.BigEndian.PutUint16(th[18:], dmsCRC(th[:18]))
+		if tr.corruptCRC {
+			th[18] ^= 0xFF
+		}
+		out = append(out, th...)
+		out = append(out, tr.packed...)
+	}
+	return out
+}
+
+// packDisk splits an ADF image into tracks, packing each in turn with the
+// given modes.
+func packDisk(img []byte, modes ...byte) []dmsTrack {
+	var tracks []dmsTrack
+	for n := range adfCylinders {
+		data := img[n*2*adfDDTrackSize : (n+1)*2*adfDDTrackSize]
+		tr := dmsTrack{number: uint16(n), mode: modes[n%len(modes)], unpacked: data}
+		switch tr.mode {
+		case dmsModeNone:
+			tr.packed = data
+		case dmsModeSimple:
+			tr.packed = packRLE(data)
+		case dmsModeQuick, dmsModeMedium:
+			mid := packRLE(data)
+			tr.packed, tr.midLen = packQuickLiterals(mid), len(mid)
+		}
+		tracks = append(tracks, tr)
+	}
+	return tracks
+}
+
+func TestParseDMS(t *testing.T) {
+	img := makeTestADF("Shadow of the Beast")
+	img[0x2000] = 0x90 // an RLE escape in the data
+	banner := dmsTrack{number: dmsBannerTrack, packed: []byte("hello"), unpacked: []byte("hello")}
+	tracks := append([]dmsTrack{banner}, packDisk(img, dmsModeNone, dmsModeSimple, dmsModeQuick, dmsModeMedium)...)
+	archive := makeTestDMS(0, tracks)
+
+	adf, err := NewDMSReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		t.Fatalf("NewDMSReader() error = %v", err)
+	}
+	got := make([]byte, adf.Size())
+	if _, err := adf.ReadAt(got, 0); err != nil {
+		t.Fatalf("ReadAt() error = %v", err)
+	}
+	if !bytes.Equal(got, img) {
+		t.Error("unpacked image doesn't match original")
+	}
+
+	info, err := ParseDMS(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		t.Fatalf("ParseDMS() error = %v", err)
+	}
+	if info.GameTitle() != "Shadow of the Beast" {
+		t.Errorf("Title = %q, want %q", info.GameTitle(), "Shadow of the Beast")
+	}
+}
+
+func TestParseDMS_PartialDisk(t *testing.T) {
+	img := makeTestADF("HALF")
+	tracks := packDisk(img, dmsModeSimple)[:adfCylinders/2+1] // through the root block
+	archive := makeTestDMS(0, tracks)
+
+	info, err := ParseDMS(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		t.Fatalf("ParseDMS() error = %v", err)
+	}
+	if info.VolumeName != "HALF" {
+		t.Errorf("VolumeName = %q, want %q", info.VolumeName, "HALF")
+	}
+}
+
+func TestParseDMS_Errors(t *testing.T) {
+	img := makeTestADF("ERR")
+
+	tests := []struct {
+		name    string
+		archive []byte
+	}{
+		{"bad magic", append([]byte("DMZ!"), makeTestDMS(0, packDisk(img, dmsModeNone))[4:]...)},
+		{"encrypted", makeTestDMS(dmsInfoEncrypted, packDisk(img, dmsModeNone))},
+		{"header CRC", func() []byte {
+			tracks := packDisk(img, dmsModeNone)
+			tracks[3].corruptCRC = true
+			return makeTestDMS(0, tracks)
+		}()},
+		{"track checksum", func() []byte {
+			tracks := packDisk(img, dmsModeNone)
+			tracks[3].corruptTrack = true
+			return makeTestDMS(0, tracks)
+		}()},
+		{"unknown mode", func() []byte {
+			tracks := packDisk(img, dmsModeNone)
+			tracks[0].mode = 7
+			return makeTestDMS(0, tracks)
+		}()},
+		{"no tracks", makeTestDMS(0, nil)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseDMS(bytes.NewReader(tt.archive), int64(len(tt.archive))); err == nil {
+				t.Error("ParseDMS() expected error")
+			}
+		})
+	}
+}
+
+func TestUnpackQuick_Match(t *testing.T) {
+	w := &bitWriter{}
+	w.write(1, 1)
+	w.write('A', 8)
+	w.write(0, 1) // match
+	w.write(1, 2) // length 3
+	w.write(0, 8) // distance 1
+	w.write(1, 1)
+	w.write('B', 8)
+
+	d := newDecruncher()
+	got := d.unpackQuick(w.out, 5)
+	if string(got) != "AAAAB" {
+		t.Errorf("unpackQuick() = %q, want %q", got, "AAAAB")
+	}
+}
+
+func TestUnpackRLE(t *testing.T) {
+	in := []byte{'a', 0x90, 0x00, 0x90, 3, 'b', 0x90, 0xFF, 'c', 0x01, 0x00}
+	want := append([]byte{'a', 0x90, 'b', 'b', 'b'}, bytes.Repeat([]byte{'c'}, 256)...)
+
+	got, err := unpackRLE(in, len(want))
+	if err != nil {
+		t.Fatalf("unpackRLE() error = %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("unpackRLE() = %x, want %x", got, want)
+	}
+}
@@ -0,0 +1,175 @@
+package c64
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/sargunv/rom-tools/internal/util"
+	"github.com/sargunv/rom-tools/lib/core"
+)
+
+// Commodore 64 CRT cartridge image parsing.
+//
+// CRT files, introduced by the CCS64 emulator and extended by VICE, wrap a
+// cartridge's ROM chips with a header naming the cartridge and its hardware,
+// which emulators need to map the banks. The header is followed by one CHIP
+// packet per ROM bank. The format is specified in the VICE manual.
+//
+// Header layout (big-endian):
+//
+//	Offset  Size  Description
+//	0x00    16    Signature "C64 CARTRIDGE   "
+//	0x10    4     Header length (usually 0x40)
+//	0x14    2     Version (0x0100, or 0x0101 with sub-type)
+//	0x16    2     Hardware type
+//	0x18    1     EXROM line status (0 = active)
+//	0x19    1     GAME line status (0 = active)
+//	0x1A    1     Hardware sub-type (version 1.1+)
+//	0x1B    5     Reserved
+//	0x20    32    Cartridge name (null-padded)
+
+const (
+	crtHeaderSize      = 0x40
+	crtVersionOffset   = 0x14
+	crtHardwareOffset  = 0x16
+	crtEXROMOffset     = 0x18
+	crtGAMEOffset      = 0x19
+	crtSubTypeOffset   = 0x1A
+	crtNameOffset      = 0x20
+	crtNameLen         = 32
+	crtSubTypeMinorVer = 0x0101
+)
+
+var crtSignature = []byte("C64 CARTRIDGE   ")
+
+// HardwareType is the cartridge hardware, which determines its banking.
+type HardwareType uint16
+
+// hardwareTypeNames names the hardware types defined by VICE.
+var hardwareTypeNames = map[HardwareType]string{
+	0:  "Normal cartridge",
+	1:  "Action Replay",
+	2:  "KCS Power Cartridge",
+	3:  "Final Cartridge III",
+	4:  "Simons' BASIC",
+	5:  "Ocean type 1",
+	6:  "Expert Cartridge",
+	7:  "Fun Play, Power Play",
+	8:  "Super Games",
+	9:  "Atomic Power",
+	10: "Epyx Fastload",
+	11: "Westermann Learning",
+	12: "Rex Utility",
+	13: "Final Cartridge I",
+	14: "Magic Formel",
+	15: "C64 Game System, System 3",
+	16: "WarpSpeed",
+	17: "Dinamic",
+	18: "Zaxxon, Super Zaxxon (Sega)",
+	19: "Magic Desk, Domark, HES Australia",
+	20: "Super Snapshot V5",
+	21: "Comal-80",
+	22: "Structured BASIC",
+	23: "Ross",
+	24: "Dela EP64",
+	25: "Dela EP7x8",
+	26: "Dela EP256",
+	27: "Rex EP256",
+	28: "Mikro Assembler",
+	29: "Final Cartridge Plus",
+	30: "Action Replay 4",
+	31: "Stardos",
+	32: "EasyFlash",
+	33: "EasyFlash Xbank",
+	34: "Capture",
+	35: "Action Replay 3",
+	36: "Retro Replay",
+	37: "MMC64",
+	38: "MMC Replay",
+	39: "IDE64",
+	40: "Super Snapshot V4",
+	41: "IEEE-488",
+	42: "Game Killer",
+	43: "Prophet64",
+	44: "EXOS",
+	45: "Freeze Frame",
+	46: "Freeze Machine",
+	47: "Snapshot64",
+	48: "Super Explode V5.0",
+	49: "Magic Voice",
+	50: "Action Replay 2",
+	51: "MACH 5",
+	52: "Diashow-Maker",
+	53: "Pagefox",
+	54: "Kingsoft",
+	55: "Silverrock 128K Cartridge",
+	56: "Formel 64",
+	57: "RGCD",
+}
+
+// String returns the hardware type's name, or its number if unknown.
+func (t HardwareType) String() string {
+	if name, ok := hardwareTypeNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("Unknown (%d)", uint16(t))
+}
+
+// CRTInfo contains metadata extracted from a CRT cartridge image.
+type CRTInfo struct {
+	// Name is the cartridge name.
+	Name string `json:"name,omitempty"`
+	// Version is the CRT format version.
+	Version uint16 `json:"version"`
+	// HardwareType is the cartridge hardware.
+	HardwareType HardwareType `json:"hardware_type"`
+	// HardwareSubType distinguishes variants of some hardware types (version 1.1+).
+	HardwareSubType byte `json:"hardware_sub_type,omitempty"`
+	// EXROM is true if the cartridge's EXROM line is active at startup.
+	EXROM bool `json:"exrom"`
+	// GAME is true if the cartridge's GAME line is active at startup.
+	GAME bool `json:"game"`
+}
+
+// GamePlatform implements core.GameInfo.
+func (i *CRTInfo) GamePlatform() core.Platform { return core.PlatformC64 }
+
+// GameTitle implements core.GameInfo.
+func (i *CRTInfo) GameTitle() string { return i.Name }
+
+// GameSerial implements core.GameInfo. CRT headers don't include serials.
+func (i *CRTInfo) GameSerial() string { return "" }
+
+// GameRegions implements core.GameInfo. CRT headers don't include regions.
+func (i *CRTInfo) GameRegions() []core.Region { return []core.Region{} }
+
+// ParseCRT extracts game information from a CRT cartridge image.
+func ParseCRT(r io.ReaderAt, size int64) (*CRTInfo, error) {
+	if size < crtHeaderSize {
+		return nil, fmt.Errorf("file too small for CRT header: %d bytes", size)
+	}
+
+	header := make([]byte, crtHeaderSize)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, fmt.Errorf("failed to read CRT header: %w", err)
+	}
+
+	if !bytes.Equal(header[:len(crtSignature)], crtSignature) {
+		return nil, fmt.Errorf("not a valid C64 CRT file: invalid signature")
+	}
+
+	version := binary.BigEndian.Uint16(header[crtVersionOffset:])
+	info := &CRTInfo{
+		Name:         util.ExtractASCII(header[crtNameOffset : crtNameOffset+crtNameLen]),
+		Version:      version,
+		HardwareType: HardwareType(binary.BigEndian.Uint16(header[crtHardwareOffset:])),
+		EXROM:        header[crtEXROMOffset] == 0,
+		GAME:         header[crtGAMEOffset] == 0,
+	}
+	if version >= crtSubTypeMinorVer {
+		info.HardwareSubType = header[crtSubTypeOffset]
+	}
+	return info, nil
+}
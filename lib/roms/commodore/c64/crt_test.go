@@ -0,0 +1,78 @@
+package c64
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/sargunv/rom-tools/lib/core"
+)
+
+// makeTestCRT creates a CRT header for an Ocean type 1 cartridge.
+func makeTestCRT(version uint16) []byte {
+	header := make([]byte, crtHeaderSize)
+	copy(header, crtSignature)
+	header[0x13] = crtHeaderSize
+	header[crtVersionOffset], header[crtVersionOffset+1] = byte(version>>8), byte(version)
+	header[crtHardwareOffset+1] = 5
+	header[crtEXROMOffset] = 0
+	header[crtGAMEOffset] = 1
+	header[crtSubTypeOffset] = 2
+	copy(header[crtNameOffset:], "ROBOCOP 2")
+	return header
+}
+
+func TestParseCRT(t *testing.T) {
+	data := makeTestCRT(0x0100)
+
+	info, err := ParseCRT(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("ParseCRT() error = %v", err)
+	}
+
+	if info.GamePlatform() != core.PlatformC64 {
+		t.Errorf("Platform = %v, want %v", info.GamePlatform(), core.PlatformC64)
+	}
+	if info.GameTitle() != "ROBOCOP 2" {
+		t.Errorf("Title = %q, want %q", info.GameTitle(), "ROBOCOP 2")
+	}
+	if info.HardwareType != 5 || info.HardwareType.String() != "Ocean type 1" {
+		t.Errorf("HardwareType = %d (%s), want 5 (Ocean type 1)", info.HardwareType, info.HardwareType)
+	}
+	if !info.EXROM || info.GAME {
+		t.Errorf("EXROM = %v, GAME = %v, want true, false", info.EXROM, info.GAME)
+	}
+	// Version 1.0 headers don't define the sub-type
+	if info.HardwareSubType != 0 {
+		t.Errorf("HardwareSubType = %d, want unset for version 1.0", info.HardwareSubType)
+	}
+}
+
+func TestParseCRT_SubType(t *testing.T) {
+	data := makeTestCRT(0x0101)
+
+	info, err := ParseCRT(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("ParseCRT() error = %v", err)
+	}
+	if info.HardwareSubType != 2 {
+		t.Errorf("HardwareSubType = %d, want 2", info.HardwareSubType)
+	}
+}
+
+func TestParseCRT_Invalid(t *testing.T) {
+	data := makeTestCRT(0x0100)
+	copy(data, "C128 CARTRIDGE  ")
+	if _, err := ParseCRT(bytes.NewReader(data), int64(len(data))); err == nil {
+		t.Error("ParseCRT() expected error for invalid signature, got nil")
+	}
+
+	if _, err := ParseCRT(bytes.NewReader(data[:16]), 16); err == nil {
+		t.Error("ParseCRT() expected error for small file, got nil")
+	}
+}
+
+func TestHardwareTypeString_Unknown(t *testing.T) {
+	if got := HardwareType(999).String(); got != "Unknown (999)" {
+		t.Errorf("String() = %q, want %q", got, "Unknown (999)")
+	}
+}
@@ -0,0 +1,224 @@
+package c64
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/sargunv/rom-tools/lib/core"
+)
+
+// Commodore 64 D64 disk image parsing.
+//
+// D64 files are sector dumps of 1541 floppy disks: 35 tracks (or 40, on
+// extended disks) of 256-byte sectors, with fewer sectors on the shorter
+// inner tracks. An image may be followed by one error byte per sector.
+//
+//	Tracks  Sectors per track
+//	1-17    21
+//	18-24   19
+//	25-30   18
+//	31-40   17
+//
+// Track 18 holds the directory. Sector 0 is the BAM (block availability
+// map), which also carries the disk label:
+//
+//	Offset  Size  Description
+//	0x00    2     Track/sector of the first directory sector
+//	0x02    1     DOS version ('A')
+//	0x90    16    Disk name (padded with 0xA0)
+//	0xA2    2     Disk ID
+//	0xA5    2     DOS type ("2A")
+//
+// Directory sectors are chained by the track/sector in their first two bytes
+// (track 0 ends the chain) and hold eight 32-byte entries:
+//
+//	Offset  Size  Description
+//	0x02    1     File type (bits 0-3: type; bit 6: locked; bit 7: closed)
+//	0x03    2     Track/sector of the first data sector
+//	0x05    16    File name (padded with 0xA0)
+//	0x1E    2     File size in sectors (little-endian)
+//
+// Names are PETSCII, decoded as their ASCII equivalents.
+
+const (
+	d64SectorSize      = 256
+	d64DirTrack        = 18
+	d64DiskNameOffset  = 0x90
+	d64DiskIDOffset    = 0xA2
+	d64DOSTypeOffset   = 0xA5
+	d64NameLen         = 16
+	d64DirEntrySize    = 32
+	d64EntryTypeOffset = 0x02
+	d64EntryNameOffset = 0x05
+	d64EntrySizeOffset = 0x1E
+	d64MaxDirSectors   = 18 // directory sectors on track 18, excluding the BAM
+	petsciiPadding     = 0xA0
+)
+
+// d64Layouts are the valid D64 image sizes and their track counts.
+var d64Layouts = []struct {
+	size       int64
+	tracks     int
+	errorBytes bool
+}{
+	{174848, 35, false},
+	{175531, 35, true},
+	{196608, 40, false},
+	{197376, 40, true},
+}
+
+// FileType is the type of a file in a disk directory.
+type FileType string
+
+// FileType values
+const (
+	FileTypeDEL FileType = "DEL"
+	FileTypeSEQ FileType = "SEQ"
+	FileTypePRG FileType = "PRG"
+	FileTypeUSR FileType = "USR"
+	FileTypeREL FileType = "REL"
+)
+
+var fileTypes = []FileType{FileTypeDEL, FileTypeSEQ, FileTypePRG, FileTypeUSR, FileTypeREL}
+
+// DiskFile is a file in a disk directory.
+type DiskFile struct {
+	Name   string   `json:"name"`
+	Type   FileType `json:"type"`
+	Blocks uint16   `json:"blocks"`
+	Locked bool     `json:"locked,omitempty"`
+}
+
+// D64Info contains metadata extracted from a D64 disk image.
+type D64Info struct {
+	// DiskName is the disk label.
+	DiskName string `json:"disk_name,omitempty"`
+	// DiskID is the two-character disk ID.
+	DiskID string `json:"disk_id,omitempty"`
+	// DOSType is the DOS type, normally "2A".
+	DOSType string `json:"dos_type,omitempty"`
+	// Tracks is the number of tracks (35 or 40).
+	Tracks int `json:"tracks"`
+	// HasErrorInfo is true if the image ends with per-sector error bytes.
+	HasErrorInfo bool `json:"has_error_info,omitempty"`
+	// Files lists the directory, excluding scratched entries.
+	Files []DiskFile `json:"files"`
+}
+
+// GamePlatform implements core.GameInfo.
+func (i *D64Info) GamePlatform() core.Platform { return core.PlatformC64 }
+
+// GameTitle implements core.GameInfo.
+func (i *D64Info) GameTitle() string { return i.DiskName }
+
+// GameSerial implements core.GameInfo. Disks don't include serials.
+func (i *D64Info) GameSerial() string { return "" }
+
+// GameRegions implements core.GameInfo. Disks don't include regions.
+func (i *D64Info) GameRegions() []core.Region { return []core.Region{} }
+
+// ParseD64 extracts the disk label and directory from a D64 disk image.
+func ParseD64(r io.ReaderAt, size int64) (*D64Info, error) {
+	info := &D64Info{}
+	for _, layout := range d64Layouts {
+		if layout.size == size {
+			info.Tracks, info.HasErrorInfo = layout.tracks, layout.errorBytes
+		}
+	}
+	if info.Tracks == 0 {
+		return nil, fmt.Errorf("not a valid D64 image: unexpected size %d", size)
+	}
+
+	bam := make([]byte, d64SectorSize)
+	if _, err := r.ReadAt(bam, d64SectorOffset(d64DirTrack, 0)); err != nil {
+		return nil, fmt.Errorf("failed to read D64 BAM: %w", err)
+	}
+	info.DiskName = decodePETSCII(bam[d64DiskNameOffset : d64DiskNameOffset+d64NameLen])
+	info.DiskID = decodePETSCII(bam[d64DiskIDOffset : d64DiskIDOffset+2])
+	info.DOSType = decodePETSCII(bam[d64DOSTypeOffset : d64DOSTypeOffset+2])
+
+	files, err := readDirectory(r, info.Tracks, bam[0], bam[1])
+	if err != nil {
+		return nil, err
+	}
+	info.Files = files
+	return info, nil
+}
+
+// readDirectory follows the directory sector chain starting at track/sector.
+func readDirectory(r io.ReaderAt, tracks int, track, sector byte) ([]DiskFile, error) {
+	files := []DiskFile{}
+	sectorData := make([]byte, d64SectorSize)
+	for read := 0; track != 0; read++ {
+		if read == d64MaxDirSectors {
+			return nil, fmt.Errorf("not a valid D64 image: directory chain is longer than track %d", d64DirTrack)
+		}
+		if int(track) > tracks || int(sector) >= d64SectorsPerTrack(int(track)) {
+			return nil, fmt.Errorf("not a valid D64 image: directory sector %d/%d out of range", track, sector)
+		}
+		if _, err := r.ReadAt(sectorData, d64SectorOffset(int(track), int(sector))); err != nil {
+			return nil, fmt.Errorf("failed to read D64 directory: %w", err)
+		}
+
+		for entry := 0; entry < d64SectorSize; entry += d64DirEntrySize {
+			e := sectorData[entry:]
+			fileType := e[d64EntryTypeOffset]
+			// Scratched and empty entries have type 0
+			if fileType == 0 || int(fileType&0x0F) >= len(fileTypes) {
+				continue
+			}
+			files = append(files, DiskFile{
+				Name:   decodePETSCII(e[d64EntryNameOffset : d64EntryNameOffset+d64NameLen]),
+				Type:   fileTypes[fileType&0x0F],
+				Blocks: binary.LittleEndian.Uint16(e[d64EntrySizeOffset:]),
+				Locked: fileType&0x40 != 0,
+			})
+		}
+		track, sector = sectorData[0], sectorData[1]
+	}
+	return files, nil
+}
+
+// d64SectorsPerTrack returns the number of sectors on a track.
+func d64SectorsPerTrack(track int) int {
+	switch {
+	case track <= 17:
+		return 21
+	case track <= 24:
+		return 19
+	case track <= 30:
+		return 18
+	default:
+		return 17
+	}
+}
+
+// d64SectorOffset returns the byte offset of a sector in the image.
+func d64SectorOffset(track, sector int) int64 {
+	offset := 0
+	for t := 1; t < track; t++ {
+		offset += d64SectorsPerTrack(t)
+	}
+	return int64(offset+sector) * d64SectorSize
+}
+
+// decodePETSCII decodes a PETSCII name, stopping at 0xA0 padding. Letters in
+// either case set decode as uppercase ASCII, and graphics characters as '?'.
+func decodePETSCII(data []byte) string {
+	var sb strings.Builder
+	for _, c := range data {
+		switch {
+		case c == petsciiPadding || c == 0:
+			return strings.TrimSpace(sb.String())
+		case c >= 0x20 && c <= 0x5D:
+			sb.WriteByte(c)
+		case c >= 0xC1 && c <= 0xDA:
+			sb.WriteByte(c - 0x80)
+		default:
+			sb.WriteByte('?')
+		}
+	}
+	return strings.TrimSpace(sb.String())
+}
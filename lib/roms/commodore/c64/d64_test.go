@@ -0,0 +1,108 @@
+package c64
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/sargunv/rom-tools/lib/core"
+)
+
+// padPETSCII pads a name to 16 bytes with 0xA0.
+func padPETSCII(name string) []byte {
+	b := bytes.Repeat([]byte{petsciiPadding}, d64NameLen)
+	copy(b, name)
+	return b
+}
+
+// makeTestD64 creates a 35-track D64 image with a two-sector directory.
+func makeTestD64() []byte {
+	img := make([]byte, 174848)
+
+	bam := img[d64SectorOffset(d64DirTrack, 0):]
+	bam[0], bam[1], bam[2] = d64DirTrack, 1, 'A'
+	copy(bam[d64DiskNameOffset:], padPETSCII("LAST NINJA"))
+	copy(bam[d64DiskIDOffset:], "01")
+	copy(bam[d64DOSTypeOffset:], "2A")
+
+	dir1 := img[d64SectorOffset(d64DirTrack, 1):]
+	dir1[0], dir1[1] = d64DirTrack, 4
+	dir1[d64EntryTypeOffset] = 0x82 // closed PRG
+	copy(dir1[d64EntryNameOffset:], padPETSCII("NINJA"))
+	dir1[d64EntrySizeOffset] = 200
+	dir1[d64DirEntrySize+d64EntryTypeOffset] = 0x00 // scratched
+	copy(dir1[d64DirEntrySize+d64EntryNameOffset:], padPETSCII("OLD"))
+
+	dir2 := img[d64SectorOffset(d64DirTrack, 4):]
+	dir2[0], dir2[1] = 0, 0xFF
+	dir2[d64EntryTypeOffset] = 0xC1 // closed, locked SEQ
+	copy(dir2[d64EntryNameOffset:], padPETSCII("HISCORE\xC1"))
+	dir2[d64EntrySizeOffset] = 1
+	return img
+}
+
+func TestParseD64(t *testing.T) {
+	img := makeTestD64()
+
+	info, err := ParseD64(bytes.NewReader(img), int64(len(img)))
+	if err != nil {
+		t.Fatalf("ParseD64() error = %v", err)
+	}
+
+	if info.GamePlatform() != core.PlatformC64 {
+		t.Errorf("Platform = %v, want %v", info.GamePlatform(), core.PlatformC64)
+	}
+	if info.GameTitle() != "LAST NINJA" {
+		t.Errorf("Title = %q, want %q", info.GameTitle(), "LAST NINJA")
+	}
+	if info.DiskID != "01" || info.DOSType != "2A" {
+		t.Errorf("DiskID = %q, DOSType = %q, want %q, %q", info.DiskID, info.DOSType, "01", "2A")
+	}
+	if info.Tracks != 35 || info.HasErrorInfo {
+		t.Errorf("Tracks = %d, HasErrorInfo = %v, want 35, false", info.Tracks, info.HasErrorInfo)
+	}
+
+	want := []DiskFile{
+		{Name: "NINJA", Type: FileTypePRG, Blocks: 200},
+		{Name: "HISCOREA", Type: FileTypeSEQ, Blocks: 1, Locked: true},
+	}
+	if len(info.Files) != len(want) {
+		t.Fatalf("Files = %+v, want %+v", info.Files, want)
+	}
+	for i := range want {
+		if info.Files[i] != want[i] {
+			t.Errorf("Files[%d] = %+v, want %+v", i, info.Files[i], want[i])
+		}
+	}
+}
+
+func TestParseD64_ErrorInfo(t *testing.T) {
+	img := append(makeTestD64(), make([]byte, 683)...)
+
+	info, err := ParseD64(bytes.NewReader(img), int64(len(img)))
+	if err != nil {
+		t.Fatalf("ParseD64() error = %v", err)
+	}
+	if !info.HasErrorInfo {
+		t.Error("HasErrorInfo = false, want true")
+	}
+}
+
+func TestParseD64_Invalid(t *testing.T) {
+	if _, err := ParseD64(bytes.NewReader(make([]byte, 1000)), 1000); err == nil {
+		t.Error("ParseD64() expected error for unexpected size, got nil")
+	}
+
+	// A directory chain that loops never ends
+	img := makeTestD64()
+	dir2 := img[d64SectorOffset(d64DirTrack, 4):]
+	dir2[0], dir2[1] = d64DirTrack, 1
+	if _, err := ParseD64(bytes.NewReader(img), int64(len(img))); err == nil {
+		t.Error("ParseD64() expected error for looping directory, got nil")
+	}
+}
+
+func TestD64SectorOffset(t *testing.T) {
+	if got := d64SectorOffset(d64DirTrack, 0); got != 0x16500 {
+		t.Errorf("d64SectorOffset(18, 0) = %#x, want 0x16500", got)
+	}
+}
@@ -0,0 +1,138 @@
+package c64
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/sargunv/rom-tools/lib/core"
+)
+
+// Commodore 64 T64 tape image parsing.
+//
+// T64 files, introduced by the C64S emulator, are archives of the programs
+// on a tape rather than a recording of the tape itself. A 64-byte header is
+// followed by a directory of 32-byte entries and then the file data.
+//
+// Header layout (little-endian):
+//
+//	Offset  Size  Description
+//	0x00    32    Signature, starting "C64" (e.g. "C64 tape image file")
+//	0x20    2     Version (0x0100 or 0x0101)
+//	0x22    2     Directory entries
+//	0x24    2     Used directory entries (often 0 in old images, meaning 1)
+//	0x28    24    Tape name (padded with spaces)
+//
+// Directory entry layout:
+//
+//	Offset  Size  Description
+//	0x00    1     Entry type (0 = free, 1 = normal tape file)
+//	0x01    1     C64 file type
+//	0x02    2     Start address
+//	0x04    2     End address
+//	0x08    4     Offset of the file data in the image
+//	0x10    16    File name (padded with spaces or 0xA0)
+
+const (
+	t64HeaderSize        = 0x40
+	t64VersionOffset     = 0x20
+	t64EntriesOffset     = 0x22
+	t64NameOffset        = 0x28
+	t64NameLen           = 24
+	t64EntrySize         = 32
+	t64EntryStartOffset  = 0x02
+	t64EntryEndOffset    = 0x04
+	t64EntryDataOffset   = 0x08
+	t64EntryNameOffset   = 0x10
+	t64EntryNameLen      = 16
+	t64EntryTypeNormal   = 1
+	t64MaxDirectoryCount = 1024
+)
+
+var t64Signature = []byte("C64")
+
+// TapeFile is a file in a T64 tape image.
+type TapeFile struct {
+	Name         string `json:"name"`
+	StartAddress uint16 `json:"start_address"`
+	EndAddress   uint16 `json:"end_address"`
+	Offset       uint32 `json:"offset"`
+}
+
+// T64Info contains metadata extracted from a T64 tape image.
+type T64Info struct {
+	// TapeName is the tape name.
+	TapeName string `json:"tape_name,omitempty"`
+	// Version is the T64 format version.
+	Version uint16 `json:"version"`
+	// Files lists the files on the tape.
+	Files []TapeFile `json:"files"`
+}
+
+// GamePlatform implements core.GameInfo.
+func (i *T64Info) GamePlatform() core.Platform { return core.PlatformC64 }
+
+// GameTitle implements core.GameInfo. The tape name, or the first file's name
+// if the tape has none.
+func (i *T64Info) GameTitle() string {
+	if i.TapeName == "" && len(i.Files) > 0 {
+		return i.Files[0].Name
+	}
+	return i.TapeName
+}
+
+// GameSerial implements core.GameInfo. Tapes don't include serials.
+func (i *T64Info) GameSerial() string { return "" }
+
+// GameRegions implements core.GameInfo. Tapes don't include regions.
+func (i *T64Info) GameRegions() []core.Region { return []core.Region{} }
+
+// ParseT64 extracts the tape name and file list from a T64 tape image.
+func ParseT64(r io.ReaderAt, size int64) (*T64Info, error) {
+	if size < t64HeaderSize {
+		return nil, fmt.Errorf("file too small for T64 header: %d bytes", size)
+	}
+
+	header := make([]byte, t64HeaderSize)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, fmt.Errorf("failed to read T64 header: %w", err)
+	}
+	if !bytes.HasPrefix(header, t64Signature) {
+		return nil, fmt.Errorf("not a valid T64 file: invalid signature")
+	}
+
+	entries := int(binary.LittleEndian.Uint16(header[t64EntriesOffset:]))
+	if entries == 0 || entries > t64MaxDirectoryCount {
+		return nil, fmt.Errorf("not a valid T64 file: %d directory entries", entries)
+	}
+	dirSize := int64(entries) * t64EntrySize
+	if t64HeaderSize+dirSize > size {
+		return nil, fmt.Errorf("file too small for T64 directory: %d entries in %d bytes", entries, size)
+	}
+
+	dir := make([]byte, dirSize)
+	if _, err := r.ReadAt(dir, t64HeaderSize); err != nil {
+		return nil, fmt.Errorf("failed to read T64 directory: %w", err)
+	}
+
+	info := &T64Info{
+		TapeName: decodePETSCII(header[t64NameOffset : t64NameOffset+t64NameLen]),
+		Version:  binary.LittleEndian.Uint16(header[t64VersionOffset:]),
+		Files:    []TapeFile{},
+	}
+	// The used entry count is unreliable, so every entry is checked instead
+	for i := range entries {
+		e := dir[i*t64EntrySize:]
+		if e[0] != t64EntryTypeNormal {
+			continue
+		}
+		info.Files = append(info.Files, TapeFile{
+			Name:         decodePETSCII(e[t64EntryNameOffset : t64EntryNameOffset+t64EntryNameLen]),
+			StartAddress: binary.LittleEndian.Uint16(e[t64EntryStartOffset:]),
+			EndAddress:   binary.LittleEndian.Uint16(e[t64EntryEndOffset:]),
+			Offset:       binary.LittleEndian.Uint32(e[t64EntryDataOffset:]),
+		})
+	}
+	return info, nil
+}
@@ -0,0 +1,75 @@
+package c64
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/sargunv/rom-tools/lib/core"
+)
+
+// makeTestT64 creates a T64 image with the given tape name and a directory
+// of two entries, the second of them free.
+func makeTestT64(tapeName string) []byte {
+	data := make([]byte, t64HeaderSize+2*t64EntrySize+16)
+	copy(data, "C64S tape image file")
+	binary.LittleEndian.PutUint16(data[t64VersionOffset:], 0x0101)
+	binary.LittleEndian.PutUint16(data[t64EntriesOffset:], 2)
+	copy(data[t64NameOffset:], tapeName+"                        ")
+
+	e := data[t64HeaderSize:]
+	e[0], e[1] = t64EntryTypeNormal, 0x82
+	binary.LittleEndian.PutUint16(e[t64EntryStartOffset:], 0x0801)
+	binary.LittleEndian.PutUint16(e[t64EntryEndOffset:], 0x0811)
+	binary.LittleEndian.PutUint32(e[t64EntryDataOffset:], t64HeaderSize+2*t64EntrySize)
+	copy(e[t64EntryNameOffset:], "BOULDER DASH    ")
+	return data
+}
+
+func TestParseT64(t *testing.T) {
+	data := makeTestT64("BOULDER TAPE")
+
+	info, err := ParseT64(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("ParseT64() error = %v", err)
+	}
+
+	if info.GamePlatform() != core.PlatformC64 {
+		t.Errorf("Platform = %v, want %v", info.GamePlatform(), core.PlatformC64)
+	}
+	if info.GameTitle() != "BOULDER TAPE" {
+		t.Errorf("Title = %q, want %q", info.GameTitle(), "BOULDER TAPE")
+	}
+	if info.Version != 0x0101 {
+		t.Errorf("Version = %#x, want 0x0101", info.Version)
+	}
+	want := TapeFile{Name: "BOULDER DASH", StartAddress: 0x0801, EndAddress: 0x0811, Offset: 0x80}
+	if len(info.Files) != 1 || info.Files[0] != want {
+		t.Errorf("Files = %+v, want [%+v]", info.Files, want)
+	}
+}
+
+func TestParseT64_NoTapeName(t *testing.T) {
+	data := makeTestT64("")
+
+	info, err := ParseT64(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("ParseT64() error = %v", err)
+	}
+	if info.GameTitle() != "BOULDER DASH" {
+		t.Errorf("Title = %q, want first file name %q", info.GameTitle(), "BOULDER DASH")
+	}
+}
+
+func TestParseT64_Invalid(t *testing.T) {
+	data := makeTestT64("TAPE")
+	copy(data, "C65")
+	if _, err := ParseT64(bytes.NewReader(data), int64(len(data))); err == nil {
+		t.Error("ParseT64() expected error for invalid signature, got nil")
+	}
+
+	data = makeTestT64("TAPE")
+	if _, err := ParseT64(bytes.NewReader(data[:t64HeaderSize+10]), t64HeaderSize+10); err == nil {
+		t.Error("ParseT64() expected error for truncated directory, got nil")
+	}
+}
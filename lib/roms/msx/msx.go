@@ -0,0 +1,212 @@
+package msx
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/sargunv/rom-tools/lib/core"
+)
+
+// MSX cartridge ROM format parsing.
+//
+// MSX cartridges start with a 16-byte header that the BIOS looks for at the
+// start of each 16K page when it scans the slots at boot. Most cartridges put
+// it at the start of the ROM; some larger ones leave the first page for
+// another purpose and put it 16K in. The header has no title, serial, or
+// region; it only lists the handlers the BIOS calls:
+//
+//	Offset  Size  Description
+//	0x00    2     ID "AB"
+//	0x02    2     INIT: called at boot (little-endian address, 0 = none)
+//	0x04    2     STATEMENT: CALL statement handler for BASIC
+//	0x06    2     DEVICE: device handler for BASIC
+//	0x08    2     TEXT: BASIC program stored in the cartridge
+//	0x0A    6     Reserved
+//
+// Cartridges over 64K ("MegaROMs") switch banks through a mapper, which the
+// header doesn't name. Emulators guess it by counting the "LD (nnnn),A"
+// instructions that write to each mapper's bank registers, and so does this
+// package, following openMSX's heuristic.
+//
+// Nothing in the ROM distinguishes MSX from MSX2 software, so the platform
+// comes from the file extension (.mx1 or .mx2), defaulting to MSX.
+
+const (
+	msxHeaderSize      = 16
+	msxInitOffset      = 0x02
+	msxStatementOffset = 0x04
+	msxDeviceOffset    = 0x06
+	msxTextOffset      = 0x08
+	msxPageSize        = 16 * 1024
+	msxMaxPlainSize    = 64 * 1024
+	msxMaxROMSize      = 8 * 1024 * 1024
+
+	z80LoadAbsA = 0x32 // LD (nnnn),A
+)
+
+var msxID = []byte("AB")
+
+// Mapper is a cartridge bank switching mapper.
+type Mapper string
+
+// Mapper values
+const (
+	MapperNone      Mapper = ""           // Up to 64K, no bank switching
+	MapperGeneric8K Mapper = "Generic 8K" // Fallback for MegaROMs without a recognizable mapper
+	MapperKonami    Mapper = "Konami"
+	MapperKonamiSCC Mapper = "Konami SCC"
+	MapperASCII8    Mapper = "ASCII8"
+	MapperASCII16   Mapper = "ASCII16"
+)
+
+// mapperOrder is the order in which mapper guesses are compared. Ties go to
+// the later mapper.
+var mapperOrder = []Mapper{MapperKonamiSCC, MapperKonami, MapperASCII8, MapperASCII16}
+
+// mapperRegisters maps bank register addresses to the mappers that have them.
+var mapperRegisters = map[uint16][]Mapper{
+	0x4000: {MapperKonami},
+	0x5000: {MapperKonamiSCC},
+	0x6000: {MapperKonami, MapperASCII8, MapperASCII16},
+	0x6800: {MapperASCII8},
+	0x7000: {MapperKonamiSCC, MapperASCII8, MapperASCII16},
+	0x77FF: {MapperASCII16},
+	0x7800: {MapperASCII8},
+	0x8000: {MapperKonami},
+	0x9000: {MapperKonamiSCC},
+	0xA000: {MapperKonami},
+	0xB000: {MapperKonamiSCC},
+}
+
+// Info contains metadata extracted from an MSX cartridge ROM.
+type Info struct {
+	// MSX2 is true for ROMs identified as MSX2 software by their extension.
+	MSX2 bool `json:"msx2,omitempty"`
+	// HeaderOffset is the offset of the cartridge header in the ROM.
+	HeaderOffset int64 `json:"header_offset"`
+	// Init is the address of the initialization handler (0 if none).
+	Init uint16 `json:"init"`
+	// Statement is the address of the BASIC CALL statement handler (0 if none).
+	Statement uint16 `json:"statement"`
+	// Device is the address of the BASIC device handler (0 if none).
+	Device uint16 `json:"device"`
+	// Text is the address of the stored BASIC program (0 if none).
+	Text uint16 `json:"text"`
+	// Mapper is the guessed bank switching mapper.
+	Mapper Mapper `json:"mapper,omitempty"`
+	// ROMSize is the size of the ROM.
+	ROMSize int64 `json:"rom_size"`
+}
+
+// GamePlatform implements core.GameInfo.
+func (i *Info) GamePlatform() core.Platform {
+	if i.MSX2 {
+		return core.PlatformMSX2
+	}
+	return core.PlatformMSX
+}
+
+// GameTitle implements core.GameInfo. MSX cartridges don't have embedded
+// titles.
+func (i *Info) GameTitle() string { return "" }
+
+// GameSerial implements core.GameInfo. MSX cartridges don't have embedded
+// serials.
+func (i *Info) GameSerial() string { return "" }
+
+// GameRegions implements core.GameInfo. MSX cartridges don't record a region.
+func (i *Info) GameRegions() []core.Region { return []core.Region{} }
+
+// Parse extracts information from an MSX cartridge ROM.
+func Parse(r io.ReaderAt, size int64) (*Info, error) {
+	if size < msxHeaderSize {
+		return nil, fmt.Errorf("file too small for MSX ROM: %d bytes", size)
+	}
+	if size > msxMaxROMSize {
+		return nil, fmt.Errorf("not a valid MSX ROM: size %d exceeds largest MegaROM", size)
+	}
+
+	info, err := parseHeader(r, size)
+	if err != nil {
+		return nil, err
+	}
+	info.ROMSize = size
+
+	if size > msxMaxPlainSize {
+		rom := make([]byte, size)
+		if _, err := r.ReadAt(rom, 0); err != nil {
+			return nil, fmt.Errorf("failed to read MSX ROM: %w", err)
+		}
+		info.Mapper = guessMapper(rom)
+	}
+
+	return info, nil
+}
+
+// ParseMSX2 is like Parse, for ROMs known to be MSX2 software.
+func ParseMSX2(r io.ReaderAt, size int64) (*Info, error) {
+	info, err := Parse(r, size)
+	if err != nil {
+		return nil, err
+	}
+	info.MSX2 = true
+	return info, nil
+}
+
+// parseHeader finds the cartridge header at the start of the first or second
+// page.
+func parseHeader(r io.ReaderAt, size int64) (*Info, error) {
+	header := make([]byte, msxHeaderSize)
+	for _, offset := range []int64{0, msxPageSize} {
+		if offset+msxHeaderSize > size {
+			break
+		}
+		if _, err := r.ReadAt(header, offset); err != nil {
+			return nil, fmt.Errorf("failed to read MSX header: %w", err)
+		}
+		if !bytes.Equal(header[:len(msxID)], msxID) {
+			continue
+		}
+
+		info := &Info{
+			HeaderOffset: offset,
+			Init:         binary.LittleEndian.Uint16(header[msxInitOffset:]),
+			Statement:    binary.LittleEndian.Uint16(header[msxStatementOffset:]),
+			Device:       binary.LittleEndian.Uint16(header[msxDeviceOffset:]),
+			Text:         binary.LittleEndian.Uint16(header[msxTextOffset:]),
+		}
+		// A cartridge must run something, either at boot or from BASIC
+		if info.Init != 0 || info.Statement != 0 || info.Device != 0 || info.Text != 0 {
+			return info, nil
+		}
+	}
+	return nil, fmt.Errorf("not a valid MSX ROM: no %q header with handlers", msxID)
+}
+
+// guessMapper guesses a MegaROM's mapper from its writes to bank registers.
+func guessMapper(rom []byte) Mapper {
+	counts := make(map[Mapper]int)
+	for i := 0; i+2 < len(rom); i++ {
+		if rom[i] != z80LoadAbsA {
+			continue
+		}
+		for _, mapper := range mapperRegisters[binary.LittleEndian.Uint16(rom[i+1:])] {
+			counts[mapper]++
+		}
+	}
+	// ASCII8 shares most registers with the others, so needs one more write
+	// to win
+	if counts[MapperASCII8] > 0 {
+		counts[MapperASCII8]--
+	}
+
+	best := MapperGeneric8K
+	for _, mapper := range mapperOrder {
+		if counts[mapper] > 0 && counts[mapper] >= counts[best] {
+			best = mapper
+		}
+	}
+	return best
+}
@@ -0,0 +1,128 @@
+package msx
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/sargunv/rom-tools/lib/core"
+)
+
+// makeTestROM creates a ROM of the given size with a header at offset whose
+// INIT handler is init.
+func makeTestROM(size int, offset int, init uint16) []byte {
+	rom := make([]byte, size)
+	copy(rom[offset:], msxID)
+	binary.LittleEndian.PutUint16(rom[offset+msxInitOffset:], init)
+	return rom
+}
+
+// addWrites places count "LD (addr),A" instructions in rom at pos, returning
+// the position after them.
+func addWrites(rom []byte, pos int, addr uint16, count int) int {
+	for range count {
+		rom[pos] = z80LoadAbsA
+		binary.LittleEndian.PutUint16(rom[pos+1:], addr)
+		pos += 3
+	}
+	return pos
+}
+
+func TestParse(t *testing.T) {
+	rom := makeTestROM(32*1024, 0, 0x4010)
+
+	info, err := Parse(bytes.NewReader(rom), int64(len(rom)))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if info.GamePlatform() != core.PlatformMSX {
+		t.Errorf("Platform = %v, want %v", info.GamePlatform(), core.PlatformMSX)
+	}
+	if info.Init != 0x4010 {
+		t.Errorf("Init = %#x, want 0x4010", info.Init)
+	}
+	if info.HeaderOffset != 0 {
+		t.Errorf("HeaderOffset = %d, want 0", info.HeaderOffset)
+	}
+	if info.Mapper != MapperNone {
+		t.Errorf("Mapper = %q, want none", info.Mapper)
+	}
+	if info.ROMSize != int64(len(rom)) {
+		t.Errorf("ROMSize = %d, want %d", info.ROMSize, len(rom))
+	}
+}
+
+func TestParse_SecondPage(t *testing.T) {
+	rom := makeTestROM(48*1024, msxPageSize, 0x4010)
+
+	info, err := Parse(bytes.NewReader(rom), int64(len(rom)))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if info.HeaderOffset != msxPageSize {
+		t.Errorf("HeaderOffset = %#x, want %#x", info.HeaderOffset, msxPageSize)
+	}
+}
+
+func TestParseMSX2(t *testing.T) {
+	rom := makeTestROM(16*1024, 0, 0x4010)
+
+	info, err := ParseMSX2(bytes.NewReader(rom), int64(len(rom)))
+	if err != nil {
+		t.Fatalf("ParseMSX2() error = %v", err)
+	}
+	if info.GamePlatform() != core.PlatformMSX2 {
+		t.Errorf("Platform = %v, want %v", info.GamePlatform(), core.PlatformMSX2)
+	}
+}
+
+func TestParse_Mapper(t *testing.T) {
+	tests := []struct {
+		name   string
+		writes map[uint16]int
+		want   Mapper
+	}{
+		{"no writes", nil, MapperGeneric8K},
+		{"Konami", map[uint16]int{0x6000: 2, 0x8000: 3, 0xA000: 3}, MapperKonami},
+		{"Konami SCC", map[uint16]int{0x5000: 2, 0x7000: 3, 0x9000: 3, 0xB000: 3}, MapperKonamiSCC},
+		{"ASCII8", map[uint16]int{0x6000: 2, 0x6800: 3, 0x7000: 2, 0x7800: 3}, MapperASCII8},
+		{"ASCII16", map[uint16]int{0x6000: 3, 0x77FF: 4}, MapperASCII16},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rom := makeTestROM(128*1024, 0, 0x4010)
+			pos := 0x100
+			for addr, count := range tt.writes {
+				pos = addWrites(rom, pos, addr, count)
+			}
+
+			info, err := Parse(bytes.NewReader(rom), int64(len(rom)))
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if info.Mapper != tt.want {
+				t.Errorf("Mapper = %q, want %q", info.Mapper, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		rom  []byte
+	}{
+		{"too small", []byte("AB")},
+		{"no header", make([]byte, 32*1024)},
+		{"no handlers", makeTestROM(32*1024, 0, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(bytes.NewReader(tt.rom), int64(len(tt.rom))); err == nil {
+				t.Error("Parse() expected error, got nil")
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package pce
+
+import (
+	"fmt"
+	"io"
+	"math/bits"
+
+	"github.com/sargunv/rom-tools/lib/core"
+)
+
+// PC Engine / TurboGrafx-16 HuCard ROM format parsing.
+//
+// HuCard images have no internal header, so there is no title or serial to
+// extract. Two things are detected instead:
+//
+// Copier header:
+//
+// Dumps made with copier devices (e.g., Magic Griffin) carry a 512-byte
+// header before the ROM data. HuCard ROMs are always a multiple of 8 KiB, so
+// a file whose size is 512 bytes more than a multiple of 8 KiB is assumed to
+// have one. DATs (No-Intro) hash the ROM data without it.
+//
+// Bit-reversed (US) dumps:
+//
+// TurboGrafx-16 HuCards have their data lines wired in reverse order, so raw
+// US dumps have every byte bit-reversed. The reset vector at the end of the
+// first 8 KiB bank points into $E000-$FFFF, so its high byte is >= $E0 in a
+// normal dump; a smaller value indicates a bit-reversed one (same heuristic as
+// Mednafen).
+//
+// Layout:
+//
+//	Offset  Size  Description
+//	0x000   512   Copier header (optional)
+//	+0x0000 8K*n  ROM data (n banks of 8 KiB)
+//	+0x1FFE 2     Reset vector (little-endian, in bank 0)
+
+const (
+	// CopierHeaderSize is the size of the optional copier header.
+	CopierHeaderSize = 512
+
+	pceBankSize          = 8 * 1024
+	pceResetVectorOffset = 0x1FFE
+	pceResetVectorMinHi  = 0xE0
+	pceMaxROMSize        = 2560 * 1024 // largest HuCard (Street Fighter II')
+)
+
+// Info contains metadata extracted from a PC Engine HuCard ROM file.
+type Info struct {
+	// HeaderSize is the size of the copier header (0 or 512).
+	HeaderSize int64 `json:"header_size"`
+	// ROMSize is the size of the ROM data, excluding any copier header.
+	ROMSize int64 `json:"rom_size"`
+	// BitReversed is true for raw TurboGrafx-16 dumps with bit-reversed bytes.
+	BitReversed bool `json:"bit_reversed,omitempty"`
+	// ResetVector is the CPU reset vector (as stored, after undoing bit reversal).
+	ResetVector uint16 `json:"reset_vector"`
+}
+
+// GamePlatform implements core.GameInfo.
+func (i *Info) GamePlatform() core.Platform { return core.PlatformPCE }
+
+// GameTitle implements core.GameInfo. HuCards don't have embedded titles.
+func (i *Info) GameTitle() string { return "" }
+
+// GameSerial implements core.GameInfo. HuCards don't have embedded serials.
+func (i *Info) GameSerial() string { return "" }
+
+// GameRegions implements core.GameInfo. Bit-reversed dumps are TurboGrafx-16
+// (USA) cards; otherwise the region can't be determined.
+func (i *Info) GameRegions() []core.Region {
+	if i.BitReversed {
+		return []core.Region{core.RegionUSA}
+	}
+	return []core.Region{}
+}
+
+// Parse extracts information from a PC Engine HuCard ROM file.
+func Parse(r io.ReaderAt, size int64) (*Info, error) {
+	var headerSize int64
+	if size%pceBankSize == CopierHeaderSize {
+		headerSize = CopierHeaderSize
+	}
+
+	romSize := size - headerSize
+	if romSize < pceBankSize {
+		return nil, fmt.Errorf("file too small for PCE ROM: %d bytes", size)
+	}
+	if romSize%pceBankSize != 0 {
+		return nil, fmt.Errorf("not a valid PCE ROM: size %d is not a multiple of 8 KiB", size)
+	}
+	if romSize > pceMaxROMSize {
+		return nil, fmt.Errorf("not a valid PCE ROM: size %d exceeds largest HuCard", size)
+	}
+
+	vector := make([]byte, 2)
+	if _, err := r.ReadAt(vector, headerSize+pceResetVectorOffset); err != nil {
+		return nil, fmt.Errorf("failed to read PCE reset vector: %w", err)
+	}
+
+	bitReversed := vector[1] < pceResetVectorMinHi
+	if bitReversed {
+		vector[0] = bits.Reverse8(vector[0])
+		vector[1] = bits.Reverse8(vector[1])
+		if vector[1] < pceResetVectorMinHi {
+			return nil, fmt.Errorf("not a valid PCE ROM: reset vector $%02X%02X outside bank 0", vector[1], vector[0])
+		}
+	}
+
+	return &Info{
+		HeaderSize:  headerSize,
+		ROMSize:     romSize,
+		BitReversed: bitReversed,
+		ResetVector: uint16(vector[1])<<8 | uint16(vector[0]),
+	}, nil
+}
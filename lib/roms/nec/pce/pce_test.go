@@ -0,0 +1,88 @@
+package pce
+
+import (
+	"bytes"
+	"math/bits"
+	"testing"
+
+	"github.com/sargunv/rom-tools/lib/core"
+)
+
+// makeTestROM creates a HuCard image with the given number of 8 KiB banks and
+// reset vector, optionally bit-reversed and with a copier header.
+func makeTestROM(banks int, vector uint16, bitReversed, copierHeader bool) []byte {
+	rom := make([]byte, banks*pceBankSize)
+	rom[pceResetVectorOffset] = byte(vector)
+	rom[pceResetVectorOffset+1] = byte(vector >> 8)
+	if bitReversed {
+		for i, b := range rom {
+			rom[i] = bits.Reverse8(b)
+		}
+	}
+	if copierHeader {
+		rom = append(make([]byte, CopierHeaderSize), rom...)
+	}
+	return rom
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name            string
+		rom             []byte
+		wantHeaderSize  int64
+		wantROMSize     int64
+		wantBitReversed bool
+		wantRegions     []core.Region
+	}{
+		{"plain", makeTestROM(32, 0xE000, false, false), 0, 256 * 1024, false, []core.Region{}},
+		{"copier header", makeTestROM(32, 0xFFF0, false, true), CopierHeaderSize, 256 * 1024, false, []core.Region{}},
+		{"bit-reversed", makeTestROM(64, 0xE123, true, false), 0, 512 * 1024, true, []core.Region{core.RegionUSA}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := Parse(bytes.NewReader(tt.rom), int64(len(tt.rom)))
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if info.GamePlatform() != core.PlatformPCE {
+				t.Errorf("Platform = %v, want %v", info.GamePlatform(), core.PlatformPCE)
+			}
+			if info.HeaderSize != tt.wantHeaderSize {
+				t.Errorf("HeaderSize = %d, want %d", info.HeaderSize, tt.wantHeaderSize)
+			}
+			if info.ROMSize != tt.wantROMSize {
+				t.Errorf("ROMSize = %d, want %d", info.ROMSize, tt.wantROMSize)
+			}
+			if info.BitReversed != tt.wantBitReversed {
+				t.Errorf("BitReversed = %v, want %v", info.BitReversed, tt.wantBitReversed)
+			}
+			if info.ResetVector < 0xE000 {
+				t.Errorf("ResetVector = $%04X, want >= $E000", info.ResetVector)
+			}
+			if len(info.GameRegions()) != len(tt.wantRegions) {
+				t.Errorf("GameRegions() = %v, want %v", info.GameRegions(), tt.wantRegions)
+			}
+		})
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		rom  []byte
+	}{
+		{"too small", make([]byte, 4096)},
+		{"bad size", make([]byte, pceBankSize+100)},
+		// $0000 isn't a valid vector in either bit order
+		{"bad vector", make([]byte, pceBankSize)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(bytes.NewReader(tt.rom), int64(len(tt.rom))); err == nil {
+				t.Error("Parse() expected error, got nil")
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package pce
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/sargunv/rom-tools/internal/util"
+	"github.com/sargunv/rom-tools/lib/core"
+)
+
+// PC Engine CD-ROM² (TurboGrafx-CD) disc identification from the IPL sector.
+//
+// PC Engine CD discs have no filesystem. The system card boots the disc by
+// reading the IPL (Initial Program Loader) information block from the second
+// sector of the first data track, which carries a fixed system signature and
+// the program name.
+//
+// The data track may be cooked (2048 bytes/sector) or raw MODE1 (2352
+// bytes/sector, with a 16-byte sync and header before the user data), so
+// both layouts are probed.
+//
+// IPL sector layout:
+//
+//	Offset  Size  Description
+//	0x00    3     IPLBLK: first sector of the boot program (big-endian)
+//	0x03    1     IPLBLN: number of sectors to load
+//	0x04    2     IPLSTA: load address (little-endian)
+//	0x06    2     IPLJMP: execution address (little-endian)
+//	0x20    24    "PC Engine CD-ROM SYSTEM\0"
+//	0x38    50    Copyright ("Copyright HUDSON SOFT / NEC Home Electronics,Ltd.")
+//	0x6A    22    Program name
+
+const (
+	cdIPLSector       = 1
+	cdCookedSector    = 2048
+	cdRawSector       = 2352
+	cdRawHeaderSize   = 16 // sync pattern and MODE1 header
+	cdIPLSize         = 0x80
+	cdLoadSectorOff   = 0x00
+	cdLoadCountOff    = 0x03
+	cdLoadAddressOff  = 0x04
+	cdExecAddressOff  = 0x06
+	cdSignatureOffset = 0x20
+	cdSignature       = "PC Engine CD-ROM SYSTEM"
+	cdTitleOffset     = 0x6A
+	cdTitleLen        = 22
+)
+
+// cdIPLOffsets are the byte offsets of the IPL sector in a cooked and a raw
+// data track.
+var cdIPLOffsets = []int64{
+	cdIPLSector * cdCookedSector,
+	cdIPLSector*cdRawSector + cdRawHeaderSize,
+}
+
+// CDInfo contains metadata extracted from a PC Engine CD IPL sector.
+type CDInfo struct {
+	// Title is the program name.
+	Title string `json:"title,omitempty"`
+	// LoadSector is the first sector of the boot program.
+	LoadSector uint32 `json:"load_sector"`
+	// LoadSectors is the number of sectors of the boot program.
+	LoadSectors uint8 `json:"load_sectors"`
+	// LoadAddress is the address the boot program is loaded at.
+	LoadAddress uint16 `json:"load_address"`
+	// ExecAddress is the address the boot program starts at.
+	ExecAddress uint16 `json:"exec_address"`
+}
+
+// GamePlatform implements core.GameInfo.
+func (i *CDInfo) GamePlatform() core.Platform { return core.PlatformPCECD }
+
+// GameTitle implements core.GameInfo.
+func (i *CDInfo) GameTitle() string { return i.Title }
+
+// GameSerial implements core.GameInfo. PC Engine CD discs don't have embedded serials.
+func (i *CDInfo) GameSerial() string { return "" }
+
+// GameRegions implements core.GameInfo. The IPL sector doesn't record a region.
+func (i *CDInfo) GameRegions() []core.Region { return []core.Region{} }
+
+// ParseCD extracts information from a PC Engine CD data track, in either
+// cooked or raw MODE1 sectors.
+func ParseCD(r io.ReaderAt, size int64) (*CDInfo, error) {
+	if size < cdIPLOffsets[0]+cdIPLSize {
+		return nil, fmt.Errorf("file too small for PC Engine CD IPL sector: %d bytes", size)
+	}
+
+	data := make([]byte, cdIPLSize)
+	for _, offset := range cdIPLOffsets {
+		if offset+cdIPLSize > size {
+			continue
+		}
+		if _, err := r.ReadAt(data, offset); err != nil {
+			return nil, fmt.Errorf("failed to read PC Engine CD IPL sector: %w", err)
+		}
+		if string(data[cdSignatureOffset:cdSignatureOffset+len(cdSignature)]) == cdSignature {
+			return parseIPL(data), nil
+		}
+	}
+	return nil, fmt.Errorf("not a valid PC Engine CD: missing %q signature", cdSignature)
+}
+
+func parseIPL(data []byte) *CDInfo {
+	return &CDInfo{
+		Title:       util.ExtractASCII(data[cdTitleOffset : cdTitleOffset+cdTitleLen]),
+		LoadSector:  uint32(data[cdLoadSectorOff])<<16 | uint32(data[cdLoadSectorOff+1])<<8 | uint32(data[cdLoadSectorOff+2]),
+		LoadSectors: data[cdLoadCountOff],
+		LoadAddress: binary.LittleEndian.Uint16(data[cdLoadAddressOff:]),
+		ExecAddress: binary.LittleEndian.Uint16(data[cdExecAddressOff:]),
+	}
+}
@@ -0,0 +1,65 @@
+package pce
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/sargunv/rom-tools/lib/core"
+)
+
+// makeTestTrack creates a data track with an IPL sector, in cooked or raw
+// MODE1 sectors.
+func makeTestTrack(title string, raw bool) []byte {
+	ipl := make([]byte, cdCookedSector)
+	copy(ipl, []byte{0x00, 0x00, 0x02, 0x10, 0x00, 0x40, 0x00, 0x40})
+	copy(ipl[cdSignatureOffset:], cdSignature+"\x00")
+	copy(ipl[0x38:], "Copyright HUDSON SOFT / NEC Home Electronics,Ltd.")
+	copy(ipl[cdTitleOffset:], title)
+
+	if !raw {
+		return append(make([]byte, cdCookedSector), ipl...)
+	}
+	track := make([]byte, 2*cdRawSector)
+	copy(track[cdRawSector+cdRawHeaderSize:], ipl)
+	return track
+}
+
+func TestParseCD(t *testing.T) {
+	for _, raw := range []bool{false, true} {
+		track := makeTestTrack("YS I+II                ", raw)
+		info, err := ParseCD(bytes.NewReader(track), int64(len(track)))
+		if err != nil {
+			t.Fatalf("ParseCD(raw=%v) error = %v", raw, err)
+		}
+		if info.GamePlatform() != core.PlatformPCECD {
+			t.Errorf("Platform = %v, want %v", info.GamePlatform(), core.PlatformPCECD)
+		}
+		if info.GameTitle() != "YS I+II" {
+			t.Errorf("GameTitle() = %q, want %q", info.GameTitle(), "YS I+II")
+		}
+		if info.LoadSector != 2 || info.LoadSectors != 0x10 {
+			t.Errorf("LoadSector, LoadSectors = %d, %d, want 2, 16", info.LoadSector, info.LoadSectors)
+		}
+		if info.LoadAddress != 0x4000 || info.ExecAddress != 0x4000 {
+			t.Errorf("LoadAddress, ExecAddress = $%04X, $%04X, want $4000, $4000", info.LoadAddress, info.ExecAddress)
+		}
+	}
+}
+
+func TestParseCD_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"too small", make([]byte, cdCookedSector)},
+		{"no signature", make([]byte, 2*cdRawSector)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseCD(bytes.NewReader(tt.data), int64(len(tt.data))); err == nil {
+				t.Error("ParseCD() expected error, got nil")
+			}
+		})
+	}
+}
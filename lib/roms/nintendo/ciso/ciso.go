@@ -0,0 +1,170 @@
+package ciso
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/sargunv/rom-tools/lib/core"
+	"github.com/sargunv/rom-tools/lib/roms/nintendo/gcm"
+)
+
+// CISO (compact ISO) file parsing, as written by Wii and GameCube backup
+// loaders. Unrelated to the PSP CSO format, which shares its magic.
+//
+// The disc is split into fixed-size blocks, and only blocks the disc uses are
+// stored, in order, after the header.
+//
+// Format reference: Dolphin (Source/Core/DiscIO/CISOBlob.h)
+//
+// Header layout (0x8000 bytes, little-endian):
+//
+//	Offset  Size    Description
+//	0x00    4       Magic "CISO"
+//	0x04    4       Block size
+//	0x08    0x7FF8  Block map (one byte per disc block, 1 = stored)
+
+const (
+	cisoMagic = "CISO"
+
+	headerSize      = 0x8000
+	blockSizeOffset = 0x04
+	mapOffset       = 0x08
+	mapSize         = headerSize - mapOffset
+
+	minBlockSize = 0x8000 // one Wii sector
+	maxBlockSize = 1 << 30
+)
+
+// Info contains metadata extracted from a CISO file.
+type Info struct {
+	// GCM contains the game identification info parsed from the disc header.
+	GCM *gcm.Info `json:"gcm,omitempty"`
+	// BlockSize is the disc block size in bytes.
+	BlockSize int64 `json:"block_size"`
+	// UsedBlocks is the number of disc blocks stored in the file.
+	UsedBlocks int `json:"used_blocks"`
+}
+
+// GamePlatform implements core.GameInfo by delegating to GCM.
+func (i *Info) GamePlatform() core.Platform { return i.GCM.GamePlatform() }
+
+// GameTitle implements core.GameInfo by delegating to GCM.
+func (i *Info) GameTitle() string { return i.GCM.GameTitle() }
+
+// GameSerial implements core.GameInfo by delegating to GCM.
+func (i *Info) GameSerial() string { return i.GCM.GameSerial() }
+
+// GameRegions implements core.GameInfo by delegating to GCM.
+func (i *Info) GameRegions() []core.Region { return i.GCM.GameRegions() }
+
+// Reader provides random access to the logical disc image in a CISO file.
+// Blocks not stored in the file read as zeros.
+type Reader struct {
+	r         io.ReaderAt
+	blockSize int64
+	// blocks maps each disc block to its index in the file, or -1 if unstored
+	blocks     []int32
+	usedBlocks int
+	size       int64
+}
+
+// NewReader opens a CISO file.
+func NewReader(r io.ReaderAt, size int64) (*Reader, error) {
+	if size < headerSize {
+		return nil, fmt.Errorf("file too small for CISO header: %d bytes", size)
+	}
+
+	header := make([]byte, headerSize)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, fmt.Errorf("failed to read CISO header: %w", err)
+	}
+	if string(header[0:4]) != cisoMagic {
+		return nil, fmt.Errorf("not a valid CISO file: invalid magic")
+	}
+
+	// PSP CSO files have their header size (0x18 or 0) here instead
+	blockSize := int64(binary.LittleEndian.Uint32(header[blockSizeOffset:]))
+	if blockSize < minBlockSize || blockSize > maxBlockSize || blockSize&(blockSize-1) != 0 {
+		return nil, fmt.Errorf("invalid CISO block size: %d", blockSize)
+	}
+
+	blocks := make([]int32, mapSize)
+	var used int32
+	var numBlocks int64
+	for i, stored := range header[mapOffset:] {
+		switch stored {
+		case 0:
+			blocks[i] = -1
+		case 1:
+			blocks[i] = used
+			used++
+			numBlocks = int64(i) + 1
+		default:
+			return nil, fmt.Errorf("not a valid CISO file: invalid block map entry %d", stored)
+		}
+	}
+	if headerSize+int64(used)*blockSize > size {
+		return nil, fmt.Errorf("CISO data extends beyond file: %d blocks, file size %d", used, size)
+	}
+
+	return &Reader{
+		r:          r,
+		blockSize:  blockSize,
+		blocks:     blocks[:numBlocks],
+		usedBlocks: int(used),
+		size:       numBlocks * blockSize,
+	}, nil
+}
+
+// Size returns the size of the logical disc image, up to the end of the last
+// stored block.
+func (c *Reader) Size() int64 {
+	return c.size
+}
+
+// ReadAt implements io.ReaderAt, reading from the logical disc image.
+func (c *Reader) ReadAt(p []byte, off int64) (n int, err error) {
+	if off < 0 {
+		return 0, fmt.Errorf("negative offset")
+	}
+
+	for n < len(p) && off < c.size {
+		block := off / c.blockSize
+		inBlock := off % c.blockSize
+		chunk := p[n : n+int(min(int64(len(p)-n), c.blockSize-inBlock))]
+
+		if stored := c.blocks[block]; stored < 0 {
+			clear(chunk)
+		} else if _, err := c.r.ReadAt(chunk, headerSize+int64(stored)*c.blockSize+inBlock); err != nil {
+			return n, fmt.Errorf("read block %d: %w", block, err)
+		}
+
+		n += len(chunk)
+		off += int64(len(chunk))
+	}
+
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// Parse extracts game information from a CISO file.
+func Parse(r io.ReaderAt, size int64) (*Info, error) {
+	reader, err := NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	gcmInfo, err := gcm.Parse(reader, reader.Size())
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse disc header from CISO: %w", err)
+	}
+
+	return &Info{
+		GCM:        gcmInfo,
+		BlockSize:  reader.blockSize,
+		UsedBlocks: reader.usedBlocks,
+	}, nil
+}
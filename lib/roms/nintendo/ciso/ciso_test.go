@@ -0,0 +1,127 @@
+package ciso
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/sargunv/rom-tools/lib/core"
+)
+
+const testBlockSize = 0x8000
+
+// makeTestDiscHeader creates a minimal GameCube disc header.
+func makeTestDiscHeader(gameID, title string) []byte {
+	header := make([]byte, 0x440)
+	copy(header, gameID)
+	copy(header[0x04:], "01")
+	binary.BigEndian.PutUint32(header[0x1C:], 0xC2339F3D) // GameCube magic
+	copy(header[0x20:], title)
+	return header
+}
+
+// makeTestCISO creates a CISO file. blocks maps disc block numbers to their
+// contents; unlisted blocks aren't stored.
+func makeTestCISO(blocks map[int][]byte) []byte {
+	header := make([]byte, headerSize)
+	copy(header, cisoMagic)
+	binary.LittleEndian.PutUint32(header[blockSizeOffset:], testBlockSize)
+
+	var body bytes.Buffer
+	for i := range mapSize {
+		contents, ok := blocks[i]
+		if !ok {
+			continue
+		}
+		header[mapOffset+i] = 1
+		block := make([]byte, testBlockSize)
+		copy(block, contents)
+		body.Write(block)
+	}
+	return append(header, body.Bytes()...)
+}
+
+func TestParse(t *testing.T) {
+	data := makeTestCISO(map[int][]byte{
+		0: makeTestDiscHeader("GALE", "SUPER SMASH BROS MELEE"),
+		3: {0xAA},
+	})
+
+	info, err := Parse(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if info.BlockSize != testBlockSize {
+		t.Errorf("BlockSize = %d, want %d", info.BlockSize, testBlockSize)
+	}
+	if info.UsedBlocks != 2 {
+		t.Errorf("UsedBlocks = %d, want 2", info.UsedBlocks)
+	}
+	if got := info.GamePlatform(); got != core.PlatformGC {
+		t.Errorf("GamePlatform() = %v, want %v", got, core.PlatformGC)
+	}
+	if got := info.GameTitle(); got != "SUPER SMASH BROS MELEE" {
+		t.Errorf("GameTitle() = %q, want %q", got, "SUPER SMASH BROS MELEE")
+	}
+	if got := info.GameSerial(); got != "GALE" {
+		t.Errorf("GameSerial() = %q, want %q", got, "GALE")
+	}
+}
+
+func TestReader_ReadAt(t *testing.T) {
+	data := makeTestCISO(map[int][]byte{
+		0: bytes.Repeat([]byte{0x11}, testBlockSize),
+		2: bytes.Repeat([]byte{0x22}, testBlockSize),
+	})
+
+	reader, err := NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("NewReader() error = %v", err)
+	}
+	if reader.Size() != 3*testBlockSize {
+		t.Errorf("Size() = %d, want %d", reader.Size(), 3*testBlockSize)
+	}
+
+	// Read spanning all three blocks; block 1 isn't stored and reads as zeros
+	buf := make([]byte, testBlockSize+2)
+	if _, err := reader.ReadAt(buf, testBlockSize-1); err != nil {
+		t.Fatalf("ReadAt() error = %v", err)
+	}
+	if buf[0] != 0x11 || buf[1] != 0 || buf[testBlockSize] != 0 || buf[testBlockSize+1] != 0x22 {
+		t.Errorf("ReadAt() = [%#x %#x ... %#x %#x], want [0x11 0 ... 0 0x22]",
+			buf[0], buf[1], buf[testBlockSize], buf[testBlockSize+1])
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	valid := makeTestCISO(map[int][]byte{0: makeTestDiscHeader("GALE", "TEST")})
+
+	// A PSP CSO header, which shares the magic
+	pspCSO := bytes.Clone(valid)
+	binary.LittleEndian.PutUint32(pspCSO[blockSizeOffset:], 0x18)
+
+	badMap := bytes.Clone(valid)
+	badMap[mapOffset+1] = 2
+
+	truncated := valid[:headerSize+testBlockSize/2]
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"too small", make([]byte, 8)},
+		{"invalid magic", make([]byte, headerSize)},
+		{"PSP CSO", pspCSO},
+		{"invalid block map", badMap},
+		{"truncated", truncated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(bytes.NewReader(tt.data), int64(len(tt.data))); err == nil {
+				t.Error("Parse() expected error, got nil")
+			}
+		})
+	}
+}
@@ -0,0 +1,272 @@
+package fds
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/sargunv/rom-tools/internal/util"
+	"github.com/sargunv/rom-tools/lib/core"
+)
+
+// Famicom Disk System (FDS) disk image parsing.
+//
+// FDS disk format specification:
+// https://www.nesdev.org/wiki/FDS_disk_format
+// https://www.nesdev.org/wiki/FDS_file_format
+//
+// An .fds image is a sequence of 65500-byte disk sides, optionally preceded by
+// a 16-byte fwNES header. No-Intro hashes the image without the fwNES header.
+//
+// fwNES header (16 bytes, optional):
+//
+//	Offset  Size  Description
+//	0x00    4     Magic "FDS\x1A"
+//	0x04    1     Number of disk sides
+//	0x05    11    Reserved (zero)
+//
+// Each side begins with a disk info block (block 1, 56 bytes):
+//
+//	Offset  Size  Description
+//	0x00    1     Block code (0x01)
+//	0x01    14    Verification string "*NINTENDO-HVC*"
+//	0x0F    1     Manufacturer (licensee) code
+//	0x10    3     Game name code (ASCII, e.g., "ZEL")
+//	0x13    1     Game type (' ' = normal, 'E' = event, 'R' = sale discount)
+//	0x14    1     Game version
+//	0x15    1     Side number (0 = side A, 1 = side B)
+//	0x16    1     Disk number (0 = first disk)
+//	0x17    1     Disk type (0 = FMC normal, 1 = FSC with shutter)
+//	0x18    1     Unknown
+//	0x19    1     Boot read file code
+//	0x1A    5     Unknown (0xFF)
+//	0x1F    3     Manufacturing date (BCD: year, month, day)
+//	0x22    1     Country code (0x49 = Japan)
+//	0x23    9     Unknown
+//	0x2C    3     Rewritten date (BCD: year, month, day)
+//	0x2F    2     Unknown
+//	0x31    2     Disk Writer serial number
+//	0x33    1     Unknown
+//	0x34    1     Rewrite count (BCD)
+//	0x35    1     Actual disk side
+//	0x36    1     Disk type (other)
+//	0x37    1     Price / disk version
+//
+// Years are stored as Japanese era years: Showa (1925 + n) for most disks,
+// Heisei (1988 + n) for some later ones.
+
+const (
+	// HeaderSize is the size of the optional fwNES header.
+	HeaderSize = 16
+
+	// SideSize is the size of one disk side in an .fds image.
+	SideSize = 65500
+
+	fdsHeaderSidesOffset = 0x04
+
+	diskInfoSize                = 56
+	diskInfoBlockCode           = 0x01
+	diskInfoVerifyOffset        = 0x01
+	diskInfoManufacturerOffset  = 0x0F
+	diskInfoGameNameOffset      = 0x10
+	diskInfoGameNameLen         = 3
+	diskInfoGameTypeOffset      = 0x13
+	diskInfoVersionOffset       = 0x14
+	diskInfoSideOffset          = 0x15
+	diskInfoDiskNumberOffset    = 0x16
+	diskInfoDiskTypeOffset      = 0x17
+	diskInfoBootFileOffset      = 0x19
+	diskInfoMfgDateOffset       = 0x1F
+	diskInfoCountryOffset       = 0x22
+	diskInfoRewriteDateOffset   = 0x2C
+	diskInfoRewriteCountOffset  = 0x34
+	diskInfoDiskVersionOffset   = 0x37
+	diskInfoShowaEraFirstYear   = 1925
+	diskInfoHeiseiEraFirstYear  = 1988
+	diskInfoShowaEraMinimumYear = 58 // FDS launched in Showa 61; smaller values are Heisei
+)
+
+var (
+	fdsMagic       = []byte("FDS\x1A")
+	diskInfoVerify = []byte("*NINTENDO-HVC*")
+)
+
+// Country represents the country code in the disk info block.
+type Country byte
+
+// Country values
+const (
+	CountryJapan Country = 0x49
+)
+
+// Side is a disk side (A or B).
+type Side byte
+
+// Side values
+const (
+	SideA Side = 0
+	SideB Side = 1
+)
+
+// String returns "A" or "B".
+func (s Side) String() string {
+	if s == SideB {
+		return "B"
+	}
+	return "A"
+}
+
+// DiskInfo contains metadata from the disk info block of one disk side.
+type DiskInfo struct {
+	// ManufacturerCode is the licensee code.
+	ManufacturerCode byte `json:"manufacturer_code"`
+	// GameName is the 3-character game name code (e.g., "ZEL").
+	GameName string `json:"game_name,omitempty"`
+	// GameType is ' ' for normal disks, 'E' for event disks, 'R' for sale discount.
+	GameType byte `json:"game_type"`
+	// Version is the game version.
+	Version int `json:"version"`
+	// Side is the disk side.
+	Side Side `json:"side"`
+	// DiskNumber is the disk number (0 for the first disk).
+	DiskNumber int `json:"disk_number"`
+	// DiskType is 0 for normal (FMC) disks, 1 for shutter (FSC) disks.
+	DiskType byte `json:"disk_type"`
+	// BootFile is the file code loaded at boot.
+	BootFile byte `json:"boot_file"`
+	// ManufacturingDate is the date the disk was manufactured.
+	ManufacturingDate time.Time `json:"manufacturing_date,omitzero"`
+	// RewriteDate is the date the disk was last rewritten at a Disk Writer kiosk.
+	RewriteDate time.Time `json:"rewrite_date,omitzero"`
+	// RewriteCount is the number of times the disk was rewritten.
+	RewriteCount int `json:"rewrite_count"`
+	// Country is the country code.
+	Country Country `json:"country"`
+	// DiskVersion is the price/disk version byte.
+	DiskVersion byte `json:"disk_version"`
+}
+
+// Info contains metadata extracted from an FDS disk image.
+type Info struct {
+	// HeaderSize is the size of the fwNES header (0 or 16).
+	HeaderSize int64 `json:"header_size"`
+	// Sides contains the disk info block of each side, in image order.
+	Sides []DiskInfo `json:"sides"`
+}
+
+// GamePlatform implements core.GameInfo.
+func (i *Info) GamePlatform() core.Platform { return core.PlatformFDS }
+
+// GameTitle implements core.GameInfo. FDS disks only have a 3-character game
+// name code, which is reported as the serial instead.
+func (i *Info) GameTitle() string { return "" }
+
+// GameSerial implements core.GameInfo. Returns the product code in the form
+// printed on disk labels (e.g., "FMC-ZEL").
+func (i *Info) GameSerial() string {
+	if i.Sides[0].GameName == "" {
+		return ""
+	}
+	return "FMC-" + i.Sides[0].GameName
+}
+
+// GameRegions implements core.GameInfo.
+func (i *Info) GameRegions() []core.Region {
+	if i.Sides[0].Country == CountryJapan {
+		return []core.Region{core.RegionJapan}
+	}
+	return []core.Region{}
+}
+
+// Parse extracts game information from an FDS disk image.
+func Parse(r io.ReaderAt, size int64) (*Info, error) {
+	if size < HeaderSize {
+		return nil, fmt.Errorf("file too small for FDS image: %d bytes", size)
+	}
+
+	magic := make([]byte, len(fdsMagic)+1)
+	if _, err := r.ReadAt(magic, 0); err != nil {
+		return nil, fmt.Errorf("failed to read FDS header: %w", err)
+	}
+
+	var headerSize int64
+	numSides := int(size / SideSize)
+	if bytes.Equal(magic[:len(fdsMagic)], fdsMagic) {
+		headerSize = HeaderSize
+		numSides = int((size - HeaderSize) / SideSize)
+		// Trust the header's side count if the image is at least that large
+		if n := int(magic[fdsHeaderSidesOffset]); n > 0 && n <= numSides {
+			numSides = n
+		}
+	}
+
+	if numSides == 0 {
+		return nil, fmt.Errorf("file too small for FDS disk side: %d bytes", size)
+	}
+
+	sides := make([]DiskInfo, numSides)
+	block := make([]byte, diskInfoSize)
+	for i := range sides {
+		if _, err := r.ReadAt(block, headerSize+int64(i)*SideSize); err != nil {
+			return nil, fmt.Errorf("failed to read FDS disk info block: %w", err)
+		}
+		if err := parseDiskInfo(block, &sides[i]); err != nil {
+			return nil, fmt.Errorf("side %d: %w", i, err)
+		}
+	}
+
+	return &Info{
+		HeaderSize: headerSize,
+		Sides:      sides,
+	}, nil
+}
+
+func parseDiskInfo(block []byte, info *DiskInfo) error {
+	if block[0] != diskInfoBlockCode ||
+		!bytes.Equal(block[diskInfoVerifyOffset:diskInfoVerifyOffset+len(diskInfoVerify)], diskInfoVerify) {
+		return fmt.Errorf("not a valid FDS disk: missing disk info block")
+	}
+
+	*info = DiskInfo{
+		ManufacturerCode:  block[diskInfoManufacturerOffset],
+		GameName:          util.ExtractASCII(block[diskInfoGameNameOffset : diskInfoGameNameOffset+diskInfoGameNameLen]),
+		GameType:          block[diskInfoGameTypeOffset],
+		Version:           int(block[diskInfoVersionOffset]),
+		Side:              Side(block[diskInfoSideOffset]),
+		DiskNumber:        int(block[diskInfoDiskNumberOffset]),
+		DiskType:          block[diskInfoDiskTypeOffset],
+		BootFile:          block[diskInfoBootFileOffset],
+		ManufacturingDate: parseBCDDate(block[diskInfoMfgDateOffset : diskInfoMfgDateOffset+3]),
+		RewriteDate:       parseBCDDate(block[diskInfoRewriteDateOffset : diskInfoRewriteDateOffset+3]),
+		RewriteCount:      max(decodeBCD(block[diskInfoRewriteCountOffset]), 0),
+		Country:           Country(block[diskInfoCountryOffset]),
+		DiskVersion:       block[diskInfoDiskVersionOffset],
+	}
+	return nil
+}
+
+// parseBCDDate parses a 3-byte BCD era date (year, month, day).
+// Returns the zero time if the date is unset or invalid.
+func parseBCDDate(b []byte) time.Time {
+	year, month, day := decodeBCD(b[0]), decodeBCD(b[1]), decodeBCD(b[2])
+	if year < 0 || month < 1 || month > 12 || day < 1 || day > 31 {
+		return time.Time{}
+	}
+
+	if year >= diskInfoShowaEraMinimumYear {
+		year += diskInfoShowaEraFirstYear
+	} else {
+		year += diskInfoHeiseiEraFirstYear
+	}
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+}
+
+// decodeBCD decodes a 2-digit BCD byte, returning -1 if it isn't valid BCD.
+func decodeBCD(b byte) int {
+	hi, lo := b>>4, b&0x0F
+	if hi > 9 || lo > 9 {
+		return -1
+	}
+	return int(hi)*10 + int(lo)
+}
@@ -0,0 +1,109 @@
+package fds
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/sargunv/rom-tools/lib/core"
+)
+
+// makeSide creates a disk side with a disk info block.
+func makeSide(side Side, diskNumber byte) []byte {
+	data := make([]byte, SideSize)
+	data[0] = diskInfoBlockCode
+	copy(data[diskInfoVerifyOffset:], diskInfoVerify)
+	data[diskInfoManufacturerOffset] = 0x01
+	copy(data[diskInfoGameNameOffset:], "ZEL")
+	data[diskInfoGameTypeOffset] = ' '
+	data[diskInfoVersionOffset] = 1
+	data[diskInfoSideOffset] = byte(side)
+	data[diskInfoDiskNumberOffset] = diskNumber
+	copy(data[diskInfoMfgDateOffset:], []byte{0x61, 0x02, 0x21}) // Showa 61 = 1986
+	data[diskInfoCountryOffset] = byte(CountryJapan)
+	copy(data[diskInfoRewriteDateOffset:], []byte{0x02, 0x11, 0x05}) // Heisei 2 = 1990
+	data[diskInfoRewriteCountOffset] = 0x12
+	return data
+}
+
+func TestParse_Headered(t *testing.T) {
+	header := make([]byte, HeaderSize)
+	copy(header, fdsMagic)
+	header[fdsHeaderSidesOffset] = 2
+
+	data := append(header, makeSide(SideA, 0)...)
+	data = append(data, makeSide(SideB, 0)...)
+
+	info, err := Parse(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if info.GamePlatform() != core.PlatformFDS {
+		t.Errorf("Platform = %v, want %v", info.GamePlatform(), core.PlatformFDS)
+	}
+	if info.HeaderSize != HeaderSize {
+		t.Errorf("HeaderSize = %d, want %d", info.HeaderSize, HeaderSize)
+	}
+	if len(info.Sides) != 2 {
+		t.Fatalf("len(Sides) = %d, want 2", len(info.Sides))
+	}
+	if info.GameSerial() != "FMC-ZEL" {
+		t.Errorf("GameSerial() = %q, want %q", info.GameSerial(), "FMC-ZEL")
+	}
+	if regions := info.GameRegions(); len(regions) != 1 || regions[0] != core.RegionJapan {
+		t.Errorf("GameRegions() = %v, want [%v]", regions, core.RegionJapan)
+	}
+
+	side := info.Sides[0]
+	if side.Side != SideA || info.Sides[1].Side != SideB {
+		t.Errorf("Sides = %v, %v, want A, B", side.Side, info.Sides[1].Side)
+	}
+	if side.Version != 1 {
+		t.Errorf("Version = %d, want 1", side.Version)
+	}
+	if want := time.Date(1986, 2, 21, 0, 0, 0, 0, time.UTC); !side.ManufacturingDate.Equal(want) {
+		t.Errorf("ManufacturingDate = %v, want %v", side.ManufacturingDate, want)
+	}
+	if want := time.Date(1990, 11, 5, 0, 0, 0, 0, time.UTC); !side.RewriteDate.Equal(want) {
+		t.Errorf("RewriteDate = %v, want %v", side.RewriteDate, want)
+	}
+	if side.RewriteCount != 12 {
+		t.Errorf("RewriteCount = %d, want 12", side.RewriteCount)
+	}
+}
+
+func TestParse_Headerless(t *testing.T) {
+	// Two disks, four sides, no fwNES header
+	var data []byte
+	for disk := range byte(2) {
+		data = append(data, makeSide(SideA, disk)...)
+		data = append(data, makeSide(SideB, disk)...)
+	}
+
+	info, err := Parse(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if info.HeaderSize != 0 {
+		t.Errorf("HeaderSize = %d, want 0", info.HeaderSize)
+	}
+	if len(info.Sides) != 4 {
+		t.Fatalf("len(Sides) = %d, want 4", len(info.Sides))
+	}
+	if info.Sides[3].DiskNumber != 1 || info.Sides[3].Side != SideB {
+		t.Errorf("Sides[3] = disk %d side %v, want disk 1 side B", info.Sides[3].DiskNumber, info.Sides[3].Side)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	data := make([]byte, SideSize)
+	if _, err := Parse(bytes.NewReader(data), int64(len(data))); err == nil {
+		t.Error("Parse() expected error for missing disk info block, got nil")
+	}
+
+	if _, err := Parse(bytes.NewReader(data[:1000]), 1000); err == nil {
+		t.Error("Parse() expected error for small file, got nil")
+	}
+}
@@ -34,6 +34,8 @@ import (
 
 const (
 	gbHeaderStart          = 0x100
+	gbLogoOffset           = 0x104
+	gbLogoLen              = 48
 	gbHeaderSize           = 0x50 // 0x100 to 0x14F
 	gbTitleOffset          = 0x134
 	gbTitleMaxLen          = 16
@@ -54,6 +56,15 @@ const (
 	gbGlobalChecksumOffset = 0x14E
 )
 
+// nintendoLogo is the logo bitmap at 0x104, which the boot ROM compares
+// against its own copy, refusing to boot cartridges that don't match.
+var nintendoLogo = [gbLogoLen]byte{
+	0xCE, 0xED, 0x66, 0x66, 0xCC, 0x0D, 0x00, 0x0B, 0x03, 0x73, 0x00, 0x83,
+	0x00, 0x0C, 0x00, 0x0D, 0x00, 0x08, 0x11, 0x1F, 0x88, 0x89, 0x00, 0x0E,
+	0xDC, 0xCC, 0x6E, 0xE6, 0xDD, 0xDD, 0xD9, 0x99, 0xBB, 0xBB, 0x67, 0x63,
+	0x6E, 0x0E, 0xEC, 0xCC, 0xDD, 0xDC, 0x99, 0x9F, 0xBB, 0xB9, 0x33, 0x3E,
+}
+
 // isValidTitleByte checks if a byte is valid in a Game Boy title.
 // Valid bytes are null (padding) or printable ASCII (0x20-0x7E).
 func isValidTitleByte(b byte) bool {
@@ -158,8 +169,18 @@ type Info struct {
 	Version int `json:"version"`
 	// HeaderChecksum is the header checksum byte (0x14D).
 	HeaderChecksum byte `json:"header_checksum"`
+	// HeaderChecksumValid reports whether HeaderChecksum matches the header.
+	// The boot ROM refuses to boot ROMs with an invalid header checksum.
+	HeaderChecksumValid bool `json:"header_checksum_valid"`
+	// LogoValid reports whether the Nintendo logo (0x104-0x133) is intact.
+	// The boot ROM refuses to boot ROMs without it.
+	LogoValid bool `json:"logo_valid"`
 	// GlobalChecksum is the 16-bit global checksum (0x14E-0x14F, big-endian).
 	GlobalChecksum uint16 `json:"global_checksum"`
+	// GlobalChecksumValid reports whether GlobalChecksum matches the ROM
+	// contents, or is nil if they weren't checked (see ParseHeader). The
+	// hardware doesn't check it, but bad dumps and overdumps fail it.
+	GlobalChecksumValid *bool `json:"global_checksum_valid,omitempty"`
 	// platform is GB or GBC based on the CGB flag (internal, used by GamePlatform).
 	platform core.Platform
 }
@@ -185,8 +206,25 @@ func (i *Info) GameRegions() []core.Region {
 	}
 }
 
-// Parse extracts game information from a GB/GBC ROM file.
+// Parse extracts game information from a GB/GBC ROM file, and checks its
+// global checksum, which takes a pass over the whole ROM.
 func Parse(r io.ReaderAt, size int64) (*Info, error) {
+	info, err := ParseHeader(r, size)
+	if err != nil {
+		return nil, err
+	}
+	computed, err := computeGlobalChecksum(r, size)
+	if err != nil {
+		return nil, err
+	}
+	valid := computed == info.GlobalChecksum
+	info.GlobalChecksumValid = &valid
+	return info, nil
+}
+
+// ParseHeader is like Parse, but only reads the header, leaving
+// GlobalChecksumValid nil.
+func ParseHeader(r io.ReaderAt, size int64) (*Info, error) {
 	if size < gbHeaderStart+gbHeaderSize {
 		return nil, fmt.Errorf("file too small for GB header: %d bytes", size)
 	}
@@ -262,6 +300,8 @@ func Parse(r io.ReaderAt, size int64) (*Info, error) {
 	// Extract version
 	version := int(header[gbVersionOffset-gbHeaderStart])
 
+	validation := validateHeader(header)
+
 	return &Info{
 		Title:            title,
 		ManufacturerCode: manufacturerCode,
@@ -276,5 +316,80 @@ func Parse(r io.ReaderAt, size int64) (*Info, error) {
 		HeaderChecksum:   headerChecksum,
 		GlobalChecksum:   globalChecksum,
 		platform:         platform,
+
+		HeaderChecksumValid: validation.ChecksumValid(),
+		LogoValid:           validation.LogoValid,
 	}, nil
 }
+
+// HeaderValidation is the result of checking a GB/GBC header the way the
+// boot ROM does. Bad dumps and overdumps with a damaged or shifted header
+// fail these checks.
+type HeaderValidation struct {
+	// StoredChecksum is the header checksum byte (0x14D).
+	StoredChecksum byte `json:"stored_checksum"`
+	// ComputedChecksum is the checksum of header bytes 0x134-0x14C.
+	ComputedChecksum byte `json:"computed_checksum"`
+	// LogoValid reports whether the Nintendo logo (0x104-0x133) is intact.
+	LogoValid bool `json:"logo_valid"`
+}
+
+// ChecksumValid reports whether the stored header checksum matches the
+// computed one.
+func (v *HeaderValidation) ChecksumValid() bool {
+	return v.StoredChecksum == v.ComputedChecksum
+}
+
+// ValidateHeader checks the header checksum and Nintendo logo of a GB/GBC
+// ROM file.
+func ValidateHeader(r io.ReaderAt, size int64) (*HeaderValidation, error) {
+	if size < gbHeaderStart+gbHeaderSize {
+		return nil, fmt.Errorf("file too small for GB header: %d bytes", size)
+	}
+	header := make([]byte, gbHeaderSize)
+	if _, err := r.ReadAt(header, gbHeaderStart); err != nil {
+		return nil, fmt.Errorf("failed to read GB header: %w", err)
+	}
+	validation := validateHeader(header)
+	return &validation, nil
+}
+
+// validateHeader checks the header read from 0x100.
+func validateHeader(header []byte) HeaderValidation {
+	logoStart := gbLogoOffset - gbHeaderStart
+	return HeaderValidation{
+		StoredChecksum:   header[gbHeaderChecksumOffset-gbHeaderStart],
+		ComputedChecksum: computeHeaderChecksum(header),
+		LogoValid:        [gbLogoLen]byte(header[logoStart:logoStart+gbLogoLen]) == nintendoLogo,
+	}
+}
+
+// computeGlobalChecksum sums every byte of the ROM except the two of the
+// global checksum itself.
+func computeGlobalChecksum(r io.ReaderAt, size int64) (uint16, error) {
+	var sum uint16
+	buf := make([]byte, 32*1024)
+	for offset := int64(0); offset < size; {
+		n := min(int64(len(buf)), size-offset)
+		if _, err := r.ReadAt(buf[:n], offset); err != nil {
+			return 0, fmt.Errorf("failed to read GB ROM: %w", err)
+		}
+		for i, b := range buf[:n] {
+			if pos := offset + int64(i); pos != gbGlobalChecksumOffset && pos != gbGlobalChecksumOffset+1 {
+				sum += uint16(b)
+			}
+		}
+		offset += n
+	}
+	return sum, nil
+}
+
+// computeHeaderChecksum computes the checksum of header bytes 0x134-0x14C:
+// starting from 0, each byte and 1 are subtracted.
+func computeHeaderChecksum(header []byte) byte {
+	var sum byte
+	for _, b := range header[gbTitleOffset-gbHeaderStart : gbHeaderChecksumOffset-gbHeaderStart] {
+		sum = sum - b - 1
+	}
+	return sum
+}
@@ -81,11 +81,23 @@ func TestParseGB_GB(t *testing.T) {
 		t.Errorf("Expected HeaderChecksum 0x00, got %#02x", info.HeaderChecksum)
 	}
 
+	// The homebrew ROM's header checksum byte is left at 0, but its logo is intact
+	if info.HeaderChecksumValid {
+		t.Error("Expected HeaderChecksumValid false")
+	}
+	if !info.LogoValid {
+		t.Error("Expected LogoValid true")
+	}
+
 	// Global checksum
 	if info.GlobalChecksum != 0xA9E1 {
 		t.Errorf("Expected GlobalChecksum 0xA9E1, got %#04x", info.GlobalChecksum)
 	}
 
+	if info.GlobalChecksumValid == nil || !*info.GlobalChecksumValid {
+		t.Errorf("Expected GlobalChecksumValid true, got %v", info.GlobalChecksumValid)
+	}
+
 	// ManufacturerCode should be empty for original GB games
 	if info.ManufacturerCode != "" {
 		t.Errorf("Expected empty ManufacturerCode for GB game, got '%s'", info.ManufacturerCode)
@@ -161,6 +173,62 @@ func TestParseGB_GBC(t *testing.T) {
 	if info.GlobalChecksum == 0 {
 		t.Errorf("Expected non-zero GlobalChecksum, got %#04x", info.GlobalChecksum)
 	}
+
+	if !info.HeaderChecksumValid || !info.LogoValid {
+		t.Errorf("Expected valid header checksum and logo, got %v and %v", info.HeaderChecksumValid, info.LogoValid)
+	}
+}
+
+func TestParseGB_GlobalChecksum(t *testing.T) {
+	data, err := os.ReadFile("testdata/JUMPMAN86.GBC")
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+
+	// An overdump, with the ROM repeated, fails the checksum
+	overdump := append(data[:len(data):len(data)], data...)
+	info, err := Parse(&mockReaderAt{data: overdump}, int64(len(overdump)))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if info.GlobalChecksumValid == nil || *info.GlobalChecksumValid {
+		t.Errorf("Expected GlobalChecksumValid false for an overdump, got %v", info.GlobalChecksumValid)
+	}
+
+	// ParseHeader doesn't check it
+	info, err = ParseHeader(&mockReaderAt{data: data}, int64(len(data)))
+	if err != nil {
+		t.Fatalf("ParseHeader() error = %v", err)
+	}
+	if info.GlobalChecksumValid != nil {
+		t.Errorf("Expected GlobalChecksumValid nil from ParseHeader, got %v", *info.GlobalChecksumValid)
+	}
+}
+
+func TestValidateHeader(t *testing.T) {
+	data, err := os.ReadFile("testdata/JUMPMAN86.GBC")
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+
+	v, err := ValidateHeader(&mockReaderAt{data: data}, int64(len(data)))
+	if err != nil {
+		t.Fatalf("ValidateHeader() error = %v", err)
+	}
+	if v.StoredChecksum != 0x32 || v.ComputedChecksum != 0x32 || !v.ChecksumValid() || !v.LogoValid {
+		t.Errorf("ValidateHeader() = %+v, want a valid checksum 0x32 and logo", v)
+	}
+
+	// Damage the title and the logo, as in a bad dump
+	data[gbTitleOffset] ^= 0xFF
+	data[gbLogoOffset] ^= 0xFF
+	v, err = ValidateHeader(&mockReaderAt{data: data}, int64(len(data)))
+	if err != nil {
+		t.Fatalf("ValidateHeader() error = %v", err)
+	}
+	if v.StoredChecksum != 0x32 || v.ComputedChecksum == 0x32 || v.ChecksumValid() || v.LogoValid {
+		t.Errorf("ValidateHeader() = %+v, want an invalid checksum and logo", v)
+	}
 }
 
 func TestParseGB_FileTooSmall(t *testing.T) {
@@ -26,7 +26,7 @@ import (
 //	0xB4    1     Device type (bit 7 = debug DACS)
 //	0xB5    7     Reserved (should be zero)
 //	0xBC    1     Software version
-//	0xBD    1     Header checksum (complement check)
+//	0xBD    1     Header checksum (complement check over 0xA0-0xBC)
 //	0xBE    2     Reserved (should be zero)
 //
 // Game Code breakdown (4 bytes at 0xAC):
@@ -48,6 +48,8 @@ const (
 	gbaDeviceTypeOffset = 0xB4
 	gbaVersionOffset    = 0xBC
 	gbaChecksumOffset   = 0xBD
+	gbaChecksumStart    = 0xA0
+	gbaChecksumBias     = 0x19
 )
 
 // GameType represents the cartridge/hardware type from the first byte of the game code.
@@ -100,6 +102,9 @@ type Info struct {
 	Version int `json:"version"`
 	// HeaderChecksum is the complement check value (0xBD).
 	HeaderChecksum byte `json:"header_checksum"`
+	// HeaderChecksumValid reports whether HeaderChecksum matches the header.
+	// The BIOS refuses to boot ROMs with an invalid header checksum.
+	HeaderChecksumValid bool `json:"header_checksum_valid"`
 }
 
 // GameRegions implements core.GameInfo.
@@ -178,14 +183,25 @@ func Parse(r io.ReaderAt, size int64) (*Info, error) {
 	headerChecksum := header[gbaChecksumOffset]
 
 	return &Info{
-		Title:          title,
-		GameCode:       gameCode,
-		GameType:       gameType,
-		Destination:    destination,
-		MakerCode:      makerCode,
-		MainUnitCode:   mainUnitCode,
-		DeviceType:     deviceType,
-		Version:        version,
-		HeaderChecksum: headerChecksum,
+		Title:               title,
+		GameCode:            gameCode,
+		GameType:            gameType,
+		Destination:         destination,
+		MakerCode:           makerCode,
+		MainUnitCode:        mainUnitCode,
+		DeviceType:          deviceType,
+		Version:             version,
+		HeaderChecksum:      headerChecksum,
+		HeaderChecksumValid: computeHeaderChecksum(header) == headerChecksum,
 	}, nil
 }
+
+// computeHeaderChecksum computes the complement check of header bytes
+// 0xA0-0xBC: the negated sum minus 0x19.
+func computeHeaderChecksum(header []byte) byte {
+	var sum byte
+	for _, b := range header[gbaChecksumStart:gbaChecksumOffset] {
+		sum += b
+	}
+	return -(sum + gbaChecksumBias)
+}
@@ -60,6 +60,32 @@ func TestParse(t *testing.T) {
 	if info.HeaderChecksum != 0xC8 {
 		t.Errorf("Expected header checksum 0xC8, got 0x%02X", info.HeaderChecksum)
 	}
+
+	if !info.HeaderChecksumValid {
+		t.Error("Expected header checksum to be valid")
+	}
+}
+
+func TestParseHeaderChecksum(t *testing.T) {
+	header := makeSyntheticGBA("CHECKSUM", "BCSE", "01", 0x00, 0x00, 0, 0)
+	header[gbaChecksumOffset] = computeHeaderChecksum(header)
+
+	info, err := Parse(bytes.NewReader(header), int64(len(header)))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if !info.HeaderChecksumValid {
+		t.Errorf("HeaderChecksumValid = false for checksum 0x%02X", info.HeaderChecksum)
+	}
+
+	header[gbaChecksumOffset]++
+	info, err = Parse(bytes.NewReader(header), int64(len(header)))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if info.HeaderChecksumValid {
+		t.Error("HeaderChecksumValid = true for a corrupted checksum")
+	}
 }
 
 // makeSyntheticGBA creates a minimal valid GBA ROM header for testing.
@@ -0,0 +1,152 @@
+package gcm
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+
+	"golang.org/x/text/encoding/charmap"
+
+	"github.com/sargunv/rom-tools/internal/util"
+)
+
+// GameCube banner (opening.bnr) parsing.
+//
+// The banner in the disc's root directory holds the icon and the text shown
+// in the GameCube menu. BNR1 banners have one set of text; BNR2 banners
+// (PAL discs) have one per language, in the order English, German, French,
+// Spanish, Italian, Dutch.
+//
+// Banner layout:
+//
+//	Offset  Size    Description
+//	0x0000  4       Magic ("BNR1" or "BNR2")
+//	0x0020  0x1800  96x32 RGB5A3 image
+//	0x1820  0x140   Text, repeated for each language:
+//	                  0x00  0x20  Short title
+//	                  0x20  0x20  Short maker
+//	                  0x40  0x40  Long title
+//	                  0x80  0x40  Long maker
+//	                  0xC0  0x80  Description
+//
+// Text is Shift-JIS on Japanese discs and Windows-1252 elsewhere.
+//
+// Documentation: https://www.gc-forever.com/yagcd/chap14.html
+
+const (
+	// BannerFile is the name of the banner file in the disc's root directory.
+	BannerFile = "opening.bnr"
+
+	bnrMagic1         = "BNR1"
+	bnrMagic2         = "BNR2"
+	bnrTextOffset     = 0x1820
+	bnrTextSize       = 0x140
+	bnrShortTitleOff  = 0x00
+	bnrShortMakerOff  = 0x20
+	bnrShortLen       = 0x20
+	bnrLongTitleOff   = 0x40
+	bnrLongMakerOff   = 0x80
+	bnrLongLen        = 0x40
+	bnrDescriptionOff = 0xC0
+	bnrDescriptionLen = 0x80
+	bnrLanguagesBNR2  = 6
+	bnrSizeBNR1       = bnrTextOffset + bnrTextSize
+	bnrSizeBNR2       = bnrTextOffset + bnrLanguagesBNR2*bnrTextSize
+)
+
+// Language is the language of a banner's text.
+type Language string
+
+const (
+	LanguageDefault Language = ""   // BNR1 banners, in the disc's region language
+	LanguageEnglish Language = "en" // BNR2
+	LanguageGerman  Language = "de" // BNR2
+	LanguageFrench  Language = "fr" // BNR2
+	LanguageSpanish Language = "es" // BNR2
+	LanguageItalian Language = "it" // BNR2
+	LanguageDutch   Language = "nl" // BNR2
+)
+
+// bnr2Languages is the order of BNR2 text.
+var bnr2Languages = []Language{
+	LanguageEnglish, LanguageGerman, LanguageFrench, LanguageSpanish, LanguageItalian, LanguageDutch,
+}
+
+// BannerText is a banner's text in one language.
+type BannerText struct {
+	Language    Language `json:"language,omitempty"`
+	ShortTitle  string   `json:"short_title,omitempty"`
+	ShortMaker  string   `json:"short_maker,omitempty"`
+	LongTitle   string   `json:"long_title,omitempty"`
+	LongMaker   string   `json:"long_maker,omitempty"`
+	Description string   `json:"description,omitempty"`
+}
+
+// Banner contains the text of a GameCube banner.
+type Banner struct {
+	// Texts holds one entry for BNR1 banners, or one per language for BNR2.
+	Texts []BannerText `json:"texts"`
+}
+
+// ParseBanner parses a GameCube banner. region is the disc's region code,
+// which determines the text encoding.
+func ParseBanner(r io.ReaderAt, size int64, region Region) (*Banner, error) {
+	if size < bnrSizeBNR1 {
+		return nil, fmt.Errorf("file too small for GameCube banner: %d bytes", size)
+	}
+
+	data := make([]byte, min(size, bnrSizeBNR2))
+	if _, err := r.ReadAt(data, 0); err != nil {
+		return nil, fmt.Errorf("failed to read GameCube banner: %w", err)
+	}
+
+	languages := []Language{LanguageDefault}
+	switch magic := string(data[:4]); magic {
+	case bnrMagic1:
+	case bnrMagic2:
+		if size < bnrSizeBNR2 {
+			return nil, fmt.Errorf("file too small for BNR2 banner: %d bytes", size)
+		}
+		languages = bnr2Languages
+	default:
+		return nil, fmt.Errorf("not a valid GameCube banner: magic is %q", magic)
+	}
+
+	decode := decodeWindows1252
+	if region == RegionJapan {
+		decode = util.ExtractShiftJIS
+	}
+
+	banner := &Banner{}
+	for i, language := range languages {
+		text := data[bnrTextOffset+i*bnrTextSize:]
+		banner.Texts = append(banner.Texts, BannerText{
+			Language:    language,
+			ShortTitle:  decode(text[bnrShortTitleOff : bnrShortTitleOff+bnrShortLen]),
+			ShortMaker:  decode(text[bnrShortMakerOff : bnrShortMakerOff+bnrShortLen]),
+			LongTitle:   decode(text[bnrLongTitleOff : bnrLongTitleOff+bnrLongLen]),
+			LongMaker:   decode(text[bnrLongMakerOff : bnrLongMakerOff+bnrLongLen]),
+			Description: decode(text[bnrDescriptionOff : bnrDescriptionOff+bnrDescriptionLen]),
+		})
+	}
+	return banner, nil
+}
+
+// Banner reads and parses the disc's opening.bnr.
+func (f *FS) Banner() (*Banner, error) {
+	data, err := fs.ReadFile(f, BannerFile)
+	if err != nil {
+		return nil, err
+	}
+	return ParseBanner(bytes.NewReader(data), int64(len(data)), f.region)
+}
+
+// decodeWindows1252 extracts a null-terminated Windows-1252 string.
+func decodeWindows1252(data []byte) string {
+	decoded, err := charmap.Windows1252.NewDecoder().Bytes(data)
+	if err != nil {
+		return util.ExtractASCII(data)
+	}
+	return util.ExtractASCII(decoded)
+}
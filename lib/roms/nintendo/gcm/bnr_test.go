@@ -0,0 +1,110 @@
+package gcm
+
+import (
+	"bytes"
+	"testing"
+)
+
+// makeBanner creates a banner whose texts use title as the long title, with
+// the language index appended for BNR2 banners.
+func makeBanner(magic, title string) []byte {
+	languages := 1
+	if magic == bnrMagic2 {
+		languages = bnrLanguagesBNR2
+	}
+	banner := make([]byte, bnrTextOffset+languages*bnrTextSize)
+	copy(banner, magic)
+	for i := range languages {
+		text := banner[bnrTextOffset+i*bnrTextSize:]
+		copy(text[bnrShortTitleOff:], "Short")
+		copy(text[bnrShortMakerOff:], "Maker")
+		if languages > 1 {
+			copy(text[bnrLongTitleOff:], title+" "+string(rune('0'+i)))
+		} else {
+			copy(text[bnrLongTitleOff:], title)
+		}
+		copy(text[bnrLongMakerOff:], "Long Maker")
+		copy(text[bnrDescriptionOff:], "Description")
+	}
+	return banner
+}
+
+func TestParseBanner_BNR1(t *testing.T) {
+	data := makeBanner(bnrMagic1, "Caf\xe9")
+	banner, err := ParseBanner(bytes.NewReader(data), int64(len(data)), RegionNorthAmerica)
+	if err != nil {
+		t.Fatalf("ParseBanner() error = %v", err)
+	}
+	if len(banner.Texts) != 1 {
+		t.Fatalf("len(Texts) = %d, want 1", len(banner.Texts))
+	}
+	text := banner.Texts[0]
+	if text.LongTitle != "Café" {
+		t.Errorf("LongTitle = %q, want %q", text.LongTitle, "Café")
+	}
+	if text.ShortTitle != "Short" || text.ShortMaker != "Maker" || text.LongMaker != "Long Maker" || text.Description != "Description" {
+		t.Errorf("Texts[0] = %+v", text)
+	}
+}
+
+func TestParseBanner_BNR2(t *testing.T) {
+	data := makeBanner(bnrMagic2, "Title")
+	banner, err := ParseBanner(bytes.NewReader(data), int64(len(data)), RegionEurope)
+	if err != nil {
+		t.Fatalf("ParseBanner() error = %v", err)
+	}
+	if len(banner.Texts) != bnrLanguagesBNR2 {
+		t.Fatalf("len(Texts) = %d, want %d", len(banner.Texts), bnrLanguagesBNR2)
+	}
+	if banner.Texts[2].Language != LanguageFrench || banner.Texts[2].LongTitle != "Title 2" {
+		t.Errorf("Texts[2] = %+v, want French text %q", banner.Texts[2], "Title 2")
+	}
+}
+
+func TestParseBanner_ShiftJIS(t *testing.T) {
+	// "ゲーム" in Shift-JIS
+	data := makeBanner(bnrMagic1, "\x83\x51\x81\x5b\x83\x80")
+	banner, err := ParseBanner(bytes.NewReader(data), int64(len(data)), RegionJapan)
+	if err != nil {
+		t.Fatalf("ParseBanner() error = %v", err)
+	}
+	if banner.Texts[0].LongTitle != "ゲーム" {
+		t.Errorf("LongTitle = %q, want %q", banner.Texts[0].LongTitle, "ゲーム")
+	}
+}
+
+func TestParseBanner_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"too small", makeBanner(bnrMagic1, "Title")[:0x100]},
+		{"bad magic", makeBanner("BNR3", "Title")},
+		{"truncated BNR2", makeBanner(bnrMagic2, "Title")[:bnrSizeBNR1]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseBanner(bytes.NewReader(tt.data), int64(len(tt.data)), RegionNorthAmerica); err == nil {
+				t.Error("ParseBanner() expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestFS_Banner(t *testing.T) {
+	disc := makeSyntheticFSTDisc(RegionNorthAmerica, []testFSTFile{
+		{"", "opening.bnr", makeBanner(bnrMagic1, "Disc Title")},
+	})
+	fsys, err := NewFS(bytes.NewReader(disc), int64(len(disc)))
+	if err != nil {
+		t.Fatalf("NewFS() error = %v", err)
+	}
+	banner, err := fsys.Banner()
+	if err != nil {
+		t.Fatalf("Banner() error = %v", err)
+	}
+	if banner.Texts[0].LongTitle != "Disc Title" {
+		t.Errorf("LongTitle = %q, want %q", banner.Texts[0].LongTitle, "Disc Title")
+	}
+}
@@ -0,0 +1,292 @@
+package gcm
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sargunv/rom-tools/lib/core"
+)
+
+// GameCube disc filesystem (FST) reading.
+//
+// The FST (file system table) lists every file on the disc. Its location is
+// in the disc header:
+//
+//	Offset  Size  Description
+//	0x424   4     FST offset
+//	0x428   4     FST size
+//
+// The FST is an array of 12-byte entries followed by a string table of
+// null-terminated names. Entry 0 is the root directory, whose "next" field
+// is the total number of entries.
+//
+//	Offset  Size  Description
+//	0x00    1     Type (0 = file, 1 = directory)
+//	0x01    3     Name offset in the string table
+//	0x04    4     File: data offset; directory: parent entry index
+//	0x08    4     File: data size; directory: index of the entry after its last child
+//
+// Wii discs keep their filesystem inside encrypted partitions, which aren't
+// supported.
+//
+// Documentation: https://www.gc-forever.com/yagcd/chap13.html
+
+const (
+	fstOffsetOffset = 0x424
+	fstSizeOffset   = 0x428
+	fstHeaderEnd    = 0x42C
+	fstEntrySize    = 12
+	fstMaxSize      = 64 * 1024 * 1024
+)
+
+// FS is a GameCube disc's filesystem, read from its FST. It implements
+// fs.FS and fs.ReadDirFS. Names are matched exactly, then case-insensitively.
+type FS struct {
+	r      io.ReaderAt
+	size   int64
+	region Region
+	root   *fstNode
+}
+
+// fstNode is a file or directory in the FST.
+type fstNode struct {
+	name     string
+	isDir    bool
+	offset   int64
+	size     int64
+	children []*fstNode // directories only, sorted by name
+}
+
+// NewFS reads a GameCube disc's FST.
+func NewFS(r io.ReaderAt, size int64) (*FS, error) {
+	if size < fstHeaderEnd {
+		return nil, fmt.Errorf("file too small for disc header: need %d bytes, got %d", fstHeaderEnd, size)
+	}
+
+	header := make([]byte, fstHeaderEnd)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, fmt.Errorf("failed to read disc header: %w", err)
+	}
+	info, err := parseGCMBytes(header[:discHeaderSize])
+	if err != nil {
+		return nil, err
+	}
+	if info.GamePlatform() != core.PlatformGC {
+		return nil, errors.New("not a GameCube disc: Wii filesystems are in encrypted partitions, which aren't supported")
+	}
+
+	fstOffset := int64(binary.BigEndian.Uint32(header[fstOffsetOffset:]))
+	fstSize := int64(binary.BigEndian.Uint32(header[fstSizeOffset:]))
+	if fstSize < fstEntrySize || fstSize > fstMaxSize || fstOffset+fstSize > size {
+		return nil, fmt.Errorf("not a valid GameCube FST: offset %d, size %d", fstOffset, fstSize)
+	}
+	fst := make([]byte, fstSize)
+	if _, err := r.ReadAt(fst, fstOffset); err != nil {
+		return nil, fmt.Errorf("failed to read FST: %w", err)
+	}
+
+	root, err := parseFST(fst)
+	if err != nil {
+		return nil, err
+	}
+	return &FS{r: r, size: size, region: info.Region, root: root}, nil
+}
+
+// parseFST builds the directory tree from an FST.
+func parseFST(fst []byte) (*fstNode, error) {
+	count := int(binary.BigEndian.Uint32(fst[8:]))
+	if fst[0] != 1 || count < 1 || count*fstEntrySize > len(fst) {
+		return nil, fmt.Errorf("not a valid GameCube FST: %d entries", count)
+	}
+	names := fst[count*fstEntrySize:]
+
+	root := &fstNode{isDir: true}
+	// Directories being filled, with the index after their last child
+	type openDir struct {
+		node *fstNode
+		end  int
+	}
+	stack := []openDir{{root, count}}
+
+	for i := 1; i < count; i++ {
+		for len(stack) > 1 && i >= stack[len(stack)-1].end {
+			stack = stack[:len(stack)-1]
+		}
+		parent := stack[len(stack)-1].node
+
+		entry := fst[i*fstEntrySize:]
+		nameOffset := int(binary.BigEndian.Uint32(entry) & 0xFFFFFF)
+		if nameOffset >= len(names) {
+			return nil, fmt.Errorf("not a valid GameCube FST: entry %d name offset %d", i, nameOffset)
+		}
+		name, _, _ := strings.Cut(string(names[nameOffset:]), "\x00")
+		if name == "" || name == "." || name == ".." || strings.Contains(name, "/") {
+			return nil, fmt.Errorf("not a valid GameCube FST: entry %d name %q", i, name)
+		}
+
+		node := &fstNode{name: name, isDir: entry[0] == 1}
+		a := binary.BigEndian.Uint32(entry[4:])
+		b := int(binary.BigEndian.Uint32(entry[8:]))
+		if node.isDir {
+			if b <= i || b > stack[len(stack)-1].end {
+				return nil, fmt.Errorf("not a valid GameCube FST: directory %q ends at entry %d", name, b)
+			}
+			stack = append(stack, openDir{node, b})
+		} else {
+			node.offset, node.size = int64(a), int64(b)
+		}
+		parent.children = append(parent.children, node)
+	}
+
+	sortNodes(root)
+	return root, nil
+}
+
+// sortNodes sorts each directory's children by name.
+func sortNodes(dir *fstNode) {
+	slices.SortFunc(dir.children, func(a, b *fstNode) int { return strings.Compare(a.name, b.name) })
+	for _, child := range dir.children {
+		if child.isDir {
+			sortNodes(child)
+		}
+	}
+}
+
+// Open implements fs.FS.
+func (f *FS) Open(name string) (fs.File, error) {
+	node, err := f.lookup("open", name)
+	if err != nil {
+		return nil, err
+	}
+	info := fstFileInfo{name: path.Base(name), node: node}
+	if node.isDir {
+		return &fstDir{info: info}, nil
+	}
+	if node.offset+node.size > f.size {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fmt.Errorf("file extends beyond disc: ends at %d, disc size %d", node.offset+node.size, f.size)}
+	}
+	return &fstFile{SectionReader: io.NewSectionReader(f.r, node.offset, node.size), info: info}, nil
+}
+
+// ReadDir implements fs.ReadDirFS.
+func (f *FS) ReadDir(name string) ([]fs.DirEntry, error) {
+	node, err := f.lookup("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	if !node.isDir {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: errors.New("not a directory")}
+	}
+	return dirEntries(node), nil
+}
+
+// lookup resolves an fs.FS name to its node.
+func (f *FS) lookup(op, name string) (*fstNode, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	node := f.root
+	if name == "." {
+		return node, nil
+	}
+	for part := range strings.SplitSeq(name, "/") {
+		child := findChild(node, part)
+		if child == nil {
+			return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+		}
+		node = child
+	}
+	return node, nil
+}
+
+// findChild finds a directory's child by name, preferring an exact match
+// over a case-insensitive one.
+func findChild(dir *fstNode, name string) *fstNode {
+	if !dir.isDir {
+		return nil
+	}
+	for _, child := range dir.children {
+		if child.name == name {
+			return child
+		}
+	}
+	for _, child := range dir.children {
+		if strings.EqualFold(child.name, name) {
+			return child
+		}
+	}
+	return nil
+}
+
+// dirEntries lists a directory's children as fs.DirEntry values.
+func dirEntries(dir *fstNode) []fs.DirEntry {
+	entries := make([]fs.DirEntry, len(dir.children))
+	for i, child := range dir.children {
+		entries[i] = fs.FileInfoToDirEntry(fstFileInfo{name: child.name, node: child})
+	}
+	return entries
+}
+
+// fstFileInfo implements fs.FileInfo for an FST node.
+type fstFileInfo struct {
+	name string
+	node *fstNode
+}
+
+func (fi fstFileInfo) Name() string       { return fi.name }
+func (fi fstFileInfo) Size() int64        { return fi.node.size }
+func (fi fstFileInfo) ModTime() time.Time { return time.Time{} }
+func (fi fstFileInfo) IsDir() bool        { return fi.node.isDir }
+func (fi fstFileInfo) Sys() any           { return nil }
+
+func (fi fstFileInfo) Mode() fs.FileMode {
+	if fi.node.isDir {
+		return fs.ModeDir | 0o555
+	}
+	return 0o444
+}
+
+// fstFile is an open regular file.
+type fstFile struct {
+	*io.SectionReader
+	info fstFileInfo
+}
+
+func (f *fstFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *fstFile) Close() error               { return nil }
+
+// fstDir is an open directory, implementing fs.ReadDirFile.
+type fstDir struct {
+	info   fstFileInfo
+	offset int
+}
+
+func (d *fstDir) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *fstDir) Close() error               { return nil }
+
+func (d *fstDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.name, Err: errors.New("is a directory")}
+}
+
+// ReadDir implements fs.ReadDirFile.
+func (d *fstDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	entries := dirEntries(d.info.node)
+	remaining := entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(entries)
+		return remaining, nil
+	}
+	if len(remaining) == 0 {
+		return nil, io.EOF
+	}
+	n = min(n, len(remaining))
+	d.offset += n
+	return remaining[:n], nil
+}
@@ -0,0 +1,129 @@
+package gcm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+)
+
+// testFSTFile is a file to place on a synthetic disc.
+type testFSTFile struct {
+	dir  string // "" for the root directory
+	name string
+	data []byte
+}
+
+// makeSyntheticFSTDisc creates a GameCube disc whose FST holds the given
+// files. Root files come first, then one subdirectory per distinct dir.
+func makeSyntheticFSTDisc(region Region, files []testFSTFile) []byte {
+	type entry struct {
+		isDir      bool
+		name       string
+		offA, offB uint32
+	}
+	var entries []entry
+	var data []byte
+	const fstOffset, dataOffset = 0x1000, 0x4000
+
+	addFile := func(f testFSTFile) {
+		entries = append(entries, entry{name: f.name, offA: uint32(dataOffset + len(data)), offB: uint32(len(f.data))})
+		data = append(data, f.data...)
+	}
+	entries = append(entries, entry{isDir: true})
+	for _, f := range files {
+		if f.dir == "" {
+			addFile(f)
+		}
+	}
+	var dirs []string
+	for _, f := range files {
+		if f.dir != "" && (len(dirs) == 0 || dirs[len(dirs)-1] != f.dir) {
+			dirs = append(dirs, f.dir)
+		}
+	}
+	for _, dir := range dirs {
+		dirIndex := len(entries)
+		entries = append(entries, entry{isDir: true, name: dir})
+		for _, f := range files {
+			if f.dir == dir {
+				addFile(f)
+			}
+		}
+		entries[dirIndex].offB = uint32(len(entries))
+	}
+	entries[0].offB = uint32(len(entries))
+
+	fst := make([]byte, len(entries)*fstEntrySize)
+	var names []byte
+	for i, e := range entries {
+		nameOffset := uint32(0)
+		if i > 0 {
+			nameOffset = uint32(len(names))
+			names = append(append(names, e.name...), 0)
+		}
+		binary.BigEndian.PutUint32(fst[i*fstEntrySize:], nameOffset)
+		if e.isDir {
+			fst[i*fstEntrySize] = 1
+		}
+		binary.BigEndian.PutUint32(fst[i*fstEntrySize+4:], e.offA)
+		binary.BigEndian.PutUint32(fst[i*fstEntrySize+8:], e.offB)
+	}
+	fst = append(fst, names...)
+
+	disc := make([]byte, dataOffset+len(data))
+	copy(disc, makeSyntheticGCM(SystemCodeGameCube, "MK", region, "Test GameCube Game", false))
+	binary.BigEndian.PutUint32(disc[fstOffsetOffset:], fstOffset)
+	binary.BigEndian.PutUint32(disc[fstSizeOffset:], uint32(len(fst)))
+	copy(disc[fstOffset:], fst)
+	copy(disc[dataOffset:], data)
+	return disc
+}
+
+func TestNewFS(t *testing.T) {
+	disc := makeSyntheticFSTDisc(RegionNorthAmerica, []testFSTFile{
+		{"", "boot.dol", []byte("DOL")},
+		{"", "opening.bnr", makeBanner(bnrMagic1, "Title")},
+		{"audio", "music.adp", []byte("music data")},
+		{"audio", "voice.adp", []byte("voice")},
+		{"stage", "Stage01.arc", []byte("stage")},
+	})
+
+	fsys, err := NewFS(bytes.NewReader(disc), int64(len(disc)))
+	if err != nil {
+		t.Fatalf("NewFS() error = %v", err)
+	}
+	if err := fstest.TestFS(fsys, "boot.dol", "opening.bnr", "audio/music.adp", "audio/voice.adp", "stage/Stage01.arc"); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := fs.ReadFile(fsys, "audio/music.adp")
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if string(data) != "music data" {
+		t.Errorf("ReadFile() = %q, want %q", data, "music data")
+	}
+
+	// Names fall back to a case-insensitive match
+	if _, err := fs.Stat(fsys, "STAGE/stage01.arc"); err != nil {
+		t.Errorf("Stat() with different case error = %v", err)
+	}
+}
+
+func TestNewFS_Wii(t *testing.T) {
+	header := make([]byte, fstHeaderEnd)
+	copy(header, makeSyntheticGCM(SystemCodeWii, "SB", RegionNorthAmerica, "Test Wii Game", true))
+	if _, err := NewFS(bytes.NewReader(header), int64(len(header))); err == nil {
+		t.Error("NewFS() of a Wii disc expected error, got nil")
+	}
+}
+
+func TestNewFS_InvalidFST(t *testing.T) {
+	disc := makeSyntheticFSTDisc(RegionNorthAmerica, []testFSTFile{{"", "boot.dol", []byte("DOL")}})
+	binary.BigEndian.PutUint32(disc[fstSizeOffset:], uint32(len(disc)))
+	if _, err := NewFS(bytes.NewReader(disc), int64(len(disc))); err == nil {
+		t.Error("NewFS() with an FST past the end of the disc expected error, got nil")
+	}
+}
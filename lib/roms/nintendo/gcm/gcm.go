@@ -123,6 +123,10 @@ type Info struct {
 	Version int `json:"version"`
 	// Title is the game title.
 	Title string `json:"title,omitempty"`
+	// Partitions is the Wii partition table, with each partition's ticket
+	// and TMD. It's empty for GameCube discs and for Wii images too short to
+	// hold a partition table.
+	Partitions []Partition `json:"partitions,omitempty"`
 	// platform is the target platform (GameCube or Wii) (internal, used by GamePlatform).
 	platform core.Platform
 }
@@ -138,9 +142,21 @@ func (i *Info) GameSerial() string {
 	return fmt.Sprintf("%c%s%c", i.SystemCode, i.GameCode, i.Region)
 }
 
-// GameRegions implements core.GameInfo.
+// GameRegions implements core.GameInfo. Wii discs whose region code is
+// unknown fall back to the game partition's TMD region.
 func (i *Info) GameRegions() []core.Region {
-	switch i.Region {
+	regions := i.Region.regions()
+	if len(regions) == 0 {
+		if p := i.GamePartition(); p != nil && p.TMD != nil {
+			return p.TMD.GameRegions()
+		}
+	}
+	return regions
+}
+
+// regions returns the regions for a region code.
+func (r Region) regions() []core.Region {
+	switch r {
 	case RegionJapan:
 		return []core.Region{core.RegionJapan}
 	case RegionNorthAmerica:
@@ -191,7 +207,19 @@ func Parse(r io.ReaderAt, size int64) (*Info, error) {
 		return nil, fmt.Errorf("failed to read disc header: %w", err)
 	}
 
-	return parseGCMBytes(header)
+	info, err := parseGCMBytes(header)
+	if err != nil {
+		return nil, err
+	}
+
+	// The partition table is optional: headers copied out of container
+	// formats, and scrubbed or truncated images, may not have one.
+	if info.platform == core.PlatformWii {
+		if partitions, err := ParsePartitions(r, size); err == nil {
+			info.Partitions = partitions
+		}
+	}
+	return info, nil
 }
 
 func parseGCMBytes(header []byte) (*Info, error) {
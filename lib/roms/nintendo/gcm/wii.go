@@ -0,0 +1,339 @@
+package gcm
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+
+	"github.com/sargunv/rom-tools/internal/util"
+	"github.com/sargunv/rom-tools/lib/core"
+)
+
+// Wii partition table, ticket, and TMD parsing.
+//
+// A Wii disc holds up to four groups of partitions, listed in a table at
+// 0x40000:
+//
+//	Offset   Size  Description
+//	0x40000  4     Group 0 partition count
+//	0x40004  4     Group 0 partition entries offset (>> 2)
+//	...            Groups 1-3 follow
+//
+// Each partition entry is 8 bytes: the partition offset (>> 2) and its type
+// (0 = game data, 1 = update, 2 = channel installer, otherwise a title ID).
+//
+// Each partition starts with an unencrypted header holding its ticket and
+// TMD (title metadata). Only the partition's data area is encrypted, so these
+// can be read from encrypted and decrypted images alike.
+//
+//	Offset  Size   Description
+//	0x000   0x2A4  Ticket
+//	0x2A4   4      TMD size
+//	0x2A8   4      TMD offset (>> 2)
+//	0x2B8   4      Data offset (>> 2)
+//	0x2BC   4      Data size (>> 2)
+//
+// Ticket (relevant fields):
+//
+//	Offset  Size  Description
+//	0x1BF   16    Title key (encrypted with the common key)
+//	0x1D0   8     Ticket ID
+//	0x1DC   8     Title ID
+//	0x1F1   1     Common key index (0 = standard, 1 = Korean)
+//
+// TMD (relevant fields):
+//
+//	Offset  Size  Description
+//	0x184   8     System version (IOS title ID, 00000001-000000xx)
+//	0x18C   8     Title ID
+//	0x194   4     Title type
+//	0x198   2     Group ID (maker code)
+//	0x19C   2     Region (0 = Japan, 1 = USA, 2 = Europe, 3 = region free, 4 = Korea)
+//	0x1DC   2     Title version
+//	0x1DE   2     Number of contents
+//	0x1E0   2     Boot content index
+//	0x1E4   36*n  Contents: ID (4), index (2), type (2), size (8), SHA-1 (20)
+//
+// Documentation: https://wiibrew.org/wiki/Wii_disc, https://wiibrew.org/wiki/Ticket,
+// https://wiibrew.org/wiki/Title_metadata
+
+const (
+	partitionTableOffset = 0x40000
+	partitionGroups      = 4
+	partitionEntrySize   = 8
+	maxPartitionsInGroup = 16
+
+	partitionHeaderSize   = 0x2C0
+	ticketSize            = 0x2A4
+	tmdSizeOffset         = 0x2A4
+	tmdOffsetOffset       = 0x2A8
+	dataOffsetOffset      = 0x2B8
+	dataSizeOffset        = 0x2BC
+	ticketTitleKeyOffset  = 0x1BF
+	ticketTitleKeyLen     = 16
+	ticketIDOffset        = 0x1D0
+	ticketTitleIDOffset   = 0x1DC
+	ticketCommonKeyOffset = 0x1F1
+
+	tmdHeaderSize       = 0x1E4
+	tmdSystemVersionOff = 0x184
+	tmdTitleIDOffset    = 0x18C
+	tmdTitleTypeOffset  = 0x194
+	tmdGroupIDOffset    = 0x198
+	tmdRegionOffset     = 0x19C
+	tmdTitleVersionOff  = 0x1DC
+	tmdContentCountOff  = 0x1DE
+	tmdBootIndexOffset  = 0x1E0
+	tmdContentSize      = 36
+	tmdMaxContents      = 512
+	iosTitleIDHigh      = 0x00000001
+)
+
+// PartitionType identifies the contents of a Wii partition.
+type PartitionType uint32
+
+const (
+	PartitionTypeData    PartitionType = 0 // Game data
+	PartitionTypeUpdate  PartitionType = 1 // System update
+	PartitionTypeChannel PartitionType = 2 // Channel installer
+)
+
+// TMDRegion is the region field of a TMD.
+type TMDRegion uint16
+
+const (
+	TMDRegionJapan      TMDRegion = 0
+	TMDRegionUSA        TMDRegion = 1
+	TMDRegionEurope     TMDRegion = 2
+	TMDRegionRegionFree TMDRegion = 3
+	TMDRegionKorea      TMDRegion = 4
+)
+
+// Partition is an entry in a Wii disc's partition table.
+type Partition struct {
+	// Type is the partition type. Values other than the PartitionType
+	// constants are the partition's title ID.
+	Type PartitionType `json:"type"`
+	// Offset is the partition's offset in the disc image.
+	Offset int64 `json:"offset"`
+	// DataOffset is the offset of the partition's data area, relative to Offset.
+	DataOffset int64 `json:"data_offset"`
+	// DataSize is the size of the partition's data area.
+	DataSize int64 `json:"data_size"`
+	// Ticket is the partition's ticket.
+	Ticket *Ticket `json:"ticket,omitempty"`
+	// TMD is the partition's title metadata.
+	TMD *TMD `json:"tmd,omitempty"`
+}
+
+// Ticket contains the fields of a Wii ticket.
+type Ticket struct {
+	// TitleID is the 64-bit title ID.
+	TitleID uint64 `json:"title_id"`
+	// TicketID is the ticket's unique ID.
+	TicketID uint64 `json:"ticket_id"`
+	// TitleKey is the title key, encrypted with the common key (hex).
+	TitleKey string `json:"title_key"`
+	// CommonKeyIndex selects the common key (0 = standard, 1 = Korean).
+	CommonKeyIndex int `json:"common_key_index"`
+}
+
+// TMD contains the fields of a Wii title metadata.
+type TMD struct {
+	// TitleID is the 64-bit title ID.
+	TitleID uint64 `json:"title_id"`
+	// SystemVersion is the title ID of the IOS the title runs on.
+	SystemVersion uint64 `json:"system_version"`
+	// IOS is the IOS number (e.g., 36 for IOS36), or 0 if SystemVersion
+	// isn't an IOS title ID.
+	IOS int `json:"ios,omitempty"`
+	// TitleType is the title type.
+	TitleType uint32 `json:"title_type"`
+	// GroupID is the publisher's maker code as a 16-bit value.
+	GroupID uint16 `json:"group_id"`
+	// Region is the title's region.
+	Region TMDRegion `json:"region"`
+	// TitleVersion is the title's version.
+	TitleVersion int `json:"title_version"`
+	// BootIndex is the index of the boot content.
+	BootIndex int `json:"boot_index"`
+	// Contents lists the title's contents.
+	Contents []Content `json:"contents,omitempty"`
+}
+
+// Content is a content record in a TMD.
+type Content struct {
+	ID    uint32 `json:"id"`
+	Index int    `json:"index"`
+	Type  uint16 `json:"type"`
+	Size  int64  `json:"size"`
+	// SHA1 is the hash of the decrypted content (hex).
+	SHA1 string `json:"sha1"`
+}
+
+// GameCode returns the four-character game code in the low half of the
+// title ID (e.g., "RSBE"), or "" if it isn't printable ASCII.
+func (t *TMD) GameCode() string {
+	return titleIDGameCode(t.TitleID)
+}
+
+// GameRegions returns the regions for the TMD's region field.
+func (t *TMD) GameRegions() []core.Region {
+	switch t.Region {
+	case TMDRegionJapan:
+		return []core.Region{core.RegionJapan}
+	case TMDRegionUSA:
+		return []core.Region{core.RegionUSA}
+	case TMDRegionEurope:
+		return []core.Region{core.RegionEurope}
+	case TMDRegionRegionFree:
+		return []core.Region{core.RegionWorld}
+	case TMDRegionKorea:
+		return []core.Region{core.RegionKorea}
+	default:
+		return []core.Region{}
+	}
+}
+
+// GamePartition returns the first game data partition, or nil if there is
+// none.
+func (i *Info) GamePartition() *Partition {
+	for p := range i.Partitions {
+		if i.Partitions[p].Type == PartitionTypeData {
+			return &i.Partitions[p]
+		}
+	}
+	return nil
+}
+
+// ParsePartitions reads a Wii disc's partition table, along with each
+// partition's ticket and TMD.
+func ParsePartitions(r io.ReaderAt, size int64) ([]Partition, error) {
+	if size < partitionTableOffset+partitionGroups*8 {
+		return nil, fmt.Errorf("file too small for Wii partition table: %d bytes", size)
+	}
+
+	table := make([]byte, partitionGroups*8)
+	if _, err := r.ReadAt(table, partitionTableOffset); err != nil {
+		return nil, fmt.Errorf("failed to read Wii partition table: %w", err)
+	}
+
+	var partitions []Partition
+	for group := range partitionGroups {
+		count := int(binary.BigEndian.Uint32(table[group*8:]))
+		entriesOffset := int64(binary.BigEndian.Uint32(table[group*8+4:])) << 2
+		if count == 0 {
+			continue
+		}
+		if count > maxPartitionsInGroup || entriesOffset+int64(count*partitionEntrySize) > size {
+			return nil, fmt.Errorf("not a valid Wii partition table: group %d has %d partitions at %#x", group, count, entriesOffset)
+		}
+
+		entries := make([]byte, count*partitionEntrySize)
+		if _, err := r.ReadAt(entries, entriesOffset); err != nil {
+			return nil, fmt.Errorf("failed to read Wii partition entries: %w", err)
+		}
+		for i := range count {
+			entry := entries[i*partitionEntrySize:]
+			partition, err := parsePartition(r, size,
+				int64(binary.BigEndian.Uint32(entry))<<2,
+				PartitionType(binary.BigEndian.Uint32(entry[4:])))
+			if err != nil {
+				return nil, err
+			}
+			partitions = append(partitions, *partition)
+		}
+	}
+	return partitions, nil
+}
+
+// parsePartition reads a partition header and its ticket and TMD.
+func parsePartition(r io.ReaderAt, size, offset int64, partitionType PartitionType) (*Partition, error) {
+	if offset+partitionHeaderSize > size {
+		return nil, fmt.Errorf("not a valid Wii partition: header at %#x is beyond end of disc", offset)
+	}
+
+	header := make([]byte, partitionHeaderSize)
+	if _, err := r.ReadAt(header, offset); err != nil {
+		return nil, fmt.Errorf("failed to read Wii partition header: %w", err)
+	}
+
+	partition := &Partition{
+		Type:       partitionType,
+		Offset:     offset,
+		DataOffset: int64(binary.BigEndian.Uint32(header[dataOffsetOffset:])) << 2,
+		DataSize:   int64(binary.BigEndian.Uint32(header[dataSizeOffset:])) << 2,
+		Ticket:     parseTicket(header[:ticketSize]),
+	}
+
+	tmdSize := int64(binary.BigEndian.Uint32(header[tmdSizeOffset:]))
+	tmdOffset := offset + int64(binary.BigEndian.Uint32(header[tmdOffsetOffset:]))<<2
+	if tmdSize < tmdHeaderSize || tmdSize > tmdHeaderSize+tmdMaxContents*tmdContentSize || tmdOffset+tmdSize > size {
+		return nil, fmt.Errorf("not a valid Wii partition: TMD at %#x has size %d", tmdOffset, tmdSize)
+	}
+	tmd := make([]byte, tmdSize)
+	if _, err := r.ReadAt(tmd, tmdOffset); err != nil {
+		return nil, fmt.Errorf("failed to read Wii TMD: %w", err)
+	}
+	partition.TMD = parseTMD(tmd)
+
+	return partition, nil
+}
+
+// parseTicket extracts the fields of a ticket.
+func parseTicket(ticket []byte) *Ticket {
+	return &Ticket{
+		TitleID:        binary.BigEndian.Uint64(ticket[ticketTitleIDOffset:]),
+		TicketID:       binary.BigEndian.Uint64(ticket[ticketIDOffset:]),
+		TitleKey:       hex.EncodeToString(ticket[ticketTitleKeyOffset : ticketTitleKeyOffset+ticketTitleKeyLen]),
+		CommonKeyIndex: int(ticket[ticketCommonKeyOffset]),
+	}
+}
+
+// parseTMD extracts the fields of a TMD. Content records past the end of the
+// data are ignored.
+func parseTMD(tmd []byte) *TMD {
+	info := &TMD{
+		TitleID:       binary.BigEndian.Uint64(tmd[tmdTitleIDOffset:]),
+		SystemVersion: binary.BigEndian.Uint64(tmd[tmdSystemVersionOff:]),
+		TitleType:     binary.BigEndian.Uint32(tmd[tmdTitleTypeOffset:]),
+		GroupID:       binary.BigEndian.Uint16(tmd[tmdGroupIDOffset:]),
+		Region:        TMDRegion(binary.BigEndian.Uint16(tmd[tmdRegionOffset:])),
+		TitleVersion:  int(binary.BigEndian.Uint16(tmd[tmdTitleVersionOff:])),
+		BootIndex:     int(binary.BigEndian.Uint16(tmd[tmdBootIndexOffset:])),
+	}
+	if info.SystemVersion>>32 == iosTitleIDHigh {
+		info.IOS = int(uint32(info.SystemVersion))
+	}
+
+	count := int(binary.BigEndian.Uint16(tmd[tmdContentCountOff:]))
+	for i := range count {
+		start := tmdHeaderSize + i*tmdContentSize
+		if start+tmdContentSize > len(tmd) {
+			break
+		}
+		content := tmd[start : start+tmdContentSize]
+		info.Contents = append(info.Contents, Content{
+			ID:    binary.BigEndian.Uint32(content),
+			Index: int(binary.BigEndian.Uint16(content[4:])),
+			Type:  binary.BigEndian.Uint16(content[6:]),
+			Size:  int64(binary.BigEndian.Uint64(content[8:])),
+			SHA1:  hex.EncodeToString(content[16:36]),
+		})
+	}
+	return info
+}
+
+// titleIDGameCode returns the low half of a title ID as a four-character
+// code, or "" if it isn't printable ASCII.
+func titleIDGameCode(titleID uint64) string {
+	code := make([]byte, 4)
+	binary.BigEndian.PutUint32(code, uint32(titleID))
+	for _, c := range code {
+		if c < 0x20 || c > 0x7E {
+			return ""
+		}
+	}
+	return util.ExtractASCII(code)
+}
@@ -0,0 +1,127 @@
+package gcm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"slices"
+	"testing"
+
+	"github.com/sargunv/rom-tools/lib/core"
+)
+
+// makeSyntheticWiiDisc creates a Wii disc with a game partition and a channel
+// installer partition, each with a ticket and a one-content TMD.
+func makeSyntheticWiiDisc(headerRegion Region, tmdRegion TMDRegion) []byte {
+	const (
+		entriesOffset = 0x40020
+		gameOffset    = 0x50000
+		channelOffset = 0x60000
+		tmdOffset     = 0x2C0
+	)
+	disc := make([]byte, 0x70000)
+	copy(disc, makeSyntheticGCM(SystemCodeWii, "SB", headerRegion, "Test Wii Game", true))
+
+	binary.BigEndian.PutUint32(disc[partitionTableOffset:], 2)
+	binary.BigEndian.PutUint32(disc[partitionTableOffset+4:], entriesOffset>>2)
+	binary.BigEndian.PutUint32(disc[entriesOffset:], gameOffset>>2)
+	binary.BigEndian.PutUint32(disc[entriesOffset+4:], uint32(PartitionTypeData))
+	binary.BigEndian.PutUint32(disc[entriesOffset+8:], channelOffset>>2)
+	binary.BigEndian.PutUint32(disc[entriesOffset+12:], uint32(PartitionTypeChannel))
+
+	for _, p := range []struct {
+		offset  int
+		titleID uint64
+	}{
+		{gameOffset, 0x00010000_5253424A},    // RSBJ
+		{channelOffset, 0x00010001_48414441}, // HADA
+	} {
+		partition := disc[p.offset:]
+		binary.BigEndian.PutUint64(partition[ticketTitleIDOffset:], p.titleID)
+		binary.BigEndian.PutUint32(partition[tmdSizeOffset:], tmdHeaderSize+tmdContentSize)
+		binary.BigEndian.PutUint32(partition[tmdOffsetOffset:], tmdOffset>>2)
+		binary.BigEndian.PutUint32(partition[dataOffsetOffset:], 0x20000>>2)
+		binary.BigEndian.PutUint32(partition[dataSizeOffset:], 0x1000>>2)
+
+		tmd := partition[tmdOffset:]
+		binary.BigEndian.PutUint64(tmd[tmdSystemVersionOff:], 0x00000001_00000024) // IOS36
+		binary.BigEndian.PutUint64(tmd[tmdTitleIDOffset:], p.titleID)
+		binary.BigEndian.PutUint16(tmd[tmdRegionOffset:], uint16(tmdRegion))
+		binary.BigEndian.PutUint16(tmd[tmdTitleVersionOff:], 2)
+		binary.BigEndian.PutUint16(tmd[tmdContentCountOff:], 1)
+		content := tmd[tmdHeaderSize:]
+		binary.BigEndian.PutUint32(content, 0x0000000A)
+		binary.BigEndian.PutUint64(content[8:], 0x8000)
+		content[16] = 0xAB
+	}
+	return disc
+}
+
+func TestParse_WiiPartitions(t *testing.T) {
+	disc := makeSyntheticWiiDisc(RegionJapan, TMDRegionJapan)
+	info, err := Parse(bytes.NewReader(disc), int64(len(disc)))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(info.Partitions) != 2 {
+		t.Fatalf("len(Partitions) = %d, want 2", len(info.Partitions))
+	}
+
+	game := info.GamePartition()
+	if game == nil {
+		t.Fatal("GamePartition() = nil")
+	}
+	if game.Offset != 0x50000 || game.DataOffset != 0x20000 || game.DataSize != 0x1000 {
+		t.Errorf("game partition = offset %#x, data %#x+%#x", game.Offset, game.DataOffset, game.DataSize)
+	}
+	if game.Ticket.TitleID != 0x00010000_5253424A {
+		t.Errorf("Ticket.TitleID = %#x, want 0x000100005253424a", game.Ticket.TitleID)
+	}
+	if game.TMD.GameCode() != "RSBJ" {
+		t.Errorf("TMD.GameCode() = %q, want %q", game.TMD.GameCode(), "RSBJ")
+	}
+	if game.TMD.IOS != 36 {
+		t.Errorf("TMD.IOS = %d, want 36", game.TMD.IOS)
+	}
+	if game.TMD.TitleVersion != 2 {
+		t.Errorf("TMD.TitleVersion = %d, want 2", game.TMD.TitleVersion)
+	}
+	if len(game.TMD.Contents) != 1 || game.TMD.Contents[0].Size != 0x8000 || game.TMD.Contents[0].SHA1[:2] != "ab" {
+		t.Errorf("TMD.Contents = %+v", game.TMD.Contents)
+	}
+
+	channel := info.Partitions[1]
+	if channel.Type != PartitionTypeChannel || channel.TMD.GameCode() != "HADA" {
+		t.Errorf("Partitions[1] = type %d, game code %q; want channel HADA", channel.Type, channel.TMD.GameCode())
+	}
+}
+
+func TestParse_WiiRegionFromTMD(t *testing.T) {
+	// An unknown header region falls back to the game partition's TMD
+	disc := makeSyntheticWiiDisc(Region('?'), TMDRegionEurope)
+	info, err := Parse(bytes.NewReader(disc), int64(len(disc)))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if regions := info.GameRegions(); !slices.Equal(regions, []core.Region{core.RegionEurope}) {
+		t.Errorf("GameRegions() = %v, want [Europe]", regions)
+	}
+}
+
+func TestParse_WiiWithoutPartitions(t *testing.T) {
+	header := makeSyntheticGCM(SystemCodeWii, "SB", RegionNorthAmerica, "Test Wii Game", true)
+	info, err := Parse(bytes.NewReader(header), int64(len(header)))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if info.Partitions != nil {
+		t.Errorf("Partitions = %v, want nil", info.Partitions)
+	}
+}
+
+func TestParsePartitions_InvalidTMD(t *testing.T) {
+	disc := makeSyntheticWiiDisc(RegionJapan, TMDRegionJapan)
+	binary.BigEndian.PutUint32(disc[0x50000+tmdSizeOffset:], 0x10)
+	if _, err := ParsePartitions(bytes.NewReader(disc), int64(len(disc))); err == nil {
+		t.Error("ParsePartitions() with a short TMD expected error, got nil")
+	}
+}
@@ -0,0 +1,234 @@
+package gcz
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/sargunv/rom-tools/lib/core"
+	"github.com/sargunv/rom-tools/lib/roms/nintendo/gcm"
+)
+
+// GCZ (Dolphin compressed disc image) file parsing.
+//
+// The disc is split into fixed-size blocks, each stored zlib-compressed or,
+// if compression didn't help, uncompressed.
+//
+// Format reference: Dolphin (Source/Core/DiscIO/CompressedBlob.h)
+//
+// Header layout (32 bytes, little-endian):
+//
+//	Offset  Size  Description
+//	0x00    4     Magic (0xB10BC001)
+//	0x04    4     Sub type
+//	0x08    8     Compressed data size
+//	0x10    8     Uncompressed data size
+//	0x18    4     Block size
+//	0x1C    4     Number of blocks
+//
+// The header is followed by a uint64 offset for each block (bit 63 set =
+// stored uncompressed), then a uint32 Adler-32 checksum of each stored block,
+// then the block data. Offsets are relative to the start of the block data,
+// and each block ends where the next begins.
+
+const (
+	gczMagic = 0xB10BC001
+
+	headerSize         = 0x20
+	compressedSizeOff  = 0x08
+	dataSizeOffset     = 0x10
+	blockSizeOffset    = 0x18
+	numBlocksOffset    = 0x1C
+	blockPointerSize   = 8
+	blockHashSize      = 4
+	uncompressedFlag   = 1 << 63
+	maxBlockSize       = 1 << 24
+	maxCompressedBlock = maxBlockSize * 2
+)
+
+// Info contains metadata extracted from a GCZ file.
+type Info struct {
+	// GCM contains the game identification info parsed from the disc header.
+	GCM *gcm.Info `json:"gcm,omitempty"`
+	// BlockSize is the disc block size in bytes.
+	BlockSize int64 `json:"block_size"`
+	// DataSize is the size of the disc image in bytes.
+	DataSize int64 `json:"data_size"`
+}
+
+// GamePlatform implements core.GameInfo by delegating to GCM.
+func (i *Info) GamePlatform() core.Platform { return i.GCM.GamePlatform() }
+
+// GameTitle implements core.GameInfo by delegating to GCM.
+func (i *Info) GameTitle() string { return i.GCM.GameTitle() }
+
+// GameSerial implements core.GameInfo by delegating to GCM.
+func (i *Info) GameSerial() string { return i.GCM.GameSerial() }
+
+// GameRegions implements core.GameInfo by delegating to GCM.
+func (i *Info) GameRegions() []core.Region { return i.GCM.GameRegions() }
+
+// Reader provides random access to the disc image in a GCZ file.
+type Reader struct {
+	r              io.ReaderAt
+	blockSize      int64
+	dataSize       int64
+	dataOffset     int64
+	compressedSize int64
+	pointers       []uint64
+
+	// The most recently decompressed block, since reads are usually sequential
+	cacheMu    sync.Mutex
+	cacheBlock int64
+	cacheData  []byte
+}
+
+// NewReader opens a GCZ file.
+func NewReader(r io.ReaderAt, size int64) (*Reader, error) {
+	if size < headerSize {
+		return nil, fmt.Errorf("file too small for GCZ header: %d bytes", size)
+	}
+
+	header := make([]byte, headerSize)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, fmt.Errorf("failed to read GCZ header: %w", err)
+	}
+	if binary.LittleEndian.Uint32(header) != gczMagic {
+		return nil, fmt.Errorf("not a valid GCZ file: invalid magic")
+	}
+
+	compressedSize := int64(binary.LittleEndian.Uint64(header[compressedSizeOff:]))
+	dataSize := int64(binary.LittleEndian.Uint64(header[dataSizeOffset:]))
+	blockSize := int64(binary.LittleEndian.Uint32(header[blockSizeOffset:]))
+	numBlocks := int64(binary.LittleEndian.Uint32(header[numBlocksOffset:]))
+
+	if blockSize == 0 || blockSize > maxBlockSize {
+		return nil, fmt.Errorf("invalid GCZ block size: %d", blockSize)
+	}
+	if dataSize < 0 || (dataSize+blockSize-1)/blockSize != numBlocks {
+		return nil, fmt.Errorf("invalid GCZ data size: %d bytes in %d blocks", dataSize, numBlocks)
+	}
+
+	dataOffset := headerSize + numBlocks*(blockPointerSize+blockHashSize)
+	if compressedSize < 0 || dataOffset+compressedSize > size {
+		return nil, fmt.Errorf("GCZ data extends beyond file: %d blocks, file size %d", numBlocks, size)
+	}
+
+	table := make([]byte, numBlocks*blockPointerSize)
+	if _, err := r.ReadAt(table, headerSize); err != nil {
+		return nil, fmt.Errorf("failed to read GCZ block pointers: %w", err)
+	}
+	pointers := make([]uint64, numBlocks)
+	for i := range pointers {
+		pointers[i] = binary.LittleEndian.Uint64(table[i*blockPointerSize:])
+	}
+
+	return &Reader{
+		r:              r,
+		blockSize:      blockSize,
+		dataSize:       dataSize,
+		dataOffset:     dataOffset,
+		compressedSize: compressedSize,
+		pointers:       pointers,
+		cacheBlock:     -1,
+	}, nil
+}
+
+// Size returns the size of the disc image.
+func (g *Reader) Size() int64 {
+	return g.dataSize
+}
+
+// ReadAt implements io.ReaderAt, reading from the disc image.
+func (g *Reader) ReadAt(p []byte, off int64) (n int, err error) {
+	if off < 0 {
+		return 0, fmt.Errorf("negative offset")
+	}
+
+	for n < len(p) && off < g.dataSize {
+		block := off / g.blockSize
+		data, err := g.readBlock(block)
+		if err != nil {
+			return n, fmt.Errorf("read block %d: %w", block, err)
+		}
+
+		copied := copy(p[n:], data[off%g.blockSize:])
+		n += copied
+		off += int64(copied)
+	}
+
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// readBlock reads and decompresses a single block.
+func (g *Reader) readBlock(block int64) ([]byte, error) {
+	g.cacheMu.Lock()
+	defer g.cacheMu.Unlock()
+
+	if g.cacheBlock == block {
+		return g.cacheData, nil
+	}
+
+	want := min(g.blockSize, g.dataSize-block*g.blockSize)
+	start := int64(g.pointers[block] &^ uncompressedFlag)
+	end := g.compressedSize
+	if block+1 < int64(len(g.pointers)) {
+		end = int64(g.pointers[block+1] &^ uncompressedFlag)
+	}
+	if end < start || end-start > maxCompressedBlock {
+		return nil, fmt.Errorf("invalid block pointer")
+	}
+
+	raw := make([]byte, end-start)
+	if _, err := g.r.ReadAt(raw, g.dataOffset+start); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to read compressed data: %w", err)
+	}
+
+	var data []byte
+	if g.pointers[block]&uncompressedFlag != 0 {
+		if int64(len(raw)) < want {
+			return nil, fmt.Errorf("uncompressed block truncated: %d bytes", len(raw))
+		}
+		data = raw[:want]
+	} else {
+		zr, err := zlib.NewReader(bytes.NewReader(raw))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress: %w", err)
+		}
+		data = make([]byte, want)
+		_, err = io.ReadFull(zr, data)
+		zr.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress: %w", err)
+		}
+	}
+
+	g.cacheBlock = block
+	g.cacheData = data
+	return data, nil
+}
+
+// Parse extracts game information from a GCZ file.
+func Parse(r io.ReaderAt, size int64) (*Info, error) {
+	reader, err := NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	gcmInfo, err := gcm.Parse(reader, reader.Size())
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse disc header from GCZ: %w", err)
+	}
+
+	return &Info{
+		GCM:       gcmInfo,
+		BlockSize: reader.blockSize,
+		DataSize:  reader.dataSize,
+	}, nil
+}
@@ -0,0 +1,148 @@
+package gcz
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"io"
+	"testing"
+
+	"github.com/sargunv/rom-tools/lib/core"
+)
+
+const testBlockSize = 0x4000
+
+// makeTestDisc creates a GameCube disc image with a recognizable pattern
+// after the disc header.
+func makeTestDisc(size int) []byte {
+	disc := make([]byte, size)
+	for i := range disc {
+		disc[i] = byte(i / 5)
+	}
+	header := disc[:0x440]
+	clear(header)
+	copy(header, "GALE01")
+	binary.BigEndian.PutUint32(header[0x1C:], 0xC2339F3D) // GameCube magic
+	copy(header[0x20:], "SUPER SMASH BROS MELEE")
+	return disc
+}
+
+// makeTestGCZ compresses disc into a GCZ file. Blocks in stored are written
+// uncompressed; the rest are zlib compressed.
+func makeTestGCZ(t *testing.T, disc []byte, stored map[int]bool) []byte {
+	t.Helper()
+
+	numBlocks := (len(disc) + testBlockSize - 1) / testBlockSize
+	pointers := make([]uint64, numBlocks)
+	hashes := make([]uint32, numBlocks)
+	var body bytes.Buffer
+	for i := range numBlocks {
+		block := disc[i*testBlockSize : min((i+1)*testBlockSize, len(disc))]
+		pointers[i] = uint64(body.Len())
+		if stored[i] {
+			pointers[i] |= uncompressedFlag
+			body.Write(block)
+			continue
+		}
+		zw := zlib.NewWriter(&body)
+		if _, err := zw.Write(block); err != nil {
+			t.Fatalf("zlib Write() error = %v", err)
+		}
+		zw.Close()
+	}
+
+	header := make([]byte, headerSize)
+	binary.LittleEndian.PutUint32(header, gczMagic)
+	binary.LittleEndian.PutUint64(header[compressedSizeOff:], uint64(body.Len()))
+	binary.LittleEndian.PutUint64(header[dataSizeOffset:], uint64(len(disc)))
+	binary.LittleEndian.PutUint32(header[blockSizeOffset:], testBlockSize)
+	binary.LittleEndian.PutUint32(header[numBlocksOffset:], uint32(numBlocks))
+
+	out := bytes.NewBuffer(header)
+	binary.Write(out, binary.LittleEndian, pointers)
+	binary.Write(out, binary.LittleEndian, hashes)
+	out.Write(body.Bytes())
+	return out.Bytes()
+}
+
+func TestParse(t *testing.T) {
+	disc := makeTestDisc(testBlockSize*3 + 100)
+	data := makeTestGCZ(t, disc, nil)
+
+	info, err := Parse(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if info.BlockSize != testBlockSize {
+		t.Errorf("BlockSize = %d, want %d", info.BlockSize, testBlockSize)
+	}
+	if info.DataSize != int64(len(disc)) {
+		t.Errorf("DataSize = %d, want %d", info.DataSize, len(disc))
+	}
+	if got := info.GamePlatform(); got != core.PlatformGC {
+		t.Errorf("GamePlatform() = %v, want %v", got, core.PlatformGC)
+	}
+	if got := info.GameTitle(); got != "SUPER SMASH BROS MELEE" {
+		t.Errorf("GameTitle() = %q, want %q", got, "SUPER SMASH BROS MELEE")
+	}
+	if got := info.GameSerial(); got != "GALE" {
+		t.Errorf("GameSerial() = %q, want %q", got, "GALE")
+	}
+}
+
+func TestReader_ReadAt(t *testing.T) {
+	disc := makeTestDisc(testBlockSize*3 + 100)
+	data := makeTestGCZ(t, disc, map[int]bool{1: true, 3: true})
+
+	reader, err := NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("NewReader() error = %v", err)
+	}
+	if reader.Size() != int64(len(disc)) {
+		t.Errorf("Size() = %d, want %d", reader.Size(), len(disc))
+	}
+
+	got, err := io.ReadAll(io.NewSectionReader(reader, 0, reader.Size()))
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+	if !bytes.Equal(got, disc) {
+		t.Error("decompressed data does not match original")
+	}
+
+	// Read past the end
+	buf := make([]byte, 200)
+	n, err := reader.ReadAt(buf, int64(len(disc))-50)
+	if n != 50 || err != io.EOF {
+		t.Errorf("ReadAt() = %d, %v, want 50, io.EOF", n, err)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	valid := makeTestGCZ(t, makeTestDisc(testBlockSize), nil)
+
+	badMagic := bytes.Clone(valid)
+	badMagic[0] = 0
+
+	badBlocks := bytes.Clone(valid)
+	binary.LittleEndian.PutUint32(badBlocks[numBlocksOffset:], 1000)
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"too small", make([]byte, 8)},
+		{"invalid magic", badMagic},
+		{"block count mismatch", badBlocks},
+		{"truncated", valid[:len(valid)-10]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(bytes.NewReader(tt.data), int64(len(tt.data))); err == nil {
+				t.Error("Parse() expected error, got nil")
+			}
+		})
+	}
+}
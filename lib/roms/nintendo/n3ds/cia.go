@@ -0,0 +1,267 @@
+package n3ds
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/sargunv/rom-tools/lib/core"
+)
+
+// Nintendo 3DS CIA (CTR Importable Archive) format parsing.
+//
+// https://www.3dbrew.org/wiki/CIA
+// https://www.3dbrew.org/wiki/Title_metadata
+//
+// A CIA is a sequence of sections, each aligned to 64 bytes:
+// header, certificate chain, ticket, TMD (title metadata), content, meta.
+//
+// CIA header layout (0x2020 bytes, little-endian):
+//
+//	Offset  Size    Description
+//	0x00    4       Header size (0x2020)
+//	0x04    2       Type
+//	0x06    2       Version
+//	0x08    4       Certificate chain size
+//	0x0C    4       Ticket size
+//	0x10    4       TMD size
+//	0x14    4       Meta size
+//	0x18    8       Content size
+//	0x20    0x2000  Content index (bitfield of contents present)
+//
+// TMD layout (big-endian), after a signature whose size depends on its type:
+//
+//	Offset  Size  Description
+//	0x00    4     Signature type
+//	0x04    var   Signature + padding (see ciaSignatureSize)
+//
+// TMD header (0xC4 bytes, following the signature):
+//
+//	Offset  Size  Description
+//	0x00    64    Signature issuer
+//	0x40    1     Version
+//	0x44    8     System version
+//	0x4C    8     Title ID
+//	0x54    4     Title type
+//	0x58    2     Group ID
+//	0x5A    4     Save data size (little-endian)
+//	0x9C    2     Title version
+//	0x9E    2     Content count
+//	0xA0    2     Boot content index
+//	0xA4    32    SHA-256 of content info records
+//
+// The header is followed by 64 content info records (0x24 bytes each), then
+// one content chunk record (0x30 bytes) per content:
+//
+//	Offset  Size  Description
+//	0x00    4     Content ID
+//	0x04    2     Content index (0 = main NCCH, 1 = manual, 2 = DLP child)
+//	0x06    2     Content type flags (bit 0 = encrypted)
+//	0x08    8     Content size
+//	0x10    32    SHA-256 hash
+//
+// Contents are stored back to back in the content section, in record order.
+// Encrypted contents can't be read without the title key, so the NCCH fields
+// (product code, maker code) are only available for decrypted CIAs.
+
+const (
+	ciaHeaderSize       = 0x2020
+	ciaCertSizeOffset   = 0x08
+	ciaTicketSizeOffset = 0x0C
+	ciaTMDSizeOffset    = 0x10
+	ciaContentSizeOff   = 0x18
+	ciaAlignment        = 64
+
+	tmdHeaderSize            = 0xC4
+	tmdTitleIDOffset         = 0x4C
+	tmdSaveDataSizeOffset    = 0x5A
+	tmdTitleVersionOffset    = 0x9C
+	tmdContentCountOffset    = 0x9E
+	tmdContentInfoRecordSize = 0x24
+	tmdContentInfoRecords    = 64
+	tmdContentChunkSize      = 0x30
+
+	ciaContentFlagEncrypted = 0x0001
+)
+
+// ciaSignatureSize returns the size of the signature and padding that follow
+// the 4-byte signature type, per 3dbrew.
+func ciaSignatureSize(sigType uint32) (int64, error) {
+	switch sigType {
+	case 0x010000, 0x010003: // RSA-4096
+		return 0x200 + 0x3C, nil
+	case 0x010001, 0x010004: // RSA-2048
+		return 0x100 + 0x3C, nil
+	case 0x010002, 0x010005: // ECDSA
+		return 0x3C + 0x40, nil
+	default:
+		return 0, fmt.Errorf("unknown TMD signature type 0x%06X", sigType)
+	}
+}
+
+// CIAContent is a content entry from the CIA's TMD.
+type CIAContent struct {
+	// ID is the content ID.
+	ID uint32 `json:"id"`
+	// Index is the content index (0 = main NCCH, 1 = manual, 2 = download play child).
+	Index uint16 `json:"index"`
+	// Size is the content size in bytes.
+	Size int64 `json:"size"`
+	// Encrypted is true if the content is encrypted with the title key.
+	Encrypted bool `json:"encrypted"`
+	// Offset is the content offset in bytes within the CIA file.
+	Offset int64 `json:"offset"`
+}
+
+// CIAInfo contains metadata extracted from a 3DS CIA file.
+type CIAInfo struct {
+	// TitleID is the 64-bit title identifier from the TMD.
+	TitleID uint64 `json:"title_id"`
+	// TitleVersion is the title version from the TMD.
+	TitleVersion uint16 `json:"title_version"`
+	// SaveDataSize is the save data size in bytes from the TMD.
+	SaveDataSize uint32 `json:"save_data_size"`
+	// Contents lists the contents in the CIA, in TMD order.
+	Contents []CIAContent `json:"contents"`
+	// ProductCode is the game identifier (e.g., "CTR-P-ALGE") from the main
+	// content's NCCH. Empty if the content is encrypted.
+	ProductCode string `json:"product_code,omitempty"`
+	// MakerCode is the 2-character publisher code from the main content's NCCH.
+	MakerCode string `json:"maker_code,omitempty"`
+	// ContentType indicates the type of content from NCCH flags[5].
+	ContentType ContentType `json:"content_type"`
+	// IsNew3DSExclusive indicates if this is a New 3DS exclusive title.
+	IsNew3DSExclusive bool `json:"is_new3ds_exclusive"`
+	// Region is the target region from the product code.
+	Region Region `json:"region"`
+	// platform is the target platform (internal, used by GamePlatform).
+	platform core.Platform
+}
+
+// GamePlatform implements core.GameInfo.
+func (i *CIAInfo) GamePlatform() core.Platform { return i.platform }
+
+// GameTitle implements core.GameInfo.
+// The CIA and NCCH headers don't contain a title field.
+func (i *CIAInfo) GameTitle() string { return "" }
+
+// GameSerial implements core.GameInfo.
+// Returns the product code (e.g., "CTR-P-ALGE").
+func (i *CIAInfo) GameSerial() string { return i.ProductCode }
+
+// GameRegions implements core.GameInfo.
+func (i *CIAInfo) GameRegions() []core.Region { return i.Region.regions() }
+
+// ParseCIA extracts game information from a 3DS CIA file.
+func ParseCIA(r io.ReaderAt, size int64) (*CIAInfo, error) {
+	if size < ciaHeaderSize {
+		return nil, fmt.Errorf("file too small for CIA header: %d bytes", size)
+	}
+
+	header := make([]byte, ciaContentSizeOff+8)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, fmt.Errorf("failed to read CIA header: %w", err)
+	}
+
+	if hdrSize := binary.LittleEndian.Uint32(header[0:]); hdrSize != ciaHeaderSize {
+		return nil, fmt.Errorf("not a valid CIA file: header size 0x%X", hdrSize)
+	}
+
+	certSize := int64(binary.LittleEndian.Uint32(header[ciaCertSizeOffset:]))
+	ticketSize := int64(binary.LittleEndian.Uint32(header[ciaTicketSizeOffset:]))
+	tmdSize := int64(binary.LittleEndian.Uint32(header[ciaTMDSizeOffset:]))
+	contentSize := int64(binary.LittleEndian.Uint64(header[ciaContentSizeOff:]))
+
+	certOffset := alignCIA(ciaHeaderSize)
+	ticketOffset := alignCIA(certOffset + certSize)
+	tmdOffset := alignCIA(ticketOffset + ticketSize)
+	contentOffset := alignCIA(tmdOffset + tmdSize)
+
+	if tmdSize == 0 || tmdOffset+tmdSize > size {
+		return nil, fmt.Errorf("not a valid CIA file: TMD extends beyond file")
+	}
+	if contentOffset+contentSize > size {
+		return nil, fmt.Errorf("not a valid CIA file: content extends beyond file")
+	}
+
+	tmd := make([]byte, tmdSize)
+	if _, err := r.ReadAt(tmd, tmdOffset); err != nil {
+		return nil, fmt.Errorf("failed to read TMD: %w", err)
+	}
+
+	info, err := parseTMD(tmd, contentOffset)
+	if err != nil {
+		return nil, err
+	}
+	info.platform = core.Platform3DS
+
+	// The main NCCH is only readable if it isn't encrypted with the title key
+	for _, content := range info.Contents {
+		if content.Index != 0 || content.Encrypted {
+			continue
+		}
+		ncch, err := readNCCH(r, content.Offset, size)
+		if err != nil {
+			return nil, err
+		}
+		info.ProductCode = ncch.productCode
+		info.MakerCode = ncch.makerCode
+		info.ContentType = ncch.contentType
+		info.IsNew3DSExclusive = ncch.isNew3DSExclusive
+		info.Region = ncch.region()
+		info.platform = ncch.platform()
+		break
+	}
+
+	return info, nil
+}
+
+// parseTMD parses the title metadata and content chunk records.
+func parseTMD(tmd []byte, contentOffset int64) (*CIAInfo, error) {
+	if len(tmd) < 4 {
+		return nil, fmt.Errorf("TMD too small: %d bytes", len(tmd))
+	}
+
+	sigSize, err := ciaSignatureSize(binary.BigEndian.Uint32(tmd[0:]))
+	if err != nil {
+		return nil, err
+	}
+
+	headerOffset := 4 + sigSize
+	chunksOffset := headerOffset + tmdHeaderSize + tmdContentInfoRecords*tmdContentInfoRecordSize
+	if int64(len(tmd)) < chunksOffset {
+		return nil, fmt.Errorf("TMD too small: %d bytes", len(tmd))
+	}
+	h := tmd[headerOffset:]
+
+	contentCount := int64(binary.BigEndian.Uint16(h[tmdContentCountOffset:]))
+	if int64(len(tmd)) < chunksOffset+contentCount*tmdContentChunkSize {
+		return nil, fmt.Errorf("TMD too small for %d content records: %d bytes", contentCount, len(tmd))
+	}
+
+	contents := make([]CIAContent, contentCount)
+	offset := contentOffset
+	for i := range contents {
+		chunk := tmd[chunksOffset+int64(i)*tmdContentChunkSize:]
+		contents[i] = CIAContent{
+			ID:        binary.BigEndian.Uint32(chunk[0x00:]),
+			Index:     binary.BigEndian.Uint16(chunk[0x04:]),
+			Encrypted: binary.BigEndian.Uint16(chunk[0x06:])&ciaContentFlagEncrypted != 0,
+			Size:      int64(binary.BigEndian.Uint64(chunk[0x08:])),
+			Offset:    offset,
+		}
+		offset += contents[i].Size
+	}
+
+	return &CIAInfo{
+		TitleID:      binary.BigEndian.Uint64(h[tmdTitleIDOffset:]),
+		TitleVersion: binary.BigEndian.Uint16(h[tmdTitleVersionOffset:]),
+		SaveDataSize: binary.LittleEndian.Uint32(h[tmdSaveDataSizeOffset:]),
+		Contents:     contents,
+	}, nil
+}
+
+// alignCIA rounds an offset up to the CIA section alignment.
+func alignCIA(offset int64) int64 {
+	return (offset + ciaAlignment - 1) &^ (ciaAlignment - 1)
+}
@@ -0,0 +1,136 @@
+package n3ds
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/sargunv/rom-tools/lib/core"
+)
+
+// makeSyntheticCIA creates a CIA with an RSA-2048 signed TMD and the given
+// contents (each with an NCCH header from makeSyntheticNCSD).
+func makeSyntheticCIA(titleID uint64, encrypted bool, contents ...[]byte) []byte {
+	const certSize, ticketSize = 0xA00, 0x350
+
+	sigSize, _ := ciaSignatureSize(0x010004)
+	headerOffset := 4 + sigSize
+	chunksOffset := headerOffset + tmdHeaderSize + tmdContentInfoRecords*tmdContentInfoRecordSize
+	tmd := make([]byte, chunksOffset+int64(len(contents))*tmdContentChunkSize)
+	binary.BigEndian.PutUint32(tmd[0:], 0x010004)
+	h := tmd[headerOffset:]
+	binary.BigEndian.PutUint64(h[tmdTitleIDOffset:], titleID)
+	binary.BigEndian.PutUint16(h[tmdTitleVersionOffset:], 1040)
+	binary.LittleEndian.PutUint32(h[tmdSaveDataSizeOffset:], 0x80000)
+	binary.BigEndian.PutUint16(h[tmdContentCountOffset:], uint16(len(contents)))
+
+	var content []byte
+	for i, c := range contents {
+		chunk := tmd[chunksOffset+int64(i)*tmdContentChunkSize:]
+		binary.BigEndian.PutUint32(chunk[0x00:], uint32(i))
+		binary.BigEndian.PutUint16(chunk[0x04:], uint16(i))
+		if encrypted {
+			binary.BigEndian.PutUint16(chunk[0x06:], ciaContentFlagEncrypted)
+		}
+		binary.BigEndian.PutUint64(chunk[0x08:], uint64(len(c)))
+		content = append(content, c...)
+	}
+
+	header := make([]byte, ciaHeaderSize)
+	binary.LittleEndian.PutUint32(header[0:], ciaHeaderSize)
+	binary.LittleEndian.PutUint32(header[ciaCertSizeOffset:], certSize)
+	binary.LittleEndian.PutUint32(header[ciaTicketSizeOffset:], ticketSize)
+	binary.LittleEndian.PutUint32(header[ciaTMDSizeOffset:], uint32(len(tmd)))
+	binary.LittleEndian.PutUint64(header[ciaContentSizeOff:], uint64(len(content)))
+
+	data := make([]byte, alignCIA(alignCIA(alignCIA(alignCIA(ciaHeaderSize)+certSize)+ticketSize)+int64(len(tmd))))
+	copy(data, header)
+	tmdOffset := alignCIA(alignCIA(alignCIA(ciaHeaderSize)+certSize) + ticketSize)
+	copy(data[tmdOffset:], tmd)
+	return append(data, content...)
+}
+
+// syntheticNCCH returns a standalone NCCH header.
+func syntheticNCCH(productCode string, isNew3DS bool) []byte {
+	return makeSyntheticNCSD(productCode, "01", 0, 0, isNew3DS)[0x200:]
+}
+
+func TestParseCIA(t *testing.T) {
+	titleID := uint64(0x0004000000055D00)
+	data := makeSyntheticCIA(titleID, false, syntheticNCCH("CTR-P-EKJE", false), syntheticNCCH("CTR-P-EKJE", false))
+
+	info, err := ParseCIA(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("ParseCIA() error = %v", err)
+	}
+
+	if info.GamePlatform() != core.Platform3DS {
+		t.Errorf("GamePlatform() = %v, want %v", info.GamePlatform(), core.Platform3DS)
+	}
+	if info.TitleID != titleID {
+		t.Errorf("TitleID = %016X, want %016X", info.TitleID, titleID)
+	}
+	if info.TitleVersion != 1040 {
+		t.Errorf("TitleVersion = %d, want 1040", info.TitleVersion)
+	}
+	if info.SaveDataSize != 0x80000 {
+		t.Errorf("SaveDataSize = %d, want %d", info.SaveDataSize, 0x80000)
+	}
+	if info.GameSerial() != "CTR-P-EKJE" {
+		t.Errorf("GameSerial() = %q, want %q", info.GameSerial(), "CTR-P-EKJE")
+	}
+	if info.MakerCode != "01" {
+		t.Errorf("MakerCode = %q, want %q", info.MakerCode, "01")
+	}
+	if regions := info.GameRegions(); len(regions) != 1 || regions[0] != core.RegionUSA {
+		t.Errorf("GameRegions() = %v, want [%v]", regions, core.RegionUSA)
+	}
+	if len(info.Contents) != 2 {
+		t.Fatalf("len(Contents) = %d, want 2", len(info.Contents))
+	}
+	if info.Contents[1].Offset != info.Contents[0].Offset+info.Contents[0].Size {
+		t.Errorf("Contents[1].Offset = %d, want %d", info.Contents[1].Offset, info.Contents[0].Offset+info.Contents[0].Size)
+	}
+}
+
+func TestParseCIA_New3DS(t *testing.T) {
+	data := makeSyntheticCIA(0x0004000000127500, false, syntheticNCCH("KTR-P-CAFJ", true))
+
+	info, err := ParseCIA(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("ParseCIA() error = %v", err)
+	}
+	if info.GamePlatform() != core.PlatformNew3DS {
+		t.Errorf("GamePlatform() = %v, want %v", info.GamePlatform(), core.PlatformNew3DS)
+	}
+}
+
+func TestParseCIA_Encrypted(t *testing.T) {
+	// Encrypted content: NCCH header is garbage without the title key
+	data := makeSyntheticCIA(0x0004000000055D00, true, make([]byte, 0x200))
+
+	info, err := ParseCIA(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("ParseCIA() error = %v", err)
+	}
+	if !info.Contents[0].Encrypted {
+		t.Error("Contents[0].Encrypted = false, want true")
+	}
+	if info.ProductCode != "" {
+		t.Errorf("ProductCode = %q, want empty for encrypted content", info.ProductCode)
+	}
+	if info.TitleID != 0x0004000000055D00 {
+		t.Errorf("TitleID = %016X, want %016X", info.TitleID, uint64(0x0004000000055D00))
+	}
+}
+
+func TestParseCIA_Invalid(t *testing.T) {
+	data := make([]byte, ciaHeaderSize)
+	if _, err := ParseCIA(bytes.NewReader(data), int64(len(data))); err == nil {
+		t.Error("ParseCIA() expected error for invalid header size, got nil")
+	}
+
+	if _, err := ParseCIA(bytes.NewReader(data[:100]), 100); err == nil {
+		t.Error("ParseCIA() expected error for small file, got nil")
+	}
+}
@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"io"
 
-	"github.com/sargunv/rom-tools/internal/util"
 	"github.com/sargunv/rom-tools/lib/core"
 )
 
@@ -120,6 +119,17 @@ const (
 	RegionTaiwan    Region = 'T'
 )
 
+// Partition is an entry in the NCSD partition table.
+type Partition struct {
+	// Index is the partition number (0 = game, 1 = manual, 2 = download play child,
+	// 6 = New 3DS update data, 7 = update data).
+	Index int `json:"index"`
+	// Offset is the partition offset in bytes.
+	Offset int64 `json:"offset"`
+	// Size is the partition size in bytes.
+	Size int64 `json:"size"`
+}
+
 // Info contains metadata extracted from a 3DS CCI/NCSD file.
 type Info struct {
 	// MediaID is the unique media identifier from the NCSD header (0x108).
@@ -128,6 +138,8 @@ type Info struct {
 	ImageSize int64 `json:"image_size"`
 	// PartitionCount is the number of valid partitions.
 	PartitionCount int `json:"partition_count"`
+	// Partitions lists the valid partitions, in table order.
+	Partitions []Partition `json:"partitions,omitempty"`
 
 	// TitleID is the 64-bit title identifier from the NCCH header (0x118).
 	TitleID uint64 `json:"title_id"`
@@ -161,8 +173,11 @@ func (i *Info) GameTitle() string { return "" }
 func (i *Info) GameSerial() string { return i.ProductCode }
 
 // GameRegions implements core.GameInfo.
-func (i *Info) GameRegions() []core.Region {
-	switch i.Region {
+func (i *Info) GameRegions() []core.Region { return i.Region.regions() }
+
+// regions maps a product code region to core regions.
+func (r Region) regions() []core.Region {
+	switch r {
 	case RegionJapan:
 		return []core.Region{core.RegionJapan}
 	case RegionUSA:
@@ -215,74 +230,40 @@ func Parse(r io.ReaderAt, size int64) (*Info, error) {
 		return nil, fmt.Errorf("partition 0 is empty or invalid")
 	}
 
-	// Count valid partitions (entries fully contained within the NCSD image and file)
-	partCount := 0
+	// Collect valid partitions (entries fully contained within the NCSD image and file)
+	var partitions []Partition
 	for i := 0; i < ncsdPartTableEntries; i++ {
 		pOff, pSize, _ := parsePartitionEntry(ncsdHeader, i)
 		if pOff > 0 && pSize > 0 {
 			partEnd := int64(pOff+pSize) * mediaUnitSize
 			if partEnd <= imageSize && partEnd <= size {
-				partCount++
+				partitions = append(partitions, Partition{
+					Index:  i,
+					Offset: int64(pOff) * mediaUnitSize,
+					Size:   int64(pSize) * mediaUnitSize,
+				})
 			}
 		}
 	}
 
-	// Calculate NCCH offset in bytes
-	ncchOffset := int64(partOffset) * mediaUnitSize
-	if ncchOffset+ncchMinHeaderSize > size {
-		return nil, fmt.Errorf("NCCH partition extends beyond file: offset %d, file size %d", ncchOffset, size)
-	}
-
-	// Read NCCH header
-	ncchHeader := make([]byte, ncchMinHeaderSize)
-	if _, err := r.ReadAt(ncchHeader, ncchOffset); err != nil {
-		return nil, fmt.Errorf("failed to read NCCH header at offset %d: %w", ncchOffset, err)
-	}
-
-	// Validate NCCH magic
-	ncchMagicVal := string(ncchHeader[ncchMagicOffset : ncchMagicOffset+4])
-	if ncchMagicVal != ncchMagic {
-		return nil, fmt.Errorf("not a valid NCCH partition: expected magic %q, got %q", ncchMagic, ncchMagicVal)
-	}
-
-	// Parse NCCH fields
-	titleID := binary.LittleEndian.Uint64(ncchHeader[ncchTitleIDOffset:])
-	makerCode := util.ExtractASCII(ncchHeader[ncchMakerCodeOffset : ncchMakerCodeOffset+ncchMakerCodeLen])
-	version := binary.LittleEndian.Uint16(ncchHeader[ncchVersionOffset:])
-	productCode := util.ExtractASCII(ncchHeader[ncchProductCodeOffset : ncchProductCodeOffset+ncchProductCodeLen])
-
-	// Parse flags
-	flags := ncchHeader[ncchFlagsOffset : ncchFlagsOffset+8]
-	contentType := ContentType(flags[5] & 0x07) // Lower 3 bits
-	isNew3DSExclusive := (flags[4] & 0x02) != 0 // Bit 1 of flags[4]
-
-	// Determine region from product code (last character of game code)
-	// Format: CTR-P-XXXR where R is region
-	var region Region
-	if len(productCode) >= 10 {
-		region = Region(productCode[9])
-	}
-
-	// Determine platform
-	var platform core.Platform
-	if isNew3DSExclusive {
-		platform = core.PlatformNew3DS
-	} else {
-		platform = core.Platform3DS
+	ncch, err := readNCCH(r, int64(partOffset)*mediaUnitSize, size)
+	if err != nil {
+		return nil, err
 	}
 
 	return &Info{
 		MediaID:           mediaID,
 		ImageSize:         imageSize,
-		PartitionCount:    partCount,
-		TitleID:           titleID,
-		ProductCode:       productCode,
-		MakerCode:         makerCode,
-		Version:           version,
-		ContentType:       contentType,
-		IsNew3DSExclusive: isNew3DSExclusive,
-		Region:            region,
-		platform:          platform,
+		PartitionCount:    len(partitions),
+		Partitions:        partitions,
+		TitleID:           ncch.titleID,
+		ProductCode:       ncch.productCode,
+		MakerCode:         ncch.makerCode,
+		Version:           ncch.version,
+		ContentType:       ncch.contentType,
+		IsNew3DSExclusive: ncch.isNew3DSExclusive,
+		Region:            ncch.region(),
+		platform:          ncch.platform(),
 	}, nil
 }
 
@@ -339,3 +339,18 @@ func TestParse_OutOfBoundsPartitionsExcluded(t *testing.T) {
 		t.Errorf("PartitionCount = %d, want 1 (out-of-bounds partitions should be excluded)", info.PartitionCount)
 	}
 }
+
+func TestParse_Partitions(t *testing.T) {
+	data := makeSyntheticNCSD("CTR-P-ALGE", "00", 0, 0, false)
+
+	info, err := Parse(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(info.Partitions) != 1 {
+		t.Fatalf("len(Partitions) = %d, want 1", len(info.Partitions))
+	}
+	if p := info.Partitions[0]; p.Index != 0 || p.Offset != 0x200 || p.Size != 0x200 {
+		t.Errorf("Partitions[0] = %+v, want {Index:0 Offset:512 Size:512}", p)
+	}
+}
@@ -0,0 +1,67 @@
+package n3ds
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/sargunv/rom-tools/internal/util"
+	"github.com/sargunv/rom-tools/lib/core"
+)
+
+// ncchHeader holds the NCCH fields shared by CCI and CIA parsing.
+type ncchHeader struct {
+	titleID           uint64
+	makerCode         string
+	version           uint16
+	productCode       string
+	contentType       ContentType
+	isNew3DSExclusive bool
+}
+
+// readNCCH reads and validates the NCCH header at the given offset.
+func readNCCH(r io.ReaderAt, offset, size int64) (*ncchHeader, error) {
+	if offset+ncchMinHeaderSize > size {
+		return nil, fmt.Errorf("NCCH partition extends beyond file: offset %d, file size %d", offset, size)
+	}
+
+	header := make([]byte, ncchMinHeaderSize)
+	if _, err := r.ReadAt(header, offset); err != nil {
+		return nil, fmt.Errorf("failed to read NCCH header at offset %d: %w", offset, err)
+	}
+
+	// Validate NCCH magic
+	magic := string(header[ncchMagicOffset : ncchMagicOffset+4])
+	if magic != ncchMagic {
+		return nil, fmt.Errorf("not a valid NCCH partition: expected magic %q, got %q", ncchMagic, magic)
+	}
+
+	// Parse flags
+	flags := header[ncchFlagsOffset : ncchFlagsOffset+8]
+
+	return &ncchHeader{
+		titleID:           binary.LittleEndian.Uint64(header[ncchTitleIDOffset:]),
+		makerCode:         util.ExtractASCII(header[ncchMakerCodeOffset : ncchMakerCodeOffset+ncchMakerCodeLen]),
+		version:           binary.LittleEndian.Uint16(header[ncchVersionOffset:]),
+		productCode:       util.ExtractASCII(header[ncchProductCodeOffset : ncchProductCodeOffset+ncchProductCodeLen]),
+		contentType:       ContentType(flags[5] & 0x07), // Lower 3 bits
+		isNew3DSExclusive: (flags[4] & 0x02) != 0,       // Bit 1 of flags[4]
+	}, nil
+}
+
+// region determines the region from the product code (last character of game code).
+// Format: CTR-P-XXXR where R is region
+func (h *ncchHeader) region() Region {
+	if len(h.productCode) >= 10 {
+		return Region(h.productCode[9])
+	}
+	return 0
+}
+
+// platform returns the target platform.
+func (h *ncchHeader) platform() core.Platform {
+	if h.isNew3DSExclusive {
+		return core.PlatformNew3DS
+	}
+	return core.Platform3DS
+}
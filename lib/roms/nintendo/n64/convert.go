@@ -0,0 +1,60 @@
+package n64
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// convertChunkSize is how much of the ROM Convert reorders at a time. It must
+// be a multiple of 4 so chunks split on word boundaries.
+const convertChunkSize = 64 * 1024
+
+// Convert streams an N64 ROM from r to w, reordering its bytes to target.
+// The source byte order is detected from the start of the ROM.
+func Convert(r io.Reader, w io.Writer, target ByteOrder) error {
+	switch target {
+	case ByteOrderBigEndian, ByteOrderByteSwapped, ByteOrderLittleEndian:
+	default:
+		return fmt.Errorf("unsupported target byte order: %s", target)
+	}
+
+	buf := make([]byte, convertChunkSize)
+	n, err := io.ReadFull(r, buf)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return fmt.Errorf("failed to read N64 ROM: %w", err)
+	}
+	if n < N64HeaderSize {
+		return fmt.Errorf("file too small for N64 header: %d bytes", n)
+	}
+	source := detectByteOrder(buf[:4])
+	if source == ByteOrderUnknown {
+		return fmt.Errorf("not a valid N64 ROM: could not detect byte order")
+	}
+
+	for n > 0 {
+		chunk := buf[:n]
+		// Go through big-endian: each swap is its own inverse
+		reorder(chunk, source)
+		reorder(chunk, target)
+		if _, err := w.Write(chunk); err != nil {
+			return fmt.Errorf("failed to write N64 ROM: %w", err)
+		}
+
+		n, err = io.ReadFull(r, buf)
+		if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return fmt.Errorf("failed to read N64 ROM: %w", err)
+		}
+	}
+	return nil
+}
+
+// reorder converts data between big-endian (z64) format and order in place.
+func reorder(data []byte, order ByteOrder) {
+	switch order {
+	case ByteOrderByteSwapped:
+		swapBytes16(data)
+	case ByteOrderLittleEndian:
+		swapBytes32(data)
+	}
+}
@@ -0,0 +1,66 @@
+package n64
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+func TestConvert(t *testing.T) {
+	v64, err := os.ReadFile("testdata/flames.v64")
+	if err != nil {
+		t.Fatalf("Failed to read v64: %v", err)
+	}
+	n64, err := os.ReadFile("testdata/flames.n64")
+	if err != nil {
+		t.Fatalf("Failed to read n64: %v", err)
+	}
+
+	convert := func(t *testing.T, data []byte, target ByteOrder) []byte {
+		t.Helper()
+		var out bytes.Buffer
+		if err := Convert(bytes.NewReader(data), &out, target); err != nil {
+			t.Fatalf("Convert(%s) error = %v", target, err)
+		}
+		return out.Bytes()
+	}
+
+	if !bytes.Equal(convert(t, v64, ByteOrderLittleEndian), n64) {
+		t.Error("v64 to n64 doesn't match flames.n64")
+	}
+	if !bytes.Equal(convert(t, n64, ByteOrderByteSwapped), v64) {
+		t.Error("n64 to v64 doesn't match flames.v64")
+	}
+	if !bytes.Equal(convert(t, v64, ByteOrderByteSwapped), v64) {
+		t.Error("v64 to v64 doesn't match flames.v64")
+	}
+
+	// Both go through the same big-endian ROM
+	z64 := convert(t, v64, ByteOrderBigEndian)
+	if !bytes.Equal(convert(t, n64, ByteOrderBigEndian), z64) {
+		t.Error("n64 to z64 doesn't match v64 to z64")
+	}
+	info, err := Parse(bytes.NewReader(z64), int64(len(z64)))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if info.ByteOrder != ByteOrderBigEndian {
+		t.Errorf("converted byte order = %s, want z64", info.ByteOrder)
+	}
+	if !bytes.Equal(convert(t, z64, ByteOrderByteSwapped), v64) {
+		t.Error("z64 to v64 doesn't match flames.v64")
+	}
+}
+
+func TestConvert_Errors(t *testing.T) {
+	var out bytes.Buffer
+	if err := Convert(bytes.NewReader(make([]byte, N64HeaderSize)), &out, ByteOrderBigEndian); err == nil {
+		t.Error("Convert() of non-N64 data expected error, got nil")
+	}
+	if err := Convert(bytes.NewReader([]byte{0x80, 0x37, 0x12, 0x40}), &out, ByteOrderBigEndian); err == nil {
+		t.Error("Convert() of truncated header expected error, got nil")
+	}
+	if err := Convert(bytes.NewReader(make([]byte, N64HeaderSize)), &out, ByteOrderUnknown); err == nil {
+		t.Error("Convert() to unknown byte order expected error, got nil")
+	}
+}
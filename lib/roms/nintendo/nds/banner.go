@@ -0,0 +1,199 @@
+package nds
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"strings"
+	"unicode/utf16"
+)
+
+// NDS icon/banner parsing.
+//
+// The header's icon/title offset (0x068) points to the banner block, which
+// holds the icon shown on the DS menu and the game's title in each language.
+// Later banner versions add languages:
+//
+//	Offset  Size   Description
+//	0x000   2      Version (1 = 6 titles, 2 = +Chinese, 3 = +Korean, 0x103 = +DSi animated icon)
+//	0x002   2      CRC-16 of 0x020-0x83F
+//	0x004   2      CRC-16 of 0x020-0x93F (version 2+)
+//	0x006   2      CRC-16 of 0x020-0xA3F (version 3+)
+//	0x008   2      CRC-16 of 0x1240-0x23BF (version 0x103)
+//	0x020   0x200  Icon bitmap (32x32, 4bpp, 8x8 tiles)
+//	0x220   0x20   Icon palette (16 BGR555 colors, color 0 is transparent)
+//	0x240   0x100  Japanese title
+//	0x340   0x100  English title
+//	0x440   0x100  French title
+//	0x540   0x100  German title
+//	0x640   0x100  Italian title
+//	0x740   0x100  Spanish title
+//	0x840   0x100  Chinese title (version 2+)
+//	0x940   0x100  Korean title (version 3+)
+//
+// Titles are UTF-16LE, null-padded, with up to three lines separated by
+// newlines (usually the game name followed by the publisher).
+//
+// Homebrew built with old toolchains may leave the version at 0; those
+// banners are read as version 1.
+
+const (
+	ndsBannerOffsetOffset = 0x068
+
+	bannerVersionOffset   = 0x000
+	bannerIconOffset      = 0x020
+	bannerIconSize        = 0x200
+	bannerPaletteOffset   = 0x220
+	bannerPaletteColors   = 16
+	bannerTitlesOffset    = 0x240
+	bannerTitleSize       = 0x100
+	bannerV1Size          = 0x840
+	bannerIconDimension   = 32
+	bannerIconTileSize    = 8
+	bannerIconTileBytes   = 32
+	bannerIconTilesAcross = bannerIconDimension / bannerIconTileSize
+)
+
+// Language is the language of a banner title.
+type Language string
+
+const (
+	LanguageJapanese Language = "ja"
+	LanguageEnglish  Language = "en"
+	LanguageFrench   Language = "fr"
+	LanguageGerman   Language = "de"
+	LanguageItalian  Language = "it"
+	LanguageSpanish  Language = "es"
+	LanguageChinese  Language = "zh" // Version 2+
+	LanguageKorean   Language = "ko" // Version 3+
+)
+
+// bannerLanguages is the order of banner titles.
+var bannerLanguages = []Language{
+	LanguageJapanese, LanguageEnglish, LanguageFrench, LanguageGerman,
+	LanguageItalian, LanguageSpanish, LanguageChinese, LanguageKorean,
+}
+
+// bannerTitleCount returns the number of titles for a banner version.
+func bannerTitleCount(version uint16) int {
+	switch version & 0xFF {
+	case 2:
+		return 7
+	case 3:
+		return 8
+	default:
+		return 6
+	}
+}
+
+// BannerTitle is a banner title in one language.
+type BannerTitle struct {
+	Language Language `json:"language"`
+	// Title is the full title, with lines separated by newlines.
+	Title string `json:"title"`
+}
+
+// Banner contains the icon and localized titles from an NDS banner.
+type Banner struct {
+	// Version is the banner version.
+	Version uint16 `json:"version"`
+	// Titles holds the title in each language the banner version supports.
+	Titles []BannerTitle `json:"titles"`
+	// IconBitmap is the raw 32x32 4bpp tiled icon bitmap.
+	IconBitmap []byte `json:"-"`
+	// IconPalette is the icon's 16 BGR555 colors.
+	IconPalette [bannerPaletteColors]uint16 `json:"-"`
+}
+
+// Title returns the banner title in the given language, or "" if the banner
+// has none.
+func (b *Banner) Title(language Language) string {
+	for _, t := range b.Titles {
+		if t.Language == language {
+			return t.Title
+		}
+	}
+	return ""
+}
+
+// Icon decodes the banner icon as a 32x32 paletted image.
+func (b *Banner) Icon() *image.Paletted {
+	palette := make(color.Palette, bannerPaletteColors)
+	for i, c := range b.IconPalette {
+		r, g, bl := uint8(c&0x1F), uint8(c>>5&0x1F), uint8(c>>10&0x1F)
+		alpha := uint8(0xFF)
+		if i == 0 {
+			alpha = 0
+		}
+		palette[i] = color.NRGBA{R: r<<3 | r>>2, G: g<<3 | g>>2, B: bl<<3 | bl>>2, A: alpha}
+	}
+
+	img := image.NewPaletted(image.Rect(0, 0, bannerIconDimension, bannerIconDimension), palette)
+	for tile := range bannerIconTilesAcross * bannerIconTilesAcross {
+		tileX := tile % bannerIconTilesAcross * bannerIconTileSize
+		tileY := tile / bannerIconTilesAcross * bannerIconTileSize
+		data := b.IconBitmap[tile*bannerIconTileBytes:]
+		for p := range bannerIconTileSize * bannerIconTileSize {
+			index := data[p/2] & 0x0F
+			if p%2 == 1 {
+				index = data[p/2] >> 4
+			}
+			img.SetColorIndex(tileX+p%bannerIconTileSize, tileY+p/bannerIconTileSize, index)
+		}
+	}
+	return img
+}
+
+// parseBanner reads the banner block at offset.
+func parseBanner(r io.ReaderAt, size, offset int64) (*Banner, error) {
+	if offset+bannerV1Size > size {
+		return nil, fmt.Errorf("NDS banner at %#x is beyond end of file", offset)
+	}
+
+	versionBytes := make([]byte, 2)
+	if _, err := r.ReadAt(versionBytes, offset+bannerVersionOffset); err != nil {
+		return nil, fmt.Errorf("failed to read NDS banner: %w", err)
+	}
+	version := binary.LittleEndian.Uint16(versionBytes)
+
+	count := bannerTitleCount(version)
+	data := make([]byte, bannerTitlesOffset+count*bannerTitleSize)
+	if offset+int64(len(data)) > size {
+		return nil, fmt.Errorf("NDS banner version %#x at %#x is beyond end of file", version, offset)
+	}
+	if _, err := r.ReadAt(data, offset); err != nil {
+		return nil, fmt.Errorf("failed to read NDS banner: %w", err)
+	}
+
+	banner := &Banner{
+		Version:    version,
+		IconBitmap: data[bannerIconOffset : bannerIconOffset+bannerIconSize],
+	}
+	for i := range banner.IconPalette {
+		banner.IconPalette[i] = binary.LittleEndian.Uint16(data[bannerPaletteOffset+i*2:])
+	}
+	for i, language := range bannerLanguages[:count] {
+		start := bannerTitlesOffset + i*bannerTitleSize
+		title := decodeUTF16Title(data[start : start+bannerTitleSize])
+		if title != "" {
+			banner.Titles = append(banner.Titles, BannerTitle{Language: language, Title: title})
+		}
+	}
+	return banner, nil
+}
+
+// decodeUTF16Title decodes a null-terminated UTF-16LE title, trimming
+// surrounding whitespace.
+func decodeUTF16Title(data []byte) string {
+	units := make([]uint16, 0, len(data)/2)
+	for i := 0; i+1 < len(data); i += 2 {
+		u := binary.LittleEndian.Uint16(data[i:])
+		if u == 0 {
+			break
+		}
+		units = append(units, u)
+	}
+	return strings.TrimSpace(string(utf16.Decode(units)))
+}
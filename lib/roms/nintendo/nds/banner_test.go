@@ -0,0 +1,122 @@
+package nds
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image/color"
+	"testing"
+	"unicode/utf16"
+)
+
+// makeSyntheticBannerROM creates an NDS ROM with a banner of the given
+// version and titles (in banner order, starting with Japanese).
+func makeSyntheticBannerROM(version uint16, titles ...string) []byte {
+	const bannerOffset = 0x200
+	rom := make([]byte, bannerOffset+bannerTitlesOffset+8*bannerTitleSize)
+	copy(rom[ndsTitleOffset:], "BANNERTEST")
+	copy(rom[ndsGameCodeOffset:], "ABNE")
+	binary.LittleEndian.PutUint32(rom[ndsBannerOffsetOffset:], bannerOffset)
+
+	banner := rom[bannerOffset:]
+	binary.LittleEndian.PutUint16(banner[bannerVersionOffset:], version)
+	for i, title := range titles {
+		for j, u := range utf16.Encode([]rune(title)) {
+			binary.LittleEndian.PutUint16(banner[bannerTitlesOffset+i*bannerTitleSize+j*2:], u)
+		}
+	}
+	return rom
+}
+
+func TestParse_Banner(t *testing.T) {
+	rom := makeSyntheticBannerROM(1,
+		"ジャパン\nNintendo", "Banner Test\nNintendo", "Test de bannière\nNintendo", "", "", "")
+	info, err := Parse(bytes.NewReader(rom), int64(len(rom)))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if info.Banner == nil {
+		t.Fatal("Banner = nil, want banner")
+	}
+	if len(info.Banner.Titles) != 3 {
+		t.Errorf("len(Banner.Titles) = %d, want 3", len(info.Banner.Titles))
+	}
+	if got := info.Banner.Title(LanguageFrench); got != "Test de bannière\nNintendo" {
+		t.Errorf("Banner.Title(fr) = %q", got)
+	}
+	if info.GameTitle() != "Banner Test" {
+		t.Errorf("GameTitle() = %q, want %q", info.GameTitle(), "Banner Test")
+	}
+}
+
+func TestParse_BannerVersions(t *testing.T) {
+	tests := []struct {
+		version uint16
+		want    Language
+	}{
+		{2, LanguageChinese},
+		{3, LanguageKorean},
+		{0x103, LanguageKorean},
+	}
+
+	for _, tt := range tests {
+		titles := []string{"ja", "en", "fr", "de", "it", "es", "zh", "ko"}
+		rom := makeSyntheticBannerROM(tt.version, titles...)
+		info, err := Parse(bytes.NewReader(rom), int64(len(rom)))
+		if err != nil {
+			t.Fatalf("Parse() error = %v", err)
+		}
+		last := info.Banner.Titles[len(info.Banner.Titles)-1]
+		if last.Language != tt.want || last.Title != string(tt.want) {
+			t.Errorf("version %#x: last title = %+v, want %s", tt.version, last, tt.want)
+		}
+	}
+}
+
+func TestParse_BannerOnlyNonEnglish(t *testing.T) {
+	rom := makeSyntheticBannerROM(1, "ジャパン\nNintendo")
+	info, err := Parse(bytes.NewReader(rom), int64(len(rom)))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if info.GameTitle() != "ジャパン" {
+		t.Errorf("GameTitle() = %q, want %q", info.GameTitle(), "ジャパン")
+	}
+}
+
+func TestParse_BannerBeyondEnd(t *testing.T) {
+	rom := makeSyntheticBannerROM(1, "", "Banner Test")
+	binary.LittleEndian.PutUint32(rom[ndsBannerOffsetOffset:], uint32(len(rom)))
+	info, err := Parse(bytes.NewReader(rom), int64(len(rom)))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if info.Banner != nil {
+		t.Errorf("Banner = %+v, want nil", info.Banner)
+	}
+	if info.GameTitle() != "BANNERTEST" {
+		t.Errorf("GameTitle() = %q, want %q", info.GameTitle(), "BANNERTEST")
+	}
+}
+
+func TestBanner_Icon(t *testing.T) {
+	banner := &Banner{IconBitmap: make([]byte, bannerIconSize)}
+	banner.IconPalette[1] = 0x001F // red
+	// Pixel (1, 0) of the first tile is the high nibble of its first byte;
+	// pixel (8, 0) is the first pixel of the second tile.
+	banner.IconBitmap[0] = 0x10
+	banner.IconBitmap[bannerIconTileBytes] = 0x01
+
+	icon := banner.Icon()
+	if icon.Bounds().Dx() != 32 || icon.Bounds().Dy() != 32 {
+		t.Fatalf("Icon() bounds = %v, want 32x32", icon.Bounds())
+	}
+	red := color.NRGBA{R: 0xFF, A: 0xFF}
+	for _, p := range []struct{ x, y int }{{1, 0}, {8, 0}} {
+		if got := icon.At(p.x, p.y); got != red {
+			t.Errorf("Icon().At(%d, %d) = %v, want %v", p.x, p.y, got, red)
+		}
+	}
+	if _, _, _, a := icon.At(0, 0).RGBA(); a != 0 {
+		t.Errorf("Icon().At(0, 0) alpha = %d, want transparent", a)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
+	"strings"
 
 	"github.com/sargunv/rom-tools/internal/util"
 	"github.com/sargunv/rom-tools/lib/core"
@@ -46,6 +47,13 @@ import (
 //	0x15E   2     Header Checksum (CRC-16 of bytes 0x000-0x15D)
 //	0x160-0x1FF   Debug info and reserved
 //
+// DSi-enhanced and DSi-exclusive ROMs (unit code 0x02 or 0x03) extend the
+// header to 4KB. Relevant extended fields:
+//
+//	Offset  Size  Description
+//	0x1B0   4     DSi region flags (bit 0=Japan, 1=USA, 2=Europe, 3=Australia, 4=China, 5=Korea; 0xFFFFFFFF=region free)
+//	0x230   8     DSi title ID
+//
 // Game Code breakdown (4 bytes at 0x00C):
 //   - Byte 0: Category - game type indicator (A/B/C=NDS, D=DSi-exclusive, K=DSiWare, V=DSi-enhanced)
 //   - Bytes 1-2: Unique Code - 2-character game identifier
@@ -64,6 +72,11 @@ const (
 	ndsRegionOffset         = 0x01D
 	ndsVersionOffset        = 0x01E
 	ndsHeaderChecksumOffset = 0x15E
+
+	dsiRegionFlagsOffset = 0x1B0
+	dsiTitleIDOffset     = 0x230
+	dsiTitleIDEnd        = 0x238
+	dsiUnitCodeFlag      = 0x02
 )
 
 // UnitCode indicates the target platform for the ROM.
@@ -86,6 +99,20 @@ const (
 	RegionChina  Region = 0x80 // China
 )
 
+// DSiRegion is a bit set of regions a DSi ROM may run in.
+type DSiRegion uint32
+
+// DSiRegion flags per GBATEK.
+const (
+	DSiRegionJapan      DSiRegion = 1 << 0
+	DSiRegionUSA        DSiRegion = 1 << 1
+	DSiRegionEurope     DSiRegion = 1 << 2
+	DSiRegionAustralia  DSiRegion = 1 << 3
+	DSiRegionChina      DSiRegion = 1 << 4
+	DSiRegionKorea      DSiRegion = 1 << 5
+	DSiRegionRegionFree DSiRegion = 0xFFFFFFFF
+)
+
 // GameType represents the category from the first byte of the game code.
 type GameType byte
 
@@ -163,6 +190,14 @@ type Info struct {
 	// HeaderChecksum is the CRC-16 of header bytes 0x000-0x15D (0x15E).
 	// TODO: validate this checksum
 	HeaderChecksum uint16 `json:"header_checksum"`
+	// DSiRegion is the DSi region flags (0x1B0), for DSi-enhanced and
+	// DSi-exclusive ROMs.
+	DSiRegion DSiRegion `json:"dsi_region,omitempty"`
+	// DSiTitleID is the DSi title ID (0x230), for DSi-enhanced and
+	// DSi-exclusive ROMs.
+	DSiTitleID uint64 `json:"dsi_title_id,omitempty"`
+	// Banner is the icon/banner block, if the ROM has one.
+	Banner *Banner `json:"banner,omitempty"`
 	// platform is NDS or DSi based on unit code (internal, used by GamePlatform).
 	platform core.Platform
 }
@@ -170,14 +205,60 @@ type Info struct {
 // GamePlatform implements core.GameInfo.
 func (i *Info) GamePlatform() core.Platform { return i.platform }
 
-// GameTitle implements core.GameInfo.
-func (i *Info) GameTitle() string { return i.Title }
+// GameTitle implements core.GameInfo. It prefers the first line of the
+// banner title (English, then any other language) over the abbreviated
+// uppercase header title.
+func (i *Info) GameTitle() string {
+	if i.Banner != nil && len(i.Banner.Titles) > 0 {
+		title := i.Banner.Title(LanguageEnglish)
+		if title == "" {
+			title = i.Banner.Titles[0].Title
+		}
+		name, _, _ := strings.Cut(title, "\n")
+		return strings.TrimSpace(name)
+	}
+	return i.Title
+}
 
 // GameSerial implements core.GameInfo.
 func (i *Info) GameSerial() string { return i.GameCode }
 
-// GameRegions implements core.GameInfo.
+// GameRegions implements core.GameInfo. DSi ROMs with an unknown
+// destination fall back to their DSi region flags.
 func (i *Info) GameRegions() []core.Region {
+	regions := i.destinationRegions()
+	if len(regions) == 0 {
+		return i.DSiRegion.regions()
+	}
+	return regions
+}
+
+// regions returns the regions set in the flags.
+func (r DSiRegion) regions() []core.Region {
+	if r == DSiRegionRegionFree {
+		return []core.Region{core.RegionWorld}
+	}
+	regions := []core.Region{}
+	for _, flag := range []struct {
+		bit    DSiRegion
+		region core.Region
+	}{
+		{DSiRegionJapan, core.RegionJapan},
+		{DSiRegionUSA, core.RegionUSA},
+		{DSiRegionEurope, core.RegionEurope},
+		{DSiRegionAustralia, core.RegionAustralia},
+		{DSiRegionChina, core.RegionChina},
+		{DSiRegionKorea, core.RegionKorea},
+	} {
+		if r&flag.bit != 0 {
+			regions = append(regions, flag.region)
+		}
+	}
+	return regions
+}
+
+// destinationRegions returns the regions for the game code's destination.
+func (i *Info) destinationRegions() []core.Region {
 	switch i.Destination {
 	case DestinationJapan:
 		return []core.Region{core.RegionJapan}
@@ -280,7 +361,7 @@ func Parse(r io.ReaderAt, size int64) (*Info, error) {
 	// Extract header checksum (little-endian)
 	headerChecksum := binary.LittleEndian.Uint16(header[ndsHeaderChecksumOffset:])
 
-	return &Info{
+	info := &Info{
 		Title:          title,
 		GameCode:       gameCode,
 		GameType:       gameType,
@@ -294,5 +375,25 @@ func Parse(r io.ReaderAt, size int64) (*Info, error) {
 		Version:        version,
 		HeaderChecksum: headerChecksum,
 		platform:       platform,
-	}, nil
+	}
+
+	// Extended header fields
+	if unitCode&dsiUnitCodeFlag != 0 && size >= dsiTitleIDEnd {
+		ext := make([]byte, dsiTitleIDEnd-dsiRegionFlagsOffset)
+		if _, err := r.ReadAt(ext, dsiRegionFlagsOffset); err != nil {
+			return nil, fmt.Errorf("failed to read DSi extended header: %w", err)
+		}
+		info.DSiRegion = DSiRegion(binary.LittleEndian.Uint32(ext))
+		info.DSiTitleID = binary.LittleEndian.Uint64(ext[dsiTitleIDOffset-dsiRegionFlagsOffset:])
+	}
+
+	// The banner is optional, and some ROMs point past the end of a
+	// trimmed file, so a missing or unreadable banner isn't an error.
+	if bannerOffset := int64(binary.LittleEndian.Uint32(header[ndsBannerOffsetOffset:])); bannerOffset != 0 {
+		if banner, err := parseBanner(r, size, bannerOffset); err == nil {
+			info.Banner = banner
+		}
+	}
+
+	return info, nil
 }
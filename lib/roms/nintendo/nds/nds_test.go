@@ -2,7 +2,10 @@ package nds
 
 import (
 	"bytes"
+	"encoding/binary"
 	"os"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/sargunv/rom-tools/lib/core"
@@ -74,6 +77,76 @@ func TestParse(t *testing.T) {
 	if info.Version != 0 {
 		t.Errorf("Version = %d, want %d", info.Version, 0)
 	}
+
+	// Verify banner (homebrew banner with version 0 and only a Japanese title)
+	if info.Banner == nil {
+		t.Fatal("Banner = nil, want banner")
+	}
+	if got := info.Banner.Title(LanguageJapanese); !strings.HasPrefix(got, "ndslib example\n") {
+		t.Errorf("Banner.Title(ja) = %q, want prefix %q", got, "ndslib example\n")
+	}
+	if info.GameTitle() != "ndslib example" {
+		t.Errorf("GameTitle() = %q, want %q", info.GameTitle(), "ndslib example")
+	}
+}
+
+// makeSyntheticDSi creates a DSi-enhanced ROM header with the given
+// destination and DSi region flags.
+func makeSyntheticDSi(destination byte, region DSiRegion) []byte {
+	rom := make([]byte, 0x1000)
+	copy(rom[ndsTitleOffset:], "DSITEST")
+	copy(rom[ndsGameCodeOffset:], "KDT")
+	rom[ndsGameCodeOffset+3] = destination
+	copy(rom[ndsMakerCodeOffset:], "01")
+	rom[ndsUnitCodeOffset] = byte(UnitCodeNDSDSi)
+	binary.LittleEndian.PutUint32(rom[dsiRegionFlagsOffset:], uint32(region))
+	binary.LittleEndian.PutUint64(rom[dsiTitleIDOffset:], 0x00030004_4B445445)
+	return rom
+}
+
+func TestParse_DSiExtendedHeader(t *testing.T) {
+	rom := makeSyntheticDSi('E', DSiRegionUSA)
+	info, err := Parse(bytes.NewReader(rom), int64(len(rom)))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if info.DSiTitleID != 0x00030004_4B445445 {
+		t.Errorf("DSiTitleID = %#x, want 0x000300044b445445", info.DSiTitleID)
+	}
+	if info.DSiRegion != DSiRegionUSA {
+		t.Errorf("DSiRegion = %#x, want %#x", info.DSiRegion, DSiRegionUSA)
+	}
+	if info.Banner != nil {
+		t.Errorf("Banner = %+v, want nil without a banner offset", info.Banner)
+	}
+	if info.GameTitle() != "DSITEST" {
+		t.Errorf("GameTitle() = %q, want %q", info.GameTitle(), "DSITEST")
+	}
+}
+
+func TestParse_DSiRegionFallback(t *testing.T) {
+	tests := []struct {
+		name   string
+		region DSiRegion
+		want   []core.Region
+	}{
+		{"Japan and Korea", DSiRegionJapan | DSiRegionKorea, []core.Region{core.RegionJapan, core.RegionKorea}},
+		{"region free", DSiRegionRegionFree, []core.Region{core.RegionWorld}},
+		{"none", 0, []core.Region{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rom := makeSyntheticDSi('#', tt.region)
+			info, err := Parse(bytes.NewReader(rom), int64(len(rom)))
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if regions := info.GameRegions(); !slices.Equal(regions, tt.want) {
+				t.Errorf("GameRegions() = %v, want %v", regions, tt.want)
+			}
+		})
+	}
 }
 
 func TestParse_TooSmall(t *testing.T) {
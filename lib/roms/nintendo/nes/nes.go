@@ -34,7 +34,8 @@ import (
 //	0x0F    1     NES 2.0: Default expansion device
 
 const (
-	nesHeaderSize = 16
+	// HeaderSize is the size of the iNES header preceding the ROM data.
+	HeaderSize = 16
 )
 
 // iNES magic bytes: "NES" + 0x1A
@@ -199,11 +200,11 @@ func (i *Info) GameRegions() []core.Region {
 
 // Parse extracts information from an NES ROM file (iNES or NES 2.0 format).
 func Parse(r io.ReaderAt, size int64) (*Info, error) {
-	if size < nesHeaderSize {
+	if size < HeaderSize {
 		return nil, fmt.Errorf("file too small for NES header: %d bytes", size)
 	}
 
-	header := make([]byte, nesHeaderSize)
+	header := make([]byte, HeaderSize)
 	if _, err := r.ReadAt(header, 0); err != nil {
 		return nil, fmt.Errorf("failed to read NES header: %w", err)
 	}
@@ -0,0 +1,172 @@
+package nes
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// nesTrainerSize is the size of the optional trainer between the header and
+// PRG-ROM.
+const nesTrainerSize = 512
+
+// EncodeNES20Header encodes info as a 16-byte NES 2.0 header.
+func EncodeNES20Header(info *Info) ([]byte, error) {
+	if info.Mapper < 0 || info.Mapper > 0xFFF {
+		return nil, fmt.Errorf("mapper %d out of range", info.Mapper)
+	}
+	if info.Submapper < 0 || info.Submapper > 0x0F {
+		return nil, fmt.Errorf("submapper %d out of range", info.Submapper)
+	}
+	if info.MiscROMs < 0 || info.MiscROMs > 3 {
+		return nil, fmt.Errorf("misc ROM count %d out of range", info.MiscROMs)
+	}
+
+	prgLSB, prgMSB, err := encodeNES20ROMSize(info.PRGROMSize, 16*1024)
+	if err != nil {
+		return nil, fmt.Errorf("PRG-ROM: %w", err)
+	}
+	chrLSB, chrMSB, err := encodeNES20ROMSize(info.CHRROMSize, 8*1024)
+	if err != nil {
+		return nil, fmt.Errorf("CHR-ROM: %w", err)
+	}
+
+	ramShifts := make([]byte, 4)
+	for i, size := range []int{info.PRGRAMSize, info.PRGNVRAMSize, info.CHRRAMSize, info.CHRNVRAMSize} {
+		shift, err := encodeNES20RAMSize(size)
+		if err != nil {
+			return nil, err
+		}
+		ramShifts[i] = shift
+	}
+
+	header := make([]byte, HeaderSize)
+	copy(header, nesMagic)
+	header[4] = prgLSB
+	header[5] = chrLSB
+
+	// Flags 6: mapper low nibble, four-screen, trainer, battery, mirroring
+	header[6] = byte(info.Mapper&0x0F)<<4 | byte(info.Mirroring&0x01)
+	if info.HasBattery {
+		header[6] |= 0x02
+	}
+	if info.HasTrainer {
+		header[6] |= 0x04
+	}
+	if info.FourScreen {
+		header[6] |= 0x08
+	}
+
+	// Flags 7: mapper mid nibble, NES 2.0 identifier, console type
+	header[7] = byte(info.Mapper&0xF0) | 0x08 | byte(info.ConsoleType&0x03)
+
+	header[8] = byte(info.Submapper)<<4 | byte(info.Mapper>>8)
+	header[9] = chrMSB<<4 | prgMSB
+	header[10] = ramShifts[1]<<4 | ramShifts[0]
+	header[11] = ramShifts[3]<<4 | ramShifts[2]
+	header[12] = byte(info.TimingMode & 0x03)
+
+	switch info.ConsoleType {
+	case ConsoleVsSystem:
+		header[13] = byte(info.VsHardwareType&0x0F)<<4 | byte(info.VsPPUType&0x0F)
+	case ConsoleExtended:
+		header[13] = byte(info.ExtendedConsoleType & 0x0F)
+	}
+
+	header[14] = byte(info.MiscROMs)
+	header[15] = info.ExpansionDevice & 0x3F
+
+	return header, nil
+}
+
+// UpgradeToNES20 writes the iNES 1.0 ROM in r to w with its header replaced
+// by a NES 2.0 header describing info. Typically info is the ROM's parsed
+// Info with the fields iNES 1.0 can't express (Submapper, RAM sizes,
+// TimingMode, and so on) filled in by hand or from a Database.
+//
+// info's PRG-ROM size, CHR-ROM size, and trainer must match the ROM's header;
+// everything after the header is copied unchanged.
+func UpgradeToNES20(r io.ReaderAt, size int64, w io.Writer, info *Info) error {
+	current, err := Parse(r, size)
+	if err != nil {
+		return err
+	}
+	if current.IsNES20 {
+		return fmt.Errorf("ROM already has a NES 2.0 header")
+	}
+	if info.PRGROMSize != current.PRGROMSize || info.CHRROMSize != current.CHRROMSize {
+		return fmt.Errorf("ROM sizes don't match: PRG-ROM %d, CHR-ROM %d, want %d, %d",
+			info.PRGROMSize, info.CHRROMSize, current.PRGROMSize, current.CHRROMSize)
+	}
+	if info.HasTrainer != current.HasTrainer {
+		return fmt.Errorf("trainer doesn't match the ROM")
+	}
+
+	header, err := EncodeNES20Header(info)
+	if err != nil {
+		return fmt.Errorf("failed to encode NES 2.0 header: %w", err)
+	}
+	if _, err := w.Write(header); err != nil {
+		return fmt.Errorf("failed to write NES 2.0 header: %w", err)
+	}
+	if _, err := io.Copy(w, io.NewSectionReader(r, HeaderSize, size-HeaderSize)); err != nil {
+		return fmt.Errorf("failed to copy NES ROM data: %w", err)
+	}
+	return nil
+}
+
+// romData returns the PRG-ROM, CHR-ROM, and any miscellaneous ROM data of
+// the ROM in r: everything after the header and trainer.
+func romData(r io.ReaderAt, size int64) (*io.SectionReader, error) {
+	header := make([]byte, HeaderSize)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, fmt.Errorf("failed to read NES header: %w", err)
+	}
+	if !bytes.Equal(header[0:4], nesMagic) {
+		return nil, fmt.Errorf("not a valid NES ROM: magic mismatch")
+	}
+	offset := int64(HeaderSize)
+	if header[6]&0x04 != 0 {
+		offset += nesTrainerSize
+	}
+	if offset > size {
+		return nil, fmt.Errorf("file too small for NES trainer: %d bytes", size)
+	}
+	return io.NewSectionReader(r, offset, size-offset), nil
+}
+
+// encodeNES20ROMSize is the inverse of calculateNES20ROMSize, preferring the
+// plain unit count and falling back to exponent-multiplier notation.
+func encodeNES20ROMSize(size, unit int) (lsb, msb byte, err error) {
+	if size < 0 {
+		return 0, 0, fmt.Errorf("size %d out of range", size)
+	}
+	if size%unit == 0 && size/unit < 0xF00 {
+		units := size / unit
+		return byte(units), byte(units >> 8), nil
+	}
+	for _, multiplier := range []int{1, 3, 5, 7} {
+		for exponent := range 62 {
+			if (1<<exponent)*multiplier == size {
+				return byte(exponent<<2 | (multiplier-1)/2), 0x0F, nil
+			}
+			if (1<<exponent)*multiplier > size {
+				break
+			}
+		}
+	}
+	return 0, 0, fmt.Errorf("size %d can't be encoded", size)
+}
+
+// encodeNES20RAMSize is the inverse of calculateNES20RAMSize.
+func encodeNES20RAMSize(size int) (byte, error) {
+	if size == 0 {
+		return 0, nil
+	}
+	for shift := byte(1); shift <= 0x0F; shift++ {
+		if 64<<shift == size {
+			return shift, nil
+		}
+	}
+	return 0, fmt.Errorf("RAM size %d can't be encoded", size)
+}
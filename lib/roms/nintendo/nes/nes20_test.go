@@ -0,0 +1,214 @@
+package nes
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestEncodeNES20Header_SEROM(t *testing.T) {
+	rom, err := os.ReadFile("testdata/serom.nes")
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	info, err := Parse(bytes.NewReader(rom), int64(len(rom)))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	header, err := EncodeNES20Header(info)
+	if err != nil {
+		t.Fatalf("EncodeNES20Header() error = %v", err)
+	}
+	if !bytes.Equal(header, rom[:HeaderSize]) {
+		t.Errorf("EncodeNES20Header() = % X, want % X", header, rom[:HeaderSize])
+	}
+}
+
+func TestEncodeNES20Header_RoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		info Info
+	}{
+		{
+			name: "large mapper and exponent ROM sizes",
+			info: Info{
+				PRGROMSize: 3 * 1024, CHRROMSize: 7 * 1024 * 1024,
+				PRGNVRAMSize: 32 * 1024, CHRRAMSize: 8 * 1024,
+				Mapper: 0x123, Submapper: 5, Mirroring: MirroringVertical,
+				HasBattery: true, TimingMode: TimingMulti, ExpansionDevice: 0x2A,
+				MiscROMs: 1,
+			},
+		},
+		{
+			name: "Vs. System",
+			info: Info{
+				PRGROMSize: 32 * 1024, CHRROMSize: 16 * 1024, Mapper: 99,
+				ConsoleType: ConsoleVsSystem, VsPPUType: VsPPURC2C05_02,
+				VsHardwareType: VsHardwareUnisystemTKO, FourScreen: true,
+			},
+		},
+		{
+			name: "extended console",
+			info: Info{
+				PRGROMSize: 16 * 1024, ConsoleType: ConsoleExtended,
+				ExtendedConsoleType: ExtendedVT03, TimingMode: TimingDendy,
+				HasTrainer: true,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := tt.info
+			want.IsNES20 = true
+
+			header, err := EncodeNES20Header(&want)
+			if err != nil {
+				t.Fatalf("EncodeNES20Header() error = %v", err)
+			}
+			got, err := Parse(bytes.NewReader(header), int64(len(header)))
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if *got != want {
+				t.Errorf("Parse(EncodeNES20Header()) = %+v, want %+v", *got, want)
+			}
+		})
+	}
+}
+
+func TestEncodeNES20Header_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		info Info
+	}{
+		{"mapper", Info{Mapper: 0x1000}},
+		{"submapper", Info{Submapper: 16}},
+		{"ROM size", Info{PRGROMSize: 9}},
+		{"RAM size", Info{PRGRAMSize: 1000}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := EncodeNES20Header(&tt.info); err == nil {
+				t.Error("EncodeNES20Header() expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestUpgradeToNES20(t *testing.T) {
+	rom, err := os.ReadFile("testdata/BombSweeper.nes")
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	info, err := Parse(bytes.NewReader(rom), int64(len(rom)))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	info.PRGRAMSize = 0
+	info.TimingMode = TimingMulti
+	info.ExpansionDevice = 1
+
+	var out bytes.Buffer
+	if err := UpgradeToNES20(bytes.NewReader(rom), int64(len(rom)), &out, info); err != nil {
+		t.Fatalf("UpgradeToNES20() error = %v", err)
+	}
+	upgraded := out.Bytes()
+
+	if !bytes.Equal(upgraded[HeaderSize:], rom[HeaderSize:]) {
+		t.Error("UpgradeToNES20() changed the ROM data")
+	}
+	got, err := Parse(bytes.NewReader(upgraded), int64(len(upgraded)))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if !got.IsNES20 || got.PRGRAMSize != 0 || got.TimingMode != TimingMulti || got.ExpansionDevice != 1 {
+		t.Errorf("upgraded header = %+v, want NES 2.0 with no PRG-RAM, multi-region timing, and expansion device 1", got)
+	}
+
+	// An upgraded ROM can't be upgraded again
+	if err := UpgradeToNES20(bytes.NewReader(upgraded), int64(len(upgraded)), &out, got); err == nil {
+		t.Error("UpgradeToNES20() of NES 2.0 ROM expected error, got nil")
+	}
+
+	// The ROM sizes must match
+	info.CHRROMSize = 0
+	if err := UpgradeToNES20(bytes.NewReader(rom), int64(len(rom)), &out, info); err == nil {
+		t.Error("UpgradeToNES20() with wrong CHR-ROM size expected error, got nil")
+	}
+}
+
+func TestDatabase_Lookup(t *testing.T) {
+	rom, err := os.ReadFile("testdata/BombSweeper.nes")
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	sum := sha1.Sum(rom[HeaderSize:])
+
+	db, err := ParseDatabase(strings.NewReader(fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<nes20db date="2024-01-01">
+	<game>
+		<!-- BombSweeper.nes -->
+		<prgrom size="16384" crc32="00000000" sha1="0000000000000000000000000000000000000000"/>
+		<chrrom size="8192" crc32="00000000" sha1="0000000000000000000000000000000000000000"/>
+		<rom size="24576" crc32="00000000" sha1="%s"/>
+		<pcb mapper="0" submapper="0" mirroring="V" battery="0"/>
+		<console type="0" region="2"/>
+		<expansion type="1"/>
+	</game>
+	<game>
+		<rom size="24576" crc32="00000000" sha1="1111111111111111111111111111111111111111"/>
+		<pcb mapper="1" submapper="0" mirroring="H" battery="1"/>
+		<prgnvram size="8192"/>
+		<console type="1" region="0"/>
+		<vs hardware="2" ppu="3"/>
+	</game>
+</nes20db>
+`, strings.ToUpper(hex.EncodeToString(sum[:])))))
+	if err != nil {
+		t.Fatalf("ParseDatabase() error = %v", err)
+	}
+
+	info, err := db.Lookup(bytes.NewReader(rom), int64(len(rom)))
+	if err != nil {
+		t.Fatalf("Lookup() error = %v", err)
+	}
+	if info == nil {
+		t.Fatal("Lookup() = nil, want BombSweeper")
+	}
+	want := Info{
+		PRGROMSize: 16 * 1024, CHRROMSize: 8 * 1024, Mirroring: MirroringVertical,
+		TimingMode: TimingMulti, ExpansionDevice: 1, IsNES20: true,
+	}
+	if *info != want {
+		t.Errorf("Lookup() = %+v, want %+v", *info, want)
+	}
+
+	vs := db.LookupSHA1("1111111111111111111111111111111111111111")
+	if vs == nil || vs.ConsoleType != ConsoleVsSystem || vs.VsHardwareType != VsHardwareUnisystemTKO || vs.VsPPUType != VsPPURP2C04_0002 || !vs.HasBattery || vs.PRGNVRAMSize != 8192 {
+		t.Errorf("LookupSHA1() = %+v, want Vs. System game", vs)
+	}
+
+	// The database entry upgrades the ROM
+	var out bytes.Buffer
+	if err := UpgradeToNES20(bytes.NewReader(rom), int64(len(rom)), &out, info); err != nil {
+		t.Fatalf("UpgradeToNES20() error = %v", err)
+	}
+	got, err := Parse(bytes.NewReader(out.Bytes()), int64(out.Len()))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if *got != want {
+		t.Errorf("upgraded header = %+v, want %+v", *got, want)
+	}
+
+	if info := db.LookupSHA1("2222222222222222222222222222222222222222"); info != nil {
+		t.Errorf("LookupSHA1() of unknown ROM = %+v, want nil", info)
+	}
+}
@@ -0,0 +1,164 @@
+package nes
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// NES 2.0 header database support.
+//
+// The NES 2.0 XML database (nes20db.xml) lists the correct header fields of
+// known cartridges, keyed by the hash of their ROM data (PRG-ROM, CHR-ROM,
+// and miscellaneous ROMs, without the header or trainer):
+//
+//	<nes20db>
+//	  <game>
+//	    <prgrom size="32768" crc32="..." sha1="..."/>
+//	    <chrrom size="8192" crc32="..." sha1="..."/>
+//	    <rom size="40960" crc32="..." sha1="..."/>
+//	    <pcb mapper="0" submapper="0" mirroring="V" battery="0"/>
+//	    <prgram size="8192"/>
+//	    <console type="0" region="0"/>
+//	    <expansion type="1"/>
+//	  </game>
+//	</nes20db>
+
+// Database is a NES 2.0 header database.
+type Database struct {
+	games map[string]*Info // by lowercase SHA1 of the ROM data
+}
+
+// dbSize is an element of the database with just a size.
+type dbSize struct {
+	Size int `xml:"size,attr"`
+}
+
+// dbGame is a game in the database.
+type dbGame struct {
+	PRGROM  dbSize  `xml:"prgrom"`
+	CHRROM  dbSize  `xml:"chrrom"`
+	Trainer *dbSize `xml:"trainer"`
+	ROM     struct {
+		SHA1 string `xml:"sha1,attr"`
+	} `xml:"rom"`
+	PCB struct {
+		Mapper    int    `xml:"mapper,attr"`
+		Submapper int    `xml:"submapper,attr"`
+		Mirroring string `xml:"mirroring,attr"`
+		Battery   int    `xml:"battery,attr"`
+	} `xml:"pcb"`
+	PRGRAM   dbSize `xml:"prgram"`
+	PRGNVRAM dbSize `xml:"prgnvram"`
+	CHRRAM   dbSize `xml:"chrram"`
+	CHRNVRAM dbSize `xml:"chrnvram"`
+	Console  struct {
+		Type   int `xml:"type,attr"`
+		Region int `xml:"region,attr"`
+	} `xml:"console"`
+	Vs struct {
+		Hardware int `xml:"hardware,attr"`
+		PPU      int `xml:"ppu,attr"`
+	} `xml:"vs"`
+	MiscROMs struct {
+		Number int `xml:"number,attr"`
+	} `xml:"miscrom"`
+	Expansion struct {
+		Type int `xml:"type,attr"`
+	} `xml:"expansion"`
+}
+
+// info converts the game's fields to an Info.
+func (g *dbGame) info() *Info {
+	info := &Info{
+		PRGROMSize:   g.PRGROM.Size,
+		CHRROMSize:   g.CHRROM.Size,
+		PRGRAMSize:   g.PRGRAM.Size,
+		PRGNVRAMSize: g.PRGNVRAM.Size,
+		CHRRAMSize:   g.CHRRAM.Size,
+		CHRNVRAMSize: g.CHRNVRAM.Size,
+
+		Mapper:     g.PCB.Mapper,
+		Submapper:  g.PCB.Submapper,
+		HasBattery: g.PCB.Battery != 0,
+		HasTrainer: g.Trainer != nil,
+
+		TimingMode:      TimingMode(g.Console.Region),
+		ExpansionDevice: byte(g.Expansion.Type),
+		MiscROMs:        g.MiscROMs.Number,
+		IsNES20:         true,
+	}
+
+	// "H" and "V" are the mirroring, "4" is four-screen VRAM
+	switch g.PCB.Mirroring {
+	case "V":
+		info.Mirroring = MirroringVertical
+	case "4":
+		info.FourScreen = true
+	}
+
+	// Console types past PlayChoice-10 are extended console types
+	switch {
+	case g.Console.Type == int(ConsoleVsSystem):
+		info.ConsoleType = ConsoleVsSystem
+		info.VsHardwareType = VsHardwareType(g.Vs.Hardware)
+		info.VsPPUType = VsPPUType(g.Vs.PPU)
+	case g.Console.Type >= int(ConsoleExtended):
+		info.ConsoleType = ConsoleExtended
+		info.ExtendedConsoleType = ExtendedConsoleType(g.Console.Type)
+	default:
+		info.ConsoleType = ConsoleType(g.Console.Type)
+	}
+
+	return info
+}
+
+// ParseDatabase parses a NES 2.0 XML database.
+func ParseDatabase(r io.Reader) (*Database, error) {
+	var raw struct {
+		XMLName xml.Name `xml:"nes20db"`
+		Games   []dbGame `xml:"game"`
+	}
+	if err := xml.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("failed to parse NES 2.0 database: %w", err)
+	}
+
+	db := &Database{games: make(map[string]*Info, len(raw.Games))}
+	for i := range raw.Games {
+		g := &raw.Games[i]
+		if g.ROM.SHA1 == "" {
+			continue
+		}
+		db.games[strings.ToLower(g.ROM.SHA1)] = g.info()
+	}
+	return db, nil
+}
+
+// Lookup returns the header fields for the NES ROM in r, or nil if it isn't
+// in the database. The ROM's own header is ignored, apart from finding where
+// its ROM data starts.
+func (db *Database) Lookup(r io.ReaderAt, size int64) (*Info, error) {
+	data, err := romData(r, size)
+	if err != nil {
+		return nil, err
+	}
+	h := sha1.New()
+	if _, err := io.Copy(h, data); err != nil {
+		return nil, fmt.Errorf("failed to hash NES ROM data: %w", err)
+	}
+	return db.LookupSHA1(hex.EncodeToString(h.Sum(nil))), nil
+}
+
+// LookupSHA1 returns the header fields for the ROM data with the given SHA1
+// (hex encoded), or nil if it isn't in the database.
+func (db *Database) LookupSHA1(sum string) *Info {
+	info, ok := db.games[strings.ToLower(sum)]
+	if !ok {
+		return nil
+	}
+	found := *info
+	return &found
+}
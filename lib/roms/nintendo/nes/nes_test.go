@@ -141,8 +141,8 @@ func TestParse_NES20_SEROM(t *testing.T) {
 
 // makeSyntheticNES creates a synthetic NES ROM header with specified parameters.
 func makeSyntheticNES(header []byte) []byte {
-	if len(header) < nesHeaderSize {
-		h := make([]byte, nesHeaderSize)
+	if len(header) < HeaderSize {
+		h := make([]byte, HeaderSize)
 		copy(h, header)
 		return h
 	}
@@ -595,7 +595,7 @@ func TestParse_TooSmall(t *testing.T) {
 }
 
 func TestParse_InvalidMagic(t *testing.T) {
-	header := make([]byte, nesHeaderSize)
+	header := make([]byte, HeaderSize)
 	header[0] = 'X'
 	header[1] = 'E'
 	header[2] = 'S'
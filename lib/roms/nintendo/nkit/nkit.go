@@ -0,0 +1,116 @@
+package nkit
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/sargunv/rom-tools/internal/util"
+	"github.com/sargunv/rom-tools/lib/core"
+	"github.com/sargunv/rom-tools/lib/roms/nintendo/gcm"
+)
+
+// NKit image parsing.
+//
+// NKit images are GameCube and Wii discs with junk and padding data removed,
+// stored as a plain disc image (.nkit.iso) or inside a GCZ (.nkit.gcz). The
+// removed data can be regenerated to restore the original disc, so NKit
+// records the original image's size and CRC32 in the unused area of the disc
+// header, which DATs can be matched against.
+//
+// Format reference: NKit (https://wiki.gbatemp.net/wiki/NKit)
+//
+// NKit header layout (in the disc header, big-endian):
+//
+//	Offset  Size  Description
+//	0x200   4     Magic "NKIT"
+//	0x204   4     Version (ASCII, e.g. " v01")
+//	0x208   4     CRC32 of the original disc image
+//	0x20C   4     CRC32 of the NKit image data
+//	0x210   4     Size of the original disc image
+//	0x214   4     Junk ID (game ID used to regenerate junk data)
+//	0x218   4     CRC32 of the Wii update partition (0 for GameCube)
+
+const (
+	nkitMagic = "NKIT"
+
+	headerOffset      = 0x200
+	headerSize        = 0x1C
+	versionOffset     = 0x04
+	versionLen        = 4
+	isoCRC32Offset    = 0x08
+	dataCRC32Offset   = 0x0C
+	isoSizeOffset     = 0x10
+	junkIDOffset      = 0x14
+	junkIDLen         = 4
+	updateCRC32Offset = 0x18
+	nkitHeaderEnd     = headerOffset + headerSize
+)
+
+// Info contains metadata extracted from an NKit image.
+type Info struct {
+	// GCM contains the game identification info parsed from the disc header.
+	GCM *gcm.Info `json:"gcm,omitempty"`
+	// Version is the NKit format version.
+	Version string `json:"version"`
+	// ISOCRC32 is the CRC32 of the original disc image.
+	ISOCRC32 uint32 `json:"iso_crc32"`
+	// DataCRC32 is the CRC32 of the NKit image data.
+	DataCRC32 uint32 `json:"data_crc32"`
+	// ISOSize is the size of the original disc image in bytes.
+	ISOSize int64 `json:"iso_size"`
+	// JunkID is the game ID used to regenerate junk data, if it differs from
+	// the disc's.
+	JunkID string `json:"junk_id,omitempty"`
+	// UpdatePartitionCRC32 is the CRC32 of the Wii update partition, which
+	// NKit removes. Zero for GameCube discs.
+	UpdatePartitionCRC32 uint32 `json:"update_partition_crc32,omitempty"`
+}
+
+// GamePlatform implements core.GameInfo by delegating to GCM.
+func (i *Info) GamePlatform() core.Platform { return i.GCM.GamePlatform() }
+
+// GameTitle implements core.GameInfo by delegating to GCM.
+func (i *Info) GameTitle() string { return i.GCM.GameTitle() }
+
+// GameSerial implements core.GameInfo by delegating to GCM.
+func (i *Info) GameSerial() string { return i.GCM.GameSerial() }
+
+// GameRegions implements core.GameInfo by delegating to GCM.
+func (i *Info) GameRegions() []core.Region { return i.GCM.GameRegions() }
+
+// Parse extracts game and original image information from an NKit disc
+// image. Plain GameCube and Wii disc images are rejected.
+func Parse(r io.ReaderAt, size int64) (*Info, error) {
+	if size < nkitHeaderEnd {
+		return nil, fmt.Errorf("file too small for NKit header: %d bytes", size)
+	}
+
+	header := make([]byte, headerSize)
+	if _, err := r.ReadAt(header, headerOffset); err != nil {
+		return nil, fmt.Errorf("failed to read NKit header: %w", err)
+	}
+	if string(header[0:4]) != nkitMagic {
+		return nil, fmt.Errorf("not a valid NKit image: invalid magic")
+	}
+
+	isoSize := int64(binary.BigEndian.Uint32(header[isoSizeOffset:]))
+	if isoSize == 0 {
+		return nil, fmt.Errorf("not a valid NKit image: invalid original size %d", isoSize)
+	}
+
+	gcmInfo, err := gcm.Parse(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse disc header from NKit image: %w", err)
+	}
+
+	return &Info{
+		GCM:                  gcmInfo,
+		Version:              util.ExtractASCII(header[versionOffset : versionOffset+versionLen]),
+		ISOCRC32:             binary.BigEndian.Uint32(header[isoCRC32Offset:]),
+		DataCRC32:            binary.BigEndian.Uint32(header[dataCRC32Offset:]),
+		ISOSize:              isoSize,
+		JunkID:               util.ExtractASCII(header[junkIDOffset : junkIDOffset+junkIDLen]),
+		UpdatePartitionCRC32: binary.BigEndian.Uint32(header[updateCRC32Offset:]),
+	}, nil
+}
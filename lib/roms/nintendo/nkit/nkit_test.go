@@ -0,0 +1,92 @@
+package nkit
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/sargunv/rom-tools/lib/core"
+)
+
+// makeTestNKit creates a Wii disc header with an NKit header.
+func makeTestNKit() []byte {
+	data := make([]byte, 0x440)
+	copy(data, "RMCE01")
+	binary.BigEndian.PutUint32(data[0x18:], 0x5D1C9EA3) // Wii magic
+	copy(data[0x20:], "MARIO KART WII")
+
+	header := data[headerOffset:]
+	copy(header, nkitMagic)
+	copy(header[versionOffset:], " v01")
+	binary.BigEndian.PutUint32(header[isoCRC32Offset:], 0x12345678)
+	binary.BigEndian.PutUint32(header[dataCRC32Offset:], 0x9ABCDEF0)
+	binary.BigEndian.PutUint32(header[isoSizeOffset:], 0x182D8000)
+	binary.BigEndian.PutUint32(header[updateCRC32Offset:], 0x0BADF00D)
+	return data
+}
+
+func TestParse(t *testing.T) {
+	data := makeTestNKit()
+
+	info, err := Parse(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if info.Version != "v01" {
+		t.Errorf("Version = %q, want %q", info.Version, "v01")
+	}
+	if info.ISOCRC32 != 0x12345678 {
+		t.Errorf("ISOCRC32 = %#x, want 0x12345678", info.ISOCRC32)
+	}
+	if info.DataCRC32 != 0x9ABCDEF0 {
+		t.Errorf("DataCRC32 = %#x, want 0x9abcdef0", info.DataCRC32)
+	}
+	if info.ISOSize != 0x182D8000 {
+		t.Errorf("ISOSize = %#x, want 0x182d8000", info.ISOSize)
+	}
+	if info.UpdatePartitionCRC32 != 0x0BADF00D {
+		t.Errorf("UpdatePartitionCRC32 = %#x, want 0xbadf00d", info.UpdatePartitionCRC32)
+	}
+	if info.JunkID != "" {
+		t.Errorf("JunkID = %q, want empty", info.JunkID)
+	}
+	if got := info.GamePlatform(); got != core.PlatformWii {
+		t.Errorf("GamePlatform() = %v, want %v", got, core.PlatformWii)
+	}
+	if got := info.GameSerial(); got != "RMCE" {
+		t.Errorf("GameSerial() = %q, want %q", got, "RMCE")
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	valid := makeTestNKit()
+
+	// A plain disc image without an NKit header
+	plain := bytes.Clone(valid)
+	clear(plain[headerOffset:])
+
+	noSize := bytes.Clone(valid)
+	binary.BigEndian.PutUint32(noSize[headerOffset+isoSizeOffset:], 0)
+
+	noDiscMagic := bytes.Clone(valid)
+	binary.BigEndian.PutUint32(noDiscMagic[0x18:], 0)
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"too small", make([]byte, 0x100)},
+		{"plain disc", plain},
+		{"no original size", noSize},
+		{"invalid disc header", noDiscMagic},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(bytes.NewReader(tt.data), int64(len(tt.data))); err == nil {
+				t.Error("Parse() expected error, got nil")
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package rvz
+
+import "encoding/binary"
+
+// Lagged Fibonacci generator used by RVZ to regenerate the junk padding data
+// of Nintendo discs, ported from Dolphin's LaggedFibonacciGenerator.
+//
+// The generator has a state of 521 32-bit words. It's seeded with 17 words,
+// from which the rest of the state is derived, then advanced 4 times before
+// output. Output is the state's bytes; the state advances after each full
+// pass over it.
+
+const (
+	lfgSeedSize = 17
+	lfgK        = 521
+	lfgJ        = 32
+	lfgBytes    = lfgK * 4
+)
+
+type laggedFibonacci struct {
+	buffer   [lfgK]uint32
+	position int
+}
+
+// seed initializes the generator from a 68-byte big-endian seed.
+func (g *laggedFibonacci) seed(seed []byte) {
+	g.position = 0
+	for i := range lfgSeedSize {
+		g.buffer[i] = binary.BigEndian.Uint32(seed[i*4:])
+	}
+	for i := lfgSeedSize; i < lfgK; i++ {
+		g.buffer[i] = (g.buffer[i-17] << 23) ^ (g.buffer[i-16] >> 9) ^ g.buffer[i-1]
+	}
+
+	// The generated data uses bits 18-25 in place of bits 16-23 of each word
+	for i, x := range g.buffer {
+		g.buffer[i] = (x & 0xFF00FFFF) | ((x >> 2) & 0x00FF0000)
+	}
+
+	for range 4 {
+		g.forward()
+	}
+}
+
+// forward advances the generator state.
+func (g *laggedFibonacci) forward() {
+	for i := range lfgJ {
+		g.buffer[i] ^= g.buffer[i+lfgK-lfgJ]
+	}
+	for i := lfgJ; i < lfgK; i++ {
+		g.buffer[i] ^= g.buffer[i-lfgJ]
+	}
+}
+
+// skip discards n bytes of output.
+func (g *laggedFibonacci) skip(n int) {
+	g.position += n
+	for g.position >= lfgBytes {
+		g.forward()
+		g.position -= lfgBytes
+	}
+}
+
+// read fills p with generated bytes.
+func (g *laggedFibonacci) read(p []byte) {
+	for len(p) > 0 {
+		word := g.position / 4
+		var b [4]byte
+		binary.BigEndian.PutUint32(b[:], g.buffer[word])
+		n := copy(p, b[g.position%4:])
+		p = p[n:]
+		g.skip(n)
+	}
+}
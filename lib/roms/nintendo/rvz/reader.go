@@ -0,0 +1,553 @@
+package rvz
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz/lzma"
+)
+
+// Full RVZ/WIA data model, for reading the original disc image.
+//
+// wia_disc_t continued (offsets relative to discStructBase):
+//
+//	Offset  Size  Description
+//	0x90    4     Number of partition entries
+//	0x94    4     Size of a partition entry
+//	0x98    8     Partition entries offset
+//	0xA0    20    SHA-1 hash of partition entries
+//	0xB4    4     Number of raw data entries
+//	0xB8    8     Raw data entries offset
+//	0xC0    4     Raw data entries size (compressed)
+//	0xC4    4     Number of group entries
+//	0xC8    8     Group entries offset
+//	0xD0    4     Group entries size (compressed)
+//	0xD4    1     Compressor data length
+//	0xD5    7     Compressor data (e.g., LZMA properties)
+//
+// wia_part_t (0x30 bytes): 16-byte title key, then two wia_part_data_t:
+//
+//	Offset  Size  Description
+//	0x00    4     First sector (0x8000 bytes each)
+//	0x04    4     Number of sectors
+//	0x08    4     First group index
+//	0x0C    4     Number of groups
+//
+// wia_raw_data_t (0x18 bytes):
+//
+//	Offset  Size  Description
+//	0x00    8     Disc offset
+//	0x08    8     Size
+//	0x10    4     First group index
+//	0x14    4     Number of groups
+//
+// wia_group_t (8 bytes) / rvz_group_t (12 bytes):
+//
+//	Offset  Size  Description
+//	0x00    4     Data offset in file, divided by 4
+//	0x04    4     Data size (RVZ: bit 31 set = compressed)
+//	0x08    4     RVZ only: size of RVZ-packed data after decompression
+//
+// Partition entries are stored uncompressed; raw data and group entries are
+// compressed with the disc's compression method. A raw data region's groups
+// start at its offset rounded down to a 0x8000-byte sector.
+//
+// Wii partition data is stored decrypted and without hash blocks. Rebuilding
+// it requires re-encryption with the title key, which this reader doesn't do:
+// reads from Wii partition data return an error. GameCube discs and the
+// unencrypted areas of Wii discs can be read in full.
+
+const (
+	discNumPartOffset     = 0x90
+	discPartEntrySizeOff  = 0x94
+	discPartOffOffset     = 0x98
+	discNumRawDataOffset  = 0xB4
+	discRawDataOffOffset  = 0xB8
+	discRawDataSizeOffset = 0xC0
+	discNumGroupsOffset   = 0xC4
+	discGroupOffOffset    = 0xC8
+	discGroupSizeOffset   = 0xD0
+	discComprDataLenOff   = 0xD4
+	discComprDataOffset   = 0xD5
+	discComprDataMaxLen   = 7
+	discStructSize        = 0xDC
+
+	partEntryMinSize = 0x30
+	partKeySize      = 0x10
+	partDataSize     = 0x10
+	rawDataEntrySize = 0x18
+	wiaGroupSize     = 8
+	rvzGroupSize     = 12
+
+	wiiSectorSize        = 0x8000
+	rvzCompressedFlag    = 0x80000000
+	rvzPackJunkFlag      = 0x80000000
+	purgeHashSize        = 20
+	purgeSegmentHeadSize = 8
+)
+
+// Region kinds
+const (
+	regionRaw = iota
+	regionPartition
+)
+
+// region is a span of the disc image stored as a run of groups.
+type region struct {
+	kind       int
+	start, end int64 // disc offsets covered
+	dataStart  int64 // disc offset of the first group's data
+	firstGroup uint32
+	numGroups  uint32
+}
+
+// group is a group entry from the group table.
+type group struct {
+	offset     int64
+	size       uint32
+	compressed bool
+	packedSize uint32
+}
+
+// Reader provides random access to the original disc image stored in an
+// RVZ/WIA file.
+type Reader struct {
+	r           io.ReaderAt
+	isRVZ       bool
+	compression Compression
+	comprData   []byte
+	chunkSize   int64
+	size        int64
+	dhead       []byte
+	regions     []region
+	groups      []group
+
+	// The most recently decompressed group, since reads are usually sequential
+	cacheMu    sync.Mutex
+	cacheGroup int64
+	cacheData  []byte
+}
+
+// NewReader opens an RVZ/WIA file for reading the original disc image.
+func NewReader(r io.ReaderAt, size int64) (*Reader, error) {
+	headerSize := int64(fileHeadSize + discStructSize)
+	if size < headerSize {
+		return nil, fmt.Errorf("file too small for RVZ header: need %d bytes, got %d", headerSize, size)
+	}
+
+	header := make([]byte, headerSize)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, fmt.Errorf("failed to read RVZ header: %w", err)
+	}
+
+	magic := string(header[magicOffset : magicOffset+4])
+	if magic != "WIA\x01" && magic != "RVZ\x01" {
+		return nil, fmt.Errorf("not a valid RVZ/WIA file: invalid magic (got %q)", magic)
+	}
+
+	disc := header[discStructBase:]
+	comprLen := min(int(disc[discComprDataLenOff]), discComprDataMaxLen)
+	reader := &Reader{
+		r:           r,
+		isRVZ:       magic == "RVZ\x01",
+		compression: Compression(binary.BigEndian.Uint32(disc[compressionOffset:])),
+		comprData:   bytes.Clone(disc[discComprDataOffset : discComprDataOffset+comprLen]),
+		chunkSize:   int64(binary.BigEndian.Uint32(disc[chunkSizeOffset:])),
+		size:        int64(binary.BigEndian.Uint64(header[isoFileSizeOffset:])),
+		dhead:       bytes.Clone(disc[dheadOffset : dheadOffset+dheadSize]),
+		cacheGroup:  -1,
+	}
+	if reader.chunkSize <= 0 {
+		return nil, fmt.Errorf("invalid RVZ chunk size: %d", reader.chunkSize)
+	}
+
+	if err := reader.readPartitions(disc, size); err != nil {
+		return nil, err
+	}
+	if err := reader.readRawData(disc, size); err != nil {
+		return nil, err
+	}
+	if err := reader.readGroups(disc, size); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(reader.regions, func(i, j int) bool { return reader.regions[i].start < reader.regions[j].start })
+	for _, reg := range reader.regions {
+		if uint64(reg.firstGroup)+uint64(reg.numGroups) > uint64(len(reader.groups)) {
+			return nil, fmt.Errorf("RVZ region at 0x%X references groups beyond group table", reg.start)
+		}
+	}
+
+	return reader, nil
+}
+
+// readPartitions reads the (uncompressed) partition entries.
+func (rd *Reader) readPartitions(disc []byte, fileSize int64) error {
+	count := int64(binary.BigEndian.Uint32(disc[discNumPartOffset:]))
+	entrySize := int64(binary.BigEndian.Uint32(disc[discPartEntrySizeOff:]))
+	offset := int64(binary.BigEndian.Uint64(disc[discPartOffOffset:]))
+	if count == 0 {
+		return nil
+	}
+	if entrySize < partEntryMinSize || offset < 0 || offset+count*entrySize > fileSize {
+		return fmt.Errorf("RVZ partition entries extend beyond file")
+	}
+
+	data := make([]byte, count*entrySize)
+	if _, err := rd.r.ReadAt(data, offset); err != nil {
+		return fmt.Errorf("failed to read RVZ partition entries: %w", err)
+	}
+
+	for i := range count {
+		entry := data[i*entrySize:]
+		for j := range 2 {
+			pd := entry[partKeySize+j*partDataSize:]
+			firstSector := int64(binary.BigEndian.Uint32(pd[0:]))
+			numSectors := int64(binary.BigEndian.Uint32(pd[4:]))
+			if numSectors == 0 {
+				continue
+			}
+			rd.regions = append(rd.regions, region{
+				kind:       regionPartition,
+				start:      firstSector * wiiSectorSize,
+				end:        (firstSector + numSectors) * wiiSectorSize,
+				dataStart:  firstSector * wiiSectorSize,
+				firstGroup: binary.BigEndian.Uint32(pd[8:]),
+				numGroups:  binary.BigEndian.Uint32(pd[12:]),
+			})
+		}
+	}
+	return nil
+}
+
+// readRawData reads the compressed raw data entries.
+func (rd *Reader) readRawData(disc []byte, fileSize int64) error {
+	count := int(binary.BigEndian.Uint32(disc[discNumRawDataOffset:]))
+	offset := int64(binary.BigEndian.Uint64(disc[discRawDataOffOffset:]))
+	stored := int64(binary.BigEndian.Uint32(disc[discRawDataSizeOffset:]))
+
+	data, err := rd.readTable(offset, stored, count*rawDataEntrySize, fileSize)
+	if err != nil {
+		return fmt.Errorf("failed to read RVZ raw data entries: %w", err)
+	}
+
+	for i := range count {
+		entry := data[i*rawDataEntrySize:]
+		start := int64(binary.BigEndian.Uint64(entry[0:]))
+		length := int64(binary.BigEndian.Uint64(entry[8:]))
+		if length == 0 {
+			continue
+		}
+		rd.regions = append(rd.regions, region{
+			kind:       regionRaw,
+			start:      start,
+			end:        start + length,
+			dataStart:  start - start%wiiSectorSize,
+			firstGroup: binary.BigEndian.Uint32(entry[16:]),
+			numGroups:  binary.BigEndian.Uint32(entry[20:]),
+		})
+	}
+	return nil
+}
+
+// readGroups reads the compressed group entries.
+func (rd *Reader) readGroups(disc []byte, fileSize int64) error {
+	count := int(binary.BigEndian.Uint32(disc[discNumGroupsOffset:]))
+	offset := int64(binary.BigEndian.Uint64(disc[discGroupOffOffset:]))
+	stored := int64(binary.BigEndian.Uint32(disc[discGroupSizeOffset:]))
+
+	entrySize := wiaGroupSize
+	if rd.isRVZ {
+		entrySize = rvzGroupSize
+	}
+
+	data, err := rd.readTable(offset, stored, count*entrySize, fileSize)
+	if err != nil {
+		return fmt.Errorf("failed to read RVZ group entries: %w", err)
+	}
+
+	rd.groups = make([]group, count)
+	for i := range rd.groups {
+		entry := data[i*entrySize:]
+		size := binary.BigEndian.Uint32(entry[4:])
+		g := group{
+			offset:     int64(binary.BigEndian.Uint32(entry[0:])) << 2,
+			size:       size,
+			compressed: true,
+		}
+		if rd.isRVZ {
+			g.size = size &^ rvzCompressedFlag
+			g.compressed = size&rvzCompressedFlag != 0
+			g.packedSize = binary.BigEndian.Uint32(entry[8:])
+		}
+		if g.offset+int64(g.size) > fileSize {
+			return fmt.Errorf("RVZ group %d extends beyond file", i)
+		}
+		rd.groups[i] = g
+	}
+	return nil
+}
+
+// readTable reads and decompresses a table stored at offset.
+func (rd *Reader) readTable(offset, stored int64, want int, fileSize int64) ([]byte, error) {
+	if want == 0 {
+		return nil, nil
+	}
+	if offset < 0 || stored < 0 || offset+stored > fileSize {
+		return nil, fmt.Errorf("table extends beyond file")
+	}
+
+	raw := make([]byte, stored)
+	if _, err := rd.r.ReadAt(raw, offset); err != nil {
+		return nil, err
+	}
+
+	data, err := rd.decompress(raw, want)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < want {
+		return nil, fmt.Errorf("table truncated: %d bytes, want %d", len(data), want)
+	}
+	return data, nil
+}
+
+// Size returns the size of the original disc image in bytes.
+func (rd *Reader) Size() int64 {
+	return rd.size
+}
+
+// ReadAt implements io.ReaderAt, reading from the original disc image.
+// Areas of the disc not covered by any region read as zeros.
+func (rd *Reader) ReadAt(p []byte, off int64) (n int, err error) {
+	if off < 0 {
+		return 0, fmt.Errorf("negative offset")
+	}
+
+	for n < len(p) && off < rd.size {
+		want := min(int64(len(p)-n), rd.size-off)
+
+		// The first bytes of the disc are stored in the header
+		if off < dheadSize {
+			copied := copy(p[n:n+int(min(want, dheadSize-off))], rd.dhead[off:])
+			n += copied
+			off += int64(copied)
+			continue
+		}
+
+		i := sort.Search(len(rd.regions), func(i int) bool { return rd.regions[i].end > off })
+		if i == len(rd.regions) || rd.regions[i].start > off {
+			// Not covered by any region
+			gap := want
+			if i < len(rd.regions) {
+				gap = min(gap, rd.regions[i].start-off)
+			}
+			clear(p[n : n+int(gap)])
+			n += int(gap)
+			off += gap
+			continue
+		}
+
+		reg := rd.regions[i]
+		if reg.kind == regionPartition {
+			return n, fmt.Errorf("reading Wii partition data at 0x%X not supported", off)
+		}
+
+		index := (off - reg.dataStart) / rd.chunkSize
+		groupStart := reg.dataStart + index*rd.chunkSize
+		groupEnd := min(groupStart+rd.chunkSize, reg.end)
+		if index >= int64(reg.numGroups) {
+			return n, fmt.Errorf("RVZ region at 0x%X has no group for offset 0x%X", reg.start, off)
+		}
+
+		data, err := rd.readGroup(int64(reg.firstGroup)+index, groupStart, int(groupEnd-groupStart))
+		if err != nil {
+			return n, fmt.Errorf("read group %d: %w", int64(reg.firstGroup)+index, err)
+		}
+
+		copied := copy(p[n:n+int(min(want, groupEnd-off))], data[off-groupStart:])
+		n += copied
+		off += int64(copied)
+	}
+
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// readGroup reads and decompresses a group covering size bytes at the given
+// disc offset.
+func (rd *Reader) readGroup(index, discOffset int64, size int) ([]byte, error) {
+	rd.cacheMu.Lock()
+	defer rd.cacheMu.Unlock()
+
+	if rd.cacheGroup == index {
+		return rd.cacheData, nil
+	}
+
+	g := rd.groups[index]
+	data := make([]byte, size)
+	if g.size > 0 {
+		raw := make([]byte, g.size)
+		if _, err := rd.r.ReadAt(raw, g.offset); err != nil {
+			return nil, fmt.Errorf("failed to read group data: %w", err)
+		}
+
+		decompressedSize := size
+		if g.packedSize > 0 {
+			decompressedSize = int(g.packedSize)
+		}
+		if g.compressed {
+			var err error
+			if raw, err = rd.decompress(raw, decompressedSize); err != nil {
+				return nil, fmt.Errorf("failed to decompress: %w", err)
+			}
+		}
+
+		if g.packedSize > 0 {
+			if err := unpackRVZ(raw, data, discOffset); err != nil {
+				return nil, err
+			}
+		} else {
+			if len(raw) < size {
+				return nil, fmt.Errorf("group data truncated: %d bytes, want %d", len(raw), size)
+			}
+			copy(data, raw)
+		}
+	}
+
+	rd.cacheGroup = index
+	rd.cacheData = data
+	return data, nil
+}
+
+// decompress decompresses data with the disc's compression method, returning
+// at least size bytes on success.
+func (rd *Reader) decompress(data []byte, size int) ([]byte, error) {
+	switch rd.compression {
+	case CompressionNone:
+		return data, nil
+	case CompressionPurge:
+		return decompressPurge(data, size)
+	case CompressionBZIP2:
+		return readFull(bzip2.NewReader(bytes.NewReader(data)), size)
+	case CompressionLZMA:
+		// Compressor data holds the LZMA properties and dictionary size; build a
+		// standard .lzma header around the raw stream
+		if len(rd.comprData) < 5 {
+			return nil, fmt.Errorf("missing LZMA properties")
+		}
+		header := make([]byte, lzma.HeaderLen)
+		copy(header, rd.comprData[:5])
+		binary.LittleEndian.PutUint64(header[5:], uint64(size))
+		lr, err := lzma.NewReader(io.MultiReader(bytes.NewReader(header), bytes.NewReader(data)))
+		if err != nil {
+			return nil, err
+		}
+		return readFull(lr, size)
+	case CompressionLZMA2:
+		if len(rd.comprData) < 1 {
+			return nil, fmt.Errorf("missing LZMA2 properties")
+		}
+		prop := int(rd.comprData[0])
+		if prop > 40 {
+			return nil, fmt.Errorf("invalid LZMA2 dictionary size property: %d", prop)
+		}
+		dictCap := lzma.MaxDictCap
+		if prop < 40 {
+			dictCap = (2 | prop&1) << (prop/2 + 11)
+		}
+		lr, err := lzma.Reader2Config{DictCap: max(dictCap, lzma.MinDictCap)}.NewReader2(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		return readFull(lr, size)
+	case CompressionZstandard:
+		return zstdDecoder.DecodeAll(data, make([]byte, 0, size))
+	default:
+		return nil, fmt.Errorf("compression method %d not supported", rd.compression)
+	}
+}
+
+var zstdDecoder, _ = zstd.NewReader(nil)
+
+// readFull reads exactly size bytes from r.
+func readFull(r io.Reader, size int) ([]byte, error) {
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// decompressPurge expands WIA purge-compressed data: a sequence of segments
+// (4-byte offset, 4-byte size, data) over a zero-filled buffer, followed by a
+// SHA-1 hash.
+func decompressPurge(data []byte, size int) ([]byte, error) {
+	if len(data) < purgeHashSize {
+		return nil, fmt.Errorf("purge data too small: %d bytes", len(data))
+	}
+
+	out := make([]byte, size)
+	segments := data[:len(data)-purgeHashSize]
+	for len(segments) > 0 {
+		if len(segments) < purgeSegmentHeadSize {
+			return nil, fmt.Errorf("purge segment truncated")
+		}
+		offset := int64(binary.BigEndian.Uint32(segments[0:]))
+		length := int64(binary.BigEndian.Uint32(segments[4:]))
+		segments = segments[purgeSegmentHeadSize:]
+		if length > int64(len(segments)) || offset+length > int64(size) {
+			return nil, fmt.Errorf("purge segment out of range: offset %d, size %d", offset, length)
+		}
+		copy(out[offset:], segments[:length])
+		segments = segments[length:]
+	}
+	return out, nil
+}
+
+// unpackRVZ expands RVZ-packed data into out. Packed data is a sequence of
+// entries, each a 4-byte size followed by either that many literal bytes or,
+// if bit 31 of the size is set, a 68-byte seed for generating junk data.
+// discOffset is the disc offset of out, which the junk generator depends on.
+func unpackRVZ(packed, out []byte, discOffset int64) error {
+	pos := 0
+	for pos < len(out) {
+		if len(packed) < 4 {
+			return fmt.Errorf("RVZ packed data truncated")
+		}
+		size := binary.BigEndian.Uint32(packed)
+		packed = packed[4:]
+		junk := size&rvzPackJunkFlag != 0
+		size &^= rvzPackJunkFlag
+		if int(size) > len(out)-pos {
+			return fmt.Errorf("RVZ packed entry too large: %d bytes", size)
+		}
+
+		if junk {
+			if len(packed) < lfgSeedSize*4 {
+				return fmt.Errorf("RVZ junk seed truncated")
+			}
+			var lfg laggedFibonacci
+			lfg.seed(packed[:lfgSeedSize*4])
+			lfg.skip(int((discOffset + int64(pos)) % wiiSectorSize))
+			lfg.read(out[pos : pos+int(size)])
+			packed = packed[lfgSeedSize*4:]
+		} else {
+			if len(packed) < int(size) {
+				return fmt.Errorf("RVZ packed data truncated")
+			}
+			copy(out[pos:], packed[:size])
+			packed = packed[size:]
+		}
+		pos += int(size)
+	}
+	return nil
+}
@@ -0,0 +1,273 @@
+package rvz
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz/lzma"
+
+	"github.com/sargunv/rom-tools/lib/core"
+	"github.com/sargunv/rom-tools/lib/roms/nintendo/gcm"
+)
+
+const testChunkSize = 0x10000
+
+// testGroup describes how makeTestRVZ stores one group.
+type testGroup struct {
+	empty bool   // store no data (reads as zeros)
+	raw   bool   // store uncompressed (RVZ only)
+	junk  []byte // store as RVZ-packed junk with this seed
+}
+
+// makeTestISO creates a GameCube disc image of n chunks with a recognizable
+// pattern after the disc header.
+func makeTestISO(n int) []byte {
+	iso := make([]byte, n*testChunkSize)
+	for i := range iso {
+		iso[i] = byte(i*7 + i/testChunkSize)
+	}
+	copy(iso, makeSyntheticGCMData(gcm.SystemCodeGameCube, "TS", gcm.RegionNorthAmerica, "FULL DISC TEST", false))
+	return iso
+}
+
+// makeTestRVZ stores iso as a single raw data region, one group per chunk.
+func makeTestRVZ(t *testing.T, magic string, compression Compression, iso []byte, groups map[int]testGroup) []byte {
+	t.Helper()
+
+	isRVZ := magic == "RVZ\x01"
+	var comprData []byte
+	compress := func(data []byte) []byte { return data }
+	switch compression {
+	case CompressionZstandard:
+		enc, err := zstd.NewWriter(nil)
+		if err != nil {
+			t.Fatalf("zstd.NewWriter() error = %v", err)
+		}
+		compress = func(data []byte) []byte { return enc.EncodeAll(data, nil) }
+	case CompressionLZMA2:
+		comprData = []byte{22} // 8 MiB dictionary
+		compress = func(data []byte) []byte {
+			var buf bytes.Buffer
+			w, err := lzma.Writer2Config{DictCap: 8 << 20}.NewWriter2(&buf)
+			if err != nil {
+				t.Fatalf("NewWriter2() error = %v", err)
+			}
+			w.Write(data)
+			w.Close()
+			return buf.Bytes()
+		}
+	}
+
+	numGroups := len(iso) / testChunkSize
+	rawTable := make([]byte, rawDataEntrySize)
+	binary.BigEndian.PutUint64(rawTable[0:], dheadSize)
+	binary.BigEndian.PutUint64(rawTable[8:], uint64(len(iso)-dheadSize))
+	binary.BigEndian.PutUint32(rawTable[20:], uint32(numGroups))
+	rawTableStored := compress(rawTable)
+
+	groupEntrySize := wiaGroupSize
+	if isRVZ {
+		groupEntrySize = rvzGroupSize
+	}
+	groupTable := make([]byte, numGroups*groupEntrySize)
+
+	var body bytes.Buffer
+	// Leave room for the group table; group data offsets are stored divided by 4
+	dataStart := (fileHeadSize + discStructSize + len(rawTableStored) + 0x1000 + 3) &^ 3
+	for i := range numGroups {
+		for body.Len()%4 != 0 {
+			body.WriteByte(0)
+		}
+		chunk := iso[i*testChunkSize : (i+1)*testChunkSize]
+		entry := groupTable[i*groupEntrySize:]
+		binary.BigEndian.PutUint32(entry[0:], uint32((dataStart+body.Len())>>2))
+
+		g := groups[i]
+		var stored []byte
+		var flags uint32
+		switch {
+		case g.empty:
+		case g.raw:
+			stored = chunk
+		case g.junk != nil:
+			packed := binary.BigEndian.AppendUint32(nil, uint32(len(chunk))|rvzPackJunkFlag)
+			packed = append(packed, g.junk...)
+			binary.BigEndian.PutUint32(entry[8:], uint32(len(packed)))
+			stored = compress(packed)
+			flags = rvzCompressedFlag
+		default:
+			stored = compress(chunk)
+			if isRVZ {
+				flags = rvzCompressedFlag
+			}
+		}
+		binary.BigEndian.PutUint32(entry[4:], uint32(len(stored))|flags)
+		body.Write(stored)
+	}
+	groupTableStored := compress(groupTable)
+	if len(groupTableStored) > 0x1000 {
+		t.Fatalf("group table too large for test layout: %d bytes", len(groupTableStored))
+	}
+
+	file := make([]byte, dataStart)
+	copy(file[magicOffset:], magic)
+	binary.BigEndian.PutUint32(file[versionOffset:], 0x01000000)
+	binary.BigEndian.PutUint64(file[isoFileSizeOffset:], uint64(len(iso)))
+
+	disc := file[discStructBase:]
+	binary.BigEndian.PutUint32(disc[discTypeOffset:], uint32(DiscTypeGameCube))
+	binary.BigEndian.PutUint32(disc[compressionOffset:], uint32(compression))
+	binary.BigEndian.PutUint32(disc[chunkSizeOffset:], testChunkSize)
+	copy(disc[dheadOffset:], iso[:dheadSize])
+	binary.BigEndian.PutUint32(disc[discNumRawDataOffset:], 1)
+	binary.BigEndian.PutUint64(disc[discRawDataOffOffset:], fileHeadSize+discStructSize)
+	binary.BigEndian.PutUint32(disc[discRawDataSizeOffset:], uint32(len(rawTableStored)))
+	binary.BigEndian.PutUint32(disc[discNumGroupsOffset:], uint32(numGroups))
+	binary.BigEndian.PutUint64(disc[discGroupOffOffset:], uint64(fileHeadSize+discStructSize+len(rawTableStored)))
+	binary.BigEndian.PutUint32(disc[discGroupSizeOffset:], uint32(len(groupTableStored)))
+	disc[discComprDataLenOff] = byte(len(comprData))
+	copy(disc[discComprDataOffset:], comprData)
+
+	copy(file[fileHeadSize+discStructSize:], rawTableStored)
+	copy(file[fileHeadSize+discStructSize+len(rawTableStored):], groupTableStored)
+	return append(file, body.Bytes()...)
+}
+
+// readAll reads the full disc image from a Reader.
+func readAll(t *testing.T, reader *Reader) []byte {
+	t.Helper()
+	data := make([]byte, reader.Size())
+	if _, err := reader.ReadAt(data, 0); err != nil && err != io.EOF {
+		t.Fatalf("ReadAt() error = %v", err)
+	}
+	return data
+}
+
+func TestReader_Compression(t *testing.T) {
+	tests := []struct {
+		name        string
+		magic       string
+		compression Compression
+	}{
+		{"WIA none", "WIA\x01", CompressionNone},
+		{"RVZ zstd", "RVZ\x01", CompressionZstandard},
+		{"RVZ LZMA2", "RVZ\x01", CompressionLZMA2},
+		{"WIA LZMA2", "WIA\x01", CompressionLZMA2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			iso := makeTestISO(3)
+			data := makeTestRVZ(t, tt.magic, tt.compression, iso, nil)
+
+			reader, err := NewReader(bytes.NewReader(data), int64(len(data)))
+			if err != nil {
+				t.Fatalf("NewReader() error = %v", err)
+			}
+			if reader.Size() != int64(len(iso)) {
+				t.Errorf("Size() = %d, want %d", reader.Size(), len(iso))
+			}
+			if !bytes.Equal(readAll(t, reader), iso) {
+				t.Error("decompressed image doesn't match original")
+			}
+
+			// Unaligned read across a group boundary
+			buf := make([]byte, 100)
+			if _, err := reader.ReadAt(buf, testChunkSize-50); err != nil {
+				t.Fatalf("ReadAt() error = %v", err)
+			}
+			if !bytes.Equal(buf, iso[testChunkSize-50:testChunkSize+50]) {
+				t.Error("ReadAt() across group boundary doesn't match original")
+			}
+
+			// The disc header can be parsed from the reconstructed image
+			info, err := gcm.Parse(reader, reader.Size())
+			if err != nil {
+				t.Fatalf("gcm.Parse() error = %v", err)
+			}
+			if info.GamePlatform() != core.PlatformGC || info.Title != "FULL DISC TEST" {
+				t.Errorf("gcm.Parse() = %v %q, want %v %q", info.GamePlatform(), info.Title, core.PlatformGC, "FULL DISC TEST")
+			}
+		})
+	}
+}
+
+func TestReader_RVZGroups(t *testing.T) {
+	seed := make([]byte, lfgSeedSize*4)
+	for i := range seed {
+		seed[i] = byte(i * 13)
+	}
+
+	iso := makeTestISO(4)
+	// Group 1 is junk data, regenerated from the seed at the group's disc offset
+	var lfg laggedFibonacci
+	lfg.seed(seed)
+	lfg.skip(testChunkSize % wiiSectorSize)
+	lfg.read(iso[testChunkSize : 2*testChunkSize])
+	// Group 2 is all zeros and stored empty
+	clear(iso[2*testChunkSize : 3*testChunkSize])
+
+	data := makeTestRVZ(t, "RVZ\x01", CompressionZstandard, iso, map[int]testGroup{
+		1: {junk: seed},
+		2: {empty: true},
+		3: {raw: true},
+	})
+
+	reader, err := NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("NewReader() error = %v", err)
+	}
+	if !bytes.Equal(readAll(t, reader), iso) {
+		t.Error("decompressed image doesn't match original")
+	}
+}
+
+func TestLaggedFibonacci_SkipMatchesRead(t *testing.T) {
+	seed := bytes.Repeat([]byte{0x12, 0x34, 0x56, 0x78}, lfgSeedSize)
+
+	var full laggedFibonacci
+	full.seed(seed)
+	all := make([]byte, 3*lfgBytes)
+	full.read(all)
+
+	var skipped laggedFibonacci
+	skipped.seed(seed)
+	skipped.skip(lfgBytes + 5)
+	part := make([]byte, 100)
+	skipped.read(part)
+
+	if !bytes.Equal(part, all[lfgBytes+5:lfgBytes+105]) {
+		t.Error("output after skip() doesn't match continuous output")
+	}
+}
+
+func TestDecompressPurge(t *testing.T) {
+	var data []byte
+	data = binary.BigEndian.AppendUint32(data, 4)
+	data = binary.BigEndian.AppendUint32(data, 3)
+	data = append(data, 1, 2, 3)
+	data = append(data, make([]byte, purgeHashSize)...)
+
+	out, err := decompressPurge(data, 10)
+	if err != nil {
+		t.Fatalf("decompressPurge() error = %v", err)
+	}
+	if want := []byte{0, 0, 0, 0, 1, 2, 3, 0, 0, 0}; !bytes.Equal(out, want) {
+		t.Errorf("decompressPurge() = %v, want %v", out, want)
+	}
+
+	if _, err := decompressPurge(data, 5); err == nil {
+		t.Error("decompressPurge() expected error for out-of-range segment, got nil")
+	}
+}
+
+func TestNewReader_InvalidMagic(t *testing.T) {
+	data := make([]byte, fileHeadSize+discStructSize)
+	copy(data, "XXXX")
+	if _, err := NewReader(bytes.NewReader(data), int64(len(data))); err == nil {
+		t.Error("NewReader() expected error for invalid magic, got nil")
+	}
+}
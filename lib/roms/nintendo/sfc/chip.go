@@ -0,0 +1,108 @@
+package sfc
+
+// SNES coprocessor (special chip) detection.
+//
+// The cartridge type byte (FFD6) describes the cartridge hardware. Its low
+// nibble lists the memory and whether a coprocessor is present; its high
+// nibble names the coprocessor:
+//
+//	Low nibble   Hardware
+//	0x0-0x2      ROM, RAM, battery (no coprocessor)
+//	0x3-0x6      ROM + coprocessor, with RAM and/or battery
+//	0x9-0xA      ROM + coprocessor + RAM + battery (0x9 with RTC)
+//
+//	High nibble  Coprocessor
+//	0x0          DSP (DSP-1 to DSP-4)
+//	0x1          Super FX (GSU)
+//	0x2          OBC1
+//	0x3          SA-1
+//	0x4          S-DD1
+//	0x5          S-RTC
+//	0xE          Other (Super Game Boy, Satellaview)
+//	0xF          Custom, named by the cartridge sub-type (FFBF):
+//	             0x00 = SPC7110, 0x01 = ST010/ST011, 0x02 = ST018, 0x10 = CX4
+//
+// The header doesn't say which DSP a cartridge uses. DSP-1 is by far the most
+// common; the few DSP-2, DSP-3, and DSP-4 games are recognized by title, as
+// emulators do.
+//
+// Reference: https://snes.nesdev.org/wiki/ROM_header#$FFD6
+
+// SpecialChip is a cartridge coprocessor.
+type SpecialChip string
+
+// SpecialChip values.
+const (
+	SpecialChipNone    SpecialChip = ""
+	SpecialChipDSP1    SpecialChip = "DSP-1"
+	SpecialChipDSP2    SpecialChip = "DSP-2"
+	SpecialChipDSP3    SpecialChip = "DSP-3"
+	SpecialChipDSP4    SpecialChip = "DSP-4"
+	SpecialChipSuperFX SpecialChip = "Super FX"
+	SpecialChipOBC1    SpecialChip = "OBC1"
+	SpecialChipSA1     SpecialChip = "SA-1"
+	SpecialChipSDD1    SpecialChip = "S-DD1"
+	SpecialChipSRTC    SpecialChip = "S-RTC"
+	SpecialChipSPC7110 SpecialChip = "SPC7110"
+	SpecialChipST010   SpecialChip = "ST010" // Also ST011, which shares its sub-type
+	SpecialChipST018   SpecialChip = "ST018"
+	SpecialChipCX4     SpecialChip = "CX4"
+	SpecialChipOther   SpecialChip = "Other" // Super Game Boy, Satellaview, and unknown chips
+)
+
+const (
+	cartTypeCoprocessorMin = 0x3
+	cartTypeCoprocessorMax = 0xA
+)
+
+// dspTitles maps the titles of games using DSP-2, DSP-3, and DSP-4 to their
+// chip. Titles are as decoded from the header.
+var dspTitles = map[string]SpecialChip{
+	"DUNGEON MASTER": SpecialChipDSP2,
+	"SDｶﾞﾝﾀﾞﾑGX":     SpecialChipDSP3,
+	"TOP GEAR 3000":  SpecialChipDSP4,
+}
+
+// HasCoprocessor reports whether the cartridge type includes a coprocessor.
+func (t CartridgeType) HasCoprocessor() bool {
+	low := t & 0x0F
+	return low >= cartTypeCoprocessorMin && low <= cartTypeCoprocessorMax && low != 0x7 && low != 0x8
+}
+
+// detectSpecialChip determines a cartridge's coprocessor from its cartridge
+// type, sub-type, and title.
+func detectSpecialChip(cartType CartridgeType, subType byte, title string) SpecialChip {
+	if !cartType.HasCoprocessor() {
+		return SpecialChipNone
+	}
+
+	switch cartType >> 4 {
+	case 0x0:
+		if chip, ok := dspTitles[title]; ok {
+			return chip
+		}
+		return SpecialChipDSP1
+	case 0x1:
+		return SpecialChipSuperFX
+	case 0x2:
+		return SpecialChipOBC1
+	case 0x3:
+		return SpecialChipSA1
+	case 0x4:
+		return SpecialChipSDD1
+	case 0x5:
+		return SpecialChipSRTC
+	case 0xF:
+		switch subType {
+		case 0x00:
+			return SpecialChipSPC7110
+		case 0x01:
+			return SpecialChipST010
+		case 0x02:
+			return SpecialChipST018
+		case 0x10:
+			return SpecialChipCX4
+		}
+	}
+	return SpecialChipOther
+}
@@ -0,0 +1,40 @@
+package sfc
+
+import "testing"
+
+func TestDetectSpecialChip(t *testing.T) {
+	tests := []struct {
+		name     string
+		cartType CartridgeType
+		subType  byte
+		title    string
+		want     SpecialChip
+	}{
+		{"ROM only", CartridgeROMOnly, 0, "GAME", SpecialChipNone},
+		{"ROM RAM battery", CartridgeROMRAMBattery, 0, "GAME", SpecialChipNone},
+		{"DSP-1", 0x03, 0, "PILOTWINGS", SpecialChipDSP1},
+		{"DSP-2", 0x05, 0, "DUNGEON MASTER", SpecialChipDSP2},
+		{"DSP-3", 0x05, 0, "SDｶﾞﾝﾀﾞﾑGX", SpecialChipDSP3},
+		{"DSP-4", 0x03, 0, "TOP GEAR 3000", SpecialChipDSP4},
+		{"Super FX", 0x1A, 0, "STAR FOX", SpecialChipSuperFX},
+		{"OBC1", 0x25, 0, "METAL COMBAT", SpecialChipOBC1},
+		{"SA-1", CartridgeSA1RAMBattery, 0, "SUPER MARIO RPG", SpecialChipSA1},
+		{"S-DD1", 0x43, 0, "STREET FIGHTER ALPHA 2", SpecialChipSDD1},
+		{"S-RTC", 0x55, 0, "DAIKAIJYUMONOGATARI2", SpecialChipSRTC},
+		{"SPC7110", 0xF5, 0x00, "FAR EAST OF EDEN ZERO", SpecialChipSPC7110},
+		{"ST010", 0xF6, 0x01, "EXHAUST HEAT2", SpecialChipST010},
+		{"ST018", 0xF5, 0x02, "2DAN MORITA SHOUGI 2", SpecialChipST018},
+		{"CX4", 0xF3, 0x10, "MEGAMAN X2", SpecialChipCX4},
+		{"unknown custom", 0xF3, 0x7F, "GAME", SpecialChipOther},
+		{"Super Game Boy", 0xE3, 0, "Super GAMEBOY", SpecialChipOther},
+		{"unused low nibble", 0x17, 0, "GAME", SpecialChipNone},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := detectSpecialChip(tt.cartType, tt.subType, tt.title); got != tt.want {
+				t.Errorf("detectSpecialChip(0x%02X, 0x%02X, %q) = %q, want %q", tt.cartType, tt.subType, tt.title, got, tt.want)
+			}
+		})
+	}
+}
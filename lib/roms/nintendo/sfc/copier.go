@@ -0,0 +1,65 @@
+package sfc
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Copier header layout (512 bytes, as written by Super Wild Card style
+// copiers):
+//
+//	Offset  Size  Description
+//	0x000   2     ROM size in 8 KiB units (little-endian)
+//	0x002   1     Flags (0 for a plain ROM)
+//	0x003   5     Reserved
+//	0x008   2     ID (0xAA 0xBB)
+//	0x00A   1     File type (0x04 = game)
+//	0x00B   501   Reserved
+//
+// No-Intro dumps are headerless, so stripping the header from a .smc file
+// gives a .sfc file whose hashes match.
+
+const (
+	copierBlockSize = 8 * 1024
+	copierIDOffset  = 0x08
+)
+
+// HasCopierHeader reports whether a ROM file of the given size has a copier
+// header, which makes its size 512 more than a multiple of 1 KiB.
+func HasCopierHeader(size int64) bool {
+	return size%1024 == CopierHeaderSize
+}
+
+// StripCopierHeader writes the ROM in r to w without its copier header. A ROM
+// without a copier header is written unchanged.
+func StripCopierHeader(r io.ReaderAt, size int64, w io.Writer) error {
+	offset := int64(0)
+	if HasCopierHeader(size) {
+		offset = CopierHeaderSize
+	}
+	if _, err := io.Copy(w, io.NewSectionReader(r, offset, size-offset)); err != nil {
+		return fmt.Errorf("failed to copy SNES ROM: %w", err)
+	}
+	return nil
+}
+
+// AddCopierHeader writes the ROM in r to w with a copier header prepended. A
+// ROM that already has a copier header is written unchanged.
+func AddCopierHeader(r io.ReaderAt, size int64, w io.Writer) error {
+	if !HasCopierHeader(size) {
+		if size%1024 != 0 {
+			return fmt.Errorf("not a valid SNES ROM: size %d is not a multiple of 1 KiB", size)
+		}
+		header := make([]byte, CopierHeaderSize)
+		binary.LittleEndian.PutUint16(header, uint16((size+copierBlockSize-1)/copierBlockSize))
+		copy(header[copierIDOffset:], []byte{0xAA, 0xBB, 0x04})
+		if _, err := w.Write(header); err != nil {
+			return fmt.Errorf("failed to write copier header: %w", err)
+		}
+	}
+	if _, err := io.Copy(w, io.NewSectionReader(r, 0, size)); err != nil {
+		return fmt.Errorf("failed to copy SNES ROM: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package sfc
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+func TestCopierHeader_RoundTrip(t *testing.T) {
+	smc, err := os.ReadFile("testdata/col15.sfc")
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+
+	var sfc bytes.Buffer
+	if err := StripCopierHeader(bytes.NewReader(smc), int64(len(smc)), &sfc); err != nil {
+		t.Fatalf("StripCopierHeader() error = %v", err)
+	}
+	if !bytes.Equal(sfc.Bytes(), smc[CopierHeaderSize:]) {
+		t.Error("StripCopierHeader() output doesn't match the ROM after its header")
+	}
+
+	info, err := Parse(bytes.NewReader(sfc.Bytes()), int64(sfc.Len()))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if info.HasCopierHeader {
+		t.Error("HasCopierHeader = true after stripping, want false")
+	}
+
+	// Stripping a headerless ROM leaves it unchanged
+	var again bytes.Buffer
+	if err := StripCopierHeader(bytes.NewReader(sfc.Bytes()), int64(sfc.Len()), &again); err != nil {
+		t.Fatalf("StripCopierHeader() error = %v", err)
+	}
+	if !bytes.Equal(again.Bytes(), sfc.Bytes()) {
+		t.Error("StripCopierHeader() changed a headerless ROM")
+	}
+
+	// col15.sfc has a standard header, so adding one back restores it
+	var added bytes.Buffer
+	if err := AddCopierHeader(bytes.NewReader(sfc.Bytes()), int64(sfc.Len()), &added); err != nil {
+		t.Fatalf("AddCopierHeader() error = %v", err)
+	}
+	if !bytes.Equal(added.Bytes(), smc) {
+		t.Error("AddCopierHeader() output doesn't match the original file")
+	}
+
+	// Adding to a headered ROM leaves it unchanged
+	added.Reset()
+	if err := AddCopierHeader(bytes.NewReader(smc), int64(len(smc)), &added); err != nil {
+		t.Fatalf("AddCopierHeader() error = %v", err)
+	}
+	if !bytes.Equal(added.Bytes(), smc) {
+		t.Error("AddCopierHeader() changed a headered ROM")
+	}
+}
+
+func TestAddCopierHeader_InvalidSize(t *testing.T) {
+	var out bytes.Buffer
+	if err := AddCopierHeader(bytes.NewReader(make([]byte, 1000)), 1000, &out); err == nil {
+		t.Error("AddCopierHeader() expected error, got nil")
+	}
+}
@@ -70,8 +70,16 @@ const (
 	snesHiROMOffset   = 0xFFC0
 	snesExHiROMOffset = 0x40FFC0
 
-	// Copier header size (some ROMs have this prepended)
-	snesCopierHeaderSize = 512
+	// ExHiROM is only used by ROMs larger than 4MB
+	snesExHiROMMinSize = 0x400000
+
+	// snesTitleLastOffset is the last title byte; 0x00 there marks an early
+	// extended header that only has a valid cartridge sub-type
+	snesTitleLastOffset = snesTitleOffset + snesTitleLen - 1
+
+	// CopierHeaderSize is the size of the copier header some ROMs have
+	// prepended.
+	CopierHeaderSize = 512
 )
 
 // MapMode indicates the memory mapping mode.
@@ -111,6 +119,11 @@ const (
 	DestinationAustralia   Destination = 0x11
 )
 
+// IsExHiROM reports whether the map mode is an ExHiROM mode.
+func (m MapMode) IsExHiROM() bool {
+	return m == MapModeExHiROM || m == MapModeFastROMExHiROM
+}
+
 // CartridgeType indicates the cartridge chipset type.
 type CartridgeType byte
 
@@ -145,6 +158,9 @@ type Info struct {
 	MapMode MapMode `json:"map_mode"`
 	// CartridgeType is the chipset info (FFD6).
 	CartridgeType CartridgeType `json:"cartridge_type"`
+	// SpecialChip is the coprocessor, decoded from CartridgeType and
+	// CartridgeSubType.
+	SpecialChip SpecialChip `json:"special_chip,omitempty"`
 	// ROMSize is the ROM size in bytes (FFD7).
 	ROMSize int `json:"rom_size"`
 	// RAMSize is the RAM/SRAM size in bytes (FFD8).
@@ -212,27 +228,37 @@ func (i *Info) GameRegions() []core.Region {
 
 // Parse extracts information from a SNES ROM file.
 func Parse(r io.ReaderAt, size int64) (*Info, error) {
-	// Determine if there's a copier header (file size % 1024 == 512)
-	hasCopierHeader := (size % 1024) == snesCopierHeaderSize
+	hasCopierHeader := HasCopierHeader(size)
 	copierOffset := int64(0)
 	if hasCopierHeader {
-		copierOffset = snesCopierHeaderSize
+		copierOffset = CopierHeaderSize
 	}
 
-	// Calculate all three possible header offsets
+	// Calculate all three possible header offsets. ROMs over 4MB try
+	// ExHiROM first, since their LoROM and HiROM locations hold game data
+	// that may happen to pass validation.
 	offsets := []int64{
 		copierOffset + snesLoROMOffset,   // LoROM
 		copierOffset + snesHiROMOffset,   // HiROM
 		copierOffset + snesExHiROMOffset, // ExHiROM
 	}
+	if size-copierOffset > snesExHiROMMinSize {
+		offsets = []int64{offsets[2], offsets[0], offsets[1]}
+	}
 
 	// Try each offset and return the first valid header
 	for _, offset := range offsets {
 		if offset+snesHeaderSize <= size {
 			info, err := parseSNESHeader(r, offset, size, hasCopierHeader)
-			if err == nil && isValidSNESHeader(info, size) {
-				return info, nil
+			if err != nil || !isValidSNESHeader(info, size) {
+				continue
+			}
+			// The ExHiROM location is only valid for an ExHiROM map mode;
+			// large HiROM ROMs (e.g., SPC7110 games) mirror data there
+			if offset == copierOffset+snesExHiROMOffset && !info.MapMode.IsExHiROM() {
+				continue
 			}
+			return info, nil
 		}
 	}
 
@@ -290,6 +316,13 @@ func parseSNESHeader(r io.ReaderAt, offset int64, fileSize int64, hasCopierHeade
 	var specialVersion, cartSubType byte
 
 	extOffset := offset + int64(snesMakerCodeOffset)
+	if makerCodeOld != 0x33 && titleBytes[snesTitleLastOffset] == 0x00 && extOffset >= 0 {
+		// Early extended header: only the cartridge sub-type (FFBF) is valid
+		subType := make([]byte, 1)
+		if _, err := r.ReadAt(subType, offset+snesCartSubTypeOffset); err == nil {
+			cartSubType = subType[0]
+		}
+	}
 	if makerCodeOld == 0x33 && extOffset >= 0 {
 		extHeader := make([]byte, 16)
 		if _, err := r.ReadAt(extHeader, extOffset); err == nil {
@@ -320,6 +353,7 @@ func parseSNESHeader(r io.ReaderAt, offset int64, fileSize int64, hasCopierHeade
 		Title:           title,
 		MapMode:         mapMode,
 		CartridgeType:   cartType,
+		SpecialChip:     detectSpecialChip(cartType, cartSubType, title),
 		ROMSize:         romSize,
 		RAMSize:         ramSize,
 		Destination:     destination,
@@ -222,3 +222,83 @@ func TestParse_TooSmall(t *testing.T) {
 		t.Error("Parse() expected error for too small file, got nil")
 	}
 }
+
+// writeSyntheticHeader writes a valid header with the given fields at offset,
+// with an extended header holding the maker and game codes.
+func writeSyntheticHeader(rom []byte, offset int, title string, mapMode MapMode, cartType CartridgeType, subType byte) {
+	header := rom[offset:]
+	copy(header[snesTitleOffset:], title)
+	for i := len(title); i < snesTitleLen; i++ {
+		header[snesTitleOffset+i] = ' '
+	}
+	header[snesMapModeOffset] = byte(mapMode)
+	header[snesCartTypeOffset] = byte(cartType)
+	header[snesDestCodeOffset] = byte(DestinationUSA)
+	header[snesMakerOldOffset] = 0x33
+	header[snesChecksumCOffset] = 0xFF
+	header[snesChecksumCOffset+1] = 0xFF
+
+	ext := rom[offset+snesMakerCodeOffset:]
+	copy(ext, "08")
+	copy(ext[snesGameCodeOffset-snesMakerCodeOffset:], "ARXE")
+	ext[snesCartSubTypeOffset-snesMakerCodeOffset] = subType
+}
+
+func TestParse_ExtendedHeader(t *testing.T) {
+	rom := make([]byte, 0x100000)
+	writeSyntheticHeader(rom, snesLoROMOffset, "MEGAMAN X2", MapModeFastROMLoROM, 0xF5, 0x10)
+
+	info, err := Parse(bytes.NewReader(rom), int64(len(rom)))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if info.MakerCode != "08" {
+		t.Errorf("MakerCode = %q, want %q", info.MakerCode, "08")
+	}
+	if info.GameCode != "ARXE" {
+		t.Errorf("GameCode = %q, want %q", info.GameCode, "ARXE")
+	}
+	if info.CartridgeSubType != 0x10 {
+		t.Errorf("CartridgeSubType = 0x%02X, want 0x10", info.CartridgeSubType)
+	}
+	if info.SpecialChip != SpecialChipCX4 {
+		t.Errorf("SpecialChip = %q, want %q", info.SpecialChip, SpecialChipCX4)
+	}
+}
+
+func TestParse_ExHiROM(t *testing.T) {
+	// A 6MB ExHiROM with a decoy HiROM-looking header at 0xFFC0
+	rom := make([]byte, 0x600000)
+	writeSyntheticHeader(rom, snesHiROMOffset, "DECOY", MapModeHiROM, CartridgeROMOnly, 0)
+	writeSyntheticHeader(rom, snesExHiROMOffset, "TALES OF PHANTASIA", MapModeExHiROM, CartridgeROMRAMBattery, 0)
+
+	info, err := Parse(bytes.NewReader(rom), int64(len(rom)))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if info.Title != "TALES OF PHANTASIA" {
+		t.Errorf("Title = %q, want %q", info.Title, "TALES OF PHANTASIA")
+	}
+	if !info.MapMode.IsExHiROM() {
+		t.Errorf("MapMode = 0x%02X, want ExHiROM", info.MapMode)
+	}
+}
+
+func TestParse_LargeHiROM(t *testing.T) {
+	// A 5MB HiROM (like SPC7110 games) whose ExHiROM location holds a
+	// header-like mirror without an ExHiROM map mode
+	rom := make([]byte, 0x500000)
+	writeSyntheticHeader(rom, snesHiROMOffset, "FAR EAST OF EDEN ZERO", MapModeSPC7110, 0xF9, 0x00)
+	writeSyntheticHeader(rom, snesExHiROMOffset, "FAR EAST OF EDEN ZERO", MapModeSPC7110, 0xF9, 0x00)
+
+	info, err := Parse(bytes.NewReader(rom), int64(len(rom)))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if info.MapMode != MapModeSPC7110 {
+		t.Errorf("MapMode = 0x%02X, want 0x%02X", info.MapMode, MapModeSPC7110)
+	}
+	if info.SpecialChip != SpecialChipSPC7110 {
+		t.Errorf("SpecialChip = %q, want %q", info.SpecialChip, SpecialChipSPC7110)
+	}
+}
@@ -0,0 +1,217 @@
+package wbfs
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/sargunv/rom-tools/lib/core"
+	"github.com/sargunv/rom-tools/lib/roms/nintendo/gcm"
+)
+
+// WBFS (Wii Backup File System) file parsing.
+//
+// A .wbfs file is a WBFS partition holding a single Wii disc. The disc is
+// split into fixed-size WBFS sectors, and only sectors the disc uses are
+// stored; a per-disc table maps each disc block to a WBFS sector.
+//
+// Format reference: libwbfs (https://github.com/kwiirk/wbfs)
+//
+// Partition header layout (first HD sector, big-endian):
+//
+//	Offset  Size  Description
+//	0x00    4     Magic "WBFS"
+//	0x04    4     Number of HD sectors
+//	0x08    1     HD sector size (log2, usually 9 = 512 bytes)
+//	0x09    1     WBFS sector size (log2, usually 21 = 2 MiB)
+//	0x0A    1     Version
+//	0x0B    1     Padding
+//	0x0C    var   Disc table (one byte per slot, non-zero = used)
+//
+// Disc info for slot i starts at HD sector 1 + i * (disc info size / HD
+// sector size):
+//
+//	Offset  Size   Description
+//	0x000   0x100  Copy of the disc header
+//	0x100   2*n    WBFS sector of each disc block (0 = not stored)
+//
+// where n is the number of WBFS sectors in a dual-layer Wii disc, and the disc
+// info size is rounded up to the HD sector size.
+
+const (
+	wbfsMagic = "WBFS"
+
+	headerMinSize       = 0x0C
+	hdSectorShiftOffset = 0x08
+	wbfsSectorShiftOff  = 0x09
+	discTableOffset     = 0x0C
+
+	discHeaderCopySize = 0x100
+	wiiSectorShift     = 15         // 0x8000-byte Wii sectors
+	wiiSectorsPerDisc  = 143432 * 2 // dual-layer disc
+	minSectorShift     = 9
+	maxSectorShift     = 30
+)
+
+// Info contains metadata extracted from a WBFS file.
+type Info struct {
+	// GCM contains the game identification info parsed from the disc header.
+	GCM *gcm.Info `json:"gcm,omitempty"`
+	// HDSectorSize is the HD sector size in bytes.
+	HDSectorSize int64 `json:"hd_sector_size"`
+	// WBFSSectorSize is the WBFS sector (disc block) size in bytes.
+	WBFSSectorSize int64 `json:"wbfs_sector_size"`
+	// UsedBlocks is the number of disc blocks stored in the file.
+	UsedBlocks int `json:"used_blocks"`
+}
+
+// GamePlatform implements core.GameInfo by delegating to GCM.
+func (i *Info) GamePlatform() core.Platform { return i.GCM.GamePlatform() }
+
+// GameTitle implements core.GameInfo by delegating to GCM.
+func (i *Info) GameTitle() string { return i.GCM.GameTitle() }
+
+// GameSerial implements core.GameInfo by delegating to GCM.
+func (i *Info) GameSerial() string { return i.GCM.GameSerial() }
+
+// GameRegions implements core.GameInfo by delegating to GCM.
+func (i *Info) GameRegions() []core.Region { return i.GCM.GameRegions() }
+
+// Reader provides random access to the logical Wii disc image in a WBFS file.
+// Blocks not stored in the file read as zeros.
+type Reader struct {
+	r             io.ReaderAt
+	hdSectorShift uint8
+	sectorShift   uint8
+	blocks        []uint16
+	size          int64
+}
+
+// NewReader opens the first disc in a WBFS file.
+func NewReader(r io.ReaderAt, size int64) (*Reader, error) {
+	if size < headerMinSize {
+		return nil, fmt.Errorf("file too small for WBFS header: %d bytes", size)
+	}
+
+	header := make([]byte, headerMinSize)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, fmt.Errorf("failed to read WBFS header: %w", err)
+	}
+	if string(header[0:4]) != wbfsMagic {
+		return nil, fmt.Errorf("not a valid WBFS file: invalid magic")
+	}
+
+	hdShift := header[hdSectorShiftOffset]
+	wbfsShift := header[wbfsSectorShiftOff]
+	if hdShift < minSectorShift || hdShift > maxSectorShift || wbfsShift < wiiSectorShift || wbfsShift > maxSectorShift {
+		return nil, fmt.Errorf("invalid WBFS sector sizes: 2^%d, 2^%d", hdShift, wbfsShift)
+	}
+	hdSectorSize := int64(1) << hdShift
+
+	if size < hdSectorSize {
+		return nil, fmt.Errorf("file too small for WBFS header: %d bytes", size)
+	}
+	discTable := make([]byte, hdSectorSize-discTableOffset)
+	if _, err := r.ReadAt(discTable, discTableOffset); err != nil {
+		return nil, fmt.Errorf("failed to read WBFS disc table: %w", err)
+	}
+	slot := -1
+	for i, used := range discTable {
+		if used != 0 {
+			slot = i
+			break
+		}
+	}
+	if slot < 0 {
+		return nil, fmt.Errorf("WBFS file contains no discs")
+	}
+
+	numBlocks := int64(wiiSectorsPerDisc) >> (wbfsShift - wiiSectorShift)
+	discInfoSize := (discHeaderCopySize + numBlocks*2 + hdSectorSize - 1) &^ (hdSectorSize - 1)
+	discInfoOffset := hdSectorSize + int64(slot)*discInfoSize
+	if discInfoOffset+discHeaderCopySize+numBlocks*2 > size {
+		return nil, fmt.Errorf("WBFS disc info extends beyond file")
+	}
+
+	table := make([]byte, numBlocks*2)
+	if _, err := r.ReadAt(table, discInfoOffset+discHeaderCopySize); err != nil {
+		return nil, fmt.Errorf("failed to read WBFS block table: %w", err)
+	}
+	blocks := make([]uint16, numBlocks)
+	var usedBlocks int64
+	for i := range blocks {
+		blocks[i] = binary.BigEndian.Uint16(table[i*2:])
+		if blocks[i] != 0 {
+			usedBlocks = int64(i) + 1
+		}
+	}
+
+	return &Reader{
+		r:             r,
+		hdSectorShift: hdShift,
+		sectorShift:   wbfsShift,
+		blocks:        blocks,
+		size:          usedBlocks << wbfsShift,
+	}, nil
+}
+
+// Size returns the size of the logical disc image, up to the end of the last
+// stored block.
+func (w *Reader) Size() int64 {
+	return w.size
+}
+
+// ReadAt implements io.ReaderAt, reading from the logical disc image.
+func (w *Reader) ReadAt(p []byte, off int64) (n int, err error) {
+	if off < 0 {
+		return 0, fmt.Errorf("negative offset")
+	}
+
+	blockSize := int64(1) << w.sectorShift
+	for n < len(p) && off < w.size {
+		block := off >> w.sectorShift
+		inBlock := off & (blockSize - 1)
+		chunk := p[n : n+int(min(int64(len(p)-n), blockSize-inBlock))]
+
+		if sector := w.blocks[block]; sector == 0 {
+			clear(chunk)
+		} else if _, err := w.r.ReadAt(chunk, int64(sector)<<w.sectorShift+inBlock); err != nil {
+			return n, fmt.Errorf("read block %d: %w", block, err)
+		}
+
+		n += len(chunk)
+		off += int64(len(chunk))
+	}
+
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// Parse extracts game information from the first disc in a WBFS file.
+func Parse(r io.ReaderAt, size int64) (*Info, error) {
+	reader, err := NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	gcmInfo, err := gcm.Parse(reader, reader.Size())
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse disc header from WBFS: %w", err)
+	}
+
+	usedBlocks := 0
+	for _, sector := range reader.blocks {
+		if sector != 0 {
+			usedBlocks++
+		}
+	}
+
+	return &Info{
+		GCM:            gcmInfo,
+		HDSectorSize:   int64(1) << reader.hdSectorShift,
+		WBFSSectorSize: int64(1) << reader.sectorShift,
+		UsedBlocks:     usedBlocks,
+	}, nil
+}
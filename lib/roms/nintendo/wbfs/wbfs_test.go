@@ -0,0 +1,141 @@
+package wbfs
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/sargunv/rom-tools/lib/core"
+)
+
+const (
+	testHDShift   = 9
+	testWBFSShift = 16 // 64 KiB blocks keep the test image small
+)
+
+// makeTestDiscHeader creates a minimal Wii disc header.
+func makeTestDiscHeader(gameID, title string) []byte {
+	header := make([]byte, 0x440)
+	copy(header, gameID)
+	copy(header[0x04:], "01")
+	binary.BigEndian.PutUint32(header[0x18:], 0x5D1C9EA3) // Wii magic
+	copy(header[0x20:], title)
+	return header
+}
+
+// makeTestWBFS creates a WBFS file with one disc. blocks maps disc block
+// numbers to their contents; each is stored in its own WBFS sector.
+func makeTestWBFS(t *testing.T, blocks map[int][]byte) []byte {
+	t.Helper()
+
+	hdSectorSize := int64(1) << testHDShift
+	blockSize := int64(1) << testWBFSShift
+	numBlocks := int64(wiiSectorsPerDisc) >> (testWBFSShift - wiiSectorShift)
+	discInfoEnd := hdSectorSize + discHeaderCopySize + numBlocks*2
+	firstSector := (discInfoEnd + blockSize - 1) / blockSize
+
+	data := make([]byte, (firstSector+int64(len(blocks)))*blockSize)
+	copy(data, wbfsMagic)
+	binary.BigEndian.PutUint32(data[0x04:], uint32(int64(len(data))/hdSectorSize))
+	data[hdSectorShiftOffset] = testHDShift
+	data[wbfsSectorShiftOff] = testWBFSShift
+	data[discTableOffset] = 1
+
+	table := data[hdSectorSize+discHeaderCopySize:]
+	sector := firstSector
+	for block := range numBlocks {
+		contents, ok := blocks[int(block)]
+		if !ok {
+			continue
+		}
+		binary.BigEndian.PutUint16(table[block*2:], uint16(sector))
+		copy(data[sector*blockSize:], contents)
+		sector++
+	}
+	return data
+}
+
+func TestParse(t *testing.T) {
+	data := makeTestWBFS(t, map[int][]byte{
+		0: makeTestDiscHeader("RMCE", "MARIO KART WII"),
+		2: bytes.Repeat([]byte{0xAA}, 16),
+	})
+
+	info, err := Parse(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if info.HDSectorSize != 512 {
+		t.Errorf("HDSectorSize = %d, want 512", info.HDSectorSize)
+	}
+	if info.WBFSSectorSize != 1<<testWBFSShift {
+		t.Errorf("WBFSSectorSize = %d, want %d", info.WBFSSectorSize, 1<<testWBFSShift)
+	}
+	if info.UsedBlocks != 2 {
+		t.Errorf("UsedBlocks = %d, want 2", info.UsedBlocks)
+	}
+	if got := info.GamePlatform(); got != core.PlatformWii {
+		t.Errorf("GamePlatform() = %v, want %v", got, core.PlatformWii)
+	}
+	if got := info.GameTitle(); got != "MARIO KART WII" {
+		t.Errorf("GameTitle() = %q, want %q", got, "MARIO KART WII")
+	}
+	if got := info.GameSerial(); got != "RMCE" {
+		t.Errorf("GameSerial() = %q, want %q", got, "RMCE")
+	}
+}
+
+func TestReader_ReadAt(t *testing.T) {
+	blockSize := 1 << testWBFSShift
+	data := makeTestWBFS(t, map[int][]byte{
+		0: bytes.Repeat([]byte{0x11}, blockSize),
+		2: bytes.Repeat([]byte{0x22}, blockSize),
+	})
+
+	reader, err := NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("NewReader() error = %v", err)
+	}
+	if reader.Size() != int64(3*blockSize) {
+		t.Errorf("Size() = %d, want %d", reader.Size(), 3*blockSize)
+	}
+
+	// Read spanning all three blocks; block 1 isn't stored and reads as zeros
+	buf := make([]byte, blockSize+2)
+	if _, err := reader.ReadAt(buf, int64(blockSize-1)); err != nil {
+		t.Fatalf("ReadAt() error = %v", err)
+	}
+	if buf[0] != 0x11 || buf[1] != 0 || buf[blockSize] != 0 || buf[blockSize+1] != 0x22 {
+		t.Errorf("ReadAt() = [%#x %#x ... %#x %#x], want [0x11 0 ... 0 0x22]",
+			buf[0], buf[1], buf[blockSize], buf[blockSize+1])
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	valid := makeTestWBFS(t, map[int][]byte{0: makeTestDiscHeader("RMCE", "TEST")})
+
+	noDiscs := bytes.Clone(valid)
+	noDiscs[discTableOffset] = 0
+
+	badShift := bytes.Clone(valid)
+	badShift[wbfsSectorShiftOff] = 8
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"too small", make([]byte, 8)},
+		{"invalid magic", make([]byte, 1024)},
+		{"no discs", noDiscs},
+		{"invalid sector size", badShift},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(bytes.NewReader(tt.data), int64(len(tt.data))); err == nil {
+				t.Error("Parse() expected error, got nil")
+			}
+		})
+	}
+}
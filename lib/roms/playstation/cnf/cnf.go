@@ -51,6 +51,12 @@ type Info struct {
 	Version string `json:"version,omitempty"`
 	// VideoMode is NTSC or PAL (PS2 only).
 	VideoMode VideoMode `json:"video_mode,omitempty"`
+	// BootELF is the boot executable's ELF header, for PS2 discs whose boot
+	// executable was found (see ParseELF).
+	BootELF *ELFInfo `json:"boot_elf,omitempty"`
+	// Region is the region from a PS-X EXE license marker, for PS1 discs
+	// identified by their executable (see ParseExecutable).
+	Region core.Region `json:"region,omitempty"`
 	// platform is PS1 or PS2, determined by the boot line type (internal, used by GamePlatform).
 	platform core.Platform
 }
@@ -80,6 +86,9 @@ func (i *Info) GameRegions() []core.Region {
 			return []core.Region{core.RegionKorea}
 		}
 	}
+	if i.Region != core.RegionUnknown {
+		return []core.Region{i.Region}
+	}
 	return []core.Region{}
 }
 
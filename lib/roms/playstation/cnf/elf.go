@@ -0,0 +1,104 @@
+package cnf
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// PS2 boot executable (ELF) header parsing.
+//
+// PS2 discs boot the ELF named by SYSTEM.CNF's BOOT2 line. Its header has no
+// region or version fields (those come from the disc ID and the VER line),
+// but checking it confirms the boot path names a real R5900 executable.
+//
+// ELF header (32-bit little-endian, relevant fields):
+//
+//	Offset  Size  Description
+//	0x00    4     "\x7FELF"
+//	0x04    1     Class (1 = 32-bit)
+//	0x05    1     Data encoding (1 = little-endian)
+//	0x10    2     Type (2 = executable)
+//	0x12    2     Machine (8 = MIPS)
+//	0x18    4     Entry point
+//	0x24    4     Flags (0x00920000 = MIPS R5900)
+//
+// Specification: https://refspecs.linuxfoundation.org/elf/elf.pdf
+
+const (
+	elfHeaderSize  = 0x34
+	elfMagic       = "\x7FELF"
+	elfClassOffset = 0x04
+	elfDataOffset  = 0x05
+	elfTypeOffset  = 0x10
+	elfMachOffset  = 0x12
+	elfEntryOffset = 0x18
+	elfFlagsOffset = 0x24
+	elfClass32     = 1
+	elfDataLSB     = 1
+	elfTypeExec    = 2
+	elfMachineMIPS = 8
+	elfMachMask    = 0x00FF0000
+	elfMachR5900   = 0x00920000
+
+	bootDevicePS2 = "cdrom0:"
+	bootDevicePS1 = "cdrom:"
+)
+
+// ELFInfo contains metadata extracted from a boot executable's ELF header.
+type ELFInfo struct {
+	// Entry is the executable's entry point address.
+	Entry uint32 `json:"entry"`
+	// Flags are the MIPS ELF flags.
+	Flags uint32 `json:"flags"`
+	// R5900 indicates the executable targets the PS2's Emotion Engine CPU.
+	R5900 bool `json:"r5900"`
+}
+
+// ParseELF parses a PS2 boot executable's ELF header.
+func ParseELF(r io.ReaderAt, size int64) (*ELFInfo, error) {
+	if size < elfHeaderSize {
+		return nil, fmt.Errorf("file too small for ELF header: %d bytes", size)
+	}
+
+	header := make([]byte, elfHeaderSize)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, fmt.Errorf("failed to read ELF header: %w", err)
+	}
+	if string(header[:len(elfMagic)]) != elfMagic {
+		return nil, fmt.Errorf("not a valid ELF: magic is %q", header[:len(elfMagic)])
+	}
+	if header[elfClassOffset] != elfClass32 || header[elfDataOffset] != elfDataLSB {
+		return nil, fmt.Errorf("not a valid PS2 ELF: not 32-bit little-endian")
+	}
+	if t := binary.LittleEndian.Uint16(header[elfTypeOffset:]); t != elfTypeExec {
+		return nil, fmt.Errorf("not a valid PS2 ELF: type %d is not an executable", t)
+	}
+	if m := binary.LittleEndian.Uint16(header[elfMachOffset:]); m != elfMachineMIPS {
+		return nil, fmt.Errorf("not a valid PS2 ELF: machine %d is not MIPS", m)
+	}
+
+	flags := binary.LittleEndian.Uint32(header[elfFlagsOffset:])
+	return &ELFInfo{
+		Entry: binary.LittleEndian.Uint32(header[elfEntryOffset:]),
+		Flags: flags,
+		R5900: flags&elfMachMask == elfMachR5900,
+	}, nil
+}
+
+// BootFile returns the disc path of the boot executable, with the device
+// prefix and version suffix removed and backslashes replaced by slashes
+// (e.g., "cdrom0:\DATA\MAIN.ELF;1" becomes "DATA/MAIN.ELF").
+func (i *Info) BootFile() string {
+	path := i.BootPath
+	for _, device := range []string{bootDevicePS2, bootDevicePS1} {
+		if len(path) >= len(device) && strings.EqualFold(path[:len(device)], device) {
+			path = path[len(device):]
+			break
+		}
+	}
+	path = strings.TrimSuffix(path, ";1")
+	path = strings.ReplaceAll(path, "\\", "/")
+	return strings.TrimLeft(path, "/")
+}
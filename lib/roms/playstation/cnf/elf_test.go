@@ -0,0 +1,76 @@
+package cnf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// makeELF creates a 32-bit little-endian ELF header.
+func makeELF(machine uint16, flags uint32) []byte {
+	elf := make([]byte, elfHeaderSize)
+	copy(elf, elfMagic)
+	elf[elfClassOffset] = elfClass32
+	elf[elfDataOffset] = elfDataLSB
+	binary.LittleEndian.PutUint16(elf[elfTypeOffset:], elfTypeExec)
+	binary.LittleEndian.PutUint16(elf[elfMachOffset:], machine)
+	binary.LittleEndian.PutUint32(elf[elfEntryOffset:], 0x00100008)
+	binary.LittleEndian.PutUint32(elf[elfFlagsOffset:], flags)
+	return elf
+}
+
+func TestParseELF(t *testing.T) {
+	elf := makeELF(elfMachineMIPS, 0x20924001)
+	info, err := ParseELF(bytes.NewReader(elf), int64(len(elf)))
+	if err != nil {
+		t.Fatalf("ParseELF() error = %v", err)
+	}
+	if info.Entry != 0x00100008 {
+		t.Errorf("Entry = %#x, want 0x100008", info.Entry)
+	}
+	if !info.R5900 {
+		t.Error("R5900 should be true for R5900 flags")
+	}
+}
+
+func TestParseELF_Errors(t *testing.T) {
+	bigEndian := makeELF(elfMachineMIPS, 0)
+	bigEndian[elfDataOffset] = 2
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"too small", makeELF(elfMachineMIPS, 0)[:0x20]},
+		{"bad magic", make([]byte, elfHeaderSize)},
+		{"big-endian", bigEndian},
+		{"not MIPS", makeELF(3, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseELF(bytes.NewReader(tt.data), int64(len(tt.data))); err == nil {
+				t.Error("ParseELF() expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestInfo_BootFile(t *testing.T) {
+	tests := []struct {
+		bootPath string
+		want     string
+	}{
+		{"cdrom0:\\SLUS_123.45;1", "SLUS_123.45"},
+		{"cdrom0:\\DATA\\MAIN.ELF;1", "DATA/MAIN.ELF"},
+		{"cdrom:\\SCUS_943.00;1", "SCUS_943.00"},
+		{"CDROM0:SLES_500.03", "SLES_500.03"},
+	}
+
+	for _, tt := range tests {
+		info := &Info{BootPath: tt.bootPath}
+		if got := info.BootFile(); got != tt.want {
+			t.Errorf("BootFile() for %q = %q, want %q", tt.bootPath, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package cnf
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/sargunv/rom-tools/internal/util"
+	"github.com/sargunv/rom-tools/lib/core"
+)
+
+// PS-X EXE fallback for PS1 discs without SYSTEM.CNF.
+//
+// Without SYSTEM.CNF, the PS1 BIOS boots PSX.EXE from the root directory.
+// Some demo and prototype discs rely on this, or boot a serial-named
+// executable (e.g., "SLUS_005.94") from a minimal SYSTEM.CNF-less layout.
+//
+// PS-X EXE header (first 2048 bytes of the executable):
+//
+//	Offset  Size  Description
+//	0x00    8     "PS-X EXE"
+//	0x10    4     Initial PC
+//	0x18    4     Load address
+//	0x1C    4     Text size
+//	0x4C    ...   License marker (e.g., "Sony Computer Entertainment Inc. for North America area")
+//
+// The license marker names the region the executable was built for.
+
+const (
+	exeMagic        = "PS-X EXE"
+	exeHeaderSize   = 0x800
+	exeMarkerOffset = 0x4C
+	exeMarkerLen    = exeHeaderSize - exeMarkerOffset
+
+	// DefaultExecutable is the executable the BIOS boots without SYSTEM.CNF.
+	DefaultExecutable = "PSX.EXE"
+)
+
+// discIDPattern matches a serial-style executable name, e.g. "SLUS_005.94".
+var discIDPattern = regexp.MustCompile(`^[A-Z]{4}[_-][0-9]{3}\.[0-9]{2}$`)
+
+// exeRegions maps license marker areas to regions.
+var exeRegions = []struct {
+	area   string
+	region core.Region
+}{
+	{"North America area", core.RegionUSA},
+	{"Europe area", core.RegionEurope},
+	{"Japan area", core.RegionJapan},
+}
+
+// IsDiscID reports whether a filename is a serial-style disc ID, such as
+// "SLUS_005.94". A trailing ISO 9660 version suffix (";1") is ignored.
+func IsDiscID(name string) bool {
+	return discIDPattern.MatchString(strings.ToUpper(strings.TrimSuffix(name, ";1")))
+}
+
+// ParseExecutable identifies a PS1 disc from its boot executable, for discs
+// without SYSTEM.CNF. The executable must start with a PS-X EXE header. If
+// name is a serial-style disc ID, it becomes the DiscID; the region is taken
+// from the header's license marker.
+func ParseExecutable(r io.ReaderAt, size int64, name string) (*Info, error) {
+	if size < exeHeaderSize {
+		return nil, fmt.Errorf("file too small for PS-X EXE header: %d bytes", size)
+	}
+
+	header := make([]byte, exeHeaderSize)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, fmt.Errorf("failed to read PS-X EXE header: %w", err)
+	}
+	if string(header[:len(exeMagic)]) != exeMagic {
+		return nil, fmt.Errorf("not a valid PS-X EXE: magic is %q", header[:len(exeMagic)])
+	}
+
+	name = strings.TrimSuffix(name, ";1")
+	info := &Info{
+		BootPath: name,
+		platform: core.PlatformPS1,
+	}
+	if IsDiscID(name) {
+		info.DiscID = strings.ToUpper(name)
+	}
+
+	marker := util.ExtractASCII(header[exeMarkerOffset : exeMarkerOffset+exeMarkerLen])
+	for _, r := range exeRegions {
+		if strings.Contains(marker, r.area) {
+			info.Region = r.region
+			break
+		}
+	}
+	return info, nil
+}
@@ -0,0 +1,83 @@
+package cnf
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+
+	"github.com/sargunv/rom-tools/lib/core"
+)
+
+// makeEXE creates a PS-X EXE header with the given license marker.
+func makeEXE(marker string) []byte {
+	exe := make([]byte, exeHeaderSize+16)
+	copy(exe, exeMagic)
+	copy(exe[exeMarkerOffset:], marker)
+	return exe
+}
+
+func TestParseExecutable(t *testing.T) {
+	tests := []struct {
+		name        string
+		file        string
+		marker      string
+		wantDiscID  string
+		wantRegions []core.Region
+	}{
+		{"serial name", "SLUS_005.94;1", "Sony Computer Entertainment Inc. for North America area", "SLUS_005.94", []core.Region{core.RegionUSA}},
+		{"serial name without marker", "SCES_000.01", "", "SCES_000.01", []core.Region{core.RegionEurope}},
+		{"PSX.EXE", "PSX.EXE", "Sony Computer Entertainment Inc. for Japan area", "", []core.Region{core.RegionJapan}},
+		{"PSX.EXE without marker", "PSX.EXE", "", "", []core.Region{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exe := makeEXE(tt.marker)
+			info, err := ParseExecutable(bytes.NewReader(exe), int64(len(exe)), tt.file)
+			if err != nil {
+				t.Fatalf("ParseExecutable() error = %v", err)
+			}
+			if info.GamePlatform() != core.PlatformPS1 {
+				t.Errorf("GamePlatform() = %v, want %v", info.GamePlatform(), core.PlatformPS1)
+			}
+			if info.DiscID != tt.wantDiscID {
+				t.Errorf("DiscID = %q, want %q", info.DiscID, tt.wantDiscID)
+			}
+			if regions := info.GameRegions(); !slices.Equal(regions, tt.wantRegions) {
+				t.Errorf("GameRegions() = %v, want %v", regions, tt.wantRegions)
+			}
+		})
+	}
+}
+
+func TestParseExecutable_Errors(t *testing.T) {
+	small := makeEXE("")[:0x100]
+	if _, err := ParseExecutable(bytes.NewReader(small), int64(len(small)), "PSX.EXE"); err == nil {
+		t.Error("ParseExecutable() of a small file expected error, got nil")
+	}
+
+	notEXE := make([]byte, exeHeaderSize)
+	if _, err := ParseExecutable(bytes.NewReader(notEXE), int64(len(notEXE)), "SLUS_005.94"); err == nil {
+		t.Error("ParseExecutable() without PS-X EXE magic expected error, got nil")
+	}
+}
+
+func TestIsDiscID(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"SLUS_005.94", true},
+		{"SLUS_005.94;1", true},
+		{"scus-943.00", true},
+		{"PSX.EXE", false},
+		{"SYSTEM.CNF", false},
+		{"SLUS_0059.4", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsDiscID(tt.name); got != tt.want {
+			t.Errorf("IsDiscID(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+// Package pbp provides PlayStation PBP (EBOOT.PBP) container parsing.
+//
+// PBP is the executable container used by the PSP. Besides PSP homebrew and
+// PSN releases, it is used for PS1 Classics, where DATA.PSAR holds the
+// (possibly compressed) PS1 disc images.
+//
+// Reference: https://www.psdevwiki.com/psp/EBOOT.PBP
+//
+// PBP header layout (0x28 bytes, little-endian):
+//
+//	Offset  Size  Description
+//	0x00    4     Magic ("\x00PBP")
+//	0x04    4     Version
+//	0x08    4     PARAM.SFO offset
+//	0x0C    4     ICON0.PNG offset
+//	0x10    4     ICON1.PMF offset
+//	0x14    4     PIC0.PNG offset
+//	0x18    4     PIC1.PNG offset
+//	0x1C    4     SND0.AT3 offset
+//	0x20    4     DATA.PSP offset
+//	0x24    4     DATA.PSAR offset
+//
+// Each section extends to the next section's offset; DATA.PSAR extends to the
+// end of the file.
+//
+// For PS1 Classics, DATA.PSAR starts with either "PSISOIMG0000" (single disc)
+// or "PSTITLEIMG000000" (multi-disc). A PSISOIMG header has the disc ID at
+// 0x400, prefixed with an underscore (e.g., "_SLUS_00594"). A PSTITLEIMG
+// header has a table of PSISOIMG offsets (relative to DATA.PSAR) at 0x200.
+package pbp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/sargunv/rom-tools/internal/util"
+	"github.com/sargunv/rom-tools/lib/core"
+	"github.com/sargunv/rom-tools/lib/roms/playstation/sfo"
+)
+
+const (
+	pbpMagic        = "\x00PBP"
+	pbpHeaderSize   = 0x28
+	pbpVersionOff   = 0x04
+	pbpSectionsOff  = 0x08
+	pbpSectionCount = 8
+
+	sectionParamSFO = 0
+	sectionDataPSAR = 7
+
+	psisoMagic          = "PSISOIMG0000"
+	pstitleMagic        = "PSTITLEIMG000000"
+	psisoDiscIDOffset   = 0x400
+	psisoDiscIDLen      = 16
+	pstitleDiscsOffset  = 0x200
+	pstitleMaxDiscs     = 5
+	psarMagicBufferSize = len(pstitleMagic)
+)
+
+// Info contains metadata extracted from a PBP file.
+type Info struct {
+	// Version is the PBP format version.
+	Version uint32 `json:"version"`
+	// PS1DiscID is the disc ID of the first PS1 disc for PS1 Classics
+	// (e.g., "SLUS_00594"). Empty for PSP content.
+	PS1DiscID string `json:"ps1_disc_id,omitempty"`
+	// DiscCount is the number of PS1 discs in DATA.PSAR (0 for PSP content).
+	DiscCount int `json:"disc_count,omitempty"`
+	// SFO contains the parsed PARAM.SFO data.
+	SFO *sfo.Info `json:"sfo,omitempty"`
+}
+
+// GamePlatform implements core.GameInfo.
+func (i *Info) GamePlatform() core.Platform {
+	if i.PS1DiscID != "" {
+		return core.PlatformPS1
+	}
+	if p := i.SFO.GamePlatform(); p != "" {
+		return p
+	}
+	return core.PlatformPSP
+}
+
+// GameTitle implements core.GameInfo.
+func (i *Info) GameTitle() string { return i.SFO.Title }
+
+// GameSerial implements core.GameInfo. For PS1 Classics, returns the disc ID
+// in Redump form (e.g., "SLUS-00594"); otherwise the SFO disc ID.
+func (i *Info) GameSerial() string {
+	if i.PS1DiscID != "" {
+		return strings.ReplaceAll(i.PS1DiscID, "_", "-")
+	}
+	return i.SFO.GameSerial()
+}
+
+// GameRegions implements core.GameInfo.
+func (i *Info) GameRegions() []core.Region {
+	if i.PS1DiscID == "" {
+		return i.SFO.GameRegions()
+	}
+	if len(i.PS1DiscID) >= 4 {
+		switch i.PS1DiscID[:4] {
+		case "SLUS", "SCUS": // US
+			return []core.Region{core.RegionUSA}
+		case "SLES", "SCES": // EU
+			return []core.Region{core.RegionEurope}
+		case "SLPS", "SCPS", "SLPM": // JP
+			return []core.Region{core.RegionJapan}
+		case "SLKA", "SCKA": // Korea
+			return []core.Region{core.RegionKorea}
+		}
+	}
+	return []core.Region{}
+}
+
+// Parse extracts game information from a PBP file.
+func Parse(r io.ReaderAt, size int64) (*Info, error) {
+	if size < pbpHeaderSize {
+		return nil, fmt.Errorf("file too small for PBP header: %d bytes", size)
+	}
+
+	header := make([]byte, pbpHeaderSize)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, fmt.Errorf("failed to read PBP header: %w", err)
+	}
+
+	if string(header[0:4]) != pbpMagic {
+		return nil, fmt.Errorf("not a valid PBP file: invalid magic")
+	}
+
+	var offsets [pbpSectionCount + 1]int64
+	for i := range pbpSectionCount {
+		offsets[i] = int64(binary.LittleEndian.Uint32(header[pbpSectionsOff+i*4:]))
+	}
+	offsets[pbpSectionCount] = size
+	for i := range pbpSectionCount {
+		if offsets[i] > offsets[i+1] {
+			return nil, fmt.Errorf("not a valid PBP file: section %d offset 0x%X out of order", i, offsets[i])
+		}
+	}
+
+	sfoOffset := offsets[sectionParamSFO]
+	sfoSize := offsets[sectionParamSFO+1] - sfoOffset
+	sfoInfo, err := sfo.Parse(io.NewSectionReader(r, sfoOffset, sfoSize), sfoSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse PARAM.SFO: %w", err)
+	}
+
+	info := &Info{
+		Version: binary.LittleEndian.Uint32(header[pbpVersionOff:]),
+		SFO:     sfoInfo,
+	}
+
+	psarOffset := offsets[sectionDataPSAR]
+	if discIDs := readPS1DiscIDs(r, psarOffset, size-psarOffset); len(discIDs) > 0 {
+		info.PS1DiscID = discIDs[0]
+		info.DiscCount = len(discIDs)
+	}
+
+	return info, nil
+}
+
+// readPS1DiscIDs returns the disc IDs of the PS1 discs in DATA.PSAR, or nil
+// if DATA.PSAR doesn't contain PS1 disc images.
+func readPS1DiscIDs(r io.ReaderAt, psarOffset, psarSize int64) []string {
+	magic := make([]byte, psarMagicBufferSize)
+	if psarSize < int64(len(magic)) {
+		return nil
+	}
+	if _, err := r.ReadAt(magic, psarOffset); err != nil {
+		return nil
+	}
+
+	switch {
+	case bytes.HasPrefix(magic, []byte(pstitleMagic)):
+		table := make([]byte, pstitleMaxDiscs*4)
+		if psarSize < pstitleDiscsOffset+int64(len(table)) {
+			return nil
+		}
+		if _, err := r.ReadAt(table, psarOffset+pstitleDiscsOffset); err != nil {
+			return nil
+		}
+		var discIDs []string
+		for i := range pstitleMaxDiscs {
+			discOffset := int64(binary.LittleEndian.Uint32(table[i*4:]))
+			if discOffset == 0 {
+				break
+			}
+			if discID := readPSISODiscID(r, psarOffset+discOffset, psarSize-discOffset); discID != "" {
+				discIDs = append(discIDs, discID)
+			}
+		}
+		return discIDs
+	case bytes.HasPrefix(magic, []byte(psisoMagic)):
+		if discID := readPSISODiscID(r, psarOffset, psarSize); discID != "" {
+			return []string{discID}
+		}
+	}
+	return nil
+}
+
+// readPSISODiscID reads the disc ID from a PSISOIMG header, or returns "" if
+// there is no valid PSISOIMG header at the offset.
+func readPSISODiscID(r io.ReaderAt, offset, size int64) string {
+	if size < psisoDiscIDOffset+psisoDiscIDLen {
+		return ""
+	}
+	buf := make([]byte, psisoDiscIDOffset+psisoDiscIDLen)
+	if _, err := r.ReadAt(buf, offset); err != nil {
+		return ""
+	}
+	if !bytes.HasPrefix(buf, []byte(psisoMagic)) {
+		return ""
+	}
+	return strings.TrimPrefix(util.ExtractASCII(buf[psisoDiscIDOffset:]), "_")
+}
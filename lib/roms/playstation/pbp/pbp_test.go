@@ -0,0 +1,175 @@
+package pbp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/sargunv/rom-tools/lib/core"
+)
+
+// makeMinimalSFO creates a minimal valid SFO with DISC_ID and TITLE entries.
+func makeMinimalSFO(title, discID string) []byte {
+	keyTable := "DISC_ID\x00TITLE\x00"
+	dataTable := discID + "\x00" + title + "\x00"
+	for len(dataTable)%4 != 0 {
+		dataTable += "\x00"
+	}
+
+	headerSize := 20
+	keyTableOffset := headerSize + 2*16
+	dataTableOffset := keyTableOffset + len(keyTable)
+	sfo := make([]byte, dataTableOffset+len(dataTable))
+
+	copy(sfo[0:4], "\x00PSF")
+	binary.LittleEndian.PutUint32(sfo[4:8], 0x00000101)
+	binary.LittleEndian.PutUint32(sfo[8:12], uint32(keyTableOffset))
+	binary.LittleEndian.PutUint32(sfo[12:16], uint32(dataTableOffset))
+	binary.LittleEndian.PutUint32(sfo[16:20], 2)
+
+	// DISC_ID
+	idx1 := sfo[headerSize : headerSize+16]
+	binary.LittleEndian.PutUint16(idx1[0:2], 0)
+	binary.LittleEndian.PutUint16(idx1[2:4], 0x0204)
+	binary.LittleEndian.PutUint32(idx1[4:8], uint32(len(discID)+1))
+	binary.LittleEndian.PutUint32(idx1[8:12], uint32(len(discID)+1))
+	binary.LittleEndian.PutUint32(idx1[12:16], 0)
+
+	// TITLE
+	idx2 := sfo[headerSize+16 : headerSize+32]
+	binary.LittleEndian.PutUint16(idx2[0:2], 8)
+	binary.LittleEndian.PutUint16(idx2[2:4], 0x0204)
+	binary.LittleEndian.PutUint32(idx2[4:8], uint32(len(title)+1))
+	binary.LittleEndian.PutUint32(idx2[8:12], uint32(len(title)+1))
+	binary.LittleEndian.PutUint32(idx2[12:16], uint32(len(discID)+1))
+
+	copy(sfo[keyTableOffset:], keyTable)
+	copy(sfo[dataTableOffset:], dataTable)
+	return sfo
+}
+
+// makePSISO creates a PSISOIMG header with the given disc ID.
+func makePSISO(discID string) []byte {
+	psiso := make([]byte, 0x800)
+	copy(psiso, psisoMagic)
+	copy(psiso[psisoDiscIDOffset:], "_"+discID)
+	return psiso
+}
+
+// makeTestPBP creates a PBP with the given PARAM.SFO and DATA.PSAR. All
+// other sections are empty.
+func makeTestPBP(sfo, psar []byte) []byte {
+	pbp := make([]byte, pbpHeaderSize)
+	copy(pbp[0:4], pbpMagic)
+	binary.LittleEndian.PutUint32(pbp[pbpVersionOff:], 0x00010000)
+
+	sfoOffset := uint32(pbpHeaderSize)
+	psarOffset := sfoOffset + uint32(len(sfo))
+	binary.LittleEndian.PutUint32(pbp[pbpSectionsOff:], sfoOffset)
+	for i := 1; i < pbpSectionCount; i++ {
+		binary.LittleEndian.PutUint32(pbp[pbpSectionsOff+i*4:], psarOffset)
+	}
+
+	pbp = append(pbp, sfo...)
+	return append(pbp, psar...)
+}
+
+func TestParse_PSP(t *testing.T) {
+	data := makeTestPBP(makeMinimalSFO("Test Game", "NPUG80318"), []byte("NPUMDIMG"))
+
+	info, err := Parse(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if info.PS1DiscID != "" {
+		t.Errorf("PS1DiscID = %q, want empty", info.PS1DiscID)
+	}
+	if got := info.GamePlatform(); got != core.PlatformPSP {
+		t.Errorf("GamePlatform() = %v, want %v", got, core.PlatformPSP)
+	}
+	if got := info.GameTitle(); got != "Test Game" {
+		t.Errorf("GameTitle() = %q, want %q", got, "Test Game")
+	}
+	if got := info.GameSerial(); got != "NPUG-80318" {
+		t.Errorf("GameSerial() = %q, want %q", got, "NPUG-80318")
+	}
+	if regions := info.GameRegions(); len(regions) != 1 || regions[0] != core.RegionUSA {
+		t.Errorf("GameRegions() = %v, want [USA]", regions)
+	}
+}
+
+func TestParse_PS1Classic(t *testing.T) {
+	data := makeTestPBP(makeMinimalSFO("Castlevania", "SLUS00067"), makePSISO("SLUS_00067"))
+
+	info, err := Parse(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if info.PS1DiscID != "SLUS_00067" {
+		t.Errorf("PS1DiscID = %q, want %q", info.PS1DiscID, "SLUS_00067")
+	}
+	if info.DiscCount != 1 {
+		t.Errorf("DiscCount = %d, want 1", info.DiscCount)
+	}
+	if got := info.GamePlatform(); got != core.PlatformPS1 {
+		t.Errorf("GamePlatform() = %v, want %v", got, core.PlatformPS1)
+	}
+	if got := info.GameTitle(); got != "Castlevania" {
+		t.Errorf("GameTitle() = %q, want %q", got, "Castlevania")
+	}
+	if got := info.GameSerial(); got != "SLUS-00067" {
+		t.Errorf("GameSerial() = %q, want %q", got, "SLUS-00067")
+	}
+	if regions := info.GameRegions(); len(regions) != 1 || regions[0] != core.RegionUSA {
+		t.Errorf("GameRegions() = %v, want [USA]", regions)
+	}
+}
+
+func TestParse_PS1MultiDisc(t *testing.T) {
+	psar := make([]byte, 0x400)
+	copy(psar, pstitleMagic)
+	disc1 := makePSISO("SCES_02085")
+	disc2 := makePSISO("SCES_12085")
+	binary.LittleEndian.PutUint32(psar[pstitleDiscsOffset:], uint32(len(psar)))
+	binary.LittleEndian.PutUint32(psar[pstitleDiscsOffset+4:], uint32(len(psar)+len(disc1)))
+	psar = append(psar, disc1...)
+	psar = append(psar, disc2...)
+
+	data := makeTestPBP(makeMinimalSFO("Final Fantasy IX", "SCES02085"), psar)
+
+	info, err := Parse(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if info.PS1DiscID != "SCES_02085" {
+		t.Errorf("PS1DiscID = %q, want %q", info.PS1DiscID, "SCES_02085")
+	}
+	if info.DiscCount != 2 {
+		t.Errorf("DiscCount = %d, want 2", info.DiscCount)
+	}
+	if regions := info.GameRegions(); len(regions) != 1 || regions[0] != core.RegionEurope {
+		t.Errorf("GameRegions() = %v, want [Europe]", regions)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"too small", make([]byte, pbpHeaderSize-1)},
+		{"invalid magic", make([]byte, pbpHeaderSize)},
+		{"invalid SFO", makeTestPBP(make([]byte, 32), nil)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(bytes.NewReader(tt.data), int64(len(tt.data))); err == nil {
+				t.Error("Parse() expected error, got nil")
+			}
+		})
+	}
+}
@@ -55,6 +55,10 @@ const (
 	pkgContentIDOffset   = 0x30
 	pkgContentIDLen      = 48
 
+	// Content ID layout: "UP0001-NPUA80472_00-LITTLEBIGPLAN001" (service ID, title ID, label)
+	contentIDTitleIDOffset = 7
+	contentIDTitleIDLen    = 9
+
 	// Extended header (for PSP/Vita, located after main header + PS3 digest)
 	pkgExtHeaderOffset = pkgHeaderSize + pkgPS3DigestSize // 0xC0
 	pkgExtKeyIDOffset  = 0x24                             // within extended header
@@ -103,6 +107,8 @@ const (
 type Info struct {
 	// ContentID is the package content identifier (e.g., "UP0001-NPUA80472_00-LITTLEBIGPLAN001").
 	ContentID string `json:"content_id,omitempty"`
+	// TitleID is the title identifier from the content ID (e.g., "NPUA80472").
+	TitleID string `json:"title_id,omitempty"`
 	// Title is the game title from embedded PARAM.SFO.
 	Title string `json:"title,omitempty"`
 	// Platform is the detected platform (psp, psvita, psm, playstation3).
@@ -216,6 +222,7 @@ func Parse(r io.ReaderAt, size int64) (*Info, error) {
 
 	info := &Info{
 		ContentID:   contentID,
+		TitleID:     titleIDFromContentID(contentID),
 		Platform:    platform,
 		Type:        pkgType,
 		ContentType: contentType,
@@ -239,6 +246,15 @@ func Parse(r io.ReaderAt, size int64) (*Info, error) {
 	return info, nil
 }
 
+// titleIDFromContentID extracts the title ID from a content ID.
+// Example: "UP0001-NPUA80472_00-LITTLEBIGPLAN001" → "NPUA80472"
+func titleIDFromContentID(contentID string) string {
+	if len(contentID) < contentIDTitleIDOffset+contentIDTitleIDLen || contentID[contentIDTitleIDOffset-1] != '-' {
+		return ""
+	}
+	return contentID[contentIDTitleIDOffset : contentIDTitleIDOffset+contentIDTitleIDLen]
+}
+
 // parseMetadata reads PKG metadata entries to extract content type and SFO info.
 func parseMetadata(r io.ReaderAt, offset uint32, count uint32, fileSize int64) (ContentType, uint32, uint32, error) {
 	var contentType ContentType
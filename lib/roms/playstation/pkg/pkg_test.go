@@ -150,6 +150,9 @@ func TestParse(t *testing.T) {
 	if info.ContentID != contentID {
 		t.Errorf("ContentID = %q, want %q", info.ContentID, contentID)
 	}
+	if info.TitleID != "NPUA80472" {
+		t.Errorf("TitleID = %q, want %q", info.TitleID, "NPUA80472")
+	}
 	if info.Type != TypePS3 {
 		t.Errorf("Type = %v, want %v", info.Type, TypePS3)
 	}
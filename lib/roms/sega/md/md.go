@@ -18,7 +18,7 @@ import (
 // Header layout (starting at $100):
 //
 //	Offset   Size  Description
-//	$100     16    System Type (e.g., "SEGA MEGA DRIVE" or "SEGA GENESIS")
+//	$100     16    System Type (e.g., "SEGA MEGA DRIVE", "SEGA GENESIS", "SEGA 32X", "SEGA PICO")
 //	$110     16    Copyright/Release Date (e.g., "(C)SEGA YYYY.MM")
 //	$120     48    Domestic Title (Japanese)
 //	$150     48    Overseas Title (International)
@@ -27,10 +27,22 @@ import (
 //	$190     16    Device Support (I/O info)
 //	$1A0     8     ROM Address Range
 //	$1A8     8     RAM Address Range
-//	$1B0     12    Extra Memory (SRAM info)
+//	$1B0     12    Extra Memory (SRAM info, see below)
 //	$1BC     12    Modem Support
 //	$1C8     40    Reserved
 //	$1F0     16    Region Support (first 3 chars typically significant)
+//
+// Extra memory layout (at $1B0, when present):
+//
+//	Offset   Size  Description
+//	$1B0     2     "RA"
+//	$1B2     1     Type (bit 6 = battery-backed; bits 3-4 = 0 word, 2 even bytes, 3 odd bytes, 1 EEPROM)
+//	$1B3     1     $20
+//	$1B4     4     Start address
+//	$1B8     4     End address
+//
+// The checksum is the 16-bit sum of the big-endian words from $200 to the
+// end of the ROM.
 
 // Format represents the source ROM format.
 type Format int
@@ -50,6 +62,28 @@ const (
 	RegionOverseas50Hz Region = 1 << 3 // 0x08 - bit 3: Europe (PAL)
 )
 
+// SRAMType describes how backup memory is wired.
+type SRAMType string
+
+const (
+	SRAMTypeWord   SRAMType = "word"   // 16-bit, both even and odd addresses
+	SRAMTypeEven   SRAMType = "even"   // 8-bit, even addresses
+	SRAMTypeOdd    SRAMType = "odd"    // 8-bit, odd addresses
+	SRAMTypeEEPROM SRAMType = "eeprom" // Serial EEPROM
+)
+
+// SRAM describes a cartridge's backup memory.
+type SRAM struct {
+	// Type is how the memory is wired.
+	Type SRAMType `json:"type"`
+	// Battery indicates the memory is battery-backed (used for saves).
+	Battery bool `json:"battery"`
+	// Start is the memory's start address.
+	Start uint32 `json:"start"`
+	// End is the memory's end address.
+	End uint32 `json:"end"`
+}
+
 // Device represents a supported input device.
 type Device string
 
@@ -93,12 +127,26 @@ const (
 	mdRegionOffset       = 0x1F0
 	mdRegionLen          = 16
 
+	mdSRAMMagic       = "RA"
+	mdSRAMTypeOffset  = 0x1B2
+	mdSRAMStartOffset = 0x1B4
+	mdSRAMEndOffset   = 0x1B8
+	mdSRAMBattery     = 0x40
+
+	// Checksummed data starts after the header
+	mdChecksumStart = 0x200
+
 	// 32X-specific constants
 	// The 32X MARS header at offset 0x3C0 identifies 32X ROMs.
 	// It typically starts with "MARS" (e.g., "MARS CHECK MODE").
+	// Some 32X ROMs only declare the add-on in the system type instead.
 	md32XHeaderOffset = 0x3C0
 	md32XMagicLen     = 4
 	md32XMagic        = "MARS"
+	md32XSystemType   = "32X"
+
+	// Pico ROMs use the Mega Drive header with a "SEGA PICO" system type.
+	mdPicoSystemType = "PICO"
 
 	// Minimum size needed for full parsing including 32X detection
 	mdMinParseSize = md32XHeaderOffset + md32XMagicLen // 0x3C4
@@ -120,6 +168,8 @@ type Info struct {
 	SerialNumber string `json:"serial_number,omitempty"`
 	// Checksum is the ROM checksum (big-endian).
 	Checksum uint16 `json:"checksum"`
+	// ChecksumValid reports whether Checksum matches the ROM contents.
+	ChecksumValid bool `json:"checksum_valid"`
 	// Devices contains supported input devices.
 	Devices []Device `json:"devices,omitempty"`
 	// Region is a bitfield of supported regions.
@@ -137,17 +187,26 @@ type Info struct {
 	RAMEnd uint32 `json:"ram_end"`
 	// SRAMInfo contains backup memory information (if present).
 	SRAMInfo string `json:"sram_info,omitempty"`
+	// SRAM is the decoded backup memory information, or nil if the ROM
+	// declares none.
+	SRAM *SRAM `json:"sram,omitempty"`
 	// ModemInfo contains modem/network support information (rarely used).
 	ModemInfo string `json:"modem_info,omitempty"`
 	// Is32X indicates whether this ROM is for the Sega 32X add-on.
-	// Detected by presence of "MARS" at offset 0x3C0.
+	// Detected by presence of "MARS" at offset 0x3C0 or a "SEGA 32X" system type.
 	Is32X bool `json:"is_32x,omitempty"`
+	// IsPico indicates whether this ROM is for the Sega Pico.
+	// Detected by a "SEGA PICO" system type.
+	IsPico bool `json:"is_pico,omitempty"`
 }
 
 // GamePlatform implements core.GameInfo.
 func (i *Info) GamePlatform() core.Platform {
-	if i.Is32X {
+	switch {
+	case i.Is32X:
 		return core.Platform32X
+	case i.IsPico:
+		return core.PlatformPico
 	}
 	return core.PlatformMD
 }
@@ -207,6 +266,12 @@ func parseMD(r io.ReaderAt, size int64) (*Info, error) {
 		return nil, err
 	}
 	info.SourceFormat = FormatMD
+
+	computed, err := computeChecksum(r, size)
+	if err != nil {
+		return nil, err
+	}
+	info.ChecksumValid = computed == info.Checksum
 	return info, nil
 }
 
@@ -250,7 +315,7 @@ func parseMDBytes(data []byte) (*Info, error) {
 
 	// Check for 32X by looking for "MARS" at offset 0x3C0
 	// This is the start of the MARS header (e.g., "MARS CHECK MODE")
-	is32X := false
+	is32X := strings.Contains(systemType, md32XSystemType)
 	if len(data) >= md32XHeaderOffset+md32XMagicLen {
 		marsData := string(data[md32XHeaderOffset : md32XHeaderOffset+md32XMagicLen])
 		if marsData == md32XMagic {
@@ -272,11 +337,56 @@ func parseMDBytes(data []byte) (*Info, error) {
 		RAMStart:      ramStart,
 		RAMEnd:        ramEnd,
 		SRAMInfo:      sramInfo,
+		SRAM:          parseSRAM(data),
 		ModemInfo:     modemInfo,
 		Is32X:         is32X,
+		IsPico:        strings.Contains(systemType, mdPicoSystemType),
 	}, nil
 }
 
+// parseSRAM decodes the extra memory field, returning nil if it doesn't
+// declare backup memory.
+func parseSRAM(data []byte) *SRAM {
+	if string(data[mdSRAMInfoOffset:mdSRAMInfoOffset+len(mdSRAMMagic)]) != mdSRAMMagic {
+		return nil
+	}
+	typ := data[mdSRAMTypeOffset]
+	sram := &SRAM{
+		Battery: typ&mdSRAMBattery != 0,
+		Start:   binary.BigEndian.Uint32(data[mdSRAMStartOffset:]),
+		End:     binary.BigEndian.Uint32(data[mdSRAMEndOffset:]),
+	}
+	switch typ >> 3 & 0x3 {
+	case 0:
+		sram.Type = SRAMTypeWord
+	case 1:
+		sram.Type = SRAMTypeEEPROM
+	case 2:
+		sram.Type = SRAMTypeEven
+	case 3:
+		sram.Type = SRAMTypeOdd
+	}
+	return sram
+}
+
+// computeChecksum sums the big-endian words from $200 to the end of the ROM.
+// A trailing odd byte is ignored.
+func computeChecksum(r io.ReaderAt, size int64) (uint16, error) {
+	var sum uint16
+	buf := make([]byte, 32*1024)
+	for offset := int64(mdChecksumStart); offset+1 < size; {
+		n := min(int64(len(buf)), (size-offset)&^1)
+		if _, err := r.ReadAt(buf[:n], offset); err != nil {
+			return 0, fmt.Errorf("failed to read Mega Drive ROM: %w", err)
+		}
+		for i := int64(0); i < n; i += 2 {
+			sum += binary.BigEndian.Uint16(buf[i:])
+		}
+		offset += n
+	}
+	return sum, nil
+}
+
 // parseRegionCodes extracts region codes from the region field.
 // Mega Drive uses two styles:
 // - Old style: ASCII chars like J (Japan), U (USA), E (Europe)
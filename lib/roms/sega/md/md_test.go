@@ -2,8 +2,11 @@ package md
 
 import (
 	"bytes"
+	"encoding/binary"
 	"os"
 	"testing"
+
+	"github.com/sargunv/rom-tools/lib/core"
 )
 
 func TestParseRegionCodes(t *testing.T) {
@@ -161,3 +164,119 @@ func TestParse32X_SmallFile(t *testing.T) {
 		t.Error("Is32X should be false for files too small to contain MARS header")
 	}
 }
+
+func TestParse32X_SystemType(t *testing.T) {
+	// 32X ROM without a MARS header, identified by its system type alone
+	data := make([]byte, 0x200)
+	copy(data[mdSystemTypeOffset:], "SEGA 32X        ")
+	copy(data[mdOverseasTitleOff:], "TEST 32X GAME")
+
+	info, err := Parse(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if !info.Is32X {
+		t.Error("Is32X should be true for ROMs with a SEGA 32X system type")
+	}
+	if got := info.GamePlatform(); got != core.Platform32X {
+		t.Errorf("GamePlatform() = %q, want %q", got, core.Platform32X)
+	}
+}
+
+func TestParsePico(t *testing.T) {
+	data := make([]byte, 0x400)
+	copy(data[mdSystemTypeOffset:], "SEGA PICO       ")
+	copy(data[mdCopyrightOffset:], "(C)SEGA 1994.JUN")
+	copy(data[mdOverseasTitleOff:], "TEST PICO GAME")
+	copy(data[mdSerialNumberOffset:], "MK 00000-00")
+
+	info, err := Parse(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if !info.IsPico {
+		t.Error("IsPico should be true for ROMs with a SEGA PICO system type")
+	}
+	if info.Is32X {
+		t.Error("Is32X should be false for Pico ROMs")
+	}
+	if got := info.GamePlatform(); got != core.PlatformPico {
+		t.Errorf("GamePlatform() = %q, want %q", got, core.PlatformPico)
+	}
+}
+
+// makeChecksummedMD creates a synthetic Mega Drive ROM with a correct checksum.
+func makeChecksummedMD() []byte {
+	data := make([]byte, 0x1000)
+	copy(data[mdSystemTypeOffset:], "SEGA MEGA DRIVE ")
+	copy(data[mdOverseasTitleOff:], "CHECKSUM TEST")
+	for i := mdChecksumStart; i < len(data); i++ {
+		data[i] = byte(i * 7)
+	}
+	var sum uint16
+	for i := mdChecksumStart; i < len(data); i += 2 {
+		sum += binary.BigEndian.Uint16(data[i:])
+	}
+	binary.BigEndian.PutUint16(data[mdChecksumOffset:], sum)
+	return data
+}
+
+func TestParseChecksum(t *testing.T) {
+	data := makeChecksummedMD()
+	info, err := Parse(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if !info.ChecksumValid {
+		t.Errorf("ChecksumValid = false for checksum 0x%04X", info.Checksum)
+	}
+
+	data[len(data)-1]++
+	info, err = Parse(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if info.ChecksumValid {
+		t.Error("ChecksumValid = true for modified ROM data")
+	}
+}
+
+func TestParseSRAM(t *testing.T) {
+	tests := []struct {
+		name string
+		info []byte
+		want *SRAM
+	}{
+		{"none", []byte("            "), nil},
+		{"odd battery", []byte{'R', 'A', 0xF8, 0x20, 0x00, 0x20, 0x00, 0x01, 0x00, 0x20, 0x3F, 0xFF},
+			&SRAM{Type: SRAMTypeOdd, Battery: true, Start: 0x200001, End: 0x203FFF}},
+		{"even", []byte{'R', 'A', 0xB0, 0x20, 0x00, 0x20, 0x00, 0x00, 0x00, 0x20, 0x3F, 0xFE},
+			&SRAM{Type: SRAMTypeEven, Start: 0x200000, End: 0x203FFE}},
+		{"word", []byte{'R', 'A', 0xE0, 0x20, 0x00, 0x20, 0x00, 0x00, 0x00, 0x2F, 0xFF, 0xFF},
+			&SRAM{Type: SRAMTypeWord, Battery: true, Start: 0x200000, End: 0x2FFFFF}},
+		{"EEPROM", []byte{'R', 'A', 0xE8, 0x40, 0x00, 0x20, 0x00, 0x01, 0x00, 0x20, 0x00, 0x01},
+			&SRAM{Type: SRAMTypeEEPROM, Battery: true, Start: 0x200001, End: 0x200001}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := makeChecksummedMD()
+			copy(data[mdSRAMInfoOffset:], tt.info)
+			info, err := Parse(bytes.NewReader(data), int64(len(data)))
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if tt.want == nil {
+				if info.SRAM != nil {
+					t.Errorf("SRAM = %+v, want nil", info.SRAM)
+				}
+				return
+			}
+			if info.SRAM == nil || *info.SRAM != *tt.want {
+				t.Errorf("SRAM = %+v, want %+v", info.SRAM, tt.want)
+			}
+		})
+	}
+}
@@ -3,7 +3,9 @@ package md
 import (
 	"fmt"
 	"io"
+	"regexp"
 	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/sargunv/rom-tools/internal/util"
@@ -23,6 +25,9 @@ import (
 //
 // The Genesis-style header at offset 0x100 uses the same format as cartridge ROMs.
 //
+// There's no disc number field; multi-disc games name the disc in their titles
+// (e.g., "SLAM CITY DISC 2").
+//
 // Documentation:
 //   - https://www.retrodev.com/segacd.html
 //   - https://www.plutiedev.com/rom-header
@@ -42,6 +47,9 @@ const (
 	discIDLen        = 16
 )
 
+// discNumberPattern matches a disc number in a title, e.g. "DISC 2" or "DISK-1".
+var discNumberPattern = regexp.MustCompile(`(?i)\bDIS[CK][ -]*([1-9])\b`)
+
 // Known disc identifiers
 var (
 	discIDBootable = []string{
@@ -74,6 +82,9 @@ type CDInfo struct {
 	Devices []Device `json:"devices,omitempty"`
 	// Region is a bitfield of supported regions.
 	Region Region `json:"region,omitempty"`
+	// DiscNumber is the disc number of a multi-disc game, from its title, or
+	// 0 if the title doesn't name one.
+	DiscNumber int `json:"disc_number,omitempty"`
 }
 
 // GamePlatform implements core.GameInfo.
@@ -147,10 +158,23 @@ func parseCDBytes(data []byte) (*CDInfo, error) {
 		Devices:       devices,
 		Region:        region,
 	}
+	info.DiscNumber = parseDiscNumber(info.OverseasTitle, info.DomesticTitle)
 
 	return info, nil
 }
 
+// parseDiscNumber returns the disc number named in the first title that has
+// one, or 0.
+func parseDiscNumber(titles ...string) int {
+	for _, title := range titles {
+		if m := discNumberPattern.FindStringSubmatch(title); m != nil {
+			n, _ := strconv.Atoi(m[1])
+			return n
+		}
+	}
+	return 0
+}
+
 // getDiscType returns the disc type based on the identifier.
 func getDiscType(discID string) DiscType {
 	if slices.Contains(discIDBootable, discID) {
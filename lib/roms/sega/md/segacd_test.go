@@ -181,3 +181,33 @@ func TestParseCD_AllDevices(t *testing.T) {
 		}
 	}
 }
+
+func TestParseCD_DiscNumber(t *testing.T) {
+	testCases := []struct {
+		domestic, overseas string
+		want               int
+	}{
+		{"SONIC THE HEDGEHOG CD", "SONIC CD", 0},
+		{"SLAM CITY", "SLAM CITY DISC 2", 2},
+		{"NIGHT TRAP DISK-1", "", 1},
+		{"", "Dracula Unleashed Disc1", 1},
+		{"DISCOVERY", "", 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.domestic+tc.overseas, func(t *testing.T) {
+			data := make([]byte, 0x200)
+			copy(data[0x00:], "SEGADISCSYSTEM  ")
+			copy(data[0x120:], tc.domestic)
+			copy(data[0x150:], tc.overseas)
+
+			info, err := ParseCD(bytes.NewReader(data), int64(len(data)))
+			if err != nil {
+				t.Fatalf("ParseCD failed: %v", err)
+			}
+			if info.DiscNumber != tc.want {
+				t.Errorf("DiscNumber = %d, want %d", info.DiscNumber, tc.want)
+			}
+		})
+	}
+}
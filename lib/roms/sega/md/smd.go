@@ -27,7 +27,10 @@ import (
 // odd-position bytes in the second 8KB.
 
 const (
-	smdHeaderSize = 512
+	// SMDHeaderSize is the size of the copier header at the start of an SMD
+	// file.
+	SMDHeaderSize = 512
+
 	smdBlockSize  = 16384 // 16KB blocks
 	smdHalfBlock  = 8192  // Half of a block (for interleaving)
 	smdMagicByte1 = 0x03  // Fixed value at offset 1
@@ -38,11 +41,11 @@ const (
 // isSMDROM checks if the file has an SMD header.
 // SMD files have a 512-byte header with specific magic bytes.
 func isSMDROM(r io.ReaderAt, size int64) bool {
-	if size < smdHeaderSize+smdBlockSize {
+	if size < SMDHeaderSize+smdBlockSize {
 		return false
 	}
 
-	header := make([]byte, smdHeaderSize)
+	header := make([]byte, SMDHeaderSize)
 	if _, err := r.ReadAt(header, 0); err != nil {
 		return false
 	}
@@ -84,54 +87,80 @@ func deinterleaveSMDBlock(block []byte) []byte {
 	return result
 }
 
-// deinterleaveSMD de-interleaves an entire SMD ROM (excluding header).
-func deinterleaveSMD(data []byte) []byte {
-	numBlocks := len(data) / smdBlockSize
-	result := make([]byte, 0, len(data))
+// SMDReader provides random access to the native Mega Drive ROM in an SMD
+// file: the copier header is dropped and each block is de-interleaved as it's
+// read. A trailing partial block (which valid SMD files don't have) is passed
+// through unchanged.
+type SMDReader struct {
+	r    io.ReaderAt
+	size int64
+}
 
-	for i := 0; i < numBlocks; i++ {
-		start := i * smdBlockSize
-		end := start + smdBlockSize
-		block := deinterleaveSMDBlock(data[start:end])
-		result = append(result, block...)
+// NewSMDReader opens an SMD file as a native Mega Drive ROM.
+func NewSMDReader(r io.ReaderAt, size int64) (*SMDReader, error) {
+	if size < SMDHeaderSize+smdBlockSize {
+		return nil, fmt.Errorf("file too small for SMD format: %d bytes", size)
 	}
-
-	// Handle any remaining bytes (shouldn't happen with valid SMD files)
-	remaining := len(data) % smdBlockSize
-	if remaining > 0 {
-		result = append(result, data[numBlocks*smdBlockSize:]...)
+	if !isSMDROM(r, size) {
+		return nil, fmt.Errorf("not a valid SMD ROM")
 	}
+	return &SMDReader{r: r, size: size - SMDHeaderSize}, nil
+}
 
-	return result
+// Size returns the size of the native ROM.
+func (s *SMDReader) Size() int64 {
+	return s.size
 }
 
-// parseSMD extracts game information from an SMD (Super Magic Drive) ROM file.
-// SMD files have a 512-byte header and interleaved data that needs de-interleaving.
-func parseSMD(r io.ReaderAt, size int64) (*Info, error) {
-	if size < smdHeaderSize+smdBlockSize {
-		return nil, fmt.Errorf("file too small for SMD format: %d bytes", size)
+// ReadAt implements io.ReaderAt, reading from the native ROM.
+func (s *SMDReader) ReadAt(p []byte, off int64) (n int, err error) {
+	if off < 0 {
+		return 0, fmt.Errorf("negative offset")
 	}
 
-	// Validate SMD format
-	if !isSMDROM(r, size) {
-		return nil, fmt.Errorf("not a valid SMD ROM")
+	block := make([]byte, smdBlockSize)
+	for n < len(p) && off < s.size {
+		start := off &^ (smdBlockSize - 1)
+		data := block[:min(smdBlockSize, s.size-start)]
+		if _, err := s.r.ReadAt(data, SMDHeaderSize+start); err != nil {
+			return n, fmt.Errorf("read SMD block %d: %w", start/smdBlockSize, err)
+		}
+		data = deinterleaveSMDBlock(data)
+
+		copied := copy(p[n:], data[off-start:])
+		n += copied
+		off += int64(copied)
 	}
 
-	// Read ROM data (after header)
-	romSize := size - smdHeaderSize
-	romData := make([]byte, romSize)
-	if _, err := r.ReadAt(romData, smdHeaderSize); err != nil {
-		return nil, fmt.Errorf("failed to read SMD ROM data: %w", err)
+	if n < len(p) {
+		return n, io.EOF
 	}
+	return n, nil
+}
 
-	// De-interleave the ROM data
-	deinterleaved := deinterleaveSMD(romData)
+// parseSMD extracts game information from an SMD (Super Magic Drive) ROM file.
+// SMD files have a 512-byte header and interleaved data that needs de-interleaving.
+func parseSMD(r io.ReaderAt, size int64) (*Info, error) {
+	reader, err := NewSMDReader(r, size)
+	if err != nil {
+		return nil, err
+	}
 
 	// Parse as a regular MD ROM using the de-interleaved data
-	info, err := parseMDBytes(deinterleaved)
+	data := make([]byte, min(reader.Size(), mdMinParseSize))
+	if _, err := reader.ReadAt(data, 0); err != nil {
+		return nil, fmt.Errorf("failed to read SMD ROM data: %w", err)
+	}
+	info, err := parseMDBytes(data)
 	if err != nil {
 		return nil, err
 	}
 	info.SourceFormat = FormatSMD
+
+	computed, err := computeChecksum(reader, reader.Size())
+	if err != nil {
+		return nil, err
+	}
+	info.ChecksumValid = computed == info.Checksum
 	return info, nil
 }
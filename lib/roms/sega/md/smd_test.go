@@ -1,6 +1,8 @@
 package md
 
 import (
+	"bytes"
+	"io"
 	"os"
 	"testing"
 )
@@ -34,3 +36,83 @@ func TestParseSMD(t *testing.T) {
 		t.Error("SystemType should not be empty")
 	}
 }
+
+func TestParseSMD_Checksum(t *testing.T) {
+	// Build a one-block SMD ROM from a checksummed MD ROM
+	md := make([]byte, smdBlockSize)
+	copy(md, makeChecksummedMD())
+	var sum uint16
+	for i := mdChecksumStart; i < len(md); i += 2 {
+		sum += uint16(md[i])<<8 | uint16(md[i+1])
+	}
+	md[mdChecksumOffset], md[mdChecksumOffset+1] = byte(sum>>8), byte(sum)
+
+	smd := make([]byte, SMDHeaderSize+smdBlockSize)
+	smd[0], smd[1], smd[8], smd[9] = 1, smdMagicByte1, smdMagicByte8, smdMagicByte9
+	for i := range smdHalfBlock {
+		smd[SMDHeaderSize+smdHalfBlock+i] = md[i*2]
+		smd[SMDHeaderSize+i] = md[i*2+1]
+	}
+
+	info, err := Parse(bytes.NewReader(smd), int64(len(smd)))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if info.SourceFormat != FormatSMD {
+		t.Errorf("SourceFormat = %v, want FormatSMD", info.SourceFormat)
+	}
+	if !info.ChecksumValid {
+		t.Errorf("ChecksumValid = false for checksum 0x%04X", info.Checksum)
+	}
+}
+
+func TestSMDReader(t *testing.T) {
+	smd, err := os.ReadFile("testdata/Censor_Intro.smd")
+	if err != nil {
+		t.Fatalf("Failed to read SMD file: %v", err)
+	}
+	want, err := os.ReadFile("testdata/Censor_Intro.md")
+	if err != nil {
+		t.Fatalf("Failed to read MD file: %v", err)
+	}
+
+	reader, err := NewSMDReader(bytes.NewReader(smd), int64(len(smd)))
+	if err != nil {
+		t.Fatalf("NewSMDReader() error = %v", err)
+	}
+	if reader.Size() != int64(len(want)) {
+		t.Fatalf("Size() = %d, want %d", reader.Size(), len(want))
+	}
+
+	got, err := io.ReadAll(io.NewSectionReader(reader, 0, reader.Size()))
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Error("de-interleaved SMD doesn't match the native ROM")
+	}
+
+	// A read spanning a block boundary
+	buf := make([]byte, 100)
+	if _, err := reader.ReadAt(buf, smdBlockSize-50); err != nil {
+		t.Fatalf("ReadAt() error = %v", err)
+	}
+	if !bytes.Equal(buf, want[smdBlockSize-50:smdBlockSize+50]) {
+		t.Error("ReadAt() across a block boundary doesn't match the native ROM")
+	}
+
+	// A read past the end
+	if n, err := reader.ReadAt(buf, reader.Size()-10); n != 10 || err != io.EOF {
+		t.Errorf("ReadAt() at end = %d, %v; want 10, EOF", n, err)
+	}
+}
+
+func TestNewSMDReader_NotSMD(t *testing.T) {
+	md, err := os.ReadFile("testdata/Censor_Intro.md")
+	if err != nil {
+		t.Fatalf("Failed to read MD file: %v", err)
+	}
+	if _, err := NewSMDReader(bytes.NewReader(md), int64(len(md))); err == nil {
+		t.Error("NewSMDReader() of a native ROM expected error, got nil")
+	}
+}
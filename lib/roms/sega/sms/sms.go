@@ -11,7 +11,9 @@ import (
 
 // Sega Master System / Game Gear ROM format parsing.
 //
-// Both SMS and GG share the same "TMR SEGA" header format at offset 0x7FF0.
+// Both SMS and GG share the same "TMR SEGA" header format, normally at offset
+// 0x7FF0. The header may also be at 0x3FF0 or 0x1FF0 in smaller ROMs, which
+// are checked if it is not found at 0x7FF0.
 // The platform is determined by the region code in the header.
 //
 // Header specification:
@@ -34,11 +36,18 @@ import (
 //	5 = Japan (GG)
 //	6 = Export (GG)
 //	7 = International (GG)
+//
+// Checksum:
+//
+// The checksum is the 16-bit sum of all ROM bytes in the range given by the
+// ROM size code, skipping the header itself. Only the export SMS BIOS checks
+// it, so many Japanese SMS and GG ROMs have an invalid or zero checksum.
 
 const (
 	smsHeaderOffset     = 0x7FF0
 	smsHeaderSize       = 16
 	smsMinROMSize       = smsHeaderOffset + smsHeaderSize
+	smsMinSmallROMSize  = 0x1FF0 + smsHeaderSize // header at the lowest alternate offset
 	smsMagicOffset      = 0x00
 	smsMagicSize        = 8
 	smsChecksumOffset   = 0x0A
@@ -49,6 +58,10 @@ const (
 
 var smsMagic = []byte("TMR SEGA")
 
+// smsHeaderOffsets lists the possible header locations, in the order the BIOS
+// and emulators check them.
+var smsHeaderOffsets = []int64{smsHeaderOffset, 0x3FF0, 0x1FF0}
+
 // Region represents the region code from the SMS/GG header.
 type Region byte
 
@@ -77,6 +90,32 @@ const (
 	ROMSize1MB   ROMSize = 0x2
 )
 
+// Bytes returns the ROM size in bytes, or 0 for an unknown size code.
+func (s ROMSize) Bytes() int64 {
+	switch s {
+	case ROMSize8KB:
+		return 8 * 1024
+	case ROMSize16KB:
+		return 16 * 1024
+	case ROMSize32KB:
+		return 32 * 1024
+	case ROMSize48KB:
+		return 48 * 1024
+	case ROMSize64KB:
+		return 64 * 1024
+	case ROMSize128KB:
+		return 128 * 1024
+	case ROMSize256KB:
+		return 256 * 1024
+	case ROMSize512KB:
+		return 512 * 1024
+	case ROMSize1MB:
+		return 1024 * 1024
+	default:
+		return 0
+	}
+}
+
 // Info contains metadata extracted from a Master System or Game Gear ROM file.
 type Info struct {
 	// ProductCode is the BCD-decoded product code (e.g., "7670").
@@ -89,6 +128,10 @@ type Info struct {
 	ROMSize ROMSize `json:"rom_size"`
 	// Checksum is the ROM checksum (little-endian).
 	Checksum uint16 `json:"checksum"`
+	// ChecksumValid reports whether Checksum matches the ROM contents.
+	ChecksumValid bool `json:"checksum_valid"`
+	// HeaderOffset is the file offset of the header (0x7FF0, 0x3FF0, or 0x1FF0).
+	HeaderOffset int64 `json:"header_offset"`
 	// platform is the detected platform (SMS or Game Gear) based on region code (internal, used by GamePlatform).
 	platform core.Platform
 }
@@ -118,19 +161,13 @@ func (i *Info) GameRegions() []core.Region {
 
 // Parse extracts game information from a Master System or Game Gear ROM file.
 func Parse(r io.ReaderAt, size int64) (*Info, error) {
-	if size < smsMinROMSize {
-		return nil, fmt.Errorf("file too small for SMS/GG header: %d bytes (need at least %d)", size, smsMinROMSize)
+	if size < smsMinSmallROMSize {
+		return nil, fmt.Errorf("file too small for SMS/GG header: %d bytes (need at least %d)", size, smsMinSmallROMSize)
 	}
 
-	// Read header at 0x7FF0
-	header := make([]byte, smsHeaderSize)
-	if _, err := r.ReadAt(header, smsHeaderOffset); err != nil {
-		return nil, fmt.Errorf("failed to read SMS/GG header: %w", err)
-	}
-
-	// Verify magic bytes
-	if !bytes.Equal(header[smsMagicOffset:smsMagicOffset+smsMagicSize], smsMagic) {
-		return nil, fmt.Errorf("not a valid SMS/GG ROM: invalid magic bytes")
+	header, headerOffset, err := findHeader(r, size)
+	if err != nil {
+		return nil, err
 	}
 
 	// Extract checksum (little-endian)
@@ -155,16 +192,72 @@ func Parse(r io.ReaderAt, size int64) (*Info, error) {
 	// Determine platform from region code
 	platform := determinePlatform(region)
 
+	computed, err := computeChecksum(r, size, headerOffset, romSize)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Info{
-		ProductCode: productCode,
-		Version:     version,
-		Region:      region,
-		ROMSize:     romSize,
-		Checksum:    checksum,
-		platform:    platform,
+		ProductCode:   productCode,
+		Version:       version,
+		Region:        region,
+		ROMSize:       romSize,
+		Checksum:      checksum,
+		ChecksumValid: computed == checksum,
+		HeaderOffset:  headerOffset,
+		platform:      platform,
 	}, nil
 }
 
+// findHeader locates and reads the "TMR SEGA" header.
+func findHeader(r io.ReaderAt, size int64) ([]byte, int64, error) {
+	header := make([]byte, smsHeaderSize)
+	for _, offset := range smsHeaderOffsets {
+		if offset+smsHeaderSize > size {
+			continue
+		}
+		if _, err := r.ReadAt(header, offset); err != nil {
+			return nil, 0, fmt.Errorf("failed to read SMS/GG header: %w", err)
+		}
+		if bytes.Equal(header[smsMagicOffset:smsMagicOffset+smsMagicSize], smsMagic) {
+			return header, offset, nil
+		}
+	}
+	return nil, 0, fmt.Errorf("not a valid SMS/GG ROM: invalid magic bytes")
+}
+
+// computeChecksum sums the ROM bytes covered by the ROM size code, skipping
+// the header. Ranges past the end of the file are ignored, so an overdump or
+// truncated ROM yields a mismatch rather than an error.
+func computeChecksum(r io.ReaderAt, size, headerOffset int64, romSize ROMSize) (uint16, error) {
+	end := romSize.Bytes()
+	if end == 0 {
+		return 0, nil
+	}
+	// Ranges for sizes up to 32 KiB stop 16 bytes short, where the header
+	// would be; larger sizes cover the whole ROM except the header itself
+	if end <= smsHeaderOffset+smsHeaderSize {
+		end -= smsHeaderSize
+	}
+	end = min(end, size)
+
+	var sum uint16
+	buf := make([]byte, 32*1024)
+	for _, span := range [][2]int64{{0, min(headerOffset, end)}, {headerOffset + smsHeaderSize, end}} {
+		for off := span[0]; off < span[1]; {
+			n := min(int64(len(buf)), span[1]-off)
+			if _, err := r.ReadAt(buf[:n], off); err != nil {
+				return 0, fmt.Errorf("failed to read SMS/GG ROM data: %w", err)
+			}
+			for _, b := range buf[:n] {
+				sum += uint16(b)
+			}
+			off += n
+		}
+	}
+	return sum, nil
+}
+
 // decodeBCDProductCode decodes the BCD-encoded product code.
 // The product code is stored as BCD in 2.5 bytes (low byte, high byte, and high nibble).
 func decodeBCDProductCode(low, high, extra byte) string {
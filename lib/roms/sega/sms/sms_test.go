@@ -158,3 +158,82 @@ func TestDecodeBCDProductCode(t *testing.T) {
 		}
 	}
 }
+
+func TestParse_Checksum(t *testing.T) {
+	rom := make(readerAt, 128*1024)
+	for i := range rom {
+		rom[i] = byte(i * 7)
+	}
+	copy(rom[smsHeaderOffset:], smsMagic)
+	rom[smsHeaderOffset+smsRegionSizeOffset] = byte(RegionExportSMS)<<4 | byte(ROMSize128KB)
+
+	var sum uint16
+	for i, b := range rom {
+		if i < smsHeaderOffset || i >= smsHeaderOffset+smsHeaderSize {
+			sum += uint16(b)
+		}
+	}
+	rom[smsHeaderOffset+smsChecksumOffset] = byte(sum)
+	rom[smsHeaderOffset+smsChecksumOffset+1] = byte(sum >> 8)
+
+	info, err := Parse(rom, int64(len(rom)))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if !info.ChecksumValid {
+		t.Errorf("ChecksumValid = false, want true (checksum 0x%04X)", info.Checksum)
+	}
+
+	// Corrupt a byte outside the header
+	rom[0x10000]++
+	info, err = Parse(rom, int64(len(rom)))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if info.ChecksumValid {
+		t.Error("ChecksumValid = true after corruption, want false")
+	}
+}
+
+func TestParse_AlternateHeaderOffset(t *testing.T) {
+	// 16 KiB ROM with the header at 0x3FF0; the checksum covers 0x0000-0x3FEF
+	rom := make(readerAt, 16*1024)
+	rom[0x100] = 0x12
+	rom[0x3FEF] = 0x34
+	copy(rom[0x3FF0:], smsMagic)
+	rom[0x3FF0+smsChecksumOffset] = 0x46
+	rom[0x3FF0+smsRegionSizeOffset] = byte(RegionJapanGG)<<4 | byte(ROMSize16KB)
+
+	info, err := Parse(rom, int64(len(rom)))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if info.HeaderOffset != 0x3FF0 {
+		t.Errorf("HeaderOffset = 0x%X, want 0x3FF0", info.HeaderOffset)
+	}
+	if info.GamePlatform() != core.PlatformGameGear {
+		t.Errorf("Platform = %v, want %v", info.GamePlatform(), core.PlatformGameGear)
+	}
+	if !info.ChecksumValid {
+		t.Errorf("ChecksumValid = false, want true (checksum 0x%04X)", info.Checksum)
+	}
+}
+
+func TestROMSizeBytes(t *testing.T) {
+	tests := []struct {
+		size     ROMSize
+		expected int64
+	}{
+		{ROMSize8KB, 8 * 1024},
+		{ROMSize48KB, 48 * 1024},
+		{ROMSize256KB, 256 * 1024},
+		{ROMSize1MB, 1024 * 1024},
+		{ROMSize(0x5), 0},
+	}
+
+	for _, tt := range tests {
+		if got := tt.size.Bytes(); got != tt.expected {
+			t.Errorf("ROMSize(0x%X).Bytes() = %d, want %d", byte(tt.size), got, tt.expected)
+		}
+	}
+}
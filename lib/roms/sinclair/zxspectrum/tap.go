@@ -0,0 +1,177 @@
+package zxspectrum
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/sargunv/rom-tools/lib/core"
+)
+
+// ZX Spectrum TAP tape image parsing.
+//
+// TAP files hold the blocks the Spectrum ROM saves to tape, each prefixed by
+// its length (2 bytes, little-endian). A block's first byte is a flag (0x00
+// for headers, 0xFF for data) and its last an XOR checksum of every byte
+// before it, so a valid block XORs to 0.
+//
+// Header blocks are 19 bytes:
+//
+//	Offset  Size  Description
+//	0x00    1     Flag (0x00)
+//	0x01    1     Type (0 = program, 1 = number array, 2 = character array, 3 = bytes)
+//	0x02    10    File name (padded with spaces)
+//	0x0C    2     Length of the data block
+//	0x0E    2     Parameter 1 (program: autostart line; bytes: start address)
+//	0x10    2     Parameter 2 (program: start of variables)
+//	0x12    1     Checksum
+//
+// Names use the Spectrum character set, ASCII apart from £ (0x60) and
+// © (0x7F).
+
+const (
+	tapLengthSize      = 2
+	tapHeaderBlockSize = 19
+	tapHeaderFlag      = 0x00
+	tapTypeOffset      = 0x01
+	tapNameOffset      = 0x02
+	tapNameLen         = 10
+	tapDataLenOffset   = 0x0C
+	tapParam1Offset    = 0x0E
+	tapParam2Offset    = 0x10
+)
+
+// FileType is the type of a file saved to tape.
+type FileType string
+
+// FileType values
+const (
+	FileTypeProgram        FileType = "program"
+	FileTypeNumberArray    FileType = "number_array"
+	FileTypeCharacterArray FileType = "character_array"
+	FileTypeBytes          FileType = "bytes"
+)
+
+var fileTypes = []FileType{FileTypeProgram, FileTypeNumberArray, FileTypeCharacterArray, FileTypeBytes}
+
+// TapeFile is a file described by a tape header block.
+type TapeFile struct {
+	Name   string   `json:"name"`
+	Type   FileType `json:"type"`
+	Length uint16   `json:"length"`
+	// Param1 is the autostart line of programs (32768 or more for none) or
+	// the start address of bytes.
+	Param1 uint16 `json:"param1"`
+	// Param2 is the start of the variable area of programs.
+	Param2 uint16 `json:"param2"`
+}
+
+// TAPInfo contains metadata extracted from a TAP tape image.
+type TAPInfo struct {
+	// Blocks is the number of blocks on the tape.
+	Blocks int `json:"blocks"`
+	// Files lists the files described by header blocks.
+	Files []TapeFile `json:"files"`
+}
+
+// GamePlatform implements core.GameInfo.
+func (i *TAPInfo) GamePlatform() core.Platform { return core.PlatformZXSpectrum }
+
+// GameTitle implements core.GameInfo. The name of the first program on the
+// tape.
+func (i *TAPInfo) GameTitle() string { return firstProgramName(i.Files) }
+
+// GameSerial implements core.GameInfo. Tapes don't include serials.
+func (i *TAPInfo) GameSerial() string { return "" }
+
+// GameRegions implements core.GameInfo. Tapes don't include regions.
+func (i *TAPInfo) GameRegions() []core.Region { return []core.Region{} }
+
+// ParseTAP extracts the file list from a TAP tape image.
+func ParseTAP(r io.ReaderAt, size int64) (*TAPInfo, error) {
+	if size < tapLengthSize+tapHeaderBlockSize {
+		return nil, fmt.Errorf("file too small for TAP header: %d bytes", size)
+	}
+
+	info := &TAPInfo{Files: []TapeFile{}}
+	lenBuf := make([]byte, tapLengthSize)
+	for offset := int64(0); offset+tapLengthSize <= size; {
+		if _, err := r.ReadAt(lenBuf, offset); err != nil {
+			return nil, fmt.Errorf("failed to read TAP block length: %w", err)
+		}
+		blockLen := int64(binary.LittleEndian.Uint16(lenBuf))
+		offset += tapLengthSize
+		if blockLen == 0 || offset+blockLen > size {
+			return nil, fmt.Errorf("not a valid TAP file: block at %#x extends beyond end of file", offset-tapLengthSize)
+		}
+
+		if blockLen == tapHeaderBlockSize {
+			block := make([]byte, blockLen)
+			if _, err := r.ReadAt(block, offset); err != nil {
+				return nil, fmt.Errorf("failed to read TAP block: %w", err)
+			}
+			if file, ok := parseHeaderBlock(block); ok {
+				info.Files = append(info.Files, file)
+			}
+		}
+		info.Blocks++
+		offset += blockLen
+	}
+	return info, nil
+}
+
+// parseHeaderBlock decodes a standard header block, including its flag and
+// checksum bytes. It returns false if the block isn't a valid header.
+func parseHeaderBlock(block []byte) (TapeFile, bool) {
+	if len(block) != tapHeaderBlockSize || block[0] != tapHeaderFlag || int(block[tapTypeOffset]) >= len(fileTypes) {
+		return TapeFile{}, false
+	}
+	var sum byte
+	for _, b := range block {
+		sum ^= b
+	}
+	if sum != 0 {
+		return TapeFile{}, false
+	}
+	return TapeFile{
+		Name:   decodeName(block[tapNameOffset : tapNameOffset+tapNameLen]),
+		Type:   fileTypes[block[tapTypeOffset]],
+		Length: binary.LittleEndian.Uint16(block[tapDataLenOffset:]),
+		Param1: binary.LittleEndian.Uint16(block[tapParam1Offset:]),
+		Param2: binary.LittleEndian.Uint16(block[tapParam2Offset:]),
+	}, true
+}
+
+// firstProgramName returns the name of the first program in files, or of the
+// first file if there are no programs.
+func firstProgramName(files []TapeFile) string {
+	for _, f := range files {
+		if f.Type == FileTypeProgram {
+			return f.Name
+		}
+	}
+	if len(files) > 0 {
+		return files[0].Name
+	}
+	return ""
+}
+
+// decodeName decodes a name in the Spectrum character set, replacing
+// control codes and block graphics with '?'.
+func decodeName(data []byte) string {
+	var sb strings.Builder
+	for _, c := range data {
+		switch {
+		case c == 0x60:
+			sb.WriteRune('£')
+		case c == 0x7F:
+			sb.WriteRune('©')
+		case c >= 0x20 && c < 0x7F:
+			sb.WriteByte(c)
+		default:
+			sb.WriteByte('?')
+		}
+	}
+	return strings.TrimRight(sb.String(), " ")
+}
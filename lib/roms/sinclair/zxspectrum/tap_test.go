@@ -0,0 +1,102 @@
+package zxspectrum
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/sargunv/rom-tools/lib/core"
+)
+
+// makeHeaderBlock creates a standard header block, with flag and checksum.
+func makeHeaderBlock(fileType byte, name string, length, param1, param2 uint16) []byte {
+	block := make([]byte, tapHeaderBlockSize)
+	block[0] = tapHeaderFlag
+	block[tapTypeOffset] = fileType
+	copy(block[tapNameOffset:], []byte(name + "          ")[:tapNameLen])
+	binary.LittleEndian.PutUint16(block[tapDataLenOffset:], length)
+	binary.LittleEndian.PutUint16(block[tapParam1Offset:], param1)
+	binary.LittleEndian.PutUint16(block[tapParam2Offset:], param2)
+	for _, b := range block[:tapHeaderBlockSize-1] {
+		block[tapHeaderBlockSize-1] ^= b
+	}
+	return block
+}
+
+// makeDataBlock creates a data block of n bytes, with flag and checksum.
+func makeDataBlock(n int) []byte {
+	block := make([]byte, n+2)
+	block[0] = 0xFF
+	block[n+1] = 0xFF
+	return block
+}
+
+// makeTestTAP concatenates blocks with their length prefixes.
+func makeTestTAP(blocks ...[]byte) []byte {
+	var out []byte
+	for _, b := range blocks {
+		out = binary.LittleEndian.AppendUint16(out, uint16(len(b)))
+		out = append(out, b...)
+	}
+	return out
+}
+
+func TestParseTAP(t *testing.T) {
+	tap := makeTestTAP(
+		makeHeaderBlock(3, "loading", 6912, 16384, 32768),
+		makeDataBlock(6912),
+		makeHeaderBlock(0, "Manic`Miner", 300, 10, 300),
+		makeDataBlock(300),
+	)
+
+	info, err := ParseTAP(bytes.NewReader(tap), int64(len(tap)))
+	if err != nil {
+		t.Fatalf("ParseTAP() error = %v", err)
+	}
+
+	if info.GamePlatform() != core.PlatformZXSpectrum {
+		t.Errorf("Platform = %v, want %v", info.GamePlatform(), core.PlatformZXSpectrum)
+	}
+	if info.GameTitle() != "Manic£Mine" {
+		t.Errorf("Title = %q, want %q", info.GameTitle(), "Manic£Mine")
+	}
+	if info.Blocks != 4 {
+		t.Errorf("Blocks = %d, want 4", info.Blocks)
+	}
+
+	want := []TapeFile{
+		{Name: "loading", Type: FileTypeBytes, Length: 6912, Param1: 16384, Param2: 32768},
+		{Name: "Manic£Mine", Type: FileTypeProgram, Length: 300, Param1: 10, Param2: 300},
+	}
+	if len(info.Files) != len(want) {
+		t.Fatalf("Files = %+v, want %+v", info.Files, want)
+	}
+	for i := range want {
+		if info.Files[i] != want[i] {
+			t.Errorf("Files[%d] = %+v, want %+v", i, info.Files[i], want[i])
+		}
+	}
+}
+
+func TestParseTAP_BadChecksum(t *testing.T) {
+	header := makeHeaderBlock(0, "GAME", 100, 0, 100)
+	header[tapNameOffset] = 'X'
+	tap := makeTestTAP(header, makeDataBlock(100))
+
+	info, err := ParseTAP(bytes.NewReader(tap), int64(len(tap)))
+	if err != nil {
+		t.Fatalf("ParseTAP() error = %v", err)
+	}
+	if len(info.Files) != 0 {
+		t.Errorf("Files = %+v, want none", info.Files)
+	}
+}
+
+func TestParseTAP_Truncated(t *testing.T) {
+	tap := makeTestTAP(makeHeaderBlock(0, "GAME", 100, 0, 100), makeDataBlock(100))
+	tap = tap[:len(tap)-1]
+
+	if _, err := ParseTAP(bytes.NewReader(tap), int64(len(tap))); err == nil {
+		t.Error("ParseTAP() expected error for truncated block")
+	}
+}
@@ -0,0 +1,333 @@
+package zxspectrum
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/sargunv/rom-tools/lib/core"
+)
+
+// ZX Spectrum TZX tape image parsing.
+//
+// TZX files describe a tape's signal, so they can also hold the turbo and
+// custom loaders TAP can't. A 10-byte header is followed by blocks, each an
+// ID byte and a body whose length depends on the ID:
+//
+//	Header layout:
+//	Offset  Size  Description
+//	0x00    7     "ZXTape!"
+//	0x07    1     0x1A
+//	0x08    1     Major version
+//	0x09    1     Minor version
+//
+// Standard speed (0x10) and turbo speed (0x11) data blocks hold TAP blocks,
+// so their headers name the files on the tape. The archive info block (0x32)
+// holds text fields describing the tape:
+//
+//	Archive info block:
+//	Offset  Size  Description
+//	0x00    2     Block length (little-endian)
+//	0x02    1     Number of text fields
+//	0x03    ...   Fields: ID (1), length (1), and text
+//
+// Field IDs are 0x00 (title), 0x01 (publisher), 0x02 (author), 0x03 (year),
+// 0x04 (language), 0x05 (type), 0x06 (price), 0x07 (protection), 0x08
+// (origin), and 0xFF (comment). All values are little-endian.
+//
+// Documentation: https://worldofspectrum.net/TZXformat.html
+
+const (
+	tzxHeaderSize        = 10
+	tzxMajorOffset       = 0x08
+	tzxMinorOffset       = 0x09
+	tzxStandardHeaderLen = 4    // pause, data length
+	tzxTurboHeaderLen    = 0x12 // timings, used bits, pause, data length
+	tzxTurboLengthOffset = 0x0F
+)
+
+var tzxSignature = []byte("ZXTape!\x1A")
+
+// TZX block IDs
+const (
+	tzxBlockStandard     = 0x10
+	tzxBlockTurbo        = 0x11
+	tzxBlockPureTone     = 0x12
+	tzxBlockPulses       = 0x13
+	tzxBlockPureData     = 0x14
+	tzxBlockDirect       = 0x15
+	tzxBlockCSW          = 0x18
+	tzxBlockGeneralized  = 0x19
+	tzxBlockPause        = 0x20
+	tzxBlockGroupStart   = 0x21
+	tzxBlockGroupEnd     = 0x22
+	tzxBlockJump         = 0x23
+	tzxBlockLoopStart    = 0x24
+	tzxBlockLoopEnd      = 0x25
+	tzxBlockCallSequence = 0x26
+	tzxBlockReturn       = 0x27
+	tzxBlockSelect       = 0x28
+	tzxBlockStop48K      = 0x2A
+	tzxBlockSignalLevel  = 0x2B
+	tzxBlockText         = 0x30
+	tzxBlockMessage      = 0x31
+	tzxBlockArchiveInfo  = 0x32
+	tzxBlockHardware     = 0x33
+	tzxBlockCustomInfo   = 0x35
+	tzxBlockGlue         = 0x5A
+)
+
+// ArchiveInfo is the text describing a TZX tape.
+type ArchiveInfo struct {
+	Title      string `json:"title,omitempty"`
+	Publisher  string `json:"publisher,omitempty"`
+	Author     string `json:"author,omitempty"`
+	Year       string `json:"year,omitempty"`
+	Language   string `json:"language,omitempty"`
+	Type       string `json:"type,omitempty"`
+	Price      string `json:"price,omitempty"`
+	Protection string `json:"protection,omitempty"`
+	Origin     string `json:"origin,omitempty"`
+	Comment    string `json:"comment,omitempty"`
+}
+
+// TZXInfo contains metadata extracted from a TZX tape image.
+type TZXInfo struct {
+	// MajorVersion and MinorVersion are the TZX format version.
+	MajorVersion byte `json:"major_version"`
+	MinorVersion byte `json:"minor_version"`
+	// Blocks is the number of blocks on the tape.
+	Blocks int `json:"blocks"`
+	// Archive is the archive info block, if present.
+	Archive *ArchiveInfo `json:"archive,omitempty"`
+	// Files lists the files described by standard header blocks.
+	Files []TapeFile `json:"files"`
+}
+
+// GamePlatform implements core.GameInfo.
+func (i *TZXInfo) GamePlatform() core.Platform { return core.PlatformZXSpectrum }
+
+// GameTitle implements core.GameInfo. The archive info title, or the name of
+// the first program on the tape if there is none.
+func (i *TZXInfo) GameTitle() string {
+	if i.Archive != nil && i.Archive.Title != "" {
+		return i.Archive.Title
+	}
+	return firstProgramName(i.Files)
+}
+
+// GameSerial implements core.GameInfo. Tapes don't include serials.
+func (i *TZXInfo) GameSerial() string { return "" }
+
+// GameRegions implements core.GameInfo. Tapes don't include regions.
+func (i *TZXInfo) GameRegions() []core.Region { return []core.Region{} }
+
+// ParseTZX extracts the archive info and file list from a TZX tape image.
+func ParseTZX(r io.ReaderAt, size int64) (*TZXInfo, error) {
+	if size < tzxHeaderSize {
+		return nil, fmt.Errorf("file too small for TZX header: %d bytes", size)
+	}
+
+	header := make([]byte, tzxHeaderSize)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, fmt.Errorf("failed to read TZX header: %w", err)
+	}
+	if !bytes.HasPrefix(header, tzxSignature) {
+		return nil, fmt.Errorf("not a valid TZX file: invalid signature")
+	}
+
+	info := &TZXInfo{
+		MajorVersion: header[tzxMajorOffset],
+		MinorVersion: header[tzxMinorOffset],
+		Files:        []TapeFile{},
+	}
+	t := &tzxReader{r: r, size: size, offset: tzxHeaderSize}
+	for t.offset < size {
+		if err := t.readBlock(info); err != nil {
+			return nil, err
+		}
+		info.Blocks++
+	}
+	return info, nil
+}
+
+// tzxReader walks the blocks of a TZX file.
+type tzxReader struct {
+	r      io.ReaderAt
+	size   int64
+	offset int64
+}
+
+// read reads n bytes at the current offset and advances past them.
+func (t *tzxReader) read(n int64) ([]byte, error) {
+	if t.offset+n > t.size {
+		return nil, fmt.Errorf("not a valid TZX file: block at %#x extends beyond end of file", t.offset)
+	}
+	buf := make([]byte, n)
+	if _, err := t.r.ReadAt(buf, t.offset); err != nil {
+		return nil, fmt.Errorf("failed to read TZX block: %w", err)
+	}
+	t.offset += n
+	return buf, nil
+}
+
+// skip advances past n bytes.
+func (t *tzxReader) skip(n int64) error {
+	if t.offset+n > t.size {
+		return fmt.Errorf("not a valid TZX file: block at %#x extends beyond end of file", t.offset)
+	}
+	t.offset += n
+	return nil
+}
+
+// skipCounted reads a length field of lenSize bytes and skips that many
+// units of unit bytes.
+func (t *tzxReader) skipCounted(lenSize int, unit int64) error {
+	b, err := t.read(int64(lenSize))
+	if err != nil {
+		return err
+	}
+	return t.skip(int64(readUint(b)) * unit)
+}
+
+// readBlock reads the block at the current offset, adding any files or
+// archive info to info.
+func (t *tzxReader) readBlock(info *TZXInfo) error {
+	id, err := t.read(1)
+	if err != nil {
+		return err
+	}
+	switch id[0] {
+	case tzxBlockStandard, tzxBlockTurbo:
+		headerLen, lengthOffset, lengthSize := int64(tzxStandardHeaderLen), 2, 2
+		if id[0] == tzxBlockTurbo {
+			headerLen, lengthOffset, lengthSize = tzxTurboHeaderLen, tzxTurboLengthOffset, 3
+		}
+		blockHeader, err := t.read(headerLen)
+		if err != nil {
+			return err
+		}
+		dataLen := int64(readUint(blockHeader[lengthOffset : lengthOffset+lengthSize]))
+		if dataLen != tapHeaderBlockSize {
+			return t.skip(dataLen)
+		}
+		data, err := t.read(dataLen)
+		if err != nil {
+			return err
+		}
+		if file, ok := parseHeaderBlock(data); ok {
+			info.Files = append(info.Files, file)
+		}
+		return nil
+	case tzxBlockArchiveInfo:
+		b, err := t.read(2)
+		if err != nil {
+			return err
+		}
+		data, err := t.read(int64(readUint(b)))
+		if err != nil {
+			return err
+		}
+		info.Archive = parseArchiveInfo(data)
+		return nil
+	case tzxBlockPureTone:
+		return t.skip(4)
+	case tzxBlockPulses:
+		return t.skipCounted(1, 2)
+	case tzxBlockPureData:
+		return t.skipData(0x0A, 0x07)
+	case tzxBlockDirect:
+		return t.skipData(0x08, 0x05)
+	case tzxBlockPause, tzxBlockJump, tzxBlockLoopStart:
+		return t.skip(2)
+	case tzxBlockGroupEnd, tzxBlockLoopEnd, tzxBlockReturn:
+		return nil
+	case tzxBlockGroupStart, tzxBlockText:
+		return t.skipCounted(1, 1)
+	case tzxBlockMessage:
+		if err := t.skip(1); err != nil {
+			return err
+		}
+		return t.skipCounted(1, 1)
+	case tzxBlockCallSequence:
+		return t.skipCounted(2, 2)
+	case tzxBlockSelect:
+		return t.skipCounted(2, 1)
+	case tzxBlockHardware:
+		return t.skipCounted(1, 3)
+	case tzxBlockCustomInfo:
+		if err := t.skip(0x10); err != nil {
+			return err
+		}
+		return t.skipCounted(4, 1)
+	case tzxBlockGlue:
+		return t.skip(9)
+	case tzxBlockCSW, tzxBlockGeneralized, tzxBlockStop48K, tzxBlockSignalLevel:
+		return t.skipCounted(4, 1)
+	default:
+		// Blocks added after TZX 1.10 start with a 4-byte length
+		return t.skipCounted(4, 1)
+	}
+}
+
+// skipData skips a block with a fixed header of headerLen bytes whose 3-byte
+// data length is at lengthOffset.
+func (t *tzxReader) skipData(headerLen, lengthOffset int64) error {
+	blockHeader, err := t.read(headerLen)
+	if err != nil {
+		return err
+	}
+	return t.skip(int64(readUint(blockHeader[lengthOffset : lengthOffset+3])))
+}
+
+// parseArchiveInfo decodes the text fields of an archive info block.
+func parseArchiveInfo(data []byte) *ArchiveInfo {
+	a := &ArchiveInfo{}
+	fields := map[byte]*string{
+		0x00: &a.Title,
+		0x01: &a.Publisher,
+		0x02: &a.Author,
+		0x03: &a.Year,
+		0x04: &a.Language,
+		0x05: &a.Type,
+		0x06: &a.Price,
+		0x07: &a.Protection,
+		0x08: &a.Origin,
+		0xFF: &a.Comment,
+	}
+	if len(data) == 0 {
+		return a
+	}
+	count, data := int(data[0]), data[1:]
+	for range count {
+		if len(data) < 2 || len(data) < 2+int(data[1]) {
+			break
+		}
+		id, text := data[0], data[2:2+int(data[1])]
+		data = data[2+len(text):]
+		if field, ok := fields[id]; ok {
+			*field = decodeText(text)
+		}
+	}
+	return a
+}
+
+// decodeText decodes an archive info string. Multi-line fields separate lines
+// with carriage returns.
+func decodeText(data []byte) string {
+	lines := strings.Split(strings.TrimRight(string(data), "\r\x00 "), "\r")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// readUint decodes a little-endian unsigned integer of up to 4 bytes.
+func readUint(b []byte) uint32 {
+	var v uint32
+	for i := len(b) - 1; i >= 0; i-- {
+		v = v<<8 | uint32(b[i])
+	}
+	return v
+}
@@ -0,0 +1,135 @@
+package zxspectrum
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// tzxStandardBlock wraps a TAP block in a standard speed data block.
+func tzxStandardBlock(data []byte) []byte {
+	out := []byte{tzxBlockStandard, 0xE8, 0x03} // 1000 ms pause
+	out = binary.LittleEndian.AppendUint16(out, uint16(len(data)))
+	return append(out, data...)
+}
+
+// tzxTurboBlock wraps a TAP block in a turbo speed data block.
+func tzxTurboBlock(data []byte) []byte {
+	header := make([]byte, tzxTurboHeaderLen)
+	header[tzxTurboLengthOffset] = byte(len(data))
+	header[tzxTurboLengthOffset+1] = byte(len(data) >> 8)
+	out := append([]byte{tzxBlockTurbo}, header...)
+	return append(out, data...)
+}
+
+// tzxArchiveBlock creates an archive info block of the given fields.
+func tzxArchiveBlock(fields map[byte]string) []byte {
+	body := []byte{byte(len(fields))}
+	for _, id := range []byte{0x00, 0x01, 0x02, 0x03, 0xFF} {
+		if text, ok := fields[id]; ok {
+			body = append(body, id, byte(len(text)))
+			body = append(body, text...)
+		}
+	}
+	out := binary.LittleEndian.AppendUint16([]byte{tzxBlockArchiveInfo}, uint16(len(body)))
+	return append(out, body...)
+}
+
+func makeTestTZX(blocks ...[]byte) []byte {
+	out := append([]byte{}, tzxSignature...)
+	out = append(out, 1, 20)
+	for _, b := range blocks {
+		out = append(out, b...)
+	}
+	return out
+}
+
+func TestParseTZX(t *testing.T) {
+	tzx := makeTestTZX(
+		[]byte{tzxBlockText, 5, 'h', 'e', 'l', 'l', 'o'},
+		tzxArchiveBlock(map[byte]string{
+			0x00: "Jet Set Willy",
+			0x01: "Software Projects",
+			0x02: "Matthew Smith",
+			0x03: "1984",
+			0xFF: "Line one\rLine two\r",
+		}),
+		tzxStandardBlock(makeHeaderBlock(0, "JSW", 200, 0, 200)),
+		tzxStandardBlock(makeDataBlock(200)),
+		[]byte{tzxBlockPureTone, 0x78, 0x08, 0x97, 0x0C},
+		[]byte{tzxBlockPulses, 2, 0x9B, 0x02, 0xDF, 0x02},
+		tzxTurboBlock(makeHeaderBlock(3, "JSW code", 1000, 32768, 0)),
+		tzxTurboBlock(makeDataBlock(1000)),
+		[]byte{tzxBlockPause, 0, 0},
+		[]byte{0x4B, 3, 0, 0, 0, 1, 2, 3}, // kansas city block, skipped by length
+	)
+
+	info, err := ParseTZX(bytes.NewReader(tzx), int64(len(tzx)))
+	if err != nil {
+		t.Fatalf("ParseTZX() error = %v", err)
+	}
+
+	if info.MajorVersion != 1 || info.MinorVersion != 20 {
+		t.Errorf("Version = %d.%d, want 1.20", info.MajorVersion, info.MinorVersion)
+	}
+	if info.Blocks != 10 {
+		t.Errorf("Blocks = %d, want 10", info.Blocks)
+	}
+	if info.GameTitle() != "Jet Set Willy" {
+		t.Errorf("Title = %q, want %q", info.GameTitle(), "Jet Set Willy")
+	}
+	want := ArchiveInfo{
+		Title:     "Jet Set Willy",
+		Publisher: "Software Projects",
+		Author:    "Matthew Smith",
+		Year:      "1984",
+		Comment:   "Line one\nLine two",
+	}
+	if info.Archive == nil || *info.Archive != want {
+		t.Errorf("Archive = %+v, want %+v", info.Archive, want)
+	}
+
+	wantFiles := []TapeFile{
+		{Name: "JSW", Type: FileTypeProgram, Length: 200, Param2: 200},
+		{Name: "JSW code", Type: FileTypeBytes, Length: 1000, Param1: 32768},
+	}
+	if len(info.Files) != len(wantFiles) {
+		t.Fatalf("Files = %+v, want %+v", info.Files, wantFiles)
+	}
+	for i := range wantFiles {
+		if info.Files[i] != wantFiles[i] {
+			t.Errorf("Files[%d] = %+v, want %+v", i, info.Files[i], wantFiles[i])
+		}
+	}
+}
+
+func TestParseTZX_NoArchiveInfo(t *testing.T) {
+	tzx := makeTestTZX(tzxStandardBlock(makeHeaderBlock(0, "SABRE", 100, 0, 100)))
+
+	info, err := ParseTZX(bytes.NewReader(tzx), int64(len(tzx)))
+	if err != nil {
+		t.Fatalf("ParseTZX() error = %v", err)
+	}
+	if info.GameTitle() != "SABRE" {
+		t.Errorf("Title = %q, want %q", info.GameTitle(), "SABRE")
+	}
+}
+
+func TestParseTZX_Errors(t *testing.T) {
+	truncated := makeTestTZX(tzxStandardBlock(makeDataBlock(100)))
+
+	tests := []struct {
+		name string
+		tzx  []byte
+	}{
+		{"bad signature", append([]byte("ZXTape?\x1A"), 1, 20)},
+		{"truncated block", truncated[:len(truncated)-1]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseTZX(bytes.NewReader(tt.tzx), int64(len(tt.tzx))); err == nil {
+				t.Error("ParseTZX() expected error")
+			}
+		})
+	}
+}
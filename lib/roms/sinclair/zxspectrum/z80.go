@@ -0,0 +1,232 @@
+package zxspectrum
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/sargunv/rom-tools/lib/core"
+)
+
+// ZX Spectrum Z80 snapshot parsing.
+//
+// Z80 files are memory and CPU state snapshots, first written by the Z80
+// emulator. Version 1 files have a 30-byte header followed by the 48K of
+// RAM. Later versions set the PC in that header to 0 and follow it with an
+// extra header, which says which machine the snapshot is for, and then the
+// memory pages:
+//
+//	Offset  Size  Description
+//	0x06    2     PC (0 in version 2 and 3 files)
+//	0x0C    1     Flags (bit 5: version 1 memory is compressed)
+//	0x1D    1     Flags (bits 6-7: joystick)
+//	0x1E    2     Extra header length (23 = version 2, 54 or 55 = version 3)
+//	0x20    2     PC
+//	0x22    1     Hardware mode
+//	0x25    1     Flags (bit 7: modified hardware, e.g. 16K rather than 48K)
+//
+// Hardware modes 3 and up differ between versions 2 and 3, since version 3
+// added the MGT interface. All values are little-endian. Snapshots don't
+// store a program name.
+//
+// Documentation: https://worldofspectrum.net/faq/reference/z80format.htm
+
+const (
+	z80HeaderSize      = 30
+	z80PCOffset        = 0x06
+	z80Flags1Offset    = 0x0C
+	z80Flags2Offset    = 0x1D
+	z80ExtraLenOffset  = 0x1E
+	z80ExtraPCOffset   = 0x20
+	z80HardwareOffset  = 0x22
+	z80Flags3Offset    = 0x25
+	z80ExtraLenV2      = 23
+	z80ExtraLenV3      = 54
+	z80ExtraLenV3Long  = 55
+	z80FlagCompressed  = 1 << 5
+	z80FlagModifiedHW  = 1 << 7
+	z80JoystickShift   = 6
+	z80HardwareTS2068  = 128
+	z80HardwareV3Start = 3 // first hardware mode that differs in version 3
+	z80HardwareV3End   = 6 // last hardware mode that differs in version 3
+)
+
+// Machine is the Spectrum model a snapshot is for.
+type Machine string
+
+// Machine values
+const (
+	Machine16K             Machine = "16K"
+	Machine48K             Machine = "48K"
+	Machine128K            Machine = "128K"
+	MachinePlus2           Machine = "+2"
+	MachinePlus2A          Machine = "+2A"
+	MachinePlus3           Machine = "+3"
+	MachineSamRam          Machine = "SamRam"
+	MachinePentagon        Machine = "Pentagon 128"
+	MachineScorpion        Machine = "Scorpion 256"
+	MachineDidaktikKompakt Machine = "Didaktik Kompakt"
+	MachineTC2048          Machine = "TC2048"
+	MachineTC2068          Machine = "TC2068"
+	MachineTS2068          Machine = "TS2068"
+)
+
+// Joystick is the joystick emulation a snapshot was saved with.
+type Joystick string
+
+// Joystick values
+const (
+	JoystickCursor        Joystick = "cursor"
+	JoystickKempston      Joystick = "kempston"
+	JoystickSinclairLeft  Joystick = "sinclair_left"
+	JoystickSinclairRight Joystick = "sinclair_right"
+)
+
+var joysticks = []Joystick{JoystickCursor, JoystickKempston, JoystickSinclairLeft, JoystickSinclairRight}
+
+// z80Hardware describes a hardware mode.
+type z80Hardware struct {
+	machine    Machine
+	interface1 bool
+	mgt        bool
+}
+
+// z80HardwareV2 lists the version 2 hardware modes that differ in version 3.
+// Modes 5 and 6 are undefined in version 2.
+var z80HardwareV2 = map[byte]z80Hardware{
+	3: {machine: Machine128K},
+	4: {machine: Machine128K, interface1: true},
+}
+
+// z80HardwareModes lists the version 3 hardware modes, and those shared by
+// version 2.
+var z80HardwareModes = map[byte]z80Hardware{
+	0:                 {machine: Machine48K},
+	1:                 {machine: Machine48K, interface1: true},
+	2:                 {machine: MachineSamRam},
+	3:                 {machine: Machine48K, mgt: true},
+	4:                 {machine: Machine128K},
+	5:                 {machine: Machine128K, interface1: true},
+	6:                 {machine: Machine128K, mgt: true},
+	7:                 {machine: MachinePlus3},
+	8:                 {machine: MachinePlus3}, // mistakenly used by some emulators
+	9:                 {machine: MachinePentagon},
+	10:                {machine: MachineScorpion},
+	11:                {machine: MachineDidaktikKompakt},
+	12:                {machine: MachinePlus2},
+	13:                {machine: MachinePlus2A},
+	14:                {machine: MachineTC2048},
+	15:                {machine: MachineTC2068},
+	z80HardwareTS2068: {machine: MachineTS2068},
+}
+
+// modifiedMachines are the machines the modified hardware flag turns the
+// base machine into.
+var modifiedMachines = map[Machine]Machine{
+	Machine48K:   Machine16K,
+	Machine128K:  MachinePlus2,
+	MachinePlus3: MachinePlus2A,
+}
+
+// Z80Info contains metadata extracted from a Z80 snapshot.
+type Z80Info struct {
+	// Version is the snapshot format version (1, 2, or 3).
+	Version int `json:"version"`
+	// Machine is the Spectrum model the snapshot is for. Empty if the
+	// hardware mode is unknown.
+	Machine Machine `json:"machine,omitempty"`
+	// HardwareMode is the raw hardware mode (0 in version 1 files).
+	HardwareMode byte `json:"hardware_mode"`
+	// Interface1 is true if the snapshot uses the Interface 1.
+	Interface1 bool `json:"interface1,omitempty"`
+	// MGT is true if the snapshot uses an MGT disk interface.
+	MGT bool `json:"mgt,omitempty"`
+	// Joystick is the joystick emulation setting.
+	Joystick Joystick `json:"joystick"`
+	// PC is the program counter.
+	PC uint16 `json:"pc"`
+	// Compressed is true if version 1 memory is compressed. Later versions
+	// flag compression per memory page.
+	Compressed bool `json:"compressed,omitempty"`
+}
+
+// GamePlatform implements core.GameInfo.
+func (i *Z80Info) GamePlatform() core.Platform { return core.PlatformZXSpectrum }
+
+// GameTitle implements core.GameInfo. Snapshots don't include titles.
+func (i *Z80Info) GameTitle() string { return "" }
+
+// GameSerial implements core.GameInfo. Snapshots don't include serials.
+func (i *Z80Info) GameSerial() string { return "" }
+
+// GameRegions implements core.GameInfo. Snapshots don't include regions.
+func (i *Z80Info) GameRegions() []core.Region { return []core.Region{} }
+
+// ParseZ80 extracts the snapshot version and machine from a Z80 snapshot.
+func ParseZ80(r io.ReaderAt, size int64) (*Z80Info, error) {
+	if size < z80HeaderSize {
+		return nil, fmt.Errorf("file too small for Z80 header: %d bytes", size)
+	}
+
+	header := make([]byte, z80HeaderSize)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, fmt.Errorf("failed to read Z80 header: %w", err)
+	}
+
+	flags1 := header[z80Flags1Offset]
+	if flags1 == 0xFF {
+		flags1 = 1 // for compatibility, per the spec
+	}
+	info := &Z80Info{
+		Joystick: joysticks[header[z80Flags2Offset]>>z80JoystickShift],
+		PC:       binary.LittleEndian.Uint16(header[z80PCOffset:]),
+	}
+	if info.PC != 0 {
+		info.Version = 1
+		info.Machine = Machine48K
+		info.Compressed = flags1&z80FlagCompressed != 0
+		return info, nil
+	}
+
+	if size < z80ExtraPCOffset {
+		return nil, fmt.Errorf("file too small for Z80 extra header: %d bytes", size)
+	}
+	lenBuf := make([]byte, 2)
+	if _, err := r.ReadAt(lenBuf, z80ExtraLenOffset); err != nil {
+		return nil, fmt.Errorf("failed to read Z80 extra header: %w", err)
+	}
+	extraLen := binary.LittleEndian.Uint16(lenBuf)
+	switch extraLen {
+	case z80ExtraLenV2:
+		info.Version = 2
+	case z80ExtraLenV3, z80ExtraLenV3Long:
+		info.Version = 3
+	default:
+		return nil, fmt.Errorf("not a valid Z80 snapshot: unexpected extra header length %d", extraLen)
+	}
+	if size < z80ExtraPCOffset+int64(extraLen) {
+		return nil, fmt.Errorf("file too small for Z80 extra header: %d bytes", size)
+	}
+	extra := make([]byte, extraLen)
+	if _, err := r.ReadAt(extra, z80ExtraPCOffset); err != nil {
+		return nil, fmt.Errorf("failed to read Z80 extra header: %w", err)
+	}
+
+	info.PC = binary.LittleEndian.Uint16(extra)
+	info.HardwareMode = extra[z80HardwareOffset-z80ExtraPCOffset]
+	hw, ok := z80HardwareModes[info.HardwareMode]
+	if info.Version == 2 && info.HardwareMode >= z80HardwareV3Start && info.HardwareMode <= z80HardwareV3End {
+		hw, ok = z80HardwareV2[info.HardwareMode]
+	}
+	if !ok {
+		return info, nil
+	}
+
+	info.Machine, info.Interface1, info.MGT = hw.machine, hw.interface1, hw.mgt
+	if extra[z80Flags3Offset-z80ExtraPCOffset]&z80FlagModifiedHW != 0 {
+		if modified, ok := modifiedMachines[info.Machine]; ok {
+			info.Machine = modified
+		}
+	}
+	return info, nil
+}
@@ -0,0 +1,90 @@
+package zxspectrum
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// makeTestZ80 creates a snapshot header. Version 1 headers get a nonzero PC;
+// later versions get an extra header of extraLen bytes.
+func makeTestZ80(extraLen uint16, hardware, flags3 byte) []byte {
+	z80 := make([]byte, z80HeaderSize)
+	z80[z80Flags2Offset] = 1 << z80JoystickShift // Kempston
+	if extraLen == 0 {
+		binary.LittleEndian.PutUint16(z80[z80PCOffset:], 0x8000)
+		z80[z80Flags1Offset] = z80FlagCompressed
+		return z80
+	}
+	z80 = binary.LittleEndian.AppendUint16(z80, extraLen)
+	extra := make([]byte, extraLen)
+	binary.LittleEndian.PutUint16(extra, 0x6000)
+	extra[z80HardwareOffset-z80ExtraPCOffset] = hardware
+	extra[z80Flags3Offset-z80ExtraPCOffset] = flags3
+	return append(z80, extra...)
+}
+
+func TestParseZ80(t *testing.T) {
+	tests := []struct {
+		name       string
+		z80        []byte
+		version    int
+		machine    Machine
+		interface1 bool
+		mgt        bool
+	}{
+		{"v1", makeTestZ80(0, 0, 0), 1, Machine48K, false, false},
+		{"v2 128K", makeTestZ80(z80ExtraLenV2, 3, 0), 2, Machine128K, false, false},
+		{"v2 128K IF1", makeTestZ80(z80ExtraLenV2, 4, 0), 2, Machine128K, true, false},
+		{"v2 mode 5", makeTestZ80(z80ExtraLenV2, 5, 0), 2, "", false, false},
+		{"v3 48K MGT", makeTestZ80(z80ExtraLenV3, 3, 0), 3, Machine48K, false, true},
+		{"v3 128K", makeTestZ80(z80ExtraLenV3Long, 4, 0), 3, Machine128K, false, false},
+		{"v3 16K", makeTestZ80(z80ExtraLenV3, 0, z80FlagModifiedHW), 3, Machine16K, false, false},
+		{"v3 +2A", makeTestZ80(z80ExtraLenV3, 7, z80FlagModifiedHW), 3, MachinePlus2A, false, false},
+		{"v3 Pentagon", makeTestZ80(z80ExtraLenV3, 9, 0), 3, MachinePentagon, false, false},
+		{"v3 TS2068", makeTestZ80(z80ExtraLenV3, 128, 0), 3, MachineTS2068, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := ParseZ80(bytes.NewReader(tt.z80), int64(len(tt.z80)))
+			if err != nil {
+				t.Fatalf("ParseZ80() error = %v", err)
+			}
+			if info.Version != tt.version {
+				t.Errorf("Version = %d, want %d", info.Version, tt.version)
+			}
+			if info.Machine != tt.machine {
+				t.Errorf("Machine = %q, want %q", info.Machine, tt.machine)
+			}
+			if info.Interface1 != tt.interface1 || info.MGT != tt.mgt {
+				t.Errorf("Interface1 = %v, MGT = %v, want %v, %v", info.Interface1, info.MGT, tt.interface1, tt.mgt)
+			}
+			if info.Joystick != JoystickKempston {
+				t.Errorf("Joystick = %q, want %q", info.Joystick, JoystickKempston)
+			}
+		})
+	}
+}
+
+func TestParseZ80_V1(t *testing.T) {
+	z80 := makeTestZ80(0, 0, 0)
+
+	info, err := ParseZ80(bytes.NewReader(z80), int64(len(z80)))
+	if err != nil {
+		t.Fatalf("ParseZ80() error = %v", err)
+	}
+	if info.PC != 0x8000 || !info.Compressed {
+		t.Errorf("PC = %#x, Compressed = %v, want 0x8000, true", info.PC, info.Compressed)
+	}
+	if info.GameTitle() != "" {
+		t.Errorf("Title = %q, want empty", info.GameTitle())
+	}
+}
+
+func TestParseZ80_BadExtraHeader(t *testing.T) {
+	z80 := makeTestZ80(40, 0, 0)
+
+	if _, err := ParseZ80(bytes.NewReader(z80), int64(len(z80))); err == nil {
+		t.Error("ParseZ80() expected error for unknown extra header length")
+	}
+}
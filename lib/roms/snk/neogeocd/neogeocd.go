@@ -0,0 +1,153 @@
+package neogeocd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/sargunv/rom-tools/internal/util"
+	"github.com/sargunv/rom-tools/lib/core"
+)
+
+// Neo Geo CD disc identification from ISO 9660 files.
+//
+// Neo Geo CD discs are ISO 9660 filesystems with no system area header.
+// The BIOS boots a disc by reading IPL.TXT from the root directory, which
+// lists the files to load into memory, one per line:
+//
+//	FILENAME.EXT,bank,offset
+//
+// The 68000 program (.PRG) loaded first carries the same header as a
+// cartridge P ROM:
+//   - 0x100: "NEO-GEO" signature
+//   - 0x108: NGH number (big-endian, read as hex digits, e.g. 0x0055 is NGH-055)
+//
+// The NGH number is the game's catalog number, shared with its cartridge
+// release. The disc's abstract file, ABS.TXT, usually holds the game title.
+
+const (
+	iplFile      = "IPL.TXT"
+	abstractFile = "ABS.TXT"
+
+	programExt    = ".PRG"
+	magic         = "NEO-GEO"
+	magicOffset   = 0x100
+	nghOffset     = 0x108
+	programHeader = nghOffset + 2
+
+	// iplEOF is the DOS end-of-file marker that terminates some IPL.TXT files.
+	iplEOF = 0x1A
+)
+
+// Info contains metadata extracted from a Neo Geo CD disc.
+type Info struct {
+	// Title is the game title, from the abstract file.
+	Title string `json:"title,omitempty"`
+	// NGH is the NGH catalog number (e.g., 0x055), or 0 if the program
+	// header couldn't be read.
+	NGH uint16 `json:"ngh,omitempty"`
+	// ProgramFile is the first 68000 program file listed in IPL.TXT.
+	ProgramFile string `json:"program_file,omitempty"`
+	// IPLFiles lists the files IPL.TXT loads at boot, in order.
+	IPLFiles []string `json:"ipl_files,omitempty"`
+}
+
+// GamePlatform implements core.GameInfo.
+func (i *Info) GamePlatform() core.Platform { return core.PlatformNeoGeoCD }
+
+// GameTitle implements core.GameInfo.
+func (i *Info) GameTitle() string { return i.Title }
+
+// GameSerial implements core.GameInfo. Returns the NGCD game ID (e.g.,
+// "NGCD-055"), or an empty string if the NGH number is unknown.
+func (i *Info) GameSerial() string {
+	if i.NGH == 0 {
+		return ""
+	}
+	return fmt.Sprintf("NGCD-%03X", i.NGH)
+}
+
+// GameRegions implements core.GameInfo. Neo Geo CD discs don't record a region.
+func (i *Info) GameRegions() []core.Region { return []core.Region{} }
+
+// Parse extracts game information from a Neo Geo CD disc's filesystem.
+func Parse(fsys fs.FS) (*Info, error) {
+	ipl, err := fs.ReadFile(fsys, iplFile)
+	if err != nil {
+		return nil, fmt.Errorf("not a valid Neo Geo CD: %w", err)
+	}
+
+	files := parseIPL(ipl)
+	if len(files) == 0 {
+		return nil, fmt.Errorf("not a valid Neo Geo CD: %s lists no files", iplFile)
+	}
+
+	info := &Info{IPLFiles: files}
+	for _, name := range files {
+		if strings.EqualFold(path.Ext(name), programExt) {
+			info.ProgramFile = name
+			break
+		}
+	}
+	if info.ProgramFile != "" {
+		info.NGH, _ = readNGH(fsys, info.ProgramFile)
+	}
+	if abstract, err := fs.ReadFile(fsys, abstractFile); err == nil {
+		info.Title = parseAbstract(abstract)
+	}
+	return info, nil
+}
+
+// parseIPL returns the file names listed in an IPL.TXT file.
+func parseIPL(data []byte) []string {
+	if i := bytes.IndexByte(data, iplEOF); i >= 0 {
+		data = data[:i]
+	}
+
+	var files []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		name, _, _ := strings.Cut(scanner.Text(), ",")
+		name = strings.TrimSpace(name)
+		if name != "" && fs.ValidPath(name) {
+			files = append(files, name)
+		}
+	}
+	return files
+}
+
+// readNGH reads the NGH number from a 68000 program file's header.
+func readNGH(fsys fs.FS, name string) (uint16, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	header := make([]byte, programHeader)
+	if _, err := io.ReadFull(f, header); err != nil {
+		return 0, fmt.Errorf("failed to read Neo Geo program header: %w", err)
+	}
+	if string(header[magicOffset:magicOffset+len(magic)]) != magic {
+		return 0, fmt.Errorf("not a valid Neo Geo program: missing %q signature", magic)
+	}
+	return binary.BigEndian.Uint16(header[nghOffset:]), nil
+}
+
+// parseAbstract returns the first non-empty line of an abstract file.
+func parseAbstract(data []byte) string {
+	if i := bytes.IndexByte(data, iplEOF); i >= 0 {
+		data = data[:i]
+	}
+	for line := range strings.Lines(util.ExtractShiftJIS(data)) {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
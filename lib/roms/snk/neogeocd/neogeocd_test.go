@@ -0,0 +1,84 @@
+package neogeocd
+
+import (
+	"slices"
+	"testing"
+	"testing/fstest"
+
+	"github.com/sargunv/rom-tools/lib/core"
+)
+
+// makeProgram creates a 68000 program file with the given NGH number.
+func makeProgram(ngh uint16) []byte {
+	prg := make([]byte, 0x200)
+	copy(prg[magicOffset:], magic)
+	prg[nghOffset] = byte(ngh >> 8)
+	prg[nghOffset+1] = byte(ngh)
+	return prg
+}
+
+func TestParse(t *testing.T) {
+	fsys := fstest.MapFS{
+		"IPL.TXT":     {Data: []byte("SMA_FIX.FIX,0,0\r\nSMA.PRG,0,0\r\nSMA_Z80.Z80,0,0\r\n\x1a")},
+		"ABS.TXT":     {Data: []byte("\r\nMETAL SLUG\r\nSNK 1996\r\n")},
+		"SMA.PRG":     {Data: makeProgram(0x201)},
+		"SMA_FIX.FIX": {Data: make([]byte, 16)},
+	}
+
+	info, err := Parse(fsys)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if info.GamePlatform() != core.PlatformNeoGeoCD {
+		t.Errorf("GamePlatform() = %v, want %v", info.GamePlatform(), core.PlatformNeoGeoCD)
+	}
+	if info.GameTitle() != "METAL SLUG" {
+		t.Errorf("GameTitle() = %q, want %q", info.GameTitle(), "METAL SLUG")
+	}
+	if info.GameSerial() != "NGCD-201" {
+		t.Errorf("GameSerial() = %q, want %q", info.GameSerial(), "NGCD-201")
+	}
+	if info.ProgramFile != "SMA.PRG" {
+		t.Errorf("ProgramFile = %q, want %q", info.ProgramFile, "SMA.PRG")
+	}
+	wantFiles := []string{"SMA_FIX.FIX", "SMA.PRG", "SMA_Z80.Z80"}
+	if !slices.Equal(info.IPLFiles, wantFiles) {
+		t.Errorf("IPLFiles = %v, want %v", info.IPLFiles, wantFiles)
+	}
+}
+
+func TestParse_MissingProgram(t *testing.T) {
+	// Identified by IPL.TXT alone, without a readable program header
+	fsys := fstest.MapFS{
+		"IPL.TXT": {Data: []byte("GAME.PRG,0,0\r\n")},
+	}
+
+	info, err := Parse(fsys)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if info.NGH != 0 || info.GameSerial() != "" {
+		t.Errorf("NGH, GameSerial() = %d, %q, want 0, empty", info.NGH, info.GameSerial())
+	}
+	if info.GameTitle() != "" {
+		t.Errorf("GameTitle() = %q, want empty", info.GameTitle())
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		fsys fstest.MapFS
+	}{
+		{"no IPL.TXT", fstest.MapFS{"SYSTEM.CNF": {Data: []byte("BOOT = cdrom:\\SLUS_000.01;1")}}},
+		{"empty IPL.TXT", fstest.MapFS{"IPL.TXT": {Data: []byte("\r\n\x1a")}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(tt.fsys); err == nil {
+				t.Error("Parse() expected error, got nil")
+			}
+		})
+	}
+}
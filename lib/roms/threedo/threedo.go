@@ -0,0 +1,61 @@
+package threedo
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/sargunv/rom-tools/lib/core"
+	"github.com/sargunv/rom-tools/lib/opera"
+)
+
+// 3DO disc identification from the Opera filesystem volume label.
+//
+// 3DO discs use the Opera filesystem rather than ISO 9660. The volume label
+// at the start of the data track names the volume, which serves as the
+// disc's title; there's no serial or region. The system boots a disc by
+// running the LaunchMe program in its root directory.
+
+// launchMe is the boot program of a 3DO disc.
+const launchMe = "LaunchMe"
+
+// Info contains metadata extracted from a 3DO disc.
+type Info struct {
+	// Title is the volume identifier from the volume label.
+	Title string `json:"title,omitempty"`
+	// Commentary is the volume commentary from the volume label.
+	Commentary string `json:"commentary,omitempty"`
+	// UniqueID is the volume's unique identifier.
+	UniqueID uint32 `json:"unique_id"`
+	// Bootable indicates whether the disc has a LaunchMe program.
+	Bootable bool `json:"bootable"`
+}
+
+// GamePlatform implements core.GameInfo.
+func (i *Info) GamePlatform() core.Platform { return core.Platform3DO }
+
+// GameTitle implements core.GameInfo.
+func (i *Info) GameTitle() string { return i.Title }
+
+// GameSerial implements core.GameInfo. 3DO discs don't have embedded serials.
+func (i *Info) GameSerial() string { return "" }
+
+// GameRegions implements core.GameInfo. 3DO discs don't record a region.
+func (i *Info) GameRegions() []core.Region { return []core.Region{} }
+
+// Parse extracts game information from a 3DO disc's data track, in either
+// cooked or raw MODE1 sectors.
+func Parse(r io.ReaderAt, size int64) (*Info, error) {
+	reader, err := opera.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("not a valid 3DO disc: %w", err)
+	}
+
+	label := reader.Label()
+	_, _, err = reader.OpenFile(launchMe)
+	return &Info{
+		Title:      label.Identifier,
+		Commentary: label.Commentary,
+		UniqueID:   label.UniqueID,
+		Bootable:   err == nil,
+	}, nil
+}
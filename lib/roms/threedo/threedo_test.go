@@ -0,0 +1,82 @@
+package threedo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/sargunv/rom-tools/lib/core"
+)
+
+// createDisc creates a cooked Opera image whose root directory holds one
+// file with the given name.
+func createDisc(title, file string) []byte {
+	const blockSize = 2048
+	data := make([]byte, 3*blockSize)
+
+	// Volume label
+	data[0] = 1
+	copy(data[1:], "\x5a\x5a\x5a\x5a\x5a\x01")
+	copy(data[0x08:], "test commentary")
+	copy(data[0x28:], title)
+	binary.BigEndian.PutUint32(data[0x48:], 0xCAFE)
+	binary.BigEndian.PutUint32(data[0x4C:], blockSize)
+	binary.BigEndian.PutUint32(data[0x50:], 3)
+	binary.BigEndian.PutUint32(data[0x58:], 1)
+	binary.BigEndian.PutUint32(data[0x5C:], blockSize)
+	binary.BigEndian.PutUint32(data[0x64:], 1)
+
+	// Root directory, with a single record
+	root := data[blockSize:]
+	binary.BigEndian.PutUint32(root, 0xFFFFFFFF) // No next block
+	binary.BigEndian.PutUint32(root[0x0C:], 0x14+0x48)
+	binary.BigEndian.PutUint32(root[0x10:], 0x14)
+	entry := root[0x14:]
+	binary.BigEndian.PutUint32(entry, 0x80000002) // Last in directory, file
+	binary.BigEndian.PutUint32(entry[0x0C:], blockSize)
+	binary.BigEndian.PutUint32(entry[0x10:], 4)
+	binary.BigEndian.PutUint32(entry[0x14:], 1)
+	copy(entry[0x20:], file)
+	binary.BigEndian.PutUint32(entry[0x44:], 2)
+	return data
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name         string
+		file         string
+		wantBootable bool
+	}{
+		{"bootable", "LaunchMe", true},
+		{"data disc", "ReadMe", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := createDisc("GEX", tt.file)
+			info, err := Parse(bytes.NewReader(data), int64(len(data)))
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if info.GamePlatform() != core.Platform3DO {
+				t.Errorf("GamePlatform() = %v, want %v", info.GamePlatform(), core.Platform3DO)
+			}
+			if info.GameTitle() != "GEX" {
+				t.Errorf("GameTitle() = %q, want %q", info.GameTitle(), "GEX")
+			}
+			if info.Commentary != "test commentary" || info.UniqueID != 0xCAFE {
+				t.Errorf("Commentary, UniqueID = %q, %#x, want %q, 0xcafe", info.Commentary, info.UniqueID, "test commentary")
+			}
+			if info.Bootable != tt.wantBootable {
+				t.Errorf("Bootable = %v, want %v", info.Bootable, tt.wantBootable)
+			}
+		})
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	data := make([]byte, 4096)
+	if _, err := Parse(bytes.NewReader(data), int64(len(data))); err == nil {
+		t.Error("Parse() expected error, got nil")
+	}
+}
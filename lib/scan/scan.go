@@ -0,0 +1,318 @@
+// Package scan maintains a database of a ROM library: the path, size,
+// modification time, hashes, and identification of every file in it.
+// Rescanning only identifies the files that were added or modified since the
+// last scan, and drops the ones that were removed.
+//
+// The database is a bbolt file, keyed by absolute path, so one database can
+// hold several library roots. Export writes its contents as JSON.
+package scan
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sargunv/rom-tools/lib/core"
+	"github.com/sargunv/rom-tools/lib/identify"
+	bolt "go.etcd.io/bbolt"
+)
+
+// bucket is the bbolt bucket holding the entries.
+var bucket = []byte("files")
+
+// DB is a scan database.
+type DB struct {
+	db *bolt.DB
+}
+
+// Entry is a scanned file.
+type Entry struct {
+	Path    string    `json:"path"` // absolute path
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+	Items   []Item    `json:"items,omitempty"` // identified items (1 for single files, N for archives)
+	Error   string    `json:"error,omitempty"` // why the file couldn't be identified, if it couldn't
+}
+
+// Item is an identified file or archive entry. The game is kept both as the
+// fields common to every platform and as the platform-specific JSON that
+// identify produced.
+type Item struct {
+	Name       string          `json:"name"`
+	Size       int64           `json:"size"`
+	Hashes     core.Hashes     `json:"hashes,omitempty"`
+	HeaderSize int64           `json:"header_size,omitempty"`
+	Platform   core.Platform   `json:"platform,omitempty"`
+	Title      string          `json:"title,omitempty"`
+	Serial     string          `json:"serial,omitempty"`
+	Regions    []core.Region   `json:"regions,omitempty"`
+	Game       json.RawMessage `json:"game,omitempty"`
+	Primary    bool            `json:"primary,omitempty"`
+}
+
+// Changes lists what a scan found different from the last one. Paths are
+// absolute.
+type Changes struct {
+	Added     []string `json:"added"`
+	Modified  []string `json:"modified"`
+	Removed   []string `json:"removed"`
+	Unchanged int      `json:"unchanged"`
+}
+
+// Open opens the scan database at path, creating it if needed. It fails if
+// another process has the database open.
+func Open(path string) (*DB, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create database directory: %w", err)
+	}
+	db, err := bolt.Open(path, 0644, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open scan database: %w", err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(bucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize scan database: %w", err)
+	}
+	return &DB{db: db}, nil
+}
+
+// Close closes the database.
+func (d *DB) Close() error {
+	return d.db.Close()
+}
+
+// Statuses of the files of a scan, as reported by Event.
+const (
+	StatusAdded     = "added"
+	StatusModified  = "modified"
+	StatusUnchanged = "unchanged"
+	StatusRemoved   = "removed"
+)
+
+// Event reports a file a scan is done with, so long scans can show their
+// progress.
+type Event struct {
+	Path   string `json:"path"`            // absolute path
+	Status string `json:"status"`          // StatusAdded, StatusModified, StatusUnchanged, or StatusRemoved
+	Entry  *Entry `json:"entry,omitempty"` // new entry, for added and modified files
+	Done   int    `json:"done"`            // files done so far, including this one
+	Total  int    `json:"total"`           // files to do, including removed ones
+}
+
+// Scan brings the database up to date with the files under root. Files whose
+// size and modification time are unchanged are skipped; the rest are
+// identified with opts. Files that can't be identified are recorded with
+// their error, so they're only retried once they change. Hidden files and
+// directories are skipped.
+func (d *DB) Scan(root string, opts identify.Options) (*Changes, error) {
+	return d.ScanContext(context.Background(), root, opts, nil)
+}
+
+// ScanContext is like Scan, but calls progress, if it's set, for each file
+// once it's done with it, and stops once ctx is done. Files identified
+// before then stay in the database, but removed files are only dropped from
+// it by a complete scan.
+func (d *DB) ScanContext(ctx context.Context, root string, opts identify.Options, progress func(Event)) (*Changes, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve root: %w", err)
+	}
+
+	known, err := d.entriesUnder(root)
+	if err != nil {
+		return nil, err
+	}
+
+	// Find the files first, so progress can report the total
+	type file struct {
+		path string
+		info fs.FileInfo
+	}
+	var files []file
+	err = filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != root && strings.HasPrefix(entry.Name(), ".") {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		files = append(files, file{path, info})
+		return ctx.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	changes := &Changes{Added: []string{}, Modified: []string{}, Removed: []string{}}
+	found := make(map[string]bool, len(files))
+	for _, f := range files {
+		found[f.path] = true
+	}
+	for path := range known {
+		if !found[path] {
+			changes.Removed = append(changes.Removed, path)
+		}
+	}
+	slices.Sort(changes.Removed)
+
+	event := Event{Total: len(files) + len(changes.Removed)}
+	report := func(path, status string, entry *Entry) {
+		event.Done++
+		if progress != nil {
+			event.Path, event.Status, event.Entry = path, status, entry
+			progress(event)
+		}
+	}
+
+	for _, f := range files {
+		if err := ctx.Err(); err != nil {
+			return changes, err
+		}
+		old, ok := known[f.path]
+		if ok && old.Size == f.info.Size() && old.ModTime.Equal(f.info.ModTime()) {
+			changes.Unchanged++
+			report(f.path, StatusUnchanged, nil)
+			continue
+		}
+
+		entry := identifyEntry(ctx, f.path, f.info, opts)
+		if err := ctx.Err(); err != nil {
+			// The file wasn't fully identified, so it's left for the next scan
+			return changes, err
+		}
+		if err := d.put(entry); err != nil {
+			return changes, err
+		}
+		if ok {
+			changes.Modified = append(changes.Modified, f.path)
+			report(f.path, StatusModified, entry)
+		} else {
+			changes.Added = append(changes.Added, f.path)
+			report(f.path, StatusAdded, entry)
+		}
+	}
+
+	err = d.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket)
+		for _, path := range changes.Removed {
+			if err := b.Delete([]byte(path)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to remove entries: %w", err)
+	}
+	for _, path := range changes.Removed {
+		report(path, StatusRemoved, nil)
+	}
+	return changes, nil
+}
+
+// identifyEntry identifies the file at path, for its database entry.
+func identifyEntry(ctx context.Context, path string, info fs.FileInfo, opts identify.Options) *Entry {
+	entry := &Entry{Path: path, Size: info.Size(), ModTime: info.ModTime()}
+	result, err := identify.IdentifyContext(ctx, path, opts)
+	if err != nil {
+		entry.Error = err.Error()
+		return entry
+	}
+	for _, item := range result.Items {
+		entry.Items = append(entry.Items, newItem(item))
+	}
+	return entry
+}
+
+// newItem converts an identified item to a database item.
+func newItem(item identify.Item) Item {
+	out := Item{Name: item.Name, Size: item.Size, Hashes: item.Hashes, HeaderSize: item.HeaderSize, Primary: item.Primary}
+	if item.Game != nil {
+		out.Platform = item.Game.GamePlatform()
+		out.Title = item.Game.GameTitle()
+		out.Serial = item.Game.GameSerial()
+		out.Regions = item.Game.GameRegions()
+		out.Game, _ = json.Marshal(item.Game)
+	}
+	return out
+}
+
+// put stores an entry.
+func (d *DB) put(entry *Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal entry: %w", err)
+	}
+	return d.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).Put([]byte(entry.Path), data)
+	})
+}
+
+// entriesUnder returns the entries for files under root, by path.
+func (d *DB) entriesUnder(root string) (map[string]*Entry, error) {
+	entries := make(map[string]*Entry)
+	prefix := []byte(strings.TrimSuffix(root, string(filepath.Separator)) + string(filepath.Separator))
+	err := d.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(bucket).Cursor()
+		for k, v := c.Seek(prefix); k != nil && strings.HasPrefix(string(k), string(prefix)); k, v = c.Next() {
+			var entry Entry
+			if err := json.Unmarshal(v, &entry); err != nil {
+				return fmt.Errorf("failed to read entry %s: %w", k, err)
+			}
+			entries[entry.Path] = &entry
+		}
+		return nil
+	})
+	return entries, err
+}
+
+// Entries returns every entry, sorted by path.
+func (d *DB) Entries() ([]Entry, error) {
+	var entries []Entry
+	err := d.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).ForEach(func(k, v []byte) error {
+			var entry Entry
+			if err := json.Unmarshal(v, &entry); err != nil {
+				return fmt.Errorf("failed to read entry %s: %w", k, err)
+			}
+			entries = append(entries, entry)
+			return nil
+		})
+	})
+	return entries, err
+}
+
+// Export writes every entry to w as a JSON array, sorted by path.
+func (d *DB) Export(w io.Writer) error {
+	entries, err := d.Entries()
+	if err != nil {
+		return err
+	}
+	if entries == nil {
+		entries = []Entry{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(entries)
+}
@@ -0,0 +1,184 @@
+package scan
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/sargunv/rom-tools/lib/core"
+	"github.com/sargunv/rom-tools/lib/identify"
+)
+
+func writeFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func checkChanges(t *testing.T, got *Changes, added, modified, removed []string, unchanged int) {
+	t.Helper()
+	slices.Sort(got.Removed)
+	if !slices.Equal(got.Added, added) || !slices.Equal(got.Modified, modified) ||
+		!slices.Equal(got.Removed, removed) || got.Unchanged != unchanged {
+		t.Errorf("changes = %+v, want added %v, modified %v, removed %v, %d unchanged",
+			got, added, modified, removed, unchanged)
+	}
+}
+
+func TestScan(t *testing.T) {
+	root := t.TempDir()
+	gb, err := os.ReadFile("../identify/testdata/gbtictac.gb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	game := filepath.Join(root, "gb", "Tic-Tac-Toe.gb")
+	other := filepath.Join(root, "other.bin")
+	writeFile(t, game, gb)
+	writeFile(t, other, []byte("data"))
+	writeFile(t, filepath.Join(root, ".hidden", "skipped.bin"), []byte("hidden"))
+
+	db, err := Open(filepath.Join(t.TempDir(), "scan.db"))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer db.Close()
+
+	changes, err := db.Scan(root, identify.DefaultOptions())
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	checkChanges(t, changes, []string{game, other}, []string{}, []string{}, 0)
+
+	entries, err := db.Entries()
+	if err != nil {
+		t.Fatalf("Entries() error = %v", err)
+	}
+	if len(entries) != 2 || entries[0].Path != game {
+		t.Fatalf("Entries() = %+v", entries)
+	}
+	item := entries[0].Items[0]
+	if item.Platform != core.PlatformGB || item.Hashes[core.HashSHA1] != "48a59d5b31e374731ece4d9eb33679d38143495e" {
+		t.Errorf("item = %+v", item)
+	}
+
+	// Nothing changed
+	changes, err = db.Scan(root, identify.DefaultOptions())
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	checkChanges(t, changes, []string{}, []string{}, []string{}, 2)
+
+	// Modify one file, remove another, and add a third
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(game, later, later); err != nil {
+		t.Fatal(err)
+	}
+	os.Remove(other)
+	added := filepath.Join(root, "new.bin")
+	writeFile(t, added, []byte("new"))
+
+	changes, err = db.Scan(root, identify.DefaultOptions())
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	checkChanges(t, changes, []string{added}, []string{game}, []string{other}, 0)
+
+	var buf bytes.Buffer
+	if err := db.Export(&buf); err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	var exported []Entry
+	if err := json.Unmarshal(buf.Bytes(), &exported); err != nil {
+		t.Fatalf("Export() wrote invalid JSON: %v", err)
+	}
+	if len(exported) != 2 || exported[0].Path != game || exported[1].Path != added {
+		t.Errorf("Export() = %+v", exported)
+	}
+	if !exported[0].ModTime.Equal(later) {
+		t.Errorf("exported mod time = %v, want %v", exported[0].ModTime, later)
+	}
+}
+
+func TestScan_SeparateRoots(t *testing.T) {
+	parent := t.TempDir()
+	a := filepath.Join(parent, "a")
+	ab := filepath.Join(parent, "ab") // shares a prefix with a, but isn't under it
+	writeFile(t, filepath.Join(a, "1.bin"), []byte("1"))
+	writeFile(t, filepath.Join(ab, "2.bin"), []byte("2"))
+
+	db, err := Open(filepath.Join(t.TempDir(), "scan.db"))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer db.Close()
+
+	for _, root := range []string{ab, a} {
+		if _, err := db.Scan(root, identify.DefaultOptions()); err != nil {
+			t.Fatalf("Scan(%s) error = %v", root, err)
+		}
+	}
+	entries, err := db.Entries()
+	if err != nil {
+		t.Fatalf("Entries() error = %v", err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("Expected entries from both roots, got %+v", entries)
+	}
+}
+
+func TestScanContext_Progress(t *testing.T) {
+	root := t.TempDir()
+	a, b, c := filepath.Join(root, "a.bin"), filepath.Join(root, "b.bin"), filepath.Join(root, "c.bin")
+	writeFile(t, a, []byte("a"))
+	writeFile(t, b, []byte("b"))
+	writeFile(t, c, []byte("c"))
+
+	db, err := Open(filepath.Join(t.TempDir(), "scan.db"))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer db.Close()
+
+	// Stop after the first file
+	ctx, cancel := context.WithCancel(context.Background())
+	var events []Event
+	_, err = db.ScanContext(ctx, root, identify.DefaultOptions(), func(e Event) {
+		events = append(events, e)
+		cancel()
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("ScanContext() error = %v, want context.Canceled", err)
+	}
+	if len(events) != 1 || events[0].Path != a || events[0].Status != StatusAdded || events[0].Entry == nil ||
+		events[0].Done != 1 || events[0].Total != 3 {
+		t.Errorf("events = %+v, want a added, 1 of 3", events)
+	}
+
+	// Finish the scan with one file removed
+	os.Remove(c)
+	events = nil
+	changes, err := db.ScanContext(context.Background(), root, identify.DefaultOptions(), func(e Event) {
+		events = append(events, e)
+	})
+	if err != nil {
+		t.Fatalf("ScanContext() error = %v", err)
+	}
+	checkChanges(t, changes, []string{b}, []string{}, []string{}, 1)
+	var statuses []string
+	for _, e := range events {
+		statuses = append(statuses, e.Status)
+	}
+	if !slices.Equal(statuses, []string{StatusUnchanged, StatusAdded}) || events[1].Done != 2 || events[1].Total != 2 {
+		t.Errorf("events = %+v, want a unchanged and b added, of 2", events)
+	}
+}
@@ -18,10 +18,12 @@ type ScreenscraperClient struct {
 // NewScreenscraperClient creates a client with stored credentials.
 // devID, devPassword, and softName are required developer credentials.
 // ssID and ssPassword are optional user credentials.
-func NewScreenscraperClient(devID, devPassword, softName, ssID, ssPassword string) (*ScreenscraperClient, error) {
+// opts are applied after the credentials (e.g., WithHTTPClient to cache
+// responses).
+func NewScreenscraperClient(devID, devPassword, softName, ssID, ssPassword string, opts ...ClientOption) (*ScreenscraperClient, error) {
 	inner, err := NewClientWithResponses(
 		"https://api.screenscraper.fr/api2",
-		WithRequestEditorFn(credentialEditor(devID, devPassword, softName, ssID, ssPassword)),
+		append([]ClientOption{WithRequestEditorFn(credentialEditor(devID, devPassword, softName, ssID, ssPassword))}, opts...)...,
 	)
 	if err != nil {
 		return nil, err
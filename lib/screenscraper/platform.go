@@ -0,0 +1,133 @@
+package screenscraper
+
+import "github.com/sargunv/rom-tools/lib/core"
+
+// system is the Screenscraper system of a platform.
+type system struct {
+	platform   core.Platform
+	id         int      // Screenscraper system ID (systemeid)
+	extensions []string // ROM file extensions, in lower case with the dot
+}
+
+// systems maps platforms to Screenscraper systems. Where platforms share a
+// system, the first of them is the system's platform.
+var systems = []system{
+	// Nintendo consoles
+	{core.PlatformNES, 3, []string{".nes", ".unf", ".unif"}},
+	{core.PlatformFDS, 106, []string{".fds"}},
+	{core.PlatformSNES, 4, []string{".sfc", ".smc", ".bs", ".st"}},
+	{core.PlatformN64, 14, []string{".z64", ".v64", ".n64"}},
+	{core.PlatformGC, 13, []string{".iso", ".gcm", ".rvz", ".ciso", ".gcz"}},
+	{core.PlatformWii, 16, []string{".iso", ".wbfs", ".rvz", ".wia", ".ciso"}},
+	{core.PlatformWiiU, 18, []string{".wud", ".wux", ".rpx", ".wua"}},
+	{core.PlatformSwitch, 225, []string{".nsp", ".xci"}},
+
+	// Nintendo handhelds
+	{core.PlatformGB, 9, []string{".gb"}},
+	{core.PlatformGBC, 10, []string{".gbc"}},
+	{core.PlatformGBA, 12, []string{".gba"}},
+	{core.PlatformNDS, 15, []string{".nds"}},
+	{core.PlatformDSi, 15, []string{".nds", ".dsi"}},
+	{core.Platform3DS, 17, []string{".3ds", ".cci", ".cia", ".cxi"}},
+	{core.PlatformNew3DS, 17, []string{".3ds", ".cci", ".cia", ".cxi"}},
+
+	// Sony
+	{core.PlatformPS1, 57, []string{".cue", ".chd", ".pbp", ".m3u", ".iso"}},
+	{core.PlatformPS2, 58, []string{".iso", ".chd", ".cso", ".bin"}},
+	{core.PlatformPS3, 59, []string{".iso", ".pkg"}},
+	{core.PlatformPS4, 60, []string{".pkg"}},
+	{core.PlatformPSP, 61, []string{".iso", ".cso", ".pbp", ".chd"}},
+	{core.PlatformPSVita, 62, []string{".vpk"}},
+
+	// Sega
+	{core.PlatformMS, 2, []string{".sms"}},
+	{core.PlatformMD, 1, []string{".md", ".gen", ".bin", ".smd"}},
+	{core.PlatformSegaCD, 20, []string{".cue", ".chd", ".iso"}},
+	{core.Platform32X, 19, []string{".32x"}},
+	{core.PlatformPico, 250, []string{".md", ".bin"}},
+	{core.PlatformSaturn, 22, []string{".cue", ".chd", ".iso"}},
+	{core.PlatformDreamcast, 23, []string{".gdi", ".chd", ".cdi"}},
+	{core.PlatformGameGear, 21, []string{".gg"}},
+
+	// NEC
+	{core.PlatformPCE, 31, []string{".pce"}},
+	{core.PlatformPCECD, 114, []string{".cue", ".chd"}},
+
+	// SNK
+	{core.PlatformNeoGeoCD, 70, []string{".cue", ".chd"}},
+
+	// Atari
+	{core.PlatformAtari2600, 26, []string{".a26", ".bin"}},
+	{core.PlatformAtari7800, 41, []string{".a78"}},
+	{core.PlatformLynx, 28, []string{".lnx"}},
+	{core.PlatformJaguar, 27, []string{".j64", ".jag", ".rom"}},
+	{core.PlatformJaguarCD, 171, []string{".cue", ".chd"}},
+
+	// Others
+	{core.Platform3DO, 29, []string{".iso", ".chd", ".cue"}},
+	{core.PlatformMSX, 113, []string{".rom", ".mx1", ".dsk"}},
+	{core.PlatformMSX2, 116, []string{".rom", ".mx2", ".dsk"}},
+	{core.PlatformC64, 66, []string{".crt", ".d64", ".t64", ".prg"}},
+	{core.PlatformAmiga, 64, []string{".adf", ".ipf", ".lha", ".hdf"}},
+	{core.PlatformZXSpectrum, 76, []string{".tzx", ".tap", ".z80", ".sna"}},
+	{core.PlatformArcade, 75, []string{".zip", ".7z"}},
+
+	// Microsoft
+	{core.PlatformXbox, 32, []string{".iso", ".xiso"}},
+	{core.PlatformXbox360, 33, []string{".iso", ".xex"}},
+	{core.PlatformXboxOne, 34, nil},
+}
+
+var (
+	byPlatform = make(map[core.Platform]*system, len(systems))
+	bySystemID = make(map[int]*system, len(systems))
+)
+
+func init() {
+	for i := range systems {
+		s := &systems[i]
+		byPlatform[s.platform] = s
+		if _, ok := bySystemID[s.id]; !ok {
+			bySystemID[s.id] = s
+		}
+	}
+}
+
+// SystemID returns the Screenscraper system ID of a platform.
+func SystemID(p core.Platform) (int, bool) {
+	s, ok := byPlatform[p]
+	if !ok {
+		return 0, false
+	}
+	return s.id, true
+}
+
+// SystemPlatform returns the platform of a Screenscraper system ID. Systems
+// shared by platforms, like the DS and DSi's, return the earliest platform.
+func SystemPlatform(systemID int) (core.Platform, bool) {
+	s, ok := bySystemID[systemID]
+	if !ok {
+		return "", false
+	}
+	return s.platform, true
+}
+
+// Extensions returns the file extensions that ROMs of a platform's
+// Screenscraper system usually have, in lower case with the dot, or nil if
+// the platform has no system.
+func Extensions(p core.Platform) []string {
+	s, ok := byPlatform[p]
+	if !ok {
+		return nil
+	}
+	return append([]string(nil), s.extensions...)
+}
+
+// Platforms returns the platforms that have Screenscraper systems.
+func Platforms() []core.Platform {
+	platforms := make([]core.Platform, len(systems))
+	for i, s := range systems {
+		platforms[i] = s.platform
+	}
+	return platforms
+}
@@ -0,0 +1,65 @@
+package screenscraper
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/sargunv/rom-tools/lib/core"
+)
+
+func TestSystemID(t *testing.T) {
+	tests := []struct {
+		platform core.Platform
+		want     int
+		wantOK   bool
+	}{
+		{core.PlatformGB, 9, true},
+		{core.PlatformMD, 1, true},
+		{core.PlatformDSi, 15, true},
+		{core.PlatformLaserDisc, 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := SystemID(tt.platform)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("SystemID(%s) = %d, %v; want %d, %v", tt.platform, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestSystemPlatform(t *testing.T) {
+	// Every platform's system maps back to it, or to the platform it shares
+	// the system with
+	for _, p := range Platforms() {
+		id, _ := SystemID(p)
+		got, ok := SystemPlatform(id)
+		if !ok {
+			t.Errorf("SystemPlatform(%d) of %s not found", id, p)
+			continue
+		}
+		if gotID, _ := SystemID(got); gotID != id {
+			t.Errorf("SystemPlatform(%d) = %s, whose system is %d", id, got, gotID)
+		}
+	}
+
+	if got, _ := SystemPlatform(15); got != core.PlatformNDS {
+		t.Errorf("SystemPlatform(15) = %s, want %s", got, core.PlatformNDS)
+	}
+	if _, ok := SystemPlatform(999999); ok {
+		t.Error("SystemPlatform(999999) found, want not found")
+	}
+}
+
+func TestExtensions(t *testing.T) {
+	if got := Extensions(core.PlatformGB); !slices.Equal(got, []string{".gb"}) {
+		t.Errorf("Extensions(gameboy) = %v, want [.gb]", got)
+	}
+	if got := Extensions(core.PlatformLaserDisc); got != nil {
+		t.Errorf("Extensions(laserdisc) = %v, want nil", got)
+	}
+
+	// Callers can't change the mapping
+	Extensions(core.PlatformGB)[0] = ".x"
+	if got := Extensions(core.PlatformGB); got[0] != ".gb" {
+		t.Errorf("Extensions(gameboy) changed to %v", got)
+	}
+}
@@ -1,5 +1,13 @@
 package screenscraper
 
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"hash/crc32"
+)
+
 // Response is satisfied by all generated *XxxResponse types
 type Response interface {
 	StatusCode() int
@@ -47,3 +55,29 @@ func IsSuccess(r Response) bool {
 	code := r.StatusCode()
 	return code >= 200 && code < 300
 }
+
+// IsNoMedia returns true if a media download's body says there's no such
+// media (NOMEDIA)
+func IsNoMedia(body []byte) bool {
+	return string(body) == "NOMEDIA"
+}
+
+// IsMediaUnchanged returns true if a media download's body says the media
+// has the hash sent with the request, so the local copy is current (CRCOK,
+// MD5OK, or SHA1OK)
+func IsMediaUnchanged(body []byte) bool {
+	switch string(body) {
+	case "CRCOK", "MD5OK", "SHA1OK":
+		return true
+	}
+	return false
+}
+
+// MediaHashes returns the CRC32, MD5, and SHA1 of a local copy of media, to
+// send with a media download so it's answered with CRCOK, MD5OK, or SHA1OK
+// if the copy is current
+func MediaHashes(data []byte) (crc, md5sum, sha1sum string) {
+	m := md5.Sum(data)
+	s := sha1.Sum(data)
+	return fmt.Sprintf("%08X", crc32.ChecksumIEEE(data)), hex.EncodeToString(m[:]), hex.EncodeToString(s[:])
+}
@@ -0,0 +1,180 @@
+// Package thegamesdb is a client for the metadata of games on TheGamesDB,
+// whose API is authenticated with an API key.
+//
+// See https://api.thegamesdb.net for the API and how to request a key.
+package thegamesdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultBaseURL = "https://api.thegamesdb.net"
+
+// Client is a TheGamesDB API client. The names of genres, developers, and
+// publishers, which games refer to by ID, are requested when they're first
+// needed and kept for the life of the client.
+type Client struct {
+	BaseURL    string       // API URL, without a trailing slash
+	HTTPClient *http.Client // client to send requests with
+
+	apiKey string
+
+	mu    sync.Mutex
+	names map[string]map[int]string // by list, e.g. "Genres"
+}
+
+// NewClient creates a client with an API key.
+func NewClient(apiKey string) *Client {
+	return &Client{
+		BaseURL:    defaultBaseURL,
+		HTTPClient: http.DefaultClient,
+		apiKey:     apiKey,
+	}
+}
+
+// Game is a game on TheGamesDB, with the fields requested by
+// GamesByName.
+type Game struct {
+	ID          int    `json:"id"`
+	Title       string `json:"game_title"`
+	ReleaseDate string `json:"release_date,omitempty"` // e.g. "1992-04-13"
+	Platform    int    `json:"platform"`
+	Players     int    `json:"players,omitempty"`
+	Overview    string `json:"overview,omitempty"`
+	Rating      string `json:"rating,omitempty"` // content rating, e.g. "E - Everyone"
+	Developers  []int  `json:"developers,omitempty"`
+	Publishers  []int  `json:"publishers,omitempty"`
+	Genres      []int  `json:"genres,omitempty"`
+}
+
+// Released returns the release date, or the zero time if unknown.
+func (g *Game) Released() time.Time {
+	t, err := time.Parse(time.DateOnly, g.ReleaseDate)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// GamesByName searches for games by name, best matches first. If
+// platformID isn't 0, only games on that platform are returned.
+func (c *Client) GamesByName(ctx context.Context, name string, platformID int) ([]Game, error) {
+	params := url.Values{
+		"name":   {name},
+		"fields": {"players,publishers,genres,overview,rating"},
+	}
+	if platformID != 0 {
+		params.Set("filter[platform]", strconv.Itoa(platformID))
+	}
+
+	var resp struct {
+		Data struct {
+			Games []Game `json:"games"`
+		} `json:"data"`
+	}
+	if err := c.get(ctx, "/v1.1/Games/ByGameName", params, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Data.Games, nil
+}
+
+// GenreNames returns the names of genres by ID.
+func (c *Client) GenreNames(ctx context.Context) (map[int]string, error) {
+	return c.nameList(ctx, "Genres", "/v1/Genres", "genres")
+}
+
+// DeveloperNames returns the names of developers by ID.
+func (c *Client) DeveloperNames(ctx context.Context) (map[int]string, error) {
+	return c.nameList(ctx, "Developers", "/v1/Developers", "developers")
+}
+
+// PublisherNames returns the names of publishers by ID.
+func (c *Client) PublisherNames(ctx context.Context) (map[int]string, error) {
+	return c.nameList(ctx, "Publishers", "/v1/Publishers", "publishers")
+}
+
+// Names returns the names of the IDs in a name list, skipping unknown ones.
+func Names(list map[int]string, ids []int) []string {
+	var names []string
+	for _, id := range ids {
+		if name, ok := list[id]; ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// nameList returns a list of names by ID, requesting it if it's not kept.
+func (c *Client) nameList(ctx context.Context, list, path, field string) (map[int]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if names, ok := c.names[list]; ok {
+		return names, nil
+	}
+
+	var resp struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}
+	if err := c.get(ctx, path, nil, &resp); err != nil {
+		return nil, err
+	}
+	var entries map[string]struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(resp.Data[field], &entries); err != nil {
+		return nil, fmt.Errorf("thegamesdb: failed to decode %s: %w", strings.ToLower(list), err)
+	}
+
+	names := make(map[int]string, len(entries))
+	for _, e := range entries {
+		names[e.ID] = e.Name
+	}
+	if c.names == nil {
+		c.names = make(map[string]map[int]string)
+	}
+	c.names[list] = names
+	return names, nil
+}
+
+// get sends a GET request to path and decodes its JSON response into result.
+func (c *Client) get(ctx context.Context, path string, params url.Values, result any) error {
+	if params == nil {
+		params = url.Values{}
+	}
+	params.Set("apikey", c.apiKey)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL+path+"?"+params.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("thegamesdb: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		if msg := strings.TrimSpace(string(body)); msg != "" {
+			return fmt.Errorf("thegamesdb: %s: %s", resp.Status, msg)
+		}
+		return fmt.Errorf("thegamesdb: %s", resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("thegamesdb: failed to decode response: %w", err)
+	}
+	return nil
+}
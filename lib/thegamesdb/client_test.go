@@ -0,0 +1,84 @@
+package thegamesdb
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sargunv/rom-tools/lib/core"
+)
+
+func TestGamesByName(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("apikey") != "key" {
+			t.Errorf("apikey = %q", r.URL.Query().Get("apikey"))
+		}
+		requests = append(requests, r.URL.Path)
+		switch r.URL.Path {
+		case "/v1.1/Games/ByGameName":
+			if q := r.URL.Query(); q.Get("name") != "Zelda" || q.Get("filter[platform]") != "6" {
+				t.Errorf("query = %v", q)
+			}
+			w.Write([]byte(`{"code": 200, "status": "Success", "data": {"count": 1, "games": [{
+				"id": 1046,
+				"game_title": "The Legend of Zelda: A Link to the Past",
+				"release_date": "1992-04-13",
+				"platform": 6,
+				"players": 1,
+				"overview": "Link must rescue Zelda.",
+				"rating": "E - Everyone",
+				"developers": [6037],
+				"publishers": [1],
+				"genres": [1, 2]
+			}]}}`))
+		case "/v1/Genres":
+			w.Write([]byte(`{"code": 200, "data": {"count": 2, "genres": {"1": {"id": 1, "name": "Action"}, "2": {"id": 2, "name": "Adventure"}}}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	c := NewClient("key")
+	c.BaseURL = server.URL
+
+	platform, _ := PlatformID(core.PlatformSNES)
+	games, err := c.GamesByName(context.Background(), "Zelda", platform)
+	if err != nil {
+		t.Fatalf("GamesByName() error = %v", err)
+	}
+	if len(games) != 1 {
+		t.Fatalf("Games = %d, want 1", len(games))
+	}
+	g := games[0]
+	if g.ID != 1046 || g.Players != 1 || g.Overview != "Link must rescue Zelda." {
+		t.Errorf("Game = %+v", g)
+	}
+	if want := time.Date(1992, 4, 13, 0, 0, 0, 0, time.UTC); !g.Released().Equal(want) {
+		t.Errorf("Released() = %v, want %v", g.Released(), want)
+	}
+
+	// Names are requested once
+	for range 2 {
+		genres, err := c.GenreNames(context.Background())
+		if err != nil {
+			t.Fatalf("GenreNames() error = %v", err)
+		}
+		if got := Names(genres, g.Genres); !slices.Equal(got, []string{"Action", "Adventure"}) {
+			t.Errorf("Names() = %v", got)
+		}
+	}
+	if want := []string{"/v1.1/Games/ByGameName", "/v1/Genres"}; !slices.Equal(requests, want) {
+		t.Errorf("Requests = %v, want %v", requests, want)
+	}
+
+	// Unknown paths are errors
+	if _, err := c.DeveloperNames(context.Background()); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("DeveloperNames() error = %v, want 404", err)
+	}
+}
@@ -0,0 +1,79 @@
+package thegamesdb
+
+import "github.com/sargunv/rom-tools/lib/core"
+
+// platformIDs maps platforms to TheGamesDB platform IDs.
+var platformIDs = map[core.Platform]int{
+	// Nintendo consoles
+	core.PlatformNES:    7,
+	core.PlatformFDS:    4936,
+	core.PlatformSNES:   6,
+	core.PlatformN64:    3,
+	core.PlatformGC:     2,
+	core.PlatformWii:    9,
+	core.PlatformWiiU:   38,
+	core.PlatformSwitch: 4971,
+
+	// Nintendo handhelds
+	core.PlatformGB:     4,
+	core.PlatformGBC:    41,
+	core.PlatformGBA:    5,
+	core.PlatformNDS:    8,
+	core.PlatformDSi:    8,
+	core.Platform3DS:    4912,
+	core.PlatformNew3DS: 4912,
+
+	// Sony
+	core.PlatformPS1:    10,
+	core.PlatformPS2:    11,
+	core.PlatformPS3:    12,
+	core.PlatformPS4:    4919,
+	core.PlatformPS5:    4980,
+	core.PlatformPSP:    13,
+	core.PlatformPSVita: 39,
+
+	// Sega
+	core.PlatformMS:        35,
+	core.PlatformMD:        18,
+	core.PlatformSegaCD:    21,
+	core.Platform32X:       33,
+	core.PlatformPico:      4958,
+	core.PlatformSaturn:    17,
+	core.PlatformDreamcast: 16,
+	core.PlatformGameGear:  20,
+
+	// NEC
+	core.PlatformPCE:   34,
+	core.PlatformPCECD: 4955,
+
+	// SNK
+	core.PlatformNeoGeoCD: 4956,
+
+	// Atari
+	core.PlatformAtari2600: 22,
+	core.PlatformAtari7800: 27,
+	core.PlatformLynx:      4924,
+	core.PlatformJaguar:    28,
+	core.PlatformJaguarCD:  29,
+
+	// Others
+	core.Platform3DO:        25,
+	core.PlatformMSX:        4929,
+	core.PlatformMSX2:       4929,
+	core.PlatformC64:        40,
+	core.PlatformAmiga:      4911,
+	core.PlatformZXSpectrum: 4913,
+	core.PlatformArcade:     23,
+
+	// Microsoft
+	core.PlatformXbox:       14,
+	core.PlatformXbox360:    15,
+	core.PlatformXboxOne:    4920,
+	core.PlatformXboxSeries: 4981,
+}
+
+// PlatformID returns the TheGamesDB platform ID of a platform.
+func PlatformID(p core.Platform) (int, bool) {
+	id, ok := platformIDs[p]
+	return id, ok
+}
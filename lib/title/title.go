@@ -0,0 +1,240 @@
+// Package title normalizes game titles in ROM file names and scores how
+// closely two titles match, to find games by name when no hashes match.
+//
+// File names follow No-Intro and Redump conventions: tags in parentheses or
+// brackets after the title, and articles moved after the main title, e.g.:
+//
+//	Legend of Zelda, The - A Link to the Past (USA) (Rev 1).sfc
+//
+// Clean turns that into the title to search for, "The Legend of Zelda - A
+// Link to the Past", and Normalize into the key to compare titles by,
+// "legend of zelda a link to the past".
+package title
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// articles are the articles that names move after the main title, e.g.
+// "Legend of Zelda, The".
+var articles = []string{
+	"The", "A", "An", // English
+	"Le", "La", "Les", "L'", // French
+	"Der", "Die", "Das", // German
+	"El", "Los", "Las", // Spanish
+	"Il", // Italian
+}
+
+// leadingArticles are the articles dropped from the start of keys, so
+// titles match with or without them.
+var leadingArticles = map[string]bool{"the": true, "a": true, "an": true}
+
+// folds maps accented letters to their unaccented spellings.
+var folds = map[rune]string{
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a", 'æ': "ae",
+	'ç': "c",
+	'è': "e", 'é': "e", 'ê': "e", 'ë': "e",
+	'ì': "i", 'í': "i", 'î': "i", 'ï': "i",
+	'ñ': "n",
+	'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "o", 'ø': "o", 'œ': "oe",
+	'ù': "u", 'ú': "u", 'û': "u", 'ü': "u",
+	'ý': "y", 'ÿ': "y",
+	'ß': "ss",
+}
+
+// Clean returns the title of a ROM file name: without its extension and
+// tags, and with a moved article back at its start.
+func Clean(name string) string {
+	name = trimExtension(name)
+
+	end := len(name)
+	for _, sep := range []string{" (", " ["} {
+		if i := strings.Index(name, sep); i >= 0 && i < end {
+			end = i
+		}
+	}
+	title := strings.Join(strings.Fields(name[:end]), " ")
+	return restoreArticle(title)
+}
+
+// trimExtension removes a file extension: up to 4 letters and digits,
+// starting with a letter, after the last dot.
+func trimExtension(name string) string {
+	i := strings.LastIndexByte(name, '.')
+	if i <= 0 {
+		return name
+	}
+	ext := name[i+1:]
+	if len(ext) == 0 || len(ext) > 4 || !isLetter(ext[0]) {
+		return name
+	}
+	for j := range len(ext) {
+		if !isLetter(ext[j]) && !isDigit(ext[j]) {
+			return name
+		}
+	}
+	return name[:i]
+}
+
+// restoreArticle moves an article after the main title back to its start,
+// e.g. "Legend of Zelda, The - A Link to the Past" to "The Legend of Zelda -
+// A Link to the Past".
+func restoreArticle(title string) string {
+	for _, article := range articles {
+		i := strings.Index(title, ", "+article)
+		if i <= 0 {
+			continue
+		}
+		rest := title[i+2+len(article):]
+		if rest != "" && !strings.HasPrefix(rest, " - ") && !strings.HasPrefix(rest, ": ") {
+			continue
+		}
+		sep := " "
+		if strings.HasSuffix(article, "'") {
+			sep = ""
+		}
+		return article + sep + title[:i] + rest
+	}
+	return title
+}
+
+// Normalize returns the key to compare a title or ROM file name by: its
+// Clean title in lower case, without accents, punctuation, or a leading
+// article, with "&" spelled "and" and Roman numerals from II to XXXIX as
+// numbers. Titles that differ only in those ways have the same key.
+func Normalize(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(Clean(name)) {
+		switch {
+		case folds[r] != "":
+			b.WriteString(folds[r])
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		case r == '&':
+			b.WriteString(" and ")
+		case r == '\'' || r == '’':
+			// Joins contractions and possessives, e.g. "bugs life"
+		default:
+			b.WriteByte(' ')
+		}
+	}
+
+	words := strings.Fields(b.String())
+	if len(words) > 1 && leadingArticles[words[0]] {
+		words = words[1:]
+	}
+	for i, word := range words {
+		if n, ok := romanNumeral(word); ok {
+			words[i] = strconv.Itoa(n)
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// romanNumeral returns the value of a lowercase Roman numeral from II to
+// XXXIX. Single letters are left alone, since they're as often words or
+// names (e.g., "Mega Man X") as numbers.
+func romanNumeral(word string) (int, bool) {
+	if len(word) < 2 || strings.Trim(word, "ivx") != "" {
+		return 0, false
+	}
+	values := map[byte]int{'i': 1, 'v': 5, 'x': 10}
+	n := 0
+	for i := range len(word) {
+		v := values[word[i]]
+		if i+1 < len(word) && v < values[word[i+1]] {
+			n -= v
+		} else {
+			n += v
+		}
+	}
+	// Only accept the standard spelling of the value, e.g. not "iiv"
+	if n < 2 || n > 39 || toRoman(n) != word {
+		return 0, false
+	}
+	return n, true
+}
+
+// toRoman returns the lowercase Roman numeral of n, from 1 to 39.
+func toRoman(n int) string {
+	ones := []string{"", "i", "ii", "iii", "iv", "v", "vi", "vii", "viii", "ix"}
+	return strings.Repeat("x", n/10) + ones[n%10]
+}
+
+// Similarity scores how closely two titles or ROM file names match, from 0
+// (nothing in common) to 1 (the same Normalize key). It's the higher of the
+// edit distance similarity of their keys and the overlap of their words,
+// so a title matches both misspellings and titles with extra words, like a
+// series name.
+func Similarity(a, b string) float64 {
+	ka, kb := Normalize(a), Normalize(b)
+	if ka == "" || kb == "" {
+		return 0
+	}
+	if ka == kb {
+		return 1
+	}
+	return max(editSimilarity(ka, kb), wordOverlap(ka, kb))
+}
+
+// Best returns the index of the candidate most similar to title, and its
+// score. The first of equally similar candidates wins. It returns -1 if
+// there are no candidates.
+func Best(title string, candidates []string) (int, float64) {
+	best, bestScore := -1, -1.0
+	for i, c := range candidates {
+		if score := Similarity(title, c); score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+	if best < 0 {
+		return -1, 0
+	}
+	return best, bestScore
+}
+
+// editSimilarity returns 1 minus the Levenshtein distance of a and b over
+// the length of the longer.
+func editSimilarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return 1 - float64(prev[len(rb)])/float64(max(len(ra), len(rb)))
+}
+
+// wordOverlap returns the Dice coefficient of the words of a and b: twice
+// the words they share over the words of both.
+func wordOverlap(a, b string) float64 {
+	wa, wb := strings.Fields(a), strings.Fields(b)
+	counts := make(map[string]int, len(wa))
+	for _, w := range wa {
+		counts[w]++
+	}
+	shared := 0
+	for _, w := range wb {
+		if counts[w] > 0 {
+			counts[w]--
+			shared++
+		}
+	}
+	return 2 * float64(shared) / float64(len(wa)+len(wb))
+}
+
+func isLetter(c byte) bool { return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' }
+func isDigit(c byte) bool  { return c >= '0' && c <= '9' }
@@ -0,0 +1,77 @@
+package title
+
+import "testing"
+
+func TestClean(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Super Mario World (USA).sfc", "Super Mario World"},
+		{"Legend of Zelda, The - A Link to the Past (Europe) (En,Fr,De) (Rev 1).sfc", "The Legend of Zelda - A Link to the Past"},
+		{"Bug's Life, A (USA)", "A Bug's Life"},
+		{"Aventure, L' (France)", "L'Aventure"},
+		{"Tetris [!].gb", "Tetris"},
+		{"Dr. Mario (World)", "Dr. Mario"},
+		{"Super Mario Bros. 3", "Super Mario Bros. 3"},
+		{"Lost Vikings, The", "The Lost Vikings"},
+		{"Castle, A Tale (USA)", "Castle, A Tale"},
+		{"  Spaced   Out  (USA)", "Spaced Out"},
+	}
+	for _, tt := range tests {
+		if got := Clean(tt.name); got != tt.want {
+			t.Errorf("Clean(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Legend of Zelda, The - A Link to the Past (USA)", "legend of zelda a link to the past"},
+		{"The Legend of Zelda: A Link to the Past", "legend of zelda a link to the past"},
+		{"Final Fantasy VI (Japan)", "final fantasy 6"},
+		{"Street Fighter II Turbo", "street fighter 2 turbo"},
+		{"Mega Man X (USA)", "mega man x"},
+		{"Mix Up", "mix up"},
+		{"Pokémon Red", "pokemon red"},
+		{"Ratchet & Clank", "ratchet and clank"},
+		{"Bug's Life, A (USA)", "bugs life"},
+		{"A", "a"},
+	}
+	for _, tt := range tests {
+		if got := Normalize(tt.name); got != tt.want {
+			t.Errorf("Normalize(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	if got := Similarity("Final Fantasy VI (USA).sfc", "Final Fantasy 6"); got != 1 {
+		t.Errorf("Similarity of the same key = %v, want 1", got)
+	}
+	if got := Similarity("", "Tetris"); got != 0 {
+		t.Errorf("Similarity with an empty title = %v, want 0", got)
+	}
+
+	near := Similarity("Zelda - A Link to the Past", "The Legend of Zelda: A Link to the Past")
+	far := Similarity("Zelda - A Link to the Past", "Super Mario World")
+	if near <= far || near < 0.8 {
+		t.Errorf("Similarity near = %v, far = %v; want near > far and >= 0.8", near, far)
+	}
+	if got := Similarity("Sonic the Hedgehog", "Sonic the Hedghog"); got < 0.9 {
+		t.Errorf("Similarity of a misspelling = %v, want >= 0.9", got)
+	}
+}
+
+func TestBest(t *testing.T) {
+	candidates := []string{"Tetris 2", "Tetris DX", "Tetris", "Tetris"}
+	if i, score := Best("Tetris (World) (Rev 1).gb", candidates); i != 2 || score != 1 {
+		t.Errorf("Best = %d, %v; want 2, 1", i, score)
+	}
+	if i, _ := Best("Tetris", nil); i != -1 {
+		t.Errorf("Best of no candidates = %d, want -1", i)
+	}
+}
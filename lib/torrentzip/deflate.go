@@ -0,0 +1,892 @@
+package torrentzip
+
+import (
+	"bufio"
+	"errors"
+	"io"
+)
+
+// TorrentZip archives must be compressed exactly as zlib does at level 9
+// (with a 32 KiB window, memLevel 8, and the default strategy), since
+// compress/flate produces different, if equally valid, output. This is a
+// port of zlib's deflate_slow and its Huffman tree construction.
+
+const (
+	minMatch     = 3
+	maxMatch     = 258
+	wBits        = 15
+	wSize        = 1 << wBits
+	wMask        = wSize - 1
+	windowSize   = 2 * wSize
+	hashBits     = 8 + 7 // memLevel + 7
+	hashSize     = 1 << hashBits
+	hashMask     = hashSize - 1
+	hashShift    = (hashBits + minMatch - 1) / minMatch
+	minLookahead = maxMatch + minMatch + 1
+	maxDist      = wSize - minLookahead
+	winInit      = maxMatch
+	litBufSize   = 1 << (8 + 6) // memLevel + 6
+	tooFar       = 4096
+
+	// Level 9 configuration
+	goodLength = 32
+	maxLazy    = 258
+	niceLength = 258
+	maxChain   = 4096
+
+	lengthCodes = 29
+	literals    = 256
+	lCodes      = literals + 1 + lengthCodes
+	dCodes      = 30
+	blCodes     = 19
+	heapSize    = 2*lCodes + 1
+	maxBits     = 15
+	maxBLBits   = 7
+	endBlock    = 256
+	rep3To6     = 16
+	repz3To10   = 17
+	repz11To138 = 18
+
+	storedBlock = 0
+	staticTrees = 1
+	dynTrees    = 2
+)
+
+var (
+	extraLBits  = []int{0, 0, 0, 0, 0, 0, 0, 0, 1, 1, 1, 1, 2, 2, 2, 2, 3, 3, 3, 3, 4, 4, 4, 4, 5, 5, 5, 5, 0}
+	extraDBits  = []int{0, 0, 0, 0, 1, 1, 2, 2, 3, 3, 4, 4, 5, 5, 6, 6, 7, 7, 8, 8, 9, 9, 10, 10, 11, 11, 12, 12, 13, 13}
+	extraBLBits = []int{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 2, 3, 7}
+	blOrder     = []int{16, 17, 18, 0, 8, 7, 9, 6, 10, 5, 11, 4, 12, 3, 13, 2, 14, 1, 15}
+)
+
+// ctData is a Huffman tree node. As in zlib, each field holds one of two
+// values depending on the stage: the frequency or the code, and the parent
+// or the code length.
+type ctData struct {
+	fc uint16 // freq, then code
+	dl uint16 // dad, then len
+}
+
+type staticTreeDesc struct {
+	staticTree []ctData
+	extraBits  []int
+	extraBase  int
+	elems      int
+	maxLength  int
+}
+
+type treeDesc struct {
+	dynTree []ctData
+	maxCode int
+	stat    *staticTreeDesc
+}
+
+// Static tables, built as in zlib's tr_static_init.
+var (
+	staticLTree [lCodes + 2]ctData
+	staticDTree [dCodes]ctData
+	distCode    [512]uint8
+	lengthCode  [maxMatch - minMatch + 1]uint8
+	baseLength  [lengthCodes]int
+	baseDist    [dCodes]int
+
+	staticLDesc  = &staticTreeDesc{staticLTree[:], extraLBits, literals + 1, lCodes, maxBits}
+	staticDDesc  = &staticTreeDesc{staticDTree[:], extraDBits, 0, dCodes, maxBits}
+	staticBLDesc = &staticTreeDesc{nil, extraBLBits, 0, blCodes, maxBLBits}
+)
+
+func init() {
+	length := 0
+	code := 0
+	for code = 0; code < lengthCodes-1; code++ {
+		baseLength[code] = length
+		for range 1 << extraLBits[code] {
+			lengthCode[length] = uint8(code)
+			length++
+		}
+	}
+	lengthCode[length-1] = uint8(code)
+
+	dist := 0
+	for code = 0; code < 16; code++ {
+		baseDist[code] = dist
+		for range 1 << extraDBits[code] {
+			distCode[dist] = uint8(code)
+			dist++
+		}
+	}
+	dist >>= 7
+	for ; code < dCodes; code++ {
+		baseDist[code] = dist << 7
+		for range 1 << (extraDBits[code] - 7) {
+			distCode[256+dist] = uint8(code)
+			dist++
+		}
+	}
+
+	var blCount [maxBits + 1]uint16
+	n := 0
+	for ; n <= 143; n++ {
+		staticLTree[n].dl = 8
+		blCount[8]++
+	}
+	for ; n <= 255; n++ {
+		staticLTree[n].dl = 9
+		blCount[9]++
+	}
+	for ; n <= 279; n++ {
+		staticLTree[n].dl = 7
+		blCount[7]++
+	}
+	for ; n <= 287; n++ {
+		staticLTree[n].dl = 8
+		blCount[8]++
+	}
+	genCodes(staticLTree[:], lCodes+1, &blCount)
+
+	for n := range dCodes {
+		staticDTree[n].dl = 5
+		staticDTree[n].fc = uint16(biReverse(uint(n), 5))
+	}
+}
+
+func dCode(dist int) uint8 {
+	if dist < 256 {
+		return distCode[dist]
+	}
+	return distCode[256+(dist>>7)]
+}
+
+// bitWriter writes deflate's LSB-first bit stream.
+type bitWriter struct {
+	w    *bufio.Writer
+	buf  uint64
+	bits uint
+	n    int64 // bytes written
+}
+
+func (b *bitWriter) sendBits(value, length int) {
+	b.buf |= uint64(value) << b.bits
+	b.bits += uint(length)
+	for b.bits >= 8 {
+		b.w.WriteByte(byte(b.buf))
+		b.n++
+		b.buf >>= 8
+		b.bits -= 8
+	}
+}
+
+// windup flushes the remaining bits, padding to a byte boundary.
+func (b *bitWriter) windup() {
+	if b.bits > 0 {
+		b.w.WriteByte(byte(b.buf))
+		b.n++
+	}
+	b.buf, b.bits = 0, 0
+}
+
+func (b *bitWriter) writeBytes(p []byte) {
+	b.w.Write(p)
+	b.n += int64(len(p))
+}
+
+// symbol is a literal (dist 0) or a length/distance pair.
+type symbol struct {
+	dist uint16
+	lc   uint8
+}
+
+// deflater is the state of zlib's deflate_state used at level 9.
+type deflater struct {
+	r      *bufio.Reader
+	err    error
+	out    *bitWriter
+	window [windowSize]byte
+	prev   [wSize]uint16
+	head   [hashSize]uint16
+
+	insH           uint
+	strstart       int
+	blockStart     int
+	lookahead      int
+	matchStart     int
+	matchLength    int
+	prevMatch      int
+	prevLength     int
+	matchAvailable bool
+	highWater      int
+
+	dynLTree [heapSize]ctData
+	dynDTree [2*dCodes + 1]ctData
+	blTree   [2*blCodes + 1]ctData
+	lDesc    treeDesc
+	dDesc    treeDesc
+	blDesc   treeDesc
+	blCount  [maxBits + 1]uint16
+	heap     [2*lCodes + 1]int
+	heapLen  int
+	heapMax  int
+	depth    [2*lCodes + 1]uint8
+
+	syms      []symbol
+	optLen    int
+	staticLen int
+}
+
+// deflate compresses r to w as zlib does at level 9 with raw deflate
+// output, returning the compressed size.
+func deflate(w io.Writer, r io.Reader) (int64, error) {
+	bw := bufio.NewWriter(w)
+	s := &deflater{
+		r:           bufio.NewReader(r),
+		out:         &bitWriter{w: bw},
+		matchLength: minMatch - 1,
+		prevLength:  minMatch - 1,
+		syms:        make([]symbol, 0, litBufSize-1),
+	}
+	s.lDesc = treeDesc{dynTree: s.dynLTree[:], stat: staticLDesc}
+	s.dDesc = treeDesc{dynTree: s.dynDTree[:], stat: staticDDesc}
+	s.blDesc = treeDesc{dynTree: s.blTree[:], stat: staticBLDesc}
+	s.initBlock()
+
+	s.deflateSlow()
+	if s.err != nil {
+		return 0, s.err
+	}
+	if err := bw.Flush(); err != nil {
+		return 0, err
+	}
+	return s.out.n, nil
+}
+
+// moreInput reports whether the input has bytes left, like zlib's
+// avail_in != 0.
+func (s *deflater) moreInput() bool {
+	if s.err != nil {
+		return false
+	}
+	if _, err := s.r.Peek(1); err != nil {
+		if !errors.Is(err, io.EOF) {
+			s.err = err
+		}
+		return false
+	}
+	return true
+}
+
+func (s *deflater) readBuf(p []byte) int {
+	n, err := io.ReadFull(s.r, p)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		s.err = err
+	}
+	return n
+}
+
+func (s *deflater) updateHash(c byte) {
+	s.insH = ((s.insH << hashShift) ^ uint(c)) & hashMask
+}
+
+// insertString inserts the string at str into the hash table, returning the
+// previous head of its hash chain.
+func (s *deflater) insertString(str int) int {
+	s.updateHash(s.window[str+minMatch-1])
+	head := s.head[s.insH]
+	s.prev[str&wMask] = head
+	s.head[s.insH] = uint16(str)
+	return int(head)
+}
+
+func (s *deflater) slideHash() {
+	for i, m := range s.head {
+		if int(m) >= wSize {
+			s.head[i] = m - wSize
+		} else {
+			s.head[i] = 0
+		}
+	}
+	for i, m := range s.prev {
+		if int(m) >= wSize {
+			s.prev[i] = m - wSize
+		} else {
+			s.prev[i] = 0
+		}
+	}
+}
+
+func (s *deflater) fillWindow() {
+	for {
+		more := windowSize - s.lookahead - s.strstart
+		if s.strstart >= wSize+maxDist {
+			copy(s.window[:wSize-more], s.window[wSize:2*wSize-more])
+			s.matchStart -= wSize
+			s.strstart -= wSize
+			s.blockStart -= wSize
+			s.slideHash()
+			more += wSize
+		}
+		if !s.moreInput() {
+			break
+		}
+
+		start := s.strstart + s.lookahead
+		s.lookahead += s.readBuf(s.window[start : start+more])
+
+		if s.lookahead >= minMatch {
+			str := s.strstart
+			s.insH = uint(s.window[str])
+			s.updateHash(s.window[str+1])
+		}
+		if s.lookahead >= minLookahead || !s.moreInput() {
+			break
+		}
+	}
+
+	if s.highWater < windowSize {
+		curr := s.strstart + s.lookahead
+		if s.highWater < curr {
+			init := min(windowSize-curr, winInit)
+			clear(s.window[curr : curr+init])
+			s.highWater = curr + init
+		} else if s.highWater < curr+winInit {
+			init := min(curr+winInit-s.highWater, windowSize-s.highWater)
+			clear(s.window[s.highWater : s.highWater+init])
+			s.highWater += init
+		}
+	}
+}
+
+func (s *deflater) longestMatch(curMatch int) int {
+	chainLength := maxChain
+	scan := s.strstart
+	bestLen := s.prevLength
+	niceMatch := niceLength
+	limit := 0
+	if s.strstart > maxDist {
+		limit = s.strstart - maxDist
+	}
+	strend := s.strstart + maxMatch
+	w := s.window[:]
+	scanEnd1 := w[scan+bestLen-1]
+	scanEnd := w[scan+bestLen]
+
+	if s.prevLength >= goodLength {
+		chainLength >>= 2
+	}
+	niceMatch = min(niceMatch, s.lookahead)
+
+	for {
+		match := curMatch
+		if w[match+bestLen] == scanEnd && w[match+bestLen-1] == scanEnd1 &&
+			w[match] == w[scan] && w[match+1] == w[scan+1] {
+			// The first two bytes are known to match, and the third does
+			// too as the hashes are equal
+			sp, mp := scan+2, match+2
+			for {
+				sp++
+				mp++
+				if sp >= strend || w[sp] != w[mp] {
+					break
+				}
+			}
+			length := maxMatch - (strend - min(sp, strend))
+			if length > bestLen {
+				s.matchStart = curMatch
+				bestLen = length
+				if length >= niceMatch {
+					break
+				}
+				scanEnd1 = w[scan+bestLen-1]
+				scanEnd = w[scan+bestLen]
+			}
+		}
+
+		curMatch = int(s.prev[curMatch&wMask])
+		if curMatch <= limit {
+			break
+		}
+		chainLength--
+		if chainLength == 0 {
+			break
+		}
+	}
+
+	return min(bestLen, s.lookahead)
+}
+
+func (s *deflater) deflateSlow() {
+	for {
+		if s.lookahead < minLookahead {
+			s.fillWindow()
+			if s.err != nil {
+				return
+			}
+			if s.lookahead == 0 {
+				break
+			}
+		}
+
+		hashHead := 0
+		if s.lookahead >= minMatch {
+			hashHead = s.insertString(s.strstart)
+		}
+
+		s.prevLength, s.prevMatch = s.matchLength, s.matchStart
+		s.matchLength = minMatch - 1
+
+		if hashHead != 0 && s.prevLength < maxLazy && s.strstart-hashHead <= maxDist {
+			s.matchLength = s.longestMatch(hashHead)
+			if s.matchLength == minMatch && s.strstart-s.matchStart > tooFar {
+				s.matchLength = minMatch - 1
+			}
+		}
+
+		switch {
+		case s.prevLength >= minMatch && s.matchLength <= s.prevLength:
+			maxInsert := s.strstart + s.lookahead - minMatch
+			flush := s.tallyDist(s.strstart-1-s.prevMatch, s.prevLength-minMatch)
+			s.lookahead -= s.prevLength - 1
+			s.prevLength -= 2
+			for {
+				s.strstart++
+				if s.strstart <= maxInsert {
+					s.insertString(s.strstart)
+				}
+				s.prevLength--
+				if s.prevLength == 0 {
+					break
+				}
+			}
+			s.matchAvailable = false
+			s.matchLength = minMatch - 1
+			s.strstart++
+			if flush {
+				s.flushBlock(false)
+			}
+		case s.matchAvailable:
+			if s.tallyLit(s.window[s.strstart-1]) {
+				s.flushBlock(false)
+			}
+			s.strstart++
+			s.lookahead--
+		default:
+			s.matchAvailable = true
+			s.strstart++
+			s.lookahead--
+		}
+	}
+
+	if s.matchAvailable {
+		s.tallyLit(s.window[s.strstart-1])
+		s.matchAvailable = false
+	}
+	s.flushBlock(true)
+}
+
+func (s *deflater) flushBlock(last bool) {
+	var buf []byte
+	if s.blockStart >= 0 {
+		buf = s.window[s.blockStart:s.strstart]
+	}
+	s.trFlushBlock(buf, s.strstart-s.blockStart, last)
+	s.blockStart = s.strstart
+}
+
+func (s *deflater) tallyLit(c byte) bool {
+	s.syms = append(s.syms, symbol{lc: c})
+	s.dynLTree[c].fc++
+	return len(s.syms) == litBufSize-1
+}
+
+func (s *deflater) tallyDist(dist, length int) bool {
+	s.syms = append(s.syms, symbol{dist: uint16(dist), lc: uint8(length)})
+	dist--
+	s.dynLTree[int(lengthCode[length])+literals+1].fc++
+	s.dynDTree[dCode(dist)].fc++
+	return len(s.syms) == litBufSize-1
+}
+
+func (s *deflater) initBlock() {
+	for n := range lCodes {
+		s.dynLTree[n].fc = 0
+	}
+	for n := range dCodes {
+		s.dynDTree[n].fc = 0
+	}
+	for n := range blCodes {
+		s.blTree[n].fc = 0
+	}
+	s.dynLTree[endBlock].fc = 1
+	s.optLen, s.staticLen = 0, 0
+	s.syms = s.syms[:0]
+}
+
+func (s *deflater) trFlushBlock(buf []byte, storedLen int, last bool) {
+	s.buildTree(&s.lDesc)
+	s.buildTree(&s.dDesc)
+	maxBLIndex := s.buildBLTree()
+
+	optLenB := (s.optLen + 3 + 7) >> 3
+	staticLenB := (s.staticLen + 3 + 7) >> 3
+	if staticLenB <= optLenB {
+		optLenB = staticLenB
+	}
+
+	lastBit := 0
+	if last {
+		lastBit = 1
+	}
+	switch {
+	case storedLen+4 <= optLenB && buf != nil:
+		s.out.sendBits(storedBlock<<1+lastBit, 3)
+		s.out.windup()
+		s.out.sendBits(storedLen&0xFFFF, 16)
+		s.out.sendBits(^storedLen&0xFFFF, 16)
+		s.out.writeBytes(buf)
+	case staticLenB == optLenB:
+		s.out.sendBits(staticTrees<<1+lastBit, 3)
+		s.compressBlock(staticLTree[:], staticDTree[:])
+	default:
+		s.out.sendBits(dynTrees<<1+lastBit, 3)
+		s.sendAllTrees(s.lDesc.maxCode+1, s.dDesc.maxCode+1, maxBLIndex+1)
+		s.compressBlock(s.dynLTree[:], s.dynDTree[:])
+	}
+	s.initBlock()
+	if last {
+		s.out.windup()
+	}
+}
+
+// smaller reports whether node n sorts before node m in the heap.
+func (s *deflater) smaller(tree []ctData, n, m int) bool {
+	return tree[n].fc < tree[m].fc || (tree[n].fc == tree[m].fc && s.depth[n] <= s.depth[m])
+}
+
+func (s *deflater) pqDownHeap(tree []ctData, k int) {
+	v := s.heap[k]
+	j := k << 1
+	for j <= s.heapLen {
+		if j < s.heapLen && s.smaller(tree, s.heap[j+1], s.heap[j]) {
+			j++
+		}
+		if s.smaller(tree, v, s.heap[j]) {
+			break
+		}
+		s.heap[k] = s.heap[j]
+		k = j
+		j <<= 1
+	}
+	s.heap[k] = v
+}
+
+func (s *deflater) buildTree(desc *treeDesc) {
+	tree := desc.dynTree
+	stree := desc.stat.staticTree
+	elems := desc.stat.elems
+	maxCode := -1
+
+	s.heapLen, s.heapMax = 0, heapSize
+	for n := range elems {
+		if tree[n].fc != 0 {
+			s.heapLen++
+			s.heap[s.heapLen] = n
+			maxCode = n
+			s.depth[n] = 0
+		} else {
+			tree[n].dl = 0
+		}
+	}
+
+	// The format requires at least two codes of non-zero frequency
+	for s.heapLen < 2 {
+		node := 0
+		if maxCode < 2 {
+			maxCode++
+			node = maxCode
+		}
+		s.heapLen++
+		s.heap[s.heapLen] = node
+		tree[node].fc = 1
+		s.depth[node] = 0
+		s.optLen--
+		if stree != nil {
+			s.staticLen -= int(stree[node].dl)
+		}
+	}
+	desc.maxCode = maxCode
+
+	for n := s.heapLen / 2; n >= 1; n-- {
+		s.pqDownHeap(tree, n)
+	}
+
+	node := elems
+	for {
+		n := s.heap[1]
+		s.heap[1] = s.heap[s.heapLen]
+		s.heapLen--
+		s.pqDownHeap(tree, 1)
+		m := s.heap[1]
+
+		s.heapMax--
+		s.heap[s.heapMax] = n
+		s.heapMax--
+		s.heap[s.heapMax] = m
+
+		tree[node].fc = tree[n].fc + tree[m].fc
+		s.depth[node] = max(s.depth[n], s.depth[m]) + 1
+		tree[n].dl = uint16(node)
+		tree[m].dl = uint16(node)
+
+		s.heap[1] = node
+		node++
+		s.pqDownHeap(tree, 1)
+		if s.heapLen < 2 {
+			break
+		}
+	}
+	s.heapMax--
+	s.heap[s.heapMax] = s.heap[1]
+
+	s.genBitLen(desc)
+	genCodes(tree, maxCode, &s.blCount)
+}
+
+func (s *deflater) genBitLen(desc *treeDesc) {
+	tree := desc.dynTree
+	maxCode := desc.maxCode
+	stree := desc.stat.staticTree
+	extra := desc.stat.extraBits
+	base := desc.stat.extraBase
+	maxLength := desc.stat.maxLength
+	overflow := 0
+
+	clear(s.blCount[:])
+	tree[s.heap[s.heapMax]].dl = 0 // root of the heap
+
+	h := s.heapMax + 1
+	for ; h < heapSize; h++ {
+		n := s.heap[h]
+		bits := int(tree[tree[n].dl].dl) + 1
+		if bits > maxLength {
+			bits = maxLength
+			overflow++
+		}
+		tree[n].dl = uint16(bits)
+		if n > maxCode {
+			continue // not a leaf node
+		}
+		s.blCount[bits]++
+		xbits := 0
+		if n >= base {
+			xbits = extra[n-base]
+		}
+		f := int(tree[n].fc)
+		s.optLen += f * (bits + xbits)
+		if stree != nil {
+			s.staticLen += f * (int(stree[n].dl) + xbits)
+		}
+	}
+	if overflow == 0 {
+		return
+	}
+
+	// Find the first bit length which could increase
+	for overflow > 0 {
+		bits := maxLength - 1
+		for s.blCount[bits] == 0 {
+			bits--
+		}
+		s.blCount[bits]--
+		s.blCount[bits+1] += 2
+		s.blCount[maxLength]--
+		overflow -= 2
+	}
+
+	// Recompute the lengths, taking leaves in order of increasing frequency
+	for bits := maxLength; bits != 0; bits-- {
+		n := int(s.blCount[bits])
+		for n != 0 {
+			h--
+			m := s.heap[h]
+			if m > maxCode {
+				continue
+			}
+			if int(tree[m].dl) != bits {
+				s.optLen += (bits - int(tree[m].dl)) * int(tree[m].fc)
+				tree[m].dl = uint16(bits)
+			}
+			n--
+		}
+	}
+}
+
+func genCodes(tree []ctData, maxCode int, blCount *[maxBits + 1]uint16) {
+	var nextCode [maxBits + 1]uint
+	code := uint(0)
+	for bits := 1; bits <= maxBits; bits++ {
+		code = (code + uint(blCount[bits-1])) << 1
+		nextCode[bits] = code
+	}
+	for n := 0; n <= maxCode; n++ {
+		length := int(tree[n].dl)
+		if length == 0 {
+			continue
+		}
+		tree[n].fc = uint16(biReverse(nextCode[length], length))
+		nextCode[length]++
+	}
+}
+
+func biReverse(code uint, length int) uint {
+	res := uint(0)
+	for {
+		res |= code & 1
+		code >>= 1
+		res <<= 1
+		length--
+		if length <= 0 {
+			break
+		}
+	}
+	return res >> 1
+}
+
+// scanTree counts the code lengths of tree in the bit length tree.
+func (s *deflater) scanTree(tree []ctData, maxCode int) {
+	prevLen := -1
+	nextLen := int(tree[0].dl)
+	count := 0
+	maxCount, minCount := 7, 4
+	if nextLen == 0 {
+		maxCount, minCount = 138, 3
+	}
+	tree[maxCode+1].dl = 0xFFFF // guard
+
+	for n := 0; n <= maxCode; n++ {
+		curLen := nextLen
+		nextLen = int(tree[n+1].dl)
+		count++
+		if count < maxCount && curLen == nextLen {
+			continue
+		} else if count < minCount {
+			s.blTree[curLen].fc += uint16(count)
+		} else if curLen != 0 {
+			if curLen != prevLen {
+				s.blTree[curLen].fc++
+			}
+			s.blTree[rep3To6].fc++
+		} else if count <= 10 {
+			s.blTree[repz3To10].fc++
+		} else {
+			s.blTree[repz11To138].fc++
+		}
+		count = 0
+		prevLen = curLen
+		switch {
+		case nextLen == 0:
+			maxCount, minCount = 138, 3
+		case curLen == nextLen:
+			maxCount, minCount = 6, 3
+		default:
+			maxCount, minCount = 7, 4
+		}
+	}
+}
+
+// sendTree sends tree's code lengths using the bit length tree.
+func (s *deflater) sendTree(tree []ctData, maxCode int) {
+	prevLen := -1
+	nextLen := int(tree[0].dl)
+	count := 0
+	maxCount, minCount := 7, 4
+	if nextLen == 0 {
+		maxCount, minCount = 138, 3
+	}
+
+	for n := 0; n <= maxCode; n++ {
+		curLen := nextLen
+		nextLen = int(tree[n+1].dl)
+		count++
+		if count < maxCount && curLen == nextLen {
+			continue
+		} else if count < minCount {
+			for ; count != 0; count-- {
+				s.sendCode(curLen, s.blTree[:])
+			}
+		} else if curLen != 0 {
+			if curLen != prevLen {
+				s.sendCode(curLen, s.blTree[:])
+				count--
+			}
+			s.sendCode(rep3To6, s.blTree[:])
+			s.out.sendBits(count-3, 2)
+		} else if count <= 10 {
+			s.sendCode(repz3To10, s.blTree[:])
+			s.out.sendBits(count-3, 3)
+		} else {
+			s.sendCode(repz11To138, s.blTree[:])
+			s.out.sendBits(count-11, 7)
+		}
+		count = 0
+		prevLen = curLen
+		switch {
+		case nextLen == 0:
+			maxCount, minCount = 138, 3
+		case curLen == nextLen:
+			maxCount, minCount = 6, 3
+		default:
+			maxCount, minCount = 7, 4
+		}
+	}
+}
+
+func (s *deflater) buildBLTree() int {
+	s.scanTree(s.dynLTree[:], s.lDesc.maxCode)
+	s.scanTree(s.dynDTree[:], s.dDesc.maxCode)
+	s.buildTree(&s.blDesc)
+
+	maxBLIndex := blCodes - 1
+	for ; maxBLIndex >= 3; maxBLIndex-- {
+		if s.blTree[blOrder[maxBLIndex]].dl != 0 {
+			break
+		}
+	}
+	s.optLen += 3*(maxBLIndex+1) + 5 + 5 + 4
+	return maxBLIndex
+}
+
+func (s *deflater) sendAllTrees(lcodes, dcodes, blcodes int) {
+	s.out.sendBits(lcodes-257, 5)
+	s.out.sendBits(dcodes-1, 5)
+	s.out.sendBits(blcodes-4, 4)
+	for rank := range blcodes {
+		s.out.sendBits(int(s.blTree[blOrder[rank]].dl), 3)
+	}
+	s.sendTree(s.dynLTree[:], lcodes-1)
+	s.sendTree(s.dynDTree[:], dcodes-1)
+}
+
+func (s *deflater) sendCode(c int, tree []ctData) {
+	s.out.sendBits(int(tree[c].fc), int(tree[c].dl))
+}
+
+func (s *deflater) compressBlock(ltree, dtree []ctData) {
+	for _, sym := range s.syms {
+		if sym.dist == 0 {
+			s.sendCode(int(sym.lc), ltree)
+			continue
+		}
+		lc := int(sym.lc)
+		code := int(lengthCode[lc])
+		s.sendCode(code+literals+1, ltree)
+		if extra := extraLBits[code]; extra != 0 {
+			s.out.sendBits(lc-baseLength[code], extra)
+		}
+		dist := int(sym.dist) - 1
+		code = int(dCode(dist))
+		s.sendCode(code, dtree)
+		if extra := extraDBits[code]; extra != 0 {
+			s.out.sendBits(dist-baseDist[code], extra)
+		}
+	}
+	s.sendCode(endBlock, ltree)
+}
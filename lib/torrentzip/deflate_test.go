@@ -0,0 +1,78 @@
+package torrentzip
+
+import (
+	"bytes"
+	"compress/flate"
+	"hash/crc32"
+	"io"
+	"testing"
+)
+
+// lcgBytes generates n pseudo-random bytes of the given number of bits.
+func lcgBytes(n int, seed uint32, bits uint) []byte {
+	out := make([]byte, n)
+	x := seed
+	for i := range out {
+		x = x*1664525 + 1013904223
+		out[i] = byte(x>>24) >> (8 - bits)
+	}
+	return out
+}
+
+// lcgWords generates n pseudo-random words.
+func lcgWords(n int, seed uint32) []byte {
+	words := []string{"the ", "rom ", "set ", "zip ", "torrent ", "game ", "(USA) ", "(Europe) ", "\n", "ok. "}
+	var out []byte
+	x := seed
+	for range n {
+		x = x*1664525 + 1013904223
+		out = append(out, words[(x>>24)%10]...)
+	}
+	return out
+}
+
+func TestDeflate(t *testing.T) {
+	// Sizes and CRC32s of the output of zlib 1.2.13 at level 9, with
+	// windowBits -15, memLevel 8, and the default strategy
+	tests := []struct {
+		name     string
+		data     []byte
+		wantSize int64
+		wantCRC  uint32
+	}{
+		{"empty", nil, 2, 0x6af4413c},
+		{"zeros", lcgBytes(100000, 0, 0), 114, 0x27390c0e},
+		{"random", lcgBytes(100000, 1, 8), 100035, 0x9a95845a},
+		{"two bits", lcgBytes(200000, 2, 2), 58181, 0x05c00486},
+		{"three bits", lcgBytes(300000, 3, 3), 128805, 0xe043d174},
+		{"four bits", lcgBytes(70000, 4, 4), 40390, 0xe87966c9},
+		{"words", lcgWords(60000, 5), 40765, 0x93c447fc},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			n, err := deflate(&out, bytes.NewReader(tt.data))
+			if err != nil {
+				t.Fatalf("deflate() error = %v", err)
+			}
+			if n != int64(out.Len()) {
+				t.Errorf("deflate() = %d, wrote %d bytes", n, out.Len())
+			}
+			if n != tt.wantSize {
+				t.Errorf("size = %d, want %d", n, tt.wantSize)
+			}
+			if crc := crc32.ChecksumIEEE(out.Bytes()); crc != tt.wantCRC {
+				t.Errorf("CRC32 = %08x, want %08x", crc, tt.wantCRC)
+			}
+
+			inflated, err := io.ReadAll(flate.NewReader(&out))
+			if err != nil {
+				t.Fatalf("inflate error = %v", err)
+			}
+			if !bytes.Equal(inflated, tt.data) {
+				t.Error("inflated data doesn't match the input")
+			}
+		})
+	}
+}
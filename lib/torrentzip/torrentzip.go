@@ -0,0 +1,302 @@
+// Package torrentzip writes and verifies TorrentZip archives: ZIP files
+// written canonically, so that archives holding the same files are
+// byte-identical no matter what tool built them.
+//
+// A TorrentZip archive has its entries sorted by lowercased name, each
+// deflated as zlib does at level 9, with fixed headers:
+//
+//	Version needed   20
+//	Flags            0x0002 (maximum compression), plus 0x0800 for non-ASCII names
+//	Modified         1996-12-24 23:32:00 (DOS time 0xBC00, date 0x2198)
+//	Extra fields     none, and no data descriptors
+//	Version made by  0 (central directory only)
+//	Attributes       0 (central directory only)
+//
+// The archive comment is "TORRENTZIPPED-" followed by the CRC32 of the
+// central directory in uppercase hex. ZIP64 archives (over 4 GiB, or with
+// 65535 or more entries) are not supported.
+package torrentzip
+
+import (
+	"archive/zip"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+const (
+	localHeaderSig   = 0x04034b50
+	centralHeaderSig = 0x02014b50
+	eocdSig          = 0x06054b50
+
+	localHeaderLen   = 30
+	centralHeaderLen = 46
+	eocdLen          = 22
+
+	versionNeeded  = 20
+	flagMaxDeflate = 0x0002
+	flagUTF8       = 0x0800
+	methodDeflate  = 8
+	dosTime        = 0xBC00
+	dosDate        = 0x2198
+
+	commentPrefix = "TORRENTZIPPED-"
+	commentLen    = len(commentPrefix) + 8
+)
+
+// File is a file to write into a TorrentZip archive.
+type File struct {
+	Name string                        // path within the archive, with forward slashes
+	Open func() (io.ReadCloser, error) // opens the file's uncompressed contents
+}
+
+// entry is a file written to an archive, for its central directory header.
+type entry struct {
+	name   string
+	flags  uint16
+	crc    uint32
+	csize  uint32
+	usize  uint32
+	offset uint32
+}
+
+// Write writes files to w as a TorrentZip archive. The files are sorted
+// into TorrentZip order first; w is seeked back to fill in each local
+// header once its file is compressed.
+func Write(w io.WriteSeeker, files []File) error {
+	files = slices.Clone(files)
+	slices.SortFunc(files, func(a, b File) int { return compareNames(a.Name, b.Name) })
+	for i := 1; i < len(files); i++ {
+		if files[i].Name == files[i-1].Name {
+			return fmt.Errorf("duplicate file name: %s", files[i].Name)
+		}
+	}
+	if len(files) >= math.MaxUint16 {
+		return fmt.Errorf("too many files for a ZIP without ZIP64: %d", len(files))
+	}
+
+	entries := make([]entry, 0, len(files))
+	offset := int64(0)
+	for _, f := range files {
+		e, n, err := writeFile(w, f, offset)
+		if err != nil {
+			return fmt.Errorf("failed to write %s: %w", f.Name, err)
+		}
+		entries = append(entries, e)
+		offset += n
+	}
+
+	var cd []byte
+	for _, e := range entries {
+		cd = appendCentralHeader(cd, e)
+	}
+	if offset+int64(len(cd)) > math.MaxUint32 {
+		return fmt.Errorf("archive too large for a ZIP without ZIP64")
+	}
+
+	eocd := make([]byte, eocdLen, eocdLen+commentLen)
+	binary.LittleEndian.PutUint32(eocd[0:], eocdSig)
+	binary.LittleEndian.PutUint16(eocd[8:], uint16(len(entries)))
+	binary.LittleEndian.PutUint16(eocd[10:], uint16(len(entries)))
+	binary.LittleEndian.PutUint32(eocd[12:], uint32(len(cd)))
+	binary.LittleEndian.PutUint32(eocd[16:], uint32(offset))
+	binary.LittleEndian.PutUint16(eocd[20:], uint16(commentLen))
+	eocd = fmt.Appendf(eocd, "%s%08X", commentPrefix, crc32.ChecksumIEEE(cd))
+
+	if _, err := w.Write(cd); err != nil {
+		return err
+	}
+	_, err := w.Write(eocd)
+	return err
+}
+
+// writeFile writes the local header and compressed data of f at offset,
+// returning its entry and the number of bytes written.
+func writeFile(w io.WriteSeeker, f File, offset int64) (entry, int64, error) {
+	e := entry{name: f.Name, flags: flagMaxDeflate}
+	if offset > math.MaxUint32 {
+		return e, 0, fmt.Errorf("archive too large for a ZIP without ZIP64")
+	}
+	e.offset = uint32(offset)
+	for i := range len(f.Name) {
+		if f.Name[i] >= 0x80 {
+			e.flags |= flagUTF8
+			break
+		}
+	}
+
+	// Write the header without the CRC32 and sizes, which come after the
+	// data is compressed
+	if _, err := w.Write(appendLocalHeader(nil, e)); err != nil {
+		return e, 0, err
+	}
+
+	r, err := f.Open()
+	if err != nil {
+		return e, 0, err
+	}
+	defer r.Close()
+
+	h := crc32.NewIEEE()
+	counter := &countingReader{r: io.TeeReader(r, h)}
+	csize, err := deflate(w, counter)
+	if err != nil {
+		return e, 0, err
+	}
+	if counter.n > math.MaxUint32 || csize > math.MaxUint32 {
+		return e, 0, fmt.Errorf("file too large for a ZIP without ZIP64")
+	}
+	e.crc, e.csize, e.usize = h.Sum32(), uint32(csize), uint32(counter.n)
+
+	if _, err := w.Seek(offset, io.SeekStart); err != nil {
+		return e, 0, err
+	}
+	if _, err := w.Write(appendLocalHeader(nil, e)); err != nil {
+		return e, 0, err
+	}
+	n := int64(localHeaderLen+len(e.name)) + csize
+	if _, err := w.Seek(offset+n, io.SeekStart); err != nil {
+		return e, 0, err
+	}
+	return e, n, nil
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func appendLocalHeader(b []byte, e entry) []byte {
+	b = binary.LittleEndian.AppendUint32(b, localHeaderSig)
+	b = binary.LittleEndian.AppendUint16(b, versionNeeded)
+	b = binary.LittleEndian.AppendUint16(b, e.flags)
+	b = binary.LittleEndian.AppendUint16(b, methodDeflate)
+	b = binary.LittleEndian.AppendUint16(b, dosTime)
+	b = binary.LittleEndian.AppendUint16(b, dosDate)
+	b = binary.LittleEndian.AppendUint32(b, e.crc)
+	b = binary.LittleEndian.AppendUint32(b, e.csize)
+	b = binary.LittleEndian.AppendUint32(b, e.usize)
+	b = binary.LittleEndian.AppendUint16(b, uint16(len(e.name)))
+	b = binary.LittleEndian.AppendUint16(b, 0) // extra field length
+	return append(b, e.name...)
+}
+
+func appendCentralHeader(b []byte, e entry) []byte {
+	b = binary.LittleEndian.AppendUint32(b, centralHeaderSig)
+	b = binary.LittleEndian.AppendUint16(b, 0) // version made by
+	b = binary.LittleEndian.AppendUint16(b, versionNeeded)
+	b = binary.LittleEndian.AppendUint16(b, e.flags)
+	b = binary.LittleEndian.AppendUint16(b, methodDeflate)
+	b = binary.LittleEndian.AppendUint16(b, dosTime)
+	b = binary.LittleEndian.AppendUint16(b, dosDate)
+	b = binary.LittleEndian.AppendUint32(b, e.crc)
+	b = binary.LittleEndian.AppendUint32(b, e.csize)
+	b = binary.LittleEndian.AppendUint32(b, e.usize)
+	b = binary.LittleEndian.AppendUint16(b, uint16(len(e.name)))
+	b = binary.LittleEndian.AppendUint16(b, 0) // extra field length
+	b = binary.LittleEndian.AppendUint16(b, 0) // comment length
+	b = binary.LittleEndian.AppendUint16(b, 0) // disk number
+	b = binary.LittleEndian.AppendUint16(b, 0) // internal attributes
+	b = binary.LittleEndian.AppendUint32(b, 0) // external attributes
+	b = binary.LittleEndian.AppendUint32(b, e.offset)
+	return append(b, e.name...)
+}
+
+// compareNames orders names as TorrentZip does: by their bytes with ASCII
+// letters lowercased. Names equal apart from case are ordered by their
+// bytes, so the order is always total.
+func compareNames(a, b string) int {
+	for i := range min(len(a), len(b)) {
+		ca, cb := lower(a[i]), lower(b[i])
+		if ca != cb {
+			return int(ca) - int(cb)
+		}
+	}
+	if len(a) != len(b) {
+		return len(a) - len(b)
+	}
+	return strings.Compare(a, b)
+}
+
+func lower(c byte) byte {
+	if c >= 'A' && c <= 'Z' {
+		return c + 'a' - 'A'
+	}
+	return c
+}
+
+// Rewrite converts the ZIP archive at path to a TorrentZip archive in place,
+// unless it already is one. Directory entries are kept only for empty
+// directories. The new archive is written next to the old one and replaces
+// it only once complete.
+func Rewrite(path string) error {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return fmt.Errorf("failed to open ZIP: %w", err)
+	}
+	defer r.Close()
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	err = Verify(f, info.Size())
+	f.Close()
+	if err == nil {
+		return nil
+	}
+
+	files := ZIPFiles(&r.Reader)
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".torrentzip-*.zip")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := Write(tmp, files); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), info.Mode().Perm()); err != nil {
+		return err
+	}
+	r.Close()
+	return os.Rename(tmp.Name(), path)
+}
+
+// ZIPFiles lists the files of r to write into a TorrentZip archive. Directory
+// entries are kept only for empty directories.
+func ZIPFiles(r *zip.Reader) []File {
+	var files []File
+	for _, zf := range r.File {
+		name := strings.ReplaceAll(zf.Name, "\\", "/")
+		if strings.HasSuffix(name, "/") && slices.ContainsFunc(r.File, func(other *zip.File) bool {
+			return other != zf && strings.HasPrefix(strings.ReplaceAll(other.Name, "\\", "/"), name)
+		}) {
+			continue // not an empty directory
+		}
+		files = append(files, File{Name: name, Open: zf.Open})
+	}
+	return files
+}
@@ -0,0 +1,225 @@
+package torrentzip
+
+import (
+	"archive/zip"
+	"bytes"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testFiles are the files of the test archive, in no particular order.
+var testFiles = map[string][]byte{
+	"b.bin":     lcgBytes(1000, 1, 8),
+	"A.txt":     lcgWords(100, 5),
+	"dir/c.bin": lcgBytes(5000, 0, 0),
+	"empty":     nil,
+}
+
+func fileList(files map[string][]byte, order ...string) []File {
+	var list []File
+	for _, name := range order {
+		data := files[name]
+		list = append(list, File{Name: name, Open: func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(data)), nil
+		}})
+	}
+	return list
+}
+
+// writeArchive writes files to a temporary file and returns its contents.
+func writeArchive(t *testing.T, files []File) []byte {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.zip")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Write(f, files); err != nil {
+		f.Close()
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestWrite(t *testing.T) {
+	data := writeArchive(t, fileList(testFiles, "b.bin", "empty", "dir/c.bin", "A.txt"))
+
+	// Size and CRC32 of the archive as built by hand to the TorrentZip spec
+	if len(data) != 1586 {
+		t.Errorf("size = %d, want 1586", len(data))
+	}
+	if crc := crc32.ChecksumIEEE(data); crc != 0xcbc713bd {
+		t.Errorf("CRC32 = %08x, want cbc713bd", crc)
+	}
+	if !bytes.HasSuffix(data, []byte("TORRENTZIPPED-ADD89A10")) {
+		t.Errorf("comment = %q, want TORRENTZIPPED-ADD89A10", data[len(data)-commentLen:])
+	}
+
+	r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("zip.NewReader() error = %v", err)
+	}
+	wantOrder := []string{"A.txt", "b.bin", "dir/c.bin", "empty"}
+	if len(r.File) != len(wantOrder) {
+		t.Fatalf("got %d entries, want %d", len(r.File), len(wantOrder))
+	}
+	for i, f := range r.File {
+		if f.Name != wantOrder[i] {
+			t.Errorf("entry %d = %s, want %s", i, f.Name, wantOrder[i])
+		}
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("Open(%s) error = %v", f.Name, err)
+		}
+		got, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatalf("ReadAll(%s) error = %v", f.Name, err)
+		}
+		if !bytes.Equal(got, testFiles[f.Name]) {
+			t.Errorf("%s contents don't match", f.Name)
+		}
+	}
+
+	if err := Verify(bytes.NewReader(data), int64(len(data))); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+}
+
+func TestWrite_Deterministic(t *testing.T) {
+	a := writeArchive(t, fileList(testFiles, "A.txt", "b.bin", "dir/c.bin", "empty"))
+	b := writeArchive(t, fileList(testFiles, "empty", "dir/c.bin", "b.bin", "A.txt"))
+	if !bytes.Equal(a, b) {
+		t.Error("archives with the same files in a different order differ")
+	}
+}
+
+func TestWrite_DuplicateName(t *testing.T) {
+	files := fileList(testFiles, "A.txt", "A.txt")
+	f, err := os.Create(filepath.Join(t.TempDir(), "test.zip"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := Write(f, files); err == nil {
+		t.Error("Write() expected error for duplicate names")
+	}
+}
+
+func TestCompareNames(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int // sign only
+	}{
+		{"a", "B", -1},
+		{"B", "a", 1},
+		{"abc", "ABD", -1},
+		{"ab", "abc", -1},
+		{"A", "a", -1},
+		{"dir/file", "dir_file", -1},
+		{"same", "same", 0},
+	}
+	for _, tt := range tests {
+		got := compareNames(tt.a, tt.b)
+		if (got < 0) != (tt.want < 0) || (got > 0) != (tt.want > 0) {
+			t.Errorf("compareNames(%q, %q) = %d, want sign of %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestVerify_Invalid(t *testing.T) {
+	valid := writeArchive(t, fileList(testFiles, "A.txt", "b.bin", "dir/c.bin", "empty"))
+
+	// An ordinary ZIP of the same files
+	var plain bytes.Buffer
+	zw := zip.NewWriter(&plain)
+	for _, name := range []string{"A.txt", "b.bin"} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(testFiles[name])
+	}
+	zw.Close()
+
+	// A TorrentZip archive with a modified central directory timestamp
+	cdOffset := int(valid[len(valid)-commentLen-6]) | int(valid[len(valid)-commentLen-5])<<8
+	badTime := bytes.Clone(valid)
+	badTime[cdOffset+12]++
+
+	// A TorrentZip archive with a modified local header timestamp
+	badLocal := bytes.Clone(valid)
+	badLocal[10]++
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr string
+	}{
+		{"plain zip", plain.Bytes(), "TORRENTZIPPED"},
+		{"central directory changed", badTime, "CRC32"},
+		{"local header changed", badLocal, "local header"},
+		{"truncated", valid[:20], "too small"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify(bytes.NewReader(tt.data), int64(len(tt.data)))
+			if err == nil {
+				t.Fatal("Verify() expected error")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Verify() error = %v, want it to mention %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "set.zip")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	for _, name := range []string{"b.bin", "dir/", "dir/c.bin", "empty", "A.txt"} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(testFiles[name])
+	}
+	zw.Close()
+	f.Close()
+
+	if err := Rewrite(path); err != nil {
+		t.Fatalf("Rewrite() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The directory entry is dropped, as the directory isn't empty
+	if crc := crc32.ChecksumIEEE(data); len(data) != 1586 || crc != 0xcbc713bd {
+		t.Errorf("rewritten archive is %d bytes with CRC32 %08x, want the reference archive", len(data), crc)
+	}
+
+	// Rewriting a TorrentZip archive leaves it as is
+	info, _ := os.Stat(path)
+	if err := Rewrite(path); err != nil {
+		t.Fatalf("Rewrite() error = %v", err)
+	}
+	if info2, _ := os.Stat(path); !os.SameFile(info, info2) {
+		t.Error("Rewrite() replaced an archive that was already TorrentZip")
+	}
+}
@@ -0,0 +1,144 @@
+package torrentzip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"strconv"
+)
+
+// Verify checks that the ZIP archive in r is a valid TorrentZip archive: that
+// its comment matches the CRC32 of its central directory, its entries are
+// in TorrentZip order, and every header has the fixed TorrentZip values.
+// The compressed data itself isn't checked, as a matching comment means the
+// archive was written by a TorrentZip tool.
+//
+// Returns nil if the archive is valid, or an error describing the first
+// problem found.
+func Verify(r io.ReaderAt, size int64) error {
+	if size < eocdLen+int64(commentLen) {
+		return fmt.Errorf("file too small for TorrentZip: %d bytes", size)
+	}
+
+	eocdOffset := size - eocdLen - int64(commentLen)
+	eocd := make([]byte, eocdLen+commentLen)
+	if _, err := r.ReadAt(eocd, eocdOffset); err != nil {
+		return fmt.Errorf("failed to read end of central directory: %w", err)
+	}
+	if binary.LittleEndian.Uint32(eocd[0:]) != eocdSig {
+		return fmt.Errorf("not a valid TorrentZip: no end of central directory with a TORRENTZIPPED comment")
+	}
+	comment := string(eocd[eocdLen:])
+	if int(binary.LittleEndian.Uint16(eocd[20:])) != commentLen || comment[:len(commentPrefix)] != commentPrefix {
+		return fmt.Errorf("not a valid TorrentZip: missing TORRENTZIPPED comment")
+	}
+	wantCRC, err := strconv.ParseUint(comment[len(commentPrefix):], 16, 32)
+	if err != nil {
+		return fmt.Errorf("not a valid TorrentZip: malformed comment %q", comment)
+	}
+
+	count := int(binary.LittleEndian.Uint16(eocd[8:]))
+	cdSize := int64(binary.LittleEndian.Uint32(eocd[12:]))
+	cdOffset := int64(binary.LittleEndian.Uint32(eocd[16:]))
+	if binary.LittleEndian.Uint32(eocd[4:]) != 0 || int(binary.LittleEndian.Uint16(eocd[10:])) != count {
+		return fmt.Errorf("not a valid TorrentZip: multi-disk archive")
+	}
+	if cdOffset+cdSize != eocdOffset {
+		return fmt.Errorf("not a valid TorrentZip: central directory doesn't end at the end of central directory record")
+	}
+
+	cd := make([]byte, cdSize)
+	if _, err := r.ReadAt(cd, cdOffset); err != nil {
+		return fmt.Errorf("failed to read central directory: %w", err)
+	}
+	if crc := crc32.ChecksumIEEE(cd); uint64(crc) != wantCRC {
+		return fmt.Errorf("not a valid TorrentZip: central directory CRC32 is %08X, comment says %08X", crc, wantCRC)
+	}
+
+	offset := int64(0)
+	prevName := ""
+	for i := range count {
+		e, n, err := parseCentralHeader(cd)
+		if err != nil {
+			return fmt.Errorf("not a valid TorrentZip: entry %d: %w", i, err)
+		}
+		cd = cd[n:]
+
+		if i > 0 && compareNames(prevName, e.name) >= 0 {
+			return fmt.Errorf("not a valid TorrentZip: %s is out of order", e.name)
+		}
+		prevName = e.name
+		if int64(e.offset) != offset {
+			return fmt.Errorf("not a valid TorrentZip: %s is at offset %d, expected %d", e.name, e.offset, offset)
+		}
+		if err := verifyLocalHeader(r, e); err != nil {
+			return fmt.Errorf("not a valid TorrentZip: %s: %w", e.name, err)
+		}
+		offset += int64(localHeaderLen+len(e.name)) + int64(e.csize)
+	}
+	if len(cd) != 0 {
+		return fmt.Errorf("not a valid TorrentZip: %d bytes after the last central directory header", len(cd))
+	}
+	if offset != cdOffset {
+		return fmt.Errorf("not a valid TorrentZip: %d bytes between the last entry and the central directory", cdOffset-offset)
+	}
+	return nil
+}
+
+// parseCentralHeader parses and checks the central directory header at the
+// start of b, returning it and its length.
+func parseCentralHeader(b []byte) (entry, int, error) {
+	if len(b) < centralHeaderLen || binary.LittleEndian.Uint32(b) != centralHeaderSig {
+		return entry{}, 0, fmt.Errorf("malformed central directory header")
+	}
+	nameLen := int(binary.LittleEndian.Uint16(b[28:]))
+	if len(b) < centralHeaderLen+nameLen {
+		return entry{}, 0, fmt.Errorf("malformed central directory header")
+	}
+	e := entry{
+		name:   string(b[centralHeaderLen : centralHeaderLen+nameLen]),
+		flags:  binary.LittleEndian.Uint16(b[8:]),
+		crc:    binary.LittleEndian.Uint32(b[16:]),
+		csize:  binary.LittleEndian.Uint32(b[20:]),
+		usize:  binary.LittleEndian.Uint32(b[24:]),
+		offset: binary.LittleEndian.Uint32(b[42:]),
+	}
+	if !bytes.Equal(b[:centralHeaderLen+nameLen], appendCentralHeader(nil, e)) {
+		return e, 0, fmt.Errorf("%s has non-TorrentZip central directory header fields", e.name)
+	}
+	if err := checkFlags(e); err != nil {
+		return e, 0, err
+	}
+	return e, centralHeaderLen + nameLen, nil
+}
+
+// checkFlags checks that e's flags are the ones TorrentZip uses for its name.
+func checkFlags(e entry) error {
+	want := uint16(flagMaxDeflate)
+	for i := range len(e.name) {
+		if e.name[i] >= 0x80 {
+			want |= flagUTF8
+			break
+		}
+	}
+	if e.flags != want {
+		return fmt.Errorf("%s has flags %#04x, expected %#04x", e.name, e.flags, want)
+	}
+	return nil
+}
+
+// verifyLocalHeader checks that the local header of e matches its central
+// directory header.
+func verifyLocalHeader(r io.ReaderAt, e entry) error {
+	want := appendLocalHeader(nil, e)
+	got := make([]byte, len(want))
+	if _, err := r.ReadAt(got, int64(e.offset)); err != nil {
+		return fmt.Errorf("failed to read local header: %w", err)
+	}
+	if !bytes.Equal(got, want) {
+		return fmt.Errorf("local header doesn't match the central directory")
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package udf
+
+import (
+	"bytes"
+	"io/fs"
+	"slices"
+	"testing"
+	"testing/fstest"
+)
+
+func TestReader_FS(t *testing.T) {
+	data := createUDF([]byte("cnf"), []byte("embedded"))
+	reader, err := NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+
+	if err := fstest.TestFS(reader.FS(), "SYSTEM.CNF", "Long Name.txt", "PSP_GAME/PARAM.SFO"); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := fs.ReadFile(reader.FS(), "long name.txt")
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if string(content) != "embedded" {
+		t.Errorf("ReadFile = %q, want %q", content, "embedded")
+	}
+
+	if _, err := reader.FS().Open("/PSP_GAME"); err == nil {
+		t.Error("Open of an invalid path expected error, got nil")
+	}
+}
+
+func TestReader_Walk(t *testing.T) {
+	data := createUDF([]byte("cnf"), []byte("embedded"))
+	reader, err := NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+
+	var paths []string
+	err = reader.Walk(func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		paths = append(paths, path)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Walk failed: %v", err)
+	}
+
+	want := []string{".", "Long Name.txt", "PSP_GAME", "PSP_GAME/PARAM.SFO", "SYSTEM.CNF"}
+	if !slices.Equal(paths, want) {
+		t.Errorf("Walk paths = %q, want %q", paths, want)
+	}
+}
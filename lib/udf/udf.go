@@ -0,0 +1,667 @@
+// Package udf provides support for reading UDF (Universal Disk Format)
+// filesystem images, such as DVD images with no ISO 9660 bridge filesystem.
+//
+// Supports UDF 1.02 through 2.01 images with 2048-byte sectors and physical
+// (type 1) partition maps. Virtual, sparable, and metadata partitions (used
+// by packet-written media and UDF 2.50+) aren't supported.
+//
+// The API mirrors iso9660: use NewReader to open an image, then access files
+// via OpenFile or list directories via ReadDir. FS exposes the filesystem as
+// an io/fs.FS, and Walk enumerates it.
+//
+// UDF layout (relevant parts, in 2048-byte sectors):
+//   - Sectors 16+: Volume Recognition Sequence ("BEA01", "NSR02" or "NSR03", "TEA01")
+//   - Sector 256: Anchor Volume Descriptor Pointer, locating the Volume Descriptor Sequence
+//   - Volume Descriptor Sequence: Partition Descriptors (where partitions start)
+//     and the Logical Volume Descriptor (partition maps, File Set Descriptor location)
+//   - File Set Descriptor: root directory ICB (the location of its File Entry)
+//   - File Entry: file type, size, and allocation descriptors (where the data lives)
+//   - Directory data: File Identifier Descriptors (name and File Entry location of each entry)
+//
+// Within a partition, data is addressed by logical block number; a long_ad
+// (long allocation descriptor) also names the partition, by its index in the
+// Logical Volume Descriptor's partition maps.
+package udf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf16"
+)
+
+const (
+	sectorSize   = 2048
+	anchorSector = 256
+
+	// Volume Recognition Sequence
+	vrsStartSector = 16
+	vrsMaxSectors  = 32
+
+	// Descriptor tag identifiers
+	tagAnchor            = 2
+	tagPartition         = 5
+	tagLogicalVolume     = 6
+	tagTerminating       = 8
+	tagFileSet           = 256
+	tagFileIdentifier    = 257
+	tagAllocationExtent  = 258
+	tagFileEntry         = 261
+	tagExtendedFileEntry = 266
+
+	// Anchor Volume Descriptor Pointer: main Volume Descriptor Sequence extent
+	avdpMainVDSLength   = 16
+	avdpMainVDSLocation = 20
+
+	// Partition Descriptor
+	pdPartitionNumber = 22
+	pdStartLocation   = 188
+
+	// Logical Volume Descriptor
+	lvdBlockSize     = 212
+	lvdFileSetLongAD = 248
+	lvdNumMaps       = 268
+	lvdPartitionMaps = 440
+
+	// File Set Descriptor
+	fsdRootICB = 400
+
+	// File Entry (and Extended File Entry, whose later fields are shifted)
+	feFileType     = 27
+	feICBFlags     = 34
+	feInfoLength   = 56
+	feLengthEA     = 168
+	feLengthAD     = 172
+	feEA           = 176
+	efeLengthEA    = 208
+	efeLengthAD    = 212
+	efeEA          = 216
+	fileTypeDir    = 4
+	adTypeShort    = 0
+	adTypeLong     = 1
+	adTypeEmbedded = 3
+
+	// File Identifier Descriptor
+	fidCharacteristics = 18
+	fidLengthFI        = 19
+	fidICB             = 20
+	fidLengthIU        = 36
+	fidImplUse         = 38
+
+	charDirectory = 0x02
+	charDeleted   = 0x04
+	charParent    = 0x08
+
+	// Allocation extent types (top 2 bits of an extent's length)
+	extentRecorded     = 0
+	extentNotRecorded  = 1
+	extentUnallocated  = 2
+	extentContinuation = 3
+
+	// maxContinuations bounds how many allocation extent descriptors are
+	// followed per file
+	maxContinuations = 64
+
+	// maxDirSize bounds how much directory data is read
+	maxDirSize = 16 * 1024 * 1024
+)
+
+// DirEntry is an entry in a directory listing.
+type DirEntry struct {
+	Name  string `json:"name"`
+	Size  int64  `json:"size"`
+	IsDir bool   `json:"is_dir"`
+}
+
+// longAD is a long allocation descriptor: an extent within a partition.
+type longAD struct {
+	length    uint32
+	block     uint32
+	partition uint16
+}
+
+// extent is a run of file data at an absolute image offset. Sparse extents
+// read as zeros.
+type extent struct {
+	offset int64
+	length int64
+	sparse bool
+}
+
+// fileEntry is a parsed File Entry or Extended File Entry.
+type fileEntry struct {
+	isDir    bool
+	size     int64
+	extents  []extent
+	embedded []byte // data embedded in the File Entry itself
+}
+
+// dirRecord is a directory's File Identifier Descriptor.
+type dirRecord struct {
+	name  string
+	icb   longAD
+	isDir bool
+}
+
+// Reader provides access to a UDF filesystem image.
+type Reader struct {
+	r          io.ReaderAt
+	size       int64
+	partitions []uint32 // start sector by partition reference number
+	rootICB    longAD
+}
+
+// NewReader opens a UDF image, reading its volume descriptors and locating
+// the root directory.
+func NewReader(r io.ReaderAt, size int64) (*Reader, error) {
+	if size < (anchorSector+1)*sectorSize {
+		return nil, fmt.Errorf("file too small for UDF: %d bytes", size)
+	}
+	if !hasNSR(r) {
+		return nil, fmt.Errorf("not a valid UDF image: no NSR descriptor found")
+	}
+
+	reader := &Reader{r: r, size: size}
+
+	anchor, err := reader.readDescriptor(anchorSector*sectorSize, tagAnchor)
+	if err != nil {
+		return nil, fmt.Errorf("not a valid UDF image: anchor: %w", err)
+	}
+	vdsLength := binary.LittleEndian.Uint32(anchor[avdpMainVDSLength:])
+	vdsLocation := binary.LittleEndian.Uint32(anchor[avdpMainVDSLocation:])
+
+	if err := reader.readVolumeDescriptors(vdsLocation, vdsLength); err != nil {
+		return nil, err
+	}
+	return reader, nil
+}
+
+// hasNSR scans the Volume Recognition Sequence for an NSR descriptor, which
+// marks a UDF filesystem.
+func hasNSR(r io.ReaderAt) bool {
+	id := make([]byte, 5)
+	for sector := int64(vrsStartSector); sector < vrsStartSector+vrsMaxSectors; sector++ {
+		if _, err := r.ReadAt(id, sector*sectorSize+1); err != nil {
+			return false
+		}
+		switch string(id) {
+		case "NSR02", "NSR03":
+			return true
+		case "BEA01", "TEA01", "CD001", "CDW02", "BOOT2":
+			continue
+		}
+		return false
+	}
+	return false
+}
+
+// readVolumeDescriptors reads the Volume Descriptor Sequence for the
+// partition starts and the File Set Descriptor, which locates the root
+// directory.
+func (r *Reader) readVolumeDescriptors(location, length uint32) error {
+	starts := make(map[uint16]uint32) // by partition number
+	var partitionNumbers []uint16     // by partition reference number
+	var fileSet longAD
+	var haveLVD bool
+
+	for i := range int64(length / sectorSize) {
+		buf := make([]byte, sectorSize)
+		if _, err := r.r.ReadAt(buf, (int64(location)+i)*sectorSize); err != nil {
+			return fmt.Errorf("failed to read UDF volume descriptor: %w", err)
+		}
+		tag := binary.LittleEndian.Uint16(buf)
+		if checkTag(buf, tag) != nil {
+			continue
+		}
+		if tag == tagTerminating {
+			break
+		}
+
+		switch tag {
+		case tagPartition:
+			number := binary.LittleEndian.Uint16(buf[pdPartitionNumber:])
+			starts[number] = binary.LittleEndian.Uint32(buf[pdStartLocation:])
+
+		case tagLogicalVolume:
+			if blockSize := binary.LittleEndian.Uint32(buf[lvdBlockSize:]); blockSize != sectorSize {
+				return fmt.Errorf("unsupported UDF logical block size: %d", blockSize)
+			}
+			fileSet = parseLongAD(buf[lvdFileSetLongAD:])
+			numbers, err := parsePartitionMaps(buf)
+			if err != nil {
+				return err
+			}
+			partitionNumbers = numbers
+			haveLVD = true
+		}
+	}
+
+	if !haveLVD {
+		return fmt.Errorf("not a valid UDF image: no logical volume descriptor")
+	}
+	for _, number := range partitionNumbers {
+		start, ok := starts[number]
+		if !ok {
+			return fmt.Errorf("not a valid UDF image: partition %d not found", number)
+		}
+		r.partitions = append(r.partitions, start)
+	}
+
+	fsdOffset, err := r.blockOffset(fileSet.partition, fileSet.block)
+	if err != nil {
+		return err
+	}
+	fsd, err := r.readDescriptor(fsdOffset, tagFileSet)
+	if err != nil {
+		return fmt.Errorf("not a valid UDF image: file set: %w", err)
+	}
+	r.rootICB = parseLongAD(fsd[fsdRootICB:])
+	return nil
+}
+
+// parsePartitionMaps returns the partition number of each of a Logical
+// Volume Descriptor's partition maps.
+func parsePartitionMaps(lvd []byte) ([]uint16, error) {
+	count := binary.LittleEndian.Uint32(lvd[lvdNumMaps:])
+	var numbers []uint16
+	offset := lvdPartitionMaps
+	for range count {
+		if offset+2 > len(lvd) {
+			return nil, fmt.Errorf("not a valid UDF image: partition maps exceed descriptor")
+		}
+		mapType := lvd[offset]
+		mapLength := int(lvd[offset+1])
+		if mapType != 1 {
+			return nil, fmt.Errorf("unsupported UDF partition map type: %d", mapType)
+		}
+		if mapLength != 6 || offset+mapLength > len(lvd) {
+			return nil, fmt.Errorf("not a valid UDF image: partition map length %d", mapLength)
+		}
+		numbers = append(numbers, binary.LittleEndian.Uint16(lvd[offset+4:]))
+		offset += mapLength
+	}
+	return numbers, nil
+}
+
+// OpenFile opens a file by path (case-insensitive) and returns a reader for its contents.
+// Supports subdirectory paths like "PSP_GAME/PARAM.SFO".
+func (r *Reader) OpenFile(path string) (io.ReaderAt, int64, error) {
+	record, err := r.lookup(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	entry, err := r.readFileEntry(record.icb)
+	if err != nil {
+		return nil, 0, err
+	}
+	if entry.isDir {
+		return nil, 0, fmt.Errorf("%q is a directory, not a file", record.name)
+	}
+	return r.open(entry), entry.size, nil
+}
+
+// ReadDir lists a directory by path (case-insensitive), excluding the parent
+// entry. An empty path or "/" lists the root directory.
+func (r *Reader) ReadDir(path string) ([]DirEntry, error) {
+	record, err := r.lookup(path)
+	if err != nil {
+		return nil, err
+	}
+	records, err := r.readDir(record.icb)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]DirEntry, len(records))
+	for i, record := range records {
+		entry, err := r.readFileEntry(record.icb)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %q: %w", record.name, err)
+		}
+		entries[i] = DirEntry{
+			Name:  record.name,
+			Size:  entry.size,
+			IsDir: entry.isDir,
+		}
+	}
+	return entries, nil
+}
+
+// lookup resolves a path to its directory record. An empty path or "/" is
+// the root directory.
+func (r *Reader) lookup(path string) (dirRecord, error) {
+	dir := dirRecord{icb: r.rootICB, isDir: true}
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return dir, nil
+	}
+
+	for _, part := range strings.Split(path, "/") {
+		// Intermediate components must be directories
+		if !dir.isDir {
+			return dirRecord{}, fmt.Errorf("%q is not a directory", dir.name)
+		}
+
+		record, err := r.findEntry(dir.icb, part)
+		if err != nil {
+			return dirRecord{}, fmt.Errorf("path component %q not found: %w", part, err)
+		}
+		dir = record
+	}
+	return dir, nil
+}
+
+// findEntry searches a directory for an entry by name, preferring an exact
+// match over a case-insensitive one.
+func (r *Reader) findEntry(dirICB longAD, name string) (dirRecord, error) {
+	records, err := r.readDir(dirICB)
+	if err != nil {
+		return dirRecord{}, err
+	}
+	for _, record := range records {
+		if record.name == name {
+			return record, nil
+		}
+	}
+	for _, record := range records {
+		if strings.EqualFold(record.name, name) {
+			return record, nil
+		}
+	}
+	return dirRecord{}, fmt.Errorf("entry not found: %s", name)
+}
+
+// readDir reads a directory's File Identifier Descriptors, excluding the
+// parent entry and deleted entries.
+func (r *Reader) readDir(icb longAD) ([]dirRecord, error) {
+	entry, err := r.readFileEntry(icb)
+	if err != nil {
+		return nil, err
+	}
+	if !entry.isDir {
+		return nil, fmt.Errorf("not a directory")
+	}
+	if entry.size > maxDirSize {
+		return nil, fmt.Errorf("directory too large: %d bytes", entry.size)
+	}
+	data := make([]byte, entry.size)
+	if _, err := r.open(entry).ReadAt(data, 0); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to read directory: %w", err)
+	}
+
+	var records []dirRecord
+	offset := 0
+	for offset+fidImplUse <= len(data) {
+		fid := data[offset:]
+		if err := checkTag(fid, tagFileIdentifier); err != nil {
+			return nil, fmt.Errorf("invalid file identifier descriptor at 0x%X: %w", offset, err)
+		}
+		characteristics := fid[fidCharacteristics]
+		nameLen := int(fid[fidLengthFI])
+		nameStart := fidImplUse + int(binary.LittleEndian.Uint16(fid[fidLengthIU:]))
+		if nameStart+nameLen > len(fid) {
+			return nil, fmt.Errorf("invalid file identifier descriptor at 0x%X: name exceeds directory", offset)
+		}
+		// Padded to a multiple of 4 bytes
+		offset += (nameStart + nameLen + 3) &^ 3
+
+		if characteristics&(charParent|charDeleted) != 0 {
+			continue
+		}
+		records = append(records, dirRecord{
+			name:  decodeDString(fid[nameStart : nameStart+nameLen]),
+			icb:   parseLongAD(fid[fidICB:]),
+			isDir: characteristics&charDirectory != 0,
+		})
+	}
+	return records, nil
+}
+
+// readFileEntry reads the File Entry or Extended File Entry at icb.
+func (r *Reader) readFileEntry(icb longAD) (*fileEntry, error) {
+	offset, err := r.blockOffset(icb.partition, icb.block)
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, sectorSize)
+	if _, err := r.r.ReadAt(buf, offset); err != nil {
+		return nil, fmt.Errorf("failed to read UDF file entry: %w", err)
+	}
+
+	var lengthEA, lengthAD, ea int
+	switch binary.LittleEndian.Uint16(buf) {
+	case tagFileEntry:
+		lengthEA, lengthAD, ea = feLengthEA, feLengthAD, feEA
+	case tagExtendedFileEntry:
+		lengthEA, lengthAD, ea = efeLengthEA, efeLengthAD, efeEA
+	default:
+		return nil, fmt.Errorf("not a valid UDF file entry: tag %d", binary.LittleEndian.Uint16(buf))
+	}
+	if err := checkTag(buf, binary.LittleEndian.Uint16(buf)); err != nil {
+		return nil, fmt.Errorf("not a valid UDF file entry: %w", err)
+	}
+
+	adStart := ea + int(binary.LittleEndian.Uint32(buf[lengthEA:]))
+	adEnd := adStart + int(binary.LittleEndian.Uint32(buf[lengthAD:]))
+	if adStart > adEnd || adEnd > len(buf) {
+		return nil, fmt.Errorf("not a valid UDF file entry: allocation descriptors exceed entry")
+	}
+
+	entry := &fileEntry{
+		isDir: buf[feFileType] == fileTypeDir,
+		size:  int64(binary.LittleEndian.Uint64(buf[feInfoLength:])),
+	}
+	if entry.size < 0 || entry.size > r.size {
+		return nil, fmt.Errorf("not a valid UDF file entry: size %d exceeds image", entry.size)
+	}
+
+	ads := buf[adStart:adEnd]
+	switch adType := binary.LittleEndian.Uint16(buf[feICBFlags:]) & 0x07; adType {
+	case adTypeEmbedded:
+		if int64(len(ads)) < entry.size {
+			return nil, fmt.Errorf("not a valid UDF file entry: embedded data shorter than file")
+		}
+		entry.embedded = ads[:entry.size]
+	case adTypeShort, adTypeLong:
+		if entry.extents, err = r.readExtents(ads, adType, icb.partition); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported UDF allocation descriptor type: %d", adType)
+	}
+	return entry, nil
+}
+
+// readExtents converts allocation descriptors to extents, following
+// allocation extent descriptors when the list continues elsewhere.
+// Short allocation descriptors are in the file entry's partition.
+func (r *Reader) readExtents(ads []byte, adType uint16, partition uint16) ([]extent, error) {
+	adSize := 8
+	if adType == adTypeLong {
+		adSize = 16
+	}
+
+	var extents []extent
+	for continuations := 0; ; {
+		var next []byte
+		for len(ads) >= adSize {
+			ad := longAD{
+				length:    binary.LittleEndian.Uint32(ads),
+				block:     binary.LittleEndian.Uint32(ads[4:]),
+				partition: partition,
+			}
+			if adType == adTypeLong {
+				ad.partition = binary.LittleEndian.Uint16(ads[8:])
+			}
+			ads = ads[adSize:]
+
+			length := int64(ad.length & 0x3FFFFFFF)
+			if length == 0 {
+				break
+			}
+			switch ad.length >> 30 {
+			case extentRecorded:
+				offset, err := r.blockOffset(ad.partition, ad.block)
+				if err != nil {
+					return nil, err
+				}
+				extents = append(extents, extent{offset: offset, length: length})
+			case extentNotRecorded, extentUnallocated:
+				extents = append(extents, extent{length: length, sparse: true})
+			case extentContinuation:
+				offset, err := r.blockOffset(ad.partition, ad.block)
+				if err != nil {
+					return nil, err
+				}
+				aed, err := r.readDescriptor(offset, tagAllocationExtent)
+				if err != nil {
+					return nil, fmt.Errorf("invalid UDF allocation extent: %w", err)
+				}
+				// Allocation extent descriptor: length of descriptors at 20, descriptors at 24
+				end := 24 + int(binary.LittleEndian.Uint32(aed[20:]))
+				if end > len(aed) {
+					return nil, fmt.Errorf("invalid UDF allocation extent: descriptors exceed block")
+				}
+				next = aed[24:end]
+			}
+			if next != nil {
+				break
+			}
+		}
+
+		if next == nil {
+			return extents, nil
+		}
+		if continuations++; continuations > maxContinuations {
+			return nil, fmt.Errorf("too many UDF allocation extents")
+		}
+		ads = next
+	}
+}
+
+// open returns a reader for a file entry's data.
+func (r *Reader) open(entry *fileEntry) io.ReaderAt {
+	if entry.embedded != nil {
+		return bytes.NewReader(entry.embedded)
+	}
+	if len(entry.extents) == 1 && !entry.extents[0].sparse {
+		return io.NewSectionReader(r.r, entry.extents[0].offset, entry.size)
+	}
+	return &extentReader{r: r.r, extents: entry.extents, size: entry.size}
+}
+
+// blockOffset returns the image offset of a logical block in a partition.
+func (r *Reader) blockOffset(partition uint16, block uint32) (int64, error) {
+	if int(partition) >= len(r.partitions) {
+		return 0, fmt.Errorf("invalid UDF partition reference: %d", partition)
+	}
+	return (int64(r.partitions[partition]) + int64(block)) * sectorSize, nil
+}
+
+// readDescriptor reads the sector at offset, checking its tag.
+func (r *Reader) readDescriptor(offset int64, id uint16) ([]byte, error) {
+	buf := make([]byte, sectorSize)
+	if _, err := r.r.ReadAt(buf, offset); err != nil {
+		return nil, fmt.Errorf("failed to read descriptor: %w", err)
+	}
+	if err := checkTag(buf, id); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// checkTag checks a descriptor tag's identifier and checksum (the sum of
+// the tag's other 15 bytes).
+func checkTag(buf []byte, id uint16) error {
+	if len(buf) < 16 {
+		return fmt.Errorf("descriptor tag truncated")
+	}
+	var sum byte
+	for i, b := range buf[:16] {
+		if i != 4 {
+			sum += b
+		}
+	}
+	if sum != buf[4] {
+		return fmt.Errorf("descriptor tag checksum mismatch")
+	}
+	if got := binary.LittleEndian.Uint16(buf); got != id {
+		return fmt.Errorf("descriptor tag %d, want %d", got, id)
+	}
+	return nil
+}
+
+// parseLongAD parses a long allocation descriptor.
+func parseLongAD(b []byte) longAD {
+	return longAD{
+		length:    binary.LittleEndian.Uint32(b),
+		block:     binary.LittleEndian.Uint32(b[4:]),
+		partition: binary.LittleEndian.Uint16(b[8:]),
+	}
+}
+
+// decodeDString decodes a file identifier: a compression ID (8 for one byte
+// per character, 16 for big-endian UCS-2), then the characters.
+func decodeDString(b []byte) string {
+	if len(b) == 0 {
+		return ""
+	}
+	switch b[0] {
+	case 16, 255:
+		units := make([]uint16, (len(b)-1)/2)
+		for i := range units {
+			units[i] = binary.BigEndian.Uint16(b[1+2*i:])
+		}
+		return string(utf16.Decode(units))
+	default:
+		runes := make([]rune, len(b)-1)
+		for i, c := range b[1:] {
+			runes[i] = rune(c)
+		}
+		return string(runes)
+	}
+}
+
+// extentReader reads file data spread across several extents.
+type extentReader struct {
+	r       io.ReaderAt
+	extents []extent
+	size    int64
+}
+
+// ReadAt implements io.ReaderAt.
+func (e *extentReader) ReadAt(p []byte, off int64) (int, error) {
+	if off >= e.size {
+		return 0, io.EOF
+	}
+
+	n := 0
+	start := int64(0) // file offset of the current extent
+	for _, ext := range e.extents {
+		if n == len(p) || off+int64(n) >= e.size {
+			break
+		}
+		pos := off + int64(n)
+		if pos >= start+ext.length {
+			start += ext.length
+			continue
+		}
+
+		toRead := min(int64(len(p)-n), start+ext.length-pos, e.size-pos)
+		chunk := p[n : n+int(toRead)]
+		if ext.sparse {
+			clear(chunk)
+		} else if _, err := e.r.ReadAt(chunk, ext.offset+pos-start); err != nil {
+			return n, err
+		}
+		n += int(toRead)
+		start += ext.length
+	}
+
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
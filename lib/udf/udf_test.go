@@ -0,0 +1,285 @@
+package udf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"unicode/utf16"
+)
+
+const (
+	testPartitionStart = 300
+
+	// Logical blocks in the test partition
+	blockFileSet    = 0
+	blockRootFE     = 1
+	blockRootDir    = 2
+	blockCNFFE      = 3
+	blockCNFData    = 4
+	blockEmbeddedFE = 5
+	blockSubFE      = 7
+	blockSubDir     = 8
+	blockSplitFE    = 9
+	blockSplitA     = 10
+	blockSplitB     = 12
+	testBlocks      = 13
+)
+
+// setTag writes a descriptor tag with its checksum.
+func setTag(buf []byte, id uint16) {
+	binary.LittleEndian.PutUint16(buf, id)
+	binary.LittleEndian.PutUint16(buf[2:], 2) // Descriptor version
+	var sum byte
+	for i, b := range buf[:16] {
+		if i != 4 {
+			sum += b
+		}
+	}
+	buf[4] = sum
+}
+
+// putLongAD writes a long allocation descriptor in partition 0.
+func putLongAD(buf []byte, length, block uint32) {
+	binary.LittleEndian.PutUint32(buf, length)
+	binary.LittleEndian.PutUint32(buf[4:], block)
+}
+
+// putFileEntry writes a File Entry with the given allocation descriptors.
+func putFileEntry(buf []byte, fileType byte, adType uint16, size int, ads []byte) {
+	buf[feFileType] = fileType
+	binary.LittleEndian.PutUint16(buf[feICBFlags:], adType)
+	binary.LittleEndian.PutUint64(buf[feInfoLength:], uint64(size))
+	binary.LittleEndian.PutUint32(buf[feLengthAD:], uint32(len(ads)))
+	copy(buf[feEA:], ads)
+	setTag(buf, tagFileEntry)
+}
+
+// shortAD encodes a short allocation descriptor.
+func shortAD(extentType, length, block uint32) []byte {
+	b := make([]byte, 8)
+	binary.LittleEndian.PutUint32(b, extentType<<30|length)
+	binary.LittleEndian.PutUint32(b[4:], block)
+	return b
+}
+
+// fid encodes a File Identifier Descriptor.
+func fid(characteristics byte, name []byte, block uint32) []byte {
+	b := make([]byte, (fidImplUse+len(name)+3)&^3)
+	b[fidCharacteristics] = characteristics
+	b[fidLengthFI] = byte(len(name))
+	putLongAD(b[fidICB:], sectorSize, block)
+	copy(b[fidImplUse:], name)
+	setTag(b, tagFileIdentifier)
+	return b
+}
+
+// dstring8 and dstring16 encode file identifiers.
+func dstring8(name string) []byte { return append([]byte{8}, name...) }
+
+func dstring16(name string) []byte {
+	b := []byte{16}
+	for _, u := range utf16.Encode([]rune(name)) {
+		b = binary.BigEndian.AppendUint16(b, u)
+	}
+	return b
+}
+
+// createUDF creates a UDF image:
+//
+//	SYSTEM.CNF
+//	Long Name.txt (Extended File Entry with embedded data)
+//	PSP_GAME/PARAM.SFO (split across two extents with a sparse gap)
+//	GONE (deleted)
+func createUDF(cnf, embedded []byte) []byte {
+	data := make([]byte, (testPartitionStart+testBlocks)*sectorSize)
+	sector := func(n int) []byte { return data[n*sectorSize : (n+1)*sectorSize] }
+	block := func(n int) []byte { return sector(testPartitionStart + n) }
+
+	// Volume Recognition Sequence
+	for i, id := range []string{"BEA01", "NSR02", "TEA01"} {
+		copy(sector(16 + i)[1:], id)
+	}
+
+	// Anchor, pointing at the Volume Descriptor Sequence at sector 32
+	anchor := sector(anchorSector)
+	binary.LittleEndian.PutUint32(anchor[avdpMainVDSLength:], 4*sectorSize)
+	binary.LittleEndian.PutUint32(anchor[avdpMainVDSLocation:], 32)
+	setTag(anchor, tagAnchor)
+
+	pd := sector(32)
+	binary.LittleEndian.PutUint16(pd[pdPartitionNumber:], 7)
+	binary.LittleEndian.PutUint32(pd[pdStartLocation:], testPartitionStart)
+	setTag(pd, tagPartition)
+
+	lvd := sector(33)
+	binary.LittleEndian.PutUint32(lvd[lvdBlockSize:], sectorSize)
+	putLongAD(lvd[lvdFileSetLongAD:], sectorSize, blockFileSet)
+	binary.LittleEndian.PutUint32(lvd[lvdNumMaps:], 1)
+	copy(lvd[lvdPartitionMaps:], []byte{1, 6, 1, 0, 7, 0}) // Type 1, volume 1, partition 7
+	setTag(lvd, tagLogicalVolume)
+
+	setTag(sector(34), tagTerminating)
+
+	fsd := block(blockFileSet)
+	putLongAD(fsd[fsdRootICB:], sectorSize, blockRootFE)
+	setTag(fsd, tagFileSet)
+
+	// Root directory
+	var root []byte
+	root = append(root, fid(charDirectory|charParent, nil, blockRootFE)...)
+	root = append(root, fid(0, dstring8("SYSTEM.CNF"), blockCNFFE)...)
+	root = append(root, fid(0, dstring16("Long Name.txt"), blockEmbeddedFE)...)
+	root = append(root, fid(charDirectory, dstring8("PSP_GAME"), blockSubFE)...)
+	root = append(root, fid(charDeleted, dstring8("GONE"), blockCNFFE)...)
+	putFileEntry(block(blockRootFE), fileTypeDir, adTypeShort, len(root), shortAD(0, uint32(len(root)), blockRootDir))
+	copy(block(blockRootDir), root)
+
+	putFileEntry(block(blockCNFFE), 5, adTypeShort, len(cnf), shortAD(0, uint32(len(cnf)), blockCNFData))
+	copy(block(blockCNFData), cnf)
+
+	efe := block(blockEmbeddedFE)
+	efe[feFileType] = 5
+	binary.LittleEndian.PutUint16(efe[feICBFlags:], adTypeEmbedded)
+	binary.LittleEndian.PutUint64(efe[feInfoLength:], uint64(len(embedded)))
+	binary.LittleEndian.PutUint32(efe[efeLengthAD:], uint32(len(embedded)))
+	copy(efe[efeEA:], embedded)
+	setTag(efe, tagExtendedFileEntry)
+
+	// PSP_GAME, with long allocation descriptors
+	sub := append(fid(charDirectory|charParent, nil, blockRootFE), fid(0, dstring8("PARAM.SFO"), blockSplitFE)...)
+	longAD := make([]byte, 16)
+	putLongAD(longAD, uint32(len(sub)), blockSubDir)
+	putFileEntry(block(blockSubFE), fileTypeDir, adTypeLong, len(sub), longAD)
+	copy(block(blockSubDir), sub)
+
+	// PARAM.SFO: 2048 bytes of 'A', 100 sparse bytes, 50 bytes of 'B'
+	var ads []byte
+	ads = append(ads, shortAD(extentRecorded, sectorSize, blockSplitA)...)
+	ads = append(ads, shortAD(extentNotRecorded, 100, 0)...)
+	ads = append(ads, shortAD(extentRecorded, 50, blockSplitB)...)
+	putFileEntry(block(blockSplitFE), 5, adTypeShort, sectorSize+150, ads)
+	copy(block(blockSplitA), bytes.Repeat([]byte("A"), sectorSize))
+	copy(block(blockSplitB), bytes.Repeat([]byte("B"), 50))
+
+	return data
+}
+
+func TestNewReader_Invalid(t *testing.T) {
+	if _, err := NewReader(bytes.NewReader(make([]byte, 100)), 100); err == nil {
+		t.Error("NewReader of a small file expected error, got nil")
+	}
+
+	data := make([]byte, (anchorSector+1)*sectorSize)
+	if _, err := NewReader(bytes.NewReader(data), int64(len(data))); err == nil {
+		t.Error("NewReader without NSR expected error, got nil")
+	}
+
+	// NSR but no anchor
+	copy(data[16*sectorSize+1:], "NSR03")
+	if _, err := NewReader(bytes.NewReader(data), int64(len(data))); err == nil {
+		t.Error("NewReader without anchor expected error, got nil")
+	}
+}
+
+func TestReader_OpenFile(t *testing.T) {
+	cnf := []byte("BOOT2 = cdrom0:\\SLUS_123.45;1\n")
+	embedded := []byte("embedded in the file entry")
+	data := createUDF(cnf, embedded)
+
+	reader, err := NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+
+	split := append(bytes.Repeat([]byte("A"), sectorSize), make([]byte, 100)...)
+	split = append(split, bytes.Repeat([]byte("B"), 50)...)
+
+	tests := []struct {
+		path string
+		want []byte
+	}{
+		{"SYSTEM.CNF", cnf},
+		{"system.cnf", cnf},
+		{"Long Name.txt", embedded},
+		{"/PSP_GAME/PARAM.SFO", split},
+	}
+	for _, tt := range tests {
+		fileReader, size, err := reader.OpenFile(tt.path)
+		if err != nil {
+			t.Errorf("OpenFile(%q) failed: %v", tt.path, err)
+			continue
+		}
+		if size != int64(len(tt.want)) {
+			t.Errorf("OpenFile(%q) size = %d, want %d", tt.path, size, len(tt.want))
+			continue
+		}
+		buf := make([]byte, size)
+		if _, err := fileReader.ReadAt(buf, 0); err != nil {
+			t.Fatalf("file ReadAt failed: %v", err)
+		}
+		if !bytes.Equal(buf, tt.want) {
+			t.Errorf("OpenFile(%q) content mismatch", tt.path)
+		}
+	}
+
+	// Reads spanning extents at an offset
+	fileReader, _, err := reader.OpenFile("PSP_GAME/PARAM.SFO")
+	if err != nil {
+		t.Fatalf("OpenFile failed: %v", err)
+	}
+	buf := make([]byte, 200)
+	if n, err := fileReader.ReadAt(buf, sectorSize-10); n != 160 || err == nil {
+		t.Errorf("ReadAt past end = %d, %v, want 160, EOF", n, err)
+	}
+	if !bytes.Equal(buf[:160], split[sectorSize-10:]) {
+		t.Error("ReadAt across extents content mismatch")
+	}
+
+	for _, path := range []string{"GONE", "MISSING", "SYSTEM.CNF/X", "PSP_GAME", ""} {
+		if _, _, err := reader.OpenFile(path); err == nil {
+			t.Errorf("OpenFile(%q) expected error, got nil", path)
+		}
+	}
+}
+
+func TestReader_ReadDir(t *testing.T) {
+	data := createUDF([]byte("cnf"), []byte("embedded"))
+	reader, err := NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+
+	tests := []struct {
+		path string
+		want []DirEntry
+	}{
+		{"", []DirEntry{
+			{Name: "SYSTEM.CNF", Size: 3},
+			{Name: "Long Name.txt", Size: 8},
+			{Name: "PSP_GAME", Size: 40 + 48, IsDir: true}, // Parent and PARAM.SFO identifiers
+		}},
+		{"psp_game", []DirEntry{
+			{Name: "PARAM.SFO", Size: sectorSize + 150},
+		}},
+	}
+	for _, tt := range tests {
+		entries, err := reader.ReadDir(tt.path)
+		if err != nil {
+			t.Errorf("ReadDir(%q) failed: %v", tt.path, err)
+			continue
+		}
+		if len(entries) != len(tt.want) {
+			t.Errorf("ReadDir(%q) = %+v, want %+v", tt.path, entries, tt.want)
+			continue
+		}
+		for i := range entries {
+			if entries[i] != tt.want[i] {
+				t.Errorf("ReadDir(%q)[%d] = %+v, want %+v", tt.path, i, entries[i], tt.want[i])
+			}
+		}
+	}
+
+	if _, err := reader.ReadDir("SYSTEM.CNF"); err == nil {
+		t.Error("ReadDir of a file expected error, got nil")
+	}
+}